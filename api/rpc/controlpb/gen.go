@@ -0,0 +1,5 @@
+// Package controlpb contains the Protobuf definitions of the gRPC control API
+// of the running tests.
+package controlpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative ./control.proto
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.k6.io/k6/api/rpc/controlpb"
+)
+
+type logSubscriber struct {
+	level logrus.Level
+	ch    chan *controlpb.LogEntry
+}
+
+// LogStream is a logrus hook that forwards the log entries to the clients of
+// the log streaming RPC. Like with the samples, the entries are dropped for
+// the clients that are too slow to receive them.
+type LogStream struct {
+	mu          sync.RWMutex
+	subscribers map[*logSubscriber]struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+var _ logrus.Hook = &LogStream{}
+
+// NewLogStream returns a new LogStream.
+func NewLogStream() *LogStream {
+	return &LogStream{
+		subscribers: make(map[*logSubscriber]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Levels returns all the levels, the entries are filtered for every
+// subscriber.
+func (s *LogStream) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire sends the entry to the subscribers interested in its level.
+func (s *LogStream) Fire(entry *logrus.Entry) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.subscribers) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]string, len(entry.Data))
+	for k, v := range entry.Data {
+		fields[k] = fmt.Sprint(v)
+	}
+	logEntry := &controlpb.LogEntry{
+		Time:    timestamppb.New(entry.Time),
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Fields:  fields,
+	}
+	for sub := range s.subscribers {
+		if entry.Level > sub.level {
+			continue
+		}
+		select {
+		case sub.ch <- logEntry:
+		default: // the subscriber is too slow, it misses this entry
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel with the log entries of the given level or more
+// severe, and a function to cancel the subscription.
+func (s *LogStream) Subscribe(level logrus.Level) (<-chan *controlpb.LogEntry, func()) {
+	sub := &logSubscriber{level: level, ch: make(chan *controlpb.LogEntry, subscriberBuffer)}
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub.ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+	}
+}
+
+// Done returns a channel that is closed when the stream is closed.
+func (s *LogStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close closes the stream, ending all the subscriptions. It's safe to call it
+// more than once.
+func (s *LogStream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
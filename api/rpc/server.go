@@ -0,0 +1,255 @@
+// Package rpc implements the gRPC control API of the running tests, with the
+// same operations as the v2 REST API. Its definition is in controlpb.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go.k6.io/k6/api/rpc/controlpb"
+	v2 "go.k6.io/k6/api/v2"
+)
+
+// subscriberBuffer is for how many messages a slow client of the streaming
+// RPCs can lag behind, before the messages start being dropped for it.
+const subscriberBuffer = 256
+
+type controlServer struct {
+	controlpb.UnimplementedControlServer
+
+	cs   *v2.ControlSurface
+	logs *LogStream
+}
+
+// NewServer returns a gRPC server with the control API. The samples and the
+// log entries are streamed from the sample stream of the control surface and
+// from the log stream, if they aren't nil.
+func NewServer(cs *v2.ControlSurface, logs *LogStream, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	controlpb.RegisterControlServer(srv, &controlServer{cs: cs, logs: logs})
+	return srv
+}
+
+// Shutdown ends the streaming RPCs and waits for the others to finish, up to
+// the given timeout, before stopping the server.
+func Shutdown(srv *grpc.Server, cs *v2.ControlSurface, logs *LogStream, timeout time.Duration) {
+	if cs.SampleStream != nil {
+		cs.SampleStream.Close()
+	}
+	if logs != nil {
+		logs.Close()
+	}
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		srv.Stop()
+	}
+}
+
+func (s *controlServer) GetStatus(context.Context, *controlpb.GetStatusRequest) (*controlpb.Status, error) {
+	return newStatus(s.cs.Status()), nil
+}
+
+func (s *controlServer) SetPaused(_ context.Context, req *controlpb.SetPausedRequest) (*controlpb.Status, error) {
+	st, err := s.cs.PatchStatus(v2.StatusPatch{Paused: proto.Bool(req.GetPaused())})
+	if err != nil {
+		return nil, controlError(err)
+	}
+	return newStatus(st), nil
+}
+
+func (s *controlServer) Stop(context.Context, *controlpb.StopRequest) (*controlpb.Status, error) {
+	return newStatus(s.cs.Stop("gRPC API")), nil
+}
+
+func (s *controlServer) ListScenarios(
+	context.Context, *controlpb.ListScenariosRequest,
+) (*controlpb.ListScenariosResponse, error) {
+	return &controlpb.ListScenariosResponse{Scenarios: newScenarios(s.cs.Scenarios())}, nil
+}
+
+func (s *controlServer) PauseScenario(
+	ctx context.Context, req *controlpb.PauseScenarioRequest,
+) (*controlpb.Scenario, error) {
+	scenario, err := s.cs.PatchScenario(ctx, req.GetName(), v2.ScenarioPatch{Paused: proto.Bool(req.GetPaused())})
+	if err != nil {
+		return nil, controlError(err)
+	}
+	return newScenario(scenario), nil
+}
+
+func (s *controlServer) ScaleScenario(
+	ctx context.Context, req *controlpb.ScaleScenarioRequest,
+) (*controlpb.Scenario, error) {
+	scenario, err := s.cs.PatchScenario(ctx, req.GetName(), v2.ScenarioPatch{VUs: req.Vus, MaxVUs: req.MaxVus})
+	if err != nil {
+		return nil, controlError(err)
+	}
+	return newScenario(scenario), nil
+}
+
+func (s *controlServer) StartScenario(
+	_ context.Context, req *controlpb.StartScenarioRequest,
+) (*controlpb.Scenario, error) {
+	scenario, err := s.cs.StartScenario(req.GetName(), json.RawMessage(req.GetConfig()))
+	if err != nil {
+		return nil, controlError(err)
+	}
+	return newScenario(scenario), nil
+}
+
+func (s *controlServer) UpdateScenarioTargets(
+	ctx context.Context, req *controlpb.UpdateScenarioTargetsRequest,
+) (*controlpb.Scenario, error) {
+	if req.GetTargets() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the targets of the scenario are required")
+	}
+	scenario, err := s.cs.PatchScenario(ctx, req.GetName(), v2.ScenarioPatch{
+		Targets: json.RawMessage(req.GetTargets()),
+	})
+	if err != nil {
+		return nil, controlError(err)
+	}
+	return newScenario(scenario), nil
+}
+
+func (s *controlServer) StreamMetrics(
+	req *controlpb.StreamMetricsRequest, stream controlpb.Control_StreamMetricsServer,
+) error {
+	if s.cs.SampleStream == nil {
+		return status.Error(codes.Unavailable, "the sample stream isn't enabled")
+	}
+	samples, unsubscribe := s.cs.SampleStream.Subscribe(req.GetMetrics())
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.cs.SampleStream.Done():
+			return nil
+		case batch := <-samples:
+			msg := &controlpb.MetricSamples{Samples: make([]*controlpb.MetricSample, 0, len(batch))}
+			for _, sample := range batch {
+				msg.Samples = append(msg.Samples, &controlpb.MetricSample{
+					Metric:   sample.Metric,
+					Type:     sample.Type,
+					Time:     timestamppb.New(sample.Time),
+					Value:    sample.Value,
+					Tags:     sample.Tags,
+					Metadata: sample.Metadata,
+				})
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *controlServer) StreamLogs(req *controlpb.StreamLogsRequest, stream controlpb.Control_StreamLogsServer) error {
+	if s.logs == nil {
+		return status.Error(codes.Unavailable, "the log stream isn't enabled")
+	}
+	level := logrus.InfoLevel
+	if req.GetLevel() != "" {
+		var err error
+		if level, err = logrus.ParseLevel(req.GetLevel()); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	entries, unsubscribe := s.logs.Subscribe(level)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.logs.Done():
+			return nil
+		case entry := <-entries:
+			if err := stream.Send(entry); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// controlError returns the gRPC error of an operation of the control surface,
+// with the code of its kind.
+func controlError(err error) error {
+	switch {
+	case errors.Is(err, v2.ErrScenarioNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, v2.ErrUnsupportedOperation):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, v2.ErrInvalidConfig):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func newStatus(st v2.Status) *controlpb.Status {
+	thresholds := make([]*controlpb.Threshold, 0, len(st.Thresholds))
+	for _, t := range st.Thresholds {
+		thresholds = append(thresholds, &controlpb.Threshold{
+			Metric:      t.Metric,
+			Source:      t.Source,
+			AbortOnFail: t.AbortOnFail,
+			Breached:    t.Breached,
+		})
+	}
+	return &controlpb.Status{
+		Status:                st.Status,
+		Paused:                st.Paused,
+		Stopped:               st.Stopped,
+		Running:               st.Running,
+		Tainted:               st.Tainted,
+		Vus:                   st.VUs,
+		VusMax:                st.VUsMax,
+		Duration:              durationpb.New(time.Duration(st.Duration * float64(time.Second))),
+		CompletedIterations:   st.Iterations.Completed,
+		InterruptedIterations: st.Iterations.Interrupted,
+		Thresholds:            thresholds,
+		Scenarios:             newScenarios(st.Scenarios),
+	}
+}
+
+func newScenarios(scenarios []v2.Scenario) []*controlpb.Scenario {
+	result := make([]*controlpb.Scenario, 0, len(scenarios))
+	for _, s := range scenarios {
+		result = append(result, newScenario(s))
+	}
+	return result
+}
+
+func newScenario(s v2.Scenario) *controlpb.Scenario {
+	return &controlpb.Scenario{
+		Name:         s.Name,
+		Executor:     s.Executor,
+		Description:  s.Description,
+		StartTime:    durationpb.New(time.Duration(s.StartTime * float64(time.Second))),
+		GracefulStop: durationpb.New(time.Duration(s.GracefulStop * float64(time.Second))),
+		Paused:       s.Paused,
+		Progress:     s.Progress,
+		Details:      s.Details,
+		Vus:          s.VUs,
+		MaxVus:       s.MaxVUs,
+	}
+}
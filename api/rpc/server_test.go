@@ -0,0 +1,202 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+
+	"go.k6.io/k6/api/rpc/controlpb"
+	v2 "go.k6.io/k6/api/v2"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/local"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/metrics/engine"
+)
+
+func getControlSurface(t *testing.T) *v2.ControlSurface {
+	reg := metrics.NewRegistry()
+	testState := &lib.TestRunState{
+		TestPreInitState: &lib.TestPreInitState{
+			Logger:         testutils.NewLogger(t),
+			Registry:       reg,
+			BuiltinMetrics: metrics.RegisterBuiltinMetrics(reg),
+		},
+		Runner:  &minirunner.MiniRunner{},
+		RunTags: reg.RootTagSet(),
+	}
+	execScheduler, err := execution.NewScheduler(testState, local.NewController())
+	require.NoError(t, err)
+	me, err := engine.NewMetricsEngine(testState.Registry, testState.Logger)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ctx, _ = execution.NewTestRunContext(ctx, testState.Logger)
+	return &v2.ControlSurface{
+		RunCtx:        ctx,
+		MetricsEngine: me,
+		Scheduler:     execScheduler,
+		RunState:      testState,
+		SampleStream:  v2.NewSampleStream(),
+	}
+}
+
+func getClient(t *testing.T, cs *v2.ControlSurface, logs *LogStream) controlpb.ControlClient {
+	listener := bufconn.Listen(1024 * 1024)
+	srv := NewServer(cs, logs)
+	go func() {
+		assert.NoError(t, srv.Serve(listener))
+	}()
+	t.Cleanup(func() {
+		Shutdown(srv, cs, logs, time.Second)
+	})
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, conn.Close())
+	})
+	return controlpb.NewControlClient(conn)
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+	cs := getControlSurface(t)
+	client := getClient(t, cs, nil)
+	ctx := context.Background()
+
+	st, err := client.GetStatus(ctx, &controlpb.GetStatusRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "Created", st.GetStatus())
+	assert.False(t, st.GetPaused())
+	assert.False(t, st.GetStopped())
+
+	st, err = client.SetPaused(ctx, &controlpb.SetPausedRequest{Paused: true})
+	require.NoError(t, err)
+	assert.True(t, st.GetPaused())
+	assert.True(t, cs.Scheduler.GetState().IsPaused())
+
+	st, err = client.Stop(ctx, &controlpb.StopRequest{})
+	require.NoError(t, err)
+	assert.True(t, st.GetStopped())
+	assert.ErrorContains(t, execution.GetCancelReasonIfTestAborted(cs.RunCtx), "test run stopped from gRPC API")
+}
+
+func TestScenarios(t *testing.T) {
+	t.Parallel()
+	client := getClient(t, getControlSurface(t), nil)
+	ctx := context.Background()
+
+	scenarios, err := client.ListScenarios(ctx, &controlpb.ListScenariosRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, scenarios.GetScenarios())
+
+	_, err = client.PauseScenario(ctx, &controlpb.PauseScenarioRequest{Name: "missing", Paused: true})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.ScaleScenario(ctx, &controlpb.ScaleScenarioRequest{Name: "missing", Vus: proto.Int64(1)})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.UpdateScenarioTargets(ctx, &controlpb.UpdateScenarioTargetsRequest{
+		Name: "missing", Targets: `{"rate":100}`,
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.UpdateScenarioTargets(ctx, &controlpb.UpdateScenarioTargetsRequest{Name: "missing"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestStreamMetrics(t *testing.T) {
+	t.Parallel()
+	cs := getControlSurface(t)
+	client := getClient(t, cs, nil)
+
+	stream, err := client.StreamMetrics(context.Background(),
+		&controlpb.StreamMetricsRequest{Metrics: []string{"my_gauge"}})
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	gauge, err := registry.NewMetric("my_gauge", metrics.Gauge)
+	require.NoError(t, err)
+	now := time.Now()
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: gauge, Tags: registry.RootTagSet().With("key", "value")},
+		Time:       now,
+		Value:      3,
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// until the server subscribes
+		for {
+			cs.SampleStream.AddMetricSamples([]metrics.SampleContainer{sample})
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotEmpty(t, msg.GetSamples())
+	got := msg.GetSamples()[0]
+	assert.Equal(t, "my_gauge", got.GetMetric())
+	assert.Equal(t, "gauge", got.GetType())
+	assert.Equal(t, 3.0, got.GetValue())
+	assert.Equal(t, map[string]string{"key": "value"}, got.GetTags())
+	assert.True(t, now.Equal(got.GetTime().AsTime()))
+}
+
+func TestStreamLogs(t *testing.T) {
+	t.Parallel()
+	logs := NewLogStream()
+	client := getClient(t, getControlSurface(t), logs)
+
+	stream, err := client.StreamLogs(context.Background(), &controlpb.StreamLogsRequest{Level: "invalid"})
+	require.NoError(t, err) // the errors of the streams are only returned by Recv
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream, err = client.StreamLogs(context.Background(), &controlpb.StreamLogsRequest{Level: "warning"})
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	logger.AddHook(logs)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// until the server subscribes
+		for {
+			logger.Info("ignored")
+			logger.WithField("key", "value").Warn("streamed")
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	entry, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "warning", entry.GetLevel())
+	assert.Equal(t, "streamed", entry.GetMessage())
+	assert.Equal(t, map[string]string{"key": "value"}, entry.GetFields())
+}
@@ -13,15 +13,17 @@ import (
 	"github.com/sirupsen/logrus"
 
 	v1 "go.k6.io/k6/api/v1"
+	v2 "go.k6.io/k6/api/v2"
 	"go.k6.io/k6/execution"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/metrics/engine"
 )
 
-func newHandler(cs *v1.ControlSurface, profilingEnabled bool) http.Handler {
+func newHandler(cs *v1.ControlSurface, cs2 *v2.ControlSurface, profilingEnabled bool) http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("/v1/", v1.NewHandler(cs))
+	mux.Handle("/v2/", v2.NewHandler(cs2))
 	mux.Handle("/ping", handlePing(cs.RunState.Logger))
 	mux.Handle("/", handlePing(cs.RunState.Logger))
 
@@ -46,7 +48,8 @@ func injectProfilerHandler(mux *http.ServeMux, profilingEnabled bool) {
 	mux.Handle("/debug/vars/", expvar.Handler())
 }
 
-// GetServer returns a http.Server instance that can serve k6's REST API.
+// GetServer returns a http.Server instance that can serve k6's REST API. The
+// samples of the stream, if it isn't nil, are streamed by the v2 API.
 func GetServer(
 	runCtx context.Context,
 	addr string,
@@ -55,6 +58,7 @@ func GetServer(
 	samples chan metrics.SampleContainer,
 	me *engine.MetricsEngine,
 	es *execution.Scheduler,
+	stream *v2.SampleStream,
 ) *http.Server {
 	// TODO: reduce the control surface as much as possible? For example, if
 	// we refactor the Runner API, we won't need to send the Samples channel.
@@ -66,8 +70,22 @@ func GetServer(
 		RunState:      runState,
 	}
 
-	mux := withLoggingHandler(runState.Logger, newHandler(cs, profilingEnabled))
-	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	cs2 := &v2.ControlSurface{
+		RunCtx:        runCtx,
+		MetricsEngine: me,
+		Scheduler:     es,
+		RunState:      runState,
+		SampleStream:  stream,
+	}
+
+	mux := withLoggingHandler(runState.Logger, newHandler(cs, cs2, profilingEnabled))
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	if stream != nil {
+		// the streaming requests never end by themselves, so they need to be
+		// told to, for the shutdown to not wait for them
+		srv.RegisterOnShutdown(stream.Close)
+	}
+	return srv
 }
 
 type wrappedResponseWriter struct {
@@ -80,6 +98,13 @@ func (w *wrappedResponseWriter) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(w.status)
 }
 
+// Flush implements http.Flusher, for the streaming responses.
+func (w *wrappedResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // withLoggingHandler returns the middleware which logs response status for request.
 func withLoggingHandler(l logrus.FieldLogger, next http.Handler) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
@@ -7,6 +7,7 @@ import (
 
 	"gopkg.in/guregu/null.v3"
 
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -80,3 +81,30 @@ func NewMetric(m *metrics.Metric, t time.Duration) Metric {
 		Sample:   m.Sink.Format(t),
 	}
 }
+
+// MetricTimeSeries represents the aggregated time series of a metric, which is
+// recorded if the timeSeriesResolution option is set.
+type MetricTimeSeries struct {
+	Resolution types.Duration `json:"resolution" yaml:"resolution"`
+	Periods    []MetricPeriod `json:"periods" yaml:"periods"`
+}
+
+// MetricPeriod represents the values of a metric aggregated in a period of
+// its time series, like the sample of a Metric.
+type MetricPeriod struct {
+	Time   time.Time          `json:"time" yaml:"time"`
+	Sample map[string]float64 `json:"sample" yaml:"sample"`
+}
+
+// NewMetricTimeSeries constructs a new v1.MetricTimeSeries struct from the
+// aggregated periods of a metric.
+func NewMetricTimeSeries(periods []metrics.AggregatedPeriod, resolution time.Duration) MetricTimeSeries {
+	ts := MetricTimeSeries{
+		Resolution: types.Duration(resolution),
+		Periods:    make([]MetricPeriod, 0, len(periods)),
+	}
+	for _, p := range periods {
+		ts.Periods = append(ts.Periods, MetricPeriod{Time: p.Start, Sample: p.Sink.Format(resolution)})
+	}
+	return ts
+}
@@ -49,6 +49,28 @@ func newMetricData(m *metrics.Metric, t time.Duration) metricData {
 	}
 }
 
+type metricTimeSeriesJSONAPI struct {
+	Data metricTimeSeriesData `json:"data"`
+}
+
+type metricTimeSeriesData struct {
+	Type       string           `json:"type"`
+	ID         string           `json:"id"`
+	Attributes MetricTimeSeries `json:"attributes"`
+}
+
+func newMetricTimeSeriesEnvelope(
+	m *metrics.Metric, periods []metrics.AggregatedPeriod, resolution time.Duration,
+) metricTimeSeriesJSONAPI {
+	return metricTimeSeriesJSONAPI{
+		Data: metricTimeSeriesData{
+			Type:       "metric-time-series",
+			ID:         m.Name,
+			Attributes: NewMetricTimeSeries(periods, resolution),
+		},
+	}
+}
+
 // Metrics extract the []v1.Metric from the JSON API envelop
 func (m MetricsJSONAPI) Metrics() []Metric {
 	list := make([]Metric, 0, len(m.Data))
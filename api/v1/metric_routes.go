@@ -4,16 +4,22 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"go.k6.io/k6/metrics"
 )
 
-func handleGetMetrics(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request) {
+func handleGetMetrics(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
 	var t time.Duration
 	if cs.Scheduler != nil {
 		t = cs.Scheduler.GetState().GetCurrentTestRunDuration()
 	}
 
 	cs.MetricsEngine.MetricsLock.Lock()
-	metrics := newMetricsJSONAPI(cs.MetricsEngine.ObservedMetrics, t)
+	observed := cs.MetricsEngine.ObservedMetrics
+	if scenario := r.URL.Query().Get("scenario"); scenario != "" {
+		observed = scenarioMetrics(observed, scenario)
+	}
+	metrics := newMetricsJSONAPI(observed, t)
 	cs.MetricsEngine.MetricsLock.Unlock()
 
 	data, err := json.Marshal(metrics)
@@ -24,6 +30,22 @@ func handleGetMetrics(cs *ControlSurface, rw http.ResponseWriter, _ *http.Reques
 	_, _ = rw.Write(data)
 }
 
+// scenarioMetrics returns the sub-metrics of the scenario, the ones of the
+// scenario breakdown of the summary or of the thresholds.
+func scenarioMetrics(observed map[string]*metrics.Metric, scenario string) map[string]*metrics.Metric {
+	result := make(map[string]*metrics.Metric)
+	for name, m := range observed {
+		if m.Sub == nil {
+			continue
+		}
+		tags := m.Sub.Tags.Map()
+		if len(tags) == 1 && tags["scenario"] == scenario {
+			result[name] = m
+		}
+	}
+	return result
+}
+
 func handleGetMetric(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request, id string) {
 	var t time.Duration
 	if cs.Scheduler != nil {
@@ -47,3 +69,29 @@ func handleGetMetric(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request
 	}
 	_, _ = rw.Write(data)
 }
+
+func handleGetMetricTimeSeries(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request, id string) {
+	resolution := cs.MetricsEngine.TimeSeriesResolution()
+	if resolution == 0 {
+		apiError(rw, "Not Found", "The time series of the metrics aren't recorded, "+
+			"the timeSeriesResolution option isn't set", http.StatusNotFound)
+		return
+	}
+
+	cs.MetricsEngine.MetricsLock.Lock()
+	metric, ok := cs.MetricsEngine.ObservedMetrics[id]
+	if !ok {
+		cs.MetricsEngine.MetricsLock.Unlock()
+		apiError(rw, "Not Found", "No metric with that ID was found", http.StatusNotFound)
+		return
+	}
+	wrappedTimeSeries := newMetricTimeSeriesEnvelope(metric, cs.MetricsEngine.TimeSeries(metric), resolution)
+	cs.MetricsEngine.MetricsLock.Unlock()
+
+	data, err := json.Marshal(wrappedTimeSeries)
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, _ = rw.Write(data)
+}
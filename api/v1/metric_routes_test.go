@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -12,6 +13,7 @@ import (
 
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -136,3 +138,105 @@ func TestGetMetric(t *testing.T) {
 		})
 	})
 }
+
+func TestGetMetricTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	testState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+	testMetric, err := testState.Registry.NewMetric("my_metric", metrics.Counter)
+	require.NoError(t, err)
+
+	t.Run("not recorded", func(t *testing.T) {
+		t.Parallel()
+
+		cs := getControlSurface(t, testState)
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/metrics/my_metric/timeseries", nil))
+		res := rw.Result()
+		t.Cleanup(func() {
+			assert.NoError(t, res.Body.Close())
+		})
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Contains(t, rw.Body.String(), "the timeSeriesResolution option isn't set")
+	})
+
+	t.Run("recorded", func(t *testing.T) {
+		t.Parallel()
+
+		cs := getControlSurface(t, testState)
+		require.NoError(t, cs.MetricsEngine.InitSubMetricsAndThresholds(lib.Options{
+			TimeSeriesResolution: types.NullDurationFrom(2 * time.Second),
+		}, false))
+		ingester := cs.MetricsEngine.CreateIngester()
+		require.NoError(t, ingester.Start())
+		start := time.Unix(10, 0)
+		for _, offset := range []time.Duration{0, time.Second, 3 * time.Second} {
+			ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: testMetric, Tags: testState.Registry.RootTagSet()},
+				Time:       start.Add(offset),
+				Value:      1,
+			}})
+		}
+		require.NoError(t, ingester.Stop())
+
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/metrics/my_metric/timeseries", nil))
+		res := rw.Result()
+		t.Cleanup(func() {
+			assert.NoError(t, res.Body.Close())
+		})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var envelop metricTimeSeriesJSONAPI
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &envelop))
+		assert.Equal(t, "metric-time-series", envelop.Data.Type)
+		assert.Equal(t, "my_metric", envelop.Data.ID)
+
+		timeSeries := envelop.Data.Attributes
+		assert.Equal(t, types.Duration(2*time.Second), timeSeries.Resolution)
+		require.Len(t, timeSeries.Periods, 2)
+		assert.True(t, start.Equal(timeSeries.Periods[0].Time))
+		assert.Equal(t, map[string]float64{"count": 2, "rate": 1}, timeSeries.Periods[0].Sample)
+		assert.Equal(t, map[string]float64{"count": 1, "rate": 0.5}, timeSeries.Periods[1].Sample)
+
+		rw = httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/metrics/notreal/timeseries", nil))
+		res = rw.Result()
+		t.Cleanup(func() {
+			assert.NoError(t, res.Body.Close())
+		})
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func TestGetMetricsOfScenario(t *testing.T) {
+	t.Parallel()
+
+	testState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+	testMetric, err := testState.Registry.NewMetric("my_metric", metrics.Trend)
+	require.NoError(t, err)
+	first, err := testMetric.AddSubmetric("scenario:first")
+	require.NoError(t, err)
+	second, err := testMetric.AddSubmetric("scenario:second")
+	require.NoError(t, err)
+	cs := getControlSurface(t, testState)
+
+	cs.MetricsEngine.ObservedMetrics = map[string]*metrics.Metric{
+		"my_metric":                  testMetric,
+		"my_metric{scenario:first}":  first.Metric,
+		"my_metric{scenario:second}": second.Metric,
+	}
+
+	rw := httptest.NewRecorder()
+	NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/metrics?scenario=first", nil))
+	res := rw.Result()
+	t.Cleanup(func() {
+		assert.NoError(t, res.Body.Close())
+	})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var envelop MetricsJSONAPI
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &envelop))
+	require.Len(t, envelop.Data, 1)
+	assert.Equal(t, "my_metric{scenario:first}", envelop.Data[0].ID)
+}
@@ -3,6 +3,7 @@ package v1
 
 import (
 	"net/http"
+	"strings"
 )
 
 // NewHandler returns the top handler for the v1 REST APIs
@@ -35,6 +36,10 @@ func NewHandler(cs *ControlSurface) http.Handler {
 		}
 
 		id := r.URL.Path[len("/v1/metrics/"):]
+		if id, ok := strings.CutSuffix(id, "/timeseries"); ok {
+			handleGetMetricTimeSeries(cs, rw, r, id)
+			return
+		}
 		handleGetMetric(cs, rw, r, id)
 	})
 
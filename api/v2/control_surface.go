@@ -0,0 +1,187 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/metrics/engine"
+)
+
+var (
+	// ErrScenarioNotFound is returned for the operations on unknown scenarios.
+	ErrScenarioNotFound = errors.New("scenario not found")
+	// ErrUnsupportedOperation is returned for the operations that aren't
+	// supported by the executor of the scenario, or in the current state of
+	// the test run.
+	ErrUnsupportedOperation = errors.New("unsupported operation")
+	// ErrInvalidConfig is returned when the new configuration of a scenario
+	// isn't valid.
+	ErrInvalidConfig = errors.New("invalid config")
+)
+
+// ControlSurface includes the methods the REST API can use to control and
+// communicate with the rest of k6. Its operations are shared with the other
+// control APIs, like the gRPC one.
+type ControlSurface struct {
+	RunCtx        context.Context
+	MetricsEngine *engine.MetricsEngine
+	Scheduler     *execution.Scheduler
+	RunState      *lib.TestRunState
+	SampleStream  *SampleStream
+}
+
+// Status returns the current status of the test run.
+func (cs *ControlSurface) Status() Status {
+	return newStatus(cs)
+}
+
+// Stop stops the test run, from the given API.
+func (cs *ControlSurface) Stop(api string) Status {
+	execution.AbortTestRun( //nolint:contextcheck // false-positive cs.RunCtx a right way of passing context there
+		cs.RunCtx,
+		errext.WithAbortReasonIfNone(
+			errext.WithExitCodeIfNone(fmt.Errorf("test run stopped from %s", api), exitcodes.ScriptStoppedFromRESTAPI),
+			errext.AbortedByUser,
+		),
+	)
+	return newStatus(cs)
+}
+
+// PatchStatus stops, or pauses or resumes, the test run.
+func (cs *ControlSurface) PatchStatus(patch StatusPatch) (Status, error) {
+	if patch.Stopped {
+		return cs.Stop("REST API"), nil
+	}
+	if patch.Paused != nil {
+		if err := cs.Scheduler.SetPaused(*patch.Paused); err != nil {
+			return Status{}, fmt.Errorf("%w: %s", ErrUnsupportedOperation, err.Error())
+		}
+	}
+	return newStatus(cs), nil
+}
+
+// Scenarios returns the current state of all the scenarios.
+func (cs *ControlSurface) Scenarios() []Scenario {
+	return newScenarios(cs)
+}
+
+// Scenario returns the current state of the scenario with the given name.
+func (cs *ControlSurface) Scenario(name string) (Scenario, error) {
+	ex, err := cs.findExecutor(name)
+	if err != nil {
+		return Scenario{}, err
+	}
+	return newScenario(cs.Scheduler, ex), nil
+}
+
+// PatchScenario stops, or pauses or resumes, the scenario with the given name,
+// or changes its VUs or targets. The VUs can only be changed for the scenarios
+// with the externally-controlled executor.
+func (cs *ControlSurface) PatchScenario(ctx context.Context, name string, patch ScenarioPatch) (Scenario, error) {
+	ex, err := cs.findExecutor(name)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	if patch.Stopped {
+		return cs.StopScenario(name)
+	}
+
+	if patch.VUs != nil || patch.MaxVUs != nil {
+		mex, ok := ex.(*executor.ExternallyControlled)
+		if !ok {
+			return Scenario{}, fmt.Errorf("%w: the executor '%s' of scenario '%s' doesn't support live VU updates",
+				ErrUnsupportedOperation, ex.GetConfig().GetType(), name)
+		}
+		newConfig := mex.GetCurrentConfig().ExternallyControlledConfigParams
+		if patch.MaxVUs != nil {
+			newConfig.MaxVUs.Int64, newConfig.MaxVUs.Valid = *patch.MaxVUs, true
+		}
+		if patch.VUs != nil {
+			newConfig.VUs.Int64, newConfig.VUs.Valid = *patch.VUs, true
+		}
+		if err = mex.UpdateConfig(ctx, newConfig); err != nil {
+			return Scenario{}, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+		}
+	}
+
+	if len(patch.Targets) > 0 {
+		tex, ok := ex.(lib.TargetsUpdatableExecutor)
+		if !ok {
+			return Scenario{}, fmt.Errorf("%w: the executor '%s' of scenario '%s' doesn't support live target updates",
+				ErrUnsupportedOperation, ex.GetConfig().GetType(), name)
+		}
+		if err = tex.UpdateTargets(ctx, patch.Targets); err != nil {
+			if errors.Is(err, executor.ErrScenarioNotRunning) {
+				return Scenario{}, fmt.Errorf("%w: %s", ErrUnsupportedOperation, err.Error())
+			}
+			return Scenario{}, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+		}
+	}
+
+	if patch.Paused != nil {
+		pex, ok := ex.(lib.ScenarioPausableExecutor)
+		if !ok {
+			return Scenario{}, fmt.Errorf("%w: the executor '%s' of scenario '%s' can't be paused",
+				ErrUnsupportedOperation, ex.GetConfig().GetType(), name)
+		}
+		pex.SetScenarioPaused(*patch.Paused)
+	}
+
+	return newScenario(cs.Scheduler, ex), nil
+}
+
+// StopScenario stops the scenario with the given name, interrupting its
+// iterations, while the rest of the test continues.
+func (cs *ControlSurface) StopScenario(name string) (Scenario, error) {
+	ex, err := cs.findExecutor(name)
+	if err != nil {
+		return Scenario{}, err
+	}
+	if err = cs.Scheduler.StopScenario(name); err != nil {
+		return Scenario{}, fmt.Errorf("%w: %s", ErrUnsupportedOperation, err.Error())
+	}
+	return newScenario(cs.Scheduler, ex), nil
+}
+
+// StartScenario starts a new scenario, with the given name and executor
+// config, in the running test. The config is like the ones in the scenarios
+// option and its exec function has to be already exported by the script.
+func (cs *ControlSurface) StartScenario(name string, config json.RawMessage) (Scenario, error) {
+	executionState := cs.Scheduler.GetState()
+	if !executionState.HasStarted() || executionState.HasEnded() {
+		return Scenario{}, fmt.Errorf("%w: scenarios can only be started while the test is running",
+			ErrUnsupportedOperation)
+	}
+
+	data, err := json.Marshal(map[string]json.RawMessage{name: config})
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+	}
+	scenarios := lib.ScenarioConfigs{}
+	if err = json.Unmarshal(data, &scenarios); err != nil {
+		return Scenario{}, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+	}
+
+	ex, err := cs.Scheduler.StartScenario(scenarios[name])
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%w: %s", ErrInvalidConfig, err.Error())
+	}
+	return newScenario(cs.Scheduler, ex), nil
+}
+
+func (cs *ControlSurface) findExecutor(name string) (lib.Executor, error) {
+	for _, ex := range cs.Scheduler.GetExecutors() {
+		if ex.GetConfig().GetName() == name {
+			return ex, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: scenario '%s' doesn't exist", ErrScenarioNotFound, name)
+}
@@ -0,0 +1,63 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is an api error
+type Error struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Title, e.Detail)
+}
+
+// ErrorResponse is the body of the responses for the failed requests
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+func apiError(rw http.ResponseWriter, title, detail string, status int) {
+	data, err := json.Marshal(ErrorResponse{Error: Error{Title: title, Detail: detail}})
+	if err != nil {
+		panic(err)
+	}
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(data)
+}
+
+// controlError writes the error of an operation of the control surface, with
+// the status code of its kind.
+func controlError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrScenarioNotFound):
+		apiError(rw, "Not Found", err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrUnsupportedOperation):
+		apiError(rw, "Unsupported operation", err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrInvalidConfig):
+		apiError(rw, "Config update error", err.Error(), http.StatusBadRequest)
+	default:
+		apiError(rw, "Internal error", err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(rw http.ResponseWriter, v interface{}) {
+	writeJSONWithStatus(rw, http.StatusOK, v)
+}
+
+func writeJSONWithStatus(rw http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(data)
+}
@@ -0,0 +1,63 @@
+// Package v2 implements the v2 of the k6's REST API
+package v2
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NewHandler returns the top handler for the v2 REST APIs
+func NewHandler(cs *ControlSurface) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v2/status", func(rw http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handleGetStatus(cs, rw, r)
+		case http.MethodPatch:
+			handlePatchStatus(cs, rw, r)
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/v2/scenarios", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetScenarios(cs, rw, r)
+	})
+
+	mux.HandleFunc("/v2/scenarios/", func(rw http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/v2/scenarios/")
+		switch r.Method {
+		case http.MethodGet:
+			handleGetScenario(cs, rw, r, name)
+		case http.MethodPost:
+			handlePostScenario(cs, rw, r, name)
+		case http.MethodPatch:
+			handlePatchScenario(cs, rw, r, name)
+		default:
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/v2/metrics/stream", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetMetricsStream(cs, rw, r)
+	})
+
+	mux.HandleFunc("/v2/metrics/snapshots", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetMetricsSnapshots(cs, rw, r)
+	})
+
+	return mux
+}
@@ -0,0 +1,49 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func handleGetScenarios(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request) {
+	writeJSON(rw, cs.Scenarios())
+}
+
+func handleGetScenario(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request, name string) {
+	scenario, err := cs.Scenario(name)
+	if err != nil {
+		controlError(rw, err)
+		return
+	}
+	writeJSON(rw, scenario)
+}
+
+func handlePostScenario(cs *ControlSurface, rw http.ResponseWriter, r *http.Request, name string) {
+	var config json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scenario, err := cs.StartScenario(name, config)
+	if err != nil {
+		controlError(rw, err)
+		return
+	}
+	writeJSONWithStatus(rw, http.StatusCreated, scenario)
+}
+
+func handlePatchScenario(cs *ControlSurface, rw http.ResponseWriter, r *http.Request, name string) {
+	var patch ScenarioPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scenario, err := cs.PatchScenario(r.Context(), name, patch)
+	if err != nil {
+		controlError(rw, err)
+		return
+	}
+	writeJSON(rw, scenario)
+}
@@ -0,0 +1,209 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+)
+
+// getRunningControlSurface returns the control surface of a running test,
+// with an externally-controlled and a constant-vus scenario.
+func getRunningControlSurface(t *testing.T) *ControlSurface {
+	scenarios := lib.ScenarioConfigs{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"external": {"executor": "externally-controlled", "vus": 0, "maxVUs": 10, "duration": "0"},
+		"constant": {"executor": "constant-vus", "vus": 1, "duration": "1h"}
+	}`), &scenarios))
+
+	runner := &minirunner.MiniRunner{Fn: func(_ context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}}
+	cs := getControlSurface(t, getTestRunState(t, lib.Options{Scenarios: scenarios}, runner))
+
+	globalCtx, globalCancel := context.WithCancel(context.Background())
+	runCtx, runAbort := execution.NewTestRunContext(globalCtx, cs.RunState.Logger)
+	cs.RunCtx = runCtx
+
+	samples := make(chan metrics.SampleContainer, 1000)
+	go func() {
+		for range samples { //nolint:revive // the samples aren't needed
+		}
+	}()
+	stopEmission, err := cs.Scheduler.Init(runCtx, samples)
+	require.NoError(t, err)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	t.Cleanup(func() {
+		runAbort(fmt.Errorf("custom cancel signal"))
+		wg.Wait()
+		globalCancel()
+	})
+	go func() {
+		defer wg.Done()
+		assert.ErrorContains(t, cs.Scheduler.Run(globalCtx, runCtx, samples), "custom cancel signal")
+		stopEmission()
+		close(samples)
+	}()
+	// wait for the executors to start
+	time.Sleep(200 * time.Millisecond)
+	return cs
+}
+
+func requestScenario(t *testing.T, cs *ControlSurface, method, name, body string) (int, []byte) {
+	rw := httptest.NewRecorder()
+	NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(method, "/v2/scenarios/"+name, bytes.NewBufferString(body)))
+	res := rw.Result()
+	t.Cleanup(func() {
+		assert.NoError(t, res.Body.Close())
+	})
+	return res.StatusCode, rw.Body.Bytes()
+}
+
+func TestGetScenarios(t *testing.T) {
+	t.Parallel()
+	cs := getRunningControlSurface(t)
+
+	rw := httptest.NewRecorder()
+	NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v2/scenarios", nil))
+	res := rw.Result()
+	t.Cleanup(func() {
+		assert.NoError(t, res.Body.Close())
+	})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var scenarios []Scenario
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &scenarios))
+	require.Len(t, scenarios, 2)
+	names := map[string]Scenario{}
+	for _, s := range scenarios {
+		names[s.Name] = s
+	}
+	assert.Equal(t, "constant-vus", names["constant"].Executor)
+	assert.Nil(t, names["constant"].VUs)
+	assert.Equal(t, "externally-controlled", names["external"].Executor)
+	require.NotNil(t, names["external"].MaxVUs)
+	assert.Equal(t, int64(10), *names["external"].MaxVUs)
+
+	status, body := requestScenario(t, cs, http.MethodGet, "constant", "")
+	require.Equal(t, http.StatusOK, status)
+	var scenario Scenario
+	require.NoError(t, json.Unmarshal(body, &scenario))
+	assert.Equal(t, "constant", scenario.Name)
+	assert.NotEmpty(t, scenario.Details)
+	assert.Greater(t, scenario.Progress, 0.0)
+
+	status, _ = requestScenario(t, cs, http.MethodGet, "missing", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestPatchScenario(t *testing.T) {
+	t.Parallel()
+	cs := getRunningControlSurface(t)
+
+	status, body := requestScenario(t, cs, http.MethodPatch, "constant", `{"paused": true}`)
+	require.Equal(t, http.StatusOK, status, string(body))
+	var scenario Scenario
+	require.NoError(t, json.Unmarshal(body, &scenario))
+	assert.True(t, scenario.Paused)
+
+	status, body = requestScenario(t, cs, http.MethodPatch, "constant", `{"paused": false}`)
+	require.Equal(t, http.StatusOK, status, string(body))
+	require.NoError(t, json.Unmarshal(body, &scenario))
+	assert.False(t, scenario.Paused)
+
+	status, body = requestScenario(t, cs, http.MethodPatch, "constant", `{"vus": 2}`)
+	require.Equal(t, http.StatusConflict, status)
+	var errResponse ErrorResponse
+	require.NoError(t, json.Unmarshal(body, &errResponse))
+	assert.Contains(t, errResponse.Error.Detail, "doesn't support live VU updates")
+
+	status, body = requestScenario(t, cs, http.MethodPatch, "external", `{"vus": 5}`)
+	require.Equal(t, http.StatusOK, status, string(body))
+	require.NoError(t, json.Unmarshal(body, &scenario))
+	require.NotNil(t, scenario.VUs)
+	assert.Equal(t, int64(5), *scenario.VUs)
+
+	status, _ = requestScenario(t, cs, http.MethodPatch, "external", `{"vus": 11}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = requestScenario(t, cs, http.MethodPatch, "missing", `{"paused": true}`)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestPatchScenarioTargets(t *testing.T) {
+	t.Parallel()
+	cs := getRunningControlSurface(t)
+
+	status, body := requestScenario(t, cs, http.MethodPatch, "constant", `{"targets": {"duration": "2h"}}`)
+	require.Equal(t, http.StatusOK, status, string(body))
+	var scenario Scenario
+	require.NoError(t, json.Unmarshal(body, &scenario))
+	assert.Contains(t, scenario.Description, "for 2h0m0s")
+
+	status, body = requestScenario(t, cs, http.MethodPatch, "constant", `{"targets": {"vus": 2}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	var errResponse ErrorResponse
+	require.NoError(t, json.Unmarshal(body, &errResponse))
+	assert.Contains(t, errResponse.Error.Detail, "the vus of a running constant-vus scenario can't be changed")
+
+	status, body = requestScenario(t, cs, http.MethodPatch, "external", `{"targets": {"duration": "2h"}}`)
+	require.Equal(t, http.StatusConflict, status)
+	require.NoError(t, json.Unmarshal(body, &errResponse))
+	assert.Contains(t, errResponse.Error.Detail, "doesn't support live target updates")
+}
+
+func TestPostScenario(t *testing.T) {
+	t.Parallel()
+	cs := getRunningControlSurface(t)
+
+	status, body := requestScenario(t, cs, http.MethodPost, "injected",
+		`{"executor": "shared-iterations", "vus": 2, "iterations": 4}`)
+	require.Equal(t, http.StatusCreated, status, string(body))
+	var scenario Scenario
+	require.NoError(t, json.Unmarshal(body, &scenario))
+	assert.Equal(t, "injected", scenario.Name)
+	assert.Equal(t, "shared-iterations", scenario.Executor)
+
+	status, body = requestScenario(t, cs, http.MethodGet, "injected", "")
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	status, body = requestScenario(t, cs, http.MethodPost, "constant",
+		`{"executor": "shared-iterations", "vus": 1, "iterations": 1}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	var errResponse ErrorResponse
+	require.NoError(t, json.Unmarshal(body, &errResponse))
+	assert.Contains(t, errResponse.Error.Detail, "scenario 'constant' already exists")
+
+	status, _ = requestScenario(t, cs, http.MethodPost, "unknown", `{"executor": "unknown"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestPatchScenarioStopped(t *testing.T) {
+	t.Parallel()
+	cs := getRunningControlSurface(t)
+
+	status, body := requestScenario(t, cs, http.MethodPatch, "constant", `{"stopped": true}`)
+	require.Equal(t, http.StatusOK, status, string(body))
+	var scenario Scenario
+	require.NoError(t, json.Unmarshal(body, &scenario))
+	assert.Equal(t, "constant", scenario.Name)
+
+	status, _ = requestScenario(t, cs, http.MethodPatch, "missing", `{"stopped": true}`)
+	assert.Equal(t, http.StatusNotFound, status)
+}
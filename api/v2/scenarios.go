@@ -0,0 +1,67 @@
+package v2
+
+import (
+	"encoding/json"
+
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+)
+
+// Scenario represents the current state of a scenario of the test run.
+type Scenario struct {
+	Name         string   `json:"name"`
+	Executor     string   `json:"executor"`
+	Description  string   `json:"description"`
+	StartTime    float64  `json:"startTime"`
+	GracefulStop float64  `json:"gracefulStop"`
+	Paused       bool     `json:"paused"`
+	Progress     float64  `json:"progress"`
+	Details      []string `json:"details"`
+	VUs          *int64   `json:"vus,omitempty"`
+	MaxVUs       *int64   `json:"maxVUs,omitempty"`
+}
+
+// ScenarioPatch are the changes of a scenario. The VUs can only be changed
+// for the scenarios with the externally-controlled executor. The targets are
+// the changes of the executor config, like its rate, stages or duration, for
+// the executors that support them.
+type ScenarioPatch struct {
+	Paused  *bool           `json:"paused"`
+	Stopped bool            `json:"stopped"`
+	VUs     *int64          `json:"vus"`
+	MaxVUs  *int64          `json:"maxVUs"`
+	Targets json.RawMessage `json:"targets"`
+}
+
+func newScenario(scheduler *execution.Scheduler, ex lib.Executor) Scenario {
+	config := ex.GetConfig()
+	s := Scenario{
+		Name:         config.GetName(),
+		Executor:     config.GetType(),
+		Description:  config.GetDescription(scheduler.GetState().ExecutionTuple),
+		StartTime:    scheduler.GetStartTime(config).Seconds(),
+		GracefulStop: config.GetGracefulStop().Seconds(),
+		Details:      []string{},
+	}
+	if pex, ok := ex.(lib.ScenarioPausableExecutor); ok {
+		s.Paused = pex.IsScenarioPaused()
+	}
+	if progress := ex.GetProgress(); progress != nil {
+		s.Progress, s.Details = progress.Progress()
+	}
+	if mex, ok := ex.(*executor.ExternallyControlled); ok {
+		params := mex.GetCurrentConfig().ExternallyControlledConfigParams
+		s.VUs, s.MaxVUs = &params.VUs.Int64, &params.MaxVUs.Int64
+	}
+	return s
+}
+
+func newScenarios(cs *ControlSurface) []Scenario {
+	executors := cs.Scheduler.GetExecutors()
+	scenarios := make([]Scenario, 0, len(executors))
+	for _, ex := range executors {
+		scenarios = append(scenarios, newScenario(cs.Scheduler, ex))
+	}
+	return scenarios
+}
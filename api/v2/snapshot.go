@@ -0,0 +1,340 @@
+package v2
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	// snapshotSeconds is for how many seconds the stats of every second are
+	// kept, for the charts.
+	snapshotSeconds = 120
+	// recentSeconds is how many of the last complete seconds the recent stats
+	// are of.
+	recentSeconds = 10
+	// maxGroups is how many groups of requests, and checks, are tracked; the
+	// rest of them are counted together, so a test with unique URLs can't
+	// grow the memory.
+	maxGroups = 1000
+	// maxTagValues is how many values of every tag are listed, for the
+	// filters of the clients.
+	maxTagValues = 200
+	// maxTags is how many tags are listed.
+	maxTags = 50
+	// OtherGroup is the group of the requests past the first maxGroups.
+	OtherGroup = "(other)"
+)
+
+// SnapshotFilter selects the samples that are aggregated in the snapshots.
+type SnapshotFilter struct {
+	// Scenario is the name of the scenario of the samples, all of them if
+	// it's empty.
+	Scenario string
+	// TagKey and TagValue are a tag of the samples, all of them if either is
+	// empty.
+	TagKey, TagValue string
+	// GroupBy is the tag the requests are grouped by, "name" if it's empty.
+	GroupBy string
+}
+
+// Snapshot has the stats of the aggregated samples, at the time it was taken.
+type Snapshot struct {
+	Time time.Time `json:"time"`
+	// Seconds are the stats of the last complete seconds that had samples,
+	// the oldest first.
+	Seconds []SecondStats `json:"seconds"`
+	// Recent are the stats of the last 10 complete seconds.
+	Recent Stats `json:"recent"`
+	// Total are the stats of all the samples since the aggregation started.
+	Total   Stats        `json:"total"`
+	GroupBy string       `json:"groupBy"`
+	Groups  []GroupStats `json:"groups"`
+	Checks  []CheckStats `json:"checks"`
+	// Tags are the values of the tags of the requests, for the filters.
+	Tags map[string][]string `json:"tags"`
+}
+
+// Stats are the stats of the requests, the checks and the iterations.
+type Stats struct {
+	Requests   float64 `json:"requests"`
+	FailedRate float64 `json:"failedRate"`
+	// Duration is nil if there aren't any requests.
+	Duration   *DurationStats `json:"duration,omitempty"`
+	Checks     int64          `json:"checks"`
+	ChecksRate float64        `json:"checksRate"`
+	Iterations float64        `json:"iterations"`
+}
+
+// DurationStats are the stats of the durations of the requests.
+type DurationStats struct {
+	Avg float64 `json:"avg"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// SecondStats are the stats of the samples of a second.
+type SecondStats struct {
+	Time time.Time `json:"time"`
+	Stats
+}
+
+// GroupStats are the stats of the requests with a value of the tag they are
+// grouped by.
+type GroupStats struct {
+	Value string `json:"value"`
+	Stats
+}
+
+// CheckStats are the results of a check.
+type CheckStats struct {
+	Name   string `json:"name"`
+	Passes int64  `json:"passes"`
+	Fails  int64  `json:"fails"`
+}
+
+// statsSink aggregates the samples of the metrics of the Stats.
+type statsSink struct {
+	requests   float64
+	failed     metrics.RateSink
+	durations  *metrics.TrendSink
+	checks     metrics.RateSink
+	iterations float64
+}
+
+func newStatsSink() *statsSink {
+	return &statsSink{durations: metrics.NewTrendSinkWithPrecision(metrics.DefaultTrendPrecision)}
+}
+
+func (s *statsSink) add(sample metrics.Sample) {
+	switch sample.Metric.Name {
+	case metrics.HTTPReqsName:
+		s.requests += sample.Value
+	case metrics.HTTPReqFailedName:
+		s.failed.Add(sample)
+	case metrics.HTTPReqDurationName:
+		s.durations.Add(sample)
+	case metrics.ChecksName:
+		s.checks.Add(sample)
+	case metrics.IterationsName:
+		s.iterations += sample.Value
+	}
+}
+
+func (s *statsSink) merge(other *statsSink) {
+	s.requests += other.requests
+	s.failed.Trues += other.failed.Trues
+	s.failed.Total += other.failed.Total
+	s.durations.Merge(other.durations)
+	s.checks.Trues += other.checks.Trues
+	s.checks.Total += other.checks.Total
+	s.iterations += other.iterations
+}
+
+func (s *statsSink) stats() Stats {
+	stats := Stats{Requests: s.requests, Checks: s.checks.Total, Iterations: s.iterations}
+	if s.failed.Total > 0 {
+		stats.FailedRate = float64(s.failed.Trues) / float64(s.failed.Total)
+	}
+	if s.checks.Total > 0 {
+		stats.ChecksRate = float64(s.checks.Trues) / float64(s.checks.Total)
+	}
+	if !s.durations.IsEmpty() {
+		stats.Duration = &DurationStats{
+			Avg: s.durations.Avg(),
+			P50: s.durations.P(0.5),
+			P90: s.durations.P(0.9),
+			P95: s.durations.P(0.95),
+			P99: s.durations.P(0.99),
+			Max: s.durations.Max(),
+		}
+	}
+	return stats
+}
+
+// Aggregation aggregates the samples of the stream that match its filter,
+// from when it was started, so its snapshots have the stats of all of them.
+type Aggregation struct {
+	filter SnapshotFilter
+
+	mu      sync.Mutex
+	seconds map[int64]*statsSink
+	total   *statsSink
+	groups  map[string]*statsSink
+	checks  map[string]*CheckStats
+	tags    map[string]map[string]struct{}
+}
+
+func newAggregation(filter SnapshotFilter) *Aggregation {
+	if filter.GroupBy == "" {
+		filter.GroupBy = "name"
+	}
+	return &Aggregation{
+		filter:  filter,
+		seconds: make(map[int64]*statsSink),
+		total:   newStatsSink(),
+		groups:  make(map[string]*statsSink),
+		checks:  make(map[string]*CheckStats),
+		tags:    make(map[string]map[string]struct{}),
+	}
+}
+
+func (a *Aggregation) matches(sample metrics.Sample) bool {
+	if a.filter.Scenario != "" {
+		if scenario, _ := sample.Tags.Get("scenario"); scenario != a.filter.Scenario {
+			return false
+		}
+	}
+	if a.filter.TagKey != "" && a.filter.TagValue != "" {
+		if value, _ := sample.Tags.Get(a.filter.TagKey); value != a.filter.TagValue {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *Aggregation) add(samples []metrics.Sample) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, sample := range samples {
+		if !a.matches(sample) {
+			continue
+		}
+		a.total.add(sample)
+
+		second := sample.Time.Unix()
+		sink, ok := a.seconds[second]
+		if !ok {
+			sink = newStatsSink()
+			a.seconds[second] = sink
+		}
+		sink.add(sample)
+
+		switch sample.Metric.Name {
+		case metrics.HTTPReqsName:
+			a.addTags(sample.Tags)
+			fallthrough
+		case metrics.HTTPReqFailedName, metrics.HTTPReqDurationName:
+			value, _ := sample.Tags.Get(a.filter.GroupBy)
+			a.group(value).add(sample)
+		case metrics.ChecksName:
+			a.addCheck(sample)
+		}
+	}
+}
+
+func (a *Aggregation) group(value string) *statsSink {
+	if _, ok := a.groups[value]; !ok && len(a.groups) >= maxGroups {
+		value = OtherGroup
+	}
+	sink, ok := a.groups[value]
+	if !ok {
+		sink = newStatsSink()
+		a.groups[value] = sink
+	}
+	return sink
+}
+
+func (a *Aggregation) addCheck(sample metrics.Sample) {
+	name, _ := sample.Tags.Get("check")
+	if _, ok := a.checks[name]; !ok && len(a.checks) >= maxGroups {
+		name = OtherGroup
+	}
+	check, ok := a.checks[name]
+	if !ok {
+		check = &CheckStats{Name: name}
+		a.checks[name] = check
+	}
+	if sample.Value != 0 {
+		check.Passes++
+	} else {
+		check.Fails++
+	}
+}
+
+// addTags lists the values of the tags, except the ones that are unique to
+// every request, that are useless for the filters.
+func (a *Aggregation) addTags(tags *metrics.TagSet) {
+	for key, value := range tags.Map() {
+		if key == "url" || key == "scenario" {
+			continue
+		}
+		values, ok := a.tags[key]
+		if !ok {
+			if len(a.tags) >= maxTags {
+				continue
+			}
+			values = make(map[string]struct{})
+			a.tags[key] = values
+		}
+		if len(values) < maxTagValues {
+			values[value] = struct{}{}
+		}
+	}
+}
+
+// Snapshot returns the stats of the samples, at the given time. The seconds
+// before the last 2 minutes are dropped, and the current one isn't included,
+// since it isn't complete yet.
+func (a *Aggregation) Snapshot(now time.Time) Snapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	current := now.Unix()
+	snapshot := Snapshot{
+		Time:    now,
+		Seconds: make([]SecondStats, 0, len(a.seconds)),
+		Total:   a.total.stats(),
+		GroupBy: a.filter.GroupBy,
+		Groups:  make([]GroupStats, 0, len(a.groups)),
+		Checks:  make([]CheckStats, 0, len(a.checks)),
+		Tags:    make(map[string][]string, len(a.tags)),
+	}
+	recent := newStatsSink()
+	for second, sink := range a.seconds {
+		switch {
+		case second <= current-snapshotSeconds:
+			delete(a.seconds, second)
+			continue
+		case second >= current:
+			continue
+		case second >= current-recentSeconds:
+			recent.merge(sink)
+		}
+		snapshot.Seconds = append(snapshot.Seconds, SecondStats{Time: time.Unix(second, 0), Stats: sink.stats()})
+	}
+	sort.Slice(snapshot.Seconds, func(i, j int) bool {
+		return snapshot.Seconds[i].Time.Before(snapshot.Seconds[j].Time)
+	})
+	snapshot.Recent = recent.stats()
+
+	for value, sink := range a.groups {
+		snapshot.Groups = append(snapshot.Groups, GroupStats{Value: value, Stats: sink.stats()})
+	}
+	sort.Slice(snapshot.Groups, func(i, j int) bool {
+		if snapshot.Groups[i].Requests != snapshot.Groups[j].Requests {
+			return snapshot.Groups[i].Requests > snapshot.Groups[j].Requests
+		}
+		return snapshot.Groups[i].Value < snapshot.Groups[j].Value
+	})
+	for _, check := range a.checks {
+		snapshot.Checks = append(snapshot.Checks, *check)
+	}
+	sort.Slice(snapshot.Checks, func(i, j int) bool {
+		return snapshot.Checks[i].Name < snapshot.Checks[j].Name
+	})
+	for key, values := range a.tags {
+		list := make([]string, 0, len(values))
+		for value := range values {
+			list = append(list, value)
+		}
+		sort.Strings(list)
+		snapshot.Tags[key] = list
+	}
+	return snapshot
+}
@@ -0,0 +1,167 @@
+package v2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+)
+
+func getSnapshotSamples(
+	registry *metrics.Registry, now time.Time, scenario, name string, duration float64, failed bool,
+) metrics.Samples {
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	tags := registry.RootTagSet().With("scenario", scenario).With("name", name).With("method", "GET")
+	failedValue := 0.0
+	if failed {
+		failedValue = 1
+	}
+	return metrics.Samples{
+		{TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqs, Tags: tags}, Time: now, Value: 1},
+		{TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqDuration, Tags: tags}, Time: now, Value: duration},
+		{TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqFailed, Tags: tags}, Time: now, Value: failedValue},
+		{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtin.Checks,
+				Tags:   registry.RootTagSet().With("scenario", scenario).With("check", "status is 200"),
+			},
+			Time:  now,
+			Value: 1 - failedValue,
+		},
+	}
+}
+
+func TestAggregation(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	now := time.Unix(1000, 0)
+	stream := NewSampleStream()
+	all, stopAll := stream.Aggregate(SnapshotFilter{})
+	defer stopAll()
+	scenario, stopScenario := stream.Aggregate(SnapshotFilter{Scenario: "second", GroupBy: "method"})
+	defer stopScenario()
+
+	// every sample is aggregated, no matter how many batches there are
+	for i := 0; i < 1000; i++ {
+		stream.AddMetricSamples([]metrics.SampleContainer{
+			getSnapshotSamples(registry, now.Add(-3*time.Second), "first", "/a", 100, false),
+		})
+	}
+	stream.AddMetricSamples([]metrics.SampleContainer{
+		getSnapshotSamples(registry, now.Add(-2*time.Second), "second", "/b", 300, true),
+		getSnapshotSamples(registry, now.Add(-200*time.Second), "first", "/a", 100, false),
+		getSnapshotSamples(registry, now, "first", "/a", 100, false),
+	})
+
+	snapshot := all.Snapshot(now)
+	assert.Equal(t, now, snapshot.Time)
+	// the old second is dropped, and the current one isn't complete yet
+	require.Len(t, snapshot.Seconds, 2)
+	assert.Equal(t, now.Add(-3*time.Second), snapshot.Seconds[0].Time)
+	assert.Equal(t, 1000.0, snapshot.Seconds[0].Requests)
+	assert.Equal(t, now.Add(-2*time.Second), snapshot.Seconds[1].Time)
+	assert.Equal(t, 1.0, snapshot.Seconds[1].FailedRate)
+	assert.Equal(t, 1001.0, snapshot.Recent.Requests)
+	require.NotNil(t, snapshot.Recent.Duration)
+	assert.Equal(t, 300.0, snapshot.Recent.Duration.Max)
+	assert.Equal(t, 1003.0, snapshot.Total.Requests)
+	assert.Equal(t, int64(1003), snapshot.Total.Checks)
+
+	assert.Equal(t, "name", snapshot.GroupBy)
+	require.Len(t, snapshot.Groups, 2)
+	assert.Equal(t, "/a", snapshot.Groups[0].Value)
+	assert.Equal(t, 1002.0, snapshot.Groups[0].Requests)
+	assert.Equal(t, "/b", snapshot.Groups[1].Value)
+	assert.Equal(t, 1.0, snapshot.Groups[1].FailedRate)
+	assert.Equal(t, []CheckStats{{Name: "status is 200", Passes: 1002, Fails: 1}}, snapshot.Checks)
+	assert.Equal(t, map[string][]string{"method": {"GET"}, "name": {"/a", "/b"}}, snapshot.Tags)
+
+	snapshot = scenario.Snapshot(now)
+	require.Len(t, snapshot.Seconds, 1)
+	assert.Equal(t, 1.0, snapshot.Total.Requests)
+	assert.Equal(t, 0.0, snapshot.Total.ChecksRate)
+	require.Len(t, snapshot.Groups, 1)
+	assert.Equal(t, "GET", snapshot.Groups[0].Value)
+	require.NotNil(t, snapshot.Groups[0].Duration)
+	assert.Equal(t, 300.0, snapshot.Groups[0].Duration.P95)
+
+	// the stopped aggregations don't get the new samples
+	stopScenario()
+	stream.AddMetricSamples([]metrics.SampleContainer{
+		getSnapshotSamples(registry, now, "second", "/b", 300, true),
+	})
+	assert.Equal(t, 1.0, scenario.Snapshot(now).Total.Requests)
+}
+
+func TestAggregationGroupsAreBounded(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	now := time.Now()
+	aggregation := newAggregation(SnapshotFilter{})
+	for i := 0; i < maxGroups+10; i++ {
+		aggregation.add(getSnapshotSamples(registry, now, "default", strings.Repeat("x", i), 1, false))
+	}
+	snapshot := aggregation.Snapshot(now)
+	assert.Len(t, snapshot.Groups, maxGroups+1)
+	assert.Len(t, snapshot.Tags["name"], maxTagValues)
+	for _, group := range snapshot.Groups {
+		if group.Value == OtherGroup {
+			assert.Equal(t, 10.0, group.Requests)
+		}
+	}
+}
+
+func TestGetMetricsSnapshots(t *testing.T) {
+	t.Parallel()
+
+	cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+	srv := httptest.NewServer(NewHandler(cs))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		srv.URL+"/v2/metrics/snapshots?scenario=default&group_by=method", nil)
+	require.NoError(t, err)
+	res, err := srv.Client().Do(req) //nolint:bodyclose // it's closed by the cleanup
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, res.Body.Close())
+	})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	registry := metrics.NewRegistry()
+	cs.SampleStream.AddMetricSamples([]metrics.SampleContainer{
+		getSnapshotSamples(registry, time.Now(), "default", "/a", 100, false),
+		getSnapshotSamples(registry, time.Now(), "other", "/a", 100, false),
+	})
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: snapshot\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	data, ok := strings.CutPrefix(line, "data: ")
+	require.True(t, ok)
+	var snapshot Snapshot
+	require.NoError(t, json.Unmarshal([]byte(data), &snapshot))
+	assert.Equal(t, 1.0, snapshot.Total.Requests)
+	assert.Equal(t, "method", snapshot.GroupBy)
+	require.Len(t, snapshot.Groups, 1)
+	assert.Equal(t, "GET", snapshot.Groups[0].Value)
+}
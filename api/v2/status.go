@@ -0,0 +1,90 @@
+package v2
+
+import (
+	"sort"
+	"time"
+)
+
+// Status represents the current status of the test run.
+type Status struct {
+	Status     string            `json:"status"`
+	Paused     bool              `json:"paused"`
+	Stopped    bool              `json:"stopped"`
+	Running    bool              `json:"running"`
+	Tainted    bool              `json:"tainted"`
+	VUs        int64             `json:"vus"`
+	VUsMax     int64             `json:"vusMax"`
+	Duration   float64           `json:"duration"`
+	Iterations IterationsStatus  `json:"iterations"`
+	Thresholds []ThresholdStatus `json:"thresholds"`
+	Scenarios  []Scenario        `json:"scenarios"`
+}
+
+// IterationsStatus are the counts of the iterations of all the scenarios.
+type IterationsStatus struct {
+	Completed   uint64 `json:"completed"`
+	Interrupted uint64 `json:"interrupted"`
+}
+
+// ThresholdStatus is the state of a threshold, after it was last evaluated.
+type ThresholdStatus struct {
+	Metric      string `json:"metric"`
+	Source      string `json:"source"`
+	AbortOnFail bool   `json:"abortOnFail"`
+	Breached    bool   `json:"breached"`
+}
+
+// StatusPatch are the changes of the status of the test run.
+type StatusPatch struct {
+	Paused  *bool `json:"paused"`
+	Stopped bool  `json:"stopped"`
+}
+
+func newStatus(cs *ControlSurface) Status {
+	executionState := cs.Scheduler.GetState()
+	isStopped := false
+	select {
+	case <-cs.RunCtx.Done():
+		isStopped = true
+	default:
+	}
+
+	return Status{
+		Status:   executionState.GetCurrentExecutionStatus().String(),
+		Paused:   executionState.IsPaused(),
+		Stopped:  isStopped,
+		Running:  executionState.HasStarted() && !executionState.HasEnded(),
+		Tainted:  cs.MetricsEngine.GetMetricsWithBreachedThresholdsCount() > 0,
+		VUs:      executionState.GetCurrentlyActiveVUsCount(),
+		VUsMax:   executionState.GetInitializedVUsCount(),
+		Duration: float64(executionState.GetCurrentTestRunDuration()) / float64(time.Second),
+		Iterations: IterationsStatus{
+			Completed:   executionState.GetFullIterationCount(),
+			Interrupted: executionState.GetPartialIterationCount(),
+		},
+		Thresholds: newThresholdsStatus(cs),
+		Scenarios:  newScenarios(cs),
+	}
+}
+
+func newThresholdsStatus(cs *ControlSurface) []ThresholdStatus {
+	thresholds := cs.MetricsEngine.GetThresholds()
+	names := make([]string, 0, len(thresholds))
+	for name := range thresholds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statuses := make([]ThresholdStatus, 0, len(thresholds))
+	for _, name := range names {
+		for _, t := range thresholds[name] {
+			statuses = append(statuses, ThresholdStatus{
+				Metric:      name,
+				Source:      t.Source,
+				AbortOnFail: t.AbortOnFail,
+				Breached:    t.LastFailed,
+			})
+		}
+	}
+	return statuses
+}
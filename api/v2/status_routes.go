@@ -0,0 +1,25 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func handleGetStatus(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request) {
+	writeJSON(rw, cs.Status())
+}
+
+func handlePatchStatus(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	var patch StatusPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, err := cs.PatchStatus(patch)
+	if err != nil {
+		controlError(rw, err)
+		return
+	}
+	writeJSON(rw, status)
+}
@@ -0,0 +1,150 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/local"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/metrics/engine"
+)
+
+func getTestRunState(tb testing.TB, options lib.Options, runner lib.Runner) *lib.TestRunState {
+	require.NoError(tb, runner.SetOptions(runner.GetOptions().Apply(options)))
+	reg := metrics.NewRegistry()
+	piState := &lib.TestPreInitState{
+		Logger:         testutils.NewLogger(tb),
+		RuntimeOptions: lib.RuntimeOptions{},
+		Registry:       reg,
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(reg),
+	}
+	return &lib.TestRunState{
+		TestPreInitState: piState,
+		Options:          options,
+		Runner:           runner,
+		RunTags:          piState.Registry.RootTagSet().WithTagsFromMap(options.RunTags),
+	}
+}
+
+func getControlSurface(tb testing.TB, testState *lib.TestRunState) *ControlSurface {
+	execScheduler, err := execution.NewScheduler(testState, local.NewController())
+	require.NoError(tb, err)
+
+	me, err := engine.NewMetricsEngine(testState.Registry, testState.Logger)
+	require.NoError(tb, err)
+	require.NoError(tb, me.InitSubMetricsAndThresholds(testState.Options, false))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tb.Cleanup(cancel)
+	ctx, _ = execution.NewTestRunContext(ctx, testState.Logger)
+
+	return &ControlSurface{
+		RunCtx:        ctx,
+		MetricsEngine: me,
+		Scheduler:     execScheduler,
+		RunState:      testState,
+		SampleStream:  NewSampleStream(),
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	t.Parallel()
+
+	var options lib.Options
+	require.NoError(t, json.Unmarshal([]byte(`{"thresholds": {
+		"http_req_duration": ["p(95)<100", {"threshold": "avg<50", "abortOnFail": true}]
+	}}`), &options))
+	cs := getControlSurface(t, getTestRunState(t, options, &minirunner.MiniRunner{}))
+
+	rw := httptest.NewRecorder()
+	NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v2/status", nil))
+	res := rw.Result()
+	t.Cleanup(func() {
+		assert.NoError(t, res.Body.Close())
+	})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json; charset=utf-8", res.Header.Get("Content-Type"))
+
+	var status Status
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &status))
+	assert.Equal(t, "Created", status.Status)
+	assert.False(t, status.Paused)
+	assert.False(t, status.Stopped)
+	assert.False(t, status.Running)
+	assert.False(t, status.Tainted)
+	assert.Equal(t, []ThresholdStatus{
+		{Metric: "http_req_duration", Source: "p(95)<100"},
+		{Metric: "http_req_duration", Source: "avg<50", AbortOnFail: true},
+	}, status.Thresholds)
+	// the executors aren't created before the scheduler is initialized
+	assert.Empty(t, status.Scenarios)
+}
+
+func TestPatchStatus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPatch, "/v2/status", bytes.NewBufferString("{")))
+		res := rw.Result()
+		t.Cleanup(func() {
+			assert.NoError(t, res.Body.Close())
+		})
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var errResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &errResponse))
+		assert.Equal(t, "Invalid data", errResponse.Error.Title)
+	})
+
+	t.Run("paused", func(t *testing.T) {
+		t.Parallel()
+		cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPatch, "/v2/status",
+			bytes.NewBufferString(`{"paused": true}`)))
+		res := rw.Result()
+		t.Cleanup(func() {
+			assert.NoError(t, res.Body.Close())
+		})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.True(t, cs.Scheduler.GetState().IsPaused())
+	})
+
+	t.Run("stopped", func(t *testing.T) {
+		t.Parallel()
+		cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPatch, "/v2/status",
+			bytes.NewBufferString(`{"stopped": true}`)))
+		res := rw.Result()
+		t.Cleanup(func() {
+			assert.NoError(t, res.Body.Close())
+		})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var status Status
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &status))
+		assert.True(t, status.Stopped)
+		var aerr errext.HasAbortReason
+		require.ErrorAs(t, execution.GetCancelReasonIfTestAborted(cs.RunCtx), &aerr)
+		assert.Equal(t, errext.AbortedByUser, aerr.AbortReason())
+	})
+}
@@ -0,0 +1,176 @@
+package v2
+
+import (
+	"sync"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// SampleStreamDescription is the description of the SampleStream output, the
+// one that isn't shown in the summary of the outputs at the start of the test.
+const SampleStreamDescription = "REST API v2 sample stream"
+
+// subscriberBuffer is for how many batches of samples a slow subscriber can
+// lag behind, before the batches start being dropped for it.
+const subscriberBuffer = 64
+
+// StreamedSample is a metric sample, as it's sent to the subscribers of the
+// sample stream.
+type StreamedSample struct {
+	Metric   string            `json:"metric"`
+	Type     string            `json:"type"`
+	Time     time.Time         `json:"time"`
+	Value    float64           `json:"value"`
+	Tags     map[string]string `json:"tags"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type subscriber struct {
+	metrics map[string]bool
+	ch      chan []StreamedSample
+}
+
+// SampleStream is an output that forwards the metric samples to the clients
+// of the streaming endpoint of the REST API. The samples are never buffered
+// for slow clients, they would rather miss some of them than grow the memory
+// usage of k6, so the clients that need the stats of all of them, like the
+// TUI, use the aggregations instead, which get every sample.
+type SampleStream struct {
+	mu           sync.RWMutex
+	subscribers  map[*subscriber]struct{}
+	aggregations map[*Aggregation]struct{}
+	done         chan struct{}
+	closeOnce    sync.Once
+}
+
+// NewSampleStream returns a new SampleStream.
+func NewSampleStream() *SampleStream {
+	return &SampleStream{
+		subscribers:  make(map[*subscriber]struct{}),
+		aggregations: make(map[*Aggregation]struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Description returns a human-readable description of the output.
+func (s *SampleStream) Description() string {
+	return SampleStreamDescription
+}
+
+// Start is a noop, the stream is ready when it's created.
+func (s *SampleStream) Start() error {
+	return nil
+}
+
+// Stop closes the stream.
+func (s *SampleStream) Stop() error {
+	s.Close()
+	return nil
+}
+
+// AddMetricSamples adds the samples to all the aggregations, and sends them to
+// all the subscribers that are interested in them.
+func (s *SampleStream) AddMetricSamples(containers []metrics.SampleContainer) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.aggregations) > 0 {
+		var samples []metrics.Sample
+		for _, container := range containers {
+			samples = append(samples, container.GetSamples()...)
+		}
+		for aggregation := range s.aggregations {
+			aggregation.add(samples)
+		}
+	}
+	if len(s.subscribers) == 0 {
+		return
+	}
+
+	var streamed []StreamedSample
+	for _, container := range containers {
+		for _, sample := range container.GetSamples() {
+			streamed = append(streamed, StreamedSample{
+				Metric:   sample.Metric.Name,
+				Type:     sample.Metric.Type.String(),
+				Time:     sample.Time,
+				Value:    sample.Value,
+				Tags:     sample.Tags.Map(),
+				Metadata: sample.Metadata,
+			})
+		}
+	}
+	if len(streamed) == 0 {
+		return
+	}
+
+	for sub := range s.subscribers {
+		batch := streamed
+		if len(sub.metrics) > 0 {
+			batch = make([]StreamedSample, 0, len(streamed))
+			for _, sample := range streamed {
+				if sub.metrics[sample.Metric] {
+					batch = append(batch, sample)
+				}
+			}
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		select {
+		case sub.ch <- batch:
+		default: // the subscriber is too slow, it misses this batch
+		}
+	}
+}
+
+// Subscribe returns a channel with the batches of the samples of the
+// specified metrics, or of all of them if none is specified, and a function
+// to cancel the subscription.
+func (s *SampleStream) Subscribe(metricNames []string) (<-chan []StreamedSample, func()) {
+	sub := &subscriber{
+		metrics: make(map[string]bool, len(metricNames)),
+		ch:      make(chan []StreamedSample, subscriberBuffer),
+	}
+	for _, name := range metricNames {
+		sub.metrics[name] = true
+	}
+
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub.ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers, sub)
+		s.mu.Unlock()
+	}
+}
+
+// Aggregate starts aggregating the samples that match the filter, and returns
+// the aggregation and a function to stop it.
+func (s *SampleStream) Aggregate(filter SnapshotFilter) (*Aggregation, func()) {
+	aggregation := newAggregation(filter)
+	s.mu.Lock()
+	s.aggregations[aggregation] = struct{}{}
+	s.mu.Unlock()
+
+	return aggregation, func() {
+		s.mu.Lock()
+		delete(s.aggregations, aggregation)
+		s.mu.Unlock()
+	}
+}
+
+// Done returns a channel that is closed when the stream is closed.
+func (s *SampleStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close closes the stream, ending all the subscriptions. It's safe to call it
+// more than once.
+func (s *SampleStream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
@@ -0,0 +1,115 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// keepAliveInterval is how often a comment is sent to the clients of the
+	// stream, so the idle connections aren't closed by the proxies.
+	keepAliveInterval = 15 * time.Second
+	// snapshotInterval is how often the snapshots of the aggregated samples
+	// are sent.
+	snapshotInterval = time.Second
+)
+
+func handleGetMetricsStream(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	if cs.SampleStream == nil {
+		apiError(rw, "Not available", "the sample stream isn't enabled", http.StatusServiceUnavailable)
+		return
+	}
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		apiError(rw, "Not available", "streaming isn't supported by the connection", http.StatusInternalServerError)
+		return
+	}
+
+	samples, unsubscribe := cs.SampleStream.Subscribe(r.URL.Query()["metric"])
+	defer unsubscribe()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-cs.SampleStream.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(rw, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case batch := <-samples:
+			data, err := json.Marshal(batch)
+			if err != nil {
+				cs.RunState.Logger.WithError(err).Error("Couldn't encode the streamed samples")
+				continue
+			}
+			if _, err = fmt.Fprintf(rw, "event: samples\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// handleGetMetricsSnapshots sends the snapshots of the stats of the samples
+// that match the filter of the query, every second. They are aggregated from
+// when the request is made, by the server, so the clients don't need all the
+// samples and none of them are missed.
+func handleGetMetricsSnapshots(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	if cs.SampleStream == nil {
+		apiError(rw, "Not available", "the sample stream isn't enabled", http.StatusServiceUnavailable)
+		return
+	}
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		apiError(rw, "Not available", "streaming isn't supported by the connection", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	aggregation, stop := cs.SampleStream.Aggregate(SnapshotFilter{
+		Scenario: query.Get("scenario"),
+		TagKey:   query.Get("tag"),
+		TagValue: query.Get("value"),
+		GroupBy:  query.Get("group_by"),
+	})
+	defer stop()
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-cs.SampleStream.Done():
+			return
+		case now := <-ticker.C:
+			data, err := json.Marshal(aggregation.Snapshot(now))
+			if err != nil {
+				cs.RunState.Logger.WithError(err).Error("Couldn't encode the snapshot of the samples")
+				continue
+			}
+			if _, err = fmt.Fprintf(rw, "event: snapshot\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,119 @@
+package v2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils/minirunner"
+	"go.k6.io/k6/metrics"
+)
+
+func TestSampleStream(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	counter, err := registry.NewMetric("my_counter", metrics.Counter)
+	require.NoError(t, err)
+	gauge, err := registry.NewMetric("my_gauge", metrics.Gauge)
+	require.NoError(t, err)
+	now := time.Now()
+	samples := metrics.Samples{
+		{
+			TimeSeries: metrics.TimeSeries{Metric: counter, Tags: registry.RootTagSet().With("key", "value")},
+			Time:       now,
+			Value:      1,
+		},
+		{
+			TimeSeries: metrics.TimeSeries{Metric: gauge, Tags: registry.RootTagSet()},
+			Time:       now,
+			Value:      2,
+			Metadata:   map[string]string{"id": "1"},
+		},
+	}
+
+	stream := NewSampleStream()
+	all, unsubscribeAll := stream.Subscribe(nil)
+	defer unsubscribeAll()
+	gauges, unsubscribeGauges := stream.Subscribe([]string{"my_gauge"})
+
+	stream.AddMetricSamples([]metrics.SampleContainer{samples})
+	assert.Equal(t, []StreamedSample{
+		{Metric: "my_counter", Type: "counter", Time: now, Value: 1, Tags: map[string]string{"key": "value"}},
+		{Metric: "my_gauge", Type: "gauge", Time: now, Value: 2, Tags: map[string]string{},
+			Metadata: map[string]string{"id": "1"}},
+	}, <-all)
+	assert.Equal(t, []StreamedSample{
+		{Metric: "my_gauge", Type: "gauge", Time: now, Value: 2, Tags: map[string]string{},
+			Metadata: map[string]string{"id": "1"}},
+	}, <-gauges)
+
+	unsubscribeGauges()
+	stream.AddMetricSamples([]metrics.SampleContainer{samples[0]})
+	assert.Len(t, <-all, 1)
+	assert.Empty(t, gauges)
+
+	require.NoError(t, stream.Stop())
+	require.NoError(t, stream.Stop())
+	<-stream.Done()
+}
+
+func TestGetMetricsStream(t *testing.T) {
+	t.Parallel()
+
+	cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+	srv := httptest.NewServer(NewHandler(cs))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v2/metrics/stream?metric=my_counter", nil)
+	require.NoError(t, err)
+	res, err := srv.Client().Do(req) //nolint:bodyclose // it's closed by the cleanup
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, res.Body.Close())
+	})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	registry := metrics.NewRegistry()
+	counter, err := registry.NewMetric("my_counter", metrics.Counter)
+	require.NoError(t, err)
+	gauge, err := registry.NewMetric("my_gauge", metrics.Gauge)
+	require.NoError(t, err)
+	cs.SampleStream.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		{TimeSeries: metrics.TimeSeries{Metric: gauge, Tags: registry.RootTagSet()}, Time: time.Now(), Value: 1},
+		{TimeSeries: metrics.TimeSeries{Metric: counter, Tags: registry.RootTagSet()}, Time: time.Now(), Value: 2},
+	}})
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: samples\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	data, ok := strings.CutPrefix(line, "data: ")
+	require.True(t, ok)
+	var streamed []StreamedSample
+	require.NoError(t, json.Unmarshal([]byte(data), &streamed))
+	require.Len(t, streamed, 1)
+	assert.Equal(t, "my_counter", streamed[0].Metric)
+	assert.Equal(t, 2.0, streamed[0].Value)
+
+	// the stream ends when it's closed
+	cs.SampleStream.Close()
+	_, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	_, err = reader.ReadString('\n')
+	assert.Error(t, err)
+}
@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/distributed"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/loader"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// cmdAgent handles the `k6 agent` sub-command
+type cmdAgent struct {
+	gs *state.GlobalState
+}
+
+// distributedTokenEnv is the environment variable with the token of the
+// agents, used if it isn't set with the flags, so it isn't visible in the
+// list of the processes.
+const distributedTokenEnv = "K6_DISTRIBUTED_TOKEN"
+
+// coordinatorFlagSet returns the flags of the connection to a coordinator,
+// with the given prefix.
+func coordinatorFlagSet(prefix string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.Bool(prefix+"tls", false, "connect to the coordinator with TLS")
+	flags.String(prefix+"tls-ca", "", "`path` to the CA certificate that signed the certificate of the coordinator, "+
+		"implies --"+prefix+"tls")
+	flags.String(prefix+"token", "", "`token` that the coordinator requires from its agents, "+
+		"the "+distributedTokenEnv+" environment variable is used if it isn't set")
+	return flags
+}
+
+// getCoordinatorDialOptions returns the options of the connection to a
+// coordinator, from the flags with the given prefix.
+func getCoordinatorDialOptions(gs *state.GlobalState, flags *pflag.FlagSet, prefix string) ([]grpc.DialOption, error) {
+	useTLS, err := flags.GetBool(prefix + "tls")
+	if err != nil {
+		return nil, err
+	}
+	caPath, err := flags.GetString(prefix + "tls-ca")
+	if err != nil {
+		return nil, err
+	}
+	token, err := flags.GetString(prefix + "token")
+	if err != nil {
+		return nil, err
+	}
+	if !flags.Changed(prefix + "token") {
+		token = gs.Env[distributedTokenEnv]
+	}
+
+	var tlsConfig *tls.Config
+	if useTLS || caPath != "" {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if caPath != "" {
+			ca, err := fsext.ReadFile(gs.FS, caPath)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read the CA certificate of the coordinator: %w", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, errors.New("the CA certificate of the coordinator isn't a valid PEM certificate")
+			}
+		}
+	}
+	return distributed.DialOptions(tlsConfig, token), nil
+}
+
+// registerWithCoordinator connects to the coordinator at the given address,
+// waiting for it to be available, and registers a new instance.
+func registerWithCoordinator(
+	gs *state.GlobalState, address string, opts []grpc.DialOption,
+) (*grpc.ClientConn, distributed.DistributedTestClient, *distributed.RegisterResponse, error) {
+	conn, err := grpc.DialContext(gs.Ctx, address, opts...)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to connect to the coordinator: %w", err)
+	}
+	client := distributed.NewDistributedTestClient(conn)
+
+	gs.Logger.Debugf("Registering with the coordinator at %s...", address)
+	resp, err := client.Register(gs.Ctx, &distributed.RegisterRequest{}, grpc.WaitForReady(true))
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, nil, fmt.Errorf("unable to register with the coordinator: %w", err)
+	}
+	return conn, client, resp, nil
+}
+
+func (c *cmdAgent) run(cmd *cobra.Command, args []string) error {
+	dialOpts, err := getCoordinatorDialOptions(c.gs, cmd.Flags(), "")
+	if err != nil {
+		return err
+	}
+	conn, client, resp, err := registerWithCoordinator(c.gs, args[0], dialOpts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	logger := c.gs.Logger.WithField("instance", resp.InstanceID)
+	if resp.Archive == nil {
+		return fmt.Errorf("the coordinator at %s doesn't distribute the test, use 'k6 run --leader' instead", args[0])
+	}
+
+	var options lib.Options
+	if err = json.Unmarshal(resp.Options, &options); err != nil {
+		return fmt.Errorf("unable to parse the options from the coordinator: %w", err)
+	}
+	test, err := c.loadTest(cmd, resp.Archive, options)
+	if err != nil {
+		return err
+	}
+
+	controller, err := distributed.NewAgentController(c.gs.Ctx, resp.InstanceID, client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := controller.Close(); cerr != nil {
+			logger.WithError(cerr).Debug("The connection with the coordinator wasn't closed cleanly")
+		}
+	}()
+
+	takeOvers := &sync.WaitGroup{}
+	takeOversCtx, cancelTakeOvers := context.WithCancel(c.gs.Ctx)
+	defer func() {
+		cancelTakeOvers()
+		takeOvers.Wait()
+	}()
+	takeOvers.Add(1)
+	go func() {
+		defer takeOvers.Done()
+		for {
+			select {
+			case msg := <-controller.TakeOvers():
+				takeOvers.Add(1)
+				go func() {
+					defer takeOvers.Done()
+					lostLogger := logger.WithField("lostInstance", msg.InstanceID)
+					if terr := c.runTakeOver(takeOversCtx, cmd, resp, msg, client, controller, lostLogger); terr != nil {
+						lostLogger.WithError(terr).Error("Unable to take over the execution segment of the lost instance")
+					}
+				}()
+			case <-takeOversCtx.Done():
+				return
+			}
+		}
+	}()
+
+	runCmd := &cmdRun{
+		gs: c.gs,
+		loadConfiguredTest: func(*cobra.Command, []string) (*loadedAndConfiguredTest, execution.Controller, error) {
+			return test, controller, nil
+		},
+		additionalOutputs: []output.Output{distributed.NewMetricsOutput(resp.InstanceID, client, logger)},
+		externalAborts:    []<-chan error{controller.Aborted()},
+		instanceID:        null.IntFrom(int64(resp.InstanceID)),
+	}
+	return runCmd.run(cmd, args)
+}
+
+// runTakeOver runs the part of the test of a lost instance, alongside the
+// test of this instance, until the context is done. Since it starts in the
+// middle of the test, the execution plan of its segment starts from the
+// beginning, so the load of tests with stages is only approximately restored.
+func (c *cmdAgent) runTakeOver(
+	ctx context.Context, cmd *cobra.Command, resp *distributed.RegisterResponse, msg *distributed.TakeOverMessage,
+	client distributed.DistributedTestClient, controller *distributed.AgentController, logger logrus.FieldLogger,
+) error {
+	var options lib.Options
+	if err := json.Unmarshal(msg.Options, &options); err != nil {
+		return fmt.Errorf("unable to parse the options from the coordinator: %w", err)
+	}
+	logger.Warnf("Taking over the execution segment %s of the lost instance", options.ExecutionSegment)
+
+	test, err := c.loadTest(cmd, resp.Archive, options)
+	if err != nil {
+		return err
+	}
+	test.preInitState.Logger = logger
+	testRunState, err := test.buildTestRunState(test.derivedConfig.Options)
+	if err != nil {
+		return err
+	}
+	scheduler, err := execution.NewScheduler(testRunState, controller.TakeOverController())
+	if err != nil {
+		return err
+	}
+
+	outputManager := output.NewManager(
+		[]output.Output{distributed.NewMetricsOutput(resp.InstanceID, client, logger)}, logger, func(err error) {
+			if err != nil {
+				logger.WithError(err).Error("Received error to stop from output")
+			}
+		})
+	samples := make(chan metrics.SampleContainer, test.derivedConfig.MetricSamplesBufferSize.Int64)
+	vuSamples, vuSamplesOut := makeVUSamples(test.derivedConfig.MetricSamplesBufferSize.Int64)
+	scheduler.SetVUSamples(vuSamplesOut)
+	waitOutputsFlushed, stopOutputs, err := outputManager.Start(append(vuSamples, samples)...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		close(samples)
+		for _, ch := range vuSamples {
+			close(ch)
+		}
+		waitOutputsFlushed()
+		stopOutputs(err)
+	}()
+
+	runCtx, runAbort := execution.NewTestRunContext(ctx, logger)
+	go func() {
+		select {
+		case abortErr := <-controller.Aborted():
+			runAbort(abortErr)
+		case <-runCtx.Done():
+		}
+	}()
+	stopVUEmission, err := scheduler.Init(runCtx, samples)
+	if err != nil {
+		return err
+	}
+	defer stopVUEmission()
+
+	if err = scheduler.Run(ctx, runCtx, samples); ctx.Err() != nil {
+		// the test of this instance has finished
+		err = nil
+	}
+	return err
+}
+
+// loadTest loads the archive received from the coordinator. The thresholds and
+// the end-of-test summary are disabled, since they are handled by the
+// coordinator.
+func (c *cmdAgent) loadTest(cmd *cobra.Command, archive []byte, options lib.Options) (*loadedAndConfiguredTest, error) {
+	pwd, err := c.gs.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	runtimeOptions := lib.RuntimeOptions{
+		TestType:     null.StringFrom(testTypeArchive),
+		NoThresholds: null.BoolFrom(true),
+		NoSummary:    null.BoolFrom(true),
+		TracesOutput: null.StringFrom("none"),
+	}
+	test := &loadedTest{
+		pwd:            pwd,
+		sourceRootPath: "coordinator",
+		source:         &loader.SourceData{Data: archive, URL: &url.URL{Scheme: "file", Path: "/coordinator.tar"}},
+		fs:             c.gs.FS,
+		fileSystems:    loader.CreateFilesystems(c.gs.FS, c.gs.Env),
+		preInitState:   newPreInitState(c.gs, runtimeOptions),
+	}
+	if err = test.initializeFirstRunner(c.gs); err != nil {
+		return nil, fmt.Errorf("could not initialize the test from the coordinator: %w", err)
+	}
+
+	out, err := cmd.Flags().GetStringArray("out")
+	if err != nil {
+		return nil, err
+	}
+	// the defaults aren't always marked as set, so they aren't sent by the coordinator
+	conf := applyDefault(Config{
+		Options:       options,
+		Out:           out,
+		NoUsageReport: getNullBool(cmd.Flags(), "no-usage-report"),
+	})
+	derivedConf, err := deriveAndValidateConfig(conf, test.initRunner.IsExecutable, c.gs.Logger)
+	if err != nil {
+		return nil, err
+	}
+	return &loadedAndConfiguredTest{
+		loadedTest:         test,
+		consolidatedConfig: conf,
+		derivedConfig:      derivedConf,
+	}, nil
+}
+
+func (c *cmdAgent) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringArrayP("out", "o", []string{}, "`uri` for an external metrics database")
+	flags.Bool("no-usage-report", false, "don't send anonymous stats to the developers")
+	flags.AddFlagSet(coordinatorFlagSet(""))
+	return flags
+}
+
+func getCmdAgent(gs *state.GlobalState) *cobra.Command {
+	c := &cmdAgent{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Join the distributed test of the coordinator at the given address.
+  {{.}} agent coordinator.example.com:6566
+
+  # Join over TLS, with the token required by the coordinator.
+  K6_DISTRIBUTED_TOKEN=secret {{.}} agent --tls-ca ca.pem coordinator.example.com:6566`[1:])
+
+	agentCmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Join a distributed test",
+		Long: `Join a distributed test.
+
+The agent connects to the coordinator started with the "coordinator" command,
+receives the test and its execution segment from it and runs it, sending its
+metrics to the coordinator. If another agent is lost during the test and the
+coordinator was started with --rebalance, the agent also runs part of the
+execution segment of the lost agent.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should be the address of the coordinator"),
+		RunE:    c.run,
+	}
+
+	agentCmd.Flags().SortFlags = false
+	agentCmd.Flags().AddFlagSet(c.flagSet())
+
+	return agentCmd
+}
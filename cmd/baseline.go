@@ -0,0 +1,409 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+)
+
+// significanceLevel is the p-value under which a change from the baseline
+// isn't considered random, so it can be a regression.
+const significanceLevel = 0.05
+
+// summaryMetric is an aggregated metric of an end-of-test summary.
+type summaryMetric struct {
+	Type     metrics.MetricType
+	Contains metrics.ValueType
+	Values   map[string]float64
+}
+
+// baselineChange is the change of an aggregated value of a metric from the
+// baseline, the p-value is NaN if there isn't a significance test for it.
+type baselineChange struct {
+	metric, stat      string
+	baseline, current float64
+	pValue            float64
+	metricType        metrics.MetricType
+	contains          metrics.ValueType
+	tolerance         *lib.BaselineTolerance
+	regression        bool
+}
+
+// readSummaryMetrics reads the metrics of a JSON summary, either the one of
+// --summary-export or the data of handleSummary().
+func readSummaryMetrics(fs fsext.Fs, path string) (map[string]summaryMetric, error) {
+	data, err := fsext.ReadFile(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := parseSummaryMetrics(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid summary '%s': %w", path, err)
+	}
+	return result, nil
+}
+
+func parseSummaryMetrics(data []byte) (map[string]summaryMetric, error) {
+	var summary struct {
+		Metrics map[string]json.RawMessage `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+	if summary.Metrics == nil {
+		return nil, fmt.Errorf("there aren't any metrics")
+	}
+
+	result := make(map[string]summaryMetric, len(summary.Metrics))
+	for name, raw := range summary.Metrics {
+		// the data of handleSummary() has the type and the values of the metrics
+		var metric struct {
+			Type     metrics.MetricType `json:"type"`
+			Contains metrics.ValueType  `json:"contains"`
+			Values   map[string]float64 `json:"values"`
+		}
+		if err := json.Unmarshal(raw, &metric); err == nil && metric.Values != nil {
+			result[name] = summaryMetric{Type: metric.Type, Contains: metric.Contains, Values: metric.Values}
+			continue
+		}
+
+		// the summary of --summary-export has only the values, without the
+		// rates renamed to value and with the thresholds
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return nil, fmt.Errorf("invalid metric '%s': %w", name, err)
+		}
+		m := summaryMetric{Values: make(map[string]float64, len(values))}
+		for stat, rawValue := range values {
+			if stat == "thresholds" {
+				continue
+			}
+			var value float64
+			if err := json.Unmarshal(rawValue, &value); err != nil {
+				return nil, fmt.Errorf("invalid value '%s' of the metric '%s': %w", stat, name, err)
+			}
+			m.Values[stat] = value
+		}
+		m.Type = guessSummaryMetricType(m.Values)
+		if value, ok := m.Values["value"]; ok && m.Type == metrics.Rate {
+			m.Values["rate"] = value
+			delete(m.Values, "value")
+		}
+		result[name] = m
+	}
+	return result, nil
+}
+
+// guessSummaryMetricType returns the type of a metric of --summary-export,
+// from the names of its values.
+func guessSummaryMetricType(values map[string]float64) metrics.MetricType {
+	has := func(stat string) bool {
+		_, ok := values[stat]
+		return ok
+	}
+	switch {
+	case has("passes") && has("fails"):
+		return metrics.Rate
+	case has("count") && has("rate"):
+		return metrics.Counter
+	case has("value") && has("min") && has("max"):
+		return metrics.Gauge
+	default:
+		return metrics.Trend
+	}
+}
+
+// currentSummaryMetrics returns the aggregated values of the metrics of the
+// test run, the statistics of the trends are the ones of the baseline.
+func currentSummaryMetrics(
+	ms map[string]*metrics.Metric, duration time.Duration, baseline map[string]summaryMetric,
+) map[string]summaryMetric {
+	result := make(map[string]summaryMetric, len(ms))
+	for name, m := range ms {
+		current := summaryMetric{Type: m.Type, Contains: m.Contains, Values: make(map[string]float64)}
+		switch sink := m.Sink.(type) {
+		case *metrics.RateSink:
+			current.Values["passes"] = float64(sink.Trues)
+			current.Values["fails"] = float64(sink.Total - sink.Trues)
+			if sink.Total > 0 {
+				current.Values["rate"] = float64(sink.Trues) / float64(sink.Total)
+			}
+		case *metrics.TrendSink:
+			// the values of the baseline that aren't statistics of the
+			// trends are skipped
+			for stat := range baseline[name].Values {
+				if resolvers, err := metrics.GetResolversForTrendColumns([]string{stat}); err == nil {
+					current.Values[stat] = resolvers[stat](sink)
+				}
+			}
+		default:
+			for stat, value := range m.Sink.Format(duration) {
+				if !math.IsNaN(value) && !math.IsInf(value, 0) {
+					current.Values[stat] = value
+				}
+			}
+		}
+		result[name] = current
+	}
+	return result
+}
+
+// parseBaselineTolerances parses the tolerances of the baselineTolerances
+// option, by metric and statistic.
+func parseBaselineTolerances(tolerances map[string]string) (map[string]map[string]lib.BaselineTolerance, error) {
+	result := make(map[string]map[string]lib.BaselineTolerance)
+	for key, source := range tolerances {
+		metric, stat, err := lib.SplitBaselineKey(key)
+		if err != nil {
+			return nil, err
+		}
+		tolerance, err := lib.ParseBaselineTolerance(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid baseline tolerance of '%s': %w", key, err)
+		}
+		if result[metric] == nil {
+			result[metric] = make(map[string]lib.BaselineTolerance)
+		}
+		result[metric][stat] = tolerance
+	}
+	return result, nil
+}
+
+// compareWithBaseline returns the changes of the values of the metrics in
+// both summaries, and the keys of the tolerances without the values to check.
+func compareWithBaseline(
+	baseline, current map[string]summaryMetric, tolerances map[string]map[string]lib.BaselineTolerance,
+) (changes []baselineChange, unchecked []string) {
+	builtinMetrics := metrics.NewRegistry()
+	metrics.RegisterBuiltinMetrics(builtinMetrics)
+
+	for _, name := range sortedMapKeys(current) {
+		c := current[name]
+		b, ok := baseline[name]
+		if !ok || b.Type != c.Type {
+			continue
+		}
+		// the summaries of --summary-export don't have the value types, but
+		// the ones of the builtin metrics are known
+		contains := c.Contains
+		if contains == metrics.Default {
+			contains = b.Contains
+		}
+		if m := builtinMetrics.Get(strings.SplitN(name, "{", 2)[0]); contains == metrics.Default && m != nil {
+			contains = m.Contains
+		}
+		for _, stat := range sortedMapKeys(c.Values) {
+			baselineValue, ok := b.Values[stat]
+			if !ok {
+				continue
+			}
+			pValue, tested := significance(b, c, stat, contains)
+			change := baselineChange{
+				metric:     name,
+				stat:       stat,
+				baseline:   baselineValue,
+				current:    c.Values[stat],
+				pValue:     pValue,
+				metricType: c.Type,
+				contains:   contains,
+			}
+			if tolerance, ok := tolerances[name][stat]; ok {
+				// the changes of the values without a significance test are
+				// regressions if they exceed the tolerance, the ones with a
+				// p-value only if it's also significant, so never if the
+				// p-value can't be calculated
+				change.tolerance = &tolerance
+				change.regression = tolerance.Exceeded(change.baseline, change.current) &&
+					(!tested || change.pValue < significanceLevel)
+			}
+			changes = append(changes, change)
+		}
+	}
+
+	for _, name := range sortedMapKeys(tolerances) {
+		for _, stat := range sortedMapKeys(tolerances[name]) {
+			_, inBaseline := baseline[name].Values[stat]
+			_, inCurrent := current[name].Values[stat]
+			if !inBaseline || !inCurrent || baseline[name].Type != current[name].Type {
+				unchecked = append(unchecked, name+"."+stat)
+			}
+		}
+	}
+	return changes, unchecked
+}
+
+// significance returns the two-sided p-value of the change of the value, and
+// whether there is a test for it; the p-value is NaN if there isn't, or if it
+// can't be calculated. The rates of the rate metrics are compared with the
+// z-test of two proportions, the rates of the counters of events as the rates
+// of Poisson processes, and the averages of the trends with Welch's t-test, if
+// the summaries have their counts and standard deviations (the count and
+// stddev of summaryTrendStats). The counters of amounts, like the bytes of
+// data_received, aren't Poisson processes, so they aren't tested.
+func significance(baseline, current summaryMetric, stat string, contains metrics.ValueType) (float64, bool) {
+	b, c := baseline.Values, current.Values
+	switch {
+	case current.Type == metrics.Rate && stat == "rate":
+		n1, n2 := b["passes"]+b["fails"], c["passes"]+c["fails"]
+		if n1 == 0 || n2 == 0 {
+			return math.NaN(), true
+		}
+		pooled := (b["passes"] + c["passes"]) / (n1 + n2)
+		return zTestPValue(c["rate"]-b["rate"], math.Sqrt(pooled*(1-pooled)*(1/n1+1/n2))), true
+	case current.Type == metrics.Counter && stat == "rate" && contains == metrics.Default:
+		if b["rate"] <= 0 || c["rate"] <= 0 {
+			return math.NaN(), true
+		}
+		// the durations of the test runs are the counts divided by the rates
+		t1, t2 := b["count"]/b["rate"], c["count"]/c["rate"]
+		return zTestPValue(c["rate"]-b["rate"], math.Sqrt(b["count"]/(t1*t1)+c["count"]/(t2*t2))), true
+	case current.Type == metrics.Trend && stat == "avg":
+		_, hasCount := b["count"]
+		_, hasStdDev := b["stddev"]
+		if !hasCount || !hasStdDev {
+			return math.NaN(), false
+		}
+		return welchTestPValue(b["avg"], b["stddev"], b["count"], c["avg"], c["stddev"], c["count"]), true
+	default:
+		return math.NaN(), false
+	}
+}
+
+func zTestPValue(difference, standardError float64) float64 {
+	if standardError == 0 {
+		if difference == 0 {
+			return 1
+		}
+		return 0
+	}
+	return math.Erfc(math.Abs(difference/standardError) / math.Sqrt2)
+}
+
+// welchTestPValue returns the two-sided p-value of Welch's t-test of the
+// difference of two means, or NaN if a sample has less than 2 values.
+func welchTestPValue(mean1, stdDev1, n1, mean2, stdDev2, n2 float64) float64 {
+	if n1 < 2 || n2 < 2 {
+		return math.NaN()
+	}
+	v1, v2 := stdDev1*stdDev1/n1, stdDev2*stdDev2/n2
+	if v1+v2 == 0 {
+		if mean1 == mean2 {
+			return 1
+		}
+		return 0
+	}
+	t := (mean2 - mean1) / math.Sqrt(v1+v2)
+	// the Welch-Satterthwaite degrees of freedom
+	df := (v1 + v2) * (v1 + v2) / (v1*v1/(n1-1) + v2*v2/(n2-1))
+	return regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// regularizedIncompleteBeta returns I_x(a, b), from its continued fraction
+// with the modified Lentz's method.
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case x >= 1:
+		return 1
+	case x > (a+1)/(a+b+2):
+		// the continued fraction converges quickly only below the mean
+		return 1 - regularizedIncompleteBeta(1-x, b, a)
+	}
+
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	nonZero := func(v float64) float64 {
+		if math.Abs(v) < tiny {
+			return tiny
+		}
+		return v
+	}
+	c, d := 1.0, 1/nonZero(1-(a+b)*x/(a+1))
+	result := d
+	for m := 1.0; m <= maxIterations; m++ {
+		// the even and the odd steps of the continued fraction
+		even := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 / nonZero(1+even*d)
+		c = nonZero(1 + even/c)
+		result *= d * c
+		odd := -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 / nonZero(1+odd*d)
+		c = nonZero(1 + odd/c)
+		delta := d * c
+		result *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return front * result / a
+}
+
+// printBaselineComparison shows the changes from the baseline, and the
+// result of the check of their tolerances.
+func printBaselineComparison(w io.Writer, path string, changes []baselineChange) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "\nCompared with the baseline %s\n\n", path)
+	_, _ = fmt.Fprintf(tw, "METRIC\tSTAT\tBASELINE\tCURRENT\tCHANGE\tP-VALUE\tTOLERANCE\n")
+	for _, c := range changes {
+		format := baselineValueFormatter(c)
+		pValue := "-"
+		if !math.IsNaN(c.pValue) {
+			pValue = strconv.FormatFloat(c.pValue, 'f', 3, 64)
+		}
+		tolerance := "-"
+		if c.tolerance != nil {
+			tolerance = c.tolerance.String()
+			if c.regression {
+				tolerance += " ✗ regression"
+			} else {
+				tolerance += " ✓"
+			}
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.metric, c.stat,
+			format(c.baseline), format(c.current), formatChange(c.baseline, c.current), pValue, tolerance)
+	}
+	return tw.Flush()
+}
+
+func baselineValueFormatter(c baselineChange) func(float64) string {
+	format := valueFormatter(c.contains)
+	switch {
+	case c.metricType == metrics.Rate && c.stat == "rate":
+		return formatPercentage
+	case c.metricType == metrics.Counter && c.stat == "rate":
+		return func(v float64) string { return format(v) + "/s" }
+	case c.stat == "count" || c.stat == "passes" || c.stat == "fails":
+		return formatNumber
+	default:
+		return format
+	}
+}
+
+// baselineRegressions returns the keys of the regressions.
+func baselineRegressions(changes []baselineChange) []string {
+	var regressions []string
+	for _, c := range changes {
+		if c.regression {
+			regressions = append(regressions, c.metric+"."+c.stat)
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestParseSummaryMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("summary export", func(t *testing.T) {
+		t.Parallel()
+		summary, err := parseSummaryMetrics([]byte(`{"metrics": {
+			"http_req_duration": {"avg": 10, "p(95)": 20, "thresholds": {"p(95)<100": false}},
+			"http_req_failed": {"passes": 1, "fails": 9, "value": 0.1},
+			"http_reqs": {"count": 10, "rate": 2},
+			"vus": {"value": 1, "min": 1, "max": 2}
+		}}`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]summaryMetric{
+			"http_req_duration": {Type: metrics.Trend, Values: map[string]float64{"avg": 10, "p(95)": 20}},
+			"http_req_failed": {
+				Type: metrics.Rate, Values: map[string]float64{"passes": 1, "fails": 9, "rate": 0.1},
+			},
+			"http_reqs": {Type: metrics.Counter, Values: map[string]float64{"count": 10, "rate": 2}},
+			"vus":       {Type: metrics.Gauge, Values: map[string]float64{"value": 1, "min": 1, "max": 2}},
+		}, summary)
+	})
+
+	t.Run("handleSummary data", func(t *testing.T) {
+		t.Parallel()
+		summary, err := parseSummaryMetrics([]byte(`{"metrics": {
+			"http_req_duration": {"type": "trend", "contains": "time", "values": {"avg": 10}, "thresholds": {}},
+			"http_req_failed": {"type": "rate", "contains": "default", "values": {"rate": 0.1, "passes": 1, "fails": 9}}
+		}}`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]summaryMetric{
+			"http_req_duration": {Type: metrics.Trend, Contains: metrics.Time, Values: map[string]float64{"avg": 10}},
+			"http_req_failed": {
+				Type: metrics.Rate, Values: map[string]float64{"passes": 1, "fails": 9, "rate": 0.1},
+			},
+		}, summary)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := parseSummaryMetrics([]byte(`{"state": {}}`))
+		assert.ErrorContains(t, err, "there aren't any metrics")
+		_, err = parseSummaryMetrics([]byte(`{"metrics": {"http_reqs": {"count": "ten"}}}`))
+		assert.ErrorContains(t, err, "invalid value 'count' of the metric 'http_reqs'")
+	})
+}
+
+func TestCurrentSummaryMetrics(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	trend, err := registry.NewMetric("my_trend", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+	rate, err := registry.NewMetric("my_rate", metrics.Rate)
+	require.NoError(t, err)
+	counter, err := registry.NewMetric("my_counter", metrics.Counter)
+	require.NoError(t, err)
+	now := time.Now()
+	for i, v := range []float64{1, 2, 3, 4} {
+		trend.Sink.Add(metrics.Sample{Time: now, Value: v})
+		rate.Sink.Add(metrics.Sample{Time: now, Value: float64(i % 2)})
+		counter.Sink.Add(metrics.Sample{Time: now, Value: v})
+	}
+
+	baseline := map[string]summaryMetric{
+		"my_trend": {Type: metrics.Trend, Values: map[string]float64{"avg": 1, "max": 1, "thresholds": 1}},
+	}
+	current := currentSummaryMetrics(map[string]*metrics.Metric{
+		"my_trend": trend, "my_rate": rate, "my_counter": counter,
+	}, 2*time.Second, baseline)
+	assert.Equal(t, map[string]summaryMetric{
+		"my_trend":   {Type: metrics.Trend, Contains: metrics.Time, Values: map[string]float64{"avg": 2.5, "max": 4}},
+		"my_rate":    {Type: metrics.Rate, Values: map[string]float64{"rate": 0.5, "passes": 2, "fails": 2}},
+		"my_counter": {Type: metrics.Counter, Values: map[string]float64{"count": 10, "rate": 5}},
+	}, current)
+}
+
+func TestSignificance(t *testing.T) {
+	t.Parallel()
+
+	rate := func(passes, fails float64) summaryMetric {
+		return summaryMetric{Type: metrics.Rate, Values: map[string]float64{
+			"passes": passes, "fails": fails, "rate": passes / (passes + fails),
+		}}
+	}
+	pValue := func(baseline, current summaryMetric, stat string, contains metrics.ValueType) float64 {
+		p, tested := significance(baseline, current, stat, contains)
+		assert.True(t, tested)
+		return p
+	}
+	// 10% against 20% of failures with 1000 requests is significant, with 20 it isn't
+	assert.Less(t, pValue(rate(100, 900), rate(200, 800), "rate", metrics.Default), 0.001)
+	assert.Greater(t, pValue(rate(2, 18), rate(4, 16), "rate", metrics.Default), 0.3)
+	assert.Equal(t, 1.0, pValue(rate(0, 10), rate(0, 10), "rate", metrics.Default))
+	assert.True(t, math.IsNaN(pValue(rate(0, 0), rate(0, 10), "rate", metrics.Default)))
+
+	counter := func(count, rate float64) summaryMetric {
+		return summaryMetric{Type: metrics.Counter, Values: map[string]float64{"count": count, "rate": rate}}
+	}
+	assert.Less(t, pValue(counter(10000, 100), counter(9000, 90), "rate", metrics.Default), 0.001)
+	assert.Greater(t, pValue(counter(100, 10), counter(95, 9.5), "rate", metrics.Default), 0.5)
+
+	trend := func(avg, stdDev, count float64) summaryMetric {
+		return summaryMetric{Type: metrics.Trend, Values: map[string]float64{
+			"avg": avg, "stddev": stdDev, "count": count,
+		}}
+	}
+	// 10ms slower with a standard deviation of 20ms is significant with 1000
+	// requests, not with 10
+	assert.Less(t, pValue(trend(100, 20, 1000), trend(110, 25, 1000), "avg", metrics.Time), 0.001)
+	assert.Greater(t, pValue(trend(100, 20, 10), trend(110, 25, 10), "avg", metrics.Time), 0.3)
+	assert.Equal(t, 1.0, pValue(trend(100, 0, 10), trend(100, 0, 10), "avg", metrics.Time))
+	assert.True(t, math.IsNaN(pValue(trend(100, 20, 1), trend(110, 25, 10), "avg", metrics.Time)))
+
+	notTested := func(baseline, current summaryMetric, stat string, contains metrics.ValueType) {
+		p, tested := significance(baseline, current, stat, contains)
+		assert.False(t, tested)
+		assert.True(t, math.IsNaN(p))
+	}
+	notTested(counter(100, 10), counter(95, 9.5), "count", metrics.Default)
+	// the bytes aren't events
+	notTested(counter(10000, 100), counter(9000, 90), "rate", metrics.Data)
+	notTested(trend(100, 20, 1000), trend(110, 25, 1000), "p(95)", metrics.Time)
+	notTested(summaryMetric{Type: metrics.Trend, Values: map[string]float64{"avg": 1}},
+		summaryMetric{Type: metrics.Trend, Values: map[string]float64{"avg": 2}}, "avg", metrics.Time)
+}
+
+func TestWelchTestPValue(t *testing.T) {
+	t.Parallel()
+
+	// with 1 and 2 degrees of freedom, the t-distribution has closed forms
+	tValue := 1.5
+	assert.InDelta(t, 1-2/math.Pi*math.Atan(tValue), regularizedIncompleteBeta(1/(1+tValue*tValue), 0.5, 0.5), 1e-9)
+	assert.InDelta(t, 1-tValue/math.Sqrt(2+tValue*tValue), regularizedIncompleteBeta(2/(2+tValue*tValue), 1, 0.5), 1e-9)
+	// with many, it's the normal distribution
+	assert.InDelta(t, math.Erfc(1.5/math.Sqrt2), welchTestPValue(0, 100, 2e6, 0.15, 100, 2e6), 1e-4)
+}
+
+func TestCompareWithBaseline(t *testing.T) {
+	t.Parallel()
+
+	baseline := map[string]summaryMetric{
+		"http_req_duration": {Type: metrics.Trend, Contains: metrics.Time, Values: map[string]float64{
+			"avg": 100, "p(95)": 200,
+		}},
+		"http_reqs": {Type: metrics.Counter, Values: map[string]float64{"count": 100, "rate": 10}},
+		"vus":       {Type: metrics.Gauge, Values: map[string]float64{"value": 1}},
+	}
+	current := map[string]summaryMetric{
+		"http_req_duration": {Type: metrics.Trend, Contains: metrics.Time, Values: map[string]float64{
+			"avg": 105, "p(95)": 250,
+		}},
+		// a random change, the regression isn't significant
+		"http_reqs": {Type: metrics.Counter, Values: map[string]float64{"count": 90, "rate": 9}},
+		"vus":       {Type: metrics.Trend, Values: map[string]float64{"value": 1}},
+	}
+	tolerances, err := parseBaselineTolerances(map[string]string{
+		"http_req_duration.avg":   "10%",
+		"http_req_duration.p(95)": "+10%",
+		"http_reqs.rate":          "-5%",
+		"vus.value":               "1",
+		"iterations.count":        "1",
+	})
+	require.NoError(t, err)
+
+	changes, unchecked := compareWithBaseline(baseline, current, tolerances)
+	assert.Equal(t, []string{"iterations.count", "vus.value"}, unchecked)
+	require.Len(t, changes, 4)
+	assert.Equal(t, []string{"http_req_duration.p(95)"}, baselineRegressions(changes))
+	assert.False(t, changes[3].regression)
+	assert.Greater(t, changes[3].pValue, significanceLevel)
+
+	var buf bytes.Buffer
+	require.NoError(t, printBaselineComparison(&buf, "previous.json", changes))
+	assert.Contains(t, buf.String(), "Compared with the baseline previous.json")
+	assert.Regexp(t, `http_req_duration\s+p\(95\)\s+200ms\s+250ms\s+\+25.00%\s+-\s+\+10% ✗ regression`, buf.String())
+	assert.Regexp(t, `http_reqs\s+rate\s+10/s\s+9/s\s+-10.00%\s+0.\d{3}\s+-5% ✓`, buf.String())
+	assert.Regexp(t, `http_reqs\s+count\s+100\s+90\s+-10.00%\s+-\s+-\n`, buf.String())
+}
+
+func TestCompareWithBaselineWithoutSignificance(t *testing.T) {
+	t.Parallel()
+
+	// the summaries of --summary-export, without the value types
+	baseline := map[string]summaryMetric{
+		"data_received": {Type: metrics.Counter, Values: map[string]float64{"count": 1000, "rate": 100}},
+		"errors":        {Type: metrics.Counter, Values: map[string]float64{"count": 0, "rate": 0}},
+	}
+	current := map[string]summaryMetric{
+		"data_received": {Type: metrics.Counter, Values: map[string]float64{"count": 1000, "rate": 90}},
+		"errors":        {Type: metrics.Counter, Values: map[string]float64{"count": 10, "rate": 1}},
+	}
+	tolerances, err := parseBaselineTolerances(map[string]string{
+		"data_received.rate": "-5%",
+		"errors.rate":        "0.5",
+	})
+	require.NoError(t, err)
+
+	changes, unchecked := compareWithBaseline(baseline, current, tolerances)
+	assert.Empty(t, unchecked)
+	require.Len(t, changes, 4)
+	// the bytes aren't tested, so the tolerance decides
+	assert.Equal(t, metrics.Data, changes[1].contains)
+	assert.True(t, math.IsNaN(changes[1].pValue))
+	// the p-value can't be calculated without errors in the baseline
+	assert.True(t, math.IsNaN(changes[3].pValue))
+	assert.Equal(t, []string{"data_received.rate"}, baselineRegressions(changes))
+}
+
+func TestParseBaselineTolerancesError(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseBaselineTolerances(map[string]string{"http_reqs.rate": "5 percent"})
+	assert.ErrorContains(t, err, "invalid baseline tolerance of 'http_reqs.rate'")
+}
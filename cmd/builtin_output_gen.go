@@ -7,11 +7,11 @@ import (
 	"strings"
 )
 
-const _builtinOutputName = "cloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkastatsd"
+const _builtinOutputName = "cloudcsvdatadogelasticsearchexecexperimental-prometheus-rwinfluxdbjsonkafkaotlpparquetsqlitestatsdtimescaledb"
 
-var _builtinOutputIndex = [...]uint8{0, 5, 8, 15, 41, 49, 53, 58, 64}
+var _builtinOutputIndex = [...]uint8{0, 5, 8, 15, 28, 32, 58, 66, 70, 75, 79, 86, 92, 98, 109}
 
-const _builtinOutputLowerName = "cloudcsvdatadogexperimental-prometheus-rwinfluxdbjsonkafkastatsd"
+const _builtinOutputLowerName = "cloudcsvdatadogelasticsearchexecexperimental-prometheus-rwinfluxdbjsonkafkaotlpparquetsqlitestatsdtimescaledb"
 
 func (i builtinOutput) String() string {
 	if i >= builtinOutput(len(_builtinOutputIndex)-1) {
@@ -27,43 +27,67 @@ func _builtinOutputNoOp() {
 	_ = x[builtinOutputCloud-(0)]
 	_ = x[builtinOutputCSV-(1)]
 	_ = x[builtinOutputDatadog-(2)]
-	_ = x[builtinOutputExperimentalPrometheusRW-(3)]
-	_ = x[builtinOutputInfluxdb-(4)]
-	_ = x[builtinOutputJSON-(5)]
-	_ = x[builtinOutputKafka-(6)]
-	_ = x[builtinOutputStatsd-(7)]
+	_ = x[builtinOutputElasticsearch-(3)]
+	_ = x[builtinOutputExec-(4)]
+	_ = x[builtinOutputExperimentalPrometheusRW-(5)]
+	_ = x[builtinOutputInfluxdb-(6)]
+	_ = x[builtinOutputJSON-(7)]
+	_ = x[builtinOutputKafka-(8)]
+	_ = x[builtinOutputOTLP-(9)]
+	_ = x[builtinOutputParquet-(10)]
+	_ = x[builtinOutputSqlite-(11)]
+	_ = x[builtinOutputStatsd-(12)]
+	_ = x[builtinOutputTimescaledb-(13)]
 }
 
-var _builtinOutputValues = []builtinOutput{builtinOutputCloud, builtinOutputCSV, builtinOutputDatadog, builtinOutputExperimentalPrometheusRW, builtinOutputInfluxdb, builtinOutputJSON, builtinOutputKafka, builtinOutputStatsd}
+var _builtinOutputValues = []builtinOutput{builtinOutputCloud, builtinOutputCSV, builtinOutputDatadog, builtinOutputElasticsearch, builtinOutputExec, builtinOutputExperimentalPrometheusRW, builtinOutputInfluxdb, builtinOutputJSON, builtinOutputKafka, builtinOutputOTLP, builtinOutputParquet, builtinOutputSqlite, builtinOutputStatsd, builtinOutputTimescaledb}
 
 var _builtinOutputNameToValueMap = map[string]builtinOutput{
-	_builtinOutputName[0:5]:        builtinOutputCloud,
-	_builtinOutputLowerName[0:5]:   builtinOutputCloud,
-	_builtinOutputName[5:8]:        builtinOutputCSV,
-	_builtinOutputLowerName[5:8]:   builtinOutputCSV,
-	_builtinOutputName[8:15]:       builtinOutputDatadog,
-	_builtinOutputLowerName[8:15]:  builtinOutputDatadog,
-	_builtinOutputName[15:41]:      builtinOutputExperimentalPrometheusRW,
-	_builtinOutputLowerName[15:41]: builtinOutputExperimentalPrometheusRW,
-	_builtinOutputName[41:49]:      builtinOutputInfluxdb,
-	_builtinOutputLowerName[41:49]: builtinOutputInfluxdb,
-	_builtinOutputName[49:53]:      builtinOutputJSON,
-	_builtinOutputLowerName[49:53]: builtinOutputJSON,
-	_builtinOutputName[53:58]:      builtinOutputKafka,
-	_builtinOutputLowerName[53:58]: builtinOutputKafka,
-	_builtinOutputName[58:64]:      builtinOutputStatsd,
-	_builtinOutputLowerName[58:64]: builtinOutputStatsd,
+	_builtinOutputName[0:5]:         builtinOutputCloud,
+	_builtinOutputLowerName[0:5]:    builtinOutputCloud,
+	_builtinOutputName[5:8]:         builtinOutputCSV,
+	_builtinOutputLowerName[5:8]:    builtinOutputCSV,
+	_builtinOutputName[8:15]:        builtinOutputDatadog,
+	_builtinOutputLowerName[8:15]:   builtinOutputDatadog,
+	_builtinOutputName[15:28]:       builtinOutputElasticsearch,
+	_builtinOutputLowerName[15:28]:  builtinOutputElasticsearch,
+	_builtinOutputName[28:32]:       builtinOutputExec,
+	_builtinOutputLowerName[28:32]:  builtinOutputExec,
+	_builtinOutputName[32:58]:       builtinOutputExperimentalPrometheusRW,
+	_builtinOutputLowerName[32:58]:  builtinOutputExperimentalPrometheusRW,
+	_builtinOutputName[58:66]:       builtinOutputInfluxdb,
+	_builtinOutputLowerName[58:66]:  builtinOutputInfluxdb,
+	_builtinOutputName[66:70]:       builtinOutputJSON,
+	_builtinOutputLowerName[66:70]:  builtinOutputJSON,
+	_builtinOutputName[70:75]:       builtinOutputKafka,
+	_builtinOutputLowerName[70:75]:  builtinOutputKafka,
+	_builtinOutputName[75:79]:       builtinOutputOTLP,
+	_builtinOutputLowerName[75:79]:  builtinOutputOTLP,
+	_builtinOutputName[79:86]:       builtinOutputParquet,
+	_builtinOutputLowerName[79:86]:  builtinOutputParquet,
+	_builtinOutputName[86:92]:       builtinOutputSqlite,
+	_builtinOutputLowerName[86:92]:  builtinOutputSqlite,
+	_builtinOutputName[92:98]:       builtinOutputStatsd,
+	_builtinOutputLowerName[92:98]:  builtinOutputStatsd,
+	_builtinOutputName[98:109]:      builtinOutputTimescaledb,
+	_builtinOutputLowerName[98:109]: builtinOutputTimescaledb,
 }
 
 var _builtinOutputNames = []string{
 	_builtinOutputName[0:5],
 	_builtinOutputName[5:8],
 	_builtinOutputName[8:15],
-	_builtinOutputName[15:41],
-	_builtinOutputName[41:49],
-	_builtinOutputName[49:53],
-	_builtinOutputName[53:58],
-	_builtinOutputName[58:64],
+	_builtinOutputName[15:28],
+	_builtinOutputName[28:32],
+	_builtinOutputName[32:58],
+	_builtinOutputName[58:66],
+	_builtinOutputName[66:70],
+	_builtinOutputName[70:75],
+	_builtinOutputName[75:79],
+	_builtinOutputName[79:86],
+	_builtinOutputName[86:92],
+	_builtinOutputName[92:98],
+	_builtinOutputName[98:109],
 }
 
 // builtinOutputString retrieves an enum value from the enum constants string name.
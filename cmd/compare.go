@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+)
+
+// cmdCompare handles the `k6 compare` sub-command
+type cmdCompare struct {
+	gs *state.GlobalState
+
+	tolerances []string
+}
+
+func (c *cmdCompare) run(_ *cobra.Command, args []string) error {
+	tolerances := make(map[string]string, len(c.tolerances))
+	for _, t := range c.tolerances {
+		key, value, ok := strings.Cut(t, "=")
+		if !ok {
+			return fmt.Errorf("invalid tolerance '%s', it must be like http_req_duration.p(95)=10%%", t)
+		}
+		tolerances[key] = value
+	}
+	parsedTolerances, err := parseBaselineTolerances(tolerances)
+	if err != nil {
+		return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+
+	baseline, err := readSummaryMetrics(c.gs.FS, args[0])
+	if err != nil {
+		return err
+	}
+	current, err := readSummaryMetrics(c.gs.FS, args[1])
+	if err != nil {
+		return err
+	}
+
+	changes, unchecked := compareWithBaseline(baseline, current, parsedTolerances)
+	for _, key := range unchecked {
+		c.gs.Logger.Warnf("The tolerance of '%s' can't be checked, the value isn't in both summaries", key)
+	}
+	if err = printBaselineComparison(c.gs.Stdout, args[0], changes); err != nil {
+		return err
+	}
+
+	if regressions := baselineRegressions(changes); len(regressions) > 0 {
+		return errext.WithExitCodeIfNone(
+			fmt.Errorf("metrics '%s' have regressed from the baseline", strings.Join(regressions, ", ")),
+			exitcodes.BaselineRegression,
+		)
+	}
+	return nil
+}
+
+func (c *cmdCompare) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringArrayVar(&c.tolerances, "tolerance", c.tolerances,
+		"maximum regression of a value, like `http_req_duration.p(95)=10%`, it can be repeated")
+	return flags
+}
+
+func getCmdCompare(gs *state.GlobalState) *cobra.Command {
+	c := &cmdCompare{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Compare the summary of a test run with the one of a previous test run.
+  {{.}} run --summary-export previous.json script.js
+  {{.}} run --summary-export current.json script.js
+  {{.}} compare previous.json current.json
+
+  # Fail if the p(95) of the requests is more than 10% higher, or their rate 5% lower.
+  {{.}} compare --tolerance 'http_req_duration.p(95)=10%' --tolerance 'http_reqs.rate=-5%' previous.json current.json`[1:])
+
+	compareCmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Compare the summaries of two test runs",
+		Long: `Compare the summaries of two test runs.
+
+The aggregated values of the metrics in the JSON summaries, written by
+--summary-export or by handleSummary(), are compared with the baseline, the
+first one. The p-values of the changes of the rates of the rate metrics and
+of the counters of events, and of the averages of the trends with the count
+and stddev in summaryTrendStats, are from significance tests. The regressions
+greater than the tolerances make the command fail, if they are significant or
+they can't be tested.`,
+		Example: exampleText,
+		Args:    cobra.ExactArgs(2),
+		RunE:    c.run,
+	}
+
+	compareCmd.Flags().SortFlags = false
+	compareCmd.Flags().AddFlagSet(c.flagSet())
+
+	return compareCmd
+}
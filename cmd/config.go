@@ -45,6 +45,14 @@ type Config struct {
 
 	// TODO: deprecate
 	Collectors map[string]json.RawMessage `json:"collectors"`
+
+	// The named sets of options in the config file, the one selected with
+	// --profile is applied on top of the others
+	Profiles map[string]Config `json:"profiles,omitempty" ignored:"true"`
+
+	// The variables of the config file, the ${name} references to them in the
+	// options are replaced by their values
+	Vars map[string]json.RawMessage `json:"vars,omitempty" ignored:"true"`
 }
 
 // Validate checks if all of the specified options make sense
@@ -105,11 +113,25 @@ func getConfig(flags *pflag.FlagSet) (Config, error) {
 	}, nil
 }
 
-// Reads the configuration file from the supplied filesystem and returns it or
-// an error. The only situation in which an error won't be returned is if the
+// Reads the JSON or YAML configuration file from the supplied filesystem and
+// returns it or an error. The only situation in which an error won't be returned is if the
 // user didn't explicitly specify a config file path and the default config file
 // doesn't exist.
 func readDiskConfig(gs *state.GlobalState) (Config, error) {
+	data, err := readDiskConfigFile(gs)
+	if err != nil || data == nil {
+		return Config{}, err
+	}
+	conf, err := parseConfigFile(gs.Flags.ConfigFilePath, data)
+	if err != nil {
+		return Config{}, fmt.Errorf("couldn't parse the configuration: %w", err)
+	}
+	return conf, nil
+}
+
+// readDiskConfigFile returns the contents of the configuration file, or nil if
+// it's the default one and it doesn't exist.
+func readDiskConfigFile(gs *state.GlobalState) ([]byte, error) {
 	// Try to see if the file exists in the supplied filesystem
 	if _, err := gs.FS.Stat(gs.Flags.ConfigFilePath); err != nil {
 		if errors.Is(err, fs.ErrNotExist) && gs.Flags.ConfigFilePath == gs.DefaultFlags.ConfigFilePath {
@@ -117,19 +139,14 @@ func readDiskConfig(gs *state.GlobalState) (Config, error) {
 			// didn't specify anything), silence the error
 			err = nil
 		}
-		return Config{}, err
+		return nil, err
 	}
 
 	data, err := fsext.ReadFile(gs.FS, gs.Flags.ConfigFilePath)
 	if err != nil {
-		return Config{}, fmt.Errorf("couldn't load the configuration from %q: %w", gs.Flags.ConfigFilePath, err)
-	}
-	var conf Config
-	err = json.Unmarshal(data, &conf)
-	if err != nil {
-		return Config{}, fmt.Errorf("couldn't parse the configuration from %q: %w", gs.Flags.ConfigFilePath, err)
+		return nil, fmt.Errorf("couldn't load the configuration from %q: %w", gs.Flags.ConfigFilePath, err)
 	}
-	return conf, nil
+	return data, nil
 }
 
 // Serializes the configuration to a JSON file and writes it in the supplied
@@ -147,6 +164,39 @@ func writeDiskConfig(gs *state.GlobalState, conf Config) error {
 	return fsext.WriteFile(gs.FS, gs.Flags.ConfigFilePath, data, 0o644)
 }
 
+// loadEnvFiles adds the variables in the --env-file files to the environment,
+// unless they are already set. Since it happens after the global flags are
+// parsed, the files can't set their environment variables, like K6_CONFIG.
+func loadEnvFiles(gs *state.GlobalState) error {
+	fileEnv := make(map[string]string)
+	for _, path := range gs.Flags.EnvFiles {
+		if !filepath.IsAbs(path) {
+			cwd, err := gs.Getwd()
+			if err != nil {
+				return fmt.Errorf("'%s' is a relative path but could not determine CWD: %w", path, err)
+			}
+			path = filepath.Join(cwd, path)
+		}
+		data, err := fsext.ReadFile(gs.FS, path)
+		if err != nil {
+			return fmt.Errorf("couldn't load the environment variables from %q: %w", path, err)
+		}
+		env, err := state.ParseEnvFile(data)
+		if err != nil {
+			return fmt.Errorf("couldn't parse the environment variables from %q: %w", path, err)
+		}
+		for k, v := range env {
+			fileEnv[k] = v
+		}
+	}
+	for k, v := range fileEnv {
+		if _, ok := gs.Env[k]; !ok {
+			gs.Env[k] = v
+		}
+	}
+	return nil
+}
+
 // Reads configuration variables from the environment.
 func readEnvConfig(envMap map[string]string) (Config, error) {
 	// TODO: replace envconfig and refactor the whole configuration from the ground up :/
@@ -160,9 +210,9 @@ func readEnvConfig(envMap map[string]string) (Config, error) {
 
 // Assemble the final consolidated configuration from all of the different sources:
 // - start with the CLI-provided options to get shadowed (non-Valid) defaults in there
-// - add the global file config options
+// - add the global file config options, with the ones of the --profile on top
 // - add the Runner-provided options (they may come from Bundle too if applicable)
-// - add the environment variables
+// - add the environment variables, including the ones of the --env-file files
 // - merge the user-supplied CLI flags back in on top, to give them the greatest priority
 // - set some defaults if they weren't previously specified
 // TODO: add better validation, more explicit default values and improve consistency between formats
@@ -174,6 +224,10 @@ func getConsolidatedConfig(gs *state.GlobalState, cliConf Config, runnerOpts lib
 	if err != nil {
 		return conf, err
 	}
+	fileConf, err = applyProfile(fileConf, gs.Flags.Profile, gs.Flags.ConfigFilePath)
+	if err != nil {
+		return conf, err
+	}
 	envConf, err := readEnvConfig(gs.Env)
 	if err != nil {
 		return conf, err
@@ -198,6 +252,30 @@ func getConsolidatedConfig(gs *state.GlobalState, cliConf Config, runnerOpts lib
 	return conf, nil
 }
 
+// applyProfile returns the config of the file with the options of the named
+// profile applied on top of it.
+func applyProfile(conf Config, profile, configFilePath string) (Config, error) {
+	profiles := conf.Profiles
+	conf.Profiles = nil
+	if profile == "" {
+		return conf, nil
+	}
+	profileConf, ok := profiles[profile]
+	if !ok {
+		return conf, errext.WithExitCodeIfNone(
+			fmt.Errorf("the profile %q isn't in the config file %q", profile, configFilePath),
+			exitcodes.InvalidConfig,
+		)
+	}
+	if len(profileConf.Profiles) > 0 {
+		return conf, errext.WithExitCodeIfNone(
+			fmt.Errorf("the profile %q in the config file %q can't have profiles", profile, configFilePath),
+			exitcodes.InvalidConfig,
+		)
+	}
+	return conf.Apply(profileConf), nil
+}
+
 // applyDefault applies the default options value if it is not specified.
 // This happens with types which are not supported by "gopkg.in/guregu/null.v3".
 //
@@ -265,9 +343,18 @@ func consolidateErrorMessage(errList []error, title string) error {
 }
 
 func validateScenarioConfig(conf lib.ExecutorConfig, isExecutable func(string) bool) error {
-	execFn := conf.GetExec()
-	if !isExecutable(execFn) {
-		return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), execFn)
+	fns := []string{conf.GetExec()}
+	if mix := conf.GetExecMix(); len(mix) > 0 {
+		fns = fns[:0]
+		for fn := range mix {
+			fns = append(fns, fn)
+		}
+	}
+	fns = append(fns, conf.GetSetup(), conf.GetTeardown())
+	for _, fn := range fns {
+		if fn != "" && !isExecutable(fn) {
+			return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), fn)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+)
+
+// getCmdConfig returns the `k6 config` sub-command, together with its children.
+func getCmdConfig(gs *state.GlobalState) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Validate and show the configuration",
+		Long: `Validate and show the configuration.
+
+The options are taken, from the lowest to the highest priority, from the config
+file (--config) with its --profile, the options of the script, the environment
+variables (including the ones in the --env-file files) and the CLI flags.
+
+The config file is in JSON, or in YAML if its extension is .yaml or .yml, with
+the same options in both formats.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+	configCmd.AddCommand(
+		getCmdConfigValidate(gs),
+		getCmdConfigShow(gs),
+	)
+
+	return configCmd
+}
+
+func getCmdConfigValidate(gs *state.GlobalState) *cobra.Command {
+	exampleText := getExampleText(gs, `
+  # Validate the config file, the environment variables and the flags.
+  {{.}} config validate --config k6.yaml
+
+  # Validate them together with the options of the script.
+  {{.}} config validate --config k6.yaml --profile ci script.js`[1:])
+
+	validateCmd := &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Validate the configuration",
+		Long: `Validate the configuration.
+
+Unlike the other commands, the unknown options in the config file are reported
+as errors, with their positions in the file.`,
+		Example: exampleText,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkDiskConfig(gs); err != nil {
+				return err
+			}
+			if _, err := resolveConfig(gs, cmd, args); err != nil {
+				return err
+			}
+			printToStdout(gs, "The configuration is valid.\n")
+			return nil
+		},
+	}
+
+	validateCmd.Flags().SortFlags = false
+	validateCmd.Flags().AddFlagSet(configCmdFlagSet())
+
+	return validateCmd
+}
+
+func getCmdConfigShow(gs *state.GlobalState) *cobra.Command {
+	var resolved bool
+
+	exampleText := getExampleText(gs, `
+  # Show the options in the config file, with the ones of the profile applied.
+  {{.}} config show --config k6.yaml --profile ci
+
+  # Show the effective options of the test, from all of the sources.
+  {{.}} config show --resolved --config k6.yaml --vus 10 script.js`[1:])
+
+	showCmd := &cobra.Command{
+		Use:   "show [file]",
+		Short: "Show the configuration",
+		Long: `Show the configuration.
+
+By default, only the options in the config file are shown. With --resolved, the
+fully merged effective options are shown, as they would be used by k6 run.`,
+		Example: exampleText,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var conf Config
+			var err error
+			if resolved {
+				conf, err = resolveConfig(gs, cmd, args)
+			} else {
+				conf, err = readDiskConfig(gs)
+				if err == nil {
+					conf, err = applyProfile(conf, gs.Flags.Profile, gs.Flags.ConfigFilePath)
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			data, err := json.MarshalIndent(conf, "", "  ")
+			if err != nil {
+				return err
+			}
+			printToStdout(gs, string(data)+"\n")
+			return nil
+		},
+	}
+
+	showCmd.Flags().SortFlags = false
+	showCmd.Flags().AddFlagSet(configCmdFlagSet())
+	showCmd.Flags().BoolVar(&resolved, "resolved", false,
+		"show the effective options, merged from all of the sources")
+
+	return showCmd
+}
+
+// checkDiskConfig checks the config file strictly, if there's one.
+func checkDiskConfig(gs *state.GlobalState) error {
+	data, err := readDiskConfigFile(gs)
+	if err != nil || data == nil {
+		return err
+	}
+	if errs := checkConfigFile(gs.Flags.ConfigFilePath, data); len(errs) > 0 {
+		return errext.WithExitCodeIfNone(
+			consolidateErrorMessage(errs, "There were problems with the config file:"),
+			exitcodes.InvalidConfig,
+		)
+	}
+	return nil
+}
+
+// configCmdFlagSet returns the flags of `k6 run` that set options, so their
+// effect on the configuration can be checked.
+func configCmdFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.AddFlagSet(optionFlagSet())
+	flags.AddFlagSet(runtimeOptionFlagSet(false))
+	flags.AddFlagSet(configFlagSet())
+	return flags
+}
+
+// resolveConfig returns the effective configuration, consolidated from all of
+// the sources, derived and validated. The options of the script are included
+// only when it's given.
+func resolveConfig(gs *state.GlobalState, cmd *cobra.Command, args []string) (Config, error) {
+	if len(args) > 0 {
+		test, err := loadLocalTest(gs, cmd, args)
+		if err != nil {
+			return Config{}, err
+		}
+		configuredTest, err := test.consolidateDeriveAndValidateConfig(gs, cmd, getConfig)
+		if err != nil {
+			return Config{}, err
+		}
+		return configuredTest.derivedConfig, nil
+	}
+
+	cliConf, err := getConfig(cmd.Flags())
+	if err != nil {
+		return Config{}, err
+	}
+	conf, err := getConsolidatedConfig(gs, cliConf, lib.Options{})
+	if err != nil {
+		return Config{}, err
+	}
+	// without a script, the functions of the scenarios can't be checked
+	return deriveAndValidateConfig(conf, func(string) bool { return true }, gs.Logger)
+}
@@ -412,7 +412,7 @@ func getConfigConsolidationTestCases() []configConsolidationTestCase {
 				Policy: types.NullDNSPolicy{DNSPolicy: types.DNSpreferIPv6, Valid: true},
 			}, c.Options.DNS)
 		}},
-		// This is functionally invalid, but will error out in validation done in js.parseTTL().
+		// This is functionally invalid, but will error out in validation done in types.ParseDNSTTL().
 		{opts{cli: []string{"--dns", "ttl=-1"}}, exp{}, func(t *testing.T, c Config) {
 			assert.Equal(t, types.DNSConfig{
 				TTL:    null.StringFrom("-1"),
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"go.k6.io/k6/lib"
+)
+
+// configFileError is a problem in a config file, with its position in the
+// file when it's known.
+type configFileError struct {
+	path         string
+	line, column int
+	msg          string
+}
+
+func (e configFileError) Error() string {
+	switch {
+	case e.line == 0:
+		return fmt.Sprintf("%s: %s", e.path, e.msg)
+	case e.column == 0:
+		return fmt.Sprintf("%s:%d: %s", e.path, e.line, e.msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.path, e.line, e.column, e.msg)
+}
+
+// isYAMLConfigFile returns whether the config file is in YAML, instead of JSON,
+// based on its extension.
+func isYAMLConfigFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// parseConfigFile parses a config file in JSON or YAML. The errors have the
+// line and column of the problem in the file, when they can be found.
+func parseConfigFile(path string, data []byte) (Config, error) {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return Config{}, configFileError{path: path, msg: "TOML config files aren't supported, use JSON or YAML"}
+	}
+
+	// JSON is parsed as YAML too, only to find the positions of the problems
+	var root yaml.Node
+	yamlErr := yaml.Unmarshal(data, &root)
+
+	jsonData := data
+	if isYAMLConfigFile(path) {
+		if yamlErr != nil {
+			return Config{}, yamlConfigFileError(path, yamlErr)
+		}
+		var err error
+		if jsonData, err = yamlNodeToJSON(&root); err != nil {
+			return Config{}, configFileError{path: path, msg: err.Error()}
+		}
+	}
+
+	var file struct {
+		Vars map[string]json.RawMessage `json:"vars"`
+	}
+	err := json.Unmarshal(jsonData, &file)
+	if err == nil {
+		var conf Config
+		if conf, err = decodeConfigFile(jsonData, file.Vars); err == nil {
+			return conf, nil
+		}
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := offsetPosition(data, syntaxErr.Offset)
+		return Config{}, configFileError{path: path, line: line, column: column, msg: syntaxErr.Error()}
+	}
+	if yamlErr != nil {
+		return Config{}, configFileError{path: path, msg: err.Error()}
+	}
+	return Config{}, locateDecodeError(path, &root, file.Vars)
+}
+
+// decodeConfigFile decodes the JSON of the config file, after expanding the
+// ${name} references to its variables.
+func decodeConfigFile(data []byte, vars map[string]json.RawMessage) (Config, error) {
+	data, err := lib.ExpandVariables(data, vars)
+	if err != nil {
+		return Config{}, err
+	}
+	var conf Config
+	err = json.Unmarshal(data, &conf)
+	return conf, err
+}
+
+// checkConfigFile returns the problems in the config file, including the
+// unknown options, which are otherwise ignored. The top-level x- fields are
+// allowed, so the YAML anchors can be defined outside of the options.
+func checkConfigFile(path string, data []byte) []error {
+	if _, err := parseConfigFile(path, data); err != nil {
+		return []error{err}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return nil
+	}
+	var errs []error
+	checkConfigFileOptions(root.Content[0], reflect.TypeOf(Config{}), "", func(n *yaml.Node, msg string) {
+		errs = append(errs, configFileError{path: path, line: n.Line, column: n.Column, msg: msg})
+	})
+	return errs
+}
+
+// checkConfigFileOptions reports the keys of the node that don't match the
+// JSON fields of the type, recursively. The types with their own JSON decoding
+// aren't checked, since the format of their values can't be known.
+func checkConfigFileOptions(n *yaml.Node, t reflect.Type, path string, report func(*yaml.Node, string)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		fields := jsonFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if path == "" && strings.HasPrefix(key, "x-") {
+				continue // the extension fields, e.g. for the YAML anchors
+			}
+			field, ok := findJSONField(fields, key)
+			if !ok {
+				report(n.Content[i], fmt.Sprintf("unknown option %q", joinOptionPath(path, key)))
+				continue
+			}
+			checkConfigFileOptions(n.Content[i+1], field.Type, joinOptionPath(path, key), report)
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			checkConfigFileOptions(n.Content[i+1], t.Elem(), joinOptionPath(path, n.Content[i].Value), report)
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for i, item := range n.Content {
+			checkConfigFileOptions(item, t.Elem(), joinOptionPath(path, strconv.Itoa(i)), report)
+		}
+	}
+}
+
+// jsonFields returns the fields of the struct by their JSON names, including
+// the ones of the embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embeddedName, embeddedField := range jsonFields(field.Type) {
+				if _, ok := fields[embeddedName]; !ok {
+					fields[embeddedName] = embeddedField
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// findJSONField finds the field like encoding/json does, preferring an exact
+// match but also accepting a case-insensitive one.
+func findJSONField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if field, ok := fields[key]; ok {
+		return field, true
+	}
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinOptionPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// locateDecodeError decodes the top-level options of the file one by one, to
+// find the position of the one that can't be decoded.
+func locateDecodeError(path string, root *yaml.Node, vars map[string]json.RawMessage) error {
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	for i := 0; doc.Kind == yaml.MappingNode && i+1 < len(doc.Content); i += 2 {
+		option := &yaml.Node{Kind: yaml.MappingNode, Content: doc.Content[i : i+2]}
+		data, err := yamlNodeToJSON(option)
+		if err == nil {
+			_, err = decodeConfigFile(data, vars)
+		}
+		if err == nil {
+			continue
+		}
+
+		n, msg := doc.Content[i+1], err.Error()
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			msg = fmt.Sprintf("%q should be of type %s, not %s", typeErr.Field, typeErr.Type, typeErr.Value)
+			n = findYAMLNode(doc, strings.Split(typeErr.Field, "."))
+		} else {
+			msg = fmt.Sprintf("invalid value of %q: %s", doc.Content[i].Value, msg)
+		}
+		return configFileError{path: path, line: n.Line, column: n.Column, msg: msg}
+	}
+	return configFileError{path: path, msg: "the file isn't a valid configuration"}
+}
+
+// findYAMLNode returns the node at the path, or the deepest one found.
+func findYAMLNode(n *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		var next *yaml.Node
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if strings.EqualFold(n.Content[i].Value, key) {
+					next = n.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if idx, err := strconv.Atoi(key); err == nil && idx >= 0 && idx < len(n.Content) {
+				next = n.Content[idx]
+			}
+		}
+		if next == nil {
+			return n
+		}
+		n = next
+	}
+	return n
+}
+
+// yamlNodeToJSON converts the YAML to JSON, so it can be decoded like the
+// JSON config files.
+func yamlNodeToJSON(n *yaml.Node) ([]byte, error) {
+	var value interface{}
+	if err := n.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonCompatible(value))
+}
+
+// jsonCompatible converts the YAML maps with non-string keys, which can't be
+// encoded to JSON.
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonCompatible(item)
+		}
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+	}
+	return value
+}
+
+// yamlConfigFileError returns the YAML parsing error with the position of the
+// problem, which yaml.v3 only has in the message.
+func yamlConfigFileError(path string, err error) error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	var line int
+	if _, scanErr := fmt.Sscanf(msg, "line %d:", &line); scanErr == nil {
+		_, msg, _ = strings.Cut(msg, ": ")
+		return configFileError{path: path, line: line, msg: msg}
+	}
+	return configFileError{path: path, msg: msg}
+}
+
+// offsetPosition returns the line and column of the byte offset in the data.
+func offsetPosition(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = strings.Count(string(before), "\n") + 1
+	column = int(offset) - strings.LastIndex(string(before), "\n")
+	return line, column
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("yaml", func(t *testing.T) {
+		t.Parallel()
+		conf, err := parseConfigFile("k6.yaml", []byte(`
+vus: 10
+duration: 30s
+stages:
+  - duration: 1m
+    target: 5
+thresholds:
+  http_req_duration: ["p(95)<500"]
+out: [json=results.json]
+profiles:
+  ci:
+    vus: 1
+`))
+		require.NoError(t, err)
+		assert.Equal(t, null.IntFrom(10), conf.VUs)
+		assert.Equal(t, types.NullDurationFrom(30*time.Second), conf.Duration)
+		require.Len(t, conf.Stages, 1)
+		assert.Equal(t, types.NullDurationFrom(time.Minute), conf.Stages[0].Duration)
+		assert.Equal(t, null.IntFrom(5), conf.Stages[0].Target)
+		assert.Contains(t, conf.Thresholds, "http_req_duration")
+		assert.Equal(t, []string{"json=results.json"}, conf.Out)
+		assert.Equal(t, null.IntFrom(1), conf.Profiles["ci"].VUs)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		conf, err := parseConfigFile("config.json", []byte(`{"vus": 10, "duration": "30s"}`))
+		require.NoError(t, err)
+		assert.Equal(t, null.IntFrom(10), conf.VUs)
+		assert.Equal(t, types.NullDurationFrom(30*time.Second), conf.Duration)
+	})
+
+	t.Run("templating", func(t *testing.T) {
+		t.Parallel()
+		data := []byte(`
+vars:
+  vus: 10
+  api: https://test.k6.io/api
+x-scenario: &scenario
+  executor: constant-vus
+  vus: ${vus}
+  duration: 1m
+scenarios:
+  browse:
+    <<: *scenario
+    exec: browse
+  api:
+    <<: *scenario
+    tags:
+      region: ${region}
+    matrix:
+      region: [eu, us]
+thresholds:
+  http_req_duration{url:${api}}: ["p(95)<500"]
+`)
+		assert.Empty(t, checkConfigFile("k6.yaml", data))
+		conf, err := parseConfigFile("k6.yaml", data)
+		require.NoError(t, err)
+		require.Len(t, conf.Scenarios, 3)
+		assert.Equal(t, "browse", conf.Scenarios["browse"].GetExec())
+		assert.Equal(t, "constant-vus", conf.Scenarios["api_1"].GetType())
+		assert.Equal(t, map[string]string{"region": "us"}, conf.Scenarios["api_1"].GetTags())
+		assert.Equal(t, null.IntFrom(10), conf.Scenarios["api_0"].(executor.ConstantVUsConfig).VUs)
+		assert.Contains(t, conf.Thresholds, "http_req_duration{url:https://test.k6.io/api}")
+	})
+
+	errorTestCases := []struct {
+		name, path, data, expErr string
+	}{
+		{
+			name:   "yaml syntax",
+			path:   "k6.yml",
+			data:   "vus: 10\nduration: 30s\n  iterations: 5\n",
+			expErr: "k6.yml:3: mapping values are not allowed in this context",
+		},
+		{
+			name:   "yaml type",
+			path:   "k6.yaml",
+			data:   "duration: 30s\ntags:\n  env: staging\n  team: [a, b]\n",
+			expErr: `k6.yaml:4:9: "tags.team" should be of type string, not array`,
+		},
+		{
+			name:   "yaml value",
+			path:   "k6.yaml",
+			data:   "vus: 10\nduration: forever\n",
+			expErr: `k6.yaml:2:11: invalid value of "duration": time: invalid duration "forever"`,
+		},
+		{
+			name:   "json syntax",
+			path:   "config.json",
+			data:   "{\n  \"vus\": 10,\n}",
+			expErr: "config.json:3:2: invalid character '}' looking for beginning of object key string",
+		},
+		{
+			name:   "json type",
+			path:   "config.json",
+			data:   "{\n  \"vus\": 10,\n  \"out\": \"json\"\n}",
+			expErr: `config.json:3:10: "out" should be of type []string, not string`,
+		},
+		{
+			name:   "toml",
+			path:   "k6.toml",
+			data:   "vus = 10\n",
+			expErr: "k6.toml: TOML config files aren't supported, use JSON or YAML",
+		},
+	}
+	for _, tc := range errorTestCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parseConfigFile(tc.path, []byte(tc.data))
+			assert.EqualError(t, err, tc.expErr)
+		})
+	}
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	t.Parallel()
+
+	errs := checkConfigFile("k6.yaml", []byte(`
+vus: 10
+vu: 5
+Duration: 30s
+rateLimits:
+  - host: test.k6.io
+    rsp: 10
+scenarios:
+  main:
+    executor: shared-iterations
+profiles:
+  ci:
+    iteration: 1
+`))
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], `k6.yaml:3:1: unknown option "vu"`)
+	assert.EqualError(t, errs[1], `k6.yaml:7:5: unknown option "rateLimits.0.rsp"`)
+	assert.EqualError(t, errs[2], `k6.yaml:13:5: unknown option "profiles.ci.iteration"`)
+
+	// all of the options that k6 writes are known
+	data, err := json.Marshal(Config{Profiles: map[string]Config{"ci": {}}})
+	require.NoError(t, err)
+	assert.Empty(t, checkConfigFile("config.json", data))
+}
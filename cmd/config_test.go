@@ -6,8 +6,10 @@ import (
 
 	"github.com/mstoykov/envconfig"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/guregu/null.v3"
 
+	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/errext"
 	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/lib"
@@ -140,6 +142,62 @@ func TestConfigApply(t *testing.T) {
 	})
 }
 
+func TestApplyProfile(t *testing.T) {
+	t.Parallel()
+
+	conf := Config{
+		Options: lib.Options{VUs: null.IntFrom(1), Iterations: null.IntFrom(10)},
+		Out:     []string{"json"},
+		Profiles: map[string]Config{
+			"staging": {Options: lib.Options{VUs: null.IntFrom(5)}},
+			"nested":  {Profiles: map[string]Config{"other": {}}},
+		},
+	}
+
+	result, err := applyProfile(conf, "", "config.json")
+	require.NoError(t, err)
+	assert.Equal(t, null.IntFrom(1), result.VUs)
+	assert.Nil(t, result.Profiles)
+
+	result, err = applyProfile(conf, "staging", "config.json")
+	require.NoError(t, err)
+	assert.Equal(t, null.IntFrom(5), result.VUs)
+	assert.Equal(t, null.IntFrom(10), result.Iterations)
+	assert.Equal(t, []string{"json"}, result.Out)
+	assert.Nil(t, result.Profiles)
+
+	_, err = applyProfile(conf, "production", "config.json")
+	assert.EqualError(t, err, `the profile "production" isn't in the config file "config.json"`)
+	_, err = applyProfile(conf, "nested", "config.json")
+	assert.EqualError(t, err, `the profile "nested" in the config file "config.json" can't have profiles`)
+}
+
+func TestParseEnvFile(t *testing.T) {
+	t.Parallel()
+
+	env, err := state.ParseEnvFile([]byte(`
+# the target
+BASE_URL=https://test.k6.io # the staging one
+export K6_VUS=10
+QUOTED="multi\nline # not a comment"
+SINGLE='$NOT_EXPANDED'
+EMPTY=
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"BASE_URL": "https://test.k6.io",
+		"K6_VUS":   "10",
+		"QUOTED":   "multi\nline # not a comment",
+		"SINGLE":   "$NOT_EXPANDED",
+		"EMPTY":    "",
+	}, env)
+
+	_, err = state.ParseEnvFile([]byte("VALID=1\nINVALID\n"))
+	assert.EqualError(t, err, "line 2 isn't in the form KEY=value")
+	_, err = state.ParseEnvFile([]byte(`QUOTED="\q"`))
+	assert.ErrorContains(t, err, "line 1 has an invalid quoted value")
+}
+
 func TestDeriveAndValidateConfig(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/converter/openapi"
+	"go.k6.io/k6/converter/postman"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// convertCmd contains the flags shared by all of the `k6 convert` subcommands
+type convertCmd struct {
+	gs             *state.GlobalState
+	output         string
+	overwriteFiles bool
+}
+
+func (c *convertCmd) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringVarP(&c.output, "output", "o", "", "file to write the generated script to, instead of stdout")
+	flags.BoolVarP(&c.overwriteFiles, "force", "f", false, "Overwrite existing files")
+
+	return flags
+}
+
+// writeScript writes the generated script to the output file, or to stdout if
+// no output file was specified.
+func (c *convertCmd) writeScript(script string) error {
+	if c.output == "" {
+		_, err := fmt.Fprint(c.gs.Stdout, script)
+		return err
+	}
+
+	exists, err := fsext.Exists(c.gs.FS, c.output)
+	if err != nil {
+		return err
+	}
+	if exists && !c.overwriteFiles {
+		return fmt.Errorf("%s already exists, please use the `--force` flag if you want overwrite it", c.output)
+	}
+	return fsext.WriteFile(c.gs.FS, c.output, []byte(script), 0o644)
+}
+
+func getCmdConvertOpenAPI(gs *state.GlobalState) *cobra.Command {
+	c := &convertCmd{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Generate a script from an OpenAPI specification and print it to stdout
+  {{.}} convert openapi spec.yaml
+
+  # Generate a script from a Swagger specification and save it in test.js
+  {{.}} convert openapi swagger.json -o test.js`[1:])
+
+	openAPICmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Convert an OpenAPI or Swagger specification to a k6 script",
+		Long: `Convert an OpenAPI 3 or Swagger 2 specification to a k6 script.
+
+The generated script contains a request builder function for every operation,
+that uses the examples in the specification for the parameters and payloads,
+and a scenario and a request duration threshold for every operation.`,
+		Example: exampleText,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			data, err := fsext.ReadFile(gs.FS, args[0])
+			if err != nil {
+				return err
+			}
+			spec, err := openapi.Parse(data)
+			if err != nil {
+				return err
+			}
+			script, err := openapi.Convert(spec)
+			if err != nil {
+				return err
+			}
+			return c.writeScript(script)
+		},
+	}
+	openAPICmd.Flags().AddFlagSet(c.flagSet())
+
+	return openAPICmd
+}
+
+func getCmdConvertPostman(gs *state.GlobalState) *cobra.Command {
+	c := &convertCmd{gs: gs}
+	var environment string
+
+	exampleText := getExampleText(gs, `
+  # Generate a script from a Postman collection and print it to stdout
+  {{.}} convert postman collection.json
+
+  # Generate a script from a Postman collection and environment and save it in test.js
+  {{.}} convert postman collection.json -e staging.postman_environment.json -o test.js`[1:])
+
+	postmanCmd := &cobra.Command{
+		Use:   "postman",
+		Short: "Convert a Postman collection to a k6 script",
+		Long: `Convert a Postman v2.0 or v2.1 collection to a k6 script.
+
+The folders of the collection are converted to groups and the variables are
+initialized from the collection and the environment, if one is specified. They
+can be overridden with environment variables when running the script. The
+variable calls in pre-request scripts are translated, while the scripts that
+use other Postman APIs and the test scripts are kept as comments, except for
+the response status assertions that are converted to checks.`,
+		Example: exampleText,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			data, err := fsext.ReadFile(gs.FS, args[0])
+			if err != nil {
+				return err
+			}
+			collection, err := postman.Parse(data)
+			if err != nil {
+				return err
+			}
+			var env *postman.Environment
+			if environment != "" {
+				data, err := fsext.ReadFile(gs.FS, environment)
+				if err != nil {
+					return err
+				}
+				if env, err = postman.ParseEnvironment(data); err != nil {
+					return err
+				}
+			}
+			script, err := postman.Convert(collection, env)
+			if err != nil {
+				return err
+			}
+			return c.writeScript(script)
+		},
+	}
+	postmanCmd.Flags().AddFlagSet(c.flagSet())
+	postmanCmd.Flags().StringVarP(&environment, "environment", "e", "", "exported Postman environment to use")
+
+	return postmanCmd
+}
+
+// getCmdConvert returns the `k6 convert` sub-command, together with its children.
+func getCmdConvert(gs *state.GlobalState) *cobra.Command {
+	convertCmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert API definitions to k6 scripts",
+		Long: `Convert API definitions to k6 scripts.
+
+Use the subcommands to convert the different formats.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+	convertCmd.AddCommand(
+		getCmdConvertOpenAPI(gs),
+		getCmdConvertPostman(gs),
+	)
+
+	return convertCmd
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/cmd/tests"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.0
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses:
+        '200':
+          description: ok
+`
+
+func TestConvertOpenAPICmd(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stdout", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, "spec.yaml", []byte(testOpenAPISpec), 0o644))
+		ts.CmdArgs = []string{"k6", "convert", "openapi", "spec.yaml"}
+
+		newRootCommand(ts.GlobalState).execute()
+
+		assert.Contains(t, ts.Stdout.String(), "export function listItems() {")
+	})
+
+	t.Run("file", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, "spec.yaml", []byte(testOpenAPISpec), 0o644))
+		require.NoError(t, fsext.WriteFile(ts.FS, "test.js", []byte("existing"), 0o644))
+		ts.CmdArgs = []string{"k6", "convert", "openapi", "spec.yaml", "-o", "test.js"}
+		ts.ExpectedExitCode = -1
+
+		newRootCommand(ts.GlobalState).execute()
+
+		data, err := fsext.ReadFile(ts.FS, "test.js")
+		require.NoError(t, err)
+		assert.Equal(t, "existing", string(data))
+		assert.Contains(t, ts.Stderr.String(), "already exists")
+
+		ts = tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, "spec.yaml", []byte(testOpenAPISpec), 0o644))
+		ts.CmdArgs = []string{"k6", "convert", "openapi", "spec.yaml", "-o", "test.js"}
+
+		newRootCommand(ts.GlobalState).execute()
+
+		data, err = fsext.ReadFile(ts.FS, "test.js")
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "export function listItems() {")
+	})
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/execution/distributed"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/metrics/engine"
+	"go.k6.io/k6/output"
+)
+
+// cmdCoordinator handles the `k6 coordinator` sub-command
+type cmdCoordinator struct {
+	gs            *state.GlobalState
+	gRPCAddress   string
+	instanceCount int
+	aggregateOnly bool
+	rebalance     bool
+	tlsCert       string
+	tlsKey        string
+	token         string
+}
+
+// serverOptions returns the options of the gRPC server, with TLS and the token
+// of the agents, if they are set.
+func (c *cmdCoordinator) serverOptions(cmd *cobra.Command) ([]grpc.ServerOption, error) {
+	token := c.token
+	if !cmd.Flags().Changed("token") {
+		token = c.gs.Env[distributedTokenEnv]
+	}
+	if token == "" && !c.aggregateOnly {
+		c.gs.Logger.Warnf("Any client that connects to the coordinator receives the test, with its scripts and "+
+			"environment variables, use --token or %s to only accept the agents with the token", distributedTokenEnv)
+	}
+
+	if c.tlsCert == "" && c.tlsKey == "" {
+		c.gs.Logger.Warn("The connections with the agents aren't encrypted, use --tls-cert and --tls-key to enable TLS")
+		return distributed.ServerOptions(nil, token), nil
+	}
+	if c.tlsCert == "" || c.tlsKey == "" {
+		return nil, errors.New("both --tls-cert and --tls-key are required for TLS")
+	}
+	certPEM, err := fsext.ReadFile(c.gs.FS, c.tlsCert)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the TLS certificate: %w", err)
+	}
+	keyPEM, err := fsext.ReadFile(c.gs.FS, c.tlsKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the TLS key: %w", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate or key: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	return distributed.ServerOptions(tlsConfig, token), nil
+}
+
+//nolint:funlen
+func (c *cmdCoordinator) run(cmd *cobra.Command, args []string) (err error) {
+	test, err := loadAndConfigureLocalTest(c.gs, cmd, args, getPartialConfig)
+	if err != nil {
+		return err
+	}
+	conf := test.derivedConfig
+	testRunState, err := test.buildTestRunState(conf.Options)
+	if err != nil {
+		return err
+	}
+	logger := c.gs.Logger
+
+	metricsEngine, err := engine.NewMetricsEngine(testRunState.Registry, logger)
+	if err != nil {
+		return err
+	}
+	err = metricsEngine.InitSubMetricsAndThresholds(conf.Options, testRunState.RuntimeOptions.NoThresholds.Bool)
+	if err != nil {
+		return err
+	}
+	ingester := metricsEngine.CreateIngester()
+
+	samples := make(chan metrics.SampleContainer, conf.MetricSamplesBufferSize.Int64)
+	var archive *lib.Archive
+	if !c.aggregateOnly {
+		archive = test.initRunner.MakeArchive()
+	}
+	coordinator, err := distributed.NewCoordinatorServer(
+		c.instanceCount, archive, conf.Options, testRunState.Registry, samples, logger,
+	)
+	if err != nil {
+		return err
+	}
+	if c.rebalance {
+		coordinator.EnableRebalancing()
+	}
+
+	if !testRunState.RuntimeOptions.NoSummary.Bool {
+		defer func() {
+			logger.Debug("Generating the end-of-test summary...")
+			summaryResult, hsErr := test.initRunner.HandleSummary(c.gs.Ctx, &lib.Summary{
+				Metrics:              metricsEngine.ObservedMetrics,
+				RootGroup:            testRunState.Runner.GetDefaultGroup(),
+				TestRunDuration:      coordinator.GetCurrentTestRunDuration(),
+				TimeSeries:           metricsEngine.ObservedTimeSeries(),
+				TimeSeriesResolution: metricsEngine.TimeSeriesResolution(),
+				NoColor:              c.gs.Flags.NoColor,
+				UIState: lib.UIState{
+					IsStdOutTTY: c.gs.Stdout.IsTTY,
+					IsStdErrTTY: c.gs.Stderr.IsTTY,
+				},
+			})
+			if hsErr == nil {
+				hsErr = handleSummaryResult(c.gs.FS, c.gs.Stdout, c.gs.Stderr, summaryResult)
+			}
+			if hsErr != nil {
+				logger.WithError(hsErr).Error("failed to handle the end-of-test summary")
+			}
+		}()
+	}
+
+	outputManager := output.NewManager([]output.Output{ingester}, logger, func(err error) {
+		if err != nil {
+			logger.WithError(err).Error("Received error to stop from output")
+		}
+	})
+	waitOutputsFlushed, stopOutputs, err := outputManager.Start(samples)
+	if err != nil {
+		return err
+	}
+	defer func() { stopOutputs(err) }()
+
+	if !testRunState.RuntimeOptions.NoThresholds.Bool {
+		finalizeThresholds := metricsEngine.StartThresholdCalculations(
+			ingester, coordinator.Abort, coordinator.GetCurrentTestRunDuration,
+		)
+		if finalizeThresholds != nil {
+			defer func() {
+				logger.Debug("Finalizing thresholds...")
+				breachedThresholds := finalizeThresholds()
+				if len(breachedThresholds) == 0 {
+					return
+				}
+				tErr := errext.WithAbortReasonIfNone(
+					errext.WithExitCodeIfNone(
+						fmt.Errorf("thresholds on metrics '%s' have been crossed", strings.Join(breachedThresholds, ", ")),
+						exitcodes.ThresholdsHaveFailed,
+					), errext.AbortedByThresholdsAfterTestEnd)
+				if err == nil {
+					err = tErr
+				} else {
+					logger.WithError(tErr).Debug("Crossed thresholds, but test already exited with another error")
+				}
+			}()
+		}
+	}
+
+	defer func() {
+		logger.Debug("Waiting for metrics processing to finish...")
+		close(samples)
+		waitOutputsFlushed()
+	}()
+
+	serverOpts, err := c.serverOptions(cmd)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", c.gRPCAddress)
+	if err != nil {
+		return err
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
+	distributed.RegisterDistributedTestServer(grpcServer, coordinator)
+	go func() {
+		if serr := grpcServer.Serve(listener); serr != nil {
+			logger.WithError(serr).Error("The gRPC server stopped unexpectedly")
+		}
+	}()
+	defer grpcServer.Stop()
+
+	printToStdout(c.gs, fmt.Sprintf(
+		"Waiting for %d agents to connect to %s...\n", c.instanceCount, listener.Addr()))
+
+	// The first signal aborts the test on all instances, a second one stops
+	// the coordinator without waiting for them.
+	stopSignalHandling := handleTestAbortSignals(c.gs, func(sig os.Signal) {
+		coordinator.Abort(errext.WithAbortReasonIfNone(
+			errext.WithExitCodeIfNone(
+				fmt.Errorf("test run was aborted because the coordinator received a '%s' signal", sig),
+				exitcodes.ExternalAbort,
+			), errext.AbortedByUser,
+		))
+	}, nil)
+	defer stopSignalHandling()
+
+	if err = coordinator.Wait(); err != nil {
+		return err
+	}
+	logger.Debug("All agents have finished")
+	return nil
+}
+
+func (c *cmdCoordinator) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringVar(&c.gRPCAddress, "grpc-address", c.gRPCAddress, "address on which the agents can connect")
+	flags.IntVar(&c.instanceCount, "instance-count", c.instanceCount, "number of agents that will run the test")
+	flags.BoolVar(&c.aggregateOnly, "aggregate-only", c.aggregateOnly, "don't distribute the test, only "+
+		"synchronize the instances started with 'k6 run --leader' and evaluate the thresholds on their metrics")
+	flags.BoolVar(&c.rebalance, "rebalance", c.rebalance, "redistribute the execution segments of the agents "+
+		"lost during the test to the remaining ones, instead of failing the test")
+	flags.StringVar(&c.tlsCert, "tls-cert", c.tlsCert, "`path` to the TLS certificate of the coordinator")
+	flags.StringVar(&c.tlsKey, "tls-key", c.tlsKey, "`path` to the TLS key of the coordinator")
+	flags.StringVar(&c.token, "token", c.token, "`token` that the agents need to connect, the "+
+		distributedTokenEnv+" environment variable is used if it isn't set")
+	flags.AddFlagSet(optionFlagSet())
+	flags.AddFlagSet(runtimeOptionFlagSet(false))
+	return flags
+}
+
+func getCmdCoordinator(gs *state.GlobalState) *cobra.Command {
+	c := &cmdCoordinator{
+		gs:            gs,
+		gRPCAddress:   "localhost:6566",
+		instanceCount: 1,
+	}
+
+	exampleText := getExampleText(gs, `
+  # Split the test between 3 agents and wait for them to connect.
+  {{.}} coordinator --instance-count 3 script.js
+
+  # Only accept the agents with the token, over TLS.
+  K6_DISTRIBUTED_TOKEN=secret {{.}} coordinator --tls-cert cert.pem --tls-key key.pem --instance-count 3 script.js
+
+  # On each of the 3 machines that will run the test:
+  {{.}} agent coordinator.example.com:6566
+
+  # Evaluate the thresholds of a segmented test on the metrics of both of its instances.
+  {{.}} coordinator --aggregate-only --instance-count 2 script.js
+  {{.}} run --execution-segment 0:1/2 --execution-segment-sequence 0,1/2,1 --leader coordinator.example.com:6566 script.js
+  {{.}} run --execution-segment 1/2:1 --execution-segment-sequence 0,1/2,1 --leader coordinator.example.com:6566 script.js`[1:])
+
+	coordinatorCmd := &cobra.Command{
+		Use:   "coordinator",
+		Short: "Coordinate a distributed test",
+		Long: `Coordinate a distributed test.
+
+The coordinator splits the test into execution segments, one for each agent that
+connects to it with the "agent" command. It sends the agents the test archive,
+synchronizes them during the test and collects their metrics, so the thresholds
+and the end-of-test summary are calculated on the combined results. If a
+threshold with abortOnFail is crossed, the test is aborted on all agents.
+
+With --rebalance, the test continues if an agent is lost after the start of the
+test: its execution segment is split between the remaining agents, so long
+running tests don't silently continue with a reduced load. The agents run the
+parts they take over from the beginning of their execution plan, so this is
+most accurate for tests with a constant load.
+
+With --aggregate-only, the instances are started with "run --leader" and their
+own options, e.g. with an execution segment each, and the coordinator only
+synchronizes them and evaluates the thresholds.
+
+The test, with its scripts and environment variables, is sent to any agent that
+connects, so the coordinator should require a token, with --token or the
+K6_DISTRIBUTED_TOKEN environment variable, and use TLS with --tls-cert and
+--tls-key, unless it's only reachable from a trusted network.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should either be \"-\", if reading script from stdin, or a path to a script file"),
+		RunE:    c.run,
+	}
+
+	coordinatorCmd.Flags().SortFlags = false
+	coordinatorCmd.Flags().AddFlagSet(c.flagSet())
+
+	return coordinatorCmd
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// applyCustomExitCodes replaces the exit code of the error of the test run with
+// the custom one for its outcome, if there's one. The crossed thresholds use
+// the exit code of the first failed threshold that has one, by metric name,
+// before the one in the exitCodes option. When the load generator was
+// saturated, its exit code takes precedence over the crossed thresholds and
+// the baseline regressions, and a test run without errors fails with it too.
+func applyCustomExitCodes(
+	err error, codes *lib.ExitCodes, thresholds map[string][]metrics.Threshold, saturationWarnings []string,
+) error {
+	if codes == nil {
+		codes = &lib.ExitCodes{}
+	}
+
+	var code exitcodes.ExitCode
+	var ecerr errext.HasExitCode
+	if errors.As(err, &ecerr) {
+		code = ecerr.ExitCode()
+	}
+
+	if len(saturationWarnings) > 0 && codes.Saturated.Valid &&
+		(err == nil || code == exitcodes.ThresholdsHaveFailed || code == exitcodes.BaselineRegression) {
+		if err == nil {
+			err = errors.New("the load generator was saturated, so the results can't be trusted: " +
+				strings.Join(saturationWarnings, "; "))
+		}
+		return errext.WithExitCode(err, exitcodes.ExitCode(codes.Saturated.Int64))
+	}
+
+	var custom null.Int
+	switch code {
+	case exitcodes.ThresholdsHaveFailed:
+		custom = codes.ThresholdsCrossed
+		if thresholdCode, ok := failedThresholdExitCode(thresholds); ok {
+			custom = null.IntFrom(thresholdCode)
+		}
+	case exitcodes.ScriptException:
+		custom = codes.ScriptError
+	case exitcodes.ScriptAborted:
+		custom = codes.ScriptAborted
+	case exitcodes.ExternalAbort:
+		custom = codes.AbortedByUser
+	case exitcodes.BaselineRegression:
+		custom = codes.BaselineRegression
+	}
+	if !custom.Valid {
+		return err
+	}
+	return errext.WithExitCode(err, exitcodes.ExitCode(custom.Int64))
+}
+
+// failedThresholdExitCode returns the exit code of the first failed threshold
+// that has one, by metric name.
+func failedThresholdExitCode(thresholds map[string][]metrics.Threshold) (int64, bool) {
+	names := make([]string, 0, len(thresholds))
+	for name := range thresholds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, t := range thresholds[name] {
+			if t.LastFailed && t.ExitCode.Valid {
+				return t.ExitCode.Int64, true
+			}
+		}
+	}
+	return 0, false
+}
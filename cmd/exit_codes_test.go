@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+)
+
+func TestApplyCustomExitCodes(t *testing.T) {
+	t.Parallel()
+
+	exitCode := func(t *testing.T, err error) exitcodes.ExitCode {
+		var ecerr errext.HasExitCode
+		require.True(t, errors.As(err, &ecerr))
+		return ecerr.ExitCode()
+	}
+	codes := &lib.ExitCodes{Saturated: null.IntFrom(7), ScriptError: null.IntFrom(5)}
+	saturated := []string{"the CPU was busy"}
+	thresholdsErr := errext.WithExitCodeIfNone(errors.New("crossed"), exitcodes.ThresholdsHaveFailed)
+	scriptErr := errext.WithExitCodeIfNone(errors.New("oops"), exitcodes.ScriptException)
+
+	assert.NoError(t, applyCustomExitCodes(nil, codes, nil, nil))
+	assert.NoError(t, applyCustomExitCodes(nil, nil, nil, saturated))
+
+	err := applyCustomExitCodes(nil, codes, nil, saturated)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the CPU was busy")
+	assert.Equal(t, exitcodes.ExitCode(7), exitCode(t, err))
+
+	// the saturation explains the crossed thresholds, not the script errors
+	assert.Equal(t, exitcodes.ExitCode(7), exitCode(t, applyCustomExitCodes(thresholdsErr, codes, nil, saturated)))
+	assert.Equal(t, exitcodes.ExitCode(5), exitCode(t, applyCustomExitCodes(scriptErr, codes, nil, saturated)))
+	assert.Equal(t, exitcodes.ThresholdsHaveFailed, exitCode(t, applyCustomExitCodes(thresholdsErr, codes, nil, nil)))
+}
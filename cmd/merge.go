@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/loader"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/metrics/engine"
+	"go.k6.io/k6/output"
+	"go.k6.io/k6/output/csv"
+	"go.k6.io/k6/output/json"
+)
+
+// mergeBatchSize is how many samples are sent to the outputs at once.
+const mergeBatchSize = 1000
+
+// cmdMerge handles the `k6 merge` sub-command
+type cmdMerge struct {
+	gs *state.GlobalState
+
+	out               []string
+	summaryTrendStats []string
+	summaryTimeUnit   string
+	noThresholds      bool
+	noSummary         bool
+}
+
+//nolint:funlen
+func (c *cmdMerge) run(cmd *cobra.Command, args []string) (err error) {
+	paths, err := c.resultFiles(args)
+	if err != nil {
+		return err
+	}
+	logger := c.gs.Logger
+
+	preInitState := newPreInitState(c.gs, lib.RuntimeOptions{
+		NoThresholds: null.BoolFrom(c.noThresholds),
+		NoSummary:    null.BoolFrom(c.noSummary),
+	})
+	registry := preInitState.Registry
+
+	var samples []metrics.Sample
+	thresholds := make(map[string]metrics.Thresholds)
+	for _, path := range paths {
+		fileThresholds, rerr := c.readResults(path, registry, func(s metrics.Sample) { samples = append(samples, s) })
+		if rerr != nil {
+			return fmt.Errorf("unable to read the results from '%s': %w", path, rerr)
+		}
+		for name, t := range fileThresholds {
+			if _, ok := thresholds[name]; !ok {
+				thresholds[name] = t
+			}
+		}
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("there are no samples in the results")
+	}
+	// the samples of every instance are in order, but not the combined ones
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	testRunDuration := samples[len(samples)-1].Time.Sub(samples[0].Time)
+
+	// the thresholds from the config file have priority over the ones
+	// found in the results
+	diskConf, err := readDiskConfig(c.gs)
+	if err != nil {
+		return err
+	}
+	for name, t := range diskConf.Thresholds {
+		thresholds[name] = t
+	}
+	if !c.noThresholds {
+		for name, t := range thresholds {
+			if err = t.Parse(); err == nil {
+				err = t.Validate(name, registry)
+			}
+			if err != nil {
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+		}
+	}
+	conf := applyDefault(Config{
+		Options: lib.Options{
+			Thresholds:        thresholds,
+			SummaryTrendStats: c.summaryTrendStats,
+			SummaryTimeUnit:   null.NewString(c.summaryTimeUnit, c.summaryTimeUnit != ""),
+		},
+		Out: c.out,
+	})
+	if err = validateConfig(conf, nil); err != nil {
+		return err
+	}
+
+	test := &loadedTest{
+		sourceRootPath: "merge.js",
+		source: &loader.SourceData{
+			Data: []byte("export default function () {}"),
+			URL:  &url.URL{Scheme: "file", Path: "/merge.js"},
+		},
+		fs:           c.gs.FS,
+		fileSystems:  map[string]fsext.Fs{"file": fsext.NewMemMapFs()},
+		preInitState: preInitState,
+	}
+	if err = test.initializeFirstRunner(c.gs); err != nil {
+		return err
+	}
+	configuredTest := &loadedAndConfiguredTest{
+		loadedTest:         test,
+		consolidatedConfig: conf,
+		derivedConfig:      conf,
+	}
+	if err = test.initRunner.SetOptions(conf.Options); err != nil {
+		return err
+	}
+
+	metricsEngine, err := engine.NewMetricsEngine(registry, logger)
+	if err != nil {
+		return err
+	}
+	err = metricsEngine.InitSubMetricsAndThresholds(conf.Options, c.noThresholds)
+	if err != nil {
+		return err
+	}
+	ingester := metricsEngine.CreateIngester()
+
+	outputs, err := createOutputs(c.gs, configuredTest, nil)
+	if err != nil {
+		return err
+	}
+	outputs = append(outputs, ingester)
+	outputManager := output.NewManager(outputs, logger, func(err error) {
+		if err != nil {
+			logger.WithError(err).Error("Received error to stop from output")
+		}
+	})
+	outputManager.KeepAllSamples()
+	samplesChan := make(chan metrics.SampleContainer, conf.MetricSamplesBufferSize.Int64)
+	waitOutputsFlushed, stopOutputs, err := outputManager.Start(samplesChan)
+	if err != nil {
+		return err
+	}
+	defer func() { stopOutputs(err) }()
+
+	for start := 0; start < len(samples); start += mergeBatchSize {
+		end := start + mergeBatchSize
+		if end > len(samples) {
+			end = len(samples)
+		}
+		samplesChan <- metrics.Samples(samples[start:end])
+	}
+	close(samplesChan)
+	waitOutputsFlushed()
+
+	var breachedThresholds []string
+	if !c.noThresholds {
+		finalizeThresholds := metricsEngine.StartThresholdCalculations(
+			ingester, func(error) {}, func() time.Duration { return testRunDuration },
+		)
+		if finalizeThresholds != nil {
+			breachedThresholds = finalizeThresholds()
+		}
+	}
+
+	if !c.noSummary {
+		summaryResult, hsErr := test.initRunner.HandleSummary(c.gs.Ctx, &lib.Summary{
+			Metrics:              metricsEngine.ObservedMetrics,
+			RootGroup:            rebuildGroups(samples, logger),
+			TestRunDuration:      testRunDuration,
+			TimeSeries:           metricsEngine.ObservedTimeSeries(),
+			TimeSeriesResolution: metricsEngine.TimeSeriesResolution(),
+			NoColor:              c.gs.Flags.NoColor,
+			UIState: lib.UIState{
+				IsStdOutTTY: c.gs.Stdout.IsTTY,
+				IsStdErrTTY: c.gs.Stderr.IsTTY,
+			},
+		})
+		if hsErr == nil {
+			hsErr = handleSummaryResult(c.gs.FS, c.gs.Stdout, c.gs.Stderr, summaryResult)
+		}
+		if hsErr != nil {
+			logger.WithError(hsErr).Error("failed to handle the end-of-test summary")
+		}
+	}
+
+	if len(breachedThresholds) > 0 {
+		return errext.WithExitCodeIfNone(
+			fmt.Errorf("thresholds on metrics '%s' have been crossed", strings.Join(breachedThresholds, ", ")),
+			exitcodes.ThresholdsHaveFailed,
+		)
+	}
+	return nil
+}
+
+// resultFiles returns the paths of the result files, expanding the patterns
+// that the shell didn't expand. The JSON results are first, since the CSV
+// ones need the metric types from them, for the non built-in metrics.
+func (c *cmdMerge) resultFiles(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		matches, err := fsext.Glob(c.gs.FS, arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no results match '%s'", arg)
+		}
+		paths = append(paths, matches...)
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		return resultFormat(paths[i]) == "json" && resultFormat(paths[j]) != "json"
+	})
+	return paths, nil
+}
+
+// resultFormat returns the format of a result file from its extension.
+func resultFormat(path string) string {
+	return strings.TrimPrefix(filepath.Ext(strings.TrimSuffix(path, ".gz")), ".")
+}
+
+func (c *cmdMerge) readResults(
+	path string, registry *metrics.Registry, callback func(metrics.Sample),
+) (map[string]metrics.Thresholds, error) {
+	f, err := c.gs.FS.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gzr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = gzr.Close() }()
+		r = gzr
+	}
+
+	switch format := resultFormat(path); format {
+	case "json":
+		return json.ReadResults(r, registry, callback)
+	case "csv":
+		unknown, err := csv.ReadResults(r, registry, callback)
+		if len(unknown) > 0 {
+			c.gs.Logger.Warnf("The samples of the metrics '%s' in '%s' were skipped, since their types are unknown, "+
+				"merge them together with JSON results that contain them", strings.Join(unknown, "', '"), path)
+		}
+		return nil, err
+	default:
+		return nil, fmt.Errorf("unsupported format '%s', only the results of the json and csv outputs can be merged",
+			format)
+	}
+}
+
+// rebuildGroups recreates the groups and the checks of the test from the
+// samples of the checks metric, so they are shown in the end-of-test summary.
+func rebuildGroups(samples []metrics.Sample, logger logrus.FieldLogger) *lib.Group {
+	root, _ := lib.NewGroup("", nil)
+	for _, s := range samples {
+		if s.Metric.Name != metrics.ChecksName {
+			continue
+		}
+		name, ok := s.Tags.Get(metrics.TagCheck.String())
+		if !ok {
+			continue
+		}
+		group := root
+		path, _ := s.Tags.Get(metrics.TagGroup.String())
+		for _, groupName := range strings.Split(path, lib.GroupSeparator)[1:] {
+			var err error
+			if group, err = group.Group(groupName); err != nil {
+				break
+			}
+		}
+		check, err := group.Check(name)
+		if err != nil {
+			logger.Warnf("Unable to show the check '%s' in the summary: %s", name, err)
+			continue
+		}
+		if s.Value != 0 {
+			check.Passes++
+		} else {
+			check.Fails++
+		}
+	}
+	return root
+}
+
+func (c *cmdMerge) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringArrayVarP(&c.out, "out", "o", c.out, "`uri` for an external metrics database or a file for the merged results")
+	flags.StringSliceVar(&c.summaryTrendStats, "summary-trend-stats", c.summaryTrendStats,
+		"define `stats` for trend metrics (response times), one or more as 'avg,p(95),...'")
+	flags.StringVar(&c.summaryTimeUnit, "summary-time-unit", c.summaryTimeUnit,
+		"define the time unit used to display the trend stats. Possible units are: 's', 'ms' and 'us'")
+	flags.BoolVar(&c.noThresholds, "no-thresholds", c.noThresholds, "don't evaluate the thresholds")
+	flags.BoolVar(&c.noSummary, "no-summary", c.noSummary, "don't show the summary of the merged results")
+	return flags
+}
+
+func getCmdMerge(gs *state.GlobalState) *cobra.Command {
+	c := &cmdMerge{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Merge the results of the instances of a segmented test and show the combined summary.
+  {{.}} merge results-*.json
+
+  # Also write the merged results to a single file.
+  {{.}} merge -o json=combined.json results-1.json results-2.json.gz
+
+  # Merge CSV results, with the custom metrics types from the JSON results of one of the instances.
+  {{.}} merge results-1.json results-2.csv results-3.csv`[1:])
+
+	mergeCmd := &cobra.Command{
+		Use:   "merge",
+		Short: "Merge the results of multiple instances",
+		Long: `Merge the results of multiple instances.
+
+The results of the json and csv outputs, e.g. of the instances of a segmented
+test, are combined and sent to the given outputs. The percentiles in the
+end-of-test summary are calculated again from all the samples, and the
+thresholds are evaluated on them. The thresholds saved in the JSON results are
+used, unless the options in the config file specify other ones.`,
+		Example: exampleText,
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    c.run,
+	}
+
+	mergeCmd.Flags().SortFlags = false
+	mergeCmd.Flags().AddFlagSet(c.flagSet())
+
+	return mergeCmd
+}
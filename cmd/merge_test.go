@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/cmd/tests"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const (
+	testMergeJSONResults = `{"type":"Metric","data":{"name":"my_trend","type":"trend","contains":"time","thresholds":["p(50)<15"],"submetrics":null},"metric":"my_trend"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:00Z","value":10,"tags":{"scenario":"default"}},"metric":"my_trend"}
+{"type":"Metric","data":{"name":"checks","type":"rate","contains":"default","thresholds":[],"submetrics":null},"metric":"checks"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:01Z","value":1,"tags":{"check":"is ok","group":"::my group"}},"metric":"checks"}
+`
+	testMergeCSVResults = "metric_name,timestamp,metric_value,check,group,extra_tags,metadata\n" +
+		"my_trend,1704067202,20.000000,,,scenario=default,\n" +
+		"checks,1704067203,0.000000,is ok,::my group,,\n" +
+		"unknown,1704067203,1.000000,,,,\n"
+)
+
+func TestMergeCmd(t *testing.T) {
+	t.Parallel()
+
+	t.Run("thresholds passed", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, "results-1.json", []byte(testMergeJSONResults), 0o644))
+		ts.CmdArgs = []string{"k6", "merge", "-o", "json=combined.json", "results-*.json"}
+
+		newRootCommand(ts.GlobalState).execute()
+
+		stdout := ts.Stdout.String()
+		assert.Contains(t, stdout, "✓ my_trend")
+		assert.Contains(t, stdout, "my group")
+		assert.Contains(t, stdout, "✓ is ok")
+		data, err := fsext.ReadFile(ts.FS, "combined.json")
+		require.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(data), `"type":"Point"`))
+	})
+
+	t.Run("thresholds crossed", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, "results-2.csv", []byte(testMergeCSVResults), 0o644))
+		require.NoError(t, fsext.WriteFile(ts.FS, "results-1.json", []byte(testMergeJSONResults), 0o644))
+		// the JSON results are read first, so the metric types are known
+		ts.CmdArgs = []string{"k6", "merge", "results-2.csv", "results-1.json"}
+		ts.ExpectedExitCode = int(exitcodes.ThresholdsHaveFailed)
+
+		newRootCommand(ts.GlobalState).execute()
+
+		stdout := ts.Stdout.String()
+		assert.Contains(t, stdout, "✗ my_trend")
+		assert.Contains(t, stdout, "med=15")
+		assert.Contains(t, stdout, "50% — ✓ 1 / ✗ 1")
+		assert.Contains(t, ts.Stderr.String(), "The samples of the metrics 'unknown'")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, "results.txt", []byte("results"), 0o644))
+		ts.CmdArgs = []string{"k6", "merge", "results.txt"}
+		ts.ExpectedExitCode = -1
+
+		newRootCommand(ts.GlobalState).execute()
+
+		assert.Contains(t, ts.Stderr.String(), "unsupported format 'txt'")
+	})
+}
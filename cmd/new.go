@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"embed"
 	"fmt"
+	"io/fs"
 	"path"
+	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/fatih/color"
@@ -12,118 +16,137 @@ import (
 	"go.k6.io/k6/lib/fsext"
 )
 
-const defaultNewScriptName = "script.js"
+const (
+	defaultNewScriptName   = "script.js"
+	defaultNewTemplateName = "minimal"
+)
+
+//go:embed newtemplates
+var newTemplatesFS embed.FS
+
+// newTemplate is a template of `k6 new`, its main script is created in the
+// given path and the rest of its files are created next to it.
+type newTemplate struct {
+	name        string
+	description string
+	scriptName  string // the main script in the template's directory
+	buildDir    string // where the script is bundled, if it has to be
+}
 
 //nolint:gochecknoglobals
-var defaultNewScriptTemplate = template.Must(template.New("new").Parse(`import http from 'k6/http';
-import { sleep } from 'k6';
-
-export const options = {
-  // A number specifying the number of VUs to run concurrently.
-  vus: 10,
-  // A string specifying the total duration of the test run.
-  duration: '30s',
-
-  // The following section contains configuration options for execution of this
-  // test script in Grafana Cloud.
-  //
-  // See https://grafana.com/docs/grafana-cloud/k6/get-started/run-cloud-tests-from-the-cli/
-  // to learn about authoring and running k6 test scripts in Grafana k6 Cloud.
-  //
-  // cloud: {
-  //   // The ID of the project to which the test is assigned in the k6 Cloud UI.
-  //   // By default tests are executed in default project.
-  //   projectID: "",
-  //   // The name of the test in the k6 Cloud UI.
-  //   // Test runs with the same name will be grouped.
-  //   name: "{{ .ScriptName }}"
-  // },
-
-  // Uncomment this section to enable the use of Browser API in your tests.
-  //
-  // See https://grafana.com/docs/k6/latest/using-k6-browser/running-browser-tests/ to learn more
-  // about using Browser API in your test scripts.
-  //
-  // scenarios: {
-  //   // The scenario name appears in the result summary, tags, and so on.
-  //   // You can give the scenario any name, as long as each name in the script is unique.
-  //   ui: {
-  //     // Executor is a mandatory parameter for browser-based tests.
-  //     // Shared iterations in this case tells k6 to reuse VUs to execute iterations.
-  //     //
-  //     // See https://grafana.com/docs/k6/latest/using-k6/scenarios/executors/ for other executor types.
-  //     executor: 'shared-iterations',
-  //     options: {
-  //       browser: {
-  //         // This is a mandatory parameter that instructs k6 to launch and
-  //         // connect to a chromium-based browser, and use it to run UI-based
-  //         // tests.
-  //         type: 'chromium',
-  //       },
-  //     },
-  //   },
-  // }
-};
-
-// The function that defines VU logic.
-//
-// See https://grafana.com/docs/k6/latest/examples/get-started-with-k6/ to learn more
-// about authoring k6 scripts.
+var newTemplates = []newTemplate{
+	{name: "minimal", description: "a single script with the basic options", scriptName: defaultNewScriptName},
+	{name: "browser", description: "a browser test, with the thresholds on the web vitals", scriptName: "script.js"},
+	{name: "api", description: "an API suite with multiple scenarios and a data file", scriptName: "script.js"},
+	{name: "typescript", description: "a TypeScript script, bundled with esbuild", scriptName: "script.ts", buildDir: "dist"},
+}
+
+// newCITemplates are the CI snippets that run the new script, by the CI system.
 //
-export default function() {
-  http.get('https://test.k6.io');
-  sleep(1);
+//nolint:gochecknoglobals
+var newCITemplates = map[string]string{
+	"github": ".github/workflows/k6.yml",
+	"gitlab": ".gitlab-ci.yml",
 }
-`))
 
 type initScriptTemplateArgs struct {
-	ScriptName string
+	ScriptName  string
+	ScriptDir   string
+	ProjectName string
+	// BuildScript is where the script is bundled, relative to ScriptDir,
+	// and RunScript is the script that k6 runs, relative to the CWD.
+	BuildScript string
+	RunScript   string
 }
 
 // newScriptCmd represents the `k6 new` command
 type newScriptCmd struct {
 	gs             *state.GlobalState
 	overwriteFiles bool
+	templateName   string
+	ci             string
 }
 
 func (c *newScriptCmd) flagSet() *pflag.FlagSet {
 	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
 	flags.SortFlags = false
 	flags.BoolVarP(&c.overwriteFiles, "force", "f", false, "Overwrite existing files")
+	flags.StringVarP(&c.templateName, "template", "t", defaultNewTemplateName,
+		"the `name` of the template of the new script, one of: "+newTemplateNames())
+	flags.StringVar(&c.ci, "ci", "", "add the snippet that runs the script in the CI `system`, one of: github, gitlab")
 
 	return flags
 }
 
+func newTemplateNames() string {
+	names := make([]string, 0, len(newTemplates))
+	for _, tpl := range newTemplates {
+		names = append(names, tpl.name)
+	}
+	return strings.Join(names, ", ")
+}
+
 func (c *newScriptCmd) run(cmd *cobra.Command, args []string) error { //nolint:revive
-	target := defaultNewScriptName
+	var tpl *newTemplate
+	for i := range newTemplates {
+		if newTemplates[i].name == c.templateName {
+			tpl = &newTemplates[i]
+		}
+	}
+	if tpl == nil {
+		return fmt.Errorf("unknown template '%s', it can be one of: %s", c.templateName, newTemplateNames())
+	}
+
+	target := tpl.scriptName
 	if len(args) > 0 {
 		target = args[0]
 	}
-
-	fileExists, err := fsext.Exists(c.gs.FS, target)
+	files, err := c.templateFiles(tpl, target)
 	if err != nil {
 		return err
 	}
 
-	if fileExists && !c.overwriteFiles {
-		return fmt.Errorf("%s already exists, please use the `--force` flag if you want overwrite it", target)
+	// nothing is written, if any of the files exists
+	for _, file := range files {
+		fileExists, err := fsext.Exists(c.gs.FS, file.path)
+		if err != nil {
+			return err
+		}
+		if fileExists && !c.overwriteFiles {
+			return fmt.Errorf("%s already exists, please use the `--force` flag if you want overwrite it", file.path)
+		}
 	}
 
-	fd, err := c.gs.FS.Create(target)
-	if err != nil {
-		return err
+	scriptName := path.Base(filepath.ToSlash(target))
+	scriptDir := path.Dir(filepath.ToSlash(target))
+	tplArgs := initScriptTemplateArgs{
+		ScriptName:  scriptName,
+		ScriptDir:   scriptDir,
+		ProjectName: strings.ToLower(strings.TrimSuffix(scriptName, path.Ext(scriptName))),
+		RunScript:   filepath.ToSlash(target),
 	}
-	defer func() {
-		_ = fd.Close() // we may think to check the error and log
-	}()
-
-	if err := defaultNewScriptTemplate.Execute(fd, initScriptTemplateArgs{
-		ScriptName: path.Base(target),
-	}); err != nil {
-		return err
+	if tpl.buildDir != "" {
+		tplArgs.BuildScript = path.Join(tpl.buildDir, strings.TrimSuffix(scriptName, path.Ext(scriptName))+".js")
+		tplArgs.RunScript = path.Join(scriptDir, tplArgs.BuildScript)
+	}
+	for _, file := range files {
+		if err := c.writeTemplateFile(file, tplArgs); err != nil {
+			return err
+		}
 	}
 
 	valueColor := getColor(c.gs.Flags.NoColor || !c.gs.Stdout.IsTTY, color.Bold)
+	if tpl.buildDir != "" {
+		printToStdout(c.gs, fmt.Sprintf(
+			"Initialized a new k6 test script in %s. You can now bundle it by running `npm install && npm run build` "+
+				"in %s and execute it by running `%s run %s`.\n",
+			valueColor.Sprint(target),
+			scriptDir,
+			c.gs.BinaryName,
+			tplArgs.RunScript,
+		))
+		return nil
+	}
 	printToStdout(c.gs, fmt.Sprintf(
 		"Initialized a new k6 test script in %s. You can now execute it by running `%s run %s`.\n",
 		valueColor.Sprint(target),
@@ -134,6 +157,65 @@ func (c *newScriptCmd) run(cmd *cobra.Command, args []string) error { //nolint:r
 	return nil
 }
 
+type newTemplateFile struct {
+	path     string // where it's written
+	template string // in newTemplatesFS
+}
+
+// templateFiles returns the files of the template, with the main script in the
+// target path, the other files next to it and the CI snippet in the CWD.
+func (c *newScriptCmd) templateFiles(tpl *newTemplate, target string) ([]newTemplateFile, error) {
+	var files []newTemplateFile
+	root := path.Join("newtemplates", tpl.name)
+	err := fs.WalkDir(newTemplatesFS, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel := strings.TrimPrefix(name, root+"/")
+		file := newTemplateFile{path: filepath.Join(filepath.Dir(target), filepath.FromSlash(rel)), template: name}
+		if rel == tpl.scriptName {
+			file.path = target
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if c.ci != "" {
+		ciPath, ok := newCITemplates[c.ci]
+		if !ok {
+			return nil, fmt.Errorf("unknown CI system '%s', it can be one of: github, gitlab", c.ci)
+		}
+		files = append(files, newTemplateFile{
+			path:     filepath.FromSlash(ciPath),
+			template: path.Join("newtemplates", "ci", c.ci+".yml"),
+		})
+	}
+	return files, nil
+}
+
+func (c *newScriptCmd) writeTemplateFile(file newTemplateFile, args initScriptTemplateArgs) error {
+	tpl, err := template.ParseFS(newTemplatesFS, file.template)
+	if err != nil {
+		return err
+	}
+	if err = c.gs.FS.MkdirAll(filepath.Dir(file.path), 0o755); err != nil {
+		return err
+	}
+
+	fd, err := c.gs.FS.Create(file.path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fd.Close() // we may think to check the error and log
+	}()
+
+	return tpl.Execute(fd, args)
+}
+
 func getCmdNewScript(gs *state.GlobalState) *cobra.Command {
 	c := &newScriptCmd{gs: gs}
 
@@ -145,16 +227,30 @@ func getCmdNewScript(gs *state.GlobalState) *cobra.Command {
   {{.}} new test.js
 
   # Overwrite existing test.js with a minimal k6 script
-  {{.}} new -f test.js`[1:])
+  {{.}} new -f test.js
+
+  # Create an API suite in the tests directory, with a GitHub Actions workflow that runs it
+  {{.}} new --template api --ci github tests/api.js`[1:])
+
+	var templatesText strings.Builder
+	for _, tpl := range newTemplates {
+		fmt.Fprintf(&templatesText, "\n  %-12s %s", tpl.name, tpl.description)
+	}
 
 	initCmd := &cobra.Command{
-		Use:   "new",
+		Use:   "new [file]",
 		Short: "Create and initialize a new k6 script",
 		Long: `Create and initialize a new k6 script.
 
-This command will create a minimal k6 script in the current directory and
-store it in the file specified by the first argument. If no argument is
-provided, the script will be stored in script.js.
+This command will create a k6 script from a template in the current directory
+and store it in the file specified by the first argument. If no argument is
+provided, the script will be stored in script.js (script.ts for TypeScript).
+The other files of the template, like the data files, are created next to it.
+
+The available templates are:` + templatesText.String() + `
+
+With --ci, a snippet that runs the script in the CI system is created in the
+current directory too.
 
 This command will not overwrite existing files.`,
 		Example: exampleText,
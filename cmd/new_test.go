@@ -95,3 +95,94 @@ func TestNewScriptCmd_FileExists_Overwrite(t *testing.T) {
 	assert.Contains(t, string(data), "export const options = {")
 	assert.Contains(t, string(data), "export default function() {")
 }
+
+func TestNewScriptCmd_Templates(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		expectedFiles map[string]string // path -> expected content
+	}{
+		{
+			name: "browser",
+			args: []string{"--template", "browser"},
+			expectedFiles: map[string]string{
+				"script.js": "import { browser } from 'k6/experimental/browser';",
+			},
+		},
+		{
+			name: "api suite with data files",
+			args: []string{"-t", "api", "tests/api.js"},
+			expectedFiles: map[string]string{
+				"tests/api.js":          "open('./data/users.json')",
+				"tests/data/users.json": `"username": "test-user-1@example.com"`,
+			},
+		},
+		{
+			name: "typescript with ci",
+			args: []string{"-t", "typescript", "--ci", "github"},
+			expectedFiles: map[string]string{
+				"script.ts":                "export const options: Options = {",
+				"package.json":             "--outfile=dist/script.js",
+				"tsconfig.json":            `"include": ["script.ts"]`,
+				".github/workflows/k6.yml": "run: k6 run dist/script.js",
+			},
+		},
+		{
+			name: "gitlab ci",
+			args: []string{"--ci", "gitlab", "tests/load.js"},
+			expectedFiles: map[string]string{
+				"tests/load.js":  "export default function() {",
+				".gitlab-ci.yml": "k6 run tests/load.js",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := tests.NewGlobalTestState(t)
+			ts.CmdArgs = append([]string{"k6", "new"}, testCase.args...)
+
+			newRootCommand(ts.GlobalState).execute()
+
+			for file, expected := range testCase.expectedFiles {
+				data, err := fsext.ReadFile(ts.FS, file)
+				require.NoError(t, err)
+				assert.Contains(t, string(data), expected)
+			}
+		})
+	}
+}
+
+func TestNewScriptCmd_Templates_NoPartialWrite(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, "data/users.json", []byte("untouched"), 0o644))
+
+	ts.CmdArgs = []string{"k6", "new", "--template", "api"}
+	ts.ExpectedExitCode = -1
+
+	newRootCommand(ts.GlobalState).execute()
+
+	exists, err := fsext.Exists(ts.FS, defaultNewScriptName)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Contains(t, ts.Stderr.String(), "data/users.json already exists")
+}
+
+func TestNewScriptCmd_UnknownTemplate(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "new", "--template", "graphql"}
+	ts.ExpectedExitCode = -1
+
+	newRootCommand(ts.GlobalState).execute()
+
+	assert.Contains(t, ts.Stderr.String(), "unknown template 'graphql'")
+}
@@ -0,0 +1,83 @@
+import http from 'k6/http';
+import { check, group, sleep } from 'k6';
+import { SharedArray } from 'k6/data';
+
+// The test data is loaded once and shared by all of the VUs.
+//
+// See https://grafana.com/docs/k6/latest/javascript-api/k6-data/sharedarray/ to learn more.
+const users = new SharedArray('users', function () {
+  return JSON.parse(open('./data/users.json'));
+});
+
+const BASE_URL = __ENV.BASE_URL || 'https://test-api.k6.io';
+
+export const options = {
+  // Each scenario runs a different part of the API suite, with its own load
+  // profile.
+  //
+  // See https://grafana.com/docs/k6/latest/using-k6/scenarios/ to learn more.
+  scenarios: {
+    // A constant load of anonymous users browsing the public API.
+    browse: {
+      executor: 'constant-vus',
+      exec: 'browse',
+      vus: 5,
+      duration: '30s',
+    },
+    // Logged in users, arriving at an increasing rate.
+    login: {
+      executor: 'ramping-arrival-rate',
+      exec: 'login',
+      startRate: 1,
+      timeUnit: '1s',
+      preAllocatedVUs: 10,
+      stages: [
+        { target: 5, duration: '15s' },
+        { target: 5, duration: '15s' },
+      ],
+    },
+  },
+
+  // The test fails if the thresholds aren't met, e.g. in CI.
+  //
+  // See https://grafana.com/docs/k6/latest/using-k6/thresholds/ to learn more.
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    'http_req_duration{scenario:browse}': ['p(95)<500'],
+    'http_req_duration{scenario:login}': ['p(95)<1000'],
+    checks: ['rate>0.99'],
+  },
+
+  // The following section contains configuration options for execution of this
+  // test script in Grafana Cloud.
+  //
+  // cloud: {
+  //   name: "{{ .ScriptName }}"
+  // },
+};
+
+export function browse() {
+  group('public crocodiles', function () {
+    const res = http.get(`${BASE_URL}/public/crocodiles/`);
+    check(res, {
+      'status is 200': (r) => r.status === 200,
+      'has crocodiles': (r) => r.json().length > 0,
+    });
+  });
+  sleep(1);
+}
+
+export function login() {
+  const user = users[Math.floor(Math.random() * users.length)];
+
+  group('login', function () {
+    const res = http.post(`${BASE_URL}/auth/token/login/`, {
+      username: user.username,
+      password: user.password,
+    });
+    check(res, {
+      'logged in': (r) => r.status === 200 && r.json('access') !== undefined,
+    });
+  });
+  sleep(1);
+}
@@ -0,0 +1,66 @@
+import { browser } from 'k6/experimental/browser';
+import { check } from 'k6';
+
+export const options = {
+  scenarios: {
+    // The scenario name appears in the result summary, tags, and so on.
+    ui: {
+      // Shared iterations tells k6 to reuse VUs to execute iterations.
+      //
+      // See https://grafana.com/docs/k6/latest/using-k6/scenarios/executors/ for other executor types.
+      executor: 'shared-iterations',
+      vus: 1,
+      iterations: 5,
+      options: {
+        browser: {
+          // This is a mandatory parameter that instructs k6 to launch and
+          // connect to a chromium-based browser, and use it to run UI-based
+          // tests.
+          type: 'chromium',
+        },
+      },
+    },
+  },
+
+  // The test fails if the thresholds aren't met, e.g. in CI.
+  //
+  // See https://grafana.com/docs/k6/latest/using-k6/thresholds/ to learn more.
+  thresholds: {
+    // 95% of the pages are loaded in less than 3 seconds.
+    browser_web_vital_lcp: ['p(95)<3000'],
+    checks: ['rate==1.0'],
+  },
+
+  // The following section contains configuration options for execution of this
+  // test script in Grafana Cloud.
+  //
+  // cloud: {
+  //   name: "{{ .ScriptName }}"
+  // },
+};
+
+// The function that defines VU logic, it drives a browser page.
+//
+// See https://grafana.com/docs/k6/latest/using-k6-browser/running-browser-tests/ to learn more
+// about using Browser API in your test scripts.
+export default async function() {
+  const page = browser.newPage();
+
+  try {
+    await page.goto('https://test.k6.io/my_messages.php');
+
+    page.locator('input[name="login"]').type('admin');
+    page.locator('input[name="password"]').type('123');
+
+    await Promise.all([
+      page.waitForNavigation(),
+      page.locator('input[type="submit"]').click(),
+    ]);
+
+    check(page, {
+      'logged in': (p) => p.locator('h2').textContent() === 'Welcome, admin!',
+    });
+  } finally {
+    page.close();
+  }
+}
@@ -0,0 +1,33 @@
+import http from 'k6/http';
+import { check, sleep } from 'k6';
+import { Options } from 'k6/options';
+
+// The script is bundled into {{ .RunScript }} by `npm run build`, k6 runs the
+// bundled JavaScript file.
+export const options: Options = {
+  vus: 10,
+  duration: '30s',
+
+  // The test fails if the thresholds aren't met, e.g. in CI.
+  //
+  // See https://grafana.com/docs/k6/latest/using-k6/thresholds/ to learn more.
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    http_req_duration: ['p(95)<500'],
+  },
+};
+
+interface Crocodile {
+  id: number;
+  name: string;
+}
+
+export default function () {
+  const res = http.get('https://test-api.k6.io/public/crocodiles/');
+  const crocodiles = res.json() as unknown as Crocodile[];
+  check(res, {
+    'status is 200': (r) => r.status === 200,
+    'has crocodiles': () => crocodiles.length > 0,
+  });
+  sleep(1);
+}
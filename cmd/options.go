@@ -36,6 +36,8 @@ func optionFlagSet() *pflag.FlagSet {
 	flags.Int64("max-redirects", 10, "follow at most n redirects")
 	flags.Int64("batch", 20, "max parallel batch reqs")
 	flags.Int64("batch-per-host", 6, "max parallel batch reqs per host")
+	flags.Int64("max-conns", 0, "max simultaneously open connections per VU, 0 means unlimited")
+	flags.Int64("max-conns-per-host", 0, "max simultaneously open connections per VU and host, 0 means unlimited")
 	flags.Int64("rps", 0, "limit requests per second")
 	flags.String("user-agent", fmt.Sprintf("k6/%s (https://k6.io/)", consts.Version), "user agent for http requests")
 	flags.String("http-debug", "", "log all HTTP requests and responses. Excludes body by default. To include body use '--http-debug=full'") //nolint:lll
@@ -56,7 +58,17 @@ func optionFlagSet() *pflag.FlagSet {
 		strings.Join(lib.DefaultSummaryTrendStats, ","),
 	)
 	flags.StringSlice("summary-trend-stats", nil, sumTrendStatsHelp)
+	flags.StringSlice("summary-breakdown-tags", nil, "break down the metrics of the end-of-test summary by "+
+		"these `tags`, e.g. 'scenario,group'")
+	flags.String("scenario-breakdown", lib.ScenarioBreakdownNone, "break down the metrics of the end-of-test "+
+		"summary by scenario, as 'submetrics' under each metric or as 'sections' with the metrics of each scenario")
 	flags.String("summary-time-unit", "", "define the time unit used to display the trend stats. Possible units are: 's', 'ms' and 'us'") //nolint:lll
+	flags.Float64("trend-precision", metrics.DefaultTrendPrecision, "relative error of the percentiles of the trend "+
+		"metrics, 0 keeps all the values for the exact percentiles")
+	flags.Duration("time-series-resolution", 0, "record the time series of the metrics, aggregated in periods "+
+		"of this duration, for the summary, the REST API and the reports")
+	flags.DurationSlice("counter-rate-windows", nil, "show the rates per second of the counters over the latest "+
+		"`windows` in the summary, as rate1m-like stats, e.g. '1m,10s'")
 	// system-tags must have a default value, but we can't specify it here, otherwiese, it will always override others.
 	// set it to nil here, and add the default in applyDefault() instead.
 	systemTagsCliHelpText := fmt.Sprintf(
@@ -65,10 +77,39 @@ func optionFlagSet() *pflag.FlagSet {
 	)
 	flags.StringSlice("system-tags", nil, systemTagsCliHelpText)
 	flags.StringSlice("tag", nil, "add a `tag` to be applied to all samples, as `[name]=[value]`")
+	flags.Int64("cardinality-limit", 10000, "warn when a metric has more than this number of time series "+
+		"(distinct tag sets), 0 disables the limit")
+	flags.StringSlice("cardinality-collapse-tags", nil, "collapse these `tags` in the new time series of "+
+		"the metrics over the cardinality limit, the url tag is replaced by the name tag")
 	flags.String("console-output", "", "redirects the console logging to the provided output file")
 	flags.Bool("discard-response-bodies", false, "Read but don't process or save HTTP response bodies")
+	flags.String("capture-requests", "", "capture the details of the failed requests to this `file`, "+
+		"as a HAR document if its name ends with .har and as JSON lines of HAR entries otherwise")
+	flags.Duration("capture-slower-than", 0, "also capture the successful requests that take at least this long")
+	flags.Int64("capture-max-size", lib.DefaultCaptureMaxSize, "stop capturing the requests when their file "+
+		"reaches this size in bytes")
+	flags.String("har-out", "", "export the requests and their responses to this HAR `file`")
+	flags.Float64("har-sample-rate", 1, "export only this fraction of the requests to the HAR file, "+
+		"the failed ones are always exported")
+	flags.Bool("self-metrics", false, "emit the k6_* metrics about the health of k6 itself, like the event loop "+
+		"lag of the VUs, the GC pauses and the memory usage, to tell when k6 is the bottleneck")
+	flags.Duration("scheduling-delay-budget", lib.DefaultSchedulingDelayBudget, "warn that k6 is saturated when "+
+		"the iterations of the arrival-rate executors start later than this")
+	flags.Bool("auto-calibrate", false, "skip the iterations that start later than the scheduling delay budget, "+
+		"capping the arrival rates to what k6 can generate on time")
+	flags.Int64("max-memory", 0, "abort the test run gracefully when k6 uses more than this many bytes of memory, "+
+		"and delay the new iterations when it gets close to it; 0 disables it")
+	flags.String("test-name", "", "the `name` of the test, added to the metadata of the test run")
+	flags.String("run-id", "", "the `id` of the test run, added to its metadata")
+	flags.String("test-version", "", "the `version` of the test, e.g. the commit of the script, added to the metadata "+
+		"of the test run")
+	flags.String("test-environment", "", "the `environment` that is tested, e.g. staging, added to the metadata of "+
+		"the test run")
+	flags.Bool("http-cache", false, "emulate a browser cache for HTTP responses, separately for each VU")
 	flags.String("local-ips", "", "Client IP Ranges and/or CIDRs from which each VU will be making requests, "+
 		"e.g. '192.168.220.1,192.168.0.10-192.168.0.25', 'fd:1::0/120', etc.")
+	flags.String("local-ips-select", "", "how the local IPs are selected for the connections: 'vu' to assign an IP to "+
+		"each VU (the default), or 'roundRobin' to select them in turn for the new connections")
 	flags.String("dns", types.DefaultDNSConfig().String(), "DNS resolver configuration. Possible ttl values are: 'inf' "+
 		"for a persistent cache, '0' to disable the cache,\nor a positive duration, e.g. '1s', '1m', etc. "+
 		"Milliseconds are assumed if no unit is provided.\n"+
@@ -89,6 +130,8 @@ func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 		MaxRedirects:            getNullInt64(flags, "max-redirects"),
 		Batch:                   getNullInt64(flags, "batch"),
 		BatchPerHost:            getNullInt64(flags, "batch-per-host"),
+		MaxConns:                getNullInt64(flags, "max-conns"),
+		MaxConnsPerHost:         getNullInt64(flags, "max-conns-per-host"),
 		RPS:                     getNullInt64(flags, "rps"),
 		UserAgent:               getNullString(flags, "user-agent"),
 		HTTPDebug:               getNullString(flags, "http-debug"),
@@ -98,6 +141,25 @@ func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 		MinIterationDuration:    getNullDuration(flags, "min-iteration-duration"),
 		Throw:                   getNullBool(flags, "throw"),
 		DiscardResponseBodies:   getNullBool(flags, "discard-response-bodies"),
+		HTTPCache:               getNullBool(flags, "http-cache"),
+		CaptureRequests:         getNullString(flags, "capture-requests"),
+		CaptureSlowerThan:       getNullDuration(flags, "capture-slower-than"),
+		CaptureMaxSize:          getNullInt64(flags, "capture-max-size"),
+		HAROut:                  getNullString(flags, "har-out"),
+		HARSampleRate:           getNullFloat64(flags, "har-sample-rate"),
+		SelfMetrics:             getNullBool(flags, "self-metrics"),
+		SchedulingDelayBudget:   getNullDuration(flags, "scheduling-delay-budget"),
+		AutoCalibrate:           getNullBool(flags, "auto-calibrate"),
+		MaxMemory:               getNullInt64(flags, "max-memory"),
+		TestName:                getNullString(flags, "test-name"),
+		RunID:                   getNullString(flags, "run-id"),
+		TestVersion:             getNullString(flags, "test-version"),
+		TestEnvironment:         getNullString(flags, "test-environment"),
+		TrendPrecision:          getNullFloat64(flags, "trend-precision"),
+		TimeSeriesResolution:    getNullDuration(flags, "time-series-resolution"),
+		ScenarioBreakdown:       getNullString(flags, "scenario-breakdown"),
+		CardinalityLimit:        getNullInt64(flags, "cardinality-limit"),
+		LocalIPsSelect:          getNullString(flags, "local-ips-select"),
 		MetricSamplesBufferSize: null.NewInt(1000, false),
 	}
 
@@ -199,6 +261,23 @@ func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 		opts.SummaryTrendStats = trendStats
 	}
 
+	if flags.Changed("counter-rate-windows") {
+		windows, errWin := flags.GetDurationSlice("counter-rate-windows")
+		if errWin != nil {
+			return opts, errWin
+		}
+		opts.CounterRateWindows = make([]types.Duration, 0, len(windows))
+		for _, w := range windows {
+			opts.CounterRateWindows = append(opts.CounterRateWindows, types.Duration(w))
+		}
+	}
+
+	if flags.Changed("summary-breakdown-tags") {
+		if opts.SummaryBreakdownTags, err = flags.GetStringSlice("summary-breakdown-tags"); err != nil {
+			return opts, err
+		}
+	}
+
 	summaryTimeUnit, err := flags.GetString("summary-time-unit")
 	if err != nil {
 		return opts, err
@@ -228,6 +307,12 @@ func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 		opts.RunTags = parsedRunTags
 	}
 
+	if flags.Changed("cardinality-collapse-tags") {
+		if opts.CardinalityCollapseTags, err = flags.GetStringSlice("cardinality-collapse-tags"); err != nil {
+			return opts, err
+		}
+	}
+
 	redirectConFile, err := flags.GetString("console-output")
 	if err != nil {
 		return opts, err
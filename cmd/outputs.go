@@ -12,9 +12,16 @@ import (
 	"go.k6.io/k6/output"
 	"go.k6.io/k6/output/cloud"
 	"go.k6.io/k6/output/csv"
+	"go.k6.io/k6/output/elasticsearch"
+	"go.k6.io/k6/output/exec"
 	"go.k6.io/k6/output/influxdb"
 	"go.k6.io/k6/output/json"
+	"go.k6.io/k6/output/kafka"
+	"go.k6.io/k6/output/otlp"
+	"go.k6.io/k6/output/parquet"
+	"go.k6.io/k6/output/sqlite"
 	"go.k6.io/k6/output/statsd"
+	"go.k6.io/k6/output/timescaledb"
 
 	"github.com/grafana/xk6-dashboard/dashboard"
 	"github.com/grafana/xk6-output-prometheus-remote/pkg/remotewrite"
@@ -29,25 +36,33 @@ const (
 	builtinOutputCloud builtinOutput = iota
 	builtinOutputCSV
 	builtinOutputDatadog
+	builtinOutputElasticsearch
+	builtinOutputExec
 	builtinOutputExperimentalPrometheusRW
 	builtinOutputInfluxdb
 	builtinOutputJSON
 	builtinOutputKafka
+	builtinOutputOTLP
+	builtinOutputParquet
+	builtinOutputSqlite
 	builtinOutputStatsd
+	builtinOutputTimescaledb
 )
 
 // TODO: move this to an output sub-module after we get rid of the old collectors?
 func getAllOutputConstructors() (map[string]output.Constructor, error) {
 	// Start with the built-in outputs
 	result := map[string]output.Constructor{
-		builtinOutputJSON.String():     json.New,
-		builtinOutputCloud.String():    cloud.New,
-		builtinOutputCSV.String():      csv.New,
-		builtinOutputInfluxdb.String(): influxdb.New,
-		builtinOutputKafka.String(): func(_ output.Params) (output.Output, error) {
-			return nil, errors.New("the kafka output was deprecated in k6 v0.32.0 and removed in k6 v0.34.0, " +
-				"please use the new xk6 kafka output extension instead - https://github.com/k6io/xk6-output-kafka")
-		},
+		builtinOutputJSON.String():          json.New,
+		builtinOutputCloud.String():         cloud.New,
+		builtinOutputCSV.String():           csv.New,
+		builtinOutputElasticsearch.String(): elasticsearch.New,
+		builtinOutputExec.String():          exec.New,
+		builtinOutputInfluxdb.String():      influxdb.New,
+		builtinOutputKafka.String():         kafka.New,
+		builtinOutputOTLP.String():          otlp.New,
+		builtinOutputParquet.String():       parquet.New,
+		builtinOutputSqlite.String():        sqlite.New,
 		builtinOutputStatsd.String(): func(params output.Params) (output.Output, error) {
 			params.Logger.Warn("The statsd output is deprecated, and will be removed in a future k6 version. " +
 				"Please use the new xk6 statsd output extension instead. " +
@@ -55,6 +70,7 @@ func getAllOutputConstructors() (map[string]output.Constructor, error) {
 				"more info at https://github.com/grafana/k6/issues/2982.")
 			return statsd.New(params)
 		},
+		builtinOutputTimescaledb.String(): timescaledb.New,
 		builtinOutputDatadog.String(): func(_ output.Params) (output.Output, error) {
 			return nil, errors.New("the datadog output was deprecated in k6 v0.32.0 and removed in k6 v0.34.0, " +
 				"please use the statsd output with env. variable K6_STATSD_ENABLE_TAGS=true instead")
@@ -83,7 +99,7 @@ func getAllOutputConstructors() (map[string]output.Constructor, error) {
 func getPossibleIDList(constrs map[string]output.Constructor) string {
 	res := make([]string, 0, len(constrs))
 	for k := range constrs {
-		if k == "kafka" || k == "datadog" {
+		if k == "datadog" {
 			continue
 		}
 		res = append(res, k)
@@ -117,6 +133,7 @@ func createOutputs(
 	}
 
 	result := make([]output.Output, 0, len(outputs))
+	usedOutputs := make(map[string]struct{}, len(outputs))
 
 	for _, outputFullArg := range outputs {
 		outputType, outputArg := parseOutputArgument(outputFullArg)
@@ -138,6 +155,16 @@ func createOutputs(
 			return nil, fmt.Errorf("could not create the '%s' output: %w", outputType, err)
 		}
 
+		if aggregation, ok := test.derivedConfig.OutputAggregation[outputType]; ok {
+			if out, err = output.WithAggregation(out, aggregation); err != nil {
+				return nil, fmt.Errorf("invalid aggregation of the '%s' output: %w", outputType, err)
+			}
+		}
+
+		if filter, ok := test.derivedConfig.OutputFilters[outputType]; ok {
+			out = output.WithFilter(out, filter)
+		}
+
 		if thresholdOut, ok := out.(output.WithThresholds); ok {
 			thresholdOut.SetThresholds(test.derivedConfig.Thresholds)
 		}
@@ -147,6 +174,18 @@ func createOutputs(
 		}
 
 		result = append(result, out)
+		usedOutputs[outputType] = struct{}{}
+	}
+
+	for outputType := range test.derivedConfig.OutputFilters {
+		if _, ok := usedOutputs[outputType]; !ok {
+			gs.Logger.Warnf("There is a filter for the '%s' output, but the output isn't used", outputType)
+		}
+	}
+	for outputType := range test.derivedConfig.OutputAggregation {
+		if _, ok := usedOutputs[outputType]; !ok {
+			gs.Logger.Warnf("There is an aggregation for the '%s' output, but the output isn't used", outputType)
+		}
 	}
 
 	return result, nil
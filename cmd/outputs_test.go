@@ -9,8 +9,8 @@ import (
 func TestBuiltinOutputString(t *testing.T) {
 	t.Parallel()
 	exp := []string{
-		"cloud", "csv", "datadog", "experimental-prometheus-rw",
-		"influxdb", "json", "kafka", "statsd",
+		"cloud", "csv", "datadog", "elasticsearch", "exec", "experimental-prometheus-rw",
+		"influxdb", "json", "kafka", "otlp", "parquet", "sqlite", "statsd", "timescaledb",
 	}
 	assert.Equal(t, exp, builtinOutputStrings())
 }
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// recordCmd represents the `k6 record` command
+type recordCmd struct {
+	gs *state.GlobalState
+
+	listenAddr     string
+	scriptOut      string
+	harOut         string
+	caCert         string
+	caKey          string
+	onlyHosts      []string
+	groupGap       time.Duration
+	overwriteFiles bool
+}
+
+func (c *recordCmd) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringVarP(&c.listenAddr, "listen", "l", "localhost:8888", "address for the recording proxy to listen on")
+	flags.StringVarP(&c.scriptOut, "output", "o", "recording.js", "file to write the generated script to, empty to skip it")
+	flags.StringVar(&c.harOut, "har", "", "file to write the recorded requests to in the HAR format")
+	flags.StringVar(&c.caCert, "ca-cert", "k6-recorder-ca.pem",
+		"the CA certificate used to intercept HTTPS requests, generated if it doesn't exist")
+	flags.StringVar(&c.caKey, "ca-key", "k6-recorder-ca-key.pem", "the private key of the CA certificate")
+	flags.StringSliceVar(&c.onlyHosts, "only-host", nil, "only include the requests for this host in the script")
+	flags.DurationVar(&c.groupGap, "group-gap", har.DefaultGroupGap,
+		"minimum pause between requests that starts a new group in the script")
+	flags.BoolVarP(&c.overwriteFiles, "force", "f", false, "Overwrite existing files")
+
+	return flags
+}
+
+func (c *recordCmd) loadCA() (tls.Certificate, error) {
+	exists, err := fsext.Exists(c.gs.FS, c.caCert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if !exists {
+		certPEM, keyPEM, err := har.GenerateCA()
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("unable to generate a CA certificate: %w", err)
+		}
+		if err := fsext.WriteFile(c.gs.FS, c.caCert, certPEM, 0o644); err != nil {
+			return tls.Certificate{}, err
+		}
+		if err := fsext.WriteFile(c.gs.FS, c.caKey, keyPEM, 0o600); err != nil {
+			return tls.Certificate{}, err
+		}
+		c.gs.Logger.Infof("Generated a new CA certificate in %s, it needs to be trusted by the recorded clients", c.caCert)
+		return tls.X509KeyPair(certPEM, keyPEM)
+	}
+
+	certPEM, err := fsext.ReadFile(c.gs.FS, c.caCert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := fsext.ReadFile(c.gs.FS, c.caKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+func (c *recordCmd) checkOutputs() error {
+	if c.overwriteFiles {
+		return nil
+	}
+	for _, target := range []string{c.scriptOut, c.harOut} {
+		if target == "" {
+			continue
+		}
+		exists, err := fsext.Exists(c.gs.FS, target)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%s already exists, please use the `--force` flag if you want overwrite it", target)
+		}
+	}
+	return nil
+}
+
+func (c *recordCmd) run(_ *cobra.Command, _ []string) error {
+	if c.scriptOut == "" && c.harOut == "" {
+		return errors.New("at least one of the script or HAR outputs needs to be specified")
+	}
+	if err := c.checkOutputs(); err != nil {
+		return err
+	}
+
+	ca, err := c.loadCA()
+	if err != nil {
+		return fmt.Errorf("unable to load the CA certificate: %w", err)
+	}
+	recorder, err := har.NewRecorder(ca, c.gs.Logger)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", c.listenAddr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: recorder, ReadHeaderTimeout: time.Minute}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	valueColor := getColor(c.gs.Flags.NoColor || !c.gs.Stdout.IsTTY, color.Bold)
+	printToStdout(c.gs, fmt.Sprintf(
+		"Recording proxy listening on %s. Configure the HTTP and HTTPS proxy of your client to use it and\n"+
+			"make sure the client trusts the CA certificate in %s. Press Ctrl+C to stop recording.\n",
+		valueColor.Sprint("http://"+listener.Addr().String()), valueColor.Sprint(c.caCert),
+	))
+
+	sigC := make(chan os.Signal, 1)
+	c.gs.SignalNotify(sigC, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer c.gs.SignalStop(sigC)
+	select {
+	case <-sigC:
+	case <-c.gs.Ctx.Done():
+	case err := <-serveErr:
+		return err
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(shutdownCtx)
+
+	return c.writeOutputs(recorder.HAR())
+}
+
+func (c *recordCmd) writeOutputs(recording har.HAR) error {
+	if c.harOut != "" {
+		data, err := json.MarshalIndent(recording, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := fsext.WriteFile(c.gs.FS, c.harOut, data, 0o644); err != nil {
+			return err
+		}
+	}
+	if c.scriptOut != "" {
+		script, err := har.Convert(recording, har.Options{OnlyHosts: c.onlyHosts, GroupGap: c.groupGap})
+		if err != nil {
+			return err
+		}
+		if err := fsext.WriteFile(c.gs.FS, c.scriptOut, []byte(script), 0o644); err != nil {
+			return err
+		}
+	}
+
+	printToStdout(c.gs, fmt.Sprintf("Recorded %d requests.\n", len(recording.Log.Entries)))
+	return nil
+}
+
+func getCmdRecord(gs *state.GlobalState) *cobra.Command {
+	c := &recordCmd{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Record the traffic of a client configured to use localhost:8888 as a proxy and
+  # generate recording.js once the recording is stopped with Ctrl+C.
+  {{.}} record
+
+  # Record only the requests to test.k6.io and also save all of them as a HAR file.
+  {{.}} record --only-host test.k6.io --har recording.har -o test.js`[1:])
+
+	recordCmd := &cobra.Command{
+		Use:   "record",
+		Short: "Record requests with a local proxy and generate a script",
+		Long: `Record requests with a local proxy and generate a script.
+
+This command starts an HTTP proxy that records all of the requests going
+through it, until it's stopped with Ctrl+C. HTTPS requests are intercepted with
+certificates issued by a local CA, so the CA certificate needs to be trusted by
+the recorded client. The recording is then converted to a k6 script, with the
+requests grouped by the pauses between them and with hints for the values that
+probably need to be correlated, and can also be saved as a HAR file.`,
+		Example: exampleText,
+		Args:    cobra.NoArgs,
+		RunE:    c.run,
+	}
+	recordCmd.Flags().AddFlagSet(c.flagSet())
+
+	return recordCmd
+}
@@ -0,0 +1,454 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output/sqlite"
+)
+
+// cmdReport handles the `k6 report` sub-command
+type cmdReport struct {
+	gs *state.GlobalState
+
+	testRun    int64
+	compare    int64
+	by         []string
+	trendStats []string
+	// trendPrecision is the precision of the percentiles, like the option
+	// of the test run
+	trendPrecision float64
+	list           bool
+}
+
+// reportStat is a statistic of the samples of a metric, e.g. the p(95) of
+// a trend or the rate of a counter.
+type reportStat struct {
+	name   string
+	value  float64
+	format func(float64) string
+}
+
+func (s reportStat) String() string {
+	return s.name + "=" + s.format(s.value)
+}
+
+// testRunResults are the aggregated samples of a test run.
+type testRunResults struct {
+	run      sqlite.TestRun
+	duration time.Duration
+	metrics  map[string]*metrics.Metric
+	sinks    map[string]metrics.Sink
+	// byTag are the aggregated samples of the metrics by tag key, metric
+	// name and tag value
+	byTag map[string]map[string]map[string]metrics.Sink
+}
+
+func (c *cmdReport) run(_ *cobra.Command, args []string) error {
+	resolvers, err := metrics.GetResolversForTrendColumns(c.trendStats)
+	if err != nil {
+		return err
+	}
+	if c.trendPrecision < 0 || c.trendPrecision >= 1 {
+		return fmt.Errorf("the trend precision must be between 0 and 1, not %g", c.trendPrecision)
+	}
+
+	db, err := sqlite.OpenResults(args[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	runs, err := sqlite.ReadTestRuns(db)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return fmt.Errorf("there are no test runs in '%s'", args[0])
+	}
+
+	w := tabwriter.NewWriter(c.gs.Stdout, 0, 0, 2, ' ', 0)
+	if c.list {
+		printTestRuns(w, runs)
+		return w.Flush()
+	}
+
+	run, err := findTestRun(runs, c.testRun)
+	if err != nil {
+		return err
+	}
+	results, err := c.aggregate(db, run)
+	if err != nil {
+		return err
+	}
+	checks, err := sqlite.ReadChecks(db, run.ID)
+	if err != nil {
+		return err
+	}
+
+	var baseline *testRunResults
+	if c.compare != 0 {
+		baselineRun, err := findTestRun(runs, c.compare)
+		if err != nil {
+			return err
+		}
+		if baseline, err = c.aggregate(db, baselineRun); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "Test run %s\n\n", describeTestRun(run, results.duration))
+	c.printMetrics(w, results, resolvers)
+	printChecks(w, checks)
+	for _, key := range c.by {
+		c.printByTag(w, results, key, resolvers)
+	}
+	if baseline != nil {
+		c.printComparison(w, baseline, results, resolvers)
+	}
+	return w.Flush()
+}
+
+// findTestRun returns the test run with the ID, or the last one if it's 0.
+func findTestRun(runs []sqlite.TestRun, id int64) (sqlite.TestRun, error) {
+	if id == 0 {
+		return runs[len(runs)-1], nil
+	}
+	for _, r := range runs {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return sqlite.TestRun{}, fmt.Errorf("there is no test run with the ID %d", id)
+}
+
+// aggregate reads the samples of the test run and aggregates them by metric
+// and by the values of the tags of the --by flags.
+func (c *cmdReport) aggregate(db *sql.DB, run sqlite.TestRun) (*testRunResults, error) {
+	results := &testRunResults{
+		run:     run,
+		metrics: make(map[string]*metrics.Metric),
+		sinks:   make(map[string]metrics.Sink),
+		byTag:   make(map[string]map[string]map[string]metrics.Sink, len(c.by)),
+	}
+	for _, key := range c.by {
+		results.byTag[key] = make(map[string]map[string]metrics.Sink)
+	}
+
+	var first, last time.Time
+	registry := metrics.NewRegistry()
+	registry.SetTrendPrecision(c.trendPrecision)
+	err := sqlite.ReadSamples(db, run.ID, registry, func(s metrics.Sample) {
+		name := s.Metric.Name
+		sink, ok := results.sinks[name]
+		if !ok {
+			sink = s.Metric.NewSink()
+			results.sinks[name] = sink
+			results.metrics[name] = s.Metric
+		}
+		sink.Add(s)
+
+		for key, byMetric := range results.byTag {
+			value, ok := s.Tags.Get(key)
+			if !ok {
+				continue
+			}
+			if byMetric[name] == nil {
+				byMetric[name] = make(map[string]metrics.Sink)
+			}
+			tagSink, ok := byMetric[name][value]
+			if !ok {
+				tagSink = s.Metric.NewSink()
+				byMetric[name][value] = tagSink
+			}
+			tagSink.Add(s)
+		}
+
+		if first.IsZero() || s.Time.Before(first) {
+			first = s.Time
+		}
+		if s.Time.After(last) {
+			last = s.Time
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the samples of the test run %d: %w", run.ID, err)
+	}
+
+	// the end of an interrupted test run is unknown, so the samples tell
+	// how long it lasted at least
+	results.duration = run.Duration()
+	if results.duration == 0 {
+		results.duration = last.Sub(first)
+	}
+	return results, nil
+}
+
+func describeTestRun(run sqlite.TestRun, duration time.Duration) string {
+	return fmt.Sprintf("#%d %q (k6 v%s), started at %s, lasted %s",
+		run.ID, run.Name, run.K6Version, run.StartedAt.Format(time.RFC3339), duration.Round(time.Millisecond))
+}
+
+func printTestRuns(w io.Writer, runs []sqlite.TestRun) {
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tK6 VERSION\tSTARTED\tDURATION")
+	for _, r := range runs {
+		duration := "-"
+		if d := r.Duration(); d > 0 {
+			duration = d.Round(time.Millisecond).String()
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\tv%s\t%s\t%s\n",
+			r.ID, r.Name, r.K6Version, r.StartedAt.Format(time.RFC3339), duration)
+	}
+}
+
+func (c *cmdReport) printMetrics(
+	w io.Writer, results *testRunResults, resolvers map[string]func(*metrics.TrendSink) float64,
+) {
+	_, _ = fmt.Fprintln(w, "METRIC\tTYPE\tVALUES")
+	for _, name := range sortedMapKeys(results.sinks) {
+		m := results.metrics[name]
+		stats := c.stats(m, results.sinks[name], results.duration, resolvers)
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", name, m.Type, joinStats(stats))
+	}
+}
+
+func printChecks(w io.Writer, checks []sqlite.CheckResult) {
+	if len(checks) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(w, "\nGROUP\tCHECK\tPASSES\tFAILS\tRATE")
+	for _, c := range checks {
+		group := c.Group
+		if group == "" {
+			group = lib.GroupSeparator
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", group, c.Name, c.Passes, c.Fails,
+			formatPercentage(float64(c.Passes)/float64(c.Passes+c.Fails)))
+	}
+}
+
+func (c *cmdReport) printByTag(
+	w io.Writer, results *testRunResults, key string, resolvers map[string]func(*metrics.TrendSink) float64,
+) {
+	byMetric := results.byTag[key]
+	if len(byMetric) == 0 {
+		_, _ = fmt.Fprintf(w, "\nNo samples have the tag '%s'\n", key)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\nMETRIC\t%s\tVALUES\n", strings.ToUpper(key))
+	for _, name := range sortedMapKeys(byMetric) {
+		m := results.metrics[name]
+		for _, value := range sortedMapKeys(byMetric[name]) {
+			stats := c.stats(m, byMetric[name][value], results.duration, resolvers)
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", name, value, joinStats(stats))
+		}
+	}
+}
+
+// printComparison shows the change of every statistic of the metrics in both
+// test runs, relative to the baseline.
+func (c *cmdReport) printComparison(
+	w io.Writer, baseline, results *testRunResults, resolvers map[string]func(*metrics.TrendSink) float64,
+) {
+	_, _ = fmt.Fprintf(w, "\nCompared with test run %s\n\n", describeTestRun(baseline.run, baseline.duration))
+	_, _ = fmt.Fprintf(w, "METRIC\tSTAT\t#%d\t#%d\tCHANGE\n", baseline.run.ID, results.run.ID)
+	for _, name := range sortedMapKeys(results.sinks) {
+		m := results.metrics[name]
+		baselineMetric, ok := baseline.metrics[name]
+		if !ok || baselineMetric.Type != m.Type {
+			continue
+		}
+		baselineStats := make(map[string]reportStat)
+		for _, s := range c.stats(baselineMetric, baseline.sinks[name], baseline.duration, resolvers) {
+			baselineStats[s.name] = s
+		}
+		for _, s := range c.stats(m, results.sinks[name], results.duration, resolvers) {
+			// the rate of a counter is unknown if a test run has no duration
+			b, ok := baselineStats[s.name]
+			if !ok {
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				name, s.name, b.format(b.value), s.format(s.value), formatChange(b.value, s.value))
+		}
+	}
+}
+
+// stats returns the statistics of the samples of the metric, they depend on
+// its type, the trend stats are the ones of the --summary-trend-stats flag.
+func (c *cmdReport) stats(
+	m *metrics.Metric, sink metrics.Sink, duration time.Duration,
+	resolvers map[string]func(*metrics.TrendSink) float64,
+) []reportStat {
+	format := valueFormatter(m.Contains)
+	switch sink := sink.(type) {
+	case *metrics.CounterSink:
+		stats := []reportStat{{name: "count", value: sink.Value, format: format}}
+		if duration > 0 {
+			stats = append(stats, reportStat{
+				name:   "rate",
+				value:  sink.Value / duration.Seconds(),
+				format: func(v float64) string { return format(v) + "/s" },
+			})
+		}
+		return stats
+	case *metrics.GaugeSink:
+		return []reportStat{
+			{name: "value", value: sink.Value, format: format},
+			{name: "min", value: sink.Min, format: format},
+			{name: "max", value: sink.Max, format: format},
+		}
+	case *metrics.RateSink:
+		formatCount := valueFormatter(metrics.Default)
+		return []reportStat{
+			{name: "rate", value: float64(sink.Trues) / float64(sink.Total), format: formatPercentage},
+			{name: "passes", value: float64(sink.Trues), format: formatCount},
+			{name: "fails", value: float64(sink.Total - sink.Trues), format: formatCount},
+		}
+	case *metrics.TrendSink:
+		stats := make([]reportStat, 0, len(c.trendStats))
+		for _, name := range c.trendStats {
+			s := reportStat{name: name, value: resolvers[name](sink), format: format}
+			if name == "count" {
+				s.format = valueFormatter(metrics.Default)
+			}
+			stats = append(stats, s)
+		}
+		return stats
+	default:
+		return nil
+	}
+}
+
+func joinStats(stats []reportStat) string {
+	values := make([]string, len(stats))
+	for i, s := range stats {
+		values[i] = s.String()
+	}
+	return strings.Join(values, " ")
+}
+
+// valueFormatter returns the function that formats the values of a metric,
+// the times are in milliseconds and the data in bytes.
+func valueFormatter(contains metrics.ValueType) func(float64) string {
+	switch contains {
+	case metrics.Time:
+		return func(v float64) string {
+			d := time.Duration(v * float64(time.Millisecond))
+			switch {
+			case d >= time.Second || d <= -time.Second:
+				d = d.Round(time.Millisecond)
+			case d >= time.Millisecond || d <= -time.Millisecond:
+				d = d.Round(time.Microsecond)
+			}
+			return d.String()
+		}
+	case metrics.Data:
+		return func(v float64) string {
+			units := []string{"B", "kB", "MB", "GB", "TB"}
+			i := 0
+			for ; math.Abs(v) >= 1000 && i < len(units)-1; i++ {
+				v /= 1000
+			}
+			return formatNumber(v) + " " + units[i]
+		}
+	default:
+		return formatNumber
+	}
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func formatPercentage(v float64) string {
+	return strconv.FormatFloat(v*100, 'f', 2, 64) + "%"
+}
+
+// formatChange returns the relative change from the baseline value.
+func formatChange(baseline, value float64) string {
+	if baseline == 0 {
+		if value == 0 {
+			return "0.00%"
+		}
+		return "-"
+	}
+	return fmt.Sprintf("%+.2f%%", (value-baseline)/math.Abs(baseline)*100)
+}
+
+// sortedMapKeys returns the keys of the map in order.
+func sortedMapKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *cmdReport) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.Int64Var(&c.testRun, "test-run", c.testRun, "`id` of the test run to report, the last one by default")
+	flags.Int64Var(&c.compare, "compare", c.compare, "compare the test run with the one with this `id`")
+	flags.StringArrayVar(&c.by, "by", c.by, "break down the metrics by the values of the `tag`, it can be repeated")
+	flags.StringSliceVar(&c.trendStats, "summary-trend-stats", c.trendStats,
+		"define `stats` for trend metrics (response times), one or more as 'avg,p(95),...'")
+	flags.Float64Var(&c.trendPrecision, "trend-precision", c.trendPrecision, "relative error of the percentiles "+
+		"of the trend metrics, 0 keeps all the values for the exact percentiles")
+	flags.BoolVar(&c.list, "list", c.list, "list the test runs in the results")
+	return flags
+}
+
+func getCmdReport(gs *state.GlobalState) *cobra.Command {
+	c := &cmdReport{
+		gs:             gs,
+		trendStats:     lib.DefaultSummaryTrendStats,
+		trendPrecision: metrics.DefaultTrendPrecision,
+	}
+
+	exampleText := getExampleText(gs, `
+  # Show the summary of the last test run written by the sqlite output.
+  {{.}} report results.db
+
+  # List the test runs in the results.
+  {{.}} report --list results.db
+
+  # Show the percentiles of the test run with the ID 3 by URL and status.
+  {{.}} report --test-run 3 --by name --by status --summary-trend-stats 'p(50),p(95),p(99)' results.db
+
+  # Compare the last test run with the first one.
+  {{.}} report --compare 1 results.db`[1:])
+
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Analyze the results of the sqlite output",
+		Long: `Analyze the results of the sqlite output.
+
+The samples of a test run saved with --out sqlite=results.db are aggregated
+and shown as summary tables, with the results of the checks. The metrics can
+be broken down by the values of tags, and the statistics of two test runs in
+the same file can be compared.`,
+		Example: exampleText,
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.run,
+	}
+
+	reportCmd.Flags().SortFlags = false
+	reportCmd.Flags().AddFlagSet(c.flagSet())
+
+	return reportCmd
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd/tests"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+	"go.k6.io/k6/output/sqlite"
+)
+
+// writeTestRunResults writes the samples of a test run with the sqlite output,
+// the durations of the requests are multiplied by the factor.
+func writeTestRunResults(t *testing.T, fileName string, factor float64) {
+	t.Helper()
+
+	o, err := sqlite.New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: fileName,
+		JSONConfig:     []byte(`{"runName":"my test"}`),
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	checks := registry.MustNewMetric(metrics.ChecksName, metrics.Rate)
+	received := registry.MustNewMetric("data_received", metrics.Counter, metrics.Data)
+	sample := func(m *metrics.Metric, value float64, tags map[string]string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: registry.RootTagSet().WithTagsFromMap(tags)},
+			Time:       time.Now(),
+			Value:      value,
+		}
+	}
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(duration, 100*factor, map[string]string{"status": "200"}),
+		sample(duration, 300*factor, map[string]string{"status": "500"}),
+		sample(received, 1500, nil),
+		sample(checks, 1, map[string]string{"check": "is ok", "group": "::my group"}),
+		sample(checks, 0, map[string]string{"check": "is ok", "group": "::my group"}),
+	}})
+	require.NoError(t, o.Stop())
+}
+
+func TestReportCmd(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "results.db")
+	writeTestRunResults(t, fileName, 1)
+	writeTestRunResults(t, fileName, 2)
+
+	t.Run("summary", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		ts.CmdArgs = []string{"k6", "report", "--by", "status", "--summary-trend-stats", "min,max", fileName}
+
+		newRootCommand(ts.GlobalState).execute()
+
+		stdout := ts.Stdout.String()
+		assert.Contains(t, stdout, `Test run #2 "my test"`)
+		assert.Regexp(t, `http_req_duration +trend +min=200ms max=600ms\n`, stdout)
+		assert.Regexp(t, `data_received +counter +count=1.5 kB`, stdout)
+		assert.Regexp(t, `::my group +is ok +1 +1 +50.00%`, stdout)
+		assert.Regexp(t, `METRIC +STATUS +VALUES\nhttp_req_duration +200 +min=200ms max=200ms\n`+
+			`http_req_duration +500 +min=600ms max=600ms\n`, stdout)
+		assert.NotContains(t, stdout, "Compared with")
+	})
+
+	t.Run("comparison", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		ts.CmdArgs = []string{"k6", "report", "--summary-trend-stats", "avg", "--compare", "1", fileName}
+
+		newRootCommand(ts.GlobalState).execute()
+
+		stdout := ts.Stdout.String()
+		assert.Contains(t, stdout, `Compared with test run #1 "my test"`)
+		assert.Regexp(t, `METRIC +STAT +#1 +#2 +CHANGE\n`, stdout)
+		assert.Regexp(t, `http_req_duration +avg +200ms +400ms +\+100.00%`, stdout)
+		assert.Regexp(t, `data_received +count +1.5 kB +1.5 kB +\+0.00%`, stdout)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		ts.CmdArgs = []string{"k6", "report", "--list", fileName}
+
+		newRootCommand(ts.GlobalState).execute()
+
+		stdout := ts.Stdout.String()
+		assert.Regexp(t, `(?m)^1 +my test +v`, stdout)
+		assert.Regexp(t, `(?m)^2 +my test +v`, stdout)
+	})
+
+	t.Run("unknown test run", func(t *testing.T) {
+		t.Parallel()
+
+		ts := tests.NewGlobalTestState(t)
+		ts.CmdArgs = []string{"k6", "report", "--test-run", "3", fileName}
+		ts.ExpectedExitCode = -1
+
+		newRootCommand(ts.GlobalState).execute()
+
+		assert.Contains(t, ts.Stderr.String(), "there is no test run with the ID 3")
+	})
+}
+
+func TestReportValueFormatter(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "1.234ms", valueFormatter(metrics.Time)(1.2344))
+	assert.Equal(t, "1.5s", valueFormatter(metrics.Time)(1500.2))
+	assert.Equal(t, "999 B", valueFormatter(metrics.Data)(999))
+	assert.Equal(t, "2.35 MB", valueFormatter(metrics.Data)(2_345_678))
+	assert.Equal(t, "0.33", valueFormatter(metrics.Default)(1.0/3))
+	assert.Equal(t, "-50.00%", formatChange(10, 5))
+	assert.Equal(t, "-", formatChange(0, 5))
+}
@@ -61,9 +61,9 @@ func newRootCommand(gs *state.GlobalState) *rootCommand {
 	rootCmd.SetIn(gs.Stdin)
 
 	subCommands := []func(*state.GlobalState) *cobra.Command{
-		getCmdArchive, getCmdCloud, getCmdNewScript, getCmdInspect,
-		getCmdLogin, getCmdPause, getCmdResume, getCmdScale, getCmdRun,
-		getCmdStats, getCmdStatus, getCmdVersion,
+		getCmdAgent, getCmdArchive, getCmdCloud, getCmdCompare, getCmdConfig, getCmdConvert, getCmdCoordinator,
+		getCmdNewScript, getCmdInspect, getCmdLogin, getCmdMerge, getCmdPause, getCmdRecord, getCmdReport, getCmdResume,
+		getCmdScale, getCmdRun, getCmdSchedule, getCmdStats, getCmdStatus, getCmdValidate, getCmdVersion,
 	}
 
 	for _, sc := range subCommands {
@@ -75,6 +75,21 @@ func newRootCommand(gs *state.GlobalState) *rootCommand {
 }
 
 func (c *rootCommand) persistentPreRunE(_ *cobra.Command, _ []string) error {
+	// the sensitive data is redacted by a hook, which has to come before the
+	// ones of the remote loggers
+	if c.globalState.RedactionHook == nil {
+		c.globalState.RedactionHook = log.NewRedactionHook()
+	}
+	c.globalState.Logger.AddHook(c.globalState.RedactionHook)
+	if fallbackLogger, ok := c.globalState.FallbackLogger.(*logrus.Logger); ok { //nolint:forbidigo
+		fallbackLogger.AddHook(c.globalState.RedactionHook)
+	}
+	if err := loadEnvFiles(c.globalState); err != nil {
+		return err
+	}
+	if err := setupSecretSources(c.globalState); err != nil {
+		return err
+	}
 	err := c.setupLoggers(c.stopLoggersCh)
 	if err != nil {
 		return err
@@ -170,12 +185,22 @@ func rootCmdPersistentFlagSet(gs *state.GlobalState) *pflag.FlagSet {
 	flags.StringVar(&gs.Flags.LogFormat, "log-format", gs.Flags.LogFormat, "log output format")
 	flags.Lookup("log-format").DefValue = gs.DefaultFlags.LogFormat
 
-	flags.StringVarP(&gs.Flags.ConfigFilePath, "config", "c", gs.Flags.ConfigFilePath, "JSON config file")
+	flags.StringVarP(&gs.Flags.ConfigFilePath, "config", "c", gs.Flags.ConfigFilePath,
+		"JSON or YAML config file, YAML if its extension is .yaml or .yml")
 	// And we also need to explicitly set the default value for the usage message here, so things
 	// like `K6_CONFIG="blah" k6 run -h` don't produce a weird usage message
 	flags.Lookup("config").DefValue = gs.DefaultFlags.ConfigFilePath
 	must(cobra.MarkFlagFilename(flags, "config"))
 
+	flags.StringVar(&gs.Flags.Profile, "profile", gs.Flags.Profile,
+		"use the options of the named profile in the config file, on top of its other options")
+	flags.Lookup("profile").DefValue = gs.DefaultFlags.Profile
+
+	flags.StringArrayVar(&gs.Flags.EnvFiles, "env-file", gs.DefaultFlags.EnvFiles,
+		"load the environment variables in a .env file, the ones already set have priority, "+
+			"and the later files override the earlier ones")
+	must(cobra.MarkFlagFilename(flags, "env-file"))
+
 	flags.BoolVar(&gs.Flags.NoColor, "no-color", gs.Flags.NoColor, "disable colored output")
 	flags.Lookup("no-color").DefValue = strconv.FormatBool(gs.DefaultFlags.NoColor)
 
@@ -184,6 +209,11 @@ func rootCmdPersistentFlagSet(gs *state.GlobalState) *pflag.FlagSet {
 	flags.BoolVarP(&gs.Flags.Verbose, "verbose", "v", gs.DefaultFlags.Verbose, "enable verbose logging")
 	flags.BoolVarP(&gs.Flags.Quiet, "quiet", "q", gs.DefaultFlags.Quiet, "disable progress updates")
 	flags.StringVarP(&gs.Flags.Address, "address", "a", gs.DefaultFlags.Address, "address for the REST API server")
+	flags.StringVar(&gs.Flags.ControlAddress, "control-address", gs.DefaultFlags.ControlAddress,
+		"address for the gRPC control API server, disabled by default")
+	flags.StringArrayVar(&gs.Flags.SecretSources, "secret-source", gs.DefaultFlags.SecretSources,
+		"add a source of the secrets the script can get with k6/secrets, like file=./secrets.env, "+
+			"vault=path=k6/creds, aws=secret=k6/creds or gcp=project=my-project, the first one is the default")
 	flags.BoolVar(
 		&gs.Flags.ProfilingEnabled,
 		"profiling-enabled",
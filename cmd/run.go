@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
+	"runtime"
 	"strings"
 	"sync"
 	"syscall"
@@ -15,21 +17,27 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/api"
+	"go.k6.io/k6/api/rpc"
+	v2 "go.k6.io/k6/api/v2"
 	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/errext"
 	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/event"
 	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/distributed"
 	"go.k6.io/k6/execution/local"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
 	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/lib/trace"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/metrics/engine"
 	"go.k6.io/k6/output"
+	testreport "go.k6.io/k6/output/report"
 	"go.k6.io/k6/ui/pb"
 )
 
@@ -39,6 +47,14 @@ type cmdRun struct {
 
 	// TODO: figure out something more elegant?
 	loadConfiguredTest func(cmd *cobra.Command, args []string) (*loadedAndConfiguredTest, execution.Controller, error)
+
+	// additionalOutputs are started alongside the outputs specified by the user
+	additionalOutputs []output.Output
+	// externalAborts receive the errors with which the test run should be
+	// aborted by something other than the test itself, e.g. a coordinator
+	externalAborts []<-chan error
+	// instanceID is the ID of this instance in a distributed test run
+	instanceID null.Int
 }
 
 const (
@@ -79,6 +95,15 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	// execution.NewTestRunContext() function so that it can be aborted even
 	// from sub-contexts while also attaching a reason for the abort.
 	runCtx, runAbort := execution.NewTestRunContext(lingerCtx, logger)
+	for _, aborted := range c.externalAborts {
+		go func(aborted <-chan error) {
+			select {
+			case abortErr := <-aborted:
+				runAbort(abortErr)
+			case <-runCtx.Done():
+			}
+		}(aborted)
+	}
 
 	emitEvent := func(evt *event.Event) func() {
 		waitDone := c.gs.Events.Emit(evt)
@@ -100,10 +125,18 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		c.gs.Events.UnsubscribeAll()
 	}()
 
+	useTUI, err := c.useTUI(cmd)
+	if err != nil {
+		return err
+	}
+
 	test, controller, err := c.loadConfiguredTest(cmd, args)
 	if err != nil {
 		return err
 	}
+	if redact := test.derivedConfig.Redact; redact != nil {
+		c.gs.RedactionHook.Add(redact)
+	}
 	if test.keyLogger != nil {
 		defer func() {
 			if klErr := test.keyLogger.Close(); klErr != nil {
@@ -123,12 +156,16 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		}
 	}
 
+	// The metadata of the test run that is set is in the tags of all samples
+	test.derivedConfig.Options = test.derivedConfig.Options.WithTestMetadata()
+
 	// Write the full consolidated *and derived* options back to the Runner.
 	conf := test.derivedConfig
 	testRunState, err := test.buildTestRunState(conf.Options)
 	if err != nil {
 		return err
 	}
+	testRunState.InstanceID = c.instanceID
 
 	// Create a local execution scheduler wrapping the runner.
 	logger.Debug("Initializing the execution scheduler...")
@@ -136,6 +173,8 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	if err != nil {
 		return err
 	}
+	// The scripts can change the targets of the running scenarios
+	testRunState.UpdateScenario = execScheduler.UpdateScenario
 
 	backgroundProcesses := &sync.WaitGroup{}
 	defer backgroundProcesses.Wait()
@@ -148,15 +187,17 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	defer progressCancel()
 
 	initBar := execScheduler.GetInitProgressBar()
-	backgroundProcesses.Add(1)
-	go func() {
-		defer backgroundProcesses.Done()
-		pbs := []*pb.ProgressBar{initBar}
-		for _, s := range execScheduler.GetExecutors() {
-			pbs = append(pbs, s.GetProgress())
-		}
-		showProgress(progressCtx, c.gs, pbs, logger)
-	}()
+	if !useTUI {
+		backgroundProcesses.Add(1)
+		go func() {
+			defer backgroundProcesses.Done()
+			pbs := []*pb.ProgressBar{initBar}
+			for _, s := range execScheduler.GetExecutors() {
+				pbs = append(pbs, s.GetProgress())
+			}
+			showProgress(progressCtx, c.gs, pbs, logger)
+		}()
+	}
 
 	// Create all outputs.
 	executionPlan := execScheduler.GetExecutionPlan()
@@ -164,16 +205,55 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	if err != nil {
 		return err
 	}
+	outputs = append(outputs, c.additionalOutputs...)
+
+	// The samples are streamed by the REST and gRPC APIs, and shown by the
+	// TUI, so the stream needs to be started like any other output.
+	var sampleStream *v2.SampleStream
+	if c.gs.Flags.Address != "" || c.gs.Flags.ControlAddress != "" || useTUI {
+		sampleStream = v2.NewSampleStream()
+		outputs = append(outputs, sampleStream)
+	}
 
 	metricsEngine, err := engine.NewMetricsEngine(testRunState.Registry, logger)
 	if err != nil {
 		return err
 	}
 
+	// The end-of-test reports need the samples over time, for their charts.
+	var (
+		reports         testreport.Reports
+		reportCollector *testreport.Collector
+	)
+	if arg := testRunState.RuntimeOptions.Report.String; arg != "" {
+		if reports, err = testreport.ParseArgument(arg); err != nil {
+			return err
+		}
+		reportCollector = testreport.NewCollector(reports, logger)
+		outputs = append(outputs, reportCollector)
+	}
+
+	// The metrics are compared with the ones of the baseline at the end.
+	var (
+		baselinePath       = testRunState.RuntimeOptions.Baseline.String
+		baseline           map[string]summaryMetric
+		baselineTolerances map[string]map[string]lib.BaselineTolerance
+	)
+	if baselinePath != "" {
+		if baseline, err = readSummaryMetrics(c.gs.FS, baselinePath); err != nil {
+			return errext.WithExitCodeIfNone(fmt.Errorf("could not read the baseline: %w", err), exitcodes.InvalidConfig)
+		}
+		if baselineTolerances, err = parseBaselineTolerances(conf.BaselineTolerances); err != nil {
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+	} else if len(conf.BaselineTolerances) > 0 {
+		logger.Warn("There are baseline tolerances, but there isn't a baseline to compare with, use --baseline")
+	}
+
 	// We'll need to pipe metrics to the MetricsEngine and process them if any
-	// of these are enabled: thresholds, end-of-test summary
+	// of these are enabled: thresholds, end-of-test summary, reports, baseline
 	shouldProcessMetrics := (!testRunState.RuntimeOptions.NoSummary.Bool ||
-		!testRunState.RuntimeOptions.NoThresholds.Bool)
+		!testRunState.RuntimeOptions.NoThresholds.Bool || reports != nil || baseline != nil)
 	var metricsIngester *engine.OutputIngester
 	if shouldProcessMetrics {
 		err = metricsEngine.InitSubMetricsAndThresholds(conf.Options, testRunState.RuntimeOptions.NoThresholds.Bool)
@@ -184,17 +264,64 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		// thresholds or the end-of-test summary are enabled.
 		metricsIngester = metricsEngine.CreateIngester()
 		outputs = append(outputs, metricsIngester)
+
+		// The current values of the metrics can be read by the scripts,
+		testRunState.MetricValues = func(name string) (map[string]float64, bool) {
+			return metricsEngine.MetricValues(name, execScheduler.GetState().GetCurrentTestRunDuration())
+		}
+		// and the adaptive executors adjust the load to their recent values
+		testRunState.WatchMetric = metricsEngine.WatchMetric
 	}
 
 	executionState := execScheduler.GetState()
+	// This is deferred before the thresholds and the baseline, so the exit
+	// code is replaced after all of the outcomes of the test run are known.
+	defer func() {
+		err = applyCustomExitCodes(
+			err, conf.ExitCodes, metricsEngine.GetThresholds(), executionState.Saturation.Warnings())
+	}()
+	if baseline != nil {
+		// This is deferred before the summary, so the comparison is shown
+		// after it.
+		defer func() {
+			current := currentSummaryMetrics(
+				metricsEngine.ObservedMetrics, executionState.GetCurrentTestRunDuration(), baseline)
+			changes, unchecked := compareWithBaseline(baseline, current, baselineTolerances)
+			for _, key := range unchecked {
+				logger.Warnf("The baseline tolerance of '%s' can't be checked, "+
+					"the value isn't in both the baseline and the test run", key)
+			}
+			if pErr := printBaselineComparison(c.gs.Stdout, baselinePath, changes); pErr != nil {
+				logger.WithError(pErr).Error("failed to print the baseline comparison")
+			}
+			regressions := baselineRegressions(changes)
+			if len(regressions) == 0 {
+				return
+			}
+			bErr := errext.WithExitCodeIfNone(
+				fmt.Errorf("metrics '%s' have regressed from the baseline", strings.Join(regressions, ", ")),
+				exitcodes.BaselineRegression,
+			)
+			if err == nil {
+				err = bErr
+			} else {
+				logger.WithError(bErr).Debug("Regressions from the baseline, but test already exited with another error")
+			}
+		}()
+	}
+
 	if !testRunState.RuntimeOptions.NoSummary.Bool {
 		defer func() {
 			logger.Debug("Generating the end-of-test summary...")
+			metricsEngine.UpdateDerivedMetrics(executionState.GetCurrentTestRunDuration())
 			summaryResult, hsErr := test.initRunner.HandleSummary(globalCtx, &lib.Summary{
-				Metrics:         metricsEngine.ObservedMetrics,
-				RootGroup:       testRunState.Runner.GetDefaultGroup(),
-				TestRunDuration: executionState.GetCurrentTestRunDuration(),
-				NoColor:         c.gs.Flags.NoColor,
+				Metrics:              metricsEngine.ObservedMetrics,
+				RootGroup:            testRunState.Runner.GetDefaultGroup(),
+				TestRunDuration:      executionState.GetCurrentTestRunDuration(),
+				TimeSeries:           metricsEngine.ObservedTimeSeries(),
+				TimeSeriesResolution: metricsEngine.TimeSeriesResolution(),
+				Warnings:             executionState.Saturation.Warnings(),
+				NoColor:              c.gs.Flags.NoColor,
 				UIState: lib.UIState{
 					IsStdOutTTY: c.gs.Stdout.IsTTY,
 					IsStdErrTTY: c.gs.Stderr.IsTTY,
@@ -209,6 +336,23 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		}()
 	}
 
+	if reports != nil {
+		defer func() {
+			logger.Debug("Generating the end-of-test reports...")
+			metricsEngine.UpdateDerivedMetrics(executionState.GetCurrentTestRunDuration())
+			rErr := reports.Generate(c.gs.FS, &lib.Summary{
+				Metrics:              metricsEngine.ObservedMetrics,
+				RootGroup:            testRunState.Runner.GetDefaultGroup(),
+				TestRunDuration:      executionState.GetCurrentTestRunDuration(),
+				TimeSeries:           metricsEngine.ObservedTimeSeries(),
+				TimeSeriesResolution: metricsEngine.TimeSeriesResolution(),
+			}, reportCollector)
+			if rErr != nil {
+				logger.WithError(rErr).Error("failed to generate the end-of-test reports")
+			}
+		}()
+	}
+
 	waitInitDone := emitEvent(&event.Event{Type: event.Init})
 
 	// Create and start the outputs. We do it quite early to get any output URLs
@@ -222,8 +366,18 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		// TODO: attach run status and exit code?
 		runAbort(err)
 	})
+	if limit := test.derivedConfig.CardinalityLimit.Int64; limit > 0 {
+		outputManager.SetCardinalityGuard(output.NewCardinalityGuard(
+			int(limit), test.derivedConfig.CardinalityCollapseTags, logger))
+	}
+	outputManager.SetDroppedSamplesMetric(testRunState.BuiltinMetrics.DroppedSamples, testRunState.RunTags)
+	if test.derivedConfig.SelfMetrics.Bool {
+		outputManager.SetFlushDurationMetric(testRunState.BuiltinMetrics.OutputFlushDuration, testRunState.RunTags)
+	}
 	samples := make(chan metrics.SampleContainer, test.derivedConfig.MetricSamplesBufferSize.Int64)
-	waitOutputsFlushed, stopOutputs, err := outputManager.Start(samples)
+	vuSamples, vuSamplesOut := makeVUSamples(test.derivedConfig.MetricSamplesBufferSize.Int64)
+	execScheduler.SetVUSamples(vuSamplesOut)
+	waitOutputsFlushed, stopOutputs, err := outputManager.Start(append(vuSamples, samples)...)
 	if err != nil {
 		return err
 	}
@@ -237,6 +391,12 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	}()
 
 	if !testRunState.RuntimeOptions.NoThresholds.Bool {
+		// the scenarios can start only if the thresholds of the ones before
+		// them passed, with all of the samples that they sent
+		testRunState.ThresholdsPassed = func(scenario string) bool {
+			metricsIngester.Sync(samples, vuSamplesOut)
+			return metricsEngine.ScenarioThresholdsPassed(scenario, executionState.GetCurrentTestRunDuration())
+		}
 		finalizeThresholds := metricsEngine.StartThresholdCalculations(
 			metricsIngester, runAbort, executionState.GetCurrentTestRunDuration,
 		)
@@ -270,6 +430,9 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	defer func() {
 		logger.Debug("Waiting for metrics and traces processing to finish...")
 		close(samples)
+		for _, ch := range vuSamples {
+			close(ch)
+		}
 
 		ww := [...]func(){
 			waitOutputsFlushed,
@@ -289,6 +452,12 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		logger.Debug("Metrics and traces processing finished!")
 	}()
 
+	// The values of the derived metrics are sent to the outputs too, and the
+	// last ones before the samples channel is closed above.
+	stopDerivedMetrics := metricsEngine.StartDerivedMetricsEmission(
+		metricsIngester, samples, vuSamplesOut, testRunState.RunTags, executionState.GetCurrentTestRunDuration)
+	defer stopDerivedMetrics()
+
 	// Spin up the REST API server, if not disabled.
 	if c.gs.Flags.Address != "" { //nolint:nestif
 		initBar.Modify(pb.WithConstProgress(0, "Init API server"))
@@ -310,6 +479,7 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 			samples,
 			metricsEngine,
 			execScheduler,
+			sampleStream,
 		)
 		go func() {
 			defer apiWG.Done()
@@ -338,6 +508,21 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		}()
 	}
 
+	// Spin up the gRPC control API server, if enabled.
+	if c.gs.Flags.ControlAddress != "" {
+		stopControlAPI, cerr := c.serveControlAPI(&v2.ControlSurface{
+			RunCtx:        runCtx,
+			MetricsEngine: metricsEngine,
+			Scheduler:     execScheduler,
+			RunState:      testRunState,
+			SampleStream:  sampleStream,
+		})
+		if cerr != nil {
+			return cerr
+		}
+		defer stopControlAPI()
+	}
+
 	printExecutionDescription(
 		c.gs, "local", args[0], "", conf, executionState.ExecutionTuple, executionPlan, outputs,
 	)
@@ -361,6 +546,20 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	stopSignalHandling := handleTestAbortSignals(c.gs, gracefulStop, onHardStop)
 	defer stopSignalHandling()
 
+	// The TUI replaces the progress bars, and it's stopped as soon as the test
+	// is done, so the summary isn't printed on its screen.
+	stopTUI := func() {}
+	if useTUI {
+		stopTUI = startTUI(globalCtx, c.gs, &v2.ControlSurface{
+			RunCtx:        runCtx,
+			MetricsEngine: metricsEngine,
+			Scheduler:     execScheduler,
+			RunState:      testRunState,
+			SampleStream:  sampleStream,
+		})
+		defer stopTUI()
+	}
+
 	// Initialize the VUs and executors
 	stopVUEmission, err := execScheduler.Init(runCtx, samples)
 	if err != nil {
@@ -393,6 +592,7 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	// Start the test! However, we won't immediately return if there was an
 	// error, we still have things to do.
 	err = execScheduler.Run(globalCtx, runCtx, samples)
+	stopTUI()
 
 	waitTestEndDone := emitEvent(&event.Event{Type: event.TestEnd})
 	defer waitTestEndDone()
@@ -432,15 +632,167 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	return nil
 }
 
+// useTUI returns whether the TUI was chosen with the --ui flag. It falls back
+// to the progress bars if stdout isn't a terminal.
+// makeVUSamples returns the channels of the samples of the VUs, one per CPU,
+// both as they are read by the outputs and as they are written to by the VUs.
+func makeVUSamples(bufferSize int64) ([]chan metrics.SampleContainer, []chan<- metrics.SampleContainer) {
+	vuSamples := make([]chan metrics.SampleContainer, runtime.GOMAXPROCS(0))
+	vuSamplesOut := make([]chan<- metrics.SampleContainer, len(vuSamples))
+	for i := range vuSamples {
+		vuSamples[i] = make(chan metrics.SampleContainer, bufferSize)
+		vuSamplesOut[i] = vuSamples[i]
+	}
+	return vuSamples, vuSamplesOut
+}
+
+func (c *cmdRun) useTUI(cmd *cobra.Command) (bool, error) {
+	ui, err := cmd.Flags().GetString("ui")
+	if err != nil {
+		return false, err
+	}
+	switch ui {
+	case uiProgress:
+		return false, nil
+	case uiTUI:
+		if c.gs.Flags.Quiet || !c.gs.Stdout.IsTTY {
+			c.gs.Logger.Warn("The terminal UI needs an interactive terminal and no --quiet, " +
+				"showing the progress bars instead")
+			return false, nil
+		}
+		return true, nil
+	default:
+		return false, errext.WithExitCodeIfNone(
+			fmt.Errorf("invalid UI '%s', it can be %s or %s", ui, uiProgress, uiTUI), exitcodes.InvalidConfig)
+	}
+}
+
+// serveControlAPI starts the gRPC control API server, with the log entries of
+// the global logger streamed. The returned function stops it and waits for it.
+func (c *cmdRun) serveControlAPI(cs *v2.ControlSurface) (func(), error) {
+	listener, err := net.Listen("tcp", c.gs.Flags.ControlAddress)
+	if err != nil {
+		return nil, errext.WithExitCodeIfNone(
+			fmt.Errorf("couldn't start the gRPC control API server: %w", err), exitcodes.CannotStartRESTAPI)
+	}
+
+	logStream := rpc.NewLogStream()
+	c.gs.Logger.AddHook(logStream)
+	srv := rpc.NewServer(cs, logStream)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.gs.Logger.Debugf("Starting the gRPC control API server on %s", listener.Addr())
+		if serr := srv.Serve(listener); serr != nil {
+			c.gs.Logger.WithError(serr).Warn("Error from the gRPC control API server")
+		}
+	}()
+
+	return func() {
+		rpc.Shutdown(srv, cs, logStream, time.Second)
+		<-done
+	}, nil
+}
+
 func (c *cmdRun) flagSet() *pflag.FlagSet {
 	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
 	flags.SortFlags = false
 	flags.AddFlagSet(optionFlagSet())
 	flags.AddFlagSet(runtimeOptionFlagSet(true))
 	flags.AddFlagSet(configFlagSet())
+	flags.String("ui", uiProgress, "the `type` of the UI during the test run, "+uiProgress+" for the progress bars "+
+		"or "+uiTUI+" for an interactive terminal UI")
+	flags.String("leader", "", "`address` of the coordinator that synchronizes the instances of a segmented test "+
+		"and evaluates its thresholds")
+	flags.AddFlagSet(coordinatorFlagSet("leader-"))
+	flags.Bool("dry-run", false, "run a single iteration of each scenario with a single VU, printing the HTTP requests, "+
+		"without evaluating the thresholds")
 	return flags
 }
 
+// runWithLeader runs an instance of a segmented test, synchronized by the
+// coordinator at the given address. The thresholds are only evaluated by the
+// coordinator, on the metrics of all instances, and it can abort all of them.
+func (c *cmdRun) runWithLeader(cmd *cobra.Command, args []string, address string) error {
+	dialOpts, err := getCoordinatorDialOptions(c.gs, cmd.Flags(), "leader-")
+	if err != nil {
+		return err
+	}
+	conn, client, resp, err := registerWithCoordinator(c.gs, address, dialOpts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+	if resp.Archive != nil {
+		return fmt.Errorf("the coordinator at %s distributes the test, use 'k6 agent' instead", address)
+	}
+	logger := c.gs.Logger.WithField("instance", resp.InstanceID)
+
+	controller, err := distributed.NewAgentController(c.gs.Ctx, resp.InstanceID, client, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := controller.Close(); cerr != nil {
+			logger.WithError(cerr).Debug("The connection with the coordinator wasn't closed cleanly")
+		}
+	}()
+
+	loadConfiguredTest := c.loadConfiguredTest
+	c.loadConfiguredTest = func(cmd *cobra.Command, args []string) (*loadedAndConfiguredTest, execution.Controller, error) {
+		test, _, err := loadConfiguredTest(cmd, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		test.preInitState.RuntimeOptions.NoThresholds = null.BoolFrom(true)
+		return test, controller, nil
+	}
+	c.additionalOutputs = append(c.additionalOutputs, distributed.NewMetricsOutput(resp.InstanceID, client, logger))
+	c.externalAborts = append(c.externalAborts, controller.Aborted())
+	c.instanceID = null.IntFrom(int64(resp.InstanceID))
+	return c.run(cmd, args)
+}
+
+// setupDryRun changes the loaded test, so each scenario runs a single
+// iteration, all of them at the start of the test. The HTTP requests are
+// printed, unless --http-debug is already set, and the thresholds aren't
+// evaluated, since they make no sense for a single iteration.
+func (c *cmdRun) setupDryRun() {
+	loadConfiguredTest := c.loadConfiguredTest
+	c.loadConfiguredTest = func(cmd *cobra.Command, args []string) (*loadedAndConfiguredTest, execution.Controller, error) {
+		test, controller, err := loadConfiguredTest(cmd, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		test.derivedConfig.Scenarios = dryRunScenarios(test.derivedConfig.Scenarios)
+		if !test.derivedConfig.HTTPDebug.Valid {
+			test.derivedConfig.HTTPDebug = null.StringFrom("headers")
+		}
+		test.preInitState.RuntimeOptions.NoThresholds = null.BoolFrom(true)
+		return test, controller, nil
+	}
+}
+
+// dryRunScenarios returns the scenarios with a single iteration of a single
+// VU, with the same functions, environment variables, tags and options.
+func dryRunScenarios(scenarios lib.ScenarioConfigs) lib.ScenarioConfigs {
+	result := make(lib.ScenarioConfigs, len(scenarios))
+	for name, scenario := range scenarios {
+		config := executor.NewPerVUIterationsConfig(name)
+		config.VUs = null.IntFrom(1)
+		config.Iterations = null.IntFrom(1)
+		config.Exec = null.NewString(scenario.GetExec(), len(scenario.GetExecMix()) == 0)
+		config.ExecMix = scenario.GetExecMix()
+		config.Setup = null.NewString(scenario.GetSetup(), scenario.GetSetup() != "")
+		config.Teardown = null.NewString(scenario.GetTeardown(), scenario.GetTeardown() != "")
+		config.Env = scenario.GetEnv()
+		config.Tags = scenario.GetTags()
+		config.Options = scenario.GetScenarioOptions()
+		result[name] = config
+	}
+	return result
+}
+
 func (c *cmdRun) setupTracerProvider(ctx context.Context, test *loadedAndConfiguredTest) error {
 	ro := test.preInitState.RuntimeOptions
 	if ro.TracesOutput.String == "none" {
@@ -483,7 +835,10 @@ func getCmdRun(gs *state.GlobalState) *cobra.Command {
   {{.}} run -u 0 -s 10s:100 -s 60s:100 -s 10s:0
 
   # Send metrics to an influxdb server
-  {{.}} run -o influxdb=http://1.2.3.4:8086/k6`[1:])
+  {{.}} run -o influxdb=http://1.2.3.4:8086/k6
+
+  # Run the first half of a segmented test, with the thresholds evaluated by a coordinator
+  {{.}} run --execution-segment 0:1/2 --execution-segment-sequence 0,1/2,1 --leader leader:6566 script.js`[1:])
 
 	runCmd := &cobra.Command{
 		Use:   "run",
@@ -494,7 +849,26 @@ This also exposes a REST API to interact with it. Various k6 subcommands offer
 a commandline interface for interacting with it.`,
 		Example: exampleText,
 		Args:    exactArgsWithMsg(1, "arg should either be \"-\", if reading script from stdin, or a path to a script file"),
-		RunE:    c.run,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			leader, err := cmd.Flags().GetString("leader")
+			if err != nil {
+				return err
+			}
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				return err
+			}
+			if leader != "" {
+				if dryRun {
+					return errors.New("a dry run can't be synchronized by a coordinator")
+				}
+				return c.runWithLeader(cmd, args, leader)
+			}
+			if dryRun {
+				c.setupDryRun()
+			}
+			return c.run(cmd, args)
+		},
 	}
 
 	runCmd.Flags().SortFlags = false
@@ -10,6 +10,7 @@ import (
 
 	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/lib"
+	testreport "go.k6.io/k6/output/report"
 )
 
 // TODO: move this whole file out of the cmd package? maybe when fixing
@@ -37,8 +38,12 @@ extended: base + Babel with parts of ES2015 preset
 		"",
 		"output the end-of-test summary report to JSON file",
 	)
+	flags.String("report", "", "generate end-of-test reports, e.g. `html=report.html,junit=report.xml`")
+	flags.String("baseline", "", "compare the metrics with the ones of a JSON summary `file`, "+
+		"e.g. of --summary-export, the regressions over the baselineTolerances option fail the test")
 	flags.String("traces-output", "none",
-		"set the output for k6 traces, possible values are none,otel[=host:port]")
+		"set the output for k6 traces, like the spans of the HTTP and gRPC requests, "+
+			"possible values are none,otel[=host:port]")
 	return flags
 }
 
@@ -68,6 +73,8 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 		NoThresholds:         getNullBool(flags, "no-thresholds"),
 		NoSummary:            getNullBool(flags, "no-summary"),
 		SummaryExport:        getNullString(flags, "summary-export"),
+		Report:               getNullString(flags, "report"),
+		Baseline:             getNullString(flags, "baseline"),
 		TracesOutput:         getNullString(flags, "traces-output"),
 		Env:                  make(map[string]string),
 	}
@@ -101,6 +108,23 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 		}
 	}
 
+	if envVar, ok := environment["K6_REPORT"]; ok {
+		if !opts.Report.Valid {
+			opts.Report = null.StringFrom(envVar)
+		}
+	}
+	if opts.Report.String != "" {
+		if _, err := testreport.ParseArgument(opts.Report.String); err != nil {
+			return opts, err
+		}
+	}
+
+	if envVar, ok := environment["K6_BASELINE"]; ok {
+		if !opts.Baseline.Valid {
+			opts.Baseline = null.StringFrom(envVar)
+		}
+	}
+
 	if envVar, ok := environment["SSLKEYLOGFILE"]; ok {
 		if !opts.KeyWriter.Valid {
 			opts.KeyWriter = null.StringFrom(envVar)
@@ -365,6 +365,46 @@ func TestRuntimeOptions(t *testing.T) {
 			cliFlags:  []string{"--no-summary", "true"},
 			expErr:    true,
 		},
+		"report from env": {
+			useSysEnv: false,
+			systemEnv: map[string]string{"K6_REPORT": "html=report.html"},
+			expRTOpts: lib.RuntimeOptions{
+				IncludeSystemEnvVars: null.NewBool(false, false),
+				CompatibilityMode:    defaultCompatMode,
+				Env:                  map[string]string{},
+				Report:               null.NewString("html=report.html", true),
+				TracesOutput:         defaultTracesOutput,
+			},
+		},
+		"report from env overwritten by CLI": {
+			useSysEnv: false,
+			systemEnv: map[string]string{"K6_REPORT": "html=report.html"},
+			cliFlags:  []string{"--report", "junit=report.xml"},
+			expRTOpts: lib.RuntimeOptions{
+				IncludeSystemEnvVars: null.NewBool(false, false),
+				CompatibilityMode:    defaultCompatMode,
+				Env:                  map[string]string{},
+				Report:               null.NewString("junit=report.xml", true),
+				TracesOutput:         defaultTracesOutput,
+			},
+		},
+		"baseline from env overwritten by CLI": {
+			useSysEnv: false,
+			systemEnv: map[string]string{"K6_BASELINE": "previous.json"},
+			cliFlags:  []string{"--baseline", "other.json"},
+			expRTOpts: lib.RuntimeOptions{
+				IncludeSystemEnvVars: null.NewBool(false, false),
+				CompatibilityMode:    defaultCompatMode,
+				Env:                  map[string]string{},
+				Baseline:             null.NewString("other.json", true),
+				TracesOutput:         defaultTracesOutput,
+			},
+		},
+		"invalid report format": {
+			useSysEnv: false,
+			cliFlags:  []string{"--report", "pdf=report.pdf"},
+			expErr:    true,
+		},
 		"traces output default": {
 			useSysEnv: false,
 			expRTOpts: lib.RuntimeOptions{
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/local"
+	"go.k6.io/k6/lib/cron"
+)
+
+const (
+	// scheduleRunTag is the tag with the number of the scheduled run, which
+	// all of the metrics of the run have.
+	scheduleRunTag = "schedule_run"
+	// scheduleRunPlaceholder is replaced with the number of the scheduled run
+	// in the outputs and the summary export, so each run has its own files.
+	scheduleRunPlaceholder = "{run}"
+)
+
+// cmdSchedule handles the `k6 schedule` sub-command
+type cmdSchedule struct {
+	gs *state.GlobalState
+
+	cron string
+	runs int64
+}
+
+func (c *cmdSchedule) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringVar(&c.cron, "cron", "", "the cron `expression` of when the test runs, with an optional seconds field")
+	flags.Int64Var(&c.runs, "runs", 0, "stop the schedule after this many runs, 0 to keep running until Ctrl+C")
+	flags.AddFlagSet(optionFlagSet())
+	flags.AddFlagSet(runtimeOptionFlagSet(true))
+	flags.AddFlagSet(configFlagSet())
+	flags.String("ui", uiProgress, "the `type` of the UI during the test runs, "+uiProgress+" for the progress bars "+
+		"or "+uiTUI+" for an interactive terminal UI")
+	return flags
+}
+
+func (c *cmdSchedule) run(cmd *cobra.Command, args []string) error {
+	if c.cron == "" {
+		return errext.WithExitCodeIfNone(errors.New("the --cron expression is required"), exitcodes.InvalidConfig)
+	}
+	if args[0] == "-" {
+		return errext.WithExitCodeIfNone(
+			errors.New("the script of a schedule can't be read from stdin, since it's loaded for each run"),
+			exitcodes.InvalidConfig)
+	}
+	schedule, err := cron.Parse(c.cron)
+	if err != nil {
+		return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+
+	var failed int64
+	run := int64(1)
+	for ; c.runs == 0 || run <= c.runs; run++ {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			return errext.WithExitCodeIfNone(
+				fmt.Errorf("the cron expression '%s' doesn't match any time in the next years", schedule),
+				exitcodes.InvalidConfig)
+		}
+		c.gs.Logger.Infof("The run %d of the test is scheduled at %s", run, next.Format(time.RFC3339))
+		if !c.waitUntil(next) {
+			c.gs.Logger.Info("The schedule was stopped")
+			break
+		}
+
+		err := c.runOnce(cmd, args, run)
+		var ecerr errext.HasExitCode
+		if errors.As(err, &ecerr) && ecerr.ExitCode() == exitcodes.ExternalAbort {
+			return err
+		}
+		if err != nil {
+			failed++
+			c.gs.Logger.WithError(err).Errorf("The run %d of the test failed", run)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of the %d scheduled runs failed", failed, run-1)
+	}
+	return nil
+}
+
+// waitUntil waits for the time of the next run and returns false if the
+// schedule was stopped before it, with Ctrl+C or a signal.
+func (c *cmdSchedule) waitUntil(next time.Time) bool {
+	sigC := make(chan os.Signal, 1)
+	c.gs.SignalNotify(sigC, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer c.gs.SignalStop(sigC)
+
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-sigC:
+		return false
+	case <-c.gs.Ctx.Done():
+		return false
+	}
+}
+
+// runOnce runs the test like `k6 run` does. The test is loaded again for
+// each run, so changes of the script are picked up, and the outputs are
+// connected anew, so an output that was unavailable can be used again.
+func (c *cmdSchedule) runOnce(cmd *cobra.Command, args []string, run int64) error {
+	runCmd := &cmdRun{
+		gs: c.gs,
+		loadConfiguredTest: func(cmd *cobra.Command, args []string) (*loadedAndConfiguredTest, execution.Controller, error) {
+			test, err := loadAndConfigureLocalTest(c.gs, cmd, args, getConfig)
+			if err != nil {
+				return nil, nil, err
+			}
+			separateRunResults(test, run)
+			return test, local.NewController(), nil
+		},
+	}
+	return runCmd.run(cmd, args)
+}
+
+// separateRunResults tags all of the metrics of the scheduled run with its
+// number, which also replaces the {run} placeholder in the outputs and the
+// summary export.
+func separateRunResults(test *loadedAndConfiguredTest, run int64) {
+	runID := strconv.FormatInt(run, 10)
+
+	conf := &test.derivedConfig
+	runTags := make(map[string]string, len(conf.RunTags)+1)
+	for k, v := range conf.RunTags {
+		runTags[k] = v
+	}
+	runTags[scheduleRunTag] = runID
+	conf.RunTags = runTags
+
+	outs := make([]string, len(conf.Out))
+	for i, out := range conf.Out {
+		outs[i] = strings.ReplaceAll(out, scheduleRunPlaceholder, runID)
+	}
+	conf.Out = outs
+
+	summaryExport := &test.preInitState.RuntimeOptions.SummaryExport
+	if summaryExport.Valid {
+		summaryExport.String = strings.ReplaceAll(summaryExport.String, scheduleRunPlaceholder, runID)
+	}
+}
+
+func getCmdSchedule(gs *state.GlobalState) *cobra.Command {
+	c := &cmdSchedule{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Run the test every 15 minutes, until Ctrl+C.
+  {{.}} schedule --cron "*/15 * * * *" script.js
+
+  # Run the test at 3am on weekdays, with the results of each run in its own file.
+  {{.}} schedule --cron "0 3 * * mon-fri" -o json=results-{run}.json script.js
+
+  # Run the test 5 times, 30 seconds apart.
+  {{.}} schedule --cron "@every 30s" --runs 5 script.js`[1:])
+
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run a test repeatedly on a cron schedule",
+		Long: `Run a test repeatedly on a cron schedule.
+
+This command keeps running and starts a run of the test, like k6 run does, at
+every time that the cron expression matches. The times that pass while a run is
+still going are skipped, so runs never overlap. The test is loaded again for
+each run and the outputs are connected anew, so a run isn't affected by the
+failures of the previous ones.
+
+The metrics of each run have the schedule_run tag with its number, which also
+replaces the {run} placeholder in the --out and --summary-export values.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should be a path to a script file"),
+		RunE:    c.run,
+	}
+
+	scheduleCmd.Flags().SortFlags = false
+	scheduleCmd.Flags().AddFlagSet(c.flagSet())
+
+	return scheduleCmd
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/ext"
+	"go.k6.io/k6/secretsource"
+	awssecrets "go.k6.io/k6/secretsource/aws"
+	filesecrets "go.k6.io/k6/secretsource/file"
+	"go.k6.io/k6/secretsource/gcp"
+	"go.k6.io/k6/secretsource/vault"
+)
+
+func getAllSecretSourceConstructors() (map[string]secretsource.Constructor, error) {
+	result := map[string]secretsource.Constructor{
+		"aws":   awssecrets.New,
+		"file":  filesecrets.New,
+		"gcp":   gcp.New,
+		"vault": vault.New,
+	}
+
+	for _, e := range ext.Get(ext.SecretSourceExtension) {
+		if _, ok := result[e.Name]; ok {
+			return nil, fmt.Errorf("invalid secret source extension %s, "+
+				"built-in secret source with the same type already exists", e.Name)
+		}
+		c, ok := e.Module.(secretsource.Constructor)
+		if !ok {
+			return nil, fmt.Errorf("unexpected secret source extension type %T", e.Module)
+		}
+		result[e.Name] = c
+	}
+
+	return result, nil
+}
+
+// setupSecretSources creates the secret sources of the --secret-source flags,
+// and redacts the secrets from the logs.
+func setupSecretSources(gs *state.GlobalState) error {
+	if len(gs.Flags.SecretSources) == 0 {
+		return nil
+	}
+	constructors, err := getAllSecretSourceConstructors()
+	if err != nil {
+		return err
+	}
+
+	sources := make(map[string]secretsource.Source, len(gs.Flags.SecretSources))
+	var defaultSource string
+	for _, line := range gs.Flags.SecretSources {
+		sourceType, config, _ := strings.Cut(line, "=")
+		constructor, ok := constructors[sourceType]
+		if !ok {
+			return fmt.Errorf("invalid secret source type '%s'", sourceType)
+		}
+		if _, ok := sources[sourceType]; ok {
+			return fmt.Errorf("the secret source '%s' is configured more than once", sourceType)
+		}
+		source, err := constructor(secretsource.Params{
+			SourceType:     sourceType,
+			ConfigArgument: config,
+			Logger:         gs.Logger.WithField("secret-source", sourceType),
+			Environment:    gs.Env,
+			FS:             gs.FS,
+			Getwd:          gs.Getwd,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create the '%s' secret source: %w", sourceType, err)
+		}
+		sources[sourceType] = source
+		if defaultSource == "" {
+			defaultSource = sourceType
+		}
+	}
+
+	gs.SecretsManager, err = secretsource.NewManager(sources, defaultSource)
+	if err != nil {
+		return err
+	}
+	gs.RedactionHook.Add(gs.SecretsManager)
+	return nil
+}
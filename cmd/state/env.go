@@ -1,6 +1,10 @@
 package state
 
-import "strings"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // ParseEnvKeyValue splits an environment variable string into key and value.
 func ParseEnvKeyValue(kv string) (string, string) {
@@ -20,3 +24,40 @@ func BuildEnvMap(environ []string) map[string]string {
 	}
 	return env
 }
+
+// ParseEnvFile parses the variables in a .env file, with a KEY=value pair on
+// each line. The lines can start with export, the values can be in single or
+// double quotes, and the empty lines and the ones starting with # are ignored.
+func ParseEnvFile(data []byte) (map[string]string, error) {
+	env := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d isn't in the form KEY=value", i+1)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d has an invalid quoted value: %w", i+1, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			// the comments after the unquoted values
+			if idx := strings.Index(value, " #"); idx != -1 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+		env[key] = value
+	}
+	return env, nil
+}
@@ -14,6 +14,8 @@ import (
 
 	"go.k6.io/k6/event"
 	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/log"
+	"go.k6.io/k6/secretsource"
 	"go.k6.io/k6/ui/console"
 )
 
@@ -54,6 +56,14 @@ type GlobalState struct {
 
 	Logger         *logrus.Logger //nolint:forbidigo //TODO:change to FieldLogger
 	FallbackLogger logrus.FieldLogger
+
+	// RedactionHook redacts the sensitive data, like the secrets, from the
+	// logs, it's added to the loggers before any other hook.
+	RedactionHook *log.RedactionHook
+
+	// SecretsManager gives access to the configured secret sources, it's set
+	// up with the loggers, since it redacts the secrets from them.
+	SecretsManager *secretsource.Manager
 }
 
 // NewGlobalState returns a new GlobalState with the given ctx.
@@ -106,23 +116,24 @@ func NewGlobalState(ctx context.Context) *GlobalState {
 	defaultFlags := GetDefaultFlags(confDir)
 
 	return &GlobalState{
-		Ctx:          ctx,
-		FS:           fsext.NewOsFs(),
-		Getwd:        os.Getwd,
-		BinaryName:   filepath.Base(binary),
-		CmdArgs:      os.Args,
-		Env:          env,
-		Events:       event.NewEventSystem(100, logger),
-		DefaultFlags: defaultFlags,
-		Flags:        getFlags(defaultFlags, env),
-		OutMutex:     outMutex,
-		Stdout:       stdout,
-		Stderr:       stderr,
-		Stdin:        os.Stdin,
-		OSExit:       os.Exit,
-		SignalNotify: signal.Notify,
-		SignalStop:   signal.Stop,
-		Logger:       logger,
+		Ctx:           ctx,
+		FS:            fsext.NewOsFs(),
+		Getwd:         os.Getwd,
+		BinaryName:    filepath.Base(binary),
+		CmdArgs:       os.Args,
+		Env:           env,
+		Events:        event.NewEventSystem(100, logger),
+		DefaultFlags:  defaultFlags,
+		Flags:         getFlags(defaultFlags, env),
+		OutMutex:      outMutex,
+		Stdout:        stdout,
+		Stderr:        stderr,
+		Stdin:         os.Stdin,
+		OSExit:        os.Exit,
+		SignalNotify:  signal.Notify,
+		SignalStop:    signal.Stop,
+		Logger:        logger,
+		RedactionHook: log.NewRedactionHook(),
 		FallbackLogger: &logrus.Logger{ // we may modify the other one
 			Out:       stderr,
 			Formatter: new(logrus.TextFormatter), // no fancy formatting here
@@ -138,10 +149,14 @@ type GlobalFlags struct {
 	Quiet            bool
 	NoColor          bool
 	Address          string
+	ControlAddress   string
 	ProfilingEnabled bool
 	LogOutput        string
 	LogFormat        string
 	Verbose          bool
+	SecretSources    []string
+	EnvFiles         []string
+	Profile          string
 }
 
 // GetDefaultFlags returns the default global flags.
@@ -169,6 +184,9 @@ func getFlags(defaultFlags GlobalFlags, env map[string]string) GlobalFlags {
 	if val, ok := env["K6_LOG_FORMAT"]; ok {
 		result.LogFormat = val
 	}
+	if val, ok := env["K6_PROFILE"]; ok {
+		result.Profile = val
+	}
 	if env["K6_NO_COLOR"] != "" {
 		result.NoColor = true
 	}
@@ -66,26 +66,13 @@ func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*l
 		return nil, err
 	}
 
-	registry := metrics.NewRegistry()
-	state := &lib.TestPreInitState{
-		Logger:         gs.Logger,
-		RuntimeOptions: runtimeOptions,
-		Registry:       registry,
-		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
-		Events:         gs.Events,
-		LookupEnv: func(key string) (string, bool) {
-			val, ok := gs.Env[key]
-			return val, ok
-		},
-	}
-
 	test := &loadedTest{
 		pwd:            pwd,
 		sourceRootPath: sourceRootPath,
 		source:         src,
 		fs:             gs.FS,
 		fileSystems:    fileSystems,
-		preInitState:   state,
+		preInitState:   newPreInitState(gs, runtimeOptions),
 	}
 
 	gs.Logger.Debugf("Initializing k6 runner for '%s' (%s)...", sourceRootPath, resolvedPath)
@@ -96,6 +83,22 @@ func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*l
 	return test, nil
 }
 
+func newPreInitState(gs *state.GlobalState, runtimeOptions lib.RuntimeOptions) *lib.TestPreInitState {
+	registry := metrics.NewRegistry()
+	return &lib.TestPreInitState{
+		Logger:         gs.Logger,
+		RuntimeOptions: runtimeOptions,
+		Registry:       registry,
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Events:         gs.Events,
+		SecretsManager: gs.SecretsManager,
+		LookupEnv: func(key string) (string, bool) {
+			val, ok := gs.Env[key]
+			return val, ok
+		},
+	}
+}
+
 func (lt *loadedTest) initializeFirstRunner(gs *state.GlobalState) error {
 	testPath := lt.source.URL.String()
 	logger := gs.Logger.WithField("test_path", testPath)
@@ -171,7 +174,7 @@ func readSource(gs *state.GlobalState, filename string) (*loader.SourceData, map
 		return nil, nil, "", err
 	}
 
-	filesystems := loader.CreateFilesystems(gs.FS)
+	filesystems := loader.CreateFilesystems(gs.FS, gs.Env)
 	src, err := loader.ReadSource(gs.Logger, filename, pwd, filesystems, gs.Stdin)
 	return src, filesystems, pwd, err
 }
@@ -203,6 +206,15 @@ func (lt *loadedTest) consolidateDeriveAndValidateConfig(
 		return nil, err
 	}
 
+	// The metrics of the SLOs and the derived metrics are registered before
+	// the thresholds are validated, since the thresholds can be defined on them.
+	if err = registerSLOMetrics(lt.preInitState.Registry, consolidatedConfig.SLOs); err != nil {
+		return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+	if err = registerDerivedMetrics(lt.preInitState.Registry, consolidatedConfig.DerivedMetrics); err != nil {
+		return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+
 	gs.Logger.Debug("Parsing thresholds and validating config...")
 	// Parse the thresholds, only if the --no-threshold flag is not set.
 	// If parsing the threshold expressions failed, consider it as an
@@ -233,6 +245,39 @@ func (lt *loadedTest) consolidateDeriveAndValidateConfig(
 	}, nil
 }
 
+// registerDerivedMetrics registers the gauge metrics of the derived metrics,
+// their names can't be the ones of the other metrics.
+func registerDerivedMetrics(registry *metrics.Registry, derivedMetrics map[string]string) error {
+	for name := range derivedMetrics {
+		if registry.Get(name) != nil {
+			return fmt.Errorf("invalid derived metric '%s': there is already a metric with the same name", name)
+		}
+		if _, err := registry.NewMetric(name, metrics.Gauge); err != nil {
+			return fmt.Errorf("invalid derived metric '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// registerSLOMetrics registers the compliance and burn rate metrics of the
+// SLOs, their names can't be the ones of the other metrics.
+func registerSLOMetrics(registry *metrics.Registry, slos map[string]lib.SLO) error {
+	for name := range slos {
+		for _, m := range []struct {
+			name string
+			typ  metrics.MetricType
+		}{{lib.SLOComplianceMetric(name), metrics.Rate}, {lib.SLOBurnRateMetric(name), metrics.Gauge}} {
+			if registry.Get(m.name) != nil {
+				return fmt.Errorf("invalid SLO '%s': there is already a metric named '%s'", name, m.name)
+			}
+			if _, err := registry.NewMetric(m.name, m.typ); err != nil {
+				return fmt.Errorf("invalid SLO '%s': %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
 // loadedAndConfiguredTest contains the whole loadedTest, as well as the
 // consolidated test config and the full test run state.
 type loadedAndConfiguredTest struct {
@@ -206,6 +206,40 @@ func TestRelativeLogPathWithSetupAndTeardown(t *testing.T) {
 	assert.Equal(t, "init\ninit\ninit\nbar\nfoo\nfoo\ninit\nbaz\ninit\n", string(logContents)) //nolint:dupword
 }
 
+func TestJSONLinesLogFileWithErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{
+		"k6", "--log-output", "file=test.log,format=json,errors=test-errors.log",
+		"run", "-i", "2", "--no-summary", "-",
+	}
+	ts.Stdin = bytes.NewBufferString(`
+		import exec from 'k6/execution';
+		export default function() {
+			console.log('iteration ' + exec.vu.iterationInInstance);
+			if (exec.vu.iterationInInstance == 1) {
+				console.error('failed');
+			}
+		};
+	`)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	logContents, err := fsext.ReadFile(ts.FS, filepath.Join(ts.Cwd, "test.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(logContents),
+		`"iteration":0,"level":"info","msg":"iteration 0","scenario":"default","source":"console","time":`)
+	assert.Contains(t, string(logContents),
+		`"iteration":1,"level":"info","msg":"iteration 1","scenario":"default","source":"console","time":`)
+
+	errorContents, err := fsext.ReadFile(ts.FS, filepath.Join(ts.Cwd, "test-errors.log"))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(errorContents)), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"iteration":1,"level":"error","msg":"failed"`)
+	assert.Contains(t, lines[0], `"vu":1}`)
+}
+
 func TestWrongCliFlagIterations(t *testing.T) {
 	t.Parallel()
 
@@ -218,6 +252,32 @@ func TestWrongCliFlagIterations(t *testing.T) {
 	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel, `invalid argument "foo"`))
 }
 
+func TestRunUI(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		ts := NewGlobalTestState(t)
+		ts.CmdArgs = []string{"k6", "run", "--ui", "fancy", "-"}
+		ts.Stdin = bytes.NewBufferString(`export default function() {};`)
+		ts.ExpectedExitCode = int(exitcodes.InvalidConfig)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+			"invalid UI 'fancy', it can be progress or tui"))
+	})
+
+	t.Run("tui without a terminal", func(t *testing.T) {
+		t.Parallel()
+		ts := NewGlobalTestState(t)
+		ts.CmdArgs = []string{"k6", "run", "--ui", "tui", "-"}
+		ts.Stdin = bytes.NewBufferString(`export default function() {};`)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.WarnLevel,
+			"The terminal UI needs an interactive terminal"))
+		assert.Contains(t, ts.Stdout.String(), "1 complete and 0 interrupted iterations")
+	})
+}
+
 func TestWrongEnvVarIterations(t *testing.T) {
 	t.Parallel()
 
@@ -384,6 +444,40 @@ func testSSLKEYLOGFILE(t *testing.T, ts *GlobalTestState, filePath string) {
 	assert.Regexp(t, "^CLIENT_[A-Z_]+ [0-9a-f]+ [0-9a-f]+\n", string(sslloglines))
 }
 
+func TestHAROut(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	harPath := filepath.Join(t.TempDir(), "out.har")
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "run", "--har-out", harPath, "--har-sample-rate", "0", "-"}
+	ts.Stdin = bytes.NewReader([]byte(tb.Replacer.Replace(`
+		import http from "k6/http";
+		export default () => {
+			http.get("HTTPBIN_IP_URL/get");
+			http.get("HTTPBIN_IP_URL/status/404");
+		}
+	`)))
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	data, err := os.ReadFile(harPath) //nolint:forbidigo
+	require.NoError(t, err)
+	var har struct {
+		Log struct {
+			Entries []struct {
+				Request  struct{ URL string }
+				Response struct{ Status int }
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(data, &har))
+	// only the failed request is exported, as none of the others are sampled
+	require.Len(t, har.Log.Entries, 1)
+	assert.Equal(t, tb.Replacer.Replace("HTTPBIN_IP_URL/status/404"), har.Log.Entries[0].Request.URL)
+	assert.Equal(t, http.StatusNotFound, har.Log.Entries[0].Response.Status)
+}
+
 func TestThresholdDeprecationWarnings(t *testing.T) {
 	t.Parallel()
 
@@ -440,7 +534,7 @@ func TestExecutionTestOptionsDefaultValues(t *testing.T) {
 	loglines := ts.LoggerHook.Drain()
 	require.Len(t, loglines, 1)
 
-	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"rps":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"batch":null,"batchPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsAuth":null,"throw":null,"thresholds":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"metricSamplesBufferSize":null,"noCookiesReset":null,"discardResponseBodies":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null}},"localIPs":null}`
+	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"setupDataMaxSize":null,"rps":null,"rateLimits":null,"faults":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"batch":null,"batchPerHost":null,"maxConns":null,"maxConnsPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsAuth":null,"tlsClientCerts":null,"throw":null,"thresholds":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"summaryBreakdownTags":null,"scenarioBreakdown":null,"trendPrecision":null,"timeSeriesResolution":null,"counterRateWindows":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"outputFilters":null,"outputAggregation":null,"cardinalityLimit":null,"cardinalityCollapseTags":null,"derivedMetrics":null,"baselineTolerances":null,"slos":null,"metricSamplesBufferSize":null,"noCookiesReset":null,"discardResponseBodies":null,"correlationID":null,"metadataHeaders":null,"httpCache":null,"captureRequests":null,"captureSlowerThan":null,"captureMaxSize":null,"harOut":null,"harSampleRate":null,"selfMetrics":null,"schedulingDelayBudget":null,"autoCalibrate":null,"maxMemory":null,"testName":null,"runId":null,"testVersion":null,"testEnvironment":null,"redact":null,"exitCodes":null,"localIPsSelect":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null,"setup":null,"teardown":null,"iterationTimeout":null,"warmupIterations":null}},"localIPs":null}`
 	assert.JSONEq(t, expected, loglines[0].Message)
 }
 
@@ -2350,3 +2444,1423 @@ func TestSetupTimeout(t *testing.T) {
 	t.Log(stderr)
 	assert.Contains(t, stderr, "setup() execution timed out after 1 seconds")
 }
+
+func TestOutputFilters(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			iterations: 2,
+			outputFilters: {
+				json: { includeMetrics: ['my_*'], denyTags: ['scenario'] },
+				csv: { excludeMetrics: ['my_*'] },
+			},
+		};
+
+		const c = new Counter('my_counter');
+
+		export default function () {
+			c.add(1, { kind: 'test' });
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--out", "json=results.json"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), sum(getSampleValues(t, jsonResults, "my_counter", map[string]string{"kind": "test"})))
+	assert.Empty(t, getSampleValues(t, jsonResults, "iterations", nil))
+	assert.NotContains(t, string(jsonResults), `"scenario"`)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.WarnLevel,
+		"There is a filter for the 'csv' output, but the output isn't used"))
+}
+
+func TestSelfMetrics(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { setTimeout } from 'k6/timers';
+
+		export const options = {
+			scenarios: {
+				default: { executor: 'constant-vus', vus: 1, duration: '1200ms', gracefulStop: '0s' },
+			},
+		};
+
+		export default function () {
+			return new Promise((resolve) => setTimeout(resolve, 100));
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--self-metrics", "--out", "json=results.json"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.NotEmpty(t, getSampleValues(t, jsonResults, "k6_event_loop_lag", map[string]string{"scenario": "default"}))
+	assert.NotEmpty(t, getSampleValues(t, jsonResults, "k6_memory_usage", nil))
+	assert.NotEmpty(t, getSampleValues(t, jsonResults, "k6_samples_backlog", nil))
+	assert.NotEmpty(t, getSampleValues(t, jsonResults, "k6_output_flush_duration", nil))
+
+	// they aren't emitted by default
+	ts = getSingleFileTestState(t, script, []string{"--out", "json=results.json"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	jsonResults, err = fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.NotContains(t, string(jsonResults), "k6_")
+}
+
+func TestMaxMemory(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { sleep } from 'k6';
+
+		export const options = {
+			scenarios: {
+				default: { executor: 'constant-vus', vus: 1, duration: '30s', gracefulStop: '0s' },
+			},
+		};
+
+		export default function () {
+			sleep(0.1);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--max-memory", "1000"}, exitcodes.MemoryLimitExceeded)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	// the results are still summarized
+	assert.Contains(t, ts.Stdout.String(), "data_received")
+	stderr := ts.Stderr.String()
+	assert.Contains(t, stderr, "over the maxMemory limit of 0.0 MB")
+	assert.Contains(t, stderr, "test run was aborted because k6 used")
+}
+
+func TestOutputAggregation(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			iterations: 5,
+			outputAggregation: {
+				json: { period: '1h', tags: ['kind'], rawMetrics: ['iterations'] },
+				csv: { period: '1s' },
+			},
+		};
+
+		const c = new Counter('my_counter');
+
+		export default function () {
+			c.add(1, { kind: 'test' });
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--out", "json=results.json"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	counterValues := getSampleValues(t, jsonResults, "my_counter", nil)
+	assert.Equal(t, counterValues, getSampleValues(t, jsonResults, "my_counter",
+		map[string]string{"kind": "test", "aggregation": "sum"}))
+	assert.Less(t, len(counterValues), 5)
+	assert.Equal(t, float64(5), sum(counterValues))
+	assert.Len(t, getSampleValues(t, jsonResults, "iterations", map[string]string{"scenario": "default"}), 5)
+	assert.NotEmpty(t, getSampleValues(t, jsonResults, "iteration_duration", map[string]string{"aggregation": "p(95)"}))
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.WarnLevel,
+		"There is an aggregation for the 'csv' output, but the output isn't used"))
+}
+
+func TestCardinalityLimit(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+		import exec from 'k6/execution';
+
+		export const options = { iterations: 5 };
+
+		const c = new Counter('my_counter');
+
+		export default function () {
+			c.add(1, { id: String(exec.scenario.iterationInTest) });
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{
+		"--cardinality-limit", "2", "--cardinality-collapse-tags", "id", "--out", "json=results.json",
+	}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.Equal(t, float64(5), sum(getSampleValues(t, jsonResults, "my_counter", nil)))
+	assert.Len(t, getSampleValues(t, jsonResults, "my_counter", map[string]string{"id": "[collapsed]"}), 3)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.WarnLevel,
+		"The metric 'my_counter' has more than 2 time series (distinct tag sets), the id tags of its new time "+
+			"series will be collapsed"))
+}
+
+func TestDerivedMetrics(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			iterations: 4,
+			derivedMetrics: {
+				error_ratio: 'errors.count / iterations.count',
+			},
+			thresholds: {
+				error_ratio: ['value<0.2'],
+			},
+		};
+
+		const errors = new Counter('errors');
+
+		export default function () {
+			errors.add(1);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Regexp(t, `✗ error_ratio\.+: 1\s`, ts.Stdout.String())
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+		"thresholds on metrics 'error_ratio' have been crossed"))
+}
+
+func TestDerivedMetricsOutput(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			scenarios: {
+				api: { executor: 'shared-iterations', iterations: 4 },
+				web: { executor: 'shared-iterations', iterations: 4, startTime: '10ms' },
+			},
+			derivedMetrics: {
+				api_ratio: 'errors{scenario:api}.count / iterations.count',
+			},
+		};
+
+		const errors = new Counter('errors');
+
+		export default function () {
+			errors.add(1);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--no-thresholds", "--out", "json=results.json"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	values := getSampleValues(t, jsonResults, "api_ratio", nil)
+	require.NotEmpty(t, values)
+	assert.Equal(t, 0.5, values[len(values)-1])
+}
+
+func TestDerivedMetricsNameConflict(t *testing.T) {
+	t.Parallel()
+	script := `
+		export const options = {
+			derivedMetrics: { iterations: 'vus.value * 2' },
+		};
+		export default function () {}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+		"invalid derived metric 'iterations': there is already a metric with the same name"))
+}
+
+func TestSummaryBreakdownTags(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			scenarios: {
+				first: { executor: 'shared-iterations', iterations: 2 },
+				second: { executor: 'shared-iterations', iterations: 3 },
+			},
+		};
+
+		const errors = new Counter('errors');
+
+		export default function () {
+			errors.add(1);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--summary-breakdown-tags", "scenario"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Regexp(t, `errors\.+: 5\s`, stdout)
+	assert.Regexp(t, `{ scenario:first }\.+: 2\s`, stdout)
+	assert.Regexp(t, `{ scenario:second }\.+: 3\s`, stdout)
+}
+
+func TestScenarioBreakdown(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			scenarios: {
+				first: { executor: 'shared-iterations', iterations: 2 },
+				second: { executor: 'shared-iterations', iterations: 3 },
+			},
+		};
+
+		const errors = new Counter('errors');
+
+		export default function () {
+			errors.add(1);
+		}
+	`
+
+	t.Run("submetrics", func(t *testing.T) {
+		t.Parallel()
+		ts := getSingleFileTestState(t, script, []string{"--scenario-breakdown", "submetrics"}, 0)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		stdout := ts.Stdout.String()
+		assert.Regexp(t, `errors\.+: 5\s`, stdout)
+		assert.Regexp(t, `{ scenario:first }\.+: 2\s`, stdout)
+		assert.Regexp(t, `{ scenario:second }\.+: 3\s`, stdout)
+	})
+
+	t.Run("sections", func(t *testing.T) {
+		t.Parallel()
+		ts := getSingleFileTestState(t, script, []string{"--scenario-breakdown", "sections"}, 0)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		stdout := ts.Stdout.String()
+		assert.NotContains(t, stdout, "{ scenario:")
+		assert.Regexp(t, `(?s)errors\.+: 5\s.*█ SCENARIO first\s+.*errors\.+: 2\s.*█ SCENARIO second\s+.*errors\.+: 3\s`,
+			stdout)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		ts := getSingleFileTestState(t, script, []string{"--scenario-breakdown", "tags"}, exitcodes.InvalidConfig)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		assert.Contains(t, ts.Stderr.String(), "scenarioBreakdown must be one of")
+	})
+}
+
+func TestTimeSeriesResolution(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			iterations: 5,
+		};
+
+		const errors = new Counter('errors');
+
+		export default function () {
+			errors.add(1);
+		}
+
+		export function handleSummary(data) {
+			const periods = data.metrics.errors.timeSeries;
+			const total = periods.reduce((sum, p) => sum + p.values.count, 0);
+			return {
+				stdout: 'resolution=' + data.state.timeSeriesResolutionMs + ' total=' + total +
+					' aligned=' + periods.every((p) => p.time % 2000 === 0),
+			};
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--time-series-resolution", "2s"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(), "resolution=2000 total=5 aligned=true")
+}
+
+func TestCounterRateWindows(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			iterations: 5,
+			thresholds: {
+				errors: ['rate1m>=5'],
+			},
+		};
+
+		const errors = new Counter('errors');
+
+		export default function () {
+			errors.add(2);
+		}
+
+		export function handleSummary(data) {
+			const values = data.metrics.errors.values;
+			const stats = Object.keys(values).filter((k) => k.startsWith('rate') && k !== 'rate').sort();
+			return {
+				stdout: 'stats=' + stats.join(',') + ' ok=' + data.metrics.errors.thresholds['rate1m>=5'].ok,
+			};
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--counter-rate-windows", "10s"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(), "stats=rate10s,rate1m ok=true")
+}
+
+func TestSLOs(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Trend } from 'k6/metrics';
+
+		export const options = {
+			iterations: 4,
+			slos: {
+				latency: { metric: 'latency', condition: '<300', objective: 0.9 },
+			},
+			thresholds: {
+				latency_compliance: ['rate>=0.9'],
+			},
+		};
+
+		const latency = new Trend('latency', true);
+
+		export default function () {
+			latency.add(__ITER == 0 ? 500 : 100);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "✗ latency: 75% of latency <300, objective 90%, burn rate 2.5")
+	assert.Regexp(t, `✗ latency_compliance\.+: 75.00%`, stdout)
+	assert.Regexp(t, `latency_burn_rate\.+: 2.5`, stdout)
+}
+
+func TestThresholdsIgnoreFirst(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { sleep } from 'k6';
+		import { Trend } from 'k6/metrics';
+
+		export const options = {
+			iterations: 2,
+			thresholds: {
+				latency: [{ threshold: 'max<300', ignoreFirst: '1s' }],
+			},
+		};
+
+		const latency = new Trend('latency', true);
+
+		export default function () {
+			if (__ITER == 0) {
+				latency.add(500);
+				sleep(1.5);
+				return;
+			}
+			latency.add(100);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Regexp(t, `✓ latency\.+: .* max=500ms`, stdout)
+}
+
+func TestThresholdsOnSubmetrics(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { group } from 'k6';
+		import { Trend } from 'k6/metrics';
+
+		export const options = {
+			iterations: 2,
+			thresholds: {
+				'latency': ['max of {group:::checkout} < 1.5 * max of {group:::browse}'],
+				'latency{step:list}': ['max of {group:::browse} < 100'],
+			},
+		};
+
+		const latency = new Trend('latency', true);
+
+		export default function () {
+			group('browse', () => {
+				latency.add(200, { step: 'list' });
+			});
+			group('checkout', () => {
+				latency.add(250);
+			});
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Regexp(t, `✓ latency\.+: .* max=250ms`, stdout)
+	assert.Regexp(t, `✗ \{ step:list \}\.+: .* max=200ms`, stdout)
+	assert.Regexp(t, `\{ group:::checkout \}\.+: .* max=250ms`, stdout)
+	assert.Regexp(t, `\{ step:list,group:::browse \}\.+: .* max=200ms`, stdout)
+	assert.Contains(t, stdout, `level=error msg="thresholds on metrics 'latency{step:list}' have been crossed"`)
+}
+
+func TestAbortWithExitCodeFromMetricValues(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+		import { sleep } from 'k6';
+		import { Trend } from 'k6/metrics';
+
+		export const options = {
+			iterations: 20,
+		};
+
+		const latency = new Trend('latency', true);
+
+		export default function () {
+			latency.add(500);
+			sleep(0.1);
+			const values = exec.test.metric('latency');
+			if (values && values['p(95)'] > 300) {
+				exec.test.abort('latency too high: ' + values['p(95)'], { exitCode: 42 });
+			}
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 42)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "test aborted: latency too high: 500")
+}
+
+func TestReports(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { check, group } from 'k6';
+
+		export const options = {
+			iterations: 3,
+			thresholds: {
+				checks: ['rate>0.9'],
+			},
+		};
+
+		export default function () {
+			check(null, { 'always passes': () => true });
+			group('login', () => {
+				check(null, { 'always fails': () => false });
+			});
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--report", "html=report.html,junit=report.xml"},
+		exitcodes.ThresholdsHaveFailed)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(), "report (html=report.html,junit=report.xml)")
+
+	junit, err := fsext.ReadFile(ts.FS, "report.xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(junit), `<testsuites name="k6" tests="3" failures="2"`)
+	assert.Contains(t, string(junit), `<testcase name="always fails" classname="k6.checks.login">`)
+
+	html, err := fsext.ReadFile(ts.FS, "report.html")
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Thresholds failed")
+	assert.Contains(t, string(html), "<figcaption>iterations")
+}
+
+func TestReportsInvalidFormat(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "run", "--report", "pdf=report.pdf", "-"}
+	ts.Stdin = bytes.NewBufferString(`export default function() {};`)
+	ts.ExpectedExitCode = -1
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel, "invalid report format 'pdf'"))
+}
+
+func TestBaselineRegression(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			iterations: 10,
+			baselineTolerances: {
+				'errors.count': '50%',
+				'iterations.count': '-10%',
+			},
+		};
+
+		const errors = new Counter('errors');
+
+		export default function () {
+			errors.add(1);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--baseline", "previous.json"}, exitcodes.BaselineRegression)
+	require.NoError(t, fsext.WriteFile(ts.FS, "previous.json", []byte(`{"metrics": {
+		"errors": {"count": 2, "rate": 1},
+		"iterations": {"count": 10, "rate": 5}
+	}}`), 0o644))
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "Compared with the baseline previous.json")
+	assert.Regexp(t, `errors\s+count\s+2\s+10\s+\+400.00%\s+-\s+\+50% ✗ regression`, stdout)
+	assert.Regexp(t, `iterations\s+count\s+10\s+10\s+\+0.00%\s+-\s+-10% ✓`, stdout)
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+		"metrics 'errors.count' have regressed from the baseline"))
+}
+
+func TestBaselineMissingFile(t *testing.T) {
+	t.Parallel()
+
+	ts := getSingleFileTestState(t, `export default function () {}`, []string{"--baseline", "missing.json"},
+		exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel, "could not read the baseline"))
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, "a.json", []byte(`{"metrics": {
+		"http_req_duration": {"type": "trend", "contains": "time", "values": {"avg": 100, "p(95)": 200}}
+	}}`), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, "b.json", []byte(`{"metrics": {
+		"http_req_duration": {"avg": 110, "p(95)": 205}
+	}}`), 0o644))
+
+	ts.CmdArgs = []string{"k6", "compare", "--tolerance", "http_req_duration.avg=5%", "a.json", "b.json"}
+	ts.ExpectedExitCode = int(exitcodes.BaselineRegression)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Regexp(t, `http_req_duration\s+avg\s+100ms\s+110ms\s+\+10.00%\s+-\s+\+5% ✗ regression`, stdout)
+	assert.Regexp(t, `http_req_duration\s+p\(95\)\s+200ms\s+205ms\s+\+2.50%\s+-\s+-\n`, stdout)
+}
+
+func TestScenariosStartAfter(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			scenarios: {
+				seed: { executor: 'shared-iterations', iterations: 1 },
+				warmup: {
+					executor: 'per-vu-iterations', iterations: 1, maxDuration: '1m',
+					startAfter: ['seed'],
+				},
+				load: {
+					executor: 'per-vu-iterations', iterations: 1,
+					startAfter: [{ scenario: 'warmup', condition: 'thresholdsPassed' }],
+				},
+				report: { executor: 'per-vu-iterations', iterations: 1, startTime: '1s', startAfter: ['warmup'] },
+			},
+			thresholds: {
+				'errors{scenario:warmup}': ['count<1'],
+			},
+		};
+
+		const errors = new Counter('errors');
+
+		export default function () {
+			const scenario = exec.scenario;
+			console.log(scenario.name + " started after " + Math.round(exec.instance.currentTestRunDuration / 1000) + "s");
+			if (scenario.name == 'warmup') {
+				errors.add(1);
+			}
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, exitcodes.ThresholdsHaveFailed)
+	started := time.Now()
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	// the scenarios start when the ones before them finish, long before the
+	// end of their maxDuration
+	assert.Less(t, time.Since(started), 30*time.Second)
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `msg="seed started after 0s"`)
+	assert.Contains(t, stdout, `msg="warmup started after 0s"`)
+	assert.Contains(t, stdout, `msg="report started after 1s"`)
+	assert.NotContains(t, stdout, `msg="load started`)
+	assert.Regexp(t, `load +✓ \[ +0% \] skipped`, stdout)
+	assert.Contains(t, stdout, `level=warning msg="Skipping scenario load, since the thresholds of scenario warmup didn't pass"`)
+}
+
+func TestScenariosSetupAndTeardown(t *testing.T) {
+	t.Parallel()
+	script := `
+		export const options = {
+			scenarios: {
+				db: {
+					executor: 'shared-iterations', iterations: 2, exec: 'db',
+					setup: 'setupDB', teardown: 'teardownDB',
+				},
+				api: { executor: 'shared-iterations', iterations: 1, exec: 'api', teardown: 'teardownAPI' },
+			},
+		};
+
+		export function setup() {
+			return { service: 'global' };
+		}
+
+		export function setupDB() {
+			console.log('setupDB()');
+			return { service: 'db' };
+		}
+
+		export function teardownDB(data) {
+			console.log('teardownDB(' + data.service + ')');
+		}
+
+		export function teardownAPI(data) {
+			console.log('teardownAPI(' + data.service + ')');
+		}
+
+		export function db(data) {
+			console.log('db(' + data.service + ')');
+		}
+
+		export function api(data) {
+			console.log('api(' + data.service + ')');
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Equal(t, 1, strings.Count(stdout, `msg="setupDB()"`))
+	assert.Equal(t, 2, strings.Count(stdout, `msg="db(db)"`))
+	assert.Contains(t, stdout, `msg="teardownDB(db)"`)
+	assert.Contains(t, stdout, `msg="api(global)"`)
+	assert.Contains(t, stdout, `msg="teardownAPI(global)"`)
+	assert.Less(t, strings.Index(stdout, `msg="setupDB()"`), strings.Index(stdout, `msg="db(db)"`))
+	assert.Less(t, strings.LastIndex(stdout, `msg="db(db)"`), strings.Index(stdout, `msg="teardownDB(db)"`))
+}
+
+func TestScenariosSetupNotExported(t *testing.T) {
+	t.Parallel()
+	script := `
+		export const options = {
+			scenarios: {
+				db: { executor: 'shared-iterations', iterations: 1, setup: 'setupDB' },
+			},
+		};
+
+		export default function () {}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(), "executor db: function 'setupDB' not found in exports")
+}
+
+func TestScenariosPacing(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+		import { group } from 'k6';
+
+		export const options = {
+			scenarios: {
+				paced: {
+					executor: 'per-vu-iterations', iterations: 2,
+					pacing: { iteration: '1s', thinkTime: '200ms' },
+				},
+			},
+		};
+
+		export default function () {
+			const start = Date.now();
+			console.log('iteration started after ' + Math.floor(exec.instance.currentTestRunDuration / 1000) + 's');
+			group('login', function () {});
+			console.log('thought ' + (Date.now() - start >= 200));
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `msg="iteration started after 0s"`)
+	assert.Contains(t, stdout, `msg="iteration started after 1s"`)
+	assert.Equal(t, 2, strings.Count(stdout, `msg="thought true"`))
+}
+
+func TestScenariosExecMix(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			scenarios: {
+				mix: {
+					executor: 'shared-iterations', vus: 5, iterations: 1000,
+					execMix: { browse: 70, search: 20, checkout: 10 },
+				},
+			},
+			thresholds: {
+				'flows{flow:browse}': ['count>600', 'count<800'],
+				'flows{flow:search}': ['count>120', 'count<280'],
+				'flows{flow:checkout}': ['count>40', 'count<160'],
+			},
+		};
+
+		const flows = new Counter('flows');
+
+		export function browse() {
+			flows.add(1, { flow: 'browse' });
+		}
+
+		export function search() {
+			flows.add(1, { flow: 'search' });
+		}
+
+		export function checkout() {
+			flows.add(1, { flow: 'checkout' });
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "execMix: browse 70%, checkout 10%, search 20%")
+	assert.Contains(t, stdout, "1000 complete and 0 interrupted iterations")
+}
+
+func TestScenariosExecMixNotExported(t *testing.T) {
+	t.Parallel()
+	script := `
+		export const options = {
+			scenarios: {
+				mix: { executor: 'shared-iterations', iterations: 1, execMix: { browse: 1 } },
+			},
+		};
+
+		export default function () {}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(), "executor mix: function 'browse' not found in exports")
+}
+
+func TestScenariosUpdateTargets(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			scenarios: {
+				load: {
+					executor: 'constant-arrival-rate', rate: 10, duration: '2s',
+					preAllocatedVUs: 5, exec: 'load',
+				},
+				control: {
+					executor: 'shared-iterations', iterations: 1, startTime: '1s', exec: 'control',
+				},
+			},
+			thresholds: {
+				'loads': ['count>45', 'count<75'],
+			},
+		};
+
+		const loads = new Counter('loads');
+
+		export function load() {
+			loads.add(1);
+		}
+
+		export function control() {
+			exec.test.updateScenario('load', { rate: 50 });
+			try {
+				exec.test.updateScenario('load', { timeUnit: '1m' });
+			} catch (e) {
+				console.log(e.message);
+			}
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "Updated the targets of the scenario to 50.00 iterations/s for 2s")
+	assert.Contains(t, stdout, "the timeUnit of a running constant-arrival-rate scenario can't be changed, only its rate and duration")
+}
+
+func TestScenariosIterationTimeout(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+		import { sleep } from 'k6';
+
+		export const options = {
+			scenarios: {
+				slow: {
+					executor: 'per-vu-iterations', vus: 1, iterations: 3, iterationTimeout: '200ms',
+				},
+			},
+			thresholds: {
+				'iteration_timeouts{scenario:slow}': ['count==2'],
+				'iterations': ['count==1'],
+			},
+		};
+
+		export default function () {
+			if (exec.vu.iterationInScenario < 2) {
+				sleep(10);
+			}
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	start := time.Now()
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "the iteration timed out after 200ms")
+	assert.Contains(t, stdout, "iterationTimeout: 200ms")
+}
+
+func TestScenariosWarmupIterations(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		export const options = {
+			scenarios: {
+				warm: {
+					executor: 'per-vu-iterations', vus: 2, iterations: 3, warmupIterations: 5,
+				},
+			},
+			thresholds: {
+				'runs': ['count==6'],
+				'iterations': ['count==6'],
+			},
+		};
+
+		const runs = new Counter('runs');
+
+		export default function () {
+			runs.add(1);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "warmup: 5 iterations per VU")
+	assert.Contains(t, stdout, "Running 5 warm-up iterations")
+}
+
+func TestScenariosIdentities(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+
+		export const options = {
+			scenarios: {
+				users: {
+					executor: 'per-vu-iterations', vus: 3, iterations: 2,
+					identities: [{ user: 'alice' }, { user: 'bob' }],
+				},
+			},
+		};
+
+		export default function () {
+			const identity = exec.vu.identity;
+			if (identity !== exec.vu.identity) {
+				throw new Error('the identity should be the same object');
+			}
+			if (exec.vu.iterationInScenario === 0) {
+				identity.token = 'token-' + exec.vu.idInTest;
+			} else if (identity.token !== 'token-' + exec.vu.idInTest) {
+				throw new Error('the identity should persist across the iterations');
+			}
+			console.log(exec.vu.idInTest + ':' + exec.vu.identityIndex + ':' + identity.user);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(), "identities: 2")
+	identities := map[string]int{}
+	for _, line := range ts.LoggerHook.Drain() {
+		if regexp.MustCompile(`^\d+:\d+:`).MatchString(line.Message) {
+			identities[line.Message]++
+		}
+	}
+	assert.Equal(t, map[string]int{"1:0:alice": 2, "2:1:bob": 2, "3:0:alice": 2}, identities)
+}
+
+func TestSecretSources(t *testing.T) {
+	t.Parallel()
+	script := `
+		import secrets from 'k6/secrets';
+
+		export const options = { iterations: 1 };
+
+		export default async function () {
+			const password = await secrets.get('password');
+			console.log('the password is ' + password);
+			const user = await secrets.source('file').get('user');
+			if (user !== 'admin') {
+				throw new Error('wrong user ' + user);
+			}
+			try {
+				await secrets.get('missing');
+			} catch (e) {
+				console.log('failed to get the secret: ' + e);
+			}
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--log-output=stdout", "--secret-source", "file=secrets.env"}, 0)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "secrets.env"),
+		[]byte("user=admin\npassword=s3cret\n"), 0o600))
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `the password is ***SECRET_REDACTED***`)
+	assert.NotContains(t, stdout, "s3cret")
+	assert.NotContains(t, stdout, "wrong user")
+	assert.Contains(t, stdout, `failed to get the secret: getting the secret \"missing\" from file (secrets.env): `+
+		`no such secret`)
+	assert.Contains(t, stdout, "iterations")
+}
+
+func TestRedaction(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	script := tb.Replacer.Replace(`
+		import http from 'k6/http';
+
+		export const options = {
+			iterations: 1,
+			redact: { headers: ['Authorization'], fields: ['password'], patterns: ['tok_[0-9a-z]+'] },
+		};
+
+		export default function () {
+			http.get('HTTPBIN_IP_URL/get', { headers: { Authorization: 'Bearer tok_abc123' } });
+			console.log(JSON.stringify({ user: 'admin', password: 'hunter2' }));
+			console.error('failed with the token tok_def456');
+		}
+	`)
+
+	ts := getSingleFileTestState(t, script, []string{"--log-output=stdout", "--http-debug"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, `Authorization: ***REDACTED***`)
+	assert.Contains(t, stdout, `\"password\":\"***REDACTED***\"`)
+	assert.Contains(t, stdout, "failed with the token ***REDACTED***")
+	assert.NotContains(t, stdout, "tok_")
+	assert.NotContains(t, stdout, "hunter2")
+}
+
+func TestConfigProfileAndEnvFile(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+
+		export default function () {
+			console.log('base url ' + __ENV.BASE_URL + ', token ' + __ENV.TOKEN + ', vus ' + exec.instance.vusInitialized);
+		}
+	`
+
+	ts := NewGlobalTestState(t)
+	configPath := filepath.Join(ts.Cwd, "config.json")
+	ts.CmdArgs = []string{
+		"k6", "run", "--log-output=stdout", "--config", configPath, "--profile", "staging", "--env-file", ".env", "test.js",
+	}
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(script), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, configPath, []byte(`{
+		"iterations": 1,
+		"vus": 1,
+		"profiles": {
+			"staging": { "iterations": 2 },
+			"production": { "iterations": 100 }
+		}
+	}`), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, ".env"),
+		[]byte("BASE_URL=https://staging.example.com\nTOKEN=from-file\nK6_VUS=2\n"), 0o644))
+	ts.Env["TOKEN"] = "from-env"
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Equal(t, 2, strings.Count(stdout, "base url https://staging.example.com, token from-env, vus 2"))
+}
+
+func TestConfigValidateAndShow(t *testing.T) {
+	t.Parallel()
+
+	newTestState := func(t *testing.T, config string, args ...string) *GlobalTestState {
+		ts := NewGlobalTestState(t)
+		configPath := filepath.Join(ts.Cwd, "k6.yaml")
+		ts.CmdArgs = append([]string{"k6", "config"}, args...)
+		ts.CmdArgs = append(ts.CmdArgs, "--config", configPath)
+		require.NoError(t, fsext.WriteFile(ts.FS, configPath, []byte(config), 0o644))
+		return ts
+	}
+	config := "vus: 2\niterations: 10\nprofiles:\n  ci:\n    iterations: 20\n"
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestState(t, config, "validate")
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Equal(t, "The configuration is valid.\n", ts.Stdout.String())
+	})
+
+	t.Run("unknown option", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestState(t, "vus: 2\niteration: 10\n", "validate")
+		ts.ExpectedExitCode = int(exitcodes.InvalidConfig)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stderr.String(), `k6.yaml:2:1: unknown option \"iteration\"`)
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestState(t, config, "show", "--resolved", "--profile", "ci", "--vus", "5")
+		ts.Env["K6_ITERATIONS"] = "30"
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		var conf struct {
+			VUs        int
+			Iterations int
+			Scenarios  map[string]struct {
+				Executor   string
+				VUs        int
+				Iterations int
+			}
+		}
+		require.NoError(t, json.Unmarshal(ts.Stdout.Bytes(), &conf))
+		assert.Equal(t, 5, conf.VUs)
+		assert.Equal(t, 30, conf.Iterations)
+		assert.Equal(t, "shared-iterations", conf.Scenarios["default"].Executor)
+		assert.Equal(t, 30, conf.Scenarios["default"].Iterations)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestState(t, config, "show", "--profile", "ci", "--vus", "5")
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		var conf struct{ VUs, Iterations int }
+		require.NoError(t, json.Unmarshal(ts.Stdout.Bytes(), &conf))
+		assert.Equal(t, 2, conf.VUs)
+		assert.Equal(t, 20, conf.Iterations)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+		script := `
+			import { sleep } from 'k6';
+			export const options = { vus: 2, duration: '10s' };
+			export default function () { sleep(1); }
+		`
+		ts := getSingleFileTestState(t, script, nil, 0)
+		ts.CmdArgs = []string{"k6", "validate", "test.js"}
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Equal(t, "test.js is valid.\n", ts.Stdout.String())
+	})
+
+	t.Run("warnings", func(t *testing.T) {
+		t.Parallel()
+		script := `
+			const data = open('./data.bin');
+			export const options = {
+				scenarios: {
+					closed: { executor: 'constant-vus', vus: 1, duration: '10s' },
+					paced: { executor: 'constant-vus', vus: 1, duration: '10s', pacing: { iteration: '1s' } },
+					open: { executor: 'constant-arrival-rate', rate: 1, duration: '10s', preAllocatedVUs: 1 },
+				},
+			};
+			export default function () {}
+		`
+		ts := getSingleFileTestState(t, script, nil, 0)
+		ts.CmdArgs = []string{"k6", "lint", "test.js"}
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "data.bin"),
+			bytes.Repeat([]byte{'a'}, 12*1024*1024), 0o644))
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		stdout := ts.Stdout.String()
+		assert.Contains(t, stdout, "test.js is valid, but it may have some problems:\n")
+		assert.Contains(t, stdout, "the file data.bin (12 MB) is opened in the init context")
+		assert.Contains(t, stdout, `the VUs of scenario "closed" (constant-vus) run their iterations back to back`)
+		assert.NotContains(t, stdout, `"paced"`)
+		assert.NotContains(t, stdout, `"open"`)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		script := `
+			export const options = {
+				scenarios: { main: { executor: 'constant-vus', vus: 1, duration: '10s', exec: 'missing' } },
+			};
+			export default function () {}
+		`
+		ts := getSingleFileTestState(t, script, nil, exitcodes.InvalidConfig)
+		ts.CmdArgs = []string{"k6", "validate", "test.js"}
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stderr.String(), "function 'missing' not found in exports")
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	script := tb.Replacer.Replace(`
+		import http from 'k6/http';
+		import { check } from 'k6';
+		import exec from 'k6/execution';
+
+		export const options = {
+			scenarios: {
+				browse: { executor: 'constant-vus', vus: 10, duration: '1h', exec: 'browse', env: { PAGE: 'home' } },
+				api: { executor: 'constant-arrival-rate', rate: 100, duration: '1h', preAllocatedVUs: 10 },
+			},
+			thresholds: { checks: ['rate==1'] },
+		};
+
+		export function browse() {
+			console.log('browse ' + __ENV.PAGE + ' ' + exec.scenario.executor);
+			http.get('HTTPBIN_IP_URL/get');
+		}
+
+		export default function () {
+			console.log('api ' + exec.scenario.executor);
+			check(null, { 'failing check': () => false });
+		}
+	`)
+
+	ts := getSingleFileTestState(t, script, []string{"--dry-run", "--log-output=stdout"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Equal(t, 1, strings.Count(stdout, "browse home per-vu-iterations"))
+	assert.Equal(t, 1, strings.Count(stdout, "api per-vu-iterations"))
+	assert.Contains(t, stdout, `Request:\nGET /get HTTP/1.1`)
+	assert.Contains(t, stdout, "failing check")
+}
+
+func TestCustomExitCodes(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		options  string
+		setup    string
+		body     string
+		exitCode exitcodes.ExitCode
+	}{
+		{
+			name:     "thresholds crossed",
+			options:  `exitCodes: { thresholdsCrossed: 3 }, thresholds: { checks: ['rate==1'] }`,
+			body:     `check(null, { 'failing': () => false });`,
+			exitCode: 3,
+		},
+		{
+			name: "threshold exit code",
+			options: `exitCodes: { thresholdsCrossed: 3 },
+				thresholds: { checks: [{ threshold: 'rate==1', exitCode: 4 }], iterations: ['count<100'] }`,
+			body:     `check(null, { 'failing': () => false });`,
+			exitCode: 4,
+		},
+		{
+			name:     "passed threshold exit code",
+			options:  `exitCodes: { thresholdsCrossed: 3 }, thresholds: { checks: [{ threshold: 'rate==0', exitCode: 4 }] }`,
+			body:     `check(null, { 'failing': () => false });`,
+			exitCode: 0,
+		},
+		{
+			name:     "script error",
+			options:  `exitCodes: { scriptError: 5, thresholdsCrossed: 3 }`,
+			setup:    `throw new Error('oops');`,
+			exitCode: 5,
+		},
+		{
+			name:     "script aborted",
+			options:  `exitCodes: { scriptAborted: 6 }`,
+			body:     `exec.test.abort('stop');`,
+			exitCode: 6,
+		},
+		{
+			name:     "invalid",
+			options:  `exitCodes: { scriptError: 256 }`,
+			body:     ``,
+			exitCode: exitcodes.InvalidConfig,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			script := fmt.Sprintf(`
+				import { check } from 'k6';
+				import exec from 'k6/execution';
+
+				export const options = { iterations: 1, %s };
+
+				export function setup() { %s }
+
+				export default function () { %s }
+			`, tc.options, tc.setup, tc.body)
+
+			ts := getSingleFileTestState(t, script, nil, tc.exitCode)
+			cmd.ExecuteWithGlobalState(ts.GlobalState)
+		})
+	}
+}
+
+func TestTestMetadata(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+		import { Counter } from 'k6/metrics';
+
+		export const options = { iterations: 1, testName: 'checkout', tags: { test_environment: 'qa' } };
+
+		const c = new Counter('my_counter');
+
+		export default function () {
+			const m = exec.test.metadata;
+			console.log('metadata: ' + [m.name, m.runId, m.version, m.environment].join(' '));
+			c.add(1);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{
+		"--test-version", "abc123", "--test-environment", "staging", "--run-id", "run-1",
+		"--log-output=stdout", "--out", "json=results.json",
+	}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "metadata: checkout run-1 abc123 staging")
+	assert.Regexp(t, `█ TEST\s+name: checkout\s+run id: run-1\s+version: abc123\s+environment: staging`, stdout)
+
+	// the explicit tags take precedence over the metadata
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), sum(getSampleValues(t, jsonResults, "my_counter", map[string]string{
+		"test_name": "checkout", "run_id": "run-1", "test_version": "abc123", "test_environment": "qa",
+	})))
+}
+
+func TestIterationMetadata(t *testing.T) {
+	t.Parallel()
+	script := `
+		import exec from 'k6/execution';
+
+		export const options = { iterations: 2, vus: 1 };
+
+		export default function () {
+			const metadata = exec.vu.metrics.iterationMetadata;
+			console.log('iteration ' + exec.vu.iterationInInstance + ': ' + JSON.stringify(metadata.order));
+			metadata.order = 'order-' + exec.vu.iterationInInstance;
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--log-output=stdout"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "iteration 0: undefined")
+	assert.Contains(t, stdout, "iteration 1: undefined")
+}
+
+func TestMutexReleasedWhenIterationThrows(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Mutex } from 'k6/experimental/state';
+		import exec from 'k6/execution';
+
+		export const options = {
+			scenarios: {
+				default: { executor: 'shared-iterations', vus: 2, iterations: 6, maxDuration: '10s' },
+			},
+		};
+
+		const mutex = new Mutex('login');
+
+		export default function () {
+			mutex.lock();
+			if (exec.scenario.iterationInTest % 2 === 0) {
+				throw new Error('failed while holding the mutex');
+			}
+			mutex.unlock();
+			console.log('done ' + exec.scenario.iterationInTest);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--log-output=stdout"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Equal(t, 3, strings.Count(stdout, "failed while holding the mutex"))
+	assert.Equal(t, 3, strings.Count(stdout, "level=info msg=\"done "))
+}
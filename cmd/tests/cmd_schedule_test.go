@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/cmd"
+	"go.k6.io/k6/lib/fsext"
+)
+
+func TestScheduleRuns(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const runs = new Counter('runs');
+
+		export default function () {
+			runs.add(1);
+		}
+	`
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(script), 0o644))
+	ts.CmdArgs = []string{
+		"k6", "schedule", "--cron", "* * * * * *", "--runs", "2",
+		"--summary-export", "summary-{run}.json", "--out", "json=results-{run}.json",
+		"-v", "--log-output=stdout", "test.js",
+	}
+
+	start := time.Now()
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "The run 1 of the test is scheduled at")
+	assert.Contains(t, stdout, "The run 2 of the test is scheduled at")
+	assert.NotContains(t, stdout, "failed")
+	for _, run := range []string{"1", "2"} {
+		exists, err := fsext.Exists(ts.FS, "summary-"+run+".json")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		results, err := fsext.ReadFile(ts.FS, "results-"+run+".json")
+		require.NoError(t, err)
+		assert.Contains(t, string(results), `{"metric":"runs","type":"Point"`)
+		assert.Contains(t, string(results), `"schedule_run":"`+run+`"`)
+	}
+}
+
+func TestScheduleInvalidCron(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "schedule", "--cron", "* * *", "test.js"}
+	ts.ExpectedExitCode = 104
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.Contains(t, ts.Stderr.String(), "invalid cron expression '* * *': it has 3 fields instead of 5")
+}
@@ -18,6 +18,7 @@ import (
 	"go.k6.io/k6/event"
 	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/log"
 	"go.k6.io/k6/ui/console"
 )
 
@@ -111,6 +112,7 @@ func NewGlobalTestState(tb testing.TB) *GlobalTestState {
 		SignalStop:     signal.Stop,
 		Logger:         logger,
 		FallbackLogger: testutils.NewLogger(tb).WithField("fallback", true),
+		RedactionHook:  log.NewRedactionHook(),
 	}
 
 	return ts
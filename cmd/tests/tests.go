@@ -54,7 +54,9 @@ func Main(m *testing.M) {
 	}()
 
 	defer func() {
-		if err := goleak.Find(); err != nil {
+		// the worker of OpenCensus, which is used by the Google Cloud Storage
+		// client, is started when its package is initialized
+		if err := goleak.Find(goleak.IgnoreTopFunction("go.opencensus.io/stats/view.(*worker).start")); err != nil {
 			fmt.Println(err)
 			exitCode = 3
 		}
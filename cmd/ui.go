@@ -17,12 +17,14 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	v2 "go.k6.io/k6/api/v2"
 	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/consts"
 	"go.k6.io/k6/metrics/engine"
 	"go.k6.io/k6/output"
 	"go.k6.io/k6/ui/pb"
+	"go.k6.io/k6/ui/tui"
 )
 
 const (
@@ -32,6 +34,10 @@ const (
 	// bar text and right-side terminal window edge.
 	termPadding      = 1
 	defaultTermWidth = 80
+
+	// The values of the --ui flag of k6 run.
+	uiProgress = "progress"
+	uiTUI      = "tui"
 )
 
 // getColor returns the requested color, or an uncolored object, depending on
@@ -114,7 +120,7 @@ func printExecutionDescription(
 	default:
 		for _, out := range outputs {
 			desc := out.Description()
-			if desc == engine.IngesterDescription {
+			if desc == engine.IngesterDescription || desc == v2.SampleStreamDescription {
 				continue
 			}
 			if strings.HasPrefix(desc, dashboard.OutputName) {
@@ -375,6 +381,41 @@ func showProgress(ctx context.Context, gs *state.GlobalState, pbs []*pb.Progress
 	}
 }
 
+// startTUI shows the interactive terminal UI, instead of the progress bars,
+// until the returned function is called.
+func startTUI(ctx context.Context, gs *state.GlobalState, cs *v2.ControlSurface) (stop func()) {
+	opts := tui.Options{
+		In:      gs.Stdin,
+		Out:     gs.Stdout,
+		NoColor: gs.Flags.NoColor,
+		Logger:  gs.Logger,
+		Size: func() (int, int, error) {
+			return term.GetSize(gs.Stdout.RawOutFd)
+		},
+	}
+	if f, ok := gs.Stdin.(interface{ Fd() uintptr }); ok {
+		opts.InFd = int(f.Fd())
+		opts.InIsTTY = term.IsTerminal(opts.InFd)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := tui.New(cs, cs.SampleStream, opts).Run(ctx); err != nil {
+			gs.Logger.WithError(err).Warn("The terminal UI couldn't be shown")
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+}
+
 func yamlPrint(w io.Writer, v interface{}) error {
 	data, err := yaml.Marshal(v)
 	if err != nil {
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// largeOpenedFileSize is the size from which the files opened in the init
+// context are reported, since every VU keeps its own copy of their contents.
+const largeOpenedFileSize = 10 * 1024 * 1024
+
+//nolint:gochecknoglobals
+var sleepCall = regexp.MustCompile(`\bsleep\s*\(`)
+
+func getCmdValidate(gs *state.GlobalState) *cobra.Command {
+	exampleText := getExampleText(gs, `
+  # Validate a script, its modules and its options, without running it.
+  {{.}} validate script.js
+
+  # Validate it with the options that it's run with in CI.
+  {{.}} validate --config k6.yaml --profile ci -e BASE_URL=https://staging.example.com script.js`[1:])
+
+	validateCmd := &cobra.Command{
+		Use:     "validate [file]",
+		Aliases: []string{"lint"},
+		Short:   "Validate a script",
+		Long: `Validate a script.
+
+The script and all of its modules are loaded and its init context is executed
+once, without starting any VUs. Then its options are consolidated with the ones
+of the config file, the environment variables and the CLI flags, and validated
+like k6 run does. The errors make k6 exit with a non-zero exit code.
+
+Some common problems are reported as warnings, which don't change the exit code:
+  - the large files that are opened in the init context, since every VU keeps
+    its own copy of them
+  - the closed-model scenarios, in which the VUs run their iterations back to
+    back, when the script never sleeps and the scenarios have no pacing`,
+		Example: exampleText,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkDiskConfig(gs); err != nil {
+				return err
+			}
+
+			test, err := loadLocalTest(gs, cmd, args)
+			if err != nil {
+				return err
+			}
+			configuredTest, err := test.consolidateDeriveAndValidateConfig(gs, cmd, getConfig)
+			if err != nil {
+				return err
+			}
+
+			warnings, err := lintTest(configuredTest)
+			if err != nil {
+				return err
+			}
+			if len(warnings) == 0 {
+				printToStdout(gs, fmt.Sprintf("%s is valid.\n", args[0]))
+				return nil
+			}
+			printToStdout(gs, fmt.Sprintf("%s is valid, but it may have some problems:\n", args[0]))
+			for _, warning := range warnings {
+				printToStdout(gs, fmt.Sprintf("\t- %s\n", warning))
+			}
+			return nil
+		},
+	}
+
+	validateCmd.Flags().SortFlags = false
+	validateCmd.Flags().AddFlagSet(configCmdFlagSet())
+
+	return validateCmd
+}
+
+// lintTest returns the warnings about the common problems of the test.
+func lintTest(test *loadedAndConfiguredTest) ([]string, error) {
+	arc := test.initRunner.MakeArchive()
+	sources := []string{string(arc.Data)}
+	var warnings []string
+
+	filesystem, ok := arc.Filesystems["file"]
+	if ok {
+		if cachedfs, ok := filesystem.(fsext.CacheLayerGetter); ok {
+			filesystem = cachedfs.GetCachingFs()
+		}
+		walkFunc := func(filePath string, info fs.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			if isModuleFile(filePath) {
+				data, err := fsext.ReadFile(filesystem, filePath)
+				if err != nil {
+					return err
+				}
+				sources = append(sources, string(data))
+				return nil
+			}
+			if info.Size() >= largeOpenedFileSize {
+				warnings = append(warnings, fmt.Sprintf(
+					"the file %s (%d MB) is opened in the init context and every VU keeps its own copy of it, "+
+						"use a SharedArray or k6/experimental/fs to share it between them",
+					filepath.Base(filePath), info.Size()/(1024*1024),
+				))
+			}
+			return nil
+		}
+		if err := fsext.Walk(filesystem, fsext.FilePathSeparator, walkFunc); err != nil {
+			return nil, err
+		}
+	}
+
+	sleeps := false
+	for _, source := range sources {
+		sleeps = sleeps || sleepCall.MatchString(source)
+	}
+	if !sleeps {
+		names := make([]string, 0, len(test.derivedConfig.Scenarios))
+		for name, scenario := range test.derivedConfig.Scenarios {
+			if isUnpacedClosedModel(scenario) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			warnings = append(warnings, fmt.Sprintf(
+				"the script never sleeps, so the VUs of scenario %q (%s) run their iterations back to back, "+
+					"add a sleep() or a pacing to the scenario to simulate the real users",
+				name, test.derivedConfig.Scenarios[name].GetType(),
+			))
+		}
+	}
+
+	return warnings, nil
+}
+
+func isModuleFile(filePath string) bool {
+	switch strings.ToLower(path.Ext(filepath.ToSlash(filePath))) {
+	case ".js", ".mjs", ".cjs":
+		return true
+	default:
+		return false
+	}
+}
+
+// isUnpacedClosedModel returns whether the scenario has a closed-model
+// executor, whose VUs start a new iteration as soon as the previous one ends,
+// without a pacing. The browser scenarios are excluded, since the pages take
+// their time.
+func isUnpacedClosedModel(scenario lib.ExecutorConfig) bool {
+	var base executor.BaseConfig
+	switch config := scenario.(type) {
+	case executor.ConstantVUsConfig:
+		base = config.BaseConfig
+	case executor.RampingVUsConfig:
+		base = config.BaseConfig
+	case executor.PerVUIterationsConfig:
+		base = config.BaseConfig
+	case executor.SharedIterationsConfig:
+		base = config.BaseConfig
+	default:
+		return false
+	}
+	return base.Pacing == nil && (base.Options == nil || base.Options.Browser == nil)
+}
@@ -0,0 +1,331 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.k6.io/k6/internal/converterutil"
+)
+
+// DefaultGroupGap is the default minimum pause between two requests, that
+// starts a new group when the HAR log doesn't contain any pages.
+const DefaultGroupGap = time.Second
+
+// minCorrelationLength is the minimum length of a response value for it to be
+// considered for correlation, shorter values match way too often by accident.
+const minCorrelationLength = 8
+
+// Options controls how a HAR log is converted to a script.
+type Options struct {
+	// OnlyHosts, if not empty, restricts the script to the requests for
+	// these hosts.
+	OnlyHosts []string
+	// GroupGap is the minimum pause between two requests that starts a new
+	// group, when the HAR log doesn't contain any pages. Pauses between
+	// groups are converted to sleep() calls.
+	GroupGap time.Duration
+}
+
+type group struct {
+	name    string
+	entries []*Entry
+}
+
+// correlationCandidate is a value from a JSON response that might be used by
+// the following requests, for example an ID or a token.
+type correlationCandidate struct {
+	request int
+	path    string
+	value   string
+}
+
+// Convert generates a k6 script from the given HAR log. Requests are grouped
+// by page, or by the pauses between them if there are no pages, and comments
+// are added for the recorded values that probably need to be correlated.
+func Convert(h HAR, opts Options) (string, error) {
+	if h.Log == nil {
+		return "", fmt.Errorf("the HAR doesn't contain a log")
+	}
+	if opts.GroupGap <= 0 {
+		opts.GroupGap = DefaultGroupGap
+	}
+
+	entries, err := filterEntries(h.Log.Entries, opts.OnlyHosts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("// Generated by k6 from a HAR recording, review it before running it.\n")
+	b.WriteString("import { check, group, sleep } from 'k6';\n")
+	b.WriteString("import http from 'k6/http';\n\n")
+	b.WriteString("export const options = {};\n\n")
+	b.WriteString("export default function () {\n")
+	b.WriteString("  let res;\n")
+
+	var candidates []correlationCandidate
+	request := 0
+	groups := groupEntries(h.Log.Pages, entries, opts.GroupGap)
+	for i, g := range groups {
+		if i > 0 {
+			pause := groups[i-1].pauseUntil(g.entries[0])
+			if pause > 0 {
+				fmt.Fprintf(&b, "\n  sleep(%s);\n", strconv.FormatFloat(pause.Seconds(), 'f', -1, 64))
+			}
+		}
+		fmt.Fprintf(&b, "\n  group(%s, function () {\n", converterutil.JSString(g.name))
+		for _, e := range g.entries {
+			request++
+			if request > 1 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "    // Request #%d\n", request)
+			writeCorrelationHints(&b, e, candidates)
+			if err := writeRequest(&b, e); err != nil {
+				return "", fmt.Errorf("unable to convert request #%d: %w", request, err)
+			}
+			candidates = append(candidates, findCorrelationCandidates(request, e.Response)...)
+		}
+		b.WriteString("  });\n")
+	}
+	b.WriteString("}\n")
+
+	return b.String(), nil
+}
+
+func filterEntries(entries []*Entry, onlyHosts []string) ([]*Entry, error) {
+	filtered := make([]*Entry, 0, len(entries))
+	for _, e := range entries {
+		if e.Request == nil {
+			continue
+		}
+		if len(onlyHosts) > 0 {
+			u, err := url.Parse(e.Request.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid request URL %q: %w", e.Request.URL, err)
+			}
+			if !converterutil.ContainsString(onlyHosts, u.Hostname()) {
+				continue
+			}
+		}
+		filtered = append(filtered, e)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].StartedDateTime.Before(filtered[j].StartedDateTime)
+	})
+	return filtered, nil
+}
+
+func groupEntries(pages []Page, entries []*Entry, gap time.Duration) []*group {
+	var groups []*group
+	if len(pages) > 0 {
+		byPage := make(map[string]*group, len(pages))
+		for _, p := range pages {
+			name := p.Title
+			if name == "" {
+				name = p.ID
+			}
+			byPage[p.ID] = &group{name: name}
+		}
+		for _, e := range entries {
+			g, ok := byPage[e.Pageref]
+			if !ok {
+				g = &group{name: e.Pageref}
+				byPage[e.Pageref] = g
+			}
+			if len(g.entries) == 0 {
+				groups = append(groups, g)
+			}
+			g.entries = append(g.entries, e)
+		}
+		return groups
+	}
+
+	var current *group
+	for _, e := range entries {
+		if current == nil || current.pauseUntil(e) >= gap {
+			current = &group{name: fmt.Sprintf("page_%d - %s", len(groups)+1, e.Request.URL)}
+			groups = append(groups, current)
+		}
+		current.entries = append(current.entries, e)
+	}
+	return groups
+}
+
+// pauseUntil returns the time between the end of the last request of the
+// group and the start of the given entry, rounded to 10ms.
+func (g *group) pauseUntil(e *Entry) time.Duration {
+	var end time.Time
+	for _, ge := range g.entries {
+		geEnd := ge.StartedDateTime.Add(time.Duration(ge.Time * float64(time.Millisecond)))
+		if geEnd.After(end) {
+			end = geEnd
+		}
+	}
+	return e.StartedDateTime.Sub(end).Round(10 * time.Millisecond)
+}
+
+//nolint:gochecknoglobals
+var skippedHeaders = map[string]bool{
+	"host":           true,
+	"content-length": true,
+	"connection":     true,
+	"cookie":         true, // the cookies are handled by the cookie jar
+}
+
+func writeRequest(b *strings.Builder, e *Entry) error {
+	req := e.Request
+	method := strings.ToUpper(req.Method)
+
+	var params strings.Builder
+	headers := make([]NameValue, 0, len(req.Headers))
+	for _, h := range req.Headers {
+		if strings.HasPrefix(h.Name, ":") || skippedHeaders[strings.ToLower(h.Name)] {
+			continue
+		}
+		headers = append(headers, h)
+	}
+	if len(headers) > 0 {
+		params.WriteString(", {\n      headers: {\n")
+		for _, h := range headers {
+			fmt.Fprintf(&params, "        %s: %s,\n", converterutil.JSString(h.Name), converterutil.JSString(h.Value))
+		}
+		params.WriteString("      },\n    }")
+	}
+
+	if method == "GET" {
+		fmt.Fprintf(b, "    res = http.get(%s%s);\n", converterutil.JSString(req.URL), params.String())
+	} else {
+		body, err := requestBody(req.PostData)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "    res = http.request(%s, %s, %s%s);\n",
+			converterutil.JSString(method), converterutil.JSString(req.URL), body, params.String())
+	}
+
+	if e.Response != nil && e.Response.Status > 0 {
+		desc := fmt.Sprintf("status is %d", e.Response.Status)
+		fmt.Fprintf(b, "    check(res, { %s: (r) => r.status === %d });\n", converterutil.JSString(desc), e.Response.Status)
+	}
+	return nil
+}
+
+func requestBody(data *PostData) (string, error) {
+	switch {
+	case data == nil:
+		return "null", nil
+	case data.Text != "" || len(data.Params) == 0:
+		return converterutil.JSString(data.Text), nil
+	}
+
+	params := make([]string, 0, len(data.Params))
+	for _, p := range data.Params {
+		if p.FileName != "" {
+			return "", fmt.Errorf("file uploads aren't supported, but the %q parameter is a file", p.Name)
+		}
+		params = append(params, converterutil.JSString(p.Name)+": "+converterutil.JSString(p.Value))
+	}
+	return "{ " + strings.Join(params, ", ") + " }", nil
+}
+
+func writeCorrelationHints(b *strings.Builder, e *Entry, candidates []correlationCandidate) {
+	var haystack strings.Builder
+	haystack.WriteString(e.Request.URL)
+	for _, h := range e.Request.Headers {
+		haystack.WriteString("\n" + h.Value)
+	}
+	if e.Request.PostData != nil {
+		haystack.WriteString("\n" + e.Request.PostData.Text)
+		for _, p := range e.Request.PostData.Params {
+			haystack.WriteString("\n" + p.Value)
+		}
+	}
+	hs := haystack.String()
+
+	// the latest response with a given value is the most likely source of it
+	hinted := make(map[string]bool)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		c := candidates[i]
+		if hinted[c.value] || !strings.Contains(hs, c.value) {
+			continue
+		}
+		hinted[c.value] = true
+		fmt.Fprintf(b, "    // Correlation hint: %s comes from the %q field of the response to request #%d,\n",
+			converterutil.JSString(truncate(c.value, 40)), c.path, c.request)
+		fmt.Fprintf(b, "    // consider extracting it with res.json(%s) instead of using the recorded value.\n",
+			converterutil.JSString(c.path))
+	}
+}
+
+func findCorrelationCandidates(request int, resp *Response) []correlationCandidate {
+	if resp == nil || !strings.Contains(resp.Content.MimeType, "json") {
+		return nil
+	}
+	text := resp.Content.Text
+	if resp.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil
+		}
+		text = string(decoded)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(text), &data); err != nil {
+		return nil
+	}
+	var candidates []correlationCandidate
+	walkJSON(data, "", func(path, value string) {
+		if len(value) >= minCorrelationLength {
+			candidates = append(candidates, correlationCandidate{request: request, path: path, value: value})
+		}
+	})
+	return candidates
+}
+
+// walkJSON calls fn with the gjson path of every string or number value.
+func walkJSON(v interface{}, path string, fn func(path, value string)) {
+	join := func(key string) string {
+		key = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`).Replace(key)
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkJSON(v[k], join(k), fn)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkJSON(item, join(strconv.Itoa(i)), fn)
+		}
+	case string:
+		fn(path, v)
+	case float64:
+		if v == math.Trunc(v) {
+			fn(path, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,95 @@
+package har
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	recording := HAR{Log: &Log{Entries: []*Entry{
+		{
+			StartedDateTime: start,
+			Time:            100,
+			Request: &Request{
+				Method: "POST",
+				URL:    "https://test.k6.io/login",
+				Headers: []NameValue{
+					{Name: ":authority", Value: "test.k6.io"},
+					{Name: "Content-Type", Value: "application/json"},
+					{Name: "Cookie", Value: "session=1"},
+				},
+				PostData: &PostData{MimeType: "application/json", Text: `{"user":"admin"}`},
+			},
+			Response: &Response{
+				Status:  200,
+				Content: Content{MimeType: "application/json", Text: `{"auth":{"token":"abcdef123456"},"id":7}`},
+			},
+		},
+		{
+			StartedDateTime: start.Add(200 * time.Millisecond),
+			Time:            50,
+			Request: &Request{
+				Method:  "GET",
+				URL:     "https://test.k6.io/profile",
+				Headers: []NameValue{{Name: "Authorization", Value: "Bearer abcdef123456"}},
+			},
+			Response: &Response{Status: 200},
+		},
+		{
+			StartedDateTime: start.Add(3 * time.Second),
+			Time:            10,
+			Request:         &Request{Method: "GET", URL: "https://other.k6.io/"},
+			Response:        &Response{Status: 404},
+		},
+	}}}
+
+	script, err := Convert(recording, Options{})
+	require.NoError(t, err)
+
+	assert.Contains(t, script, `group("page_1 - https://test.k6.io/login", function () {`)
+	assert.Contains(t, script, `res = http.request("POST", "https://test.k6.io/login", "{\"user\":\"admin\"}", {`)
+	assert.Contains(t, script, `"Content-Type": "application/json",`)
+	assert.NotContains(t, script, `:authority`)
+	assert.NotContains(t, script, `Cookie`)
+	assert.Contains(t, script, `check(res, { "status is 200": (r) => r.status === 200 });`)
+	assert.Contains(t, script,
+		`// Correlation hint: "abcdef123456" comes from the "auth.token" field of the response to request #1,`)
+	assert.Contains(t, script, `res = http.get("https://test.k6.io/profile", {`)
+	assert.Contains(t, script, "sleep(2.75);")
+	assert.Contains(t, script, `group("page_2 - https://other.k6.io/", function () {`)
+
+	script, err = Convert(recording, Options{OnlyHosts: []string{"other.k6.io"}})
+	require.NoError(t, err)
+	assert.NotContains(t, script, "test.k6.io")
+	assert.Contains(t, script, `res = http.get("https://other.k6.io/");`)
+
+	_, err = Convert(HAR{}, Options{})
+	require.Error(t, err)
+}
+
+func TestConvertPages(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	recording := HAR{Log: &Log{
+		Pages: []Page{{ID: "page_1", Title: "Home"}, {ID: "page_2", Title: "Contacts"}},
+		Entries: []*Entry{
+			{Pageref: "page_2", StartedDateTime: start.Add(time.Second), Request: &Request{Method: "GET", URL: "https://test.k6.io/contacts"}},
+			{Pageref: "page_1", StartedDateTime: start, Request: &Request{Method: "GET", URL: "https://test.k6.io/"}},
+		},
+	}}
+
+	script, err := Convert(recording, Options{})
+	require.NoError(t, err)
+	home, contacts := strings.Index(script, `group("Home"`), strings.Index(script, `group("Contacts"`)
+	require.True(t, home >= 0 && contacts >= 0)
+	assert.Less(t, home, contacts)
+	assert.Contains(t, script, "sleep(1);")
+}
@@ -0,0 +1,443 @@
+package har
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib/consts"
+)
+
+// hopByHopHeaders are the headers that are meaningful only for a single
+// connection and mustn't be forwarded by proxies.
+//
+//nolint:gochecknoglobals
+var hopByHopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Recorder is an HTTP proxy that records all of the requests going through it
+// as HAR entries. HTTPS requests are recorded by terminating the TLS
+// connections with certificates that are issued on the fly by the given CA,
+// so the CA needs to be trusted by the clients.
+type Recorder struct {
+	// Transport is used to make the proxied requests.
+	Transport http.RoundTripper
+
+	ca      *x509.Certificate
+	caKey   crypto.Signer
+	leafKey *ecdsa.PrivateKey
+	logger  logrus.FieldLogger
+
+	certsMu sync.Mutex
+	certs   map[string]*tls.Certificate
+
+	entriesMu sync.Mutex
+	entries   []*Entry
+}
+
+// GenerateCA generates a new self-signed CA certificate for the Recorder and
+// returns the PEM-encoded certificate and private key.
+func GenerateCA() (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "k6 recorder CA", Organization: []string{"k6"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+func randomSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// NewRecorder returns a new Recorder that uses the given CA to intercept HTTPS
+// requests.
+func NewRecorder(ca tls.Certificate, logger logrus.FieldLogger) (*Recorder, error) {
+	if len(ca.Certificate) == 0 {
+		return nil, errors.New("the CA certificate is missing")
+	}
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate: %w", err)
+	}
+	caKey, ok := ca.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported CA private key type")
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		Transport: http.DefaultTransport,
+		ca:        caCert,
+		caKey:     caKey,
+		leafKey:   leafKey,
+		logger:    logger,
+		certs:     make(map[string]*tls.Certificate),
+	}, nil
+}
+
+// HAR returns the recorded requests.
+func (r *Recorder) HAR() HAR {
+	r.entriesMu.Lock()
+	entries := make([]*Entry, len(r.entries))
+	copy(entries, r.entries)
+	r.entriesMu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	return HAR{Log: &Log{
+		Version: "1.2",
+		Creator: &Creator{Name: "k6", Version: consts.Version},
+		Entries: entries,
+	}}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodConnect {
+		r.handleConnect(w, req)
+		return
+	}
+	if !req.URL.IsAbs() {
+		http.Error(w, "this is a recording proxy, requests need to have an absolute URL", http.StatusBadRequest)
+		return
+	}
+
+	outReq := req.Clone(req.Context())
+	outReq.RequestURI = ""
+	removeHopByHopHeaders(outReq.Header)
+
+	resp, err := r.roundTrip(outReq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	removeHopByHopHeaders(resp.Header)
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// handleConnect intercepts the TLS connection of a CONNECT tunnel, so that the
+// HTTPS requests inside of it can be recorded.
+func (r *Recorder) handleConnect(w http.ResponseWriter, req *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "hijacking isn't supported", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		return
+	}
+
+	host := req.URL.Host
+	tlsConn := tls.Server(conn, &tls.Config{ //nolint:gosec
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := hello.ServerName
+			if name == "" {
+				name, _, _ = net.SplitHostPort(host)
+			}
+			return r.certificateFor(name)
+		},
+	})
+	if err = tlsConn.Handshake(); err != nil {
+		r.logger.WithError(err).Debugf("TLS handshake with the client for %s failed", host)
+		return
+	}
+
+	reader := bufio.NewReader(tlsConn)
+	for {
+		innerReq, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		innerReq.URL.Scheme = "https"
+		innerReq.URL.Host = innerReq.Host
+		if innerReq.URL.Host == "" {
+			innerReq.URL.Host = host
+		}
+		innerReq.RequestURI = ""
+		removeHopByHopHeaders(innerReq.Header)
+
+		resp, err := r.roundTrip(innerReq)
+		if err != nil {
+			resp = &http.Response{
+				StatusCode: http.StatusBadGateway,
+				ProtoMajor: 1, ProtoMinor: 1,
+				Header: http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+				Body:   io.NopCloser(strings.NewReader(err.Error())),
+			}
+		}
+		removeHopByHopHeaders(resp.Header)
+		// the client connection is always HTTP/1.1, regardless of the upstream one
+		resp.Proto, resp.ProtoMajor, resp.ProtoMinor = "HTTP/1.1", 1, 1
+		err = resp.Write(tlsConn)
+		_ = resp.Body.Close()
+		if err != nil || innerReq.Close || resp.Close {
+			return
+		}
+	}
+}
+
+func (r *Recorder) certificateFor(host string) (*tls.Certificate, error) {
+	r.certsMu.Lock()
+	defer r.certsMu.Unlock()
+
+	if cert, ok := r.certs[host]; ok {
+		return cert, nil
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(0, 1, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, r.ca, &r.leafKey.PublicKey, r.caKey)
+	if err != nil {
+		return nil, err
+	}
+	cert := &tls.Certificate{Certificate: [][]byte{der, r.ca.Raw}, PrivateKey: r.leafKey}
+	r.certs[host] = cert
+	return cert, nil
+}
+
+// roundTrip makes the request and records it. The returned response has its
+// whole body already read.
+func (r *Recorder) roundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	start := time.Now()
+	resp, err := r.Transport.RoundTrip(req)
+	if err != nil {
+		r.logger.WithError(err).Warnf("Request to %s failed", req.URL)
+		return nil, err
+	}
+	headersReceived := time.Now()
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	end := time.Now()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	resp.TransferEncoding = nil
+	resp.Header.Del("Content-Length")
+
+	entry := NewEntry(req, reqBody, resp, respBody)
+	entry.StartedDateTime = start
+	entry.Time = milliseconds(end.Sub(start))
+	entry.Timings = Timings{Wait: milliseconds(headersReceived.Sub(start)), Receive: milliseconds(end.Sub(headersReceived))}
+
+	r.entriesMu.Lock()
+	r.entries = append(r.entries, entry)
+	r.entriesMu.Unlock()
+	r.logger.Infof("Recorded %s %s %d", req.Method, req.URL, resp.StatusCode)
+
+	return resp, nil
+}
+
+// NewEntry returns an entry with the details of the request and its response,
+// which is nil if the request failed before a response was received.
+func NewEntry(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) *Entry {
+	entry := &Entry{
+		Request: &Request{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     convertCookies(req.Cookies()),
+			Headers:     convertHeaders(req.Header),
+			QueryString: []NameValue{},
+			HeadersSize: -1,
+			BodySize:    int64(len(reqBody)),
+		},
+	}
+	if resp != nil {
+		entry.Response = &Response{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     convertCookies(resp.Cookies()),
+			Headers:     convertHeaders(resp.Header),
+			Content:     convertContent(resp.Header, respBody),
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    int64(len(respBody)),
+		}
+	} else {
+		entry.Response = &Response{
+			Cookies: []Cookie{}, Headers: []NameValue{}, HeadersSize: -1, BodySize: -1,
+		}
+	}
+	if req.Host != "" && req.Header.Get("Host") == "" {
+		entry.Request.Headers = append([]NameValue{{Name: "Host", Value: req.Host}}, entry.Request.Headers...)
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, NameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(entry.Request.QueryString, func(i, j int) bool {
+		return entry.Request.QueryString[i].Name < entry.Request.QueryString[j].Name
+	})
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &PostData{MimeType: req.Header.Get("Content-Type"), Text: string(reqBody)}
+	}
+	return entry
+}
+
+func convertHeaders(header http.Header) []NameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]NameValue, 0, len(header))
+	for _, name := range names {
+		for _, value := range header[name] {
+			result = append(result, NameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+func convertCookies(cookies []*http.Cookie) []Cookie {
+	result := make([]Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		cookie := Cookie{
+			Name: c.Name, Value: c.Value, Path: c.Path, Domain: c.Domain,
+			HTTPOnly: c.HttpOnly, Secure: c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			expires := c.Expires
+			cookie.Expires = &expires
+		}
+		result = append(result, cookie)
+	}
+	return result
+}
+
+func convertContent(header http.Header, body []byte) Content {
+	content := Content{Size: int64(len(body)), MimeType: header.Get("Content-Type")}
+	if strings.EqualFold(header.Get("Content-Encoding"), "gzip") {
+		if decoded, err := gunzip(body); err == nil {
+			content.Size = int64(len(decoded))
+			content.Compression = content.Size - int64(len(body))
+			body = decoded
+		}
+	}
+	if utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	return content
+}
+
+func gunzip(body []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = zr.Close() }()
+	return io.ReadAll(zr)
+}
+
+func removeHopByHopHeaders(header http.Header) {
+	for _, name := range header.Values("Connection") {
+		for _, h := range strings.Split(name, ",") {
+			header.Del(strings.TrimSpace(h))
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
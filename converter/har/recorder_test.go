@@ -0,0 +1,83 @@
+package har
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"method":"` + r.Method + `","body":"` + string(body) + `"}`))
+	})
+	plainSrv := httptest.NewServer(handler)
+	defer plainSrv.Close()
+	tlsSrv := httptest.NewTLSServer(handler)
+	defer tlsSrv.Close()
+
+	certPEM, keyPEM, err := GenerateCA()
+	require.NoError(t, err)
+	ca, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	recorder, err := NewRecorder(ca, testutils.NewLogger(t))
+	require.NoError(t, err)
+	recorder.Transport = tlsSrv.Client().Transport
+
+	proxySrv := httptest.NewServer(recorder)
+	defer proxySrv.Close()
+	proxyURL, err := url.Parse(proxySrv.URL)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(certPEM))
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+	}}
+
+	for _, u := range []string{plainSrv.URL, tlsSrv.URL} {
+		resp, err := client.Post(u+"/path?a=b", "text/plain", strings.NewReader("hello"))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `{"method":"POST","body":"hello"}`, string(body))
+	}
+
+	recording := recorder.HAR()
+	require.Len(t, recording.Log.Entries, 2)
+	for i, u := range []string{plainSrv.URL, tlsSrv.URL} {
+		entry := recording.Log.Entries[i]
+		assert.Equal(t, http.MethodPost, entry.Request.Method)
+		assert.Equal(t, u+"/path?a=b", entry.Request.URL)
+		assert.Equal(t, []NameValue{{Name: "a", Value: "b"}}, entry.Request.QueryString)
+		require.NotNil(t, entry.Request.PostData)
+		assert.Equal(t, "hello", entry.Request.PostData.Text)
+		assert.Equal(t, http.StatusOK, entry.Response.Status)
+		assert.Equal(t, "application/json", entry.Response.Content.MimeType)
+		assert.Equal(t, `{"method":"POST","body":"hello"}`, entry.Response.Content.Text)
+	}
+}
+
+func TestRecorderInvalidCA(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewRecorder(tls.Certificate{}, logrus.New())
+	require.Error(t, err)
+}
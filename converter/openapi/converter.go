@@ -0,0 +1,529 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.k6.io/k6/internal/converterutil"
+)
+
+// maxRefDepth limits how deeply references and nested schemas are followed,
+// so recursive schemas don't result in endless examples.
+const maxRefDepth = 8
+
+//nolint:gochecknoglobals
+var (
+	pathParamRegex = regexp.MustCompile(`\{([^}]+)\}`)
+	wordRegex      = regexp.MustCompile(`[A-Za-z0-9]+`)
+	reservedWords  = map[string]bool{
+		"break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+		"debugger": true, "default": true, "delete": true, "do": true, "else": true, "export": true,
+		"extends": true, "finally": true, "for": true, "function": true, "if": true, "import": true,
+		"in": true, "instanceof": true, "new": true, "return": true, "super": true, "switch": true,
+		"this": true, "throw": true, "try": true, "typeof": true, "var": true, "void": true,
+		"while": true, "with": true, "yield": true, "let": true, "static": true, "enum": true,
+		"await": true, "setup": true, "teardown": true, "options": true, "http": true, "check": true,
+		// declared by the generated script itself
+		"BASE_URL": true, "query": true, "pick": true,
+	}
+)
+
+type operation struct {
+	method  string
+	path    string
+	name    string
+	summary string
+	params  []*Parameter
+
+	bodyContentType string
+	bodyExample     interface{}
+	bodyIsForm      bool
+
+	expectedStatus int
+}
+
+// Convert generates a k6 script from the given specification, with a request
+// builder function and a scenario for every operation.
+func Convert(spec *Spec) (string, error) {
+	ops, err := collectOperations(spec)
+	if err != nil {
+		return "", err
+	}
+	if len(ops) == 0 {
+		return "", fmt.Errorf("the specification doesn't contain any operations")
+	}
+
+	var b strings.Builder
+	title := spec.Info.Title
+	if title == "" {
+		title = "API"
+	}
+	fmt.Fprintf(&b, "// Generated by k6 from the %s OpenAPI specification, review it before running it.\n", converterutil.JSString(title))
+	b.WriteString("import { check } from 'k6';\n")
+	b.WriteString("import http from 'k6/http';\n\n")
+	fmt.Fprintf(&b, "const BASE_URL = __ENV.BASE_URL || %s;\n\n", converterutil.JSString(spec.baseURL()))
+
+	b.WriteString("export const options = {\n")
+	b.WriteString("  // Every operation has its own scenario, adjust the executors to the desired load.\n")
+	b.WriteString("  scenarios: {\n")
+	for _, op := range ops {
+		fmt.Fprintf(&b, "    %s: { executor: 'per-vu-iterations', vus: 1, iterations: 1, exec: %s },\n",
+			op.name, converterutil.JSString(op.name))
+	}
+	b.WriteString("  },\n")
+	b.WriteString("  thresholds: {\n")
+	b.WriteString("    http_req_failed: ['rate<0.01'],\n")
+	for _, op := range ops {
+		fmt.Fprintf(&b, "    %s: ['p(95)<500'],\n", converterutil.JSString("http_req_duration{scenario:"+op.name+"}"))
+	}
+	b.WriteString("  },\n")
+	b.WriteString("};\n\n")
+
+	b.WriteString(helpers)
+
+	for _, op := range ops {
+		writeOperation(&b, spec, op)
+	}
+	return b.String(), nil
+}
+
+const helpers = `// query returns the query string for the given parameters that are set.
+function query(params, names) {
+  const parts = [];
+  for (const name of names) {
+    if (params[name] !== undefined) {
+      parts.push(encodeURIComponent(name) + '=' + encodeURIComponent(params[name]));
+    }
+  }
+  return parts.length > 0 ? '?' + parts.join('&') : '';
+}
+
+// pick returns an object with the given parameters that are set.
+function pick(params, names) {
+  const result = {};
+  for (const name of names) {
+    if (params[name] !== undefined) {
+      result[name] = String(params[name]);
+    }
+  }
+  return result;
+}
+`
+
+func collectOperations(spec *Spec) ([]*operation, error) {
+	paths := make([]string, 0, len(spec.Paths))
+	for p := range spec.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var ops []*operation
+	names := make(map[string]bool)
+	for _, path := range paths {
+		item := spec.Paths[path]
+		if item == nil {
+			continue
+		}
+		for _, o := range item.operations() {
+			op := &operation{
+				method:         o.method,
+				path:           path,
+				summary:        o.op.Summary,
+				params:         mergeParameters(spec, item.Parameters, o.op.Parameters),
+				expectedStatus: expectedStatus(o.op.Responses),
+			}
+			op.name = uniqueName(operationName(o.method, path, o.op.OperationID), names)
+			addUndeclaredPathParameters(op)
+			if err := setRequestBody(spec, op, o.op); err != nil {
+				return nil, fmt.Errorf("unable to convert %s %s: %w", o.method, path, err)
+			}
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+// addUndeclaredPathParameters adds the parameters that are used in the path
+// template, but that aren't declared by the specification.
+func addUndeclaredPathParameters(op *operation) {
+	for _, m := range pathParamRegex.FindAllStringSubmatch(op.path, -1) {
+		declared := false
+		for _, p := range op.params {
+			declared = declared || (p.In == "path" && p.Name == m[1])
+		}
+		if !declared {
+			op.params = append(op.params, &Parameter{Name: m[1], In: "path", Required: true, Type: "string"})
+		}
+	}
+}
+
+// mergeParameters returns the path-level parameters, overridden by the
+// operation-level ones.
+func mergeParameters(spec *Spec, pathParams, opParams []*Parameter) []*Parameter {
+	var result []*Parameter
+	index := make(map[string]int)
+	for _, p := range append(append([]*Parameter{}, pathParams...), opParams...) {
+		p = spec.resolveParameter(p)
+		if p == nil {
+			continue
+		}
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			result[i] = p
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, p)
+	}
+	return result
+}
+
+func expectedStatus(responses map[string]*Response) int {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if status, err := strconv.Atoi(code); err == nil && status >= 200 && status < 400 {
+			return status
+		}
+	}
+	return 200
+}
+
+func setRequestBody(spec *Spec, op *operation, o *Operation) error {
+	// Swagger 2 bodies are specified as parameters
+	var formParams []*Parameter
+	params := op.params[:0]
+	for _, p := range op.params {
+		switch p.In {
+		case "body":
+			op.bodyContentType = "application/json"
+			op.bodyExample = example(spec, p.Schema, 0)
+		case "formData":
+			formParams = append(formParams, p)
+		default:
+			params = append(params, p)
+		}
+	}
+	op.params = params
+	if len(formParams) > 0 {
+		form := make(map[string]interface{}, len(formParams))
+		for _, p := range formParams {
+			if p.Type == "file" {
+				return fmt.Errorf("file uploads aren't supported, but the %q parameter is a file", p.Name)
+			}
+			form[p.Name] = parameterExample(spec, p)
+		}
+		op.bodyContentType = "application/x-www-form-urlencoded"
+		op.bodyExample = form
+		op.bodyIsForm = true
+	}
+
+	body := spec.resolveRequestBody(o.RequestBody)
+	if body == nil || len(body.Content) == 0 {
+		return nil
+	}
+	contentTypes := make([]string, 0, len(body.Content))
+	for ct := range body.Content {
+		contentTypes = append(contentTypes, ct)
+	}
+	sort.Strings(contentTypes)
+	for _, ct := range contentTypes {
+		media := body.Content[ct]
+		if media == nil {
+			continue
+		}
+		ex := media.Example
+		if ex == nil {
+			ex = example(spec, media.Schema, 0)
+		}
+		switch {
+		case strings.Contains(ct, "json"):
+			op.bodyContentType, op.bodyExample, op.bodyIsForm = ct, ex, false
+			return nil
+		case ct == "application/x-www-form-urlencoded":
+			op.bodyContentType, op.bodyExample, op.bodyIsForm = ct, ex, true
+		}
+	}
+	if op.bodyContentType == "" {
+		return fmt.Errorf("none of the request body content types %v are supported", contentTypes)
+	}
+	return nil
+}
+
+func parameterExample(spec *Spec, p *Parameter) interface{} {
+	if p.Example != nil {
+		return p.Example
+	}
+	return example(spec, p.schema(), 0)
+}
+
+// example returns an example value for the schema, using the examples and
+// defaults it has, or a placeholder based on its type.
+func example(spec *Spec, schema *Schema, depth int) interface{} {
+	schema = spec.resolveSchema(schema)
+	if schema == nil || depth > maxRefDepth {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		var v interface{}
+		if err := schema.Enum[0].Decode(&v); err == nil {
+			return v
+		}
+	case len(schema.AllOf) > 0:
+		merged := make(map[string]interface{})
+		for _, s := range schema.AllOf {
+			if obj, ok := example(spec, s, depth+1).(map[string]interface{}); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return example(spec, schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return example(spec, schema.AnyOf[0], depth+1)
+	}
+
+	switch schema.Type {
+	case "string":
+		return stringExample(schema.Format)
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		return []interface{}{example(spec, schema.Items, depth+1)}
+	case "object", "":
+		if len(schema.Properties) == 0 && schema.Type == "" {
+			return nil
+		}
+		obj := make(map[string]interface{}, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			obj[name] = example(spec, prop, depth+1)
+		}
+		return obj
+	}
+	return nil
+}
+
+func stringExample(format string) string {
+	switch format {
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	}
+	return "string"
+}
+
+func jsDocType(spec *Spec, schema *Schema) string {
+	schema = spec.resolveSchema(schema)
+	if schema == nil {
+		return "*"
+	}
+	switch schema.Type {
+	case "integer", "number":
+		return "number"
+	case "string", "boolean", "object":
+		return schema.Type
+	case "array":
+		return "Array"
+	}
+	return "*"
+}
+
+func writeOperation(b *strings.Builder, spec *Spec, op *operation) {
+	var queryParams, headerParams []string
+	defaults := make(map[string]interface{})
+
+	fmt.Fprintf(b, "\n/**\n * %s %s", op.method, op.path)
+	if op.summary != "" {
+		fmt.Fprintf(b, ": %s", strings.TrimSpace(op.summary))
+	}
+	b.WriteString("\n *\n * @param {object} [params] the path, query and header parameters\n")
+	for _, p := range op.params {
+		name := p.Name
+		switch p.In {
+		case "path":
+		case "query":
+			queryParams = append(queryParams, name)
+		case "header":
+			headerParams = append(headerParams, name)
+		default:
+			continue // cookie parameters are left to the cookie jar
+		}
+		doc := "params." + name
+		if p.Required || p.In == "path" {
+			defaults[name] = parameterExample(spec, p)
+		} else {
+			doc = "[" + doc + "]"
+		}
+		fmt.Fprintf(b, " * @param {%s} %s %s parameter", jsDocType(spec, p.schema()), doc, p.In)
+		if p.Description != "" {
+			fmt.Fprintf(b, ": %s", strings.Join(strings.Fields(p.Description), " "))
+		}
+		b.WriteString("\n")
+	}
+	if op.bodyContentType != "" {
+		b.WriteString(" * @param {object} [body] the request body, the example payload is used by default\n")
+	}
+	b.WriteString(" * @returns {{method: string, url: string, body: *, params: object}}\n */\n")
+
+	if op.bodyContentType != "" {
+		fmt.Fprintf(b, "export function %sRequest(params = {}, body = %s) {\n", op.name, jsValue(op.bodyExample))
+	} else {
+		fmt.Fprintf(b, "export function %sRequest(params = {}) {\n", op.name)
+	}
+	if len(defaults) > 0 {
+		fmt.Fprintf(b, "  params = Object.assign(%s, params);\n", jsValue(defaults))
+	}
+
+	b.WriteString("  return {\n")
+	fmt.Fprintf(b, "    method: %s,\n", converterutil.JSString(op.method))
+	fmt.Fprintf(b, "    url: %s,\n", urlExpression(op.path, queryParams))
+	switch {
+	case op.bodyContentType == "":
+		b.WriteString("    body: null,\n")
+	case op.bodyIsForm:
+		b.WriteString("    body: body,\n")
+	default:
+		b.WriteString("    body: JSON.stringify(body),\n")
+	}
+	b.WriteString("    params: {\n")
+	headers := "{}"
+	if op.bodyContentType != "" {
+		headers = "{ 'Content-Type': " + converterutil.JSString(op.bodyContentType) + " }"
+	}
+	if len(headerParams) > 0 {
+		fmt.Fprintf(b, "      headers: Object.assign(%s, pick(params, %s)),\n", headers, jsValue(headerParams))
+	} else if op.bodyContentType != "" {
+		fmt.Fprintf(b, "      headers: %s,\n", headers)
+	}
+	fmt.Fprintf(b, "      tags: { name: %s },\n", converterutil.JSString(op.method+" "+op.path))
+	b.WriteString("    },\n")
+	b.WriteString("  };\n")
+	b.WriteString("}\n\n")
+
+	fmt.Fprintf(b, "export function %s() {\n", op.name)
+	fmt.Fprintf(b, "  const req = %sRequest();\n", op.name)
+	b.WriteString("  const res = http.request(req.method, req.url, req.body, req.params);\n")
+	fmt.Fprintf(b, "  check(res, { %s: (r) => r.status === %d });\n",
+		converterutil.JSString(fmt.Sprintf("status is %d", op.expectedStatus)), op.expectedStatus)
+	b.WriteString("}\n")
+}
+
+func urlExpression(path string, queryParams []string) string {
+	parts := []string{"BASE_URL"}
+	last := 0
+	for _, m := range pathParamRegex.FindAllStringSubmatchIndex(path, -1) {
+		if m[0] > last {
+			parts = append(parts, converterutil.JSString(path[last:m[0]]))
+		}
+		name := path[m[2]:m[3]]
+		parts = append(parts, "encodeURIComponent(params["+converterutil.JSString(name)+"])")
+		last = m[1]
+	}
+	if last < len(path) {
+		parts = append(parts, converterutil.JSString(path[last:]))
+	}
+	if len(queryParams) > 0 {
+		parts = append(parts, "query(params, "+jsValue(queryParams)+")")
+	}
+	return strings.Join(parts, " + ")
+}
+
+func operationName(method, path, operationID string) string {
+	source := operationID
+	if source == "" {
+		source = strings.ToLower(method) + " " + path
+	}
+	words := wordRegex.FindAllString(source, -1)
+	var name strings.Builder
+	for i, w := range words {
+		if i == 0 {
+			// keep the existing camelCase of operation IDs
+			r := []rune(w)
+			r[0] = unicode.ToLower(r[0])
+			name.WriteString(string(r))
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		name.WriteString(string(r))
+	}
+	result := name.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "op" + result
+	}
+	if reservedWords[result] {
+		result += "Operation"
+	}
+	return result
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	result := name
+	for i := 2; used[result]; i++ {
+		result = name + strconv.Itoa(i)
+	}
+	used[result] = true
+	return result
+}
+
+// jsValue returns the JSON representation of v, which is also a valid JS
+// expression, since JSON escapes U+2028 and U+2029.
+func jsValue(v interface{}) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(normalize(v)); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// normalize converts the map[interface{}]interface{} values, that YAML
+// examples could contain, to something that can be encoded to JSON.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			result[fmt.Sprint(k)] = normalize(val)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			result[k] = normalize(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			result[i] = normalize(val)
+		}
+		return result
+	}
+	return v
+}
@@ -0,0 +1,198 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const petstoreSpec = `
+openapi: 3.0.0
+info:
+  title: Petstore
+  version: 1.0.0
+servers:
+  - url: https://{env}.example.com/v1/
+    variables:
+      env:
+        default: api
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List all pets
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+        - $ref: '#/components/parameters/RequestID'
+      responses:
+        '200':
+          description: A list of pets
+    post:
+      operationId: createPets
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        '201':
+          description: Created
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema:
+          type: string
+          example: rex
+    delete:
+      responses:
+        '204':
+          description: Deleted
+components:
+  parameters:
+    RequestID:
+      name: X-Request-ID
+      in: header
+      required: true
+      schema:
+        type: string
+        format: uuid
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+          example: doggie
+        tags:
+          type: array
+          items:
+            type: string
+            enum: [cute, small]
+        birthday:
+          type: string
+          format: date
+`
+
+func TestConvertOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	spec, err := Parse([]byte(petstoreSpec))
+	require.NoError(t, err)
+	script, err := Convert(spec)
+	require.NoError(t, err)
+
+	assert.Contains(t, script, `const BASE_URL = __ENV.BASE_URL || "https://api.example.com/v1";`)
+	assert.Contains(t, script, `listPets: { executor: 'per-vu-iterations', vus: 1, iterations: 1, exec: "listPets" },`)
+	assert.Contains(t, script, `"http_req_duration{scenario:createPets}": ['p(95)<500'],`)
+	assert.Contains(t, script, `"http_req_duration{scenario:deletePetsPetId}": ['p(95)<500'],`)
+
+	assert.Contains(t, script, ` * GET /pets: List all pets`)
+	assert.Contains(t, script, ` * @param {number} [params.limit] query parameter`)
+	assert.Contains(t, script, `params = Object.assign({"X-Request-ID":"00000000-0000-0000-0000-000000000000"}, params);`)
+	assert.Contains(t, script, `url: BASE_URL + "/pets" + query(params, ["limit"]),`)
+	assert.Contains(t, script, `headers: Object.assign({}, pick(params, ["X-Request-ID"])),`)
+
+	assert.Contains(t, script,
+		`export function createPetsRequest(params = {}, body = {"birthday":"2024-01-01","name":"doggie","tags":["cute"]}) {`)
+	assert.Contains(t, script, `body: JSON.stringify(body),`)
+	assert.Contains(t, script, `headers: { 'Content-Type': "application/json" },`)
+	assert.Contains(t, script, `check(res, { "status is 201": (r) => r.status === 201 });`)
+
+	assert.Contains(t, script, `params = Object.assign({"petId":"rex"}, params);`)
+	assert.Contains(t, script, `url: BASE_URL + "/pets/" + encodeURIComponent(params["petId"]),`)
+	assert.Contains(t, script, `tags: { name: "DELETE /pets/{petId}" },`)
+}
+
+func TestConvertSwagger(t *testing.T) {
+	t.Parallel()
+
+	spec, err := Parse([]byte(`{
+		"swagger": "2.0",
+		"info": {"title": "Users"},
+		"host": "users.example.com",
+		"basePath": "/api",
+		"schemes": ["http"],
+		"paths": {
+			"/users/{id}": {
+				"put": {
+					"operationId": "update-user",
+					"parameters": [
+						{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}
+					]
+				}
+			},
+			"/login": {
+				"post": {
+					"operationId": "delete",
+					"parameters": [
+						{"name": "user", "in": "formData", "type": "string", "required": true},
+						{"name": "remember", "in": "formData", "type": "boolean"}
+					]
+				}
+			}
+		},
+		"definitions": {
+			"User": {"type": "object", "properties": {"age": {"type": "integer"}, "friend": {"$ref": "#/definitions/User"}}}
+		}
+	}`))
+	require.NoError(t, err)
+	script, err := Convert(spec)
+	require.NoError(t, err)
+
+	assert.Contains(t, script, `const BASE_URL = __ENV.BASE_URL || "http://users.example.com/api";`)
+	assert.Contains(t, script, `export function updateUser() {`)
+	assert.Contains(t, script, `params = Object.assign({"id":"string"}, params);`)
+	assert.Contains(t, script, `"age":1`)
+	assert.Contains(t, script, `export function deleteOperationRequest(params = {}, body = {"remember":true,"user":"string"}) {`)
+	assert.Contains(t, script, `headers: { 'Content-Type': "application/x-www-form-urlencoded" },`)
+	assert.Contains(t, script, "    body: body,\n")
+}
+
+func TestConvertReservedNames(t *testing.T) {
+	t.Parallel()
+
+	spec, err := Parse([]byte(`openapi: 3.0.0
+paths:
+  /search:
+    get:
+      operationId: query
+      parameters:
+        - name: pick
+          in: query
+          schema:
+            type: string
+  /pick:
+    post:
+      operationId: pick
+`))
+	require.NoError(t, err)
+	script, err := Convert(spec)
+	require.NoError(t, err)
+
+	assert.Contains(t, script, "export function queryOperation() {\n")
+	assert.Contains(t, script, "export function pickOperation() {\n")
+	assert.Contains(t, script, "export function queryOperationRequest(params = {}) {\n")
+	assert.Contains(t, script, `url: BASE_URL + "/search" + query(params, ["pick"]),`)
+	assert.NotContains(t, script, "export function query(")
+	assert.NotContains(t, script, "export function pick(")
+}
+
+func TestParseInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte(`openapi: [`))
+	require.Error(t, err)
+	_, err = Parse([]byte(`swagger: "1.2"`))
+	require.Error(t, err)
+
+	spec, err := Parse([]byte(`openapi: 3.1.0`))
+	require.NoError(t, err)
+	_, err = Convert(spec)
+	require.Error(t, err)
+}
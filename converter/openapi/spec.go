@@ -0,0 +1,229 @@
+// Package openapi generates k6 scripts from OpenAPI 3 and Swagger 2
+// specifications.
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"go.k6.io/k6/internal/converterutil"
+)
+
+// Spec contains the parts of an OpenAPI 3 or Swagger 2 specification that are
+// needed for generating scripts.
+type Spec struct {
+	OpenAPI string `yaml:"openapi"`
+	Swagger string `yaml:"swagger"`
+	Info    Info   `yaml:"info"`
+
+	// OpenAPI 3
+	Servers    []Server   `yaml:"servers"`
+	Components Components `yaml:"components"`
+
+	// Swagger 2
+	Host        string             `yaml:"host"`
+	BasePath    string             `yaml:"basePath"`
+	Schemes     []string           `yaml:"schemes"`
+	Consumes    []string           `yaml:"consumes"`
+	Definitions map[string]*Schema `yaml:"definitions"`
+
+	Paths map[string]*PathItem `yaml:"paths"`
+}
+
+// Info contains the metadata of the API.
+type Info struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+// Server is an OpenAPI 3 server.
+type Server struct {
+	URL       string                    `yaml:"url"`
+	Variables map[string]ServerVariable `yaml:"variables"`
+}
+
+// ServerVariable is a variable of a Server URL template.
+type ServerVariable struct {
+	Default string `yaml:"default"`
+}
+
+// Components contains the reusable OpenAPI 3 objects.
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+}
+
+// PathItem describes the operations available on a single path.
+type PathItem struct {
+	Get        *Operation   `yaml:"get"`
+	Put        *Operation   `yaml:"put"`
+	Post       *Operation   `yaml:"post"`
+	Delete     *Operation   `yaml:"delete"`
+	Options    *Operation   `yaml:"options"`
+	Head       *Operation   `yaml:"head"`
+	Patch      *Operation   `yaml:"patch"`
+	Trace      *Operation   `yaml:"trace"`
+	Parameters []*Parameter `yaml:"parameters"`
+}
+
+// operations returns the operations of the path, in a stable order.
+func (p *PathItem) operations() []struct {
+	method string
+	op     *Operation
+} {
+	all := []struct {
+		method string
+		op     *Operation
+	}{
+		{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch},
+		{"DELETE", p.Delete}, {"HEAD", p.Head}, {"OPTIONS", p.Options}, {"TRACE", p.Trace},
+	}
+	result := all[:0]
+	for _, o := range all {
+		if o.op != nil {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Summary     string               `yaml:"summary"`
+	Tags        []string             `yaml:"tags"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+	Consumes    []string             `yaml:"consumes"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Ref         string      `yaml:"$ref"`
+	Name        string      `yaml:"name"`
+	In          string      `yaml:"in"`
+	Description string      `yaml:"description"`
+	Required    bool        `yaml:"required"`
+	Schema      *Schema     `yaml:"schema"`
+	Example     interface{} `yaml:"example"`
+
+	// Swagger 2 non-body parameters have their schema inline
+	Type   string      `yaml:"type"`
+	Format string      `yaml:"format"`
+	Items  *Schema     `yaml:"items"`
+	Enum   []yaml.Node `yaml:"enum"`
+}
+
+func (p *Parameter) schema() *Schema {
+	if p.Schema != nil {
+		return p.Schema
+	}
+	return &Schema{Type: p.Type, Format: p.Format, Items: p.Items, Enum: p.Enum}
+}
+
+// RequestBody describes an OpenAPI 3 request body.
+type RequestBody struct {
+	Ref      string                `yaml:"$ref"`
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+// MediaType describes the schema and example of a request body type.
+type MediaType struct {
+	Schema  *Schema     `yaml:"schema"`
+	Example interface{} `yaml:"example"`
+}
+
+// Response describes a single response of an operation.
+type Response struct {
+	Description string `yaml:"description"`
+}
+
+// Schema is a JSON schema, as used by OpenAPI.
+type Schema struct {
+	Ref        string             `yaml:"$ref"`
+	Type       string             `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Properties map[string]*Schema `yaml:"properties"`
+	Items      *Schema            `yaml:"items"`
+	Required   []string           `yaml:"required"`
+	Example    interface{}        `yaml:"example"`
+	Default    interface{}        `yaml:"default"`
+	Enum       []yaml.Node        `yaml:"enum"`
+	AllOf      []*Schema          `yaml:"allOf"`
+	OneOf      []*Schema          `yaml:"oneOf"`
+	AnyOf      []*Schema          `yaml:"anyOf"`
+}
+
+// Parse parses an OpenAPI 3 or Swagger 2 specification, in either the YAML
+// or the JSON format.
+func Parse(data []byte) (*Spec, error) {
+	spec := &Spec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("unable to parse the specification: %w", err)
+	}
+	switch {
+	case strings.HasPrefix(spec.OpenAPI, "3."), strings.HasPrefix(spec.Swagger, "2."):
+	default:
+		return nil, fmt.Errorf("only OpenAPI 3.x and Swagger 2.0 specifications are supported")
+	}
+	return spec, nil
+}
+
+// baseURL returns the URL of the first server of the specification.
+func (s *Spec) baseURL() string {
+	if strings.HasPrefix(s.Swagger, "2.") {
+		if s.Host == "" {
+			return strings.TrimSuffix(s.BasePath, "/")
+		}
+		scheme := "https"
+		if len(s.Schemes) > 0 && !converterutil.ContainsString(s.Schemes, "https") {
+			scheme = s.Schemes[0]
+		}
+		return scheme + "://" + s.Host + strings.TrimSuffix(s.BasePath, "/")
+	}
+
+	if len(s.Servers) == 0 {
+		return ""
+	}
+	u := s.Servers[0].URL
+	for name, v := range s.Servers[0].Variables {
+		u = strings.ReplaceAll(u, "{"+name+"}", v.Default)
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// resolveParameter follows $ref links to components.
+func (s *Spec) resolveParameter(p *Parameter) *Parameter {
+	for i := 0; p != nil && p.Ref != "" && i < maxRefDepth; i++ {
+		name := strings.TrimPrefix(p.Ref, "#/components/parameters/")
+		p = s.Components.Parameters[name]
+	}
+	return p
+}
+
+func (s *Spec) resolveRequestBody(b *RequestBody) *RequestBody {
+	for i := 0; b != nil && b.Ref != "" && i < maxRefDepth; i++ {
+		name := strings.TrimPrefix(b.Ref, "#/components/requestBodies/")
+		b = s.Components.RequestBodies[name]
+	}
+	return b
+}
+
+func (s *Spec) resolveSchema(schema *Schema) *Schema {
+	for i := 0; schema != nil && schema.Ref != "" && i < maxRefDepth; i++ {
+		switch {
+		case strings.HasPrefix(schema.Ref, "#/components/schemas/"):
+			schema = s.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		case strings.HasPrefix(schema.Ref, "#/definitions/"):
+			schema = s.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")]
+		default:
+			return nil // external references aren't supported
+		}
+	}
+	return schema
+}
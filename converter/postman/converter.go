@@ -0,0 +1,335 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.k6.io/k6/internal/converterutil"
+)
+
+//nolint:gochecknoglobals
+var (
+	setVariableRegex = regexp.MustCompile(
+		`^(\s*)(?:pm\.(?:environment|variables|collectionVariables|globals)\.set|` +
+			`postman\.set(?:Environment|Global)Variable)\(\s*("[^"]*"|'[^']*')\s*,\s*(.*)\)\s*;?\s*$`)
+	unsetVariableRegex = regexp.MustCompile(
+		`^(\s*)(?:pm\.(?:environment|variables|collectionVariables|globals)\.unset|` +
+			`postman\.clear(?:Environment|Global)Variable)\(\s*("[^"]*"|'[^']*')\s*\)\s*;?\s*$`)
+	getVariableRegex = regexp.MustCompile(
+		`(?:pm\.(?:environment|variables|collectionVariables|globals)\.get|` +
+			`postman\.get(?:Environment|Global)Variable)\(\s*("[^"]*"|'[^']*')\s*\)`)
+	statusAssertionRegex = regexp.MustCompile(
+		`pm\.response\.to\.have\.status\(\s*(\d{3})\s*\)|responseCode\.code\s*===?\s*(\d{3})`)
+	postmanAPIRegex = regexp.MustCompile(`\b(?:pm|postman)\.`)
+)
+
+// Convert generates a k6 script from the given collection. Folders are mapped
+// to groups, the variables are initialized from the collection and the
+// environment, if there's one, and can be overridden with environment
+// variables. Pre-request scripts are translated on a best-effort basis.
+func Convert(collection *Collection, env *Environment) (string, error) {
+	c := &converter{}
+
+	var body strings.Builder
+	for _, item := range collection.Item {
+		if err := c.writeItem(&body, item, collection.Auth, collection.Event, "  "); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	name := collection.Info.Name
+	if name == "" {
+		name = "unnamed"
+	}
+	fmt.Fprintf(&b, "// Generated by k6 from the %s Postman collection, review it before running it.\n", converterutil.JSString(name))
+	b.WriteString("import { check, group } from 'k6';\n")
+	if c.usesEncoding {
+		b.WriteString("import encoding from 'k6/encoding';\n")
+	}
+	b.WriteString("import http from 'k6/http';\n\n")
+	b.WriteString("export const options = {};\n\n")
+
+	writeVariables(&b, collection.Variable, env)
+	b.WriteString(helpers)
+
+	b.WriteString("\nexport default function () {\n")
+	b.WriteString("  let res;\n")
+	b.WriteString(body.String())
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+const helpers = `
+// render replaces the {{variable}} placeholders with the values of the variables.
+function render(s) {
+  return s.replace(/{{\s*([^{}]+?)\s*}}/g, (match, name) => (name in vars ? String(vars[name]) : match));
+}
+`
+
+type converter struct {
+	usesEncoding bool
+}
+
+func writeVariables(b *strings.Builder, variables []Variable, env *Environment) {
+	values := make(map[string]string)
+	for _, v := range variables {
+		if !v.Disabled {
+			values[v.Key] = stringValue(v.Value)
+		}
+	}
+	if env != nil {
+		for _, v := range env.Values {
+			if v.Enabled == nil || *v.Enabled {
+				values[v.Key] = stringValue(v.Value)
+			}
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteString("// The variables of the collection, they can be overridden with environment variables.\n")
+	b.WriteString("const vars = {\n")
+	for _, k := range keys {
+		fmt.Fprintf(b, "  %s: __ENV[%s] || %s,\n", converterutil.JSString(k), converterutil.JSString(k), converterutil.JSString(values[k]))
+	}
+	b.WriteString("};\n")
+}
+
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, _ := json.Marshal(v) //nolint:errchkjson
+		return string(data)
+	}
+}
+
+func (c *converter) writeItem(b *strings.Builder, item *Item, auth *Auth, events []Event, indent string) error {
+	if item.Auth != nil {
+		auth = item.Auth
+	}
+	events = append(append([]Event{}, events...), item.Event...)
+
+	if item.isFolder() {
+		fmt.Fprintf(b, "\n%sgroup(%s, function () {\n", indent, converterutil.JSString(item.Name))
+		for _, child := range item.Item {
+			if err := c.writeItem(b, child, auth, events, indent+"  "); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(b, "%s});\n", indent)
+		return nil
+	}
+
+	req := item.Request
+	if req.Auth != nil {
+		auth = req.Auth
+	}
+	fmt.Fprintf(b, "\n%s// %s\n", indent, item.Name)
+	for _, e := range events {
+		if e.Listen == "prerequest" {
+			writePreRequestScript(b, e.Script.Exec, indent)
+		}
+	}
+
+	headers, err := c.headers(req, auth)
+	if err != nil {
+		return fmt.Errorf("unable to convert %q: %w", item.Name, err)
+	}
+	body, contentType, err := requestBody(req.Body)
+	if err != nil {
+		return fmt.Errorf("unable to convert %q: %w", item.Name, err)
+	}
+	if contentType != "" && !hasHeader(req.Header, "Content-Type") {
+		headers = append([]string{converterutil.JSString("Content-Type") + ": " + converterutil.JSString(contentType)}, headers...)
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	fmt.Fprintf(b, "%sres = http.request(%s, render(%s), %s, {\n", indent, converterutil.JSString(method), converterutil.JSString(req.URL.Raw), body)
+	if len(headers) > 0 {
+		fmt.Fprintf(b, "%s  headers: {\n", indent)
+		for _, h := range headers {
+			fmt.Fprintf(b, "%s    %s,\n", indent, h)
+		}
+		fmt.Fprintf(b, "%s  },\n", indent)
+	}
+	fmt.Fprintf(b, "%s  tags: { name: %s },\n", indent, converterutil.JSString(item.Name))
+	fmt.Fprintf(b, "%s});\n", indent)
+
+	for _, e := range events {
+		if e.Listen == "test" {
+			writeTestScript(b, e.Script.Exec, indent)
+		}
+	}
+	return nil
+}
+
+func (c *converter) headers(req *Request, auth *Auth) ([]string, error) {
+	var headers []string
+	for _, h := range req.Header {
+		if !h.Disabled {
+			headers = append(headers, converterutil.JSString(h.Key)+": render("+converterutil.JSString(h.Value)+")")
+		}
+	}
+	if auth == nil || hasHeader(req.Header, "Authorization") {
+		return headers, nil
+	}
+
+	switch auth.Type {
+	case "", "noauth":
+	case "basic":
+		c.usesEncoding = true
+		credentials := fmt.Sprintf("render(%s) + ':' + render(%s)",
+			converterutil.JSString(auth.Basic["username"]), converterutil.JSString(auth.Basic["password"]))
+		headers = append(headers, `"Authorization": "Basic " + encoding.b64encode(`+credentials+`)`)
+	case "bearer":
+		headers = append(headers, `"Authorization": "Bearer " + render(`+converterutil.JSString(auth.Bearer["token"])+`)`)
+	case "apikey":
+		if in := auth.APIKey["in"]; in != "" && in != "header" {
+			return nil, fmt.Errorf("only API keys in headers are supported, not in %q", in)
+		}
+		headers = append(headers, "render("+converterutil.JSString(auth.APIKey["key"])+"): render("+converterutil.JSString(auth.APIKey["value"])+")")
+	default:
+		return nil, fmt.Errorf("the %q authentication isn't supported", auth.Type)
+	}
+	return headers, nil
+}
+
+func hasHeader(headers []KeyValue, name string) bool {
+	for _, h := range headers {
+		if !h.Disabled && strings.EqualFold(h.Key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestBody returns the JS expression of the request body and the content
+// type that Postman would set automatically for it.
+func requestBody(body *Body) (string, string, error) {
+	if body == nil {
+		return "null", "", nil
+	}
+	switch body.Mode {
+	case "", "none":
+		return "null", "", nil
+	case "raw":
+		contentType := ""
+		switch body.Options.Raw.Language {
+		case "json":
+			contentType = "application/json"
+		case "xml":
+			contentType = "application/xml"
+		}
+		return "render(" + converterutil.JSString(body.Raw) + ")", contentType, nil
+	case "urlencoded", "formdata":
+		fields := body.URLEncoded
+		if body.Mode == "formdata" {
+			fields = body.FormData
+		}
+		parts := make([]string, 0, len(fields))
+		for _, f := range fields {
+			if f.Disabled {
+				continue
+			}
+			if f.Type == "file" {
+				return "", "", fmt.Errorf("file uploads aren't supported, but the %q field is a file", f.Key)
+			}
+			parts = append(parts, converterutil.JSString(f.Key)+": render("+converterutil.JSString(f.Value)+")")
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", "", nil
+	case "graphql":
+		if body.GraphQL == nil {
+			return "null", "", nil
+		}
+		variables := "{}"
+		if strings.TrimSpace(body.GraphQL.Variables) != "" {
+			variables = "JSON.parse(render(" + converterutil.JSString(body.GraphQL.Variables) + "))"
+		}
+		return "JSON.stringify({ query: render(" + converterutil.JSString(body.GraphQL.Query) + "), variables: " + variables + " })",
+			"application/json", nil
+	}
+	return "", "", fmt.Errorf("the %q body mode isn't supported", body.Mode)
+}
+
+// translatePreRequestScript translates the Postman variable API calls of the
+// script, it returns false if there were other calls to the Postman APIs.
+func translatePreRequestScript(lines []string) ([]string, bool) {
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		switch {
+		case setVariableRegex.MatchString(line):
+			line = setVariableRegex.ReplaceAllString(line, "${1}vars[${2}] = ${3};")
+		case unsetVariableRegex.MatchString(line):
+			line = unsetVariableRegex.ReplaceAllString(line, "${1}delete vars[${2}];")
+		}
+		line = getVariableRegex.ReplaceAllString(line, "vars[${1}]")
+		if postmanAPIRegex.MatchString(line) {
+			return nil, false
+		}
+		result = append(result, line)
+	}
+	return result, true
+}
+
+func writePreRequestScript(b *strings.Builder, lines []string, indent string) {
+	if strings.TrimSpace(strings.Join(lines, "")) == "" {
+		return
+	}
+	translated, ok := translatePreRequestScript(lines)
+	if !ok {
+		fmt.Fprintf(b, "%s// TODO: the pre-request script uses Postman APIs that couldn't be translated:\n", indent)
+		writeCommented(b, lines, indent)
+		return
+	}
+	fmt.Fprintf(b, "%s// Translated pre-request script\n", indent)
+	b.WriteString(indent + "{\n")
+	for _, line := range translated {
+		if strings.TrimSpace(line) != "" {
+			fmt.Fprintf(b, "%s  %s\n", indent, line)
+		}
+	}
+	b.WriteString(indent + "}\n")
+}
+
+// writeTestScript converts the status assertions of a test script to checks,
+// and keeps the rest of the script as comments.
+func writeTestScript(b *strings.Builder, lines []string, indent string) {
+	if strings.TrimSpace(strings.Join(lines, "")) == "" {
+		return
+	}
+	fmt.Fprintf(b, "%s// TODO: translate the rest of the test script:\n", indent)
+	writeCommented(b, lines, indent)
+
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		for _, m := range statusAssertionRegex.FindAllStringSubmatch(line, -1) {
+			status := m[1] + m[2]
+			if seen[status] {
+				continue
+			}
+			seen[status] = true
+			fmt.Fprintf(b, "%scheck(res, { %s: (r) => r.status === %s });\n",
+				indent, converterutil.JSString("status is "+status), status)
+		}
+	}
+}
+
+func writeCommented(b *strings.Builder, lines []string, indent string) {
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
@@ -0,0 +1,140 @@
+package postman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCollection = `{
+  "info": {
+    "name": "Shop",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "variable": [
+    {"key": "baseUrl", "value": "https://shop.example.com"},
+    {"key": "token", "value": "collection-token"}
+  ],
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+  "item": [
+    {
+      "name": "Products",
+      "item": [
+        {
+          "name": "List products",
+          "event": [
+            {"listen": "prerequest", "script": {"exec": [
+              "const ts = Date.now();",
+              "pm.environment.set(\"ts\", ts);"
+            ]}},
+            {"listen": "test", "script": {"exec": [
+              "pm.test(\"Status code is 200\", function () {",
+              "    pm.response.to.have.status(200);",
+              "});"
+            ]}}
+          ],
+          "request": {
+            "method": "GET",
+            "header": [
+              {"key": "Accept", "value": "application/json"},
+              {"key": "X-Debug", "value": "1", "disabled": true}
+            ],
+            "url": {"raw": "{{baseUrl}}/products?ts={{ts}}", "host": ["{{baseUrl}}"]}
+          }
+        }
+      ]
+    },
+    {
+      "name": "Login",
+      "event": [
+        {"listen": "prerequest", "script": {"exec": "pm.sendRequest(\"https://example.com\");"}}
+      ],
+      "request": {
+        "method": "POST",
+        "auth": {"type": "basic", "basic": [
+          {"key": "username", "value": "{{user}}"},
+          {"key": "password", "value": "secret"}
+        ]},
+        "body": {"mode": "raw", "raw": "{\"remember\": true}", "options": {"raw": {"language": "json"}}},
+        "url": "{{baseUrl}}/login"
+      }
+    },
+    {
+      "name": "Subscribe",
+      "request": {
+        "method": "POST",
+        "body": {"mode": "urlencoded", "urlencoded": [{"key": "email", "value": "{{user}}@example.com"}]},
+        "url": "{{baseUrl}}/subscribe"
+      }
+    }
+  ]
+}`
+
+const testEnvironment = `{
+  "name": "staging",
+  "values": [
+    {"key": "baseUrl", "value": "https://staging.example.com", "enabled": true},
+    {"key": "user", "value": "tester", "enabled": true},
+    {"key": "unused", "value": "nope", "enabled": false}
+  ]
+}`
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	collection, err := Parse([]byte(testCollection))
+	require.NoError(t, err)
+	env, err := ParseEnvironment([]byte(testEnvironment))
+	require.NoError(t, err)
+
+	script, err := Convert(collection, env)
+	require.NoError(t, err)
+
+	assert.Contains(t, script, `import encoding from 'k6/encoding';`)
+	assert.Contains(t, script, `"baseUrl": __ENV["baseUrl"] || "https://staging.example.com",`)
+	assert.Contains(t, script, `"token": __ENV["token"] || "collection-token",`)
+	assert.Contains(t, script, `"user": __ENV["user"] || "tester",`)
+	assert.NotContains(t, script, "unused")
+
+	assert.Contains(t, script, `  group("Products", function () {`)
+	assert.Contains(t, script, "      const ts = Date.now();\n      vars[\"ts\"] = ts;\n")
+	assert.Contains(t, script, `res = http.request("GET", render("{{baseUrl}}/products?ts={{ts}}"), null, {`)
+	assert.Contains(t, script, `"Accept": render("application/json"),`)
+	assert.NotContains(t, script, "X-Debug")
+	assert.Contains(t, script, `"Authorization": "Bearer " + render("{{token}}"),`)
+	assert.Contains(t, script, `tags: { name: "List products" },`)
+	assert.Contains(t, script, `//     pm.response.to.have.status(200);`)
+	assert.Contains(t, script, `check(res, { "status is 200": (r) => r.status === 200 });`)
+
+	assert.Contains(t, script, `// TODO: the pre-request script uses Postman APIs that couldn't be translated:`)
+	assert.Contains(t, script, `// pm.sendRequest("https://example.com");`)
+	assert.Contains(t, script, `res = http.request("POST", render("{{baseUrl}}/login"), render("{\"remember\": true}"), {`)
+	assert.Contains(t, script, `"Content-Type": "application/json",`)
+	assert.Contains(t, script,
+		`"Authorization": "Basic " + encoding.b64encode(render("{{user}}") + ':' + render("secret")),`)
+
+	assert.Contains(t, script, `{ "email": render("{{user}}@example.com") }`)
+}
+
+func TestConvertUnsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`))
+	require.Error(t, err)
+
+	collection, err := Parse([]byte(`{"item": [{"name": "upload", "request": {
+		"method": "POST", "url": "https://example.com",
+		"body": {"mode": "formdata", "formdata": [{"key": "file", "type": "file", "src": "a.txt"}]}
+	}}]}`))
+	require.NoError(t, err)
+	_, err = Convert(collection, nil)
+	require.Error(t, err)
+
+	collection, err = Parse([]byte(`{"item": [{"name": "oauth", "request": {
+		"method": "GET", "url": "https://example.com", "auth": {"type": "oauth2"}
+	}}]}`))
+	require.NoError(t, err)
+	_, err = Convert(collection, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,193 @@
+// Package postman generates k6 scripts from Postman v2.0 and v2.1 collections.
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Collection is a Postman collection.
+type Collection struct {
+	Info     Info       `json:"info"`
+	Item     []*Item    `json:"item"`
+	Variable []Variable `json:"variable"`
+	Event    []Event    `json:"event"`
+	Auth     *Auth      `json:"auth"`
+}
+
+// Info contains the metadata of a collection.
+type Info struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// Item is either a folder, that contains other items, or a single request.
+type Item struct {
+	Name    string   `json:"name"`
+	Item    []*Item  `json:"item"`
+	Request *Request `json:"request"`
+	Event   []Event  `json:"event"`
+	Auth    *Auth    `json:"auth"`
+}
+
+// isFolder returns true if the item is a folder.
+func (i *Item) isFolder() bool {
+	return i.Request == nil
+}
+
+// Request is a single request of a collection.
+type Request struct {
+	Method string     `json:"method"`
+	URL    URL        `json:"url"`
+	Header []KeyValue `json:"header"`
+	Body   *Body      `json:"body"`
+	Auth   *Auth      `json:"auth"`
+}
+
+// URL is a request URL, that can be either a string or an object.
+type URL struct {
+	Raw string `json:"raw"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (u *URL) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &u.Raw)
+	}
+	type plain URL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+// KeyValue is used for headers, form fields and other key-value pairs.
+type KeyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+}
+
+// Body is the body of a request.
+type Body struct {
+	Mode       string     `json:"mode"`
+	Raw        string     `json:"raw"`
+	URLEncoded []KeyValue `json:"urlencoded"`
+	FormData   []KeyValue `json:"formdata"`
+	GraphQL    *GraphQL   `json:"graphql"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+// GraphQL is the body of a GraphQL request.
+type GraphQL struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables"`
+}
+
+// Auth is the authentication of a collection, a folder or a request.
+type Auth struct {
+	Type   string     `json:"type"`
+	Basic  AuthParams `json:"basic"`
+	Bearer AuthParams `json:"bearer"`
+	APIKey AuthParams `json:"apikey"`
+}
+
+// AuthParams are the parameters of an authentication method. Postman v2.1
+// uses a list of key-value pairs, while v2.0 uses an object.
+type AuthParams map[string]string
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (p *AuthParams) UnmarshalJSON(data []byte) error {
+	*p = make(AuthParams)
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		var list []KeyValue
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+		for _, kv := range list {
+			(*p)[kv.Key] = kv.Value
+		}
+		return nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	for k, v := range obj {
+		if s, ok := v.(string); ok {
+			(*p)[k] = s
+		}
+	}
+	return nil
+}
+
+// Event is a script that's executed before a request or after it, as a test.
+type Event struct {
+	Listen string `json:"listen"`
+	Script Script `json:"script"`
+}
+
+// Script contains the source of an event script.
+type Script struct {
+	Exec []string `json:"exec"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (s *Script) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Exec json.RawMessage `json:"exec"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw.Exec) == 0 {
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(raw.Exec, &single); err == nil {
+		s.Exec = strings.Split(single, "\n")
+		return nil
+	}
+	return json.Unmarshal(raw.Exec, &s.Exec)
+}
+
+// Variable is a collection variable.
+type Variable struct {
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+	Disabled bool        `json:"disabled"`
+}
+
+// Environment is an exported Postman environment.
+type Environment struct {
+	Name   string `json:"name"`
+	Values []struct {
+		Key     string      `json:"key"`
+		Value   interface{} `json:"value"`
+		Enabled *bool       `json:"enabled"`
+	} `json:"values"`
+}
+
+// Parse parses a Postman collection.
+func Parse(data []byte) (*Collection, error) {
+	collection := &Collection{}
+	if err := json.Unmarshal(data, collection); err != nil {
+		return nil, fmt.Errorf("unable to parse the collection: %w", err)
+	}
+	if collection.Info.Schema != "" && !strings.Contains(collection.Info.Schema, "/v2.") {
+		return nil, fmt.Errorf("only v2.0 and v2.1 collections are supported, but the schema is %q", collection.Info.Schema)
+	}
+	return collection, nil
+}
+
+// ParseEnvironment parses an exported Postman environment.
+func ParseEnvironment(data []byte) (*Environment, error) {
+	env := &Environment{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, fmt.Errorf("unable to parse the environment: %w", err)
+	}
+	return env, nil
+}
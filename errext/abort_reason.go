@@ -15,6 +15,7 @@ const (
 	AbortedByScriptAbort
 	AbortedByTimeout
 	AbortedByOutput
+	AbortedByMemoryLimit
 )
 
 // HasAbortReason is a wrapper around an error with an attached abort reason.
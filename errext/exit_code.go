@@ -47,3 +47,12 @@ func (wh withExitCode) ExitCode() exitcodes.ExitCode {
 }
 
 var _ HasExitCode = withExitCode{}
+
+// WithExitCode attaches the exit code to the given error, replacing the one it
+// already had, if any. If there is no error, it won't do anything.
+func WithExitCode(err error, exitCode exitcodes.ExitCode) error {
+	if err == nil {
+		return nil
+	}
+	return withExitCode{err, exitCode}
+}
@@ -53,4 +53,12 @@ const (
 
 	// GoPanic indicates the script was aborted by a panic in the Go runtime.
 	GoPanic ExitCode = 109
+
+	// BaselineRegression indicates that one or more metrics have regressed
+	// from the baseline more than their tolerances.
+	BaselineRegression ExitCode = 110
+
+	// MemoryLimitExceeded indicates the test run was aborted because k6 used
+	// more memory than its maxMemory option allowed.
+	MemoryLimitExceeded ExitCode = 111
 )
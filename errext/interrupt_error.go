@@ -9,6 +9,8 @@ import (
 // InterruptError is an error that halts engine execution
 type InterruptError struct {
 	Reason string
+	// Code is the exit code of the k6 process, ScriptAborted if it's 0
+	Code exitcodes.ExitCode
 }
 
 var _ interface {
@@ -23,6 +25,9 @@ func (i *InterruptError) Error() string {
 
 // ExitCode returns the status code used when the k6 process exits.
 func (i *InterruptError) ExitCode() exitcodes.ExitCode {
+	if i.Code != 0 {
+		return i.Code
+	}
 	return exitcodes.ScriptAborted
 }
 
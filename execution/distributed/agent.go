@@ -0,0 +1,366 @@
+package distributed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/execution/local"
+	"go.k6.io/k6/output"
+)
+
+// AgentController implements the execution.Controller interface for the
+// agents of a distributed test, by relaying all operations to the coordinator.
+type AgentController struct {
+	instanceID uint32
+	stream     CommandAndControlClient
+	logger     logrus.FieldLogger
+
+	sendLock sync.Mutex
+
+	mu          sync.Mutex
+	err         error // set when the stream is broken
+	subscribers map[string][]chan string
+	dataWaiters map[string][]chan *DataPacket
+	callbacks   map[string]func() ([]byte, error)
+	abortErr    error
+	aborted     []chan error
+	takeOvers   chan *TakeOverMessage
+	done        chan struct{}
+}
+
+var _ execution.Controller = &AgentController{}
+
+// NewAgentController opens the command and control stream with the
+// coordinator and returns the controller for the given instance.
+func NewAgentController(
+	ctx context.Context, instanceID uint32, client DistributedTestClient, logger logrus.FieldLogger,
+) (*AgentController, error) {
+	stream, err := client.CommandAndControl(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&AgentMessage{InstanceID: instanceID}); err != nil {
+		return nil, err
+	}
+
+	c := &AgentController{
+		instanceID:  instanceID,
+		stream:      stream,
+		logger:      logger.WithField("component", "agent-controller"),
+		subscribers: make(map[string][]chan string),
+		dataWaiters: make(map[string][]chan *DataPacket),
+		callbacks:   make(map[string]func() ([]byte, error)),
+		takeOvers:   make(chan *TakeOverMessage, takeOversBufferSize),
+		done:        make(chan struct{}),
+	}
+	go c.receive()
+	return c, nil
+}
+
+func (c *AgentController) receive() {
+	defer close(c.done)
+	for {
+		msg, err := c.stream.Recv()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch {
+		case msg.DoneWait != nil:
+			c.mu.Lock()
+			subscribers := c.subscribers[msg.DoneWait.EventID]
+			delete(c.subscribers, msg.DoneWait.EventID)
+			c.mu.Unlock()
+			for _, ch := range subscribers {
+				ch <- msg.DoneWait.Error
+			}
+		case msg.CreateData != nil:
+			go c.createData(msg.CreateData.ID)
+		case msg.Data != nil:
+			c.mu.Lock()
+			waiters := c.dataWaiters[msg.Data.ID]
+			delete(c.dataWaiters, msg.Data.ID)
+			delete(c.callbacks, msg.Data.ID)
+			c.mu.Unlock()
+			for _, ch := range waiters {
+				ch <- msg.Data
+			}
+		case msg.Abort != nil:
+			c.abort(msg.Abort.toError())
+		case msg.TakeOver != nil:
+			select {
+			case c.takeOvers <- msg.TakeOver:
+			default:
+				c.logger.Errorf("Unable to take over the execution segment of instance %d, too many are pending",
+					msg.TakeOver.InstanceID)
+			}
+		}
+	}
+}
+
+// fail unblocks all pending operations once the stream is broken.
+func (c *AgentController) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.err = fmt.Errorf("the connection with the coordinator was lost: %w", err)
+	for eventID, subscribers := range c.subscribers {
+		for _, ch := range subscribers {
+			ch <- c.err.Error()
+		}
+		delete(c.subscribers, eventID)
+	}
+	for id, waiters := range c.dataWaiters {
+		for _, ch := range waiters {
+			ch <- &DataPacket{ID: id, Error: c.err.Error()}
+		}
+		delete(c.dataWaiters, id)
+	}
+}
+
+func (c *AgentController) send(msg *AgentMessage) error {
+	msg.InstanceID = c.instanceID
+	c.sendLock.Lock()
+	defer c.sendLock.Unlock()
+	return c.stream.Send(msg)
+}
+
+func (c *AgentController) createData(id string) {
+	c.mu.Lock()
+	callback := c.callbacks[id]
+	delete(c.callbacks, id)
+	c.mu.Unlock()
+
+	packet := &DataPacket{ID: id}
+	if callback == nil {
+		packet.Error = fmt.Sprintf("instance %d didn't request the data with ID %q", c.instanceID, id)
+	} else if data, err := callback(); err != nil {
+		packet.Error = err.Error()
+	} else {
+		packet.Data = data
+	}
+	if err := c.send(&AgentMessage{CreatedData: packet}); err != nil {
+		c.logger.WithError(err).Errorf("Unable to send the data with ID %q", id)
+	}
+}
+
+// GetOrCreateData requests the data with the given ID from the coordinator,
+// which will ask this instance to create it with the callback, if no other
+// instance has created it before.
+func (c *AgentController) GetOrCreateData(id string, callback func() ([]byte, error)) ([]byte, error) {
+	ch := make(chan *DataPacket, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.dataWaiters[id] = append(c.dataWaiters[id], ch)
+	if _, ok := c.callbacks[id]; !ok {
+		c.callbacks[id] = callback
+	}
+	c.mu.Unlock()
+
+	if err := c.send(&AgentMessage{GetOrCreateData: &DataPacket{ID: id}}); err != nil {
+		return nil, err
+	}
+	packet := <-ch
+	if packet.Error != "" {
+		return nil, errors.New(packet.Error)
+	}
+	return packet.Data, nil
+}
+
+// Subscribe creates a listener for the specified event ID and returns a
+// callback that waits until all instances have reached it, or one of them has
+// had an error.
+func (c *AgentController) Subscribe(eventID string) func() error {
+	ch := make(chan string, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		ch <- c.err.Error()
+	} else {
+		c.subscribers[eventID] = append(c.subscribers[eventID], ch)
+	}
+	c.mu.Unlock()
+
+	return func() error {
+		if errMsg := <-ch; errMsg != "" {
+			return errors.New(errMsg)
+		}
+		return nil
+	}
+}
+
+// Signal notifies the coordinator that this instance has reached the given
+// event ID, or that it has had an error.
+func (c *AgentController) Signal(eventID string, err error) error {
+	msg := &SignalMessage{EventID: eventID}
+	if err != nil {
+		msg.Error = err.Error()
+	}
+	return c.send(&AgentMessage{Signal: msg})
+}
+
+func (c *AgentController) abort(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.abortErr != nil {
+		return // the test was already aborted
+	}
+	c.abortErr = err
+	for _, ch := range c.aborted {
+		ch <- err
+	}
+	c.aborted = nil
+}
+
+// Aborted returns a new channel that receives the error with which the
+// coordinator asked to abort the test run.
+func (c *AgentController) Aborted() <-chan error {
+	ch := make(chan error, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.abortErr != nil {
+		ch <- c.abortErr
+	} else {
+		c.aborted = append(c.aborted, ch)
+	}
+	return ch
+}
+
+// TakeOvers returns a channel that receives the parts of the test of the lost
+// instances that this instance was asked to run.
+func (c *AgentController) TakeOvers() <-chan *TakeOverMessage {
+	return c.takeOvers
+}
+
+// TakeOverController returns the controller for running the part of the test
+// of a lost instance. It gets the data, e.g. the result of setup(), from the
+// coordinator, but it doesn't wait for the other instances, since they have
+// already started the test.
+func (c *AgentController) TakeOverController() execution.Controller {
+	return &takeOverController{Controller: local.NewController(), agent: c}
+}
+
+type takeOverController struct {
+	*local.Controller
+	agent *AgentController
+}
+
+// GetOrCreateData requests the data from the coordinator.
+func (c *takeOverController) GetOrCreateData(id string, callback func() ([]byte, error)) ([]byte, error) {
+	return c.agent.GetOrCreateData(id, callback)
+}
+
+// Close closes the command and control stream, after the test has finished,
+// and waits for the coordinator to acknowledge it.
+func (c *AgentController) Close() error {
+	c.sendLock.Lock()
+	err := c.stream.CloseSend()
+	c.sendLock.Unlock()
+	<-c.done
+	return err
+}
+
+// takeOversBufferSize is how many parts of the test of lost instances can be
+// waiting to be run by an agent.
+const takeOversBufferSize = 16
+
+const (
+	// metricsFlushInterval is how often the agents send their metrics.
+	metricsFlushInterval = time.Second
+	// metricsDumpMaxSamples is the maximum number of samples in a dump, so
+	// the samples of a busy agent are sent in multiple smaller messages.
+	metricsDumpMaxSamples = 10000
+)
+
+// MetricsOutput is an output that sends the metric samples of an agent to the
+// coordinator, so the thresholds and the end-of-test summary are calculated on
+// the samples of all instances.
+type MetricsOutput struct {
+	output.SampleBuffer
+
+	instanceID      uint32
+	client          DistributedTestClient
+	logger          logrus.FieldLogger
+	periodicFlusher *output.PeriodicFlusher
+	maxDumpSamples  int
+}
+
+var _ output.Output = &MetricsOutput{}
+
+// NewMetricsOutput returns a new output for the metrics of the given instance.
+func NewMetricsOutput(instanceID uint32, client DistributedTestClient, logger logrus.FieldLogger) *MetricsOutput {
+	return &MetricsOutput{
+		instanceID:     instanceID,
+		client:         client,
+		logger:         logger.WithField("output", "distributed"),
+		maxDumpSamples: metricsDumpMaxSamples,
+	}
+}
+
+// Description returns a human-readable description of the output.
+func (mo *MetricsOutput) Description() string {
+	return fmt.Sprintf("coordinator (instance %d)", mo.instanceID)
+}
+
+// Start starts sending the buffered samples periodically.
+func (mo *MetricsOutput) Start() error {
+	pf, err := output.NewPeriodicFlusher(metricsFlushInterval, mo.flush)
+	if err != nil {
+		return err
+	}
+	mo.periodicFlusher = pf
+	return nil
+}
+
+// Stop sends the remaining samples and stops the output.
+func (mo *MetricsOutput) Stop() error {
+	mo.periodicFlusher.Stop()
+	return nil
+}
+
+func (mo *MetricsOutput) flush() {
+	containers := mo.GetBufferedSamples()
+	if len(containers) == 0 {
+		return
+	}
+
+	var samples []Sample
+	for _, container := range containers {
+		for _, s := range container.GetSamples() {
+			samples = append(samples, Sample{
+				Metric:   s.Metric.Name,
+				Type:     s.Metric.Type,
+				Contains: s.Metric.Contains,
+				Tags:     s.Tags.Map(),
+				Metadata: s.Metadata,
+				Time:     s.Time,
+				Value:    s.Value,
+			})
+		}
+	}
+
+	for len(samples) > 0 {
+		n := len(samples)
+		if n > mo.maxDumpSamples {
+			n = mo.maxDumpSamples
+		}
+		mo.send(&MetricsDump{InstanceID: mo.instanceID, Samples: samples[:n]})
+		samples = samples[n:]
+	}
+}
+
+func (mo *MetricsOutput) send(dump *MetricsDump) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := mo.client.SendMetrics(ctx, dump); err != nil {
+		mo.logger.WithError(err).Errorf("Unable to send %d samples to the coordinator", len(dump.Samples))
+	}
+}
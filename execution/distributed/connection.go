@@ -0,0 +1,95 @@
+package distributed
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// maxMessageSize is the maximum size of the messages between the coordinator
+// and the agents, instead of the 4MB default of gRPC, since the test archives
+// can be much larger than that.
+const maxMessageSize = 1 << 30
+
+// tokenMetadataKey is the gRPC metadata key with the token of the agents.
+const tokenMetadataKey = "authorization"
+
+// ServerOptions returns the options of the gRPC server of a coordinator. If
+// the TLS config is not nil, the connections are encrypted with it, and if the
+// token is not empty, only the agents that have it are accepted.
+func ServerOptions(tlsConfig *tls.Config, token string) []grpc.ServerOption {
+	opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(maxMessageSize)}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if token != "" {
+		opts = append(opts,
+			grpc.UnaryInterceptor(func(
+				ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+			) (interface{}, error) {
+				if err := checkToken(ctx, token); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(
+				srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+			) error {
+				if err := checkToken(stream.Context(), token); err != nil {
+					return err
+				}
+				return handler(srv, stream)
+			}),
+		)
+	}
+	return opts
+}
+
+// DialOptions returns the options of the gRPC connections of the agents to
+// the coordinator, with TLS if the config is not nil, and with the token if
+// it's not empty.
+func DialOptions(tlsConfig *tls.Config, token string) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxMessageSize), grpc.MaxCallSendMsgSize(maxMessageSize)),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials{token: token, secure: tlsConfig != nil}))
+	}
+	return opts
+}
+
+func checkToken(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(tokenMetadataKey) {
+		if subtle.ConstantTimeCompare([]byte(value), []byte("Bearer "+token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "the token of the agent is missing or invalid")
+}
+
+// tokenCredentials adds the token to the metadata of every call.
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (c tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{tokenMetadataKey: "Bearer " + c.token}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
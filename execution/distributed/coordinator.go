@@ -0,0 +1,580 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	// testStartEventID is the event after which the test run duration is
+	// measured, it's reached by all instances right before setup() is called.
+	testStartEventID = "test-ready-to-run-setup"
+	// testEndEventID is the last event of the test run.
+	testEndEventID = "scheduler-run-done"
+)
+
+// CoordinatorServer coordinates a distributed test. It splits the test in
+// execution segments, one for each agent, provides the agents with the test
+// archive and their options, synchronizes them and collects their metrics.
+//
+// Without an archive, the coordinator only synchronizes the instances and
+// collects their metrics, for segmented tests where every instance was started
+// with its own options by `k6 run --leader`.
+//
+// If rebalancing is enabled, an agent that is lost during the test doesn't fail
+// it. Instead, its execution segments are split between the remaining agents,
+// which run them alongside their own ones until the end of the test.
+type CoordinatorServer struct {
+	instanceCount int
+	archive       []byte
+	options       []lib.Options
+	registry      *metrics.Registry
+	samples       chan<- metrics.SampleContainer
+	logger        logrus.FieldLogger
+	rebalance     bool
+
+	registeredInstances uint32
+	agentsDone          *sync.WaitGroup
+
+	mu       sync.Mutex
+	agents   map[uint32]*agentStream
+	barriers map[string]*barrier
+	data     map[string]*dataEntry
+	// assigned are the options of the execution segments run by each
+	// instance, its own one and the ones it has taken over
+	assigned  map[uint32][]lib.Options
+	lost      map[uint32]bool // the instances that aren't waited for anymore
+	failure   string
+	abortErr  error
+	startTime time.Time
+	endTime   time.Time
+}
+
+type agentStream struct {
+	send chan *ControllerMessage
+}
+
+type barrier struct {
+	reached map[uint32]bool
+	err     string
+	done    bool
+}
+
+type dataEntry struct {
+	packet  *DataPacket
+	creator uint32
+	waiting []uint32
+}
+
+var _ DistributedTestServer = &CoordinatorServer{}
+
+// NewCoordinatorServer initializes a new coordinator for the given number of
+// agents. Each agent will run the archived test with the given options and its
+// own execution segment, unless the archive is nil. The metric samples of all
+// agents are sent to the samples channel, after their metrics are registered
+// in the given registry.
+func NewCoordinatorServer(
+	instanceCount int, archive *lib.Archive, options lib.Options,
+	registry *metrics.Registry, samples chan<- metrics.SampleContainer, logger logrus.FieldLogger,
+) (*CoordinatorServer, error) {
+	if instanceCount < 1 {
+		return nil, fmt.Errorf("the number of instances must be at least 1, %d received", instanceCount)
+	}
+	cs := &CoordinatorServer{
+		instanceCount: instanceCount,
+		registry:      registry,
+		samples:       samples,
+		logger:        logger.WithField("component", "coordinator"),
+		agentsDone:    &sync.WaitGroup{},
+		agents:        make(map[uint32]*agentStream),
+		barriers:      make(map[string]*barrier),
+		data:          make(map[string]*dataEntry),
+		assigned:      make(map[uint32][]lib.Options),
+		lost:          make(map[uint32]bool),
+	}
+	cs.agentsDone.Add(instanceCount)
+	if archive == nil {
+		return cs, nil
+	}
+
+	if options.ExecutionSegment != nil || options.ExecutionSegmentSequence != nil {
+		return nil, errors.New("the execution segments are set by the coordinator, they can't be specified")
+	}
+
+	segments, err := (*lib.ExecutionSegment)(nil).Split(int64(instanceCount))
+	if err != nil {
+		return nil, err
+	}
+	ess, err := lib.NewExecutionSegmentSequence(segments...)
+	if err != nil {
+		return nil, err
+	}
+	instanceOptions := make([]lib.Options, instanceCount)
+	for i, segment := range segments {
+		instanceOptions[i] = options
+		instanceOptions[i].ExecutionSegment = segment
+		instanceOptions[i].ExecutionSegmentSequence = &ess
+	}
+
+	buf := &bytes.Buffer{}
+	if err := archive.Write(buf); err != nil {
+		return nil, fmt.Errorf("unable to archive the test: %w", err)
+	}
+	cs.archive = buf.Bytes()
+	cs.options = instanceOptions
+	return cs, nil
+}
+
+// EnableRebalancing makes the coordinator redistribute the execution segments
+// of the agents that are lost during the test to the remaining ones, instead
+// of failing the test. It has no effect without an archive, since the
+// instances started with `k6 run --leader` can't receive other segments.
+func (cs *CoordinatorServer) EnableRebalancing() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.rebalance = true
+}
+
+// Register assigns an instance ID and an execution segment to a new agent.
+func (cs *CoordinatorServer) Register(_ context.Context, _ *RegisterRequest) (*RegisterResponse, error) {
+	instanceID := atomic.AddUint32(&cs.registeredInstances, 1)
+	if instanceID > uint32(cs.instanceCount) {
+		return nil, status.Errorf(codes.ResourceExhausted,
+			"the test already has all of its %d instances", cs.instanceCount)
+	}
+
+	if cs.archive == nil {
+		cs.logger.Infof("Instance %d of %d connected", instanceID, cs.instanceCount)
+		return &RegisterResponse{InstanceID: instanceID}, nil
+	}
+
+	options := cs.options[instanceID-1]
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	cs.mu.Lock()
+	cs.assigned[instanceID] = []lib.Options{options}
+	cs.mu.Unlock()
+	cs.logger.Infof("Instance %d of %d connected, its execution segment is %s",
+		instanceID, cs.instanceCount, options.ExecutionSegment)
+
+	return &RegisterResponse{
+		InstanceID: instanceID,
+		Archive:    cs.archive,
+		Options:    optionsJSON,
+	}, nil
+}
+
+// CommandAndControl handles the execution.Controller operations of an agent.
+func (cs *CoordinatorServer) CommandAndControl(stream CommandAndControlServer) error {
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	instanceID := msg.InstanceID
+	if instanceID == 0 || instanceID > atomic.LoadUint32(&cs.registeredInstances) ||
+		instanceID > uint32(cs.instanceCount) {
+		return status.Errorf(codes.InvalidArgument, "unknown instance %d", instanceID)
+	}
+
+	agent := &agentStream{send: make(chan *ControllerMessage, 100)}
+	cs.mu.Lock()
+	if _, ok := cs.agents[instanceID]; ok {
+		cs.mu.Unlock()
+		return status.Errorf(codes.AlreadyExists, "instance %d is already connected", instanceID)
+	}
+	cs.agents[instanceID] = agent
+	if cs.abortErr != nil {
+		cs.send(instanceID, &ControllerMessage{Abort: newAbortMessage(cs.abortErr)})
+	}
+	cs.mu.Unlock()
+
+	logger := cs.logger.WithField("instance", instanceID)
+	defer cs.agentsDone.Done()
+
+	sendErr := make(chan error, 1)
+	go func(send <-chan *ControllerMessage) {
+		for msg := range send {
+			if err := stream.Send(msg); err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- nil
+	}(agent.send)
+
+	for err == nil {
+		cs.handleAgentMessage(instanceID, msg)
+		msg, err = stream.Recv()
+	}
+	if errors.Is(err, io.EOF) {
+		logger.Debug("Instance finished")
+		err = nil
+	} else {
+		logger.WithError(err).Error("Instance disconnected")
+	}
+	cs.disconnect(instanceID, err)
+	return <-sendErr
+}
+
+func (cs *CoordinatorServer) handleAgentMessage(instanceID uint32, msg *AgentMessage) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	switch {
+	case msg.Signal != nil:
+		cs.signal(instanceID, msg.Signal)
+	case msg.GetOrCreateData != nil:
+		id := msg.GetOrCreateData.ID
+		entry, ok := cs.data[id]
+		switch {
+		case !ok:
+			cs.data[id] = &dataEntry{creator: instanceID, waiting: []uint32{instanceID}}
+			cs.send(instanceID, &ControllerMessage{CreateData: &DataPacket{ID: id}})
+		case entry.packet != nil:
+			cs.send(instanceID, &ControllerMessage{Data: entry.packet})
+		default:
+			entry.waiting = append(entry.waiting, instanceID)
+		}
+	case msg.CreatedData != nil:
+		entry, ok := cs.data[msg.CreatedData.ID]
+		if !ok || entry.packet != nil {
+			cs.logger.Warnf("Instance %d sent unexpected data with ID %q", instanceID, msg.CreatedData.ID)
+			return
+		}
+		entry.packet = msg.CreatedData
+		for _, id := range entry.waiting {
+			cs.send(id, &ControllerMessage{Data: entry.packet})
+		}
+		entry.waiting = nil
+	}
+}
+
+// signal records that an instance reached an event, or had an error. It
+// notifies all instances once all of them have reached the event or as soon
+// as there's an error. It needs to be called with the lock held.
+func (cs *CoordinatorServer) signal(instanceID uint32, msg *SignalMessage) {
+	b, ok := cs.barriers[msg.EventID]
+	if !ok {
+		b = &barrier{reached: make(map[uint32]bool)}
+		cs.barriers[msg.EventID] = b
+	}
+	if b.done {
+		// the instance connected after the barrier was completed
+		cs.send(instanceID, &ControllerMessage{DoneWait: &SignalMessage{EventID: msg.EventID, Error: b.err}})
+		return
+	}
+	b.reached[instanceID] = true
+	switch {
+	case msg.Error != "":
+		b.err = msg.Error
+		if cs.failure == "" {
+			cs.failure = fmt.Sprintf("instance %d: %s", instanceID, msg.Error)
+		}
+	case cs.failure != "":
+		b.err = cs.failure
+	case !cs.reachedByAll(b):
+		return
+	}
+	cs.completeBarrier(msg.EventID, b)
+}
+
+// reachedByAll returns whether all instances, except the forgotten ones, have
+// reached the barrier. It needs to be called with the lock held.
+func (cs *CoordinatorServer) reachedByAll(b *barrier) bool {
+	for id := uint32(1); id <= uint32(cs.instanceCount); id++ {
+		if !cs.lost[id] && !b.reached[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func (cs *CoordinatorServer) completeBarrier(eventID string, b *barrier) {
+	b.done = true
+	if b.err == "" {
+		switch eventID {
+		case testStartEventID:
+			cs.startTime = time.Now()
+		case testEndEventID:
+			cs.endTime = time.Now()
+		}
+	}
+	for id := range cs.agents {
+		cs.send(id, &ControllerMessage{DoneWait: &SignalMessage{EventID: eventID, Error: b.err}})
+	}
+}
+
+// send queues a message for the given instance. It needs to be called with the
+// lock held.
+func (cs *CoordinatorServer) send(instanceID uint32, msg *ControllerMessage) {
+	if agent, ok := cs.agents[instanceID]; ok && agent.send != nil {
+		agent.send <- msg
+	}
+}
+
+// disconnect removes an instance. If it disconnected before the end of the
+// test, the test can't continue, so all pending and future events fail,
+// unless its execution segments can be redistributed to the other instances.
+func (cs *CoordinatorServer) disconnect(instanceID uint32, err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	close(cs.agents[instanceID].send)
+	cs.agents[instanceID].send = nil
+	if end, ok := cs.barriers[testEndEventID]; ok && end.done {
+		// the remaining events, e.g. the teardown, don't need to wait for it
+		cs.forget(instanceID)
+		return
+	}
+	if err == nil {
+		err = errors.New("the connection was closed before the end of the test")
+	}
+	if cs.canRebalance() {
+		rerr := cs.redistribute(instanceID)
+		if rerr == nil {
+			return
+		}
+		cs.logger.WithError(rerr).Errorf("Unable to redistribute the execution segments of instance %d", instanceID)
+	}
+	if cs.failure == "" {
+		cs.failure = fmt.Sprintf("instance %d disconnected: %s", instanceID, err)
+	}
+	for eventID, b := range cs.barriers {
+		if !b.done && b.err == "" {
+			b.err = cs.failure
+			cs.completeBarrier(eventID, b)
+		}
+	}
+	for id, entry := range cs.data {
+		if entry.packet == nil {
+			entry.packet = &DataPacket{ID: id, Error: cs.failure}
+			for _, waiting := range entry.waiting {
+				cs.send(waiting, &ControllerMessage{Data: entry.packet})
+			}
+			entry.waiting = nil
+		}
+	}
+}
+
+// canRebalance returns whether the segments of a lost instance can be taken
+// over by the others: the test has to be distributed by the coordinator, all
+// instances have to be started and at least one of them has to be connected.
+// It needs to be called with the lock held.
+func (cs *CoordinatorServer) canRebalance() bool {
+	if !cs.rebalance || cs.archive == nil || cs.failure != "" || cs.abortErr != nil {
+		return false
+	}
+	if start, ok := cs.barriers[testStartEventID]; !ok || !start.done || start.err != "" {
+		return false
+	}
+	return len(cs.connectedInstances()) > 0
+}
+
+// connectedInstances returns the sorted IDs of the connected instances. It
+// needs to be called with the lock held.
+func (cs *CoordinatorServer) connectedInstances() []uint32 {
+	var ids []uint32
+	for id := uint32(1); id <= uint32(cs.instanceCount); id++ {
+		if agent, ok := cs.agents[id]; ok && agent.send != nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// redistribute splits every execution segment of a lost instance between the
+// connected instances and asks them to take the parts over. The pending
+// events and data don't wait for the lost instance anymore. It needs to be
+// called with the lock held.
+func (cs *CoordinatorServer) redistribute(lostID uint32) error {
+	survivors := cs.connectedInstances()
+	takeOvers := make(map[uint32][]lib.Options)
+	messages := make(map[uint32][]*ControllerMessage)
+	for _, options := range cs.assigned[lostID] {
+		parts, err := options.ExecutionSegment.Split(int64(len(survivors)))
+		if err != nil {
+			return err
+		}
+		sequence, err := splitSequence(options.ExecutionSegmentSequence, options.ExecutionSegment, parts)
+		if err != nil {
+			return err
+		}
+		for i, part := range parts {
+			partOptions := options
+			partOptions.ExecutionSegment = part
+			partOptions.ExecutionSegmentSequence = &sequence
+			// the teardown is run at the end of the test by the original instances
+			partOptions.NoTeardown = null.BoolFrom(true)
+			optionsJSON, err := json.Marshal(partOptions)
+			if err != nil {
+				return err
+			}
+			id := survivors[i]
+			takeOvers[id] = append(takeOvers[id], partOptions)
+			messages[id] = append(messages[id],
+				&ControllerMessage{TakeOver: &TakeOverMessage{InstanceID: lostID, Options: optionsJSON}})
+		}
+	}
+
+	for _, id := range survivors {
+		for i, options := range takeOvers[id] {
+			cs.logger.Warnf("Instance %d was lost, instance %d takes over its execution segment %s",
+				lostID, id, options.ExecutionSegment)
+			cs.send(id, messages[id][i])
+		}
+		cs.assigned[id] = append(cs.assigned[id], takeOvers[id]...)
+	}
+	delete(cs.assigned, lostID)
+	cs.forget(lostID)
+	return nil
+}
+
+// forget stops waiting for a disconnected instance: the pending events are
+// completed if all other instances have reached them and the pending data
+// that it was creating is created by another instance. It needs to be called
+// with the lock held.
+func (cs *CoordinatorServer) forget(instanceID uint32) {
+	cs.lost[instanceID] = true
+	for eventID, b := range cs.barriers {
+		if !b.done && cs.reachedByAll(b) {
+			cs.completeBarrier(eventID, b)
+		}
+	}
+	for id, entry := range cs.data {
+		if entry.packet != nil {
+			continue
+		}
+		waiting := entry.waiting[:0]
+		for _, waitingID := range entry.waiting {
+			if waitingID != instanceID {
+				waiting = append(waiting, waitingID)
+			}
+		}
+		entry.waiting = waiting
+		switch {
+		case entry.creator != instanceID:
+		case len(waiting) == 0:
+			delete(cs.data, id) // the next instance that requests it will create it
+		default:
+			entry.creator = waiting[0]
+			cs.send(entry.creator, &ControllerMessage{CreateData: &DataPacket{ID: id}})
+		}
+	}
+}
+
+// splitSequence replaces the given segment in the sequence with its parts.
+func splitSequence(
+	sequence *lib.ExecutionSegmentSequence, segment *lib.ExecutionSegment, parts []*lib.ExecutionSegment,
+) (lib.ExecutionSegmentSequence, error) {
+	if sequence == nil {
+		return lib.NewExecutionSegmentSequence(parts...)
+	}
+	segments := make([]*lib.ExecutionSegment, 0, len(*sequence)+len(parts)-1)
+	found := false
+	for _, s := range *sequence {
+		if s.Equal(segment) {
+			segments = append(segments, parts...)
+			found = true
+		} else {
+			segments = append(segments, s)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("the execution segment %s isn't in the sequence %s", segment, sequence)
+	}
+	return lib.NewExecutionSegmentSequence(segments...)
+}
+
+// SendMetrics registers the metrics of the received samples, if needed, and
+// sends the samples to the coordinator's samples channel.
+func (cs *CoordinatorServer) SendMetrics(_ context.Context, dump *MetricsDump) (*MetricsDumpResponse, error) {
+	samples := make(metrics.Samples, 0, len(dump.Samples))
+	for _, s := range dump.Samples {
+		metric, err := cs.registry.NewMetric(s.Metric, s.Type, s.Contains)
+		if err != nil {
+			cs.logger.WithError(err).Warnf("Instance %d sent an invalid sample", dump.InstanceID)
+			continue
+		}
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: metric,
+				Tags:   cs.registry.RootTagSet().WithTagsFromMap(s.Tags),
+			},
+			Time:     s.Time,
+			Metadata: s.Metadata,
+			Value:    s.Value,
+		})
+	}
+	if len(samples) > 0 {
+		cs.samples <- samples
+	}
+	return &MetricsDumpResponse{}, nil
+}
+
+// GetCurrentTestRunDuration returns how long the test has been running, or
+// how long it ran, if it has already finished.
+func (cs *CoordinatorServer) GetCurrentTestRunDuration() time.Duration {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	switch {
+	case cs.startTime.IsZero():
+		return 0
+	case cs.endTime.IsZero():
+		return time.Since(cs.startTime)
+	default:
+		return cs.endTime.Sub(cs.startTime)
+	}
+}
+
+// Abort asks all instances to abort the test run with the given error, e.g.
+// because a threshold with abortOnFail was crossed on the combined metrics.
+func (cs *CoordinatorServer) Abort(err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.abortErr != nil {
+		cs.logger.Debugf("The test was already aborted, ignoring the new reason '%s'", err)
+		return
+	}
+	cs.logger.WithError(err).Warn("Aborting the test on all instances")
+	cs.abortErr = err
+	msg := &ControllerMessage{Abort: newAbortMessage(err)}
+	for id := range cs.agents {
+		cs.send(id, msg)
+	}
+}
+
+// Wait blocks until all agents have connected and finished. It returns the
+// abort error, if the test was aborted, the first error of the agents, or an
+// error if any of them disconnected before the end of the test.
+func (cs *CoordinatorServer) Wait() error {
+	cs.agentsDone.Wait()
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.abortErr != nil {
+		return cs.abortErr
+	}
+	if cs.failure != "" {
+		return errors.New(cs.failure)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package distributed implements the execution.Controller interface for
+// distributed (multi-instance) k6 execution, where a coordinator splits the
+// test in execution segments and its agents run them.
+//
+// The coordinator and the agents communicate over gRPC. The messages are plain
+// Go structs encoded as JSON, so no protobuf code generation is needed and the
+// wire format can be easily inspected and extended.
+package distributed
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/encoding"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/metrics"
+)
+
+// codecName is the gRPC content-subtype of the messages.
+const codecName = "k6-distributed-json"
+
+//nolint:gochecknoinits // this is how gRPC codecs are registered
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return codecName }
+
+// RegisterRequest is sent by the agents to join a test.
+type RegisterRequest struct{}
+
+// RegisterResponse contains everything an agent needs to run its part of the
+// test: the test archive and the options with its execution segment.
+type RegisterResponse struct {
+	InstanceID uint32 `json:"instanceID"`
+	Archive    []byte `json:"archive"`
+	Options    []byte `json:"options"`
+}
+
+// AgentMessage is sent by the agents over the command and control stream.
+// Exactly one of its fields, besides InstanceID, is set.
+type AgentMessage struct {
+	InstanceID uint32 `json:"instanceID"`
+
+	// Signal notifies that the agent has reached an event, or had an error.
+	Signal *SignalMessage `json:"signal,omitempty"`
+	// GetOrCreateData requests the data with the given ID.
+	GetOrCreateData *DataPacket `json:"getOrCreateData,omitempty"`
+	// CreatedData is the data the agent was asked to create.
+	CreatedData *DataPacket `json:"createdData,omitempty"`
+}
+
+// ControllerMessage is sent by the coordinator over the command and control
+// stream. Exactly one of its fields is set.
+type ControllerMessage struct {
+	// DoneWait notifies that all instances reached the event, or that one of
+	// them had an error.
+	DoneWait *SignalMessage `json:"doneWait,omitempty"`
+	// CreateData asks the agent to create the data with the given ID.
+	CreateData *DataPacket `json:"createData,omitempty"`
+	// Data is the requested data, created by one of the agents.
+	Data *DataPacket `json:"data,omitempty"`
+	// Abort asks the agent to abort the test run.
+	Abort *AbortMessage `json:"abort,omitempty"`
+	// TakeOver asks the agent to also run part of the test of a lost agent.
+	TakeOver *TakeOverMessage `json:"takeOver,omitempty"`
+}
+
+// TakeOverMessage contains the options, with the execution segment, of the
+// part of the test of a lost instance that an agent has to run.
+type TakeOverMessage struct {
+	InstanceID uint32 `json:"instanceID"`
+	Options    []byte `json:"options"`
+}
+
+// AbortMessage contains the reason why the test was aborted, with its exit
+// code and abort reason, so all instances exit in the same way.
+type AbortMessage struct {
+	Error       string             `json:"error"`
+	ExitCode    exitcodes.ExitCode `json:"exitCode,omitempty"`
+	AbortReason errext.AbortReason `json:"abortReason,omitempty"`
+}
+
+func newAbortMessage(err error) *AbortMessage {
+	msg := &AbortMessage{Error: err.Error()}
+	var ecerr errext.HasExitCode
+	if errors.As(err, &ecerr) {
+		msg.ExitCode = ecerr.ExitCode()
+	}
+	var arerr errext.HasAbortReason
+	if errors.As(err, &arerr) {
+		msg.AbortReason = arerr.AbortReason()
+	}
+	return msg
+}
+
+func (msg *AbortMessage) toError() error {
+	err := errors.New(msg.Error)
+	if msg.ExitCode != 0 {
+		err = errext.WithExitCodeIfNone(err, msg.ExitCode)
+	}
+	if msg.AbortReason != 0 {
+		err = errext.WithAbortReasonIfNone(err, msg.AbortReason)
+	}
+	return err
+}
+
+// SignalMessage is an event that was reached, with an optional error.
+type SignalMessage struct {
+	EventID string `json:"eventID"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DataPacket is a chunk of data with its ID, or the error that prevented its
+// creation.
+type DataPacket struct {
+	ID    string `json:"id"`
+	Data  []byte `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// MetricsDump is a batch of metric samples sent by an agent.
+type MetricsDump struct {
+	InstanceID uint32   `json:"instanceID"`
+	Samples    []Sample `json:"samples"`
+}
+
+// MetricsDumpResponse is the response to a MetricsDump.
+type MetricsDumpResponse struct{}
+
+// Sample is a single metric sample, with enough information about its metric
+// for the coordinator to register it, if it doesn't know about it yet.
+type Sample struct {
+	Metric   string             `json:"metric"`
+	Type     metrics.MetricType `json:"type"`
+	Contains metrics.ValueType  `json:"contains"`
+	Tags     map[string]string  `json:"tags,omitempty"`
+	Metadata map[string]string  `json:"metadata,omitempty"`
+	Time     time.Time          `json:"time"`
+	Value    float64            `json:"value"`
+}
@@ -0,0 +1,359 @@
+package distributed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/execution"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestArchive(t *testing.T) *lib.Archive {
+	t.Helper()
+	script := []byte(`export default function () {}`)
+	fs := fsext.NewMemMapFs()
+	require.NoError(t, fsext.WriteFile(fs, "/path/to/script.js", script, 0o644))
+	return &lib.Archive{
+		Type:        "js",
+		FilenameURL: &url.URL{Scheme: "file", Path: "/path/to/script.js"},
+		Data:        script,
+		PwdURL:      &url.URL{Scheme: "file", Path: "/path/to"},
+		Filesystems: map[string]fsext.Fs{"file": fs},
+	}
+}
+
+func newTestCoordinator(
+	t *testing.T, instanceCount int, archive *lib.Archive, samples chan metrics.SampleContainer,
+) (*CoordinatorServer, DistributedTestClient) {
+	t.Helper()
+	return newTestCoordinatorWithToken(t, instanceCount, archive, samples, "", "")
+}
+
+// newTestCoordinatorWithToken returns a coordinator that requires the server
+// token, if any, and a client that sends the client token.
+func newTestCoordinatorWithToken(
+	t *testing.T, instanceCount int, archive *lib.Archive, samples chan metrics.SampleContainer,
+	serverToken, clientToken string,
+) (*CoordinatorServer, DistributedTestClient) {
+	t.Helper()
+
+	coordinator, err := NewCoordinatorServer(
+		instanceCount, archive, lib.Options{},
+		metrics.NewRegistry(), samples, testutils.NewLogger(t),
+	)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(ServerOptions(nil, serverToken)...)
+	RegisterDistributedTestServer(server, coordinator)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet", append(DialOptions(nil, clientToken),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+	)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return coordinator, NewDistributedTestClient(conn)
+}
+
+func TestCoordinatorRegister(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestCoordinator(t, 2, newTestArchive(t), nil)
+
+	var segments []string
+	for i := uint32(1); i <= 2; i++ {
+		resp, err := client.Register(context.Background(), &RegisterRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, i, resp.InstanceID)
+		assert.NotEmpty(t, resp.Archive)
+
+		var options lib.Options
+		require.NoError(t, json.Unmarshal(resp.Options, &options))
+		segments = append(segments, options.ExecutionSegment.String())
+		assert.Equal(t, "0,1/2,1", options.ExecutionSegmentSequence.String())
+	}
+	assert.Equal(t, []string{"0:1/2", "1/2:1"}, segments)
+
+	_, err := client.Register(context.Background(), &RegisterRequest{})
+	require.Error(t, err)
+}
+
+func TestCoordinatorRegisterLargeArchive(t *testing.T) {
+	t.Parallel()
+
+	archive := newTestArchive(t)
+	data := make([]byte, 8<<20) // more than the 4MB default limit of gRPC
+	require.NoError(t, fsext.WriteFile(archive.Filesystems["file"], "/path/to/data.bin", data, 0o644))
+	_, client := newTestCoordinator(t, 1, archive, nil)
+
+	resp, err := client.Register(context.Background(), &RegisterRequest{})
+	require.NoError(t, err)
+	assert.Greater(t, len(resp.Archive), len(data))
+}
+
+func TestCoordinatorToken(t *testing.T) {
+	t.Parallel()
+
+	for _, token := range []string{"", "wrong"} {
+		_, client := newTestCoordinatorWithToken(t, 1, newTestArchive(t), nil, "secret", token)
+		_, err := client.Register(context.Background(), &RegisterRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), token)
+
+		stream, err := client.CommandAndControl(context.Background())
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), token)
+	}
+
+	_, client := newTestCoordinatorWithToken(t, 1, newTestArchive(t), nil, "secret", "secret")
+	resp, err := client.Register(context.Background(), &RegisterRequest{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Archive)
+}
+
+func TestCoordinatorRegisterAggregateOnly(t *testing.T) {
+	t.Parallel()
+
+	_, client := newTestCoordinator(t, 2, nil, nil)
+	for i := uint32(1); i <= 2; i++ {
+		resp, err := client.Register(context.Background(), &RegisterRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, i, resp.InstanceID)
+		assert.Nil(t, resp.Archive)
+		assert.Nil(t, resp.Options)
+	}
+}
+
+func TestNewCoordinatorServerWithSegment(t *testing.T) {
+	t.Parallel()
+
+	segment, err := lib.NewExecutionSegmentFromString("0:1/2")
+	require.NoError(t, err)
+	_, err = NewCoordinatorServer(
+		2, newTestArchive(t), lib.Options{ExecutionSegment: segment}, metrics.NewRegistry(), nil, testutils.NewLogger(t),
+	)
+	require.Error(t, err)
+}
+
+func TestAgentControllers(t *testing.T) {
+	t.Parallel()
+
+	const instanceCount = 3
+	coordinator, client := newTestCoordinator(t, instanceCount, newTestArchive(t), nil)
+
+	var created int64
+	var wg sync.WaitGroup
+	results := make([][]byte, instanceCount)
+	for i := 0; i < instanceCount; i++ {
+		resp, err := client.Register(context.Background(), &RegisterRequest{})
+		require.NoError(t, err)
+		controller, err := NewAgentController(context.Background(), resp.InstanceID, client, testutils.NewLogger(t))
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func(i int, c *AgentController) {
+			defer wg.Done()
+			assert.NoError(t, execution.SignalAndWait(c, testStartEventID))
+			data, err := c.GetOrCreateData("setup", func() ([]byte, error) {
+				atomic.AddInt64(&created, 1)
+				time.Sleep(10 * time.Millisecond)
+				return []byte("data"), nil
+			})
+			assert.NoError(t, err)
+			results[i] = data
+			assert.NoError(t, execution.SignalAndWait(c, testEndEventID))
+			assert.NoError(t, c.Close())
+		}(i, controller)
+	}
+	wg.Wait()
+
+	require.NoError(t, coordinator.Wait())
+	assert.Equal(t, int64(1), created)
+	for _, data := range results {
+		assert.Equal(t, []byte("data"), data)
+	}
+	assert.Greater(t, coordinator.GetCurrentTestRunDuration(), time.Duration(0))
+}
+
+func TestAgentControllersError(t *testing.T) {
+	t.Parallel()
+
+	coordinator, client := newTestCoordinator(t, 2, newTestArchive(t), nil)
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		resp, err := client.Register(context.Background(), &RegisterRequest{})
+		require.NoError(t, err)
+		controller, err := NewAgentController(context.Background(), resp.InstanceID, client, testutils.NewLogger(t))
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func(i int, c *AgentController) {
+			defer wg.Done()
+			var initErr error
+			if i == 0 {
+				initErr = errors.New("init failed")
+			}
+			errs[i] = execution.SignalErrorOrWait(c, "init-done", initErr)
+			assert.NoError(t, c.Close())
+		}(i, controller)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.ErrorContains(t, err, "init failed")
+	}
+	require.ErrorContains(t, coordinator.Wait(), "init failed")
+}
+
+func TestCoordinatorRebalance(t *testing.T) {
+	t.Parallel()
+
+	const instanceCount = 3
+	coordinator, client := newTestCoordinator(t, instanceCount, newTestArchive(t), nil)
+	coordinator.EnableRebalancing()
+
+	controllers := make([]*AgentController, instanceCount)
+	cancels := make([]context.CancelFunc, instanceCount)
+	for i := range controllers {
+		resp, err := client.Register(context.Background(), &RegisterRequest{})
+		require.NoError(t, err)
+		var ctx context.Context
+		ctx, cancels[i] = context.WithCancel(context.Background())
+		defer cancels[i]()
+		controllers[i], err = NewAgentController(ctx, resp.InstanceID, client, testutils.NewLogger(t))
+		require.NoError(t, err)
+	}
+	signalAll := func(eventID string, controllers []*AgentController) {
+		var wg sync.WaitGroup
+		for _, c := range controllers {
+			wg.Add(1)
+			go func(c *AgentController) {
+				defer wg.Done()
+				assert.NoError(t, execution.SignalAndWait(c, eventID))
+			}(c)
+		}
+		wg.Wait()
+	}
+	signalAll(testStartEventID, controllers)
+
+	// the last instance is lost in the middle of the test
+	cancels[instanceCount-1]()
+	var segments []string
+	for _, c := range controllers[:instanceCount-1] {
+		select {
+		case msg := <-c.TakeOvers():
+			assert.Equal(t, uint32(instanceCount), msg.InstanceID)
+			var options lib.Options
+			require.NoError(t, json.Unmarshal(msg.Options, &options))
+			segments = append(segments, options.ExecutionSegment.String())
+			assert.Equal(t, "0,1/3,2/3,5/6,1", options.ExecutionSegmentSequence.String())
+			assert.True(t, options.NoTeardown.Bool)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the take over wasn't received")
+		}
+	}
+	assert.Equal(t, []string{"2/3:5/6", "5/6:1"}, segments)
+
+	signalAll(testEndEventID, controllers[:instanceCount-1])
+	for _, c := range controllers[:instanceCount-1] {
+		assert.NoError(t, c.Close())
+	}
+	require.NoError(t, coordinator.Wait())
+}
+
+func TestCoordinatorAbort(t *testing.T) {
+	t.Parallel()
+
+	coordinator, client := newTestCoordinator(t, 2, newTestArchive(t), nil)
+
+	abortErr := errext.WithAbortReasonIfNone(
+		errext.WithExitCodeIfNone(errors.New("thresholds crossed"), exitcodes.ThresholdsHaveFailed),
+		errext.AbortedByThreshold,
+	)
+	controllers := make([]*AgentController, 2)
+	for i := range controllers {
+		resp, err := client.Register(context.Background(), &RegisterRequest{})
+		require.NoError(t, err)
+		controllers[i], err = NewAgentController(context.Background(), resp.InstanceID, client, testutils.NewLogger(t))
+		require.NoError(t, err)
+		if i == 0 {
+			// the second instance connects after the abort
+			coordinator.Abort(abortErr)
+		}
+	}
+
+	for _, c := range controllers {
+		select {
+		case err := <-c.Aborted():
+			require.ErrorContains(t, err, "thresholds crossed")
+			var ecerr errext.HasExitCode
+			require.ErrorAs(t, err, &ecerr)
+			assert.Equal(t, exitcodes.ThresholdsHaveFailed, ecerr.ExitCode())
+			var arerr errext.HasAbortReason
+			require.ErrorAs(t, err, &arerr)
+			assert.Equal(t, errext.AbortedByThreshold, arerr.AbortReason())
+		case <-time.After(5 * time.Second):
+			t.Fatal("the abort wasn't received")
+		}
+		assert.NoError(t, c.Close())
+	}
+	require.ErrorIs(t, coordinator.Wait(), abortErr)
+}
+
+func TestMetricsOutput(t *testing.T) {
+	t.Parallel()
+
+	samples := make(chan metrics.SampleContainer, 10)
+	_, client := newTestCoordinator(t, 1, nil, samples)
+
+	registry := metrics.NewRegistry()
+	metric := registry.MustNewMetric("my_trend", metrics.Trend, metrics.Time)
+	out := NewMetricsOutput(1, client, testutils.NewLogger(t))
+	out.maxDumpSamples = 2
+	require.NoError(t, out.Start())
+	now := time.Now()
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet().With("key", "value")},
+		Time:       now,
+		Value:      42,
+	}
+	out.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{sample, sample, sample}})
+	require.NoError(t, out.Stop())
+
+	// the samples are sent in dumps of at most maxDumpSamples
+	received := metrics.GetBufferedSamples(samples)
+	require.Len(t, received, 2)
+	assert.Len(t, received[0].GetSamples(), 2)
+	assert.Len(t, received[1].GetSamples(), 1)
+	sample = received[0].GetSamples()[0]
+	assert.Equal(t, "my_trend", sample.Metric.Name)
+	assert.Equal(t, metrics.Trend, sample.Metric.Type)
+	assert.Equal(t, metrics.Time, sample.Metric.Contains)
+	assert.Equal(t, map[string]string{"key": "value"}, sample.Tags.Map())
+	assert.Equal(t, float64(42), sample.Value)
+	assert.True(t, now.Equal(sample.Time))
+}
@@ -0,0 +1,173 @@
+package distributed
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+const serviceName = "k6.distributed.DistributedTest"
+
+// DistributedTestServer is the API of the coordinator, used by the agents.
+type DistributedTestServer interface {
+	// Register adds a new agent to the test.
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	// CommandAndControl is used for the execution.Controller operations.
+	CommandAndControl(CommandAndControlServer) error
+	// SendMetrics sends a batch of metric samples to the coordinator.
+	SendMetrics(context.Context, *MetricsDump) (*MetricsDumpResponse, error)
+}
+
+// CommandAndControlServer is the server side of the command and control stream.
+type CommandAndControlServer interface {
+	Send(*ControllerMessage) error
+	Recv() (*AgentMessage, error)
+	grpc.ServerStream
+}
+
+// CommandAndControlClient is the client side of the command and control stream.
+type CommandAndControlClient interface {
+	Send(*AgentMessage) error
+	Recv() (*ControllerMessage, error)
+	grpc.ClientStream
+}
+
+// DistributedTestClient is the client of the DistributedTestServer API.
+type DistributedTestClient interface {
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	CommandAndControl(ctx context.Context, opts ...grpc.CallOption) (CommandAndControlClient, error)
+	SendMetrics(ctx context.Context, in *MetricsDump, opts ...grpc.CallOption) (*MetricsDumpResponse, error)
+}
+
+// RegisterDistributedTestServer registers the coordinator with the gRPC server.
+func RegisterDistributedTestServer(s grpc.ServiceRegistrar, srv DistributedTestServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+// NewDistributedTestClient returns a new client for the coordinator API.
+func NewDistributedTestClient(cc grpc.ClientConnInterface) DistributedTestClient {
+	return &client{cc: cc}
+}
+
+//nolint:gochecknoglobals
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*DistributedTestServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Register", Handler: registerHandler},
+		{MethodName: "SendMetrics", Handler: sendMetricsHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CommandAndControl",
+			Handler:       commandAndControlHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+}
+
+func registerHandler(
+	srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handle := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DistributedTestServer).Register(ctx, req.(*RegisterRequest)) //nolint:forcetypeassert
+	}
+	if interceptor == nil {
+		return handle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Register"}
+	return interceptor(ctx, in, info, handle)
+}
+
+func sendMetricsHandler(
+	srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(MetricsDump)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handle := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DistributedTestServer).SendMetrics(ctx, req.(*MetricsDump)) //nolint:forcetypeassert
+	}
+	if interceptor == nil {
+		return handle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/SendMetrics"}
+	return interceptor(ctx, in, info, handle)
+}
+
+func commandAndControlHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DistributedTestServer).CommandAndControl(&commandAndControlServer{stream}) //nolint:forcetypeassert
+}
+
+type commandAndControlServer struct {
+	grpc.ServerStream
+}
+
+func (s *commandAndControlServer) Send(m *ControllerMessage) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *commandAndControlServer) Recv() (*AgentMessage, error) {
+	m := new(AgentMessage)
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type client struct {
+	cc grpc.ClientConnInterface
+}
+
+func (c *client) Register(
+	ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption,
+) (*RegisterResponse, error) {
+	out := new(RegisterResponse)
+	opts = append(opts, grpc.CallContentSubtype(codecName))
+	if err := c.cc.Invoke(ctx, "/"+serviceName+"/Register", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *client) SendMetrics(
+	ctx context.Context, in *MetricsDump, opts ...grpc.CallOption,
+) (*MetricsDumpResponse, error) {
+	out := new(MetricsDumpResponse)
+	opts = append(opts, grpc.CallContentSubtype(codecName))
+	if err := c.cc.Invoke(ctx, "/"+serviceName+"/SendMetrics", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *client) CommandAndControl(ctx context.Context, opts ...grpc.CallOption) (CommandAndControlClient, error) {
+	opts = append(opts, grpc.CallContentSubtype(codecName))
+	stream, err := c.cc.NewStream(ctx, &serviceDesc.Streams[0], "/"+serviceName+"/CommandAndControl", opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &commandAndControlClient{stream}, nil
+}
+
+type commandAndControlClient struct {
+	grpc.ClientStream
+}
+
+func (c *commandAndControlClient) Send(m *AgentMessage) error {
+	return c.ClientStream.SendMsg(m)
+}
+
+func (c *commandAndControlClient) Recv() (*ControllerMessage, error) {
+	m := new(ControllerMessage)
+	if err := c.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,81 @@
+package execution
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	rtmetrics "runtime/metrics"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+)
+
+// memoryCheckInterval is how often the memory usage is checked against the
+// maxMemory limit, it has to be short, since it can grow quickly.
+const memoryCheckInterval = 100 * time.Millisecond
+
+// processMemory returns the resident set size of the k6 process, or the
+// memory mapped by the Go runtime and not released to the OS where it isn't
+// available.
+func processMemory(samples []rtmetrics.Sample) int64 {
+	if statm, err := os.ReadFile("/proc/self/statm"); err == nil {
+		// the second field is the resident set size, in pages
+		if fields := bytes.Fields(statm); len(fields) > 1 {
+			if pages, err := strconv.ParseInt(string(fields[1]), 10, 64); err == nil {
+				return pages * int64(os.Getpagesize())
+			}
+		}
+	}
+
+	rtmetrics.Read(samples)
+	return int64(samples[0].Value.Uint64() - samples[1].Value.Uint64())
+}
+
+// watchMemory checks the memory usage of k6 against its MemoryBudget, and
+// aborts the test run gracefully once it's over it, before the OOM killer
+// kills k6 and all of the results are lost.
+func (e *Scheduler) watchMemory(ctx, runCtx context.Context) func() {
+	budget := e.state.MemoryBudget
+	if budget == nil {
+		return func() {}
+	}
+
+	samples := []rtmetrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	ticker := time.NewTicker(memoryCheckInterval)
+	go func() {
+		defer func() {
+			ticker.Stop()
+			wg.Done()
+		}()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := budget.Observe(processMemory(samples))
+				if err == nil {
+					continue
+				}
+				e.state.Test.Logger.WithError(err).Error("Aborting the test run to not run out of memory")
+				AbortTestRun(runCtx, errext.WithAbortReasonIfNone(
+					errext.WithExitCodeIfNone(
+						fmt.Errorf("test run was aborted because %w", err), exitcodes.MemoryLimitExceeded,
+					), errext.AbortedByMemoryLimit,
+				))
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return wg.Wait
+}
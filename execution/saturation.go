@@ -0,0 +1,52 @@
+package execution
+
+import (
+	rtmetrics "runtime/metrics"
+)
+
+// cpuUsage measures the fraction of the time in which the CPU available to the
+// k6 process was busy, between its calls. It's estimated by the Go runtime,
+// which only updates it at the end of each garbage collection, so it can't be
+// measured between the calls in which no collection has finished, but a
+// saturated k6 allocates, and so collects, often enough.
+type cpuUsage struct {
+	samples         []rtmetrics.Sample
+	idle, total     float64
+	hasMeasurements bool
+}
+
+func newCPUUsage() *cpuUsage {
+	cu := &cpuUsage{
+		samples: []rtmetrics.Sample{
+			{Name: "/cpu/classes/idle:cpu-seconds"},
+			{Name: "/cpu/classes/total:cpu-seconds"},
+		},
+	}
+	cu.read()
+	return cu
+}
+
+// read returns the idle and the total CPU seconds so far, and false if they
+// aren't supported by the runtime.
+func (cu *cpuUsage) read() (idle, total float64, ok bool) {
+	rtmetrics.Read(cu.samples)
+	for _, s := range cu.samples {
+		if s.Value.Kind() != rtmetrics.KindFloat64 {
+			return 0, 0, false
+		}
+	}
+	idle, total = cu.samples[0].Value.Float64(), cu.samples[1].Value.Float64()
+	cu.idle, cu.total, cu.hasMeasurements = idle, total, true
+	return idle, total, true
+}
+
+// usage returns the CPU usage since the previous call, and false if it can't
+// be measured.
+func (cu *cpuUsage) usage() (float64, bool) {
+	prevIdle, prevTotal, hadMeasurements := cu.idle, cu.total, cu.hasMeasurements
+	idle, total, ok := cu.read()
+	if !ok || !hadMeasurements || total <= prevTotal {
+		return 0, false
+	}
+	return 1 - (idle-prevIdle)/(total-prevTotal), true
+}
@@ -2,8 +2,10 @@ package execution
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -23,12 +25,42 @@ type Scheduler struct {
 	controller Controller
 
 	initProgress    *pb.ProgressBar
-	executorConfigs []lib.ExecutorConfig // sorted by (startTime, ID)
+	executorsMx     sync.RWMutex         // the executors can be added by StartScenario()
+	executorConfigs []lib.ExecutorConfig // sorted by (startTime, ID), then the started ones
 	executors       []lib.Executor       // sorted by (startTime, ID), excludes executors with no work
 	executionPlan   []lib.ExecutionStep
-	maxDuration     time.Duration // cached value derived from the execution plan
-	maxPossibleVUs  uint64        // cached value derived from the execution plan
+	startTimes      map[string]time.Duration // the latest ones of the scenarios, with their startAfter
+	finished        map[string]chan struct{} // closed when the scenarios finish, for the ones after them
+	maxDuration     time.Duration            // cached value derived from the execution plan
+	maxPossibleVUs  uint64                   // cached value derived from the execution plan
 	state           *lib.ExecutionState
+
+	// the channels of the samples of the VUs, by their IDs, if they don't
+	// send them to the one of Init() and Run()
+	vuSamplesOut []chan<- metrics.SampleContainer
+
+	runningMx sync.Mutex
+	running   *runningExecutors // set by Run(), once the executors are started
+}
+
+// runningExecutors is what StartScenario() needs to start a new executor
+// alongside the ones started by Run(), and to have Run() wait for it too.
+type runningExecutors struct {
+	ctx        context.Context
+	samplesOut chan<- metrics.SampleContainer
+	results    chan error
+	pending    int                           // the number of executors that Run() is still waiting for
+	done       bool                          // all executors have finished, no new ones can be started
+	cancels    map[string]context.CancelFunc // of the contexts of the executors, by name
+	starting   map[string]struct{}           // the scenarios whose VUs StartScenario() is initializing
+}
+
+// start starts the executor, with its own context, so it can be stopped
+// alone by StopScenario(). It has to be called with the runningMx held.
+func (r *runningExecutors) start(e *Scheduler, exec lib.Executor) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	r.cancels[exec.GetConfig().GetName()] = cancel
+	go e.runExecutor(ctx, r.results, r.samplesOut, exec)
 }
 
 // NewScheduler creates and returns a new Scheduler instance, without
@@ -50,9 +82,12 @@ func NewScheduler(trs *lib.TestRunState, controller Controller) (*Scheduler, err
 
 	executorConfigs := options.Scenarios.GetSortedConfigs()
 	executors := make([]lib.Executor, 0, len(executorConfigs))
+	finished := make(map[string]chan struct{}, len(executorConfigs))
 	// Only take executors which have work.
 	for _, sc := range executorConfigs {
+		finished[sc.GetName()] = make(chan struct{})
 		if !sc.HasWork(et) {
+			close(finished[sc.GetName()]) // the scenarios after it don't wait for it
 			trs.Logger.Warnf(
 				"Executor '%s' is disabled for segment %s due to lack of work!",
 				sc.GetName(), options.ExecutionSegment,
@@ -80,6 +115,8 @@ func NewScheduler(trs *lib.TestRunState, controller Controller) (*Scheduler, err
 		executors:       executors,
 		executorConfigs: executorConfigs,
 		executionPlan:   executionPlan,
+		startTimes:      options.Scenarios.GetStartTimes(),
+		finished:        finished,
 		maxDuration:     maxDuration,
 		maxPossibleVUs:  maxPossibleVUs,
 		state:           executionState,
@@ -99,12 +136,16 @@ func (e *Scheduler) GetState() *lib.ExecutionState {
 // GetExecutors returns the slice of configured executor instances which
 // have work, sorted by their (startTime, name) in an ascending order.
 func (e *Scheduler) GetExecutors() []lib.Executor {
+	e.executorsMx.RLock()
+	defer e.executorsMx.RUnlock()
 	return e.executors
 }
 
 // GetExecutorConfigs returns the slice of all executor configs, sorted by
 // their (startTime, name) in an ascending order.
 func (e *Scheduler) GetExecutorConfigs() []lib.ExecutorConfig {
+	e.executorsMx.RLock()
+	defer e.executorsMx.RUnlock()
 	return e.executorConfigs
 }
 
@@ -121,6 +162,13 @@ func (e *Scheduler) GetExecutionPlan() []lib.ExecutionStep {
 	return e.executionPlan
 }
 
+// SetVUSamples makes the VUs send their samples to the given channels, by
+// their IDs, instead of to the samples channel of Init() and Run(), so they
+// don't all contend for the same one. It has to be called before Init().
+func (e *Scheduler) SetVUSamples(samplesOut []chan<- metrics.SampleContainer) {
+	e.vuSamplesOut = samplesOut
+}
+
 // initVU is a helper method that's used to both initialize the planned VUs
 // in the Init() method, and also passed to executors so they can initialize
 // any unplanned VUs themselves.
@@ -130,6 +178,9 @@ func (e *Scheduler) initVU(
 	// Get the VU IDs here, so that the VUs are (mostly) ordered by their
 	// number in the channel buffer
 	vuIDLocal, vuIDGlobal := e.state.GetUniqueVUIdentifiers()
+	if len(e.vuSamplesOut) > 0 {
+		samplesOut = e.vuSamplesOut[vuIDLocal%uint64(len(e.vuSamplesOut))]
+	}
 	vu, err := e.state.Test.Runner.NewVU(ctx, vuIDLocal, vuIDGlobal, samplesOut)
 	if err != nil {
 		return nil, errext.WithHint(err, fmt.Sprintf("error while initializing VU #%d", vuIDGlobal))
@@ -202,6 +253,15 @@ func (e *Scheduler) emitVUsAndVUsMax(ctx context.Context, out chan<- metrics.Sam
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 
+	var self *selfMetrics
+	if e.state.Test.Options.SelfMetrics.Bool {
+		self = newSelfMetrics(e.state.Test.BuiltinMetrics, tags)
+	}
+	var cpu *cpuUsage
+	if e.state.Saturation != nil {
+		cpu = newCPUUsage()
+	}
+
 	emitMetrics := func() {
 		t := time.Now()
 		samples := metrics.ConnectedSamples{
@@ -225,6 +285,15 @@ func (e *Scheduler) emitVUsAndVUsMax(ctx context.Context, out chan<- metrics.Sam
 			Tags: tags,
 			Time: t,
 		}
+		if self != nil {
+			samples.Samples = append(samples.Samples, self.samples(t, out)...)
+		}
+		if cpu != nil {
+			if usage, ok := cpu.usage(); ok {
+				e.state.Saturation.ObserveCPU(usage)
+			}
+			e.state.Saturation.ObserveSamplesBacklog(len(out), cap(out))
+		}
 		metrics.PushIfNotDone(ctx, out, samples)
 	}
 
@@ -325,9 +394,10 @@ func (e *Scheduler) initVUsAndExecutors(ctx context.Context, samplesOut chan<- m
 }
 
 // runExecutor gets called by the public Run() method once per configured
-// executor, each time in a new goroutine. It is responsible for waiting out the
-// configured startTime for the specific executor and then running its Run()
-// method.
+// executor, each time in a new goroutine. It is responsible for waiting for the
+// scenarios it starts after, if any, and out the configured startTime for the
+// specific executor and then running its Run() method, between the setup and
+// teardown functions of the scenario, if any.
 func (e *Scheduler) runExecutor(
 	runCtx context.Context, runResults chan<- error, engineOut chan<- metrics.SampleContainer, executor lib.Executor,
 ) {
@@ -339,6 +409,14 @@ func (e *Scheduler) runExecutor(
 		"startTime": executorStartTime,
 	})
 	executorProgress := executor.GetProgress()
+	if finished, ok := e.finished[executorConfig.GetName()]; ok {
+		defer close(finished)
+	}
+
+	if !e.waitForStartAfter(runCtx, executorConfig, executorProgress, executorLogger) {
+		runResults <- nil // no error since executor hasn't started yet
+		return
+	}
 
 	// Check if we have to wait before starting the actual executor execution
 	if executorStartTime > 0 {
@@ -361,12 +439,43 @@ func (e *Scheduler) runExecutor(
 		}
 	}
 
+	if dep, met := e.startAfterConditionsMet(executorConfig); !met {
+		executorLogger.Warnf("Skipping scenario %s, since the thresholds of scenario %s didn't pass",
+			executorConfig.GetName(), dep)
+		executorProgress.Modify(
+			pb.WithStatus(pb.Done),
+			pb.WithConstProgress(0, "skipped"),
+		)
+		runResults <- nil
+		return
+	}
+
+	runner := e.state.Test.Runner
+	if fn := executorConfig.GetSetup(); fn != "" {
+		executorProgress.Modify(
+			pb.WithStatus(pb.Running),
+			pb.WithConstProgress(0, "setup"),
+		)
+		if err := runner.ScenarioSetup(runCtx, engineOut, executorConfig.GetName(), fn); err != nil {
+			executorLogger.WithField("error", err).Errorf("Scenario setup error")
+			runResults <- fmt.Errorf("error in %s() of scenario %s: %w", fn, executorConfig.GetName(), err)
+			return
+		}
+	}
+
 	executorProgress.Modify(
 		pb.WithStatus(pb.Running),
 		pb.WithConstProgress(0, "started"),
 	)
 	executorLogger.Debugf("Starting executor")
 	err := executor.Run(runCtx, engineOut) // executor should handle context cancel itself
+
+	// the teardown is skipped if the scenario or the test were interrupted
+	if fn := executorConfig.GetTeardown(); fn != "" && err == nil && runCtx.Err() == nil {
+		if err = runner.ScenarioTeardown(runCtx, engineOut, executorConfig.GetName(), fn); err != nil {
+			err = fmt.Errorf("error in %s() of scenario %s: %w", fn, executorConfig.GetName(), err)
+		}
+	}
 	if err == nil {
 		executorLogger.Debugf("Executor finished successfully")
 	} else {
@@ -375,6 +484,65 @@ func (e *Scheduler) runExecutor(
 	runResults <- err
 }
 
+// waitForStartAfter waits for the scenarios that the scenario starts after to
+// finish, if any. It returns false if the test was stopped in the meantime.
+func (e *Scheduler) waitForStartAfter(
+	runCtx context.Context, config lib.ExecutorConfig, progress *pb.ProgressBar, logger logrus.FieldLogger,
+) bool {
+	deps := config.GetStartAfter()
+	if len(deps) == 0 {
+		return true
+	}
+	names := make([]string, len(deps))
+	for i, dep := range deps {
+		names[i] = dep.Scenario
+	}
+	progress.Modify(
+		pb.WithStatus(pb.Waiting),
+		pb.WithConstProgress(0, "waiting for "+strings.Join(names, ", ")),
+	)
+	logger.Debugf("Waiting for scenarios %s to finish...", strings.Join(names, ", "))
+	for _, name := range names {
+		select {
+		case <-runCtx.Done():
+			return false
+		case <-e.finished[name]:
+			// continue
+		}
+	}
+	return true
+}
+
+// GetStartTime returns the latest time the scenario starts at, relative to
+// the beginning of the test, with its startAfter resolved. The scenarios that
+// start after others start earlier, if the ones before them finish earlier.
+func (e *Scheduler) GetStartTime(config lib.ExecutorConfig) time.Duration {
+	if startTime, ok := e.startTimes[config.GetName()]; ok {
+		return startTime
+	}
+	return config.GetStartTime() // started by StartScenario()
+}
+
+// startAfterConditionsMet checks the conditions of the scenarios the scenario
+// starts after, and returns the first one whose condition isn't met, if any.
+func (e *Scheduler) startAfterConditionsMet(config lib.ExecutorConfig) (string, bool) {
+	for _, dep := range config.GetStartAfter() {
+		if dep.Condition != lib.ScenarioConditionThresholdsPassed {
+			continue
+		}
+		thresholdsPassed := e.state.Test.ThresholdsPassed
+		if thresholdsPassed == nil {
+			e.state.Test.Logger.Warnf("The thresholds of scenario %s can't be checked before scenario %s starts, "+
+				"since they aren't processed", dep.Scenario, config.GetName())
+			continue
+		}
+		if !thresholdsPassed(dep.Scenario) {
+			return dep.Scenario, false
+		}
+	}
+	return "", true
+}
+
 // Init concurrently initializes all of the planned VUs and then sequentially
 // initializes all of the configured executors. It also starts the measurement
 // and emission of the `vus` and `vus_max` metrics.
@@ -392,10 +560,12 @@ func (e *Scheduler) Init(
 
 	execSchedRunCtx, execSchedRunCancel := context.WithCancel(runCtx)
 	waitForVUsMetricPush := e.emitVUsAndVUsMax(execSchedRunCtx, samplesOut)
+	waitForMemoryWatch := e.watchMemory(execSchedRunCtx, runCtx)
 	stopVUEmission = func() {
 		logger.Debugf("Stopping vus and vux_max metrics emission...")
 		execSchedRunCancel()
 		waitForVUsMetricPush()
+		waitForMemoryWatch()
 	}
 
 	defer func() {
@@ -496,13 +666,24 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 
 	executorsRunCtx, executorsRunCancel := context.WithCancel(withExecStateCtx)
 	defer executorsRunCancel()
-	for _, exec := range e.executors {
-		go e.runExecutor(executorsRunCtx, runResults, samplesOut, exec)
+	executors := e.GetExecutors()
+	e.runningMx.Lock()
+	e.running = &runningExecutors{
+		ctx:        executorsRunCtx,
+		samplesOut: samplesOut,
+		results:    runResults,
+		pending:    len(executors),
+		cancels:    make(map[string]context.CancelFunc, len(executors)),
+		starting:   make(map[string]struct{}),
+	}
+	for _, exec := range executors {
+		e.running.start(e, exec)
 	}
+	e.runningMx.Unlock()
 
-	// Wait for all executors to finish
+	// Wait for all executors to finish, including the ones started since
 	var firstErr error
-	for range e.executors {
+	for e.waitForNextExecutor() {
 		// TODO: add logic to abort the test early if there was an error from
 		// the controller (e.g. some other instance for this test died)
 		err := <-runResults
@@ -572,7 +753,7 @@ func (e *Scheduler) SetPaused(pause bool) error {
 		return e.state.Resume()
 	}
 
-	for _, exec := range e.executors {
+	for _, exec := range e.GetExecutors() {
 		pausableExecutor, ok := exec.(lib.PausableExecutor)
 		if !ok {
 			return fmt.Errorf(
@@ -589,3 +770,171 @@ func (e *Scheduler) SetPaused(pause bool) error {
 	}
 	return e.state.Resume()
 }
+
+// waitForNextExecutor returns whether Run() has to wait for another executor
+// to finish. Once it returns false, no new scenarios can be started.
+func (e *Scheduler) waitForNextExecutor() bool {
+	e.runningMx.Lock()
+	defer e.runningMx.Unlock()
+	if e.running.pending == 0 {
+		e.running.done = true
+		return false
+	}
+	e.running.pending--
+	return true
+}
+
+// StartScenario starts a new scenario, that isn't in the options of the test,
+// while the test is running. Its exec function has to be already exported by
+// the script, and its startTime is relative to the moment it's started.
+//
+// The VUs of the scenario are initialized before it's started, in addition to
+// the ones of the configured scenarios, and its executor has its own buffer of
+// VUs. The scenario isn't part of the execution plan, so it doesn't change the
+// expected duration of the test, but the test doesn't end before it's done.
+func (e *Scheduler) StartScenario(config lib.ExecutorConfig) (lib.Executor, error) {
+	name := config.GetName()
+	running, err := e.reserveScenario(config)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		e.runningMx.Lock()
+		delete(running.starting, name)
+		e.runningMx.Unlock()
+	}()
+
+	// The VUs are initialized without holding the lock, so the other
+	// scenarios can be stopped and updated in the meantime.
+	logger := e.state.Test.Logger.WithFields(logrus.Fields{
+		"scenario": name,
+		"executor": config.GetType(),
+	})
+	et := e.state.ExecutionTuple
+	executionPlan := config.GetExecutionRequirements(et)
+	maxPlannedVUs := lib.GetMaxPlannedVUs(executionPlan)
+	state := e.state.WithOwnVUBuffer(maxPlannedVUs, lib.GetMaxPossibleVUs(executionPlan))
+	for i := uint64(0); i < maxPlannedVUs; i++ {
+		vu, err := e.initVU(running.ctx, running.samplesOut, logger)
+		if err != nil {
+			e.state.ModInitializedVUsCount(-int64(i)) // return the VUs initialized so far
+			return nil, err
+		}
+		state.AddInitializedVU(vu)
+	}
+
+	exec, err := config.NewExecutor(state, logger)
+	if err == nil {
+		err = exec.Init(running.ctx)
+		if err != nil {
+			err = fmt.Errorf("error while initializing executor %s: %w", name, err)
+		}
+	}
+	if err != nil {
+		e.state.ModInitializedVUsCount(-int64(maxPlannedVUs))
+		return nil, err
+	}
+
+	// The test could have finished while the VUs were initialized.
+	e.runningMx.Lock()
+	defer e.runningMx.Unlock()
+	if running.done || running.ctx.Err() != nil {
+		e.state.ModInitializedVUsCount(-int64(maxPlannedVUs))
+		return nil, fmt.Errorf("the test finished before scenario '%s' could be started", name)
+	}
+
+	e.executorsMx.Lock()
+	e.executorConfigs = append(e.executorConfigs, config)
+	e.executors = append(e.executors, exec)
+	e.executorsMx.Unlock()
+
+	running.pending++
+	running.start(e, exec)
+	logger.Infof("Started scenario %s, with %d initialized VUs", name, maxPlannedVUs)
+	return exec, nil
+}
+
+// reserveScenario validates the config of a scenario started by
+// StartScenario(), and reserves its name until it's started or it fails to.
+func (e *Scheduler) reserveScenario(config lib.ExecutorConfig) (*runningExecutors, error) {
+	e.runningMx.Lock()
+	defer e.runningMx.Unlock()
+	running := e.running
+	if running == nil || running.done || running.ctx.Err() != nil {
+		return nil, errors.New("scenarios can only be started while the test is running")
+	}
+
+	name := config.GetName()
+	if _, ok := running.starting[name]; ok {
+		return nil, fmt.Errorf("scenario '%s' is already being started", name)
+	}
+	for _, sc := range e.GetExecutorConfigs() {
+		if sc.GetName() == name {
+			return nil, fmt.Errorf("scenario '%s' already exists", name)
+		}
+	}
+	if errs := config.Validate(); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config of scenario '%s': %w", name, errors.Join(errs...))
+	}
+	if len(config.GetStartAfter()) > 0 {
+		return nil, fmt.Errorf("scenario '%s' can't start after other scenarios, since it's started during the test", name)
+	}
+	if exec := config.GetExec(); len(config.GetExecMix()) == 0 && !e.state.Test.Runner.IsExecutable(exec) {
+		return nil, fmt.Errorf("the exec function '%s' of scenario '%s' isn't exported by the script", exec, name)
+	}
+	fns := []string{config.GetSetup(), config.GetTeardown()}
+	for fn := range config.GetExecMix() {
+		fns = append(fns, fn)
+	}
+	for _, fn := range fns {
+		if fn != "" && !e.state.Test.Runner.IsExecutable(fn) {
+			return nil, fmt.Errorf("the function '%s' of scenario '%s' isn't exported by the script", fn, name)
+		}
+	}
+	et := e.state.ExecutionTuple
+	if !config.HasWork(et) {
+		return nil, fmt.Errorf("scenario '%s' has no work for the execution segment %s", name, et.Segment)
+	}
+
+	running.starting[name] = struct{}{}
+	return running, nil
+}
+
+// StopScenario stops the scenario with the given name, while the test is
+// running, interrupting its iterations. The rest of the scenarios continue as
+// before, and the stop of a scenario that is already done has no effect.
+func (e *Scheduler) StopScenario(name string) error {
+	e.runningMx.Lock()
+	defer e.runningMx.Unlock()
+	if e.running == nil || e.running.done {
+		return errors.New("scenarios can only be stopped while the test is running")
+	}
+	cancel, ok := e.running.cancels[name]
+	if !ok {
+		return fmt.Errorf("scenario '%s' doesn't exist", name)
+	}
+	cancel()
+	e.state.Test.Logger.WithField("scenario", name).Infof("Stopped scenario %s", name)
+	return nil
+}
+
+// UpdateScenario changes the targets of the running scenario with the given
+// name, like its rate, stages or duration, if its executor supports it. The
+// changes are a JSON object with the fields of the executor config to change.
+func (e *Scheduler) UpdateScenario(ctx context.Context, name string, changes []byte) error {
+	for _, exec := range e.GetExecutors() {
+		if exec.GetConfig().GetName() != name {
+			continue
+		}
+		tex, ok := exec.(lib.TargetsUpdatableExecutor)
+		if !ok {
+			return fmt.Errorf("the targets of the %s executor of scenario '%s' can't be updated",
+				exec.GetConfig().GetType(), name)
+		}
+		if err := tex.UpdateTargets(ctx, changes); err != nil {
+			return fmt.Errorf("the targets of scenario '%s' can't be updated: %w", name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("scenario '%s' doesn't exist", name)
+}
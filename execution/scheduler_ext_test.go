@@ -2,12 +2,14 @@ package execution_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/url"
 	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -1373,3 +1375,194 @@ func TestNewSchedulerHasWork(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "doesn't support pause and resume operations after its start")
 }
+
+func TestSchedulerStartScenario(t *testing.T) {
+	t.Parallel()
+	var iterations, injectedIterations atomic.Int64
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			if lib.GetScenarioState(ctx).Name == "injected" {
+				injectedIterations.Add(1)
+				return nil
+			}
+			iterations.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		},
+	}
+	constant := executor.NewConstantVUsConfig("constant")
+	constant.VUs = null.IntFrom(1)
+	constant.Duration = types.NullDurationFrom(time.Second)
+	options := lib.Options{Scenarios: lib.ScenarioConfigs{"constant": constant}}
+	ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, options)
+	defer cancel()
+
+	injected := executor.NewSharedIterationsConfig("injected")
+	injected.VUs = null.IntFrom(2)
+	injected.Iterations = null.IntFrom(5)
+
+	_, err := execScheduler.StartScenario(injected)
+	require.ErrorContains(t, err, "scenarios can only be started while the test is running")
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- execScheduler.Run(ctx, ctx, samples) }()
+	for !execScheduler.GetState().HasStarted() {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = execScheduler.StartScenario(constant)
+	require.ErrorContains(t, err, "scenario 'constant' already exists")
+
+	invalid := executor.NewSharedIterationsConfig("invalid")
+	invalid.VUs = null.IntFrom(3)
+	invalid.Iterations = null.IntFrom(1)
+	_, err = execScheduler.StartScenario(invalid)
+	require.ErrorContains(t, err, "invalid config of scenario 'invalid'")
+
+	exec, err := execScheduler.StartScenario(injected)
+	require.NoError(t, err)
+	assert.Equal(t, "injected", exec.GetConfig().GetName())
+	require.Len(t, execScheduler.GetExecutors(), 2)
+	require.Len(t, execScheduler.GetExecutorConfigs(), 2)
+
+	require.NoError(t, <-runErr)
+	assert.Equal(t, int64(5), injectedIterations.Load())
+	assert.Positive(t, iterations.Load())
+
+	_, err = execScheduler.StartScenario(executor.NewSharedIterationsConfig("late"))
+	require.ErrorContains(t, err, "scenarios can only be started while the test is running")
+}
+
+// initHookRunner is a MiniRunner that calls the hook, if it's set, before
+// it initializes a VU.
+type initHookRunner struct {
+	*minirunner.MiniRunner
+	hook atomic.Pointer[func() error]
+}
+
+func (r *initHookRunner) NewVU(
+	ctx context.Context, idLocal, idGlobal uint64, out chan<- metrics.SampleContainer,
+) (lib.InitializedVU, error) {
+	if hook := r.hook.Load(); hook != nil {
+		if err := (*hook)(); err != nil {
+			return nil, err
+		}
+	}
+	return r.MiniRunner.NewVU(ctx, idLocal, idGlobal, out)
+}
+
+func TestSchedulerStartScenarioInitErrors(t *testing.T) {
+	t.Parallel()
+	scenarios := lib.ScenarioConfigs{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"constant": {"executor": "constant-vus", "vus": 1, "duration": "1h"}
+	}`), &scenarios))
+	runner := &initHookRunner{MiniRunner: &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			select {
+			case <-ctx.Done():
+			case <-time.After(10 * time.Millisecond):
+			}
+			return nil
+		},
+	}}
+	ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{Scenarios: scenarios})
+	defer cancel()
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- execScheduler.Run(ctx, ctx, samples) }()
+	for !execScheduler.GetState().HasStarted() {
+		time.Sleep(time.Millisecond)
+	}
+	initializedVUs := execScheduler.GetState().GetInitializedVUsCount()
+
+	// the second VU of the scenario fails to be initialized
+	var count atomic.Int64
+	failSecond := func() error {
+		if count.Add(1) == 2 {
+			return errors.New("init error")
+		}
+		return nil
+	}
+	runner.hook.Store(&failSecond)
+	injected := executor.NewSharedIterationsConfig("injected")
+	injected.VUs = null.IntFrom(3)
+	injected.Iterations = null.IntFrom(3)
+	_, err := execScheduler.StartScenario(injected)
+	require.ErrorContains(t, err, "init error")
+	assert.Equal(t, initializedVUs, execScheduler.GetState().GetInitializedVUsCount())
+	require.Len(t, execScheduler.GetExecutors(), 1)
+
+	// the other scenarios can be stopped while the VUs are initialized, and
+	// the scenario isn't started if the test finished in the meantime
+	initializing, unblock := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	block := func() error {
+		once.Do(func() { close(initializing) })
+		<-unblock
+		return nil
+	}
+	runner.hook.Store(&block)
+	startErr := make(chan error, 1)
+	go func() {
+		_, err := execScheduler.StartScenario(injected)
+		startErr <- err
+	}()
+	<-initializing
+	require.NoError(t, execScheduler.StopScenario("constant"))
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the test didn't end after the scenario was stopped")
+	}
+	close(unblock)
+	require.ErrorContains(t, <-startErr, "the test finished before scenario 'injected' could be started")
+	assert.Equal(t, initializedVUs, execScheduler.GetState().GetInitializedVUsCount())
+	require.Len(t, execScheduler.GetExecutors(), 1)
+}
+
+func TestSchedulerStopScenario(t *testing.T) {
+	t.Parallel()
+	scenarios := lib.ScenarioConfigs{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"first": {"executor": "constant-vus", "vus": 1, "duration": "1h"},
+		"second": {"executor": "constant-vus", "vus": 1, "duration": "1h"}
+	}`), &scenarios))
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+			select {
+			case <-ctx.Done():
+			case <-time.After(10 * time.Millisecond):
+			}
+			return nil
+		},
+	}
+	ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{Scenarios: scenarios})
+	defer cancel()
+
+	require.ErrorContains(t, execScheduler.StopScenario("first"),
+		"scenarios can only be stopped while the test is running")
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- execScheduler.Run(ctx, ctx, samples) }()
+	for !execScheduler.GetState().HasStarted() {
+		time.Sleep(time.Millisecond)
+	}
+
+	require.ErrorContains(t, execScheduler.StopScenario("missing"), "scenario 'missing' doesn't exist")
+	require.NoError(t, execScheduler.StopScenario("first"))
+	select {
+	case err := <-runErr:
+		t.Fatalf("the test ended with only one of the scenarios stopped: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	require.NoError(t, execScheduler.StopScenario("second"))
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the test didn't end after all the scenarios were stopped")
+	}
+}
@@ -0,0 +1,61 @@
+package execution
+
+import (
+	"runtime"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// selfMetrics measures the health of the k6 process itself, so the users can
+// tell when the load generator, and not the system under test, is the
+// bottleneck of a test run.
+type selfMetrics struct {
+	builtin *metrics.BuiltinMetrics
+	tags    *metrics.TagSet
+
+	// the number of garbage collections whose pauses were already emitted
+	numGC uint32
+}
+
+func newSelfMetrics(builtin *metrics.BuiltinMetrics, tags *metrics.TagSet) *selfMetrics {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return &selfMetrics{builtin: builtin, tags: tags, numGC: ms.NumGC}
+}
+
+// samples returns the samples of the memory usage, of the backlog of the
+// channel of the samples, and of the GC pauses since the previous call.
+func (sm *selfMetrics) samples(t time.Time, out chan<- metrics.SampleContainer) []metrics.Sample {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	samples := []metrics.Sample{
+		{
+			TimeSeries: metrics.TimeSeries{Metric: sm.builtin.MemoryUsage, Tags: sm.tags},
+			Time:       t,
+			Value:      float64(ms.Sys),
+		},
+		{
+			TimeSeries: metrics.TimeSeries{Metric: sm.builtin.SamplesBacklog, Tags: sm.tags},
+			Time:       t,
+			Value:      float64(len(out)),
+		},
+	}
+
+	// only the pauses of the last 256 collections are kept by the runtime
+	first := sm.numGC
+	if ms.NumGC-first > uint32(len(ms.PauseNs)) {
+		first = ms.NumGC - uint32(len(ms.PauseNs))
+	}
+	for i := first; i < ms.NumGC; i++ {
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: sm.builtin.GCPause, Tags: sm.tags},
+			Time:       t,
+			Value:      metrics.D(time.Duration(ms.PauseNs[i%uint32(len(ms.PauseNs))])),
+		})
+	}
+	sm.numGC = ms.NumGC
+
+	return samples
+}
@@ -0,0 +1,36 @@
+package execution
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestSelfMetrics(t *testing.T) {
+	t.Parallel()
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	sm := newSelfMetrics(builtin, registry.RootTagSet())
+
+	out := make(chan metrics.SampleContainer, 10)
+	out <- metrics.Sample{}
+	out <- metrics.Sample{}
+	runtime.GC()
+	runtime.GC()
+
+	values := make(map[*metrics.Metric][]float64)
+	for _, s := range sm.samples(time.Now(), out) {
+		values[s.Metric] = append(values[s.Metric], s.Value)
+	}
+	require.Len(t, values[builtin.MemoryUsage], 1)
+	assert.Greater(t, values[builtin.MemoryUsage][0], 0.0)
+	assert.Equal(t, []float64{2}, values[builtin.SamplesBacklog])
+	// other tests can run collections meanwhile too
+	assert.GreaterOrEqual(t, len(values[builtin.GCPause]), 2)
+
+}
@@ -25,6 +25,8 @@ type ExtensionType uint8
 const (
 	JSExtension ExtensionType = iota + 1
 	OutputExtension
+	SecretSourceExtension
+	ExecutorExtension
 )
 
 func (e ExtensionType) String() string {
@@ -34,6 +36,10 @@ func (e ExtensionType) String() string {
 		s = "js"
 	case OutputExtension:
 		s = "output"
+	case SecretSourceExtension:
+		s = "secret-source"
+	case ExecutorExtension:
+		s = "executor"
 	}
 	return s
 }
@@ -100,8 +106,9 @@ func GetAll() []*Extension {
 	mx.RLock()
 	defer mx.RUnlock()
 
-	js, out := extensions[JSExtension], extensions[OutputExtension]
-	result := make([]*Extension, 0, len(js)+len(out))
+	js, out, secrets := extensions[JSExtension], extensions[OutputExtension], extensions[SecretSourceExtension]
+	executors := extensions[ExecutorExtension]
+	result := make([]*Extension, 0, len(js)+len(out)+len(secrets)+len(executors))
 
 	for _, e := range js {
 		result = append(result, e)
@@ -109,6 +116,12 @@ func GetAll() []*Extension {
 	for _, e := range out {
 		result = append(result, e)
 	}
+	for _, e := range secrets {
+		result = append(result, e)
+	}
+	for _, e := range executors {
+		result = append(result, e)
+	}
 
 	sort.Slice(result, func(i, j int) bool {
 		if result[i].Path == result[j].Path {
@@ -157,4 +170,6 @@ func extractModuleInfo(mod interface{}) (path, version string) {
 func init() {
 	extensions[JSExtension] = make(map[string]*Extension)
 	extensions[OutputExtension] = make(map[string]*Extension)
+	extensions[SecretSourceExtension] = make(map[string]*Extension)
+	extensions[ExecutorExtension] = make(map[string]*Extension)
 }
@@ -0,0 +1,29 @@
+// Package converterutil contains the helpers shared by the converters of HAR,
+// OpenAPI and Postman files to k6 scripts, and by the k6/experimental/har
+// module.
+package converterutil
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// JSString returns s as a JS string literal. JSON escapes everything that
+// isn't valid in JS string literals, including U+2028 and U+2029.
+func JSString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ContainsString returns whether the list contains s.
+func ContainsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
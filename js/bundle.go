@@ -331,6 +331,9 @@ func (b *Bundle) instantiate(vuImpl *moduleVUImpl, vuID uint64) (*goja.Object, e
 	// any subsequent VUs to open new files
 	if vuID == 0 {
 		allowOnlyOpenedFiles(b.filesystems["file"])
+		for _, scheme := range loader.ObjectStorageSchemes() {
+			allowOnlyOpenedFiles(b.filesystems[scheme])
+		}
 	}
 
 	rt.SetRandSource(common.NewRandSource())
@@ -406,7 +409,7 @@ func (b *Bundle) setInitGlobals(rt *goja.Runtime, vu *moduleVUImpl, modSys *modu
 		}
 		// This uses the pwd from the requireImpl
 		pwd := impl.internal.CurrentlyRequiredModule()
-		return openImpl(rt, b.filesystems["file"], &pwd, filename, args...)
+		return openImpl(rt, b.filesystems, &pwd, filename, args...)
 	})
 }
 
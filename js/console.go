@@ -1,6 +1,7 @@
 package js
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"strings"
@@ -35,6 +36,18 @@ func newFileConsole(filepath string, formatter logrus.Formatter, level logrus.Le
 	return &console{l}, nil
 }
 
+// withLogContext returns the logger with the context added to its entries.
+func withLogContext(logger logrus.FieldLogger, ctx context.Context) logrus.FieldLogger {
+	switch l := logger.(type) {
+	case *logrus.Logger:
+		return l.WithContext(ctx)
+	case *logrus.Entry:
+		return l.WithContext(ctx)
+	default:
+		return logger
+	}
+}
+
 func (c console) log(level logrus.Level, args ...goja.Value) {
 	var strs strings.Builder
 	for i := 0; i < len(args); i++ {
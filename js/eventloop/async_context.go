@@ -0,0 +1,57 @@
+package eventloop
+
+import (
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
+)
+
+// asyncContextTracker keeps the group of the VU, and so its group tag, across
+// the async boundaries. The group is grabbed when a promise reaction or an
+// event loop callback is scheduled, and it's restored when they run, so the
+// code after an await, in a .then() or in a timer, is in the group that
+// scheduled it instead of whichever group happens to be current.
+type asyncContextTracker struct {
+	vu modules.VU
+
+	// the groups that were current before each of the resumed contexts
+	suspended []*lib.Group
+}
+
+var _ goja.AsyncContextTracker = &asyncContextTracker{}
+
+// Grab implements goja.AsyncContextTracker.
+func (t *asyncContextTracker) Grab() interface{} {
+	state := t.vu.State()
+	if state == nil {
+		return nil
+	}
+	return state.Group
+}
+
+// Resumed implements goja.AsyncContextTracker.
+func (t *asyncContextTracker) Resumed(trackingObject interface{}) {
+	state := t.vu.State()
+	group, _ := trackingObject.(*lib.Group)
+	if state == nil || group == nil {
+		t.suspended = append(t.suspended, nil)
+		return
+	}
+	t.suspended = append(t.suspended, state.Group)
+	if state.Group != group {
+		state.SetGroup(group)
+	}
+}
+
+// Exited implements goja.AsyncContextTracker.
+func (t *asyncContextTracker) Exited() {
+	if len(t.suspended) == 0 {
+		return
+	}
+	group := t.suspended[len(t.suspended)-1]
+	t.suspended = t.suspended[:len(t.suspended)-1]
+	if state := t.vu.State(); state != nil && group != nil && state.Group != group {
+		state.SetGroup(group)
+	}
+}
@@ -4,9 +4,11 @@ package eventloop
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/dop251/goja"
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/metrics"
 )
 
 // EventLoop implements an event with
@@ -32,18 +34,24 @@ type EventLoop struct {
 	// if there is something in this map at an end of an event loop then it will exit with an error.
 	// It's similar to what Deno and Node do.
 	pendingPromiseRejections map[*goja.Promise]struct{}
+
+	asyncContext *asyncContextTracker
 }
 
 // New returns a new event loop with a few helpers attached to it:
 // - adding setTimeout javascript implementation
 // - reporting (and aborting on) unhandled promise rejections
+// - keeping the group of the VU across awaits, promise callbacks and the
+// registered callbacks
 func New(vu modules.VU) *EventLoop {
 	e := &EventLoop{
 		wakeupCh:                 make(chan struct{}, 1),
 		pendingPromiseRejections: make(map[*goja.Promise]struct{}),
 		vu:                       vu,
+		asyncContext:             &asyncContextTracker{vu: vu},
 	}
 	vu.Runtime().SetPromiseRejectionTracker(e.promiseRejectionTracker)
+	vu.Runtime().SetAsyncContextTracker(e.asyncContext)
 
 	return e
 }
@@ -110,12 +118,16 @@ func (e *EventLoop) wakeup() {
 // ensures that the Promise resolution happens safely back on the main thread
 // once the async work is done, as required by goja and all other JS runtimes.
 //
+// The callbacks run in the group that was current when RegisterCallback() was
+// called, so their metrics are tagged like the code that started the work.
+//
 // TODO: rename to ReservePendingCallback or something more appropriate?
 func (e *EventLoop) RegisterCallback() (enqueueCallback func(func() error)) {
 	e.lock.Lock()
 	var callbackCalled bool
 	e.registeredCallbacks++
 	e.lock.Unlock()
+	asyncCtx := e.asyncContext.Grab()
 
 	return func(f func() error) {
 		e.lock.Lock()
@@ -124,13 +136,39 @@ func (e *EventLoop) RegisterCallback() (enqueueCallback func(func() error)) {
 			panic("RegisterCallback called twice")
 		}
 		callbackCalled = true
-		e.queue = append(e.queue, f)
+		enqueuedAt := time.Now()
+		e.queue = append(e.queue, func() error {
+			e.emitLag(time.Since(enqueuedAt))
+			e.asyncContext.Resumed(asyncCtx)
+			defer e.asyncContext.Exited()
+			return f()
+		})
 		e.registeredCallbacks--
 		e.lock.Unlock()
 		e.wakeup()
 	}
 }
 
+// emitLag emits how long a callback waited in the queue before it ran, if the
+// self metrics are enabled, as a VU that's too busy to run its callbacks on
+// time makes all of the async measurements longer than they really are.
+func (e *EventLoop) emitLag(lag time.Duration) {
+	state := e.vu.State()
+	if state == nil || !state.Options.SelfMetrics.Bool {
+		return
+	}
+	ctm := state.Tags.GetCurrentValues()
+	metrics.PushIfNotDone(e.vu.Context(), state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: state.BuiltinMetrics.EventLoopLag,
+			Tags:   ctm.Tags,
+		},
+		Time:     time.Now(),
+		Metadata: ctm.Metadata,
+		Value:    metrics.D(lag),
+	})
+}
+
 func (e *EventLoop) promiseRejectionTracker(p *goja.Promise, op goja.PromiseRejectionOperation) {
 	// No locking necessary here as the goja runtime will call this synchronously
 	// Read Notes on https://tc39.es/ecma262/#sec-host-promise-rejection-tracker
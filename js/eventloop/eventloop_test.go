@@ -1,6 +1,7 @@
 package eventloop_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync/atomic"
@@ -9,9 +10,13 @@ import (
 
 	"github.com/dop251/goja"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/eventloop"
 	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
 )
 
 func TestBasicEventLoop(t *testing.T) {
@@ -237,3 +242,71 @@ func TestEventLoopAsyncAwait(t *testing.T) {
 	loop.WaitOnRegistered()
 	require.EqualError(t, err, "Uncaught (in promise) ReferenceError: some is not defined\n\tat a (<eval>:3:13(1))\n\tat <eval>:6:20(2)\n")
 }
+
+func TestEventLoopAsyncContext(t *testing.T) {
+	t.Parallel()
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	group, err := root.Group("my group")
+	require.NoError(t, err)
+	state := &lib.State{
+		Group:   root,
+		Options: lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+		Tags:    lib.NewVUStateTags(metrics.NewRegistry().RootTagSet()),
+	}
+	vu := &modulestest.VU{RuntimeField: goja.New(), StateField: state}
+	loop := eventloop.New(vu)
+	rt := vu.Runtime()
+
+	var groups []string
+	require.NoError(t, rt.Set("record", func() {
+		groupTag, _ := state.Tags.GetCurrentValues().Tags.Get("group")
+		groups = append(groups, state.Group.Path+"|"+groupTag)
+	}))
+	err = loop.Start(func() error {
+		state.SetGroup(group)
+		enqueue := loop.RegisterCallback()
+		_, err := rt.RunString(`Promise.resolve().then(record)`)
+		state.SetGroup(root)
+		go enqueue(func() error {
+			_, err := rt.RunString(`record(); Promise.resolve().then(record)`)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		_, err = rt.RunString(`record()`)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"::my group|::my group", "|", "::my group|::my group", "::my group|::my group"}, groups)
+	require.Equal(t, root, state.Group)
+}
+
+func TestEventLoopLag(t *testing.T) {
+	t.Parallel()
+	registry := metrics.NewRegistry()
+	samples := make(chan metrics.SampleContainer, 10)
+	state := &lib.State{
+		Options:        lib.Options{SelfMetrics: null.BoolFrom(true)},
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet().With("tag", "value")),
+		Samples:        samples,
+	}
+	vu := &modulestest.VU{RuntimeField: goja.New(), StateField: state, CtxField: context.Background()}
+	loop := eventloop.New(vu)
+
+	err := loop.Start(func() error {
+		enqueue := loop.RegisterCallback()
+		enqueue(func() error { return nil })
+		// the callback waits in the queue while the VU is busy
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	sample := (<-samples).GetSamples()[0]
+	require.Equal(t, state.BuiltinMetrics.EventLoopLag, sample.Metric)
+	require.Equal(t, map[string]string{"tag": "value"}, sample.Tags.Map())
+	require.GreaterOrEqual(t, sample.Value, 20.0)
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"path"
+	"path/filepath"
 
 	"github.com/dop251/goja"
 	"github.com/sirupsen/logrus"
@@ -17,9 +19,24 @@ const cantBeUsedOutsideInitContextMsg = `the "%s" function is only available in
 
 // openImpl implements openImpl() in the init context and will read and return the
 // contents of a file. If the second argument is "b" it returns an ArrayBuffer
-// instance, otherwise a string representation.
-func openImpl(rt *goja.Runtime, fs fsext.Fs, basePWD *url.URL, filename string, args ...string) (goja.Value, error) {
-	data, err := readFile(fs, fsext.Abs(basePWD.Path, filename))
+// instance, otherwise a string representation. The files are read from the local disk, unless
+// they are object storage URLs or relative to the ones of object storage scripts.
+func openImpl(
+	rt *goja.Runtime, filesystems map[string]fsext.Fs, basePWD *url.URL, filename string, args ...string,
+) (goja.Value, error) {
+	scheme, filePath := "file", fsext.Abs(basePWD.Path, filename)
+	if u, err := url.Parse(filename); err == nil && loader.IsObjectStorageScheme(u.Scheme) {
+		scheme, filePath = u.Scheme, "/"+u.Host+u.Path
+	} else if loader.IsObjectStorageScheme(basePWD.Scheme) && !filepath.IsAbs(filename) && filename[0] != '/' {
+		scheme, filePath = basePWD.Scheme, "/"+basePWD.Host+path.Join(basePWD.Path, filename)
+	}
+	fs, ok := filesystems[scheme]
+	if !ok {
+		// the archives don't have the filesystems of the object storages they don't use
+		return nil, fmt.Errorf("open() can't be used with %q, since it isn't in the archive", filename)
+	}
+
+	data, err := readFile(fs, filePath)
 	if err != nil {
 		return nil, err
 	}
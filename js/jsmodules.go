@@ -13,12 +13,19 @@ import (
 	"go.k6.io/k6/js/modules/k6/data"
 	"go.k6.io/k6/js/modules/k6/encoding"
 	"go.k6.io/k6/js/modules/k6/execution"
+	"go.k6.io/k6/js/modules/k6/experimental/contract"
+	"go.k6.io/k6/js/modules/k6/experimental/expect"
 	"go.k6.io/k6/js/modules/k6/experimental/fs"
+	"go.k6.io/k6/js/modules/k6/experimental/har"
+	"go.k6.io/k6/js/modules/k6/experimental/log"
+	"go.k6.io/k6/js/modules/k6/experimental/mock"
+	"go.k6.io/k6/js/modules/k6/experimental/state"
 	"go.k6.io/k6/js/modules/k6/experimental/tracing"
 	"go.k6.io/k6/js/modules/k6/grpc"
 	"go.k6.io/k6/js/modules/k6/html"
 	"go.k6.io/k6/js/modules/k6/http"
 	"go.k6.io/k6/js/modules/k6/metrics"
+	"go.k6.io/k6/js/modules/k6/secrets"
 	"go.k6.io/k6/js/modules/k6/timers"
 	"go.k6.io/k6/js/modules/k6/ws"
 
@@ -43,14 +50,21 @@ func getInternalJSModules() map[string]interface{} {
 		"k6/experimental/timers": newWarnExperimentalModule(timers.New(),
 			"k6/experimental/timers is now part of the k6 core, please change your imports to use k6/timers instead."+
 				" The k6/experimental/timers will be removed in k6 v0.52.0"),
-		"k6/experimental/tracing": tracing.New(),
-		"k6/experimental/browser": browser.New(),
-		"k6/experimental/fs":      fs.New(),
-		"k6/net/grpc":             grpc.New(),
-		"k6/html":                 html.New(),
-		"k6/http":                 http.New(),
-		"k6/metrics":              metrics.New(),
-		"k6/ws":                   ws.New(),
+		"k6/experimental/tracing":  tracing.New(),
+		"k6/experimental/browser":  browser.New(),
+		"k6/experimental/contract": contract.New(),
+		"k6/experimental/expect":   expect.New(),
+		"k6/experimental/fs":       fs.New(),
+		"k6/experimental/har":      har.New(),
+		"k6/experimental/log":      log.New(),
+		"k6/experimental/mock":     mock.New(),
+		"k6/experimental/state":    state.New(),
+		"k6/net/grpc":              grpc.New(),
+		"k6/html":                  html.New(),
+		"k6/http":                  http.New(),
+		"k6/metrics":               metrics.New(),
+		"k6/secrets":               secrets.New(),
+		"k6/ws":                    ws.New(),
 		"k6/experimental/grpc": newRemovedModule(
 			"k6/experimental/grpc has been graduated, please use k6/net/grpc instead." +
 				" See https://grafana.com/docs/k6/latest/javascript-api/k6-net-grpc/ for more information.",
@@ -10,25 +10,43 @@ import (
 	"github.com/dop251/goja"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
 )
 
 type (
 	// RootModule is the global module instance that will create module
 	// instances for each VU.
 	RootModule struct {
-		shared sharedArrays
+		shared  sharedArrays
+		objects sharedObjects
 	}
 
 	// Data represents an instance of the data module.
 	Data struct {
-		vu     modules.VU
-		shared *sharedArrays
+		vu      modules.VU
+		shared  *sharedArrays
+		objects *sharedObjects
+
+		// the wrapped setup data, by the data of each scenario
+		setupData map[*lib.SetupData]goja.Value
 	}
 
 	sharedArrays struct {
 		data map[string]sharedArray
 		mu   sync.RWMutex
 	}
+
+	// sharedObjects are the values shared with SharedMap and shareImmutable.
+	sharedObjects struct {
+		data map[string]sharedObject
+		mu   sync.RWMutex
+	}
+
+	// sharedObject is a value stored once per process, that is wrapped as a
+	// read-only value for each VU.
+	sharedObject interface {
+		wrap(rt *goja.Runtime) goja.Value
+	}
 )
 
 var (
@@ -42,6 +60,9 @@ func New() *RootModule {
 		shared: sharedArrays{
 			data: make(map[string]sharedArray),
 		},
+		objects: sharedObjects{
+			data: make(map[string]sharedObject),
+		},
 	}
 }
 
@@ -49,8 +70,9 @@ func New() *RootModule {
 // a new instance for each VU.
 func (rm *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	return &Data{
-		vu:     vu,
-		shared: &rm.shared,
+		vu:      vu,
+		shared:  &rm.shared,
+		objects: &rm.objects,
 	}
 }
 
@@ -58,7 +80,10 @@ func (rm *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 func (d *Data) Exports() modules.Exports {
 	return modules.Exports{
 		Named: map[string]interface{}{
-			"SharedArray": d.sharedArray,
+			"SharedArray":     d.sharedArray,
+			"SharedMap":       d.sharedMap,
+			"shareImmutable":  d.shareImmutable,
+			"sharedSetupData": d.sharedSetupData,
 		},
 	}
 }
@@ -0,0 +1,87 @@
+package data
+
+import (
+	"errors"
+
+	"github.com/dop251/goja"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
+)
+
+// sharedSetupData returns the data of the setup function of the current
+// scenario, or of setup(), as a read-only value shared by the VU's
+// iterations. The top-level fields of an object are parsed only when they're
+// read, so VUs that don't need all of the data don't pay for it.
+func (d *Data) sharedSetupData() goja.Value {
+	rt := d.vu.Runtime()
+	state := d.vu.State()
+	if state == nil {
+		common.Throw(rt, errors.New("sharedSetupData can't be called in the init context"))
+	}
+	if state.SetupData == nil {
+		return goja.Undefined()
+	}
+	data := state.SetupData()
+	if data == nil {
+		return goja.Undefined()
+	}
+
+	if v, ok := d.setupData[data]; ok {
+		return v
+	}
+	parser := newFrozenParser(rt)
+	var v goja.Value
+	if data.IsObject() {
+		v = rt.NewDynamicObject(&wrappedSetupData{
+			frozenParser: parser,
+			data:         data,
+			values:       make(map[string]goja.Value),
+		})
+	} else {
+		v = parser.parseFrozen(string(data.JSON()))
+	}
+	if d.setupData == nil {
+		d.setupData = make(map[*lib.SetupData]goja.Value)
+	}
+	d.setupData[data] = v
+	return v
+}
+
+// wrappedSetupData is the read-only object of the setup data, its fields are
+// parsed and frozen the first time they're read.
+type wrappedSetupData struct {
+	frozenParser
+
+	data   *lib.SetupData
+	values map[string]goja.Value
+}
+
+func (s *wrappedSetupData) Get(key string) goja.Value {
+	if v, ok := s.values[key]; ok {
+		return v
+	}
+	field, ok := s.data.Field(key)
+	if !ok {
+		return nil
+	}
+	v := s.parseFrozen(string(field))
+	s.values[key] = v
+	return v
+}
+
+func (s *wrappedSetupData) Set(_ string, _ goja.Value) bool {
+	panic(s.rt.NewTypeError("the shared setup data is immutable")) // this is specifically a type error
+}
+
+func (s *wrappedSetupData) Has(key string) bool {
+	_, ok := s.data.Field(key)
+	return ok
+}
+
+func (s *wrappedSetupData) Delete(_ string) bool {
+	panic(s.rt.NewTypeError("the shared setup data is immutable")) // this is specifically a type error
+}
+
+func (s *wrappedSetupData) Keys() []string {
+	return s.data.Keys()
+}
@@ -13,23 +13,33 @@ type sharedArray struct {
 
 type wrappedSharedArray struct {
 	sharedArray
+	frozenParser
+}
 
+// frozenParser parses JSON into deeply frozen values.
+type frozenParser struct {
 	rt       *goja.Runtime
 	freeze   goja.Callable
 	isFrozen goja.Callable
 	parse    goja.Callable
 }
 
-func (s sharedArray) wrap(rt *goja.Runtime) goja.Value {
+func newFrozenParser(rt *goja.Runtime) frozenParser {
 	freeze, _ := goja.AssertFunction(rt.GlobalObject().Get("Object").ToObject(rt).Get("freeze"))
 	isFrozen, _ := goja.AssertFunction(rt.GlobalObject().Get("Object").ToObject(rt).Get("isFrozen"))
 	parse, _ := goja.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("parse"))
+	return frozenParser{
+		rt:       rt,
+		freeze:   freeze,
+		isFrozen: isFrozen,
+		parse:    parse,
+	}
+}
+
+func (s sharedArray) wrap(rt *goja.Runtime) goja.Value {
 	return rt.NewDynamicArray(wrappedSharedArray{
-		sharedArray: s,
-		rt:          rt,
-		freeze:      freeze,
-		isFrozen:    isFrozen,
-		parse:       parse,
+		sharedArray:  s,
+		frozenParser: newFrozenParser(rt),
 	})
 }
 
@@ -45,7 +55,16 @@ func (s wrappedSharedArray) Get(index int) goja.Value {
 	if index < 0 || index >= len(s.arr) {
 		return goja.Undefined()
 	}
-	val, err := s.parse(goja.Undefined(), s.rt.ToValue(s.arr[index]))
+	return s.parseFrozen(s.arr[index])
+}
+
+func (s wrappedSharedArray) Len() int {
+	return len(s.arr)
+}
+
+// parseFrozen parses the JSON and deeply freezes the value, it throws on errors.
+func (s frozenParser) parseFrozen(data string) goja.Value {
+	val, err := s.parse(goja.Undefined(), s.rt.ToValue(data))
 	if err != nil {
 		common.Throw(s.rt, err)
 	}
@@ -57,11 +76,7 @@ func (s wrappedSharedArray) Get(index int) goja.Value {
 	return val
 }
 
-func (s wrappedSharedArray) Len() int {
-	return len(s.arr)
-}
-
-func (s wrappedSharedArray) deepFreeze(rt *goja.Runtime, val goja.Value) error {
+func (s frozenParser) deepFreeze(rt *goja.Runtime, val goja.Value) error {
 	if val != nil && goja.IsNull(val) {
 		return nil
 	}
@@ -0,0 +1,168 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dop251/goja"
+	"go.k6.io/k6/js/common"
+)
+
+// sharedMap is a read-only object shared by all the VUs, with the values of its
+// keys stored as JSON.
+type sharedMap struct {
+	keys   []string
+	values map[string]string
+}
+
+type wrappedSharedMap struct {
+	sharedMap
+	frozenParser
+}
+
+// sharedValue is any other value shared by all the VUs, stored as JSON.
+type sharedValue struct {
+	value string
+}
+
+func (s sharedMap) wrap(rt *goja.Runtime) goja.Value {
+	return rt.NewDynamicObject(wrappedSharedMap{
+		sharedMap:    s,
+		frozenParser: newFrozenParser(rt),
+	})
+}
+
+func (s wrappedSharedMap) Get(key string) goja.Value {
+	value, ok := s.values[key]
+	if !ok {
+		return nil
+	}
+	return s.parseFrozen(value)
+}
+
+func (s wrappedSharedMap) Set(_ string, _ goja.Value) bool {
+	panic(s.rt.NewTypeError("SharedMap is immutable")) // this is specifically a type error
+}
+
+func (s wrappedSharedMap) Has(key string) bool {
+	_, ok := s.values[key]
+	return ok
+}
+
+func (s wrappedSharedMap) Delete(_ string) bool {
+	panic(s.rt.NewTypeError("SharedMap is immutable")) // this is specifically a type error
+}
+
+func (s wrappedSharedMap) Keys() []string {
+	return s.keys
+}
+
+func (s sharedValue) wrap(rt *goja.Runtime) goja.Value {
+	return newFrozenParser(rt).parseFrozen(s.value)
+}
+
+// sharedMap is a constructor returning a shareable read-only object
+// identified by the name and having the keys and values of whatever the call
+// returns. The values are copied, and frozen, each time they're read.
+func (d *Data) sharedMap(call goja.ConstructorCall) *goja.Object {
+	rt := d.vu.Runtime()
+	fn := d.sharedCallable("new SharedMap", call.Argument(0).String(), call.Argument(1))
+
+	object := d.objects.get(rt, call.Argument(0).String(), fn)
+	if _, ok := object.(sharedMap); !ok {
+		common.Throw(rt, errors.New("only objects can be made into SharedMap"))
+	}
+	return object.wrap(rt).ToObject(rt)
+}
+
+// shareImmutable returns a shareable read-only value identified by the name
+// and with the contents of whatever the call returns: arrays are shared like
+// SharedArray, objects like SharedMap, and any other value is copied, and
+// frozen, for each VU.
+func (d *Data) shareImmutable(name string, val goja.Value) goja.Value {
+	rt := d.vu.Runtime()
+	fn := d.sharedCallable("shareImmutable", name, val)
+
+	return d.objects.get(rt, name, fn).wrap(rt)
+}
+
+// sharedCallable checks the arguments of the constructors of the shared
+// values, it throws if they're wrong.
+func (d *Data) sharedCallable(constructor, name string, val goja.Value) goja.Callable {
+	rt := d.vu.Runtime()
+	if d.vu.State() != nil {
+		common.Throw(rt, fmt.Errorf("%s must be called in the init context", constructor))
+	}
+	if name == "" {
+		common.Throw(rt, fmt.Errorf("empty name provided to %s", constructor))
+	}
+	if common.IsAsyncFunction(rt, val) {
+		common.Throw(rt, fmt.Errorf("%s does not support async functions as second argument", constructor))
+	}
+	fn, ok := goja.AssertFunction(val)
+	if !ok {
+		common.Throw(rt, fmt.Errorf("a function is expected as the second argument of %s", constructor))
+	}
+	return fn
+}
+
+func (s *sharedObjects) get(rt *goja.Runtime, name string, call goja.Callable) sharedObject {
+	s.mu.RLock()
+	object, ok := s.data[name]
+	s.mu.RUnlock()
+	if !ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		object, ok = s.data[name]
+		if !ok {
+			object = getSharedObjectFromCall(rt, call)
+			s.data[name] = object
+		}
+	}
+
+	return object
+}
+
+func getSharedObjectFromCall(rt *goja.Runtime, call goja.Callable) sharedObject {
+	gojaValue, err := call(goja.Undefined())
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	if common.IsNullish(gojaValue) {
+		common.Throw(rt, errors.New("only JSON-like values can be shared, not null or undefined"))
+	}
+	obj, isObject := gojaValue.(*goja.Object)
+	if isObject && obj.ClassName() == "Array" {
+		return getShareArrayFromCall(rt, func(goja.Value, ...goja.Value) (goja.Value, error) {
+			return gojaValue, nil
+		})
+	}
+
+	stringify, _ := goja.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("stringify"))
+	toJSON := func(v goja.Value) (string, bool) {
+		val, err := stringify(goja.Undefined(), v)
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		if goja.IsUndefined(val) {
+			return "", false // e.g. functions
+		}
+		return val.String(), true
+	}
+	if !isObject || obj.ClassName() != "Object" {
+		value, ok := toJSON(gojaValue)
+		if !ok {
+			common.Throw(rt, errors.New("only JSON-like values can be shared"))
+		}
+		return sharedValue{value: value}
+	}
+
+	m := sharedMap{values: make(map[string]string)}
+	for _, key := range obj.Keys() {
+		if value, ok := toJSON(obj.Get(key)); ok {
+			m.keys = append(m.keys, key)
+			m.values[key] = value
+		}
+	}
+	return m
+}
@@ -0,0 +1,132 @@
+package data
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/require"
+)
+
+const makeMapScript = `
+var users = new data.SharedMap("users", function() {
+	var users = {};
+	for (var i = 0; i < 50; i++) {
+		users["user" + i] = {name: "name" + i, tags: ["a", "b"]};
+	}
+	users.skipped = function() {};
+	return users;
+});
+`
+
+func TestSharedMapConstructorExceptions(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		code, err string
+	}{
+		"returning array": {
+			code: `new data.SharedMap("wat", function() {return [1]});`,
+			err:  "only objects can be made into SharedMap",
+		},
+		"returning null": {
+			code: `new data.SharedMap("wat", function() {return null});`,
+			err:  "only JSON-like values can be shared, not null or undefined",
+		},
+		"empty name": {
+			code: `new data.SharedMap("", function() {return {}});`,
+			err:  "empty name provided to new SharedMap",
+		},
+		"not a function": {
+			code: `new data.SharedMap("wat", "astring");`,
+			err:  "a function is expected as the second argument of new SharedMap",
+		},
+		"async function": {
+			code: `data.shareImmutable("wat", async function() {});`,
+			err:  "shareImmutable does not support async functions as second argument",
+		},
+		"sharing a function": {
+			code: `data.shareImmutable("wat", function() { return function() {} });`,
+			err:  "only JSON-like values can be shared",
+		},
+	}
+
+	for name, testCase := range cases {
+		name, testCase := name, testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			runtime, err := newConfiguredRuntime(t)
+			require.NoError(t, err)
+			_, err = runtime.VU.Runtime().RunString(testCase.code)
+			require.Error(t, err)
+			exc := new(goja.Exception)
+			require.True(t, errors.As(err, &exc))
+			require.Contains(t, exc.Error(), testCase.err)
+		})
+	}
+}
+
+func TestSharedMapAnotherRuntime(t *testing.T) {
+	t.Parallel()
+
+	testRuntime, err := newConfiguredRuntime(t)
+	require.NoError(t, err)
+	_, err = testRuntime.VU.Runtime().RunString(makeMapScript)
+	require.NoError(t, err)
+
+	testRuntime, err = configuredRuntimeFromAnother(t, testRuntime)
+	require.NoError(t, err)
+	rt := testRuntime.VU.Runtime()
+	_, err = rt.RunString(`var users = new data.SharedMap("users", function() { throw "wat"; });`)
+	require.NoError(t, err)
+
+	_, err = rt.RunString(`
+	if (users.user2.name !== "name2" || users.user2.tags[1] !== "b") {
+		throw new Error("bad users.user2=" + JSON.stringify(users.user2));
+	}
+	if (Object.keys(users).length !== 50 || "skipped" in users || users.missing !== undefined) {
+		throw new Error("bad keys " + Object.keys(users));
+	}
+	if (users.user2 === users.user2 || !Object.isFrozen(users.user2.tags)) {
+		throw new Error("the values should be frozen copies");
+	}
+	`)
+	require.NoError(t, err)
+
+	cases := map[string]string{
+		"setting a key":          `users.user2 = {}`,
+		"deleting a key":         `delete users.user2`,
+		"setting in a value":     `users.user2.name = "bad"`,
+		"appending to a value":   `users.user2.tags.push("c")`,
+		"setting a shared value": `data.shareImmutable("value", function() { return [1] })[0] = 2`,
+	}
+	for name, code := range cases {
+		_, err = rt.RunString(`'use strict';` + code)
+		require.Error(t, err, name)
+	}
+}
+
+func TestShareImmutable(t *testing.T) {
+	t.Parallel()
+
+	runtime, err := newConfiguredRuntime(t)
+	require.NoError(t, err)
+	_, err = runtime.VU.Runtime().RunString(`
+	var arr = data.shareImmutable("array", function() { return [{a: 1}, {a: 2}] });
+	if (arr.length !== 2 || arr[1].a !== 2 || !Object.isFrozen(arr[1])) {
+		throw new Error("bad array " + JSON.stringify(arr));
+	}
+	var obj = data.shareImmutable("object", function() { return {a: {b: 1}} });
+	if (obj.a.b !== 1 || !Object.isFrozen(obj.a)) {
+		throw new Error("bad object " + JSON.stringify(obj));
+	}
+	var str = data.shareImmutable("string", function() { return "value" });
+	if (str !== "value") {
+		throw new Error("bad string " + str);
+	}
+	var same = new data.SharedMap("object", function() { throw "wat"; });
+	if (same.a.b !== 1) {
+		throw new Error("the object should be shared with SharedMap");
+	}
+	`)
+	require.NoError(t, err)
+}
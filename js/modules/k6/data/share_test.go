@@ -13,6 +13,7 @@ import (
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/compiler"
 	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
 )
 
 const makeArrayScript = `
@@ -254,3 +255,41 @@ func TestSharedArrayRaceInInitialization(t *testing.T) {
 		}
 	}
 }
+
+func TestSharedSetupData(t *testing.T) {
+	t.Parallel()
+
+	runtime, err := newConfiguredRuntime(t)
+	require.NoError(t, err)
+	_, err = runtime.VU.Runtime().RunString(`data.sharedSetupData()`)
+	require.ErrorContains(t, err, "sharedSetupData can't be called in the init context")
+
+	setupData := lib.NewSetupData([]byte(`{"users": [{"name": "a"}], "count": 1}`))
+	runtime.MoveToVUContext(&lib.State{
+		SetupData: func() *lib.SetupData { return setupData },
+	})
+	_, err = runtime.VU.Runtime().RunString(`
+		var d = data.sharedSetupData();
+		if (d !== data.sharedSetupData()) {
+			throw "the setup data should be cached";
+		}
+		if (Object.keys(d).join() !== "users,count") {
+			throw "wrong keys " + Object.keys(d);
+		}
+		if (d.count !== 1 || d.users[0].name !== "a" || d.missing !== undefined || !("users" in d)) {
+			throw "wrong data " + JSON.stringify(d);
+		}
+		if (!Object.isFrozen(d.users) || !Object.isFrozen(d.users[0])) {
+			throw "the fields should be frozen";
+		}
+		try {
+			d.count = 2;
+			throw "the setup data should be immutable";
+		} catch (e) {
+			if (!(e instanceof TypeError)) {
+				throw e;
+			}
+		}
+	`)
+	require.NoError(t, err)
+}
@@ -5,14 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/dop251/goja"
+	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -25,6 +29,15 @@ type (
 	ModuleInstance struct {
 		vu  modules.VU
 		obj *goja.Object
+
+		// the identities of the VU, by scenario and index, parsed once so
+		// their objects persist across the iterations
+		identities map[identityKey]goja.Value
+	}
+
+	identityKey struct {
+		scenario string
+		index    int
 	}
 )
 
@@ -152,6 +165,17 @@ func (mi *ModuleInstance) newInstanceInfo() (*goja.Object, error) {
 		"vusInitialized": func() interface{} {
 			return es.GetInitializedVUsCount()
 		},
+		// the part of the test that this instance runs
+		"executionSegment": func() interface{} {
+			return es.ExecutionTuple.Segment.String()
+		},
+		// the ID of the instance in a distributed test run, or null
+		"id": func() interface{} {
+			if es.Test == nil || !es.Test.InstanceID.Valid {
+				return nil
+			}
+			return es.Test.InstanceID.Int64
+		},
 	}
 
 	return newInfoObj(rt, ti)
@@ -165,14 +189,81 @@ func (mi *ModuleInstance) newTestInfo() (*goja.Object, error) {
 	var optionsObject *goja.Object
 	rt := mi.vu.Runtime()
 	ti := map[string]func() interface{}{
-		// stop the test run
+		// stop the test run, with the exit code of the options, if any
 		"abort": func() interface{} {
-			return func(msg goja.Value) {
+			return func(msg goja.Value, opts goja.Value) {
 				reason := errext.AbortTest
 				if msg != nil && !goja.IsUndefined(msg) {
 					reason = fmt.Sprintf("%s: %s", reason, msg.String())
 				}
-				rt.Interrupt(&errext.InterruptError{Reason: reason})
+				code, err := abortExitCode(rt, opts)
+				if err != nil {
+					common.Throw(rt, err)
+				}
+				rt.Interrupt(&errext.InterruptError{Reason: reason, Code: code})
+			}
+		},
+		// the current aggregated values of a metric, or sub-metric, or null
+		// if it hasn't been observed, of all of its samples or of the ones in
+		// the window of the options, if any
+		"metric": func() interface{} {
+			return func(name string, opts goja.Value) interface{} {
+				es := lib.GetExecutionState(mi.vu.Context())
+				if es == nil || mi.vu.State() == nil {
+					common.Throw(rt, errors.New("getting metric values in the init context is not supported"))
+				}
+				window, err := metricWindow(rt, opts)
+				if err != nil {
+					common.Throw(rt, err)
+				}
+				if window > 0 {
+					return windowMetricValues(rt, es, name, window)
+				}
+				if es.Test == nil || es.Test.MetricValues == nil {
+					return nil
+				}
+				values, ok := es.Test.MetricValues(name)
+				if !ok {
+					return nil
+				}
+				return values
+			}
+		},
+		// change the targets of a running scenario, like its rate, stages or
+		// duration, with an object like its config in the scenarios option
+		"updateScenario": func() interface{} {
+			return func(name string, changes goja.Value) {
+				es := lib.GetExecutionState(mi.vu.Context())
+				if es == nil || mi.vu.State() == nil {
+					common.Throw(rt, errors.New("updating scenarios in the init context is not supported"))
+				}
+				if es.Test == nil || es.Test.UpdateScenario == nil {
+					common.Throw(rt, errors.New("the scenarios can't be updated in this test run"))
+				}
+				if common.IsNullish(changes) {
+					common.Throw(rt, errors.New("the changes of the scenario are required"))
+				}
+				data, err := json.Marshal(changes.Export())
+				if err != nil {
+					common.Throw(rt, err)
+				}
+				if err = es.Test.UpdateScenario(mi.vu.Context(), name, data); err != nil {
+					common.Throw(rt, err)
+				}
+			}
+		},
+		// the metadata of the test run, with null for the unset values
+		"metadata": func() interface{} {
+			vuState := mi.vu.State()
+			if vuState == nil {
+				common.Throw(rt, errors.New("getting the metadata in the init context is not supported"))
+			}
+			opts := vuState.Options
+			return map[string]interface{}{
+				"name":        nullStringValue(opts.TestName),
+				"runId":       nullStringValue(opts.RunID),
+				"version":     nullStringValue(opts.TestVersion),
+				"environment": nullStringValue(opts.TestEnvironment),
 			}
 		},
 		"options": func() interface{} {
@@ -190,6 +281,93 @@ func (mi *ModuleInstance) newTestInfo() (*goja.Object, error) {
 	return newInfoObj(rt, ti)
 }
 
+// metricWindow returns the length of the window of the options of
+// test.metric(), 0 if it isn't set.
+func metricWindow(rt *goja.Runtime, opts goja.Value) (time.Duration, error) {
+	if common.IsNullish(opts) {
+		return 0, nil
+	}
+	window := opts.ToObject(rt).Get("window")
+	if common.IsNullish(window) {
+		return 0, nil
+	}
+	d, err := types.GetDurationValue(window.Export())
+	if err != nil {
+		return 0, fmt.Errorf("invalid window of the metric: %w", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("the window of the metric must be positive, not %s", d)
+	}
+	return d, nil
+}
+
+// windowMetricValues returns the aggregated values of the samples of the
+// metric in the latest window, or null if there aren't any. The samples are
+// kept from the first time that the window is requested.
+func windowMetricValues(rt *goja.Runtime, es *lib.ExecutionState, name string, window time.Duration) interface{} {
+	if es.Test == nil || es.Test.WatchMetric == nil {
+		return nil
+	}
+	sink, err := es.Test.WatchMetric(name, window)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	if s := sink(); s != nil {
+		return s.Format(window)
+	}
+	return nil
+}
+
+// nullStringValue returns the value of the string, or nil if it isn't set.
+func nullStringValue(s null.String) interface{} {
+	if !s.Valid {
+		return nil
+	}
+	return s.String
+}
+
+// abortExitCode returns the exit code of the options of test.abort(), 0 for
+// the default one if it isn't set.
+func abortExitCode(rt *goja.Runtime, opts goja.Value) (exitcodes.ExitCode, error) {
+	if common.IsNullish(opts) {
+		return 0, nil
+	}
+	exitCode := opts.ToObject(rt).Get("exitCode")
+	if common.IsNullish(exitCode) {
+		return 0, nil
+	}
+	code := exitCode.ToFloat()
+	if code != math.Trunc(code) || code < 1 || code > 255 {
+		return 0, fmt.Errorf("the exit code must be an integer between 1 and 255, not %s", exitCode)
+	}
+	return exitcodes.ExitCode(code), nil
+}
+
+// getIdentity returns the identity assigned to the VU in the current scenario,
+// or undefined if it doesn't have any identities. It's the same object in all
+// the iterations, so the script can keep the state of the identity in it.
+func (mi *ModuleInstance) getIdentity() goja.Value {
+	identity := mi.vu.State().Identity
+	if identity == nil {
+		return goja.Undefined()
+	}
+	key := identityKey{scenario: identity.Scenario, index: identity.Index}
+	if v, ok := mi.identities[key]; ok {
+		return v
+	}
+	rt := mi.vu.Runtime()
+	parse, _ := goja.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("parse"))
+	v, err := parse(goja.Undefined(), rt.ToValue(string(identity.Data)))
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	if mi.identities == nil {
+		mi.identities = make(map[identityKey]goja.Value)
+	}
+	mi.identities[key] = v
+	return v
+}
+
 // newVUInfo returns a goja.Object with property accessors to retrieve
 // information about the currently executing VU.
 func (mi *ModuleInstance) newVUInfo() (*goja.Object, error) {
@@ -211,6 +389,13 @@ func (mi *ModuleInstance) newVUInfo() (*goja.Object, error) {
 
 			return vuState.GetScenarioVUIter()
 		},
+		"identity": func() interface{} { return mi.getIdentity() },
+		"identityIndex": func() interface{} {
+			if vuState.Identity == nil {
+				return nil
+			}
+			return vuState.Identity.Index
+		},
 	}
 
 	o, err := newInfoObj(rt, vi)
@@ -236,6 +421,13 @@ func (mi *ModuleInstance) newVUInfo() (*goja.Object, error) {
 				state:   vuState,
 			})
 		},
+		"iterationMetadata": func() interface{} {
+			return rt.NewDynamicObject(&metadataDynamicObject{
+				runtime:   rt,
+				state:     vuState,
+				iteration: true,
+			})
+		},
 	})
 	if err != nil {
 		return o, err
@@ -382,13 +574,19 @@ func (o *tagsDynamicObject) Keys() []string {
 	return keys
 }
 
+// metadataDynamicObject is the metadata of the VU, or only the one of the
+// current iteration, which is deleted when it ends.
 type metadataDynamicObject struct {
-	runtime *goja.Runtime
-	state   *lib.State
+	runtime   *goja.Runtime
+	state     *lib.State
+	iteration bool
 }
 
 // Get a property value for the key. May return nil if the property does not exist.
 func (o *metadataDynamicObject) Get(key string) goja.Value {
+	if !o.visible(key) {
+		return nil
+	}
 	tcv := o.state.Tags.GetCurrentValues()
 	if metadatum, ok := tcv.Metadata[key]; ok {
 		return o.runtime.ToValue(metadatum)
@@ -405,11 +603,20 @@ func (o *metadataDynamicObject) Set(key string, val goja.Value) bool {
 			panic(o.runtime.NewTypeError(err.Error()))
 		}
 	})
+	if o.iteration {
+		if o.state.IterationMetadata == nil {
+			o.state.IterationMetadata = make(map[string]struct{})
+		}
+		o.state.IterationMetadata[key] = struct{}{}
+	}
 	return true
 }
 
 // Has returns true if the property exists.
 func (o *metadataDynamicObject) Has(key string) bool {
+	if !o.visible(key) {
+		return false
+	}
 	ctv := o.state.Tags.GetCurrentValues()
 	if _, ok := ctv.Metadata[key]; ok {
 		return true
@@ -423,6 +630,7 @@ func (o *metadataDynamicObject) Delete(key string) bool {
 	o.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
 		tagsAndMeta.DeleteMetadata(key)
 	})
+	delete(o.state.IterationMetadata, key)
 	return true
 }
 
@@ -433,7 +641,19 @@ func (o *metadataDynamicObject) Keys() []string {
 
 	keys := make([]string, 0, len(ctv.Metadata))
 	for k := range ctv.Metadata {
-		keys = append(keys, k)
+		if o.visible(k) {
+			keys = append(keys, k)
+		}
 	}
 	return keys
 }
+
+// visible returns if the metadata with the key is in the object, all of it
+// is in the one of the VU.
+func (o *metadataDynamicObject) visible(key string) bool {
+	if !o.iteration {
+		return true
+	}
+	_, ok := o.state.IterationMetadata[key]
+	return ok
+}
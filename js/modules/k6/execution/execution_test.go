@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modulestest"
 	"go.k6.io/k6/lib"
@@ -218,12 +219,217 @@ func TestAbortTest(t *testing.T) { //nolint:tparallel
 	t.Run("custom reason", func(t *testing.T) { //nolint:paralleltest
 		prove(t, `exec.test.abort("mayday")`, fmt.Sprintf("%s: mayday", errext.AbortTest))
 	})
+	t.Run("custom exit code", func(t *testing.T) { //nolint:paralleltest
+		_, err := rt.RunString(`exec.test.abort("mayday", { exitCode: 42 })`)
+		var x *goja.InterruptedError
+		require.ErrorAs(t, err, &x)
+		v, ok := x.Value().(*errext.InterruptError)
+		require.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("%s: mayday", errext.AbortTest), v.Reason)
+		assert.Equal(t, exitcodes.ExitCode(42), v.ExitCode())
+	})
+	t.Run("invalid exit code", func(t *testing.T) { //nolint:paralleltest
+		for _, code := range []string{"0", "256", "1.5", "'foo'"} {
+			_, err := rt.RunString(fmt.Sprintf(`exec.test.abort("mayday", { exitCode: %s })`, code))
+			require.ErrorContains(t, err, "the exit code must be an integer between 1 and 255")
+		}
+	})
+}
+
+func TestTestMetric(t *testing.T) {
+	t.Parallel()
+
+	newRuntime := func(t *testing.T, state *lib.State, es *lib.ExecutionState) *goja.Runtime {
+		rt := goja.New()
+		ctx := context.Background()
+		if es != nil {
+			ctx = lib.WithExecutionState(ctx, es)
+		}
+		vu := &modulestest.VU{RuntimeField: rt, CtxField: ctx, StateField: state}
+		if state == nil {
+			vu.InitEnvField = &common.InitEnvironment{}
+		}
+		m, ok := New().NewModuleInstance(vu).(*ModuleInstance)
+		require.True(t, ok)
+		require.NoError(t, rt.Set("exec", m.Exports().Default))
+		return rt
+	}
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+
+		es := &lib.ExecutionState{Test: &lib.TestRunState{
+			MetricValues: func(name string) (map[string]float64, bool) {
+				if name != "http_req_duration" {
+					return nil, false
+				}
+				return map[string]float64{"avg": 100, "p(95)": 250}, true
+			},
+		}}
+		rt := newRuntime(t, &lib.State{}, es)
+
+		value, err := rt.RunString(`exec.test.metric("http_req_duration")["p(95)"]`)
+		require.NoError(t, err)
+		assert.Equal(t, int64(250), value.Export())
+
+		value, err = rt.RunString(`exec.test.metric("unknown")`)
+		require.NoError(t, err)
+		assert.True(t, goja.IsNull(value))
+	})
+
+	t.Run("window", func(t *testing.T) {
+		t.Parallel()
+
+		registry := metrics.NewRegistry()
+		metric, err := registry.NewMetric("errors", metrics.Rate)
+		require.NoError(t, err)
+		var watched []string
+		es := &lib.ExecutionState{Test: &lib.TestRunState{
+			WatchMetric: func(name string, length time.Duration) (func() metrics.Sink, error) {
+				watched = append(watched, fmt.Sprintf("%s %s", name, length))
+				sink := metrics.NewSink(metric.Type)
+				sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: metric}, Value: 1})
+				sink.Add(metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: metric}, Value: 0})
+				return func() metrics.Sink { return sink }, nil
+			},
+		}}
+		rt := newRuntime(t, &lib.State{}, es)
+
+		value, err := rt.RunString(`exec.test.metric("errors", { window: "30s" }).rate`)
+		require.NoError(t, err)
+		assert.Equal(t, 0.5, value.Export())
+		assert.Equal(t, []string{"errors 30s"}, watched)
+
+		_, err = rt.RunString(`exec.test.metric("errors", { window: "-1s" })`)
+		require.ErrorContains(t, err, "the window of the metric must be positive")
+	})
+
+	t.Run("metrics not processed", func(t *testing.T) {
+		t.Parallel()
+
+		rt := newRuntime(t, &lib.State{}, &lib.ExecutionState{Test: &lib.TestRunState{}})
+		value, err := rt.RunString(`exec.test.metric("http_req_duration")`)
+		require.NoError(t, err)
+		assert.True(t, goja.IsNull(value))
+	})
+
+	t.Run("init context", func(t *testing.T) {
+		t.Parallel()
+
+		rt := newRuntime(t, nil, nil)
+		_, err := rt.RunString(`exec.test.metric("http_req_duration")`)
+		require.ErrorContains(t, err, "getting metric values in the init context is not supported")
+	})
+}
+
+func TestInstanceSegmentAndID(t *testing.T) {
+	t.Parallel()
+
+	segment, err := lib.NewExecutionSegmentFromString("1/2:1")
+	require.NoError(t, err)
+	et, err := lib.NewExecutionTuple(segment, nil)
+	require.NoError(t, err)
+
+	rt := goja.New()
+	ctx := lib.WithExecutionState(context.Background(), &lib.ExecutionState{
+		ExecutionTuple: et,
+		Test:           &lib.TestRunState{InstanceID: null.IntFrom(2)},
+	})
+	m, ok := New().NewModuleInstance(&modulestest.VU{RuntimeField: rt, CtxField: ctx}).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, rt.Set("exec", m.Exports().Default))
+
+	value, err := rt.RunString(`exec.instance.executionSegment + " " + exec.instance.id`)
+	require.NoError(t, err)
+	assert.Equal(t, "1/2:1 2", value.String())
+}
+
+func TestVUIterationMetadata(t *testing.T) {
+	t.Parallel()
+
+	tenv := setupTagsExecEnv(t)
+	tenv.MoveToVUContext(&lib.State{Tags: lib.NewVUStateTags(metrics.NewRegistry().RootTagSet())})
+	_, err := tenv.VU.Runtime().RunString(`
+		exec.vu.metrics.metadata.user = "vu";
+		exec.vu.metrics.iterationMetadata.order = "42";
+	`)
+	require.NoError(t, err)
+
+	state := tenv.VU.StateField
+	assert.Equal(t, map[string]string{"user": "vu", "order": "42"}, state.Tags.GetCurrentValues().Metadata)
+	assert.Equal(t, map[string]struct{}{"order": {}}, state.IterationMetadata)
+
+	value, err := tenv.VU.Runtime().RunString(`Object.keys(exec.vu.metrics.iterationMetadata).join(",")`)
+	require.NoError(t, err)
+	assert.Equal(t, "order", value.String())
+}
+
+func TestTestUpdateScenario(t *testing.T) {
+	t.Parallel()
+
+	newRuntime := func(t *testing.T, state *lib.State, es *lib.ExecutionState) *goja.Runtime {
+		rt := goja.New()
+		ctx := context.Background()
+		if es != nil {
+			ctx = lib.WithExecutionState(ctx, es)
+		}
+		vu := &modulestest.VU{RuntimeField: rt, CtxField: ctx, StateField: state}
+		if state == nil {
+			vu.InitEnvField = &common.InitEnvironment{}
+		}
+		m, ok := New().NewModuleInstance(vu).(*ModuleInstance)
+		require.True(t, ok)
+		require.NoError(t, rt.Set("exec", m.Exports().Default))
+		return rt
+	}
+
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+
+		var updates []string
+		es := &lib.ExecutionState{Test: &lib.TestRunState{
+			UpdateScenario: func(_ context.Context, name string, changes []byte) error {
+				if name != "soak" {
+					return fmt.Errorf("scenario '%s' doesn't exist", name)
+				}
+				updates = append(updates, string(changes))
+				return nil
+			},
+		}}
+		rt := newRuntime(t, &lib.State{}, es)
+
+		_, err := rt.RunString(`exec.test.updateScenario("soak", { rate: 100, duration: "1h" })`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{`{"duration":"1h","rate":100}`}, updates)
+
+		_, err = rt.RunString(`exec.test.updateScenario("unknown", { rate: 100 })`)
+		require.ErrorContains(t, err, "scenario 'unknown' doesn't exist")
+
+		_, err = rt.RunString(`exec.test.updateScenario("soak")`)
+		require.ErrorContains(t, err, "the changes of the scenario are required")
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		t.Parallel()
+
+		rt := newRuntime(t, &lib.State{}, &lib.ExecutionState{Test: &lib.TestRunState{}})
+		_, err := rt.RunString(`exec.test.updateScenario("soak", { rate: 100 })`)
+		require.ErrorContains(t, err, "the scenarios can't be updated in this test run")
+	})
+
+	t.Run("init context", func(t *testing.T) {
+		t.Parallel()
+
+		rt := newRuntime(t, nil, nil)
+		_, err := rt.RunString(`exec.test.updateScenario("soak", { rate: 100 })`)
+		require.ErrorContains(t, err, "updating scenarios in the init context is not supported")
+	})
 }
 
 func TestOptionsTestFull(t *testing.T) {
 	t.Parallel()
 
-	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","rps":100,"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","batch":15,"batchPerHost":5,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","systemTags":["iter","vu"],"tags":null,"metricSamplesBufferSize":8,"noCookiesReset":true,"discardResponseBodies":true,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
+	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","setup":null,"teardown":null,"iterationTimeout":null,"warmupIterations":null,"tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","setupDataMaxSize":null,"rps":100,"rateLimits":[{"host":"*.example.com","tags":null,"rps":10,"burst":null}],"faults":null,"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","batch":15,"batchPerHost":5,"maxConns":50,"maxConnsPerHost":10,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"tlsClientCerts":null,"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","summaryBreakdownTags":null,"scenarioBreakdown":null,"trendPrecision":0.005,"timeSeriesResolution":null,"counterRateWindows":null,"systemTags":["iter","vu"],"tags":null,"outputFilters":null,"outputAggregation":null,"cardinalityLimit":null,"cardinalityCollapseTags":null,"derivedMetrics":null,"baselineTolerances":null,"slos":null,"metricSamplesBufferSize":8,"noCookiesReset":true,"discardResponseBodies":true,"correlationID":null,"metadataHeaders":null,"httpCache":true,"captureRequests":null,"captureSlowerThan":null,"captureMaxSize":null,"harOut":null,"harSampleRate":null,"selfMetrics":null,"schedulingDelayBudget":null,"autoCalibrate":null,"maxMemory":null,"testName":null,"runId":null,"testVersion":null,"testEnvironment":null,"redact":null,"exitCodes":null,"localIPsSelect":null,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
 
 	var (
 		rt    = goja.New()
@@ -272,11 +478,15 @@ func TestOptionsTestFull(t *testing.T) {
 				Throw:                 null.BoolFrom(true),
 				NoCookiesReset:        null.BoolFrom(true),
 				DiscardResponseBodies: null.BoolFrom(true),
+				HTTPCache:             null.BoolFrom(true),
 				RPS:                   null.IntFrom(100),
+				RateLimits:            []lib.RateLimit{{Host: null.StringFrom("*.example.com"), RPS: null.IntFrom(10)}},
 				MaxRedirects:          null.IntFrom(3),
 				UserAgent:             null.StringFrom("k6-user-agent"),
 				Batch:                 null.IntFrom(15),
 				BatchPerHost:          null.IntFrom(5),
+				MaxConns:              null.IntFrom(50),
+				MaxConnsPerHost:       null.IntFrom(10),
 				SetupTimeout:          types.NullDurationFrom(1 * time.Minute),
 				TeardownTimeout:       types.NullDurationFrom(5 * time.Minute),
 				MinIterationDuration:  types.NullDurationFrom(10 * time.Second),
@@ -345,6 +555,7 @@ func TestOptionsTestFull(t *testing.T) {
 				},
 				SummaryTrendStats: []string{"avg", "min", "max"},
 				SummaryTimeUnit:   null.StringFrom("ms"),
+				TrendPrecision:    null.FloatFrom(0.005),
 				SystemTags: func() *metrics.SystemTagSet {
 					sysm := metrics.SystemTagSet(metrics.TagIter | metrics.TagVU)
 					return &sysm
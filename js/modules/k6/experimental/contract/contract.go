@@ -0,0 +1,291 @@
+// Package contract implements the k6/experimental/contract module, that
+// validates the responses of the tested system against JSON schemas or the
+// operations of an OpenAPI specification. The results are recorded as
+// checks, with a failing check for each violated constraint, so the contract
+// can be validated under load without JS validators in each VU.
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/jsonschema"
+	"go.k6.io/k6/metrics"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the JS module.
+	ModuleInstance struct {
+		vu modules.VU
+	}
+)
+
+// Ensure the interfaces are implemented correctly
+var (
+	_ modules.Instance = &ModuleInstance{}
+	_ modules.Module   = &RootModule{}
+)
+
+// ErrValidateInInitContext is returned when a response is validated in the
+// init context, where there aren't any checks.
+var ErrValidateInInitContext = common.NewInitContextError("Validating in the init context is not supported")
+
+// New returns a pointer to a new RootModule instance
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu}
+}
+
+// Exports implements the modules.Instance interface and returns
+// the exports of the JS module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]interface{}{
+			"validate": mi.validate,
+			"openapi":  mi.openapi,
+		},
+	}
+}
+
+// validate validates a value, or the JSON body of an HTTP response or the
+// message of a gRPC one, against the JSON schema. The optional name is the
+// name of the check, which is "matches the schema" by default.
+func (mi *ModuleInstance) validate(value, schema goja.Value, name goja.Value) bool {
+	rt := mi.vu.Runtime()
+	if common.IsNullish(schema) {
+		common.Throw(rt, errors.New("the schema to validate against is required"))
+	}
+	s, err := exportJSON(schema)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid schema: %w", err))
+	}
+
+	checkName := "matches the schema"
+	if !common.IsNullish(name) {
+		checkName = name.String()
+	}
+	v, violation := responseValue(rt, value)
+	violations := []jsonschema.Violation{}
+	if violation != nil {
+		violations = append(violations, *violation)
+	} else {
+		violations = jsonschema.Validate(s, v)
+	}
+	return mi.record(checkName, violations)
+}
+
+// openapi parses an OpenAPI 3 or Swagger 2 specification, in the YAML or the
+// JSON format or as an object, whose operations the responses can be
+// validated against.
+func (mi *ModuleInstance) openapi(spec goja.Value) *goja.Object {
+	rt := mi.vu.Runtime()
+	if common.IsNullish(spec) {
+		common.Throw(rt, errors.New("the OpenAPI specification is required"))
+	}
+
+	var s *openAPISpec
+	var err error
+	if data, ok := spec.Export().(string); ok {
+		s, err = parseOpenAPI([]byte(data))
+	} else {
+		var doc interface{}
+		if doc, err = exportJSON(spec); err == nil {
+			s, err = newOpenAPISpec(doc)
+		}
+	}
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	obj := rt.NewObject()
+	if err := obj.Set("validate", func(res goja.Value, operation goja.Value) bool {
+		return mi.validateOperation(s, res, operation)
+	}); err != nil {
+		common.Throw(rt, err)
+	}
+	return obj
+}
+
+// validateOperation validates the HTTP response against its operation in the
+// specification, which is found by the method and the URL of its request,
+// unless it's given as "METHOD /path" or its operationId.
+func (mi *ModuleInstance) validateOperation(s *openAPISpec, res goja.Value, operation goja.Value) bool {
+	rt := mi.vu.Runtime()
+	if common.IsNullish(res) {
+		common.Throw(rt, errors.New("the response to validate is required"))
+	}
+	resObj := res.ToObject(rt)
+
+	var op *openAPIOperation
+	var err error
+	if common.IsNullish(operation) {
+		request := resObj.Get("request")
+		if common.IsNullish(request) {
+			common.Throw(rt, errors.New("the operation has to be specified for a response without its request"))
+		}
+		reqObj := request.ToObject(rt)
+		op, err = s.findByRequest(reqObj.Get("method").String(), reqObj.Get("url").String())
+	} else {
+		op, err = s.find(operation.String())
+	}
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	status := int(resObj.Get("status").ToInteger())
+	var contentType string
+	if headers := resObj.Get("headers"); !common.IsNullish(headers) {
+		if h, ok := headers.Export().(map[string]string); ok {
+			contentType = h["Content-Type"]
+		}
+	}
+
+	schema, violation := op.responseSchema(status, contentType)
+	var violations []jsonschema.Violation
+	switch {
+	case violation != nil:
+		violations = []jsonschema.Violation{*violation}
+	case schema != nil:
+		body, bodyViolation := responseValue(rt, res)
+		if bodyViolation != nil {
+			violations = []jsonschema.Violation{*bodyViolation}
+		} else {
+			violations = s.validator.Validate(schema, body)
+		}
+	}
+	return mi.record(op.name(), violations)
+}
+
+// arrayIndex matches the indexes in the paths of the violations, which are
+// left out of the names of their checks, so there is one check for each
+// constraint of the schema instead of for each invalid value.
+var arrayIndex = regexp.MustCompile(`\[\d+\]`)
+
+// record records the validation as a check with the name, and a failed check
+// for each violation, named after its path and the keyword of its constraint.
+func (mi *ModuleInstance) record(name string, violations []jsonschema.Violation) bool {
+	state := mi.vu.State()
+	if state == nil {
+		common.Throw(mi.vu.Runtime(), ErrValidateInInitContext)
+	}
+
+	mi.recordCheck(name, len(violations) == 0)
+	recorded := make(map[string]bool, len(violations))
+	for _, v := range violations {
+		state.Logger.WithField("check", name).Debugf("Contract violation: %s", v.Message)
+		checkName := fmt.Sprintf("%s: %s %s", name, arrayIndex.ReplaceAllString(v.Path, "[*]"), v.Keyword)
+		if !recorded[checkName] {
+			recorded[checkName] = true
+			mi.recordCheck(checkName, false)
+		}
+	}
+	return len(violations) == 0
+}
+
+func (mi *ModuleInstance) recordCheck(name string, pass bool) {
+	state := mi.vu.State()
+	check, err := state.Group.Check(name)
+	if err != nil {
+		common.Throw(mi.vu.Runtime(), err)
+	}
+
+	ctm := state.Tags.GetCurrentValues()
+	tags := ctm.Tags
+	if state.Options.SystemTags.Has(metrics.TagCheck) {
+		tags = tags.With("check", check.Name)
+	}
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: state.BuiltinMetrics.Checks, Tags: tags},
+		Time:       time.Now(),
+		Metadata:   ctm.Metadata,
+	}
+	if pass {
+		atomic.AddInt64(&check.Passes, 1)
+		sample.Value = 1
+	} else {
+		atomic.AddInt64(&check.Fails, 1)
+	}
+	metrics.PushIfNotDone(mi.vu.Context(), state.Samples, sample)
+}
+
+// responseValue returns the value that is validated: the parsed JSON body of
+// an HTTP response, the message of a gRPC response, or the value itself.
+func responseValue(rt *goja.Runtime, value goja.Value) (interface{}, *jsonschema.Violation) {
+	if obj, ok := value.(*goja.Object); ok {
+		if body := obj.Get("body"); body != nil && obj.Get("status") != nil {
+			return parseBody(body)
+		}
+		if message := obj.Get("message"); message != nil && obj.Get("status") != nil {
+			value = message
+		}
+	}
+	v, err := exportJSON(value)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return v, nil
+}
+
+func parseBody(body goja.Value) (interface{}, *jsonschema.Violation) {
+	var data []byte
+	switch b := body.Export().(type) {
+	case string:
+		data = []byte(b)
+	case []byte:
+		data = b
+	case goja.ArrayBuffer:
+		data = b.Bytes()
+	case nil:
+	default:
+		return nil, &jsonschema.Violation{Path: "$", Keyword: "body", Message: "$ isn't a JSON body"}
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, &jsonschema.Violation{
+			Path: "$", Keyword: "body", Message: fmt.Sprintf("$ isn't a valid JSON body: %s", err),
+		}
+	}
+	return v, nil
+}
+
+// exportJSON returns the JS value like JSON.parse(JSON.stringify(v)) would.
+func exportJSON(v goja.Value) (interface{}, error) {
+	if common.IsNullish(v) {
+		return nil, nil //nolint:nilnil
+	}
+	data, err := json.Marshal(v.Export())
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mediaType returns the media type of a Content-Type header, without its
+// parameters like the charset.
+func mediaType(contentType string) string {
+	mt, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mt))
+}
@@ -0,0 +1,270 @@
+package contract
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+type testCase struct {
+	samples     chan metrics.SampleContainer
+	testRuntime *modulestest.Runtime
+	state       *lib.State
+}
+
+func testCaseRuntime(t testing.TB) *testCase {
+	testRuntime := modulestest.NewRuntime(t)
+	mi, ok := New().NewModuleInstance(testRuntime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, testRuntime.VU.RuntimeField.Set("contract", mi.Exports().Named))
+
+	registry := metrics.NewRegistry()
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	logger, _ := test.NewNullLogger()
+	samples := make(chan metrics.SampleContainer, 1000)
+	state := &lib.State{
+		Group: root,
+		Options: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+		},
+		Samples:        samples,
+		Tags:           lib.NewVUStateTags(registry.RootTagSet().WithTagsFromMap(map[string]string{"group": root.Path})),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Logger:         logger,
+	}
+	testRuntime.MoveToVUContext(state)
+
+	return &testCase{samples: samples, testRuntime: testRuntime, state: state}
+}
+
+func (tc *testCase) checks(t *testing.T) map[string][2]int64 {
+	t.Helper()
+	checks := make(map[string][2]int64, len(tc.state.Group.Checks))
+	for name, check := range tc.state.Group.Checks {
+		checks[name] = [2]int64{check.Passes, check.Fails}
+	}
+	return checks
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tcr := testCaseRuntime(t)
+
+	v, err := tcr.testRuntime.VU.Runtime().RunString(`
+		const schema = {
+			type: "object",
+			required: ["id"],
+			properties: {
+				id: { type: "integer" },
+				tags: { type: "array", items: { type: "string" } },
+			},
+		};
+		[
+			contract.validate({ id: 1, tags: ["a"] }, schema),
+			contract.validate({ status: 200, body: '{"tags": ["a", 1, 2]}' }, schema, "user"),
+			contract.validate({ status: 200, body: "<html>" }, schema, "page"),
+			contract.validate({ status: 0, message: { id: 2 } }, schema, "grpc"),
+		]
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{true, false, false, true}, v.Export())
+
+	assert.Equal(t, map[string][2]int64{
+		"matches the schema":   {1, 0},
+		"user":                 {0, 1},
+		"user: $.id required":  {0, 1},
+		"user: $.tags[*] type": {0, 1},
+		"page":                 {0, 1},
+		"page: $ body":         {0, 1},
+		"grpc":                 {1, 0},
+	}, tcr.checks(t))
+
+	samples := metrics.GetBufferedSamples(tcr.samples)
+	assert.Len(t, samples, 7)
+}
+
+const spec = `
+openapi: 3.0.0
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      responses:
+        "200":
+          description: The user
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+        "404":
+          $ref: "#/components/responses/NotFound"
+  /users/me:
+    get:
+      responses:
+        "2XX":
+          description: The current user
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+          nullable: true
+  responses:
+    NotFound:
+      description: Not found
+      content:
+        application/json:
+          schema:
+            type: object
+            required: [error]
+`
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		code   string
+		pass   bool
+		checks map[string][2]int64
+	}{
+		{
+			name: "valid",
+			code: `api.validate({
+				status: 200, body: '{"id": 1, "name": null}',
+				headers: { "Content-Type": "application/json; charset=utf-8" },
+				request: { method: "GET", url: "https://api.example.com/v1/users/1" },
+			})`,
+			pass:   true,
+			checks: map[string][2]int64{"GET /users/{id} conforms to the spec": {1, 0}},
+		},
+		{
+			name: "invalid",
+			code: `api.validate({
+				status: 200, body: '{"id": "1"}',
+				request: { method: "GET", url: "https://api.example.com/v1/users/1" },
+			})`,
+			checks: map[string][2]int64{
+				"GET /users/{id} conforms to the spec":                  {0, 1},
+				"GET /users/{id} conforms to the spec: $.id type":       {0, 1},
+				"GET /users/{id} conforms to the spec: $.name required": {0, 1},
+			},
+		},
+		{
+			name: "response ref",
+			code: `api.validate({ status: 404, body: '{}' }, "getUser")`,
+			checks: map[string][2]int64{
+				"GET /users/{id} conforms to the spec":                   {0, 1},
+				"GET /users/{id} conforms to the spec: $.error required": {0, 1},
+			},
+		},
+		{
+			name: "undocumented status",
+			code: `api.validate({ status: 500, body: '{}' }, "GET /users/{id}")`,
+			checks: map[string][2]int64{
+				"GET /users/{id} conforms to the spec":           {0, 1},
+				"GET /users/{id} conforms to the spec: $ status": {0, 1},
+			},
+		},
+		{
+			name: "status range and path without templates",
+			code: `api.validate({
+				status: 201, body: '{"id": 1, "name": "me"}',
+				request: { method: "GET", url: "https://api.example.com/v1/users/me?x=1" },
+			})`,
+			pass:   true,
+			checks: map[string][2]int64{"GET /users/me conforms to the spec": {1, 0}},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tcr := testCaseRuntime(t)
+			rt := tcr.testRuntime.VU.Runtime()
+			require.NoError(t, rt.Set("spec", spec))
+
+			v, err := rt.RunString(`const api = contract.openapi(spec); ` + tc.code)
+			require.NoError(t, err)
+			assert.Equal(t, tc.pass, v.ToBoolean())
+			assert.Equal(t, tc.checks, tcr.checks(t))
+		})
+	}
+}
+
+func TestOpenAPIErrors(t *testing.T) {
+	t.Parallel()
+	tcr := testCaseRuntime(t)
+	rt := tcr.testRuntime.VU.Runtime()
+	require.NoError(t, rt.Set("spec", spec))
+
+	_, err := rt.RunString(`contract.openapi(spec).validate({ status: 200, body: "{}" }, "deleteUser")`)
+	require.ErrorContains(t, err, "the operation 'deleteUser' isn't in the OpenAPI specification")
+
+	_, err = rt.RunString(`contract.openapi(spec).validate({
+		status: 200, body: "{}", request: { method: "POST", url: "https://api.example.com/v1/users/1" },
+	})`)
+	require.ErrorContains(t, err, "there isn't an operation for POST /v1/users/1 in the OpenAPI specification")
+
+	_, err = rt.RunString(`contract.openapi({ info: {} })`)
+	require.ErrorContains(t, err, "it doesn't have an openapi or a swagger version")
+}
+
+func TestSwagger(t *testing.T) {
+	t.Parallel()
+	tcr := testCaseRuntime(t)
+
+	v, err := tcr.testRuntime.VU.Runtime().RunString(`
+		const api = contract.openapi({
+			swagger: "2.0",
+			basePath: "/api",
+			paths: {
+				"/items": {
+					get: {
+						responses: {
+							200: { description: "Items", schema: { type: "array", items: { type: "integer" } } },
+						},
+					},
+				},
+			},
+		});
+		api.validate({ status: 200, body: "[1, 2, 3.5]", request: { method: "GET", url: "http://localhost/api/items" } })
+	`)
+	require.NoError(t, err)
+	assert.False(t, v.ToBoolean())
+	assert.Equal(t, map[string][2]int64{
+		"GET /items conforms to the spec":            {0, 1},
+		"GET /items conforms to the spec: $[*] type": {0, 1},
+	}, tcr.checks(t))
+}
+
+func TestValidateInInitContext(t *testing.T) {
+	t.Parallel()
+
+	testRuntime := modulestest.NewRuntime(t)
+	mi, ok := New().NewModuleInstance(testRuntime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, testRuntime.VU.RuntimeField.Set("contract", mi.Exports().Named))
+
+	_, err := testRuntime.VU.Runtime().RunString(`contract.validate(1, { type: "integer" })`)
+	require.ErrorContains(t, err, "Validating in the init context is not supported")
+}
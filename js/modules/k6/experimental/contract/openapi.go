@@ -0,0 +1,241 @@
+package contract
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"go.k6.io/k6/lib/jsonschema"
+)
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPISpec is an OpenAPI 3 or Swagger 2 specification, whose operations
+// the responses are validated against.
+type openAPISpec struct {
+	doc        map[string]interface{}
+	validator  *jsonschema.Validator
+	swagger    bool
+	basePaths  []string
+	operations []*openAPIOperation
+}
+
+// openAPIOperation is an operation of the specification, like GET /users/{id}
+type openAPIOperation struct {
+	spec        *openAPISpec
+	method      string
+	path        string
+	segments    []string
+	operationID string
+	responses   map[string]interface{}
+}
+
+// parseOpenAPI parses a specification in the YAML or the JSON format.
+func parseOpenAPI(data []byte) (*openAPISpec, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("couldn't parse the OpenAPI specification: %w", err)
+	}
+	// The YAML values are normalized by a round trip through JSON, so the
+	// numbers are float64 and the maps are keyed by strings, as the schemas
+	// are validated in that form.
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse the OpenAPI specification: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(jsonData, &doc); err != nil {
+		return nil, fmt.Errorf("couldn't parse the OpenAPI specification: %w", err)
+	}
+	return newOpenAPISpec(doc)
+}
+
+func newOpenAPISpec(doc interface{}) (*openAPISpec, error) {
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid OpenAPI specification, it has to be an object")
+	}
+	s := &openAPISpec{doc: root, validator: jsonschema.NewValidator(root)}
+
+	switch {
+	case root["swagger"] != nil:
+		s.swagger = true
+		if basePath, ok := root["basePath"].(string); ok {
+			s.basePaths = append(s.basePaths, strings.TrimSuffix(basePath, "/"))
+		}
+	case root["openapi"] != nil:
+		servers, _ := root["servers"].([]interface{})
+		for _, server := range servers {
+			serverURL, _ := server.(map[string]interface{})["url"].(string)
+			if u, err := url.Parse(serverURL); err == nil && u.Path != "" {
+				s.basePaths = append(s.basePaths, strings.TrimSuffix(u.Path, "/"))
+			}
+		}
+	default:
+		return nil, errors.New("invalid OpenAPI specification, it doesn't have an openapi or a swagger version")
+	}
+
+	paths, _ := root["paths"].(map[string]interface{})
+	for path, item := range paths {
+		pathItem, _ := item.(map[string]interface{})
+		for _, method := range httpMethods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			responses, _ := s.deref(operation["responses"]).(map[string]interface{})
+			operationID, _ := operation["operationId"].(string)
+			s.operations = append(s.operations, &openAPIOperation{
+				spec:        s,
+				method:      strings.ToUpper(method),
+				path:        path,
+				segments:    strings.Split(strings.Trim(path, "/"), "/"),
+				operationID: operationID,
+				responses:   responses,
+			})
+		}
+	}
+	// The paths without templates are matched first, so /users/me is
+	// preferred over /users/{id}, as OpenAPI requires.
+	sort.SliceStable(s.operations, func(i, j int) bool {
+		ti, tj := strings.Count(s.operations[i].path, "{"), strings.Count(s.operations[j].path, "{")
+		if ti != tj {
+			return ti < tj
+		}
+		return s.operations[i].path < s.operations[j].path
+	})
+	return s, nil
+}
+
+// find returns the operation with the operationId or the "METHOD /path".
+func (s *openAPISpec) find(operation string) (*openAPIOperation, error) {
+	method, path, hasPath := strings.Cut(strings.TrimSpace(operation), " ")
+	for _, op := range s.operations {
+		if op.operationID == operation {
+			return op, nil
+		}
+		if hasPath && op.method == strings.ToUpper(method) && op.path == strings.TrimSpace(path) {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("the operation '%s' isn't in the OpenAPI specification", operation)
+}
+
+// findByRequest returns the operation whose path template matches the URL of
+// the request, without the base path of the API.
+func (s *openAPISpec) findByRequest(method, rawURL string) (*openAPIOperation, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request URL '%s': %w", rawURL, err)
+	}
+	method = strings.ToUpper(method)
+
+	paths := []string{u.Path}
+	for _, basePath := range s.basePaths {
+		if p, ok := strings.CutPrefix(u.Path, basePath); ok && basePath != "" {
+			paths = append([]string{p}, paths...)
+		}
+	}
+	for _, path := range paths {
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+		for _, op := range s.operations {
+			if op.method == method && op.matches(segments) {
+				return op, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("there isn't an operation for %s %s in the OpenAPI specification", method, u.Path)
+}
+
+// deref returns the object that a $ref links to, or the value itself.
+func (s *openAPISpec) deref(v interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return v
+		}
+		pointer, ok := strings.CutPrefix(ref, "#/")
+		if !ok {
+			return v
+		}
+		var current interface{} = s.doc
+		for _, token := range strings.Split(pointer, "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			m, _ := current.(map[string]interface{})
+			current = m[token]
+		}
+		v = current
+	}
+	return v
+}
+
+func (op *openAPIOperation) name() string {
+	return fmt.Sprintf("%s %s conforms to the spec", op.method, op.path)
+}
+
+func (op *openAPIOperation) matches(segments []string) bool {
+	if len(segments) != len(op.segments) {
+		return false
+	}
+	for i, segment := range op.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// responseSchema returns the schema of the response body for the status and
+// the content type, or nil if the body isn't documented. A violation is
+// returned if the status isn't documented.
+func (op *openAPIOperation) responseSchema(status int, contentType string) (interface{}, *jsonschema.Violation) {
+	code := strconv.Itoa(status)
+	response, ok := op.responses[code]
+	if !ok && len(code) == 3 {
+		response, ok = op.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		response, ok = op.responses["default"]
+	}
+	if !ok {
+		return nil, &jsonschema.Violation{
+			Path: "$", Keyword: "status",
+			Message: fmt.Sprintf("the status %d isn't documented for %s %s", status, op.method, op.path),
+		}
+	}
+
+	res, _ := op.spec.deref(response).(map[string]interface{})
+	if op.spec.swagger {
+		return res["schema"], nil
+	}
+	content, _ := res["content"].(map[string]interface{})
+	if len(content) == 0 {
+		return nil, nil
+	}
+	if media, ok := content[mediaType(contentType)].(map[string]interface{}); ok {
+		return media["schema"], nil
+	}
+	if media, ok := content["application/json"].(map[string]interface{}); ok {
+		return media["schema"], nil
+	}
+	return nil, &jsonschema.Violation{
+		Path: "$", Keyword: "content-type",
+		Message: fmt.Sprintf("the content type '%s' isn't documented for %s %s", contentType, op.method, op.path),
+	}
+}
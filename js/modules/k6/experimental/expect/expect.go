@@ -0,0 +1,394 @@
+// Package expect implements the k6/experimental/expect module, with typed
+// assertions whose results are recorded as checks. A failed assertion with
+// the fail severity can also abort the iteration, while one with the warn
+// severity is only logged.
+package expect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/jsonschema"
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	severityFail = "fail"
+	severityWarn = "warn"
+
+	// severityTag is the tag of the check samples with the severity of the
+	// assertion, so the thresholds can only take the failing ones into account.
+	severityTag = "severity"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the JS module.
+	ModuleInstance struct {
+		vu       modules.VU
+		defaults options
+	}
+)
+
+// Ensure the interfaces are implemented correctly
+var (
+	_ modules.Instance = &ModuleInstance{}
+	_ modules.Module   = &RootModule{}
+)
+
+// ErrExpectInInitContext is returned when an assertion is made in the init
+// context, where there aren't any checks.
+var ErrExpectInInitContext = common.NewInitContextError("Using expect in the init context is not supported")
+
+// New returns a pointer to a new RootModule instance
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu, defaults: options{severity: severityFail}}
+}
+
+// Exports implements the modules.Instance interface and returns
+// the exports of the JS module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	rt := mi.vu.Runtime()
+	expect := rt.ToValue(mi.expect).ToObject(rt)
+	if err := expect.Set("configure", mi.configure); err != nil {
+		common.Throw(rt, err)
+	}
+	return modules.Exports{
+		Named: map[string]interface{}{
+			"expect": expect,
+		},
+	}
+}
+
+// options are the options of the assertions, they can be configured for all
+// of them in the VU or for each one.
+type options struct {
+	name           string
+	severity       string
+	abortIteration bool
+}
+
+// parse overwrites the options with the ones in the JS object.
+func (o *options) parse(rt *goja.Runtime, v goja.Value) error {
+	obj := v.ToObject(rt)
+	for _, key := range obj.Keys() {
+		value := obj.Get(key)
+		switch key {
+		case "name":
+			o.name = value.String()
+		case "severity":
+			severity := value.String()
+			if severity != severityFail && severity != severityWarn {
+				return fmt.Errorf("invalid severity '%s', it can be %s or %s", severity, severityFail, severityWarn)
+			}
+			o.severity = severity
+		case "abortIteration":
+			o.abortIteration = value.ToBoolean()
+		default:
+			return fmt.Errorf("unknown option '%s'", key)
+		}
+	}
+	return nil
+}
+
+// configure changes the default options of the assertions in the VU.
+func (mi *ModuleInstance) configure(opts goja.Value) {
+	rt := mi.vu.Runtime()
+	defaults := mi.defaults
+	if err := defaults.parse(rt, opts); err != nil {
+		common.Throw(rt, fmt.Errorf("invalid expect options: %w", err))
+	}
+	if defaults.name != "" {
+		common.Throw(rt, errors.New("invalid expect options: the name can only be set for each assertion"))
+	}
+	mi.defaults = defaults
+}
+
+// expect starts an assertion on the value. The second argument is either the
+// name of the check, or an object with it and the severity and abortIteration
+// options.
+func (mi *ModuleInstance) expect(value goja.Value, opts goja.Value) *goja.Object {
+	rt := mi.vu.Runtime()
+	a := &assertion{mi: mi, value: value, options: mi.defaults}
+	switch {
+	case common.IsNullish(opts):
+	case opts.ExportType().Kind() == reflect.String:
+		a.options.name = opts.String()
+	default:
+		if err := a.options.parse(rt, opts); err != nil {
+			common.Throw(rt, fmt.Errorf("invalid expect options: %w", err))
+		}
+	}
+
+	obj := a.object()
+	negated := *a
+	negated.negate = true
+	if err := obj.Set("not", negated.object()); err != nil {
+		common.Throw(rt, err)
+	}
+	return obj
+}
+
+// assertion is an assertion on a value, its matchers record the result as a
+// check and return whether it passed.
+type assertion struct {
+	mi      *ModuleInstance
+	value   goja.Value
+	options options
+	negate  bool
+}
+
+func (a *assertion) object() *goja.Object {
+	rt := a.mi.vu.Runtime()
+	obj := rt.NewObject()
+	matchers := map[string]interface{}{
+		"toBe":            a.toBe,
+		"toEqual":         a.toEqual,
+		"toBeTruthy":      a.toBeTruthy,
+		"toBeCloseTo":     a.toBeCloseTo,
+		"toBeGreaterThan": a.compare("toBeGreaterThan", "more than", func(x, y float64) bool { return x > y }),
+		"toBeGreaterThanOrEqual": a.compare("toBeGreaterThanOrEqual", "at least",
+			func(x, y float64) bool { return x >= y }),
+		"toBeLessThan":        a.compare("toBeLessThan", "less than", func(x, y float64) bool { return x < y }),
+		"toBeLessThanOrEqual": a.compare("toBeLessThanOrEqual", "at most", func(x, y float64) bool { return x <= y }),
+		"toBeBetween":         a.toBeBetween,
+		"toContain":           a.toContain,
+		"toMatch":             a.toMatch,
+		"toMatchSchema":       a.toMatchSchema,
+	}
+	for name, matcher := range matchers {
+		if err := obj.Set(name, matcher); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	return obj
+}
+
+func (a *assertion) toBe(expected goja.Value) bool {
+	return a.record("toBe", []goja.Value{expected}, a.value.StrictEquals(expected),
+		"to be "+describe(expected))
+}
+
+func (a *assertion) toEqual(expected goja.Value) bool {
+	actual, err := normalize(a.value)
+	a.throwIf(err)
+	exp, err := normalize(expected)
+	a.throwIf(err)
+	return a.record("toEqual", []goja.Value{expected}, reflect.DeepEqual(actual, exp),
+		"to equal "+describe(expected))
+}
+
+func (a *assertion) toBeTruthy() bool {
+	return a.record("toBeTruthy", nil, a.value.ToBoolean(), "to be truthy")
+}
+
+func (a *assertion) toBeCloseTo(expected, tolerance goja.Value) bool {
+	if common.IsNullish(tolerance) {
+		common.Throw(a.mi.vu.Runtime(), errors.New("toBeCloseTo needs the tolerance of the expected value"))
+	}
+	exp, tol := expected.ToFloat(), tolerance.ToFloat()
+	pass := isNumber(a.value) && math.Abs(a.value.ToFloat()-exp) <= tol
+	return a.record("toBeCloseTo", []goja.Value{expected, tolerance}, pass,
+		fmt.Sprintf("to be %v ± %v", exp, tol))
+}
+
+func (a *assertion) compare(matcher, description string, cmp func(x, y float64) bool) func(goja.Value) bool {
+	return func(expected goja.Value) bool {
+		pass := isNumber(a.value) && cmp(a.value.ToFloat(), expected.ToFloat())
+		return a.record(matcher, []goja.Value{expected}, pass, "to be "+description+" "+describe(expected))
+	}
+}
+
+func (a *assertion) toBeBetween(minValue, maxValue goja.Value) bool {
+	v := a.value.ToFloat()
+	pass := isNumber(a.value) && v >= minValue.ToFloat() && v <= maxValue.ToFloat()
+	return a.record("toBeBetween", []goja.Value{minValue, maxValue}, pass,
+		fmt.Sprintf("to be between %s and %s", describe(minValue), describe(maxValue)))
+}
+
+func (a *assertion) toContain(item goja.Value) bool {
+	var pass bool
+	switch actual := a.value.Export().(type) {
+	case string:
+		pass = strings.Contains(actual, item.String())
+	case []interface{}:
+		exp, err := normalize(item)
+		a.throwIf(err)
+		for _, e := range actual {
+			v, err := normalize(a.mi.vu.Runtime().ToValue(e))
+			a.throwIf(err)
+			if reflect.DeepEqual(v, exp) {
+				pass = true
+				break
+			}
+		}
+	}
+	return a.record("toContain", []goja.Value{item}, pass, "to contain "+describe(item))
+}
+
+// toMatch checks a string against a RegExp or a Go regular expression.
+func (a *assertion) toMatch(pattern goja.Value) bool {
+	rt := a.mi.vu.Runtime()
+	var pass bool
+	if obj, ok := pattern.(*goja.Object); ok && obj.ClassName() == "RegExp" {
+		test, _ := goja.AssertFunction(obj.Get("test"))
+		result, err := test(obj, a.value)
+		a.throwIf(err)
+		pass = result.ToBoolean()
+	} else {
+		re, err := regexp.Compile(pattern.String())
+		if err != nil {
+			common.Throw(rt, fmt.Errorf("invalid pattern: %w", err))
+		}
+		pass = re.MatchString(a.value.String())
+	}
+	return a.record("toMatch", []goja.Value{pattern}, pass, "to match "+pattern.String())
+}
+
+// toMatchSchema validates the value against a JSON schema, with the subset of
+// its keywords that the jsonschema package supports.
+func (a *assertion) toMatchSchema(schema goja.Value) bool {
+	s, err := normalize(schema)
+	a.throwIf(err)
+	v, err := normalize(a.value)
+	a.throwIf(err)
+	violations := jsonschema.Validate(s, v)
+	message := "to match the schema"
+	if len(violations) > 0 {
+		message += ": " + violations[0].Message
+	}
+	return a.record("toMatchSchema", nil, len(violations) == 0, message)
+}
+
+func (a *assertion) throwIf(err error) {
+	if err != nil {
+		common.Throw(a.mi.vu.Runtime(), err)
+	}
+}
+
+// record records the result of the matcher as a check, with the severity of
+// the assertion as a tag. If the assertion failed, it's logged when it has
+// the warn severity and the iteration is aborted when it has the fail one and
+// abortIteration is enabled.
+func (a *assertion) record(matcher string, args []goja.Value, pass bool, expectation string) bool {
+	rt := a.mi.vu.Runtime()
+	state := a.mi.vu.State()
+	if state == nil {
+		common.Throw(rt, ErrExpectInInitContext)
+	}
+	if a.negate {
+		pass = !pass
+		matcher = "not." + matcher
+		expectation = "not " + expectation
+	}
+
+	name := a.options.name
+	if name == "" {
+		name = defaultCheckName(matcher, args)
+	}
+	check, err := state.Group.Check(name)
+	a.throwIf(err)
+
+	ctm := state.Tags.GetCurrentValues()
+	tags := ctm.Tags.With(severityTag, a.options.severity)
+	if state.Options.SystemTags.Has(metrics.TagCheck) {
+		tags = tags.With("check", check.Name)
+	}
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: state.BuiltinMetrics.Checks, Tags: tags},
+		Time:       time.Now(),
+		Metadata:   ctm.Metadata,
+	}
+	if pass {
+		atomic.AddInt64(&check.Passes, 1)
+		sample.Value = 1
+	} else {
+		atomic.AddInt64(&check.Fails, 1)
+	}
+	metrics.PushIfNotDone(a.mi.vu.Context(), state.Samples, sample)
+
+	if pass {
+		return true
+	}
+	message := fmt.Sprintf("%s: expected %s %s", name, describe(a.value), expectation)
+	switch {
+	case a.options.severity == severityWarn:
+		state.Logger.Warn(message)
+	case a.options.abortIteration:
+		common.Throw(rt, errors.New(message))
+	}
+	return false
+}
+
+// defaultCheckName is the name of the check of an assertion without one, it
+// includes the arguments of the matcher unless they are objects, so the
+// number of checks stays low.
+func defaultCheckName(matcher string, args []goja.Value) string {
+	described := make([]string, len(args))
+	for i, arg := range args {
+		if _, ok := arg.(*goja.Object); ok {
+			described[i] = "..."
+		} else {
+			described[i] = describe(arg)
+		}
+	}
+	return fmt.Sprintf("expect(...).%s(%s)", matcher, strings.Join(described, ", "))
+}
+
+func isNumber(v goja.Value) bool {
+	switch v.Export().(type) {
+	case int64, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalize returns the value like JSON.parse(JSON.stringify(v)) would, so
+// the values can be compared regardless of their Go types.
+func normalize(v goja.Value) (interface{}, error) {
+	if common.IsNullish(v) {
+		return nil, nil //nolint:nilnil
+	}
+	data, err := json.Marshal(v.Export())
+	if err != nil {
+		return nil, fmt.Errorf("the value can't be compared: %w", err)
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("the value can't be compared: %w", err)
+	}
+	return result, nil
+}
+
+// describe returns a short description of the value for the messages.
+func describe(v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	data, err := json.Marshal(v.Export())
+	if err != nil {
+		return v.String()
+	}
+	return jsonschema.Describe(json.RawMessage(data))
+}
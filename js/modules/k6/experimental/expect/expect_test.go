@@ -0,0 +1,206 @@
+package expect
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+type testCase struct {
+	samples     chan metrics.SampleContainer
+	testRuntime *modulestest.Runtime
+	state       *lib.State
+	logHook     *test.Hook
+}
+
+func testCaseRuntime(t testing.TB) *testCase {
+	testRuntime := modulestest.NewRuntime(t)
+	mi, ok := New().NewModuleInstance(testRuntime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, testRuntime.VU.RuntimeField.Set("expect", mi.Exports().Named["expect"]))
+
+	registry := metrics.NewRegistry()
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	logger, logHook := test.NewNullLogger()
+	logger.SetLevel(logrus.WarnLevel)
+	samples := make(chan metrics.SampleContainer, 1000)
+	state := &lib.State{
+		Group: root,
+		Options: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+		},
+		Samples:        samples,
+		Tags:           lib.NewVUStateTags(registry.RootTagSet().WithTagsFromMap(map[string]string{"group": root.Path})),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Logger:         logger,
+	}
+	testRuntime.MoveToVUContext(state)
+
+	return &testCase{samples: samples, testRuntime: testRuntime, state: state, logHook: logHook}
+}
+
+func TestMatchers(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		code string
+		pass bool
+	}{
+		{`expect(200).toBe(200)`, true},
+		{`expect("200").toBe(200)`, false},
+		{`expect(200).not.toBe(404)`, true},
+		{`expect({a: [1, {b: "c"}], d: null}).toEqual({d: null, a: [1.0, {b: "c"}]})`, true},
+		{`expect({a: 1}).toEqual({a: 1, b: 2})`, false},
+		{`expect("x").toBeTruthy()`, true},
+		{`expect(0).toBeTruthy()`, false},
+		{`expect(102.5).toBeCloseTo(100, 5)`, true},
+		{`expect(106).toBeCloseTo(100, 5)`, false},
+		{`expect("100").toBeCloseTo(100, 5)`, false},
+		{`expect(3).toBeGreaterThan(2)`, true},
+		{`expect(2).toBeGreaterThan(2)`, false},
+		{`expect(2).toBeGreaterThanOrEqual(2)`, true},
+		{`expect(1).toBeLessThan(2)`, true},
+		{`expect(3).toBeLessThanOrEqual(2)`, false},
+		{`expect(150).toBeBetween(100, 200)`, true},
+		{`expect(250).toBeBetween(100, 200)`, false},
+		{`expect("hello world").toContain("world")`, true},
+		{`expect([1, {a: 2}]).toContain({a: 2})`, true},
+		{`expect([1, 2]).toContain(3)`, false},
+		{`expect("abc123").toMatch(/^[a-z]+\d+$/)`, true},
+		{`expect("abc").toMatch("^\\d+$")`, false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.code, func(t *testing.T) {
+			t.Parallel()
+			tcr := testCaseRuntime(t)
+
+			v, err := tcr.testRuntime.VU.Runtime().RunString(tc.code)
+			require.NoError(t, err)
+			assert.Equal(t, tc.pass, v.ToBoolean())
+
+			samples := metrics.GetBufferedSamples(tcr.samples)
+			require.Len(t, samples, 1)
+			sample := samples[0].GetSamples()[0]
+			assert.Equal(t, metrics.ChecksName, sample.Metric.Name)
+			assert.Equal(t, map[bool]float64{true: 1, false: 0}[tc.pass], sample.Value)
+			severity, _ := sample.Tags.Get("severity")
+			assert.Equal(t, "fail", severity)
+		})
+	}
+}
+
+func TestMatchSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := `{
+		type: "object",
+		required: ["id", "name"],
+		properties: {
+			id: { type: "integer", minimum: 1 },
+			name: { type: "string", minLength: 1, pattern: "^[A-Z]" },
+			tags: { type: "array", items: { enum: ["a", "b"] }, maxItems: 2 },
+			score: { type: ["number", "null"] },
+		},
+		additionalProperties: false,
+	}`
+	testCases := []struct {
+		value, expErr string
+	}{
+		{`{id: 1, name: "Alice", tags: ["a"], score: 1.5}`, ""},
+		{`{id: 1, name: "Alice", score: null}`, ""},
+		{`{name: "Alice"}`, "$.id is required"},
+		{`{id: 1.5, name: "Alice"}`, "$.id has to be of type integer, but it's number"},
+		{`{id: 0, name: "Alice"}`, "$.id has to be at least 1, but it's 0"},
+		{`{id: 1, name: "alice"}`, `$.name has to match "^[A-Z]", but it's "alice"`},
+		{`{id: 1, name: "Alice", tags: ["c"]}`, `$.tags[0] has to be one of ["a","b"], but it's "c"`},
+		{`{id: 1, name: "Alice", tags: ["a", "b", "a"]}`, "$.tags has to have at most 2 items, but it has 3"},
+		{`{id: 1, name: "Alice", extra: true}`, "$.extra isn't allowed"},
+		{`[]`, "$ has to be of type object, but it's array"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+			tcr := testCaseRuntime(t)
+
+			v, err := tcr.testRuntime.VU.Runtime().RunString(
+				`expect(` + tc.value + `, { severity: "warn" }).toMatchSchema(` + schema + `)`)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expErr == "", v.ToBoolean())
+
+			entries := tcr.logHook.AllEntries()
+			if tc.expErr == "" {
+				assert.Empty(t, entries)
+				return
+			}
+			require.Len(t, entries, 1)
+			assert.Contains(t, entries[0].Message, "to match the schema: "+tc.expErr)
+		})
+	}
+}
+
+func TestSeverityAndAbort(t *testing.T) {
+	t.Parallel()
+	tcr := testCaseRuntime(t)
+	rt := tcr.testRuntime.VU.Runtime()
+
+	_, err := rt.RunString(`
+		expect(404, { name: "status", severity: "warn", abortIteration: true }).toBe(200);
+		expect(404, "status").toBe(200);
+	`)
+	require.NoError(t, err)
+	entries := tcr.logHook.AllEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "status: expected 404 to be 200", entries[0].Message)
+
+	_, err = rt.RunString(`
+		expect.configure({ abortIteration: true });
+		expect(404, "status").not.toBe(404);
+	`)
+	require.ErrorContains(t, err, "status: expected 404 not to be 404")
+
+	check, err := tcr.state.Group.Check("status")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), check.Fails)
+
+	_, err = rt.RunString(`expect(1, { severity: "fatal" })`)
+	require.ErrorContains(t, err, "invalid expect options: invalid severity 'fatal', it can be fail or warn")
+	_, err = rt.RunString(`expect.configure({ name: "x" })`)
+	require.ErrorContains(t, err, "the name can only be set for each assertion")
+}
+
+func TestDefaultCheckName(t *testing.T) {
+	t.Parallel()
+	tcr := testCaseRuntime(t)
+
+	_, err := tcr.testRuntime.VU.Runtime().RunString(`
+		expect(1).toBeCloseTo(1, 0.5);
+		expect({}).not.toEqual({ a: 1 });
+	`)
+	require.NoError(t, err)
+	assert.Contains(t, tcr.state.Group.Checks, "expect(...).toBeCloseTo(1, 0.5)")
+	assert.Contains(t, tcr.state.Group.Checks, "expect(...).not.toEqual(...)")
+}
+
+func TestExpectInInitContext(t *testing.T) {
+	t.Parallel()
+
+	testRuntime := modulestest.NewRuntime(t)
+	mi, ok := New().NewModuleInstance(testRuntime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, testRuntime.VU.RuntimeField.Set("expect", mi.Exports().Named["expect"]))
+
+	_, err := testRuntime.VU.Runtime().RunString(`expect(1).toBe(1)`)
+	require.ErrorContains(t, err, "Using expect in the init context is not supported")
+}
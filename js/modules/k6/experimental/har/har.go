@@ -0,0 +1,307 @@
+// Package har implements the k6/experimental/har module, that replays the
+// requests recorded in HAR files with their original timing.
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/internal/converterutil"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the JS module.
+	ModuleInstance struct {
+		vu modules.VU
+	}
+)
+
+// Ensure the interfaces are implemented correctly
+var (
+	_ modules.Instance = &ModuleInstance{}
+	_ modules.Module   = &RootModule{}
+)
+
+// New returns a pointer to a new RootModule instance
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu}
+}
+
+// Exports implements the modules.Instance interface and returns
+// the exports of the JS module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]interface{}{
+			"Replay": mi.newReplay,
+		},
+	}
+}
+
+// replayOptions controls how the recorded requests are replayed.
+type replayOptions struct {
+	// TimeScale multiplies the recorded delays between the requests, so 0.5
+	// replays the recording twice as fast. 0 makes all requests at once.
+	TimeScale float64
+	// Replace contains the strings that are substituted in the URLs, headers
+	// and bodies of the recorded requests, for example to change the host or
+	// to use a different auth token.
+	Replace map[string]string
+	// OnlyHosts, if not empty, restricts the replay to these hosts.
+	OnlyHosts []string
+	// Timeout of every request.
+	Timeout time.Duration
+}
+
+func newReplayOptions(rt *goja.Runtime, v goja.Value) (replayOptions, error) {
+	opts := replayOptions{TimeScale: 1, Timeout: 60 * time.Second}
+	if common.IsNullish(v) {
+		return opts, nil
+	}
+
+	obj := v.ToObject(rt)
+	for _, key := range obj.Keys() {
+		value := obj.Get(key)
+		switch key {
+		case "timeScale":
+			opts.TimeScale = value.ToFloat()
+			if opts.TimeScale < 0 {
+				return opts, errors.New("timeScale can't be negative")
+			}
+		case "replace":
+			if err := rt.ExportTo(value, &opts.Replace); err != nil {
+				return opts, fmt.Errorf("replace needs to be an object with string values: %w", err)
+			}
+		case "onlyHosts":
+			if err := rt.ExportTo(value, &opts.OnlyHosts); err != nil {
+				return opts, fmt.Errorf("onlyHosts needs to be an array of strings: %w", err)
+			}
+		case "timeout":
+			timeout, err := time.ParseDuration(value.String())
+			if err != nil {
+				return opts, fmt.Errorf("invalid timeout: %w", err)
+			}
+			opts.Timeout = timeout
+		default:
+			return opts, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return opts, nil
+}
+
+// Replay replays the requests of a HAR recording.
+type Replay struct {
+	vu       modules.VU
+	entries  []*har.Entry
+	opts     replayOptions
+	replacer *strings.Replacer
+}
+
+// ReplayResult is the result of a single replayed request.
+type ReplayResult struct {
+	Method string `js:"method"`
+	URL    string `js:"url"`
+	Status int    `js:"status"`
+	Error  string `js:"error"`
+}
+
+// newReplay is the JS constructor of Replay. It expects the contents of a HAR
+// file, either as a string or as an ArrayBuffer, and optional replay options.
+func (mi *ModuleInstance) newReplay(cc goja.ConstructorCall) *goja.Object {
+	rt := mi.vu.Runtime()
+
+	if mi.vu.State() != nil {
+		common.Throw(rt, common.NewInitContextError("Replay needs to be created in the init context"))
+	}
+	if len(cc.Arguments) < 1 || common.IsNullish(cc.Argument(0)) {
+		common.Throw(rt, errors.New("Replay expects the contents of a HAR file as its first argument"))
+	}
+
+	data, err := common.ToBytes(cc.Argument(0).Export())
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	var recording har.HAR
+	if err = json.Unmarshal(data, &recording); err != nil {
+		common.Throw(rt, fmt.Errorf("unable to parse the HAR file: %w", err))
+	}
+	if recording.Log == nil {
+		common.Throw(rt, errors.New("the HAR file doesn't contain a log"))
+	}
+
+	opts, err := newReplayOptions(rt, cc.Argument(1))
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid Replay options: %w", err))
+	}
+
+	replay := &Replay{vu: mi.vu, opts: opts}
+	for _, e := range recording.Log.Entries {
+		if e.Request == nil {
+			continue
+		}
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			common.Throw(rt, fmt.Errorf("invalid request URL %q: %w", e.Request.URL, err))
+		}
+		if len(opts.OnlyHosts) > 0 && !converterutil.ContainsString(opts.OnlyHosts, u.Hostname()) {
+			continue
+		}
+		replay.entries = append(replay.entries, e)
+	}
+	sort.SliceStable(replay.entries, func(i, j int) bool {
+		return replay.entries[i].StartedDateTime.Before(replay.entries[j].StartedDateTime)
+	})
+
+	oldnew := make([]string, 0, 2*len(opts.Replace))
+	for old, replacement := range opts.Replace {
+		oldnew = append(oldnew, old, replacement)
+	}
+	replay.replacer = strings.NewReplacer(oldnew...)
+
+	return rt.ToValue(replay).ToObject(rt)
+}
+
+// Run replays all of the recorded requests, with the same delays between
+// their starts as in the recording, scaled by the timeScale option. That
+// means the requests that were concurrent in the recording are also made
+// concurrently. It blocks until all of the requests are done.
+func (r *Replay) Run() []ReplayResult {
+	rt := r.vu.Runtime()
+	state := r.vu.State()
+	if state == nil {
+		common.Throw(rt, errors.New("Replay.run() can't be called in the init context"))
+	}
+	if len(r.entries) == 0 {
+		return []ReplayResult{}
+	}
+
+	ctx := r.vu.Context()
+	results := make([]ReplayResult, len(r.entries))
+	errs := make([]error, len(r.entries))
+	var wg sync.WaitGroup
+
+	start, first := time.Now(), r.entries[0].StartedDateTime
+	for i, e := range r.entries {
+		preq, err := r.parseEntry(e)
+		if err != nil {
+			wg.Wait()
+			common.Throw(rt, err)
+		}
+		results[i] = ReplayResult{Method: preq.Req.Method, URL: preq.URL.URL}
+
+		delay := time.Duration(float64(e.StartedDateTime.Sub(first)) * r.opts.TimeScale)
+		if wait := time.Until(start.Add(delay)); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				wg.Wait()
+				return results[:i]
+			case <-timer.C:
+			}
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := httpext.MakeRequest(ctx, state, preq)
+			errs[i] = err
+			if resp != nil {
+				results[i].Status = resp.Status
+				results[i].Error = resp.Error
+			} else if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	return results
+}
+
+//nolint:gochecknoglobals
+var skippedHeaders = map[string]bool{
+	"host":           true,
+	"content-length": true,
+	"connection":     true,
+}
+
+func (r *Replay) parseEntry(e *har.Entry) (*httpext.ParsedHTTPRequest, error) {
+	state := r.vu.State()
+
+	rawURL := r.replacer.Replace(e.Request.URL)
+	u, err := httpext.NewURL(rawURL, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	preq := &httpext.ParsedHTTPRequest{
+		URL: &u,
+		Req: &http.Request{
+			Method: strings.ToUpper(e.Request.Method),
+			URL:    u.GetURL(),
+			Header: make(http.Header),
+		},
+		Timeout: r.opts.Timeout,
+		Throw:   state.Options.Throw.Bool,
+		// Redirects are recorded as separate entries, so they're not followed
+		Redirects:    null.IntFrom(0),
+		ResponseType: httpext.ResponseTypeNone,
+		ResponseCallback: func(status int) bool {
+			return status >= 200 && status < 400
+		},
+		TagsAndMeta: state.Tags.GetCurrentValues(),
+	}
+
+	// The recorded cookie headers are replayed as they are, so the VU cookie
+	// jar isn't used, to avoid sending the same cookies twice.
+	for _, h := range e.Request.Headers {
+		if strings.HasPrefix(h.Name, ":") || skippedHeaders[strings.ToLower(h.Name)] {
+			continue
+		}
+		preq.Req.Header.Add(h.Name, r.replacer.Replace(h.Value))
+	}
+
+	if data := e.Request.PostData; data != nil {
+		body := data.Text
+		if body == "" && len(data.Params) > 0 {
+			values := make(url.Values, len(data.Params))
+			for _, p := range data.Params {
+				values.Add(p.Name, p.Value)
+			}
+			body = values.Encode()
+		}
+		preq.Body = bytes.NewBufferString(r.replacer.Replace(body))
+	}
+
+	return preq, nil
+}
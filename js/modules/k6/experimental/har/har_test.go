@@ -0,0 +1,132 @@
+package har
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization")+" "+string(body))
+		mu.Unlock()
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	recording, err := json.Marshal(har.HAR{Log: &har.Log{Entries: []*har.Entry{
+		{
+			StartedDateTime: start.Add(400 * time.Millisecond),
+			Request:         &har.Request{Method: "GET", URL: "http://prod.example.com/missing"},
+		},
+		{
+			StartedDateTime: start,
+			Request: &har.Request{
+				Method: "POST",
+				URL:    "http://prod.example.com/login",
+				Headers: []har.NameValue{
+					{Name: ":authority", Value: "prod.example.com"},
+					{Name: "Authorization", Value: "Bearer PROD_TOKEN"},
+				},
+				PostData: &har.PostData{Text: "user=PROD_USER"},
+			},
+		},
+		{
+			StartedDateTime: start,
+			Request:         &har.Request{Method: "GET", URL: "http://other.example.com/"},
+		},
+	}}})
+	require.NoError(t, err)
+
+	runtime := modulestest.NewRuntime(t)
+	mi, ok := New().NewModuleInstance(runtime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	rt := runtime.VU.Runtime()
+	require.NoError(t, rt.Set("har", mi.Exports().Named))
+	require.NoError(t, rt.Set("recording", string(recording)))
+	require.NoError(t, rt.Set("targetURL", srv.URL))
+
+	_, err = rt.RunString(`
+		var replay = new har.Replay(recording, {
+			timeScale: 0.5,
+			onlyHosts: ["prod.example.com"],
+			replace: { "http://prod.example.com": targetURL, "PROD_TOKEN": "token", "PROD_USER": "user" },
+		});
+	`)
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	samples := make(chan metrics.SampleContainer, 100)
+	runtime.MoveToVUContext(&lib.State{
+		Options:        lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+		Logger:         testutils.NewLogger(t),
+		Transport:      srv.Client().Transport,
+		BufferPool:     lib.NewBufferPool(),
+		Samples:        samples,
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+	})
+
+	before := time.Now()
+	v, err := rt.RunString(`replay.run()`)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(before), 200*time.Millisecond)
+
+	var results []ReplayResult
+	require.NoError(t, rt.ExportTo(v, &results))
+	assert.Equal(t, []ReplayResult{
+		{Method: "POST", URL: srv.URL + "/login", Status: http.StatusOK},
+		{Method: "GET", URL: srv.URL + "/missing", Status: http.StatusNotFound},
+	}, results)
+	assert.Equal(t, []string{"POST /login Bearer token user=user", "GET /missing  "}, received)
+	assert.NotEmpty(t, metrics.GetBufferedSamples(samples))
+}
+
+func TestReplayInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"no data":         `new har.Replay()`,
+		"invalid json":    `new har.Replay("{")`,
+		"no log":          `new har.Replay("{}")`,
+		"invalid option":  `new har.Replay('{"log":{"entries":[]}}', { foo: 1 })`,
+		"negative scale":  `new har.Replay('{"log":{"entries":[]}}', { timeScale: -1 })`,
+		"invalid timeout": `new har.Replay('{"log":{"entries":[]}}', { timeout: "foo" })`,
+	}
+	for name, script := range testCases {
+		script := script
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			runtime := modulestest.NewRuntime(t)
+			mi, ok := New().NewModuleInstance(runtime.VU).(*ModuleInstance)
+			require.True(t, ok)
+			require.NoError(t, runtime.VU.Runtime().Set("har", mi.Exports().Named))
+			_, err := runtime.VU.Runtime().RunString(script)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,306 @@
+// Package log implements the k6/experimental/log module, a structured logger
+// for the scripts. The messages have levels and fields, which the Loki and the
+// file log outputs keep as labels and keys, and they can be rate limited and
+// sampled for each scenario, so the tests with many VUs don't flood the logs.
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+
+	"github.com/dop251/goja"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct {
+		mu sync.Mutex
+		// the rate limiters of the scenarios, which are shared by their VUs
+		limiters map[string]*rate.Limiter
+		// the scenarios whose dropped messages were already reported
+		dropped map[string]bool
+	}
+
+	// ModuleInstance represents an instance of the JS module.
+	ModuleInstance struct {
+		vu      modules.VU
+		root    *RootModule
+		options options
+	}
+)
+
+// Ensure the interfaces are implemented correctly
+var (
+	_ modules.Instance = &ModuleInstance{}
+	_ modules.Module   = &RootModule{}
+)
+
+// New returns a pointer to a new RootModule instance
+func New() *RootModule {
+	return &RootModule{
+		limiters: make(map[string]*rate.Limiter),
+		dropped:  make(map[string]bool),
+	}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns
+// a new instance for each VU.
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{
+		vu:      vu,
+		root:    r,
+		options: options{scenarioOptions: scenarioOptions{level: logrus.DebugLevel, sampleRate: 1}},
+	}
+}
+
+// Exports implements the modules.Instance interface and returns
+// the exports of the JS module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	named := map[string]interface{}{
+		"configure": mi.configure,
+	}
+	for name, fn := range mi.logger(nil).methods() {
+		named[name] = fn
+	}
+	return modules.Exports{Named: named}
+}
+
+// scenarioOptions are the options of the logging in a scenario.
+type scenarioOptions struct {
+	level      logrus.Level
+	rateLimit  float64
+	sampleRate float64
+}
+
+// options are the options of the logging, with the ones that override them
+// for some scenarios.
+type options struct {
+	scenarioOptions
+	scenarios map[string]scenarioOptions
+}
+
+// parse overwrites the options with the ones in the JS object.
+func (o *scenarioOptions) parse(obj *goja.Object, key string) error {
+	value := obj.Get(key)
+	switch key {
+	case "level":
+		level, err := logrus.ParseLevel(value.String())
+		if err != nil || level < logrus.ErrorLevel || level > logrus.DebugLevel {
+			return fmt.Errorf("invalid level '%s', it can be debug, info, warn or error", value.String())
+		}
+		o.level = level
+	case "rateLimit":
+		limit := value.ToFloat()
+		if math.IsNaN(limit) || limit < 0 {
+			return fmt.Errorf("invalid rateLimit %s, it can't be negative", value.String())
+		}
+		o.rateLimit = limit
+	case "sampleRate":
+		sampleRate := value.ToFloat()
+		if math.IsNaN(sampleRate) || sampleRate < 0 || sampleRate > 1 {
+			return fmt.Errorf("invalid sampleRate %s, it has to be between 0 and 1", value.String())
+		}
+		o.sampleRate = sampleRate
+	default:
+		return fmt.Errorf("unknown option '%s'", key)
+	}
+	return nil
+}
+
+// configure changes the options of the logging in the VU. They are the same
+// in all of the VUs, as every VU runs the init code that calls it.
+func (mi *ModuleInstance) configure(opts goja.Value) {
+	rt := mi.vu.Runtime()
+	if common.IsNullish(opts) {
+		return
+	}
+
+	o := mi.options
+	obj := opts.ToObject(rt)
+	for _, key := range obj.Keys() {
+		if key == "scenarios" {
+			continue
+		}
+		if err := o.parse(obj, key); err != nil {
+			common.Throw(rt, fmt.Errorf("invalid log options: %w", err))
+		}
+	}
+
+	// the options of the scenarios default to the global ones
+	if scenarios := obj.Get("scenarios"); !common.IsNullish(scenarios) {
+		scenariosObj := scenarios.ToObject(rt)
+		o.scenarios = make(map[string]scenarioOptions, len(scenariosObj.Keys()))
+		for _, name := range scenariosObj.Keys() {
+			so := o.scenarioOptions
+			scenarioObj := scenariosObj.Get(name).ToObject(rt)
+			for _, key := range scenarioObj.Keys() {
+				if err := so.parse(scenarioObj, key); err != nil {
+					common.Throw(rt, fmt.Errorf("invalid log options of the scenario '%s': %w", name, err))
+				}
+			}
+			o.scenarios[name] = so
+		}
+	}
+	mi.options = o
+}
+
+// logger is a logger with its fields, which are added to all of its messages.
+type logger struct {
+	mi     *ModuleInstance
+	fields logrus.Fields
+}
+
+func (mi *ModuleInstance) logger(fields logrus.Fields) *logger {
+	return &logger{mi: mi, fields: fields}
+}
+
+func (l *logger) methods() map[string]interface{} {
+	return map[string]interface{}{
+		"debug": l.logFunc(logrus.DebugLevel),
+		"info":  l.logFunc(logrus.InfoLevel),
+		"warn":  l.logFunc(logrus.WarnLevel),
+		"error": l.logFunc(logrus.ErrorLevel),
+		"with":  l.with,
+	}
+}
+
+// with returns a logger with the fields added to the ones of this logger.
+func (l *logger) with(fields goja.Value) *goja.Object {
+	rt := l.mi.vu.Runtime()
+	merged := make(logrus.Fields, len(l.fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	if err := exportFields(rt, fields, merged); err != nil {
+		common.Throw(rt, err)
+	}
+
+	obj := rt.NewObject()
+	for name, fn := range l.mi.logger(merged).methods() {
+		if err := obj.Set(name, fn); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	return obj
+}
+
+func (l *logger) logFunc(level logrus.Level) func(msg goja.Value, fields goja.Value) {
+	return func(msg goja.Value, fields goja.Value) {
+		rt := l.mi.vu.Runtime()
+		entryFields := make(logrus.Fields, len(l.fields)+2)
+		for k, v := range l.fields {
+			entryFields[k] = v
+		}
+		if err := exportFields(rt, fields, entryFields); err != nil {
+			common.Throw(rt, err)
+		}
+
+		var message string
+		if !common.IsNullish(msg) {
+			message = msg.String()
+		}
+		l.mi.log(level, message, entryFields)
+	}
+}
+
+// log logs the message, unless its level is disabled or it's dropped by the
+// sampling or the rate limit of the scenario.
+func (mi *ModuleInstance) log(level logrus.Level, msg string, fields logrus.Fields) {
+	var logger logrus.FieldLogger
+	var scenario string
+	if state := mi.vu.State(); state != nil {
+		logger = state.Logger
+		if ss := lib.GetScenarioState(mi.vu.Context()); ss != nil {
+			scenario = ss.Name
+		}
+	} else {
+		logger = mi.vu.InitEnv().Logger
+	}
+
+	opts := mi.options.scenarioOptions
+	if so, ok := mi.options.scenarios[scenario]; ok {
+		opts = so
+	}
+	if level > opts.level {
+		return
+	}
+	if opts.sampleRate < 1 && rand.Float64() >= opts.sampleRate { //nolint:gosec
+		return
+	}
+	if opts.rateLimit > 0 && scenario != "" && !mi.root.allow(scenario, opts.rateLimit) {
+		if mi.root.reportDropped(scenario) {
+			logger.Warnf("Some of the log messages of the scenario '%s' are dropped, "+
+				"as it logs more than %v of them per second", scenario, opts.rateLimit)
+		}
+		return
+	}
+
+	entry := logger.WithField("source", "log")
+	if scenario != "" {
+		fields["scenario"] = scenario
+	}
+	entry.WithFields(fields).Log(level, msg)
+}
+
+// allow returns whether a message of the scenario can be logged without going
+// over its rate limit, which is shared by all of its VUs.
+func (r *RootModule) allow(scenario string, limit float64) bool {
+	r.mu.Lock()
+	limiter, ok := r.limiters[scenario]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit), int(math.Max(1, math.Ceil(limit))))
+		r.limiters[scenario] = limiter
+	}
+	r.mu.Unlock()
+	return limiter.Allow()
+}
+
+// reportDropped returns true only the first time that it's called for the
+// scenario, so the dropped messages are reported once.
+func (r *RootModule) reportDropped(scenario string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dropped[scenario] {
+		return false
+	}
+	r.dropped[scenario] = true
+	return true
+}
+
+// exportFields adds the properties of the JS object to the fields. The
+// objects and the arrays are added as JSON, so the outputs get them as text.
+func exportFields(rt *goja.Runtime, v goja.Value, fields logrus.Fields) error {
+	if common.IsNullish(v) {
+		return nil
+	}
+	if _, isObject := v.(*goja.Object); !isObject {
+		return errors.New("the log fields have to be an object")
+	}
+	obj := v.ToObject(rt)
+	for _, key := range obj.Keys() {
+		value := obj.Get(key).Export()
+		switch reflect.ValueOf(value).Kind() { //nolint:exhaustive
+		case reflect.Map, reflect.Slice, reflect.Struct, reflect.Ptr:
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("invalid log field '%s': %w", key, err)
+			}
+			fields[key] = string(data)
+		default:
+			fields[key] = value
+		}
+	}
+	return nil
+}
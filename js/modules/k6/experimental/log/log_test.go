@@ -0,0 +1,173 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+)
+
+func testRuntime(t testing.TB, root *RootModule, scenario string) (*modulestest.Runtime, *test.Hook) {
+	testRuntime := modulestest.NewRuntime(t)
+	mi, ok := root.NewModuleInstance(testRuntime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, testRuntime.VU.RuntimeField.Set("log", mi.Exports().Named))
+
+	logger, logHook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	testRuntime.VU.InitEnvField.Logger = logger
+	testRuntime.VU.CtxField = lib.WithScenarioState(testRuntime.VU.CtxField, &lib.ScenarioState{Name: scenario})
+
+	return testRuntime, logHook
+}
+
+func moveToVUContext(testRuntime *modulestest.Runtime) {
+	testRuntime.MoveToVUContext(&lib.State{Logger: testRuntime.VU.InitEnvField.Logger})
+}
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+	rt, logHook := testRuntime(t, New(), "default")
+	moveToVUContext(rt)
+
+	_, err := rt.VU.Runtime().RunString(`
+		log.info("started", { user: "alice", attempt: 2, tags: ["a", "b"], ok: true });
+		const checkout = log.with({ step: "checkout", user: "bob" });
+		checkout.warn("slow response", { duration: 1.5 });
+		checkout.with({ step: "payment" }).error("failed");
+		log.debug("done");
+	`)
+	require.NoError(t, err)
+
+	entries := logHook.AllEntries()
+	require.Len(t, entries, 4)
+	assert.Equal(t, logrus.InfoLevel, entries[0].Level)
+	assert.Equal(t, "started", entries[0].Message)
+	assert.Equal(t, logrus.Fields{
+		"source": "log", "scenario": "default", "user": "alice", "attempt": int64(2), "tags": `["a","b"]`, "ok": true,
+	}, entries[0].Data)
+	assert.Equal(t, logrus.WarnLevel, entries[1].Level)
+	assert.Equal(t, logrus.Fields{
+		"source": "log", "scenario": "default", "step": "checkout", "user": "bob", "duration": 1.5,
+	}, entries[1].Data)
+	assert.Equal(t, logrus.ErrorLevel, entries[2].Level)
+	assert.Equal(t, logrus.Fields{
+		"source": "log", "scenario": "default", "step": "payment", "user": "bob",
+	}, entries[2].Data)
+	assert.Equal(t, logrus.DebugLevel, entries[3].Level)
+}
+
+func TestLogInInitContext(t *testing.T) {
+	t.Parallel()
+	rt, logHook := testRuntime(t, New(), "default")
+
+	_, err := rt.VU.Runtime().RunString(`log.info("loading", { file: "data.csv" })`)
+	require.NoError(t, err)
+	entries := logHook.AllEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logrus.Fields{"source": "log", "file": "data.csv"}, entries[0].Data)
+}
+
+func TestLogLevelAndSampling(t *testing.T) {
+	t.Parallel()
+	rt, logHook := testRuntime(t, New(), "quiet")
+
+	_, err := rt.VU.Runtime().RunString(`
+		log.configure({ level: "warn", scenarios: { quiet: { sampleRate: 0 }, other: { level: "debug" } } });
+	`)
+	require.NoError(t, err)
+	moveToVUContext(rt)
+	_, err = rt.VU.Runtime().RunString(`
+		for (let i = 0; i < 10; i++) {
+			log.error("dropped");
+		}
+	`)
+	require.NoError(t, err)
+	assert.Empty(t, logHook.AllEntries())
+
+	rt, logHook = testRuntime(t, New(), "default")
+	_, err = rt.VU.Runtime().RunString(`log.configure({ level: "warn" })`)
+	require.NoError(t, err)
+	moveToVUContext(rt)
+	_, err = rt.VU.Runtime().RunString(`log.info("dropped"); log.warn("logged")`)
+	require.NoError(t, err)
+	entries := logHook.AllEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "logged", entries[0].Message)
+}
+
+func TestLogRateLimit(t *testing.T) {
+	t.Parallel()
+	root := New()
+
+	// the VUs of a scenario share its rate limit
+	var hooks []*test.Hook
+	for i := 0; i < 2; i++ {
+		rt, logHook := testRuntime(t, root, "limited")
+		_, err := rt.VU.Runtime().RunString(`log.configure({ rateLimit: 5 })`)
+		require.NoError(t, err)
+		moveToVUContext(rt)
+		_, err = rt.VU.Runtime().RunString(`
+			for (let i = 0; i < 10; i++) {
+				log.info("message " + i);
+			}
+		`)
+		require.NoError(t, err)
+		hooks = append(hooks, logHook)
+	}
+
+	var messages, warnings int
+	for _, hook := range hooks {
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel {
+				warnings++
+				assert.Equal(t, "Some of the log messages of the scenario 'limited' are dropped, "+
+					"as it logs more than 5 of them per second", entry.Message)
+			} else {
+				messages++
+			}
+		}
+	}
+	// the burst of the limit, and maybe one more if a new token came meanwhile
+	assert.GreaterOrEqual(t, messages, 5)
+	assert.LessOrEqual(t, messages, 6)
+	assert.Equal(t, 1, warnings)
+
+	// while the other scenarios have their own
+	rt, logHook := testRuntime(t, root, "other")
+	_, err := rt.VU.Runtime().RunString(`log.configure({ rateLimit: 5 })`)
+	require.NoError(t, err)
+	moveToVUContext(rt)
+	_, err = rt.VU.Runtime().RunString(`log.info("message")`)
+	require.NoError(t, err)
+	assert.Len(t, logHook.AllEntries(), 1)
+}
+
+func TestLogInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		`log.configure({ level: "trace" })`: "invalid log options: invalid level 'trace', " +
+			"it can be debug, info, warn or error",
+		`log.configure({ rateLimit: -1 })`:   "invalid log options: invalid rateLimit -1, it can't be negative",
+		`log.configure({ sampleRate: 1.5 })`: "invalid log options: invalid sampleRate 1.5, it has to be between 0 and 1",
+		`log.configure({ color: true })`:     "invalid log options: unknown option 'color'",
+		`log.configure({ scenarios: { a: { level: "x" } } })`: "invalid log options of the scenario 'a': " +
+			"invalid level 'x', it can be debug, info, warn or error",
+		`log.info("x", "y")`: "the log fields have to be an object",
+	}
+	for code, expErr := range testCases {
+		code, expErr := code, expErr
+		t.Run(code, func(t *testing.T) {
+			t.Parallel()
+			rt, _ := testRuntime(t, New(), "default")
+			_, err := rt.VU.Runtime().RunString(code)
+			require.ErrorContains(t, err, expErr)
+		})
+	}
+}
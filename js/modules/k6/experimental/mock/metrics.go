@@ -0,0 +1,25 @@
+package mock
+
+import "go.k6.io/k6/metrics"
+
+// instanceMetrics contains the metrics for the mock module.
+type instanceMetrics struct {
+	Requests        *metrics.Metric
+	RequestDuration *metrics.Metric
+}
+
+// registerMetrics registers and returns the metrics in the provided registry
+func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
+	var err error
+	m := &instanceMetrics{}
+
+	if m.Requests, err = registry.NewMetric("mock_reqs", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.RequestDuration, err = registry.NewMetric("mock_req_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
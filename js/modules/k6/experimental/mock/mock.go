@@ -0,0 +1,302 @@
+// Package mock implements the k6/experimental/mock module, that serves mock
+// responses for the declared routes, so the dependencies of the system under
+// test can be virtualized by the same k6 process that's testing it.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the JS module.
+	ModuleInstance struct {
+		vu      modules.VU
+		metrics *instanceMetrics
+		routes  []*route
+	}
+)
+
+// Ensure the interfaces are implemented correctly
+var (
+	_ modules.Instance = &ModuleInstance{}
+	_ modules.Module   = &RootModule{}
+)
+
+// New returns a pointer to a new RootModule instance
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	m, err := registerMetrics(vu.InitEnv().Registry)
+	if err != nil {
+		common.Throw(vu.Runtime(), fmt.Errorf("failed to register mock module metrics: %w", err))
+	}
+	return &ModuleInstance{vu: vu, metrics: m}
+}
+
+// Exports implements the modules.Instance interface and returns
+// the exports of the JS module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]interface{}{
+			"route": mi.route,
+			"serve": mi.serve,
+		},
+	}
+}
+
+// route is a mocked route and the response that's returned for it.
+type route struct {
+	method string // empty matches all methods
+	host   string // empty matches all hosts
+	path   string // an exact path, or a prefix if it ends with *
+
+	status  int
+	headers map[string]string
+	body    []byte
+	delay   time.Duration
+}
+
+func (r *route) name() string {
+	method := r.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + r.host + r.path
+}
+
+func (r *route) matches(req *http.Request) bool {
+	if r.method != "" && r.method != req.Method {
+		return false
+	}
+	if r.host != "" {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.EqualFold(r.host, host) {
+			return false
+		}
+	}
+	if prefix, ok := strings.CutSuffix(r.path, "*"); ok {
+		return strings.HasPrefix(req.URL.Path, prefix)
+	}
+	return r.path == req.URL.Path
+}
+
+// route declares a mocked route. The first argument is either a string with
+// an optional method and a path, like "POST /charge", or an object with the
+// method, host and path properties. The second one describes the response,
+// with its status, headers, body or json and delay properties.
+func (mi *ModuleInstance) route(matcher goja.Value, response goja.Value) {
+	rt := mi.vu.Runtime()
+	r, err := parseRoute(rt, matcher, response)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid mock route: %w", err))
+	}
+	mi.routes = append(mi.routes, r)
+}
+
+func parseRoute(rt *goja.Runtime, matcher goja.Value, response goja.Value) (*route, error) {
+	r := &route{status: http.StatusOK, headers: make(map[string]string)}
+
+	switch {
+	case common.IsNullish(matcher):
+		return nil, errors.New("the route path needs to be specified")
+	case matcher.ExportType().Kind() == reflect.String:
+		parts := strings.Fields(matcher.String())
+		switch len(parts) {
+		case 1:
+			r.path = parts[0]
+		case 2:
+			r.method, r.path = strings.ToUpper(parts[0]), parts[1]
+		default:
+			return nil, fmt.Errorf("invalid route %q, it should be a path with an optional method", matcher.String())
+		}
+	default:
+		obj := matcher.ToObject(rt)
+		for _, key := range obj.Keys() {
+			value := obj.Get(key).String()
+			switch key {
+			case "method":
+				r.method = strings.ToUpper(value)
+			case "host":
+				r.host = value
+			case "path":
+				r.path = value
+			default:
+				return nil, fmt.Errorf("unknown route property %q", key)
+			}
+		}
+	}
+	if r.method == "*" {
+		r.method = ""
+	}
+	if !strings.HasPrefix(r.path, "/") {
+		return nil, fmt.Errorf("the route path %q should start with /", r.path)
+	}
+
+	if common.IsNullish(response) {
+		return r, nil
+	}
+	obj := response.ToObject(rt)
+	for _, key := range obj.Keys() {
+		value := obj.Get(key)
+		switch key {
+		case "status":
+			r.status = int(value.ToInteger())
+			if r.status < 100 || r.status > 999 {
+				return nil, fmt.Errorf("invalid status %d", r.status)
+			}
+		case "headers":
+			headers := value.ToObject(rt)
+			for _, name := range headers.Keys() {
+				r.headers[name] = headers.Get(name).String()
+			}
+		case "body":
+			body, err := common.ToBytes(value.Export())
+			if err != nil {
+				return nil, err
+			}
+			r.body = body
+		case "json":
+			body, err := json.Marshal(value.Export())
+			if err != nil {
+				return nil, fmt.Errorf("unable to serialize the json response: %w", err)
+			}
+			r.body = body
+			if _, ok := r.headers["Content-Type"]; !ok {
+				r.headers["Content-Type"] = "application/json"
+			}
+		case "delay":
+			delay, err := types.GetDurationValue(value.Export())
+			if err != nil {
+				return nil, fmt.Errorf("invalid delay: %w", err)
+			}
+			r.delay = delay
+		default:
+			return nil, fmt.Errorf("unknown response property %q", key)
+		}
+	}
+	return r, nil
+}
+
+// serve starts a server with the declared routes on the given address and
+// blocks until the VU context is done, so it's meant to be the only thing
+// done by the iterations of a dedicated scenario.
+func (mi *ModuleInstance) serve(address string) {
+	rt := mi.vu.Runtime()
+	state := mi.vu.State()
+	if state == nil {
+		common.Throw(rt, errors.New("serve() can't be called in the init context"))
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("unable to start the mock server: %w", err))
+	}
+
+	ctx := mi.vu.Context()
+	h := &handler{
+		ctx:         ctx,
+		routes:      append([]*route{}, mi.routes...),
+		metrics:     mi.metrics,
+		samples:     state.Samples,
+		tagsAndMeta: state.Tags.GetCurrentValues(),
+	}
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: time.Minute}
+	go func() { _ = srv.Serve(listener) }()
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = srv.Shutdown(shutdownCtx)
+}
+
+type handler struct {
+	ctx         context.Context
+	routes      []*route
+	metrics     *instanceMetrics
+	samples     chan<- metrics.SampleContainer
+	tagsAndMeta metrics.TagsAndMeta
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	var matched *route
+	for _, r := range h.routes {
+		if r.matches(req) {
+			matched = r
+			break
+		}
+	}
+
+	status, routeName := http.StatusNotFound, "unmatched"
+	if matched == nil {
+		http.Error(w, fmt.Sprintf("no mock route matches %s %s", req.Method, req.URL.Path), status)
+	} else {
+		status, routeName = matched.status, matched.name()
+		if matched.delay > 0 {
+			timer := time.NewTimer(matched.delay)
+			select {
+			case <-timer.C:
+			case <-req.Context().Done():
+				timer.Stop()
+			}
+		}
+		for name, value := range matched.headers {
+			w.Header().Set(name, value)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(matched.body)
+	}
+
+	tags := h.tagsAndMeta.Tags.
+		With("method", req.Method).
+		With("route", routeName).
+		With("status", strconv.Itoa(status))
+	now := time.Now()
+	metrics.PushIfNotDone(h.ctx, h.samples, metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{Metric: h.metrics.Requests, Tags: tags},
+				Time:       now,
+				Metadata:   h.tagsAndMeta.Metadata,
+				Value:      1,
+			},
+			{
+				TimeSeries: metrics.TimeSeries{Metric: h.metrics.RequestDuration, Tags: tags},
+				Time:       now,
+				Metadata:   h.tagsAndMeta.Metadata,
+				Value:      metrics.D(now.Sub(start)),
+			},
+		},
+		Tags: tags,
+		Time: now,
+	})
+}
@@ -0,0 +1,144 @@
+package mock
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	runtime := modulestest.NewRuntime(t)
+	mi, ok := New().NewModuleInstance(runtime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	rt := runtime.VU.Runtime()
+	require.NoError(t, rt.Set("mock", mi.Exports().Named))
+
+	_, err := rt.RunString(`
+		mock.route("GET /users/1", { json: { id: 1, name: "alice" } });
+		mock.route({ method: "post", path: "/payments/*" }, { status: 201, headers: { "X-Mock": "yes" }, body: "ok" });
+		mock.route("/slow", { delay: "50ms" });
+	`)
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	samples := make(chan metrics.SampleContainer, 100)
+	runtime.MoveToVUContext(&lib.State{
+		Samples: samples,
+		Tags:    lib.NewVUStateTags(registry.RootTagSet().With("scenario", "dependencies")),
+	})
+
+	addr := freeAddress(t)
+	require.NoError(t, rt.Set("address", addr))
+	done := make(chan error)
+	go func() {
+		_, err := rt.RunString(`mock.serve(address)`)
+		done <- err
+	}()
+
+	url := "http://" + addr
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	res, err := http.Get(url + "/users/1") //nolint:noctx
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"id":1,"name":"alice"}`, string(body))
+
+	res, err = http.Post(url+"/payments/42", "text/plain", nil) //nolint:noctx
+	require.NoError(t, err)
+	body, err = io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "yes", res.Header.Get("X-Mock"))
+	assert.Equal(t, "ok", string(body))
+
+	before := time.Now()
+	res, err = http.Get(url + "/slow") //nolint:noctx
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.GreaterOrEqual(t, time.Since(before), 50*time.Millisecond)
+
+	res, err = http.Get(url + "/payments/42") //nolint:noctx
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	runtime.CancelContext()
+	require.NoError(t, <-done)
+
+	var routes []string
+	for _, container := range metrics.GetBufferedSamples(samples) {
+		for _, sample := range container.GetSamples() {
+			if sample.Metric.Name != "mock_reqs" {
+				continue
+			}
+			tags := sample.Tags.Map()
+			assert.Equal(t, "dependencies", tags["scenario"])
+			routes = append(routes, tags["method"]+" "+tags["route"]+" "+tags["status"])
+		}
+	}
+	assert.Equal(t, []string{
+		"GET GET /users/1 200",
+		"POST POST /payments/* 201",
+		"GET * /slow 200",
+		"GET unmatched 404",
+	}, routes)
+}
+
+func TestRouteInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"no route":         `mock.route()`,
+		"relative path":    `mock.route("GET users")`,
+		"too many parts":   `mock.route("GET /users now")`,
+		"unknown matcher":  `mock.route({ path: "/", query: "a" })`,
+		"unknown response": `mock.route("/", { foo: 1 })`,
+		"invalid status":   `mock.route("/", { status: 42 })`,
+		"invalid delay":    `mock.route("/", { delay: "foo" })`,
+		"init context":     `mock.serve("127.0.0.1:0")`,
+	}
+	for name, script := range testCases {
+		script := script
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			runtime := modulestest.NewRuntime(t)
+			mi, ok := New().NewModuleInstance(runtime.VU).(*ModuleInstance)
+			require.True(t, ok)
+			require.NoError(t, runtime.VU.Runtime().Set("mock", mi.Exports().Named))
+			_, err := runtime.VU.Runtime().RunString(script)
+			require.Error(t, err)
+		})
+	}
+}
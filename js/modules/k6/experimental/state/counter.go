@@ -0,0 +1,43 @@
+package state
+
+import (
+	"sync/atomic"
+
+	"github.com/dop251/goja"
+)
+
+// counter is an atomic counter shared by all the VUs.
+type counter struct {
+	value atomic.Int64
+}
+
+// newCounter is the constructor of the counters, they're identified by their
+// name: `new Counter("logins")`.
+func (mi *ModuleInstance) newCounter(call goja.ConstructorCall) *goja.Object {
+	name := mi.name(call, "Counter")
+
+	mi.root.mu.Lock()
+	c, ok := mi.root.counters[name]
+	if !ok {
+		c = &counter{}
+		mi.root.counters[name] = c
+	}
+	mi.root.mu.Unlock()
+
+	return mi.methods(map[string]interface{}{
+		// add adds the delta, 1 by default, and returns the new value
+		"add": func(delta goja.Value) int64 {
+			if delta == nil || goja.IsUndefined(delta) {
+				return c.value.Add(1)
+			}
+			return c.value.Add(delta.ToInteger())
+		},
+		"value": func() int64 {
+			return c.value.Load()
+		},
+		// set sets the value and returns the previous one
+		"set": func(value int64) int64 {
+			return c.value.Swap(value)
+		},
+	})
+}
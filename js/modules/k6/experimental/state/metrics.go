@@ -0,0 +1,36 @@
+package state
+
+import "go.k6.io/k6/metrics"
+
+// instanceMetrics contains the metrics of the delivery of the messages of the
+// topics.
+type instanceMetrics struct {
+	Published        *metrics.Metric
+	Delivered        *metrics.Metric
+	Dropped          *metrics.Metric
+	DeliveryDuration *metrics.Metric
+}
+
+// registerMetrics registers and returns the metrics in the provided registry
+func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
+	var err error
+	m := &instanceMetrics{}
+
+	if m.Published, err = registry.NewMetric("pubsub_messages_published", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.Delivered, err = registry.NewMetric("pubsub_messages_delivered", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.Dropped, err = registry.NewMetric("pubsub_messages_dropped", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.DeliveryDuration, err = registry.NewMetric("pubsub_delivery_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"context"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+)
+
+// queue is a bounded FIFO queue shared by all the VUs. The values are stored
+// as JSON, so they can be read by the other VUs.
+type queue struct {
+	values chan string
+}
+
+// newQueue is the constructor of the queues, they're identified by their name
+// and have a capacity: `new Queue("tokens", 100)`. The capacity of the first
+// queue with the name is used.
+func (mi *ModuleInstance) newQueue(call goja.ConstructorCall) *goja.Object {
+	name := mi.name(call, "Queue")
+	capacity := mi.size(call, "Queue", "capacity")
+
+	mi.root.mu.Lock()
+	q, ok := mi.root.queues[name]
+	if !ok {
+		q = &queue{values: make(chan string, capacity)}
+		mi.root.queues[name] = q
+	}
+	mi.root.mu.Unlock()
+
+	toJSON, fromJSON := jsonFuncs(mi.vu.Runtime())
+	return mi.methods(map[string]interface{}{
+		// push adds the value, waiting until there's space for it
+		"push": func(v goja.Value) {
+			mi.mustBeInVUContext()
+			json := toJSON(v)
+			mi.wait("push to the queue", func(ctx context.Context) bool {
+				select {
+				case q.values <- json:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+		},
+		// tryPush adds the value if there's space for it, and returns if it did
+		"tryPush": func(v goja.Value) bool {
+			select {
+			case q.values <- toJSON(v):
+				return true
+			default:
+				return false
+			}
+		},
+		// pop removes and returns the oldest value, waiting until there's one
+		"pop": func() goja.Value {
+			mi.mustBeInVUContext()
+			var json string
+			mi.wait("pop from the queue", func(ctx context.Context) bool {
+				select {
+				case json = <-q.values:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+			return fromJSON(json)
+		},
+		// tryPop removes and returns the oldest value, or undefined if the
+		// queue is empty
+		"tryPop": func() goja.Value {
+			select {
+			case json := <-q.values:
+				return fromJSON(json)
+			default:
+				return goja.Undefined()
+			}
+		},
+		"size": func() int {
+			return len(q.values)
+		},
+		"capacity": func() int {
+			return cap(q.values)
+		},
+	})
+}
+
+// jsonFuncs returns the functions that convert the values to JSON and back,
+// they throw on errors.
+func jsonFuncs(rt *goja.Runtime) (toJSON func(goja.Value) string, fromJSON func(string) goja.Value) {
+	stringify, _ := goja.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("stringify"))
+	parse, _ := goja.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("parse"))
+	toJSON = func(v goja.Value) string {
+		json, err := stringify(goja.Undefined(), v)
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		if goja.IsUndefined(json) {
+			return "null" // e.g. undefined or functions
+		}
+		return json.String()
+	}
+	fromJSON = func(json string) goja.Value {
+		v, err := parse(goja.Undefined(), rt.ToValue(json))
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		return v
+	}
+	return toJSON, fromJSON
+}
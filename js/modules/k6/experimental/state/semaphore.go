@@ -0,0 +1,170 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/event"
+	"go.k6.io/k6/js/common"
+)
+
+// semaphore is a counting semaphore shared by all the VUs, a mutex is a
+// semaphore with one permit.
+type semaphore struct {
+	permits chan struct{}
+}
+
+// newMutex is the constructor of the mutexes, they're identified by their
+// name: `new Mutex("login")`.
+func (mi *ModuleInstance) newMutex(call goja.ConstructorCall) *goja.Object {
+	name := mi.name(call, "Mutex")
+	s := mi.semaphore("mutex:"+name, 1)
+
+	return mi.methods(map[string]interface{}{
+		"lock":    func() { mi.acquire(s, "lock the mutex") },
+		"tryLock": func() bool { return mi.tryAcquire(s) },
+		"unlock":  func() { mi.release(s, "unlock a mutex that isn't locked by the VU") },
+		// withLock calls the function with the mutex locked, and unlocks it
+		// even if the function throws
+		"withLock": func(fn goja.Value) goja.Value {
+			return mi.with(s, "lock the mutex", fn)
+		},
+	})
+}
+
+// newSemaphore is the constructor of the semaphores, they're identified by
+// their name and have a number of permits: `new Semaphore("logins", 10)`. The
+// permits of the first semaphore with the name are used.
+func (mi *ModuleInstance) newSemaphore(call goja.ConstructorCall) *goja.Object {
+	name := mi.name(call, "Semaphore")
+	permits := mi.size(call, "Semaphore", "number of permits")
+	s := mi.semaphore("semaphore:"+name, permits)
+
+	return mi.methods(map[string]interface{}{
+		"acquire":    func() { mi.acquire(s, "acquire the semaphore") },
+		"tryAcquire": func() bool { return mi.tryAcquire(s) },
+		"release":    func() { mi.release(s, "release a semaphore that isn't acquired by the VU") },
+		// withPermit calls the function with a permit of the semaphore, and
+		// releases it even if the function throws
+		"withPermit": func(fn goja.Value) goja.Value {
+			return mi.with(s, "acquire the semaphore", fn)
+		},
+		"available": func() int {
+			return cap(s.permits) - len(s.permits)
+		},
+	})
+}
+
+func (mi *ModuleInstance) semaphore(key string, permits int) *semaphore {
+	mi.root.mu.Lock()
+	defer mi.root.mu.Unlock()
+	s, ok := mi.root.semaphores[key]
+	if !ok {
+		s = &semaphore{permits: make(chan struct{}, permits)}
+		mi.root.semaphores[key] = s
+	}
+	return s
+}
+
+func (mi *ModuleInstance) acquire(s *semaphore, what string) {
+	mi.mustBeInVUContext()
+	mi.wait(what, func(ctx context.Context) bool {
+		select {
+		case s.permits <- struct{}{}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	mi.held[s]++
+	mi.releaseAtIterationEnd()
+}
+
+func (mi *ModuleInstance) tryAcquire(s *semaphore) bool {
+	select {
+	case s.permits <- struct{}{}:
+		mi.held[s]++
+		mi.releaseAtIterationEnd()
+		return true
+	default:
+		return false
+	}
+}
+
+// release releases a permit held by the VU, it throws if the VU doesn't hold
+// any, so a VU can't release the permits of the others.
+func (mi *ModuleInstance) release(s *semaphore, errMsg string) {
+	if mi.held[s] == 0 {
+		common.Throw(mi.vu.Runtime(), fmt.Errorf("can't %s", errMsg))
+	}
+	mi.held[s]--
+	<-s.permits
+}
+
+// releaseAtIterationEnd makes the VU release the permits it still holds at
+// the end of every iteration, like when the iteration throws before releasing
+// them or it's interrupted because the VU is deactivated, and at the end of
+// the test run. The VU is subscribed to the events the first time it acquires
+// a permit.
+func (mi *ModuleInstance) releaseAtIterationEnd() {
+	events := mi.vu.Events()
+	if mi.subscribed || events.Local == nil || events.Global == nil {
+		return
+	}
+	mi.subscribed = true
+
+	localID, iterEnds := events.Local.Subscribe(event.IterEnd)
+	globalID, exits := events.Global.Subscribe(event.Exit)
+	go func() {
+		for {
+			select {
+			case evt, ok := <-iterEnds:
+				if !ok {
+					return
+				}
+				// the VU waits for it, so the permits can't be changed now
+				mi.releaseAll()
+				evt.Done()
+			case evt, ok := <-exits:
+				if !ok {
+					return
+				}
+				mi.releaseAll()
+				evt.Done()
+				events.Local.Unsubscribe(localID)
+				events.Global.Unsubscribe(globalID)
+				return
+			}
+		}
+	}()
+}
+
+// releaseAll releases all of the permits held by the VU.
+func (mi *ModuleInstance) releaseAll() {
+	for s, n := range mi.held {
+		for ; n > 0; n-- {
+			<-s.permits
+		}
+		delete(mi.held, s)
+	}
+}
+
+func (mi *ModuleInstance) with(s *semaphore, what string, fn goja.Value) goja.Value {
+	rt := mi.vu.Runtime()
+	call, ok := goja.AssertFunction(fn)
+	if !ok {
+		common.Throw(rt, fmt.Errorf("a function is expected to be called after waiting to %s", what))
+	}
+	mi.acquire(s, what)
+	defer func() {
+		mi.held[s]--
+		<-s.permits
+	}()
+	v, err := call(goja.Undefined())
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+// Package state implements the k6/experimental/state module, with counters,
+// bounded queues, mutexes, semaphores and publish/subscribe topics that are
+// shared by all the VUs of a k6 instance, for coordinating them.
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU. It keeps the shared state by name.
+	RootModule struct {
+		mu         sync.Mutex
+		counters   map[string]*counter
+		queues     map[string]*queue
+		semaphores map[string]*semaphore
+		topics     map[string]*topic
+	}
+
+	// ModuleInstance represents an instance of the JS module.
+	ModuleInstance struct {
+		vu      modules.VU
+		root    *RootModule
+		metrics *instanceMetrics
+
+		// held are the permits of the semaphores and mutexes held by the VU,
+		// they're released at the end of its iterations once it's subscribed
+		held       map[*semaphore]int
+		subscribed bool
+	}
+)
+
+// Ensure the interfaces are implemented correctly
+var (
+	_ modules.Instance = &ModuleInstance{}
+	_ modules.Module   = &RootModule{}
+)
+
+// New returns a pointer to a new RootModule instance
+func New() *RootModule {
+	return &RootModule{
+		counters:   make(map[string]*counter),
+		queues:     make(map[string]*queue),
+		semaphores: make(map[string]*semaphore),
+		topics:     make(map[string]*topic),
+	}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns
+// a new instance for each VU.
+func (rm *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	m, err := registerMetrics(vu.InitEnv().Registry)
+	if err != nil {
+		common.Throw(vu.Runtime(), fmt.Errorf("failed to register state module metrics: %w", err))
+	}
+	return &ModuleInstance{vu: vu, root: rm, metrics: m, held: make(map[*semaphore]int)}
+}
+
+// Exports implements the modules.Instance interface and returns
+// the exports of the JS module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]interface{}{
+			"Counter":   mi.newCounter,
+			"Queue":     mi.newQueue,
+			"Mutex":     mi.newMutex,
+			"Semaphore": mi.newSemaphore,
+			"Topic":     mi.newTopic,
+		},
+	}
+}
+
+// name returns the name of the shared state, it throws if it's empty.
+func (mi *ModuleInstance) name(call goja.ConstructorCall, constructor string) string {
+	name := call.Argument(0).String()
+	if common.IsNullish(call.Argument(0)) || name == "" {
+		common.Throw(mi.vu.Runtime(), fmt.Errorf("empty name provided to %s's constructor", constructor))
+	}
+	return name
+}
+
+// size returns the positive size argument, like the capacity of a queue, it
+// throws if it's invalid.
+func (mi *ModuleInstance) size(call goja.ConstructorCall, constructor, what string) int {
+	size := call.Argument(1).ToInteger()
+	if common.IsNullish(call.Argument(1)) || size < 1 {
+		common.Throw(mi.vu.Runtime(), fmt.Errorf("the %s of %s must be a positive number", what, constructor))
+	}
+	return int(size)
+}
+
+// wait calls the function that waits, with the VU's context, it throws if the
+// context was done before the wait was over.
+func (mi *ModuleInstance) wait(what string, wait func(ctx context.Context) bool) {
+	ctx := mi.vu.Context()
+	if !wait(ctx) {
+		common.Throw(mi.vu.Runtime(), fmt.Errorf("interrupted while waiting to %s: %w", what, ctx.Err()))
+	}
+}
+
+// methods returns a new object with the methods.
+func (mi *ModuleInstance) methods(methods map[string]interface{}) *goja.Object {
+	rt := mi.vu.Runtime()
+	obj := rt.NewObject()
+	for name, method := range methods {
+		if err := obj.Set(name, method); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	return obj
+}
+
+var errInitContext = errors.New("the shared state can't be waited on in the init context")
+
+// mustBeInVUContext throws if the VU is in the init context, where it can't
+// wait for the other VUs.
+func (mi *ModuleInstance) mustBeInVUContext() {
+	if mi.vu.State() == nil {
+		common.Throw(mi.vu.Runtime(), errInitContext)
+	}
+}
@@ -0,0 +1,221 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/event"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+)
+
+// newVU returns the runtime of a VU with the module, in the VU context.
+func newVU(t *testing.T, root *RootModule) *goja.Runtime {
+	t.Helper()
+	runtime := modulestest.NewRuntime(t)
+	mi, ok := root.NewModuleInstance(runtime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	rt := runtime.VU.Runtime()
+	require.NoError(t, rt.Set("state", mi.Exports().Named))
+	runtime.MoveToVUContext(&lib.State{})
+	return rt
+}
+
+func TestCounter(t *testing.T) {
+	t.Parallel()
+
+	root := New()
+	vus := make([]*goja.Runtime, 10)
+	for i := range vus {
+		vus[i] = newVU(t, root)
+	}
+
+	var wg sync.WaitGroup
+	for _, rt := range vus {
+		rt := rt
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rt.RunString(`
+				var logins = new state.Counter("logins");
+				for (var i = 0; i < 100; i++) {
+					logins.add();
+				}
+				logins.add(-50);
+			`)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	v, err := vus[0].RunString(`logins.value()`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), v.ToInteger())
+	v, err = vus[1].RunString(`[logins.set(1), new state.Counter("logins").value()]`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(500), int64(1)}, v.Export())
+}
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	root := New()
+	producer, consumer := newVU(t, root), newVU(t, root)
+
+	_, err := producer.RunString(`
+		var queue = new state.Queue("tokens", 2);
+		queue.push({ token: "a" });
+		if (!queue.tryPush("b") || queue.tryPush("c") || queue.size() !== 2) {
+			throw "the queue should be full";
+		}
+	`)
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := producer.RunString(`queue.push(3)`) // waits for the consumer
+		done <- err
+	}()
+
+	v, err := consumer.RunString(`
+		var queue = new state.Queue("tokens", 10);
+		var values = [queue.pop().token, queue.pop(), queue.pop(), queue.tryPop(), queue.capacity()];
+		values
+	`)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+	assert.Equal(t, []interface{}{"a", "b", int64(3), nil, int64(2)}, v.Export())
+}
+
+func TestSemaphore(t *testing.T) {
+	t.Parallel()
+
+	root := New()
+	first, second := newVU(t, root), newVU(t, root)
+
+	_, err := first.RunString(`
+		var logins = new state.Semaphore("logins", 2);
+		logins.acquire();
+		if (!logins.tryAcquire() || logins.tryAcquire() || logins.available() !== 0) {
+			throw "the semaphore should have no permits";
+		}
+		var mutex = new state.Mutex("login");
+		mutex.lock();
+	`)
+	require.NoError(t, err)
+
+	_, err = second.RunString(`
+		var logins = new state.Semaphore("logins", 10);
+		var mutex = new state.Mutex("login");
+		if (logins.tryAcquire() || mutex.tryLock()) {
+			throw "the semaphore and the mutex should be held by the other VU";
+		}
+	`)
+	require.NoError(t, err)
+	_, err = second.RunString(`mutex.unlock()`)
+	require.ErrorContains(t, err, "can't unlock a mutex that isn't locked by the VU")
+
+	done := make(chan error)
+	go func() {
+		_, err := second.RunString(`
+			var result = mutex.withLock(function() { return logins.withPermit(function() { return 42; }) });
+			if (result !== 42 || logins.available() !== 1 || !mutex.tryLock()) {
+				throw "the permits should be released";
+			}
+		`)
+		done <- err
+	}()
+	_, err = first.RunString(`logins.release(); mutex.unlock()`)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	go func() {
+		_, err := first.RunString(`mutex.withLock(function() {})`) // waits for the other VU
+		done <- err
+	}()
+	_, err = second.RunString(`mutex.unlock()`)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+}
+
+func TestSemaphoreReleasedAtIterationEnd(t *testing.T) {
+	t.Parallel()
+
+	root := New()
+	logger := testutils.NewLogger(t)
+	globalEvents := event.NewEventSystem(10, logger)
+	localEvents := event.NewEventSystem(10, logger)
+
+	runtime := modulestest.NewRuntime(t)
+	runtime.VU.EventsField = common.Events{Global: globalEvents, Local: localEvents}
+	mi, ok := root.NewModuleInstance(runtime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	holder := runtime.VU.Runtime()
+	require.NoError(t, holder.Set("state", mi.Exports().Named))
+	runtime.MoveToVUContext(&lib.State{})
+	other := newVU(t, root)
+
+	// the iteration of the holder throws before unlocking the mutex
+	_, err := holder.RunString(`
+		var mutex = new state.Mutex("login");
+		var logins = new state.Semaphore("logins", 3);
+		logins.acquire();
+		logins.acquire();
+		mutex.lock();
+		throw new Error("oops");
+	`)
+	require.ErrorContains(t, err, "oops")
+	_, err = other.RunString(`
+		var mutex = new state.Mutex("login");
+		var logins = new state.Semaphore("logins", 3);
+		if (mutex.tryLock() || logins.available() !== 1) {
+			throw "the mutex and the semaphore should be held by the other VU";
+		}
+	`)
+	require.NoError(t, err)
+
+	waitDone := localEvents.Emit(&event.Event{Type: event.IterEnd, Data: event.IterData{Error: err}})
+	require.NoError(t, waitDone(context.Background()))
+	_, err = other.RunString(`
+		if (!mutex.tryLock() || logins.available() !== 3) {
+			throw "the mutex and the semaphore should be released";
+		}
+		mutex.unlock();
+	`)
+	require.NoError(t, err)
+
+	// and at the end of the test run
+	_, err = holder.RunString(`mutex.lock()`)
+	require.NoError(t, err)
+	waitDone = globalEvents.Emit(&event.Event{Type: event.Exit, Data: &event.ExitData{}})
+	require.NoError(t, waitDone(context.Background()))
+	_, err = other.RunString(`
+		if (!mutex.tryLock()) {
+			throw "the mutex should be released";
+		}
+	`)
+	require.NoError(t, err)
+}
+
+func TestConstructorExceptions(t *testing.T) {
+	t.Parallel()
+
+	rt := newVU(t, New())
+	cases := map[string]string{
+		`new state.Counter("")`:           "empty name provided to Counter's constructor",
+		`new state.Queue("q")`:            "the capacity of Queue must be a positive number",
+		`new state.Semaphore("s", 0)`:     "the number of permits of Semaphore must be a positive number",
+		`new state.Mutex("m").withLock()`: "a function is expected to be called after waiting to lock the mutex",
+	}
+	for code, expErr := range cases {
+		_, err := rt.RunString(code)
+		require.ErrorContains(t, err, expErr, code)
+	}
+}
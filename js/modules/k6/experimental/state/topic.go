@@ -0,0 +1,182 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/metrics"
+)
+
+// defaultBufferSize is the default number of messages that a subscription
+// keeps until they're read, the newer ones are dropped.
+const defaultBufferSize = 1000
+
+// topic is a publish/subscribe topic shared by all the VUs. The messages are
+// stored as JSON, so they can be read by the other VUs.
+type topic struct {
+	name string
+
+	mu          sync.Mutex
+	subscribers map[*subscription]struct{}
+}
+
+type subscription struct {
+	messages chan message
+}
+
+type message struct {
+	json      string
+	published time.Time
+}
+
+// newTopic is the constructor of the topics, they're identified by their name:
+// `new Topic("token", { metrics: true })`. With the metrics option, the
+// publishing and the delivery of the messages are measured.
+func (mi *ModuleInstance) newTopic(call goja.ConstructorCall) *goja.Object {
+	rt := mi.vu.Runtime()
+	name := mi.name(call, "Topic")
+	var withMetrics bool
+	if opts := call.Argument(1); !common.IsNullish(opts) {
+		withMetrics = opts.ToObject(rt).Get("metrics").ToBoolean()
+	}
+
+	mi.root.mu.Lock()
+	t, ok := mi.root.topics[name]
+	if !ok {
+		t = &topic{name: name, subscribers: make(map[*subscription]struct{})}
+		mi.root.topics[name] = t
+	}
+	mi.root.mu.Unlock()
+
+	toJSON, fromJSON := jsonFuncs(rt)
+	return mi.methods(map[string]interface{}{
+		// publish sends the value to the subscribers, and returns to how many
+		// of them it was delivered; it's dropped for the ones with full buffers
+		"publish": func(v goja.Value) int {
+			msg := message{json: toJSON(v), published: time.Now()}
+			delivered, dropped := t.publish(msg)
+			if withMetrics {
+				mi.pushMetrics(t.name, map[*metrics.Metric]float64{
+					mi.metrics.Published: 1,
+					mi.metrics.Dropped:   float64(dropped),
+				})
+			}
+			return delivered
+		},
+		// subscribe returns a new subscription, that receives the messages
+		// published from now on, until it's unsubscribed
+		"subscribe": func(bufferSize goja.Value) *goja.Object {
+			size := defaultBufferSize
+			if !common.IsNullish(bufferSize) {
+				if size = int(bufferSize.ToInteger()); size < 1 {
+					common.Throw(rt, errors.New("the buffer size of the subscriptions must be a positive number"))
+				}
+			}
+			s := t.subscribe(size)
+			receive := func(msg message) goja.Value {
+				if withMetrics {
+					mi.pushMetrics(t.name, map[*metrics.Metric]float64{
+						mi.metrics.Delivered:        1,
+						mi.metrics.DeliveryDuration: metrics.D(time.Since(msg.published)),
+					})
+				}
+				return fromJSON(msg.json)
+			}
+			return mi.methods(map[string]interface{}{
+				// poll returns the oldest message, or undefined if there isn't any
+				"poll": func() goja.Value {
+					select {
+					case msg := <-s.messages:
+						return receive(msg)
+					default:
+						return goja.Undefined()
+					}
+				},
+				// next returns the oldest message, waiting until there's one
+				"next": func() goja.Value {
+					mi.mustBeInVUContext()
+					var msg message
+					mi.wait("receive a message", func(ctx context.Context) bool {
+						select {
+						case msg = <-s.messages:
+							return true
+						case <-ctx.Done():
+							return false
+						}
+					})
+					return receive(msg)
+				},
+				"pending": func() int {
+					return len(s.messages)
+				},
+				"unsubscribe": func() {
+					t.unsubscribe(s)
+				},
+			})
+		},
+		"subscribers": func() int {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return len(t.subscribers)
+		},
+	})
+}
+
+func (t *topic) publish(msg message) (delivered, dropped int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for s := range t.subscribers {
+		select {
+		case s.messages <- msg:
+			delivered++
+		default:
+			dropped++
+		}
+	}
+	return delivered, dropped
+}
+
+func (t *topic) subscribe(bufferSize int) *subscription {
+	s := &subscription{messages: make(chan message, bufferSize)}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.subscribers[s] = struct{}{}
+	return s
+}
+
+func (t *topic) unsubscribe(s *subscription) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.subscribers, s)
+}
+
+// pushMetrics emits the metrics of the topic, if the VU isn't in the init
+// context.
+func (mi *ModuleInstance) pushMetrics(topic string, values map[*metrics.Metric]float64) {
+	state := mi.vu.State()
+	if state == nil {
+		return
+	}
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	tags := tagsAndMeta.Tags.With("topic", topic)
+	now := time.Now()
+	samples := make([]metrics.Sample, 0, len(values))
+	for metric, value := range values {
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+			Time:       now,
+			Metadata:   tagsAndMeta.Metadata,
+			Value:      value,
+		})
+	}
+	metrics.PushIfNotDone(mi.vu.Context(), state.Samples, metrics.ConnectedSamples{
+		Samples: samples,
+		Tags:    tags,
+		Time:    now,
+	})
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestTopic(t *testing.T) {
+	t.Parallel()
+
+	root := New()
+	publisher, subscriber := newVU(t, root), newVU(t, root)
+
+	_, err := subscriber.RunString(`
+		var topic = new state.Topic("token");
+		var sub = topic.subscribe(2);
+	`)
+	require.NoError(t, err)
+
+	v, err := publisher.RunString(`
+		var topic = new state.Topic("token");
+		[topic.publish({ token: "a" }), topic.publish("b"), topic.publish("c"), topic.subscribers()]
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), int64(1), int64(0), int64(1)}, v.Export())
+
+	done := make(chan goja.Value)
+	go func() {
+		v, err := subscriber.RunString(`[sub.poll().token, sub.next(), sub.pending(), sub.poll(), sub.next()]`)
+		assert.NoError(t, err)
+		done <- v
+	}()
+	require.Eventually(t, func() bool {
+		v, err := publisher.RunString(`topic.publish(3)`)
+		require.NoError(t, err)
+		return v.ToInteger() == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []interface{}{"a", "b", int64(0), nil, int64(3)}, (<-done).Export())
+
+	v, err = subscriber.RunString(`sub.unsubscribe(); topic.publish(4)`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), v.ToInteger())
+}
+
+func TestTopicMetrics(t *testing.T) {
+	t.Parallel()
+
+	runtime := modulestest.NewRuntime(t)
+	mi, ok := New().NewModuleInstance(runtime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	rt := runtime.VU.Runtime()
+	require.NoError(t, rt.Set("state", mi.Exports().Named))
+
+	registry := metrics.NewRegistry()
+	samples := make(chan metrics.SampleContainer, 100)
+	runtime.MoveToVUContext(&lib.State{
+		Samples: samples,
+		Tags:    lib.NewVUStateTags(registry.RootTagSet().With("scenario", "actors")),
+	})
+	_, err := rt.RunString(`
+		var topic = new state.Topic("phase", { metrics: true });
+		var sub = topic.subscribe(1);
+		topic.publish(2);
+		topic.publish(3);
+		sub.next();
+	`)
+	require.NoError(t, err)
+	close(samples)
+
+	values := map[string]float64{}
+	for _, sample := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sample.GetSamples() {
+			topic, _ := s.Tags.Get("topic")
+			assert.Equal(t, "phase", topic)
+			values[s.Metric.Name] += s.Value
+		}
+	}
+	assert.Equal(t, 2.0, values["pubsub_messages_published"])
+	assert.Equal(t, 1.0, values["pubsub_messages_dropped"])
+	assert.Equal(t, 1.0, values["pubsub_messages_delivered"])
+	assert.Contains(t, values, "pubsub_delivery_duration")
+}
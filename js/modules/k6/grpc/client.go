@@ -8,11 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"strings"
 	"time"
 
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/netext"
 	"go.k6.io/k6/lib/netext/grpcext"
 
 	"github.com/dop251/goja"
@@ -234,6 +236,16 @@ func (c *Client) Connect(addr string, params goja.Value) (bool, error) {
 	}
 	opts = append(opts, grpc.WithTransportCredentials(tcred))
 
+	if p.DNS != nil || p.Shaper != nil {
+		dialer, err := netext.ClientDialer(state.Dialer, state.Options.DNS, p.DNS, p.Shaper)
+		if err != nil {
+			return false, err
+		}
+		opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", addr)
+		}))
+	}
+
 	if ua := state.Options.UserAgent; ua.Valid {
 		opts = append(opts, grpc.WithUserAgent(ua.ValueOrZero()))
 	}
@@ -320,13 +332,26 @@ func (c *Client) Invoke(
 
 	p.SetSystemTags(state, c.addr, method)
 
+	host, _, err := net.SplitHostPort(c.addr)
+	if err != nil {
+		host = c.addr
+	}
+	if err := state.RateLimiters.Wait(ctx, host, p.TagsAndMeta.Tags); err != nil {
+		return nil, err
+	}
+
+	ctx, span := startRPCSpan(ctx, state, method, p.Metadata, &p.TagsAndMeta)
+
 	reqmsg := grpcext.Request{
 		MethodDescriptor: methodDesc,
 		Message:          b,
 		TagsAndMeta:      &p.TagsAndMeta,
+		Fault:            state.Faults.Pick(host, p.TagsAndMeta.Tags),
 	}
 
-	return c.conn.Invoke(ctx, method, p.Metadata, reqmsg)
+	resp, err := c.conn.Invoke(ctx, method, p.Metadata, reqmsg)
+	endRPCSpan(span, resp, err)
+	return resp, err
 }
 
 // Close will close the client gRPC connection
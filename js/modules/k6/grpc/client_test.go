@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	k6grpc "go.k6.io/k6/js/modules/k6/grpc"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext/grpcext"
 	"go.k6.io/k6/lib/testutils/httpmultibin"
 	grpcanytesting "go.k6.io/k6/lib/testutils/httpmultibin/grpc_any_testing"
@@ -30,6 +31,7 @@ import (
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/metadata"
 	v1alphagrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	grpcstats "google.golang.org/grpc/stats"
@@ -199,6 +201,41 @@ func TestClient(t *testing.T) {
 				client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`},
 			vuString: codeBlock{code: `client.connect("GRPCBIN_ADDR");`},
 		},
+		{
+			name: "ConnectHosts",
+			initString: codeBlock{code: `
+				var client = new grpc.Client();
+				client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`},
+			vuString: codeBlock{code: `
+				client.connect("HTTP2BIN_DOMAIN:1", { timeout: "5s", hosts: { "HTTP2BIN_DOMAIN:1": "HTTP2BIN_IP:HTTP2BIN_PORT" } });`},
+		},
+		{
+			name: "ConnectInvalidDNS",
+			initString: codeBlock{code: `
+				var client = new grpc.Client();
+				client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`},
+			vuString: codeBlock{
+				code: `client.connect("GRPCBIN_ADDR", { dns: { ttl: "-1" } });`,
+				err:  "invalid DNS TTL: -1",
+			},
+		},
+		{
+			name: "ConnectShaping",
+			initString: codeBlock{code: `
+				var client = new grpc.Client();
+				client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`},
+			vuString: codeBlock{code: `client.connect("GRPCBIN_ADDR", { shaping: { latency: "10ms", bandwidth: 100000 } });`},
+		},
+		{
+			name: "ConnectInvalidShaping",
+			initString: codeBlock{code: `
+				var client = new grpc.Client();
+				client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`},
+			vuString: codeBlock{
+				code: `client.connect("GRPCBIN_ADDR", { shaping: { loss: 1 } });`,
+				err:  "the loss of the traffic shaping must be from 0 to 1",
+			},
+		},
 		{
 			name: "InvokeNotFound",
 			initString: codeBlock{code: `
@@ -1269,3 +1306,41 @@ func TestClientConnectionReflectMetadata(t *testing.T) {
 
 	assert.True(t, foundReflectionCall, "expected to find a reflection call in the logs, but didn't")
 }
+
+func TestClientFaults(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestState(t)
+	ts.httpBin.GRPCStub.EmptyCallFunc = func(context.Context, *grpc_testing.Empty) (*grpc_testing.Empty, error) {
+		return &grpc_testing.Empty{}, nil
+	}
+	val, err := ts.Run(`
+		var client = new grpc.Client();
+		client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`)
+	assertResponse(t, codeBlock{}, err, val, ts)
+
+	ts.ToVUContext()
+	faults, err := lib.NewFaultInjector([]lib.Fault{
+		{Tags: map[string]string{"fault": "drop"}, Rate: null.FloatFrom(1), Drop: null.BoolFrom(true)},
+		{Tags: map[string]string{"fault": "status"}, Rate: null.FloatFrom(1), Status: null.IntFrom(403)},
+	})
+	require.NoError(t, err)
+	ts.VU.State().Faults = faults
+
+	vuString := codeBlock{code: `
+		client.connect("GRPCBIN_ADDR");
+		var resp = client.invoke("grpc.testing.TestService/EmptyCall", {}, { tags: { fault: "drop" } });
+		if (resp.status !== grpc.StatusUnavailable || resp.error.message !== "request dropped by an injected fault") {
+			throw new Error("unexpected dropped response: " + JSON.stringify(resp.error));
+		}
+		resp = client.invoke("grpc.testing.TestService/EmptyCall", {}, { tags: { fault: "status" } });
+		if (resp.status !== grpc.StatusPermissionDenied) {
+			throw new Error("unexpected status: " + resp.status);
+		}
+		resp = client.invoke("grpc.testing.TestService/EmptyCall", {});
+		if (resp.status !== grpc.StatusOK) {
+			throw new Error("unexpected status of the request without faults: " + resp.status);
+		}`}
+	val, err = ts.Run(vuString.code)
+	assertResponse(t, vuString, err, val, ts)
+}
@@ -10,6 +10,7 @@ import (
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 	"google.golang.org/grpc/metadata"
@@ -31,35 +32,41 @@ func newCallParams(vu modules.VU, input goja.Value) (*callParams, error) {
 		TagsAndMeta: vu.State().Tags.GetCurrentValues(),
 	}
 
-	if common.IsNullish(input) {
-		return result, nil
-	}
+	if !common.IsNullish(input) {
+		rt := vu.Runtime()
+		params := input.ToObject(rt)
 
-	rt := vu.Runtime()
-	params := input.ToObject(rt)
+		for _, k := range params.Keys() {
+			switch k {
+			case "metadata":
+				md, err := newMetadata(params.Get(k))
+				if err != nil {
+					return result, fmt.Errorf("invalid metadata param: %w", err)
+				}
 
-	for _, k := range params.Keys() {
-		switch k {
-		case "metadata":
-			md, err := newMetadata(params.Get(k))
-			if err != nil {
-				return result, fmt.Errorf("invalid metadata param: %w", err)
+				result.Metadata = md
+			case "tags":
+				if err := common.ApplyCustomUserTags(rt, &result.TagsAndMeta, params.Get(k)); err != nil {
+					return result, fmt.Errorf("metric tags: %w", err)
+				}
+			case "timeout":
+				var err error
+				v := params.Get(k).Export()
+				result.Timeout, err = types.GetDurationValue(v)
+				if err != nil {
+					return result, fmt.Errorf("invalid timeout value: %w", err)
+				}
+			default:
+				return result, fmt.Errorf("unknown param: %q", k)
 			}
+		}
+	}
 
-			result.Metadata = md
-		case "tags":
-			if err := common.ApplyCustomUserTags(rt, &result.TagsAndMeta, params.Get(k)); err != nil {
-				return result, fmt.Errorf("metric tags: %w", err)
-			}
-		case "timeout":
-			var err error
-			v := params.Get(k).Export()
-			result.Timeout, err = types.GetDurationValue(v)
-			if err != nil {
-				return result, fmt.Errorf("invalid timeout value: %w", err)
-			}
-		default:
-			return result, fmt.Errorf("unknown param: %q", k)
+	// the metadata of the VU, like the correlation ID, is sent in the gRPC
+	// metadata that isn't set by the call
+	for header, value := range lib.MetadataHeaderValues(vu.State().MetadataHeaders, result.TagsAndMeta.Metadata) {
+		if len(result.Metadata.Get(header)) == 0 {
+			result.Metadata.Set(header, value)
 		}
 	}
 
@@ -128,6 +135,10 @@ type connectParams struct {
 	MaxReceiveSize        int64
 	MaxSendSize           int64
 	TLS                   map[string]interface{}
+	// The DNS configuration of the connection, nil for the one of the VU.
+	DNS *netext.ClientDNS
+	// The traffic shaping of the connection, nil for the one of the VU.
+	Shaper *netext.Shaper
 }
 
 func newConnectParams(vu modules.VU, input goja.Value) (*connectParams, error) { //nolint:gocognit
@@ -147,6 +158,7 @@ func newConnectParams(vu modules.VU, input goja.Value) (*connectParams, error) {
 	rt := vu.Runtime()
 	params := input.ToObject(rt)
 
+	var hosts, dns interface{}
 	for _, k := range params.Keys() {
 		v := params.Get(k).Export()
 
@@ -198,10 +210,25 @@ func newConnectParams(vu modules.VU, input goja.Value) (*connectParams, error) {
 			if err := parseConnectTLSParam(result, v); err != nil {
 				return result, err
 			}
+		case "hosts":
+			hosts = v
+		case "dns":
+			dns = v
+		case "shaping":
+			var err error
+			if result.Shaper, err = netext.ParseShaper(v); err != nil {
+				return result, err
+			}
 		default:
 			return result, fmt.Errorf("unknown connect param: %q", k)
 		}
 	}
+	if hosts != nil || dns != nil {
+		var err error
+		if result.DNS, err = netext.ParseClientDNS(hosts, dns); err != nil {
+			return result, err
+		}
+	}
 
 	return result, nil
 }
@@ -98,6 +98,26 @@ func TestCallParamsMetadata(t *testing.T) {
 	}
 }
 
+func TestCallParamsMetadataHeaders(t *testing.T) {
+	t.Parallel()
+
+	testRuntime, params := newParamsTestRuntime(t, `{metadata: {"x-user-id": "bob"}}`)
+	state := testRuntime.VU.State()
+	state.MetadataHeaders = map[string]string{"correlation_id": "X-Correlation-ID", "user": "X-User-ID"}
+	state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+		tagsAndMeta.SetMetadata("correlation_id", "1234")
+		tagsAndMeta.SetMetadata("user", "alice")
+	})
+
+	p, err := newCallParams(testRuntime.VU, params)
+	require.NoError(t, err)
+	assert.Equal(t, metadata.New(map[string]string{"x-correlation-id": "1234", "x-user-id": "bob"}), p.Metadata)
+
+	p, err = newCallParams(testRuntime.VU, goja.Undefined())
+	require.NoError(t, err)
+	assert.Equal(t, metadata.New(map[string]string{"x-correlation-id": "1234", "x-user-id": "alice"}), p.Metadata)
+}
+
 func TestCallParamsTimeOutParse(t *testing.T) {
 	t.Parallel()
 
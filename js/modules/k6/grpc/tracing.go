@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext/grpcext"
+	"go.k6.io/k6/metrics"
+)
+
+// metadataCarrier propagates the trace context in the metadata of a request.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// startRPCSpan starts the span of the invocation of the method, that is in
+// the /package.Service/Method form.
+func startRPCSpan(
+	ctx context.Context, state *lib.State, method string, md metadata.MD, tagsAndMeta *metrics.TagsAndMeta,
+) (context.Context, trace.Span) {
+	name := strings.TrimPrefix(method, "/")
+	service, rpcMethod, _ := strings.Cut(name, "/")
+	return state.StartRequestSpan(ctx, name, metadataCarrier(md), tagsAndMeta,
+		semconv.RPCSystemGRPC,
+		semconv.RPCService(service),
+		semconv.RPCMethod(rpcMethod),
+	)
+}
+
+// endRPCSpan ends the span of an invocation, with an error status if it
+// failed. A nil response means the invocation couldn't be made at all.
+func endRPCSpan(span trace.Span, resp *grpcext.Response, err error) {
+	defer span.End()
+	if !span.IsRecording() {
+		return
+	}
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case resp != nil:
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(resp.Status)))
+		if resp.Status != grpccodes.OK {
+			span.SetStatus(codes.Error, resp.Status.String())
+		}
+	}
+}
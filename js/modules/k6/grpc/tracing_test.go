@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext/grpcext"
+	"go.k6.io/k6/metrics"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (r *spanRecorder) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+func TestRPCSpan(t *testing.T) {
+	t.Parallel()
+
+	recorder := &spanRecorder{}
+	registry := metrics.NewRegistry()
+	state := &lib.State{TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(recorder))}
+	md := metadata.New(map[string]string{"x-custom": "value"})
+	tagsAndMeta := &metrics.TagsAndMeta{Tags: registry.RootTagSet()}
+
+	_, span := startRPCSpan(context.Background(), state, "/grpc.testing.TestService/UnaryCall", md, tagsAndMeta)
+	endRPCSpan(span, &grpcext.Response{Status: grpccodes.NotFound}, nil)
+
+	sc := span.SpanContext()
+	assert.Equal(t, []string{"00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"}, md.Get("traceparent"))
+	assert.Equal(t, []string{"value"}, md.Get("x-custom"))
+	assert.Equal(t, sc.TraceID().String(), tagsAndMeta.Metadata[lib.MetadataTraceID])
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.Len(t, recorder.spans, 1)
+	recorded := recorder.spans[0]
+	assert.Equal(t, "grpc.testing.TestService/UnaryCall", recorded.Name())
+	assert.Equal(t, codes.Error, recorded.Status().Code)
+	assert.Equal(t, "NotFound", recorded.Status().Description)
+	assert.Contains(t, recorded.Attributes(), attribute.String("rpc.service", "grpc.testing.TestService"))
+	assert.Contains(t, recorded.Attributes(), attribute.String("rpc.method", "UnaryCall"))
+	assert.Contains(t, recorded.Attributes(), attribute.Int("rpc.grpc.status_code", int(grpccodes.NotFound)))
+}
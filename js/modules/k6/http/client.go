@@ -0,0 +1,214 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dop251/goja"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// Client represents a stand-alone HTTP client.
+type Client struct {
+	moduleInstance   *ModuleInstance
+	responseCallback func(int) bool
+	cache            *httpext.Cache
+
+	// the TLS configuration of the clients created with new http.Client(),
+	// nil for the default client, that uses the one of the VU
+	tlsConfig *netext.TLSClientConfig
+	// the DNS configuration of the client, nil if it uses the one of the VU
+	dns *netext.ClientDNS
+	// the traffic shaping of the client, nil if it uses the one of the VU
+	shaper *netext.Shaper
+	// the transport with the configuration, created with the first request
+	transport *http.Transport
+}
+
+// methods returns the methods of the client, the same ones of the module for
+// the default client.
+func (c *Client) methods() map[string]interface{} {
+	return map[string]interface{}{
+		"get":                 c.getNoBodyMethodClosure(http.MethodGet),
+		"head":                c.getNoBodyMethodClosure(http.MethodHead),
+		"post":                c.getMethodClosure(http.MethodPost),
+		"put":                 c.getMethodClosure(http.MethodPut),
+		"patch":               c.getMethodClosure(http.MethodPatch),
+		"del":                 c.getMethodClosure(http.MethodDelete),
+		"options":             c.getMethodClosure(http.MethodOptions),
+		"request":             c.Request,
+		"asyncRequest":        c.asyncRequest,
+		"batch":               c.Batch,
+		"setResponseCallback": c.SetResponseCallback,
+	}
+}
+
+// newClient creates a new client, with its own TLS and DNS configuration,
+// traffic shaping and connections, e.g.
+// new http.Client({ tls: { cacerts: [ca], pins: [pin] } }) or
+// new http.Client({ hosts: { "test.k6.io": "10.0.0.5" } }).
+func (mi *ModuleInstance) newClient(call goja.ConstructorCall) *goja.Object {
+	rt := mi.vu.Runtime()
+	c := &Client{
+		moduleInstance:   mi,
+		responseCallback: defaultExpectedStatuses.match,
+		tlsConfig:        &netext.TLSClientConfig{},
+	}
+
+	if params := call.Argument(0); !common.IsNullish(params) {
+		var hosts, dns interface{}
+		paramsObj := params.ToObject(rt)
+		for _, k := range paramsObj.Keys() {
+			switch k {
+			case "tls":
+				if err := parseClientTLSParams(c.tlsConfig, paramsObj.Get(k).Export()); err != nil {
+					common.Throw(rt, err)
+				}
+			case "hosts":
+				hosts = paramsObj.Get(k).Export()
+			case "dns":
+				dns = paramsObj.Get(k).Export()
+			case "shaping":
+				var err error
+				if c.shaper, err = netext.ParseShaper(paramsObj.Get(k).Export()); err != nil {
+					common.Throw(rt, err)
+				}
+			default:
+				common.Throw(rt, fmt.Errorf("unknown HTTP client parameter '%s'", k))
+			}
+		}
+		if hosts != nil || dns != nil {
+			var err error
+			if c.dns, err = netext.ParseClientDNS(hosts, dns); err != nil {
+				common.Throw(rt, err)
+			}
+		}
+	}
+
+	obj := rt.NewObject()
+	for name, method := range c.methods() {
+		if err := obj.Set(name, method); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	return obj
+}
+
+// parseClientTLSParams parses the TLS parameters of a client.
+//
+//nolint:cyclop
+func parseClientTLSParams(config *netext.TLSClientConfig, v interface{}) error {
+	params, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid tls value: '%#v', expected (optional) keys: "+
+			"cacerts, pins, sessionResumption, minVersion and maxVersion", v)
+	}
+	for key, value := range params {
+		switch key {
+		case "cacerts":
+			certs, ok := stringOrStrings(value)
+			if !ok {
+				return fmt.Errorf("invalid tls cacerts value: '%#v', "+
+					"it needs to be a PEM formatted string or an array of them", value)
+			}
+			for _, cert := range certs {
+				if err := config.AddRootCAs([]byte(cert)); err != nil {
+					return err
+				}
+			}
+		case "pins":
+			pins, ok := stringOrStrings(value)
+			if !ok {
+				return fmt.Errorf("invalid tls pins value: '%#v', it needs to be an array of strings", value)
+			}
+			for _, pin := range pins {
+				hash, err := netext.ParseSPKIPin(pin)
+				if err != nil {
+					return err
+				}
+				config.Pins = append(config.Pins, hash)
+			}
+		case "sessionResumption":
+			resumption, ok := value.(bool)
+			if !ok {
+				return fmt.Errorf("invalid tls sessionResumption value: '%#v', it needs to be a boolean", value)
+			}
+			config.SessionResumption = null.BoolFrom(resumption)
+		case "minVersion", "maxVersion":
+			str, _ := value.(string)
+			version, ok := lib.SupportedTLSVersions[str]
+			if !ok {
+				return fmt.Errorf("invalid tls %s value: '%#v', it needs to be one of "+
+					"http.TLS_1_0, http.TLS_1_1, http.TLS_1_2 or http.TLS_1_3", key, value)
+			}
+			if key == "minVersion" {
+				config.MinVersion = uint16(version)
+			} else {
+				config.MaxVersion = uint16(version)
+			}
+		default:
+			return fmt.Errorf("unknown tls parameter '%s'", key)
+		}
+	}
+	if config.MinVersion != 0 && config.MaxVersion != 0 && config.MinVersion > config.MaxVersion {
+		return fmt.Errorf("the tls minVersion can't be higher than the maxVersion")
+	}
+	return nil
+}
+
+func stringOrStrings(v interface{}) ([]string, bool) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		strs := make([]string, len(v))
+		for i, e := range v {
+			str, ok := e.(string)
+			if !ok {
+				return nil, false
+			}
+			strs[i] = str
+		}
+		return strs, true
+	default:
+		return nil, false
+	}
+}
+
+// getTransport returns the transport of the client, nil if it uses the one of
+// the VU.
+func (c *Client) getTransport(state *lib.State) (http.RoundTripper, error) {
+	if c.tlsConfig == nil {
+		return nil, nil //nolint:nilnil // the VU's one
+	}
+	if c.transport == nil {
+		transport, err := httpext.NewClientTransport(state.Transport, c.tlsConfig.Apply(state.TLSConfig))
+		if err != nil {
+			return nil, err
+		}
+		if c.dns != nil || c.shaper != nil {
+			dialer, err := netext.ClientDialer(state.Dialer, state.Options.DNS, c.dns, c.shaper)
+			if err != nil {
+				return nil, err
+			}
+			// the maxConns limit is separate for the connections of the client
+			limitedDialer := netext.NewLimitedDialer(dialer, int(state.Options.MaxConns.Int64))
+			if ld, ok := limitedDialer.(*netext.LimitedDialer); ok {
+				ld.SetIdleCloser(transport.CloseIdleConnections)
+			}
+			transport.DialContext = limitedDialer.DialContext
+		}
+		c.transport = transport
+	}
+	// it's registered on every use, since the VU forgets its transports when
+	// it's deactivated
+	if state.AddTransport != nil {
+		state.AddTransport(c.transport)
+	}
+	return c.transport, nil
+}
@@ -0,0 +1,214 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestClientTLS(t *testing.T) {
+	t.Parallel()
+
+	newTestCaseWithTLSVersion := func(t *testing.T) *httpTestCase {
+		ts := newTestCase(t)
+		ts.tb.Mux.HandleFunc("/tls-version", http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+			fmt.Fprint(resp, lib.SupportedTLSVersionsToString[lib.TLSVersion(req.TLS.Version)])
+		}))
+		return ts
+	}
+	serverCert := func(ts *httpTestCase) (string, string) {
+		cert := ts.tb.ServerHTTPS.Certificate()
+		hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		return string(certPEM), "sha256/" + base64.StdEncoding.EncodeToString(hash[:])
+	}
+
+	t.Run("Versions", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCaseWithTLSVersion(t)
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var client = new http.Client({ tls: { maxVersion: http.TLS_1_2 } });
+			var res = client.get("HTTPSBIN_URL/tls-version");
+			if (res.body != "tls1.2") {
+				throw new Error("unexpected tls version of the client: " + res.body);
+			}
+			res = http.get("HTTPSBIN_URL/tls-version");
+			if (res.body != "tls1.3") {
+				throw new Error("unexpected tls version of the default client: " + res.body);
+			}
+		`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("CACerts", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCaseWithTLSVersion(t)
+		certPEM, _ := serverCert(ts)
+		require.NoError(t, ts.runtime.VU.Runtime().Set("serverCert", certPEM))
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var client = new http.Client({ tls: { cacerts: [serverCert] } });
+			client.get("HTTPSBIN_URL/tls-version");
+		`))
+		assert.NoError(t, err)
+
+		_, err = ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var client = new http.Client({ tls: { cacerts: [http.TLS_1_2] } });
+		`))
+		assert.ErrorContains(t, err, "no valid PEM certificates were found in the CA certificates")
+	})
+
+	t.Run("Pins", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCaseWithTLSVersion(t)
+		_, pin := serverCert(ts)
+		require.NoError(t, ts.runtime.VU.Runtime().Set("serverPin", pin))
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var client = new http.Client({ tls: { pins: [serverPin] } });
+			client.get("HTTPSBIN_URL/tls-version");
+		`))
+		assert.NoError(t, err)
+
+		_, err = ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var client = new http.Client({ tls: { pins: ["` + base64.StdEncoding.EncodeToString(make([]byte, 32)) + `"] } });
+			client.get("HTTPSBIN_URL/tls-version");
+		`))
+		assert.ErrorContains(t, err, "none of the certificates of the server matches the pinned public keys")
+	})
+
+	t.Run("SessionResumption", func(t *testing.T) {
+		t.Parallel()
+
+		for _, resumption := range []bool{true, false} {
+			resumption := resumption
+			t.Run(fmt.Sprint(resumption), func(t *testing.T) {
+				t.Parallel()
+				ts := newTestCaseWithTLSVersion(t)
+				// every request makes a new connection, so a new handshake
+				transport := ts.tb.HTTPTransport.Clone()
+				transport.DisableKeepAlives = true
+				ts.runtime.VU.State().Transport = transport
+
+				_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(fmt.Sprintf(`
+					var client = new http.Client({ tls: { sessionResumption: %t } });
+					client.get("HTTPSBIN_URL/tls-version");
+					client.get("HTTPSBIN_URL/tls-version");
+				`, resumption)))
+				require.NoError(t, err)
+
+				var resumed []float64
+				for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+					for _, sample := range sc.GetSamples() {
+						if sample.Metric.Name == metrics.HTTPReqTLSResumedName {
+							resumed = append(resumed, sample.Value)
+						}
+					}
+				}
+				assert.Equal(t, []float64{0, metrics.B(resumption)}, resumed)
+			})
+		}
+	})
+
+	t.Run("InvalidParams", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+
+		for script, errMsg := range map[string]string{
+			`new http.Client({ timeout: 10 })`:                                                 "unknown HTTP client parameter 'timeout'",
+			`new http.Client({ tls: "tls1.2" })`:                                               "invalid tls value",
+			`new http.Client({ tls: { ciphers: [] } })`:                                        "unknown tls parameter 'ciphers'",
+			`new http.Client({ tls: { pins: ["abc"] } })`:                                      "invalid pin 'abc'",
+			`new http.Client({ tls: { sessionResumption: "yes" } })`:                           "invalid tls sessionResumption value",
+			`new http.Client({ tls: { minVersion: "tls9" } })`:                                 "invalid tls minVersion value",
+			`new http.Client({ tls: { minVersion: http.TLS_1_3, maxVersion: http.TLS_1_2 } })`: "the tls minVersion can't be higher than the maxVersion",
+		} {
+			_, err := ts.runtime.VU.Runtime().RunString(script)
+			assert.ErrorContains(t, err, errMsg, script)
+		}
+	})
+}
+
+func TestClientDNS(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Hosts", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.runtime.VU.State().Dialer = ts.tb.Dialer
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var blue = new http.Client({ hosts: { "HTTPBIN_DOMAIN:1": "HTTPBIN_IP:HTTPBIN_PORT" } });
+			var res = blue.get("http://HTTPBIN_DOMAIN:1/get");
+			if (res.status != 200) {
+				throw new Error("unexpected status of the client: " + res.status);
+			}
+			res = http.get("http://HTTPBIN_DOMAIN:1/get", { throw: false });
+			if (res.status != 0) {
+				throw new Error("unexpected status of the default client: " + res.status);
+			}
+		`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("InvalidParams", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+
+		for script, errMsg := range map[string]string{
+			`new http.Client({ hosts: { "test.k6.io": 1 } })`: "invalid hosts value",
+			`new http.Client({ dns: { ttl: "-1" } })`:         "invalid DNS TTL: -1",
+			`new http.Client({ dns: { select: "any" } })`:     "invalid dns value",
+			`new http.Client({ dns: { servers: [] } })`:       "unknown dns parameter 'servers'",
+		} {
+			_, err := ts.runtime.VU.Runtime().RunString(script)
+			assert.ErrorContains(t, err, errMsg, script)
+		}
+	})
+}
+
+func TestClientShaping(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Latency", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		ts.runtime.VU.State().Dialer = ts.tb.Dialer
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var slow = new http.Client({ shaping: { latency: "200ms" } });
+			var res = slow.get("HTTPBIN_URL/get");
+			if (res.status != 200) {
+				throw new Error("unexpected status: " + res.status);
+			}
+			// a round trip for the connection and one for the request
+			if (res.timings.connecting < 200 || res.timings.waiting < 200) {
+				throw new Error("unexpected timings: " + JSON.stringify(res.timings));
+			}
+		`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("InvalidParams", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+
+		for script, errMsg := range map[string]string{
+			`new http.Client({ shaping: { latency: "fast" } })`: "invalid traffic shaping",
+			`new http.Client({ shaping: { loss: 2 } })`:         "the loss of the traffic shaping must be from 0 to 1",
+		} {
+			_, err := ts.runtime.VU.Runtime().RunString(script)
+			assert.ErrorContains(t, err, errMsg, script)
+		}
+	})
+}
@@ -1,13 +1,18 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
 	neturl "net/url"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/dop251/goja"
 	"go.k6.io/k6/js/common"
@@ -114,3 +119,180 @@ func (j CookieJar) Delete(url, name string) error {
 
 	return nil
 }
+
+// ExportedCookie is the serializable representation of a cookie, as returned
+// by CookieJar.Export() and accepted by CookieJar.Import(). It has the same
+// fields as the cookies returned by the cookies() method of the browser
+// contexts, so the session of a browser can be continued with k6/http, and
+// the other way around.
+type ExportedCookie struct {
+	// URL is only used by Import(), for the cookies set for a URL instead
+	// of a domain.
+	URL   string `js:"url" json:"url,omitempty"`
+	Name  string `js:"name" json:"name"`
+	Value string `js:"value" json:"value"`
+	// Domain is prefixed with a dot if the cookie is also sent to the
+	// subdomains, like the browsers do.
+	Domain string `js:"domain" json:"domain"`
+	Path   string `js:"path" json:"path"`
+	// Expires is in seconds since the epoch, -1 for the session cookies.
+	Expires  int64 `js:"expires" json:"expires"`
+	HTTPOnly bool  `js:"httpOnly" json:"httpOnly"`
+	Secure   bool  `js:"secure" json:"secure"`
+}
+
+// Export returns all of the cookies in the jar, with all of their attributes,
+// in a form that can be serialized to JSON, e.g. returned from setup() and
+// later imported in another jar.
+func (j CookieJar) Export() ([]ExportedCookie, error) {
+	return jarCookies(j.Jar)
+}
+
+// jarCookies returns all of the unexpired cookies in the jar.
+//
+// The cookiejar package doesn't have a way to list its cookies, so they are
+// read from its unexported fields, which have been the same since Go 1.1.
+func jarCookies(jar *cookiejar.Jar) ([]ExportedCookie, error) {
+	errUnsupported := errors.New("the cookies of the jar can't be listed with this version of Go")
+
+	v := reflect.ValueOf(jar).Elem()
+	muField, entriesField := v.FieldByName("mu"), v.FieldByName("entries")
+	if !muField.IsValid() || muField.Type() != reflect.TypeOf(sync.Mutex{}) ||
+		!entriesField.IsValid() || entriesField.Kind() != reflect.Map {
+		return nil, errUnsupported
+	}
+	entryType := entriesField.Type().Elem().Elem()
+	for name, typ := range map[string]reflect.Type{
+		"Name": reflect.TypeOf(""), "Value": reflect.TypeOf(""), "Domain": reflect.TypeOf(""),
+		"Path": reflect.TypeOf(""), "Secure": reflect.TypeOf(false), "HttpOnly": reflect.TypeOf(false),
+		"Persistent": reflect.TypeOf(false), "HostOnly": reflect.TypeOf(false), "Expires": reflect.TypeOf(time.Time{}),
+	} {
+		if f, ok := entryType.FieldByName(name); !ok || f.Type != typ {
+			return nil, errUnsupported
+		}
+	}
+
+	mu := (*sync.Mutex)(unsafe.Pointer(muField.UnsafeAddr()))                                       //nolint:gosec
+	entries := reflect.NewAt(entriesField.Type(), unsafe.Pointer(entriesField.UnsafeAddr())).Elem() //nolint:gosec
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	var cookies []ExportedCookie
+	for domains := entries.MapRange(); domains.Next(); {
+		for ids := domains.Value().MapRange(); ids.Next(); {
+			e := ids.Value()
+			c := ExportedCookie{
+				Name:     e.FieldByName("Name").String(),
+				Value:    e.FieldByName("Value").String(),
+				Domain:   e.FieldByName("Domain").String(),
+				Path:     e.FieldByName("Path").String(),
+				Expires:  -1,
+				HTTPOnly: e.FieldByName("HttpOnly").Bool(),
+				Secure:   e.FieldByName("Secure").Bool(),
+			}
+			if e.FieldByName("Persistent").Bool() {
+				expires := e.FieldByName("Expires").Interface().(time.Time) //nolint:forcetypeassert
+				if !expires.After(now) {
+					continue
+				}
+				c.Expires = expires.Unix()
+			}
+			if !e.FieldByName("HostOnly").Bool() {
+				c.Domain = "." + c.Domain
+			}
+			cookies = append(cookies, c)
+		}
+	}
+
+	sort.Slice(cookies, func(i, k int) bool {
+		a, b := cookies[i], cookies[k]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Name < b.Name
+	})
+	return cookies, nil
+}
+
+// toHTTP returns the cookie, and the URL it has to be set for.
+func (c ExportedCookie) toHTTP() (*neturl.URL, *http.Cookie, error) {
+	if c.Name == "" {
+		return nil, nil, errors.New("the name is missing")
+	}
+
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		HttpOnly: c.HTTPOnly,
+		Secure:   c.Secure,
+	}
+	// the browsers use -1 for the session cookies
+	if c.Expires > 0 {
+		cookie.Expires = time.Unix(c.Expires, 0)
+	}
+	// the cookies that are also sent to the subdomains have their domain
+	// prefixed with a dot, the others are host-only
+	host := strings.TrimPrefix(c.Domain, ".")
+	if host != c.Domain {
+		cookie.Domain = host
+	}
+
+	if c.URL != "" {
+		u, err := neturl.Parse(c.URL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid url: %w", err)
+		}
+		if u.Host == "" {
+			return nil, nil, fmt.Errorf("the url %q has no host", c.URL)
+		}
+		return u, cookie, nil
+	}
+	if host == "" {
+		return nil, nil, errors.New("either a url or a domain must be specified")
+	}
+	scheme := "http"
+	if c.Secure {
+		scheme = "https"
+	}
+	return &neturl.URL{Scheme: scheme, Host: host, Path: c.Path}, cookie, nil
+}
+
+// Import adds the given cookies to the jar. They can be specified either as
+// an array of objects, as returned by Export() or by the cookies() method of
+// the browser contexts, or as its JSON representation.
+func (j CookieJar) Import(cookies goja.Value) error {
+	if common.IsNullish(cookies) {
+		return nil
+	}
+
+	var imported []ExportedCookie
+	if str, ok := cookies.Export().(string); ok {
+		if err := json.Unmarshal([]byte(str), &imported); err != nil {
+			return fmt.Errorf("unable to parse the exported cookies: %w", err)
+		}
+	} else {
+		// the objects are converted through JSON, since the browser cookies
+		// are Go structs with JSON tags and not plain JS objects
+		data, err := json.Marshal(cookies.Export())
+		if err == nil {
+			err = json.Unmarshal(data, &imported)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to import the cookies: %w", err)
+		}
+	}
+
+	for i, c := range imported {
+		u, cookie, err := c.toHTTP()
+		if err != nil {
+			return fmt.Errorf("invalid cookie %d (%q): %w", i, c.Name, err)
+		}
+		j.Jar.SetCookies(u, []*http.Cookie{cookie})
+	}
+	return nil
+}
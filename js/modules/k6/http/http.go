@@ -1,7 +1,6 @@
 package http
 
 import (
-	"net/http"
 	"net/http/cookiejar"
 
 	"github.com/dop251/goja"
@@ -68,27 +67,10 @@ func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	// TODO: refactor so the Client actually has better APIs and these are
 	// wrappers (facades) that convert the old k6 idiosyncratic APIs to the new
 	// proper Client ones that accept Request objects and don't suck
-	mustExport("get", func(url goja.Value, args ...goja.Value) (*Response, error) {
-		// http.get(url, params) doesn't have a body argument, so we add undefined
-		// as the third argument to http.request(method, url, body, params)
-		args = append([]goja.Value{goja.Undefined()}, args...)
-		return mi.defaultClient.Request(http.MethodGet, url, args...)
-	})
-	mustExport("head", func(url goja.Value, args ...goja.Value) (*Response, error) {
-		// http.head(url, params) doesn't have a body argument, so we add undefined
-		// as the third argument to http.request(method, url, body, params)
-		args = append([]goja.Value{goja.Undefined()}, args...)
-		return mi.defaultClient.Request(http.MethodHead, url, args...)
-	})
-	mustExport("post", mi.defaultClient.getMethodClosure(http.MethodPost))
-	mustExport("put", mi.defaultClient.getMethodClosure(http.MethodPut))
-	mustExport("patch", mi.defaultClient.getMethodClosure(http.MethodPatch))
-	mustExport("del", mi.defaultClient.getMethodClosure(http.MethodDelete))
-	mustExport("options", mi.defaultClient.getMethodClosure(http.MethodOptions))
-	mustExport("request", mi.defaultClient.Request)
-	mustExport("asyncRequest", mi.defaultClient.asyncRequest)
-	mustExport("batch", mi.defaultClient.Batch)
-	mustExport("setResponseCallback", mi.defaultClient.SetResponseCallback)
+	for name, method := range mi.defaultClient.methods() {
+		mustExport(name, method)
+	}
+	mustExport("Client", mi.newClient)
 
 	mustExport("expectedStatuses", mi.expectedStatuses) // TODO: refactor?
 
@@ -141,13 +123,19 @@ func (mi *ModuleInstance) defineConstants() {
 	mustAddProp("OCSP_REASON_AA_COMPROMISE", netext.OCSP_REASON_AA_COMPROMISE)
 }
 
-func (mi *ModuleInstance) newCookieJar(_ goja.ConstructorCall) *goja.Object {
+// newCookieJar creates a new, empty cookie jar. If cookies exported from
+// another jar are passed, they are imported in the new one.
+func (mi *ModuleInstance) newCookieJar(call goja.ConstructorCall) *goja.Object {
 	rt := mi.vu.Runtime()
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		common.Throw(rt, err)
 	}
-	return rt.ToValue(&CookieJar{mi, jar}).ToObject(rt)
+	cj := &CookieJar{mi, jar}
+	if err := cj.Import(call.Argument(0)); err != nil {
+		common.Throw(rt, err)
+	}
+	return rt.ToValue(cj).ToObject(rt)
 }
 
 // getVUCookieJar returns the active cookie jar for the current VU.
@@ -173,11 +161,3 @@ func (mi *ModuleInstance) URL(parts []string, pieces ...string) (httpext.URL, er
 	}
 	return httpext.NewURL(urlstr, name)
 }
-
-// Client represents a stand-alone HTTP client.
-//
-// TODO: move to its own file
-type Client struct {
-	moduleInstance   *ModuleInstance
-	responseCallback func(int) bool
-}
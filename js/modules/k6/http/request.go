@@ -16,6 +16,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext/httpext"
 	"go.k6.io/k6/lib/types"
 )
@@ -34,6 +35,16 @@ func (c *Client) getMethodClosure(method string) func(url goja.Value, args ...go
 	}
 }
 
+// getNoBodyMethodClosure returns the closure of the methods, like get(url,
+// params), that don't have a body argument, so undefined is added as the
+// third argument to request(method, url, body, params).
+func (c *Client) getNoBodyMethodClosure(method string) func(url goja.Value, args ...goja.Value) (*Response, error) {
+	return func(url goja.Value, args ...goja.Value) (*Response, error) {
+		args = append([]goja.Value{goja.Undefined()}, args...)
+		return c.Request(method, url, args...)
+	}
+}
+
 // Request makes an http request of the provided `method` and returns a corresponding response by
 // taking goja.Values as arguments
 func (c *Client) Request(method string, url goja.Value, args ...goja.Value) (*Response, error) {
@@ -174,6 +185,21 @@ func (c *Client) parseRequest(
 		TagsAndMeta:      c.moduleInstance.vu.State().Tags.GetCurrentValues(),
 	}
 
+	if state.Options.HTTPCache.Bool {
+		if c.cache == nil {
+			c.cache = httpext.NewCache()
+		}
+		result.Cache = c.cache
+	}
+
+	transport, err := c.getTransport(state)
+	if err != nil {
+		return nil, err
+	}
+	if transport != nil {
+		result.Transport = transport
+	}
+
 	if state.Options.DiscardResponseBodies.Bool {
 		result.ResponseType = httpext.ResponseTypeNone
 	} else {
@@ -394,6 +420,14 @@ func (c *Client) parseRequest(
 		}
 	}
 
+	// the metadata, like the correlation ID, is sent in the headers that
+	// aren't set by the request
+	for header, value := range lib.MetadataHeaderValues(state.MetadataHeaders, result.TagsAndMeta.Metadata) {
+		if result.Req.Header.Get(header) == "" {
+			result.Req.Header.Set(header, value)
+		}
+	}
+
 	if result.ActiveJar != nil {
 		httpext.SetRequestCookies(result.Req, result.ActiveJar, result.Cookies)
 	}
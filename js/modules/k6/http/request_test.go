@@ -11,6 +11,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"io"
@@ -662,6 +663,52 @@ func TestRequest(t *testing.T) {
 				assertRequestMetricsEmitted(t, metrics.GetBufferedSamples(samples), "GET", sr("HTTPBIN_URL/cookies"), 200, "")
 			})
 
+			t.Run("exportImport", func(t *testing.T) {
+				cookieJar, err := cookiejar.New(nil)
+				assert.NoError(t, err)
+				state.CookieJar = cookieJar
+				v, err := rt.RunString(sr(`
+				var jar = http.cookieJar();
+				jar.set("HTTPBIN_URL/cookies", "key", "value",
+					{ path: "/cookies", http_only: true, expires: "Mon, 01 Jan 2120 00:00:00 GMT" });
+				jar.set("HTTPBIN_URL/", "session", "abc");
+				jar.set("HTTPBIN_URL/", "shared", "xyz", { domain: "httpbin.local", secure: true });
+				var exported = JSON.stringify(jar.export());
+
+				var fromObjects = new http.CookieJar(JSON.parse(exported));
+				if (JSON.stringify(fromObjects.export()) !== exported) {
+					throw new Error("wrong cookies in jar imported from objects: " + JSON.stringify(fromObjects.export()));
+				}
+				var fromJSON = new http.CookieJar();
+				fromJSON.import(exported);
+				var res = http.request("GET", "HTTPBIN_URL/cookies", null, { jar: fromJSON });
+				if (res.json().key != "value" || res.json().session != "abc") {
+					throw new Error("wrong cookies sent: " + res.body);
+				}
+				exported;
+				`))
+				require.NoError(t, err)
+				assertRequestMetricsEmitted(t, metrics.GetBufferedSamples(samples), "GET", sr("HTTPBIN_URL/cookies"), 200, "")
+
+				var exported []ExportedCookie
+				require.NoError(t, json.Unmarshal([]byte(v.String()), &exported))
+				assert.Equal(t, []ExportedCookie{
+					{Name: "shared", Value: "xyz", Domain: ".httpbin.local", Path: "/", Expires: -1, Secure: true},
+					{Name: "session", Value: "abc", Domain: "httpbin.local", Path: "/", Expires: -1},
+					{
+						Name: "key", Value: "value", Domain: "httpbin.local", Path: "/cookies",
+						Expires: time.Date(2120, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), HTTPOnly: true,
+					},
+				}, exported)
+
+				_, err = rt.RunString(`new http.CookieJar("not json")`)
+				assert.ErrorContains(t, err, "unable to parse the exported cookies")
+				_, err = rt.RunString(`new http.CookieJar([{ name: "key", value: "value" }])`)
+				assert.ErrorContains(t, err, `invalid cookie 0 ("key"): either a url or a domain must be specified`)
+				_, err = rt.RunString(sr(`new http.CookieJar([{ url: "HTTPBIN_URL/", name: "a" }, { url: "HTTPBIN_URL/" }])`))
+				assert.ErrorContains(t, err, `invalid cookie 1 (""): the name is missing`)
+			})
+
 			t.Run("requestScope", func(t *testing.T) {
 				cookieJar, err := cookiejar.New(nil)
 				assert.NoError(t, err)
@@ -1307,6 +1354,49 @@ func TestRequestCancellation(t *testing.T) {
 	assert.Nil(t, ts.hook.LastEntry())
 }
 
+func TestRequestFaults(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	sr := ts.tb.Replacer.Replace
+
+	faults, err := lib.NewFaultInjector([]lib.Fault{
+		{Tags: map[string]string{"fault": "drop"}, Rate: null.FloatFrom(1), Drop: null.BoolFrom(true)},
+		{Tags: map[string]string{"fault": "status"}, Rate: null.FloatFrom(1), Status: null.IntFrom(503)},
+		{
+			Tags:  map[string]string{"fault": "delay"},
+			Rate:  null.FloatFrom(1),
+			Delay: types.NullDurationFrom(200 * time.Millisecond),
+		},
+	})
+	require.NoError(t, err)
+	ts.runtime.VU.State().Faults = faults
+
+	_, err = ts.runtime.VU.Runtime().RunString(sr(`
+		var res = http.get("HTTPBIN_URL/get", { tags: { fault: "drop" }, throw: false });
+		if (res.status !== 0 || res.error_code !== 1060) {
+			throw new Error("unexpected dropped response: " + res.status + " " + res.error_code);
+		}
+		res = http.get("HTTPBIN_URL/get", { tags: { fault: "status" } });
+		if (res.status !== 503 || res.body !== "") {
+			throw new Error("unexpected status: " + res.status);
+		}
+		var start = Date.now();
+		res = http.get("HTTPBIN_URL/get", { tags: { fault: "delay" } });
+		if (res.status !== 200 || Date.now() - start < 200) {
+			throw new Error("the request wasn't delayed: " + res.status);
+		}
+		res = http.get("HTTPBIN_URL/get", { tags: { fault: "delay" }, timeout: "50ms", throw: false });
+		if (res.error_code !== 1050) {
+			throw new Error("the delayed request didn't time out: " + res.error_code);
+		}
+		res = http.get("HTTPBIN_URL/get");
+		if (res.status !== 200) {
+			throw new Error("unexpected status of the request without faults: " + res.status);
+		}
+	`))
+	assert.NoError(t, err)
+}
+
 func TestRequestArrayBufferBody(t *testing.T) {
 	t.Parallel()
 	ts := newTestCase(t)
@@ -1926,7 +2016,7 @@ func TestRedirectMetricTags(t *testing.T) {
 
 	checkTags := func(sc metrics.SampleContainer, expTags map[string]string) {
 		allSamples := sc.GetSamples()
-		assert.Len(t, allSamples, 9)
+		assert.Len(t, allSamples, 12)
 		for _, s := range allSamples {
 			assert.Equal(t, expTags, s.Tags.Map())
 		}
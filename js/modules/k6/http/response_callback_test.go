@@ -100,6 +100,9 @@ func TestResponseCallbackInAction(t *testing.T) {
 		metrics.HTTPReqWaitingName,
 		metrics.HTTPReqSendingName,
 		metrics.HTTPReqTLSHandshakingName,
+		metrics.HTTPReqPoolWaitingName,
+		metrics.HTTPReqConnReusedName,
+		metrics.HTTPConnsOpenedName,
 	}
 
 	allHTTPMetrics := append(HTTPMetricsWithoutFailed, metrics.HTTPReqFailedName) //nolint: gocritic
@@ -310,6 +313,9 @@ func TestResponseCallbackBatch(t *testing.T) {
 		metrics.HTTPReqWaitingName,
 		metrics.HTTPReqSendingName,
 		metrics.HTTPReqTLSHandshakingName,
+		metrics.HTTPReqPoolWaitingName,
+		metrics.HTTPReqConnReusedName,
+		metrics.HTTPConnsOpenedName,
 	}
 
 	allHTTPMetrics := append(HTTPMetricsWithoutFailed, metrics.HTTPReqFailedName) //nolint:gocritic
@@ -428,6 +434,9 @@ func TestResponseCallbackInActionWithoutPassedTag(t *testing.T) {
 		metrics.HTTPReqSendingName,
 		metrics.HTTPReqWaitingName,
 		metrics.HTTPReqTLSHandshakingName,
+		metrics.HTTPReqPoolWaitingName,
+		metrics.HTTPReqConnReusedName,
+		metrics.HTTPConnsOpenedName,
 	}
 	deleteSystemTag(state, metrics.TagExpectedResponse.String())
 
@@ -490,6 +499,9 @@ func TestDigestWithResponseCallback(t *testing.T) {
 		metrics.HTTPReqSendingName,
 		metrics.HTTPReqWaitingName,
 		metrics.HTTPReqTLSHandshakingName,
+		metrics.HTTPReqPoolWaitingName,
+		metrics.HTTPReqConnReusedName,
+		metrics.HTTPConnsOpenedName,
 	}
 	_, err := rt.RunString(fmt.Sprintf(`
 		var res = http.get(%q,  { auth: "digest" });
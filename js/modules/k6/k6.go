@@ -89,6 +89,8 @@ func (mi *K6) RandomSeed(seed int64) {
 }
 
 // Group wraps a function call and executes it within the provided group name.
+// If the function is async, or returns a promise, the group ends when the
+// promise settles, and the code after its awaits stays in the group.
 func (mi *K6) Group(name string, val goja.Value) (goja.Value, error) {
 	state := mi.vu.State()
 	if state == nil {
@@ -102,49 +104,71 @@ func (mi *K6) Group(name string, val goja.Value) (goja.Value, error) {
 	if !ok {
 		return nil, errors.New("group() requires a callback as a second argument")
 	}
-	if common.IsAsyncFunction(mi.vu.Runtime(), val) {
-		return goja.Undefined(), fmt.Errorf(asyncFunctionNotSupportedMsg, "group")
-	}
 	g, err := state.Group.Group(name)
 	if err != nil {
 		return goja.Undefined(), err
 	}
 
 	old := state.Group
-	state.Group = g
+	state.SetGroup(g)
+	defer state.SetGroup(old)
 
-	shouldUpdateTag := state.Options.SystemTags.Has(metrics.TagGroup)
-	if shouldUpdateTag {
-		state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
-			tagsAndMeta.SetSystemTagOrMeta(metrics.TagGroup, g.Path)
+	startTime := time.Now()
+	startRequests := atomic.LoadInt64(&state.Requests)
+	end := func(err error) {
+		t := time.Now()
+		ctx := mi.vu.Context()
+		ctm := state.Tags.GetCurrentValues()
+		metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: state.BuiltinMetrics.GroupDuration,
+				Tags:   ctm.Tags,
+			},
+			Time:     t,
+			Value:    metrics.D(t.Sub(startTime)),
+			Metadata: ctm.Metadata,
 		})
-	}
-	defer func() {
-		state.Group = old
-		if shouldUpdateTag {
-			state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
-				tagsAndMeta.SetSystemTagOrMeta(metrics.TagGroup, old.Path)
-			})
+		metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: state.BuiltinMetrics.GroupReqs,
+				Tags:   ctm.Tags,
+			},
+			Time:     t,
+			Value:    float64(atomic.LoadInt64(&state.Requests) - startRequests),
+			Metadata: ctm.Metadata,
+		})
+
+		// the think time of the scenario is waited after the top-level groups,
+		// outside of their duration
+		if err == nil && old.Parent == nil && state.ThinkTime != nil {
+			mi.Sleep(state.ThinkTime().Seconds())
 		}
-	}()
+	}
 
-	startTime := time.Now()
 	ret, err := fn(goja.Undefined())
-	t := time.Now()
-
-	ctx := mi.vu.Context()
-	ctm := state.Tags.GetCurrentValues()
-	metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
-		TimeSeries: metrics.TimeSeries{
-			Metric: state.BuiltinMetrics.GroupDuration,
-			Tags:   ctm.Tags,
-		},
-		Time:     t,
-		Value:    metrics.D(t.Sub(startTime)),
-		Metadata: ctm.Metadata,
-	})
+	if err != nil {
+		end(err)
+		return ret, err
+	}
+	if _, isPromise := ret.Export().(*goja.Promise); !isPromise {
+		end(nil)
+		return ret, nil
+	}
 
-	return ret, err
+	// The reactions are added while the group is still current, so the event
+	// loop resumes them in it when the promise settles.
+	rt := mi.vu.Runtime()
+	then, _ := goja.AssertFunction(ret.ToObject(rt).Get("then"))
+	return then(ret,
+		rt.ToValue(func(v goja.Value) goja.Value {
+			end(nil)
+			return v
+		}),
+		rt.ToValue(func(reason goja.Value) goja.Value {
+			end(errors.New(reason.String()))
+			panic(reason)
+		}),
+	)
 }
 
 // Check will emit check metrics for the provided checks.
@@ -2,6 +2,7 @@ package k6
 
 import (
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -108,6 +109,25 @@ func TestGroup(t *testing.T) {
 		assert.Equal(t, groupTag, root.Name)
 	})
 
+	t.Run("ThinkTime", func(t *testing.T) {
+		t.Parallel()
+		tc := testCaseRuntime(t)
+		state := tc.testRuntime.VU.State()
+		var thinkTimes int
+		state.ThinkTime = func() time.Duration {
+			thinkTimes++
+			return 100 * time.Millisecond
+		}
+		start := time.Now()
+		_, err := tc.testRuntime.RunOnEventLoop(`
+			k6.group("outer", function() { k6.group("inner", function() {}) });
+			k6.group("other", function() {});
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, 2, thinkTimes, "only the top-level groups should wait")
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	})
+
 	t.Run("Invalid", func(t *testing.T) {
 		t.Parallel()
 		tc := testCaseRuntime(t)
@@ -115,18 +135,116 @@ func TestGroup(t *testing.T) {
 		assert.Contains(t, err.Error(), "group and check names may not contain '::'")
 	})
 
+	t.Run("Requests", func(t *testing.T) {
+		t.Parallel()
+		tc := testCaseRuntime(t)
+		state := tc.testRuntime.VU.State()
+		require.NoError(t, tc.testRuntime.VU.Runtime().Set("request", func() {
+			atomic.AddInt64(&state.Requests, 1)
+		}))
+		_, err := tc.testRuntime.RunOnEventLoop(`
+			k6.group("outer", function() {
+				request();
+				k6.group("inner", function() { request(); request(); });
+			});
+		`)
+		require.NoError(t, err)
+
+		reqs := map[string]float64{}
+		for _, sample := range metrics.GetBufferedSamples(tc.samples) {
+			for _, s := range sample.GetSamples() {
+				if s.Metric.Name == metrics.GroupReqsName {
+					group, _ := s.Tags.Get("group")
+					reqs[group] = s.Value
+				}
+			}
+		}
+		assert.Equal(t, map[string]float64{"::outer": 3, "::outer::inner": 2}, reqs)
+	})
+
 	t.Run("async function", func(t *testing.T) {
 		t.Parallel()
 		tc := testCaseRuntime(t)
-		_, err := tc.testRuntime.RunOnEventLoop(`k6.group("something", async function() { })`)
-		assert.ErrorContains(t, err, "group() does not support async functions as arguments")
+		state := tc.testRuntime.VU.State()
+		root := state.Group
+		require.NoError(t, tc.testRuntime.VU.Runtime().Set("groupTag", func() string {
+			groupTag, _ := state.Tags.GetCurrentValues().Tags.Get("group")
+			return groupTag
+		}))
+		v, err := tc.testRuntime.RunOnEventLoop(`
+			var tags = [];
+			k6.group("outer", async function() {
+				tags.push(groupTag());
+				await null;
+				tags.push(groupTag());
+				await k6.group("inner", async () => {
+					await null;
+					tags.push(groupTag());
+				});
+				tags.push(groupTag());
+			}).then(() => tags.push(groupTag()));
+			tags;
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"::outer", "::outer", "::outer::inner", "::outer", ""}, v.Export())
+		assert.Equal(t, root, state.Group)
+
+		var groups []string
+		for _, sample := range metrics.GetBufferedSamples(tc.samples) {
+			for _, s := range sample.GetSamples() {
+				if s.Metric.Name == metrics.GroupDurationName {
+					group, _ := s.Tags.Get("group")
+					groups = append(groups, group)
+				}
+			}
+		}
+		assert.Equal(t, []string{"::outer::inner", "::outer"}, groups)
 	})
 
-	t.Run("async lambda", func(t *testing.T) {
+	t.Run("concurrent async functions", func(t *testing.T) {
+		t.Parallel()
+		tc := testCaseRuntime(t)
+		state := tc.testRuntime.VU.State()
+		require.NoError(t, tc.testRuntime.VU.Runtime().Set("groupTag", func() string {
+			groupTag, _ := state.Tags.GetCurrentValues().Tags.Get("group")
+			return groupTag
+		}))
+		v, err := tc.testRuntime.RunOnEventLoop(`
+			var tags = [];
+			function task(name) {
+				return k6.group(name, async () => {
+					for (let i = 0; i < 3; i++) {
+						tags.push(name + groupTag());
+						await null;
+					}
+				}).then(() => tags.push("after " + name + groupTag()));
+			}
+			Promise.all([task("a"), task("b")]).then(() => tags.push("end" + groupTag()));
+			tags.push("sync" + groupTag());
+			tags;
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			"a::a", "b::b", "sync", "a::a", "b::b", "a::a", "b::b", "after a", "after b", "end",
+		}, v.Export())
+	})
+
+	t.Run("async rejection", func(t *testing.T) {
 		t.Parallel()
 		tc := testCaseRuntime(t)
-		_, err := tc.testRuntime.RunOnEventLoop(`k6.group("something", async () => { })`)
-		assert.ErrorContains(t, err, "group() does not support async functions as arguments")
+		state := tc.testRuntime.VU.State()
+		root := state.Group
+		v, err := tc.testRuntime.RunOnEventLoop(`
+			var result = {};
+			k6.group("something", async () => {
+				await null;
+				throw new Error("nooo");
+			}).catch((e) => { result.error = e.message; });
+			result;
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"error": "nooo"}, v.Export())
+		assert.Equal(t, root, state.Group)
 	})
 }
 
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -31,15 +33,23 @@ func (mi *ModuleInstance) newMetric(call goja.ConstructorCall, t metrics.MetricT
 		return nil, errors.New("metrics must be declared in the init context")
 	}
 	rt := mi.vu.Runtime()
-	c, _ := goja.AssertFunction(rt.ToValue(func(name string, isTime ...bool) (*goja.Object, error) {
+	c, _ := goja.AssertFunction(rt.ToValue(func(name string, opts goja.Value) (*goja.Object, error) {
+		options, err := parseMetricOptions(rt, opts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid options for the '%s' metric: %w", name, err)
+		}
 		valueType := metrics.Default
-		if len(isTime) > 0 && isTime[0] {
+		if options.isTime {
 			valueType = metrics.Time
 		}
 		m, err := initEnv.Registry.NewMetric(name, t, valueType)
 		if err != nil {
 			return nil, err
 		}
+		err = initEnv.Registry.DescribeMetric(m, options.unit, options.description, options.labels)
+		if err != nil {
+			return nil, err
+		}
 		metric := &Metric{metric: m, vu: mi.vu}
 		o := rt.NewObject()
 		err = o.DefineDataProperty("name", rt.ToValue(name), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
@@ -59,6 +69,49 @@ func (mi *ModuleInstance) newMetric(call goja.ConstructorCall, t metrics.MetricT
 	return v.ToObject(rt), nil
 }
 
+// metricOptions are the options of the constructors of the custom metrics.
+type metricOptions struct {
+	isTime      bool
+	unit        string
+	description string
+	labels      []string
+}
+
+// parseMetricOptions parses the second argument of the constructors of the
+// custom metrics, it can be the isTime boolean or an object with the options.
+func parseMetricOptions(rt *goja.Runtime, v goja.Value) (metricOptions, error) {
+	var options metricOptions
+	if common.IsNullish(v) {
+		return options, nil
+	}
+	if v.ExportType().Kind() == reflect.Bool {
+		options.isTime = v.ToBoolean()
+		return options, nil
+	}
+	obj, ok := v.(*goja.Object)
+	if !ok {
+		return options, fmt.Errorf("a boolean or an object is expected, not '%s'", v.String())
+	}
+	if isTime := obj.Get("isTime"); !common.IsNullish(isTime) {
+		options.isTime = isTime.ToBoolean()
+	}
+	if unit := obj.Get("unit"); !common.IsNullish(unit) {
+		options.unit = unit.String()
+	}
+	if description := obj.Get("description"); !common.IsNullish(description) {
+		options.description = description.String()
+	}
+	if labels := obj.Get("labels"); !common.IsNullish(labels) {
+		if err := rt.ExportTo(labels, &options.labels); err != nil {
+			return options, fmt.Errorf("labels must be an array of strings: %w", err)
+		}
+		if options.labels == nil {
+			options.labels = []string{}
+		}
+	}
+	return options, nil
+}
+
 const warnMessageValueMaxSize = 100
 
 func limitValue(v string) string {
@@ -74,7 +127,7 @@ func limitValue(v string) string {
 	}, string(omitMsg))
 }
 
-func (m Metric) add(v goja.Value, addTags goja.Value) (bool, error) {
+func (m Metric) add(v goja.Value, addTags goja.Value, opts goja.Value) (bool, error) {
 	state := m.vu.State()
 	if state == nil {
 		return false, ErrMetricsAddInInitContext
@@ -110,6 +163,22 @@ func (m Metric) add(v goja.Value, addTags goja.Value) (bool, error) {
 		return raiseNan()
 	}
 
+	if err := m.checkLabels(addTags); err != nil {
+		return raiseErr(err)
+	}
+
+	sampleTime := time.Now()
+	if timestamp := optionValue(m.vu.Runtime(), opts, "timestamp"); !common.IsNullish(timestamp) {
+		if m.metric.Type != metrics.Gauge {
+			return raiseErr(fmt.Errorf("a timestamp can be set only for the samples of the gauges, '%s' is a %s",
+				m.metric.Name, m.metric.Type))
+		}
+		var err error
+		if sampleTime, err = parseTimestamp(timestamp); err != nil {
+			return raiseErr(fmt.Errorf("invalid timestamp for metric '%s': %w", m.metric.Name, err))
+		}
+	}
+
 	ctm := state.Tags.GetCurrentValues()
 	if err := common.ApplyCustomUserTags(m.vu.Runtime(), &ctm, addTags); err != nil {
 		return false, fmt.Errorf("cannot add tags for the '%s' custom metric: %w", m.metric.Name, err)
@@ -120,7 +189,7 @@ func (m Metric) add(v goja.Value, addTags goja.Value) (bool, error) {
 			Metric: m.metric,
 			Tags:   ctm.Tags,
 		},
-		Time:     time.Now(),
+		Time:     sampleTime,
 		Metadata: ctm.Metadata,
 		Value:    vfloat,
 	}
@@ -128,6 +197,48 @@ func (m Metric) add(v goja.Value, addTags goja.Value) (bool, error) {
 	return true, nil
 }
 
+// checkLabels checks that the tags are in the labels that were declared for the
+// metric, if any.
+func (m Metric) checkLabels(tags goja.Value) error {
+	if m.metric.Labels == nil || common.IsNullish(tags) {
+		return nil
+	}
+	obj, ok := tags.(*goja.Object)
+	if !ok {
+		return nil // ApplyCustomUserTags reports it
+	}
+	for _, key := range obj.Keys() {
+		i := sort.SearchStrings(m.metric.Labels, key)
+		if i == len(m.metric.Labels) || m.metric.Labels[i] != key {
+			return fmt.Errorf("'%s' isn't a label of metric '%s', the labels are %s",
+				key, m.metric.Name, strings.Join(m.metric.Labels, ", "))
+		}
+	}
+	return nil
+}
+
+// optionValue returns the value of the option with the key, if the options
+// are an object.
+func optionValue(rt *goja.Runtime, opts goja.Value, key string) goja.Value {
+	if common.IsNullish(opts) {
+		return nil
+	}
+	return opts.ToObject(rt).Get(key)
+}
+
+// parseTimestamp parses the timestamp of a sample, a Date or the milliseconds
+// since the Unix epoch.
+func parseTimestamp(v goja.Value) (time.Time, error) {
+	if t, ok := v.Export().(time.Time); ok {
+		return t, nil
+	}
+	ms := v.ToFloat()
+	if math.IsNaN(ms) || math.IsInf(ms, 0) {
+		return time.Time{}, fmt.Errorf("'%s' isn't a Date or a number of milliseconds", limitValue(v.String()))
+	}
+	return time.UnixMilli(int64(ms)), nil
+}
+
 type (
 	// RootModule is the root metrics module
 	RootModule struct{}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/dop251/goja"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modulestest"
@@ -235,3 +237,76 @@ func TestMetricDuplicates(t *testing.T) {
 
 	require.True(t, v.ToBoolean())
 }
+
+func TestMetricOptions(t *testing.T) {
+	t.Parallel()
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+
+	registry := metrics.NewRegistry()
+	mii := &modulestest.VU{
+		RuntimeField: rt,
+		InitEnvField: &common.InitEnvironment{TestPreInitState: &lib.TestPreInitState{Registry: registry}},
+		CtxField:     context.Background(),
+	}
+	m, ok := New().NewModuleInstance(mii).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, rt.Set("metrics", m.Exports().Named))
+	_, err := rt.RunString(`
+		var g = new metrics.Gauge("queue_size", {
+			unit: "items", description: "The size of the queue", labels: ["queue"],
+		});
+		var tr = new metrics.Trend("wait_time", { isTime: true });
+	`)
+	require.NoError(t, err)
+
+	gauge := registry.Get("queue_size")
+	require.NotNil(t, gauge)
+	assert.Equal(t, "items", gauge.Unit)
+	assert.Equal(t, "The size of the queue", gauge.Description)
+	assert.Equal(t, []string{"queue"}, gauge.Labels)
+	trend := registry.Get("wait_time")
+	require.NotNil(t, trend)
+	assert.Equal(t, metrics.Time, trend.Contains)
+	assert.Nil(t, trend.Labels)
+
+	_, err = rt.RunString(`new metrics.Gauge("queue_size", { unit: "bytes" })`)
+	require.ErrorContains(t, err, "already exists but with unit 'items'")
+	_, err = rt.RunString(`new metrics.Gauge("other", "items")`)
+	require.ErrorContains(t, err, "a boolean or an object is expected")
+
+	samples := make(chan metrics.SampleContainer, 10)
+	mii.InitEnvField = nil
+	mii.StateField = &lib.State{
+		Options: lib.Options{Throw: null.BoolFrom(true)},
+		Samples: samples,
+		Tags:    lib.NewVUStateTags(registry.RootTagSet()),
+	}
+
+	t.Run("Timestamp", func(t *testing.T) {
+		_, err := rt.RunString(`
+			g.add(1, { queue: "a" }, { timestamp: new Date(1000) });
+			g.add(2, { queue: "b" }, { timestamp: 2000 });
+		`)
+		require.NoError(t, err)
+		bufSamples := metrics.GetBufferedSamples(samples)
+		require.Len(t, bufSamples, 2)
+		assert.Equal(t, time.UnixMilli(1000), bufSamples[0].GetSamples()[0].Time)
+		assert.Equal(t, time.UnixMilli(2000), bufSamples[1].GetSamples()[0].Time)
+
+		_, err = rt.RunString(`tr.add(1, {}, { timestamp: 2000 })`)
+		require.ErrorContains(t, err, "only for the samples of the gauges")
+		_, err = rt.RunString(`g.add(1, {}, { timestamp: "yesterday" })`)
+		require.ErrorContains(t, err, "invalid timestamp")
+	})
+
+	t.Run("Labels", func(t *testing.T) {
+		_, err := rt.RunString(`g.add(1, { queue: "a", host: "b" })`)
+		require.ErrorContains(t, err, "'host' isn't a label of metric 'queue_size'")
+		_, err = rt.RunString(`tr.add(1, { host: "b" })`)
+		require.NoError(t, err)
+		bufSamples := metrics.GetBufferedSamples(samples)
+		require.Len(t, bufSamples, 1)
+		assert.Equal(t, map[string]string{"host": "b"}, bufSamples[0].GetSamples()[0].Tags.Map())
+	})
+}
@@ -0,0 +1,90 @@
+// Package secrets implements the k6/secrets module, which gives the scripts
+// the secrets of the sources configured with --secret-source. Their values are
+// redacted from the logs once they are retrieved.
+package secrets
+
+import (
+	"errors"
+
+	"github.com/dop251/goja"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/js/promises"
+	"go.k6.io/k6/secretsource"
+)
+
+type (
+	// RootModule is the global module instance that will create module
+	// instances for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the JS module.
+	ModuleInstance struct {
+		vu      modules.VU
+		manager *secretsource.Manager
+	}
+)
+
+var errEmptySourceName = errors.New("the name of the secret source can't be empty")
+
+// Ensure the interfaces are implemented correctly
+var (
+	_ modules.Instance = &ModuleInstance{}
+	_ modules.Module   = &RootModule{}
+)
+
+// New returns a pointer to a new RootModule instance
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns
+// a new instance for each VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	mi := &ModuleInstance{vu: vu}
+	// the module is always imported in the init context
+	if initEnv := vu.InitEnv(); initEnv != nil && initEnv.TestPreInitState != nil {
+		mi.manager = initEnv.SecretsManager
+	}
+	return mi
+}
+
+// Exports implements the modules.Instance interface and returns
+// the exports of the JS module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{Named: map[string]interface{}{
+		"get":    mi.getter(""),
+		"source": mi.source,
+	}}
+}
+
+// getter returns a function that resolves a promise with the secret with the
+// key from the named source.
+func (mi *ModuleInstance) getter(sourceName string) func(key string) *goja.Promise {
+	return func(key string) *goja.Promise {
+		promise, resolve, reject := promises.New(mi.vu)
+		go func() {
+			value, err := mi.manager.Get(sourceName, key)
+			if err != nil {
+				reject(err)
+				return
+			}
+			resolve(value)
+		}()
+		return promise
+	}
+}
+
+// source returns an object for getting the secrets of the named source.
+func (mi *ModuleInstance) source(name string) *goja.Object {
+	rt := mi.vu.Runtime()
+	if name == "" {
+		common.Throw(rt, errEmptySourceName)
+	}
+	obj := rt.NewObject()
+	if err := obj.Set("get", mi.getter(name)); err != nil {
+		common.Throw(rt, err)
+	}
+	return obj
+}
@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/secretsource"
+)
+
+type mapSource map[string]string
+
+func (s mapSource) Description() string { return "map" }
+
+func (s mapSource) Get(key string) (string, error) {
+	value, ok := s[key]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return value, nil
+}
+
+func testRuntime(t testing.TB, sources map[string]secretsource.Source, defaultSource string) *modulestest.Runtime {
+	testRuntime := modulestest.NewRuntime(t)
+	if sources != nil {
+		manager, err := secretsource.NewManager(sources, defaultSource)
+		require.NoError(t, err)
+		testRuntime.VU.InitEnvField.SecretsManager = manager
+	}
+	mi, ok := New().NewModuleInstance(testRuntime.VU).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, testRuntime.VU.RuntimeField.Set("secrets", mi.Exports().Named))
+	return testRuntime
+}
+
+// run runs the code on the event loop, and returns the results and the errors
+// it stored in the values and errs objects.
+func run(t *testing.T, rt *modulestest.Runtime, code string) (values, errs map[string]any) {
+	t.Helper()
+	_, err := rt.RunOnEventLoop(`var values = {}, errs = {};` + code)
+	require.NoError(t, err)
+	values, ok := rt.VU.Runtime().Get("values").Export().(map[string]any)
+	require.True(t, ok)
+	errs, ok = rt.VU.Runtime().Get("errs").Export().(map[string]any)
+	require.True(t, ok)
+	return values, errs
+}
+
+func TestSecretsGet(t *testing.T) {
+	t.Parallel()
+	rt := testRuntime(t, map[string]secretsource.Source{
+		"first":  mapSource{"password": "s3cret"},
+		"second": mapSource{"password": "other"},
+	}, "first")
+
+	values, errs := run(t, rt, `
+		secrets.get("password").then((v) => { values.default = v });
+		secrets.source("second").get("password").then((v) => { values.second = v });
+	`)
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]any{"default": "s3cret", "second": "other"}, values)
+}
+
+func TestSecretsUnknownSource(t *testing.T) {
+	t.Parallel()
+	rt := testRuntime(t, map[string]secretsource.Source{"first": mapSource{}}, "first")
+
+	values, errs := run(t, rt, `
+		secrets.source("missing").get("password").then(
+			(v) => { values.missing = v },
+			(e) => { errs.missing = e.toString() },
+		);
+	`)
+	assert.Empty(t, values)
+	assert.Equal(t, map[string]any{"missing": `no secret source "missing" is configured`}, errs)
+
+	_, err := rt.VU.Runtime().RunString(`secrets.source("")`)
+	assert.ErrorContains(t, err, "the name of the secret source can't be empty")
+}
+
+func TestSecretsErrors(t *testing.T) {
+	t.Parallel()
+
+	rt := testRuntime(t, map[string]secretsource.Source{"first": mapSource{}}, "first")
+	values, errs := run(t, rt, `
+		secrets.get("password").then((v) => { values.password = v }, (e) => { errs.password = e.toString() });
+	`)
+	assert.Empty(t, values)
+	assert.Equal(t, map[string]any{
+		"password": `getting the secret "password" from map: no such secret`,
+	}, errs)
+
+	rt = testRuntime(t, nil, "")
+	values, errs = run(t, rt, `
+		secrets.get("password").then((v) => { values.password = v }, (e) => { errs.password = e.toString() });
+	`)
+	assert.Empty(t, values)
+	assert.Equal(t, map[string]any{
+		"password": "no secret sources are configured, they can be with --secret-source",
+	}, errs)
+}
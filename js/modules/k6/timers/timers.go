@@ -9,6 +9,7 @@ import (
 
 	"github.com/dop251/goja"
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
 )
 
 // RootModule is the global module instance that will create module
@@ -124,12 +125,24 @@ func (e *Timers) timerInitialization(
 		timeout = 0
 	}
 
+	// the timers share a single task queue, so the callback is run in the
+	// group that was current when the timer was set up
+	var group *lib.Group
+	if state := e.vu.State(); state != nil {
+		group = state.Group
+	}
+
 	task := func() error {
 		// Specification 8.1: If id does not exist in global's map of active timers, then abort these steps.
 		if _, exist := e.timers[id]; !exist {
 			return nil
 		}
 
+		if state := e.vu.State(); state != nil && group != nil && state.Group != group {
+			current := state.Group
+			state.SetGroup(group)
+			defer state.SetGroup(current)
+		}
 		err := e.call(callback, args)
 
 		if _, exist := e.timers[id]; !exist { // 8.4
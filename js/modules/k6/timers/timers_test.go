@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
 )
 
 func TestSetTimeout(t *testing.T) {
@@ -190,3 +192,44 @@ func TestSetTimeoutContextCancel(t *testing.T) {
 		require.Empty(t, log)
 	}
 }
+
+func TestSetTimeoutGroup(t *testing.T) {
+	t.Parallel()
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModuleSystem(map[string]any{"k6/x/timers": New()}, nil, nil)
+	require.NoError(t, err)
+
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	state := &lib.State{
+		Group:   root,
+		Options: lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+		Tags:    lib.NewVUStateTags(metrics.NewRegistry().RootTagSet()),
+	}
+	runtime.MoveToVUContext(state)
+
+	rt := runtime.VU.Runtime()
+	var log []string
+	require.NoError(t, rt.Set("print", func(s string) {
+		groupTag, _ := state.Tags.GetCurrentValues().Tags.Get("group")
+		log = append(log, s+groupTag)
+	}))
+	require.NoError(t, rt.Set("enterGroup", func(name string) {
+		g, err := root.Group(name)
+		require.NoError(t, err)
+		state.SetGroup(g)
+	}))
+	require.NoError(t, rt.Set("exitGroup", func() { state.SetGroup(root) }))
+
+	_, err = runtime.RunOnEventLoop(`
+		let timers = require("k6/x/timers");
+		enterGroup("a");
+		timers.setTimeout(() => print("in setTimeout"), 10);
+		exitGroup();
+		timers.setTimeout(() => print("in other setTimeout"), 1);
+		print("outside setTimeout");
+	`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"outside setTimeout", "in other setTimeout", "in setTimeout::a"}, log)
+	require.Equal(t, root, state.Group)
+}
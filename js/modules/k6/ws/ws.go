@@ -281,7 +281,7 @@ func (mi *WS) dial(
 	if httpResponse != nil {
 		if state.Options.SystemTags.Has(metrics.TagStatus) {
 			args.tagsAndMeta.SetSystemTagOrMeta(
-				metrics.TagStatus, strconv.Itoa(httpResponse.StatusCode))
+				metrics.TagStatus, metrics.IntTagValue(httpResponse.StatusCode))
 		}
 
 		if state.Options.SystemTags.Has(metrics.TagSubproto) {
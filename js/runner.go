@@ -7,13 +7,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dop251/goja"
@@ -32,6 +36,7 @@ import (
 	"go.k6.io/k6/lib/netext"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/loader"
+	"go.k6.io/k6/log"
 	"go.k6.io/k6/metrics"
 )
 
@@ -55,11 +60,24 @@ type Runner struct {
 	// TODO: Remove ActualResolver, it's a hack to simplify mocking in tests.
 	ActualResolver netext.MultiResolver
 	RPSLimit       *rate.Limiter
+	RateLimiters   *lib.RateLimiters
+	Faults         *lib.FaultInjector
+	RequestCapture *lib.RequestCapture
+	HARExport      *lib.RequestCapture
 	RunTags        *metrics.TagSet
 
 	console    *console
-	setupData  []byte
+	setupData  *lib.SetupData
 	BufferPool *lib.BufferPool
+
+	scenarioSetupDataMx sync.RWMutex
+	scenarioSetupData   map[string]*lib.SetupData // returned by the setup functions of the scenarios
+
+	clientCertsOnce sync.Once
+	clientCerts     []tls.Certificate // assigned to the VUs, from the tlsClientCerts option
+	clientCertsErr  error
+
+	localIPsCounter uint64 // the connections of the VUs, if the local IPs are selected in turn
 }
 
 // New returns a new Runner for the provided source
@@ -168,12 +186,18 @@ func (r *Runner) newVU(
 		BlockedHostnames: r.Bundle.Options.BlockedHostnames.Trie,
 		Hosts:            r.Bundle.Options.Hosts.Trie,
 	}
-	if r.Bundle.Options.LocalIPs.Valid {
-		var ipIndex uint64
-		if idLocal > 0 {
-			ipIndex = idLocal - 1
+	if pool := r.Bundle.Options.LocalIPs; pool.Valid {
+		if r.Bundle.Options.LocalIPsSelect.String == lib.LocalIPsRoundRobin {
+			dialer.GetLocalIP = func() net.IP {
+				return pool.Pool.GetIP(atomic.AddUint64(&r.localIPsCounter, 1) - 1)
+			}
+		} else {
+			var ipIndex uint64
+			if idLocal > 0 {
+				ipIndex = idLocal - 1
+			}
+			dialer.Dialer.LocalAddr = &net.TCPAddr{IP: pool.Pool.GetIP(ipIndex)}
 		}
-		dialer.Dialer.LocalAddr = &net.TCPAddr{IP: r.Bundle.Options.LocalIPs.Pool.GetIP(ipIndex)}
 	}
 
 	tlsConfig := &tls.Config{
@@ -198,14 +222,20 @@ func (r *Runner) newVU(
 		//nolint:staticcheck // ignore SA1019 we can deprecate it but we have to continue to support the previous code.
 		tlsConfig.NameToCertificate = nameToCert
 	}
+	clientCerts, err := r.getClientCerts()
+	if err != nil {
+		return nil, err
+	}
+	limitedDialer := netext.NewLimitedDialer(dialer, int(r.Bundle.Options.MaxConns.Int64))
 	transport := &http.Transport{
 		Proxy:               http.ProxyFromEnvironment,
 		TLSClientConfig:     tlsConfig,
-		DialContext:         dialer.DialContext,
+		DialContext:         limitedDialer.DialContext,
 		DisableCompression:  true,
 		DisableKeepAlives:   r.Bundle.Options.NoConnectionReuse.Bool,
 		MaxIdleConns:        int(r.Bundle.Options.Batch.Int64),
 		MaxIdleConnsPerHost: int(r.Bundle.Options.BatchPerHost.Int64),
+		MaxConnsPerHost:     int(r.Bundle.Options.MaxConnsPerHost.Int64),
 	}
 
 	if r.forceHTTP1() {
@@ -213,7 +243,6 @@ func (r *Runner) newVU(
 	} else {
 		_ = http2.ConfigureTransport(transport) // send over h2 protocol
 	}
-
 	cookieJar, err := cookiejar.New(nil)
 	if err != nil {
 		return nil, err
@@ -229,20 +258,46 @@ func (r *Runner) newVU(
 		Dialer:         dialer,
 		CookieJar:      cookieJar,
 		TLSConfig:      tlsConfig,
-		Console:        r.console,
 		BufferPool:     r.BufferPool,
 		Samples:        samplesOut,
 		scenarioIter:   make(map[string]uint64),
+		warmedUp:       make(map[string]bool),
+
+		scenarioSetupData: make(map[string]goja.Value),
+		clientTransports:  make(map[*http.Transport]struct{}),
+	}
+	// the clients of the VU share its dialer, unless they have their own, so
+	// the idle connections of all of its transports are closed
+	if ld, ok := limitedDialer.(*netext.LimitedDialer); ok {
+		ld.SetIdleCloser(vu.closeIdleConnections)
 	}
 
+	// the client certificate of the VU overrides the ones of tlsAuth
+	if len(clientCerts) > 0 {
+		vu.assignClientCert(nil)
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return vu.clientCert.Load(), nil
+		}
+	}
+
+	// the log entries of the VU have its metadata, for the outputs that
+	// include it, e.g. the JSON lines of the file output
+	logCtx := log.WithMetadata(context.Background(), vu.logMetadata.Load)
+	vu.Console = &console{logger: withLogContext(r.console.logger, logCtx)}
+
 	vu.state = &lib.State{
-		Logger:         vu.Runner.preInitState.Logger,
+		Logger:         withLogContext(vu.Runner.preInitState.Logger, logCtx),
 		Options:        vu.Runner.Bundle.Options,
 		Transport:      vu.Transport,
+		AddTransport:   vu.addClientTransport,
 		Dialer:         vu.Dialer,
 		TLSConfig:      vu.TLSConfig,
 		CookieJar:      cookieJar,
 		RPSLimit:       vu.Runner.RPSLimit,
+		RateLimiters:   vu.Runner.RateLimiters,
+		Faults:         vu.Runner.Faults,
+		RequestCapture: vu.Runner.RequestCapture,
+		HARExport:      vu.Runner.HARExport,
 		BufferPool:     vu.BufferPool,
 		VUID:           vu.ID,
 		VUIDGlobal:     vu.IDGlobal,
@@ -250,7 +305,12 @@ func (r *Runner) newVU(
 		Tags:           lib.NewVUStateTags(vu.Runner.RunTags),
 		Group:          r.defaultGroup,
 		BuiltinMetrics: r.preInitState.BuiltinMetrics,
-		TracerProvider: r.preInitState.TracerProvider,
+
+		MetadataHeaders: vu.Runner.Bundle.Options.MetadataHeaderNames(),
+	}
+	// a nil provider can't be set directly, as the interface wouldn't be nil
+	if r.preInitState.TracerProvider != nil {
+		vu.state.TracerProvider = r.preInitState.TracerProvider
 	}
 	vu.moduleVUImpl.state = vu.state
 	_ = vu.Runtime.Set("console", vu.Console)
@@ -258,6 +318,19 @@ func (r *Runner) newVU(
 	return vu, nil
 }
 
+// getClientCerts loads the client certificates of the tlsClientCerts option,
+// only once for all the VUs.
+func (r *Runner) getClientCerts() ([]tls.Certificate, error) {
+	r.clientCertsOnce.Do(func() {
+		opt := r.Bundle.Options.TLSClientCerts
+		if opt == nil {
+			return
+		}
+		r.clientCerts, r.clientCertsErr = opt.Load(r.Bundle.filesystems["file"], r.Bundle.pwd.Path)
+	})
+	return r.clientCerts, r.clientCertsErr
+}
+
 // forceHTTP1 checks if force http1 env variable has been set in order to force requests to be sent over h1
 // TODO: This feature is temporary until #936 is resolved
 func (r *Runner) forceHTTP1() bool {
@@ -291,32 +364,42 @@ func (r *Runner) Setup(ctx context.Context, out chan<- metrics.SampleContainer)
 	setupCtx, setupCancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.SetupFn))
 	defer setupCancel()
 
-	v, err := r.runPart(setupCtx, out, consts.SetupFn, nil)
+	v, err := r.runPart(setupCtx, out, consts.SetupFn, consts.SetupFn, nil)
 	if err != nil {
 		return err
 	}
-	// r.setupData = nil is special it means undefined from this moment forward
+	r.setupData, err = r.marshalSetupData(v, consts.SetupFn)
+	return err
+}
+
+// marshalSetupData returns the data returned by the setup function as JSON,
+// or nil if it's undefined. It fails if the data is larger than the
+// setupDataMaxSize option.
+func (r *Runner) marshalSetupData(v goja.Value, fn string) (*lib.SetupData, error) {
+	// nil setup data is special, it means undefined from this moment forward
 	if goja.IsUndefined(v) {
-		r.setupData = nil
-		return nil
+		return nil, nil
 	}
 
-	r.setupData, err = json.Marshal(v.Export())
+	data, err := json.Marshal(v.Export())
 	if err != nil {
-		return fmt.Errorf("error marshaling setup() data to JSON: %w", err)
+		return nil, fmt.Errorf("error marshaling %s() data to JSON: %w", fn, err)
+	}
+	if err = lib.CheckSetupDataSize(data, r.Bundle.Options.SetupDataMaxSize.Int64, fn); err != nil {
+		return nil, err
 	}
 	var tmp interface{}
-	return json.Unmarshal(r.setupData, &tmp)
+	return lib.NewSetupData(data), json.Unmarshal(data, &tmp)
 }
 
 // GetSetupData returns the setup data as json if Setup() was specified and executed, nil otherwise
 func (r *Runner) GetSetupData() []byte {
-	return r.setupData
+	return r.setupData.JSON()
 }
 
 // SetSetupData saves the externally supplied setup data as json in the runner, so it can be used in VUs
 func (r *Runner) SetSetupData(data []byte) {
-	r.setupData = data
+	r.setupData = lib.NewSetupData(data)
 }
 
 // Teardown runs the teardown function if there is one.
@@ -334,13 +417,77 @@ func (r *Runner) Teardown(ctx context.Context, out chan<- metrics.SampleContaine
 
 	var data interface{}
 	if r.setupData != nil {
-		if err := json.Unmarshal(r.setupData, &data); err != nil {
+		if err := json.Unmarshal(r.setupData.JSON(), &data); err != nil {
 			return fmt.Errorf("error unmarshaling setup data for teardown() from JSON: %w", err)
 		}
 	} else {
 		data = goja.Undefined()
 	}
-	_, err := r.runPart(teardownCtx, out, consts.TeardownFn, data)
+	_, err := r.runPart(teardownCtx, out, consts.TeardownFn, consts.TeardownFn, data)
+	return err
+}
+
+// ScenarioSetup runs the setup function of a scenario, with the setupTimeout,
+// and keeps the data it returns for the iterations of the scenario.
+func (r *Runner) ScenarioSetup(
+	ctx context.Context, out chan<- metrics.SampleContainer, scenario, fn string,
+) error {
+	r.preInitState.Logger.Debugf("Running %s() of scenario %s...", fn, scenario)
+
+	setupCtx, setupCancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.SetupFn))
+	defer setupCancel()
+
+	v, err := r.runPart(setupCtx, out, consts.SetupFn, fn, nil)
+	if err != nil {
+		return err
+	}
+	data, err := r.marshalSetupData(v, fn)
+	if err != nil {
+		return err
+	}
+
+	r.scenarioSetupDataMx.Lock()
+	defer r.scenarioSetupDataMx.Unlock()
+	if r.scenarioSetupData == nil {
+		r.scenarioSetupData = make(map[string]*lib.SetupData)
+	}
+	r.scenarioSetupData[scenario] = data
+	return nil
+}
+
+// getScenarioSetupData returns the data of the setup function of the
+// scenario, and whether it has been run.
+func (r *Runner) getScenarioSetupData(scenario string) (*lib.SetupData, bool) {
+	r.scenarioSetupDataMx.RLock()
+	defer r.scenarioSetupDataMx.RUnlock()
+	data, ok := r.scenarioSetupData[scenario]
+	return data, ok
+}
+
+// ScenarioTeardown runs the teardown function of a scenario, with the
+// teardownTimeout, passing it the data of the setup function of the scenario,
+// if it has one, or the setup() one.
+func (r *Runner) ScenarioTeardown(
+	ctx context.Context, out chan<- metrics.SampleContainer, scenario, fn string,
+) error {
+	r.preInitState.Logger.Debugf("Running %s() of scenario %s...", fn, scenario)
+
+	teardownCtx, teardownCancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.TeardownFn))
+	defer teardownCancel()
+
+	setupData, ok := r.getScenarioSetupData(scenario)
+	if !ok {
+		setupData = r.setupData
+	}
+	var data interface{}
+	if setupData != nil {
+		if err := json.Unmarshal(setupData.JSON(), &data); err != nil {
+			return fmt.Errorf("error unmarshaling setup data for %s() from JSON: %w", fn, err)
+		}
+	} else {
+		data = goja.Undefined()
+	}
+	_, err := r.runPart(teardownCtx, out, consts.TeardownFn, fn, data)
 	return err
 }
 
@@ -363,7 +510,7 @@ func (r *Runner) IsExecutable(name string) bool {
 
 // HandleSummary calls the specified summary callback, if supplied.
 func (r *Runner) HandleSummary(ctx context.Context, summary *lib.Summary) (map[string]io.Reader, error) {
-	summaryDataForJS := summarizeMetricsToObject(summary, r.Bundle.Options, r.setupData)
+	summaryDataForJS := summarizeMetricsToObject(summary, r.Bundle.Options, r.setupData.JSON())
 
 	out := make(chan metrics.SampleContainer, 100)
 	defer close(out)
@@ -452,6 +599,42 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 	if rps := opts.RPS; rps.Valid && rps.Int64 > 0 {
 		r.RPSLimit = rate.NewLimiter(rate.Limit(rps.Int64), 1)
 	}
+	rateLimiters, err := lib.NewRateLimiters(opts.RateLimits)
+	if err != nil {
+		return err
+	}
+	r.RateLimiters = rateLimiters
+	faults, err := lib.NewFaultInjector(opts.Faults)
+	if err != nil {
+		return err
+	}
+	r.Faults = faults
+
+	r.RequestCapture, r.HARExport = nil, nil
+	if path := opts.CaptureRequests.String; opts.CaptureRequests.Valid && path != "" {
+		maxSize := int64(lib.DefaultCaptureMaxSize)
+		if opts.CaptureMaxSize.Valid {
+			maxSize = opts.CaptureMaxSize.Int64
+		}
+		rc, err := newRequestCapture(path, strings.EqualFold(filepath.Ext(path), ".har"), maxSize)
+		if err != nil {
+			return err
+		}
+		rc.SlowerThan = opts.CaptureSlowerThan.TimeDuration()
+		r.RequestCapture = rc
+	}
+	if path := opts.HAROut.String; opts.HAROut.Valid && path != "" {
+		// the size of the export is limited only by its sampling
+		rc, err := newRequestCapture(path, true, math.MaxInt64)
+		if err != nil {
+			return err
+		}
+		rc.SampleRate = 1
+		if opts.HARSampleRate.Valid {
+			rc.SampleRate = opts.HARSampleRate.Float64
+		}
+		r.HARExport = rc
+	}
 
 	// TODO: validate that all exec values are either nil or valid exported methods (or HTTP requests in the future)
 
@@ -492,8 +675,23 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 	return nil
 }
 
+// newRequestCapture opens the file of the captured requests, truncating it,
+// so it has only the requests of this test run.
+func newRequestCapture(path string, har bool, maxSize int64) (*lib.RequestCapture, error) {
+	//nolint:gosec,forbidigo // see https://github.com/grafana/k6/issues/2565
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := lib.NewRequestCapture(f, har, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write to the file of the captured requests %s: %w", path, err)
+	}
+	return rc, nil
+}
+
 func (r *Runner) setResolver(dns types.DNSConfig) error {
-	ttl, err := parseTTL(dns.TTL.String)
+	ttl, err := types.ParseDNSTTL(dns.TTL.String)
 	if err != nil {
 		return err
 	}
@@ -512,33 +710,14 @@ func (r *Runner) setResolver(dns types.DNSConfig) error {
 	return nil
 }
 
-func parseTTL(ttlS string) (time.Duration, error) {
-	ttl := time.Duration(0)
-	switch ttlS {
-	case "inf":
-		// cache "infinitely"
-		ttl = time.Hour * 24 * 365
-	case "0":
-		// disable cache
-	case "":
-		ttlS = types.DefaultDNSConfig().TTL.String
-		fallthrough
-	default:
-		var err error
-		ttl, err = types.ParseExtendedDuration(ttlS)
-		if ttl < 0 || err != nil {
-			return ttl, fmt.Errorf("invalid DNS TTL: %s", ttlS)
-		}
-	}
-	return ttl, nil
-}
-
 // Runs an exported function in its own temporary VU, optionally with an argument. Execution is
 // interrupted if the context expires. No error is returned if the part does not exist.
+// runPart runs the exported function name in a new VU, with the timeout of
+// the stage, which is either setup() or teardown().
 func (r *Runner) runPart(
 	parentCtx context.Context,
 	out chan<- metrics.SampleContainer,
-	name string,
+	stage, name string,
 	arg interface{},
 ) (goja.Value, error) {
 	ctx, cancel := context.WithCancel(parentCtx)
@@ -572,7 +751,7 @@ func (r *Runner) runPart(
 	vu.state.Group = group
 	v, _, _, err := vu.runFn(ctx, false, fn, nil, vu.Runtime.ToValue(arg))
 
-	if deadlineError := r.checkDeadline(ctx, name, v, err); deadlineError != nil {
+	if deadlineError := r.checkDeadline(ctx, stage, v, err); deadlineError != nil {
 		return nil, deadlineError
 	}
 
@@ -629,11 +808,22 @@ type VU struct {
 
 	Samples chan<- metrics.SampleContainer
 
-	setupData goja.Value
+	setupData         goja.Value
+	scenarioSetupData map[string]goja.Value // of the scenarios with setup functions
 
 	state *lib.State
 	// count of iterations executed by this VU in each scenario
 	scenarioIter map[string]uint64
+	// the scenarios in which this VU already ran its warm-up iterations
+	warmedUp map[string]bool
+	// the metadata of the current iteration, for the log entries
+	logMetadata atomic.Pointer[log.Metadata]
+	// the client certificate of the VU, if the tlsClientCerts option is set
+	clientCert atomic.Pointer[tls.Certificate]
+	// the transports of the HTTP clients of the VU with their own TLS
+	// configuration, they can be closed by the dialer from other goroutines
+	clientTransportsMx sync.Mutex
+	clientTransports   map[*http.Transport]struct{}
 }
 
 // Verify that interfaces are implemented
@@ -649,6 +839,8 @@ type ActiveVU struct {
 	busy chan struct{}
 
 	scenarioName              string
+	scenarioSetupData         *lib.SetupData // the data of the setup function of the scenario
+	hasScenarioSetup          bool
 	getNextIterationCounters  func() (uint64, uint64)
 	scIterLocal, scIterGlobal uint64
 }
@@ -658,6 +850,64 @@ func (u *VU) GetID() uint64 {
 	return u.ID
 }
 
+// addClientTransport registers the transport of an HTTP client of the VU.
+func (u *VU) addClientTransport(transport *http.Transport) {
+	u.clientTransportsMx.Lock()
+	defer u.clientTransportsMx.Unlock()
+	u.clientTransports[transport] = struct{}{}
+}
+
+// closeIdleConnections closes the idle connections of the transport of the
+// VU and of the ones of its HTTP clients.
+func (u *VU) closeIdleConnections() {
+	u.Transport.CloseIdleConnections()
+	u.closeClientsIdleConnections(false)
+}
+
+// closeClientsIdleConnections closes the idle connections of the transports
+// of the HTTP clients of the VU, and unregisters them if forget is true, like
+// when the VU is deactivated, since its clients could have been discarded.
+// The ones that are still used are registered again.
+func (u *VU) closeClientsIdleConnections(forget bool) {
+	u.clientTransportsMx.Lock()
+	defer u.clientTransportsMx.Unlock()
+	for transport := range u.clientTransports {
+		transport.CloseIdleConnections()
+		if forget {
+			delete(u.clientTransports, transport)
+		}
+	}
+}
+
+// assignClientCert assigns the client certificate of the VU, by its global ID
+// or by the index of its identity, if they're assigned to the identities. The
+// idle connections are closed if it changes, so the next requests present it.
+func (u *VU) assignClientCert(identity *lib.Identity) {
+	certs := u.Runner.clientCerts
+	if len(certs) == 0 {
+		return
+	}
+	var index int
+	if opt := u.Runner.Bundle.Options.TLSClientCerts; opt.PerIdentity() && identity != nil {
+		index = identity.Index % len(certs)
+	} else if u.IDGlobal > 0 {
+		index = int((u.IDGlobal - 1) % uint64(len(certs)))
+	}
+	if old := u.clientCert.Swap(&certs[index]); old != nil && old != &certs[index] {
+		u.closeIdleConnections()
+	}
+}
+
+// shapeTraffic shapes the traffic of the VU in a scenario. The idle
+// connections are closed if it was shaped before or it's shaped now, so the
+// next requests are shaped as configured.
+func (u *VU) shapeTraffic(ts *lib.TrafficShaping) {
+	shaper := netext.NewShaper(ts)
+	if old := u.Dialer.SetShaper(shaper); old != nil || shaper != nil {
+		u.closeIdleConnections()
+	}
+}
+
 // Activate the VU so it will be able to run code.
 func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	u.Runtime.ClearInterrupt()
@@ -702,6 +952,10 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	u.state.GetScenarioVUIter = func() uint64 {
 		return u.scenarioIter[params.Scenario]
 	}
+	u.state.ThinkTime = params.ThinkTime
+	u.state.Identity = lib.AssignIdentity(params.Scenario, params.Identities, u.IDGlobal)
+	u.assignClientCert(u.state.Identity)
+	u.shapeTraffic(params.TrafficShaping)
 
 	avu := &ActiveVU{
 		VU:                       u,
@@ -713,12 +967,21 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 		getNextIterationCounters: params.GetNextIterationCounters,
 	}
 
+	// the setup function of the scenario is run before the scenario starts
+	avu.scenarioSetupData, avu.hasScenarioSetup = u.Runner.getScenarioSetupData(params.Scenario)
+
 	u.state.GetScenarioLocalVUIter = func() uint64 {
 		return avu.scIterLocal
 	}
 	u.state.GetScenarioGlobalVUIter = func() uint64 {
 		return avu.scIterGlobal
 	}
+	u.state.SetupData = func() *lib.SetupData {
+		if avu.hasScenarioSetup {
+			return avu.scenarioSetupData
+		}
+		return u.Runner.setupData
+	}
 
 	go func() {
 		// Wait for the run context to be over
@@ -728,6 +991,7 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 		// Wait for the VU to stop running, if it was, and prevent it from
 		// running again for this activation
 		avu.busy <- struct{}{}
+		u.closeClientsIdleConnections(true)
 
 		if params.DeactivateCallback != nil {
 			params.DeactivateCallback(u)
@@ -737,6 +1001,44 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	return avu
 }
 
+// getSetupData returns the data of the setup function of the scenario, if it
+// has one, or the setup() one. The data is unmarshaled only the first time for
+// each VU, so that VUs are isolated but we still don't use too much CPU in the
+// middle of the test.
+func (u *ActiveVU) getSetupData() (goja.Value, error) {
+	if u.hasScenarioSetup {
+		if v, ok := u.VU.scenarioSetupData[u.scenarioName]; ok {
+			return v, nil
+		}
+		v, err := u.unmarshalSetupData(u.scenarioSetupData.JSON())
+		if err != nil {
+			return nil, err
+		}
+		u.VU.scenarioSetupData[u.scenarioName] = v
+		return v, nil
+	}
+
+	if u.setupData == nil {
+		v, err := u.unmarshalSetupData(u.Runner.setupData.JSON())
+		if err != nil {
+			return nil, err
+		}
+		u.setupData = v
+	}
+	return u.setupData, nil
+}
+
+func (u *ActiveVU) unmarshalSetupData(setupData []byte) (goja.Value, error) {
+	if setupData == nil {
+		return goja.Undefined(), nil
+	}
+	var data interface{}
+	if err := json.Unmarshal(setupData, &data); err != nil {
+		return nil, fmt.Errorf("error unmarshaling setup data for the iteration from JSON: %w", err)
+	}
+	return u.Runtime.ToValue(data), nil
+}
+
 // RunOnce runs the configured Exec function once.
 func (u *ActiveVU) RunOnce() error {
 	select {
@@ -749,24 +1051,26 @@ func (u *ActiveVU) RunOnce() error {
 		<-u.busy // unlock deactivation again
 	}()
 
-	// Unmarshall the setupData only the first time for each VU so that VUs are isolated but we
-	// still don't use too much CPU in the middle test
-	if u.setupData == nil {
-		if u.Runner.setupData != nil {
-			var data interface{}
-			if err := json.Unmarshal(u.Runner.setupData, &data); err != nil {
-				return fmt.Errorf("error unmarshaling setup data for the iteration from JSON: %w", err)
-			}
-			u.setupData = u.Runtime.ToValue(data)
-		} else {
-			u.setupData = goja.Undefined()
-		}
+	setupData, err := u.getSetupData()
+	if err != nil {
+		return err
 	}
 
-	fn := u.getCallableExport(u.Exec)
+	exec := u.Exec
+	if u.NextExec != nil {
+		exec = u.NextExec()
+	}
+	fn := u.getCallableExport(exec)
 	if fn == nil {
 		// Shouldn't happen; this is validated in cmd.validateScenarioConfig()
-		panic(fmt.Sprintf("function '%s' not found in exports", u.Exec))
+		panic(fmt.Sprintf("function '%s' not found in exports", exec))
+	}
+
+	if u.WarmupIterations > 0 && !u.warmedUp[u.scenarioName] {
+		u.warmedUp[u.scenarioName] = true
+		if err := u.runWarmup(fn, setupData); err != nil {
+			return err
+		}
 	}
 
 	u.incrIteration()
@@ -786,9 +1090,38 @@ func (u *ActiveVU) RunOnce() error {
 
 	u.emitAndWaitEvent(&event.Event{Type: event.IterStart, Data: eventIterData})
 
+	// If the scenario has an iterationTimeout, interrupt both the script and
+	// anything that is waiting on the iteration context when it's reached.
+	// Whether the iteration timed out is decided only by the goroutine, either
+	// the iteration or the timer is done first.
+	iterDone := make(chan struct{})
+	timedOut := make(chan bool, 1)
+	if u.IterationTimeout > 0 {
+		timer := time.NewTimer(u.IterationTimeout)
+		go func() {
+			defer timer.Stop()
+			select {
+			case <-iterDone:
+				timedOut <- false
+			case <-timer.C:
+				cancel()
+				u.Runtime.Interrupt(lib.ErrIterationTimeout)
+				timedOut <- true
+			}
+		}()
+	} else {
+		timedOut <- false
+	}
+
 	// Call the exported function.
-	_, isFullIteration, totalTime, err := u.runFn(ctx, true, fn, cancel, u.setupData)
-	if err != nil {
+	_, isFullIteration, totalTime, err := u.runFn(ctx, true, fn, cancel, setupData)
+	close(iterDone)
+	if <-timedOut {
+		u.Runtime.ClearInterrupt()
+		err = fmt.Errorf("%w after %s", lib.ErrIterationTimeout, u.IterationTimeout)
+		eventIterData.Error = err
+		u.emitIterationTimeout()
+	} else if err != nil {
 		var x *goja.InterruptedError
 		if errors.As(err, &x) {
 			if v, ok := x.Value().(*errext.InterruptError); ok {
@@ -816,6 +1149,56 @@ func (u *ActiveVU) RunOnce() error {
 	return err
 }
 
+// runWarmup runs the warm-up iterations of the VU in the scenario. All of
+// their metric samples are dropped and they aren't counted as iterations, so
+// the cold-start effects like establishing connections don't skew the metrics.
+func (u *ActiveVU) runWarmup(fn goja.Callable, setupData goja.Value) error {
+	samples := make(chan metrics.SampleContainer, 100)
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for range samples { //nolint:revive
+		}
+	}()
+	origSamples := u.state.Samples
+	u.state.Samples = samples
+	defer func() {
+		u.state.Samples = origSamples
+		close(samples)
+		<-drained
+	}()
+
+	u.state.Logger.Debugf("Running %d warm-up iterations", u.WarmupIterations)
+	for i := int64(0); i < u.WarmupIterations; i++ {
+		ctx, cancel := context.WithCancel(u.RunContext)
+		u.moduleVUImpl.ctx = ctx
+		_, _, _, err := u.runFn(ctx, true, fn, cancel, setupData)
+		cancel()
+		if u.RunContext.Err() != nil {
+			return u.RunContext.Err()
+		}
+		if err != nil {
+			u.state.Logger.WithError(err).Warn("A warm-up iteration failed")
+		}
+	}
+	return nil
+}
+
+// emitIterationTimeout emits the metric of an iteration that was interrupted
+// by the iterationTimeout.
+func (u *ActiveVU) emitIterationTimeout() {
+	ctm := u.state.Tags.GetCurrentValues()
+	metrics.PushIfNotDone(u.RunContext, u.state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: u.Runner.preInitState.BuiltinMetrics.IterationTimeouts,
+			Tags:   ctm.Tags,
+		},
+		Time:     time.Now(),
+		Metadata: ctm.Metadata,
+		Value:    1,
+	})
+}
+
 func (u *ActiveVU) emitAndWaitEvent(evt *event.Event) {
 	waitDone := u.moduleVUImpl.events.local.Emit(evt)
 	waitCtx, waitCancel := context.WithTimeout(u.RunContext, 30*time.Minute)
@@ -849,6 +1232,12 @@ func (u *VU) runFn(
 		})
 	}
 
+	if opts.CorrelationID.Bool {
+		if err = u.setCorrelationID(); err != nil {
+			return goja.Undefined(), false, time.Duration(0), err
+		}
+	}
+
 	startTime := time.Now()
 
 	if u.moduleVUImpl.eventLoop == nil {
@@ -879,21 +1268,48 @@ func (u *VU) runFn(
 	}
 
 	if u.Runner.Bundle.Options.NoVUConnectionReuse.Bool {
-		u.Transport.CloseIdleConnections()
+		u.closeIdleConnections()
 	}
 
 	u.state.Samples <- u.Dialer.GetTrail(
 		startTime, endTime, isFullIteration,
 		isDefault, u.state.Tags.GetCurrentValues(), u.Runner.preInitState.BuiltinMetrics)
 
+	if len(u.state.IterationMetadata) > 0 {
+		u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+			for key := range u.state.IterationMetadata {
+				tagsAndMeta.DeleteMetadata(key)
+			}
+		})
+		u.state.IterationMetadata = nil
+	}
+
 	v = unPromisify(v)
 
 	return v, isFullIteration, endTime.Sub(startTime), err
 }
 
+// setCorrelationID sets a new correlation ID in the metadata of the
+// iteration, it's deleted when the iteration ends.
+func (u *VU) setCorrelationID() error {
+	id, err := lib.NewCorrelationID()
+	if err != nil {
+		return err
+	}
+	u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+		tagsAndMeta.SetMetadata(lib.CorrelationIDMetadata, id)
+	})
+	if u.state.IterationMetadata == nil {
+		u.state.IterationMetadata = make(map[string]struct{})
+	}
+	u.state.IterationMetadata[lib.CorrelationIDMetadata] = struct{}{}
+	return nil
+}
+
 func (u *ActiveVU) incrIteration() {
 	u.iteration++
 	u.state.Iteration = u.iteration
+	u.logMetadata.Store(&log.Metadata{VU: u.ID, Scenario: u.scenarioName, Iteration: u.iteration})
 
 	if _, ok := u.scenarioIter[u.scenarioName]; ok {
 		u.scenarioIter[u.scenarioName]++
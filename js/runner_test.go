@@ -8,6 +8,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"go/build"
@@ -20,6 +21,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -33,6 +35,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/errext"
+	"go.k6.io/k6/event"
 	"go.k6.io/k6/execution"
 	"go.k6.io/k6/execution/local"
 	"go.k6.io/k6/js/modules/k6"
@@ -556,6 +559,101 @@ func TestSetupDataPromise(t *testing.T) {
 	};`)
 }
 
+func TestSetupDataShared(t *testing.T) {
+	t.Parallel()
+	testSetupDataHelper(t, `
+	var data = require("k6/data");
+	exports.options = { setupTimeout: "1s", teardownTimeout: "1s" };
+	exports.setup = function() {
+		return { users: ["a", "b"], count: 2 };
+	}
+	exports.default = function() {
+		var shared = data.sharedSetupData();
+		if (shared.count !== 2 || shared.users[1] !== "b" || !Object.isFrozen(shared.users)) {
+			throw new Error("default: wrong data: " + JSON.stringify(shared))
+		}
+	};`)
+}
+
+func TestSetupDataMaxSize(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	exports.options = { setupTimeout: "1s", setupDataMaxSize: 10 };
+	exports.setup = function() {
+		return { data: "more than ten bytes" };
+	}
+	exports.default = function() {};`)
+	require.NoError(t, err)
+
+	samples := make(chan metrics.SampleContainer, 100)
+	err = r.Setup(context.Background(), samples)
+	require.ErrorContains(t, err,
+		"the data returned by setup() is 30 bytes as JSON, more than the setupDataMaxSize of 10 bytes")
+
+	// there's no limit by default
+	r, err = getSimpleRunner(t, "/script.js", `
+	exports.options = { setupTimeout: "1s" };
+	exports.setup = function() {
+		return { data: "x".repeat(1024 * 1024) };
+	}
+	exports.default = function() {};`)
+	require.NoError(t, err)
+	require.NoError(t, r.Setup(context.Background(), samples))
+}
+
+func TestScenarioSetupData(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	exports.options = { setupTimeout: "1s", teardownTimeout: "1s" };
+	exports.setup = function() {
+		return "global";
+	}
+	exports.setupDB = function() {
+		return {"db": "correct"};
+	}
+	exports.teardownDB = function(data) {
+		if (data.db !== "correct") {
+			throw new Error("teardownDB: wrong data: " + JSON.stringify(data))
+		}
+	};
+	exports.teardownAPI = function(data) {
+		if (data !== "global") {
+			throw new Error("teardownAPI: wrong data: " + JSON.stringify(data))
+		}
+	};
+	exports.db = function(data) {
+		if (data.db !== "correct") {
+			throw new Error("db: wrong data: " + JSON.stringify(data))
+		}
+	};
+	exports.api = function(data) {
+		if (data !== "global") {
+			throw new Error("api: wrong data: " + JSON.stringify(data))
+		}
+	};`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 100)
+
+	require.NoError(t, r.Setup(ctx, samples))
+	require.NoError(t, r.ScenarioSetup(ctx, samples, "db", "setupDB"))
+	assert.Equal(t, []byte(`"global"`), r.GetSetupData())
+
+	for id, scenario := range []string{"db", "api"} {
+		initVU, err := r.NewVU(ctx, uint64(id+1), uint64(id+1), samples)
+		require.NoError(t, err)
+		vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: scenario, Exec: scenario})
+		require.NoError(t, vu.RunOnce())
+		require.NoError(t, vu.RunOnce())
+	}
+
+	require.NoError(t, r.ScenarioTeardown(ctx, samples, "db", "teardownDB"))
+	require.NoError(t, r.ScenarioTeardown(ctx, samples, "api", "teardownAPI"))
+	require.ErrorContains(t, r.ScenarioTeardown(ctx, samples, "db", "teardownAPI"), "wrong data")
+}
+
 func TestRunnerIntegrationImports(t *testing.T) {
 	t.Parallel()
 	t.Run("Modules", func(t *testing.T) {
@@ -721,6 +819,88 @@ func TestVURunInterrupt(t *testing.T) {
 	}
 }
 
+func TestVURunIterationTimeout(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+		var sleep = require("k6").sleep;
+		exports.default = function() {
+			if (__ITER == 0) { while(true) {} }
+			if (__ITER == 1) { sleep(10) }
+		}
+		`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 100)
+	vu, err := r.newVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	activeVU := vu.Activate(&lib.VUActivationParams{RunContext: ctx, IterationTimeout: 100 * time.Millisecond})
+
+	// the IterEnd events have the errors of the iterations
+	_, iterEnds := vu.moduleVUImpl.events.local.Subscribe(event.IterEnd)
+	iterErrors := make(chan error, 3)
+	go func() {
+		for evt := range iterEnds {
+			iterErrors <- evt.Data.(event.IterData).Error //nolint:forcetypeassert
+			evt.Done()
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		err = activeVU.RunOnce()
+		require.ErrorIs(t, err, lib.ErrIterationTimeout)
+		assert.Contains(t, err.Error(), "the iteration timed out after 100ms")
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Equal(t, err, <-iterErrors)
+	}
+	require.NoError(t, activeVU.RunOnce())
+	assert.NoError(t, <-iterErrors)
+
+	var timeouts float64
+	for _, sc := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sc.GetSamples() {
+			if s.Metric.Name == metrics.IterationTimeoutsName {
+				timeouts += s.Value
+			}
+		}
+	}
+	assert.Equal(t, 2.0, timeouts)
+}
+
+func TestVURunWarmupIterations(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+		var Counter = require("k6/metrics").Counter;
+		var runs = new Counter("runs");
+		exports.default = function() { runs.add(1); }
+		`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 100)
+	vu, err := r.newVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+
+	for _, scenario := range []string{"first", "first", "second"} {
+		activeVU := vu.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: scenario, WarmupIterations: 3})
+		require.NoError(t, activeVU.RunOnce())
+		require.NoError(t, activeVU.RunOnce())
+	}
+
+	counts := make(map[string]float64)
+	for _, sc := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sc.GetSamples() {
+			counts[s.Metric.Name] += s.Value
+		}
+	}
+	assert.Equal(t, 6.0, counts["runs"])
+	assert.Equal(t, 6.0, counts[metrics.IterationsName])
+	assert.Equal(t, int64(5), vu.iteration)
+}
+
 func TestVURunInterruptDoesntPanic(t *testing.T) {
 	t.Parallel()
 	r1, err := getSimpleRunner(t, "/script.js", `
@@ -1276,6 +1456,45 @@ func TestVUIntegrationBlockHostnamesScript(t *testing.T) {
 	}
 }
 
+func TestVUIntegrationMetadataHeaders(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		var exec = require("k6/execution");
+		exports.options = { correlationID: true, metadataHeaders: { user: "X-User-ID" } };
+		var previous;
+		exports.default = function() {
+			var id = exec.vu.metrics.iterationMetadata.correlation_id;
+			if (!id || id === previous) {
+				throw new Error("wrong correlation ID " + id);
+			}
+			previous = id;
+			exec.vu.metrics.iterationMetadata.user = "alice";
+
+			var headers = http.get("HTTPBIN_URL/headers").json().headers;
+			if (headers["X-Correlation-Id"][0] !== id || headers["X-User-Id"][0] !== "alice") {
+				throw new Error("wrong headers " + JSON.stringify(headers));
+			}
+			headers = http.get("HTTPBIN_URL/headers", { headers: { "X-User-ID": "bob" } }).json().headers;
+			if (headers["X-User-Id"][0] !== "bob") {
+				throw new Error("the header of the request should be kept " + JSON.stringify(headers));
+			}
+		}
+	`))
+	require.NoError(t, err)
+	r.Bundle.Options.Hosts = types.NullHosts{Trie: tb.Dialer.Hosts}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+	vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
+	require.NoError(t, vu.RunOnce())
+	require.NoError(t, vu.RunOnce())
+}
+
 func TestVUIntegrationHosts(t *testing.T) {
 	t.Parallel()
 	tb := httpmultibin.NewHTTPMultiBin(t)
@@ -1945,6 +2164,170 @@ func TestVUIntegrationClientCerts(t *testing.T) {
 	}
 }
 
+func TestVUIntegrationTLSClientCerts(t *testing.T) {
+	t.Parallel()
+
+	memFs := fsext.NewMemMapFs()
+	serials := make([]string, 2)
+	for i, name := range []string{"device1", "device2"} {
+		certPem, keyPem := generateTLSCertificate(t, "127.0.0.1", time.Now(), time.Hour)
+		require.NoError(t, fsext.WriteFile(memFs, "/certs/"+name+".crt", certPem, 0o644))
+		require.NoError(t, fsext.WriteFile(memFs, "/certs/"+name+".key", keyPem, 0o644))
+		block, _ := pem.Decode(certPem)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		serials[i] = cert.SerialNumber.String()
+	}
+
+	srvCertPem, srvKeyPem := generateTLSCertificate(t, "127.0.0.1", time.Now(), time.Hour)
+	serverCert, err := tls.X509KeyPair(srvCertPem, srvKeyPem)
+	require.NoError(t, err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{ //nolint:gosec
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAnyClientCert,
+	})
+	require.NoError(t, err)
+	srv := &http.Server{ //nolint:gosec
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, r.TLS.PeerCertificates[0].SerialNumber.String())
+		}),
+		ErrorLog: stdlog.New(io.Discard, "", 0),
+	}
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	testdata := map[string]struct {
+		assignment string
+		identities []json.RawMessage
+		expected   []string // the serials of the certificates of the VUs 1, 2 and 3
+	}{
+		"PerVU":       {"", nil, []string{serials[0], serials[1], serials[0]}},
+		"PerIdentity": {"identity", []json.RawMessage{json.RawMessage(`"user"`)}, []string{serials[0], serials[0], serials[0]}},
+	}
+	for name, data := range testdata {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := getSimpleRunner(t, "/script.js", fmt.Sprintf(`
+			var http = require("k6/http");
+			exports.default = function() {
+				var res = http.get("https://%s");
+				if (res.body !== __ENV["SERIAL_" + __VU]) {
+					throw new Error("unexpected certificate " + res.body);
+				}
+			}`, listener.Addr().String()), memFs)
+			require.NoError(t, err)
+			require.NoError(t, r.SetOptions(lib.Options{
+				Throw:                 null.BoolFrom(true),
+				InsecureSkipTLSVerify: null.BoolFrom(true),
+				TLSClientCerts:        &lib.TLSClientCerts{Dir: "certs", Assignment: data.assignment},
+			}))
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			for i, serial := range data.expected {
+				id := uint64(i + 1)
+				initVU, err := r.NewVU(ctx, id, id, make(chan metrics.SampleContainer, 100))
+				require.NoError(t, err)
+				vu := initVU.Activate(&lib.VUActivationParams{
+					RunContext: ctx,
+					Scenario:   "default",
+					Identities: data.identities,
+					Env:        map[string]string{fmt.Sprintf("SERIAL_%d", id): serial},
+				})
+				require.NoError(t, vu.RunOnce())
+			}
+		})
+	}
+}
+
+func TestVUIntegrationTrafficShaping(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.default = function() {
+			var res = http.get("HTTPBIN_URL/get");
+			var shaped = res.timings.connecting >= 200 && res.timings.waiting >= 200;
+			if (shaped !== (__ENV.SHAPED === "true")) {
+				throw new Error("unexpected timings: " + JSON.stringify(res.timings));
+			}
+		}`))
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(lib.Options{
+		Throw: null.BoolFrom(true),
+		Hosts: types.NullHosts{Trie: tb.Dialer.Hosts, Valid: true},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+
+	// the connections of the previous scenario aren't reused after the shaping changes
+	shaping := &lib.TrafficShaping{Latency: types.NullDurationFrom(200 * time.Millisecond)}
+	for _, ts := range []*lib.TrafficShaping{shaping, nil, shaping} {
+		scenarioCtx, scenarioCancel := context.WithCancel(ctx)
+		deactivated := make(chan struct{})
+		vu := initVU.Activate(&lib.VUActivationParams{
+			RunContext:         scenarioCtx,
+			DeactivateCallback: func(lib.InitializedVU) { close(deactivated) },
+			TrafficShaping:     ts,
+			Env:                map[string]string{"SHAPED": strconv.FormatBool(ts != nil)},
+		})
+		require.NoError(t, vu.RunOnce())
+		scenarioCancel()
+		<-deactivated
+	}
+}
+
+func TestVUIntegrationClientConnectionReuse(t *testing.T) {
+	t.Parallel()
+
+	for _, noReuse := range []bool{false, true} {
+		noReuse := noReuse
+		t.Run(fmt.Sprintf("NoVUConnectionReuse=%t", noReuse), func(t *testing.T) {
+			t.Parallel()
+			tb := httpmultibin.NewHTTPMultiBin(t)
+			tb.Mux.HandleFunc("/remote-addr", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = fmt.Fprint(w, r.RemoteAddr)
+			})
+
+			r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+				var http = require("k6/http");
+				var client = new http.Client({ tls: { maxVersion: http.TLS_1_2 } });
+				var previous;
+				exports.default = function() {
+					var addr = client.get("HTTPSBIN_URL/remote-addr").body;
+					if (previous && (addr !== previous) !== (__ENV.NO_REUSE === "true")) {
+						throw new Error("unexpected connection reuse: " + previous + " " + addr);
+					}
+					previous = addr;
+				}`))
+			require.NoError(t, err)
+			require.NoError(t, r.SetOptions(lib.Options{
+				Throw:                 null.BoolFrom(true),
+				Hosts:                 types.NullHosts{Trie: tb.Dialer.Hosts, Valid: true},
+				InsecureSkipTLSVerify: null.BoolFrom(true),
+				NoVUConnectionReuse:   null.BoolFrom(noReuse),
+			}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+			require.NoError(t, err)
+			vu := initVU.Activate(&lib.VUActivationParams{
+				RunContext: ctx,
+				Env:        map[string]string{"NO_REUSE": strconv.FormatBool(noReuse)},
+			})
+			for i := 0; i < 3; i++ {
+				require.NoError(t, vu.RunOnce())
+			}
+		})
+	}
+}
+
 func TestHTTPRequestInInitContext(t *testing.T) {
 	t.Parallel()
 	tb := httpmultibin.NewHTTPMultiBin(t)
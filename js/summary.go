@@ -58,20 +58,47 @@ func metricValueGetter(summaryTrendStats []string) func(metrics.Sink, time.Durat
 }
 
 // summarizeMetricsToObject transforms the summary objects in a way that's
-// suitable to pass to the JS runtime or export to JSON.
+// suitable to pass to the JS runtime or export to JSON. It's the data received
+// by handleSummary(), with the following stable schema:
+//
+//   - root_group: the group tree, with name, path, id, the checks (name, path,
+//     id, passes and fails) and the sub-groups of every group.
+//   - options: summaryTrendStats, summaryTimeUnit, summaryBreakdownTags,
+//     scenarioBreakdown and noColor.
+//   - state: isStdOutTTY, isStdErrTTY, testRunDurationMs and
+//     timeSeriesResolutionMs (0 if the time series aren't recorded).
+//   - metrics: the observed metrics and sub-metrics, by name, with their type,
+//     contains (the value type), values (the aggregated values, depending on
+//     the type) and thresholds (the ok state of each threshold), if any. The
+//     sub-metrics also have their parent metric name and their tags. If the
+//     timeSeriesResolution option is set, they also have their timeSeries, the
+//     values aggregated in each period, with its start time in milliseconds
+//     since the Unix epoch.
+//   - slos: the SLOs, by name, if any, with their metric, condition,
+//     objective, compliance (the fraction of the good samples), burnRate (of
+//     the error budget) and ok (if the objective was met).
+//   - warnings: the descriptions of the saturations of the load generator that
+//     were detected during the test run, if any.
+//   - metadata: the metadata of the test run that is set, by the names of the
+//     tags it's added to the samples with: test_name, run_id, test_version and
+//     test_environment.
+//   - setup_data: the data returned by setup(), if any.
 func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData []byte) map[string]interface{} {
 	m := make(map[string]interface{})
 	m["root_group"] = exportGroup(data.RootGroup)
 	m["options"] = map[string]interface{}{
 		// TODO: improve when we can easily export all option values, including defaults?
-		"summaryTrendStats": options.SummaryTrendStats,
-		"summaryTimeUnit":   options.SummaryTimeUnit.String,
-		"noColor":           data.NoColor, // TODO: move to the (runtime) options
+		"summaryTrendStats":    options.SummaryTrendStats,
+		"summaryTimeUnit":      options.SummaryTimeUnit.String,
+		"summaryBreakdownTags": options.SummaryBreakdownTags,
+		"scenarioBreakdown":    options.ScenarioBreakdown.String,
+		"noColor":              data.NoColor, // TODO: move to the (runtime) options
 	}
 	m["state"] = map[string]interface{}{
-		"isStdOutTTY":       data.UIState.IsStdOutTTY,
-		"isStdErrTTY":       data.UIState.IsStdErrTTY,
-		"testRunDurationMs": float64(data.TestRunDuration) / float64(time.Millisecond),
+		"isStdOutTTY":            data.UIState.IsStdOutTTY,
+		"isStdErrTTY":            data.UIState.IsStdErrTTY,
+		"testRunDurationMs":      float64(data.TestRunDuration) / float64(time.Millisecond),
+		"timeSeriesResolutionMs": float64(data.TimeSeriesResolution) / float64(time.Millisecond),
 	}
 
 	getMetricValues := metricValueGetter(options.SummaryTrendStats)
@@ -83,6 +110,20 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 			"contains": m.Contains.String(),
 			"values":   getMetricValues(m.Sink, data.TestRunDuration),
 		}
+		if m.Sub != nil {
+			metricData["parent"] = m.Sub.Parent.Name
+			metricData["tags"] = m.Sub.Tags.Map()
+		}
+		if periods := data.TimeSeries[name]; len(periods) > 0 {
+			timeSeries := make([]interface{}, 0, len(periods))
+			for _, p := range periods {
+				timeSeries = append(timeSeries, map[string]interface{}{
+					"time":   p.Start.UnixMilli(),
+					"values": getMetricValues(p.Sink, data.TimeSeriesResolution),
+				})
+			}
+			metricData["timeSeries"] = timeSeries
+		}
 
 		if len(m.Thresholds.Thresholds) > 0 {
 			thresholds := make(map[string]interface{})
@@ -97,6 +138,22 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 	}
 	m["metrics"] = metricsData
 
+	if len(options.SLOs) > 0 {
+		m["slos"] = summarizeSLOs(data, options.SLOs)
+	}
+
+	if metadata := options.TestMetadata(); len(metadata) > 0 {
+		m["metadata"] = metadata
+	}
+
+	if len(data.Warnings) > 0 {
+		warnings := make([]interface{}, 0, len(data.Warnings))
+		for _, w := range data.Warnings {
+			warnings = append(warnings, w)
+		}
+		m["warnings"] = warnings
+	}
+
 	var setupDataI interface{}
 	if setupData != nil {
 		if err := json.Unmarshal(setupData, &setupDataI); err != nil {
@@ -112,6 +169,37 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 	return m
 }
 
+// summarizeSLOs returns the results of the SLOs, from their compliance and burn
+// rate metrics.
+func summarizeSLOs(data *lib.Summary, slos map[string]lib.SLO) map[string]interface{} {
+	result := make(map[string]interface{}, len(slos))
+	for name, slo := range slos {
+		var compliance, burnRate float64
+		var total int64
+		if m, ok := data.Metrics[lib.SLOComplianceMetric(name)]; ok {
+			if sink, ok := m.Sink.(*metrics.RateSink); ok && sink.Total > 0 {
+				total = sink.Total
+				compliance = float64(sink.Trues) / float64(sink.Total)
+			}
+		}
+		if m, ok := data.Metrics[lib.SLOBurnRateMetric(name)]; ok {
+			if sink, ok := m.Sink.(*metrics.GaugeSink); ok {
+				burnRate = sink.Value
+			}
+		}
+		result[name] = map[string]interface{}{
+			"metric":     slo.Metric,
+			"condition":  slo.Condition,
+			"objective":  slo.Objective,
+			"compliance": compliance,
+			"burnRate":   burnRate,
+			// without any sample, the objective isn't met
+			"ok": total > 0 && compliance >= slo.Objective,
+		}
+	}
+	return result
+}
+
 func exportGroup(group *lib.Group) map[string]interface{} {
 	subGroups := make([]map[string]interface{}, len(group.OrderedGroups))
 	for i, subGroup := range group.OrderedGroups {
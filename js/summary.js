@@ -116,6 +116,81 @@ function summarizeGroup(indent, group, decorate) {
   return result
 }
 
+function summarizeSLOs(indent, data, decorate) {
+  var result = []
+  if (!data.slos) {
+    return result
+  }
+
+  result.push(indent + groupPrefix + ' SLOs\n')
+  var names = Object.keys(data.slos).sort()
+  for (var name of names) {
+    var slo = data.slos[name]
+    result.push(
+      decorate(
+        indent +
+        '  ' +
+        (slo.ok ? succMark : failMark) +
+        ' ' +
+        name +
+        ': ' +
+        toFixedNoTrailingZeros(slo.compliance * 100, 2) +
+        '% of ' +
+        slo.metric +
+        ' ' +
+        slo.condition +
+        ', objective ' +
+        toFixedNoTrailingZeros(slo.objective * 100, 2) +
+        '%, burn rate ' +
+        toFixedNoTrailingZeros(slo.burnRate, 2),
+        slo.ok ? palette.green : palette.red
+      )
+    )
+  }
+  result.push('')
+
+  return result
+}
+
+var metadataLabels = [
+  ['test_name', 'name'],
+  ['run_id', 'run id'],
+  ['test_version', 'version'],
+  ['test_environment', 'environment'],
+]
+
+function summarizeMetadata(indent, data, decorate) {
+  var result = []
+  if (!data.metadata) {
+    return result
+  }
+
+  result.push(indent + groupPrefix + ' TEST\n')
+  for (var label of metadataLabels) {
+    if (data.metadata[label[0]]) {
+      result.push(indent + '  ' + label[1] + ': ' + decorate(data.metadata[label[0]], palette.cyan))
+    }
+  }
+  result.push('')
+
+  return result
+}
+
+function summarizeWarnings(indent, data, decorate) {
+  var result = []
+  if (!data.warnings) {
+    return result
+  }
+
+  result.push(indent + groupPrefix + ' WARNINGS\n')
+  for (var warning of data.warnings) {
+    result.push(decorate(indent + '  ' + failMark + ' ' + warning, palette.red))
+  }
+  result.push('')
+
+  return result
+}
+
 function displayNameForMetric(name) {
   var subMetricPos = name.indexOf('{')
   if (subMetricPos >= 0) {
@@ -220,10 +295,20 @@ function humanizeValue(val, metric, timeUnit) {
 function nonTrendMetricValueForSum(metric, timeUnit) {
   switch (metric.type) {
     case 'counter':
-      return [
+      var values = [
         humanizeValue(metric.values.count, metric, timeUnit),
         humanizeValue(metric.values.rate, metric, timeUnit) + '/s',
       ]
+      // the rates over the latest windows, like rate1m
+      Object.keys(metric.values)
+        .filter(function (stat) {
+          return /^rate[0-9]/.test(stat)
+        })
+        .sort()
+        .forEach(function (stat) {
+          values.push(stat + '=' + humanizeValue(metric.values[stat], metric, timeUnit) + '/s')
+        })
+      return values
     case 'gauge':
       return [
         humanizeValue(metric.values.value, metric, timeUnit),
@@ -293,7 +378,7 @@ function summarizeMetrics(options, data, decorate) {
     nonTrendExtras[name] = values.slice(1)
     for (var i = 1; i < values.length; i++) {
       var extraLen = strWidth(values[i])
-      if (extraLen > nonTrendExtraMaxLens[i - 1]) {
+      if (!nonTrendExtraMaxLens[i - 1] || extraLen > nonTrendExtraMaxLens[i - 1]) {
         nonTrendExtraMaxLens[i - 1] = extraLen
       }
     }
@@ -382,6 +467,45 @@ function summarizeMetrics(options, data, decorate) {
   return result
 }
 
+// splitScenarioMetrics returns the metrics without the sub-metrics of the
+// scenarios and, by scenario, the sub-metrics of each one by parent metric,
+// when the summary is broken down by scenario in sections.
+function splitScenarioMetrics(data) {
+  var result = { metrics: data.metrics, scenarios: {} }
+  if (!data.options || data.options.scenarioBreakdown !== 'sections') {
+    return result
+  }
+
+  result.metrics = {}
+  forEach(data.metrics, function (name, metric) {
+    var tags = metric.tags ? Object.keys(metric.tags) : []
+    if (tags.length !== 1 || tags[0] !== 'scenario') {
+      result.metrics[name] = metric
+      return
+    }
+    var scenario = metric.tags.scenario
+    if (!result.scenarios[scenario]) {
+      result.scenarios[scenario] = {}
+    }
+    result.scenarios[scenario][metric.parent] = metric
+  })
+  return result
+}
+
+function summarizeScenarios(options, data, scenarios, decorate) {
+  var result = []
+  var indent = options.indent + '    '
+  var names = Object.keys(scenarios).sort()
+  for (var name of names) {
+    result.push('')
+    result.push(indent + groupPrefix + ' SCENARIO ' + name + '\n')
+    var scenarioData = Object.assign({}, data, { metrics: scenarios[name] })
+    var scenarioOpts = Object.assign({}, options, { indent: options.indent + '  ' })
+    Array.prototype.push.apply(result, summarizeMetrics(scenarioOpts, scenarioData, decorate))
+  }
+  return result
+}
+
 function generateTextSummary(data, options) {
   var mergedOpts = Object.assign({}, defaultOptions, data.options, options)
   var lines = []
@@ -400,12 +524,24 @@ function generateTextSummary(data, options) {
     }
   }
 
+  Array.prototype.push.apply(lines, summarizeMetadata(mergedOpts.indent + '    ', data, decorate))
+
+  Array.prototype.push.apply(lines, summarizeWarnings(mergedOpts.indent + '    ', data, decorate))
+
   Array.prototype.push.apply(
     lines,
     summarizeGroup(mergedOpts.indent + '    ', data.root_group, decorate)
   )
 
-  Array.prototype.push.apply(lines, summarizeMetrics(mergedOpts, data, decorate))
+  Array.prototype.push.apply(lines, summarizeSLOs(mergedOpts.indent + '    ', data, decorate))
+
+  var split = splitScenarioMetrics(data)
+  Array.prototype.push.apply(
+    lines,
+    summarizeMetrics(mergedOpts, Object.assign({}, data, { metrics: split.metrics }), decorate)
+  )
+
+  Array.prototype.push.apply(lines, summarizeScenarios(mergedOpts, data, split.scenarios, decorate))
 
   return lines.join('\n')
 }
@@ -290,6 +290,97 @@ const expectedOldJSONExportResult = `{
 }
 `
 
+func TestTextSummarySLOs(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	compliance, err := registry.NewMetric("api_latency_compliance", metrics.Rate)
+	require.NoError(t, err)
+	for _, value := range []float64{1, 1, 1, 0} {
+		compliance.Sink.Add(metrics.Sample{Value: value})
+	}
+	burnRate, err := registry.NewMetric("api_latency_burn_rate", metrics.Gauge)
+	require.NoError(t, err)
+	burnRate.Sink.Add(metrics.Sample{Value: 2.5})
+
+	summary := &lib.Summary{
+		Metrics: map[string]*metrics.Metric{
+			compliance.Name: compliance,
+			burnRate.Name:   burnRate,
+		},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		`
+			exports.options = {slos: {
+				api_latency: {metric: "http_req_duration", condition: "<300", objective: 0.9},
+				api_errors: {metric: "http_req_failed", condition: "==0", objective: 0.99},
+			}};
+			exports.default = function() {/* we don't run this, metrics are mocked */};
+		`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+	assert.Contains(t, string(summaryOut), "     █ SLOs\n\n"+
+		"       ✗ api_errors: 0% of http_req_failed ==0, objective 99%, burn rate 0\n"+
+		"       ✗ api_latency: 75% of http_req_duration <300, objective 90%, burn rate 2.5\n\n")
+
+	data := summarizeMetricsToObject(summary, runner.GetOptions(), nil)
+	assert.Equal(t, map[string]interface{}{
+		"metric":     "http_req_duration",
+		"condition":  "<300",
+		"objective":  0.9,
+		"compliance": 0.75,
+		"burnRate":   2.5,
+		"ok":         false,
+	}, data["slos"].(map[string]interface{})["api_latency"]) //nolint:forcetypeassert
+}
+
+func TestTextSummaryWarnings(t *testing.T) {
+	t.Parallel()
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+		Warnings:        []string{"the first warning", "the second warning"},
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		`exports.default = function() {/* we don't run this, metrics are mocked */};`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+	assert.Contains(t, string(summaryOut), "     █ WARNINGS\n\n"+
+		"       ✗ the first warning\n"+
+		"       ✗ the second warning\n\n")
+
+	data := summarizeMetricsToObject(summary, runner.GetOptions(), nil)
+	assert.Equal(t, []interface{}{"the first warning", "the second warning"}, data["warnings"])
+
+	summary.Warnings = nil
+	data = summarizeMetricsToObject(summary, runner.GetOptions(), nil)
+	assert.NotContains(t, data, "warnings")
+}
+
 func TestOldJSONExport(t *testing.T) {
 	t.Parallel()
 	runner, err := getSimpleRunner(
@@ -372,12 +463,15 @@ const expectedHandleSummaryRawData = `
             "count"
         ],
         "summaryTimeUnit": "",
+        "summaryBreakdownTags": [],
+        "scenarioBreakdown": "",
         "noColor": false
     },
     "state": {
         "isStdErrTTY": false,
         "isStdOutTTY": false,
-        "testRunDurationMs": 1000
+        "testRunDurationMs": 1000,
+        "timeSeriesResolutionMs": 0
     },
     "metrics": {
         "checks": {
@@ -489,12 +583,15 @@ const expectedHandleSummaryDataWithSetup = `
             "count"
             ],
             "summaryTimeUnit": "",
+            "summaryBreakdownTags": [],
+            "scenarioBreakdown": "",
             "noColor": false
         },
         "state": {
             "isStdErrTTY": false,
             "isStdOutTTY": false,
-            "testRunDurationMs": 1000
+            "testRunDurationMs": 1000,
+            "timeSeriesResolutionMs": 0
         },
         "setup_data": 5,
         "metrics": {
@@ -701,3 +798,62 @@ func TestExceptionInHandleSummaryFallsBackToTextSummary(t *testing.T) {
 	assert.Contains(t, errMsg, "\"Error: intentional error\\n\\tat file:///script.js:4:11(3)\\n")
 	assert.Equal(t, logErrors[0].Data, logrus.Fields{"hint": "script exception"})
 }
+
+func TestSummarizeMetricsToObjectSubmetrics(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	sm, err := reqs.AddSubmetric("scenario:api")
+	require.NoError(t, err)
+	sm.Metric.Sink.Add(metrics.Sample{Time: time.Now(), Value: 2})
+
+	data := summarizeMetricsToObject(&lib.Summary{
+		Metrics:         map[string]*metrics.Metric{reqs.Name: reqs, sm.Metric.Name: sm.Metric},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+	}, lib.Options{SummaryTrendStats: lib.DefaultSummaryTrendStats, SummaryBreakdownTags: []string{"scenario"}}, nil)
+
+	metricsData, ok := data["metrics"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, map[string]interface{}{
+		"type":     "counter",
+		"contains": "default",
+		"values":   map[string]float64{"count": 2, "rate": 2},
+		"parent":   "http_reqs",
+		"tags":     map[string]string{"scenario": "api"},
+	}, metricsData["http_reqs{scenario:api}"])
+	assert.NotContains(t, metricsData["http_reqs"], "parent")
+	assert.Equal(t, []string{"scenario"}, data["options"].(map[string]interface{})["summaryBreakdownTags"]) //nolint:forcetypeassert
+}
+
+func TestSummarizeMetricsToObjectTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	reqs.Sink.Add(metrics.Sample{Time: time.Now(), Value: 3})
+	first, second := &metrics.CounterSink{}, &metrics.CounterSink{}
+	first.Add(metrics.Sample{Value: 1})
+	second.Add(metrics.Sample{Value: 2})
+
+	data := summarizeMetricsToObject(&lib.Summary{
+		Metrics:         map[string]*metrics.Metric{reqs.Name: reqs},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: 4 * time.Second,
+		TimeSeries: map[string][]metrics.AggregatedPeriod{
+			"http_reqs": {{Start: time.UnixMilli(1000), Sink: first}, {Start: time.UnixMilli(3000), Sink: second}},
+		},
+		TimeSeriesResolution: 2 * time.Second,
+	}, lib.Options{SummaryTrendStats: lib.DefaultSummaryTrendStats}, nil)
+
+	assert.Equal(t, float64(2000), data["state"].(map[string]interface{})["timeSeriesResolutionMs"]) //nolint:forcetypeassert
+	metricsData, ok := data["metrics"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"time": int64(1000), "values": map[string]float64{"count": 1, "rate": 0.5}},
+		map[string]interface{}{"time": int64(3000), "values": map[string]float64{"count": 2, "rate": 1}},
+	}, metricsData["http_reqs"].(map[string]interface{})["timeSeries"]) //nolint:forcetypeassert
+}
@@ -164,16 +164,16 @@ func ReadArchive(in io.Reader) (*Archive, error) {
 			}
 			fallthrough
 		case "https", "file":
-			fileSystem := arc.getFs(pfx)
-			name = filepath.FromSlash(name)
-			if err = fsext.WriteFile(fileSystem, name, data, fs.FileMode(hdr.Mode)); err != nil {
+			if err = arc.writeFile(pfx, name, data, hdr); err != nil {
 				return nil, err
 			}
-			if err = fileSystem.Chtimes(name, hdr.AccessTime, hdr.ModTime); err != nil {
+		default:
+			if !loader.IsObjectStorageScheme(pfx) {
+				return nil, fmt.Errorf("unknown file prefix `%s` for file `%s`", pfx, normPath)
+			}
+			if err = arc.writeFile(pfx, name, data, hdr); err != nil {
 				return nil, err
 			}
-		default:
-			return nil, fmt.Errorf("unknown file prefix `%s` for file `%s`", pfx, normPath)
 		}
 	}
 	scheme, pathOnFs := getURLPathOnFs(arc.FilenameURL)
@@ -190,6 +190,16 @@ func ReadArchive(in io.Reader) (*Archive, error) {
 	return arc, nil
 }
 
+// writeFile writes the file from the archive to the filesystem of the scheme.
+func (arc *Archive) writeFile(scheme, name string, data []byte, hdr *tar.Header) error {
+	fileSystem := arc.getFs(scheme)
+	name = filepath.FromSlash(name)
+	if err := fsext.WriteFile(fileSystem, name, data, fs.FileMode(hdr.Mode)); err != nil {
+		return err
+	}
+	return fileSystem.Chtimes(name, hdr.AccessTime, hdr.ModTime)
+}
+
 func normalizeAndAnonymizeURL(u *url.URL) {
 	if u.Scheme == "file" {
 		u.Path = NormalizeAndAnonymizePath(u.Path)
@@ -262,7 +272,7 @@ func (arc *Archive) Write(out io.Writer) error {
 	if _, err = w.Write(arc.Data); err != nil {
 		return err
 	}
-	for _, name := range [...]string{"file", "https"} {
+	for _, name := range append([]string{"file", "https"}, loader.ObjectStorageSchemes()...) {
 		filesystem, ok := arc.Filesystems[name]
 		if !ok {
 			continue
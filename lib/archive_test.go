@@ -161,6 +161,50 @@ func TestArchiveReadWrite(t *testing.T) {
 		diffMapFilesystems(t, arc1Filesystems, arc2Filesystems)
 	})
 
+	t.Run("ObjectStorage", func(t *testing.T) {
+		t.Parallel()
+		arc1 := &Archive{
+			Type:      "js",
+			K6Version: consts.Version,
+			Options: Options{
+				VUs:        null.IntFrom(12345),
+				SystemTags: &metrics.DefaultSystemTagSet,
+			},
+			FilenameURL: &url.URL{Scheme: "s3", Host: "bucket", Path: "/path/to/a.js"},
+			Data:        []byte(`// a contents`),
+			PwdURL:      &url.URL{Scheme: "s3", Host: "bucket", Path: "/path/to/"},
+			Filesystems: map[string]fsext.Fs{
+				"s3": makeMemMapFs(t, map[string][]byte{
+					"/bucket/path/to/a.js":      []byte(`// a contents`),
+					"/bucket/path/to/file1.txt": []byte(`hi!`),
+				}),
+				"gs": makeMemMapFs(t, map[string][]byte{
+					"/other-bucket/b.js": []byte(`// b contents`),
+				}),
+			},
+		}
+
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, arc1.Write(buf))
+
+		arc1Filesystems := arc1.Filesystems
+		arc1.Filesystems = nil
+
+		arc2, err := ReadArchive(buf)
+		require.NoError(t, err)
+
+		arc2Filesystems := arc2.Filesystems
+		arc2.Filesystems = nil
+		arc2.Filename = ""
+		arc2.Pwd = ""
+
+		assert.Equal(t, arc1, arc2)
+
+		arc1Filesystems["file"] = fsext.NewMemMapFs()
+		arc1Filesystems["https"] = fsext.NewMemMapFs()
+		diffMapFilesystems(t, arc1Filesystems, arc2Filesystems)
+	})
+
 	t.Run("Anonymized", func(t *testing.T) {
 		t.Parallel()
 		testdata := []struct {
@@ -0,0 +1,77 @@
+// Package awsconfig loads the configuration of the AWS clients, for the
+// features of k6 that use AWS services, from the environment of k6.
+package awsconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+)
+
+// DefaultRegion is the region of the clients, if it isn't configured.
+const DefaultRegion = "us-east-1"
+
+// Load returns the AWS configuration, with credentials. The configuration and
+// the credentials are found by the AWS SDK, in the same way as the AWS CLI
+// does: from the environment variables, the shared config and credentials
+// files (with their profiles, assumed roles and SSO sessions), the web
+// identity token, the ECS container credentials or the EC2 instance metadata
+// service. The environment variables are read from env, not from the ones of
+// the process.
+func Load(ctx context.Context, env map[string]string) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if profile := env["AWS_PROFILE"]; profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
+	}
+	if filename := env["AWS_CONFIG_FILE"]; filename != "" {
+		opts = append(opts, config.WithSharedConfigFiles([]string{filename}))
+	}
+	if filename := env["AWS_SHARED_CREDENTIALS_FILE"]; filename != "" {
+		opts = append(opts, config.WithSharedCredentialsFiles([]string{filename}))
+	}
+	region := env["AWS_REGION"]
+	if region == "" {
+		region = env["AWS_DEFAULT_REGION"]
+	}
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	if id, secret := env["AWS_ACCESS_KEY_ID"], env["AWS_SECRET_ACCESS_KEY"]; id != "" && secret != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(id, secret, env["AWS_SESSION_TOKEN"])))
+	}
+	if strings.EqualFold(env["AWS_EC2_METADATA_DISABLED"], "true") {
+		opts = append(opts, config.WithEC2IMDSClientEnableState(imds.ClientDisabled))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("unable to load the AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	if cfg.Credentials == nil {
+		return aws.Config{}, errors.New("no AWS credentials were found")
+	}
+	if _, err = cfg.Credentials.Retrieve(ctx); err != nil {
+		return aws.Config{}, fmt.Errorf("no AWS credentials were found: %w", err)
+	}
+	return cfg, nil
+}
+
+// Endpoint returns the endpoint of the service with the ID, like S3 or
+// SECRETS_MANAGER, from the AWS_ENDPOINT_URL_<ID> or AWS_ENDPOINT_URL
+// environment variables, if it's set.
+func Endpoint(env map[string]string, serviceID string) string {
+	if endpoint := env["AWS_ENDPOINT_URL_"+serviceID]; endpoint != "" {
+		return endpoint
+	}
+	return env["AWS_ENDPOINT_URL"]
+}
@@ -0,0 +1,85 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BaselineTolerance is how much an aggregated value of a metric can change
+// from the baseline before it's a regression, e.g. "10%" or "+50" for an
+// increase, "-5%" for a decrease. The absolute values are in the units of the
+// metric, i.e. milliseconds for the times.
+type BaselineTolerance struct {
+	// Decrease is true if the regressions are the decreases of the value,
+	// e.g. of the rate of the requests, instead of the increases.
+	Decrease bool
+	// Value is the maximum change, a fraction of the baseline value if the
+	// tolerance is relative.
+	Value    float64
+	Relative bool
+}
+
+// ParseBaselineTolerance parses a tolerance like "10%", "+50" or "-5%".
+func ParseBaselineTolerance(s string) (BaselineTolerance, error) {
+	var t BaselineTolerance
+	text := strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(text, "-"):
+		t.Decrease = true
+		text = text[1:]
+	case strings.HasPrefix(text, "+"):
+		text = text[1:]
+	}
+	if strings.HasSuffix(text, "%") {
+		t.Relative = true
+		text = text[:len(text)-1]
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return t, fmt.Errorf("invalid tolerance '%s', it must be like 10%%, +50 or -5%%", s)
+	}
+	t.Value = value
+	if t.Relative {
+		t.Value /= 100
+	}
+	return t, nil
+}
+
+// Exceeded returns true if the change from the baseline value is a
+// regression greater than the tolerance.
+func (t BaselineTolerance) Exceeded(baseline, current float64) bool {
+	change := current - baseline
+	if t.Decrease {
+		change = -change
+	}
+	limit := t.Value
+	if t.Relative {
+		limit *= math.Abs(baseline)
+	}
+	return change > limit
+}
+
+func (t BaselineTolerance) String() string {
+	sign := "+"
+	if t.Decrease {
+		sign = "-"
+	}
+	if t.Relative {
+		return sign + strconv.FormatFloat(t.Value*100, 'f', -1, 64) + "%"
+	}
+	return sign + strconv.FormatFloat(t.Value, 'f', -1, 64)
+}
+
+// SplitBaselineKey splits the key of a baseline tolerance, like
+// http_req_duration{scenario:api}.p(95), in the metric and the statistic.
+func SplitBaselineKey(key string) (metric, stat string, err error) {
+	// the statistic can have dots, e.g. p(99.9), the tags can have them too
+	start := strings.LastIndexByte(key, '}') + 1
+	i := strings.IndexByte(key[start:], '.') + start
+	if i < start || i == 0 || i == len(key)-1 {
+		return "", "", fmt.Errorf("invalid baseline tolerance key '%s', it must be like http_req_duration.p(95)", key)
+	}
+	return key[:i], key[i+1:], nil
+}
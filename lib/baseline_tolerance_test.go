@@ -0,0 +1,66 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBaselineTolerance(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]BaselineTolerance{
+		"10%":   {Value: 0.1, Relative: true},
+		"+50":   {Value: 50},
+		" -5% ": {Decrease: true, Value: 0.05, Relative: true},
+		"0":     {},
+	}
+	for source, expected := range testCases {
+		tolerance, err := ParseBaselineTolerance(source)
+		require.NoError(t, err, source)
+		assert.Equal(t, expected, tolerance, source)
+	}
+
+	for _, source := range []string{"", "%", "ten", "+-5", "-inf", "10%%"} {
+		_, err := ParseBaselineTolerance(source)
+		assert.ErrorContains(t, err, "invalid tolerance", source)
+	}
+}
+
+func TestBaselineToleranceExceeded(t *testing.T) {
+	t.Parallel()
+
+	increase := BaselineTolerance{Value: 0.1, Relative: true}
+	assert.False(t, increase.Exceeded(100, 110))
+	assert.True(t, increase.Exceeded(100, 111))
+	assert.False(t, increase.Exceeded(100, 50))
+	assert.Equal(t, "+10%", increase.String())
+
+	decrease := BaselineTolerance{Decrease: true, Value: 5}
+	assert.False(t, decrease.Exceeded(100, 95))
+	assert.True(t, decrease.Exceeded(100, 94))
+	assert.False(t, decrease.Exceeded(100, 200))
+	assert.Equal(t, "-5", decrease.String())
+}
+
+func TestSplitBaselineKey(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string][2]string{
+		"http_req_duration.p(95)":                 {"http_req_duration", "p(95)"},
+		"http_req_duration.p(99.9)":               {"http_req_duration", "p(99.9)"},
+		"http_req_duration{url:http://a.b/c}.avg": {"http_req_duration{url:http://a.b/c}", "avg"},
+		"http_req_failed{scenario:api}.rate":      {"http_req_failed{scenario:api}", "rate"},
+	}
+	for key, expected := range testCases {
+		metric, stat, err := SplitBaselineKey(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, expected, [2]string{metric, stat}, key)
+	}
+
+	for _, key := range []string{"http_reqs", "http_reqs.", ".rate", "http_reqs{a:b.c}"} {
+		_, _, err := SplitBaselineKey(key)
+		assert.ErrorContains(t, err, "invalid baseline tolerance key", key)
+	}
+}
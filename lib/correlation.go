@@ -0,0 +1,53 @@
+package lib
+
+import (
+	uuid "github.com/nu7hatch/gouuid"
+)
+
+const (
+	// CorrelationIDMetadata is the key of the metadata of the correlation ID
+	// of the iterations, if the correlationID option is enabled.
+	CorrelationIDMetadata = "correlation_id"
+
+	// DefaultCorrelationIDHeader is the header that the correlation ID is sent
+	// as, if the metadataHeaders option doesn't set another one.
+	DefaultCorrelationIDHeader = "X-Correlation-ID"
+)
+
+// NewCorrelationID returns a new random correlation ID.
+func NewCorrelationID() (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// MetadataHeaderNames returns the names of the headers that the metadata is
+// sent as by the HTTP and gRPC clients, by metadata key. The correlation ID is
+// sent as X-Correlation-ID, if it's enabled and no other header is set for it.
+func (o Options) MetadataHeaderNames() map[string]string {
+	if len(o.MetadataHeaders) == 0 && !o.CorrelationID.Bool {
+		return nil
+	}
+	headers := make(map[string]string, len(o.MetadataHeaders)+1)
+	for key, header := range o.MetadataHeaders {
+		headers[key] = header
+	}
+	if _, ok := headers[CorrelationIDMetadata]; o.CorrelationID.Bool && !ok {
+		headers[CorrelationIDMetadata] = DefaultCorrelationIDHeader
+	}
+	return headers
+}
+
+// MetadataHeaderValues returns the values of the headers of the metadata that
+// is set, by header name.
+func MetadataHeaderValues(headers map[string]string, metadata map[string]string) map[string]string {
+	values := make(map[string]string, len(headers))
+	for key, header := range headers {
+		if value, ok := metadata[key]; ok {
+			values[header] = value
+		}
+	}
+	return values
+}
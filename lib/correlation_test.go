@@ -0,0 +1,45 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestMetadataHeaderNames(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, Options{}.MetadataHeaderNames())
+	assert.Equal(t,
+		map[string]string{"correlation_id": "X-Correlation-ID"},
+		Options{CorrelationID: null.BoolFrom(true)}.MetadataHeaderNames())
+	assert.Equal(t,
+		map[string]string{"correlation_id": "X-Request-ID", "user": "X-User-ID"},
+		Options{
+			CorrelationID:   null.BoolFrom(true),
+			MetadataHeaders: map[string]string{"correlation_id": "X-Request-ID", "user": "X-User-ID"},
+		}.MetadataHeaderNames())
+}
+
+func TestMetadataHeaderValues(t *testing.T) {
+	t.Parallel()
+
+	values := MetadataHeaderValues(
+		map[string]string{"correlation_id": "X-Correlation-ID", "user": "X-User-ID"},
+		map[string]string{"correlation_id": "1234", "other": "value"},
+	)
+	assert.Equal(t, map[string]string{"X-Correlation-ID": "1234"}, values)
+}
+
+func TestNewCorrelationID(t *testing.T) {
+	t.Parallel()
+
+	first, err := NewCorrelationID()
+	require.NoError(t, err)
+	second, err := NewCorrelationID()
+	require.NoError(t, err)
+	assert.Len(t, first, 36)
+	assert.NotEqual(t, first, second)
+}
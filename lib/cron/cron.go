@@ -0,0 +1,239 @@
+// Package cron parses cron expressions and calculates the times they match.
+//
+// The expressions have the standard 5 fields (minute, hour, day of month,
+// month and day of week), optionally preceded by a seconds field. Each field
+// can be a *, a value, a range, a list of them and have a /step. The months
+// and the days of the week can also be given by their 3-letter names. The
+// @yearly, @monthly, @weekly, @daily, @hourly and @every <duration> shortcuts
+// are supported too.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr string
+
+	second, minute, hour, dom, month, dow uint64
+	// If either of the days is *, both have to match, otherwise it's enough
+	// for one of them to match, like in the standard cron.
+	anyDay bool
+
+	every time.Duration // for the @every shortcut
+}
+
+// field is the range of the values of a field of a cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+//nolint:gochecknoglobals
+var (
+	secondField = field{name: "seconds", min: 0, max: 59}
+	minuteField = field{name: "minutes", min: 0, max: 59}
+	hourField   = field{name: "hours", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is Sunday as well as 0
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	shortcuts = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Schedule, error) {
+	s, err := parse(strings.TrimSpace(expr))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': %w", expr, err)
+	}
+	s.expr = expr
+	return s, nil
+}
+
+func parse(expr string) (*Schedule, error) {
+	if every, ok := strings.CutPrefix(expr, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(every))
+		if err != nil {
+			return nil, err
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("the @every interval has to be at least 1s")
+		}
+		return &Schedule{every: d}, nil
+	}
+	if strings.HasPrefix(expr, "@") {
+		fields, ok := shortcuts[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("unknown shortcut %s", expr)
+		}
+		expr = fields
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("it has %d fields instead of 5, or 6 with the seconds", len(fields))
+	}
+
+	s := &Schedule{}
+	var err error
+	for i, f := range []struct {
+		bits  *uint64
+		field field
+	}{
+		{&s.second, secondField}, {&s.minute, minuteField}, {&s.hour, hourField},
+		{&s.dom, domField}, {&s.month, monthField}, {&s.dow, dowField},
+	} {
+		if *f.bits, err = f.field.parse(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.anyDay = isAny(fields[3]) || isAny(fields[5])
+	return s, nil
+}
+
+func isAny(value string) bool {
+	return value == "*" || value == "?"
+}
+
+// parse returns the bits of the values that match the value of the field.
+func (f field) parse(value string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rng, stepValue, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepValue); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step '%s' of the %s", stepValue, f.name)
+			}
+		}
+
+		var start, end int
+		if isAny(rng) {
+			start, end = f.min, f.max
+		} else {
+			first, last, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = f.value(first); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = f.value(last); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = f.max
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range '%s' of the %s", rng, f.name)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(value string) (int, error) {
+	if v, ok := f.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value '%s' of the %s, it has to be from %d to %d", value, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the cron expression.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t that the schedule matches, in the
+// location of t. It returns the zero time if there isn't one in the next 5
+// years, e.g. for the 30th of February.
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Truncate(time.Second).Add(s.every)
+	}
+
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for s.second&(1<<uint(t.Second())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second()+1, 0, loc)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatches := s.dom&(1<<uint(t.Day())) != 0
+	dowMatches := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDay {
+		return domMatches && dowMatches
+	}
+	return domMatches || dowMatches
+}
@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		expr, expErr string
+	}{
+		{"* * * *", "it has 4 fields instead of 5, or 6 with the seconds"},
+		{"60 * * * *", "invalid value '60' of the minutes, it has to be from 0 to 59"},
+		{"* * 0 * *", "invalid value '0' of the day of month, it has to be from 1 to 31"},
+		{"* * * foo * ", "invalid value 'foo' of the month, it has to be from 1 to 12"},
+		{"*/0 * * * *", "invalid step '0' of the minutes"},
+		{"5-1 * * * *", "invalid range '5-1' of the minutes"},
+		{"@often", "unknown shortcut @often"},
+		{"@every 10ms", "the @every interval has to be at least 1s"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse(tc.expr)
+			require.ErrorContains(t, err, "invalid cron expression '"+tc.expr+"': "+tc.expErr)
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	// a Wednesday
+	from := time.Date(2024, time.January, 31, 10, 17, 30, 500, time.UTC)
+	testCases := []struct {
+		expr string
+		exp  time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.January, 31, 10, 18, 0, 0, time.UTC)},
+		{"* * * * * *", time.Date(2024, time.January, 31, 10, 17, 31, 0, time.UTC)},
+		{"*/15 * * * * *", time.Date(2024, time.January, 31, 10, 17, 45, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC)},
+		{"5,10 9-11 * * *", time.Date(2024, time.January, 31, 11, 5, 0, 0, time.UTC)},
+		{"0 0 * * *", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{"0 12 * * mon-fri", time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 1", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)}, // either of the days
+		{"0 0 13 * fri", time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2024, time.January, 31, 10, 19, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+			s, err := Parse(tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, s.Next(from))
+			assert.Equal(t, tc.expr, s.String())
+		})
+	}
+}
@@ -80,6 +80,14 @@ type ExecutionState struct {
 
 	ExecutionTuple *ExecutionTuple // TODO Rename, possibly move
 
+	// Saturation detects when k6 itself is the bottleneck of the test run,
+	// it's nil if there's no test run state.
+	Saturation *SaturationMonitor
+
+	// MemoryBudget delays the new iterations when k6 is close to its
+	// maxMemory limit, it's nil if there's no limit.
+	MemoryBudget *MemoryBudget
+
 	// vus is the shared channel buffer that contains all of the VUs that have
 	// been initialized and aren't currently being used by a executor.
 	//
@@ -189,12 +197,19 @@ type ExecutionState struct {
 	//
 	// But if we want to wait until a script resumes, or be notified of the
 	// start/resume event from a channel (as part of a select{}), we have to
-	// acquire the pauseStateLock, get the current resumeNotify instance,
+	// acquire the pause state lock, get the current resumeNotify instance,
 	// release the lock and wait to read from resumeNotify (when it's closed by
 	// Resume()).
-	currentPauseTime    *int64
-	pauseStateLock      sync.RWMutex
-	totalPausedDuration time.Duration // only modified behind the lock
+	currentPauseTime *int64
+	pause            *pauseState
+}
+
+// pauseState is the part of the pause state of the ExecutionState that is
+// modified behind its lock. It's a pointer, like the rest of the state, so it's
+// shared with the derived states of WithOwnVUBuffer().
+type pauseState struct {
+	lock                sync.RWMutex
+	totalPausedDuration time.Duration
 	resumeNotify        chan struct{}
 }
 
@@ -209,10 +224,21 @@ func NewExecutionState(
 
 	maxUnplannedUninitializedVUs := int64(maxPossibleVUs - maxPlannedVUs)
 
+	var saturation *SaturationMonitor
+	var memoryBudget *MemoryBudget
+	if testRunState != nil {
+		saturation = NewSaturationMonitor(testRunState.Options, testRunState.Logger)
+		if maxMemory := testRunState.Options.MaxMemory.Int64; maxMemory > 0 {
+			memoryBudget = NewMemoryBudget(maxMemory, testRunState.Logger)
+		}
+	}
+
 	segIdx := NewSegmentedIndex(et)
 	return &ExecutionState{
 		Test:           testRunState,
 		ExecutionTuple: et,
+		Saturation:     saturation,
+		MemoryBudget:   memoryBudget,
 
 		vus: make(chan InitializedVU, maxPossibleVUs),
 
@@ -227,12 +253,24 @@ func NewExecutionState(
 		startTime:                  new(int64),
 		endTime:                    new(int64),
 		currentPauseTime:           new(int64),
-		pauseStateLock:             sync.RWMutex{},
-		totalPausedDuration:        0, // Accessed only behind the pauseStateLock
-		resumeNotify:               resumeNotify,
+		pause:                      &pauseState{resumeNotify: resumeNotify},
 	}
 }
 
+// WithOwnVUBuffer returns a copy of the ExecutionState, which shares all of its
+// counters and its pause state, but has its own buffer of VUs, for the
+// executors that are started after the test was initialized, like the
+// scenarios started from the REST API. Their VUs aren't planned for in the
+// original buffer, so they are initialized by the caller and added to the new
+// one with AddInitializedVU(), and the unplanned ones are initialized lazily.
+func (es *ExecutionState) WithOwnVUBuffer(maxPlannedVUs, maxPossibleVUs uint64) *ExecutionState {
+	maxUnplannedUninitializedVUs := int64(maxPossibleVUs - maxPlannedVUs)
+	derived := *es
+	derived.vus = make(chan InitializedVU, maxPossibleVUs)
+	derived.uninitializedUnplannedVUs = &maxUnplannedUninitializedVUs
+	return &derived
+}
+
 // GetUniqueVUIdentifiers returns the next unique VU IDs, both local (for the
 // current instance, exposed as __VU) and global (across k6 instances, exposed
 // in the k6/execution module). It starts from 1, for backwards compatibility.
@@ -378,10 +416,10 @@ func (es *ExecutionState) GetCurrentTestRunDuration() time.Duration {
 		return 0
 	}
 
-	es.pauseStateLock.RLock()
+	es.pause.lock.RLock()
 	endTime := atomic.LoadInt64(es.endTime)
-	pausedDuration := es.totalPausedDuration
-	es.pauseStateLock.RUnlock()
+	pausedDuration := es.pause.totalPausedDuration
+	es.pause.lock.RUnlock()
 
 	if endTime == 0 {
 		pauseTime := atomic.LoadInt64(es.currentPauseTime)
@@ -401,13 +439,13 @@ func (es *ExecutionState) GetCurrentTestRunDuration() time.Duration {
 // channel for resumeNotify.
 // Pause can return an error if the test was already paused.
 func (es *ExecutionState) Pause() error {
-	es.pauseStateLock.Lock()
-	defer es.pauseStateLock.Unlock()
+	es.pause.lock.Lock()
+	defer es.pause.lock.Unlock()
 
 	if !atomic.CompareAndSwapInt64(es.currentPauseTime, 0, time.Now().UnixNano()) {
 		return errors.New("test execution was already paused")
 	}
-	es.resumeNotify = make(chan struct{})
+	es.pause.resumeNotify = make(chan struct{})
 	return nil
 }
 
@@ -416,8 +454,8 @@ func (es *ExecutionState) Pause() error {
 // the old currentPauseTime and adds it to
 // Resume will emit an error if the test wasn't paused.
 func (es *ExecutionState) Resume() error {
-	es.pauseStateLock.Lock()
-	defer es.pauseStateLock.Unlock()
+	es.pause.lock.Lock()
+	defer es.pause.lock.Unlock()
 
 	currentPausedTime := atomic.SwapInt64(es.currentPauseTime, 0)
 	if currentPausedTime == 0 {
@@ -426,10 +464,10 @@ func (es *ExecutionState) Resume() error {
 
 	// Check that it's not the pause before execution actually starts
 	if atomic.LoadInt64(es.startTime) != 0 {
-		es.totalPausedDuration += time.Duration(time.Now().UnixNano() - currentPausedTime)
+		es.pause.totalPausedDuration += time.Duration(time.Now().UnixNano() - currentPausedTime)
 	}
 
-	close(es.resumeNotify)
+	close(es.pause.resumeNotify)
 
 	return nil
 }
@@ -450,9 +488,9 @@ func (es *ExecutionState) Resume() error {
 //	    <-executionState.ResumeNotify()
 //	}
 func (es *ExecutionState) ResumeNotify() <-chan struct{} {
-	es.pauseStateLock.RLock()
-	defer es.pauseStateLock.RUnlock()
-	return es.resumeNotify
+	es.pause.lock.RLock()
+	defer es.pause.lock.RUnlock()
+	return es.pause.resumeNotify
 }
 
 // GetPlannedVU tries to get a pre-initialized VU from the buffer channel. This
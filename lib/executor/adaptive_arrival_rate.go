@@ -0,0 +1,547 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/ui/pb"
+)
+
+const adaptiveArrivalRateType = "adaptive-arrival-rate"
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		adaptiveArrivalRateType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewAdaptiveArrivalRateConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// AdaptiveArrivalRateConfig stores config for the adaptive arrival-rate executor
+type AdaptiveArrivalRateConfig struct {
+	BaseConfig
+	StartRate null.Int           `json:"startRate"`
+	MaxRate   null.Int           `json:"maxRate"`
+	TimeUnit  types.NullDuration `json:"timeUnit"`
+	Duration  types.NullDuration `json:"duration"`
+
+	// The goal is a threshold expression, like p(95)<500, that is evaluated on
+	// the samples of the metric, or sub-metric, of the latest adjustment
+	// interval, to decide if the rate can be increased or has to be decreased.
+	Metric             null.String        `json:"metric"`
+	Goal               null.String        `json:"goal"`
+	AdjustmentInterval types.NullDuration `json:"adjustmentInterval"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewAdaptiveArrivalRateConfig returns an AdaptiveArrivalRateConfig with default values
+func NewAdaptiveArrivalRateConfig(name string) *AdaptiveArrivalRateConfig {
+	return &AdaptiveArrivalRateConfig{
+		BaseConfig:         NewBaseConfig(name, adaptiveArrivalRateType),
+		StartRate:          null.NewInt(1, false),
+		TimeUnit:           types.NewNullDuration(1*time.Second, false),
+		AdjustmentInterval: types.NewNullDuration(10*time.Second, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &AdaptiveArrivalRateConfig{}
+
+// GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
+func (aarc AdaptiveArrivalRateConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(aarc.PreAllocatedVUs.Int64)
+}
+
+// GetMaxVUs is just a helper method that returns the scaled max VUs.
+func (aarc AdaptiveArrivalRateConfig) GetMaxVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(aarc.MaxVUs.Int64)
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (aarc AdaptiveArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) string {
+	preAllocatedVUs, maxVUs := aarc.GetPreAllocatedVUs(et), aarc.GetMaxVUs(et)
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", preAllocatedVUs)
+	if maxVUs > preAllocatedVUs {
+		maxVUsRange += fmt.Sprintf("-%d", maxVUs)
+	}
+
+	timeUnit := aarc.TimeUnit.TimeDuration()
+	maxRatePerSec, _ := getArrivalRatePerSec(
+		getScaledArrivalRate(et.Segment, aarc.MaxRate.Int64, timeUnit),
+	).Float64()
+
+	return fmt.Sprintf("Up to %.2f iterations/s for %s while %s %s%s", maxRatePerSec,
+		aarc.Duration.Duration, aarc.Metric.String, aarc.Goal.String, aarc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+func (aarc *AdaptiveArrivalRateConfig) Validate() []error {
+	errors := append(aarc.BaseConfig.Validate(), validateNoIterationPacing(aarc.Pacing)...)
+	if !aarc.MaxRate.Valid {
+		errors = append(errors, fmt.Errorf("the maximum iteration rate isn't specified"))
+	} else if aarc.MaxRate.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the maximum iteration rate must be more than 0"))
+	}
+
+	if aarc.StartRate.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the start iteration rate must be more than 0"))
+	} else if aarc.MaxRate.Valid && aarc.StartRate.Int64 > aarc.MaxRate.Int64 {
+		errors = append(errors, fmt.Errorf("the start iteration rate can't be more than the maximum one"))
+	}
+
+	if aarc.TimeUnit.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the timeUnit must be more than 0"))
+	}
+
+	if !aarc.Duration.Valid {
+		errors = append(errors, fmt.Errorf("the duration is unspecified"))
+	} else if aarc.Duration.TimeDuration() < minDuration {
+		errors = append(errors, fmt.Errorf(
+			"the duration must be at least %s, but is %s", minDuration, aarc.Duration,
+		))
+	}
+
+	if aarc.Metric.String == "" {
+		errors = append(errors, fmt.Errorf("the metric of the goal isn't specified"))
+	}
+	if aarc.Goal.String == "" {
+		errors = append(errors, fmt.Errorf("the goal isn't specified"))
+	} else if err := aarc.newGoal().Parse(); err != nil {
+		errors = append(errors, fmt.Errorf("the goal is invalid: %w", err))
+	}
+
+	if aarc.AdjustmentInterval.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the adjustmentInterval must be more than 0"))
+	} else if aarc.Duration.Valid && aarc.AdjustmentInterval.TimeDuration() > aarc.Duration.TimeDuration() {
+		errors = append(errors, fmt.Errorf("the adjustmentInterval can't be more than the duration"))
+	}
+
+	if !aarc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if aarc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !aarc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		aarc.MaxVUs.Int64 = aarc.PreAllocatedVUs.Int64
+	} else if aarc.MaxVUs.Int64 < aarc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errors
+}
+
+// newGoal returns the unparsed threshold of the goal.
+func (aarc AdaptiveArrivalRateConfig) newGoal() *metrics.Thresholds {
+	goal := metrics.NewThresholds([]string{aarc.Goal.String})
+	return &goal
+}
+
+// IsDistributable returns false, since the rate is adjusted to the metrics of
+// the local instance.
+func (aarc AdaptiveArrivalRateConfig) IsDistributable() bool {
+	return false
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+func (aarc AdaptiveArrivalRateConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(et.ScaleInt64(aarc.PreAllocatedVUs.Int64)),
+			MaxUnplannedVUs: uint64(et.ScaleInt64(aarc.MaxVUs.Int64) - et.ScaleInt64(aarc.PreAllocatedVUs.Int64)),
+		}, {
+			TimeOffset:      aarc.Duration.TimeDuration() + aarc.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new AdaptiveArrivalRate executor
+func (aarc AdaptiveArrivalRateConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	return &AdaptiveArrivalRate{
+		BaseExecutor: NewBaseExecutor(&aarc, es, logger),
+		config:       aarc,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (aarc AdaptiveArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return aarc.GetMaxVUs(et) > 0
+}
+
+// capacitySearch looks for the highest rate the goal is met at: it doubles
+// the rate until the goal isn't met, and then it bisects the rates between
+// the highest one it was met at and the lowest one it wasn't, until it holds
+// the former. If the goal isn't met at it anymore, the search starts over
+// from half of it.
+type capacitySearch struct {
+	rate, maxRate int64
+	// met is the highest rate the goal was met at, 0 if none
+	met int64
+	// failed is the lowest rate the goal wasn't met at, 0 if none
+	failed int64
+}
+
+func (cs *capacitySearch) adjust(goalMet bool) {
+	if !goalMet {
+		cs.failed = cs.rate
+		if cs.met >= cs.failed {
+			cs.met = cs.failed / 2
+		}
+		cs.rate = (cs.met + cs.failed) / 2
+		if cs.rate < 1 {
+			cs.rate = 1
+		}
+		return
+	}
+
+	if cs.rate > cs.met {
+		cs.met = cs.rate
+	}
+	if cs.failed == 0 {
+		cs.rate *= 2
+		if cs.rate > cs.maxRate {
+			cs.rate = cs.maxRate
+		}
+		return
+	}
+	cs.rate = (cs.met + cs.failed) / 2
+}
+
+// AdaptiveArrivalRate adjusts the iteration rate to find and hold the highest
+// one at which a goal on a metric is met, and reports it as its capacity.
+type AdaptiveArrivalRate struct {
+	*BaseExecutor
+	config AdaptiveArrivalRateConfig
+	et     *lib.ExecutionTuple
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &AdaptiveArrivalRate{}
+
+// Init values needed for the execution
+func (aar *AdaptiveArrivalRate) Init(_ context.Context) error {
+	// err should always be nil, because Init() won't be called for executors
+	// with no work, as determined by their config's HasWork() method.
+	et, err := aar.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(aar.config.MaxVUs.Int64)
+	aar.et = et
+	aar.iterSegIndex = lib.NewSegmentedIndex(et)
+
+	return err
+}
+
+// tickerPeriod returns the period between the iterations at the rate, 0 if
+// there aren't any in the execution segment.
+func (aar AdaptiveArrivalRate) tickerPeriod(rate int64) time.Duration {
+	return getTickerPeriod(getScaledArrivalRate(aar.et.Segment, rate, aar.config.TimeUnit.TimeDuration())).TimeDuration()
+}
+
+// ratePerSec returns the iterations per second of the rate.
+func (aar AdaptiveArrivalRate) ratePerSec(rate int64) float64 {
+	perSec, _ := getArrivalRatePerSec(
+		getScaledArrivalRate(aar.et.Segment, rate, aar.config.TimeUnit.TimeDuration()),
+	).Float64()
+	return perSec
+}
+
+// watchGoal returns a function that returns if the goal is met by the
+// samples of the metric in the latest adjustment interval, and false if there
+// aren't any.
+func (aar AdaptiveArrivalRate) watchGoal() (func() (met bool, ok bool, err error), error) {
+	test := aar.executionState.Test
+	if test.WatchMetric == nil {
+		return nil, fmt.Errorf("the %s executor needs the metrics to be processed, "+
+			"it can't be used with both --no-summary and --no-thresholds", adaptiveArrivalRateType)
+	}
+
+	goal := aar.config.newGoal()
+	if err := goal.Parse(); err != nil {
+		return nil, err
+	}
+	if err := goal.Validate(aar.config.Metric.String, test.Registry); err != nil {
+		return nil, err
+	}
+
+	interval := aar.config.AdjustmentInterval.TimeDuration()
+	windowSink, err := test.WatchMetric(aar.config.Metric.String, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() (bool, bool, error) {
+		sink := windowSink()
+		if sink == nil {
+			return false, false, nil
+		}
+		met, err := goal.Run(sink, interval)
+		return met, true, err
+	}, nil
+}
+
+// Run executes the iterations at the rate adjusted to the goal.
+//
+//nolint:funlen,gocognit
+func (aar AdaptiveArrivalRate) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	goalMet, err := aar.watchGoal()
+	if err != nil {
+		return err
+	}
+
+	gracefulStop := aar.config.GetGracefulStop()
+	duration := aar.config.Duration.TimeDuration()
+	interval := aar.config.AdjustmentInterval.TimeDuration()
+	preAllocatedVUs := aar.config.GetPreAllocatedVUs(aar.executionState.ExecutionTuple)
+	maxVUs := aar.config.GetMaxVUs(aar.executionState.ExecutionTuple)
+	search := &capacitySearch{rate: aar.config.StartRate.Int64, maxRate: aar.config.MaxRate.Int64}
+	currentRate := search.rate
+
+	// Make sure the log and the progress bar have accurate information
+	aar.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"adjustmentInterval": interval, "type": aar.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	defer func() {
+		cancel()
+		<-waitOnProgressChannel
+	}()
+
+	vusPool := newActiveVUPool(aar.executionState)
+	defer func() {
+		// Make sure all VUs aren't executing iterations anymore, for the cancel()
+		// below to deactivate them.
+		<-returnedVUs
+		// first close the vusPool so we wait for the gracefulShutdown
+		vusPool.Close()
+		cancel()
+		activeVUsWg.Wait()
+	}()
+	activeVUsCount := uint64(0)
+
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+	maxRatePerSec := aar.ratePerSec(search.maxRate)
+	progressFn := func() (float64, []string) {
+		spent := time.Since(startTime)
+		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs",
+			vusPool.Running(), currActiveVUs)
+		ratePerSec := aar.ratePerSec(atomic.LoadInt64(&currentRate))
+		progIters := fmt.Sprintf(pb.GetFixedLengthFloatFormat(maxRatePerSec, 2)+" iters/s", ratePerSec)
+
+		right := []string{progVUs, duration.String(), progIters}
+
+		if spent > duration {
+			return 1, right
+		}
+
+		spentDuration := pb.GetFixedLengthDuration(spent, duration)
+		progDur := fmt.Sprintf("%s/%s", spentDuration, duration)
+		right[1] = progDur
+
+		return math.Min(1, float64(spent)/float64(duration)), right
+	}
+	aar.progress.Modify(pb.WithProgress(progressFn))
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:       aar.config.Name,
+		Executor:   aar.config.Type,
+		StartTime:  startTime,
+		ProgressFn: progressFn,
+	})
+
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &aar, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		// Return the VU without decreasing the global active VU counter, which
+		// is done in the goroutine started by activeVUPool.AddVU, whenever the
+		// VU finishes running an iteration. This results in a more accurate
+		// report of VUs that are _actually_ active.
+		aar.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationBasic := getIterationRunner(aar.executionState, aar.logger)
+	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
+		activeVUsWg.Add(1)
+		activeVU := initVU.Activate(getVUActivationParams(
+			maxDurationCtx, aar.config.BaseConfig, returnVU,
+			aar.nextIterationCounters,
+		))
+		atomic.AddUint64(&activeVUsCount, 1)
+		vusPool.AddVU(maxDurationCtx, activeVU, runIterationBasic)
+		return activeVU
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+		for range makeUnplannedVUCh {
+			aar.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := aar.executionState.GetUnplannedVU(maxDurationCtx, aar.logger)
+			if err != nil {
+				// TODO figure out how to return it to the Run goroutine
+				aar.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				aar.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	// Get the pre-allocated VUs in the local buffer
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := aar.executionState.GetPlannedVU(aar.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	adjustment := time.NewTicker(interval)
+	defer adjustment.Stop()
+
+	droppedIterationMetric := aar.executionState.Test.BuiltinMetrics.DroppedIterations
+	capacityMetric := aar.executionState.Test.BuiltinMetrics.AdaptiveCapacity
+	shownWarning := false
+	metricTags := aar.getMetricTags(nil)
+
+	// the first iteration starts right away, and the next ones are scheduled
+	// after the previous one, at the period of the current rate
+	period := aar.tickerPeriod(search.rate)
+	last := startTime.Add(-period)
+	timer := time.NewTimer(time.Hour * 24)
+	defer timer.Stop()
+	reschedule := func() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if period > 0 {
+			timer.Reset(time.Until(last.Add(period)))
+		}
+	}
+	reschedule()
+	for {
+		select {
+		case <-timer.C:
+			// no VU is taken for the iteration while the scenario is paused,
+			// and the next ones are scheduled after it's resumed
+			if resumed := aar.resumedChan(); resumed != nil {
+				pausedAt := time.Now()
+				select {
+				case <-resumed:
+					last = last.Add(time.Since(pausedAt))
+				case <-regDurationCtx.Done():
+					return nil
+				}
+			}
+			last = last.Add(period)
+			timer.Reset(time.Until(last.Add(period)))
+			if skipLateIteration(parentCtx, aar.executionState, out, aar.config.Name, metricTags, time.Since(last)) {
+				continue
+			}
+			if vusPool.TryRunIteration() {
+				continue
+			}
+
+			// Since there aren't any free VUs available, consider this iteration
+			// dropped - we aren't going to try to recover it, but
+			metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: droppedIterationMetric,
+					Tags:   metricTags,
+				},
+				Time:  time.Now(),
+				Value: 1,
+			})
+
+			// We'll try to start allocating another VU in the background,
+			// non-blockingly, if we have remainingUnplannedVUs...
+			if remainingUnplannedVUs == 0 {
+				if !shownWarning {
+					aar.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+					shownWarning = true
+				}
+				continue
+			}
+
+			select {
+			case makeUnplannedVUCh <- struct{}{}: // great!
+				remainingUnplannedVUs--
+			default: // we're already allocating a new VU
+			}
+
+		case <-adjustment.C:
+			met, ok, err := goalMet()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue // there aren't any samples to adjust the rate to yet
+			}
+			search.adjust(met)
+			atomic.StoreInt64(&currentRate, search.rate)
+
+			if newPeriod := aar.tickerPeriod(search.rate); newPeriod != period {
+				if period == 0 {
+					last = time.Now().Add(-newPeriod)
+				}
+				period = newPeriod
+				reschedule()
+			}
+			aar.logger.WithFields(logrus.Fields{
+				"goalMet": met, "rate": search.rate, "capacity": search.met,
+			}).Debug("Adjusted the iteration rate")
+
+			metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: capacityMetric,
+					Tags:   metricTags,
+				},
+				Time:  time.Now(),
+				Value: aar.ratePerSec(search.met),
+			})
+
+		case <-regDurationCtx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func getTestAdaptiveArrivalRateConfig() *AdaptiveArrivalRateConfig {
+	return &AdaptiveArrivalRateConfig{
+		BaseConfig:         BaseConfig{GracefulStop: types.NullDurationFrom(1 * time.Second)},
+		StartRate:          null.IntFrom(10),
+		MaxRate:            null.IntFrom(80),
+		TimeUnit:           types.NullDurationFrom(time.Second),
+		Duration:           types.NullDurationFrom(2 * time.Second),
+		Metric:             null.StringFrom("iteration_duration"),
+		Goal:               null.StringFrom("avg<100"),
+		AdjustmentInterval: types.NullDurationFrom(400 * time.Millisecond),
+		PreAllocatedVUs:    null.IntFrom(10),
+		MaxVUs:             null.IntFrom(20),
+	}
+}
+
+func TestCapacitySearch(t *testing.T) {
+	t.Parallel()
+
+	search := &capacitySearch{rate: 10, maxRate: 100}
+	steps := []struct {
+		goalMet         bool
+		expRate, expMet int64
+	}{
+		{goalMet: true, expRate: 20, expMet: 10},
+		{goalMet: true, expRate: 40, expMet: 20},
+		{goalMet: false, expRate: 30, expMet: 20},
+		{goalMet: true, expRate: 35, expMet: 30},
+		{goalMet: true, expRate: 37, expMet: 35},
+		{goalMet: false, expRate: 36, expMet: 35},
+		{goalMet: true, expRate: 36, expMet: 36},
+		// the goal isn't met at the capacity anymore
+		{goalMet: false, expRate: 27, expMet: 18},
+		{goalMet: false, expRate: 22, expMet: 18},
+	}
+	for i, step := range steps {
+		search.adjust(step.goalMet)
+		assert.Equal(t, step.expRate, search.rate, "rate of step %d", i)
+		assert.Equal(t, step.expMet, search.met, "capacity of step %d", i)
+	}
+
+	search = &capacitySearch{rate: 60, maxRate: 100}
+	search.adjust(true)
+	assert.Equal(t, int64(100), search.rate)
+	search.adjust(true)
+	assert.Equal(t, int64(100), search.rate)
+	assert.Equal(t, int64(100), search.met)
+
+	search = &capacitySearch{rate: 1, maxRate: 100}
+	search.adjust(false)
+	assert.Equal(t, int64(1), search.rate)
+}
+
+func TestAdaptiveArrivalRateRunWithoutMetrics(t *testing.T) {
+	t.Parallel()
+
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error { return nil })
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestAdaptiveArrivalRateConfig())
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	err := test.executor.Run(test.ctx, engineOut)
+	require.ErrorContains(t, err, "needs the metrics to be processed")
+}
+
+func TestAdaptiveArrivalRateRunAdjustsRate(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestAdaptiveArrivalRateConfig())
+	defer test.cancel()
+
+	var watched string
+	test.state.Test.WatchMetric = func(name string, length time.Duration) (func() metrics.Sink, error) {
+		watched = name
+		assert.Equal(t, 400*time.Millisecond, length)
+		return func() metrics.Sink {
+			sink := metrics.NewSink(metrics.Trend)
+			sink.Add(metrics.Sample{Value: 50})
+			return sink
+		}, nil
+	}
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	assert.Equal(t, "iteration_duration", watched)
+
+	// the goal is always met, so the rate is doubled from 10 to 20, 40 and
+	// then 80 iterations/s, where it's capped
+	var capacities []float64
+	for len(engineOut) > 0 {
+		for _, sample := range (<-engineOut).GetSamples() {
+			if sample.Metric.Name == metrics.AdaptiveCapacityName {
+				capacities = append(capacities, sample.Value)
+			}
+		}
+	}
+	require.GreaterOrEqual(t, len(capacities), 3)
+	assert.Equal(t, []float64{10, 20, 40}, capacities[:3])
+	assert.InDelta(t, 10*0.4+20*0.4+40*0.4+80*0.8, float64(atomic.LoadInt64(&count)), 15)
+}
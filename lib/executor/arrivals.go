@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+const (
+	arrivalsConstant  = "constant"
+	arrivalsPoisson   = "poisson"
+	arrivalsLognormal = "lognormal"
+	arrivalsCustom    = "custom"
+)
+
+// ArrivalsConfig configures the distribution of the times between the
+// iterations of the arrival-rate executors, which are evenly spaced by
+// default. The times are drawn with the mean of the evenly spaced ones, so
+// the average rate stays the same.
+type ArrivalsConfig struct {
+	// One of constant, the default, poisson, lognormal or custom.
+	Distribution null.String `json:"distribution"`
+	// The standard deviation of the logarithm of the lognormal times.
+	Sigma null.Float `json:"sigma"`
+	// The relative times that the custom ones are uniformly drawn from.
+	Values []float64 `json:"values"`
+	// The seed of the random times, to reproduce them.
+	Seed null.Int `json:"seed"`
+}
+
+// Validate makes sure the distribution is known and has valid parameters.
+func (ac *ArrivalsConfig) Validate() []error {
+	if ac == nil {
+		return nil
+	}
+
+	var errors []error
+	switch ac.Distribution.String {
+	case "", arrivalsConstant, arrivalsPoisson:
+	case arrivalsLognormal:
+		if ac.Sigma.Valid && ac.Sigma.Float64 <= 0 {
+			errors = append(errors, fmt.Errorf("the sigma of the lognormal arrivals must be more than 0"))
+		}
+	case arrivalsCustom:
+		if len(ac.Values) == 0 {
+			errors = append(errors, fmt.Errorf("the values of the custom arrivals aren't specified"))
+		}
+		for _, v := range ac.Values {
+			if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+				errors = append(errors, fmt.Errorf("the values of the custom arrivals can't be negative"))
+				break
+			}
+		}
+		if mean(ac.Values) == 0 {
+			errors = append(errors, fmt.Errorf("the values of the custom arrivals can't be all 0"))
+		}
+	default:
+		errors = append(errors, fmt.Errorf(
+			"the arrivals distribution '%s' is unknown, it has to be one of %s, %s, %s or %s",
+			ac.Distribution.String, arrivalsConstant, arrivalsPoisson, arrivalsLognormal, arrivalsCustom,
+		))
+	}
+
+	if ac.Distribution.String != arrivalsLognormal && ac.Sigma.Valid {
+		errors = append(errors, fmt.Errorf("the sigma can only be specified for lognormal arrivals"))
+	}
+	if ac.Distribution.String != arrivalsCustom && ac.Values != nil {
+		errors = append(errors, fmt.Errorf("the values can only be specified for custom arrivals"))
+	}
+	return errors
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	if len(values) == 0 {
+		return 0
+	}
+	return sum / float64(len(values))
+}
+
+// newSampler returns a function that draws the relative times between the
+// iterations, with a mean of 1, or nil if they are evenly spaced.
+func (ac *ArrivalsConfig) newSampler() func() float64 {
+	if ac == nil {
+		return nil
+	}
+
+	seed := ac.Seed.Int64
+	if !ac.Seed.Valid {
+		seed = time.Now().UnixNano()
+	}
+	r := rand.New(rand.NewSource(seed)) //nolint:gosec
+
+	switch ac.Distribution.String {
+	case arrivalsPoisson:
+		return r.ExpFloat64
+	case arrivalsLognormal:
+		sigma := ac.Sigma.ValueOrZero()
+		if !ac.Sigma.Valid {
+			sigma = 1
+		}
+		// the mean of the lognormal distribution is exp(mu + sigma^2/2)
+		mu := -sigma * sigma / 2
+		return func() float64 {
+			return math.Exp(mu + sigma*r.NormFloat64())
+		}
+	case arrivalsCustom:
+		m := mean(ac.Values)
+		return func() float64 {
+			return ac.Values[r.Intn(len(ac.Values))] / m
+		}
+	default:
+		return nil
+	}
+}
+
+// arrivals returns the positions of the iterations in the sequence of evenly
+// spaced ones, moved by the times drawn from the distribution.
+type arrivals struct {
+	sample func() float64
+	// pos is the position of the iteration i
+	i   int64
+	pos float64
+}
+
+func newArrivals(ac *ArrivalsConfig) *arrivals {
+	return &arrivals{sample: ac.newSampler()}
+}
+
+// position returns the position of the iteration i, which can't be before the
+// one of the previous call.
+func (a *arrivals) position(i int64) float64 {
+	if a.sample == nil {
+		return float64(i)
+	}
+	for ; a.i < i; a.i++ {
+		a.pos += a.sample()
+	}
+	return a.pos
+}
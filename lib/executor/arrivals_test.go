@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func TestArrivalsConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		config ArrivalsConfig
+		valid  bool
+	}{
+		{config: ArrivalsConfig{}, valid: true},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("constant")}, valid: true},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("poisson")}, valid: true},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("lognormal")}, valid: true},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("lognormal"), Sigma: null.FloatFrom(0.3)}, valid: true},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("custom"), Values: []float64{1, 0, 3}}, valid: true},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("gaussian")}},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("lognormal"), Sigma: null.FloatFrom(0)}},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("poisson"), Sigma: null.FloatFrom(1)}},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("custom")}},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("custom"), Values: []float64{0, 0}}},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("custom"), Values: []float64{1, -1}}},
+		{config: ArrivalsConfig{Distribution: null.StringFrom("poisson"), Values: []float64{1}}},
+	}
+
+	for _, tc := range testCases {
+		errs := tc.config.Validate()
+		assert.Equal(t, tc.valid, len(errs) == 0, "%#v: %v", tc.config, errs)
+	}
+
+	var nilConfig *ArrivalsConfig
+	assert.Empty(t, nilConfig.Validate())
+}
+
+func TestArrivalsSampler(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, (*ArrivalsConfig)(nil).newSampler())
+	assert.Nil(t, (&ArrivalsConfig{Distribution: null.StringFrom("constant")}).newSampler())
+
+	configs := map[string]*ArrivalsConfig{
+		"poisson":   {Distribution: null.StringFrom("poisson"), Seed: null.IntFrom(1)},
+		"lognormal": {Distribution: null.StringFrom("lognormal"), Sigma: null.FloatFrom(0.5), Seed: null.IntFrom(1)},
+		"custom":    {Distribution: null.StringFrom("custom"), Values: []float64{1, 2, 6}, Seed: null.IntFrom(1)},
+	}
+	for name, config := range configs {
+		config := config
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sample, same := config.newSampler(), config.newSampler()
+			var sum float64
+			const n = 100000
+			for i := 0; i < n; i++ {
+				v := sample()
+				require.Equal(t, v, same(), "the samples with the same seed should be the same")
+				require.GreaterOrEqual(t, v, 0.0)
+				sum += v
+			}
+			assert.InDelta(t, 1, sum/n, 0.02)
+		})
+	}
+}
+
+func TestArrivalsPosition(t *testing.T) {
+	t.Parallel()
+
+	even := newArrivals(nil)
+	assert.Equal(t, 3.0, even.position(3))
+
+	values := []float64{2}
+	custom := newArrivals(&ArrivalsConfig{Distribution: null.StringFrom("custom"), Values: values})
+	custom.sample = func() float64 {
+		v := values[0]
+		values[0]++
+		return v
+	}
+	assert.Equal(t, 0.0, custom.position(0))
+	assert.Equal(t, 5.0, custom.position(2))
+	assert.Equal(t, 5.0, custom.position(2))
+	assert.Equal(t, 9.0, custom.position(3))
+}
+
+func TestArrivalRateWithPoissonArrivals(t *testing.T) {
+	t.Parallel()
+
+	configs := map[string]lib.ExecutorConfig{
+		"constant": &ConstantArrivalRateConfig{
+			BaseConfig:      BaseConfig{GracefulStop: types.NullDurationFrom(1 * time.Second)},
+			TimeUnit:        types.NullDurationFrom(time.Second),
+			Rate:            null.IntFrom(100),
+			Duration:        types.NullDurationFrom(3 * time.Second),
+			PreAllocatedVUs: null.IntFrom(10),
+			MaxVUs:          null.IntFrom(10),
+			Arrivals:        &ArrivalsConfig{Distribution: null.StringFrom("poisson")},
+		},
+		"ramping": &RampingArrivalRateConfig{
+			BaseConfig: BaseConfig{GracefulStop: types.NullDurationFrom(1 * time.Second)},
+			TimeUnit:   types.NullDurationFrom(time.Second),
+			StartRate:  null.IntFrom(100),
+			Stages: []Stage{
+				{Duration: types.NullDurationFrom(3 * time.Second), Target: null.IntFrom(100)},
+			},
+			PreAllocatedVUs: null.IntFrom(10),
+			MaxVUs:          null.IntFrom(10),
+			Arrivals:        &ArrivalsConfig{Distribution: null.StringFrom("poisson")},
+		},
+	}
+
+	for name, config := range configs {
+		config := config
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var count int64
+			runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+				atomic.AddInt64(&count, 1)
+				return nil
+			})
+			test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+			defer test.cancel()
+
+			engineOut := make(chan metrics.SampleContainer, 1000)
+			require.NoError(t, test.executor.Run(test.ctx, engineOut))
+			// the standard deviation of the number of the poisson arrivals is
+			// the square root of the 300 expected ones
+			assert.InDelta(t, 300, atomic.LoadInt64(&count), 70)
+		})
+	}
+}
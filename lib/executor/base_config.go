@@ -1,7 +1,9 @@
 package executor
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
 	"time"
@@ -24,14 +26,23 @@ const scenarioNameErr = "the scenario name should contain only numbers, latin le
 
 // BaseConfig contains the common config fields for all executors
 type BaseConfig struct {
-	Name         string               `json:"-"` // set via the JS object key
-	Type         string               `json:"executor"`
-	StartTime    types.NullDuration   `json:"startTime"`
-	GracefulStop types.NullDuration   `json:"gracefulStop"`
-	Env          map[string]string    `json:"env"`
-	Exec         null.String          `json:"exec"` // function name, externally validated
-	Tags         map[string]string    `json:"tags"`
-	Options      *lib.ScenarioOptions `json:"options,omitempty"`
+	Name             string                   `json:"-"` // set via the JS object key
+	Type             string                   `json:"executor"`
+	StartTime        types.NullDuration       `json:"startTime"`
+	StartAfter       []lib.ScenarioDependency `json:"startAfter,omitempty"`
+	GracefulStop     types.NullDuration       `json:"gracefulStop"`
+	Env              map[string]string        `json:"env"`
+	Exec             null.String              `json:"exec"`              // function name, externally validated
+	ExecMix          map[string]float64       `json:"execMix,omitempty"` // function weights, externally validated
+	Setup            null.String              `json:"setup"`             // function name, externally validated
+	Teardown         null.String              `json:"teardown"`          // function name, externally validated
+	IterationTimeout types.NullDuration       `json:"iterationTimeout"`
+	WarmupIterations null.Int                 `json:"warmupIterations"`
+	Identities       []json.RawMessage        `json:"identities,omitempty"`
+	TrafficShaping   *lib.TrafficShaping      `json:"trafficShaping,omitempty"`
+	Tags             map[string]string        `json:"tags"`
+	Pacing           *PacingConfig            `json:"pacing,omitempty"`
+	Options          *lib.ScenarioOptions     `json:"options,omitempty"`
 
 	// TODO: future extensions like distribution, others?
 }
@@ -58,6 +69,23 @@ func (bc BaseConfig) Validate() (errors []error) {
 	if bc.Exec.Valid && bc.Exec.String == "" {
 		errors = append(errors, fmt.Errorf("exec value cannot be empty"))
 	}
+	if len(bc.ExecMix) > 0 && bc.Exec.Valid {
+		errors = append(errors, fmt.Errorf("exec and execMix can't be used together"))
+	}
+	for fn, weight := range bc.ExecMix {
+		if fn == "" {
+			errors = append(errors, fmt.Errorf("the functions in execMix can't be empty"))
+		}
+		if !(weight > 0) || math.IsInf(weight, 0) {
+			errors = append(errors, fmt.Errorf("the weight of '%s' in execMix must be more than 0", fn))
+		}
+	}
+	if bc.Setup.Valid && bc.Setup.String == "" {
+		errors = append(errors, fmt.Errorf("setup value cannot be empty"))
+	}
+	if bc.Teardown.Valid && bc.Teardown.String == "" {
+		errors = append(errors, fmt.Errorf("teardown value cannot be empty"))
+	}
 	if bc.Type == "" {
 		errors = append(errors, fmt.Errorf("missing or empty type field"))
 	}
@@ -68,6 +96,24 @@ func (bc BaseConfig) Validate() (errors []error) {
 	if bc.GracefulStop.Duration < 0 {
 		errors = append(errors, fmt.Errorf("the gracefulStop timeout can't be negative"))
 	}
+	if bc.IterationTimeout.Valid && bc.IterationTimeout.Duration <= 0 {
+		errors = append(errors, fmt.Errorf("the iterationTimeout must be more than 0"))
+	}
+	if bc.WarmupIterations.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the warmupIterations can't be negative"))
+	}
+	if bc.Identities != nil && len(bc.Identities) == 0 {
+		errors = append(errors, fmt.Errorf("the identities can't be empty"))
+	}
+	for _, dep := range bc.StartAfter {
+		if dep.Scenario == bc.Name {
+			errors = append(errors, fmt.Errorf("the scenario can't start after itself"))
+		} else if dep.Scenario == "" {
+			errors = append(errors, fmt.Errorf("the scenarios in startAfter can't be empty"))
+		}
+	}
+	errors = append(errors, bc.Pacing.Validate()...)
+	errors = append(errors, bc.TrafficShaping.Validate()...)
 	return errors
 }
 
@@ -87,6 +133,12 @@ func (bc BaseConfig) GetStartTime() time.Duration {
 	return bc.StartTime.TimeDuration()
 }
 
+// GetStartAfter returns the scenarios that have to finish before this one
+// starts, which its start time is counted from.
+func (bc BaseConfig) GetStartAfter() []lib.ScenarioDependency {
+	return bc.StartAfter
+}
+
 // GetGracefulStop returns how long k6 is supposed to wait for any still
 // running iterations to finish executing at the end of the normal executor
 // duration, before it actually kills them.
@@ -112,6 +164,46 @@ func (bc BaseConfig) GetExec() string {
 	return exec
 }
 
+// GetExecMix returns the weights of the functions that the iterations are
+// spread across, if they're configured instead of a single exec function.
+func (bc BaseConfig) GetExecMix() map[string]float64 {
+	return bc.ExecMix
+}
+
+// GetSetup returns the function that sets up the scenario, if any.
+func (bc BaseConfig) GetSetup() string {
+	return bc.Setup.ValueOrZero()
+}
+
+// GetTeardown returns the function that tears down the scenario, if any.
+func (bc BaseConfig) GetTeardown() string {
+	return bc.Teardown.ValueOrZero()
+}
+
+// GetIterationTimeout returns how long an iteration can run before it's
+// interrupted, or 0 if there is no limit.
+func (bc BaseConfig) GetIterationTimeout() time.Duration {
+	return bc.IterationTimeout.TimeDuration()
+}
+
+// GetWarmupIterations returns how many iterations each VU runs before its
+// first one in the scenario, without any of their metrics.
+func (bc BaseConfig) GetWarmupIterations() int64 {
+	return bc.WarmupIterations.ValueOrZero()
+}
+
+// GetIdentities returns the virtual identities that are assigned to the VUs
+// of the scenario, if any.
+func (bc BaseConfig) GetIdentities() []json.RawMessage {
+	return bc.Identities
+}
+
+// GetTrafficShaping returns how the traffic of the VUs of the scenario is
+// shaped, nil if it isn't.
+func (bc BaseConfig) GetTrafficShaping() *lib.TrafficShaping {
+	return bc.TrafficShaping
+}
+
 // GetScenarioOptions returns the options specific to a scenario.
 func (bc BaseConfig) GetScenarioOptions() *lib.ScenarioOptions {
 	return bc.Options
@@ -133,6 +225,40 @@ func (bc BaseConfig) getBaseInfo(facts ...string) string {
 	if bc.Exec.Valid {
 		facts = append(facts, fmt.Sprintf("exec: %s", bc.Exec.String))
 	}
+	if len(bc.ExecMix) > 0 {
+		facts = append(facts, fmt.Sprintf("execMix: %s", formatExecMix(bc.ExecMix)))
+	}
+	if bc.Setup.Valid {
+		facts = append(facts, fmt.Sprintf("setup: %s", bc.Setup.String))
+	}
+	if bc.Teardown.Valid {
+		facts = append(facts, fmt.Sprintf("teardown: %s", bc.Teardown.String))
+	}
+	if len(bc.StartAfter) > 0 {
+		names := make([]string, len(bc.StartAfter))
+		for i, dep := range bc.StartAfter {
+			names[i] = dep.Scenario
+		}
+		facts = append(facts, fmt.Sprintf("startAfter: %s", strings.Join(names, ", ")))
+	}
+	if bc.Pacing != nil && bc.Pacing.Iteration.Duration > 0 {
+		facts = append(facts, fmt.Sprintf("pacing: %s", bc.Pacing.Iteration.Duration))
+	}
+	if bc.Pacing != nil && bc.Pacing.ThinkTime.Duration > 0 {
+		facts = append(facts, fmt.Sprintf("thinkTime: %s", bc.Pacing.ThinkTime.Duration))
+	}
+	if bc.IterationTimeout.Duration > 0 {
+		facts = append(facts, fmt.Sprintf("iterationTimeout: %s", bc.IterationTimeout.Duration))
+	}
+	if bc.WarmupIterations.Int64 > 0 {
+		facts = append(facts, fmt.Sprintf("warmup: %d iterations per VU", bc.WarmupIterations.Int64))
+	}
+	if len(bc.Identities) > 0 {
+		facts = append(facts, fmt.Sprintf("identities: %d", len(bc.Identities)))
+	}
+	if !bc.TrafficShaping.IsZero() {
+		facts = append(facts, fmt.Sprintf("trafficShaping: %s", bc.TrafficShaping))
+	}
 	if bc.StartTime.Duration > 0 {
 		facts = append(facts, fmt.Sprintf("startTime: %s", bc.StartTime.Duration))
 	}
@@ -18,23 +18,30 @@ import (
 // inside of most of the executors, for the purpose of reducing boilerplate
 // code.
 type BaseExecutor struct {
+	configMx       *sync.Mutex
 	config         lib.ExecutorConfig
+	updates        *targetsUpdates // not nil while the targets can be updated
 	executionState *lib.ExecutionState
 	iterSegIndexMx *sync.Mutex
 	iterSegIndex   *lib.SegmentedIndex
 	logger         *logrus.Entry
 	progress       *pb.ProgressBar
+
+	pauseMx *sync.Mutex
+	resumed chan struct{} // not nil while the scenario is paused
 }
 
 // NewBaseExecutor returns an initialized BaseExecutor
 func NewBaseExecutor(config lib.ExecutorConfig, es *lib.ExecutionState, logger *logrus.Entry) *BaseExecutor {
 	segIdx := lib.NewSegmentedIndex(es.ExecutionTuple)
 	return &BaseExecutor{
+		configMx:       new(sync.Mutex),
 		config:         config,
 		executionState: es,
 		logger:         logger,
 		iterSegIndexMx: new(sync.Mutex),
 		iterSegIndex:   segIdx,
+		pauseMx:        new(sync.Mutex),
 		progress: pb.New(
 			pb.WithLeft(config.GetName),
 			pb.WithLogger(logger),
@@ -56,8 +63,11 @@ func (bs *BaseExecutor) Init(_ context.Context) error {
 	return nil
 }
 
-// GetConfig returns the configuration with which this executor was launched.
+// GetConfig returns the configuration with which this executor was launched,
+// or the one its targets were updated to while it's running.
 func (bs *BaseExecutor) GetConfig() lib.ExecutorConfig {
+	bs.configMx.Lock()
+	defer bs.configMx.Unlock()
 	return bs.config
 }
 
@@ -71,12 +81,65 @@ func (bs *BaseExecutor) GetProgress() *pb.ProgressBar {
 	return bs.progress
 }
 
+// SetScenarioPaused pauses or resumes the scenario of the executor. While it's
+// paused, its VUs don't start new iterations.
+func (bs *BaseExecutor) SetScenarioPaused(paused bool) {
+	bs.pauseMx.Lock()
+	defer bs.pauseMx.Unlock()
+	switch {
+	case paused && bs.resumed == nil:
+		bs.resumed = make(chan struct{})
+		bs.logger.Debug("Scenario paused")
+	case !paused && bs.resumed != nil:
+		close(bs.resumed)
+		bs.resumed = nil
+		bs.logger.Debug("Scenario resumed")
+	}
+}
+
+// IsScenarioPaused returns whether the scenario of the executor is paused.
+func (bs *BaseExecutor) IsScenarioPaused() bool {
+	bs.pauseMx.Lock()
+	defer bs.pauseMx.Unlock()
+	return bs.resumed != nil
+}
+
+// resumedChan returns the channel that is closed when the scenario of the
+// executor is resumed, or nil if it isn't paused. The arrival-rate executors
+// use it in their dispatch loops, so they don't take VUs while it's paused.
+func (bs *BaseExecutor) resumedChan() <-chan struct{} {
+	bs.pauseMx.Lock()
+	defer bs.pauseMx.Unlock()
+	return bs.resumed
+}
+
+// waitWhilePaused wraps the iteration runner of the VU-based executors, so the
+// VUs wait for the scenario to be resumed before starting an iteration. It returns false without running
+// the iteration if the context or the regular duration of the executor is done
+// while they wait, since no new iterations would be started then anyway.
+func (bs *BaseExecutor) waitWhilePaused(
+	regDurationDone <-chan struct{}, runIteration func(context.Context, lib.ActiveVU) bool,
+) func(context.Context, lib.ActiveVU) bool {
+	return func(ctx context.Context, vu lib.ActiveVU) bool {
+		if resumed := bs.resumedChan(); resumed != nil {
+			select {
+			case <-resumed:
+			case <-regDurationDone:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+		return runIteration(ctx, vu)
+	}
+}
+
 // getMetricTags returns a tag set that can be used to emit metrics by the
 // executor. The VU ID is optional.
 func (bs *BaseExecutor) getMetricTags(vuID *uint64) *metrics.TagSet {
 	tags := bs.executionState.Test.RunTags
 	if bs.executionState.Test.Options.SystemTags.Has(metrics.TagScenario) {
-		tags = tags.With("scenario", bs.config.GetName())
+		tags = tags.With("scenario", bs.GetConfig().GetName())
 	}
 	if vuID != nil && bs.executionState.Test.Options.SystemTags.Has(metrics.TagVU) {
 		tags = tags.With("vu", strconv.FormatUint(*vuID, 10))
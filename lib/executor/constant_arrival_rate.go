@@ -43,6 +43,10 @@ type ConstantArrivalRateConfig struct {
 	// absolutely hard limit on the number of VUs the executor will use
 	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
 	MaxVUs          null.Int `json:"maxVUs"`
+
+	// The distribution of the times between the iterations, which are evenly
+	// spaced if it's unspecified
+	Arrivals *ArrivalsConfig `json:"arrivals,omitempty"`
 }
 
 // NewConstantArrivalRateConfig returns a ConstantArrivalRateConfig with default values
@@ -89,7 +93,7 @@ func (carc ConstantArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) str
 
 // Validate makes sure all options are configured and valid
 func (carc *ConstantArrivalRateConfig) Validate() []error {
-	errors := carc.BaseConfig.Validate()
+	errors := append(carc.BaseConfig.Validate(), validateNoIterationPacing(carc.Pacing)...)
 	if !carc.Rate.Valid {
 		errors = append(errors, fmt.Errorf("the iteration rate isn't specified"))
 	} else if carc.Rate.Int64 <= 0 {
@@ -121,6 +125,8 @@ func (carc *ConstantArrivalRateConfig) Validate() []error {
 		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
 	}
 
+	errors = append(errors, carc.Arrivals.Validate()...)
+
 	return errors
 }
 
@@ -166,8 +172,11 @@ type ConstantArrivalRate struct {
 	et     *lib.ExecutionTuple
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &ConstantArrivalRate{}
+// Make sure we implement the lib.Executor and lib.TargetsUpdatableExecutor interfaces.
+var (
+	_ lib.Executor                 = &ConstantArrivalRate{}
+	_ lib.TargetsUpdatableExecutor = &ConstantArrivalRate{}
+)
 
 // Init values needed for the execution
 func (car *ConstantArrivalRate) Init(_ context.Context) error {
@@ -212,7 +221,8 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 
 	returnedVUs := make(chan struct{})
 	waitOnProgressChannel := make(chan struct{})
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	durations, maxDurationCtx, regDurationCtx, cancel := newUpdatableDurations(parentCtx, duration, gracefulStop)
+	startTime := durations.startTime
 	defer func() {
 		cancel()
 		<-waitOnProgressChannel
@@ -231,15 +241,17 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 	activeVUsCount := uint64(0)
 
 	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
-	progIters := fmt.Sprintf(
-		pb.GetFixedLengthFloatFormat(arrivalRatePerSec, 2)+" iters/s", arrivalRatePerSec)
+	itersFmt := pb.GetFixedLengthFloatFormat(arrivalRatePerSec, 2) + " iters/s"
+	var progIters atomic.Value // the rate can be updated
+	progIters.Store(fmt.Sprintf(itersFmt, arrivalRatePerSec))
 	progressFn := func() (float64, []string) {
 		spent := time.Since(startTime)
+		duration := durations.getDuration()
 		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
 		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs",
 			vusPool.Running(), currActiveVUs)
 
-		right := []string{progVUs, duration.String(), progIters}
+		right := []string{progVUs, duration.String(), progIters.Load().(string)} //nolint:forcetypeassert
 
 		if spent > duration {
 			return 1, right
@@ -313,6 +325,7 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 	}
 
 	start, offsets, _ := car.et.GetStripedOffsets()
+	arrivals := newArrivals(car.config.Arrivals)
 	timer := time.NewTimer(time.Hour * 24)
 	// here the we need the not scaled one
 	notScaledTickerPeriod := getTickerPeriod(
@@ -321,48 +334,114 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 			int64(car.config.TimeUnit.TimeDuration()),
 		)).TimeDuration()
 
+	// the iterations are started at the new rate right away if it's updated,
+	// so the times are offset from the last started one
+	var lastTime time.Duration
+	var lastPosition float64
+	updates, stopUpdates := car.startTargetsUpdates()
+	defer stopUpdates()
+	applyUpdate := func(newConfig *ConstantArrivalRateConfig) error {
+		if !durations.setDuration(newConfig.Duration.TimeDuration()) {
+			return ErrScenarioNotRunning
+		}
+		notScaledTickerPeriod = getTickerPeriod(
+			big.NewRat(newConfig.Rate.Int64, int64(newConfig.TimeUnit.TimeDuration())),
+		).TimeDuration()
+		newRatePerSec, _ := getArrivalRatePerSec(getScaledArrivalRate(
+			car.et.Segment, newConfig.Rate.Int64, newConfig.TimeUnit.TimeDuration(),
+		)).Float64()
+		progIters.Store(fmt.Sprintf(itersFmt, newRatePerSec))
+		car.config = *newConfig
+		car.setConfig(newConfig)
+		return nil
+	}
+
 	droppedIterationMetric := car.executionState.Test.BuiltinMetrics.DroppedIterations
 	shownWarning := false
 	metricTags := car.getMetricTags(nil)
+	// the iterations are scheduled later by the time the scenario was paused
+	var pausedFor time.Duration
 	for li, gi := 0, start; ; li, gi = li+1, gi+offsets[li%len(offsets)] {
-		t := notScaledTickerPeriod*time.Duration(gi) - time.Since(startTime)
-		timer.Reset(t)
-		select {
-		case <-timer.C:
-			if vusPool.TryRunIteration() {
-				continue
+		position := arrivals.position(gi)
+		var t time.Duration
+		for started := false; !started; {
+			t = lastTime + time.Duration(float64(notScaledTickerPeriod)*(position-lastPosition))
+			timer.Reset(t + pausedFor - time.Since(startTime))
+			select {
+			case <-timer.C:
+				started = true
+			case update := <-updates:
+				if !timer.Stop() {
+					<-timer.C
+				}
+				update.err <- applyUpdate(update.newConfig.(*ConstantArrivalRateConfig)) //nolint:forcetypeassert
+			case <-regDurationCtx.Done():
+				return nil
 			}
-
-			// Since there aren't any free VUs available, consider this iteration
-			// dropped - we aren't going to try to recover it, but
-
-			metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
-				TimeSeries: metrics.TimeSeries{
-					Metric: droppedIterationMetric,
-					Tags:   metricTags,
-				},
-				Time:  time.Now(),
-				Value: 1,
-			})
-
-			// We'll try to start allocating another VU in the background,
-			// non-blockingly, if we have remainingUnplannedVUs...
-			if remainingUnplannedVUs == 0 {
-				if !shownWarning {
-					car.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
-					shownWarning = true
+		}
+		lastTime, lastPosition = t, position
+
+		// no VU is taken for the iteration while the scenario is paused
+		if resumed := car.resumedChan(); resumed != nil {
+			pausedAt := time.Now()
+			for paused := true; paused; {
+				select {
+				case <-resumed:
+					paused = false
+				case update := <-updates:
+					update.err <- applyUpdate(update.newConfig.(*ConstantArrivalRateConfig)) //nolint:forcetypeassert
+				case <-regDurationCtx.Done():
+					return nil
 				}
-				continue
 			}
+			pausedFor += time.Since(pausedAt)
+		}
 
-			select {
-			case makeUnplannedVUCh <- struct{}{}: // great!
-				remainingUnplannedVUs--
-			default: // we're already allocating a new VU
+		delay := time.Since(startTime) - pausedFor - t
+		if skipLateIteration(parentCtx, car.executionState, out, car.config.Name, metricTags, delay) {
+			continue
+		}
+		if vusPool.TryRunIteration() {
+			continue
+		}
+
+		// Since there aren't any free VUs available, consider this iteration
+		// dropped - we aren't going to try to recover it, but
+
+		metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: droppedIterationMetric,
+				Tags:   metricTags,
+			},
+			Time:  time.Now(),
+			Value: 1,
+		})
+
+		// We'll try to start allocating another VU in the background,
+		// non-blockingly, if we have remainingUnplannedVUs...
+		if remainingUnplannedVUs == 0 {
+			if !shownWarning {
+				car.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+				shownWarning = true
 			}
+			continue
+		}
 
-		case <-regDurationCtx.Done():
-			return nil
+		select {
+		case makeUnplannedVUCh <- struct{}{}: // great!
+			remainingUnplannedVUs--
+		default: // we're already allocating a new VU
 		}
 	}
 }
+
+// UpdateTargets changes the rate or the duration of the running scenario. The
+// iterations are started at the new rate right away, and the duration is still
+// from the start of the scenario.
+func (car *ConstantArrivalRate) UpdateTargets(ctx context.Context, changes []byte) error {
+	newConfig := *car.GetConfig().(*ConstantArrivalRateConfig) //nolint:forcetypeassert
+	if err := applyTargetsChanges(&newConfig, changes, "rate", "duration"); err != nil {
+		return err
+	}
+	return car.updateTargets(ctx, &newConfig)
+}
@@ -311,6 +311,39 @@ func TestConstantArrivalRateDroppedIterations(t *testing.T) {
 	assert.Equal(t, float64(5), sumMetricValues(engineOut, metrics.DroppedIterationsName))
 }
 
+func TestConstantArrivalRateRunScenarioPaused(t *testing.T) {
+	t.Parallel()
+	var count int64
+
+	config := &ConstantArrivalRateConfig{
+		BaseConfig:      BaseConfig{GracefulStop: types.NullDurationFrom(0 * time.Second)},
+		TimeUnit:        types.NullDurationFrom(time.Second),
+		Rate:            null.IntFrom(10),
+		Duration:        types.NullDurationFrom(950 * time.Millisecond),
+		PreAllocatedVUs: null.IntFrom(2),
+		MaxVUs:          null.IntFrom(2),
+	}
+
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	pausable, ok := test.executor.(lib.ScenarioPausableExecutor)
+	require.True(t, ok)
+	pausable.SetScenarioPaused(true)
+	time.AfterFunc(500*time.Millisecond, func() { pausable.SetScenarioPaused(false) })
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	// the iterations are only started after the scenario is resumed, and the
+	// VUs aren't held while it's paused, so none of them are dropped
+	assert.InDelta(t, 5, atomic.LoadInt64(&count), 1)
+	assert.Equal(t, float64(0), sumMetricValues(engineOut, metrics.DroppedIterationsName))
+}
+
 func TestConstantArrivalRateGlobalIters(t *testing.T) {
 	t.Parallel()
 
@@ -117,8 +117,11 @@ type ConstantVUs struct {
 	config ConstantVUsConfig
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &ConstantVUs{}
+// Make sure we implement the lib.Executor and lib.TargetsUpdatableExecutor interfaces.
+var (
+	_ lib.Executor                 = &ConstantVUs{}
+	_ lib.TargetsUpdatableExecutor = &ConstantVUs{}
+)
 
 // Run constantly loops through as many iterations as possible on a fixed number
 // of VUs for the specified duration.
@@ -128,7 +131,8 @@ func (clv ConstantVUs) Run(parentCtx context.Context, _ chan<- metrics.SampleCon
 	gracefulStop := clv.config.GetGracefulStop()
 
 	waitOnProgressChannel := make(chan struct{})
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	durations, maxDurationCtx, regDurationCtx, cancel := newUpdatableDurations(parentCtx, duration, gracefulStop)
+	startTime := durations.startTime
 	defer func() {
 		cancel()
 		<-waitOnProgressChannel
@@ -141,6 +145,7 @@ func (clv ConstantVUs) Run(parentCtx context.Context, _ chan<- metrics.SampleCon
 
 	progressFn := func() (float64, []string) {
 		spent := time.Since(startTime)
+		duration := durations.getDuration()
 		right := []string{fmt.Sprintf("%d VUs", numVUs)}
 		if spent > duration {
 			right = append(right, duration.String())
@@ -163,12 +168,34 @@ func (clv ConstantVUs) Run(parentCtx context.Context, _ chan<- metrics.SampleCon
 		close(waitOnProgressChannel)
 	}()
 
+	// The duration can be updated while the VUs are running
+	updates, stopUpdates := clv.startTargetsUpdates()
+	defer stopUpdates()
+	regDurationDone := regDurationCtx.Done()
+	go func() {
+		for {
+			select {
+			case update := <-updates:
+				newConfig := update.newConfig.(ConstantVUsConfig) //nolint:forcetypeassert
+				if !durations.setDuration(newConfig.Duration.TimeDuration()) {
+					update.err <- ErrScenarioNotRunning
+					continue
+				}
+				clv.setConfig(newConfig)
+				update.err <- nil
+			case <-regDurationDone:
+				return
+			}
+		}
+	}()
+
 	// Actually schedule the VUs and iterations...
 	activeVUs := &sync.WaitGroup{}
 	defer activeVUs.Wait()
 
-	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(clv.executionState, clv.logger)
+	runIteration := clv.waitWhilePaused(regDurationDone, withIterationPacing(
+		clv.config.Pacing, regDurationDone, getIterationRunner(clv.executionState, clv.logger),
+	))
 
 	returnVU := func(u lib.InitializedVU) {
 		clv.executionState.ReturnVU(u, true)
@@ -204,3 +231,13 @@ func (clv ConstantVUs) Run(parentCtx context.Context, _ chan<- metrics.SampleCon
 
 	return nil
 }
+
+// UpdateTargets changes the duration of the running scenario, which is still
+// from its start.
+func (clv ConstantVUs) UpdateTargets(ctx context.Context, changes []byte) error {
+	newConfig := clv.GetConfig().(ConstantVUsConfig) //nolint:forcetypeassert
+	if err := applyTargetsChanges(&newConfig, changes, "duration"); err != nil {
+		return err
+	}
+	return clv.updateTargets(ctx, newConfig)
+}
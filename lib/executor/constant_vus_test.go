@@ -52,3 +52,34 @@ func TestConstantVUsRun(t *testing.T) {
 	})
 	assert.Equal(t, uint64(50), totalIters)
 }
+
+func TestConstantVUsRunScenarioPaused(t *testing.T) {
+	t.Parallel()
+	var iterations int64
+	var mx sync.Mutex
+
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		mx.Lock()
+		iterations++
+		mx.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestConstantVUsConfig())
+	defer test.cancel()
+
+	pausable, ok := test.executor.(lib.ScenarioPausableExecutor)
+	require.True(t, ok)
+	pausable.SetScenarioPaused(true)
+	assert.True(t, pausable.IsScenarioPaused())
+	time.AfterFunc(500*time.Millisecond, func() { pausable.SetScenarioPaused(false) })
+
+	require.NoError(t, test.executor.Run(test.ctx, nil))
+	assert.False(t, pausable.IsScenarioPaused())
+
+	mx.Lock()
+	defer mx.Unlock()
+	// only the second half of the duration had iterations
+	assert.InDelta(t, 100, iterations, 20)
+}
@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sortedExecMix returns the functions of the mix, sorted by name, with their
+// cumulative weights.
+func sortedExecMix(mix map[string]float64) ([]string, []float64) {
+	fns := make([]string, 0, len(mix))
+	for fn := range mix {
+		fns = append(fns, fn)
+	}
+	sort.Strings(fns)
+
+	cumWeights := make([]float64, len(fns))
+	var total float64
+	for i, fn := range fns {
+		total += mix[fn]
+		cumWeights[i] = total
+	}
+	return fns, cumWeights
+}
+
+// formatExecMix returns the shares of the functions of the mix, like
+// "browse 70%, checkout 10%, search 20%".
+func formatExecMix(mix map[string]float64) string {
+	fns, cumWeights := sortedExecMix(mix)
+	total := cumWeights[len(cumWeights)-1]
+	shares := make([]string, len(fns))
+	for i, fn := range fns {
+		shares[i] = fmt.Sprintf("%s %.4g%%", fn, 100*mix[fn]/total)
+	}
+	return strings.Join(shares, ", ")
+}
+
+// newExecPicker returns a function that picks the function of every iteration
+// from the mix, according to the weights, or nil if there isn't a mix. The
+// function isn't safe for concurrent use, so every VU activation gets its own.
+func newExecPicker(mix map[string]float64) func() string {
+	if len(mix) == 0 {
+		return nil
+	}
+	fns, cumWeights := sortedExecMix(mix)
+	total := cumWeights[len(cumWeights)-1]
+	r := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+
+	return func() string {
+		i := sort.SearchFloat64s(cumWeights, r.Float64()*total)
+		if i >= len(fns) { // just in case of rounding errors
+			i = len(fns) - 1
+		}
+		return fns[i]
+	}
+}
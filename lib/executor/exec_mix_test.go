@@ -0,0 +1,32 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatExecMix(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "browse 70%, checkout 10%, search 20%",
+		formatExecMix(map[string]float64{"search": 2, "browse": 7, "checkout": 1}))
+	assert.Equal(t, "a 33.33%, b 66.67%", formatExecMix(map[string]float64{"a": 1, "b": 2}))
+}
+
+func TestExecPicker(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, newExecPicker(nil))
+
+	pick := newExecPicker(map[string]float64{"browse": 70, "search": 20, "checkout": 10})
+	counts := make(map[string]int)
+	const n = 100000
+	for i := 0; i < n; i++ {
+		counts[pick()]++
+	}
+	assert.Len(t, counts, 3)
+	assert.InDelta(t, 0.7, float64(counts["browse"])/n, 0.01)
+	assert.InDelta(t, 0.2, float64(counts["search"])/n, 0.01)
+	assert.InDelta(t, 0.1, float64(counts["checkout"])/n, 0.01)
+}
@@ -102,6 +102,81 @@ var configMapTestCases = []configMapTestCase{
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "0s"}}`, exp{validationError: true}},
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "startTime": "-10s"}}`, exp{validationError: true}},
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": ""}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": "a", "execMix": {"b": 1}}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "execMix": {"a": 1, "b": 0}}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "execMix": {"": 1}}}`, exp{validationError: true}},
+	{
+		`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "execMix": {"browse": 3, "checkout": 1}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Equal(t, map[string]float64{"browse": 3, "checkout": 1}, cm["aname"].GetExecMix())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Contains(t, cm["aname"].GetDescription(et), "execMix: browse 75%, checkout 25%")
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "pacing": {"iteration": "-1s"}}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "pacing": {"thinkTime": "1s", "think": "1s"}}}`, exp{parseError: true}},
+	{
+		`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s",
+		"pacing": {"iteration": "5s", "thinkTime": "1s", "distribution": {"distribution": "poisson"}}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Contains(t, cm["aname"].GetDescription(et), "pacing: 5s, thinkTime: 1s")
+		}},
+	},
+	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10s", "preAllocatedVUs": 5, "pacing": {"iteration": "1s"}}}`, exp{validationError: true}},
+	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10s", "preAllocatedVUs": 5, "pacing": {"thinkTime": "1s"}}}`, exp{}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "setup": ""}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "teardown": ""}}`, exp{validationError: true}},
+	{
+		`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "setup": "setupDB", "teardown": "teardownDB"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Equal(t, "setupDB", cm["aname"].GetSetup())
+			assert.Equal(t, "teardownDB", cm["aname"].GetTeardown())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Contains(t, cm["aname"].GetDescription(et), "setup: setupDB, teardown: teardownDB")
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "iterationTimeout": "0s"}}`, exp{validationError: true}},
+	{
+		`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "iterationTimeout": "5s"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Contains(t, cm["aname"].GetDescription(et), "iterationTimeout: 5s")
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "warmupIterations": -1}}`, exp{validationError: true}},
+	{
+		`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "warmupIterations": 3}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Contains(t, cm["aname"].GetDescription(et), "warmup: 3 iterations per VU")
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "identities": []}}`, exp{validationError: true}},
+	{
+		`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "identities": [{"user": "a"}, "b"]}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Equal(t,
+				[]json.RawMessage{json.RawMessage(`{"user": "a"}`), json.RawMessage(`"b"`)},
+				cm["aname"].(ConstantVUsConfig).GetIdentities())
+		}},
+	},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "trafficShaping": {"loss": 1}}}`, exp{validationError: true}},
+	{
+		`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s",
+			"trafficShaping": {"bandwidth": 125000, "latency": "100ms", "jitter": "20ms", "loss": 0.01}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Equal(t, int64(125000), cm["aname"].(ConstantVUsConfig).GetTrafficShaping().Bandwidth.Int64)
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Contains(t, cm["aname"].GetDescription(et), "trafficShaping: 125000 B/s 100ms±20ms 1% loss")
+		}},
+	},
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "gracefulStop": "-2s"}}`, exp{validationError: true}},
 	// ramping-vus
 	{
@@ -356,6 +431,18 @@ var configMapTestCases = []configMapTestCase{
 	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20, "maxVUs": 15}}`, exp{validationError: true}},
 	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "0s", "preAllocatedVUs": 20, "maxVUs": 25}}`, exp{validationError: true}},
 	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": -2, "maxVUs": 25}}`, exp{validationError: true}},
+	{
+		`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20,
+		"arrivals": {"distribution": "lognormal", "sigma": 0.5, "seed": 7}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm["carrival"].Validate())
+			assert.Equal(t, &ArrivalsConfig{
+				Distribution: null.StringFrom("lognormal"), Sigma: null.FloatFrom(0.5), Seed: null.IntFrom(7),
+			}, cm["carrival"].(*ConstantArrivalRateConfig).Arrivals)
+		}},
+	},
+	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20, "arrivals": {"distribution": "normal"}}}`, exp{validationError: true}},
+	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20, "arrivals": {"shape": 2}}}`, exp{parseError: true}},
 	// ramping-arrival-rate
 	{
 		`{"varrival": {"executor": "ramping-arrival-rate", "startRate": 10, "timeUnit": "30s", "preAllocatedVUs": 20,
@@ -406,6 +493,132 @@ var configMapTestCases = []configMapTestCase{
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10}], "timeUnit": "-1s"}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10}], "timeUnit": "0s"}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 30, "maxVUs": 20, "stages": [{"duration": "5m", "target": 10}]}}`, exp{validationError: true}},
+	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "stages": [{"duration": "5m", "target": 10}], "arrivals": {"distribution": "custom", "values": [1, 3]}}}`, exp{}},
+	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "stages": [{"duration": "5m", "target": 10}], "arrivals": {"distribution": "custom"}}}`, exp{validationError: true}},
+	// adaptive-arrival-rate
+	{
+		`{"aarrival": {"executor": "adaptive-arrival-rate", "startRate": 5, "maxRate": 100, "duration": "10m",
+		"metric": "http_req_duration{scenario:aarrival}", "goal": "p(95)<500", "preAllocatedVUs": 20, "maxVUs": 50}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			sched := NewAdaptiveArrivalRateConfig("aarrival")
+			sched.StartRate = null.IntFrom(5)
+			sched.MaxRate = null.IntFrom(100)
+			sched.Duration = types.NullDurationFrom(10 * time.Minute)
+			sched.Metric = null.StringFrom("http_req_duration{scenario:aarrival}")
+			sched.Goal = null.StringFrom("p(95)<500")
+			sched.PreAllocatedVUs = null.IntFrom(20)
+			sched.MaxVUs = null.IntFrom(50)
+			require.Equal(t, cm, lib.ScenarioConfigs{"aarrival": sched})
+
+			assert.Empty(t, cm["aarrival"].Validate())
+			assert.Empty(t, cm.Validate())
+			assert.False(t, cm["aarrival"].IsDistributable())
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Up to 100.00 iterations/s for 10m0s while http_req_duration{scenario:aarrival} p(95)<500 "+
+				"(maxVUs: 20-50, gracefulStop: 30s)", cm["aarrival"].GetDescription(et))
+
+			schedReqs := cm["aarrival"].GetExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(schedReqs)
+			assert.Equal(t, 630*time.Second, endOffset)
+			assert.Equal(t, true, isFinal)
+			assert.Equal(t, uint64(20), lib.GetMaxPlannedVUs(schedReqs))
+			assert.Equal(t, uint64(50), lib.GetMaxPossibleVUs(schedReqs))
+		}},
+	},
+	{`{"aarrival": {"executor": "adaptive-arrival-rate", "maxRate": 100, "duration": "10m", "metric": "iteration_duration", "goal": "avg<100", "preAllocatedVUs": 20}}`, exp{}},
+	{`{"aarrival": {"executor": "adaptive-arrival-rate", "duration": "10m", "metric": "iteration_duration", "goal": "avg<100", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"aarrival": {"executor": "adaptive-arrival-rate", "startRate": 200, "maxRate": 100, "duration": "10m", "metric": "iteration_duration", "goal": "avg<100", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"aarrival": {"executor": "adaptive-arrival-rate", "maxRate": 100, "duration": "10m", "goal": "avg<100", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"aarrival": {"executor": "adaptive-arrival-rate", "maxRate": 100, "duration": "10m", "metric": "iteration_duration", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"aarrival": {"executor": "adaptive-arrival-rate", "maxRate": 100, "duration": "10m", "metric": "iteration_duration", "goal": "avg!100", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"aarrival": {"executor": "adaptive-arrival-rate", "maxRate": 100, "duration": "10s", "metric": "iteration_duration", "goal": "avg<100", "adjustmentInterval": "1m", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"aarrival": {"executor": "adaptive-arrival-rate", "maxRate": 100, "duration": "10m", "metric": "iteration_duration", "goal": "avg<100", "preAllocatedVUs": 20, "maxVUs": 10}}`, exp{validationError: true}},
+	// stepped-arrival-rate
+	{
+		`{"sarrival": {"executor": "stepped-arrival-rate", "timeline": "time,rate\n0,10\n60,30\n180,5", "scale": 2,
+		"preAllocatedVUs": 20, "maxVUs": 50}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			sched := NewSteppedArrivalRateConfig("sarrival")
+			sched.Timeline = null.StringFrom("time,rate\n0,10\n60,30\n180,5")
+			sched.Scale = null.FloatFrom(2)
+			sched.PreAllocatedVUs = null.IntFrom(20)
+			sched.MaxVUs = null.IntFrom(50)
+			require.Equal(t, cm, lib.ScenarioConfigs{"sarrival": sched})
+
+			assert.Empty(t, cm["sarrival"].Validate())
+			assert.Empty(t, cm.Validate())
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Up to 60.00 iterations/s for 5m0s over 3 timeline steps (maxVUs: 20-50, gracefulStop: 30s)",
+				cm["sarrival"].GetDescription(et))
+
+			schedReqs := cm["sarrival"].GetExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(schedReqs)
+			assert.Equal(t, 330*time.Second, endOffset)
+			assert.Equal(t, true, isFinal)
+			assert.Equal(t, uint64(20), lib.GetMaxPlannedVUs(schedReqs))
+			assert.Equal(t, uint64(50), lib.GetMaxPossibleVUs(schedReqs))
+		}},
+	},
+	{`{"sarrival": {"executor": "stepped-arrival-rate", "timeline": "[{\"time\":\"0s\",\"rate\":1},{\"time\":\"1m\",\"rate\":2}]", "preAllocatedVUs": 20}}`, exp{}},
+	{`{"sarrival": {"executor": "stepped-arrival-rate", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"sarrival": {"executor": "stepped-arrival-rate", "timeline": "0,10", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"sarrival": {"executor": "stepped-arrival-rate", "timeline": "0,10\n60,30", "scale": 0, "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"sarrival": {"executor": "stepped-arrival-rate", "timeline": "0,10\n60,30", "maxVUs": 20}}`, exp{validationError: true}},
+	// scenario dependencies
+	{
+		`{"seed": {"executor": "shared-iterations", "iterations": 10, "vus": 2, "maxDuration": "1m", "gracefulStop": "5s"},
+		"warmup": {"executor": "constant-vus", "vus": 5, "duration": "30s", "gracefulStop": "0s", "startAfter": ["seed"]},
+		"load": {"executor": "constant-vus", "vus": 20, "duration": "2m", "startTime": "10s",
+			"startAfter": [{"scenario": "warmup", "condition": "thresholdsPassed"}, "seed"]}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			assert.Equal(t, []lib.ScenarioDependency{
+				{Scenario: "warmup", Condition: lib.ScenarioConditionThresholdsPassed}, {Scenario: "seed"},
+			}, cm["load"].GetStartAfter())
+
+			assert.Equal(t, map[string]time.Duration{
+				"seed":   0,
+				"warmup": 65 * time.Second,
+				"load":   105 * time.Second,
+			}, cm.GetStartTimes())
+
+			sorted := cm.GetSortedConfigs()
+			require.Len(t, sorted, 3)
+			assert.Equal(t, []string{"seed", "warmup", "load"},
+				[]string{sorted[0].GetName(), sorted[1].GetName(), sorted[2].GetName()})
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "5 looping VUs for 30s (startAfter: seed)", cm["warmup"].GetDescription(et))
+
+			totalReqs := cm.GetFullExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(totalReqs)
+			assert.Equal(t, 255*time.Second, endOffset)
+			assert.Equal(t, true, isFinal)
+			// the scenarios can start as soon as the ones before them finish,
+			// so they can all run at the same time, when these finish quickly
+			assert.Equal(t, uint64(27), lib.GetMaxPlannedVUs(totalReqs))
+		}},
+	},
+	{`{"a": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["b"]}}`, exp{validationError: true}},
+	{`{"a": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["a"]}}`, exp{validationError: true}},
+	{`{"a": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": [""]}}`, exp{validationError: true}},
+	{`{"a": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": [{"scenario": "a", "unknown": true}]}}`, exp{parseError: true}},
+	{
+		`{"a": {"executor": "constant-vus", "vus": 1, "duration": "10s"},
+		"b": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": [{"scenario": "a", "condition": "passed"}]}}`,
+		exp{validationError: true},
+	},
+	{
+		`{"a": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["c"]},
+		"b": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["a"]},
+		"c": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["b"]}}`,
+		exp{validationError: true},
+	},
 	// TODO: more tests of mixed executors and execution plans
 
 	// scenario options
@@ -451,6 +664,80 @@ func TestConfigMapParsingAndValidation(t *testing.T) {
 	}
 }
 
+// The scenario dependencies are tested here, for the same reason as below.
+func TestScenarioConfigsStartAfter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("JSON", func(t *testing.T) {
+		t.Parallel()
+
+		var result lib.ScenarioConfigs
+		rawJSON := `{"a": {"executor": "constant-vus", "vus": 1, "duration": "10s"},` +
+			`"b": {"executor": "constant-vus", "vus": 1, "duration": "10s",` +
+			`"startAfter": ["a", {"scenario": "a", "condition": "thresholdsPassed"}]}}`
+		require.NoError(t, json.Unmarshal([]byte(rawJSON), &result))
+
+		data, err := json.Marshal(result["b"])
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"startAfter":["a",{"scenario":"a","condition":"thresholdsPassed"}]`)
+
+		data, err = json.Marshal(result["a"])
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "startAfter")
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		t.Parallel()
+
+		var result lib.ScenarioConfigs
+		rawJSON := `{"a": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["c"]},` +
+			`"b": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["a"]},` +
+			`"c": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["b"]},` +
+			`"d": {"executor": "constant-vus", "vus": 1, "duration": "10s", "startAfter": ["a"]}}`
+		require.NoError(t, json.Unmarshal([]byte(rawJSON), &result))
+
+		errs := result.Validate()
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], "the scenarios can't start after each other: a -> c -> b -> a")
+		// the start times of the invalid configs can still be calculated
+		assert.Len(t, result.GetStartTimes(), 4)
+	})
+}
+
+// The matrix is tested here, for the same reason as below.
+func TestScenarioConfigsMatrix(t *testing.T) {
+	t.Parallel()
+
+	var result lib.ScenarioConfigs
+	rawJSON := `{"browse": {"executor": "constant-vus", "vus": "${VUS}", "duration": "10s",` +
+		`"exec": "browse", "env": {"REGION": "eu"}, "tags": {"target": "${BASE_URL}"},` +
+		`"matrix": {"BASE_URL": ["https://a.test", "https://b.test"], "VUS": [1, 5]}}}`
+	require.NoError(t, json.Unmarshal([]byte(rawJSON), &result))
+	require.Len(t, result, 4)
+	require.Empty(t, result.Validate())
+
+	cfg, ok := result["browse_3"].(ConstantVUsConfig)
+	require.True(t, ok)
+	assert.Equal(t, "browse_3", cfg.Name)
+	assert.Equal(t, null.IntFrom(5), cfg.VUs)
+	assert.Equal(t, null.StringFrom("browse"), cfg.Exec)
+	assert.Equal(t, map[string]string{"target": "https://b.test"}, cfg.Tags)
+	assert.Equal(t, map[string]string{"BASE_URL": "https://b.test", "VUS": "5", "REGION": "eu"}, cfg.Env)
+
+	cfg, ok = result["browse_0"].(ConstantVUsConfig)
+	require.True(t, ok)
+	assert.Equal(t, null.IntFrom(1), cfg.VUs)
+	assert.Equal(t, map[string]string{"target": "https://a.test"}, cfg.Tags)
+
+	rawJSON = `{"a": {"executor": "constant-vus", "duration": "10s", "matrix": {"N": [1, 2]}},` +
+		`"a_1": {"executor": "constant-vus", "duration": "10s"}}`
+	assert.EqualError(t, json.Unmarshal([]byte(rawJSON), &result),
+		"scenario 'a_1' of the matrix of scenario 'a' already exists")
+	rawJSON = `{"a": {"executor": "constant-vus", "duration": "10s", "matrix": {"N": []}}}`
+	assert.EqualError(t, json.Unmarshal([]byte(rawJSON), &result),
+		"scenario 'a' has no values for the matrix variable 'N'")
+}
+
 // Test that the executor configuration is properly written into an archive, and
 // then read back. The reason this test is not in lib/archive_test.go is to avoid
 // an import cycle (lib -> lib/executor -> lib), since we need to import a
@@ -0,0 +1,38 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/ext"
+	"go.k6.io/k6/lib"
+)
+
+// RegisterExtension registers the config constructor of the executor type of
+// an extension, so the scenarios with that type are run by its executors like
+// the ones of the built-in types, and the extension is listed with the others.
+// This function panics if an executor with the same type is already
+// registered, including a built-in one.
+func RegisterExtension(configType string, c lib.ExecutorConfigConstructor) {
+	lib.RegisterExecutorConfigType(configType, c)
+	ext.Register(configType, ext.ExecutorExtension, c)
+}
+
+// NewVUActivationParams returns the params to activate the VUs of a scenario
+// with, so the executors of extensions, which embed BaseConfig and
+// BaseExecutor, support all of the scenario options like the built-in ones.
+func NewVUActivationParams(
+	ctx context.Context, be *BaseExecutor, conf BaseConfig, deactivateCallback func(lib.InitializedVU),
+) *lib.VUActivationParams {
+	return getVUActivationParams(ctx, conf, deactivateCallback, be.nextIterationCounters)
+}
+
+// NewIterationRunner returns the function that the executors of extensions can
+// run the iterations of the active VUs with. It updates the execution state
+// and logs the errors, and returns whether a full iteration was finished.
+func NewIterationRunner(
+	executionState *lib.ExecutionState, logger *logrus.Entry,
+) func(context.Context, lib.ActiveVU) bool {
+	return getIterationRunner(executionState, logger)
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/ext"
+	"go.k6.io/k6/lib"
+)
+
+type extensionConfig struct {
+	ConstantVUsConfig
+	configType string
+}
+
+func (ec extensionConfig) GetType() string {
+	return ec.configType
+}
+
+// extensionsCount makes the names of the extensions of the tests unique, since
+// they can't be registered again, like when the tests are run more than once.
+var extensionsCount atomic.Int64 //nolint:gochecknoglobals
+
+func TestRegisterExtension(t *testing.T) {
+	t.Parallel()
+
+	configType := fmt.Sprintf("test-extension-%d", extensionsCount.Add(1))
+	RegisterExtension(configType, func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+		config := extensionConfig{NewConstantVUsConfig(name), configType}
+		err := lib.StrictJSONUnmarshal(rawJSON, &config.ConstantVUsConfig)
+		return config, err
+	})
+
+	e, ok := ext.Get(ext.ExecutorExtension)[configType]
+	require.True(t, ok)
+	assert.Equal(t, ext.ExecutorExtension, e.Type)
+
+	var cm lib.ScenarioConfigs
+	require.NoError(t, cm.UnmarshalJSON([]byte(`{"replay": {"executor": "`+configType+`", "vus": 2, "duration": "10s"}}`)))
+	config, ok := cm["replay"].(extensionConfig)
+	require.True(t, ok)
+	assert.Equal(t, configType, config.GetType())
+	assert.EqualValues(t, 2, config.VUs.Int64)
+	assert.Empty(t, config.Validate())
+
+	assert.Panics(t, func() {
+		RegisterExtension(constantVUsType, func(string, []byte) (lib.ExecutorConfig, error) {
+			return nil, nil //nolint:nilnil
+		})
+	})
+}
@@ -523,7 +523,9 @@ func (mex *ExternallyControlled) Run(parentCtx context.Context, _ chan<- metrics
 		currentlyPaused: false,
 		activeVUsCount:  new(int64),
 		maxVUs:          new(int64),
-		runIteration:    getIterationRunner(mex.executionState, mex.logger),
+		runIteration: mex.waitWhilePaused(ctx.Done(), withIterationPacing(
+			mex.config.Pacing, ctx.Done(), getIterationRunner(mex.executionState, mex.logger),
+		)),
 	}
 	ss.ProgressFn = runState.progressFn
 
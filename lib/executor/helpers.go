@@ -13,6 +13,7 @@ import (
 	"go.k6.io/k6/execution"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/ui/pb"
 )
 
@@ -105,6 +106,10 @@ func getIterationRunner(
 	executionState *lib.ExecutionState, logger *logrus.Entry,
 ) func(context.Context, lib.ActiveVU) bool {
 	return func(ctx context.Context, vu lib.ActiveVU) bool {
+		// apply backpressure when k6 is close to running out of memory
+		if !executionState.MemoryBudget.Wait(ctx) {
+			return false
+		}
 		err := vu.RunOnce()
 
 		// TODO: track (non-ramp-down) errors from script iterations as a metric,
@@ -118,6 +123,11 @@ func getIterationRunner(
 			return false
 		default:
 			if err != nil {
+				if errors.Is(err, lib.ErrIterationTimeout) {
+					logger.Warn(err.Error())
+					executionState.AddInterruptedIterations(1)
+					return false
+				}
 				if handleInterrupt(ctx, err) {
 					executionState.AddInterruptedIterations(1)
 					return false
@@ -260,5 +270,32 @@ func getVUActivationParams(
 		Tags:                     conf.GetTags(),
 		DeactivateCallback:       deactivateCallback,
 		GetNextIterationCounters: nextIterationCounters,
+		ThinkTime:                conf.Pacing.getThinkTime(),
+		NextExec:                 newExecPicker(conf.ExecMix),
+		IterationTimeout:         conf.GetIterationTimeout(),
+		WarmupIterations:         conf.GetWarmupIterations(),
+		Identities:               conf.GetIdentities(),
+		TrafficShaping:           conf.GetTrafficShaping(),
+	}
+}
+
+// skipLateIteration reports how late an iteration of an arrival-rate executor
+// is to start, and returns true if it has to be skipped to cap the rate at
+// what k6 can generate on time, in which case it's counted as dropped.
+func skipLateIteration(
+	ctx context.Context, es *lib.ExecutionState, out chan<- metrics.SampleContainer,
+	scenario string, tags *metrics.TagSet, delay time.Duration,
+) bool {
+	if !es.Saturation.ObserveSchedulingDelay(scenario, delay) {
+		return false
 	}
+	metrics.PushIfNotDone(ctx, out, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: es.Test.BuiltinMetrics.DroppedIterations,
+			Tags:   tags,
+		},
+		Time:  time.Now(),
+		Value: 1,
+	})
+	return true
 }
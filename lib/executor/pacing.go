@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+// PacingConfig configures the pacing of the iterations of the VUs of a
+// scenario and their think time between groups, so that it doesn't have to be
+// done with sleep() calls in the script, which don't take the response times
+// into account.
+type PacingConfig struct {
+	// The time between the starts of the iterations of each VU. The VUs wait
+	// for the rest of it after the iterations that finish earlier.
+	Iteration types.NullDuration `json:"iteration"`
+	// The time the VUs wait after each top-level group.
+	ThinkTime types.NullDuration `json:"thinkTime"`
+	// The distribution of the times, which are drawn around the configured
+	// ones, they are constant by default.
+	Distribution *ArrivalsConfig `json:"distribution,omitempty"`
+}
+
+// Validate makes sure the times aren't negative and the distribution is valid.
+func (pc *PacingConfig) Validate() []error {
+	if pc == nil {
+		return nil
+	}
+
+	var errors []error
+	if pc.Iteration.Duration < 0 {
+		errors = append(errors, fmt.Errorf("the iteration pacing can't be negative"))
+	}
+	if pc.ThinkTime.Duration < 0 {
+		errors = append(errors, fmt.Errorf("the think time can't be negative"))
+	}
+	for _, err := range pc.Distribution.Validate() {
+		errors = append(errors, fmt.Errorf("invalid pacing distribution: %w", err))
+	}
+	return errors
+}
+
+// validateNoIterationPacing is used by the arrival-rate executors, whose
+// iterations are already paced by their rate.
+func validateNoIterationPacing(pc *PacingConfig) []error {
+	if pc != nil && pc.Iteration.Valid {
+		return []error{fmt.Errorf("the iterations of arrival-rate executors can't be paced, they're started at their rate")}
+	}
+	return nil
+}
+
+// newTimeSampler returns a function that draws times from the distribution,
+// around the given one, or nil if the time isn't configured. The function
+// isn't safe for concurrent use.
+func (pc *PacingConfig) newTimeSampler(d types.NullDuration) func() time.Duration {
+	if d.Duration <= 0 {
+		return nil
+	}
+	sample := pc.Distribution.newSampler()
+	if sample == nil {
+		return d.TimeDuration
+	}
+	return func() time.Duration {
+		return time.Duration(float64(d.Duration) * sample())
+	}
+}
+
+// getThinkTime returns the think time sampler for the VUs activated in a
+// scenario. Every activation gets its own, so they aren't shared between VUs.
+func (pc *PacingConfig) getThinkTime() func() time.Duration {
+	if pc == nil {
+		return nil
+	}
+	return pc.newTimeSampler(pc.ThinkTime)
+}
+
+// withIterationPacing wraps the iteration runner, so that the VUs wait for the
+// rest of the iteration pacing after every iteration that finishes earlier.
+// They stop waiting when the context or the regular duration of the executor
+// is done, since no new iterations would be started then anyway.
+func withIterationPacing(
+	pc *PacingConfig, regDurationDone <-chan struct{}, runIteration func(context.Context, lib.ActiveVU) bool,
+) func(context.Context, lib.ActiveVU) bool {
+	if pc == nil {
+		return runIteration
+	}
+	sample := pc.newTimeSampler(pc.Iteration)
+	if sample == nil {
+		return runIteration
+	}
+
+	var sampleMx sync.Mutex
+	return func(ctx context.Context, vu lib.ActiveVU) bool {
+		sampleMx.Lock()
+		pacing := sample()
+		sampleMx.Unlock()
+
+		start := time.Now()
+		fullIteration := runIteration(ctx, vu)
+		if wait := pacing - time.Since(start); fullIteration && wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-regDurationDone:
+			case <-ctx.Done():
+			}
+		}
+		return fullIteration
+	}
+}
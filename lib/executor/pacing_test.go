@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func TestPacingConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		config PacingConfig
+		valid  bool
+	}{
+		{config: PacingConfig{}, valid: true},
+		{config: PacingConfig{Iteration: types.NullDurationFrom(time.Second)}, valid: true},
+		{
+			config: PacingConfig{
+				ThinkTime:    types.NullDurationFrom(time.Second),
+				Distribution: &ArrivalsConfig{Distribution: null.StringFrom("lognormal")},
+			},
+			valid: true,
+		},
+		{config: PacingConfig{Iteration: types.NullDurationFrom(-time.Second)}},
+		{config: PacingConfig{ThinkTime: types.NullDurationFrom(-time.Second)}},
+		{config: PacingConfig{Distribution: &ArrivalsConfig{Distribution: null.StringFrom("gaussian")}}},
+	}
+
+	for _, tc := range testCases {
+		errs := tc.config.Validate()
+		assert.Equal(t, tc.valid, len(errs) == 0, "%#v: %v", tc.config, errs)
+	}
+
+	var nilConfig *PacingConfig
+	assert.Empty(t, nilConfig.Validate())
+	assert.Nil(t, nilConfig.getThinkTime())
+}
+
+func TestPacingThinkTime(t *testing.T) {
+	t.Parallel()
+
+	constant := &PacingConfig{ThinkTime: types.NullDurationFrom(2 * time.Second)}
+	assert.Equal(t, 2*time.Second, constant.getThinkTime()())
+	assert.Nil(t, (&PacingConfig{Iteration: types.NullDurationFrom(time.Second)}).getThinkTime())
+
+	random := &PacingConfig{
+		ThinkTime:    types.NullDurationFrom(2 * time.Second),
+		Distribution: &ArrivalsConfig{Distribution: null.StringFrom("custom"), Values: []float64{1, 3}},
+	}
+	thinkTime := random.getThinkTime()
+	for i := 0; i < 100; i++ {
+		assert.Contains(t, []time.Duration{time.Second, 3 * time.Second}, thinkTime())
+	}
+}
+
+func TestConstantVUsRunWithIterationPacing(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	config := NewConstantVUsConfig("test")
+	config.VUs = null.IntFrom(2)
+	config.Duration = types.NullDurationFrom(time.Second)
+	config.GracefulStop = types.NullDurationFrom(0)
+	config.Pacing = &PacingConfig{Iteration: types.NullDurationFrom(300 * time.Millisecond)}
+	require.Empty(t, config.Validate())
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	// every VU starts an iteration at 0, 300, 600 and 900ms
+	assert.Equal(t, int64(8), atomic.LoadInt64(&count))
+}
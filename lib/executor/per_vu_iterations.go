@@ -192,7 +192,9 @@ func (pvi PerVUIterations) Run(parentCtx context.Context, out chan<- metrics.Sam
 	defer activeVUs.Wait()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(pvi.executionState, pvi.logger)
+	runIteration := pvi.waitWhilePaused(regDurationDone, withIterationPacing(
+		pvi.config.Pacing, regDurationDone, getIterationRunner(pvi.executionState, pvi.logger),
+	))
 
 	returnVU := func(u lib.InitializedVU) {
 		pvi.executionState.ReturnVU(u, true)
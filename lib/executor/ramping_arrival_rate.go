@@ -43,6 +43,10 @@ type RampingArrivalRateConfig struct {
 	// absolutely hard limit on the number of VUs the executor will use
 	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
 	MaxVUs          null.Int `json:"maxVUs"`
+
+	// The distribution of the times between the iterations, which are evenly
+	// spaced if it's unspecified
+	Arrivals *ArrivalsConfig `json:"arrivals,omitempty"`
 }
 
 // NewRampingArrivalRateConfig returns a RampingArrivalRateConfig with default values
@@ -85,7 +89,7 @@ func (varc RampingArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) stri
 
 // Validate makes sure all options are configured and valid
 func (varc *RampingArrivalRateConfig) Validate() []error {
-	errors := varc.BaseConfig.Validate()
+	errors := append(varc.BaseConfig.Validate(), validateNoIterationPacing(varc.Pacing)...)
 
 	if varc.StartRate.Int64 < 0 {
 		errors = append(errors, fmt.Errorf("the startRate value can't be negative"))
@@ -110,6 +114,8 @@ func (varc *RampingArrivalRateConfig) Validate() []error {
 		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
 	}
 
+	errors = append(errors, varc.Arrivals.Validate()...)
+
 	return errors
 }
 
@@ -157,8 +163,11 @@ type RampingArrivalRate struct {
 	et     *lib.ExecutionTuple
 }
 
-// Make sure we implement the lib.Executor interface.
-var _ lib.Executor = &RampingArrivalRate{}
+// Make sure we implement the lib.Executor and lib.TargetsUpdatableExecutor interfaces.
+var (
+	_ lib.Executor                 = &RampingArrivalRate{}
+	_ lib.TargetsUpdatableExecutor = &RampingArrivalRate{}
+)
 
 // Init values needed for the execution
 func (varr *RampingArrivalRate) Init(_ context.Context) error {
@@ -232,21 +241,40 @@ func (varr *RampingArrivalRate) Init(_ context.Context) error {
 // the striping algorithm from the lib.ExecutionTuple for additional speed up but this could
 // possibly be refactored if need for this arises.
 func (varc RampingArrivalRateConfig) cal(et *lib.ExecutionTuple, ch chan<- time.Duration) {
+	varc.calUntilStopped(et, ch, nil)
+}
+
+// calUntilStopped is like cal, but it stops sending the times once the stop
+// channel is closed, e.g. when the stages are updated.
+func (varc RampingArrivalRateConfig) calUntilStopped(
+	et *lib.ExecutionTuple, ch chan<- time.Duration, stop <-chan struct{},
+) {
 	start, offsets, _ := et.GetStripedOffsets()
-	li := -1
+	// start .. starts at 0 but the algorithm works with area so we need to start from 1 not 0
+	li, gi := -1, start+1
+	// the area of the events is moved by the arrivals distribution, if any
+	arrivals := newArrivals(varc.Arrivals)
 	// TODO: move this to a utility function, or directly what GetStripedOffsets uses once we see everywhere we will use it
-	next := func() int64 {
+	next := func() float64 {
 		li++
-		return offsets[li%len(offsets)]
+		gi += offsets[li%len(offsets)]
+		return arrivals.position(gi)
 	}
 	defer close(ch) // TODO: maybe this is not a good design - closing a channel we get
+	send := func(t time.Duration) bool {
+		select {
+		case ch <- t:
+			return true
+		case <-stop:
+			return false
+		}
+	}
 	var (
 		stageStart                   time.Duration
 		timeUnit                     = float64(varc.TimeUnit.Duration)
 		doneSoFar, endCount, to, dur float64
 		from                         = float64(varc.StartRate.ValueOrZero()) / timeUnit
-		// start .. starts at 0 but the algorithm works with area so we need to start from 1 not 0
-		i = float64(start + 1)
+		i                            = arrivals.position(gi)
 	)
 
 	for _, stage := range varc.Stages {
@@ -254,17 +282,21 @@ func (varc RampingArrivalRateConfig) cal(et *lib.ExecutionTuple, ch chan<- time.
 		dur = float64(stage.Duration.Duration)
 		if from != to { // ramp up/down
 			endCount += dur * ((to-from)/2 + from)
-			for ; i <= endCount; i += float64(next()) {
+			for ; i <= endCount; i = next() {
 				// TODO: try to twist this in a way to be able to get i (the only changing part)
 				// somewhere where it is less in the middle of the equation
 				x := (from*dur - noNegativeSqrt(dur*(from*from*dur+2*(i-doneSoFar)*(to-from)))) / (from - to)
 
-				ch <- time.Duration(x) + stageStart
+				if !send(time.Duration(x) + stageStart) {
+					return
+				}
 			}
 		} else {
 			endCount += dur * to
-			for ; i <= endCount; i += float64(next()) {
-				ch <- time.Duration((i-doneSoFar)/to) + stageStart
+			for ; i <= endCount; i = next() {
+				if !send(time.Duration((i-doneSoFar)/to) + stageStart) {
+					return
+				}
 			}
 		}
 		doneSoFar = endCount
@@ -326,7 +358,8 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 
 	returnedVUs := make(chan struct{})
 	waitOnProgressChannel := make(chan struct{})
-	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	durations, maxDurationCtx, regDurationCtx, cancel := newUpdatableDurations(parentCtx, duration, gracefulStop)
+	startTime := durations.startTime
 
 	vusPool := newActiveVUPool(varr.executionState)
 
@@ -358,6 +391,7 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		}
 		progIters := fmt.Sprintf(itersFmt, itersPerSec)
 
+		duration := durations.getDuration()
 		right := []string{progVUs, duration.String(), progIters}
 
 		spent := time.Since(startTime)
@@ -439,11 +473,52 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 	timer := time.NewTimer(time.Hour)
 	start := time.Now()
 	ch := make(chan time.Duration, 10) // buffer 10 iteration times ahead
-	var prevTime time.Duration
+	stopCal := make(chan struct{})
+	defer func() { close(stopCal) }()
+	var prevTime, calOffset time.Duration
 	shownWarning := false
 	metricTags := varr.getMetricTags(nil)
-	go varr.config.cal(varr.et, ch)
-	for nextTime := range ch {
+	go varr.config.calUntilStopped(varr.et, ch, stopCal)
+
+	// The updated stages replace the remaining ones, from the current rate,
+	// so the times are calculated again from now on.
+	updates, stopUpdates := varr.startTargetsUpdates()
+	defer stopUpdates()
+	applyUpdate := func(newStages []Stage) error {
+		elapsed := time.Since(start)
+		if !durations.setDuration(time.Since(startTime) + sumStagesDuration(newStages)) {
+			return ErrScenarioNotRunning
+		}
+		pastStages, currentRate := varr.config.stagesUntil(elapsed)
+		calConfig := varr.config
+		calConfig.StartRate = null.IntFrom(int64(math.Round(currentRate)))
+		calConfig.Stages = newStages
+
+		close(stopCal)
+		ch, stopCal, calOffset = make(chan time.Duration, 10), make(chan struct{}), elapsed
+		go calConfig.calUntilStopped(varr.et, ch, stopCal)
+
+		newConfig := varr.config
+		newConfig.Stages = append(pastStages, newStages...)
+		varr.config = newConfig
+		varr.setConfig(&newConfig)
+		return nil
+	}
+
+	for {
+		var nextTime time.Duration
+		select {
+		case t, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			nextTime = calOffset + t
+		case update := <-updates:
+			update.err <- applyUpdate(update.newConfig.(*RampingArrivalRateConfig).Stages) //nolint:forcetypeassert
+			continue
+		case <-regDurationDone:
+			return nil
+		}
 		select {
 		case <-regDurationDone:
 			return nil
@@ -456,11 +531,40 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 			timer.Reset(b)
 			select {
 			case <-timer.C:
+			case update := <-updates:
+				// the iteration is replaced by the ones of the updated stages
+				if !timer.Stop() {
+					<-timer.C
+				}
+				update.err <- applyUpdate(update.newConfig.(*RampingArrivalRateConfig).Stages) //nolint:forcetypeassert
+				continue
 			case <-regDurationDone:
 				return nil
 			}
 		}
 
+		// no VU is taken for the iteration while the scenario is paused, and
+		// the stages are shifted by the time it was paused
+		if resumed := varr.resumedChan(); resumed != nil {
+			pausedAt := time.Now()
+			select {
+			case <-resumed:
+				start = start.Add(time.Since(pausedAt))
+			case update := <-updates:
+				// the iteration is replaced by the ones of the updated stages,
+				// which wait for the scenario to be resumed too
+				start = start.Add(time.Since(pausedAt))
+				update.err <- applyUpdate(update.newConfig.(*RampingArrivalRateConfig).Stages) //nolint:forcetypeassert
+				continue
+			case <-regDurationDone:
+				return nil
+			}
+		}
+
+		delay := time.Since(start.Add(nextTime))
+		if skipLateIteration(parentCtx, varr.executionState, out, varr.config.Name, metricTags, delay) {
+			continue
+		}
 		if vusPool.TryRunIteration() {
 			continue
 		}
@@ -492,7 +596,39 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		default: // we're already allocating a new VU
 		}
 	}
-	return nil
+}
+
+// stagesUntil returns the stages until the given time, with the last one cut
+// at it, and the unscaled rate at that time.
+func (varc RampingArrivalRateConfig) stagesUntil(t time.Duration) ([]Stage, float64) {
+	stages := make([]Stage, 0, len(varc.Stages))
+	var stageStart time.Duration
+	from := float64(varc.StartRate.Int64)
+	for _, stage := range varc.Stages {
+		to, dur := float64(stage.Target.Int64), stage.Duration.TimeDuration()
+		if stageStart+dur > t {
+			rate := from + (to-from)*float64(t-stageStart)/float64(dur)
+			return append(stages, Stage{
+				Duration: types.NullDurationFrom(t - stageStart),
+				Target:   null.IntFrom(int64(math.Round(rate))),
+			}), rate
+		}
+		stages = append(stages, stage)
+		from = to
+		stageStart += dur
+	}
+	return stages, from
+}
+
+// UpdateTargets replaces the remaining stages of the running scenario with
+// the new ones, which start from the current rate.
+func (varr *RampingArrivalRate) UpdateTargets(ctx context.Context, changes []byte) error {
+	newConfig := *varr.GetConfig().(*RampingArrivalRateConfig) //nolint:forcetypeassert
+	newConfig.Stages = nil
+	if err := applyTargetsChanges(&newConfig, changes, "stages"); err != nil {
+		return err
+	}
+	return varr.updateTargets(ctx, &newConfig)
 }
 
 // activeVUPool controls the activeVUs
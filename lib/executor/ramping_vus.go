@@ -522,7 +522,9 @@ func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer
 		maxVUs:         maxVUs,
 		activeVUsCount: new(int64),
 		started:        startTime,
-		runIteration:   getIterationRunner(vlv.executionState, vlv.logger),
+		runIteration: vlv.waitWhilePaused(regularDurationCtx.Done(), withIterationPacing(
+			vlv.config.Pacing, regularDurationCtx.Done(), getIterationRunner(vlv.executionState, vlv.logger),
+		)),
 	}
 
 	progressFn := runState.makeProgressFn(regularDuration)
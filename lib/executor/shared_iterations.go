@@ -229,7 +229,9 @@ func (si SharedIterations) Run(parentCtx context.Context, out chan<- metrics.Sam
 	}()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(si.executionState, si.logger)
+	runIteration := si.waitWhilePaused(regDurationDone, withIterationPacing(
+		si.config.Pacing, regDurationDone, getIterationRunner(si.executionState, si.logger),
+	))
 
 	returnVU := func(u lib.InitializedVU) {
 		si.executionState.ReturnVU(u, true)
@@ -0,0 +1,302 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+const steppedArrivalRateType = "stepped-arrival-rate"
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		steppedArrivalRateType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewSteppedArrivalRateConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// SteppedArrivalRateConfig stores config for the stepped arrival-rate
+// executor, which replays a timeline of arrival rates, e.g. one exported from
+// an observability system with open('./traffic.csv').
+type SteppedArrivalRateConfig struct {
+	BaseConfig
+	// The timeline is either CSV, with a time and a rate column and an
+	// optional header, or a JSON array of {"time": ..., "rate": ...} objects.
+	// The times are durations like 30s, numbers of seconds, like unix
+	// timestamps, or RFC3339 dates, and they are relative to the first one.
+	// Every rate is held until the next time, and the last one for as long as
+	// the one before it.
+	Timeline null.String        `json:"timeline"`
+	Scale    null.Float         `json:"scale"`
+	TimeUnit types.NullDuration `json:"timeUnit"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewSteppedArrivalRateConfig returns a SteppedArrivalRateConfig with default values
+func NewSteppedArrivalRateConfig(name string) *SteppedArrivalRateConfig {
+	return &SteppedArrivalRateConfig{
+		BaseConfig: NewBaseConfig(name, steppedArrivalRateType),
+		Scale:      null.NewFloat(1, false),
+		TimeUnit:   types.NewNullDuration(1*time.Second, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &SteppedArrivalRateConfig{}
+
+// timelinePoint is a rate of the timeline, at the time relative to its start.
+type timelinePoint struct {
+	time time.Duration
+	rate float64
+}
+
+// parseTimelineTime parses the time of a timeline point, and returns if it's
+// a date.
+func parseTimelineTime(s string) (time.Duration, bool, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), false, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return time.Duration(t.UnixNano()), true, nil
+	}
+	d, err := types.ParseExtendedDuration(s)
+	if err != nil {
+		return 0, false, fmt.Errorf("'%s' isn't a duration, a number of seconds or an RFC3339 date", s)
+	}
+	return d, false, nil
+}
+
+func parseTimelineCSV(data string) ([][2]string, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+
+	var rows [][2]string
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// skip the header
+		if _, err := strconv.ParseFloat(record[1], 64); err != nil && first {
+			continue
+		}
+		rows = append(rows, [2]string{record[0], record[1]})
+	}
+	return rows, nil
+}
+
+func parseTimelineJSON(data string) ([][2]string, error) {
+	var points []struct {
+		Time json.RawMessage `json:"time"`
+		Rate json.Number     `json:"rate"`
+	}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&points); err != nil {
+		return nil, err
+	}
+
+	rows := make([][2]string, 0, len(points))
+	for _, p := range points {
+		t := string(bytes.Trim(p.Time, `"`))
+		rows = append(rows, [2]string{t, p.Rate.String()})
+	}
+	return rows, nil
+}
+
+// parseTimeline returns the points of the timeline, with the times relative
+// to the first one.
+func parseTimeline(data string) ([]timelinePoint, error) {
+	data = strings.TrimSpace(data)
+	var rows [][2]string
+	var err error
+	if strings.HasPrefix(data, "[") {
+		rows, err = parseTimelineJSON(data)
+	} else {
+		rows, err = parseTimelineCSV(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("it has to have at least two points")
+	}
+
+	points := make([]timelinePoint, 0, len(rows))
+	var start time.Duration
+	var absolute bool
+	for i, row := range rows {
+		t, abs, err := parseTimelineTime(strings.TrimSpace(row[0]))
+		if err != nil {
+			return nil, fmt.Errorf("the time of point %d is invalid: %w", i+1, err)
+		}
+		if i == 0 {
+			start, absolute = t, abs
+		} else if abs != absolute {
+			return nil, fmt.Errorf("the time of point %d can't mix dates with durations", i+1)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+		if err != nil || rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("the rate of point %d has to be a non-negative number", i+1)
+		}
+		if i > 0 && t-start <= points[i-1].time {
+			return nil, fmt.Errorf("the time of point %d has to be after the one of point %d", i+1, i)
+		}
+		points = append(points, timelinePoint{time: t - start, rate: rate})
+	}
+	return points, nil
+}
+
+// getRampingConfig returns the config of a ramping arrival-rate executor,
+// which steps through the scaled rates of the timeline.
+func (sarc SteppedArrivalRateConfig) getRampingConfig() (*RampingArrivalRateConfig, error) {
+	points, err := parseTimeline(sarc.Timeline.String)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := func(i int) null.Int {
+		return null.IntFrom(int64(math.Round(points[i].rate * sarc.Scale.Float64)))
+	}
+	stages := make([]Stage, 0, 2*len(points)-1)
+	for i := range points {
+		var duration time.Duration
+		if i+1 < len(points) {
+			duration = points[i+1].time - points[i].time
+		} else {
+			duration = points[i].time - points[i-1].time
+		}
+		if i > 0 {
+			stages = append(stages, Stage{Duration: types.NullDurationFrom(0), Target: rate(i)})
+		}
+		stages = append(stages, Stage{Duration: types.NullDurationFrom(duration), Target: rate(i)})
+	}
+
+	return &RampingArrivalRateConfig{
+		BaseConfig:      sarc.BaseConfig,
+		StartRate:       rate(0),
+		TimeUnit:        sarc.TimeUnit,
+		Stages:          stages,
+		PreAllocatedVUs: sarc.PreAllocatedVUs,
+		MaxVUs:          sarc.MaxVUs,
+	}, nil
+}
+
+// rampingConfig returns the ramping config, without any stages if the
+// timeline is invalid, since that's reported by Validate().
+func (sarc SteppedArrivalRateConfig) rampingConfig() *RampingArrivalRateConfig {
+	varc, err := sarc.getRampingConfig()
+	if err != nil {
+		return &RampingArrivalRateConfig{
+			BaseConfig:      sarc.BaseConfig,
+			TimeUnit:        sarc.TimeUnit,
+			PreAllocatedVUs: sarc.PreAllocatedVUs,
+			MaxVUs:          sarc.MaxVUs,
+		}
+	}
+	return varc
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (sarc SteppedArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) string {
+	varc := sarc.rampingConfig()
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", et.ScaleInt64(varc.PreAllocatedVUs.Int64))
+	if varc.MaxVUs.Int64 > varc.PreAllocatedVUs.Int64 {
+		maxVUsRange += fmt.Sprintf("-%d", et.ScaleInt64(varc.MaxVUs.Int64))
+	}
+	maxUnscaledRate := getStagesUnscaledMaxTarget(varc.StartRate.Int64, varc.Stages)
+	maxArrRatePerSec, _ := getArrivalRatePerSec(
+		getScaledArrivalRate(et.Segment, maxUnscaledRate, varc.TimeUnit.TimeDuration()),
+	).Float64()
+
+	return fmt.Sprintf("Up to %.2f iterations/s for %s over %d timeline steps%s",
+		maxArrRatePerSec, sumStagesDuration(varc.Stages),
+		(len(varc.Stages)+1)/2, sarc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+func (sarc *SteppedArrivalRateConfig) Validate() []error {
+	errors := append(sarc.BaseConfig.Validate(), validateNoIterationPacing(sarc.Pacing)...)
+
+	if !sarc.Timeline.Valid {
+		errors = append(errors, fmt.Errorf("the timeline isn't specified"))
+	} else if _, err := parseTimeline(sarc.Timeline.String); err != nil {
+		errors = append(errors, fmt.Errorf("the timeline is invalid: %w", err))
+	}
+
+	if sarc.Scale.Float64 <= 0 {
+		errors = append(errors, fmt.Errorf("the scale must be more than 0"))
+	}
+
+	if sarc.TimeUnit.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the timeUnit must be more than 0"))
+	}
+
+	if !sarc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if sarc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !sarc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		sarc.MaxVUs.Int64 = sarc.PreAllocatedVUs.Int64
+	} else if sarc.MaxVUs.Int64 < sarc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errors
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+func (sarc SteppedArrivalRateConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return sarc.rampingConfig().GetExecutionRequirements(et)
+}
+
+// NewExecutor creates a new ramping arrival-rate executor, which runs the
+// steps of the timeline.
+func (sarc SteppedArrivalRateConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	varc, err := sarc.getRampingConfig()
+	if err != nil {
+		return nil, err
+	}
+	return varc.NewExecutor(es, logger)
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (sarc SteppedArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return et.ScaleInt64(sarc.MaxVUs.Int64) > 0
+}
@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func TestParseTimeline(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name, timeline string
+		exp            []timelinePoint
+		expErr         string
+	}{
+		{
+			name:     "CSV with a header and seconds",
+			timeline: "time,rate\n0,10\n30,20.5\n",
+			exp:      []timelinePoint{{time: 0, rate: 10}, {time: 30 * time.Second, rate: 20.5}},
+		},
+		{
+			name:     "CSV with unix timestamps",
+			timeline: "1700000000, 5\n1700000060, 0",
+			exp:      []timelinePoint{{time: 0, rate: 5}, {time: time.Minute, rate: 0}},
+		},
+		{
+			name:     "CSV with dates",
+			timeline: "2023-11-01T10:00:00Z,5\n2023-11-01T10:05:00Z,8",
+			exp:      []timelinePoint{{time: 0, rate: 5}, {time: 5 * time.Minute, rate: 8}},
+		},
+		{
+			name:     "JSON with durations",
+			timeline: `[{"time":"1m","rate":1},{"time":"2m30s","rate":3}]`,
+			exp:      []timelinePoint{{time: 0, rate: 1}, {time: 90 * time.Second, rate: 3}},
+		},
+		{
+			name:     "JSON with numbers",
+			timeline: `[{"time":0,"rate":1},{"time":10,"rate":3}]`,
+			exp:      []timelinePoint{{time: 0, rate: 1}, {time: 10 * time.Second, rate: 3}},
+		},
+		{
+			name:     "a single point",
+			timeline: "0,10",
+			expErr:   "at least two points",
+		},
+		{
+			name:     "decreasing times",
+			timeline: "0,10\n30,10\n20,10",
+			expErr:   "the time of point 3 has to be after the one of point 2",
+		},
+		{
+			name:     "negative rate",
+			timeline: "0,10\n30,-1",
+			expErr:   "the rate of point 2 has to be a non-negative number",
+		},
+		{
+			name:     "mixed dates and durations",
+			timeline: "2023-11-01T10:00:00Z,5\n5m,8",
+			expErr:   "the time of point 2 can't mix dates with durations",
+		},
+		{
+			name:     "invalid time",
+			timeline: "0,10\nsoon,10",
+			expErr:   "the time of point 2 is invalid",
+		},
+		{
+			name:     "unknown JSON field",
+			timeline: `[{"time":0,"rps":1},{"time":10,"rps":3}]`,
+			expErr:   "unknown field",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			points, err := parseTimeline(tc.timeline)
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, points)
+		})
+	}
+}
+
+func TestSteppedArrivalRateRampingConfig(t *testing.T) {
+	t.Parallel()
+
+	config := NewSteppedArrivalRateConfig("test")
+	config.Timeline = null.StringFrom("0,10\n20,4\n50,0")
+	config.Scale = null.FloatFrom(1.5)
+	config.PreAllocatedVUs = null.IntFrom(5)
+	require.Empty(t, config.Validate())
+
+	varc, err := config.getRampingConfig()
+	require.NoError(t, err)
+	assert.Equal(t, null.IntFrom(15), varc.StartRate)
+	assert.Equal(t, []Stage{
+		{Duration: types.NullDurationFrom(20 * time.Second), Target: null.IntFrom(15)},
+		{Duration: types.NullDurationFrom(0), Target: null.IntFrom(6)},
+		{Duration: types.NullDurationFrom(30 * time.Second), Target: null.IntFrom(6)},
+		{Duration: types.NullDurationFrom(0), Target: null.IntFrom(0)},
+		{Duration: types.NullDurationFrom(30 * time.Second), Target: null.IntFrom(0)},
+	}, varc.Stages)
+	assert.Equal(t, int64(5), varc.MaxVUs.Int64)
+	assert.Empty(t, varc.Validate())
+}
+
+func TestSteppedArrivalRateRunCorrectRate(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+
+	config := NewSteppedArrivalRateConfig("test")
+	config.Timeline = null.StringFrom(`[{"time":"0s","rate":20},{"time":"1s","rate":100},{"time":"2s","rate":0}]`)
+	config.Scale = null.FloatFrom(0.5)
+	config.PreAllocatedVUs = null.IntFrom(10)
+	config.GracefulStop = types.NullDurationFrom(time.Second)
+	require.Empty(t, config.Validate())
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	// 10 iterations in the first second and 50 in the next one
+	assert.InDelta(t, 60, atomic.LoadInt64(&count), 2)
+	assert.Empty(t, test.logHook.Drain())
+}
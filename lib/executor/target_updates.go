@@ -0,0 +1,189 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.k6.io/k6/lib"
+)
+
+// ErrScenarioNotRunning is returned when the targets of a scenario are updated
+// while it isn't running, i.e. before it started or after its regular duration.
+var ErrScenarioNotRunning = errors.New("the scenario isn't running")
+
+// targetsUpdate is sent to the Run loop of an executor, which applies the new
+// config and replies on the err channel.
+type targetsUpdate struct {
+	newConfig lib.ExecutorConfig
+	err       chan error
+}
+
+// targetsUpdates are the updates the Run loop of an executor receives, until
+// done is closed.
+type targetsUpdates struct {
+	ch   chan targetsUpdate
+	done chan struct{}
+}
+
+// startTargetsUpdates starts accepting the updates of the targets of the
+// executor, which are received on the returned channel until the returned
+// function is called.
+func (bs *BaseExecutor) startTargetsUpdates() (<-chan targetsUpdate, func()) {
+	updates := &targetsUpdates{ch: make(chan targetsUpdate), done: make(chan struct{})}
+	bs.configMx.Lock()
+	bs.updates = updates
+	bs.configMx.Unlock()
+
+	return updates.ch, func() {
+		close(updates.done)
+		bs.configMx.Lock()
+		bs.updates = nil
+		bs.configMx.Unlock()
+	}
+}
+
+// updateTargets sends the new config to the Run loop of the executor and
+// waits for it to be applied. It's used by the UpdateTargets() methods of the
+// executors, after they validated it.
+func (bs *BaseExecutor) updateTargets(ctx context.Context, newConfig lib.ExecutorConfig) error {
+	bs.configMx.Lock()
+	updates := bs.updates
+	bs.configMx.Unlock()
+	if updates == nil {
+		return ErrScenarioNotRunning
+	}
+
+	update := targetsUpdate{newConfig: newConfig, err: make(chan error, 1)}
+	select {
+	case updates.ch <- update:
+		if err := <-update.err; err != nil {
+			return err
+		}
+		bs.logger.Infof("Updated the targets of the scenario to %s",
+			bs.GetConfig().GetDescription(bs.executionState.ExecutionTuple))
+		return nil
+	case <-updates.done:
+		return ErrScenarioNotRunning
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// setConfig replaces the config of the executor with the one its targets
+// were updated to, so the descriptions of the scenario stay accurate.
+func (bs *BaseExecutor) setConfig(config lib.ExecutorConfig) {
+	bs.configMx.Lock()
+	defer bs.configMx.Unlock()
+	bs.config = config
+}
+
+// applyTargetsChanges applies the JSON changes on the config, which has to be
+// a pointer to a copy of the current one. Only the fields with the given names
+// can be changed and the resulting config has to be valid.
+func applyTargetsChanges(config lib.ExecutorConfig, changes []byte, fields ...string) error {
+	var changed map[string]json.RawMessage
+	if err := json.Unmarshal(changes, &changed); err != nil {
+		return fmt.Errorf("the changes have to be a JSON object: %w", err)
+	}
+	if len(changed) == 0 {
+		return errors.New("there are no changes")
+	}
+	for name := range changed {
+		if !contains(fields, name) {
+			return fmt.Errorf("the %s of a running %s scenario can't be changed, only its %s",
+				name, config.GetType(), strings.Join(fields, " and "))
+		}
+	}
+
+	if err := lib.StrictJSONUnmarshal(changes, config); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if errs := config.Validate(); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// updatableDurations are like the contexts of getDurationContexts(), but the
+// regular duration can be changed while the executor is running.
+type updatableDurations struct {
+	startTime    time.Time
+	gracefulStop time.Duration
+	duration     int64 // accessed atomically
+
+	mx       sync.Mutex
+	regCtx   context.Context
+	regTimer *time.Timer // nil if there is no graceful stop
+	maxTimer *time.Timer
+}
+
+// newUpdatableDurations returns the updatable durations and their contexts,
+// the cancel function cancels both of them.
+func newUpdatableDurations(parentCtx context.Context, regularDuration, gracefulStop time.Duration) (
+	durations *updatableDurations, maxDurationCtx, regDurationCtx context.Context, maxDurationCancel func(),
+) {
+	durations = &updatableDurations{
+		startTime:    time.Now(),
+		gracefulStop: gracefulStop,
+		duration:     int64(regularDuration),
+	}
+
+	maxDurationCtx, cancelMax := context.WithCancel(parentCtx)
+	durations.maxTimer = time.AfterFunc(regularDuration+gracefulStop, cancelMax)
+	regDurationCtx = maxDurationCtx
+	if gracefulStop > 0 {
+		var cancelReg func()
+		regDurationCtx, cancelReg = context.WithCancel(maxDurationCtx)
+		durations.regTimer = time.AfterFunc(regularDuration, cancelReg)
+	}
+	durations.regCtx = regDurationCtx
+
+	return durations, maxDurationCtx, regDurationCtx, func() {
+		durations.mx.Lock()
+		durations.maxTimer.Stop()
+		if durations.regTimer != nil {
+			durations.regTimer.Stop()
+		}
+		durations.mx.Unlock()
+		cancelMax()
+	}
+}
+
+// getDuration returns the current regular duration.
+func (ud *updatableDurations) getDuration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&ud.duration))
+}
+
+// setDuration changes the regular duration, from the start of the executor,
+// and returns false if it's already over. It ends right away if the new
+// duration has already passed.
+func (ud *updatableDurations) setDuration(regularDuration time.Duration) bool {
+	ud.mx.Lock()
+	defer ud.mx.Unlock()
+	if ud.regCtx.Err() != nil {
+		return false
+	}
+
+	atomic.StoreInt64(&ud.duration, int64(regularDuration))
+	left := time.Until(ud.startTime.Add(regularDuration))
+	if ud.regTimer != nil {
+		ud.regTimer.Reset(left)
+	}
+	ud.maxTimer.Reset(left + ud.gracefulStop)
+	return true
+}
@@ -0,0 +1,191 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func getTestTargetsConfig() *ConstantArrivalRateConfig {
+	config := NewConstantArrivalRateConfig("test")
+	config.GracefulStop = types.NullDurationFrom(time.Second)
+	config.Rate = null.IntFrom(20)
+	config.Duration = types.NullDurationFrom(2 * time.Second)
+	config.PreAllocatedVUs = null.IntFrom(10)
+	config.MaxVUs = null.IntFrom(10)
+	return config
+}
+
+func TestApplyTargetsChanges(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name, changes string
+		expErr        string
+	}{
+		{name: "rate", changes: `{"rate":50}`},
+		{name: "rate and duration", changes: `{"rate":50,"duration":"1m"}`},
+		{name: "not an object", changes: `[50]`, expErr: "the changes have to be a JSON object"},
+		{name: "no changes", changes: `{}`, expErr: "there are no changes"},
+		{
+			name: "other field", changes: `{"timeUnit":"1m"}`,
+			expErr: "the timeUnit of a running constant-arrival-rate scenario can't be changed, only its rate and duration",
+		},
+		{name: "invalid rate", changes: `{"rate":-1}`, expErr: "the iteration rate must be more than 0"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := getTestTargetsConfig()
+			err := applyTargetsChanges(config, []byte(tc.changes), "rate", "duration")
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, null.IntFrom(50), config.Rate)
+		})
+	}
+}
+
+func TestRampingArrivalRateStagesUntil(t *testing.T) {
+	t.Parallel()
+
+	config := RampingArrivalRateConfig{
+		StartRate: null.IntFrom(10),
+		Stages: []Stage{
+			{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(30)},
+			{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(30)},
+		},
+	}
+
+	stages, rate := config.stagesUntil(5 * time.Second)
+	assert.Equal(t, []Stage{{Duration: types.NullDurationFrom(5 * time.Second), Target: null.IntFrom(20)}}, stages)
+	assert.Equal(t, 20.0, rate)
+
+	stages, rate = config.stagesUntil(15 * time.Second)
+	assert.Equal(t, []Stage{
+		config.Stages[0],
+		{Duration: types.NullDurationFrom(5 * time.Second), Target: null.IntFrom(30)},
+	}, stages)
+	assert.Equal(t, 30.0, rate)
+
+	stages, rate = config.stagesUntil(time.Minute)
+	assert.Equal(t, config.Stages, stages)
+	assert.Equal(t, 30.0, rate)
+}
+
+func TestUpdateTargetsNotRunning(t *testing.T) {
+	t.Parallel()
+
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error { return nil })
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestTargetsConfig())
+	defer test.cancel()
+
+	tex, ok := test.executor.(lib.TargetsUpdatableExecutor)
+	require.True(t, ok)
+	err := tex.UpdateTargets(context.Background(), []byte(`{"rate":100}`))
+	require.ErrorIs(t, err, ErrScenarioNotRunning)
+	assert.Equal(t, null.IntFrom(20), test.executor.GetConfig().(*ConstantArrivalRateConfig).Rate) //nolint:forcetypeassert
+}
+
+// runAndUpdateTargets runs the executor and updates its targets after the
+// delay, it returns how long the run took.
+func runAndUpdateTargets(t *testing.T, test *executorTest, delay time.Duration, changes string) time.Duration {
+	t.Helper()
+
+	go func() {
+		time.Sleep(delay)
+		tex, ok := test.executor.(lib.TargetsUpdatableExecutor)
+		assert.True(t, ok)
+		assert.NoError(t, tex.UpdateTargets(test.ctx, []byte(changes)))
+	}()
+
+	start := time.Now()
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	return time.Since(start)
+}
+
+func TestConstantArrivalRateUpdateTargets(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestTargetsConfig())
+	defer test.cancel()
+
+	took := runAndUpdateTargets(t, test, time.Second, `{"rate":100,"duration":"3s"}`)
+	// 20 iterations in the first second and 100 in each of the next two
+	assert.InDelta(t, 220, atomic.LoadInt64(&count), 10)
+	assert.InDelta(t, 3*time.Second, took, float64(300*time.Millisecond))
+
+	newConfig := test.executor.GetConfig().(*ConstantArrivalRateConfig) //nolint:forcetypeassert
+	assert.Equal(t, null.IntFrom(100), newConfig.Rate)
+	assert.Equal(t, types.NullDurationFrom(3*time.Second), newConfig.Duration)
+	assert.Empty(t, test.logHook.Drain())
+}
+
+func TestRampingArrivalRateUpdateTargets(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+	config := NewRampingArrivalRateConfig("test")
+	config.GracefulStop = types.NullDurationFrom(time.Second)
+	config.StartRate = null.IntFrom(20)
+	config.Stages = []Stage{{Duration: types.NullDurationFrom(3 * time.Second), Target: null.IntFrom(20)}}
+	config.PreAllocatedVUs = null.IntFrom(10)
+	config.MaxVUs = null.IntFrom(10)
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	took := runAndUpdateTargets(t, test, time.Second,
+		`{"stages":[{"duration":"0s","target":100},{"duration":"1s","target":100}]}`)
+	// 20 iterations in the first second and 100 in the next one
+	assert.InDelta(t, 120, atomic.LoadInt64(&count), 10)
+	assert.InDelta(t, 2*time.Second, took, float64(300*time.Millisecond))
+
+	newConfig := test.executor.GetConfig().(*RampingArrivalRateConfig) //nolint:forcetypeassert
+	require.Len(t, newConfig.Stages, 3)
+	assert.InDelta(t, time.Second, newConfig.Stages[0].Duration.TimeDuration(), float64(100*time.Millisecond))
+	assert.Equal(t, null.IntFrom(20), newConfig.Stages[0].Target)
+	assert.Empty(t, test.logHook.Drain())
+}
+
+func TestConstantVUsUpdateTargets(t *testing.T) {
+	t.Parallel()
+
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	config := NewConstantVUsConfig("default")
+	config.VUs = null.IntFrom(2)
+	config.Duration = types.NullDurationFrom(time.Second)
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+
+	took := runAndUpdateTargets(t, test, 500*time.Millisecond, `{"duration":"2s"}`)
+	assert.InDelta(t, 2*time.Second, took, float64(300*time.Millisecond))
+	assert.Equal(t, types.NullDurationFrom(2*time.Second),
+		test.executor.GetConfig().(ConstantVUsConfig).Duration) //nolint:forcetypeassert
+}
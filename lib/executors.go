@@ -63,6 +63,9 @@ type ExecutorConfig interface {
 	GetName() string
 	GetType() string
 	GetStartTime() time.Duration
+	// The scenarios that have to finish before this one starts, its start
+	// time is counted from the end of the last one of them.
+	GetStartAfter() []ScenarioDependency
 	GetGracefulStop() time.Duration
 
 	// This is used to validate whether a particular script can run in the cloud
@@ -76,6 +79,14 @@ type ExecutorConfig interface {
 	//
 	// TODO: use interface{} so plain http requests can be specified?
 	GetExec() string
+	// The weights of the functions that the iterations are spread across,
+	// instead of the exec one, if they're configured.
+	GetExecMix() map[string]float64
+	// The functions that run before and after the scenario, if any. The data
+	// returned by the setup one is passed to the iterations of the scenario
+	// and to the teardown one, instead of the data returned by setup().
+	GetSetup() string
+	GetTeardown() string
 	GetTags() map[string]string
 
 	// Calculates the VU requirements in different stages of the executor's
@@ -128,6 +139,17 @@ type PausableExecutor interface {
 	SetPaused(bool) error
 }
 
+// ScenarioPausableExecutor should be implemented by the executors whose
+// scenario can be paused and resumed on its own in the middle of the test
+// execution. While a scenario is paused, its VUs don't start new iterations,
+// but unlike with PausableExecutor, the executor keeps to its schedule, so the
+// time while paused still counts towards its duration and the iterations that
+// an arrival-rate executor can't start are dropped.
+type ScenarioPausableExecutor interface {
+	SetScenarioPaused(bool)
+	IsScenarioPaused() bool
+}
+
 // LiveUpdatableExecutor should be implemented for the executors whose
 // configuration can be modified in the middle of the test execution. Currently,
 // only the manual execution executor implements it.
@@ -135,6 +157,48 @@ type LiveUpdatableExecutor interface {
 	UpdateConfig(ctx context.Context, newConfig interface{}) error
 }
 
+// TargetsUpdatableExecutor should be implemented by the executors whose
+// targets, like their rate, stages or duration, can be changed while their
+// scenario is running. The changes are a JSON object with the same fields as
+// the config of the executor, and the ones that aren't in it are kept.
+type TargetsUpdatableExecutor interface {
+	UpdateTargets(ctx context.Context, changes []byte) error
+}
+
+// ScenarioConditionThresholdsPassed is the condition of a scenario dependency
+// which is met if all the thresholds of the scenario passed, i.e. the ones on
+// its sub-metrics, like http_req_duration{scenario:warmup}.
+const ScenarioConditionThresholdsPassed = "thresholdsPassed"
+
+// ScenarioDependency is a scenario that has to finish before another one
+// starts, and an optional condition that has to be met by it when it does,
+// otherwise the other scenario is skipped. It can be specified with just the
+// name of the scenario, or as a {"scenario": ..., "condition": ...} object.
+type ScenarioDependency struct {
+	Scenario  string `json:"scenario"`
+	Condition string `json:"condition,omitempty"`
+}
+
+// UnmarshalJSON unmarshals either the name of the scenario, or the full object.
+func (sd *ScenarioDependency) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*sd = ScenarioDependency{}
+		return json.Unmarshal(data, &sd.Scenario)
+	}
+	type plain ScenarioDependency
+	return StrictJSONUnmarshal(data, (*plain)(sd))
+}
+
+// MarshalJSON marshals the dependency without a condition as just the name of
+// the scenario.
+func (sd ScenarioDependency) MarshalJSON() ([]byte, error) {
+	if sd.Condition == "" {
+		return json.Marshal(sd.Scenario)
+	}
+	type plain ScenarioDependency
+	return json.Marshal(plain(sd))
+}
+
 // ExecutorConfigConstructor is a simple function that returns a concrete
 // Config instance with the specified name and all default values correctly
 // initialized
@@ -183,11 +247,29 @@ func (scs *ScenarioConfigs) UnmarshalJSON(data []byte) error {
 		if v.executorType == "" {
 			return fmt.Errorf("scenario '%s' doesn't have a specified executor type", k)
 		}
-		config, err := GetParsedExecutorConfig(k, v.executorType, v.rawJSON)
+		if v.matrix == nil {
+			config, err := GetParsedExecutorConfig(k, v.executorType, v.rawJSON)
+			if err != nil {
+				return err
+			}
+			result[k] = config
+			continue
+		}
+
+		expanded, err := expandScenarioMatrix(k, v.rawJSON, v.matrix)
 		if err != nil {
 			return err
 		}
-		result[k] = config
+		for name, rawJSON := range expanded {
+			if _, ok := protoConfigs[name]; ok {
+				return fmt.Errorf("scenario '%s' of the matrix of scenario '%s' already exists", name, k)
+			}
+			config, err := GetParsedExecutorConfig(name, v.executorType, rawJSON)
+			if err != nil {
+				return err
+			}
+			result[name] = config
+		}
 	}
 
 	*scs = result
@@ -202,10 +284,121 @@ func (scs ScenarioConfigs) Validate() (errors []error) {
 			errors = append(errors,
 				fmt.Errorf("scenario %s has configuration errors: %s", name, ConcatErrors(execErr, ", ")))
 		}
+		for _, dep := range exec.GetStartAfter() {
+			if _, ok := scs[dep.Scenario]; !ok {
+				errors = append(errors, fmt.Errorf("scenario %s starts after the unknown scenario '%s'", name, dep.Scenario))
+			}
+			if dep.Condition != "" && dep.Condition != ScenarioConditionThresholdsPassed {
+				errors = append(errors, fmt.Errorf(
+					"scenario %s starts after scenario %s with the unknown condition '%s', it can only be '%s'",
+					name, dep.Scenario, dep.Condition, ScenarioConditionThresholdsPassed,
+				))
+			}
+		}
+	}
+	if cycle := scs.findStartAfterCycle(); cycle != nil {
+		errors = append(errors, fmt.Errorf("the scenarios can't start after each other: %s", strings.Join(cycle, " -> ")))
 	}
 	return errors
 }
 
+// findStartAfterCycle returns the names of the scenarios in a cycle of their
+// dependencies, if there's one.
+func (scs ScenarioConfigs) findStartAfterCycle() []string {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(scs))
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range scs[name].GetStartAfter() {
+			if _, ok := scs[dep.Scenario]; !ok {
+				continue
+			}
+			if cycle := visit(dep.Scenario); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	names := make([]string, 0, len(scs))
+	for name := range scs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// GetStartTimes returns the latest start times of the scenarios, relative to
+// the beginning of the test. The scenarios that start after others start when
+// the last of them finishes, so their latest start times are counted from the
+// end of its maxDuration, including its graceful stop.
+func (scs ScenarioConfigs) GetStartTimes() map[string]time.Duration {
+	_, latest := scs.getStartTimeBounds()
+	return latest
+}
+
+// getStartTimeBounds returns the earliest and the latest start times of the
+// scenarios. They're different for the scenarios that start after others,
+// since the ones before them can finish right away or after their maxDuration.
+func (scs ScenarioConfigs) getStartTimeBounds() (earliest, latest map[string]time.Duration) {
+	// the end offsets don't depend on the execution segment
+	et, _ := NewExecutionTuple(nil, nil)
+	earliest = make(map[string]time.Duration, len(scs))
+	latest = make(map[string]time.Duration, len(scs))
+	var resolve func(name string, depth int)
+	resolve = func(name string, depth int) {
+		if _, ok := latest[name]; ok {
+			return
+		}
+		config := scs[name]
+		var earliestAfter, latestAfter time.Duration
+		// the depth guards against cycles in configs that weren't validated
+		for _, dep := range config.GetStartAfter() {
+			depConfig, ok := scs[dep.Scenario]
+			if !ok || depth > len(scs) {
+				continue
+			}
+			resolve(dep.Scenario, depth+1)
+			if start := earliest[dep.Scenario]; start > earliestAfter {
+				earliestAfter = start
+			}
+			depEnd, _ := GetEndOffset(depConfig.GetExecutionRequirements(et))
+			if end := latest[dep.Scenario] + depEnd; end > latestAfter {
+				latestAfter = end
+			}
+		}
+		earliest[name] = earliestAfter + config.GetStartTime()
+		latest[name] = latestAfter + config.GetStartTime()
+	}
+	for name := range scs {
+		resolve(name, 0)
+	}
+	return earliest, latest
+}
+
 // GetSortedConfigs returns a slice with the executor configurations,
 // sorted in a consistent and predictable manner. It is useful when we want or
 // have to avoid using maps with string keys (and tons of string lookups in
@@ -217,6 +410,7 @@ func (scs ScenarioConfigs) Validate() (errors []error) {
 // there are ties.
 func (scs ScenarioConfigs) GetSortedConfigs() []ExecutorConfig {
 	configs := make([]ExecutorConfig, len(scs))
+	startTimes := scs.GetStartTimes()
 
 	// Populate the configs slice with sorted executor configs
 	i := 0
@@ -225,10 +419,11 @@ func (scs ScenarioConfigs) GetSortedConfigs() []ExecutorConfig {
 		i++
 	}
 	sort.Slice(configs, func(a, b int) bool { // sort by (start time, name)
+		startA, startB := startTimes[configs[a].GetName()], startTimes[configs[b].GetName()]
 		switch {
-		case configs[a].GetStartTime() < configs[b].GetStartTime():
+		case startA < startB:
 			return true
-		case configs[a].GetStartTime() == configs[b].GetStartTime():
+		case startA == startB:
 			return strings.Compare(configs[a].GetName(), configs[b].GetName()) < 0
 		default:
 			return false
@@ -244,6 +439,7 @@ func (scs ScenarioConfigs) GetSortedConfigs() []ExecutorConfig {
 // moment in the test execution.
 func (scs ScenarioConfigs) GetFullExecutionRequirements(et *ExecutionTuple) []ExecutionStep {
 	sortedConfigs := scs.GetSortedConfigs()
+	earliest, latest := scs.getStartTimeBounds()
 
 	// Combine the steps and requirements from all different executors, and
 	// sort them by their time offset, counting the executors' startTimes as
@@ -254,8 +450,14 @@ func (scs ScenarioConfigs) GetFullExecutionRequirements(et *ExecutionTuple) []Ex
 	}
 	trackedSteps := []trackedStep{}
 	for configID, config := range sortedConfigs { // orderly iteration over a slice
-		configStartTime := config.GetStartTime()
+		name := config.GetName()
+		configStartTime := earliest[name]
 		configSteps := config.GetExecutionRequirements(et)
+		if latest[name] > configStartTime {
+			// the scenario can start at any moment between its earliest and
+			// latest start times, so all of its VUs are planned for that time
+			configSteps = spanExecutionSteps(configSteps, latest[name]-configStartTime)
+		}
 		for _, cs := range configSteps {
 			cs.TimeOffset += configStartTime // add the executor start time to the step time offset
 			trackedSteps = append(trackedSteps, trackedStep{cs, configID})
@@ -313,6 +515,22 @@ func (scs ScenarioConfigs) GetFullExecutionRequirements(et *ExecutionTuple) []Ex
 	return consolidatedSteps
 }
 
+// spanExecutionSteps returns the steps of an executor that can start at any
+// moment during the span, which need the most VUs of the steps during the
+// whole span and the time they take, and the VUs of the last step after it.
+func spanExecutionSteps(steps []ExecutionStep, span time.Duration) []ExecutionStep {
+	if len(steps) == 0 {
+		return steps
+	}
+	maxPlannedVUs := GetMaxPlannedVUs(steps)
+	lastStep := steps[len(steps)-1]
+	lastStep.TimeOffset += span
+	return []ExecutionStep{
+		{PlannedVUs: maxPlannedVUs, MaxUnplannedVUs: GetMaxPossibleVUs(steps) - maxPlannedVUs},
+		lastStep,
+	}
+}
+
 // GetParsedExecutorConfig returns a struct instance corresponding to the supplied
 // config type. It will be fully initialized - with both the default values of
 // the type, as well as with whatever the user had specified in the JSON
@@ -330,15 +548,18 @@ func GetParsedExecutorConfig(name, configType string, rawJSON []byte) (result Ex
 type protoExecutorConfig struct {
 	executorType string
 	rawJSON      json.RawMessage
+	matrix       map[string][]json.RawMessage
 }
 
-// UnmarshalJSON unmarshals the base config (to get the type), but it also
-// stores the unprocessed JSON so we can parse the full config in the next step
+// UnmarshalJSON unmarshals the base config (to get the type and the matrix),
+// but it also stores the unprocessed JSON so we can parse the full config in
+// the next step
 func (pc *protoExecutorConfig) UnmarshalJSON(b []byte) error {
 	var tmp struct {
-		ExecutorType string `json:"executor"`
+		ExecutorType string                       `json:"executor"`
+		Matrix       map[string][]json.RawMessage `json:"matrix"`
 	}
 	err := json.Unmarshal(b, &tmp)
-	*pc = protoExecutorConfig{tmp.ExecutorType, b}
+	*pc = protoExecutorConfig{tmp.ExecutorType, b, tmp.Matrix}
 	return err
 }
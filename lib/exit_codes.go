@@ -0,0 +1,48 @@
+package lib
+
+import (
+	"fmt"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+// ExitCodes are the custom exit codes of k6 for the outcomes of a test run,
+// so the CI pipelines can tell the regressions of the system under test apart
+// from the problems of the test or of the load generator. The outcomes without
+// a custom exit code keep the default one.
+type ExitCodes struct {
+	// The thresholds were crossed, during or at the end of the test run
+	ThresholdsCrossed null.Int `json:"thresholdsCrossed"`
+	// The script threw an exception
+	ScriptError null.Int `json:"scriptError"`
+	// The script aborted the test run with test.abort(), without its own code
+	ScriptAborted null.Int `json:"scriptAborted"`
+	// The user aborted the test run, with Ctrl+C or a signal
+	AbortedByUser null.Int `json:"abortedByUser"`
+	// The load generator was saturated, so the results can't be trusted; it
+	// takes precedence over the crossed thresholds and the baseline regressions
+	Saturated null.Int `json:"saturated"`
+	// The metrics regressed from the baseline
+	BaselineRegression null.Int `json:"baselineRegression"`
+}
+
+// Validate checks that the exit codes are valid, non-zero, process exit codes.
+func (ec ExitCodes) Validate() error {
+	codes := []struct {
+		name string
+		code null.Int
+	}{
+		{"thresholdsCrossed", ec.ThresholdsCrossed},
+		{"scriptError", ec.ScriptError},
+		{"scriptAborted", ec.ScriptAborted},
+		{"abortedByUser", ec.AbortedByUser},
+		{"saturated", ec.Saturated},
+		{"baselineRegression", ec.BaselineRegression},
+	}
+	for _, c := range codes {
+		if c.code.Valid && (c.code.Int64 < 1 || c.code.Int64 > 255) {
+			return fmt.Errorf("the exit code of %s must be between 1 and 255, not %d", c.name, c.code.Int64)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// Fault describes a fault that is injected into a share of the requests for
+// hosts matching the Host pattern, of the requests with all of the specified
+// Tags, or of the requests matching both criteria, to test how the scripts
+// handle the failures, or to add noise to the traffic.
+//
+// The faulted requests are delayed, if the Delay is set, and then dropped,
+// failing without being sent, or responded with the Status, without being
+// sent either, if they're set.
+type Fault struct {
+	Host   null.String        `json:"host"`
+	Tags   map[string]string  `json:"tags"`
+	Rate   null.Float         `json:"rate"`
+	Delay  types.NullDuration `json:"delay"`
+	Drop   null.Bool          `json:"drop"`
+	Status null.Int           `json:"status"`
+}
+
+// Validate checks if the fault makes sense.
+func (f Fault) Validate() error {
+	if !f.Host.Valid && len(f.Tags) == 0 {
+		return errors.New("either a host pattern or tags need to be specified")
+	}
+	if !f.Rate.Valid || !(f.Rate.Float64 > 0 && f.Rate.Float64 <= 1) {
+		return errors.New("the rate value needs to be more than 0 and at most 1")
+	}
+	if f.Delay.Duration < 0 {
+		return errors.New("the delay value can't be negative")
+	}
+	if f.Drop.Bool && f.Status.Valid {
+		return errors.New("the request can't be both dropped and responded with a status")
+	}
+	if f.Status.Valid && (f.Status.Int64 < 100 || f.Status.Int64 > 599) {
+		return fmt.Errorf("invalid status value %d", f.Status.Int64)
+	}
+	if f.Delay.Duration == 0 && !f.Drop.Bool && !f.Status.Valid {
+		return errors.New("either a delay, drop or a status need to be specified")
+	}
+	return nil
+}
+
+// DelayDuration returns how long the faulted requests are delayed.
+func (f *Fault) DelayDuration() time.Duration {
+	return time.Duration(f.Delay.Duration)
+}
+
+type faultRule struct {
+	Fault
+	hosts *types.HostnameTrie
+}
+
+func (r *faultRule) matches(host string, tags *metrics.TagSet) bool {
+	if r.hosts != nil {
+		if _, ok := r.hosts.Contains(host); !ok {
+			return false
+		}
+	}
+	for name, value := range r.Tags {
+		if v, ok := tags.Get(name); !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// FaultInjector injects a set of Fault rules into the requests. It's shared
+// between all VUs, so the rates apply to the requests of the whole k6
+// instance.
+type FaultInjector struct {
+	rules []*faultRule
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewFaultInjector returns the FaultInjector for the given faults, or nil if
+// there are no faults.
+func NewFaultInjector(faults []Fault) (*FaultInjector, error) {
+	if len(faults) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+	fi := &FaultInjector{
+		rules: make([]*faultRule, 0, len(faults)),
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+	}
+	for i, fault := range faults {
+		if err := fault.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid fault %d: %w", i, err)
+		}
+		rule := &faultRule{Fault: fault}
+		if fault.Host.Valid {
+			hosts, err := types.NewHostnameTrie([]string{fault.Host.String})
+			if err != nil {
+				return nil, fmt.Errorf("invalid fault %d: %w", i, err)
+			}
+			rule.hosts = hosts
+		}
+		fi.rules = append(fi.rules, rule)
+	}
+	return fi, nil
+}
+
+// Pick returns the fault that is injected into a request for the given host
+// and with the given tags, or nil if the request isn't faulted. The faults are
+// drawn in order, so the first one that matches and hits its rate is injected.
+func (fi *FaultInjector) Pick(host string, tags *metrics.TagSet) *Fault {
+	if fi == nil {
+		return nil
+	}
+	for _, rule := range fi.rules {
+		if !rule.matches(host, tags) {
+			continue
+		}
+		fi.mu.Lock()
+		hit := fi.rand.Float64() < rule.Rate.Float64
+		fi.mu.Unlock()
+		if hit {
+			return &rule.Fault
+		}
+	}
+	return nil
+}
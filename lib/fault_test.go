@@ -0,0 +1,69 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func TestNewFaultInjector(t *testing.T) {
+	t.Parallel()
+
+	fi, err := NewFaultInjector(nil)
+	require.NoError(t, err)
+	assert.Nil(t, fi)
+	assert.Nil(t, fi.Pick("example.com", nil))
+
+	host := null.StringFrom("example.com")
+	invalid := []Fault{
+		{Rate: null.FloatFrom(0.1), Drop: null.BoolFrom(true)},
+		{Host: host, Drop: null.BoolFrom(true)},
+		{Host: host, Rate: null.FloatFrom(0), Drop: null.BoolFrom(true)},
+		{Host: host, Rate: null.FloatFrom(1.1), Drop: null.BoolFrom(true)},
+		{Host: host, Rate: null.FloatFrom(0.1)},
+		{Host: host, Rate: null.FloatFrom(0.1), Delay: types.NullDurationFrom(-time.Second)},
+		{Host: host, Rate: null.FloatFrom(0.1), Drop: null.BoolFrom(true), Status: null.IntFrom(503)},
+		{Host: host, Rate: null.FloatFrom(0.1), Status: null.IntFrom(1000)},
+		{Host: null.StringFrom("exa*mple.com"), Rate: null.FloatFrom(0.1), Drop: null.BoolFrom(true)},
+	}
+	for _, fault := range invalid {
+		_, err := NewFaultInjector([]Fault{fault})
+		assert.Error(t, err, fault)
+	}
+}
+
+func TestFaultInjectorPick(t *testing.T) {
+	t.Parallel()
+
+	faults := []Fault{
+		{Host: null.StringFrom("*.example.com"), Rate: null.FloatFrom(1), Status: null.IntFrom(503)},
+		{Tags: map[string]string{"dependency": "payments"}, Rate: null.FloatFrom(1), Drop: null.BoolFrom(true)},
+		{Host: null.StringFrom("k6.io"), Rate: null.FloatFrom(0.5), Delay: types.NullDurationFrom(time.Second)},
+	}
+	fi, err := NewFaultInjector(faults)
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	noTags := registry.RootTagSet()
+	paymentTags := noTags.With("dependency", "payments")
+
+	assert.Equal(t, &faults[0], fi.Pick("a.example.com", noTags))
+	assert.Equal(t, &faults[0], fi.Pick("a.example.com", paymentTags), "the first matching fault is injected")
+	assert.Equal(t, &faults[1], fi.Pick("test.k6.io", paymentTags))
+	assert.Nil(t, fi.Pick("test.k6.io", noTags))
+
+	var faulted int
+	for i := 0; i < 1000; i++ {
+		if fault := fi.Pick("k6.io", noTags); fault != nil {
+			assert.Equal(t, time.Second, fault.DelayDuration())
+			faulted++
+		}
+	}
+	assert.InDelta(t, 500, faulted, 100)
+}
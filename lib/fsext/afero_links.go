@@ -54,3 +54,8 @@ func IsDir(fs Fs, path string) (bool, error) {
 	// TODO move fix here
 	return afero.IsDir(fs, path)
 }
+
+// Glob returns the names of all files matching the pattern or nil if there is no matching file
+func Glob(fs Fs, pattern string) ([]string, error) {
+	return afero.Glob(fs, pattern)
+}
@@ -0,0 +1,26 @@
+package lib
+
+import "encoding/json"
+
+// Identity is the virtual identity, like credentials or a device profile,
+// that is assigned to a VU from the identities of a scenario.
+type Identity struct {
+	// The scenario, and the index of the identity in its identities.
+	Scenario string
+	Index    int
+	// The identity, as JSON.
+	Data json.RawMessage
+}
+
+// AssignIdentity returns the identity of the VU with the global ID from the
+// identities of a scenario, nil if there aren't any. The VUs get the
+// identities in order, and they're reused if there are more VUs than
+// identities. The global IDs are unique across all the instances, so the
+// identities are assigned stably across the execution segments.
+func AssignIdentity(scenario string, identities []json.RawMessage, vuIDGlobal uint64) *Identity {
+	if len(identities) == 0 || vuIDGlobal == 0 {
+		return nil
+	}
+	index := int((vuIDGlobal - 1) % uint64(len(identities)))
+	return &Identity{Scenario: scenario, Index: index, Data: identities[index]}
+}
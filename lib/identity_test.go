@@ -0,0 +1,25 @@
+package lib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignIdentity(t *testing.T) {
+	t.Parallel()
+
+	identities := []json.RawMessage{json.RawMessage(`{"user":"a"}`), json.RawMessage(`{"user":"b"}`)}
+	assert.Nil(t, AssignIdentity("users", nil, 1))
+	assert.Nil(t, AssignIdentity("users", identities, 0))
+	assert.Equal(t,
+		&Identity{Scenario: "users", Index: 0, Data: identities[0]},
+		AssignIdentity("users", identities, 1))
+	assert.Equal(t,
+		&Identity{Scenario: "users", Index: 1, Data: identities[1]},
+		AssignIdentity("users", identities, 2))
+	assert.Equal(t,
+		&Identity{Scenario: "users", Index: 0, Data: identities[0]},
+		AssignIdentity("users", identities, 3))
+}
@@ -0,0 +1,316 @@
+// Package jsonschema validates JSON values against a subset of JSON Schema,
+// which includes the keywords that OpenAPI specifications use for the schemas
+// of their responses.
+//
+// The supported keywords are type, nullable, enum, const, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern,
+// minItems, maxItems, items, required, properties, additionalProperties,
+// allOf, anyOf, oneOf and local $ref links, like #/components/schemas/User.
+// The rest of them, like format, are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxRefDepth limits how many $ref links are followed for a single value,
+// so recursive schemas can't loop forever.
+const maxRefDepth = 32
+
+// Violation is a constraint of the schema that a value doesn't satisfy.
+type Violation struct {
+	// Path is the path of the invalid value, like $.users[0].id
+	Path string
+	// Keyword is the keyword of the violated constraint, like type
+	Keyword string
+	Message string
+}
+
+// Error returns the message of the violation, which includes its path.
+func (v Violation) Error() string {
+	return v.Message
+}
+
+// Validator validates values against schemas, which can link to the other
+// schemas in the root document.
+type Validator struct {
+	root interface{}
+}
+
+// NewValidator returns a validator whose schemas can have $ref links to the
+// root document, e.g. an OpenAPI specification. The root, like the schemas
+// and the values, has to be in the form that encoding/json unmarshals to.
+func NewValidator(root interface{}) *Validator {
+	return &Validator{root: root}
+}
+
+// Validate validates the value against the schema and returns all of the
+// violations, sorted by their paths.
+func (v *Validator) Validate(schema, value interface{}) []Violation {
+	var violations []Violation
+	v.validate(schema, value, "$", 0, &violations)
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	return violations
+}
+
+// Validate validates the value against a standalone schema.
+func Validate(schema, value interface{}) []Violation {
+	return NewValidator(schema).Validate(schema, value)
+}
+
+//nolint:funlen,gocognit,cyclop,gocyclo
+func (v *Validator) validate(schema, value interface{}, path string, depth int, violations *[]Violation) {
+	add := func(keyword, format string, args ...interface{}) {
+		*violations = append(*violations, Violation{
+			Path: path, Keyword: keyword, Message: path + " " + fmt.Sprintf(format, args...),
+		})
+	}
+
+	if b, ok := schema.(bool); ok {
+		if !b {
+			add("false", "isn't allowed")
+		}
+		return
+	}
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		add("schema", "has an invalid schema, it has to be an object")
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			add("$ref", "has too many nested $ref links")
+			return
+		}
+		resolved, err := v.resolve(ref)
+		if err != nil {
+			add("$ref", "has an invalid schema: %s", err)
+			return
+		}
+		v.validate(resolved, value, path, depth+1, violations)
+		return
+	}
+
+	if value == nil && s["nullable"] == true {
+		return
+	}
+	if t, ok := s["type"]; ok {
+		if !matchesType(t, value) {
+			add("type", "has to be of type %s, but it's %s", describeTypes(t), jsonType(value))
+			return
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("enum", "has to be one of %s, but it's %s", Describe(enum), Describe(value))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		add("const", "has to be %s, but it's %s", Describe(c), Describe(value))
+	}
+
+	for _, sub := range asSlice(s["allOf"]) {
+		v.validate(sub, value, path, depth, violations)
+	}
+	if anyOf := asSlice(s["anyOf"]); len(anyOf) > 0 && v.countMatching(anyOf, value, path, depth) == 0 {
+		add("anyOf", "has to match at least one of the anyOf schemas")
+	}
+	if oneOf := asSlice(s["oneOf"]); len(oneOf) > 0 {
+		if n := v.countMatching(oneOf, value, path, depth); n != 1 {
+			add("oneOf", "has to match exactly one of the oneOf schemas, but it matches %d", n)
+		}
+	}
+
+	switch val := value.(type) {
+	case float64:
+		if limit, ok := s["minimum"].(float64); ok && val < limit {
+			add("minimum", "has to be at least %v, but it's %v", limit, val)
+		}
+		if limit, ok := s["maximum"].(float64); ok && val > limit {
+			add("maximum", "has to be at most %v, but it's %v", limit, val)
+		}
+		if limit, ok := s["exclusiveMinimum"].(float64); ok && val <= limit {
+			add("exclusiveMinimum", "has to be more than %v, but it's %v", limit, val)
+		}
+		if limit, ok := s["exclusiveMaximum"].(float64); ok && val >= limit {
+			add("exclusiveMaximum", "has to be less than %v, but it's %v", limit, val)
+		}
+	case string:
+		length := float64(len([]rune(val)))
+		if limit, ok := s["minLength"].(float64); ok && length < limit {
+			add("minLength", "has to be at least %v characters long, but it's %v", limit, length)
+		}
+		if limit, ok := s["maxLength"].(float64); ok && length > limit {
+			add("maxLength", "has to be at most %v characters long, but it's %v", limit, length)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			switch {
+			case err != nil:
+				add("pattern", "has an invalid pattern: %s", err)
+			case !re.MatchString(val):
+				add("pattern", "has to match %q, but it's %q", pattern, val)
+			}
+		}
+	case []interface{}:
+		length := float64(len(val))
+		if limit, ok := s["minItems"].(float64); ok && length < limit {
+			add("minItems", "has to have at least %v items, but it has %v", limit, length)
+		}
+		if limit, ok := s["maxItems"].(float64); ok && length > limit {
+			add("maxItems", "has to have at most %v items, but it has %v", limit, length)
+		}
+		if items, ok := s["items"]; ok {
+			for i, item := range val {
+				v.validate(items, item, path+"["+strconv.Itoa(i)+"]", depth, violations)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range asSlice(s["required"]) {
+			if n, ok := name.(string); ok {
+				if _, has := val[n]; !has {
+					*violations = append(*violations, Violation{
+						Path: path + "." + n, Keyword: "required", Message: path + "." + n + " is required",
+					})
+				}
+			}
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		for name, propValue := range val {
+			propPath := path + "." + name
+			if propSchema, ok := properties[name]; ok {
+				v.validate(propSchema, propValue, propPath, depth, violations)
+			} else if additional, ok := s["additionalProperties"]; ok {
+				v.validate(additional, propValue, propPath, depth, violations)
+			}
+		}
+	}
+}
+
+func (v *Validator) countMatching(schemas []interface{}, value interface{}, path string, depth int) int {
+	n := 0
+	for _, sub := range schemas {
+		var violations []Violation
+		v.validate(sub, value, path, depth, &violations)
+		if len(violations) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// resolve returns the schema that a local $ref, a JSON pointer to the root
+// document, links to.
+func (v *Validator) resolve(ref string) (interface{}, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("only local $ref links are supported, not %q", ref)
+	}
+	current := v.root
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch c := current.(type) {
+		case map[string]interface{}:
+			current, ok = c[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			ok = err == nil && i >= 0 && i < len(c)
+			if ok {
+				current = c[i]
+			}
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, fmt.Errorf("the $ref %q doesn't exist", ref)
+		}
+	}
+	return current, nil
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func matchesType(schemaType interface{}, value interface{}) bool {
+	actual := jsonType(value)
+	for _, t := range typeNames(schemaType) {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeNames(schemaType interface{}) []string {
+	switch t := schemaType.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		names := make([]string, 0, len(t))
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	default:
+		return nil
+	}
+}
+
+func describeTypes(schemaType interface{}) string {
+	return strings.Join(typeNames(schemaType), " or ")
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// Describe returns the value in JSON, shortened for the messages.
+func Describe(value interface{}) string {
+	const maxLength = 100
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	if len(data) > maxLength {
+		return string(data[:maxLength]) + "..."
+	}
+	return string(data)
+}
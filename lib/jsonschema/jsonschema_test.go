@@ -0,0 +1,113 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unmarshal(t *testing.T, data string) interface{} {
+	t.Helper()
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &v))
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		schema, value string
+		exp           []string
+	}{
+		{`{"type": "integer"}`, `1`, nil},
+		{`{"type": "integer"}`, `1.5`, []string{"$ has to be of type integer, but it's number"}},
+		{`{"type": "number"}`, `1`, nil},
+		{`{"type": "string", "nullable": true}`, `null`, nil},
+		{`{"type": ["string", "null"]}`, `null`, nil},
+		{`{"const": "a"}`, `"b"`, []string{`$ has to be "a", but it's "b"`}},
+		{`{"exclusiveMaximum": 10}`, `10`, []string{"$ has to be less than 10, but it's 10"}},
+		{`{"maxLength": 2}`, `"äöü"`, []string{"$ has to be at most 2 characters long, but it's 3"}},
+		{`{"pattern": "["}`, `"a"`, []string{"$ has an invalid pattern: error parsing regexp: missing closing ]: `[`"}},
+		{`{"allOf": [{"minimum": 1}, {"maximum": 5}]}`, `7`, []string{"$ has to be at most 5, but it's 7"}},
+		{`{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `1`, nil},
+		{
+			`{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `true`,
+			[]string{"$ has to match at least one of the anyOf schemas"},
+		},
+		{
+			`{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `1`,
+			[]string{"$ has to match exactly one of the oneOf schemas, but it matches 2"},
+		},
+		{`false`, `1`, []string{"$ isn't allowed"}},
+		{
+			`{"type": "object", "required": ["a", "b"], "properties": {"c": {"type": "array", "items": {"type": "string"}}}}`,
+			`{"c": ["x", 1, 2]}`,
+			[]string{"$.a is required", "$.b is required", "$.c[1] has to be of type string, but it's integer",
+				"$.c[2] has to be of type string, but it's integer"},
+		},
+		{`{"additionalProperties": {"type": "boolean"}}`, `{"a": true, "b": 1}`,
+			[]string{"$.b has to be of type boolean, but it's integer"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.schema+" "+tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			violations := Validate(unmarshal(t, tc.schema), unmarshal(t, tc.value))
+			var messages []string
+			for _, v := range violations {
+				messages = append(messages, v.Error())
+			}
+			assert.Equal(t, tc.exp, messages)
+		})
+	}
+}
+
+func TestValidateRefs(t *testing.T) {
+	t.Parallel()
+
+	root := unmarshal(t, `{
+		"components": {
+			"schemas": {
+				"User": {
+					"type": "object",
+					"required": ["id"],
+					"properties": {
+						"id": {"type": "integer"},
+						"friends": {"type": "array", "items": {"$ref": "#/components/schemas/User"}}
+					}
+				},
+				"Loop": {"$ref": "#/components/schemas/Loop"}
+			}
+		}
+	}`)
+	v := NewValidator(root)
+
+	violations := v.Validate(
+		unmarshal(t, `{"$ref": "#/components/schemas/User"}`),
+		unmarshal(t, `{"id": 1, "friends": [{"id": 2}, {"id": "3"}, {"friends": []}]}`),
+	)
+	require.Len(t, violations, 2)
+	assert.Equal(t, Violation{
+		Path: "$.friends[1].id", Keyword: "type", Message: "$.friends[1].id has to be of type integer, but it's string",
+	}, violations[0])
+	assert.Equal(t, Violation{
+		Path: "$.friends[2].id", Keyword: "required", Message: "$.friends[2].id is required",
+	}, violations[1])
+
+	violations = v.Validate(unmarshal(t, `{"$ref": "#/components/schemas/Missing"}`), 1.0)
+	require.Len(t, violations, 1)
+	assert.Equal(t, `$ has an invalid schema: the $ref "#/components/schemas/Missing" doesn't exist`, violations[0].Message)
+
+	violations = v.Validate(unmarshal(t, `{"$ref": "#/components/schemas/Loop"}`), 1.0)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "$ has too many nested $ref links", violations[0].Message)
+
+	violations = v.Validate(unmarshal(t, `{"$ref": "other.json#/User"}`), 1.0)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "$ref", violations[0].Keyword)
+}
@@ -0,0 +1,90 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// memoryPressure is the fraction of the memory budget over which the new
+// iterations are delayed.
+const memoryPressure = 0.9
+
+// MemoryBudget protects the k6 process from being killed by the OOM killer,
+// which loses all of the results of a test run. It's fed with the memory usage
+// of the process by the execution scheduler, and once it gets close to the
+// budget, the VUs wait before starting new iterations until it's lower again,
+// while over the budget the test run has to be aborted gracefully, so the
+// results collected so far are still summarized and flushed to the outputs.
+//
+// All of its methods are safe to call on a nil MemoryBudget.
+type MemoryBudget struct {
+	Max int64
+
+	logger logrus.FieldLogger
+
+	mu sync.Mutex
+	// relieved is closed when the memory usage drops below the pressure
+	// threshold again, it's nil when the usage is below it
+	relieved chan struct{}
+}
+
+// NewMemoryBudget returns a MemoryBudget of max bytes.
+func NewMemoryBudget(max int64, logger logrus.FieldLogger) *MemoryBudget {
+	return &MemoryBudget{Max: max, logger: logger}
+}
+
+// Observe records the memory usage of the process, in bytes, and returns an
+// error if it's over the budget.
+func (mb *MemoryBudget) Observe(usage int64) error {
+	if mb == nil {
+		return nil
+	}
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	switch pressure := float64(usage) >= memoryPressure*float64(mb.Max); {
+	case pressure && mb.relieved == nil:
+		mb.relieved = make(chan struct{})
+		if mb.logger != nil {
+			mb.logger.Warnf("k6 uses %s of memory, close to the maxMemory limit of %s, so the new iterations "+
+				"are delayed until it drops", formatMemory(usage), formatMemory(mb.Max))
+		}
+	case !pressure && mb.relieved != nil:
+		close(mb.relieved)
+		mb.relieved = nil
+	}
+
+	if usage > mb.Max {
+		return fmt.Errorf("k6 used %s of memory, over the maxMemory limit of %s",
+			formatMemory(usage), formatMemory(mb.Max))
+	}
+	return nil
+}
+
+// Wait blocks while the memory usage is close to the budget, and returns false
+// if the context was done before it dropped.
+func (mb *MemoryBudget) Wait(ctx context.Context) bool {
+	if mb == nil {
+		return true
+	}
+	mb.mu.Lock()
+	relieved := mb.relieved
+	mb.mu.Unlock()
+	if relieved == nil {
+		return true
+	}
+
+	select {
+	case <-relieved:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func formatMemory(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/1e6)
+}
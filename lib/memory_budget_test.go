@@ -0,0 +1,49 @@
+package lib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	t.Parallel()
+
+	logger, hook := test.NewNullLogger()
+	mb := NewMemoryBudget(1000, logger)
+	require.NoError(t, mb.Observe(100))
+	assert.True(t, mb.Wait(context.Background()))
+	assert.Empty(t, hook.AllEntries())
+
+	require.NoError(t, mb.Observe(950))
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, "k6 uses 0.0 MB of memory, close to the maxMemory limit of 0.0 MB, so the new iterations "+
+		"are delayed until it drops", hook.LastEntry().Message)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, mb.Wait(ctx))
+
+	waited := make(chan bool)
+	go func() {
+		waited <- mb.Wait(context.Background())
+	}()
+	require.NoError(t, mb.Observe(960))
+	require.NoError(t, mb.Observe(500))
+	assert.True(t, <-waited)
+	assert.Len(t, hook.AllEntries(), 1)
+
+	assert.EqualError(t, mb.Observe(2e6), "k6 used 2.0 MB of memory, over the maxMemory limit of 0.0 MB")
+}
+
+func TestMemoryBudgetNil(t *testing.T) {
+	t.Parallel()
+
+	var mb *MemoryBudget
+	require.NoError(t, mb.Observe(1<<40))
+	assert.True(t, mb.Wait(context.Background()))
+}
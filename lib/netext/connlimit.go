@@ -0,0 +1,78 @@
+package netext
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"go.k6.io/k6/lib"
+)
+
+// LimitedDialer wraps a lib.DialContexter and restricts the number of
+// connections established through it that can be open at the same time.
+// When all of the slots are taken, the idle connections are closed with the
+// function set by SetIdleCloser, if any, and dialing blocks until a
+// previously opened connection is closed or until the supplied context is
+// done.
+type LimitedDialer struct {
+	lib.DialContexter
+	slots     chan struct{}
+	closeIdle func()
+}
+
+// NewLimitedDialer returns a new LimitedDialer that allows at most limit
+// simultaneously open connections. If limit is not positive, the passed
+// dialer is returned as it is.
+func NewLimitedDialer(dialer lib.DialContexter, limit int) lib.DialContexter {
+	if limit <= 0 {
+		return dialer
+	}
+	return &LimitedDialer{
+		DialContexter: dialer,
+		slots:         make(chan struct{}, limit),
+	}
+}
+
+// SetIdleCloser sets the function that closes the idle connections of the
+// transports that use the dialer, like http.Transport.CloseIdleConnections,
+// so the kept-alive connections don't hold their slots forever.
+func (d *LimitedDialer) SetIdleCloser(closeIdle func()) {
+	d.closeIdle = closeIdle
+}
+
+// DialContext waits for a free connection slot and dials with the wrapped dialer.
+func (d *LimitedDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	select {
+	case d.slots <- struct{}{}:
+	default:
+		// the idle connections release their slots when they are closed
+		if d.closeIdle != nil {
+			d.closeIdle()
+		}
+		select {
+		case d.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	conn, err := d.DialContexter.DialContext(ctx, network, addr)
+	if err != nil {
+		<-d.slots
+		return nil, err
+	}
+	return &limitedConn{Conn: conn, release: func() { <-d.slots }}, nil
+}
+
+// limitedConn releases its slot in the LimitedDialer when it's closed.
+type limitedConn struct {
+	net.Conn
+	release func()
+	once    sync.Once
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
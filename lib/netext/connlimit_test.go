@@ -0,0 +1,80 @@
+package netext
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pipeDialer struct{}
+
+func (pipeDialer) DialContext(_ context.Context, _, _ string) (net.Conn, error) {
+	c, _ := net.Pipe()
+	return c, nil
+}
+
+func TestLimitedDialer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Unlimited", func(t *testing.T) {
+		t.Parallel()
+		d := pipeDialer{}
+		assert.Equal(t, d, NewLimitedDialer(d, 0))
+	})
+
+	t.Run("Limited", func(t *testing.T) {
+		t.Parallel()
+		d := NewLimitedDialer(pipeDialer{}, 1)
+
+		conn, err := d.DialContext(context.Background(), "tcp", "example.com:80")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = d.DialContext(ctx, "tcp", "example.com:80")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.NoError(t, conn.Close())
+		require.NoError(t, conn.Close()) // closing twice doesn't release two slots
+
+		conn, err = d.DialContext(context.Background(), "tcp", "example.com:80")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	})
+
+	t.Run("MoreHostsThanSlots", func(t *testing.T) {
+		t.Parallel()
+		handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		})
+		srv1, srv2 := httptest.NewServer(handler), httptest.NewServer(handler)
+		defer srv1.Close()
+		defer srv2.Close()
+
+		d := NewLimitedDialer(&net.Dialer{}, 1)
+		transport := &http.Transport{DialContext: d.DialContext}
+		defer transport.CloseIdleConnections()
+		d.(*LimitedDialer).SetIdleCloser(transport.CloseIdleConnections) //nolint:forcetypeassert
+		client := &http.Client{Transport: transport}
+
+		// the kept-alive connection to a host doesn't block the ones to the others
+		for _, url := range []string{srv1.URL, srv2.URL, srv1.URL} {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			_, err = io.Copy(io.Discard, resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			cancel()
+		}
+	})
+}
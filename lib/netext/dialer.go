@@ -22,9 +22,14 @@ type Dialer struct {
 	Blacklist        []*lib.IPNet
 	BlockedHostnames *types.HostnameTrie
 	Hosts            *types.Hosts
+	// Returns the local IP of each new connection, instead of the LocalAddr
+	// of the dialer, if it's set.
+	GetLocalIP func() net.IP
 
 	BytesRead    int64
 	BytesWritten int64
+
+	shaper atomic.Pointer[Shaper]
 }
 
 // NewDialer constructs a new Dialer with the given DNS resolver.
@@ -57,15 +62,86 @@ func (b BlockedHostError) Error() string {
 
 // DialContext wraps the net.Dialer.DialContext and handles the k6 specifics
 func (d *Dialer) DialContext(ctx context.Context, proto, addr string) (net.Conn, error) {
-	dialAddr, err := d.getDialAddr(addr)
+	return d.dial(ctx, proto, addr, nil, d.Resolver, d.shaper.Load())
+}
+
+// SetShaper sets the shaper of the traffic of the new connections, nil to not
+// shape it, and returns the previous one.
+func (d *Dialer) SetShaper(shaper *Shaper) *Shaper {
+	return d.shaper.Swap(shaper)
+}
+
+// WithDNS returns a dialer like d, e.g. for a client with its own DNS
+// configuration, that resolves the hostnames with the hosts, before the ones
+// of d, and with the resolver instead of the one of d, if they're set. The
+// data of its connections is counted in d.
+func (d *Dialer) WithDNS(hosts *types.Hosts, resolver Resolver) lib.DialContexter {
+	if resolver == nil {
+		resolver = d.Resolver
+	}
+	return &clientDialer{dialer: d, hosts: hosts, resolver: resolver}
+}
+
+// ClientDialer returns the dialer of a client, from the dialer of the VU, with
+// the DNS configuration and the traffic shaping of the client, if they're set.
+func ClientDialer(
+	vuDialer lib.DialContexter, vuDNS types.DNSConfig, dns *ClientDNS, shaper *Shaper,
+) (lib.DialContexter, error) {
+	dialer := vuDialer
+	if dns != nil {
+		var err error
+		if dialer, err = dns.Dialer(vuDialer, vuDNS); err != nil {
+			return nil, err
+		}
+	}
+	if shaper != nil {
+		return WithShaper(dialer, shaper)
+	}
+	return dialer, nil
+}
+
+// clientDialer dials with the configuration of a client, see WithDNS() and
+// WithShaper(). The traffic is shaped by the shaper of the dialer if the
+// client doesn't have one.
+type clientDialer struct {
+	dialer   *Dialer
+	hosts    *types.Hosts
+	resolver Resolver
+	shaper   *Shaper
+}
+
+func (d *clientDialer) DialContext(ctx context.Context, proto, addr string) (net.Conn, error) {
+	shaper := d.shaper
+	if shaper == nil {
+		shaper = d.dialer.shaper.Load()
+	}
+	return d.dialer.dial(ctx, proto, addr, d.hosts, d.resolver, shaper)
+}
+
+func (d *Dialer) dial(
+	ctx context.Context, proto, addr string, hosts *types.Hosts, resolver Resolver, shaper *Shaper,
+) (net.Conn, error) {
+	dialAddr, err := d.getDialAddrWith(addr, hosts, resolver)
 	if err != nil {
 		return nil, err
 	}
-	conn, err := d.Dialer.DialContext(ctx, proto, dialAddr)
+	netDialer := &d.Dialer
+	if d.GetLocalIP != nil {
+		localDialer := d.Dialer
+		localDialer.LocalAddr = &net.TCPAddr{IP: d.GetLocalIP()}
+		netDialer = &localDialer
+	}
+	if shaper != nil {
+		netDialer = shaper.netDialer(*netDialer)
+	}
+	conn, err := netDialer.DialContext(ctx, proto, dialAddr)
 	if err != nil {
 		return nil, err
 	}
 	conn = &Conn{conn, &d.BytesRead, &d.BytesWritten}
+	if shaper != nil {
+		conn = &shapedConn{Conn: conn, shaper: shaper}
+	}
 	return conn, err
 }
 
@@ -134,7 +210,11 @@ func (d *Dialer) GetTrail(
 }
 
 func (d *Dialer) getDialAddr(addr string) (string, error) {
-	remote, err := d.findRemote(addr)
+	return d.getDialAddrWith(addr, nil, d.Resolver)
+}
+
+func (d *Dialer) getDialAddrWith(addr string, hosts *types.Hosts, resolver Resolver) (string, error) {
+	remote, err := d.findRemote(addr, hosts, resolver)
 	if err != nil {
 		return "", err
 	}
@@ -148,7 +228,7 @@ func (d *Dialer) getDialAddr(addr string) (string, error) {
 	return remote.String(), nil
 }
 
-func (d *Dialer) findRemote(addr string) (*types.Host, error) {
+func (d *Dialer) findRemote(addr string, hosts *types.Hosts, resolver Resolver) (*types.Host, error) {
 	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
@@ -161,8 +241,11 @@ func (d *Dialer) findRemote(addr string) (*types.Host, error) {
 		}
 	}
 
-	if d.Hosts != nil {
-		remote, e := d.getConfiguredHost(addr, host, port)
+	for _, hosts := range [...]*types.Hosts{hosts, d.Hosts} {
+		if hosts == nil {
+			continue
+		}
+		remote, e := getConfiguredHost(hosts, addr, host, port)
 		if e != nil || remote != nil {
 			return remote, e
 		}
@@ -172,7 +255,7 @@ func (d *Dialer) findRemote(addr string) (*types.Host, error) {
 		return types.NewHost(ip, port)
 	}
 
-	ip, err = d.Resolver.LookupIP(host)
+	ip, err = resolver.LookupIP(host)
 	if err != nil {
 		return nil, err
 	}
@@ -184,12 +267,12 @@ func (d *Dialer) findRemote(addr string) (*types.Host, error) {
 	return types.NewHost(ip, port)
 }
 
-func (d *Dialer) getConfiguredHost(addr, host, port string) (*types.Host, error) {
-	if remote := d.Hosts.Match(addr); remote != nil {
+func getConfiguredHost(hosts *types.Hosts, addr, host, port string) (*types.Host, error) {
+	if remote := hosts.Match(addr); remote != nil {
 		return remote, nil
 	}
 
-	if remote := d.Hosts.Match(host); remote != nil {
+	if remote := hosts.Match(host); remote != nil {
 		if remote.Port != 0 || port == "" {
 			return remote, nil
 		}
@@ -1,6 +1,7 @@
 package netext
 
 import (
+	"context"
 	"net"
 	"testing"
 
@@ -148,3 +149,37 @@ func newResolver() *mockresolver.MockResolver {
 		},
 	)
 }
+
+func TestDialerGetLocalIP(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	remotes := make(chan string, 4)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			remotes <- c.RemoteAddr().(*net.TCPAddr).IP.String() //nolint:forcetypeassert
+			_ = c.Close()
+		}
+	}()
+
+	pool, err := types.NewIPPool("127.0.0.2-127.0.0.3")
+	require.NoError(t, err)
+	var next uint64
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	dialer.GetLocalIP = func() net.IP {
+		next++
+		return pool.GetIP(next - 1)
+	}
+	for _, expected := range []string{"127.0.0.2", "127.0.0.3", "127.0.0.2"} {
+		conn, err := dialer.DialContext(context.Background(), "tcp", ln.Addr().String())
+		require.NoError(t, err)
+		require.Equal(t, expected, <-remotes)
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,130 @@
+package netext
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+// ClientDNS is the DNS configuration of a client, like an HTTP or a gRPC
+// client, that overrides the one of its VU, e.g. to send its requests to a
+// blue or a green deployment, or directly to a pod.
+type ClientDNS struct {
+	// The overrides of the hostnames, before the ones of the hosts option.
+	Hosts *types.Hosts
+	// The address of the DNS server, instead of the ones of the system.
+	Server string
+	// The TTL, select and policy of the lookups, the ones of the VU if they
+	// aren't set.
+	DNS types.DNSConfig
+}
+
+// ParseClientDNS parses the hosts and the dns parameters of a client, as
+// exported from JS, like { "test.k6.io": "1.2.3.4" } and { server:
+// "10.0.0.2:53", ttl: "1m", select: "first", policy: "onlyIPv4" }.
+func ParseClientDNS(hosts, dns interface{}) (*ClientDNS, error) {
+	c := &ClientDNS{}
+	if hosts != nil {
+		data, err := json.Marshal(hosts)
+		if err != nil {
+			return nil, err
+		}
+		var nullHosts types.NullHosts
+		if err := json.Unmarshal(data, &nullHosts); err != nil {
+			return nil, fmt.Errorf("invalid hosts value: %w", err)
+		}
+		c.Hosts = nullHosts.Trie
+	}
+	if dns == nil {
+		return c, nil
+	}
+
+	params, ok := dns.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid dns value: '%#v', expected (optional) keys: server, ttl, select and policy", dns)
+	}
+	config := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		switch key {
+		case "server":
+			server, ok := value.(string)
+			if !ok || server == "" {
+				return nil, fmt.Errorf("invalid dns server value: '%#v', it needs to be an address", value)
+			}
+			if _, _, err := net.SplitHostPort(server); err != nil {
+				server = net.JoinHostPort(server, "53")
+			}
+			c.Server = server
+		case "ttl", "select", "policy":
+			config[key] = value
+		default:
+			return nil, fmt.Errorf("unknown dns parameter '%s'", key)
+		}
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.DNS); err != nil {
+		return nil, fmt.Errorf("invalid dns value: %w", err)
+	}
+	if c.DNS.TTL.Valid {
+		if _, err := types.ParseDNSTTL(c.DNS.TTL.String); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Dialer returns the dialer of the client, from the dialer and the DNS
+// configuration of the VU.
+func (c *ClientDNS) Dialer(vuDialer lib.DialContexter, vuDNS types.DNSConfig) (lib.DialContexter, error) {
+	base, ok := vuDialer.(*Dialer)
+	if !ok {
+		return nil, errors.New("the dialer of the VU can't be customized")
+	}
+	if c.Server == "" && !c.DNS.TTL.Valid && !c.DNS.Select.Valid && !c.DNS.Policy.Valid {
+		return base.WithDNS(c.Hosts, nil), nil
+	}
+
+	config := c.DNS
+	for _, fallback := range [...]types.DNSConfig{vuDNS, types.DefaultDNSConfig()} {
+		if !config.TTL.Valid {
+			config.TTL = fallback.TTL
+		}
+		if !config.Select.Valid {
+			config.Select = fallback.Select
+		}
+		if !config.Policy.Valid {
+			config.Policy = fallback.Policy
+		}
+	}
+	ttl, err := types.ParseDNSTTL(config.TTL.String)
+	if err != nil {
+		return nil, err
+	}
+	lookup := net.LookupIP
+	if c.Server != "" {
+		lookup = serverLookup(c.Server)
+	}
+	return base.WithDNS(c.Hosts, NewResolver(lookup, ttl, config.Select.DNSSelect, config.Policy.DNSPolicy)), nil
+}
+
+// serverLookup returns a lookup function that queries the DNS server.
+func serverLookup(server string) MultiResolver {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+	return func(host string) ([]net.IP, error) {
+		return resolver.LookupIP(context.Background(), "ip", host)
+	}
+}
@@ -0,0 +1,87 @@
+package netext
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/testutils/mockresolver"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestParseClientDNS(t *testing.T) {
+	t.Parallel()
+
+	c, err := ParseClientDNS(
+		map[string]interface{}{"blue.example.com": "10.0.0.1", "green.example.com:443": "10.0.0.2:8443"},
+		map[string]interface{}{"server": "10.0.0.53", "ttl": "1m", "policy": "onlyIPv4"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, &types.Host{IP: net.ParseIP("10.0.0.1")}, c.Hosts.Match("blue.example.com"))
+	assert.Equal(t, &types.Host{IP: net.ParseIP("10.0.0.2"), Port: 8443}, c.Hosts.Match("green.example.com:443"))
+	assert.Equal(t, "10.0.0.53:53", c.Server)
+	assert.Equal(t, types.DNSConfig{
+		TTL:    null.StringFrom("1m"),
+		Policy: types.NullDNSPolicy{DNSPolicy: types.DNSonlyIPv4, Valid: true},
+	}, c.DNS)
+
+	c, err = ParseClientDNS(nil, map[string]interface{}{"server": "[::1]:5353"})
+	require.NoError(t, err)
+	assert.Nil(t, c.Hosts)
+	assert.Equal(t, "[::1]:5353", c.Server)
+
+	for _, dns := range []interface{}{
+		"1.1.1.1",
+		map[string]interface{}{"server": ""},
+		map[string]interface{}{"ttl": "-1"},
+		map[string]interface{}{"select": "any"},
+		map[string]interface{}{"timeout": "1s"},
+	} {
+		_, err := ParseClientDNS(nil, dns)
+		assert.Error(t, err, dns)
+	}
+}
+
+func TestClientDNSDialer(t *testing.T) {
+	t.Parallel()
+
+	vuHosts, err := types.NewHosts(map[string]types.Host{
+		"blue.example.com":  {IP: net.ParseIP("1.1.1.1")},
+		"green.example.com": {IP: net.ParseIP("2.2.2.2")},
+	})
+	require.NoError(t, err)
+	vuDialer := NewDialer(net.Dialer{}, mockresolver.New(map[string][]net.IP{
+		"example.com": {net.ParseIP("3.3.3.3")},
+	}))
+	vuDialer.Hosts = vuHosts
+
+	c, err := ParseClientDNS(map[string]interface{}{"blue.example.com": "10.0.0.1"}, nil)
+	require.NoError(t, err)
+	dialer, err := c.Dialer(vuDialer, types.DNSConfig{})
+	require.NoError(t, err)
+	d, ok := dialer.(*clientDialer)
+	require.True(t, ok)
+
+	for addr, expected := range map[string]string{
+		"blue.example.com:80":  "10.0.0.1:80", // the hosts of the client first
+		"green.example.com:80": "2.2.2.2:80",  // then the ones of the VU
+		"example.com:80":       "3.3.3.3:80",  // and the resolver of the VU
+	} {
+		dialAddr, err := vuDialer.getDialAddrWith(addr, d.hosts, d.resolver)
+		require.NoError(t, err)
+		assert.Equal(t, expected, dialAddr, addr)
+	}
+
+	// the client has its own resolver, if any of the DNS settings is set
+	c, err = ParseClientDNS(nil, map[string]interface{}{"select": "first"})
+	require.NoError(t, err)
+	dialer, err = c.Dialer(vuDialer, types.DNSConfig{})
+	require.NoError(t, err)
+	assert.NotEqual(t, vuDialer.Resolver, dialer.(*clientDialer).resolver) //nolint:forcetypeassert
+
+	_, err = c.Dialer(&LimitedDialer{}, types.DNSConfig{})
+	assert.EqualError(t, err, "the dialer of the VU can't be customized")
+}
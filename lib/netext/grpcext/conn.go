@@ -6,8 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 	"go.k6.io/k6/lib"
@@ -32,6 +32,8 @@ type Request struct {
 	MethodDescriptor protoreflect.MethodDescriptor
 	TagsAndMeta      *metrics.TagsAndMeta
 	Message          []byte
+	// The fault that is injected into the request, if any.
+	Fault *lib.Fault
 }
 
 // StreamRequest represents a gRPC stream request.
@@ -128,7 +130,13 @@ func (c *Conn) Invoke(
 	copts = append(copts, opts...)
 	copts = append(copts, grpc.Header(&header), grpc.Trailer(&trailer))
 
-	err := c.raw.Invoke(ctx, url, reqdm, resp, copts...)
+	var err error
+	if req.Fault != nil {
+		err = injectFault(ctx, req.Fault)
+	}
+	if err == nil {
+		err = c.raw.Invoke(ctx, url, reqdm, resp, copts...)
+	}
 
 	response := Response{
 		Headers:  header,
@@ -238,9 +246,10 @@ func (h statsHandler) HandleRPC(ctx context.Context, stat grpcstats.RPCStats) {
 		}
 	case *grpcstats.End:
 		if state.Options.SystemTags.Has(metrics.TagStatus) {
-			stateRPC.tagsAndMeta.SetSystemTagOrMeta(metrics.TagStatus, strconv.Itoa(int(status.Code(s.Error))))
+			stateRPC.tagsAndMeta.SetSystemTagOrMeta(metrics.TagStatus, metrics.IntTagValue(int(status.Code(s.Error))))
 		}
 
+		atomic.AddInt64(&state.Requests, 1)
 		metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
 			TimeSeries: metrics.TimeSeries{
 				Metric: state.BuiltinMetrics.GRPCReqDuration,
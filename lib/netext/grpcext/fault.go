@@ -0,0 +1,54 @@
+package grpcext
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.k6.io/k6/lib"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// faultStatusCodes maps the HTTP statuses of the faults to the gRPC codes,
+// like gRPC does for the responses without a gRPC status, e.g. of proxies.
+//
+//nolint:gochecknoglobals
+var faultStatusCodes = map[int64]codes.Code{
+	http.StatusBadRequest:         codes.Internal,
+	http.StatusUnauthorized:       codes.Unauthenticated,
+	http.StatusForbidden:          codes.PermissionDenied,
+	http.StatusNotFound:           codes.Unimplemented,
+	http.StatusTooManyRequests:    codes.Unavailable,
+	http.StatusBadGateway:         codes.Unavailable,
+	http.StatusServiceUnavailable: codes.Unavailable,
+	http.StatusGatewayTimeout:     codes.Unavailable,
+}
+
+// injectFault injects the fault into a request: it waits for its delay, and
+// it returns the error of the request if the fault drops it or responds with a
+// status, nil if the request is sent.
+func injectFault(ctx context.Context, fault *lib.Fault) error {
+	if delay := fault.DelayDuration(); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	switch {
+	case fault.Drop.Bool:
+		return status.Error(codes.Unavailable, "request dropped by an injected fault")
+	case fault.Status.Valid:
+		code, ok := faultStatusCodes[fault.Status.Int64]
+		if !ok {
+			code = codes.Unknown
+		}
+		return status.Errorf(code, "injected fault with the HTTP status %d", fault.Status.Int64)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,375 @@
+package httpext
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// Cache is an in-memory private HTTP cache, similar to the one every browser
+// has. It honors the Cache-Control, Expires, ETag and Last-Modified response
+// headers, so that repeated requests for the same resource can either be
+// served directly from the cache or be conditionally revalidated.
+//
+// It's safe for concurrent use, since a single VU can make multiple
+// asynchronous requests at the same time. The stored entries are never
+// modified, they are replaced when they are revalidated, so they can be read
+// without holding the lock.
+//
+// Like the browser caches, it's bounded: when it has too many entries, or
+// their bodies are too large, the least recently used ones are evicted.
+type Cache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element // of *cacheItem, in lru
+	lru        *list.List               // the most recently used first
+	size       int64
+	maxEntries int
+	maxSize    int64
+	now        func() time.Time
+}
+
+const (
+	// cacheMaxEntries is the maximum number of responses in a Cache.
+	cacheMaxEntries = 1000
+	// cacheMaxSize is the maximum total size, in bytes, of the bodies of the
+	// responses in a Cache.
+	cacheMaxSize = 16 << 20
+)
+
+// NewCache returns a new empty Cache.
+func NewCache() *Cache {
+	return &Cache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: cacheMaxEntries,
+		maxSize:    cacheMaxSize,
+		now:        time.Now,
+	}
+}
+
+type cacheItem struct {
+	key   string
+	entry *cacheEntry
+}
+
+type cacheEntry struct {
+	status      int
+	statusText  string
+	proto       string
+	header      http.Header
+	body        []byte
+	varyHeaders http.Header
+
+	storedAt  time.Time
+	initAge   time.Duration
+	freshness time.Duration
+}
+
+// cacheableStatuses are the status codes that are cacheable by default, see
+// https://www.rfc-editor.org/rfc/rfc9110#section-15.1
+//
+//nolint:gochecknoglobals
+var cacheableStatuses = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, arg, _ := strings.Cut(part, "=")
+			directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+		}
+	}
+	return directives
+}
+
+func parseSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// freshnessLifetime calculates for how long a response can be served from the
+// cache without revalidation, see https://www.rfc-editor.org/rfc/rfc9111#section-4.2.1
+func freshnessLifetime(header http.Header, cc map[string]string, storedAt time.Time) time.Duration {
+	if _, noCache := cc["no-cache"]; noCache {
+		return 0
+	}
+	if maxAge, ok := cc["max-age"]; ok {
+		lifetime, _ := parseSeconds(maxAge)
+		return lifetime
+	}
+
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = storedAt
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil || !expiresAt.After(date) {
+			return 0
+		}
+		return expiresAt.Sub(date)
+	}
+
+	// Heuristic freshness, as commonly implemented by browsers, see
+	// https://www.rfc-editor.org/rfc/rfc9111#section-4.2.2
+	if lastModified, err := http.ParseTime(header.Get("Last-Modified")); err == nil && date.After(lastModified) {
+		return date.Sub(lastModified) / 10
+	}
+	return 0
+}
+
+func (e *cacheEntry) isFresh(now time.Time) bool {
+	return e.initAge+now.Sub(e.storedAt) < e.freshness
+}
+
+func (e *cacheEntry) hasValidators() bool {
+	return e.header.Get("ETag") != "" || e.header.Get("Last-Modified") != ""
+}
+
+func (e *cacheEntry) matches(req *http.Request) bool {
+	for name, values := range e.varyHeaders {
+		if strings.Join(req.Header.Values(name), ", ") != strings.Join(values, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        e.statusText,
+		StatusCode:    e.status,
+		Proto:         e.proto,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+func newCacheEntry(req *http.Request, resp *http.Response, now time.Time) (*cacheEntry, bool) {
+	if req.Method != http.MethodGet || !cacheableStatuses[resp.StatusCode] {
+		return nil, false
+	}
+	if _, noStore := parseCacheControl(req.Header)["no-store"]; noStore {
+		return nil, false
+	}
+	cc := parseCacheControl(resp.Header)
+	if _, noStore := cc["no-store"]; noStore {
+		return nil, false
+	}
+
+	entry := &cacheEntry{
+		status:      resp.StatusCode,
+		statusText:  resp.Status,
+		proto:       resp.Proto,
+		header:      resp.Header.Clone(),
+		varyHeaders: make(http.Header),
+		storedAt:    now,
+		freshness:   freshnessLifetime(resp.Header, cc, now),
+	}
+	// Stored responses shouldn't set cookies every time they are served
+	entry.header.Del("Set-Cookie")
+	if age, ok := parseSeconds(resp.Header.Get("Age")); ok {
+		entry.initAge = age
+	}
+	for _, vary := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				entry.varyHeaders[name] = req.Header.Values(name)
+			}
+		}
+	}
+
+	if entry.freshness <= 0 && !entry.hasValidators() {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *Cache) get(key string, req *http.Request) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*cacheItem).entry //nolint:forcetypeassert
+	if !entry.matches(req) {
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return entry
+}
+
+func (c *Cache) set(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	if int64(len(entry.body)) > c.maxSize {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheItem{key: key, entry: entry})
+	c.size += int64(len(entry.body))
+	for c.lru.Len() > c.maxEntries || c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	item := c.lru.Remove(elem).(*cacheItem) //nolint:forcetypeassert
+	delete(c.entries, item.key)
+	c.size -= int64(len(item.entry.body))
+}
+
+// refresh replaces a stored entry with one updated with the headers of a 304
+// Not Modified response, see https://www.rfc-editor.org/rfc/rfc9111#section-4.3.4,
+// and returns it.
+func (c *Cache) refresh(key string, entry *cacheEntry, header http.Header) *cacheEntry {
+	updated := *entry
+	updated.header = entry.header.Clone()
+	for name, values := range header {
+		if name == "Content-Length" || name == "Set-Cookie" {
+			continue
+		}
+		updated.header[name] = values
+	}
+	updated.storedAt = c.now()
+	updated.initAge = 0
+	if age, ok := parseSeconds(updated.header.Get("Age")); ok {
+		updated.initAge = age
+	}
+	updated.freshness = freshnessLifetime(updated.header, parseCacheControl(updated.header), updated.storedAt)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// it's only replaced if it wasn't already, or evicted, in the meantime
+	if elem, ok := c.entries[key]; ok && elem.Value.(*cacheItem).entry == entry { //nolint:forcetypeassert
+		elem.Value = &cacheItem{key: key, entry: &updated}
+	}
+	return &updated
+}
+
+// cacheTransport is an http.RoundTripper that serves responses from a Cache
+// whenever possible and stores the cacheable responses it receives.
+type cacheTransport struct {
+	ctx               context.Context
+	state             *lib.State
+	tagsAndMeta       *metrics.TagsAndMeta
+	cache             *Cache
+	originalTransport http.RoundTripper
+}
+
+func (t cacheTransport) emitCacheHit(req *http.Request, hit bool) {
+	tagsAndMeta := t.tagsAndMeta.Clone()
+	enabledTags := t.state.Options.SystemTags
+	if _, ok := tagsAndMeta.Tags.Get(metrics.TagName.String()); !ok {
+		cleanURL := URL{u: req.URL, URL: req.URL.String()}.Clean()
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagName, cleanURL)
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagURL, cleanURL)
+	}
+	tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagMethod, req.Method)
+
+	metrics.PushIfNotDone(t.ctx, t.state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: t.state.BuiltinMetrics.HTTPCacheHits,
+			Tags:   tagsAndMeta.Tags,
+		},
+		Time:     time.Now(),
+		Metadata: tagsAndMeta.Metadata,
+		Value:    metrics.B(hit),
+	})
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqCC := parseCacheControl(req.Header)
+	if _, noStore := reqCC["no-store"]; noStore || req.Method != http.MethodGet {
+		return t.originalTransport.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	entry := t.cache.get(key, req)
+	_, noCache := reqCC["no-cache"]
+	if entry != nil && !noCache && entry.isFresh(t.cache.now()) {
+		t.emitCacheHit(req, true)
+		return entry.response(req), nil
+	}
+
+	outReq := req
+	if entry != nil && entry.hasValidators() &&
+		req.Header.Get("If-None-Match") == "" && req.Header.Get("If-Modified-Since") == "" {
+		outReq = req.Clone(req.Context())
+		if etag := entry.header.Get("ETag"); etag != "" {
+			outReq.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := entry.header.Get("Last-Modified"); lastModified != "" {
+			outReq.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.originalTransport.RoundTrip(outReq)
+	if err != nil {
+		return resp, err
+	}
+
+	if outReq != req && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		entry = t.cache.refresh(key, entry, resp.Header)
+		t.emitCacheHit(req, true)
+		return entry.response(req), nil
+	}
+
+	t.emitCacheHit(req, false)
+	if newEntry, ok := newCacheEntry(req, resp, t.cache.now()); ok {
+		resp.Body = &cacheRecorder{ReadCloser: resp.Body, store: func(body []byte) {
+			newEntry.body = body
+			t.cache.set(key, newEntry)
+		}}
+	}
+	return resp, nil
+}
+
+// cacheRecorder stores the response body in the cache once it's fully read.
+type cacheRecorder struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	store func([]byte)
+}
+
+func (r *cacheRecorder) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.Write(p[:n])
+	if errors.Is(err, io.EOF) && r.store != nil {
+		r.store(r.buf.Bytes())
+		r.store = nil
+	}
+	return n, err
+}
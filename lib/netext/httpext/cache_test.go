@@ -0,0 +1,228 @@
+package httpext
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestFreshnessLifetime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		header   http.Header
+		expected time.Duration
+	}{
+		"none":     {http.Header{}, 0},
+		"max-age":  {http.Header{"Cache-Control": {"public, max-age=60"}}, time.Minute},
+		"no-cache": {http.Header{"Cache-Control": {"no-cache, max-age=60"}}, 0},
+		"expires": {http.Header{
+			"Date":    {now.Format(http.TimeFormat)},
+			"Expires": {now.Add(time.Hour).Format(http.TimeFormat)},
+		}, time.Hour},
+		"expired": {http.Header{
+			"Date":    {now.Format(http.TimeFormat)},
+			"Expires": {now.Add(-time.Hour).Format(http.TimeFormat)},
+		}, 0},
+		"max-age over expires": {http.Header{
+			"Cache-Control": {"max-age=10"},
+			"Expires":       {now.Add(time.Hour).Format(http.TimeFormat)},
+		}, 10 * time.Second},
+		"heuristic": {http.Header{
+			"Date":          {now.Format(http.TimeFormat)},
+			"Last-Modified": {now.Add(-10 * time.Hour).Format(http.TimeFormat)},
+		}, time.Hour},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, freshnessLifetime(tc.header, parseCacheControl(tc.header), now))
+		})
+	}
+}
+
+func TestMakeRequestWithCache(t *testing.T) {
+	t.Parallel()
+
+	var originRequests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&originRequests, 1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=3600")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		_, _ = w.Write([]byte("body of " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	samples := make(chan metrics.SampleContainer, 100)
+	registry := metrics.NewRegistry()
+	state := &lib.State{
+		Options: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+		},
+		Transport:      srv.Client().Transport,
+		Samples:        samples,
+		Logger:         logrus.New(),
+		BufferPool:     lib.NewBufferPool(),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+	}
+	cache := NewCache()
+
+	doRequest := func(path string) *Response {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		res, err := MakeRequest(context.Background(), state, &ParsedHTTPRequest{
+			Req:          req,
+			URL:          &URL{u: req.URL, URL: req.URL.String()},
+			Timeout:      10 * time.Second,
+			ResponseType: ResponseTypeText,
+			TagsAndMeta:  state.Tags.GetCurrentValues(),
+			Cache:        cache,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.Status)
+		require.Equal(t, "body of "+path, res.Body)
+		return res
+	}
+	cacheHits := func() (hits []float64) {
+		for _, sc := range metrics.GetBufferedSamples(samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric == state.BuiltinMetrics.HTTPCacheHits {
+					hits = append(hits, s.Value)
+				}
+			}
+		}
+		return hits
+	}
+
+	testCases := []struct {
+		path           string
+		originRequests int64
+	}{
+		{"/fresh", 1},    // always served from the cache after the first request
+		{"/etag", 3},     // always revalidated
+		{"/no-store", 3}, // never stored
+	}
+	for _, tc := range testCases { //nolint:paralleltest
+		atomic.StoreInt64(&originRequests, 0)
+		doRequest(tc.path)
+		doRequest(tc.path)
+		doRequest(tc.path)
+		assert.Equal(t, tc.originRequests, atomic.LoadInt64(&originRequests), tc.path)
+
+		if tc.path == "/no-store" {
+			assert.Equal(t, []float64{0, 0, 0}, cacheHits(), tc.path)
+		} else {
+			assert.Equal(t, []float64{0, 1, 1}, cacheHits(), tc.path)
+		}
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCache()
+	cache.maxEntries = 3
+	cache.maxSize = 10
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	set := func(key string, size int) {
+		cache.set(key, &cacheEntry{header: http.Header{}, body: make([]byte, size)})
+	}
+
+	set("a", 1)
+	set("b", 1)
+	set("c", 1)
+	require.NotNil(t, cache.get("a", req)) // used more recently than b
+	set("d", 1)
+	assert.Nil(t, cache.get("b", req), "the least recently used entry is evicted")
+	assert.NotNil(t, cache.get("a", req))
+
+	set("e", 9)
+	assert.Equal(t, 2, cache.lru.Len(), "the entries are evicted until the bodies fit")
+	assert.EqualValues(t, 10, cache.size)
+	assert.NotNil(t, cache.get("e", req))
+
+	set("f", 11)
+	assert.Nil(t, cache.get("f", req), "a body larger than the cache isn't stored")
+	assert.EqualValues(t, 10, cache.size)
+}
+
+func TestCacheConcurrentRevalidation(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.Header().Set("Age", "1")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("body"))
+	}))
+	defer srv.Close()
+
+	registry := metrics.NewRegistry()
+	state := &lib.State{
+		Options:        lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+		Samples:        make(chan metrics.SampleContainer, 1000),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+	}
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	transport := cacheTransport{
+		ctx:               context.Background(),
+		state:             state,
+		tagsAndMeta:       &tagsAndMeta,
+		cache:             NewCache(),
+		originalTransport: srv.Client().Transport,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 10; k++ {
+				req := httptest.NewRequest(http.MethodGet, srv.URL+"/", nil)
+				req.RequestURI = ""
+				resp, err := transport.RoundTrip(req)
+				if !assert.NoError(t, err) {
+					return
+				}
+				body, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.NoError(t, resp.Body.Close())
+				assert.Equal(t, "body", string(body))
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,178 @@
+package httpext
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"go.k6.io/k6/converter/har"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// capturedBodySize is the size that the bodies of the captured requests and
+// responses are truncated to.
+const capturedBodySize = 4 << 10
+
+// capturedRequest is a HAR entry with the k6 specific details of the request,
+// in custom fields as the HAR format requires.
+type capturedRequest struct {
+	*har.Entry
+	VU        uint64            `json:"_vu"`
+	Iteration int64             `json:"_iteration"`
+	Tags      map[string]string `json:"_tags,omitempty"`
+	Error     string            `json:"_error,omitempty"`
+	ErrorCode int               `json:"_errorCode,omitempty"`
+}
+
+// captureRequest writes the details of the request to the file of the
+// captured requests, if it failed or it was slow, and to the HAR export, if
+// it's sampled.
+func captureRequest(
+	state *lib.State, preq *ParsedHTTPRequest, resp *Response, finishedReq *finishedRequest, resErr error,
+) {
+	failed := resErr != nil
+	var trail *Trail
+	if finishedReq != nil {
+		trail = finishedReq.trail
+		failed = failed || trail.Failed.Bool
+	}
+	if trail == nil {
+		trail = &Trail{}
+	}
+	var captures []*lib.RequestCapture
+	for _, rc := range []*lib.RequestCapture{state.RequestCapture, state.HARExport} {
+		if rc.ShouldCapture(failed, trail.Duration) {
+			captures = append(captures, rc)
+		}
+	}
+	if len(captures) == 0 {
+		return
+	}
+
+	req, res := preq.Req, (*http.Response)(nil)
+	if finishedReq != nil {
+		if finishedReq.request != nil {
+			req = finishedReq.request
+		}
+		res = finishedReq.response
+	}
+	// the body of the request before it's compressed
+	reqBody, resBody := []byte(resp.Request.Body), []byte(nil)
+	switch body := resp.Body.(type) {
+	case []byte:
+		resBody = body
+	case string:
+		resBody = []byte(body)
+	}
+
+	entry := har.NewEntry(req, truncateBody(reqBody), res, truncateBody(resBody))
+	entry.Request.BodySize = int64(len(reqBody))
+	if entry.Request.PostData != nil && len(reqBody) > capturedBodySize {
+		entry.Request.PostData.Comment = fmt.Sprintf("truncated to %d bytes", capturedBodySize)
+	}
+	if res != nil {
+		entry.Response.BodySize = int64(len(resBody))
+		entry.Response.Content.Size = int64(len(resBody))
+		if len(resBody) > capturedBodySize {
+			entry.Response.Content.Comment = fmt.Sprintf("truncated to %d bytes", capturedBodySize)
+		}
+	}
+
+	entry.Timings = har.Timings{
+		Blocked: metrics.D(trail.Blocked),
+		Connect: metrics.D(trail.ConnDuration),
+		SSL:     metrics.D(trail.TLSHandshaking),
+		Send:    metrics.D(trail.Sending),
+		Wait:    metrics.D(trail.Waiting),
+		Receive: metrics.D(trail.Receiving),
+	}
+	total := trail.Blocked + trail.ConnDuration + trail.Duration
+	entry.Time = metrics.D(total)
+	if !trail.EndTime.IsZero() {
+		entry.StartedDateTime = trail.EndTime.Add(-total)
+	}
+	if trail.ConnRemoteAddr != nil {
+		entry.ServerIPAddress, _, _ = net.SplitHostPort(trail.ConnRemoteAddr.String())
+	}
+
+	captured := capturedRequest{
+		Entry:     entry,
+		VU:        state.VUID,
+		Iteration: state.Iteration,
+		Tags:      preq.TagsAndMeta.Tags.Map(),
+		Error:     resp.Error,
+		ErrorCode: resp.ErrorCode,
+	}
+	// the tags of the trail have the status and the error of the response too
+	if trail.Tags != nil {
+		captured.Tags = trail.Tags.Map()
+	}
+	if resErr != nil && captured.Error == "" {
+		captured.Error = resErr.Error()
+	}
+	if rules := state.Options.Redact; rules != nil {
+		redactEntry(entry, rules)
+		captured.Error = rules.Redact(captured.Error)
+		for k, v := range captured.Tags {
+			captured.Tags[k] = rules.Redact(v)
+		}
+	}
+	for _, rc := range captures {
+		if err := rc.Capture(captured); err != nil {
+			state.Logger.WithError(err).Warn("Failed to capture a request")
+		}
+	}
+}
+
+// redactEntry redacts the sensitive data in the URL, headers, cookies,
+// parameters and bodies of the entry.
+func redactEntry(entry *har.Entry, rules *lib.RedactionRules) {
+	redactValues := func(values []har.NameValue, isSensitive func(string) bool) {
+		for i := range values {
+			if isSensitive(values[i].Name) {
+				values[i].Value = lib.Redacted
+			} else {
+				values[i].Value = rules.Redact(values[i].Value)
+			}
+		}
+	}
+	redactCookies := func(cookies []har.Cookie, header string) {
+		for i := range cookies {
+			if rules.IsSensitiveHeader(header) || rules.IsSensitiveField(cookies[i].Name) {
+				cookies[i].Value = lib.Redacted
+			}
+		}
+	}
+
+	req := entry.Request
+	req.URL = rules.Redact(req.URL)
+	redactValues(req.Headers, rules.IsSensitiveHeader)
+	redactValues(req.QueryString, rules.IsSensitiveField)
+	redactCookies(req.Cookies, "Cookie")
+	if req.PostData != nil {
+		req.PostData.Text = rules.Redact(req.PostData.Text)
+		for i, p := range req.PostData.Params {
+			if rules.IsSensitiveField(p.Name) {
+				req.PostData.Params[i].Value = lib.Redacted
+			}
+		}
+	}
+
+	if res := entry.Response; res != nil {
+		redactValues(res.Headers, rules.IsSensitiveHeader)
+		redactCookies(res.Cookies, "Set-Cookie")
+		res.RedirectURL = rules.Redact(res.RedirectURL)
+		// the binary bodies are base64 encoded
+		if res.Content.Encoding == "" {
+			res.Content.Text = rules.Redact(res.Content.Text)
+		}
+	}
+}
+
+func truncateBody(body []byte) []byte {
+	if len(body) > capturedBodySize {
+		return body[:capturedBodySize]
+	}
+	return body
+}
@@ -0,0 +1,187 @@
+package httpext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+)
+
+func TestMakeRequestCapture(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(strings.Repeat("e", capturedBodySize+10)))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	fs := fsext.NewMemMapFs()
+	f, err := fs.Create("/capture.jsonl")
+	require.NoError(t, err)
+	rc, err := lib.NewRequestCapture(f, false, lib.DefaultCaptureMaxSize)
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	state := &lib.State{
+		Options: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+		},
+		Transport:      srv.Client().Transport,
+		Samples:        make(chan metrics.SampleContainer, 10),
+		Logger:         logrus.New(),
+		BufferPool:     lib.NewBufferPool(),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+		RequestCapture: rc,
+		VUID:           3,
+		Iteration:      7,
+	}
+	for _, path := range []string{"/ok", "/fail"} {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, nil)
+		require.NoError(t, err)
+		preq := &ParsedHTTPRequest{
+			Req:              req,
+			URL:              &URL{u: req.URL, URL: srv.URL + path},
+			Body:             bytes.NewBufferString("payload"),
+			Timeout:          10 * time.Second,
+			ResponseCallback: func(status int) bool { return status < 400 },
+			TagsAndMeta:      state.Tags.GetCurrentValues(),
+		}
+		_, err = MakeRequest(context.Background(), state, preq)
+		require.NoError(t, err)
+	}
+
+	data, err := fsext.ReadFile(fs, "/capture.jsonl")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1, "only the failed request is captured")
+
+	var captured struct {
+		Request struct {
+			Method   string
+			URL      string
+			BodySize int64
+			PostData struct{ Text string }
+		}
+		Response struct {
+			Status   int
+			BodySize int64
+			Content  struct{ Text, Comment string }
+		}
+		Timings   struct{ Wait float64 }
+		VU        uint64            `json:"_vu"`
+		Iteration int64             `json:"_iteration"`
+		Tags      map[string]string `json:"_tags"`
+		Error     string            `json:"_error"`
+		ErrorCode int               `json:"_errorCode"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &captured))
+	assert.Equal(t, http.MethodPost, captured.Request.Method)
+	assert.Equal(t, srv.URL+"/fail", captured.Request.URL)
+	assert.Equal(t, "payload", captured.Request.PostData.Text)
+	assert.Equal(t, http.StatusInternalServerError, captured.Response.Status)
+	assert.Equal(t, int64(capturedBodySize+10), captured.Response.BodySize)
+	assert.Equal(t, strings.Repeat("e", capturedBodySize), captured.Response.Content.Text)
+	assert.Equal(t, "truncated to 4096 bytes", captured.Response.Content.Comment)
+	assert.Greater(t, captured.Timings.Wait, 0.0)
+	assert.Equal(t, uint64(3), captured.VU)
+	assert.Equal(t, int64(7), captured.Iteration)
+	assert.Equal(t, "500", captured.Tags["status"])
+	assert.Equal(t, 1500, captured.ErrorCode)
+}
+
+func TestMakeRequestCaptureRedaction(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-session"})
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error": "invalid key sk_live_123", "token": "secret-token"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var rules *lib.RedactionRules
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"headers": ["Authorization", "Set-Cookie"],
+		"fields": ["password", "token", "api_key"],
+		"patterns": ["sk_live_[0-9a-z]+"]
+	}`), &rules))
+
+	fs := fsext.NewMemMapFs()
+	f, err := fs.Create("/capture.jsonl")
+	require.NoError(t, err)
+	rc, err := lib.NewRequestCapture(f, false, lib.DefaultCaptureMaxSize)
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	state := &lib.State{
+		Options: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+			Redact:     rules,
+		},
+		Transport:      srv.Client().Transport,
+		Samples:        make(chan metrics.SampleContainer, 10),
+		Logger:         logrus.New(),
+		BufferPool:     lib.NewBufferPool(),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+		RequestCapture: rc,
+	}
+	url := srv.URL + "/login?api_key=secret-key&page=1"
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-bearer")
+	preq := &ParsedHTTPRequest{
+		Req:              req,
+		URL:              &URL{u: req.URL, URL: url},
+		Body:             bytes.NewBufferString(`{"user": "admin", "password": "secret-password"}`),
+		Timeout:          10 * time.Second,
+		ResponseCallback: func(status int) bool { return status < 400 },
+		TagsAndMeta:      state.Tags.GetCurrentValues(),
+	}
+	_, err = MakeRequest(context.Background(), state, preq)
+	require.NoError(t, err)
+
+	data, err := fsext.ReadFile(fs, "/capture.jsonl")
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-")
+	assert.NotContains(t, string(data), "sk_live_123")
+
+	var captured struct {
+		Request struct {
+			URL         string
+			Headers     []struct{ Name, Value string }
+			QueryString []struct{ Name, Value string }
+			PostData    struct{ Text string }
+		}
+		Response struct {
+			Cookies []struct{ Name, Value string }
+			Content struct{ Text string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(data, &captured))
+	assert.Equal(t, srv.URL+"/login?api_key="+lib.Redacted+"&page=1", captured.Request.URL)
+	assert.Contains(t, captured.Request.Headers, struct{ Name, Value string }{"Authorization", lib.Redacted})
+	assert.Contains(t, captured.Request.QueryString, struct{ Name, Value string }{"page", "1"})
+	assert.Equal(t, `{"user": "admin", "password": "`+lib.Redacted+`"}`, captured.Request.PostData.Text)
+	assert.Equal(t, []struct{ Name, Value string }{{"session", lib.Redacted}}, captured.Response.Cookies)
+	assert.Equal(t, `{"error": "invalid key `+lib.Redacted+`", "token": "`+lib.Redacted+`"}`,
+		captured.Response.Content.Text)
+}
@@ -0,0 +1,39 @@
+package httpext
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// clientIdleConnTimeout is how long the idle connections of the transports of
+// the clients are kept, like the ones of the default transport of Go, so the
+// ones of the clients that aren't used anymore are closed eventually.
+const clientIdleConnTimeout = 90 * time.Second
+
+// NewClientTransport returns a transport like the one of the VU, but with its
+// own TLS configuration and connections, for the clients that don't share
+// the ones of the VU.
+func NewClientTransport(base http.RoundTripper, tlsConfig *tls.Config) (*http.Transport, error) {
+	baseTransport, ok := base.(*http.Transport)
+	if !ok {
+		return nil, errors.New("the transport of the VU can't be customized")
+	}
+	transport := baseTransport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.IdleConnTimeout = clientIdleConnTimeout
+	// the HTTP/2 connections have to be in the pool of this transport, not in
+	// the one of the VU, so it's configured again
+	if baseTransport.TLSNextProto != nil && len(baseTransport.TLSNextProto) == 0 {
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper) // HTTP/1.1 only
+		return transport, nil
+	}
+	transport.TLSNextProto = nil
+	if err := http2.ConfigureTransport(transport); err != nil {
+		return nil, err
+	}
+	return transport, nil
+}
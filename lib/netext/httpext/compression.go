@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/andybalholm/brotli"
 	"github.com/klauspost/compress/zstd"
@@ -193,6 +194,69 @@ func readResponseBody(
 	return result, respErr
 }
 
+// The gzip and zstd decoders are reused between the responses, since creating
+// them allocates a lot, and they can be reset to decode another body.
+//
+//nolint:gochecknoglobals
+var (
+	gzipReaders  sync.Pool
+	zstdDecoders sync.Pool
+)
+
+// pooledGzipReader returns the gzip reader to the pool when it's closed.
+type pooledGzipReader struct {
+	*gzip.Reader
+}
+
+func (r pooledGzipReader) Close() error {
+	err := r.Reader.Close()
+	gzipReaders.Put(r.Reader)
+	return err
+}
+
+// pooledZstdDecoder returns the zstd decoder to the pool when it's closed,
+// instead of closing it.
+type pooledZstdDecoder struct {
+	*zstd.Decoder
+}
+
+func (d pooledZstdDecoder) Close() error {
+	// release the reference to the body
+	_ = d.Decoder.Reset(nil)
+	zstdDecoders.Put(d.Decoder)
+	return nil
+}
+
+func newGzipReader(r io.Reader) (io.Reader, error) {
+	if gr, ok := gzipReaders.Get().(*gzip.Reader); ok {
+		if err := gr.Reset(r); err != nil {
+			gzipReaders.Put(gr)
+			return nil, err
+		}
+		return pooledGzipReader{gr}, nil
+	}
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return pooledGzipReader{gr}, nil
+}
+
+func newZstdDecoder(r io.Reader) (io.Reader, error) {
+	if d, ok := zstdDecoders.Get().(*zstd.Decoder); ok {
+		if err := d.Reset(r); err != nil {
+			zstdDecoders.Put(d)
+			return nil, err
+		}
+		return pooledZstdDecoder{d}, nil
+	}
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return pooledZstdDecoder{d}, nil
+}
+
 func pickDecoder(compression CompressionType, rc *readCloser) (io.Reader, error) {
 	var decoder io.Reader
 	var err error
@@ -200,9 +264,9 @@ func pickDecoder(compression CompressionType, rc *readCloser) (io.Reader, error)
 	case CompressionTypeDeflate:
 		decoder, err = zlib.NewReader(rc)
 	case CompressionTypeGzip:
-		decoder, err = gzip.NewReader(rc)
+		decoder, err = newGzipReader(rc)
 	case CompressionTypeZstd:
-		decoder, err = zstd.NewReader(rc)
+		decoder, err = newZstdDecoder(rc)
 	case CompressionTypeBr:
 		decoder = brotli.NewReader(rc)
 	default:
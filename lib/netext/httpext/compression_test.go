@@ -0,0 +1,90 @@
+package httpext
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+)
+
+func TestReadResponseBodyReusesDecoders(t *testing.T) {
+	t.Parallel()
+
+	compress := map[string]func(t *testing.T, body string) []byte{
+		"gzip": func(t *testing.T, body string) []byte {
+			var buf bytes.Buffer
+			w := gzip.NewWriter(&buf)
+			_, err := w.Write([]byte(body))
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			return buf.Bytes()
+		},
+		"zstd": func(t *testing.T, body string) []byte {
+			w, err := zstd.NewWriter(nil)
+			require.NoError(t, err)
+			return w.EncodeAll([]byte(body), nil)
+		},
+	}
+
+	state := &lib.State{BufferPool: lib.NewBufferPool()}
+	for encoding, compressBody := range compress {
+		encoding, compressBody := encoding, compressBody
+		t.Run(encoding, func(t *testing.T) {
+			t.Parallel()
+
+			// the decoders returned to the pool have to decode the next
+			// bodies, including after a failure
+			for _, body := range []string{"first body", "second body", "", "last body"} {
+				resp := &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Encoding": []string{encoding}},
+					Body:       io.NopCloser(bytes.NewReader(compressBody(t, body))),
+				}
+				result, err := readResponseBody(state, ResponseTypeText, resp, nil)
+				require.NoError(t, err)
+				assert.Equal(t, body, result)
+
+				resp = &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Encoding": []string{encoding}},
+					Body:       io.NopCloser(bytes.NewReader([]byte("not compressed"))),
+				}
+				_, err = readResponseBody(state, ResponseTypeText, resp, nil)
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestReadResponseBodyNone(t *testing.T) {
+	t.Parallel()
+
+	body := &trackingBody{Reader: bytes.NewReader([]byte("not compressed"))}
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:       body,
+	}
+	result, err := readResponseBody(&lib.State{}, ResponseTypeNone, resp, nil)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.True(t, body.closed)
+	assert.Zero(t, body.Reader.(*bytes.Reader).Len())
+}
+
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
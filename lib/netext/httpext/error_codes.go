@@ -28,6 +28,7 @@ const (
 	defaultNetNonTCPErrorCode errCode = 1010
 	invalidURLErrorCode       errCode = 1020
 	requestTimeoutErrorCode   errCode = 1050
+	injectedFaultErrorCode    errCode = 1060
 	// DNS errors
 	defaultDNSErrorCode      errCode = 1100
 	dnsNoSuchHostErrorCode   errCode = 1101
@@ -82,6 +83,7 @@ const (
 	x509HostnameErrorCodeMsg    = "x509: certificate doesn't match hostname"
 	x509UnknownAuthority        = "x509: unknown authority"
 	requestTimeoutErrorCodeMsg  = "request timeout"
+	injectedFaultErrorCodeMsg   = "request dropped by an injected fault"
 	invalidURLErrorCodeMsg      = "invalid URL"
 )
 
@@ -0,0 +1,47 @@
+package httpext
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.k6.io/k6/lib"
+)
+
+// faultTransport injects a fault into a request: it's delayed, and then
+// dropped or responded with the status of the fault, without being sent, or
+// sent with the original transport.
+type faultTransport struct {
+	fault             *lib.Fault
+	originalTransport http.RoundTripper
+}
+
+func (t faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if delay := t.fault.DelayDuration(); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	switch {
+	case t.fault.Drop.Bool:
+		return nil, NewK6Error(injectedFaultErrorCode, injectedFaultErrorCodeMsg, nil)
+	case t.fault.Status.Valid:
+		status := int(t.fault.Status.Int64)
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode: status,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	default:
+		return t.originalTransport.RoundTrip(req)
+	}
+}
@@ -7,12 +7,15 @@ import (
 
 	uuid "github.com/nu7hatch/gouuid"
 	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib"
 )
 
 type httpDebugTransport struct {
 	originalTransport http.RoundTripper
 	httpDebugOption   string
 	logger            logrus.FieldLogger
+	redact            *lib.RedactionRules
 }
 
 // RoundTrip prints passing HTTP requests and received responses
@@ -35,7 +38,7 @@ func (t httpDebugTransport) debugRequest(req *http.Request, requestID string) {
 		t.logger.Error(err)
 	}
 	t.logger.WithField("request_id", requestID).Infof("Request:\n%s\n",
-		bytes.ReplaceAll(dump, []byte("\r\n"), []byte{'\n'}))
+		t.redact.Redact(string(bytes.ReplaceAll(dump, []byte("\r\n"), []byte{'\n'}))))
 }
 
 func (t httpDebugTransport) debugResponse(res *http.Response, requestID string) {
@@ -45,6 +48,6 @@ func (t httpDebugTransport) debugResponse(res *http.Response, requestID string)
 			t.logger.Error(err)
 		}
 		t.logger.WithField("request_id", requestID).Infof("Response:\n%s\n",
-			bytes.ReplaceAll(dump, []byte("\r\n"), []byte{'\n'}))
+			t.redact.Redact(string(bytes.ReplaceAll(dump, []byte("\r\n"), []byte{'\n'}))))
 	}
 }
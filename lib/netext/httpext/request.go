@@ -15,6 +15,10 @@ import (
 
 	"github.com/Azure/go-ntlmssp"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/lib"
@@ -51,6 +55,10 @@ type ParsedHTTPRequest struct {
 	ActiveJar        *cookiejar.Jar
 	Cookies          map[string]*HTTPRequestCookie
 	TagsAndMeta      metrics.TagsAndMeta
+	Cache            *Cache
+	// The transport of the client, like one with its own TLS configuration,
+	// instead of the one of the VU, if it's set.
+	Transport http.RoundTripper
 }
 
 // Matches non-compliant io.Closer implementations (e.g. zstd.Decoder)
@@ -180,8 +188,21 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 			return nil, err
 		}
 	}
+	if err := state.RateLimiters.Wait(ctx, preq.Req.URL.Hostname(), preq.TagsAndMeta.Tags); err != nil {
+		return nil, err
+	}
+
+	ctx, span := state.StartRequestSpan(ctx, preq.Req.Method, propagation.HeaderCarrier(preq.Req.Header),
+		&preq.TagsAndMeta,
+		semconv.HTTPRequestMethodKey.String(preq.Req.Method),
+		semconv.URLFull(preq.URL.Clean()),
+		semconv.ServerAddress(preq.Req.URL.Hostname()),
+	)
+	defer span.End()
 
 	tracerTransport := newTransport(ctx, state, &preq.TagsAndMeta, preq.ResponseCallback)
+	tracerTransport.roundTripper = preq.Transport
+	tracerTransport.fault = state.Faults.Pick(preq.Req.URL.Hostname(), preq.TagsAndMeta.Tags)
 	var transport http.RoundTripper = tracerTransport
 
 	if state.Options.HTTPDebug.String != "" {
@@ -201,6 +222,7 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 			originalTransport: transport,
 			httpDebugOption:   state.Options.HTTPDebug.String,
 			logger:            state.Logger.WithFields(combinedLogFields),
+			redact:            state.Options.Redact,
 		}
 	}
 
@@ -228,6 +250,16 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 		transport = ntlmssp.Negotiator{RoundTripper: transport}
 	}
 
+	if preq.Cache != nil {
+		transport = cacheTransport{
+			ctx:               ctx,
+			state:             state,
+			tagsAndMeta:       &preq.TagsAndMeta,
+			cache:             preq.Cache,
+			originalTransport: transport,
+		}
+	}
+
 	resp := &Response{URL: preq.URL.URL, Request: respReq}
 	client := http.Client{
 		Transport: transport,
@@ -322,6 +354,9 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 		}
 	}
 
+	captureRequest(state, preq, resp, finishedReq, resErr)
+	setRequestSpanStatus(span, resp.Status, resErr)
+
 	if resErr != nil {
 		if preq.Throw { // if we are going to throw, we shouldn't log it
 			return nil, resErr
@@ -338,6 +373,24 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 	return resp, nil
 }
 
+// setRequestSpanStatus sets the status of the span of a request, that is an
+// error if the request failed or the server responded with an error status.
+func setRequestSpanStatus(span trace.Span, status int, err error) {
+	if !span.IsRecording() {
+		return
+	}
+	if status != 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	}
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case status >= http.StatusBadRequest:
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
 // SetRequestCookies sets the cookies of the requests getting those cookies both from the jar and
 // from the reqCookies map. The Replace field of the HTTPRequestCookie will be taken into account
 func SetRequestCookies(req *http.Request, jar *cookiejar.Jar, reqCookies map[string]*HTTPRequestCookie) {
@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -19,7 +20,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
 	"go.k6.io/k6/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
 	"golang.org/x/time/rate"
 	"gopkg.in/guregu/null.v3"
 )
@@ -274,7 +281,7 @@ func TestMakeRequestTimeoutInTheMiddle(t *testing.T) {
 	assert.Len(t, samples, 1)
 	sampleCont := <-samples
 	allSamples := sampleCont.GetSamples()
-	require.Len(t, allSamples, 9)
+	require.Len(t, allSamples, 12)
 	expTags := map[string]string{
 		"error":             "request timeout",
 		"error_code":        "1050",
@@ -422,7 +429,7 @@ func TestMakeRequestDialTimeout(t *testing.T) {
 	assert.Len(t, samples, 1)
 	sampleCont := <-samples
 	allSamples := sampleCont.GetSamples()
-	require.Len(t, allSamples, 9)
+	require.Len(t, allSamples, 12)
 	expTags := map[string]string{
 		"error":             "dial: i/o timeout",
 		"error_code":        "1211",
@@ -477,7 +484,7 @@ func TestMakeRequestTimeoutInTheBegining(t *testing.T) {
 	assert.Len(t, samples, 1)
 	sampleCont := <-samples
 	allSamples := sampleCont.GetSamples()
-	require.Len(t, allSamples, 9)
+	require.Len(t, allSamples, 12)
 	expTags := map[string]string{
 		"error":             "request timeout",
 		"error_code":        "1050",
@@ -554,3 +561,136 @@ func TestMakeRequestRPSLimit(t *testing.T) {
 		}
 	}
 }
+
+// spanRecorder is a span exporter that keeps the ended spans.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (r *spanRecorder) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(context.Context) error { return nil }
+
+func TestMakeRequestSpan(t *testing.T) {
+	t.Parallel()
+
+	traceparents := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	recorder := &spanRecorder{}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(recorder))
+	samples := make(chan metrics.SampleContainer, 10)
+	registry := metrics.NewRegistry()
+	state := &lib.State{
+		Options: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+		},
+		Transport:      ts.Client().Transport,
+		Samples:        samples,
+		Logger:         testutils.NewLogger(t),
+		BufferPool:     lib.NewBufferPool(),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+		TracerProvider: tp,
+		VUID:           3,
+		Iteration:      7,
+	}
+
+	// the span continues the trace of the traceparent header, e.g. set by the
+	// tracing module, and replaces it with its own
+	const parentTraceID, parentSpanID = "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/slow", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	preq := &ParsedHTTPRequest{
+		Req:         req,
+		URL:         &URL{u: req.URL, URL: ts.URL + "/slow"},
+		Timeout:     10 * time.Second,
+		TagsAndMeta: state.Tags.GetCurrentValues(),
+	}
+	ctx := lib.WithScenarioState(context.Background(), &lib.ScenarioState{Name: "browse"})
+	res, err := MakeRequest(ctx, state, preq)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.Status)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.Len(t, recorder.spans, 1)
+	span := recorder.spans[0]
+	assert.Equal(t, "GET", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, parentTraceID, span.SpanContext().TraceID().String())
+	assert.Equal(t, parentSpanID, span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	assert.Equal(t, "GET", attrs["http.request.method"].AsString())
+	assert.Equal(t, ts.URL+"/slow", attrs["url.full"].AsString())
+	assert.Equal(t, int64(500), attrs["http.response.status_code"].AsInt64())
+	assert.Equal(t, "browse", attrs["k6.scenario"].AsString())
+	assert.Equal(t, int64(3), attrs["k6.vu"].AsInt64())
+	assert.Equal(t, int64(7), attrs["k6.iteration"].AsInt64())
+
+	traceID, spanID := span.SpanContext().TraceID().String(), span.SpanContext().SpanID().String()
+	assert.Equal(t, "00-"+traceID+"-"+spanID+"-01", <-traceparents)
+	for _, sc := range metrics.GetBufferedSamples(samples) {
+		for _, sample := range sc.GetSamples() {
+			assert.Equal(t, traceID, sample.Metadata[lib.MetadataTraceID], sample.Metric.Name)
+			assert.Equal(t, spanID, sample.Metadata[lib.MetadataSpanID], sample.Metric.Name)
+		}
+	}
+}
+
+func TestMakeRequestWithoutTracing(t *testing.T) {
+	t.Parallel()
+
+	traceparents := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+	}))
+	defer ts.Close()
+
+	samples := make(chan metrics.SampleContainer, 10)
+	registry := metrics.NewRegistry()
+	state := &lib.State{
+		Options: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+		},
+		Transport:      ts.Client().Transport,
+		Samples:        samples,
+		Logger:         testutils.NewLogger(t),
+		BufferPool:     lib.NewBufferPool(),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+		TracerProvider: tracenoop.NewTracerProvider(),
+	}
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	preq := &ParsedHTTPRequest{
+		Req:         req,
+		URL:         &URL{u: req.URL, URL: ts.URL},
+		Timeout:     10 * time.Second,
+		TagsAndMeta: state.Tags.GetCurrentValues(),
+	}
+	_, err = MakeRequest(context.Background(), state, preq)
+	require.NoError(t, err)
+
+	assert.Empty(t, <-traceparents)
+	for _, sc := range metrics.GetBufferedSamples(samples) {
+		for _, sample := range sc.GetSamples() {
+			assert.NotContains(t, sample.Metadata, lib.MetadataTraceID)
+		}
+	}
+}
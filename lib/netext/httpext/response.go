@@ -28,6 +28,7 @@ const (
 	// to null. This saves CPU and memory and is suitable for HTTP requests that we just
 	// want to  measure, but we don't care about their responses' contents. This is the
 	// default value for all requests if the global discardResponseBodies is enablled.
+	// The discarded body is never decompressed, nor copied in a buffer.
 	ResponseTypeNone
 )
 
@@ -29,96 +29,73 @@ type Trail struct {
 	Waiting        time.Duration // Waiting for first byte.
 	Receiving      time.Duration // Receiving response.
 
+	// Time spent waiting for a connection from the pool, i.e. the blocked
+	// time without the time spent establishing a new connection.
+	PoolWaiting time.Duration
+
 	// Detailed connection information.
 	ConnReused     bool
 	ConnRemoteAddr net.Addr
+	// Whether the TLS session was resumed, valid only if the request made a
+	// TLS handshake.
+	TLSResumed null.Bool
 
 	Failed null.Bool
 	// Populated by SaveSamples()
 	Tags     *metrics.TagSet
 	Metadata map[string]string
 	Samples  []metrics.Sample
+
+	// samples is the backing array of Samples, so they are allocated
+	// together with the Trail, with 2 more for a possible HTTPReqTLSResumed
+	// and HTTPReqFailed
+	samples [13]metrics.Sample
 }
 
 // SaveSamples populates the Trail's sample slice so they're accessible via GetSamples()
 func (tr *Trail) SaveSamples(builtinMetrics *metrics.BuiltinMetrics, ctm *metrics.TagsAndMeta) {
 	tr.Tags = ctm.Tags
 	tr.Metadata = ctm.Metadata
-	tr.Samples = make([]metrics.Sample, 0, 9) // this is with 1 more for a possible HTTPReqFailed
-	tr.Samples = append(tr.Samples, []metrics.Sample{
-		{
-			TimeSeries: metrics.TimeSeries{
-				Metric: builtinMetrics.HTTPReqs,
-				Tags:   ctm.Tags,
-			},
-			Time:     tr.EndTime,
-			Metadata: ctm.Metadata,
-			Value:    1,
-		},
-		{
-			TimeSeries: metrics.TimeSeries{
-				Metric: builtinMetrics.HTTPReqDuration,
-				Tags:   ctm.Tags,
-			},
-			Time:     tr.EndTime,
-			Metadata: ctm.Metadata,
-			Value:    metrics.D(tr.Duration),
-		},
-		{
-			TimeSeries: metrics.TimeSeries{
-				Metric: builtinMetrics.HTTPReqBlocked,
-				Tags:   ctm.Tags,
-			},
-			Time:     tr.EndTime,
-			Metadata: ctm.Metadata,
-			Value:    metrics.D(tr.Blocked),
-		},
-		{
-			TimeSeries: metrics.TimeSeries{
-				Metric: builtinMetrics.HTTPReqConnecting,
-				Tags:   ctm.Tags,
-			},
-			Time:     tr.EndTime,
-			Metadata: ctm.Metadata,
-			Value:    metrics.D(tr.Connecting),
-		},
-		{
-			TimeSeries: metrics.TimeSeries{
-				Metric: builtinMetrics.HTTPReqTLSHandshaking,
-				Tags:   ctm.Tags,
-			},
-			Time:     tr.EndTime,
-			Metadata: ctm.Metadata,
-			Value:    metrics.D(tr.TLSHandshaking),
-		},
-		{
-			TimeSeries: metrics.TimeSeries{
-				Metric: builtinMetrics.HTTPReqSending,
-				Tags:   ctm.Tags,
-			},
-			Time:     tr.EndTime,
-			Metadata: ctm.Metadata,
-			Value:    metrics.D(tr.Sending),
-		},
-		{
+
+	values := [...]struct {
+		metric *metrics.Metric
+		value  float64
+	}{
+		{builtinMetrics.HTTPReqs, 1},
+		{builtinMetrics.HTTPReqDuration, metrics.D(tr.Duration)},
+		{builtinMetrics.HTTPReqBlocked, metrics.D(tr.Blocked)},
+		{builtinMetrics.HTTPReqConnecting, metrics.D(tr.Connecting)},
+		{builtinMetrics.HTTPReqTLSHandshaking, metrics.D(tr.TLSHandshaking)},
+		{builtinMetrics.HTTPReqSending, metrics.D(tr.Sending)},
+		{builtinMetrics.HTTPReqWaiting, metrics.D(tr.Waiting)},
+		{builtinMetrics.HTTPReqReceiving, metrics.D(tr.Receiving)},
+		{builtinMetrics.HTTPReqPoolWaiting, metrics.D(tr.PoolWaiting)},
+		{builtinMetrics.HTTPReqConnReused, metrics.B(tr.ConnReused)},
+		{builtinMetrics.HTTPConnsOpened, metrics.B(!tr.ConnReused && tr.ConnRemoteAddr != nil)},
+	}
+	tr.Samples = tr.samples[:len(values)]
+	for i, v := range values {
+		tr.Samples[i] = metrics.Sample{
 			TimeSeries: metrics.TimeSeries{
-				Metric: builtinMetrics.HTTPReqWaiting,
+				Metric: v.metric,
 				Tags:   ctm.Tags,
 			},
 			Time:     tr.EndTime,
 			Metadata: ctm.Metadata,
-			Value:    metrics.D(tr.Waiting),
-		},
-		{
+			Value:    v.value,
+		}
+	}
+	if tr.TLSResumed.Valid {
+		tr.Samples = append(tr.Samples, metrics.Sample{
 			TimeSeries: metrics.TimeSeries{
-				Metric: builtinMetrics.HTTPReqReceiving,
+				Metric: builtinMetrics.HTTPReqTLSResumed,
 				Tags:   ctm.Tags,
 			},
 			Time:     tr.EndTime,
 			Metadata: ctm.Metadata,
-			Value:    metrics.D(tr.Receiving),
-		},
-	}...)
+			Value:    metrics.B(tr.TLSResumed.Bool),
+		})
+	}
 }
 
 // GetSamples implements the metrics.SampleContainer interface.
@@ -153,11 +130,19 @@ type Tracer struct {
 	gotConn              int64
 	wroteRequest         int64
 	gotFirstResponseByte int64
+	// the kind of the TLS handshake: 0 if there wasn't one, then
+	// tlsHandshakeFull or tlsHandshakeResumed
+	tlsHandshakeKind int32
 
 	connReused     bool
 	connRemoteAddr net.Addr
 }
 
+const (
+	tlsHandshakeFull int32 = iota + 1
+	tlsHandshakeResumed
+)
+
 // Trace returns a premade ClientTrace that calls all of the Tracer's hooks.
 func (t *Tracer) Trace() *httptrace.ClientTrace {
 	return &httptrace.ClientTrace{
@@ -239,9 +224,14 @@ func (t *Tracer) TLSHandshakeStart() {
 // it will be called after TLSHandshakeStart() and before GotConn().
 // If the request was cancelled, this could be called after the
 // RoundTrip() method has returned.
-func (t *Tracer) TLSHandshakeDone(_ tls.ConnectionState, err error) {
+func (t *Tracer) TLSHandshakeDone(state tls.ConnectionState, err error) {
 	if err == nil {
 		atomic.CompareAndSwapInt64(&t.tlsHandshakeDone, 0, now())
+		kind := tlsHandshakeFull
+		if state.DidResume {
+			kind = tlsHandshakeResumed
+		}
+		atomic.CompareAndSwapInt32(&t.tlsHandshakeKind, 0, kind)
 	}
 	// if there is an error it will be returned by the http call
 }
@@ -274,6 +264,7 @@ func (t *Tracer) GotConn(info httptrace.GotConnInfo) {
 		if isConnTLS {
 			atomic.SwapInt64(&t.tlsHandshakeStart, now)
 			atomic.SwapInt64(&t.tlsHandshakeDone, now)
+			atomic.StoreInt32(&t.tlsHandshakeKind, 0)
 		}
 	} else {
 		// There's a bug in the Go stdlib where an HTTP/2 connection can be reused
@@ -343,6 +334,9 @@ func (t *Tracer) Done() *Trail {
 	if tlsHandshakeDone != 0 && tlsHandshakeStart != 0 {
 		trail.TLSHandshaking = time.Duration(tlsHandshakeDone - tlsHandshakeStart)
 	}
+	if kind := atomic.LoadInt32(&t.tlsHandshakeKind); kind != 0 {
+		trail.TLSResumed = null.BoolFrom(kind == tlsHandshakeResumed)
+	}
 	if wroteRequest != 0 {
 		switch {
 		case tlsHandshakeDone != 0:
@@ -378,6 +372,9 @@ func (t *Tracer) Done() *Trail {
 	// Calculate total times using adjusted values.
 	trail.EndTime = done
 	trail.ConnDuration = trail.Connecting + trail.TLSHandshaking
+	if trail.Blocked > trail.ConnDuration {
+		trail.PoolWaiting = trail.Blocked - trail.ConnDuration
+	}
 	trail.Duration = trail.Sending + trail.Waiting + trail.Receiving
 
 	return &trail
@@ -145,7 +145,12 @@ func TestTracer(t *testing.T) { //nolint:tparallel
 
 			assert.Equal(t, strings.TrimPrefix(srv.URL, "https://"), trail.ConnRemoteAddr.String())
 
-			assert.Len(t, samples, 8)
+			// the TLS resumption is reported only for the new connections
+			if isReuse {
+				assert.Len(t, samples, 11)
+			} else {
+				assert.Len(t, samples, 12)
+			}
 			seenMetrics := map[*metrics.Metric]bool{}
 			for i, s := range samples {
 				assert.NotContains(t, seenMetrics, s.Metric)
@@ -166,6 +171,15 @@ func TestTracer(t *testing.T) { //nolint:tparallel
 					fallthrough
 				case builtinMetrics.HTTPReqDuration, builtinMetrics.HTTPReqBlocked, builtinMetrics.HTTPReqSending, builtinMetrics.HTTPReqWaiting, builtinMetrics.HTTPReqReceiving:
 					assert.True(t, s.Value > 0.0, "%s is <= 0", s.Metric.Name)
+				case builtinMetrics.HTTPReqPoolWaiting:
+					assert.True(t, s.Value >= 0.0, "%s is < 0", s.Metric.Name)
+				case builtinMetrics.HTTPReqConnReused:
+					assert.Equal(t, metrics.B(isReuse), s.Value)
+				case builtinMetrics.HTTPConnsOpened:
+					assert.Equal(t, metrics.B(!isReuse), s.Value)
+				case builtinMetrics.HTTPReqTLSResumed:
+					assert.False(t, isReuse)
+					assert.Equal(t, 0.0, s.Value)
 				default:
 					t.Errorf("unexpected metric: %s", s.Metric.Name)
 				}
@@ -290,3 +304,14 @@ func TestCancelledRequest(t *testing.T) {
 		}
 	})
 }
+
+func BenchmarkTrailSaveSamples(b *testing.B) {
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+	ctm := &metrics.TagsAndMeta{Tags: registry.RootTagSet().With("status", "200")}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tr := &Trail{Duration: time.Millisecond}
+		tr.SaveSamples(builtinMetrics, ctm)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net/http/httptrace"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext"
@@ -22,6 +23,10 @@ type transport struct {
 	state            *lib.State
 	tagsAndMeta      *metrics.TagsAndMeta
 	responseCallback func(int) bool
+	// the transport of the client of the request, if it isn't the VU's one
+	roundTripper http.RoundTripper
+	// the fault injected into the request, if any, see lib.Fault
+	fault *lib.Fault
 
 	lastRequest     *unfinishedRequest
 	lastRequestLock *sync.Mutex
@@ -107,13 +112,13 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 	if unfReq.err != nil {
 		result.errorCode, result.errorMsg = errorCodeForError(unfReq.err)
 		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagError, result.errorMsg)
-		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagErrorCode, strconv.Itoa(int(result.errorCode)))
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagErrorCode, metrics.IntTagValue(int(result.errorCode)))
 		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagStatus, "0")
 	} else {
-		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagStatus, strconv.Itoa(unfReq.response.StatusCode))
+		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagStatus, metrics.IntTagValue(unfReq.response.StatusCode))
 		if unfReq.response.StatusCode >= 400 {
 			result.errorCode = errCode(1000 + unfReq.response.StatusCode)
-			tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagErrorCode, strconv.Itoa(int(result.errorCode)))
+			tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagErrorCode, metrics.IntTagValue(int(result.errorCode)))
 		}
 		tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagProto, unfReq.response.Proto)
 
@@ -161,6 +166,7 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 			},
 		)
 	}
+	atomic.AddInt64(&t.state.Requests, 1)
 	metrics.PushIfNotDone(t.ctx, t.state.Samples, trail)
 	return result
 }
@@ -204,7 +210,16 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	ctx := req.Context()
 	tracer := &Tracer{}
 	reqWithTracer := req.WithContext(httptrace.WithClientTrace(ctx, tracer.Trace()))
-	resp, err := t.state.Transport.RoundTrip(reqWithTracer)
+	roundTripper := t.roundTripper
+	if roundTripper == nil {
+		roundTripper = t.state.Transport
+	}
+	if t.fault != nil {
+		// only the request is faulted, not its redirects
+		roundTripper = faultTransport{fault: t.fault, originalTransport: roundTripper}
+		t.fault = nil
+	}
+	resp, err := roundTripper.RoundTrip(reqWithTracer)
 
 	var netError net.Error
 	if errors.As(err, &netError) && netError.Timeout() {
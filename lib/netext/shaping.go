@@ -0,0 +1,208 @@
+package netext
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.k6.io/k6/lib"
+)
+
+const (
+	// shapingSegmentSize is the size of the packets, like the usual TCP MSS,
+	// of which the lost ones are drawn.
+	shapingSegmentSize = 1460
+	// shapingRetransmitDelay is how long a lost packet delays the data, like
+	// the minimum retransmission timeout of TCP.
+	shapingRetransmitDelay = 200 * time.Millisecond
+)
+
+// Shaper shapes the traffic of the connections of a VU, or of a client, as
+// configured. The bandwidth is shared by all of its connections.
+type Shaper struct {
+	latency, jitter time.Duration
+	loss            float64
+	sent, received  *pacer
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// NewShaper returns the shaper of the traffic shaping, nil if the traffic
+// isn't shaped.
+func NewShaper(ts *lib.TrafficShaping) *Shaper {
+	if ts.IsZero() {
+		return nil
+	}
+	s := &Shaper{
+		latency: time.Duration(ts.Latency.Duration),
+		jitter:  time.Duration(ts.Jitter.Duration),
+		loss:    ts.Loss.Float64,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+	}
+	if ts.Bandwidth.Int64 > 0 {
+		s.sent = &pacer{rate: float64(ts.Bandwidth.Int64)}
+		s.received = &pacer{rate: float64(ts.Bandwidth.Int64)}
+	}
+	return s
+}
+
+// ParseShaper parses the traffic shaping of a client, like
+// {latency: '100ms', bandwidth: 125000}, and returns its shaper.
+func ParseShaper(v interface{}) (*Shaper, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var ts lib.TrafficShaping
+	if err = json.Unmarshal(raw, &ts); err != nil {
+		return nil, fmt.Errorf("invalid traffic shaping: %w", err)
+	}
+	if errs := ts.Validate(); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return NewShaper(&ts), nil
+}
+
+// WithShaper returns a dialer like d, a Dialer or one returned by its
+// methods, that shapes the traffic of the connections with the shaper instead
+// of the one of the VU.
+func WithShaper(d lib.DialContexter, shaper *Shaper) (lib.DialContexter, error) {
+	switch d := d.(type) {
+	case *Dialer:
+		return &clientDialer{dialer: d, resolver: d.Resolver, shaper: shaper}, nil
+	case *clientDialer:
+		result := *d
+		result.shaper = shaper
+		return &result, nil
+	default:
+		return nil, errors.New("the dialer of the VU can't be customized")
+	}
+}
+
+// delay returns the latency of a round trip, with its jitter.
+func (s *Shaper) delay() time.Duration {
+	if s.jitter <= 0 {
+		return s.latency
+	}
+	s.randMu.Lock()
+	d := s.latency + time.Duration((s.rand.Float64()*2-1)*float64(s.jitter))
+	s.randMu.Unlock()
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// lossDelay returns the delay of the retransmission of the lost packets of n
+// bytes of data.
+func (s *Shaper) lossDelay(n int) time.Duration {
+	if s.loss <= 0 {
+		return 0
+	}
+	var lost time.Duration
+	s.randMu.Lock()
+	for i := 0; i < n; i += shapingSegmentSize {
+		if s.rand.Float64() < s.loss {
+			lost++
+		}
+	}
+	s.randMu.Unlock()
+	return lost * (shapingRetransmitDelay + s.latency)
+}
+
+// netDialer returns a copy of the dialer that waits, after it creates the
+// sockets, for the latency of the round trip of the handshake of the
+// connections.
+func (s *Shaper) netDialer(d net.Dialer) *net.Dialer {
+	control, controlContext := d.Control, d.ControlContext
+	d.Control = nil
+	d.ControlContext = func(ctx context.Context, network, address string, c syscall.RawConn) error {
+		if err := s.wait(ctx); err != nil {
+			return err
+		}
+		if controlContext != nil {
+			return controlContext(ctx, network, address, c)
+		}
+		if control != nil {
+			return control(network, address, c)
+		}
+		return nil
+	}
+	return &d
+}
+
+// wait waits for the latency of a round trip, or until the context is done.
+func (s *Shaper) wait(ctx context.Context) error {
+	d := s.delay()
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pacer paces the data to a rate, in bytes per second, without bursts after
+// the idle times.
+type pacer struct {
+	mu   sync.Mutex
+	rate float64
+	next time.Time
+}
+
+// delay returns how long n bytes of data take to be transferred, after the
+// data before them.
+func (p *pacer) delay(n int) time.Duration {
+	if p == nil || n <= 0 {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	p.next = p.next.Add(time.Duration(float64(n) / p.rate * float64(time.Second)))
+	return p.next.Sub(now)
+}
+
+// shapedConn shapes the traffic of a connection: the data is paced to the
+// bandwidth, delayed by the lost packets, and the first data received after
+// some was sent, like a response, is delayed by the latency.
+type shapedConn struct {
+	net.Conn
+
+	shaper *Shaper
+	sent   uint32 // 1 if data was sent since the latest received
+}
+
+func (c *shapedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		d := c.shaper.received.delay(n) + c.shaper.lossDelay(n)
+		if atomic.CompareAndSwapUint32(&c.sent, 1, 0) {
+			d += c.shaper.delay()
+		}
+		time.Sleep(d)
+	}
+	return n, err
+}
+
+func (c *shapedConn) Write(b []byte) (int, error) {
+	time.Sleep(c.shaper.sent.delay(len(b)) + c.shaper.lossDelay(len(b)))
+	atomic.StoreUint32(&c.sent, 1)
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,168 @@
+package netext
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils/mockresolver"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestParseShaper(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseShaper(map[string]interface{}{"bandwidth": 1000, "latency": "100ms", "jitter": "10ms", "loss": 0.1})
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, s.latency)
+	assert.Equal(t, 10*time.Millisecond, s.jitter)
+	assert.Equal(t, 0.1, s.loss)
+	assert.Equal(t, float64(1000), s.sent.rate)
+	assert.Equal(t, float64(1000), s.received.rate)
+
+	s, err = ParseShaper(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Nil(t, s)
+	assert.Nil(t, NewShaper(nil))
+
+	for _, v := range []interface{}{
+		"100ms",
+		map[string]interface{}{"latency": "fast"},
+		map[string]interface{}{"loss": 1.5},
+		map[string]interface{}{"bandwidth": -1},
+	} {
+		_, err = ParseShaper(v)
+		assert.Error(t, err, v)
+	}
+}
+
+func TestShaperDelay(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseShaper(map[string]interface{}{"latency": "100ms", "jitter": "20ms"})
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		d := s.delay()
+		assert.GreaterOrEqual(t, d, 80*time.Millisecond)
+		assert.LessOrEqual(t, d, 120*time.Millisecond)
+	}
+
+	s, err = ParseShaper(map[string]interface{}{"latency": "10ms", "loss": 0.5})
+	require.NoError(t, err)
+	assert.Zero(t, s.lossDelay(0))
+	lost := s.lossDelay(1000 * shapingSegmentSize)
+	assert.Zero(t, lost%(shapingRetransmitDelay+10*time.Millisecond))
+	assert.Greater(t, lost, 300*(shapingRetransmitDelay+10*time.Millisecond))
+	assert.Less(t, lost, 700*(shapingRetransmitDelay+10*time.Millisecond))
+}
+
+func TestPacer(t *testing.T) {
+	t.Parallel()
+
+	p := &pacer{rate: 1000}
+	d := p.delay(500)
+	assert.InDelta(t, float64(500*time.Millisecond), float64(d), float64(10*time.Millisecond))
+	d = p.delay(500)
+	assert.InDelta(t, float64(time.Second), float64(d), float64(10*time.Millisecond))
+
+	var none *pacer
+	assert.Zero(t, none.delay(500))
+}
+
+func TestShapedConn(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseShaper(map[string]interface{}{"latency": "50ms", "bandwidth": 100000})
+	require.NoError(t, err)
+	client, server := net.Pipe()
+	defer func() { _ = server.Close() }()
+	conn := &shapedConn{Conn: client, shaper: s}
+	defer func() { _ = conn.Close() }()
+
+	go func() {
+		buf := make([]byte, 10000)
+		for {
+			n, err := server.Read(buf)
+			if err != nil {
+				return
+			}
+			if _, err = server.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}()
+
+	// the data is paced to the bandwidth, and the response is delayed by the latency
+	start := time.Now()
+	_, err = conn.Write(make([]byte, 10000))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	start = time.Now()
+	n, err := conn.Read(make([]byte, 10000))
+	require.NoError(t, err)
+	assert.Equal(t, 10000, n)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestDialerShaper(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = c.Close()
+		}
+	}()
+
+	dialer := NewDialer(net.Dialer{}, mockresolver.New(nil))
+	conn, err := dialer.DialContext(context.Background(), "tcp", ln.Addr().String())
+	require.NoError(t, err)
+	assert.IsType(t, &Conn{}, conn)
+	_ = conn.Close()
+
+	shaper, err := ParseShaper(map[string]interface{}{"latency": "50ms"})
+	require.NoError(t, err)
+	assert.Nil(t, dialer.SetShaper(shaper))
+	start := time.Now()
+	conn, err = dialer.DialContext(context.Background(), "tcp", ln.Addr().String())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Same(t, shaper, conn.(*shapedConn).shaper) //nolint:forcetypeassert
+	_ = conn.Close()
+
+	// the clients shape the traffic with their shaper, or with the one of the VU
+	clientShaper, err := ParseShaper(map[string]interface{}{"latency": "1ms"})
+	require.NoError(t, err)
+	clientDialer, err := ClientDialer(dialer, types.DNSConfig{}, nil, clientShaper)
+	require.NoError(t, err)
+	conn, err = clientDialer.DialContext(context.Background(), "tcp", ln.Addr().String())
+	require.NoError(t, err)
+	assert.Same(t, clientShaper, conn.(*shapedConn).shaper) //nolint:forcetypeassert
+	_ = conn.Close()
+
+	conn, err = dialer.WithDNS(nil, nil).DialContext(context.Background(), "tcp", ln.Addr().String())
+	require.NoError(t, err)
+	assert.Same(t, shaper, conn.(*shapedConn).shaper) //nolint:forcetypeassert
+	_ = conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = dialer.DialContext(ctx, "tcp", ln.Addr().String())
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Same(t, shaper, dialer.SetShaper(nil))
+
+	_, err = WithShaper(NewLimitedDialer(dialer, 1), shaper)
+	assert.Error(t, err)
+}
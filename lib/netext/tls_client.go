@@ -0,0 +1,90 @@
+package netext
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+// ErrTLSPinMismatch is returned by the TLS handshakes with the servers that
+// don't have any of the pinned public keys.
+var ErrTLSPinMismatch = errors.New("none of the certificates of the server matches the pinned public keys")
+
+// TLSClientConfig is the TLS configuration of a client, like an HTTP client,
+// that overrides the one of its VU.
+type TLSClientConfig struct {
+	// The CAs that the certificates of the servers are verified with, instead
+	// of the ones of the system.
+	RootCAs *x509.CertPool
+	// The SHA-256 hashes of the public keys (SPKIs) that are pinned: one of
+	// the certificates of the servers has to have one of them.
+	Pins [][]byte
+	// Whether the sessions are resumed, with session tickets or PSKs, or the
+	// handshakes are always full. Go doesn't resume them by default.
+	SessionResumption null.Bool
+	// The range of the TLS versions, 0 for any. It replaces the one of the VU
+	// if any of them is set.
+	MinVersion, MaxVersion uint16
+}
+
+// ParseSPKIPin parses a pinned public key, as the base64 SHA-256 hash of its
+// SPKI, with an optional sha256/ prefix like the one of HPKP.
+func ParseSPKIPin(pin string) ([]byte, error) {
+	hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+	if err != nil || len(hash) != sha256.Size {
+		return nil, fmt.Errorf("invalid pin '%s', it has to be the base64 SHA-256 hash of a public key", pin)
+	}
+	return hash, nil
+}
+
+// AddRootCAs adds the PEM certificates to the CAs of the servers.
+func (c *TLSClientConfig) AddRootCAs(certs []byte) error {
+	if c.RootCAs == nil {
+		c.RootCAs = x509.NewCertPool()
+	}
+	if !c.RootCAs.AppendCertsFromPEM(certs) {
+		return errors.New("no valid PEM certificates were found in the CA certificates")
+	}
+	return nil
+}
+
+// Apply returns a copy of the base configuration with the changes of the
+// client.
+func (c *TLSClientConfig) Apply(base *tls.Config) *tls.Config {
+	config := base.Clone()
+	if c.RootCAs != nil {
+		config.RootCAs = c.RootCAs
+	}
+	if c.MinVersion != 0 || c.MaxVersion != 0 {
+		config.MinVersion, config.MaxVersion = c.MinVersion, c.MaxVersion
+	}
+	if c.SessionResumption.Valid {
+		config.SessionTicketsDisabled = !c.SessionResumption.Bool
+		config.ClientSessionCache = nil
+		if c.SessionResumption.Bool {
+			config.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+		}
+	}
+	if len(c.Pins) > 0 {
+		pins := c.Pins
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, cert := range cs.PeerCertificates {
+				hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+				for _, pin := range pins {
+					if bytes.Equal(hash[:], pin) {
+						return nil
+					}
+				}
+			}
+			return ErrTLSPinMismatch
+		}
+	}
+	return config
+}
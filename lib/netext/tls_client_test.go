@@ -0,0 +1,56 @@
+package netext
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestParseSPKIPin(t *testing.T) {
+	t.Parallel()
+
+	hash := make([]byte, 32)
+	hash[0] = 1
+	pin := base64.StdEncoding.EncodeToString(hash)
+
+	for _, s := range []string{pin, "sha256/" + pin} {
+		parsed, err := ParseSPKIPin(s)
+		require.NoError(t, err)
+		assert.Equal(t, hash, parsed)
+	}
+	for _, s := range []string{"", "sha256/", "not base64", base64.StdEncoding.EncodeToString(hash[:20])} {
+		_, err := ParseSPKIPin(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestTLSClientConfigApply(t *testing.T) {
+	t.Parallel()
+
+	base := &tls.Config{MinVersion: tls.VersionTLS13, ServerName: "example.com"} //nolint:gosec
+	config := (&TLSClientConfig{}).Apply(base)
+	assert.Equal(t, base.MinVersion, config.MinVersion)
+	assert.Equal(t, "example.com", config.ServerName)
+	assert.NotSame(t, base, config)
+
+	config = (&TLSClientConfig{MaxVersion: tls.VersionTLS12}).Apply(base)
+	assert.Equal(t, uint16(0), config.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MaxVersion)
+
+	config = (&TLSClientConfig{SessionResumption: null.BoolFrom(true)}).Apply(base)
+	assert.NotNil(t, config.ClientSessionCache)
+	assert.False(t, config.SessionTicketsDisabled)
+
+	config = (&TLSClientConfig{SessionResumption: null.BoolFrom(false)}).Apply(config)
+	assert.Nil(t, config.ClientSessionCache)
+	assert.True(t, config.SessionTicketsDisabled)
+
+	config = (&TLSClientConfig{Pins: [][]byte{make([]byte, 32)}}).Apply(base)
+	require.NotNil(t, config.VerifyConnection)
+	assert.ErrorIs(t, config.VerifyConnection(tls.ConnectionState{}), ErrTLSPinMismatch)
+	assert.Nil(t, base.VerifyConnection)
+}
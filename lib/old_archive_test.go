@@ -167,13 +167,13 @@ func TestFilenamePwdResolve(t *testing.T) {
 		{
 			Filename:      "ftps://example.com/something/dot.js",
 			Pwd:           "https://example.com/something",
-			expectedError: "only supported schemes for imports are file and https",
+			expectedError: "only supported schemes for imports are file, https, s3, gs and azblob",
 			version:       "0.25.0",
 		},
 		{
 			Filename:      "https://example.com/something/dot.js",
 			Pwd:           "ftps://example.com/something",
-			expectedError: "only supported schemes for imports are file and https",
+			expectedError: "only supported schemes for imports are file, https, s3, gs and azblob",
 			version:       "0.25.0",
 		},
 	}
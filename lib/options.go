@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"time"
 
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
@@ -19,6 +20,22 @@ import (
 // iterations+vus, or stages)
 const DefaultScenarioName = "default"
 
+// The ways the metrics of the end-of-test summary can be broken down by scenario.
+const (
+	ScenarioBreakdownNone       = "none"
+	ScenarioBreakdownSubmetrics = "submetrics"
+	ScenarioBreakdownSections   = "sections"
+)
+
+// The ways the local IPs of the connections are selected from their pool.
+const (
+	// LocalIPsPerVU assigns an IP to each VU, by its local ID.
+	LocalIPsPerVU = "vu"
+	// LocalIPsRoundRobin selects the IPs in turn for the new connections of
+	// all the VUs.
+	LocalIPsRoundRobin = "roundRobin"
+)
+
 // DefaultSummaryTrendStats are the default trend columns shown in the test summary output
 //
 //nolint:gochecknoglobals
@@ -251,9 +268,18 @@ type Options struct {
 	NoTeardown      null.Bool          `json:"noTeardown" envconfig:"K6_NO_TEARDOWN"`
 	TeardownTimeout types.NullDuration `json:"teardownTimeout" envconfig:"K6_TEARDOWN_TIMEOUT"`
 
+	// Maximum size in bytes of the data returned by the setup functions, as JSON; unlimited if it's unset or 0
+	SetupDataMaxSize null.Int `json:"setupDataMaxSize" envconfig:"K6_SETUP_DATA_MAX_SIZE"`
+
 	// Limit HTTP requests per second.
 	RPS null.Int `json:"rps" envconfig:"K6_RPS"`
 
+	// Limit HTTP and gRPC requests per second for specific hosts or tags.
+	RateLimits []RateLimit `json:"rateLimits" ignored:"true"`
+
+	// Inject faults into a share of the HTTP and gRPC requests for specific hosts or tags.
+	Faults []Fault `json:"faults" ignored:"true"`
+
 	// DNS handling configuration.
 	DNS types.DNSConfig `json:"dns" envconfig:"K6_DNS"`
 
@@ -267,6 +293,10 @@ type Options struct {
 	Batch        null.Int `json:"batch" envconfig:"K6_BATCH"`
 	BatchPerHost null.Int `json:"batchPerHost" envconfig:"K6_BATCH_PER_HOST"`
 
+	// How many connections can each VU have open simultaneously, in total and per host? 0 means unlimited.
+	MaxConns        null.Int `json:"maxConns" envconfig:"K6_MAX_CONNS"`
+	MaxConnsPerHost null.Int `json:"maxConnsPerHost" envconfig:"K6_MAX_CONNS_PER_HOST"`
+
 	// Should all HTTP requests and responses be logged (excluding body)?
 	HTTPDebug null.String `json:"httpDebug" envconfig:"K6_HTTP_DEBUG"`
 
@@ -278,6 +308,9 @@ type Options struct {
 	TLSVersion      *TLSVersions     `json:"tlsVersion" ignored:"true"`
 	TLSAuth         []*TLSAuth       `json:"tlsAuth" envconfig:"K6_TLSAUTH"`
 
+	// A pool of client certificates, one of them for each VU. Can't be set through env vars.
+	TLSClientCerts *TLSClientCerts `json:"tlsClientCerts" ignored:"true"`
+
 	// Throw warnings (eg. failed HTTP requests) as errors instead of simply logging them.
 	Throw null.Bool `json:"throw" envconfig:"K6_THROW"`
 
@@ -321,6 +354,25 @@ type Options struct {
 	// Summary time unit for summary metrics (response times) in CLI output
 	SummaryTimeUnit null.String `json:"summaryTimeUnit" envconfig:"K6_SUMMARY_TIME_UNIT"`
 
+	// Tags the metrics of the end-of-test summary are broken down by, e.g. scenario
+	SummaryBreakdownTags []string `json:"summaryBreakdownTags" envconfig:"K6_SUMMARY_BREAKDOWN_TAGS"`
+
+	// How the metrics of the end-of-test summary are broken down by scenario: "submetrics" under
+	// each metric, or "sections" with the metrics of each scenario; "none" by default
+	ScenarioBreakdown null.String `json:"scenarioBreakdown" envconfig:"K6_SCENARIO_BREAKDOWN"`
+
+	// Relative error of the percentiles of the trend metrics, for the summary and the thresholds;
+	// 0 means that all the values are kept, for the exact percentiles
+	TrendPrecision null.Float `json:"trendPrecision" envconfig:"K6_TREND_PRECISION"`
+
+	// Period of the aggregated time series of the metrics, for their trends over time in the
+	// summary, the REST API and the reports; they aren't recorded if it isn't set
+	TimeSeriesResolution types.NullDuration `json:"timeSeriesResolution" envconfig:"K6_TIME_SERIES_RESOLUTION"`
+
+	// Windows of the rates per second of the counters, shown in the summary as rate1m-like stats;
+	// the windows of the thresholds on these stats are added to them
+	CounterRateWindows []types.Duration `json:"counterRateWindows" envconfig:"K6_COUNTER_RATE_WINDOWS"`
+
 	// Which system tags to include with metrics ("method", "vu" etc.)
 	// Use pointer for identifying whether user provide any tag or not.
 	SystemTags *metrics.SystemTagSet `json:"systemTags" envconfig:"K6_SYSTEM_TAGS"`
@@ -328,20 +380,102 @@ type Options struct {
 	// Tags are key-value pairs to be applied to all samples for the run.
 	RunTags map[string]string `json:"tags" envconfig:"K6_TAGS"`
 
+	// Filters of the metrics and the tags of the samples sent to each output, by output type.
+	// Can't be set through env vars.
+	OutputFilters map[string]OutputFilter `json:"outputFilters" ignored:"true"`
+
+	// Aggregation of the samples sent to each output, by output type.
+	// Can't be set through env vars.
+	OutputAggregation map[string]OutputAggregation `json:"outputAggregation" ignored:"true"`
+
+	// Maximum number of time series (distinct tag sets) of each metric; 0 disables the limit
+	CardinalityLimit null.Int `json:"cardinalityLimit" envconfig:"K6_CARDINALITY_LIMIT"`
+
+	// Tags collapsed in the new time series of the metrics over the cardinality limit
+	CardinalityCollapseTags []string `json:"cardinalityCollapseTags" envconfig:"K6_CARDINALITY_COLLAPSE_TAGS"`
+
+	// Gauge metrics calculated from the aggregated values of other metrics, or of their sub-metrics,
+	// by name, e.g. {"error_ratio": "http_req_failed{scenario:api}.rate / http_reqs.rate"}. Their
+	// values are sent to the outputs every 2 seconds, when the metrics are processed for the
+	// summary, the thresholds, the reports or the baseline. Can't be set through env vars.
+	DerivedMetrics map[string]string `json:"derivedMetrics" ignored:"true"`
+
+	// How much the aggregated values of the metrics can regress from the baseline of --baseline,
+	// e.g. {"http_req_duration.p(95)": "10%"}. Can't be set through env vars.
+	BaselineTolerances map[string]string `json:"baselineTolerances" ignored:"true"`
+
+	// Service level objectives, by name, with their compliance and burn rate metrics, e.g.
+	// {"api_latency": {"metric": "http_req_duration", "condition": "<300", "objective": 0.99}}.
+	// Can't be set through env vars.
+	SLOs map[string]SLO `json:"slos" ignored:"true"`
+
 	// Buffer size of the channel for metric samples; 0 means unbuffered
 	MetricSamplesBufferSize null.Int `json:"metricSamplesBufferSize" envconfig:"K6_METRIC_SAMPLES_BUFFER_SIZE"`
 
 	// Do not reset cookies after a VU iteration
 	NoCookiesReset null.Bool `json:"noCookiesReset" envconfig:"K6_NO_COOKIES_RESET"`
 
+	// Emulate a browser cache, serving the cacheable HTTP responses from a per-VU cache
+	HTTPCache null.Bool `json:"httpCache" envconfig:"K6_HTTP_CACHE"`
+
+	// Capture the details of the failed requests to a file, in the HAR format
+	// if its name ends with .har and as JSON lines of HAR entries otherwise
+	CaptureRequests null.String `json:"captureRequests" envconfig:"K6_CAPTURE_REQUESTS"`
+
+	// Also capture the successful requests that take at least this long
+	CaptureSlowerThan types.NullDuration `json:"captureSlowerThan" envconfig:"K6_CAPTURE_SLOWER_THAN"`
+
+	// The size limit of the file with the captured requests, in bytes
+	CaptureMaxSize null.Int `json:"captureMaxSize" envconfig:"K6_CAPTURE_MAX_SIZE"`
+
+	// Export the requests and their responses to a HAR file
+	HAROut null.String `json:"harOut" envconfig:"K6_HAR_OUT"`
+
+	// The probability of a request being exported to the HAR file, the failed ones are always exported
+	HARSampleRate null.Float `json:"harSampleRate" envconfig:"K6_HAR_SAMPLE_RATE"`
+
+	// Emit the metrics about the health of k6 itself, like the lag of the event loops of the VUs
+	SelfMetrics null.Bool `json:"selfMetrics" envconfig:"K6_SELF_METRICS"`
+
+	// How late the iterations of the arrival-rate executors can start before k6 warns that it's saturated
+	SchedulingDelayBudget types.NullDuration `json:"schedulingDelayBudget" envconfig:"K6_SCHEDULING_DELAY_BUDGET"`
+
+	// Skip the iterations that are later than the budget, capping the arrival rates to what k6 can generate
+	AutoCalibrate null.Bool `json:"autoCalibrate" envconfig:"K6_AUTO_CALIBRATE"`
+
+	// The memory k6 can use, in bytes, before the test run is aborted; new iterations are delayed close to it
+	MaxMemory null.Int `json:"maxMemory" envconfig:"K6_MAX_MEMORY"`
+
+	// The metadata of the test run, added to the tags of all of the samples and to the summary
+	TestName        null.String `json:"testName" envconfig:"K6_TEST_NAME"`
+	RunID           null.String `json:"runId" envconfig:"K6_RUN_ID"`
+	TestVersion     null.String `json:"testVersion" envconfig:"K6_TEST_VERSION"`
+	TestEnvironment null.String `json:"testEnvironment" envconfig:"K6_TEST_ENVIRONMENT"`
+
+	// Redact the sensitive data, like the values of some headers and fields, from the logs and the captured requests
+	Redact *RedactionRules `json:"redact" ignored:"true"`
+
+	// The custom exit codes for the outcomes of the test run
+	ExitCodes *ExitCodes `json:"exitCodes" ignored:"true"`
+
 	// Discard Http Responses Body
 	DiscardResponseBodies null.Bool `json:"discardResponseBodies" envconfig:"K6_DISCARD_RESPONSE_BODIES"`
 
+	// Add a random correlation ID to the metadata of each iteration, as correlation_id
+	CorrelationID null.Bool `json:"correlationID" envconfig:"K6_CORRELATION_ID"`
+
+	// The HTTP headers, and gRPC metadata, that the metadata of the VUs is sent as, by metadata
+	// key, e.g. {"user": "X-User-ID"}. Can't be set through env vars.
+	MetadataHeaders map[string]string `json:"metadataHeaders" ignored:"true"`
+
 	// Redirect console logging to a file
 	ConsoleOutput null.String `json:"-" envconfig:"K6_CONSOLE_OUTPUT"`
 
 	// Specify client IP ranges and/or CIDR from which VUs will make requests
 	LocalIPs types.NullIPPool `json:"-" envconfig:"K6_LOCAL_IPS"`
+
+	// How the local IPs are selected from their pool: "vu" (the default) or "roundRobin"
+	LocalIPsSelect null.String `json:"localIPsSelect" envconfig:"K6_LOCAL_IPS_SELECT"`
 }
 
 // Apply returns the result of overwriting any fields with any that are set on the argument.
@@ -415,9 +549,18 @@ func (o Options) Apply(opts Options) Options {
 	if opts.TeardownTimeout.Valid {
 		o.TeardownTimeout = opts.TeardownTimeout
 	}
+	if opts.SetupDataMaxSize.Valid {
+		o.SetupDataMaxSize = opts.SetupDataMaxSize
+	}
 	if opts.RPS.Valid {
 		o.RPS = opts.RPS
 	}
+	if opts.RateLimits != nil {
+		o.RateLimits = opts.RateLimits
+	}
+	if opts.Faults != nil {
+		o.Faults = opts.Faults
+	}
 	if opts.MaxRedirects.Valid {
 		o.MaxRedirects = opts.MaxRedirects
 	}
@@ -430,6 +573,12 @@ func (o Options) Apply(opts Options) Options {
 	if opts.BatchPerHost.Valid {
 		o.BatchPerHost = opts.BatchPerHost
 	}
+	if opts.MaxConns.Valid {
+		o.MaxConns = opts.MaxConns
+	}
+	if opts.MaxConnsPerHost.Valid {
+		o.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
 	if opts.HTTPDebug.Valid {
 		o.HTTPDebug = opts.HTTPDebug
 	}
@@ -445,6 +594,9 @@ func (o Options) Apply(opts Options) Options {
 	if opts.TLSAuth != nil {
 		o.TLSAuth = opts.TLSAuth
 	}
+	if opts.TLSClientCerts != nil {
+		o.TLSClientCerts = opts.TLSClientCerts
+	}
 	if opts.Throw.Valid {
 		o.Throw = opts.Throw
 	}
@@ -484,24 +636,117 @@ func (o Options) Apply(opts Options) Options {
 	if opts.SummaryTimeUnit.Valid {
 		o.SummaryTimeUnit = opts.SummaryTimeUnit
 	}
+	if opts.SummaryBreakdownTags != nil {
+		o.SummaryBreakdownTags = opts.SummaryBreakdownTags
+	}
+	if opts.ScenarioBreakdown.Valid {
+		o.ScenarioBreakdown = opts.ScenarioBreakdown
+	}
+	if opts.TrendPrecision.Valid {
+		o.TrendPrecision = opts.TrendPrecision
+	}
+	if opts.TimeSeriesResolution.Valid {
+		o.TimeSeriesResolution = opts.TimeSeriesResolution
+	}
+	if opts.CounterRateWindows != nil {
+		o.CounterRateWindows = opts.CounterRateWindows
+	}
 	if opts.SystemTags != nil {
 		o.SystemTags = opts.SystemTags
 	}
 	if len(opts.RunTags) > 0 {
 		o.RunTags = opts.RunTags
 	}
+	if len(opts.OutputFilters) > 0 {
+		o.OutputFilters = opts.OutputFilters
+	}
+	if len(opts.OutputAggregation) > 0 {
+		o.OutputAggregation = opts.OutputAggregation
+	}
+	if opts.CardinalityLimit.Valid {
+		o.CardinalityLimit = opts.CardinalityLimit
+	}
+	if opts.CardinalityCollapseTags != nil {
+		o.CardinalityCollapseTags = opts.CardinalityCollapseTags
+	}
+	if len(opts.DerivedMetrics) > 0 {
+		o.DerivedMetrics = opts.DerivedMetrics
+	}
+	if len(opts.BaselineTolerances) > 0 {
+		o.BaselineTolerances = opts.BaselineTolerances
+	}
+	if len(opts.SLOs) > 0 {
+		o.SLOs = opts.SLOs
+	}
 	if opts.MetricSamplesBufferSize.Valid {
 		o.MetricSamplesBufferSize = opts.MetricSamplesBufferSize
 	}
+	if opts.HTTPCache.Valid {
+		o.HTTPCache = opts.HTTPCache
+	}
+	if opts.CaptureRequests.Valid {
+		o.CaptureRequests = opts.CaptureRequests
+	}
+	if opts.CaptureSlowerThan.Valid {
+		o.CaptureSlowerThan = opts.CaptureSlowerThan
+	}
+	if opts.CaptureMaxSize.Valid {
+		o.CaptureMaxSize = opts.CaptureMaxSize
+	}
+	if opts.HAROut.Valid {
+		o.HAROut = opts.HAROut
+	}
+	if opts.HARSampleRate.Valid {
+		o.HARSampleRate = opts.HARSampleRate
+	}
+	if opts.SelfMetrics.Valid {
+		o.SelfMetrics = opts.SelfMetrics
+	}
+	if opts.SchedulingDelayBudget.Valid {
+		o.SchedulingDelayBudget = opts.SchedulingDelayBudget
+	}
+	if opts.AutoCalibrate.Valid {
+		o.AutoCalibrate = opts.AutoCalibrate
+	}
+	if opts.MaxMemory.Valid {
+		o.MaxMemory = opts.MaxMemory
+	}
+	if opts.TestName.Valid {
+		o.TestName = opts.TestName
+	}
+	if opts.RunID.Valid {
+		o.RunID = opts.RunID
+	}
+	if opts.TestVersion.Valid {
+		o.TestVersion = opts.TestVersion
+	}
+	if opts.TestEnvironment.Valid {
+		o.TestEnvironment = opts.TestEnvironment
+	}
+	if opts.Redact != nil {
+		o.Redact = opts.Redact
+	}
+	if opts.ExitCodes != nil {
+		o.ExitCodes = opts.ExitCodes
+	}
 	if opts.DiscardResponseBodies.Valid {
 		o.DiscardResponseBodies = opts.DiscardResponseBodies
 	}
+	if opts.CorrelationID.Valid {
+		o.CorrelationID = opts.CorrelationID
+	}
+	if len(opts.MetadataHeaders) > 0 {
+		o.MetadataHeaders = opts.MetadataHeaders
+	}
 	if opts.ConsoleOutput.Valid {
 		o.ConsoleOutput = opts.ConsoleOutput
 	}
 	if opts.LocalIPs.Valid {
 		o.LocalIPs = opts.LocalIPs
 	}
+	if opts.LocalIPsSelect.Valid {
+		o.LocalIPsSelect = opts.LocalIPsSelect
+	}
 	if opts.DNS.TTL.Valid {
 		o.DNS.TTL = opts.DNS.TTL
 	}
@@ -534,6 +779,106 @@ func (o Options) Validate() []error {
 					o.ExecutionSegment, o.ExecutionSegmentSequence))
 		}
 	}
+	if o.TrendPrecision.Valid && (o.TrendPrecision.Float64 < 0 || o.TrendPrecision.Float64 >= 1) {
+		errors = append(errors,
+			fmt.Errorf("trendPrecision must be between 0 and 1, not %g", o.TrendPrecision.Float64))
+	}
+	if o.TimeSeriesResolution.Valid && o.TimeSeriesResolution.Duration <= 0 {
+		errors = append(errors,
+			fmt.Errorf("timeSeriesResolution must be positive, not %s", o.TimeSeriesResolution.Duration))
+	}
+	if o.ScenarioBreakdown.Valid {
+		switch o.ScenarioBreakdown.String {
+		case ScenarioBreakdownNone, ScenarioBreakdownSubmetrics, ScenarioBreakdownSections:
+		default:
+			errors = append(errors, fmt.Errorf("scenarioBreakdown must be one of %s, %s or %s, not %q",
+				ScenarioBreakdownNone, ScenarioBreakdownSubmetrics, ScenarioBreakdownSections,
+				o.ScenarioBreakdown.String))
+		}
+	}
+	for _, w := range o.CounterRateWindows {
+		if err := metrics.ValidateCounterRateWindow(time.Duration(w)); err != nil {
+			errors = append(errors, fmt.Errorf("invalid counterRateWindows: %w", err))
+		}
+	}
+	if o.SetupDataMaxSize.Valid && o.SetupDataMaxSize.Int64 < 0 {
+		errors = append(errors,
+			fmt.Errorf("setupDataMaxSize can't be negative, use 0 to disable it, not %d", o.SetupDataMaxSize.Int64))
+	}
+	if o.CardinalityLimit.Valid && o.CardinalityLimit.Int64 < 0 {
+		errors = append(errors,
+			fmt.Errorf("cardinalityLimit can't be negative, use 0 to disable it, not %d", o.CardinalityLimit.Int64))
+	}
+	if o.CaptureSlowerThan.Valid && o.CaptureSlowerThan.Duration < 0 {
+		errors = append(errors,
+			fmt.Errorf("captureSlowerThan can't be negative, not %s", o.CaptureSlowerThan.Duration))
+	}
+	if o.CaptureMaxSize.Valid && o.CaptureMaxSize.Int64 <= 0 {
+		errors = append(errors,
+			fmt.Errorf("captureMaxSize must be positive, not %d", o.CaptureMaxSize.Int64))
+	}
+	if o.HARSampleRate.Valid && (o.HARSampleRate.Float64 < 0 || o.HARSampleRate.Float64 > 1) {
+		errors = append(errors,
+			fmt.Errorf("harSampleRate must be between 0 and 1, not %g", o.HARSampleRate.Float64))
+	}
+	if o.SchedulingDelayBudget.Valid && o.SchedulingDelayBudget.Duration <= 0 {
+		errors = append(errors,
+			fmt.Errorf("schedulingDelayBudget must be positive, not %s", o.SchedulingDelayBudget.Duration))
+	}
+	if o.MaxMemory.Valid && o.MaxMemory.Int64 < 0 {
+		errors = append(errors,
+			fmt.Errorf("maxMemory can't be negative, use 0 to disable it, not %d", o.MaxMemory.Int64))
+	}
+	if o.ExitCodes != nil {
+		if err := o.ExitCodes.Validate(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	for name, expression := range o.DerivedMetrics {
+		if _, err := metrics.ParseDerivedExpression(expression); err != nil {
+			errors = append(errors, fmt.Errorf("invalid derived metric '%s': %w", name, err))
+		}
+	}
+	for key, tolerance := range o.BaselineTolerances {
+		if _, _, err := SplitBaselineKey(key); err != nil {
+			errors = append(errors, err)
+		} else if _, err := ParseBaselineTolerance(tolerance); err != nil {
+			errors = append(errors, fmt.Errorf("invalid baseline tolerance of '%s': %w", key, err))
+		}
+	}
+	if o.TLSClientCerts != nil {
+		if err := o.TLSClientCerts.Validate(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if o.LocalIPsSelect.Valid {
+		switch o.LocalIPsSelect.String {
+		case LocalIPsPerVU, LocalIPsRoundRobin:
+		default:
+			errors = append(errors, fmt.Errorf("localIPsSelect must be %s or %s, not %q",
+				LocalIPsPerVU, LocalIPsRoundRobin, o.LocalIPsSelect.String))
+		}
+	}
+	for key, header := range o.MetadataHeaders {
+		if key == "" || header == "" {
+			errors = append(errors, fmt.Errorf("invalid metadata header '%s': '%s', they can't be empty", key, header))
+		}
+	}
+	for name, slo := range o.SLOs {
+		if err := slo.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid SLO '%s': %w", name, err))
+		}
+	}
+	for outputType, filter := range o.OutputFilters {
+		if err := filter.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid filter of the '%s' output: %w", outputType, err))
+		}
+	}
+	for outputType, aggregation := range o.OutputAggregation {
+		if err := aggregation.Validate(); err != nil {
+			errors = append(errors, fmt.Errorf("invalid aggregation of the '%s' output: %w", outputType, err))
+		}
+	}
 	return append(errors, o.Scenarios.Validate()...)
 }
 
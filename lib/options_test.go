@@ -75,6 +75,32 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.RPS.Valid)
 		assert.Equal(t, int64(12345), opts.RPS.Int64)
 	})
+	t.Run("RateLimits", func(t *testing.T) {
+		t.Parallel()
+		limits := []RateLimit{{Host: null.StringFrom("*.example.com"), RPS: null.IntFrom(10)}}
+		opts := Options{}.Apply(Options{RateLimits: limits})
+		assert.Equal(t, limits, opts.RateLimits)
+
+		var jsonOpts Options
+		require.NoError(t, json.Unmarshal(
+			[]byte(`{"rateLimits":[{"host":"*.example.com","rps":10}]}`), &jsonOpts))
+		assert.Equal(t, limits, jsonOpts.RateLimits)
+	})
+	t.Run("Faults", func(t *testing.T) {
+		t.Parallel()
+		faults := []Fault{{
+			Host:  null.StringFrom("*.example.com"),
+			Rate:  null.FloatFrom(0.1),
+			Delay: types.NullDurationFrom(2 * time.Second),
+		}}
+		opts := Options{}.Apply(Options{Faults: faults})
+		assert.Equal(t, faults, opts.Faults)
+
+		var jsonOpts Options
+		require.NoError(t, json.Unmarshal(
+			[]byte(`{"faults":[{"host":"*.example.com","rate":0.1,"delay":"2s"}]}`), &jsonOpts))
+		assert.Equal(t, faults, jsonOpts.Faults)
+	})
 	t.Run("MaxRedirects", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{MaxRedirects: null.IntFrom(12345)})
@@ -99,6 +125,18 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.BatchPerHost.Valid)
 		assert.Equal(t, int64(12345), opts.BatchPerHost.Int64)
 	})
+	t.Run("MaxConns", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{MaxConns: null.IntFrom(12345)})
+		assert.True(t, opts.MaxConns.Valid)
+		assert.Equal(t, int64(12345), opts.MaxConns.Int64)
+	})
+	t.Run("MaxConnsPerHost", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{MaxConnsPerHost: null.IntFrom(12345)})
+		assert.True(t, opts.MaxConnsPerHost.Valid)
+		assert.Equal(t, int64(12345), opts.MaxConnsPerHost.Int64)
+	})
 	t.Run("HTTPDebug", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{HTTPDebug: null.StringFrom("foo")})
@@ -549,6 +587,88 @@ func TestOptions(t *testing.T) {
 		opts := Options{}.Apply(Options{RunTags: tags})
 		assert.Equal(t, tags, opts.RunTags)
 	})
+	t.Run("OutputFilters", func(t *testing.T) {
+		t.Parallel()
+		filters := map[string]OutputFilter{"json": {DenyTags: []string{"url"}}}
+		opts := Options{}.Apply(Options{OutputFilters: filters})
+		assert.Equal(t, filters, opts.OutputFilters)
+		assert.Empty(t, opts.Validate())
+
+		opts = Options{OutputFilters: map[string]OutputFilter{"csv": {IncludeMetrics: []string{"["}}}}
+		errs := opts.Validate()
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], "invalid filter of the 'csv' output: invalid metric pattern '[': syntax error in pattern")
+	})
+	t.Run("CardinalityLimit", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{CardinalityLimit: null.IntFrom(100), CardinalityCollapseTags: []string{"url"}})
+		assert.Equal(t, null.IntFrom(100), opts.CardinalityLimit)
+		assert.Equal(t, []string{"url"}, opts.CardinalityCollapseTags)
+		assert.Empty(t, opts.Validate())
+
+		errs := Options{CardinalityLimit: null.IntFrom(-1)}.Validate()
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], "cardinalityLimit can't be negative, use 0 to disable it, not -1")
+	})
+	t.Run("TimeSeriesResolution", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{TimeSeriesResolution: types.NullDurationFrom(5 * time.Second)})
+		assert.Equal(t, types.NullDurationFrom(5*time.Second), opts.TimeSeriesResolution)
+		assert.Empty(t, opts.Validate())
+
+		errs := Options{TimeSeriesResolution: types.NullDurationFrom(0)}.Validate()
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], "timeSeriesResolution must be positive, not 0s")
+	})
+	t.Run("DerivedMetrics", func(t *testing.T) {
+		t.Parallel()
+		derived := map[string]string{"error_ratio": "http_req_failed.rate / http_reqs.rate"}
+		opts := Options{}.Apply(Options{DerivedMetrics: derived})
+		assert.Equal(t, derived, opts.DerivedMetrics)
+		assert.Empty(t, opts.Validate())
+
+		errs := Options{DerivedMetrics: map[string]string{"error_ratio": "http_req_failed.rate /"}}.Validate()
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "invalid derived metric 'error_ratio': parsing derived metric expression failed")
+	})
+	t.Run("BaselineTolerances", func(t *testing.T) {
+		t.Parallel()
+		tolerances := map[string]string{"http_req_duration.p(95)": "10%", "http_reqs.rate": "-5%"}
+		opts := Options{}.Apply(Options{BaselineTolerances: tolerances})
+		assert.Equal(t, tolerances, opts.BaselineTolerances)
+		assert.Empty(t, opts.Validate())
+
+		errs := Options{BaselineTolerances: map[string]string{"http_req_duration.p(95)": "ten"}}.Validate()
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "invalid baseline tolerance of 'http_req_duration.p(95)'")
+
+		errs = Options{BaselineTolerances: map[string]string{"http_req_duration": "10%"}}.Validate()
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "invalid baseline tolerance key 'http_req_duration'")
+	})
+	t.Run("SLOs", func(t *testing.T) {
+		t.Parallel()
+		slos := map[string]SLO{"api_latency": {Metric: "http_req_duration", Condition: "<300", Objective: 0.99}}
+		opts := Options{}.Apply(Options{SLOs: slos})
+		assert.Equal(t, slos, opts.SLOs)
+		assert.Empty(t, opts.Validate())
+
+		errs := Options{SLOs: map[string]SLO{"api_latency": {Metric: "http_req_duration", Objective: 0.99}}}.Validate()
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "invalid SLO 'api_latency': invalid condition ''")
+	})
+	t.Run("OutputAggregation", func(t *testing.T) {
+		t.Parallel()
+		aggregation := map[string]OutputAggregation{"json": {Tags: []string{"status"}}}
+		opts := Options{}.Apply(Options{OutputAggregation: aggregation})
+		assert.Equal(t, aggregation, opts.OutputAggregation)
+		assert.Empty(t, opts.Validate())
+
+		opts = Options{OutputAggregation: map[string]OutputAggregation{"csv": {TrendStats: []string{"p(101)"}}}}
+		errs := opts.Validate()
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "invalid aggregation of the 'csv' output: invalid percentile trend stat value")
+	})
 	t.Run("DiscardResponseBodies", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{DiscardResponseBodies: null.BoolFrom(true)})
@@ -563,6 +683,16 @@ func TestOptions(t *testing.T) {
 		opts := Options{}.Apply(Options{LocalIPs: clientIPRanges})
 		assert.NotNil(t, opts.LocalIPs)
 	})
+	t.Run("LocalIPsSelect", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{LocalIPsSelect: null.StringFrom(LocalIPsRoundRobin)})
+		assert.Equal(t, null.StringFrom("roundRobin"), opts.LocalIPsSelect)
+		assert.Empty(t, opts.Validate())
+
+		errs := Options{LocalIPsSelect: null.StringFrom("random")}.Validate()
+		require.Len(t, errs, 1)
+		assert.EqualError(t, errs[0], `localIPsSelect must be vu or roundRobin, not "random"`)
+	})
 }
 
 func TestOptionsEnv(t *testing.T) {
@@ -611,6 +741,14 @@ func TestOptionsEnv(t *testing.T) {
 			"":    null.Int{},
 			"123": null.IntFrom(123),
 		},
+		{"MaxConns", "K6_MAX_CONNS"}: {
+			"":   null.Int{},
+			"50": null.IntFrom(50),
+		},
+		{"MaxConnsPerHost", "K6_MAX_CONNS_PER_HOST"}: {
+			"":   null.Int{},
+			"10": null.IntFrom(10),
+		},
 		{"NoSetup", "K6_NO_SETUP"}: {
 			"":      null.Bool{},
 			"true":  null.BoolFrom(true),
@@ -648,11 +786,42 @@ func TestOptionsEnv(t *testing.T) {
 			"192.168.220.2":    mustNullIPPool("192.168.220.2"),
 			"192.168.220.0/24": mustNullIPPool("192.168.220.0/24"),
 		},
+		{"LocalIPsSelect", "K6_LOCAL_IPS_SELECT"}: {
+			"":           null.String{},
+			"roundRobin": null.StringFrom("roundRobin"),
+		},
 		{"Throw", "K6_THROW"}: {
 			"":      null.Bool{},
 			"true":  null.BoolFrom(true),
 			"false": null.BoolFrom(false),
 		},
+		{"TrendPrecision", "K6_TREND_PRECISION"}: {
+			"":      null.Float{},
+			"0":     null.FloatFrom(0),
+			"0.005": null.FloatFrom(0.005),
+		},
+		{"TimeSeriesResolution", "K6_TIME_SERIES_RESOLUTION"}: {
+			"":   types.NullDuration{},
+			"5s": types.NullDurationFrom(5 * time.Second),
+		},
+		{"SummaryBreakdownTags", "K6_SUMMARY_BREAKDOWN_TAGS"}: {
+			"":               []string{},
+			"scenario,group": []string{"scenario", "group"},
+		},
+		{"CardinalityLimit", "K6_CARDINALITY_LIMIT"}: {
+			"":      null.Int{},
+			"0":     null.IntFrom(0),
+			"50000": null.IntFrom(50000),
+		},
+		{"CardinalityCollapseTags", "K6_CARDINALITY_COLLAPSE_TAGS"}: {
+			"":         []string{},
+			"url,name": []string{"url", "name"},
+		},
+		{"HTTPCache", "K6_HTTP_CACHE"}: {
+			"":      null.Bool{},
+			"true":  null.BoolFrom(true),
+			"false": null.BoolFrom(false),
+		},
 		{"NoCookiesReset", "K6_NO_COOKIES_RESET"}: {
 			"":      null.Bool{},
 			"true":  null.BoolFrom(true),
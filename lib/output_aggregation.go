@@ -0,0 +1,90 @@
+package lib
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// DefaultOutputAggregationPeriod is the period of the aggregation of the
+// samples sent to an output, if it isn't configured.
+const DefaultOutputAggregationPeriod = 10 * time.Second
+
+// DefaultOutputAggregationWaitPeriod is how long the samples of a period are
+// waited for after its end, if it isn't configured.
+const DefaultOutputAggregationWaitPeriod = 2 * time.Second
+
+// OutputAggregation configures the aggregation of the samples before they are
+// sent to an output: instead of every sample, the output receives the
+// aggregated values of each metric and dimension, once per period.
+type OutputAggregation struct {
+	// Period is the length of the time buckets of the aggregation.
+	Period types.NullDuration `json:"period"`
+	// WaitPeriod is how long after the end of a period its aggregated values
+	// are sent, so the samples that arrive late are still in them.
+	WaitPeriod types.NullDuration `json:"waitPeriod"`
+	// Tags are the dimensions of the aggregation, the other tags are removed.
+	// All the tags are kept if it's not set, none of them if it's empty.
+	Tags []string `json:"tags"`
+	// RawMetrics are the metrics whose samples are sent to the output without
+	// aggregation. The metric names can be patterns with * wildcards.
+	RawMetrics []string `json:"rawMetrics,omitempty"`
+	// TrendStats are the values of the trend metrics sent to the output, in
+	// addition to the count of the samples.
+	TrendStats []string `json:"trendStats,omitempty"`
+}
+
+// Validate checks the period, the metric patterns and the trend stats.
+func (a OutputAggregation) Validate() error {
+	if a.Period.Valid && a.Period.Duration <= 0 {
+		return fmt.Errorf("the aggregation period must be positive, not %s", a.Period.Duration.String())
+	}
+	if a.WaitPeriod.Valid && a.WaitPeriod.Duration < 0 {
+		return fmt.Errorf("the aggregation wait period can't be negative, not %s", a.WaitPeriod.Duration.String())
+	}
+	for _, p := range a.RawMetrics {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid metric pattern '%s': %w", p, err)
+		}
+	}
+	_, err := metrics.GetResolversForTrendColumns(a.TrendStats)
+	return err
+}
+
+// GetPeriod returns the configured period, or the default one.
+func (a OutputAggregation) GetPeriod() time.Duration {
+	if a.Period.Valid {
+		return a.Period.TimeDuration()
+	}
+	return DefaultOutputAggregationPeriod
+}
+
+// GetWaitPeriod returns the configured wait period, or the default one.
+func (a OutputAggregation) GetWaitPeriod() time.Duration {
+	if a.WaitPeriod.Valid {
+		return a.WaitPeriod.TimeDuration()
+	}
+	return DefaultOutputAggregationWaitPeriod
+}
+
+// GetTrendStats returns the configured trend stats, or the default ones of the
+// end-of-test summary.
+func (a OutputAggregation) GetTrendStats() []string {
+	if len(a.TrendStats) > 0 {
+		return a.TrendStats
+	}
+	return DefaultSummaryTrendStats
+}
+
+// AggregatesMetric returns true if the samples of the metric are aggregated.
+func (a OutputAggregation) AggregatesMetric(name string) bool {
+	return !matchesAny(a.RawMetrics, name)
+}
+
+// KeepsTag returns true if the tag is one of the dimensions of the aggregation.
+func (a OutputAggregation) KeepsTag(key string) bool {
+	return a.Tags == nil || contains(a.Tags, key)
+}
@@ -0,0 +1,48 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestOutputAggregation(t *testing.T) {
+	t.Parallel()
+
+	a := OutputAggregation{}
+	assert.NoError(t, a.Validate())
+	assert.Equal(t, DefaultOutputAggregationPeriod, a.GetPeriod())
+	assert.Equal(t, DefaultOutputAggregationWaitPeriod, a.GetWaitPeriod())
+	assert.Equal(t, DefaultSummaryTrendStats, a.GetTrendStats())
+	assert.True(t, a.AggregatesMetric("http_reqs"))
+	assert.True(t, a.KeepsTag("url"))
+
+	a = OutputAggregation{
+		Period:     types.NullDurationFrom(time.Minute),
+		WaitPeriod: types.NullDurationFrom(0),
+		Tags:       []string{},
+		RawMetrics: []string{"checks", "http_req_*"},
+		TrendStats: []string{"p(99)"},
+	}
+	assert.NoError(t, a.Validate())
+	assert.Equal(t, time.Minute, a.GetPeriod())
+	assert.Equal(t, time.Duration(0), a.GetWaitPeriod())
+	assert.Equal(t, []string{"p(99)"}, a.GetTrendStats())
+	assert.False(t, a.AggregatesMetric("http_req_duration"))
+	assert.True(t, a.AggregatesMetric("http_reqs"))
+	assert.False(t, a.KeepsTag("url"))
+
+	assert.True(t, OutputAggregation{Tags: []string{"status"}}.KeepsTag("status"))
+
+	assert.EqualError(t, OutputAggregation{Period: types.NullDurationFrom(0)}.Validate(),
+		"the aggregation period must be positive, not 0s")
+	assert.EqualError(t, OutputAggregation{WaitPeriod: types.NullDurationFrom(-time.Second)}.Validate(),
+		"the aggregation wait period can't be negative, not -1s")
+	assert.EqualError(t, OutputAggregation{RawMetrics: []string{"["}}.Validate(),
+		"invalid metric pattern '[': syntax error in pattern")
+	assert.EqualError(t, OutputAggregation{TrendStats: []string{"p99"}}.Validate(),
+		"invalid trend stat 'p99', unknown format")
+}
@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"fmt"
+	"path"
+)
+
+// OutputFilter selects the metrics whose samples are sent to an output, and
+// the tags of the samples, e.g. to drop the high-cardinality tags only for a
+// metrics database. The metric names can be patterns with * wildcards.
+type OutputFilter struct {
+	// IncludeMetrics are the only metrics sent to the output, if any.
+	IncludeMetrics []string `json:"includeMetrics,omitempty"`
+	// ExcludeMetrics are the metrics that aren't sent to the output, even if
+	// they are included.
+	ExcludeMetrics []string `json:"excludeMetrics,omitempty"`
+	// AllowTags are the only tags the samples keep, if any.
+	AllowTags []string `json:"allowTags,omitempty"`
+	// DenyTags are the tags removed from the samples, even if they are allowed.
+	DenyTags []string `json:"denyTags,omitempty"`
+}
+
+// Validate checks that the metric patterns are valid.
+func (f OutputFilter) Validate() error {
+	for _, patterns := range [][]string{f.IncludeMetrics, f.ExcludeMetrics} {
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid metric pattern '%s': %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// IncludesMetric returns true if the samples of the metric are sent to the
+// output.
+func (f OutputFilter) IncludesMetric(name string) bool {
+	if len(f.IncludeMetrics) > 0 && !matchesAny(f.IncludeMetrics, name) {
+		return false
+	}
+	return !matchesAny(f.ExcludeMetrics, name)
+}
+
+// KeepsTag returns true if the samples keep the tag.
+func (f OutputFilter) KeepsTag(key string) bool {
+	if len(f.AllowTags) > 0 && !contains(f.AllowTags, key) {
+		return false
+	}
+	return !contains(f.DenyTags, key)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputFilter(t *testing.T) {
+	t.Parallel()
+
+	f := OutputFilter{
+		IncludeMetrics: []string{"http_req_*", "iterations"},
+		ExcludeMetrics: []string{"http_req_tls_*"},
+		AllowTags:      []string{"status", "url", "method"},
+		DenyTags:       []string{"url"},
+	}
+	assert.NoError(t, f.Validate())
+	assert.True(t, f.IncludesMetric("http_req_duration"))
+	assert.True(t, f.IncludesMetric("iterations"))
+	assert.False(t, f.IncludesMetric("http_req_tls_handshaking"))
+	assert.False(t, f.IncludesMetric("vus"))
+	assert.True(t, f.KeepsTag("status"))
+	assert.False(t, f.KeepsTag("url"))
+	assert.False(t, f.KeepsTag("scenario"))
+
+	all := OutputFilter{}
+	assert.True(t, all.IncludesMetric("vus"))
+	assert.True(t, all.KeepsTag("scenario"))
+
+	assert.EqualError(t, OutputFilter{ExcludeMetrics: []string{"http_req_[a"}}.Validate(),
+		"invalid metric pattern 'http_req_[a': syntax error in pattern")
+}
@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/time/rate"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// RateLimit describes a client-side requests per second limit, that's applied
+// to the requests for hosts matching the Host pattern, to the requests with
+// all of the specified Tags, or to the requests matching both criteria.
+//
+// A separate token bucket is used for every host matching the Host pattern,
+// while all requests matching a tags-only limit share a single bucket.
+type RateLimit struct {
+	Host  null.String       `json:"host"`
+	Tags  map[string]string `json:"tags"`
+	RPS   null.Int          `json:"rps"`
+	Burst null.Int          `json:"burst"`
+}
+
+// Validate checks if the limit makes sense.
+func (rl RateLimit) Validate() error {
+	if !rl.Host.Valid && len(rl.Tags) == 0 {
+		return errors.New("either a host pattern or tags need to be specified")
+	}
+	if !rl.RPS.Valid || rl.RPS.Int64 <= 0 {
+		return errors.New("the rps value needs to be positive")
+	}
+	if rl.Burst.Valid && rl.Burst.Int64 <= 0 {
+		return errors.New("the burst value needs to be positive")
+	}
+	return nil
+}
+
+type rateLimitRule struct {
+	RateLimit
+	hosts *types.HostnameTrie
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// RateLimiters applies a set of RateLimit rules. It's shared between all VUs,
+// so the limits apply to the whole k6 instance.
+type RateLimiters struct {
+	rules []*rateLimitRule
+}
+
+// NewRateLimiters returns the RateLimiters for the given limits, or nil if
+// there are no limits.
+func NewRateLimiters(limits []RateLimit) (*RateLimiters, error) {
+	if len(limits) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+	rl := &RateLimiters{rules: make([]*rateLimitRule, 0, len(limits))}
+	for i, limit := range limits {
+		if err := limit.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid rate limit %d: %w", i, err)
+		}
+		rule := &rateLimitRule{RateLimit: limit, limiters: make(map[string]*rate.Limiter)}
+		if limit.Host.Valid {
+			hosts, err := types.NewHostnameTrie([]string{limit.Host.String})
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate limit %d: %w", i, err)
+			}
+			rule.hosts = hosts
+		}
+		rl.rules = append(rl.rules, rule)
+	}
+	return rl, nil
+}
+
+func (r *rateLimitRule) limiter(host string, tags *metrics.TagSet) *rate.Limiter {
+	key := ""
+	if r.hosts != nil {
+		if _, ok := r.hosts.Contains(host); !ok {
+			return nil
+		}
+		key = host
+	}
+	for name, value := range r.Tags {
+		if v, ok := tags.Get(name); !ok || v != value {
+			return nil
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.limiters[key]
+	if !ok {
+		burst := 1
+		if r.Burst.Valid {
+			burst = int(r.Burst.Int64)
+		}
+		l = rate.NewLimiter(rate.Limit(r.RPS.Int64), burst)
+		r.limiters[key] = l
+	}
+	return l
+}
+
+// Wait blocks until all of the limits matching the given host and tags allow
+// a request to be made, or until the context is done.
+func (rl *RateLimiters) Wait(ctx context.Context, host string, tags *metrics.TagSet) error {
+	if rl == nil {
+		return nil
+	}
+	for _, rule := range rl.rules {
+		if l := rule.limiter(host, tags); l != nil {
+			if err := l.Wait(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestNewRateLimiters(t *testing.T) {
+	t.Parallel()
+
+	rl, err := NewRateLimiters(nil)
+	require.NoError(t, err)
+	assert.Nil(t, rl)
+	assert.NoError(t, rl.Wait(context.Background(), "example.com", nil))
+
+	invalid := []RateLimit{
+		{RPS: null.IntFrom(1)},
+		{Host: null.StringFrom("example.com")},
+		{Host: null.StringFrom("example.com"), RPS: null.IntFrom(-1)},
+		{Host: null.StringFrom("example.com"), RPS: null.IntFrom(1), Burst: null.IntFrom(0)},
+		{Host: null.StringFrom("exa*mple.com"), RPS: null.IntFrom(1)},
+	}
+	for _, limit := range invalid {
+		_, err := NewRateLimiters([]RateLimit{limit})
+		assert.Error(t, err, limit)
+	}
+}
+
+func TestRateLimitersWait(t *testing.T) {
+	t.Parallel()
+
+	rl, err := NewRateLimiters([]RateLimit{
+		{Host: null.StringFrom("*.example.com"), RPS: null.IntFrom(1)},
+		{Tags: map[string]string{"dependency": "payments"}, RPS: null.IntFrom(1)},
+	})
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	noTags := registry.RootTagSet()
+	paymentTags := noTags.With("dependency", "payments")
+
+	// blocked returns whether a request would have to wait for the limiter
+	blocked := func(host string, tags *metrics.TagSet) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return rl.Wait(ctx, host, tags) != nil
+	}
+
+	assert.False(t, blocked("a.example.com", noTags))
+	assert.True(t, blocked("a.example.com", noTags))
+	assert.False(t, blocked("b.example.com", noTags), "every host has its own bucket")
+	assert.False(t, blocked("k6.io", noTags), "unmatched hosts aren't limited")
+	assert.False(t, blocked("k6.io", noTags))
+
+	assert.False(t, blocked("k6.io", paymentTags))
+	assert.True(t, blocked("test.k6.io", paymentTags), "tag limits share a single bucket")
+}
@@ -0,0 +1,119 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the sensitive data matched by the RedactionRules.
+const Redacted = "***REDACTED***"
+
+// RedactionRules are the rules for redacting sensitive data, like tokens and
+// personal data, from the logs, including the --http-debug output and the
+// console messages, and from the captured and exported requests.
+type RedactionRules struct {
+	// The names of the headers whose values are redacted
+	Headers []string `json:"headers,omitempty"`
+	// The names of the JSON fields and of the query and form parameters whose
+	// values are redacted
+	Fields []string `json:"fields,omitempty"`
+	// Regular expressions whose matches are redacted
+	Patterns []string `json:"patterns,omitempty"`
+
+	headers  map[string]bool
+	fields   map[string]bool
+	matchers []redactionMatcher
+}
+
+// redactionMatcher replaces the matches of re with replacement, which can
+// refer to the submatches like regexp.Regexp.ReplaceAllString.
+type redactionMatcher struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// UnmarshalJSON parses the rules, and compiles them.
+func (r *RedactionRules) UnmarshalJSON(data []byte) error {
+	type rawRules RedactionRules
+	var raw rawRules
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*r = RedactionRules(raw)
+	return r.compile()
+}
+
+func (r *RedactionRules) compile() error {
+	r.headers, r.fields, r.matchers = make(map[string]bool), make(map[string]bool), nil
+
+	quoted := func(names []string) string {
+		q := make([]string, len(names))
+		for i, name := range names {
+			q[i] = regexp.QuoteMeta(name)
+		}
+		return strings.Join(q, "|")
+	}
+
+	if len(r.Headers) > 0 {
+		for _, h := range r.Headers {
+			r.headers[strings.ToLower(h)] = true
+		}
+		// the header lines, like in the --http-debug output
+		r.matchers = append(r.matchers, redactionMatcher{
+			re:          regexp.MustCompile(`(?im)^((?:` + quoted(r.Headers) + `):[ \t]*)[^\r\n]*`),
+			replacement: "${1}" + Redacted,
+		})
+	}
+	if len(r.Fields) > 0 {
+		for _, f := range r.Fields {
+			r.fields[strings.ToLower(f)] = true
+		}
+		names := quoted(r.Fields)
+		r.matchers = append(r.matchers,
+			// the JSON fields with string, number or boolean values
+			redactionMatcher{
+				re:          regexp.MustCompile(`(?i)("(?:` + names + `)"\s*:\s*)(?:"(?:[^"\\]|\\.)*"|[-+.\w]+)`),
+				replacement: `${1}"` + Redacted + `"`,
+			},
+			// the query and form parameters
+			redactionMatcher{
+				re:          regexp.MustCompile(`(?i)((?:^|[?&\s])(?:` + names + `)=)[^&\s#"]*`),
+				replacement: "${1}" + Redacted,
+			},
+		)
+	}
+	for _, p := range r.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern '%s': %w", p, err)
+		}
+		r.matchers = append(r.matchers, redactionMatcher{re: re, replacement: Redacted})
+	}
+	return nil
+}
+
+// Redact returns the string with the sensitive data matched by the rules
+// replaced.
+func (r *RedactionRules) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, m := range r.matchers {
+		s = m.re.ReplaceAllString(s, m.replacement)
+	}
+	return s
+}
+
+// IsSensitiveHeader returns whether the values of the header with the name
+// have to be redacted.
+func (r *RedactionRules) IsSensitiveHeader(name string) bool {
+	return r != nil && r.headers[strings.ToLower(name)]
+}
+
+// IsSensitiveField returns whether the values of the JSON field, or query or
+// form parameter, with the name have to be redacted.
+func (r *RedactionRules) IsSensitiveField(name string) bool {
+	return r != nil && r.fields[strings.ToLower(name)]
+}
@@ -0,0 +1,52 @@
+package lib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactionRules(t *testing.T) {
+	t.Parallel()
+
+	var rules *RedactionRules
+	assert.Equal(t, "Authorization: Bearer token", rules.Redact("Authorization: Bearer token"))
+	assert.False(t, rules.IsSensitiveHeader("Authorization"))
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"headers": ["Authorization", "X-Api-Key"],
+		"fields": ["password", "access_token"],
+		"patterns": ["sk_live_[0-9a-z]+"]
+	}`), &rules))
+	assert.True(t, rules.IsSensitiveHeader("authorization"))
+	assert.False(t, rules.IsSensitiveHeader("Accept"))
+	assert.True(t, rules.IsSensitiveField("Password"))
+	assert.False(t, rules.IsSensitiveField("user"))
+
+	dump := "POST /login?access_token=abc&page=2 HTTP/1.1\n" +
+		"Host: example.com\n" +
+		"authorization: Bearer abc.def\n" +
+		"x-api-key:sk_live_123\n" +
+		"\n" +
+		`{"user": "admin", "password": "hunter\"2", "pin": 1234, "key": "sk_live_456"}`
+	assert.Equal(t, "POST /login?access_token="+Redacted+"&page=2 HTTP/1.1\n"+
+		"Host: example.com\n"+
+		"authorization: "+Redacted+"\n"+
+		"x-api-key:"+Redacted+"\n"+
+		"\n"+
+		`{"user": "admin", "password": "`+Redacted+`", "pin": 1234, "key": "`+Redacted+`"}`,
+		rules.Redact(dump))
+	assert.Equal(t, "user=admin&password="+Redacted, rules.Redact("user=admin&password=hunter2"))
+
+	// the rules survive being passed around as JSON, like the other options
+	data, err := json.Marshal(rules)
+	require.NoError(t, err)
+	var decoded *RedactionRules
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, rules.Redact(dump), decoded.Redact(dump))
+
+	assert.EqualError(t, json.Unmarshal([]byte(`{"patterns": ["("]}`), &decoded),
+		"invalid redaction pattern '(': error parsing regexp: missing closing ): `(`")
+}
@@ -0,0 +1,118 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib/consts"
+)
+
+// DefaultCaptureMaxSize is the default size limit of the file with the
+// captured requests.
+const DefaultCaptureMaxSize = 10 << 20
+
+// harTrailer closes the entries array and the log object of a HAR document,
+// it's rewritten after each entry so the file is always a valid HAR.
+const harTrailer = "\n]}}\n"
+
+// RequestCapture writes the details of the failed and, optionally, the slow
+// or a sample of all the requests to a file, so the errors of a test run can
+// be diagnosed after it without rerunning it with --http-debug, and its traffic
+// can be inspected in the browser devtools. Each request is written as a HAR
+// entry, either in a HAR document or in a JSON line of its own, and no more
+// requests are captured once the file reaches its size limit.
+//
+// It's shared between all VUs, so the limit applies to the whole k6 instance.
+type RequestCapture struct {
+	// The successful requests are captured too if they take at least this
+	// long, if it's not zero.
+	SlowerThan time.Duration
+	// The rest of the requests are captured with this probability.
+	SampleRate float64
+
+	mu      sync.Mutex
+	w       io.WriterAt
+	har     bool
+	maxSize int64
+	offset  int64
+	entries int
+	full    bool
+}
+
+// NewRequestCapture returns a RequestCapture that writes to w, as a HAR
+// document if har is true and as JSON lines otherwise.
+func NewRequestCapture(w io.WriterAt, har bool, maxSize int64) (*RequestCapture, error) {
+	rc := &RequestCapture{w: w, har: har, maxSize: maxSize}
+	if !har {
+		return rc, nil
+	}
+
+	header := fmt.Sprintf(`{"log":{"version":"1.2","creator":{"name":"k6","version":%q},"entries":[`, consts.Version)
+	if _, err := w.WriteAt([]byte(header+harTrailer), 0); err != nil {
+		return nil, err
+	}
+	rc.offset = int64(len(header))
+	return rc, nil
+}
+
+// ShouldCapture returns whether a request that took the given duration, and
+// failed or not, has to be captured. It's safe to call on a nil capture.
+func (rc *RequestCapture) ShouldCapture(failed bool, duration time.Duration) bool {
+	if rc == nil {
+		return false
+	}
+	slow := rc.SlowerThan > 0 && duration >= rc.SlowerThan
+	sampled := rc.SampleRate >= 1 || (rc.SampleRate > 0 && rand.Float64() < rc.SampleRate) //nolint:gosec
+	if !failed && !slow && !sampled {
+		return false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return !rc.full
+}
+
+// Capture writes the entry to the file, unless it would make it bigger than
+// its size limit, in which case the capturing stops.
+func (rc *RequestCapture) Capture(entry interface{}) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.full {
+		return nil
+	}
+
+	var prefix, suffix string
+	switch {
+	case !rc.har:
+		suffix = "\n"
+	case rc.entries > 0:
+		prefix, suffix = ",\n", harTrailer
+	default:
+		prefix, suffix = "\n", harTrailer
+	}
+	if rc.offset+int64(len(prefix)+len(data)+len(suffix)) > rc.maxSize {
+		rc.full = true
+		return nil
+	}
+
+	buf := make([]byte, 0, len(prefix)+len(data)+len(suffix))
+	buf = append(append(append(buf, prefix...), data...), suffix...)
+	if _, err := rc.w.WriteAt(buf, rc.offset); err != nil {
+		return err
+	}
+	// the next entry overwrites the trailer of the HAR document
+	rc.offset += int64(len(buf) - len(suffix))
+	if !rc.har {
+		rc.offset += int64(len(suffix))
+	}
+	rc.entries++
+	return nil
+}
@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+func newTestRequestCapture(t *testing.T, har bool, maxSize int64) (*RequestCapture, func() string) {
+	fs := fsext.NewMemMapFs()
+	f, err := fs.Create("/capture")
+	require.NoError(t, err)
+	rc, err := NewRequestCapture(f, har, maxSize)
+	require.NoError(t, err)
+	return rc, func() string {
+		data, err := fsext.ReadFile(fs, "/capture")
+		require.NoError(t, err)
+		return string(data)
+	}
+}
+
+func TestRequestCaptureShouldCapture(t *testing.T) {
+	t.Parallel()
+
+	var nilCapture *RequestCapture
+	assert.False(t, nilCapture.ShouldCapture(true, time.Second))
+
+	rc, _ := newTestRequestCapture(t, false, 100)
+	assert.True(t, rc.ShouldCapture(true, 0))
+	assert.False(t, rc.ShouldCapture(false, time.Hour))
+
+	rc.SlowerThan = time.Second
+	assert.True(t, rc.ShouldCapture(false, time.Second))
+	assert.False(t, rc.ShouldCapture(false, time.Second-1))
+
+	rc.SampleRate = 1
+	assert.True(t, rc.ShouldCapture(false, 0))
+
+	rc.SampleRate = 0.5
+	var sampled int
+	for i := 0; i < 1000; i++ {
+		if rc.ShouldCapture(false, 0) {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 500, sampled, 100)
+}
+
+func TestRequestCaptureJSONLines(t *testing.T) {
+	t.Parallel()
+
+	rc, contents := newTestRequestCapture(t, false, 30)
+	require.NoError(t, rc.Capture(map[string]int{"a": 1}))
+	require.NoError(t, rc.Capture(map[string]int{"b": 2}))
+	assert.Equal(t, "{\"a\":1}\n{\"b\":2}\n", contents())
+
+	// the capturing stops once an entry doesn't fit in the size limit
+	require.NoError(t, rc.Capture(map[string]string{"c": strings.Repeat("x", 10)}))
+	assert.False(t, rc.ShouldCapture(true, 0))
+	require.NoError(t, rc.Capture(map[string]int{"d": 4}))
+	assert.Equal(t, "{\"a\":1}\n{\"b\":2}\n", contents())
+}
+
+func TestRequestCaptureHAR(t *testing.T) {
+	t.Parallel()
+
+	rc, contents := newTestRequestCapture(t, true, DefaultCaptureMaxSize)
+	var har struct {
+		Log struct {
+			Version string
+			Entries []map[string]int
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(contents()), &har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Empty(t, har.Log.Entries)
+
+	// the file is a valid HAR document after each entry
+	require.NoError(t, rc.Capture(map[string]int{"a": 1}))
+	require.NoError(t, json.Unmarshal([]byte(contents()), &har))
+	assert.Equal(t, []map[string]int{{"a": 1}}, har.Log.Entries)
+
+	require.NoError(t, rc.Capture(map[string]int{"b": 2}))
+	require.NoError(t, json.Unmarshal([]byte(contents()), &har))
+	assert.Equal(t, []map[string]int{{"a": 1}, {"b": 2}}, har.Log.Entries)
+}
@@ -0,0 +1,69 @@
+package lib
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	// MetadataTraceID is the metadata key of the ID of the trace of a request,
+	// set on its metric samples.
+	MetadataTraceID = "trace_id"
+	// MetadataSpanID is the metadata key of the ID of the span of a request,
+	// set on its metric samples.
+	MetadataSpanID = "span_id"
+
+	requestTracerName = "k6"
+)
+
+// StartRequestSpan starts the client span of a request made by the VU, if the
+// traces output is enabled. The span is a child of the W3C trace context in
+// the carrier, if there is one, e.g. set by the tracing module, and it
+// replaces it with its own, so the spans of the system under test are its
+// children. The IDs of the trace and of the span are set as metadata of the
+// metric samples of the request, so they can be linked to it.
+//
+// The returned span has to be ended by the caller, and it's a non-recording
+// one if tracing is disabled.
+func (s *State) StartRequestSpan(
+	ctx context.Context, name string, carrier propagation.TextMapCarrier,
+	tagsAndMeta *metrics.TagsAndMeta, attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	if s.TracerProvider == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	propagator := propagation.TraceContext{}
+	parentCtx := propagator.Extract(ctx, carrier)
+
+	attrs = append(attrs,
+		attribute.Int64("k6.vu", int64(s.VUID)), //nolint:gosec
+		attribute.Int64("k6.iteration", s.Iteration),
+	)
+	if scenario := GetScenarioState(ctx); scenario != nil {
+		attrs = append(attrs, attribute.String("k6.scenario", scenario.Name))
+	}
+	if group, ok := tagsAndMeta.Tags.Get(metrics.TagGroup.String()); ok && group != "" {
+		attrs = append(attrs, attribute.String("k6.group", group))
+	}
+	spanCtx, span := s.TracerProvider.Tracer(requestTracerName).Start(parentCtx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	if !span.IsRecording() {
+		// the traces output is disabled, or the span isn't sampled, so the
+		// request and its samples are left alone
+		return ctx, span
+	}
+
+	propagator.Inject(spanCtx, carrier)
+	sc := span.SpanContext()
+	tagsAndMeta.SetMetadata(MetadataTraceID, sc.TraceID().String())
+	tagsAndMeta.SetMetadata(MetadataSpanID, sc.SpanID().String())
+	return trace.ContextWithSpan(ctx, span), span
+}
@@ -2,12 +2,18 @@ package lib
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"time"
 
 	"go.k6.io/k6/metrics"
 )
 
+// ErrIterationTimeout is wrapped by the errors of the iterations that ran for
+// longer than the iterationTimeout of their scenario and were interrupted.
+var ErrIterationTimeout = errors.New("the iteration timed out")
+
 // ActiveVU represents an actively running virtual user.
 type ActiveVU interface {
 	// Run the configured exported function in the VU once. The only
@@ -36,6 +42,21 @@ type VUActivationParams struct {
 	Env, Tags                map[string]string
 	Exec, Scenario           string
 	GetNextIterationCounters func() (uint64, uint64)
+	// Returns the time to wait after each top-level group, if it's set.
+	ThinkTime func() time.Duration
+	// Returns the function of the next iteration, instead of Exec, if the
+	// iterations are spread across a mix of them.
+	NextExec func() string
+	// How long an iteration can run before it's interrupted, 0 if unlimited.
+	IterationTimeout time.Duration
+	// How many iterations the VU runs, without any metrics, before its first
+	// one in the scenario.
+	WarmupIterations int64
+	// The virtual identities of the scenario, one of them is assigned to the
+	// VU, see AssignIdentity().
+	Identities []json.RawMessage
+	// How the traffic of the VU is shaped in the scenario, nil if it isn't.
+	TrafficShaping *TrafficShaping
 }
 
 // A Runner is a factory for VUs. It should precompute as much as possible upon
@@ -73,6 +94,14 @@ type Runner interface {
 	// Runs post-test teardown, if applicable.
 	Teardown(ctx context.Context, out chan<- metrics.SampleContainer) error
 
+	// Runs the setup function of a scenario, and keeps the returned data for
+	// the iterations of that scenario, instead of the setup() one.
+	ScenarioSetup(ctx context.Context, out chan<- metrics.SampleContainer, scenario, fn string) error
+
+	// Runs the teardown function of a scenario, with the data of its setup
+	// function, or the setup() one if it doesn't have one.
+	ScenarioTeardown(ctx context.Context, out chan<- metrics.SampleContainer, scenario, fn string) error
+
 	// Returns the default (root) Group.
 	GetDefaultGroup() *Group
 
@@ -103,4 +132,11 @@ type Summary struct {
 	TestRunDuration time.Duration // TODO: use lib.ExecutionState-based interface instead?
 	NoColor         bool          // TODO: drop this when noColor is part of the (runtime) options
 	UIState         UIState
+
+	// The aggregated time series of the metrics, by name, if they are recorded
+	TimeSeries           map[string][]metrics.AggregatedPeriod
+	TimeSeriesResolution time.Duration
+
+	// The descriptions of the saturations of the load generator, if any
+	Warnings []string
 }
@@ -0,0 +1,187 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSchedulingDelayBudget is how late the iterations of the arrival-rate
+// executors can start before k6 considers itself saturated.
+const DefaultSchedulingDelayBudget = 100 * time.Millisecond
+
+const (
+	// the CPU is saturated when it's busier than this for long enough
+	saturatedCPUUsage   = 0.9
+	saturatedCPUSeconds = 3
+	// the samples buffer is saturated when it's fuller than this
+	saturatedSamplesBacklog = 0.9
+)
+
+// SaturationMonitor detects when the load generator itself is saturated,
+// since the results of a test run measure it instead of the system under test
+// then. It's fed with the CPU usage and the backlog of the metric samples by
+// the execution scheduler, and with the delays of the iterations by the
+// arrival-rate executors, and it warns about the saturation once it's detected
+// and in the end-of-test summary.
+//
+// With CapRate, the iterations that start later than the budget are skipped
+// and counted as dropped, so the arrival rates are capped at what the machine
+// can generate on time, instead of being caught up in bursts.
+//
+// All of its methods are safe to call on a nil SaturationMonitor.
+type SaturationMonitor struct {
+	DelayBudget time.Duration
+	CapRate     bool
+
+	logger logrus.FieldLogger
+
+	mu sync.Mutex
+	// the consecutive and the total seconds with a saturated CPU, and its
+	// highest usage then
+	busySeconds, saturatedCPUSeconds int
+	maxCPUUsage                      float64
+	// the number of times the samples buffer was saturated
+	fullBacklogs int
+	// the late iterations of each scenario
+	late map[string]*lateIterations
+	// the kinds of saturation that were already logged
+	warned map[string]bool
+}
+
+type lateIterations struct {
+	count, skipped int
+	maxDelay       time.Duration
+}
+
+// NewSaturationMonitor returns a SaturationMonitor for the given options.
+func NewSaturationMonitor(opts Options, logger logrus.FieldLogger) *SaturationMonitor {
+	sm := &SaturationMonitor{
+		DelayBudget: DefaultSchedulingDelayBudget,
+		CapRate:     opts.AutoCalibrate.Bool,
+		logger:      logger,
+		late:        make(map[string]*lateIterations),
+		warned:      make(map[string]bool),
+	}
+	if opts.SchedulingDelayBudget.Valid {
+		sm.DelayBudget = opts.SchedulingDelayBudget.TimeDuration()
+	}
+	return sm
+}
+
+// warnOnce logs the warning the first time that the kind of saturation is
+// detected. It has to be called with the lock held.
+func (sm *SaturationMonitor) warnOnce(kind, format string, args ...interface{}) {
+	if sm.warned[kind] || sm.logger == nil {
+		return
+	}
+	sm.warned[kind] = true
+	sm.logger.Warnf("The load generator is saturated: "+format, args...)
+}
+
+// ObserveCPU records the fraction of the time in which the CPU available to
+// k6 was busy, in the last second.
+func (sm *SaturationMonitor) ObserveCPU(usage float64) {
+	if sm == nil {
+		return
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if usage < saturatedCPUUsage {
+		sm.busySeconds = 0
+		return
+	}
+	sm.busySeconds++
+	if sm.busySeconds < saturatedCPUSeconds {
+		return
+	}
+	if sm.busySeconds == saturatedCPUSeconds {
+		// the previous seconds are counted too, once it's saturated long enough
+		sm.saturatedCPUSeconds += saturatedCPUSeconds - 1
+	}
+	sm.saturatedCPUSeconds++
+	if usage > sm.maxCPUUsage {
+		sm.maxCPUUsage = usage
+	}
+	sm.warnOnce("cpu", "its CPU usage is %.0f%%", usage*100)
+}
+
+// ObserveSamplesBacklog records the number of the metric samples waiting in
+// their buffer, and its capacity.
+func (sm *SaturationMonitor) ObserveSamplesBacklog(backlog, capacity int) {
+	if sm == nil || capacity == 0 || float64(backlog) < saturatedSamplesBacklog*float64(capacity) {
+		return
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.fullBacklogs++
+	sm.warnOnce("samples", "the buffer of the metric samples is full, so the VUs wait to emit them")
+}
+
+// ObserveSchedulingDelay records how late an iteration of the scenario
+// started, and returns true if it has to be skipped, as it's later than the
+// budget and the rate is capped.
+func (sm *SaturationMonitor) ObserveSchedulingDelay(scenario string, delay time.Duration) (skip bool) {
+	if sm == nil || sm.DelayBudget <= 0 || delay <= sm.DelayBudget {
+		return false
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	late, ok := sm.late[scenario]
+	if !ok {
+		late = &lateIterations{}
+		sm.late[scenario] = late
+	}
+	late.count++
+	if delay > late.maxDelay {
+		late.maxDelay = delay
+	}
+	if sm.CapRate {
+		late.skipped++
+	}
+	sm.warnOnce("delay/"+scenario, "the iterations of the scenario '%s' start later than the budget of %s",
+		scenario, sm.DelayBudget)
+	return sm.CapRate
+}
+
+// Warnings returns the descriptions of the detected saturations, for the
+// end-of-test summary.
+func (sm *SaturationMonitor) Warnings() []string {
+	if sm == nil {
+		return nil
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	var warnings []string
+	if sm.saturatedCPUSeconds > 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"The CPU usage of k6 was up to %.0f%% for %ds of the test run, so the results may measure the "+
+				"load generator instead of the system under test", sm.maxCPUUsage*100, sm.saturatedCPUSeconds))
+	}
+	if sm.fullBacklogs > 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"The buffer of the metric samples was full %d times, so the VUs waited to emit them; increase "+
+				"metricSamplesBufferSize or use fewer or faster outputs", sm.fullBacklogs))
+	}
+	scenarios := make([]string, 0, len(sm.late))
+	for scenario := range sm.late {
+		scenarios = append(scenarios, scenario)
+	}
+	sort.Strings(scenarios)
+	for _, scenario := range scenarios {
+		late := sm.late[scenario]
+		warning := fmt.Sprintf("%d iterations of the scenario '%s' were up to %s late to start, over the budget of %s",
+			late.count, scenario, late.maxDelay.Round(time.Millisecond), sm.DelayBudget)
+		if late.skipped > 0 {
+			warning += fmt.Sprintf(", and %d of them were skipped to cap the rate", late.skipped)
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func newTestSaturationMonitor(opts Options) (*SaturationMonitor, *test.Hook) {
+	logger, hook := test.NewNullLogger()
+	return NewSaturationMonitor(opts, logger), hook
+}
+
+func TestSaturationMonitorNil(t *testing.T) {
+	t.Parallel()
+
+	var sm *SaturationMonitor
+	sm.ObserveCPU(1)
+	sm.ObserveSamplesBacklog(10, 10)
+	assert.False(t, sm.ObserveSchedulingDelay("default", time.Hour))
+	assert.Empty(t, sm.Warnings())
+}
+
+func TestSaturationMonitorCPU(t *testing.T) {
+	t.Parallel()
+
+	sm, hook := newTestSaturationMonitor(Options{})
+	sm.ObserveCPU(0.95)
+	sm.ObserveCPU(0.95)
+	sm.ObserveCPU(0.5)
+	sm.ObserveCPU(0.95)
+	sm.ObserveCPU(0.95)
+	assert.Empty(t, sm.Warnings())
+	assert.Empty(t, hook.AllEntries())
+
+	sm.ObserveCPU(0.97)
+	sm.ObserveCPU(0.96)
+	assert.Equal(t, []string{
+		"The CPU usage of k6 was up to 97% for 4s of the test run, so the results may measure the " +
+			"load generator instead of the system under test",
+	}, sm.Warnings())
+
+	entries := hook.AllEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, logrus.WarnLevel, entries[0].Level)
+	assert.Equal(t, "The load generator is saturated: its CPU usage is 97%", entries[0].Message)
+}
+
+func TestSaturationMonitorSamplesBacklog(t *testing.T) {
+	t.Parallel()
+
+	sm, hook := newTestSaturationMonitor(Options{})
+	sm.ObserveSamplesBacklog(10, 100)
+	sm.ObserveSamplesBacklog(0, 0)
+	assert.Empty(t, sm.Warnings())
+
+	sm.ObserveSamplesBacklog(95, 100)
+	sm.ObserveSamplesBacklog(100, 100)
+	assert.Equal(t, []string{
+		"The buffer of the metric samples was full 2 times, so the VUs waited to emit them; increase " +
+			"metricSamplesBufferSize or use fewer or faster outputs",
+	}, sm.Warnings())
+	assert.Len(t, hook.AllEntries(), 1)
+}
+
+func TestSaturationMonitorSchedulingDelay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("warn", func(t *testing.T) {
+		t.Parallel()
+
+		sm, hook := newTestSaturationMonitor(Options{})
+		assert.False(t, sm.ObserveSchedulingDelay("a", DefaultSchedulingDelayBudget))
+		assert.Empty(t, sm.Warnings())
+
+		assert.False(t, sm.ObserveSchedulingDelay("b", 2*time.Second))
+		assert.False(t, sm.ObserveSchedulingDelay("a", 150*time.Millisecond))
+		assert.False(t, sm.ObserveSchedulingDelay("a", 120*time.Millisecond))
+		assert.Equal(t, []string{
+			"2 iterations of the scenario 'a' were up to 150ms late to start, over the budget of 100ms",
+			"1 iterations of the scenario 'b' were up to 2s late to start, over the budget of 100ms",
+		}, sm.Warnings())
+		assert.Len(t, hook.AllEntries(), 2)
+	})
+
+	t.Run("cap", func(t *testing.T) {
+		t.Parallel()
+
+		sm, _ := newTestSaturationMonitor(Options{
+			SchedulingDelayBudget: types.NullDurationFrom(time.Second),
+			AutoCalibrate:         null.BoolFrom(true),
+		})
+		assert.False(t, sm.ObserveSchedulingDelay("a", 500*time.Millisecond))
+		assert.True(t, sm.ObserveSchedulingDelay("a", 1500*time.Millisecond))
+		assert.Equal(t, []string{
+			"1 iterations of the scenario 'a' were up to 1.5s late to start, over the budget of 1s, " +
+				"and 1 of them were skipped to cap the rate",
+		}, sm.Warnings())
+	})
+}
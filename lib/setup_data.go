@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// SetupData is the data returned by a setup function, as JSON, shared by all
+// the VUs. Its top-level fields, if it's an object, are split only once and
+// only if they are needed, so the VUs can read them lazily, without
+// unmarshaling all of the data.
+type SetupData struct {
+	raw []byte
+
+	once   sync.Once
+	fields map[string]json.RawMessage
+	keys   []string
+}
+
+// NewSetupData returns the SetupData of the JSON data, nil if there isn't any.
+func NewSetupData(raw []byte) *SetupData {
+	if raw == nil {
+		return nil
+	}
+	return &SetupData{raw: raw}
+}
+
+// JSON returns the data as JSON, nil if there isn't any.
+func (sd *SetupData) JSON() []byte {
+	if sd == nil {
+		return nil
+	}
+	return sd.raw
+}
+
+// Size returns the size of the data as JSON.
+func (sd *SetupData) Size() int {
+	return len(sd.JSON())
+}
+
+// IsObject returns true if the data is a JSON object, so its fields can be
+// read separately.
+func (sd *SetupData) IsObject() bool {
+	raw := bytes.TrimLeft(sd.JSON(), " \t\r\n")
+	return len(raw) > 0 && raw[0] == '{'
+}
+
+// Field returns the JSON of the top-level field with the key, if the data is
+// an object that has it.
+func (sd *SetupData) Field(key string) (json.RawMessage, bool) {
+	if !sd.split() {
+		return nil, false
+	}
+	field, ok := sd.fields[key]
+	return field, ok
+}
+
+// Keys returns the keys of the top-level fields, in their order, if the data
+// is an object.
+func (sd *SetupData) Keys() []string {
+	if !sd.split() {
+		return nil
+	}
+	return sd.keys
+}
+
+// split splits the top-level fields, if the data is an object.
+func (sd *SetupData) split() bool {
+	if !sd.IsObject() {
+		return false
+	}
+	sd.once.Do(func() {
+		dec := json.NewDecoder(bytes.NewReader(sd.raw))
+		sd.fields = make(map[string]json.RawMessage)
+		if _, err := dec.Token(); err != nil { // the opening brace
+			return
+		}
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return
+			}
+			key, _ := t.(string)
+			var value json.RawMessage
+			if err = dec.Decode(&value); err != nil {
+				return
+			}
+			if _, ok := sd.fields[key]; !ok {
+				sd.keys = append(sd.keys, key)
+			}
+			sd.fields[key] = value
+		}
+	})
+	return true
+}
+
+// CheckSetupDataSize returns an error if the data returned by the setup
+// function is larger than the maximum size, if there's one.
+func CheckSetupDataSize(data []byte, maxSize int64, fn string) error {
+	if maxSize > 0 && int64(len(data)) > maxSize {
+		return fmt.Errorf("the data returned by %s() is %d bytes as JSON, more than the setupDataMaxSize of %d bytes",
+			fn, len(data), maxSize)
+	}
+	return nil
+}
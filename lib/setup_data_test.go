@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupData(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil", func(t *testing.T) {
+		t.Parallel()
+		sd := NewSetupData(nil)
+		assert.Nil(t, sd)
+		assert.Nil(t, sd.JSON())
+		assert.Zero(t, sd.Size())
+		assert.False(t, sd.IsObject())
+		assert.Nil(t, sd.Keys())
+		_, ok := sd.Field("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("object", func(t *testing.T) {
+		t.Parallel()
+		sd := NewSetupData([]byte(` {"b": [1, 2], "a": {"c": "d"}}`))
+		assert.True(t, sd.IsObject())
+		assert.Equal(t, []string{"b", "a"}, sd.Keys())
+		field, ok := sd.Field("a")
+		require.True(t, ok)
+		assert.Equal(t, json.RawMessage(`{"c": "d"}`), field)
+		_, ok = sd.Field("c")
+		assert.False(t, ok)
+	})
+
+	t.Run("array", func(t *testing.T) {
+		t.Parallel()
+		sd := NewSetupData([]byte(`[1, 2]`))
+		assert.False(t, sd.IsObject())
+		assert.Nil(t, sd.Keys())
+		assert.Equal(t, 6, sd.Size())
+	})
+}
+
+func TestCheckSetupDataSize(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`{"a":"b"}`)
+	assert.NoError(t, CheckSetupDataSize(data, 0, "setup"))
+	assert.NoError(t, CheckSetupDataSize(data, 9, "setup"))
+	assert.ErrorContains(t, CheckSetupDataSize(data, 8, "setup"),
+		"the data returned by setup() is 9 bytes as JSON, more than the setupDataMaxSize of 8 bytes")
+}
@@ -0,0 +1,90 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SLO is a service level objective, the fraction of the samples of a metric,
+// or of a sub-metric, that have to meet a condition during the test, e.g.
+// {"metric": "http_req_duration{expected_response:true}", "condition": "<300",
+// "objective": 0.99} for 99% of the responses faster than 300ms.
+type SLO struct {
+	Metric    string  `json:"metric"`
+	Condition string  `json:"condition"`
+	Objective float64 `json:"objective"`
+}
+
+// SLOComplianceMetric returns the name of the rate metric of the good samples
+// of the SLO with the name.
+func SLOComplianceMetric(name string) string {
+	return name + "_compliance"
+}
+
+// SLOBurnRateMetric returns the name of the gauge metric of the burn rate of
+// the error budget of the SLO with the name, 1 when it's exhausted.
+func SLOBurnRateMetric(name string) string {
+	return name + "_burn_rate"
+}
+
+// SLOCondition is the parsed condition of the good samples of an SLO, e.g.
+// "<300" or "==0", the values are in the units of the metric.
+type SLOCondition struct {
+	Operator string
+	Value    float64
+}
+
+// sloOperators are the operators of the SLO conditions, the ones that are
+// prefixes of others are after them.
+var sloOperators = []string{"<=", ">=", "==", "!=", "<", ">"} //nolint:gochecknoglobals
+
+// ParseSLOCondition parses a condition like "<300", ">=0.5" or "==0".
+func ParseSLOCondition(s string) (SLOCondition, error) {
+	text := strings.TrimSpace(s)
+	for _, operator := range sloOperators {
+		if !strings.HasPrefix(text, operator) {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(text[len(operator):]), 64)
+		if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+			break
+		}
+		return SLOCondition{Operator: operator, Value: value}, nil
+	}
+	return SLOCondition{}, fmt.Errorf("invalid condition '%s', it must be like <300 or ==0", s)
+}
+
+// Good returns true if the value of a sample meets the condition.
+func (c SLOCondition) Good(value float64) bool {
+	switch c.Operator {
+	case "<":
+		return value < c.Value
+	case "<=":
+		return value <= c.Value
+	case ">":
+		return value > c.Value
+	case ">=":
+		return value >= c.Value
+	case "==":
+		return value == c.Value
+	default:
+		return value != c.Value
+	}
+}
+
+// Validate checks that the SLO has a metric, a valid condition and an
+// objective that leaves an error budget.
+func (s SLO) Validate() error {
+	if s.Metric == "" {
+		return fmt.Errorf("the metric is missing")
+	}
+	if _, err := ParseSLOCondition(s.Condition); err != nil {
+		return err
+	}
+	if !(s.Objective > 0 && s.Objective < 1) {
+		return fmt.Errorf("the objective must be between 0 and 1, not %g", s.Objective)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSLOCondition(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]SLOCondition{
+		"<300":    {Operator: "<", Value: 300},
+		" <= 0.5": {Operator: "<=", Value: 0.5},
+		">=1":     {Operator: ">=", Value: 1},
+		"==0":     {Operator: "==", Value: 0},
+		"!=0":     {Operator: "!=", Value: 0},
+	}
+	for source, expected := range testCases {
+		condition, err := ParseSLOCondition(source)
+		require.NoError(t, err, source)
+		assert.Equal(t, expected, condition, source)
+	}
+
+	for _, source := range []string{"", "300", "<", "=0", "<ten", "<inf", "p(95)<300"} {
+		_, err := ParseSLOCondition(source)
+		assert.ErrorContains(t, err, "invalid condition", source)
+	}
+}
+
+func TestSLOConditionGood(t *testing.T) {
+	t.Parallel()
+
+	below := SLOCondition{Operator: "<", Value: 300}
+	assert.True(t, below.Good(299))
+	assert.False(t, below.Good(300))
+
+	notFailed := SLOCondition{Operator: "==", Value: 0}
+	assert.True(t, notFailed.Good(0))
+	assert.False(t, notFailed.Good(1))
+
+	assert.True(t, SLOCondition{Operator: "!=", Value: 0}.Good(1))
+	assert.True(t, SLOCondition{Operator: ">=", Value: 1}.Good(1))
+}
+
+func TestSLOValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, SLO{Metric: "http_req_duration", Condition: "<300", Objective: 0.99}.Validate())
+	assert.EqualError(t, SLO{Condition: "<300", Objective: 0.99}.Validate(), "the metric is missing")
+	assert.ErrorContains(t, SLO{Metric: "checks", Condition: "1", Objective: 0.99}.Validate(), "invalid condition")
+	assert.EqualError(t, SLO{Metric: "checks", Condition: "==1", Objective: 1}.Validate(),
+		"the objective must be between 0 and 1, not 1")
+}
@@ -0,0 +1,144 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var (
+	variableName      = regexp.MustCompile(`^\w+$`)
+	variableReference = regexp.MustCompile(`\$\{(\w+)\}`)
+)
+
+// ExpandVariables replaces the ${name} references to the variables in the
+// string values and the keys of the JSON document. A string that is only a
+// reference is replaced by the value of the variable, keeping its JSON type,
+// while the references in longer strings are replaced by its text. The
+// references to unknown variables are left as they are.
+func ExpandVariables(data []byte, vars map[string]json.RawMessage) ([]byte, error) {
+	if len(vars) == 0 {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(expandVariables(doc, vars))
+}
+
+func expandVariables(value interface{}, vars map[string]json.RawMessage) interface{} {
+	switch v := value.(type) {
+	case string:
+		if m := variableReference.FindStringSubmatch(v); m != nil && m[0] == v {
+			if raw, ok := vars[m[1]]; ok {
+				return raw
+			}
+		}
+		return expandVariablesText(v, vars)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[expandVariablesText(key, vars)] = expandVariables(item, vars)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = expandVariables(item, vars)
+		}
+	}
+	return value
+}
+
+func expandVariablesText(s string, vars map[string]json.RawMessage) string {
+	return variableReference.ReplaceAllStringFunc(s, func(ref string) string {
+		raw, ok := vars[ref[2:len(ref)-1]]
+		if !ok {
+			return ref
+		}
+		return variableText(raw)
+	})
+}
+
+// variableText returns the text of the variable, which is the value of the
+// strings and the JSON of the other types.
+func variableText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// expandScenarioMatrix returns the raw JSON configs of the scenarios of the
+// matrix, one for each combination of its variables, named with the index of
+// the combination. The variables are expanded in the configs and they're added
+// to the environment variables of the scenarios, unless they're already set.
+func expandScenarioMatrix(
+	name string, rawJSON []byte, matrix map[string][]json.RawMessage,
+) (map[string][]byte, error) {
+	vars := make([]string, 0, len(matrix))
+	combinations := 1
+	for v, values := range matrix {
+		if !variableName.MatchString(v) {
+			return nil, fmt.Errorf("scenario '%s' has an invalid matrix variable name '%s'", name, v)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("scenario '%s' has no values for the matrix variable '%s'", name, v)
+		}
+		vars = append(vars, v)
+		combinations *= len(values)
+	}
+	sort.Strings(vars)
+
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(rawJSON, &config); err != nil {
+		return nil, err
+	}
+	delete(config, "matrix")
+	var env map[string]string
+	if rawEnv, ok := config["env"]; ok {
+		if err := json.Unmarshal(rawEnv, &env); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make(map[string][]byte, combinations)
+	for i := 0; i < combinations; i++ {
+		// the last variable changes the fastest, like in nested loops
+		values := make(map[string]json.RawMessage, len(vars))
+		rest := i
+		for j := len(vars) - 1; j >= 0; j-- {
+			values[vars[j]] = matrix[vars[j]][rest%len(matrix[vars[j]])]
+			rest /= len(matrix[vars[j]])
+		}
+
+		scenarioEnv := make(map[string]string, len(env)+len(values))
+		for v, value := range values {
+			scenarioEnv[v] = variableText(value)
+		}
+		for k, v := range env {
+			scenarioEnv[k] = v
+		}
+		rawEnv, err := json.Marshal(scenarioEnv)
+		if err != nil {
+			return nil, err
+		}
+		config["env"] = rawEnv
+
+		scenarioJSON, err := json.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		if scenarioJSON, err = ExpandVariables(scenarioJSON, values); err != nil {
+			return nil, err
+		}
+		result[name+"_"+strconv.Itoa(i)] = scenarioJSON
+	}
+	return result, nil
+}
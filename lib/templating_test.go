@@ -0,0 +1,38 @@
+package lib
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandVariables(t *testing.T) {
+	t.Parallel()
+
+	vars := map[string]json.RawMessage{
+		"vus":      json.RawMessage(`10`),
+		"base_url": json.RawMessage(`"https://test.k6.io"`),
+		"stages":   json.RawMessage(`[{"duration": "1m", "target": 5}]`),
+	}
+	data, err := ExpandVariables([]byte(`{
+		"vus": "${vus}",
+		"stages": "${stages}",
+		"tags": {"target": "${base_url}/api", "unknown": "${unknown}"},
+		"thresholds": {"http_req_duration{url:${base_url}}": ["p(95)<${vus}"]},
+		"iterations": 100
+	}`), vars)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"vus": 10,
+		"stages": [{"duration": "1m", "target": 5}],
+		"tags": {"target": "https://test.k6.io/api", "unknown": "${unknown}"},
+		"thresholds": {"http_req_duration{url:https://test.k6.io}": ["p(95)<10"]},
+		"iterations": 100
+	}`, string(data))
+
+	data, err = ExpandVariables([]byte(`{"vus": "${vus}"}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"vus": "${vus}"}`, string(data))
+}
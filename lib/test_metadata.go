@@ -0,0 +1,39 @@
+package lib
+
+import "gopkg.in/guregu/null.v3"
+
+// TestMetadata returns the metadata of the test run that is set, by the names
+// of the tags they're added to the samples with.
+func (o Options) TestMetadata() map[string]string {
+	metadata := make(map[string]string, 4)
+	for tag, value := range map[string]null.String{
+		"test_name":        o.TestName,
+		"run_id":           o.RunID,
+		"test_version":     o.TestVersion,
+		"test_environment": o.TestEnvironment,
+	} {
+		if value.Valid && value.String != "" {
+			metadata[tag] = value.String
+		}
+	}
+	return metadata
+}
+
+// WithTestMetadata returns the options with the metadata of the test run that
+// is set added to the tags of the test run. The tags that are already set
+// aren't overridden.
+func (o Options) WithTestMetadata() Options {
+	metadata := o.TestMetadata()
+	if len(metadata) == 0 {
+		return o
+	}
+	runTags := make(map[string]string, len(o.RunTags)+len(metadata))
+	for k, v := range metadata {
+		runTags[k] = v
+	}
+	for k, v := range o.RunTags {
+		runTags[k] = v
+	}
+	o.RunTags = runTags
+	return o
+}
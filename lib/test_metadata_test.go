@@ -0,0 +1,31 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestWithTestMetadata(t *testing.T) {
+	t.Parallel()
+
+	opts := Options{
+		TestName: null.StringFrom("checkout"),
+		RunTags:  map[string]string{"test_name": "explicit", "team": "payments"},
+	}.WithTestMetadata()
+	assert.False(t, opts.RunID.Valid)
+	assert.Equal(t, map[string]string{
+		"test_name": "explicit",
+		"team":      "payments",
+	}, opts.RunTags)
+
+	opts = Options{RunID: null.StringFrom("run-1"), TestVersion: null.StringFrom("abc123")}.WithTestMetadata()
+	assert.Equal(t, map[string]string{"run_id": "run-1", "test_version": "abc123"}, opts.RunTags)
+	assert.Equal(t, opts.RunTags, opts.TestMetadata())
+
+	// the tags aren't changed without any metadata
+	opts = Options{}.WithTestMetadata()
+	assert.Nil(t, opts.RunTags)
+	assert.Empty(t, opts.TestMetadata())
+}
@@ -1,12 +1,17 @@
 package lib
 
 import (
+	"context"
 	"io"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
 	"go.k6.io/k6/event"
 	"go.k6.io/k6/lib/trace"
 	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/secretsource"
 )
 
 // TestPreInitState contains all of the state that can be gathered and built
@@ -20,6 +25,7 @@ type TestPreInitState struct {
 	LookupEnv      func(key string) (val string, ok bool)
 	Logger         logrus.FieldLogger
 	TracerProvider *trace.TracerProvider
+	SecretsManager *secretsource.Manager
 }
 
 // TestRunState contains the pre-init state as well as all of the state and
@@ -31,6 +37,32 @@ type TestRunState struct {
 	Runner  Runner // TODO: rename to something better, see type comment
 	RunTags *metrics.TagSet
 
+	// InstanceID is the ID of this instance in a distributed test run, it
+	// isn't valid otherwise.
+	InstanceID null.Int
+
+	// MetricValues returns the current aggregated values of the observed
+	// metric, or sub-metric, with the name, and false if it hasn't been
+	// observed. It's nil if the metrics aren't processed during the test run.
+	MetricValues func(name string) (map[string]float64, bool)
+
+	// WatchMetric starts keeping the samples of the metric, or sub-metric,
+	// with the name in a sliding window of the length, and returns a function
+	// that returns a sink with the ones in it, nil if there aren't any. It's
+	// nil if the metrics aren't processed during the test run.
+	WatchMetric func(name string, length time.Duration) (func() metrics.Sink, error)
+
+	// ThresholdsPassed returns if all the thresholds on the sub-metrics of the
+	// scenario with the name passed, after all of the samples sent until then
+	// were processed. It's nil if the thresholds aren't processed during the
+	// test run.
+	ThresholdsPassed func(scenario string) bool
+
+	// UpdateScenario changes the targets of the running scenario with the
+	// name, like its rate, stages or duration, with the JSON changes. It's
+	// nil if the scenarios can't be updated.
+	UpdateScenario func(ctx context.Context, name string, changes []byte) error
+
 	// TODO: add other properties that are computed or derived after init, e.g.
 	// thresholds?
 }
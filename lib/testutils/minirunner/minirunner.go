@@ -21,10 +21,12 @@ var (
 // using a real JS runtime, it allows us to directly specify the options and
 // functions with Go code.
 type MiniRunner struct {
-	Fn              func(ctx context.Context, state *lib.State, out chan<- metrics.SampleContainer) error
-	SetupFn         func(ctx context.Context, out chan<- metrics.SampleContainer) ([]byte, error)
-	TeardownFn      func(ctx context.Context, out chan<- metrics.SampleContainer) error
-	HandleSummaryFn func(context.Context, *lib.Summary) (map[string]io.Reader, error)
+	Fn                 func(ctx context.Context, state *lib.State, out chan<- metrics.SampleContainer) error
+	SetupFn            func(ctx context.Context, out chan<- metrics.SampleContainer) ([]byte, error)
+	TeardownFn         func(ctx context.Context, out chan<- metrics.SampleContainer) error
+	ScenarioSetupFn    func(ctx context.Context, out chan<- metrics.SampleContainer, scenario, fn string) error
+	ScenarioTeardownFn func(ctx context.Context, out chan<- metrics.SampleContainer, scenario, fn string) error
+	HandleSummaryFn    func(context.Context, *lib.Summary) (map[string]io.Reader, error)
 
 	SetupData []byte
 
@@ -86,6 +88,27 @@ func (r MiniRunner) Teardown(ctx context.Context, out chan<- metrics.SampleConta
 	return nil
 }
 
+// ScenarioSetup calls the supplied mock scenario setup function, if present.
+func (r MiniRunner) ScenarioSetup(
+	ctx context.Context, out chan<- metrics.SampleContainer, scenario, fn string,
+) error {
+	if r.ScenarioSetupFn != nil {
+		return r.ScenarioSetupFn(ctx, out, scenario, fn)
+	}
+	return nil
+}
+
+// ScenarioTeardown calls the supplied mock scenario teardown function, if
+// present.
+func (r MiniRunner) ScenarioTeardown(
+	ctx context.Context, out chan<- metrics.SampleContainer, scenario, fn string,
+) error {
+	if r.ScenarioTeardownFn != nil {
+		return r.ScenarioTeardownFn(ctx, out, scenario, fn)
+	}
+	return nil
+}
+
 // GetDefaultGroup returns the default group.
 func (r MiniRunner) GetDefaultGroup() *lib.Group {
 	if r.Group == nil {
@@ -0,0 +1,153 @@
+package lib
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12" //nolint:staticcheck // the only PKCS#12 decoder in the dependencies
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+// The ways the client certificates are assigned.
+const (
+	// TLSClientCertsPerVU assigns the certificates to the VUs by their global
+	// IDs, so each VU keeps its certificate in all the scenarios.
+	TLSClientCertsPerVU = "vu"
+	// TLSClientCertsPerIdentity assigns the certificates to the identities of
+	// the scenarios, by their index, so each identity has its certificate.
+	TLSClientCertsPerIdentity = "identity"
+)
+
+// TLSClientCerts is a pool of client certificates that are assigned to the
+// VUs, one for each of them, for mTLS systems where the certificates identify
+// the devices or the users. They override the tlsAuth certificates.
+type TLSClientCerts struct {
+	// A directory with the certificates and their keys as PEM files, like
+	// device1.crt (or .pem) and device1.key, sorted by name.
+	Dir string `json:"dir"`
+	// A PKCS#12 bundle with the certificates and their keys.
+	PKCS12   string `json:"pkcs12"`
+	Password string `json:"password"`
+	// How they're assigned: "vu" (the default) or "identity".
+	Assignment string `json:"assignment"`
+}
+
+// Validate checks that either the directory or the bundle are set.
+func (c *TLSClientCerts) Validate() error {
+	if (c.Dir == "") == (c.PKCS12 == "") {
+		return fmt.Errorf("either the dir or the pkcs12 bundle of the client certificates have to be set")
+	}
+	switch c.Assignment {
+	case "", TLSClientCertsPerVU, TLSClientCertsPerIdentity:
+		return nil
+	default:
+		return fmt.Errorf("invalid assignment '%s' of the client certificates, it can be '%s' or '%s'",
+			c.Assignment, TLSClientCertsPerVU, TLSClientCertsPerIdentity)
+	}
+}
+
+// PerIdentity returns true if the certificates are assigned to the identities.
+func (c *TLSClientCerts) PerIdentity() bool {
+	return c.Assignment == TLSClientCertsPerIdentity
+}
+
+// Load loads the certificates from the filesystem, the relative paths are
+// relative to the base directory.
+func (c *TLSClientCerts) Load(fs fsext.Fs, base string) ([]tls.Certificate, error) {
+	abs := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(base, path)
+	}
+	var (
+		certs []tls.Certificate
+		err   error
+	)
+	if c.Dir != "" {
+		certs, err = loadPEMClientCerts(fs, abs(c.Dir))
+	} else {
+		certs, err = loadPKCS12ClientCerts(fs, abs(c.PKCS12), c.Password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no client certificates were found")
+	}
+	return certs, nil
+}
+
+func loadPEMClientCerts(fs fsext.Fs, dir string) ([]tls.Certificate, error) {
+	entries, err := fsext.ReadDir(fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the directory of the client certificates: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".crt" || ext == ".pem") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	certs := make([]tls.Certificate, 0, len(names))
+	for _, name := range names {
+		certPEM, err := fsext.ReadFile(fs, filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		keyPEM, err := fsext.ReadFile(fs, filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".key"))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the key of the client certificate %s: %w", name, err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate %s: %w", name, err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// loadPKCS12ClientCerts loads the certificates of the bundle, in their order,
+// with the keys that have their local key IDs.
+func loadPKCS12ClientCerts(fs fsext.Fs, path, password string) ([]tls.Certificate, error) {
+	data, err := fsext.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the PKCS#12 bundle of the client certificates: %w", err)
+	}
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PKCS#12 bundle of the client certificates: %w", err)
+	}
+
+	keys := make(map[string][]byte)
+	for _, block := range blocks {
+		if block.Type == "PRIVATE KEY" {
+			keys[block.Headers["localKeyId"]] = pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes})
+		}
+	}
+	var certs []tls.Certificate
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		key, ok := keys[block.Headers["localKeyId"]]
+		if !ok {
+			continue // a CA certificate
+		}
+		cert, err := tls.X509KeyPair(pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes}), key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate in the PKCS#12 bundle: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
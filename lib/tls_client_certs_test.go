@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+func generateClientCert(t *testing.T, name string) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestTLSClientCertsValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, (&TLSClientCerts{Dir: "certs"}).Validate())
+	assert.NoError(t, (&TLSClientCerts{PKCS12: "certs.p12", Assignment: "identity"}).Validate())
+	assert.Error(t, (&TLSClientCerts{}).Validate())
+	assert.Error(t, (&TLSClientCerts{Dir: "certs", PKCS12: "certs.p12"}).Validate())
+	assert.Error(t, (&TLSClientCerts{Dir: "certs", Assignment: "iteration"}).Validate())
+}
+
+func TestTLSClientCertsLoad(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	for _, name := range []string{"device2", "device1"} {
+		certPem, keyPem := generateClientCert(t, name)
+		require.NoError(t, fsext.WriteFile(fs, "/test/certs/"+name+".crt", certPem, 0o644))
+		require.NoError(t, fsext.WriteFile(fs, "/test/certs/"+name+".key", keyPem, 0o644))
+	}
+	require.NoError(t, fsext.WriteFile(fs, "/test/certs/README", []byte("not a certificate"), 0o644))
+
+	certs, err := (&TLSClientCerts{Dir: "certs"}).Load(fs, "/test")
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	for i, name := range []string{"device1", "device2"} {
+		leaf, err := x509.ParseCertificate(certs[i].Certificate[0])
+		require.NoError(t, err)
+		assert.Equal(t, name, leaf.Subject.CommonName)
+	}
+
+	certs, err = (&TLSClientCerts{Dir: "/test/certs"}).Load(fs, "/other")
+	require.NoError(t, err)
+	assert.Len(t, certs, 2)
+
+	t.Run("MissingKey", func(t *testing.T) {
+		t.Parallel()
+		fs := fsext.NewMemMapFs()
+		certPem, _ := generateClientCert(t, "device1")
+		require.NoError(t, fsext.WriteFile(fs, "/certs/device1.pem", certPem, 0o644))
+		_, err := (&TLSClientCerts{Dir: "/certs"}).Load(fs, "/")
+		assert.ErrorContains(t, err, "couldn't read the key of the client certificate device1.pem")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+		fs := fsext.NewMemMapFs()
+		require.NoError(t, fs.MkdirAll("/certs", 0o755))
+		_, err := (&TLSClientCerts{Dir: "/certs"}).Load(fs, "/")
+		assert.ErrorContains(t, err, "no client certificates were found")
+	})
+
+	t.Run("InvalidPKCS12", func(t *testing.T) {
+		t.Parallel()
+		fs := fsext.NewMemMapFs()
+		require.NoError(t, fsext.WriteFile(fs, "/certs.p12", []byte("not a bundle"), 0o644))
+		_, err := (&TLSClientCerts{PKCS12: "certs.p12"}).Load(fs, "/")
+		assert.ErrorContains(t, err, "invalid PKCS#12 bundle of the client certificates")
+	})
+}
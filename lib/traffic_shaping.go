@@ -0,0 +1,76 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// TrafficShaping simulates the network of slower clients, like mobile or
+// cross-region ones, on the connections of the VUs, so they can be modeled
+// from a well-connected load generator.
+type TrafficShaping struct {
+	// The maximum rate, in bytes per second, of the data that each VU sends,
+	// and of the data that it receives.
+	Bandwidth null.Int `json:"bandwidth"`
+	// The latency added to each round trip, varied randomly by up to the
+	// jitter, more or less.
+	Latency types.NullDuration `json:"latency"`
+	Jitter  types.NullDuration `json:"jitter"`
+	// The probability, from 0 to 1, that a packet is lost. The lost packets
+	// are delayed by their retransmission, since the connections are reliable.
+	Loss null.Float `json:"loss"`
+}
+
+// Validate checks that the values aren't negative and that the loss is a
+// probability.
+func (ts *TrafficShaping) Validate() []error {
+	if ts == nil {
+		return nil
+	}
+
+	var errors []error
+	if ts.Bandwidth.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the bandwidth of the traffic shaping can't be negative"))
+	}
+	if ts.Latency.Duration < 0 {
+		errors = append(errors, fmt.Errorf("the latency of the traffic shaping can't be negative"))
+	}
+	if ts.Jitter.Duration < 0 {
+		errors = append(errors, fmt.Errorf("the jitter of the traffic shaping can't be negative"))
+	}
+	if !(ts.Loss.Float64 >= 0 && ts.Loss.Float64 < 1) {
+		errors = append(errors, fmt.Errorf("the loss of the traffic shaping must be from 0 to 1, not %v", ts.Loss.Float64))
+	}
+	return errors
+}
+
+// IsZero returns true if the traffic isn't shaped at all.
+func (ts *TrafficShaping) IsZero() bool {
+	return ts == nil || (ts.Bandwidth.Int64 <= 0 && ts.Latency.Duration <= 0 &&
+		ts.Jitter.Duration <= 0 && ts.Loss.Float64 <= 0)
+}
+
+func (ts *TrafficShaping) String() string {
+	if ts.IsZero() {
+		return "none"
+	}
+	var facts []string
+	if ts.Bandwidth.Int64 > 0 {
+		facts = append(facts, fmt.Sprintf("%d B/s", ts.Bandwidth.Int64))
+	}
+	if ts.Latency.Duration > 0 || ts.Jitter.Duration > 0 {
+		latency := ts.Latency.Duration.String()
+		if ts.Jitter.Duration > 0 {
+			latency += "±" + ts.Jitter.Duration.String()
+		}
+		facts = append(facts, latency)
+	}
+	if ts.Loss.Float64 > 0 {
+		facts = append(facts, fmt.Sprintf("%g%% loss", ts.Loss.Float64*100))
+	}
+	return strings.Join(facts, " ")
+}
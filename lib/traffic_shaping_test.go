@@ -0,0 +1,39 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestTrafficShaping(t *testing.T) {
+	t.Parallel()
+
+	var none *TrafficShaping
+	assert.Empty(t, none.Validate())
+	assert.True(t, none.IsZero())
+	assert.True(t, (&TrafficShaping{}).IsZero())
+	assert.Equal(t, "none", (&TrafficShaping{}).String())
+
+	ts := &TrafficShaping{
+		Bandwidth: null.IntFrom(125000),
+		Latency:   types.NullDurationFrom(100 * time.Millisecond),
+		Jitter:    types.NullDurationFrom(20 * time.Millisecond),
+		Loss:      null.FloatFrom(0.005),
+	}
+	assert.Empty(t, ts.Validate())
+	assert.False(t, ts.IsZero())
+	assert.Equal(t, "125000 B/s 100ms±20ms 0.5% loss", ts.String())
+
+	assert.Len(t, (&TrafficShaping{
+		Bandwidth: null.IntFrom(-1),
+		Latency:   types.NullDurationFrom(-time.Second),
+		Jitter:    types.NullDurationFrom(-time.Second),
+		Loss:      null.FloatFrom(1),
+	}).Validate(), 4)
+	assert.Len(t, (&TrafficShaping{Loss: null.FloatFrom(-0.1)}).Validate(), 1)
+}
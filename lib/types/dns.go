@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"gopkg.in/guregu/null.v3"
 )
@@ -173,6 +174,30 @@ func (c DNSConfig) String() string {
 		c.TTL.String, c.Select.String(), c.Policy.String())
 }
 
+// ParseDNSTTL parses the TTL of the DNS lookups: inf to cache them
+// "infinitely", 0 to not cache them, or a duration, the default one if it's
+// empty.
+func ParseDNSTTL(ttlS string) (time.Duration, error) {
+	ttl := time.Duration(0)
+	switch ttlS {
+	case "inf":
+		// cache "infinitely"
+		ttl = time.Hour * 24 * 365
+	case "0":
+		// disable cache
+	case "":
+		ttlS = DefaultDNSConfig().TTL.String
+		fallthrough
+	default:
+		var err error
+		ttl, err = ParseExtendedDuration(ttlS)
+		if ttl < 0 || err != nil {
+			return ttl, fmt.Errorf("invalid DNS TTL: %s", ttlS)
+		}
+	}
+	return ttl, nil
+}
+
 // UnmarshalJSON implements json.Unmarshaler.
 func (c *DNSConfig) UnmarshalJSON(data []byte) error {
 	var s struct {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
@@ -45,6 +46,10 @@ type State struct {
 	// Current group; all emitted metrics are tagged with this.
 	Group *Group
 
+	// The number of HTTP and gRPC requests made by the VU, so the requests
+	// of each group can be counted. It has to be accessed atomically.
+	Requests int64
+
 	// Networking equipment.
 	Dialer DialContexter
 
@@ -54,8 +59,23 @@ type State struct {
 	CookieJar *cookiejar.Jar
 	TLSConfig *tls.Config
 
+	// Registers another transport of the VU, like the ones of the HTTP
+	// clients with their own TLS configuration, so its idle connections are
+	// closed whenever the ones of the Transport are, and when the VU is
+	// deactivated. It can be called again for the same transport.
+	AddTransport func(*http.Transport)
+
 	// Rate limits.
-	RPSLimit *rate.Limiter
+	RPSLimit     *rate.Limiter
+	RateLimiters *RateLimiters
+
+	// Faults injected into the requests.
+	Faults *FaultInjector
+
+	// Capture of the failed and the slow requests, if it's enabled.
+	RequestCapture *RequestCapture
+	// Export of all, or of a sample of, the requests as HAR, if it's enabled.
+	HARExport *RequestCapture
 
 	// Sample channel, possibly buffered
 	Samples chan<- metrics.SampleContainer
@@ -70,6 +90,14 @@ type State struct {
 	// because it includes now also the metadata.
 	Tags *VUStateTags
 
+	// The keys of the metadata that are set only for the current iteration,
+	// they're deleted from the Tags when it ends.
+	IterationMetadata map[string]struct{}
+
+	// The headers that the metadata is sent as by the HTTP and gRPC clients,
+	// by metadata key, see Options.MetadataHeaderNames().
+	MetadataHeaders map[string]string
+
 	// These will be assigned on VU activation.
 	// Returns the iteration number of this VU in the current scenario.
 	GetScenarioVUIter func() uint64
@@ -81,11 +109,31 @@ type State struct {
 	// unique globally across k6 instances (taking into account execution
 	// segments).
 	GetScenarioGlobalVUIter func() uint64
+	// Returns the time to wait after each top-level group, if the scenario
+	// has a think time.
+	ThinkTime func() time.Duration
+	// Returns the data of the setup function of the current scenario, if it
+	// has one, or of setup(); nil if there isn't any.
+	SetupData func() *SetupData
+	// The virtual identity assigned to the VU in the current scenario, nil if
+	// it doesn't have any identities.
+	Identity *Identity
 
 	// Tracing instrumentation.
 	TracerProvider TracerProvider
 }
 
+// SetGroup changes the current group of the VU, and its group tag if the
+// system tag is enabled.
+func (s *State) SetGroup(g *Group) {
+	s.Group = g
+	if s.Options.SystemTags.Has(metrics.TagGroup) {
+		s.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+			tagsAndMeta.SetSystemTagOrMeta(metrics.TagGroup, g.Path)
+		})
+	}
+}
+
 // VUStateTags wraps the current VU's tags and ensures a thread-safe way to
 // access and modify them exists. This is necessary because the VU tags and
 // metadata can be modified from the JS scripts via the `vu.tags` API in the
@@ -0,0 +1,120 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+// azureStorage is Azure Blob Storage, with the azblob://container/blob URLs
+// of the blobs of the account in AZURE_STORAGE_ACCOUNT, or of the one in the
+// connection string in AZURE_STORAGE_CONNECTION_STRING. The requests are
+// authorized with the connection string, with the SAS token or the shared key
+// of the account in AZURE_STORAGE_SAS_TOKEN and AZURE_STORAGE_KEY, or with the
+// default Azure credential of the SDK: the service principal of the
+// environment variables, the workload identity, the managed identity or the
+// Azure CLI.
+type azureStorage struct {
+	env map[string]string
+
+	once   sync.Once
+	client *azblob.Client
+	err    error
+}
+
+func newAzureStorage(env map[string]string) *azureStorage {
+	return &azureStorage{env: env}
+}
+
+func (s *azureStorage) getClient() (*azblob.Client, error) {
+	s.once.Do(func() {
+		s.client, s.err = s.newClient()
+	})
+	return s.client, s.err
+}
+
+func (s *azureStorage) newClient() (*azblob.Client, error) {
+	if connectionString := s.env["AZURE_STORAGE_CONNECTION_STRING"]; connectionString != "" {
+		client, err := azblob.NewClientFromConnectionString(connectionString, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Azure Storage connection string: %w", err)
+		}
+		return client, nil
+	}
+
+	account := s.env["AZURE_STORAGE_ACCOUNT"]
+	if account == "" {
+		return nil, errors.New("the Azure Storage account is unknown, " +
+			"set it with AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING")
+	}
+	serviceURL := "https://" + account + ".blob.core.windows.net/"
+
+	if sas := s.env["AZURE_STORAGE_SAS_TOKEN"]; sas != "" {
+		return azblob.NewClientWithNoCredential(serviceURL+"?"+strings.TrimPrefix(sas, "?"), nil)
+	}
+	if key := s.env["AZURE_STORAGE_KEY"]; key != "" {
+		credential, err := azblob.NewSharedKeyCredential(account, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Azure Storage key: %w", err)
+		}
+		return azblob.NewClientWithSharedKeyCredential(serviceURL, credential, nil)
+	}
+	// the credentials are only looked up with the first request, which fails
+	// if none of them is available
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("no Azure credentials were found: %w", err)
+	}
+	return azblob.NewClient(serviceURL, credential, nil)
+}
+
+func (s *azureStorage) stat(ctx context.Context, container, blob string) (objectInfo, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return objectInfo{}, err
+	}
+	res, err := client.ServiceClient().NewContainerClient(container).NewBlobClient(blob).GetProperties(ctx, nil)
+	if err != nil {
+		return objectInfo{}, azureError(err)
+	}
+	return azureObjectInfo(res.ContentLength, res.LastModified), nil
+}
+
+func (s *azureStorage) open(ctx context.Context, container, blob string) (io.ReadCloser, objectInfo, error) {
+	client, err := s.getClient()
+	if err != nil {
+		return nil, objectInfo{}, err
+	}
+	res, err := client.DownloadStream(ctx, container, blob, nil)
+	if err != nil {
+		return nil, objectInfo{}, azureError(err)
+	}
+	return res.Body, azureObjectInfo(res.ContentLength, res.LastModified), nil
+}
+
+func azureObjectInfo(size *int64, modTime *time.Time) objectInfo {
+	var info objectInfo
+	if size != nil {
+		info.size = *size
+	}
+	if modTime != nil {
+		info.modTime = *modTime
+	}
+	return info
+}
+
+func azureError(err error) error {
+	var resErr *azcore.ResponseError
+	if errors.As(err, &resErr) {
+		return statusError(resErr.StatusCode, err)
+	}
+	return err
+}
@@ -6,8 +6,10 @@ import (
 	"go.k6.io/k6/lib/fsext"
 )
 
-// CreateFilesystems creates the correct filesystem map for the current OS
-func CreateFilesystems(osfs fsext.Fs) map[string]fsext.Fs {
+// CreateFilesystems creates the correct filesystem map for the current OS.
+// The environment variables are used for the credentials of the object
+// storage services.
+func CreateFilesystems(osfs fsext.Fs, env map[string]string) map[string]fsext.Fs {
 	// We want to eliminate disk access at runtime, so we set up a memory mapped cache that's
 	// written every time something is read from the real filesystem. This cache is then used for
 	// successive spawns to read from (they have no access to the real disk).
@@ -18,8 +20,15 @@ func CreateFilesystems(osfs fsext.Fs) map[string]fsext.Fs {
 		// volumes
 		osfs = fsext.NewTrimFilePathSeparatorFs(osfs)
 	}
-	return map[string]fsext.Fs{
+	filesystems := map[string]fsext.Fs{
 		"file":  fsext.NewCacheOnReadFs(osfs, fsext.NewMemMapFs(), 0),
 		"https": fsext.NewMemMapFs(),
 	}
+	// The objects are cached like the local files, the requests for them are
+	// only made the first time they are read.
+	for _, scheme := range ObjectStorageSchemes() {
+		filesystems[scheme] = fsext.NewCacheOnReadFs(
+			newObjectStorageFs(scheme, newObjectStorage(scheme, env)), fsext.NewMemMapFs(), 0)
+	}
+	return filesystems
 }
@@ -0,0 +1,111 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+// gcsStorage is Google Cloud Storage. The credentials are found like the
+// Google Cloud client libraries do: the access token in the
+// GOOGLE_OAUTH_ACCESS_TOKEN environment variable, or the application default
+// credentials, i.e. the file in GOOGLE_APPLICATION_CREDENTIALS, the one
+// written by gcloud (including the workload identity federation ones) or the
+// service account of the instance. The requests to the emulator in
+// STORAGE_EMULATOR_HOST are anonymous, unless there is an explicit access
+// token.
+type gcsStorage struct {
+	env map[string]string
+
+	once   sync.Once
+	client *storage.Client
+	err    error
+}
+
+func newGCSStorage(env map[string]string) *gcsStorage {
+	return &gcsStorage{env: env}
+}
+
+func (s *gcsStorage) getClient(ctx context.Context) (*storage.Client, error) {
+	s.once.Do(func() {
+		s.client, s.err = s.newClient(ctx)
+	})
+	return s.client, s.err
+}
+
+func (s *gcsStorage) newClient(ctx context.Context) (*storage.Client, error) {
+	var opts []option.ClientOption
+	emulator := s.env["STORAGE_EMULATOR_HOST"]
+	if emulator != "" {
+		if !strings.Contains(emulator, "://") {
+			emulator = "http://" + emulator
+		}
+		opts = append(opts, option.WithEndpoint(strings.TrimSuffix(emulator, "/")+"/storage/v1/"))
+	}
+
+	switch token := s.env["GOOGLE_OAUTH_ACCESS_TOKEN"]; {
+	case token != "":
+		opts = append(opts, option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})))
+	case emulator != "":
+		opts = append(opts, option.WithoutAuthentication())
+	case s.env["GOOGLE_APPLICATION_CREDENTIALS"] != "":
+		opts = append(opts, option.WithCredentialsFile(s.env["GOOGLE_APPLICATION_CREDENTIALS"]))
+	default:
+		credentials, err := google.FindDefaultCredentials(ctx, storage.ScopeReadOnly)
+		if err != nil {
+			return nil, fmt.Errorf("no Google Cloud credentials were found: %w", err)
+		}
+		opts = append(opts, option.WithCredentials(credentials))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the Google Cloud Storage client: %w", err)
+	}
+	return client, nil
+}
+
+func (s *gcsStorage) stat(ctx context.Context, bucket, key string) (objectInfo, error) {
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return objectInfo{}, err
+	}
+	attrs, err := client.Bucket(bucket).Object(key).Attrs(ctx)
+	if err != nil {
+		return objectInfo{}, gcsError(err)
+	}
+	return objectInfo{size: attrs.Size, modTime: attrs.Updated}, nil
+}
+
+func (s *gcsStorage) open(ctx context.Context, bucket, key string) (io.ReadCloser, objectInfo, error) {
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, objectInfo{}, err
+	}
+	r, err := client.Bucket(bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, objectInfo{}, gcsError(err)
+	}
+	return r, objectInfo{size: r.Attrs.Size, modTime: r.Attrs.LastModified}, nil
+}
+
+func gcsError(err error) error {
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) {
+		return fs.ErrNotExist
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return statusError(apiErr.Code, err)
+	}
+	return err
+}
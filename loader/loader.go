@@ -75,12 +75,12 @@ func Resolve(pwd *url.URL, moduleSpecifier string) (*url.URL, error) {
 		if err != nil {
 			return nil, err
 		}
-		if u.Scheme != "file" && u.Scheme != "https" {
+		if u.Scheme != "file" && u.Scheme != "https" && !IsObjectStorageScheme(u.Scheme) {
 			return nil,
-				fmt.Errorf("only supported schemes for imports are file and https, %s has `%s`",
+				fmt.Errorf("only supported schemes for imports are file, https, s3, gs and azblob, %s has `%s`",
 					moduleSpecifier, u.Scheme)
 		}
-		if u.Scheme == "file" && pwd.Scheme == "https" {
+		if u.Scheme == "file" && (pwd.Scheme == "https" || IsObjectStorageScheme(pwd.Scheme)) {
 			return nil, fmt.Errorf("origin (%s) not allowed to load local file: %s", pwd, moduleSpecifier)
 		}
 		return u, err
@@ -135,7 +135,8 @@ func Dir(old *url.URL) *url.URL {
 
 // Load loads the provided moduleSpecifier from the given filesystems which are map of fsext.Fs
 // for a given scheme which is they key of the map. If the scheme is https then a request will
-// be made if the files is not found in the map and written to the map.
+// be made if the files is not found in the map and written to the map. The filesystems of the
+// object storage schemes make the requests for the objects themselves.
 func Load(
 	logger logrus.FieldLogger, filesystems map[string]fsext.Fs, moduleSpecifier *url.URL, originalModuleSpecifier string,
 ) (*SourceData, error) {
@@ -168,6 +169,10 @@ func Load(
 		return nil, err
 	}
 
+	if IsObjectStorageScheme(scheme) {
+		return loadObject(filesystems[scheme], moduleSpecifier, originalModuleSpecifier, pathOnFs)
+	}
+
 	data, err := fsext.ReadFile(filesystems[scheme], pathOnFs)
 
 	if err == nil {
@@ -208,6 +213,23 @@ func Load(
 	return result, nil
 }
 
+// loadObject loads the moduleSpecifier from the filesystem of its object storage, which is
+// missing from the archives without any object from it.
+func loadObject(
+	filesystem fsext.Fs, moduleSpecifier *url.URL, originalModuleSpecifier, pathOnFs string,
+) (*SourceData, error) {
+	if filesystem == nil {
+		//nolint:stylecheck
+		return nil, fmt.Errorf(httpsSchemeCouldntBeLoadedMsg, originalModuleSpecifier, moduleSpecifier, fs.ErrNotExist)
+	}
+	data, err := fsext.ReadFile(filesystem, pathOnFs)
+	if err != nil {
+		//nolint:stylecheck
+		return nil, fmt.Errorf(httpsSchemeCouldntBeLoadedMsg, originalModuleSpecifier, moduleSpecifier, err)
+	}
+	return &SourceData{URL: moduleSpecifier, Data: data}, nil
+}
+
 const (
 	magicURLsDeprecationWarning = "Specifier %q resolved to use a non-conventional %[2]q loader. " +
 		"The used %[2]q loader is deprecated and will be removed in k6 v0.53.0."
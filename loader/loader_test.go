@@ -57,7 +57,7 @@ func TestResolve(t *testing.T) {
 			moduleSpecifier := "ws://example.com/html"
 			_, err := loader.Resolve(root, moduleSpecifier)
 			assert.EqualError(t, err,
-				"only supported schemes for imports are file and https, "+moduleSpecifier+" has `ws`")
+				"only supported schemes for imports are file, https, s3, gs and azblob, "+moduleSpecifier+" has `ws`")
 		})
 
 		t.Run("HTTP", func(t *testing.T) {
@@ -65,7 +65,7 @@ func TestResolve(t *testing.T) {
 			moduleSpecifier := "http://example.com/html"
 			_, err := loader.Resolve(root, moduleSpecifier)
 			assert.EqualError(t, err,
-				"only supported schemes for imports are file and https, "+moduleSpecifier+" has `http`")
+				"only supported schemes for imports are file, https, s3, gs and azblob, "+moduleSpecifier+" has `http`")
 		})
 	})
 
@@ -78,6 +78,23 @@ func TestResolve(t *testing.T) {
 		assert.EqualError(t, err, "origin (https://example.com/) not allowed to load local file: file:///etc/shadow")
 	})
 
+	t.Run("Object Storage", func(t *testing.T) {
+		t.Parallel()
+		pwdURL, err := url.Parse("s3://bucket/path/to/")
+		require.NoError(t, err)
+
+		moduleURL, err := loader.Resolve(pwdURL, "../lib.js")
+		require.NoError(t, err)
+		require.Equal(t, "s3://bucket/path/lib.js", moduleURL.String())
+
+		moduleURL, err = loader.Resolve(&url.URL{Scheme: "file", Path: "/"}, "gs://bucket/script.js")
+		require.NoError(t, err)
+		require.Equal(t, "gs://bucket/script.js", moduleURL.String())
+
+		_, err = loader.Resolve(pwdURL, "file:///etc/shadow")
+		assert.EqualError(t, err, "origin (s3://bucket/path/to/) not allowed to load local file: file:///etc/shadow")
+	})
+
 	t.Run("Fixes missing slash in pwd", func(t *testing.T) {
 		t.Parallel()
 		pwdURL, err := url.Parse("https://example.com/path/to")
@@ -0,0 +1,219 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/afero/mem"
+)
+
+// objectStorageTimeout is the maximum duration of a request for an object.
+const objectStorageTimeout = time.Minute
+
+// ObjectStorageSchemes returns the URL schemes of the supported object storage
+// services: s3:// for Amazon S3, gs:// for Google Cloud Storage and azblob://
+// for Azure Blob Storage.
+func ObjectStorageSchemes() []string {
+	return []string{"s3", "gs", "azblob"}
+}
+
+// IsObjectStorageScheme returns whether the URL scheme is one of an object
+// storage service.
+func IsObjectStorageScheme(scheme string) bool {
+	for _, s := range ObjectStorageSchemes() {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// objectStorage gets the objects of an object storage service, with the
+// client of its official SDK. The errors for the missing objects and for the
+// denied accesses wrap fs.ErrNotExist and fs.ErrPermission.
+type objectStorage interface {
+	// stat returns the size and the modification time of the object.
+	stat(ctx context.Context, bucket, key string) (objectInfo, error)
+	// open returns the content of the object, with its size and modification
+	// time.
+	open(ctx context.Context, bucket, key string) (io.ReadCloser, objectInfo, error)
+}
+
+// newObjectStorage returns the object storage for the URL scheme. The clients
+// are only created, and their credentials looked up, the first time an object
+// is requested.
+func newObjectStorage(scheme string, env map[string]string) objectStorage {
+	switch scheme {
+	case "s3":
+		return newS3Storage(env)
+	case "gs":
+		return newGCSStorage(env)
+	case "azblob":
+		return newAzureStorage(env)
+	default:
+		panic(fmt.Sprintf("unknown object storage '%s'", scheme))
+	}
+}
+
+// statusError returns the error for the HTTP status code of a failed request.
+func statusError(statusCode int, err error) error {
+	switch statusCode {
+	case http.StatusNotFound:
+		return fs.ErrNotExist
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", fs.ErrPermission, err)
+	default:
+		return err
+	}
+}
+
+// objectStorageFs is a read-only filesystem with the objects of an object
+// storage service. The first directory of the paths is the bucket (or the
+// container) and the rest is the key of the object. Every operation makes a
+// request, so it's meant to be used under a CacheOnReadFs.
+type objectStorageFs struct {
+	scheme  string
+	storage objectStorage
+}
+
+var _ afero.Fs = &objectStorageFs{}
+
+func newObjectStorageFs(scheme string, storage objectStorage) *objectStorageFs {
+	return &objectStorageFs{scheme: scheme, storage: storage}
+}
+
+// objectInfo describes an object.
+type objectInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (oi objectInfo) Name() string       { return oi.name }
+func (oi objectInfo) Size() int64        { return oi.size }
+func (oi objectInfo) ModTime() time.Time { return oi.modTime }
+func (oi objectInfo) IsDir() bool        { return oi.dir }
+func (oi objectInfo) Sys() any           { return nil }
+
+func (oi objectInfo) Mode() fs.FileMode {
+	if oi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+func splitObjectPath(name string) (bucket, key string) {
+	bucket, key, _ = strings.Cut(strings.TrimPrefix(filepath.ToSlash(name), "/"), "/")
+	return bucket, key
+}
+
+// isBucketOrDir returns whether the path is of a bucket or of a "directory",
+// since there aren't requests for them.
+func isBucketOrDir(name string) bool {
+	_, key := splitObjectPath(name)
+	return key == "" || strings.HasSuffix(key, "/")
+}
+
+// Stat returns the information of the object, without downloading it.
+func (f *objectStorageFs) Stat(name string) (fs.FileInfo, error) {
+	if isBucketOrDir(name) {
+		return objectInfo{name: path.Base(filepath.ToSlash(name)), modTime: time.Now(), dir: true}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), objectStorageTimeout)
+	defer cancel()
+	bucket, key := splitObjectPath(name)
+	info, err := f.storage.stat(ctx, bucket, key)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	info.name = path.Base(filepath.ToSlash(name))
+	return info, nil
+}
+
+// Open downloads the object.
+func (f *objectStorageFs) Open(name string) (afero.File, error) {
+	if isBucketOrDir(name) {
+		return mem.NewReadOnlyFileHandle(mem.CreateDir(name)), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), objectStorageTimeout)
+	defer cancel()
+	bucket, key := splitObjectPath(name)
+	body, info, err := f.storage.open(ctx, bucket, key)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	defer func() { _ = body.Close() }()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	fileData := mem.CreateFile(name)
+	if _, err = mem.NewFileHandle(fileData).Write(data); err != nil {
+		return nil, err
+	}
+	mem.SetModTime(fileData, info.modTime)
+	return mem.NewReadOnlyFileHandle(fileData), nil
+}
+
+// OpenFile opens the object, if the flags are only for reading it.
+func (f *objectStorageFs) OpenFile(name string, flag int, _ fs.FileMode) (afero.File, error) {
+	if flag&(syscall.O_WRONLY|syscall.O_RDWR|syscall.O_APPEND|syscall.O_CREAT|syscall.O_TRUNC) != 0 {
+		return nil, readOnlyError("open", name)
+	}
+	return f.Open(name)
+}
+
+// Name returns the URL scheme of the object storage.
+func (f *objectStorageFs) Name() string { return f.scheme }
+
+func readOnlyError(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: syscall.EPERM}
+}
+
+// Create isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) Create(name string) (afero.File, error) {
+	return nil, readOnlyError("create", name)
+}
+
+// Mkdir isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) Mkdir(name string, _ fs.FileMode) error {
+	return readOnlyError("mkdir", name)
+}
+
+// MkdirAll isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) MkdirAll(name string, _ fs.FileMode) error {
+	return readOnlyError("mkdir", name)
+}
+
+// Remove isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) Remove(name string) error { return readOnlyError("remove", name) }
+
+// RemoveAll isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) RemoveAll(name string) error { return readOnlyError("remove", name) }
+
+// Rename isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) Rename(oldname, _ string) error { return readOnlyError("rename", oldname) }
+
+// Chmod isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) Chmod(name string, _ fs.FileMode) error {
+	return readOnlyError("chmod", name)
+}
+
+// Chown isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) Chown(name string, _, _ int) error { return readOnlyError("chown", name) }
+
+// Chtimes isn't supported, since the filesystem is read-only.
+func (f *objectStorageFs) Chtimes(name string, _, _ time.Time) error {
+	return readOnlyError("chtimes", name)
+}
@@ -0,0 +1,180 @@
+package loader
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+)
+
+func TestObjectStorageLoad(t *testing.T) {
+	t.Parallel()
+
+	const script = "export default function () {}"
+	testCases := []struct {
+		name          string
+		scheme        string
+		path          string
+		env           func(endpoint string) map[string]string
+		authorization string
+		query         string
+		// the requests for the information and for the content of the object
+		requests []string
+	}{
+		{
+			name:   "s3",
+			scheme: "s3",
+			path:   "/bucket/path/script.js",
+			env: func(endpoint string) map[string]string {
+				return map[string]string{
+					"AWS_ENDPOINT_URL_S3":   endpoint,
+					"AWS_REGION":            "eu-west-1",
+					"AWS_ACCESS_KEY_ID":     "AKID",
+					"AWS_SECRET_ACCESS_KEY": "SECRET",
+					"AWS_SESSION_TOKEN":     "TOKEN",
+				}
+			},
+			authorization: "AWS4-HMAC-SHA256 Credential=AKID/",
+			requests:      []string{"HEAD /bucket/path/script.js", "GET /bucket/path/script.js"},
+		},
+		{
+			name:   "gs",
+			scheme: "gs",
+			path:   "/bucket/path/script.js",
+			env: func(endpoint string) map[string]string {
+				return map[string]string{"STORAGE_EMULATOR_HOST": endpoint, "GOOGLE_OAUTH_ACCESS_TOKEN": "TOKEN"}
+			},
+			authorization: "Bearer TOKEN",
+			requests:      []string{"GET /storage/v1/b/bucket/o/path/script.js", "GET /bucket/path/script.js"},
+		},
+		{
+			name:   "azblob with shared key",
+			scheme: "azblob",
+			path:   "/account/bucket/path/script.js",
+			env: func(endpoint string) map[string]string {
+				return map[string]string{"AZURE_STORAGE_CONNECTION_STRING": "AccountName=account;" +
+					"AccountKey=a2V5;BlobEndpoint=" + endpoint + "/account"}
+			},
+			authorization: "SharedKey account:",
+			requests:      []string{"HEAD /account/bucket/path/script.js", "GET /account/bucket/path/script.js"},
+		},
+		{
+			name:   "azblob with SAS",
+			scheme: "azblob",
+			path:   "/account/bucket/path/script.js",
+			env: func(endpoint string) map[string]string {
+				return map[string]string{"AZURE_STORAGE_CONNECTION_STRING": "AccountName=account;" +
+					"SharedAccessSignature=sv=2021-08-06&sig=SIG;BlobEndpoint=" + endpoint + "/account"}
+			},
+			query:    "sig=SIG",
+			requests: []string{"HEAD /account/bucket/path/script.js", "GET /account/bucket/path/script.js"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				mu.Unlock()
+				if !assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), tc.authorization)) ||
+					!assert.Contains(t, r.URL.RawQuery, tc.query) {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				switch r.URL.Path {
+				case tc.path:
+					w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+					w.Header().Set("Content-Length", strconv.Itoa(len(script)))
+					if r.Method != http.MethodHead {
+						_, _ = w.Write([]byte(script))
+					}
+				case "/storage/v1/b/bucket/o/path/script.js":
+					_, _ = fmt.Fprintf(w, `{"bucket":"bucket","name":"path/script.js","size":"%d",`+
+						`"updated":"2015-10-21T07:28:00Z"}`, len(script))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			logger := logrus.New()
+			logger.SetOutput(testutils.NewTestOutput(t))
+			filesystems := CreateFilesystems(fsext.NewMemMapFs(), tc.env(srv.URL))
+
+			moduleSpecifier := tc.scheme + "://bucket/path/script.js"
+			moduleURL, err := url.Parse(moduleSpecifier)
+			require.NoError(t, err)
+			src, err := Load(logger, filesystems, moduleURL, moduleSpecifier)
+			require.NoError(t, err)
+			assert.Equal(t, script, string(src.Data))
+			assert.Equal(t, moduleURL, src.URL)
+
+			// the object is cached
+			_, err = Load(logger, filesystems, moduleURL, moduleSpecifier)
+			require.NoError(t, err)
+			assert.Equal(t, tc.requests, requests)
+
+			moduleSpecifier = tc.scheme + "://bucket/missing.js"
+			moduleURL, err = url.Parse(moduleSpecifier)
+			require.NoError(t, err)
+			_, err = Load(logger, filesystems, moduleURL, moduleSpecifier)
+			require.ErrorContains(t, err, `The moduleSpecifier "`+moduleSpecifier+`" couldn't be retrieved`)
+			require.ErrorContains(t, err, "file does not exist")
+		})
+	}
+}
+
+func TestObjectStorageWithoutCredentials(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	storage := newS3Storage(map[string]string{
+		"AWS_CONFIG_FILE":             filepath.Join(dir, "config"),
+		"AWS_SHARED_CREDENTIALS_FILE": filepath.Join(dir, "credentials"),
+		"AWS_EC2_METADATA_DISABLED":   "true",
+	})
+	filesystem := newObjectStorageFs("s3", storage)
+	_, err := filesystem.Stat("/bucket/script.js")
+	require.ErrorContains(t, err, "no AWS credentials were found")
+}
+
+func TestObjectStorageLoadWithoutFilesystem(t *testing.T) {
+	t.Parallel()
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	moduleURL, err := url.Parse("s3://bucket/script.js")
+	require.NoError(t, err)
+
+	_, err = Load(logger, map[string]fsext.Fs{"file": fsext.NewMemMapFs()}, moduleURL, "s3://bucket/script.js")
+	require.ErrorContains(t, err, `The moduleSpecifier "s3://bucket/script.js" couldn't be retrieved`)
+}
+
+func TestObjectStorageFsIsReadOnly(t *testing.T) {
+	t.Parallel()
+	filesystem := newObjectStorageFs("s3", nil)
+	_, err := filesystem.Create("/bucket/key")
+	require.ErrorContains(t, err, "operation not permitted")
+	require.Error(t, filesystem.Remove("/bucket/key"))
+
+	info, err := filesystem.Stat("/bucket")
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
@@ -109,7 +109,7 @@ func TestReadSourceHttpError(t *testing.T) {
 	_, err := ReadSource(logger, "http://github.com/something", "/c",
 		map[string]fsext.Fs{"file": fsext.NewMemMapFs(), "https": fs}, nil)
 	require.Error(t, err)
-	require.Contains(t, err.Error(), `only supported schemes for imports are file and https`)
+	require.Contains(t, err.Error(), `only supported schemes for imports are file, https, s3, gs and azblob`)
 }
 
 func TestReadSourceMissingFileError(t *testing.T) {
@@ -0,0 +1,84 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"go.k6.io/k6/lib/awsconfig"
+)
+
+// s3Storage is Amazon S3, or any service compatible with it, e.g. MinIO. The
+// configuration and the credentials are found like the AWS CLI does, see
+// awsconfig.Load().
+type s3Storage struct {
+	env map[string]string
+
+	once   sync.Once
+	client *s3.Client
+	err    error
+}
+
+func newS3Storage(env map[string]string) *s3Storage {
+	return &s3Storage{env: env}
+}
+
+func (s *s3Storage) getClient(ctx context.Context) (*s3.Client, error) {
+	s.once.Do(func() {
+		s.client, s.err = s.newClient(ctx)
+	})
+	return s.client, s.err
+}
+
+func (s *s3Storage) newClient(ctx context.Context) (*s3.Client, error) {
+	cfg, err := awsconfig.Load(ctx, s.env)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := awsconfig.Endpoint(s.env, "S3")
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			// the services compatible with S3 don't always support the
+			// virtual-hosted-style URLs
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	}), nil
+}
+
+func (s *s3Storage) stat(ctx context.Context, bucket, key string) (objectInfo, error) {
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return objectInfo{}, err
+	}
+	res, err := client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return objectInfo{}, s3Error(err)
+	}
+	return objectInfo{size: aws.ToInt64(res.ContentLength), modTime: aws.ToTime(res.LastModified)}, nil
+}
+
+func (s *s3Storage) open(ctx context.Context, bucket, key string) (io.ReadCloser, objectInfo, error) {
+	client, err := s.getClient(ctx)
+	if err != nil {
+		return nil, objectInfo{}, err
+	}
+	res, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, objectInfo{}, s3Error(err)
+	}
+	return res.Body, objectInfo{size: aws.ToInt64(res.ContentLength), modTime: aws.ToTime(res.LastModified)}, nil
+}
+
+func s3Error(err error) error {
+	var resErr *awshttp.ResponseError
+	if errors.As(err, &resErr) {
+		return statusError(resErr.HTTPStatusCode(), err)
+	}
+	return err
+}
@@ -8,8 +8,11 @@ import (
 	"io"
 	"io/fs"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.k6.io/k6/lib/fsext"
@@ -19,6 +22,10 @@ import (
 // fileHookBufferSize is a default size for the fileHook's loglines channel.
 const fileHookBufferSize = 100
 
+// rotatedFileTimeFormat is the format of the time that is appended to the
+// names of the rotated log files.
+const rotatedFileTimeFormat = "20060102T150405.000"
+
 // fileHook is a hook to handle writing to local files.
 type fileHook struct {
 	fs             fsext.Fs
@@ -28,6 +35,20 @@ type fileHook struct {
 	w              io.WriteCloser
 	bw             *bufio.Writer
 	levels         []logrus.Level
+
+	// the entries are formatted with the formatter of the logger if it's nil
+	formatter logrus.Formatter
+	// the hook of the file with only the errors, if there is one
+	errorHook *fileHook
+
+	// the file is rotated when it gets bigger than maxSize, or older than
+	// rotateInterval, and only the last maxBackups rotated files are kept
+	maxSize        int64
+	rotateInterval time.Duration
+	maxBackups     int
+	size           int64
+	openedAt       time.Time
+	now            func() time.Time
 }
 
 // FileHookFromConfigLine returns new fileHook hook.
@@ -35,71 +56,147 @@ func FileHookFromConfigLine(
 	fs fsext.Fs, getCwd func() (string, error),
 	fallbackLogger logrus.FieldLogger, line string,
 ) (AsyncHook, error) {
-	hook := &fileHook{
-		fs:             fs,
-		fallbackLogger: fallbackLogger,
-		levels:         logrus.AllLevels,
-		loglines:       make(chan []byte, fileHookBufferSize),
-	}
+	hook := newFileHook(fs, fallbackLogger)
 
 	parts := strings.SplitN(line, "=", 2)
 	if parts[0] != "file" {
 		return nil, fmt.Errorf("logfile configuration should be in the form `file=path-to-local-file` but is `%s`", line)
 	}
-	if err := hook.parseArgs(line); err != nil {
+	errorPath, err := hook.parseArgs(line)
+	if err != nil {
+		return nil, err
+	}
+	if err := hook.resolvePath(getCwd); err != nil {
 		return nil, err
 	}
-	if err := hook.openFile(getCwd); err != nil {
+	if errorPath != "" {
+		errorHook := newFileHook(fs, fallbackLogger)
+		errorHook.path = errorPath
+		errorHook.levels = logrus.AllLevels[:logrus.ErrorLevel+1]
+		errorHook.formatter = hook.formatter
+		errorHook.maxSize, errorHook.rotateInterval, errorHook.maxBackups =
+			hook.maxSize, hook.rotateInterval, hook.maxBackups
+		if err := errorHook.resolvePath(getCwd); err != nil {
+			return nil, err
+		}
+		if err := errorHook.openFile(); err != nil {
+			return nil, err
+		}
+		hook.errorHook = errorHook
+	}
+	if err := hook.openFile(); err != nil {
 		return nil, err
 	}
 	return hook, nil
 }
 
-func (h *fileHook) parseArgs(line string) error {
+func newFileHook(fs fsext.Fs, fallbackLogger logrus.FieldLogger) *fileHook {
+	return &fileHook{
+		fs:             fs,
+		fallbackLogger: fallbackLogger,
+		levels:         logrus.AllLevels,
+		loglines:       make(chan []byte, fileHookBufferSize),
+		now:            time.Now,
+	}
+}
+
+//nolint:cyclop
+func (h *fileHook) parseArgs(line string) (errorPath string, err error) {
 	tokens, err := strvals.Parse(line)
 	if err != nil {
-		return fmt.Errorf("error while parsing logfile configuration %w", err)
+		return "", fmt.Errorf("error while parsing logfile configuration %w", err)
 	}
 
 	for _, token := range tokens {
 		switch token.Key {
 		case "file":
 			if token.Value == "" {
-				return fmt.Errorf("filepath must not be empty")
+				return "", fmt.Errorf("filepath must not be empty")
 			}
 			h.path = token.Value
 		case "level":
 			h.levels, err = parseLevels(token.Value)
 			if err != nil {
-				return err
+				return "", err
+			}
+		case "format":
+			if token.Value != "json" {
+				return "", fmt.Errorf("unknown logfile format %s, only json is supported", token.Value)
 			}
+			h.formatter = &jsonLinesFormatter{}
+		case "maxsize":
+			h.maxSize, err = parseSize(token.Value)
+			if err != nil {
+				return "", fmt.Errorf("invalid logfile maxsize %s: %w", token.Value, err)
+			}
+		case "rotate":
+			h.rotateInterval, err = time.ParseDuration(token.Value)
+			if err != nil || h.rotateInterval <= 0 {
+				return "", fmt.Errorf("invalid logfile rotate interval %s, it has to be a positive duration", token.Value)
+			}
+		case "backups":
+			h.maxBackups, err = strconv.Atoi(token.Value)
+			if err != nil || h.maxBackups < 0 {
+				return "", fmt.Errorf("invalid logfile backups %s, it has to be a non-negative integer", token.Value)
+			}
+		case "errors":
+			if token.Value == "" {
+				return "", fmt.Errorf("the errors filepath must not be empty")
+			}
+			errorPath = token.Value
 		default:
-			return fmt.Errorf("unknown logfile config key %s", token.Key)
+			return "", fmt.Errorf("unknown logfile config key %s", token.Key)
 		}
 	}
 
-	return nil
+	return errorPath, nil
 }
 
-// openFile opens logfile and initializes writers.
-func (h *fileHook) openFile(getCwd func() (string, error)) error {
-	path := h.path
-	if !filepath.IsAbs(path) {
+// parseSize parses a size in bytes, with an optional KB, MB or GB suffix.
+func parseSize(s string) (int64, error) {
+	multiplier := int64(1)
+	upper := strings.ToUpper(s)
+	for suffix, m := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if trimmed, ok := strings.CutSuffix(upper, suffix); ok {
+			upper, multiplier = trimmed, m
+			break
+		}
+	}
+	size, err := strconv.ParseInt(strings.TrimSuffix(upper, "B"), 10, 64)
+	if err != nil || size <= 0 {
+		return 0, errors.New("it has to be a positive number of bytes, optionally with a KB, MB or GB suffix")
+	}
+	return size * multiplier, nil
+}
+
+// resolvePath makes the path of the logfile absolute, so the file can be
+// reopened after it's rotated.
+func (h *fileHook) resolvePath(getCwd func() (string, error)) error {
+	if !filepath.IsAbs(h.path) {
 		cwd, err := getCwd()
 		if err != nil {
-			return fmt.Errorf("'%s' is a relative path but could not determine CWD: %w", path, err)
+			return fmt.Errorf("'%s' is a relative path but could not determine CWD: %w", h.path, err)
 		}
-		path = filepath.Join(cwd, path)
+		h.path = filepath.Join(cwd, h.path)
 	}
 
-	if _, err := h.fs.Stat(filepath.Dir(path)); errors.Is(err, fs.ErrNotExist) {
-		return fmt.Errorf("provided directory '%s' does not exist", filepath.Dir(path))
+	if _, err := h.fs.Stat(filepath.Dir(h.path)); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("provided directory '%s' does not exist", filepath.Dir(h.path))
 	}
+	return nil
+}
 
-	file, err := h.fs.OpenFile(path, syscall.O_WRONLY|syscall.O_APPEND|syscall.O_CREAT, 0o600)
+// openFile opens logfile and initializes writers.
+func (h *fileHook) openFile() error {
+	file, err := h.fs.OpenFile(h.path, syscall.O_WRONLY|syscall.O_APPEND|syscall.O_CREAT, 0o600)
 	if err != nil {
-		return fmt.Errorf("failed to open logfile %s: %w", path, err)
+		return fmt.Errorf("failed to open logfile %s: %w", h.path, err)
 	}
+	h.size = 0
+	if info, err := file.Stat(); err == nil {
+		h.size = info.Size()
+	}
+	h.openedAt = h.now()
 
 	h.w = file
 	h.bw = bufio.NewWriter(file)
@@ -107,15 +204,112 @@ func (h *fileHook) openFile(getCwd func() (string, error)) error {
 	return nil
 }
 
+// write writes the log line to the logfile, after rotating it if needed.
+func (h *fileHook) write(line []byte) {
+	if h.shouldRotate(len(line)) {
+		if err := h.rotate(); err != nil {
+			h.fallbackLogger.Errorf("failed to rotate the logfile: %s", err)
+		}
+	}
+	n, err := h.bw.Write(line)
+	h.size += int64(n)
+	if err != nil {
+		h.fallbackLogger.Errorf("failed to write a log message to a logfile: %s", err)
+	}
+}
+
+func (h *fileHook) shouldRotate(lineSize int) bool {
+	if h.maxSize > 0 && h.size > 0 && h.size+int64(lineSize) > h.maxSize {
+		return true
+	}
+	return h.rotateInterval > 0 && h.now().Sub(h.openedAt) >= h.rotateInterval
+}
+
+// rotate renames the logfile, with the current time appended to its name,
+// removes the rotated files over the backups limit and opens a new logfile.
+func (h *fileHook) rotate() error {
+	if err := h.bw.Flush(); err != nil {
+		return err
+	}
+	if err := h.w.Close(); err != nil {
+		return err
+	}
+
+	rotatedPath := h.path + "." + h.now().Format(rotatedFileTimeFormat)
+	for i := 1; ; i++ {
+		exists, err := fsext.Exists(h.fs, rotatedPath)
+		if err != nil || !exists {
+			break
+		}
+		rotatedPath = h.path + "." + h.now().Format(rotatedFileTimeFormat) + "-" + strconv.Itoa(i)
+	}
+	if err := h.fs.Rename(h.path, rotatedPath); err != nil {
+		// the logging goes on in the same file, as it's better than losing it
+		if openErr := h.openFile(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := h.openFile(); err != nil {
+		return err
+	}
+	return h.removeOldBackups()
+}
+
+func (h *fileHook) removeOldBackups() error {
+	if h.maxBackups == 0 {
+		return nil
+	}
+	files, err := fsext.ReadDir(h.fs, filepath.Dir(h.path))
+	if err != nil {
+		return err
+	}
+	prefix := filepath.Base(h.path) + "."
+	var backups []string
+	for _, f := range files {
+		rotatedAt, ok := strings.CutPrefix(f.Name(), prefix)
+		if !ok || f.IsDir() {
+			continue
+		}
+		// other files can have the same prefix, like the errors logfile
+		rotatedAt, _, _ = strings.Cut(rotatedAt, "-")
+		if _, err := time.Parse(rotatedFileTimeFormat, rotatedAt); err == nil {
+			backups = append(backups, f.Name())
+		}
+	}
+	if len(backups) <= h.maxBackups {
+		return nil
+	}
+	// the names end with the time of their rotation, so they sort by it
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-h.maxBackups] {
+		if err := h.fs.Remove(filepath.Join(filepath.Dir(h.path), name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Listen waits for log lines to flush.
 func (h *fileHook) Listen(ctx context.Context) {
+	if h.errorHook != nil {
+		done := make(chan struct{})
+		go func() {
+			h.errorHook.Listen(ctx)
+			close(done)
+		}()
+		defer func() { <-done }()
+	}
+	if h.now == nil {
+		h.now = time.Now
+	}
+
 	for {
 		select {
 		case entry := <-h.loglines:
-			if _, err := h.bw.Write(entry); err != nil {
-				h.fallbackLogger.Errorf("failed to write a log message to a logfile: %w", err)
-			}
+			h.write(entry)
 		case <-ctx.Done():
+			h.drain()
 			if err := h.bw.Flush(); err != nil {
 				h.fallbackLogger.Errorf("failed to flush buffer: %w", err)
 			}
@@ -129,14 +323,35 @@ func (h *fileHook) Listen(ctx context.Context) {
 	}
 }
 
+// drain writes the log lines that are still buffered.
+func (h *fileHook) drain() {
+	for {
+		select {
+		case entry := <-h.loglines:
+			h.write(entry)
+		default:
+			return
+		}
+	}
+}
+
 // Fire writes the log file to defined path.
 func (h *fileHook) Fire(entry *logrus.Entry) error {
-	message, err := entry.Bytes()
+	var message []byte
+	var err error
+	if h.formatter != nil {
+		message, err = h.formatter.Format(entry)
+	} else {
+		message, err = entry.Bytes()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get a log entry bytes: %w", err)
 	}
 
 	h.loglines <- message
+	if h.errorHook != nil && entry.Level <= logrus.ErrorLevel {
+		return h.errorHook.Fire(entry)
+	}
 	return nil
 }
 
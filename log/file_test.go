@@ -4,7 +4,10 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -78,6 +81,36 @@ func TestFileHookFromConfigLine(t *testing.T) {
 			err:        true,
 			errMessage: "unknown logfile config key unknown",
 		},
+		{
+			line: "file=/k6.log,format=json,maxsize=10MB,rotate=1h,backups=3,errors=/k6-errors.log",
+			err:  false,
+		},
+		{
+			line:       "file=/k6.log,format=logfmt",
+			err:        true,
+			errMessage: "unknown logfile format logfmt, only json is supported",
+		},
+		{
+			line: "file=/k6.log,maxsize=10TB",
+			err:  true,
+			errMessage: "invalid logfile maxsize 10TB: it has to be a positive number of bytes, " +
+				"optionally with a KB, MB or GB suffix",
+		},
+		{
+			line:       "file=/k6.log,rotate=-1h",
+			err:        true,
+			errMessage: "invalid logfile rotate interval -1h, it has to be a positive duration",
+		},
+		{
+			line:       "file=/k6.log,backups=many",
+			err:        true,
+			errMessage: "invalid logfile backups many, it has to be a non-negative integer",
+		},
+		{
+			line:       "file=/k6.log,errors=/a/c/errors.log",
+			err:        true,
+			errMessage: "provided directory '/a/c' does not exist",
+		},
 		{
 			line:       "unknown=something",
 			err:        true,
@@ -146,3 +179,139 @@ func TestFileHookFire(t *testing.T) {
 
 	assert.Contains(t, buffer.String(), "example log line")
 }
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+
+	for s, exp := range map[string]int64{"100": 100, "100b": 100, "2KB": 2048, "10MB": 10 << 20, "1gb": 1 << 30} {
+		size, err := parseSize(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, exp, size, s)
+	}
+	for _, s := range []string{"", "0", "-1MB", "1.5MB", "MB", "1TB"} {
+		_, err := parseSize(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func newTestFileHook(t *testing.T, fs fsext.Fs, line string) (*fileHook, context.CancelFunc, chan struct{}) {
+	t.Helper()
+	hook, err := FileHookFromConfigLine(fs, func() (string, error) { return "/", nil }, logrus.New(), line)
+	require.NoError(t, err)
+	fh, ok := hook.(*fileHook)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		fh.Listen(ctx)
+		close(done)
+	}()
+	return fh, cancel, done
+}
+
+func TestFileHookRotateBySize(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	hook, cancel, done := newTestFileHook(t, fs, "file=/k6.log,maxsize=100,backups=2")
+
+	for i := 0; i < 4; i++ {
+		// each line is more than half of the max size, so each one is in its own file
+		hook.loglines <- []byte(strings.Repeat(strconv.Itoa(i), 59) + "\n")
+	}
+	cancel()
+	<-done
+
+	data, err := fsext.ReadFile(fs, "/k6.log")
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("3", 59)+"\n", string(data))
+
+	files, err := fsext.ReadDir(fs, "/")
+	require.NoError(t, err)
+	var backups []string
+	for _, f := range files {
+		if f.Name() != "k6.log" {
+			backups = append(backups, f.Name())
+		}
+	}
+	// the oldest backup, with the line 0, was removed
+	require.Len(t, backups, 2)
+	for i, backup := range backups {
+		data, err := fsext.ReadFile(fs, "/"+backup)
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat(strconv.Itoa(i+1), 59)+"\n", string(data))
+	}
+}
+
+func TestFileHookRotateByTime(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	now := start
+	hook, err := FileHookFromConfigLine(fs, func() (string, error) { return "/", nil }, logrus.New(),
+		"file=/k6.log,rotate=1h")
+	require.NoError(t, err)
+	fh, ok := hook.(*fileHook)
+	require.True(t, ok)
+	fh.now = func() time.Time { return now }
+	fh.openedAt = start
+
+	fh.write([]byte("first\n"))
+	now = start.Add(30 * time.Minute)
+	fh.write([]byte("second\n"))
+	now = start.Add(time.Hour)
+	fh.write([]byte("third\n"))
+	require.NoError(t, fh.bw.Flush())
+
+	data, err := fsext.ReadFile(fs, "/k6.log")
+	require.NoError(t, err)
+	assert.Equal(t, "third\n", string(data))
+	data, err = fsext.ReadFile(fs, "/k6.log.20240102T040405.000")
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data))
+}
+
+func TestFileHookJSONLinesAndErrors(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	hook, cancel, done := newTestFileHook(t, fs, "file=/k6.log,format=json,errors=/k6.log.errors")
+
+	logger := logrus.New()
+	logger.AddHook(hook)
+	logger.SetOutput(io.Discard)
+
+	metadata := &Metadata{VU: 3, Scenario: "checkout", Iteration: 7}
+	vuLogger := logger.WithContext(WithMetadata(context.Background(), func() *Metadata { return metadata }))
+	vuLogger.WithField("source", "console").Info("from a VU")
+	vuLogger.Error("failed in a VU")
+	logger.Warn("not from a VU")
+
+	cancel()
+	<-done
+
+	data, err := fsext.ReadFile(fs, "/k6.log")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "from a VU", entry["msg"])
+	assert.Equal(t, "info", entry["level"])
+	assert.Equal(t, "console", entry["source"])
+	assert.Equal(t, 3.0, entry["vu"])
+	assert.Equal(t, "checkout", entry["scenario"])
+	assert.Equal(t, 7.0, entry["iteration"])
+
+	entry = nil
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &entry))
+	assert.NotContains(t, entry, "vu")
+
+	data, err = fsext.ReadFile(fs, "/k6.log.errors")
+	require.NoError(t, err)
+	lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"msg":"failed in a VU"`)
+}
@@ -0,0 +1,59 @@
+package log
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Metadata is the information about the VU that logged an entry, which the
+// JSON lines of the file output include.
+type Metadata struct {
+	VU        uint64
+	Scenario  string
+	Iteration int64
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a context for the log entries of a VU, with a function
+// that returns its metadata at the time an entry is logged. The function
+// returns nil while the VU isn't running an iteration.
+func WithMetadata(ctx context.Context, metadata func() *Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, metadata)
+}
+
+// metadataFromEntry returns the metadata of the VU that logged the entry, if
+// it has any.
+func metadataFromEntry(entry *logrus.Entry) *Metadata {
+	if entry.Context == nil {
+		return nil
+	}
+	metadata, ok := entry.Context.Value(metadataKey{}).(func() *Metadata)
+	if !ok {
+		return nil
+	}
+	return metadata()
+}
+
+// jsonLinesFormatter formats the entries as JSON lines, with the metadata of
+// the VUs that logged them.
+type jsonLinesFormatter struct {
+	logrus.JSONFormatter
+}
+
+// Format implements logrus.Formatter.
+func (f *jsonLinesFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if md := metadataFromEntry(entry); md != nil {
+		withMetadata := entry.WithFields(logrus.Fields{
+			"vu":        md.VU,
+			"scenario":  md.Scenario,
+			"iteration": md.Iteration,
+		})
+		withMetadata.Level = entry.Level
+		withMetadata.Message = entry.Message
+		withMetadata.Caller = entry.Caller
+		entry = withMetadata
+	}
+	return f.JSONFormatter.Format(entry)
+}
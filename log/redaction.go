@@ -0,0 +1,77 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redactor replaces the sensitive data in a string.
+type Redactor interface {
+	Redact(s string) string
+}
+
+// RedactionHook is a logrus hook that redacts the messages and the fields of
+// the log entries with its redactors. It has to be added to the logger before
+// any hook that sends the entries elsewhere, and the redactors can be added to
+// it later, e.g. once the options of the test are known.
+type RedactionHook struct {
+	mx        sync.RWMutex
+	redactors []Redactor
+}
+
+// NewRedactionHook returns a RedactionHook without any redactors.
+func NewRedactionHook() *RedactionHook {
+	return &RedactionHook{}
+}
+
+// Add adds the redactor to the hook.
+func (h *RedactionHook) Add(r Redactor) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.redactors = append(h.redactors, r)
+}
+
+// Redact returns the string redacted by all of the redactors.
+func (h *RedactionHook) Redact(s string) string {
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+	for _, r := range h.redactors {
+		s = r.Redact(s)
+	}
+	return s
+}
+
+// Levels returns all of the levels.
+func (h *RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire redacts the message and the string, error and fmt.Stringer fields of
+// the entry.
+func (h *RedactionHook) Fire(entry *logrus.Entry) error {
+	h.mx.RLock()
+	empty := len(h.redactors) == 0
+	h.mx.RUnlock()
+	if empty {
+		return nil
+	}
+
+	entry.Message = h.Redact(entry.Message)
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case string:
+			entry.Data[k] = h.Redact(v)
+		case error:
+			if msg := v.Error(); h.Redact(msg) != msg {
+				entry.Data[k] = h.Redact(msg)
+			}
+		case fmt.Stringer:
+			if s := v.String(); h.Redact(s) != s {
+				entry.Data[k] = h.Redact(s)
+			}
+		}
+	}
+	return nil
+}
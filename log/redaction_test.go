@@ -0,0 +1,47 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type replaceRedactor string
+
+func (r replaceRedactor) Redact(s string) string {
+	return strings.ReplaceAll(s, string(r), "***")
+}
+
+func TestRedactionHook(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	redaction := NewRedactionHook()
+	logger.AddHook(redaction)
+	hook := test.NewLocal(logger)
+
+	logger.Info("the token is abc")
+	assert.Equal(t, "the token is abc", hook.LastEntry().Message)
+
+	redaction.Add(replaceRedactor("abc"))
+	redaction.Add(replaceRedactor("xyz"))
+	logger.WithFields(logrus.Fields{
+		"url":   "http://example.com/?token=xyz",
+		"error": errors.New("invalid token abc"),
+		"other": errors.New("unrelated"),
+		"count": 1,
+	}).Warn("the tokens are abc and xyz")
+
+	entry := hook.LastEntry()
+	assert.Equal(t, "the tokens are *** and ***", entry.Message)
+	assert.Equal(t, "http://example.com/?token=***", entry.Data["url"])
+	assert.Equal(t, "invalid token ***", entry.Data["error"])
+	assert.Equal(t, errors.New("unrelated"), entry.Data["other"])
+	assert.Equal(t, 1, entry.Data["count"])
+}
@@ -8,9 +8,12 @@ const (
 	IterationsName        = "iterations"
 	IterationDurationName = "iteration_duration"
 	DroppedIterationsName = "dropped_iterations"
+	IterationTimeoutsName = "iteration_timeouts"
+	AdaptiveCapacityName  = "adaptive_capacity"
 
 	ChecksName        = "checks"
 	GroupDurationName = "group_duration"
+	GroupReqsName     = "group_reqs"
 
 	HTTPReqsName              = "http_reqs"
 	HTTPReqFailedName         = "http_req_failed"
@@ -21,6 +24,11 @@ const (
 	HTTPReqSendingName        = "http_req_sending"
 	HTTPReqWaitingName        = "http_req_waiting"
 	HTTPReqReceivingName      = "http_req_receiving"
+	HTTPReqPoolWaitingName    = "http_req_pool_waiting"
+	HTTPReqConnReusedName     = "http_req_conn_reused"
+	HTTPConnsOpenedName       = "http_conns_opened"
+	HTTPCacheHitsName         = "http_cache_hits"
+	HTTPReqTLSResumedName     = "http_req_tls_resumed"
 
 	WSSessionsName         = "ws_sessions"
 	WSMessagesSentName     = "ws_msgs_sent"
@@ -33,6 +41,14 @@ const (
 
 	DataSentName     = "data_sent"
 	DataReceivedName = "data_received"
+
+	EventLoopLagName        = "k6_event_loop_lag"
+	GCPauseName             = "k6_gc_pause"
+	SamplesBacklogName      = "k6_samples_backlog"
+	OutputFlushDurationName = "k6_output_flush_duration"
+	MemoryUsageName         = "k6_memory_usage"
+
+	DroppedSamplesName = "k6_dropped_samples"
 )
 
 // BuiltinMetrics represent all the builtin metrics of k6
@@ -42,10 +58,13 @@ type BuiltinMetrics struct {
 	Iterations        *Metric
 	IterationDuration *Metric
 	DroppedIterations *Metric
+	IterationTimeouts *Metric
+	AdaptiveCapacity  *Metric
 
 	// Runner-emitted.
 	Checks        *Metric
 	GroupDuration *Metric
+	GroupReqs     *Metric
 
 	// HTTP-related.
 	HTTPReqs              *Metric
@@ -57,6 +76,11 @@ type BuiltinMetrics struct {
 	HTTPReqSending        *Metric
 	HTTPReqWaiting        *Metric
 	HTTPReqReceiving      *Metric
+	HTTPReqPoolWaiting    *Metric
+	HTTPReqConnReused     *Metric
+	HTTPConnsOpened       *Metric
+	HTTPCacheHits         *Metric
+	HTTPReqTLSResumed     *Metric
 
 	// Websocket-related
 	WSSessions         *Metric
@@ -72,6 +96,18 @@ type BuiltinMetrics struct {
 	// Network-related; used for future protocols as well.
 	DataSent     *Metric
 	DataReceived *Metric
+
+	// Self-telemetry, about the health of k6 itself; emitted only with the
+	// selfMetrics option.
+	EventLoopLag        *Metric
+	GCPause             *Metric
+	SamplesBacklog      *Metric
+	OutputFlushDuration *Metric
+	MemoryUsage         *Metric
+
+	// The samples that weren't sent to an output that can drop them, since it
+	// couldn't keep up with them; emitted only when that happens.
+	DroppedSamples *Metric
 }
 
 // RegisterBuiltinMetrics register and returns the builtin metrics in the provided registry
@@ -82,9 +118,12 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 		Iterations:        registry.MustNewMetric(IterationsName, Counter),
 		IterationDuration: registry.MustNewMetric(IterationDurationName, Trend, Time),
 		DroppedIterations: registry.MustNewMetric(DroppedIterationsName, Counter),
+		IterationTimeouts: registry.MustNewMetric(IterationTimeoutsName, Counter),
+		AdaptiveCapacity:  registry.MustNewMetric(AdaptiveCapacityName, Gauge),
 
 		Checks:        registry.MustNewMetric(ChecksName, Rate),
 		GroupDuration: registry.MustNewMetric(GroupDurationName, Trend, Time),
+		GroupReqs:     registry.MustNewMetric(GroupReqsName, Counter),
 
 		HTTPReqs:              registry.MustNewMetric(HTTPReqsName, Counter),
 		HTTPReqFailed:         registry.MustNewMetric(HTTPReqFailedName, Rate),
@@ -95,6 +134,11 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 		HTTPReqSending:        registry.MustNewMetric(HTTPReqSendingName, Trend, Time),
 		HTTPReqWaiting:        registry.MustNewMetric(HTTPReqWaitingName, Trend, Time),
 		HTTPReqReceiving:      registry.MustNewMetric(HTTPReqReceivingName, Trend, Time),
+		HTTPReqPoolWaiting:    registry.MustNewMetric(HTTPReqPoolWaitingName, Trend, Time),
+		HTTPReqConnReused:     registry.MustNewMetric(HTTPReqConnReusedName, Rate),
+		HTTPConnsOpened:       registry.MustNewMetric(HTTPConnsOpenedName, Counter),
+		HTTPCacheHits:         registry.MustNewMetric(HTTPCacheHitsName, Rate),
+		HTTPReqTLSResumed:     registry.MustNewMetric(HTTPReqTLSResumedName, Rate),
 
 		WSSessions:         registry.MustNewMetric(WSSessionsName, Counter),
 		WSMessagesSent:     registry.MustNewMetric(WSMessagesSentName, Counter),
@@ -107,5 +151,13 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 
 		DataSent:     registry.MustNewMetric(DataSentName, Counter, Data),
 		DataReceived: registry.MustNewMetric(DataReceivedName, Counter, Data),
+
+		EventLoopLag:        registry.MustNewMetric(EventLoopLagName, Trend, Time),
+		GCPause:             registry.MustNewMetric(GCPauseName, Trend, Time),
+		SamplesBacklog:      registry.MustNewMetric(SamplesBacklogName, Gauge),
+		OutputFlushDuration: registry.MustNewMetric(OutputFlushDurationName, Trend, Time),
+		MemoryUsage:         registry.MustNewMetric(MemoryUsageName, Gauge, Data),
+
+		DroppedSamples: registry.MustNewMetric(DroppedSamplesName, Counter),
 	}
 }
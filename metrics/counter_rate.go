@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// counterRates keeps the values of a Counter per second, in a ring as long as
+// the longest window, for the rates of the counter over the latest windows.
+type counterRates struct {
+	windows []time.Duration
+	buckets []float64
+
+	// first and latest are the seconds of the first and of the latest samples
+	first, latest int64
+	started       bool
+}
+
+func newCounterRates(windows []time.Duration) *counterRates {
+	longest := windows[len(windows)-1]
+	return &counterRates{
+		windows: windows,
+		buckets: make([]float64, int64(longest/time.Second)),
+	}
+}
+
+func (cr *counterRates) bucket(sec int64) *float64 {
+	n := int64(len(cr.buckets))
+	return &cr.buckets[((sec%n)+n)%n]
+}
+
+func (cr *counterRates) add(t time.Time, value float64) {
+	sec := t.Unix()
+	if !cr.started {
+		cr.first, cr.latest, cr.started = sec, sec, true
+	}
+	n := int64(len(cr.buckets))
+	if sec > cr.latest {
+		// the buckets of the seconds without samples are reset
+		for s := cr.latest + 1; s <= sec && s-cr.latest <= n; s++ {
+			*cr.bucket(s) = 0
+		}
+		cr.latest = sec
+	}
+	if sec <= cr.latest-n {
+		return // before the longest window
+	}
+	if sec < cr.first {
+		cr.first = sec
+	}
+	*cr.bucket(sec) += value
+}
+
+// rate returns the rate per second of the counter over the window up to the
+// latest sample, or over the time since the first one if it's shorter.
+func (cr *counterRates) rate(window time.Duration) float64 {
+	if !cr.started {
+		return 0
+	}
+	secs := int64(window / time.Second)
+	if elapsed := cr.latest - cr.first + 1; elapsed < secs {
+		secs = elapsed
+	}
+	var total float64
+	for s := cr.latest - secs + 1; s <= cr.latest; s++ {
+		total += *cr.bucket(s)
+	}
+	return total / float64(secs)
+}
+
+// format adds the rates over the windows to the values of the counter.
+func (cr *counterRates) format(values map[string]float64) {
+	for _, w := range cr.windows {
+		values[CounterRateStat(w)] = cr.rate(w)
+	}
+}
+
+// CounterRateStat returns the name of the stat of the rate of the counters over
+// the window, like rate1m or rate30s.
+func CounterRateStat(window time.Duration) string {
+	s := window.String() // e.g. 1m0s or 1h0m0s
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return tokenRate + s
+}
+
+// ParseCounterRateWindow parses the window of a counter rate stat, like rate1m.
+// It returns false if the stat isn't a counter rate over a window.
+func ParseCounterRateWindow(stat string) (time.Duration, bool, error) {
+	if !strings.HasPrefix(stat, tokenRate) || len(stat) == len(tokenRate) {
+		return 0, false, nil
+	}
+	window, err := time.ParseDuration(stat[len(tokenRate):])
+	if err != nil {
+		return 0, true, fmt.Errorf("malformed window of the rate %q; reason: %w", stat, err)
+	}
+	if err := ValidateCounterRateWindow(window); err != nil {
+		return 0, true, err
+	}
+	return window, true, nil
+}
+
+// ValidateCounterRateWindow checks that the window of the rate of the counters
+// is positive and made of whole seconds.
+func ValidateCounterRateWindow(window time.Duration) error {
+	if window < time.Second || window%time.Second != 0 {
+		return fmt.Errorf("the windows of the counter rates must be whole seconds, not %s", window)
+	}
+	return nil
+}
+
+// normalizeCounterRateWindows returns the windows sorted and without duplicates.
+func normalizeCounterRateWindows(windows []time.Duration) []time.Duration {
+	if len(windows) == 0 {
+		return nil
+	}
+	sorted := append([]time.Duration{}, windows...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	result := sorted[:1]
+	for _, w := range sorted[1:] {
+		if w != result[len(result)-1] {
+			result = append(result, w)
+		}
+	}
+	return result
+}
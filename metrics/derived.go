@@ -0,0 +1,324 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDerivedExpressionParsing indicates that a derived metric expression is
+// malformed.
+var ErrDerivedExpressionParsing = errors.New("parsing derived metric expression failed")
+
+// DerivedReference is a reference to an aggregated value of a metric, or of a
+// sub-metric, in the expression of a derived metric, e.g.
+// http_req_failed{scenario:api}.rate.
+type DerivedReference struct {
+	// Metric is the name of the metric, with the tags of the sub-metric if any.
+	Metric string
+	// Stat is the aggregation method, the same ones of the thresholds.
+	Stat string
+}
+
+func (r DerivedReference) String() string {
+	return r.Metric + "." + r.Stat
+}
+
+// Validate checks that the aggregation method is supported by the type of the
+// metric.
+func (r DerivedReference) Validate(t MetricType) error {
+	method, _, err := parseThresholdAggregationMethod(r.Stat)
+	if err != nil {
+		return err
+	}
+	if !t.supportsAggregationMethod(method) {
+		return fmt.Errorf("unsupported aggregation method %s on '%s' of type %s, "+
+			"supported aggregation methods for this metric are: %s",
+			method, r.Metric, t, strings.Join(t.supportedAggregationMethods(), ", "))
+	}
+	return nil
+}
+
+// DerivedExpression is the arithmetic expression of a derived metric, it can
+// contain numbers, references to the aggregated values of other metrics, the
+// +, -, * and / operators and parentheses, e.g.
+// http_req_failed.rate / http_reqs.rate.
+type DerivedExpression struct {
+	Source string
+
+	root       derivedNode
+	references []DerivedReference
+}
+
+// ParseDerivedExpression parses the expression of a derived metric.
+func ParseDerivedExpression(source string) (*DerivedExpression, error) {
+	p := &derivedParser{input: source}
+	root, err := p.parseSum()
+	if err == nil && p.skipSpaces() < len(p.input) {
+		err = p.errorf("unexpected '%c'", p.input[p.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrDerivedExpressionParsing, source, err)
+	}
+	return &DerivedExpression{Source: source, root: root, references: p.references}, nil
+}
+
+// References returns the references of the expression, in the order of their
+// first appearance.
+func (e *DerivedExpression) References() []DerivedReference {
+	return e.references
+}
+
+// Evaluate returns the value of the expression, with the sinks of the
+// referenced metrics and the duration of the test run for the rates of the
+// counters. It returns false if the value can't be calculated yet, i.e. if
+// any of the sinks is empty, or if it isn't a finite number.
+func (e *DerivedExpression) Evaluate(sinks func(DerivedReference) Sink, t time.Duration) (float64, bool) {
+	value, ok := e.root.evaluate(sinks, t)
+	if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+type derivedNode interface {
+	evaluate(sinks func(DerivedReference) Sink, t time.Duration) (float64, bool)
+}
+
+type derivedNumber float64
+
+func (n derivedNumber) evaluate(func(DerivedReference) Sink, time.Duration) (float64, bool) {
+	return float64(n), true
+}
+
+type derivedOperation struct {
+	operator    byte
+	left, right derivedNode
+}
+
+func (o derivedOperation) evaluate(sinks func(DerivedReference) Sink, t time.Duration) (float64, bool) {
+	left, ok := o.left.evaluate(sinks, t)
+	if !ok {
+		return 0, false
+	}
+	right, ok := o.right.evaluate(sinks, t)
+	if !ok {
+		return 0, false
+	}
+	switch o.operator {
+	case '+':
+		return left + right, true
+	case '-':
+		return left - right, true
+	case '*':
+		return left * right, true
+	default:
+		return left / right, true
+	}
+}
+
+type derivedValue struct {
+	reference  DerivedReference
+	method     string
+	percentile float64
+}
+
+func (v derivedValue) evaluate(sinks func(DerivedReference) Sink, t time.Duration) (float64, bool) {
+	sink := sinks(v.reference)
+	if sink == nil || sink.IsEmpty() {
+		return 0, false
+	}
+	switch sink := sink.(type) {
+	case *CounterSink:
+		if v.method == tokenRate {
+			return sink.Value / t.Seconds(), t > 0
+		}
+		return sink.Value, true
+	case *GaugeSink:
+		return sink.Value, true
+	case *RateSink:
+		return float64(sink.Trues) / float64(sink.Total), true
+	case *TrendSink:
+		switch v.method {
+		case tokenAvg:
+			return sink.Avg(), true
+		case tokenMin:
+			return sink.Min(), true
+		case tokenMax:
+			return sink.Max(), true
+		case tokenMed:
+			return sink.P(0.5), true
+		default:
+			return sink.P(v.percentile / 100), true
+		}
+	}
+	return 0, false
+}
+
+type derivedParser struct {
+	input      string
+	pos        int
+	references []DerivedReference
+}
+
+func (p *derivedParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at position %d: "+format, append([]interface{}{p.pos}, args...)...)
+}
+
+// skipSpaces moves to the next character that isn't a space and returns its
+// position.
+func (p *derivedParser) skipSpaces() int {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+	return p.pos
+}
+
+// next returns the next character that isn't a space, or 0 at the end.
+func (p *derivedParser) next() byte {
+	if p.skipSpaces() == len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *derivedParser) parseSum() (derivedNode, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.next(); op == '+' || op == '-'; op = p.next() {
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = derivedOperation{operator: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *derivedParser) parseProduct() (derivedNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.next(); op == '*' || op == '/'; op = p.next() {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = derivedOperation{operator: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *derivedParser) parseFactor() (derivedNode, error) {
+	c := p.next()
+	switch {
+	case c == 0:
+		return nil, p.errorf("unexpected end of the expression")
+	case c == '-':
+		p.pos++
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return derivedOperation{operator: '-', left: derivedNumber(0), right: operand}, nil
+	case c == '(':
+		p.pos++
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ')' {
+			return nil, p.errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return p.parseReference()
+	default:
+		return nil, p.errorf("unexpected '%c'", c)
+	}
+}
+
+func (p *derivedParser) parseNumber() (derivedNode, error) {
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] == '.' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+		p.pos++
+	}
+	number := p.input[start:p.pos]
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number '%s'", number)
+	}
+	return derivedNumber(value), nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// parseReference parses a reference like metric{tag:value}.stat.
+func (p *derivedParser) parseReference() (derivedNode, error) {
+	start := p.pos
+	for p.pos < len(p.input) && isNameChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == '{' {
+		end := strings.IndexByte(p.input[p.pos:], '}')
+		if end < 0 {
+			return nil, p.errorf("missing closing curly brace")
+		}
+		p.pos += end + 1
+	}
+	metric := p.input[start:p.pos]
+	if _, _, err := ParseMetricName(metric); err != nil {
+		return nil, err
+	}
+
+	if p.pos >= len(p.input) || p.input[p.pos] != '.' {
+		return nil, p.errorf("missing the aggregation method of '%s', e.g. %s.rate", metric, metric)
+	}
+	p.pos++
+	statStart := p.pos
+	for p.pos < len(p.input) && isNameChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		end := strings.IndexByte(p.input[p.pos:], ')')
+		if end < 0 {
+			return nil, p.errorf("missing closing parenthesis")
+		}
+		p.pos += end + 1
+	}
+	stat := p.input[statStart:p.pos]
+	method, percentile, err := parseThresholdAggregationMethod(stat)
+	if err != nil {
+		p.pos = statStart
+		return nil, p.errorf("invalid aggregation method '%s'", stat)
+	}
+
+	reference := DerivedReference{Metric: metric, Stat: stat}
+	if !p.hasReference(reference) {
+		p.references = append(p.references, reference)
+	}
+	return derivedValue{reference: reference, method: method, percentile: percentile.Float64}, nil
+}
+
+func (p *derivedParser) hasReference(reference DerivedReference) bool {
+	for _, r := range p.references {
+		if r == reference {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDerivedExpression(t *testing.T) {
+	t.Parallel()
+
+	counter := &CounterSink{Value: 20, First: time.Unix(1, 0)}
+	rate := &RateSink{Trues: 1, Total: 4}
+	trend := NewTrendSink()
+	for _, v := range []float64{10, 20, 30} {
+		trend.Add(Sample{Value: v})
+	}
+	sinks := map[DerivedReference]Sink{
+		{Metric: "http_reqs", Stat: "count"}:                    counter,
+		{Metric: "http_reqs", Stat: "rate"}:                     counter,
+		{Metric: "http_req_failed{scenario:api}", Stat: "rate"}: rate,
+		{Metric: "http_req_duration", Stat: "max"}:              trend,
+		{Metric: "http_req_duration", Stat: "p(50)"}:            trend,
+	}
+	sink := func(r DerivedReference) Sink { return sinks[r] }
+
+	cases := map[string]float64{
+		"1 + 2 * 3":       7,
+		"(1 + 2) * 3":     9,
+		"-2 - -3":         1,
+		"10 / 4 / 5":      0.5,
+		"http_reqs.count": 20,
+		"http_reqs.rate":  2,
+		"http_req_failed{scenario:api}.rate * 100":        25,
+		"http_req_duration.max - http_req_duration.p(50)": 10,
+		" 1 - http_req_failed{scenario:api}.rate ":        0.75,
+	}
+	for source, expected := range cases {
+		e, err := ParseDerivedExpression(source)
+		require.NoError(t, err, source)
+		value, ok := e.Evaluate(sink, 10*time.Second)
+		require.True(t, ok, source)
+		assert.InDelta(t, expected, value, 0.000001, source)
+	}
+
+	e, err := ParseDerivedExpression("http_reqs.count / http_reqs.rate + http_reqs.count")
+	require.NoError(t, err)
+	assert.Equal(t, []DerivedReference{{Metric: "http_reqs", Stat: "count"}, {Metric: "http_reqs", Stat: "rate"}},
+		e.References())
+	_, ok := e.Evaluate(sink, 0)
+	assert.False(t, ok, "no rate without the test run duration")
+	e, err = ParseDerivedExpression("http_reqs.count / 0")
+	require.NoError(t, err)
+	_, ok = e.Evaluate(sink, time.Second)
+	assert.False(t, ok, "division by zero")
+	_, ok = e.Evaluate(func(DerivedReference) Sink { return &CounterSink{} }, time.Second)
+	assert.False(t, ok, "empty sinks")
+}
+
+func TestParseDerivedExpressionErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"":                    "at position 0: unexpected end of the expression",
+		"1 +":                 "at position 3: unexpected end of the expression",
+		"(1 + 2":              "at position 6: missing closing parenthesis",
+		"1 2":                 "at position 2: unexpected '2'",
+		"1.2.3":               "at position 0: invalid number '1.2.3'",
+		"http_reqs":           "at position 9: missing the aggregation method of 'http_reqs', e.g. http_reqs.rate",
+		"http_reqs.total":     "at position 10: invalid aggregation method 'total'",
+		"http_reqs{a:b.count": "at position 9: missing closing curly brace",
+		"1 % 2":               "at position 2: unexpected '%'",
+	}
+	for source, expected := range cases {
+		_, err := ParseDerivedExpression(source)
+		require.ErrorIs(t, err, ErrDerivedExpressionParsing, source)
+		assert.ErrorContains(t, err, expected, source)
+	}
+}
+
+func TestDerivedReferenceValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, DerivedReference{Metric: "m", Stat: "p(99)"}.Validate(Trend))
+	assert.NoError(t, DerivedReference{Metric: "m", Stat: "rate"}.Validate(Counter))
+	assert.EqualError(t, DerivedReference{Metric: "m", Stat: "avg"}.Validate(Rate),
+		"unsupported aggregation method avg on 'm' of type rate, supported aggregation methods for this metric are: rate")
+}
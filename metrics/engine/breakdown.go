@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.k6.io/k6/metrics"
+)
+
+// breakdownValuesLimit is the maximum number of values of each tag the metrics
+// are broken down by, to keep the summary readable and its memory usage low.
+const breakdownValuesLimit = 100
+
+// summaryBreakdown creates the sub-metrics of the values of the tags the
+// end-of-test summary is broken down by, as they are seen in the samples.
+type summaryBreakdown struct {
+	tags   []string
+	logger logrus.FieldLogger
+
+	// the values seen for every metric and tag
+	values      map[*metrics.Metric]map[string]map[string]struct{}
+	overLimit   map[string]bool
+	invalidTags map[string]bool
+}
+
+func newSummaryBreakdown(tags []string, logger logrus.FieldLogger) *summaryBreakdown {
+	return &summaryBreakdown{
+		tags:        tags,
+		logger:      logger,
+		values:      make(map[*metrics.Metric]map[string]map[string]struct{}),
+		overLimit:   make(map[string]bool),
+		invalidTags: make(map[string]bool),
+	}
+}
+
+// withTag returns the tags with the tag, if it isn't already there.
+func withTag(tags []string, tag string) []string {
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(append(make([]string, 0, len(tags)+1), tags...), tag)
+}
+
+// add creates the sub-metrics of the metric of the sample, for the values of
+// its tags that weren't seen before.
+func (sb *summaryBreakdown) add(sample metrics.Sample) {
+	m := sample.Metric
+	if m.Sub != nil || sample.Tags == nil {
+		return
+	}
+	metricValues, ok := sb.values[m]
+	if !ok {
+		metricValues = make(map[string]map[string]struct{}, len(sb.tags))
+		sb.values[m] = metricValues
+	}
+
+	for _, tag := range sb.tags {
+		value, ok := sample.Tags.Get(tag)
+		if !ok || value == "" {
+			continue
+		}
+		values, ok := metricValues[tag]
+		if !ok {
+			values = make(map[string]struct{})
+			metricValues[tag] = values
+		}
+		if _, ok = values[value]; ok {
+			continue
+		}
+		if len(values) >= breakdownValuesLimit {
+			if !sb.overLimit[tag] {
+				sb.overLimit[tag] = true
+				sb.logger.Warnf("The '%s' tag has more than %d values, the end-of-test summary "+
+					"isn't broken down by the next ones", tag, breakdownValuesLimit)
+			}
+			continue
+		}
+		values[value] = struct{}{}
+
+		// the values that can't be in a sub-metric definition are skipped
+		if strings.Contains(value, ",") || strings.Trim(strings.TrimSpace(value), `"'`) != value {
+			if !sb.invalidTags[tag] {
+				sb.invalidTags[tag] = true
+				sb.logger.Debugf("The end-of-test summary isn't broken down by some values of the '%s' tag, "+
+					"like '%s'", tag, value)
+			}
+			continue
+		}
+		if _, err := m.AddSubmetric(tag + ":" + value); err != nil {
+			sb.logger.WithError(err).Debugf("Couldn't break down the '%s' metric", m.Name)
+		}
+	}
+}
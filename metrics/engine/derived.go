@@ -0,0 +1,150 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// derivedMetric is a gauge metric whose values are calculated from the
+// aggregated values of other metrics.
+type derivedMetric struct {
+	metric     *metrics.Metric
+	expression *metrics.DerivedExpression
+	references map[metrics.DerivedReference]*metrics.Metric
+}
+
+func (dm *derivedMetric) sink(reference metrics.DerivedReference) metrics.Sink {
+	return dm.references[reference].Sink
+}
+
+// initDerivedMetrics creates the gauge metrics of the derived metrics, if they
+// weren't already registered, and the sub-metrics they reference.
+func (me *MetricsEngine) initDerivedMetrics(definitions map[string]string) error {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	// the derived metrics can reference the previous ones
+	sort.Strings(names)
+
+	for _, name := range names {
+		expression, err := metrics.ParseDerivedExpression(definitions[name])
+		if err != nil {
+			return fmt.Errorf("invalid derived metric '%s': %w", name, err)
+		}
+		metric, err := me.registry.NewMetric(name, metrics.Gauge)
+		if err != nil {
+			return fmt.Errorf("invalid derived metric '%s': %w", name, err)
+		}
+
+		dm := &derivedMetric{
+			metric:     metric,
+			expression: expression,
+			references: make(map[metrics.DerivedReference]*metrics.Metric),
+		}
+		for _, reference := range expression.References() {
+			referenced, err := me.getThresholdMetricOrSubmetric(reference.Metric)
+			if err == nil {
+				err = reference.Validate(referenced.Type)
+			}
+			if err != nil {
+				return fmt.Errorf("invalid derived metric '%s': %w", name, err)
+			}
+			dm.references[reference] = referenced
+		}
+		me.derivedMetrics = append(me.derivedMetrics, dm)
+	}
+	return nil
+}
+
+// updateDerivedMetrics adds the current values of the derived metrics to
+// their sinks, the ones that can't be calculated yet are skipped, after the
+// burn rates of the SLOs, which they can reference. It returns the samples of
+// the values, and it has to be called with the MetricsLock.
+func (me *MetricsEngine) updateDerivedMetrics(t time.Duration) metrics.Samples {
+	now := time.Now()
+	me.updateSLOBurnRates(now)
+	var samples metrics.Samples
+	for _, dm := range me.derivedMetrics {
+		value, ok := dm.expression.Evaluate(dm.sink, t)
+		if !ok {
+			continue
+		}
+		sample := metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: dm.metric, Tags: me.registry.RootTagSet()},
+			Time:       now,
+			Value:      value,
+		}
+		me.markObserved(dm.metric)
+		me.addSample(dm.metric, sample)
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// UpdateDerivedMetrics calculates the current values of the derived metrics
+// and of the burn rates of the SLOs, e.g. for the end-of-test summary. They
+// are also calculated with the thresholds.
+func (me *MetricsEngine) UpdateDerivedMetrics(t time.Duration) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	me.updateDerivedMetrics(t)
+}
+
+// StartDerivedMetricsEmission spins up a new goroutine that periodically sends
+// the values of the derived metrics to the samples channel, for the outputs,
+// with the tags. It returns a callback that stops the goroutine and sends the
+// last values, after the ingester has added all of the samples sent until
+// then to it and to the ones of the VUs, which has to be called before the
+// channels are closed.
+func (me *MetricsEngine) StartDerivedMetricsEmission(
+	ingester *OutputIngester,
+	samples chan<- metrics.SampleContainer,
+	vuSamples []chan<- metrics.SampleContainer,
+	tags *metrics.TagSet,
+	getCurrentTestRunDuration func() time.Duration,
+) (stop func()) {
+	if ingester == nil || len(me.derivedMetrics) == 0 {
+		return func() {}
+	}
+
+	emit := func() {
+		me.MetricsLock.Lock()
+		values := me.updateDerivedMetrics(getCurrentTestRunDuration())
+		me.MetricsLock.Unlock()
+		if len(values) == 0 {
+			return
+		}
+		for i := range values {
+			values[i].Tags = tags
+		}
+		samples <- values
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(thresholdsRate)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				emit()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+
+		ingester.Sync(samples, vuSamples)
+		emit()
+	}
+}
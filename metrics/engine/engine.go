@@ -31,6 +31,22 @@ type MetricsEngine struct {
 	metricsWithThresholds   []*metrics.Metric
 	breachedThresholdsCount uint32
 
+	derivedMetrics []*derivedMetric
+
+	// the SLOs by the metric or sub-metric they are about
+	slos map[*metrics.Metric][]*serviceLevelObjective
+
+	// the time of the first sample, the start of the warm-up of the thresholds
+	firstSampleTime time.Time
+
+	summaryBreakdownTags []string
+
+	// nil if the time series of the metrics aren't recorded
+	timeSeries *timeSeriesRecorder
+
+	// the sliding windows of the samples of the watched metrics
+	windows map[*metrics.Metric][]*metrics.SampleWindow
+
 	// TODO: completely refactor:
 	//   - make these private, add a method to export the raw data
 	//   - do not use an unnecessary map for the observed metrics
@@ -54,11 +70,16 @@ func NewMetricsEngine(registry *metrics.Registry, logger logrus.FieldLogger) (*M
 // CreateIngester returns a pseudo-Output that uses the given metric samples to
 // update the engine's inner state.
 func (me *MetricsEngine) CreateIngester() *OutputIngester {
-	return &OutputIngester{
-		logger:        me.logger.WithField("component", "metrics-engine-ingester"),
+	logger := me.logger.WithField("component", "metrics-engine-ingester")
+	oi := &OutputIngester{
+		logger:        logger,
 		metricsEngine: me,
 		cardinality:   newCardinalityControl(),
 	}
+	if len(me.summaryBreakdownTags) > 0 {
+		oi.breakdown = newSummaryBreakdown(me.summaryBreakdownTags, logger)
+	}
+	return oi
 }
 
 func (me *MetricsEngine) getThresholdMetricOrSubmetric(name string) (*metrics.Metric, error) {
@@ -114,10 +135,111 @@ func (me *MetricsEngine) markObserved(metric *metrics.Metric) {
 	}
 }
 
+// addSample adds the sample to the sink of the metric, which can be a
+// sub-metric, to its thresholds with a warm-up or a window, to its time
+// series, if they are recorded, and to its windows, if it's watched. It has to
+// be called with the MetricsLock.
+func (me *MetricsEngine) addSample(metric *metrics.Metric, sample metrics.Sample) {
+	if me.firstSampleTime.IsZero() || sample.Time.Before(me.firstSampleTime) {
+		me.firstSampleTime = sample.Time
+	}
+	metric.Sink.Add(sample)
+	if metric.Thresholds.HasWindows() {
+		metric.Thresholds.AddSample(sample, me.firstSampleTime)
+	}
+	if me.timeSeries != nil {
+		me.timeSeries.add(metric, sample)
+	}
+	for _, slo := range me.slos[metric] {
+		me.addSLOSample(slo, sample)
+	}
+	for _, w := range me.windows[metric] {
+		w.Add(sample)
+	}
+}
+
+// TimeSeriesResolution returns the period of the aggregated time series of the
+// metrics, or 0 if they aren't recorded.
+func (me *MetricsEngine) TimeSeriesResolution() time.Duration {
+	if me.timeSeries == nil {
+		return 0
+	}
+	return me.timeSeries.resolution
+}
+
+// TimeSeries returns the aggregated periods of the time series of the metric,
+// in order, or nil if they aren't recorded. They have to be read with the
+// MetricsLock.
+func (me *MetricsEngine) TimeSeries(metric *metrics.Metric) []metrics.AggregatedPeriod {
+	if me.timeSeries == nil {
+		return nil
+	}
+	return me.timeSeries.periods[metric]
+}
+
+// ObservedTimeSeries returns the time series of the observed metrics, by
+// name, or nil if they aren't recorded. They have to be read with the
+// MetricsLock.
+func (me *MetricsEngine) ObservedTimeSeries() map[string][]metrics.AggregatedPeriod {
+	if me.timeSeries == nil {
+		return nil
+	}
+	result := make(map[string][]metrics.AggregatedPeriod, len(me.ObservedMetrics))
+	for name, m := range me.ObservedMetrics {
+		if periods := me.timeSeries.periods[m]; len(periods) > 0 {
+			result[name] = periods
+		}
+	}
+	return result
+}
+
 // InitSubMetricsAndThresholds parses the thresholds from the test Options and
 // initializes both the thresholds themselves, as well as any submetrics that
-// were referenced in them.
+// were referenced in them. It also sets the precision of the Trend sinks, the
+// windows of the rates of the Counter sinks and the tags the summary is broken
+// down by, and it initializes the metrics of
+// the SLOs and the derived metrics, which the thresholds can reference, and
+// the recording of the time series of the metrics.
 func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLogErrors bool) error {
+	me.summaryBreakdownTags = options.SummaryBreakdownTags
+	if options.ScenarioBreakdown.Valid && options.ScenarioBreakdown.String != lib.ScenarioBreakdownNone {
+		me.summaryBreakdownTags = withTag(me.summaryBreakdownTags, "scenario")
+	}
+
+	// The sinks of the Trend metrics have to be set up before the sub-metrics
+	// are created and any sample is added.
+	trendPrecision := metrics.DefaultTrendPrecision
+	if options.TrendPrecision.Valid {
+		trendPrecision = options.TrendPrecision.Float64
+		me.registry.SetTrendPrecision(trendPrecision)
+	}
+
+	// The same for the windows of the rates of the Counter metrics, for the
+	// summary and for the thresholds on them.
+	var rateWindows []time.Duration
+	for _, w := range options.CounterRateWindows {
+		rateWindows = append(rateWindows, time.Duration(w))
+	}
+	for _, thresholds := range options.Thresholds {
+		thresholds := thresholds
+		rateWindows = append(rateWindows, thresholds.CounterRateWindows()...)
+	}
+	if len(rateWindows) > 0 {
+		me.registry.SetCounterRateWindows(rateWindows)
+	}
+
+	if options.TimeSeriesResolution.Valid && options.TimeSeriesResolution.Duration > 0 {
+		me.timeSeries = newTimeSeriesRecorder(time.Duration(options.TimeSeriesResolution.Duration), trendPrecision)
+	}
+
+	if err := me.initSLOs(options.SLOs); err != nil {
+		return err
+	}
+
+	if err := me.initDerivedMetrics(options.DerivedMetrics); err != nil {
+		return err
+	}
+
 	for metricName, thresholds := range options.Thresholds {
 		metric, err := me.getThresholdMetricOrSubmetric(metricName)
 
@@ -142,6 +264,10 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 		if metric.Sub != nil {
 			me.markObserved(metric.Sub.Parent)
 		}
+
+		if err := me.initThresholdsSubmetrics(metric); err != nil {
+			return fmt.Errorf("invalid threshold definitions on metric '%s': %w", metricName, err)
+		}
 	}
 
 	// TODO: refactor out of here when https://github.com/grafana/k6/issues/1321
@@ -156,15 +282,60 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 	return nil
 }
 
-// StartThresholdCalculations spins up a new goroutine to crunch thresholds and
+// initThresholdsSubmetrics creates the sub-metrics the thresholds of the
+// metric are evaluated on, like {group:::checkout} in the expression
+// p(95) of {group:::checkout} < 500, with the tags of the metric too, if it's
+// a sub-metric itself. The referenced tags replace the ones of the metric with
+// the same keys, so {name:y} of http_req_duration{name:x} is
+// http_req_duration{name:y}.
+func (me *MetricsEngine) initThresholdsSubmetrics(metric *metrics.Metric) error {
+	for _, tags := range metric.Thresholds.SubmetricTags() {
+		name := metric.Name + "{" + tags + "}"
+		if metric.Sub != nil {
+			name = metric.Sub.Parent.Name + "{" + mergeSubmetricTags(metric.Sub.Suffix, tags) + "}"
+		}
+		submetric, err := me.getThresholdMetricOrSubmetric(name)
+		if err != nil {
+			return err
+		}
+		metric.Thresholds.SetSubmetric(tags, submetric)
+		me.markObserved(submetric)
+	}
+	return nil
+}
+
+// mergeSubmetricTags returns the key:value pairs of the sub-metric tags, with
+// the ones of the overrides replacing those with the same keys.
+func mergeSubmetricTags(tags, overrides string) string {
+	var pairs []string
+	indexes := make(map[string]int)
+	for _, kv := range strings.Split(tags+","+overrides, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(kv, ":")
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		if i, ok := indexes[key]; ok {
+			pairs[i] = kv
+			continue
+		}
+		indexes[key] = len(pairs)
+		pairs = append(pairs, kv)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// StartThresholdCalculations spins up a new goroutine to crunch thresholds,
+// and to calculate the derived metrics and the burn rates of the SLOs, and
 // returns a callback that will stop the goroutine and finalizes calculations.
 func (me *MetricsEngine) StartThresholdCalculations(
 	ingester *OutputIngester,
 	abortRun func(error),
 	getCurrentTestRunDuration func() time.Duration,
 ) (finalize func() (breached []string)) {
-	if len(me.metricsWithThresholds) == 0 {
-		return nil // no thresholds were defined
+	if len(me.metricsWithThresholds) == 0 && len(me.derivedMetrics) == 0 && len(me.slos) == 0 {
+		return nil // no thresholds, derived metrics or SLOs were defined
 	}
 
 	stop := make(chan struct{})
@@ -212,7 +383,8 @@ func (me *MetricsEngine) StartThresholdCalculations(
 	}
 }
 
-// evaluateThresholds processes all of the thresholds.
+// evaluateThresholds processes all of the thresholds, after it calculates the
+// derived metrics.
 //
 // TODO: refactor, optimize
 func (me *MetricsEngine) evaluateThresholds(
@@ -223,6 +395,7 @@ func (me *MetricsEngine) evaluateThresholds(
 	defer me.MetricsLock.Unlock()
 
 	t := getCurrentTestRunDuration()
+	me.updateDerivedMetrics(t)
 
 	me.logger.Debugf("Running thresholds on %d metrics...", len(me.metricsWithThresholds))
 	for _, m := range me.metricsWithThresholds {
@@ -255,6 +428,98 @@ func (me *MetricsEngine) evaluateThresholds(
 	return breachedThresholds, shouldAbort
 }
 
+// ScenarioThresholdsPassed runs the thresholds on the sub-metrics of the
+// scenario, like http_req_duration{scenario:warmup}, and returns if all of
+// them passed. The ones without any samples aren't counted.
+func (me *MetricsEngine) ScenarioThresholdsPassed(scenario string, duration time.Duration) bool {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	passed := true
+	for _, m := range me.metricsWithThresholds {
+		if m.Sub == nil || m.Sink.IsEmpty() {
+			continue
+		}
+		if s, ok := m.Sub.Tags.Get("scenario"); !ok || s != scenario {
+			continue
+		}
+		succ, err := m.Thresholds.Run(m.Sink, duration)
+		if err != nil {
+			me.logger.WithField("metric_name", m.Name).WithError(err).Error("Threshold error")
+			continue
+		}
+		if !succ {
+			m.Tainted = null.BoolFrom(true)
+			passed = false
+		}
+	}
+	return passed
+}
+
+// MetricValues returns the current aggregated values of the observed metric, or
+// sub-metric, with the name, the same ones as the REST API, with the rates of
+// the counters over the duration, and false if it hasn't been observed.
+func (me *MetricsEngine) MetricValues(name string, duration time.Duration) (map[string]float64, bool) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	metric, ok := me.ObservedMetrics[name]
+	if !ok {
+		return nil, false
+	}
+	return metric.Sink.Format(duration), true
+}
+
+// WatchMetric starts keeping the samples of the metric, or sub-metric, with
+// the name in a sliding window of the length, and returns a function that
+// returns a sink with the ones in it, nil if there aren't any.
+func (me *MetricsEngine) WatchMetric(name string, length time.Duration) (func() metrics.Sink, error) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	metric, err := me.getThresholdMetricOrSubmetric(name)
+	if err != nil {
+		return nil, err
+	}
+	// the windows of the same length are shared, e.g. by all of the VUs
+	var w *metrics.SampleWindow
+	for _, existing := range me.windows[metric] {
+		if existing.Length() == length {
+			w = existing
+			break
+		}
+	}
+	if w == nil {
+		w = metrics.NewSampleWindow(length)
+		if me.windows == nil {
+			me.windows = make(map[*metrics.Metric][]*metrics.SampleWindow)
+		}
+		me.windows[metric] = append(me.windows[metric], w)
+	}
+
+	return func() metrics.Sink {
+		me.MetricsLock.Lock()
+		defer me.MetricsLock.Unlock()
+		return w.Sink()
+	}, nil
+}
+
+// GetThresholds returns a copy of the thresholds of the metrics and
+// sub-metrics, with their state after they were last evaluated. This API is
+// safe to use concurrently.
+func (me *MetricsEngine) GetThresholds() map[string][]metrics.Threshold {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	thresholds := make(map[string][]metrics.Threshold, len(me.metricsWithThresholds))
+	for _, m := range me.metricsWithThresholds {
+		for _, t := range m.Thresholds.Thresholds {
+			thresholds[m.Name] = append(thresholds[m.Name], *t)
+		}
+	}
+	return thresholds
+}
+
 // GetMetricsWithBreachedThresholdsCount returns the number of metrics for which
 // the thresholds were breached (failed) during the last processing phase. This
 // API is safe to use concurrently.
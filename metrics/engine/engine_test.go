@@ -68,6 +68,39 @@ func TestMetricsEngineGetThresholdMetricOrSubmetricError(t *testing.T) {
 	}
 }
 
+func TestMetricsEngineReferencedSubmetricsWithConflictingTags(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	metric, err := me.registry.NewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+
+	thresholds := map[string]metrics.Thresholds{
+		"http_req_duration{name:x}":            metrics.NewThresholds([]string{"p(95) < 1.5 * p(95) of {name:y}"}),
+		"http_req_duration{name:x,method:GET}": metrics.NewThresholds([]string{"p(95) < p(95) of {name:y}"}),
+		"http_req_duration{name:y}":            metrics.NewThresholds([]string{"p(95) < 500"}),
+	}
+	for name, ts := range thresholds {
+		require.NoError(t, ts.Parse())
+		thresholds[name] = ts
+	}
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{Thresholds: thresholds}, false))
+
+	names := make([]string, 0, len(metric.Submetrics))
+	for _, sm := range metric.Submetrics {
+		names = append(names, sm.Name)
+		if sm.Suffix == "name:y" {
+			assert.Equal(t, map[string]string{"name": "y"}, sm.Tags.Map())
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"http_req_duration{name:x}",
+		"http_req_duration{name:x,method:GET}",
+		"http_req_duration{name:y}",
+		"http_req_duration{name:y,method:GET}",
+	}, names)
+}
+
 func TestNewMetricsEngineNoThresholds(t *testing.T) {
 	t.Parallel()
 
@@ -153,6 +186,171 @@ func TestMetricsEngineEvaluateIgnoreEmptySink(t *testing.T) {
 	assert.Empty(t, breached)
 }
 
+func TestMetricsEngineDerivedMetrics(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	reqs, err := me.registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	failed, err := me.registry.NewMetric("http_req_failed", metrics.Rate)
+	require.NoError(t, err)
+
+	ths := metrics.NewThresholds([]string{"value<0.5"})
+	require.NoError(t, ths.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		DerivedMetrics: map[string]string{
+			"api_failures":    "http_req_failed{scenario:api}.rate * http_reqs.count",
+			"api_failures_pc": "api_failures.value / http_reqs.count * 100",
+		},
+		Thresholds: map[string]metrics.Thresholds{"api_failures_pc": ths},
+	}, false))
+	require.Len(t, me.derivedMetrics, 2)
+	require.Len(t, failed.Submetrics, 1)
+
+	// the values can't be calculated without samples
+	breached, _ := me.evaluateThresholds(true, zeroTestRunDuration)
+	assert.Empty(t, breached)
+	assert.NotContains(t, me.ObservedMetrics, "api_failures")
+
+	apiTags := me.registry.RootTagSet().With("scenario", "api")
+	for _, value := range []float64{1, 0, 0, 0} {
+		sample := metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: failed, Tags: apiTags},
+			Time:       time.Now(),
+			Value:      value,
+		}
+		failed.Sink.Add(sample)
+		failed.Submetrics[0].Metric.Sink.Add(sample)
+	}
+	reqs.Sink.Add(metrics.Sample{Time: time.Now(), Value: 4})
+
+	breached, _ = me.evaluateThresholds(true, zeroTestRunDuration)
+	assert.Equal(t, []string{"api_failures_pc"}, breached)
+	require.Contains(t, me.ObservedMetrics, "api_failures")
+	assert.Equal(t, 1.0, me.ObservedMetrics["api_failures"].Sink.(*metrics.GaugeSink).Value)     //nolint:forcetypeassert
+	assert.Equal(t, 25.0, me.ObservedMetrics["api_failures_pc"].Sink.(*metrics.GaugeSink).Value) //nolint:forcetypeassert
+}
+
+func TestMetricsEngineDerivedMetricsErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"http_reqs.value":       "unsupported aggregation method value on 'http_reqs' of type counter",
+		"missing.count":         "metric 'missing' does not exist in the script",
+		"http_reqs.count / (2":  "missing closing parenthesis",
+		"http_reqs{status}.max": `"status" tag expression is malformed`,
+	}
+	for expression, expErr := range cases {
+		expression, expErr := expression, expErr
+		t.Run(expression, func(t *testing.T) {
+			t.Parallel()
+			me := newTestMetricsEngine(t)
+			_, err := me.registry.NewMetric("http_reqs", metrics.Counter)
+			require.NoError(t, err)
+
+			err = me.InitSubMetricsAndThresholds(lib.Options{
+				DerivedMetrics: map[string]string{"derived": expression},
+			}, false)
+			assert.ErrorContains(t, err, "invalid derived metric 'derived'")
+			assert.ErrorContains(t, err, expErr)
+		})
+	}
+}
+
+func TestMetricsEngineSLOs(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	duration, err := me.registry.NewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+
+	ths := metrics.NewThresholds([]string{"rate>=0.9"})
+	require.NoError(t, ths.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		SLOs: map[string]lib.SLO{
+			"api_latency": {Metric: "http_req_duration{scenario:api}", Condition: "<300", Objective: 0.9},
+		},
+		Thresholds: map[string]metrics.Thresholds{"api_latency_compliance": ths},
+	}, false))
+	require.Len(t, duration.Submetrics, 1)
+	assert.Contains(t, me.ObservedMetrics, "api_latency_compliance")
+
+	ingester := me.CreateIngester()
+	require.NoError(t, ingester.Start())
+	for i, value := range []float64{100, 200, 400, 250, 500} {
+		scenario := "api"
+		if i == 4 {
+			scenario = "web"
+		}
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: duration,
+				Tags:   me.registry.RootTagSet().With("scenario", scenario),
+			},
+			Time:  time.Now(),
+			Value: value,
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+
+	breached, _ := me.evaluateThresholds(true, zeroTestRunDuration)
+	assert.Equal(t, []string{"api_latency_compliance"}, breached)
+	compliance := me.ObservedMetrics["api_latency_compliance"].Sink.(*metrics.RateSink) //nolint:forcetypeassert
+	assert.Equal(t, int64(4), compliance.Total)
+	assert.Equal(t, int64(3), compliance.Trues)
+	// a quarter of bad samples with an error budget of a tenth
+	require.Contains(t, me.ObservedMetrics, "api_latency_burn_rate")
+	burnRate := me.ObservedMetrics["api_latency_burn_rate"].Sink.(*metrics.GaugeSink) //nolint:forcetypeassert
+	assert.InDelta(t, 2.5, burnRate.Value, 1e-9)
+}
+
+func TestMetricsEngineSLOsErrors(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	err := me.InitSubMetricsAndThresholds(lib.Options{
+		SLOs: map[string]lib.SLO{"api_latency": {Metric: "missing", Condition: "<300", Objective: 0.9}},
+	}, false)
+	assert.EqualError(t, err, "invalid SLO 'api_latency': metric 'missing' does not exist in the script")
+}
+
+func TestMetricsEngineScenarioThresholdsPassed(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	errors, err := me.registry.NewMetric("errors", metrics.Counter)
+	require.NoError(t, err)
+
+	thresholds := func(expr string) metrics.Thresholds {
+		ths := metrics.NewThresholds([]string{expr})
+		require.NoError(t, ths.Parse())
+		return ths
+	}
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		Thresholds: map[string]metrics.Thresholds{
+			"errors":                  thresholds("count<1"),
+			"errors{scenario:warmup}": thresholds("count<2"),
+			"errors{scenario:load}":   thresholds("count<2"),
+		},
+	}, false))
+
+	tags := func(scenario string) *metrics.TagSet {
+		return me.registry.RootTagSet().With("scenario", scenario)
+	}
+	ingester := me.CreateIngester()
+	require.NoError(t, ingester.Start())
+	ingester.AddMetricSamples([]metrics.SampleContainer{
+		metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: errors, Tags: tags("warmup")}, Time: time.Now(), Value: 1},
+		metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: errors, Tags: tags("load")}, Time: time.Now(), Value: 2},
+	})
+	require.NoError(t, ingester.Stop())
+
+	// the thresholds of the parent metric, and of the other scenarios, aren't counted
+	assert.True(t, me.ScenarioThresholdsPassed("warmup", time.Second))
+	assert.False(t, me.ScenarioThresholdsPassed("load", time.Second))
+	assert.True(t, me.ScenarioThresholdsPassed("unknown", time.Second))
+}
+
 func newTestMetricsEngine(t *testing.T) *MetricsEngine {
 	m, err := NewMetricsEngine(metrics.NewRegistry(), testutils.NewLogger(t))
 	require.NoError(t, err)
@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -29,6 +30,7 @@ type OutputIngester struct {
 	metricsEngine   *MetricsEngine
 	periodicFlusher *output.PeriodicFlusher
 	cardinality     *cardinalityControl
+	breakdown       *summaryBreakdown
 }
 
 // Description returns a human-readable description of the output.
@@ -58,6 +60,28 @@ func (oi *OutputIngester) Stop() error {
 	return nil
 }
 
+// ingesterSync is sent through every samples channel to know when the ingester
+// has added all of the samples sent before it to them, it's done then.
+type ingesterSync struct {
+	pending *sync.WaitGroup
+}
+
+// GetSamples implements metrics.SampleContainer, it has no samples.
+func (ingesterSync) GetSamples() []metrics.Sample { return nil }
+
+// Sync waits until the ingester has added all of the samples sent until now to
+// the samples channel, and to the ones of the VUs, to the MetricsEngine. The
+// outputs have to be started, and the channels can't be closed yet.
+func (oi *OutputIngester) Sync(samples chan<- metrics.SampleContainer, vuSamples []chan<- metrics.SampleContainer) {
+	synced := ingesterSync{pending: &sync.WaitGroup{}}
+	synced.pending.Add(1 + len(vuSamples))
+	samples <- synced
+	for _, ch := range vuSamples {
+		ch <- synced
+	}
+	synced.pending.Wait()
+}
+
 // flushMetrics Writes samples to the MetricsEngine
 func (oi *OutputIngester) flushMetrics() {
 	sampleContainers := oi.GetBufferedSamples()
@@ -78,6 +102,10 @@ func (oi *OutputIngester) flushMetrics() {
 	// and eliminate the map loopkups altogether!
 
 	for _, sampleContainer := range sampleContainers {
+		if synced, ok := sampleContainer.(ingesterSync); ok {
+			synced.pending.Done()
+			continue
+		}
 		samples := sampleContainer.GetSamples()
 
 		if len(samples) == 0 {
@@ -85,9 +113,15 @@ func (oi *OutputIngester) flushMetrics() {
 		}
 
 		for _, sample := range samples {
-			m := sample.Metric               // this should have come from the Registry, no need to look it up
-			oi.metricsEngine.markObserved(m) // mark it as observed so it shows in the end-of-test summary
-			m.Sink.Add(sample)               // finally, add its value to its own sink
+			m := sample.Metric                    // this should have come from the Registry, no need to look it up
+			oi.metricsEngine.markObserved(m)      // mark it as observed so it shows in the end-of-test summary
+			oi.metricsEngine.addSample(m, sample) // finally, add its value to its own sink
+
+			// create the sub-metrics of the new values of the tags the
+			// summary is broken down by, if any
+			if oi.breakdown != nil {
+				oi.breakdown.add(sample)
+			}
 
 			// and also to the same for any submetrics that match the metric sample
 			for _, sm := range m.Submetrics {
@@ -95,7 +129,7 @@ func (oi *OutputIngester) flushMetrics() {
 					continue
 				}
 				oi.metricsEngine.markObserved(sm.Metric)
-				sm.Metric.Sink.Add(sample)
+				oi.metricsEngine.addSample(sm.Metric, sample)
 			}
 
 			oi.cardinality.Add(sample.TimeSeries)
@@ -3,12 +3,14 @@ package engine
 import (
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -47,6 +49,101 @@ func TestIngesterOutputFlushMetrics(t *testing.T) {
 	assert.Equal(t, 42.0, sink.Total())
 }
 
+func TestIngesterOutputSummaryBreakdown(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	testMetric, err := piState.Registry.NewMetric("test_metric", metrics.Counter)
+	require.NoError(t, err)
+
+	me := &MetricsEngine{
+		logger:          piState.Logger,
+		registry:        piState.Registry,
+		ObservedMetrics: make(map[string]*metrics.Metric),
+	}
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{SummaryBreakdownTags: []string{"scenario"}}, false))
+	ingester := me.CreateIngester()
+	require.NotNil(t, ingester.breakdown)
+
+	require.NoError(t, ingester.Start())
+	for _, scenario := range []string{"a", "b", "a", "with,comma", ""} {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: testMetric,
+				Tags:   piState.Registry.RootTagSet().With("scenario", scenario),
+			},
+			Value: 1,
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+
+	require.Len(t, me.ObservedMetrics, 3)
+	counts := make(map[string]float64)
+	for name, m := range me.ObservedMetrics {
+		counts[name] = m.Sink.(*metrics.CounterSink).Value //nolint:forcetypeassert
+	}
+	assert.Equal(t, map[string]float64{
+		"test_metric":             5,
+		"test_metric{scenario:a}": 2,
+		"test_metric{scenario:b}": 1,
+	}, counts)
+}
+
+func TestIngesterOutputTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	testMetric, err := piState.Registry.NewMetric("test_metric", metrics.Trend)
+	require.NoError(t, err)
+
+	me := &MetricsEngine{
+		logger:          piState.Logger,
+		registry:        piState.Registry,
+		ObservedMetrics: make(map[string]*metrics.Metric),
+	}
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		Thresholds: map[string]metrics.Thresholds{
+			"test_metric{a:1}": metrics.NewThresholds([]string{"max<100"}),
+		},
+		TimeSeriesResolution: types.NullDurationFrom(5 * time.Second),
+	}, false))
+	assert.Equal(t, 5*time.Second, me.TimeSeriesResolution())
+	ingester := me.CreateIngester()
+
+	start := time.Unix(100, 0)
+	require.NoError(t, ingester.Start())
+	// the late samples are added to their periods
+	for _, offset := range []time.Duration{0, 6 * time.Second, 1 * time.Second, 16 * time.Second, 7 * time.Second} {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: testMetric,
+				Tags:   piState.Registry.RootTagSet().With("a", strconv.Itoa(int(offset/time.Second)%2)),
+			},
+			Time:  start.Add(offset),
+			Value: float64(offset / time.Second),
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	series := me.ObservedTimeSeries()
+	require.Len(t, series, 2)
+
+	periodStats := func(periods []metrics.AggregatedPeriod) map[int64][]float64 {
+		result := make(map[int64][]float64, len(periods))
+		for _, p := range periods {
+			sink := p.Sink.(*metrics.TrendSink) //nolint:forcetypeassert
+			result[p.Start.Unix()] = []float64{float64(sink.Count()), sink.Max()}
+		}
+		return result
+	}
+	assert.Equal(t, map[int64][]float64{100: {2, 1}, 105: {2, 7}, 115: {1, 16}}, periodStats(series["test_metric"]))
+	assert.Equal(t, map[int64][]float64{100: {1, 1}, 105: {1, 7}}, periodStats(series["test_metric{a:1}"]))
+	assert.Equal(t, series["test_metric"], me.TimeSeries(testMetric))
+	assert.True(t, series["test_metric"][1].Start.Before(series["test_metric"][2].Start))
+}
+
 func TestIngesterOutputFlushSubmetrics(t *testing.T) {
 	t.Parallel()
 
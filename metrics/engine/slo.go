@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// serviceLevelObjective is an SLO, the samples of its metric are added to its
+// compliance rate metric, as good or bad ones.
+type serviceLevelObjective struct {
+	objective  float64
+	condition  lib.SLOCondition
+	compliance *metrics.Metric
+	burnRate   *metrics.Metric
+}
+
+// initSLOs creates the compliance and burn rate metrics of the SLOs and the
+// sub-metrics they are about.
+func (me *MetricsEngine) initSLOs(definitions map[string]lib.SLO) error {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		definition := definitions[name]
+		condition, err := lib.ParseSLOCondition(definition.Condition)
+		if err != nil {
+			return fmt.Errorf("invalid SLO '%s': %w", name, err)
+		}
+		source, err := me.getThresholdMetricOrSubmetric(definition.Metric)
+		if err != nil {
+			return fmt.Errorf("invalid SLO '%s': %w", name, err)
+		}
+		slo := &serviceLevelObjective{objective: definition.Objective, condition: condition}
+		if slo.compliance, err = me.registry.NewMetric(lib.SLOComplianceMetric(name), metrics.Rate); err != nil {
+			return fmt.Errorf("invalid SLO '%s': %w", name, err)
+		}
+		if slo.burnRate, err = me.registry.NewMetric(lib.SLOBurnRateMetric(name), metrics.Gauge); err != nil {
+			return fmt.Errorf("invalid SLO '%s': %w", name, err)
+		}
+
+		// they are shown in the end-of-test summary even without samples
+		me.markObserved(slo.compliance)
+		if me.slos == nil {
+			me.slos = make(map[*metrics.Metric][]*serviceLevelObjective)
+		}
+		me.slos[source] = append(me.slos[source], slo)
+	}
+	return nil
+}
+
+// addSLOSample adds a sample of the metric of the SLO to its compliance, 1 if
+// it's good and 0 if it isn't. It has to be called with the MetricsLock.
+func (me *MetricsEngine) addSLOSample(slo *serviceLevelObjective, sample metrics.Sample) {
+	value := 0.0
+	if slo.condition.Good(sample.Value) {
+		value = 1
+	}
+	me.addSample(slo.compliance, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: slo.compliance, Tags: sample.Tags},
+		Time:       sample.Time,
+		Value:      value,
+	})
+}
+
+// updateSLOBurnRates adds the current burn rates of the error budgets of the
+// SLOs to their gauges, i.e. the ratios of the bad samples to the ones allowed
+// by the objectives, so the budgets are exhausted at 1. It has to be called
+// with the MetricsLock.
+func (me *MetricsEngine) updateSLOBurnRates(now time.Time) {
+	for _, slos := range me.slos {
+		for _, slo := range slos {
+			sink, ok := slo.compliance.Sink.(*metrics.RateSink)
+			if !ok || sink.Total == 0 {
+				continue
+			}
+			bad := 1 - float64(sink.Trues)/float64(sink.Total)
+			me.markObserved(slo.burnRate)
+			me.addSample(slo.burnRate, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: slo.burnRate, Tags: me.registry.RootTagSet()},
+				Time:       now,
+				Value:      bad / (1 - slo.objective),
+			})
+		}
+	}
+}
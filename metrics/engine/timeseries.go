@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// timeSeriesRecorder aggregates the samples of the metrics and sub-metrics in
+// periods of the same duration, for the trends of their values over time.
+type timeSeriesRecorder struct {
+	resolution     time.Duration
+	trendPrecision float64
+	periods        map[*metrics.Metric][]metrics.AggregatedPeriod
+}
+
+func newTimeSeriesRecorder(resolution time.Duration, trendPrecision float64) *timeSeriesRecorder {
+	return &timeSeriesRecorder{
+		resolution:     resolution,
+		trendPrecision: trendPrecision,
+		periods:        make(map[*metrics.Metric][]metrics.AggregatedPeriod),
+	}
+}
+
+// add adds the sample to the period of the metric it is in, the samples are
+// mostly in order, so the periods are searched from the last one.
+func (tsr *timeSeriesRecorder) add(m *metrics.Metric, sample metrics.Sample) {
+	start := sample.Time.Truncate(tsr.resolution)
+	periods := tsr.periods[m]
+
+	i := len(periods)
+	for i > 0 && periods[i-1].Start.After(start) {
+		i--
+	}
+	if i == 0 || !periods[i-1].Start.Equal(start) {
+		periods = append(periods, metrics.AggregatedPeriod{})
+		copy(periods[i+1:], periods[i:])
+		periods[i] = metrics.AggregatedPeriod{Start: start, Sink: tsr.newSink(m.Type)}
+		tsr.periods[m] = periods
+		i++
+	}
+	periods[i-1].Sink.Add(sample)
+}
+
+func (tsr *timeSeriesRecorder) newSink(mt metrics.MetricType) metrics.Sink {
+	if mt == metrics.Trend {
+		return metrics.NewTrendSinkWithPrecision(tsr.trendPrecision)
+	}
+	return metrics.NewSink(mt)
+}
@@ -0,0 +1,26 @@
+package metrics
+
+import "strconv"
+
+// maxInternedInt is the limit of the interned numeric tag values, which covers
+// the HTTP and gRPC status codes and the k6 error codes.
+const maxInternedInt = 2000
+
+//nolint:gochecknoglobals
+var internedInts = func() []string {
+	values := make([]string, maxInternedInt)
+	for i := range values {
+		values[i] = strconv.Itoa(i)
+	}
+	return values
+}()
+
+// IntTagValue returns the decimal representation of n as a tag value. The
+// common values are interned, so emitting a sample tagged with a status code
+// doesn't allocate a new string every time.
+func IntTagValue(n int) string {
+	if n >= 0 && n < maxInternedInt {
+		return internedInts[n]
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntTagValue(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int{0, 7, 200, 1404, maxInternedInt - 1, maxInternedInt, 123456, -1} {
+		assert.Equal(t, strconv.Itoa(n), IntTagValue(n))
+	}
+}
+
+func BenchmarkIntTagValue(b *testing.B) {
+	b.Run("interned", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = IntTagValue(200 + i%400)
+		}
+	})
+	b.Run("strconv", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = strconv.Itoa(200 + i%400)
+		}
+	})
+}
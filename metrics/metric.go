@@ -15,6 +15,13 @@ type Metric struct {
 	Type     MetricType `json:"type"`
 	Contains ValueType  `json:"contains"`
 
+	// The optional description of the metric, for the outputs that support
+	// the metadata of the metrics
+	Unit        string `json:"unit,omitempty"`
+	Description string `json:"description,omitempty"`
+	// The names of the labels that the custom metric accepts, if declared
+	Labels []string `json:"labels,omitempty"`
+
 	// TODO: decouple the metrics from the sinks and thresholds... have them
 	// linked, but not in the same struct?
 	Tainted    null.Bool    `json:"tainted"`
@@ -25,6 +32,15 @@ type Metric struct {
 	Observed   bool         `json:"-"`
 }
 
+// NewSink returns a new empty sink for the samples of the metric, with the
+// precision of the Trend sinks of its registry.
+func (m *Metric) NewSink() Sink {
+	if m.registry == nil {
+		return NewSink(m.Type)
+	}
+	return m.registry.newSink(m.Type)
+}
+
 // A Submetric represents a filtered dataset based on a parent metric.
 type Submetric struct {
 	Name   string  `json:"name"`
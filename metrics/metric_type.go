@@ -90,7 +90,7 @@ func (t MetricType) String() string {
 func (t MetricType) supportedAggregationMethods() []string {
 	switch t {
 	case Counter:
-		return []string{tokenCount, tokenRate}
+		return []string{tokenCount, tokenRate, tokenRate + "<window>"}
 	case Gauge:
 		return []string{tokenValue}
 	case Rate:
@@ -112,6 +112,9 @@ func (t MetricType) supportedAggregationMethods() []string {
 // supportsAggregationMethod returns whether the MetricType supports a
 // given threshold aggregation method or not.
 func (t MetricType) supportsAggregationMethod(aggregationMethod string) bool {
+	if _, ok, _ := ParseCounterRateWindow(aggregationMethod); ok {
+		return t == Counter
+	}
 	for _, m := range t.supportedAggregationMethods() {
 		if aggregationMethod == m {
 			return true
@@ -3,7 +3,9 @@ package metrics
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/mstoykov/atlas"
 )
@@ -14,6 +16,11 @@ type Registry struct {
 	l       sync.RWMutex
 
 	rootTagSet *atlas.Node
+
+	// trendPrecision is the precision of the sinks of the Trend metrics
+	trendPrecision float64
+	// counterRateWindows are the windows of the rates of the Counter metrics
+	counterRateWindows []time.Duration
 }
 
 // NewRegistry returns a new registry
@@ -22,7 +29,8 @@ func NewRegistry() *Registry {
 		metrics: make(map[string]*Metric),
 		// All the new TagSts must branch out from this root, otherwise
 		// comparing them and using their Equals() method won't work correctly.
-		rootTagSet: atlas.New(),
+		rootTagSet:     atlas.New(),
+		trendPrecision: DefaultTrendPrecision,
 	}
 }
 
@@ -66,6 +74,48 @@ func (r *Registry) NewMetric(name string, typ MetricType, t ...ValueType) (*Metr
 	return oldMetric, nil
 }
 
+// DescribeMetric sets the unit, the description and the label names of the
+// metric, the label names are sorted. The metrics are shared between the VUs, so the ones that were already
+// set can't be changed with different values.
+func (r *Registry) DescribeMetric(m *Metric, unit, description string, labels []string) error {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if unit != "" {
+		if m.Unit != "" && m.Unit != unit {
+			return fmt.Errorf("metric '%s' already exists but with unit '%s', instead of '%s'", m.Name, m.Unit, unit)
+		}
+		m.Unit = unit
+	}
+	if description != "" {
+		if m.Description != "" && m.Description != description {
+			return fmt.Errorf("metric '%s' already exists but with a different description", m.Name)
+		}
+		m.Description = description
+	}
+	if labels != nil {
+		labels = append([]string{}, labels...)
+		sort.Strings(labels)
+		if m.Labels != nil && !sameLabels(m.Labels, labels) {
+			return fmt.Errorf("metric '%s' already exists but with labels %v, instead of %v", m.Name, m.Labels, labels)
+		}
+		m.Labels = labels
+	}
+	return nil
+}
+
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // MustNewMetric is like NewMetric, but will panic if there is an error
 func (r *Registry) MustNewMetric(name string, typ MetricType, t ...ValueType) *Metric {
 	m, err := r.NewMetric(name, typ, t...)
@@ -96,13 +146,73 @@ func (r *Registry) newMetric(name string, mt MetricType, vt ...ValueType) *Metri
 		valueType = vt[0]
 	}
 
-	sink := NewSink(mt)
 	return &Metric{
 		registry: r,
 		Name:     name,
 		Type:     mt,
 		Contains: valueType,
-		Sink:     sink,
+		Sink:     r.newSink(mt),
+	}
+}
+
+// newSink returns a new sink of the metric type, with the precision of the
+// Trend metrics and the rate windows of the Counter metrics.
+func (r *Registry) newSink(mt MetricType) Sink {
+	switch mt {
+	case Trend:
+		return NewTrendSinkWithPrecision(r.trendPrecision)
+	case Counter:
+		return NewCounterSinkWithRateWindows(r.counterRateWindows)
+	default:
+		return NewSink(mt)
+	}
+}
+
+// SetTrendPrecision sets the precision of the sinks of the Trend metrics, see
+// NewTrendSinkWithPrecision. The sinks of the already registered metrics are
+// replaced, if they are still empty, so it has to be called before the test
+// starts.
+func (r *Registry) SetTrendPrecision(precision float64) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.trendPrecision = precision
+	for _, m := range r.metrics {
+		if m.Type != Trend {
+			continue
+		}
+		if m.Sink.IsEmpty() {
+			m.Sink = NewTrendSinkWithPrecision(precision)
+		}
+		for _, sm := range m.Submetrics {
+			if sm.Metric.Sink.IsEmpty() {
+				sm.Metric.Sink = NewTrendSinkWithPrecision(precision)
+			}
+		}
+	}
+}
+
+// SetCounterRateWindows sets the windows of the rates of the sinks of the
+// Counter metrics, see NewCounterSinkWithRateWindows. The sinks of the already
+// registered metrics are replaced, if they are still empty, so it has to be
+// called before the test starts.
+func (r *Registry) SetCounterRateWindows(windows []time.Duration) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.counterRateWindows = normalizeCounterRateWindows(windows)
+	for _, m := range r.metrics {
+		if m.Type != Counter {
+			continue
+		}
+		if m.Sink.IsEmpty() {
+			m.Sink = NewCounterSinkWithRateWindows(r.counterRateWindows)
+		}
+		for _, sm := range m.Submetrics {
+			if sm.Metric.Sink.IsEmpty() {
+				sm.Metric.Sink = NewCounterSinkWithRateWindows(r.counterRateWindows)
+			}
+		}
 	}
 }
 
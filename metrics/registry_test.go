@@ -3,6 +3,7 @@ package metrics
 import (
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -101,3 +102,68 @@ func TestRegistryAll(t *testing.T) {
 		assert.ElementsMatch(t, exp, names(metrics))
 	})
 }
+
+func TestRegistrySetTrendPrecision(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	trend := r.MustNewMetric("my_trend", Trend)
+	assert.Equal(t, NewTrendSinkWithPrecision(DefaultTrendPrecision), trend.Sink)
+	counter := r.MustNewMetric("my_counter", Counter)
+
+	used := r.MustNewMetric("used_trend", Trend)
+	used.Sink.Add(Sample{Value: 1})
+
+	r.SetTrendPrecision(0)
+	assert.Equal(t, NewTrendSink(), trend.Sink)
+	assert.Equal(t, &CounterSink{}, counter.Sink)
+	// the sinks with values are kept
+	usedSink, ok := used.Sink.(*TrendSink)
+	require.True(t, ok)
+	assert.NotNil(t, usedSink.hist)
+	assert.Equal(t, uint64(1), usedSink.Count())
+
+	sm, err := trend.AddSubmetric("a:1")
+	require.NoError(t, err)
+	assert.Equal(t, NewTrendSink(), sm.Metric.Sink)
+	// and so are the sinks of the windows and the outputs
+	assert.Equal(t, NewTrendSink(), trend.NewSink())
+	assert.Equal(t, &CounterSink{}, counter.NewSink())
+	assert.Equal(t, NewTrendSinkWithPrecision(DefaultTrendPrecision), (&Metric{Type: Trend}).NewSink())
+}
+
+func TestRegistryDescribeMetric(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	m := r.MustNewMetric("my_gauge", Gauge)
+	require.NoError(t, r.DescribeMetric(m, "bytes", "The size of the queue", []string{"queue", "host"}))
+	assert.Equal(t, "bytes", m.Unit)
+	assert.Equal(t, "The size of the queue", m.Description)
+	assert.Equal(t, []string{"host", "queue"}, m.Labels)
+
+	// the same or empty values are fine
+	require.NoError(t, r.DescribeMetric(m, "", "The size of the queue", []string{"queue", "host"}))
+	assert.Equal(t, "bytes", m.Unit)
+
+	require.ErrorContains(t, r.DescribeMetric(m, "items", "", nil), "with unit 'bytes'")
+	require.ErrorContains(t, r.DescribeMetric(m, "", "Other", nil), "different description")
+	require.ErrorContains(t, r.DescribeMetric(m, "", "", []string{"host"}), "with labels")
+}
+
+func TestRegistrySetCounterRateWindows(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	counter := r.MustNewMetric("my_counter", Counter)
+	assert.Equal(t, &CounterSink{}, counter.Sink)
+
+	r.SetCounterRateWindows([]time.Duration{time.Minute})
+	counter.Sink.Add(Sample{Value: 6, Time: time.Unix(1, 0)})
+	assert.Contains(t, counter.Sink.Format(time.Second), "rate1m")
+
+	// the new metrics and sub-metrics have the windows too
+	sm, err := r.MustNewMetric("other_counter", Counter).AddSubmetric("a:1")
+	require.NoError(t, err)
+	assert.Equal(t, NewCounterSinkWithRateWindows([]time.Duration{time.Minute}), sm.Metric.Sink)
+}
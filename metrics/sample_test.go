@@ -44,7 +44,7 @@ func TestGetResolversForTrendColumnsValidation(t *testing.T) {
 		expErr bool
 	}{
 		{[]string{}, false},
-		{[]string{"avg", "min", "med", "max", "p(0)", "p(99)", "p(99.999)", "count"}, false},
+		{[]string{"avg", "min", "med", "max", "p(0)", "p(99)", "p(99.999)", "count", "stddev"}, false},
 		{[]string{"avg", "p(err)"}, true},
 		{[]string{"nil", "p(err)"}, true},
 		{[]string{"p90"}, true},
@@ -31,7 +31,7 @@ func NewSink(mt MetricType) Sink {
 	case Gauge:
 		sink = &GaugeSink{}
 	case Trend:
-		sink = NewTrendSink()
+		sink = NewTrendSinkWithPrecision(DefaultTrendPrecision)
 	case Rate:
 		sink = &RateSink{}
 	default:
@@ -47,6 +47,20 @@ func NewSink(mt MetricType) Sink {
 type CounterSink struct {
 	Value float64
 	First time.Time
+
+	// rates are the rates over the latest windows, if there are any
+	rates *counterRates
+}
+
+// NewCounterSinkWithRateWindows returns a CounterSink that also keeps the rates
+// per second of the counter over the latest windows of these lengths, in whole
+// seconds, as the rate1m-like stats.
+func NewCounterSinkWithRateWindows(windows []time.Duration) *CounterSink {
+	windows = normalizeCounterRateWindows(windows)
+	if len(windows) == 0 {
+		return &CounterSink{}
+	}
+	return &CounterSink{rates: newCounterRates(windows)}
 }
 
 // Add a single sample to the sink
@@ -55,6 +69,9 @@ func (c *CounterSink) Add(s Sample) {
 	if c.First.IsZero() {
 		c.First = s.Time
 	}
+	if c.rates != nil {
+		c.rates.add(s.Time, s.Value)
+	}
 }
 
 // IsEmpty indicates whether the CounterSink is empty.
@@ -62,10 +79,14 @@ func (c *CounterSink) IsEmpty() bool { return c.First.IsZero() }
 
 // Format counter and return a map
 func (c *CounterSink) Format(t time.Duration) map[string]float64 {
-	return map[string]float64{
+	values := map[string]float64{
 		"count": c.Value,
 		"rate":  c.Value / (float64(t) / float64(time.Second)),
 	}
+	if c.rates != nil {
+		c.rates.format(values)
+	}
+	return values
 }
 
 // GaugeSink is a sink represents a Gauge
@@ -95,19 +116,42 @@ func (g *GaugeSink) Format(_ time.Duration) map[string]float64 {
 	return map[string]float64{"value": g.Value}
 }
 
-// NewTrendSink makes a Trend sink with the OpenHistogram circllhist histogram.
+// DefaultTrendPrecision is the default relative error of the percentiles of
+// the Trend sinks created by the registries.
+const DefaultTrendPrecision = 0.01
+
+// NewTrendSink makes a Trend sink that keeps all the added values, so the
+// percentiles are exact, but its memory grows with the number of samples.
 func NewTrendSink() *TrendSink {
 	return &TrendSink{}
 }
 
+// NewTrendSinkWithPrecision makes a Trend sink that aggregates the added
+// values in a histogram, so its memory is bounded, and the percentiles have a
+// relative error of at most the precision. A precision of 0 makes a sink that
+// keeps all the values, like NewTrendSink.
+func NewTrendSinkWithPrecision(precision float64) *TrendSink {
+	if precision <= 0 {
+		return NewTrendSink()
+	}
+	return &TrendSink{hist: newTrendHistogram(precision)}
+}
+
 // TrendSink is a sink for a Trend
 type TrendSink struct {
 	values []float64
 	sorted bool
 
+	// hist aggregates the values instead of keeping them, it's nil if all the
+	// values are kept
+	hist *trendHistogram
+
 	count    uint64
 	min, max float64
 	sum      float64
+	// sumSquares is the sum of the squares of the values, for the standard
+	// deviation
+	sumSquares float64
 }
 
 // IsEmpty indicates whether the TrendSink is empty.
@@ -115,21 +159,65 @@ func (t *TrendSink) IsEmpty() bool { return t.count == 0 }
 
 // Add a single sample into the trend
 func (t *TrendSink) Add(s Sample) {
+	t.add(s.Value)
+}
+
+func (t *TrendSink) add(v float64) {
+	if t.hist != nil && math.IsNaN(v) {
+		return // it can't be ordered, so it can't be in a bucket
+	}
 	if t.count == 0 {
-		t.max, t.min = s.Value, s.Value
+		t.max, t.min = v, v
 	} else {
-		if s.Value > t.max {
-			t.max = s.Value
+		if v > t.max {
+			t.max = v
 		}
-		if s.Value < t.min {
-			t.min = s.Value
+		if v < t.min {
+			t.min = v
 		}
 	}
 
-	t.values = append(t.values, s.Value)
-	t.sorted = false
+	if t.hist != nil {
+		t.hist.add(v)
+	} else {
+		t.values = append(t.values, v)
+		t.sorted = false
+	}
 	t.count++
-	t.sum += s.Value
+	t.sum += v
+	t.sumSquares += v * v
+}
+
+// Merge adds the values of the other sink to this one. If they both aggregate
+// the values in a histogram, the precision of the other one has to be the
+// same; if only the other one does, its values are lost, so it shouldn't be
+// merged to a sink that keeps all of them.
+func (t *TrendSink) Merge(other *TrendSink) {
+	if other.count == 0 {
+		return
+	}
+	if t.hist != nil && other.hist == nil {
+		for _, v := range other.values {
+			t.add(v)
+		}
+		return
+	}
+	if t.count == 0 || other.min < t.min {
+		t.min = other.min
+	}
+	if t.count == 0 || other.max > t.max {
+		t.max = other.max
+	}
+
+	if t.hist != nil {
+		t.hist.merge(other.hist)
+	} else {
+		t.values = append(t.values, other.values...)
+		t.sorted = false
+	}
+	t.count += other.count
+	t.sum += other.sum
+	t.sumSquares += other.sumSquares
 }
 
 // P calculates the given percentile from sink values.
@@ -138,24 +226,43 @@ func (t *TrendSink) P(pct float64) float64 {
 	case 0:
 		return 0
 	case 1:
-		return t.values[0]
+		return t.min
 	default:
-		if !t.sorted {
-			sort.Float64s(t.values)
-			t.sorted = true
-		}
-
 		// If percentile falls on a value in Values slice, we return that value.
 		// If percentile does not fall on a value in Values slice, we calculate (linear interpolation)
 		// the value that would fall at percentile, given the values above and below that percentile.
 		i := pct * (float64(t.count) - 1.0)
-		j := t.values[int(math.Floor(i))]
-		k := t.values[int(math.Ceil(i))]
+		lower, upper := uint64(math.Floor(i)), uint64(math.Ceil(i))
 		f := i - math.Floor(i)
+
+		if t.hist != nil {
+			j, k := t.hist.at(lower, upper)
+			// the estimates of the buckets could be out of the actual range
+			return math.Min(math.Max(interpolate(j, k, f), t.min), t.max)
+		}
+
+		if !t.sorted {
+			sort.Float64s(t.values)
+			t.sorted = true
+		}
+		j := t.values[lower]
+		k := t.values[upper]
 		return j + (k-j)*f
 	}
 }
 
+// interpolate returns the value at the fraction f of the way from j to k, or
+// the nearest of them if one is infinite, since there is nothing in between.
+func interpolate(j, k, f float64) float64 {
+	if j == k || math.IsInf(j, 0) || math.IsInf(k, 0) {
+		if f < 0.5 {
+			return j
+		}
+		return k
+	}
+	return j + (k-j)*f
+}
+
 // Min returns the minimum value.
 func (t *TrendSink) Min() float64 {
 	return t.min
@@ -179,6 +286,17 @@ func (t *TrendSink) Avg() float64 {
 	return 0
 }
 
+// StdDev returns the sample standard deviation of the values.
+func (t *TrendSink) StdDev() float64 {
+	if t.count < 2 {
+		return 0
+	}
+	n := float64(t.count)
+	// the rounding errors could make the variance slightly negative
+	variance := (t.sumSquares - t.sum*t.sum/n) / (n - 1)
+	return math.Sqrt(math.Max(variance, 0))
+}
+
 // Total returns the total (i.e. "sum") value for all measurements.
 func (t *TrendSink) Total() float64 {
 	return t.sum
@@ -197,6 +315,129 @@ func (t *TrendSink) Format(_ time.Duration) map[string]float64 {
 	}
 }
 
+// trendHistogram aggregates the values of a Trend in buckets with
+// exponentially growing bounds, so any two values in the same bucket differ by
+// at most the precision, relatively. The percentiles are estimated with the
+// means of the values of their buckets, so they are exact if all the values
+// in a bucket are the same.
+type trendHistogram struct {
+	logGamma float64
+
+	// positive and negative have the buckets of the values by the index of
+	// their absolute value, the bucket i has the values in (gamma^(i-1), gamma^i]
+	positive, negative map[int]trendBucket
+	zero               trendBucket
+
+	// ordered has the non-empty buckets from the lowest values to the highest,
+	// it's nil if a value has been added since it has been built
+	ordered []trendBucket
+}
+
+type trendBucket struct {
+	count uint64
+	sum   float64
+}
+
+func newTrendHistogram(precision float64) *trendHistogram {
+	return &trendHistogram{
+		logGamma: math.Log1p(precision),
+		positive: make(map[int]trendBucket),
+		negative: make(map[int]trendBucket),
+	}
+}
+
+func (h *trendHistogram) add(v float64) {
+	h.ordered = nil
+	switch {
+	case v > 0:
+		i := h.index(v)
+		h.positive[i] = h.positive[i].add(v)
+	case v < 0:
+		i := h.index(-v)
+		h.negative[i] = h.negative[i].add(v)
+	default:
+		h.zero = h.zero.add(v)
+	}
+}
+
+func (h *trendHistogram) merge(other *trendHistogram) {
+	h.ordered = nil
+	for i, b := range other.positive {
+		h.positive[i] = h.positive[i].merge(b)
+	}
+	for i, b := range other.negative {
+		h.negative[i] = h.negative[i].merge(b)
+	}
+	h.zero = h.zero.merge(other.zero)
+}
+
+func (h *trendHistogram) index(v float64) int {
+	if math.IsInf(v, 1) {
+		// the infinities have their own buckets, past the ones of the finite
+		// values, since the logarithm of the infinity isn't a valid index
+		return h.index(math.MaxFloat64) + 1
+	}
+	return int(math.Ceil(math.Log(v) / h.logGamma))
+}
+
+func (b trendBucket) add(v float64) trendBucket {
+	return trendBucket{count: b.count + 1, sum: b.sum + v}
+}
+
+func (b trendBucket) merge(other trendBucket) trendBucket {
+	return trendBucket{count: b.count + other.count, sum: b.sum + other.sum}
+}
+
+// at returns the estimates of the values with the given ranks, i.e. their
+// indexes if all the values were sorted. The first rank can't be greater
+// than the second one.
+func (h *trendHistogram) at(first, second uint64) (float64, float64) {
+	if h.ordered == nil {
+		h.order()
+	}
+	var seen uint64
+	var firstValue float64
+	firstFound := false
+	for _, b := range h.ordered {
+		seen += b.count
+		mean := b.sum / float64(b.count)
+		if !firstFound && first < seen {
+			firstValue, firstFound = mean, true
+		}
+		if second < seen {
+			return firstValue, mean
+		}
+	}
+	// the ranks are out of range, it shouldn't happen
+	last := h.ordered[len(h.ordered)-1]
+	return firstValue, last.sum / float64(last.count)
+}
+
+func (h *trendHistogram) order() {
+	h.ordered = make([]trendBucket, 0, len(h.negative)+len(h.positive)+1)
+
+	// the higher the index of a negative value the lower it is
+	indexes := sortedKeys(h.negative)
+	for i := len(indexes) - 1; i >= 0; i-- {
+		h.ordered = append(h.ordered, h.negative[indexes[i]])
+	}
+	if h.zero.count > 0 {
+		h.ordered = append(h.ordered, h.zero)
+	}
+	for _, i := range sortedKeys(h.positive) {
+		h.ordered = append(h.ordered, h.positive[i])
+	}
+}
+
+func sortedKeys(buckets map[int]trendBucket) []int {
+	keys := make([]int, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
 // RateSink is a sink for a rate
 type RateSink struct {
 	Trues int64
@@ -223,3 +464,10 @@ func (r RateSink) Format(_ time.Duration) map[string]float64 {
 
 	return map[string]float64{"rate": rate}
 }
+
+// AggregatedPeriod is the aggregation of the samples of a metric in a period
+// of its time series, for the trend of its values over time.
+type AggregatedPeriod struct {
+	Start time.Time
+	Sink  Sink
+}
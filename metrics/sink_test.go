@@ -19,7 +19,7 @@ func TestNewSink(t *testing.T) {
 		{mt: Counter, sink: &CounterSink{}},
 		{mt: Gauge, sink: &GaugeSink{}},
 		{mt: Rate, sink: &RateSink{}},
-		{mt: Trend, sink: NewTrendSink()},
+		{mt: Trend, sink: NewTrendSinkWithPrecision(DefaultTrendPrecision)},
 	}
 	for _, tc := range tests {
 		assert.Equal(t, tc.sink, NewSink(tc.mt))
@@ -63,6 +63,51 @@ func TestCounterSink(t *testing.T) {
 		}
 		assert.Equal(t, map[string]float64{"count": 145, "rate": 145.0}, sink.Format(1*time.Second))
 	})
+	t.Run("rate windows", func(t *testing.T) {
+		t.Parallel()
+		sink := NewCounterSinkWithRateWindows([]time.Duration{time.Minute, 10 * time.Second, time.Minute})
+		start := time.Unix(1000, 0)
+		add := func(sec int, value float64) {
+			sink.Add(Sample{
+				TimeSeries: TimeSeries{Metric: &Metric{}},
+				Value:      value,
+				Time:       start.Add(time.Duration(sec) * time.Second),
+			})
+		}
+
+		// shorter than the windows, the rates are over the time since the first sample
+		add(0, 10)
+		add(4, 10)
+		values := sink.Format(5 * time.Second)
+		assert.Equal(t, 4.0, values["rate10s"])
+		assert.Equal(t, 4.0, values["rate1m"])
+
+		// 1 per second for 2 minutes, then 10 per second for 10 seconds
+		for sec := 5; sec < 125; sec++ {
+			add(sec, 1)
+		}
+		for sec := 125; sec < 135; sec++ {
+			add(sec, 10)
+		}
+		values = sink.Format(135 * time.Second)
+		assert.Equal(t, 10.0, values["rate10s"])
+		assert.Equal(t, (50.0+100.0)/60.0, values["rate1m"])
+		assert.Equal(t, 240.0, values["count"])
+
+		// the late samples are added to their second, if it's still in the windows
+		add(130, 10)
+		add(10, 1000)
+		assert.Equal(t, 11.0, sink.Format(135 * time.Second)["rate10s"])
+	})
+}
+
+func TestCounterRateStat(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "rate30s", CounterRateStat(30*time.Second))
+	assert.Equal(t, "rate1m", CounterRateStat(time.Minute))
+	assert.Equal(t, "rate1m30s", CounterRateStat(90*time.Second))
+	assert.Equal(t, "rate1h", CounterRateStat(time.Hour))
+	assert.Equal(t, "rate1h0m30s", CounterRateStat(time.Hour+30*time.Second))
 }
 
 func TestGaugeSink(t *testing.T) {
@@ -118,6 +163,7 @@ func TestTrendSink(t *testing.T) {
 			assert.Equal(t, 7.0, sink.Max())
 			assert.Equal(t, 7.0, sink.Avg())
 			assert.Equal(t, 7.0, sink.Total())
+			assert.Equal(t, 0.0, sink.StdDev())
 			assert.Equal(t, []float64{7.0}, sink.values)
 		})
 		t.Run("values", func(t *testing.T) {
@@ -132,6 +178,7 @@ func TestTrendSink(t *testing.T) {
 			assert.Equal(t, 100.0, sink.Max())
 			assert.Equal(t, 54.0, sink.Avg())
 			assert.Equal(t, 540.0, sink.Total())
+			assert.InDelta(t, 32.04164, sink.StdDev(), 0.00001)
 			assert.Equal(t, unsortedSamples10, sink.values)
 		})
 		t.Run("negative", func(t *testing.T) {
@@ -236,6 +283,104 @@ func TestTrendSink(t *testing.T) {
 	})
 }
 
+func TestTrendSinkWithPrecision(t *testing.T) {
+	t.Parallel()
+
+	t.Run("raw", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, NewTrendSink(), NewTrendSinkWithPrecision(0))
+	})
+	t.Run("exact", func(t *testing.T) {
+		t.Parallel()
+
+		// the values are far enough to be in different buckets, so the
+		// percentiles are the same as the ones of all the values
+		raw, hist := NewTrendSink(), NewTrendSinkWithPrecision(0.01)
+		for _, v := range []float64{-3, 0.0, 100.0, 30.0, 80.0, 0, 70.0, 60.0, 50.0, 40.0, 90.0, 20.0, -1.5} {
+			raw.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+			hist.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+		}
+		assert.Equal(t, raw.Min(), hist.Min())
+		assert.Equal(t, raw.Max(), hist.Max())
+		assert.Equal(t, raw.Total(), hist.Total())
+		for i := 0; i <= 100; i++ {
+			assert.InDelta(t, raw.P(float64(i)/100), hist.P(float64(i)/100), 0.000001, i)
+		}
+	})
+	t.Run("precision", func(t *testing.T) {
+		t.Parallel()
+
+		const precision = 0.01
+		raw, hist := NewTrendSink(), NewTrendSinkWithPrecision(precision)
+		for i := 0; i < 100000; i++ {
+			// from 1ms to more than a minute, like the durations of requests
+			v := math.Exp(float64(i%997) / 997 * 11)
+			raw.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+			hist.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+		}
+		assert.Less(t, len(hist.hist.positive), 1200)
+		assert.Empty(t, hist.values)
+		for _, pct := range []float64{0, 0.5, 0.9, 0.95, 0.99, 0.999, 1} {
+			assert.InEpsilon(t, raw.P(pct), hist.P(pct), precision, pct)
+		}
+
+		// a new value invalidates the order of the buckets
+		hist.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 100000})
+		assert.Equal(t, 100000.0, hist.P(1))
+	})
+	t.Run("merge", func(t *testing.T) {
+		t.Parallel()
+
+		all, first, second := NewTrendSinkWithPrecision(0.01), NewTrendSinkWithPrecision(0.01), NewTrendSink()
+		merged := NewTrendSinkWithPrecision(0.01)
+		for i, v := range []float64{-3, 0.0, 100.0, 30.0, 80.0, 0, 70.0, 60.0, 50.0, 40.0, 90.0, 20.0, -1.5} {
+			all.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+			if i%2 == 0 {
+				first.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+			} else {
+				second.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+			}
+		}
+		merged.Merge(first)
+		merged.Merge(second)
+		merged.Merge(NewTrendSink())
+		assert.Equal(t, all.Count(), merged.Count())
+		assert.Equal(t, all.Min(), merged.Min())
+		assert.Equal(t, all.Max(), merged.Max())
+		assert.Equal(t, all.Total(), merged.Total())
+		for i := 0; i <= 100; i++ {
+			assert.Equal(t, all.P(float64(i)/100), merged.P(float64(i)/100), i)
+		}
+	})
+	t.Run("infinities and NaN", func(t *testing.T) {
+		t.Parallel()
+
+		hist, raw := NewTrendSinkWithPrecision(0.01), NewTrendSink()
+		for _, v := range []float64{math.NaN(), 2, math.Inf(1), 1, math.Inf(-1), 3, math.NaN()} {
+			hist.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+			raw.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+		}
+		// the NaN values are skipped and the infinities are past the finite values
+		assert.Equal(t, uint64(5), hist.Count())
+		assert.Equal(t, hist.hist.index(math.MaxFloat64)+1, hist.hist.index(math.Inf(1)))
+		assert.Equal(t, math.Inf(-1), hist.Min())
+		assert.Equal(t, math.Inf(1), hist.Max())
+		assert.Equal(t, math.Inf(-1), hist.P(0))
+		assert.InEpsilon(t, 1.0, hist.P(0.25), 0.01)
+		assert.InEpsilon(t, 2.0, hist.P(0.5), 0.01)
+		assert.InEpsilon(t, 3.0, hist.P(0.75), 0.01)
+		assert.Equal(t, math.Inf(1), hist.P(1))
+
+		// the NaN values of a sink without a histogram are skipped too
+		merged := NewTrendSinkWithPrecision(0.01)
+		merged.Merge(raw)
+		assert.Equal(t, hist.Count(), merged.Count())
+		for _, pct := range []float64{0, 0.25, 0.5, 0.75, 1} {
+			assert.Equal(t, hist.P(pct), merged.P(pct), pct)
+		}
+	})
+}
+
 func TestRateSink(t *testing.T) {
 	t.Parallel()
 	samples6 := []float64{1.0, 0.0, 1.0, 0.0, 0.0, 1.0}
@@ -11,6 +11,7 @@ import (
 	"go.k6.io/k6/errext"
 	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/lib/types"
+	"gopkg.in/guregu/null.v3"
 )
 
 // Threshold is a representation of a single threshold for a single metric
@@ -24,8 +25,21 @@ type Threshold struct {
 	// AbortGracePeriod is a the minimum amount of time a test should be running before a failing
 	// this threshold will abort the test
 	AbortGracePeriod types.NullDuration
+	// IgnoreFirst is the warm-up period, from the first sample of the test, whose samples
+	// aren't evaluated
+	IgnoreFirst types.NullDuration
+	// Window is the period of the latest samples the threshold is evaluated over, instead of
+	// all of them; a window that fails fails the threshold, even if the next ones don't
+	Window types.NullDuration
+	// ConsecutiveWindows is how many consecutive windows have to fail to fail the threshold
+	ConsecutiveWindows null.Int
+	// ExitCode is the exit code of k6 if the threshold fails, instead of the
+	// one of the crossed thresholds
+	ExitCode null.Int
 	// parsed is the threshold expression parsed from the Source
 	parsed *thresholdExpression
+	// window has the samples of the threshold, if it has a warm-up or a window
+	window *thresholdWindow
 }
 
 func newThreshold(src string, abortOnFail bool, gracePeriod types.NullDuration) *Threshold {
@@ -47,24 +61,33 @@ func (t *Threshold) runNoTaint(sinks map[string]float64) (bool, error) {
 		return true, nil
 	}
 
+	rhs := t.parsed.Value
+	if t.parsed.Relative != nil {
+		relative, ok := sinks[t.parsed.Relative.SinkKey()]
+		if !ok {
+			return true, nil
+		}
+		rhs *= relative
+	}
+
 	// Apply the threshold expression operator to the left and
 	// right hand side values
 	var passes bool
 	switch t.parsed.Operator {
 	case ">":
-		passes = lhs > t.parsed.Value
+		passes = lhs > rhs
 	case ">=":
-		passes = lhs >= t.parsed.Value
+		passes = lhs >= rhs
 	case "<=":
-		passes = lhs <= t.parsed.Value
+		passes = lhs <= rhs
 	case "<":
-		passes = lhs < t.parsed.Value
+		passes = lhs < rhs
 	case "==", "===":
 		// Considering a sink always maps to float64 values,
 		// strictly equal is equivalent to loosely equal
-		passes = lhs == t.parsed.Value
+		passes = lhs == rhs
 	case "!=":
-		passes = lhs != t.parsed.Value
+		passes = lhs != rhs
 	default:
 		// The parseThresholdExpression function should ensure that no invalid
 		// operator gets through, but let's protect our future selves anyhow.
@@ -85,10 +108,34 @@ func (t *Threshold) run(sinks map[string]float64) (bool, error) {
 	return passes, err
 }
 
+// validateWindow checks the options of the warm-up and of the windows.
+func (t *Threshold) validateWindow() error {
+	switch {
+	case t.IgnoreFirst.Valid && t.IgnoreFirst.Duration < 0:
+		return fmt.Errorf("ignoreFirst can't be negative")
+	case t.Window.Valid && t.Window.Duration <= 0:
+		return fmt.Errorf("window must be positive")
+	case t.ConsecutiveWindows.Valid && !t.Window.Valid:
+		return fmt.Errorf("consecutiveWindows requires a window")
+	case t.ConsecutiveWindows.Valid && t.ConsecutiveWindows.Int64 < 1:
+		return fmt.Errorf("consecutiveWindows must be at least 1")
+	case t.window != nil && t.parsed != nil && t.parsed.hasSubmetrics():
+		return fmt.Errorf("ignoreFirst and window can't be used with sub-metrics")
+	default:
+		return nil
+	}
+}
+
 type thresholdConfig struct {
 	Threshold        string             `json:"threshold"`
 	AbortOnFail      bool               `json:"abortOnFail"`
 	AbortGracePeriod types.NullDuration `json:"delayAbortEval"`
+	// the evaluation windows are omitted if they aren't set, for the
+	// compatibility of the JSON of the other thresholds
+	IgnoreFirst        *types.Duration `json:"ignoreFirst,omitempty"`
+	Window             *types.Duration `json:"window,omitempty"`
+	ConsecutiveWindows *int64          `json:"consecutiveWindows,omitempty"`
+	ExitCode           *int64          `json:"exitCode,omitempty"`
 }
 
 // used internally for JSON marshalling
@@ -106,7 +153,8 @@ func (tc *thresholdConfig) UnmarshalJSON(data []byte) error {
 
 func (tc thresholdConfig) MarshalJSON() ([]byte, error) {
 	var data interface{} = tc.Threshold
-	if tc.AbortOnFail {
+	if tc.AbortOnFail || tc.IgnoreFirst != nil || tc.Window != nil || tc.ConsecutiveWindows != nil ||
+		tc.ExitCode != nil {
 		data = rawThresholdConfig(tc)
 	}
 
@@ -118,6 +166,8 @@ type Thresholds struct {
 	Thresholds []*Threshold
 	Abort      bool
 	sinked     map[string]float64
+	// submetrics are the sub-metrics the thresholds are evaluated on, by tags
+	submetrics map[string]*Metric
 }
 
 // NewThresholds returns Thresholds objects representing the provided source strings
@@ -136,16 +186,37 @@ func newThresholdsWithConfig(configs []thresholdConfig) Thresholds {
 
 	for i, config := range configs {
 		t := newThreshold(config.Threshold, config.AbortOnFail, config.AbortGracePeriod)
+		if config.IgnoreFirst != nil {
+			t.IgnoreFirst = types.NullDurationFrom(time.Duration(*config.IgnoreFirst))
+		}
+		if config.Window != nil {
+			t.Window = types.NullDurationFrom(time.Duration(*config.Window))
+		}
+		if config.ConsecutiveWindows != nil {
+			t.ConsecutiveWindows = null.IntFrom(*config.ConsecutiveWindows)
+		}
+		if config.ExitCode != nil {
+			t.ExitCode = null.IntFrom(*config.ExitCode)
+		}
+		if t.IgnoreFirst.Valid || t.Window.Valid {
+			t.window = newThresholdWindow(t)
+		}
 		thresholds[i] = t
 	}
 
-	return Thresholds{thresholds, false, sinked}
+	return Thresholds{Thresholds: thresholds, Abort: false, sinked: sinked}
 }
 
 func (ts *Thresholds) runAll(timeSpentInTest time.Duration) (bool, error) {
 	succeeded := true
 	for i, threshold := range ts.Thresholds {
-		b, err := threshold.run(ts.sinked)
+		var b bool
+		var err error
+		if threshold.window != nil {
+			b, err = threshold.window.run(threshold, timeSpentInTest)
+		} else {
+			b, err = threshold.run(ts.sinked)
+		}
 		if err != nil {
 			return false, fmt.Errorf("threshold %d run error: %w", i, err)
 		}
@@ -165,11 +236,135 @@ func (ts *Thresholds) runAll(timeSpentInTest time.Duration) (bool, error) {
 	return succeeded, nil
 }
 
+// HasWindows returns true if any of the thresholds has a warm-up or a window,
+// so the samples of the metric have to be added to them with AddSample.
+func (ts *Thresholds) HasWindows() bool {
+	for _, t := range ts.Thresholds {
+		if t.window != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AddSample adds the sample to the thresholds with a warm-up or a window, the
+// warm-up is from the start, the time of the first sample of the test.
+func (ts *Thresholds) AddSample(s Sample, start time.Time) {
+	for _, t := range ts.Thresholds {
+		if t.window != nil {
+			t.window.add(s, start)
+		}
+	}
+}
+
+// CounterRateWindows returns the windows of the rates of the counters, like
+// rate1m, the thresholds are evaluated on.
+func (ts *Thresholds) CounterRateWindows() []time.Duration {
+	var windows []time.Duration
+	add := func(method string) {
+		if window, ok, err := ParseCounterRateWindow(method); ok && err == nil {
+			windows = append(windows, window)
+		}
+	}
+	for _, t := range ts.Thresholds {
+		if t.parsed == nil {
+			continue
+		}
+		add(t.parsed.AggregationMethod)
+		if t.parsed.Relative != nil {
+			add(t.parsed.Relative.AggregationMethod)
+		}
+	}
+	return windows
+}
+
+// SubmetricTags returns the tags of the sub-metrics the thresholds are evaluated
+// on, in the order they are referenced. They have to be set with SetSubmetric
+// before the thresholds are run.
+func (ts *Thresholds) SubmetricTags() []string {
+	var tags []string
+	add := func(submetric string) {
+		if submetric == "" {
+			return
+		}
+		for _, t := range tags {
+			if t == submetric {
+				return
+			}
+		}
+		tags = append(tags, submetric)
+	}
+	for _, t := range ts.Thresholds {
+		if t.parsed == nil {
+			continue
+		}
+		add(t.parsed.Submetric)
+		if t.parsed.Relative != nil {
+			add(t.parsed.Relative.Submetric)
+		}
+	}
+	return tags
+}
+
+// SetSubmetric sets the sub-metric with the tags the thresholds are evaluated on.
+func (ts *Thresholds) SetSubmetric(tags string, submetric *Metric) {
+	if ts.submetrics == nil {
+		ts.submetrics = make(map[string]*Metric)
+	}
+	ts.submetrics[tags] = submetric
+}
+
 // Run processes all the thresholds with the provided Sink at the provided time and returns if any
 // of them fails
 func (ts *Thresholds) Run(sink Sink, duration time.Duration) (bool, error) {
-	// Initialize the sinks store
-	ts.sinked = make(map[string]float64)
+	sinked, err := sinkValues(sink, duration, percentiles(ts.Thresholds, ""))
+	if err != nil {
+		return false, err
+	}
+
+	// The values of the sub-metrics are keyed by the aggregation method and
+	// their tags, the ones without samples are left out, so the thresholds
+	// on them are skipped like the ones on an empty sink.
+	for tags, submetric := range ts.submetrics {
+		if submetric.Sink.IsEmpty() {
+			continue
+		}
+		values, err := sinkValues(submetric.Sink, duration, percentiles(ts.Thresholds, tags))
+		if err != nil {
+			return false, err
+		}
+		for key, value := range values {
+			sinked[key+" of {"+tags+"}"] = value
+		}
+	}
+	ts.sinked = sinked
+
+	return ts.runAll(duration)
+}
+
+// percentiles returns the percentiles the thresholds are evaluated on, of the
+// sub-metric with the tags, or of the metric if they are empty.
+func percentiles(thresholds []*Threshold, submetric string) []float64 {
+	var result []float64
+	add := func(method string, value null.Float, tags string) {
+		if method == tokenPercentile && tags == submetric {
+			result = append(result, value.Float64)
+		}
+	}
+	for _, threshold := range thresholds {
+		add(threshold.parsed.AggregationMethod, threshold.parsed.AggregationValue, threshold.parsed.Submetric)
+		if relative := threshold.parsed.Relative; relative != nil {
+			add(relative.AggregationMethod, relative.AggregationValue, relative.Submetric)
+		}
+	}
+	return result
+}
+
+// sinkValues returns the values of the sink the thresholds can be evaluated
+// on, with the percentiles, the rates of the counters are per second of the
+// duration or of their windows.
+func sinkValues(sink Sink, duration time.Duration, percentiles []float64) (map[string]float64, error) {
+	sinked := make(map[string]float64)
 
 	// FIXME: Remove this comment as soon as the metrics.Sink does not expose Format anymore.
 	//
@@ -180,37 +375,34 @@ func (ts *Thresholds) Run(sink Sink, duration time.Duration) (bool, error) {
 	// For more details, see https://github.com/grafana/k6/issues/2320
 	switch sinkImpl := sink.(type) {
 	case *CounterSink:
-		ts.sinked["count"] = sinkImpl.Value
-		ts.sinked["rate"] = sinkImpl.Value / (float64(duration) / float64(time.Second))
+		sinked["count"] = sinkImpl.Value
+		sinked["rate"] = sinkImpl.Value / (float64(duration) / float64(time.Second))
+		if sinkImpl.rates != nil {
+			sinkImpl.rates.format(sinked)
+		}
 	case *GaugeSink:
-		ts.sinked["value"] = sinkImpl.Value
+		sinked["value"] = sinkImpl.Value
 	case *TrendSink:
-		ts.sinked["min"] = sinkImpl.Min()
-		ts.sinked["max"] = sinkImpl.Max()
-		ts.sinked["avg"] = sinkImpl.Avg()
-		ts.sinked["med"] = sinkImpl.P(0.5)
-
-		// Parse the percentile thresholds and insert them in
-		// the sinks mapping.
-		for _, threshold := range ts.Thresholds {
-			if threshold.parsed.AggregationMethod != tokenPercentile {
-				continue
-			}
-
-			key := fmt.Sprintf("p(%g)", threshold.parsed.AggregationValue.Float64)
-			ts.sinked[key] = sinkImpl.P(threshold.parsed.AggregationValue.Float64 / 100)
+		sinked["min"] = sinkImpl.Min()
+		sinked["max"] = sinkImpl.Max()
+		sinked["avg"] = sinkImpl.Avg()
+		sinked["med"] = sinkImpl.P(0.5)
+
+		// Insert the percentiles of the thresholds in the sinks mapping.
+		for _, percentile := range percentiles {
+			key := fmt.Sprintf("p(%g)", percentile)
+			sinked[key] = sinkImpl.P(percentile / 100)
 		}
 	case *RateSink:
 		// We want to avoid division by zero, which
 		// would lead to [#2520](https://github.com/grafana/k6/issues/2520)
 		if sinkImpl.Total > 0 {
-			ts.sinked["rate"] = float64(sinkImpl.Trues) / float64(sinkImpl.Total)
+			sinked["rate"] = float64(sinkImpl.Trues) / float64(sinkImpl.Total)
 		}
 	default:
-		return false, fmt.Errorf("unable to run Thresholds; reason: unknown sink type")
+		return nil, fmt.Errorf("unable to run Thresholds; reason: unknown sink type")
 	}
-
-	return ts.runAll(duration)
+	return sinked, nil
 }
 
 // Parse parses the Thresholds and fills each Threshold.parsed field with the result.
@@ -266,6 +458,17 @@ func (ts *Thresholds) Validate(metricName string, r *Registry) error {
 			threshold.parsed = thresholdExpression
 		}
 
+		if threshold.ExitCode.Valid && (threshold.ExitCode.Int64 < 1 || threshold.ExitCode.Int64 > 255) {
+			err := fmt.Errorf("%w %q applied on metric %s; reason: exitCode must be between 1 and 255",
+				ErrInvalidThreshold, threshold.Source, metricName)
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+
+		if err := threshold.validateWindow(); err != nil {
+			err = fmt.Errorf("%w %q applied on metric %s; reason: %s", ErrInvalidThreshold, threshold.Source, metricName, err)
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+
 		// If the threshold's expression aggregation method is not
 		// supported for the metric we validate against, then we return
 		// an error indicating the InvalidConfig exitcode should be used.
@@ -280,6 +483,19 @@ func (ts *Thresholds) Validate(metricName string, r *Registry) error {
 			)
 			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
 		}
+
+		if relative := threshold.parsed.Relative; relative != nil &&
+			!metric.Type.supportsAggregationMethod(relative.AggregationMethod) {
+			err := fmt.Errorf(
+				"%w %q applied on metric %s; reason: "+
+					"unsupported aggregation method %s on metric of type %s in the right hand side. "+
+					"supported aggregation methods for this metric are: %s",
+				ErrInvalidThreshold, threshold.Source, metricName,
+				relative.AggregationMethod, metric.Type,
+				strings.Join(metric.Type.supportedAggregationMethods(), ", "),
+			)
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
 	}
 
 	return nil
@@ -304,6 +520,18 @@ func (ts Thresholds) MarshalJSON() ([]byte, error) {
 		configs[i].Threshold = t.Source
 		configs[i].AbortOnFail = t.AbortOnFail
 		configs[i].AbortGracePeriod = t.AbortGracePeriod
+		if t.IgnoreFirst.Valid {
+			configs[i].IgnoreFirst = &t.IgnoreFirst.Duration
+		}
+		if t.Window.Valid {
+			configs[i].Window = &t.Window.Duration
+		}
+		if t.ConsecutiveWindows.Valid {
+			configs[i].ConsecutiveWindows = &t.ConsecutiveWindows.Int64
+		}
+		if t.ExitCode.Valid {
+			configs[i].ExitCode = &t.ExitCode.Int64
+		}
 	}
 
 	return MarshalJSONWithoutHTMLEscape(configs)
@@ -21,12 +21,32 @@ type thresholdExpression struct {
 	// would result in AggregationValue to be set to 99.9.
 	AggregationValue null.Float
 
+	// Submetric holds the tags of the sub-metric the aggregation method is
+	// applied to, for an expression like p(95) of {group:::checkout} < 500,
+	// e.g. "group:::checkout". It is empty if the aggregation method is applied
+	// to the metric of the threshold.
+	Submetric string
+
 	// Operator holds the operator parsed from the threshold expression.
 	// Possible values are described by `operatorTokens`.
 	Operator string
 
 	// Value holds the value parsed from the threshold expression.
 	Value float64
+
+	// Relative holds the right hand side aggregation of an expression comparing
+	// two aggregations, like p(95) of {group:::checkout} < 1.5 * p(95) of {group:::browse},
+	// in which case Value is the factor it's multiplied by. It is nil if the
+	// right hand side is a constant value.
+	Relative *thresholdOperand
+}
+
+// thresholdOperand is an aggregation method applied to a sub-metric, or to the
+// metric of the threshold if Submetric is empty.
+type thresholdOperand struct {
+	AggregationMethod string
+	AggregationValue  null.Float
+	Submetric         string
 }
 
 // SinkKey computes the key used to index a thresholdExpression in the engine's sinks.
@@ -41,24 +61,54 @@ type thresholdExpression struct {
 // case specifically. If we encounter the percentile aggregation method token,
 // we recompute the whole "p(value)" expression in order to look for it in the
 // sinks.
+//
+// The aggregation methods applied to a sub-metric are suffixed with its tags,
+// e.g. "p(95) of {group:::checkout}".
 func (te *thresholdExpression) SinkKey() string {
-	if te.AggregationMethod == tokenPercentile {
-		return fmt.Sprintf("%s(%g)", tokenPercentile, te.AggregationValue.Float64)
+	return sinkKey(te.AggregationMethod, te.AggregationValue, te.Submetric)
+}
+
+// SinkKey computes the key used to index the operand in the engine's sinks,
+// like thresholdExpression.SinkKey does.
+func (to *thresholdOperand) SinkKey() string {
+	return sinkKey(to.AggregationMethod, to.AggregationValue, to.Submetric)
+}
+
+func sinkKey(method string, value null.Float, submetric string) string {
+	key := method
+	if method == tokenPercentile {
+		key = fmt.Sprintf("%s(%g)", tokenPercentile, value.Float64)
+	}
+	if submetric != "" {
+		key += " of {" + submetric + "}"
 	}
 
-	return te.AggregationMethod
+	return key
+}
+
+// hasSubmetrics returns true if any of the aggregation methods of the
+// expression is applied to a sub-metric.
+func (te *thresholdExpression) hasSubmetrics() bool {
+	return te.Submetric != "" || (te.Relative != nil && te.Relative.Submetric != "")
 }
 
 // parseThresholdExpression parses a threshold condition expression,
 // as defined in a JS script (for instance p(95)<1000), into a thresholdExpression
 // instance.
 //
-// It is expected to be of the form: `aggregation_method operator value`.
+// It is expected to be of the form: `aggregation_method operator value`,
+// where the aggregation methods can be applied to sub-metrics of the metric,
+// and the value can be another aggregation, multiplied by a factor.
 // As defined by the following BNF:
 // ```
-// assertion           -> aggregation_method whitespace* operator whitespace* float
+// assertion           -> operand whitespace* operator whitespace* (float | relative)
+// relative            -> (float whitespace* "*" whitespace*)? operand
+// operand             -> aggregation_method (whitespace* "of" whitespace* submetric)?
+// submetric           -> "{" tag ("," tag)* "}"
+// tag                 -> key ":" value
 // aggregation_method  -> trend | rate | gauge | counter
-// counter             -> "count" | "rate"
+// counter             -> "count" | "rate" | "rate" window
+// window              -> duration, e.g. "1m" or "30s"
 // gauge               -> "value"
 // rate                -> "rate"
 // trend               -> "avg" | "min" | "max" | "med" | percentile
@@ -76,7 +126,7 @@ func parseThresholdExpression(input string) (*thresholdExpression, error) {
 		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: %w", input, err)
 	}
 
-	parsedMethod, parsedMethodValue, err := parseThresholdAggregationMethod(method)
+	parsedOperand, err := parseThresholdOperand(method)
 	if err != nil {
 		err = fmt.Errorf("failed parsing threshold expression's %q left hand side; "+
 			"reason: %w", input, err,
@@ -84,7 +134,7 @@ func parseThresholdExpression(input string) (*thresholdExpression, error) {
 		return nil, err
 	}
 
-	parsedValue, err := strconv.ParseFloat(value, 64)
+	parsedValue, relative, err := parseThresholdValue(value)
 	if err != nil {
 		err = fmt.Errorf("failed parsing threshold expresion's %q right hand side; "+
 			"reason: %w", input, err,
@@ -93,15 +143,77 @@ func parseThresholdExpression(input string) (*thresholdExpression, error) {
 	}
 
 	condition := &thresholdExpression{
-		AggregationMethod: parsedMethod,
-		AggregationValue:  parsedMethodValue,
+		AggregationMethod: parsedOperand.AggregationMethod,
+		AggregationValue:  parsedOperand.AggregationValue,
+		Submetric:         parsedOperand.Submetric,
 		Operator:          operator,
 		Value:             parsedValue,
+		Relative:          relative,
 	}
 
 	return condition, nil
 }
 
+// parseThresholdOperand parses an aggregation method, optionally applied to
+// a sub-metric, e.g. `p(95)` or `p(95) of {group:::checkout,status:200}`.
+func parseThresholdOperand(input string) (*thresholdOperand, error) {
+	method, submetric := input, ""
+	if pos := strings.IndexByte(input, '{'); pos != -1 {
+		before := strings.TrimSpace(input[:pos])
+		if !strings.HasSuffix(before, " of") {
+			return nil, fmt.Errorf("malformed sub-metric, it needs to be like 'p(95) of {key:value}'")
+		}
+		method = strings.TrimSpace(strings.TrimSuffix(before, " of"))
+
+		selector := input[pos:]
+		if !strings.HasSuffix(selector, "}") {
+			return nil, fmt.Errorf("missing ending bracket, sub-metric format needs to be '{key:value}'")
+		}
+		submetric = strings.TrimSpace(trimDelimited("{", selector, "}"))
+		if submetric == "" {
+			return nil, fmt.Errorf("sub-metric criteria cannot be empty")
+		}
+	}
+
+	parsedMethod, parsedMethodValue, err := parseThresholdAggregationMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	return &thresholdOperand{
+		AggregationMethod: parsedMethod,
+		AggregationValue:  parsedMethodValue,
+		Submetric:         submetric,
+	}, nil
+}
+
+// parseThresholdValue parses the right hand side of a threshold expression,
+// either a constant value or an aggregation multiplied by a factor, like
+// `1.5 * p(95) of {group:::browse}`, which is returned as the operand with
+// the factor as value.
+func parseThresholdValue(input string) (float64, *thresholdOperand, error) {
+	if value, err := strconv.ParseFloat(input, 64); err == nil {
+		return value, nil, nil
+	}
+
+	factor, operand := 1.0, input
+	if pos := strings.IndexByte(input, '*'); pos != -1 && !strings.Contains(input[:pos], "{") {
+		var err error
+		factor, err = strconv.ParseFloat(strings.TrimSpace(input[:pos]), 64)
+		if err != nil {
+			return 0, nil, fmt.Errorf("malformed factor; reason: %w", err)
+		}
+		operand = strings.TrimSpace(input[pos+1:])
+	}
+
+	relative, err := parseThresholdOperand(operand)
+	if err != nil {
+		return 0, nil, fmt.Errorf("it's neither a number nor an aggregation; reason: %w", err)
+	}
+
+	return factor, relative, nil
+}
+
 // Define accepted threshold expression operators tokens
 const (
 	tokenLessEqual     = "<="
@@ -206,6 +318,14 @@ func parseThresholdAggregationMethod(input string) (string, null.Float, error) {
 		return tokenPercentile, null.FloatFrom(aggregationValue), nil
 	}
 
+	// Or a rate of a counter over a window, e.g. rate1m
+	if window, ok, err := ParseCounterRateWindow(input); ok {
+		if err != nil {
+			return "", null.Float{}, err
+		}
+		return CounterRateStat(window), null.Float{}, nil
+	}
+
 	return "", null.Float{}, fmt.Errorf("failed parsing method from expression")
 }
 
@@ -40,6 +40,65 @@ func TestParseThresholdExpression(t *testing.T) {
 			wantExpression: &thresholdExpression{AggregationMethod: "count", Operator: ">", Value: 20},
 			wantErr:        false,
 		},
+		{
+			name:  "valid sub-metric threshold expression syntax",
+			input: "p(95) of {group:::checkout,status:200} < 500",
+			wantExpression: &thresholdExpression{
+				AggregationMethod: "p",
+				AggregationValue:  null.FloatFrom(95),
+				Submetric:         "group:::checkout,status:200",
+				Operator:          "<",
+				Value:             500,
+			},
+			wantErr: false,
+		},
+		{
+			name:  "valid relative threshold expression syntax",
+			input: "p(95) of {group:::checkout} < 1.5 * p(95) of {group:::browse}",
+			wantExpression: &thresholdExpression{
+				AggregationMethod: "p",
+				AggregationValue:  null.FloatFrom(95),
+				Submetric:         "group:::checkout",
+				Operator:          "<",
+				Value:             1.5,
+				Relative: &thresholdOperand{
+					AggregationMethod: "p",
+					AggregationValue:  null.FloatFrom(95),
+					Submetric:         "group:::browse",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "valid relative threshold expression syntax without a factor",
+			input: "p(99)<=med of {scenario:main}",
+			wantExpression: &thresholdExpression{
+				AggregationMethod: "p",
+				AggregationValue:  null.FloatFrom(99),
+				Operator:          "<=",
+				Value:             1,
+				Relative:          &thresholdOperand{AggregationMethod: "med", Submetric: "scenario:main"},
+			},
+			wantErr: false,
+		},
+		{
+			name:           "sub-metric without the of keyword fails",
+			input:          "p(95){group:::checkout}<500",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "empty sub-metric fails",
+			input:          "p(95) of {} < 500",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "non numerical factor fails",
+			input:          "p(95) < abc * p(95) of {group:::browse}",
+			wantExpression: nil,
+			wantErr:        true,
+		},
 	}
 	for _, testCase := range tests {
 		testCase := testCase
@@ -94,6 +153,27 @@ func TestParseThresholdAggregationMethod(t *testing.T) {
 			wantMethodValue: null.Float{},
 			wantErr:         false,
 		},
+		{
+			name:            "rate over a window is parsed",
+			input:           "rate60s",
+			wantMethod:      "rate1m",
+			wantMethodValue: null.Float{},
+			wantErr:         false,
+		},
+		{
+			name:            "rate over a fraction of second fails",
+			input:           "rate1.5s",
+			wantMethod:      "",
+			wantMethodValue: null.Float{},
+			wantErr:         true,
+		},
+		{
+			name:            "rate over a malformed window fails",
+			input:           "rated",
+			wantMethod:      "",
+			wantMethodValue: null.Float{},
+			wantErr:         true,
+		},
 		{
 			name:            "value method is parsed",
 			input:           "value",
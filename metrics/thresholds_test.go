@@ -42,7 +42,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 	}{
 		{
 			name:             "valid expression using the > operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenGreater, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 1},
 			wantOk:           true,
@@ -50,7 +50,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the > operator over passing threshold and defined abort grace period",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenGreater, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(2 * time.Second),
 			sinks:            map[string]float64{"rate": 1},
 			wantOk:           true,
@@ -58,7 +58,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the >= operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreaterEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenGreaterEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -66,7 +66,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the <= operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLessEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenLessEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -74,7 +74,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the < operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLess, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenLess, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           true,
@@ -82,7 +82,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the == operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLooselyEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenLooselyEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -90,7 +90,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the === operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenStrictlyEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenStrictlyEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -98,7 +98,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using != operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenBangEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenBangEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.02},
 			wantOk:           true,
@@ -106,7 +106,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression over failing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenGreater, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           false,
@@ -114,7 +114,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression over non-existing sink",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenGreater, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"med": 27.2},
 			wantOk:           true,
@@ -124,7 +124,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 			// The ParseThresholdCondition constructor should ensure that no invalid
 			// operator gets through, but let's protect our future selves anyhow.
 			name:             "invalid expression operator",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, "&", 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: "&", Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           false,
@@ -168,7 +168,7 @@ func BenchmarkRunNoTaint(b *testing.B) {
 		LastFailed:       false,
 		AbortOnFail:      false,
 		AbortGracePeriod: types.NullDurationFrom(2 * time.Second),
-		parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+		parsed:           &thresholdExpression{AggregationMethod: tokenRate, AggregationValue: null.Float{}, Operator: tokenGreater, Value: 0.01},
 	}
 
 	sinks := map[string]float64{"rate": 1}
@@ -412,6 +412,22 @@ func TestThresholdsValidate(t *testing.T) {
 				},
 				wantErr: false,
 			},
+			{
+				name:       "threshold expression using 'rate1m' is valid against a counter metric",
+				metricName: "test_counter",
+				thresholds: Thresholds{
+					Thresholds: []*Threshold{newThreshold("rate1m<100", false, types.NullDuration{})},
+				},
+				wantErr: false,
+			},
+			{
+				name:       "threshold expression using 'rate1m' is invalid against a rate metric",
+				metricName: "test_rate",
+				thresholds: Thresholds{
+					Thresholds: []*Threshold{newThreshold("rate1m<100", false, types.NullDuration{})},
+				},
+				wantErr: true,
+			},
 			{
 				name:       "threshold expression using 'rate' is valid against a counter single tag submetric",
 				metricName: "test_counter{foo:bar}",
@@ -566,8 +582,8 @@ func TestNewThresholdsWithConfig(t *testing.T) {
 		t.Parallel()
 
 		configs := []thresholdConfig{
-			{`rate<0.01`, false, types.NullDuration{}},
-			{`p(95)<200`, true, types.NullDuration{}},
+			{Threshold: `rate<0.01`, AbortOnFail: false, AbortGracePeriod: types.NullDuration{}},
+			{Threshold: `p(95)<200`, AbortOnFail: true, AbortGracePeriod: types.NullDuration{}},
 		}
 		ts := newThresholdsWithConfig(configs)
 		assert.Len(t, ts.Thresholds, 2)
@@ -749,6 +765,59 @@ func TestThresholdsRun(t *testing.T) {
 	}
 }
 
+func TestThresholdsRunSubmetrics(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	metric, err := registry.NewMetric("my_trend", Trend)
+	require.NoError(t, err)
+	checkout, err := metric.AddSubmetric("group:::checkout")
+	require.NoError(t, err)
+	browse, err := metric.AddSubmetric("group:::browse")
+	require.NoError(t, err)
+	_, err = metric.AddSubmetric("group:::empty")
+	require.NoError(t, err)
+
+	for _, value := range []float64{100, 200, 300} {
+		checkout.Metric.Sink.Add(Sample{Value: value})
+	}
+	for _, value := range []float64{100, 150, 250} {
+		browse.Metric.Sink.Add(Sample{Value: value})
+	}
+
+	tests := map[string]bool{
+		"max of {group:::checkout} < 400":                           true,
+		"max of {group:::checkout} < 250":                           false,
+		"max of {group:::checkout} < 1.5 * max of {group:::browse}": true,
+		"max of {group:::checkout} < 1.1 * max of {group:::browse}": false,
+		"avg of {group:::browse} < avg of {group:::checkout}":       true,
+		"max of {group:::empty} > 1000":                             true,
+		"max of {group:::checkout} > 1000 * max of {group:::empty}": true,
+		"max of {group:::checkout} < 2 * min":                       false,
+	}
+
+	for expression, want := range tests {
+		expression, want := expression, want
+		t.Run(expression, func(t *testing.T) {
+			t.Parallel()
+
+			thresholds := NewThresholds([]string{expression})
+			require.NoError(t, thresholds.Parse())
+			require.NoError(t, thresholds.Validate(metric.Name, registry))
+			for _, tags := range thresholds.SubmetricTags() {
+				sm, err := metric.AddSubmetric(tags)
+				require.NoError(t, err)
+				thresholds.SetSubmetric(tags, sm.Metric)
+			}
+
+			sink := getTrendSink(100, 100)
+			gotOk, gotErr := thresholds.Run(sink, 0)
+			require.NoError(t, gotErr)
+			assert.Equal(t, want, gotOk)
+		})
+	}
+}
+
 func TestThresholdsJSON(t *testing.T) {
 	t.Parallel()
 
@@ -815,6 +884,20 @@ func TestThresholdsJSON(t *testing.T) {
 			types.NullDuration{},
 			`["rate<0.01"]`,
 		},
+		{
+			`[{"threshold":"p(95)<200","ignoreFirst":"10s","window":"30s","consecutiveWindows":2}]`,
+			[]string{"p(95)<200"},
+			false,
+			types.NullDuration{},
+			`[{"threshold":"p(95)<200","abortOnFail":false,"delayAbortEval":null,"ignoreFirst":"10s","window":"30s","consecutiveWindows":2}]`,
+		},
+		{
+			`[{"threshold":"rate<0.01","exitCode":3}]`,
+			[]string{"rate<0.01"},
+			false,
+			types.NullDuration{},
+			`[{"threshold":"rate<0.01","abortOnFail":false,"delayAbortEval":null,"exitCode":3}]`,
+		},
 		{
 			`[{"threshold":"rate<0.01"}, "p(95)<200"]`,
 			[]string{"rate<0.01", "p(95)<200"},
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"time"
+)
+
+// thresholdWindow has the samples a threshold with a warm-up or a window is
+// evaluated over, instead of the sink of its metric. The times are the ones
+// of the samples, so the thresholds are evaluated the same way when the
+// samples are replayed, e.g. by k6 merge.
+type thresholdWindow struct {
+	ignoreFirst        time.Duration
+	window             time.Duration
+	consecutiveWindows int64
+
+	// sink has the samples after the warm-up, if there isn't a window
+	sink Sink
+	// samples are the ones in the window, if there is one
+	samples *SampleWindow
+
+	// failingSince is the time of the latest sample of the first failed
+	// window of the current streak, zero if the last window didn't fail
+	failingSince time.Time
+	breached     bool
+}
+
+func newThresholdWindow(t *Threshold) *thresholdWindow {
+	tw := &thresholdWindow{
+		ignoreFirst:        time.Duration(t.IgnoreFirst.Duration),
+		window:             time.Duration(t.Window.Duration),
+		consecutiveWindows: 1,
+	}
+	if t.ConsecutiveWindows.Valid {
+		tw.consecutiveWindows = t.ConsecutiveWindows.Int64
+	}
+	if tw.window > 0 {
+		tw.samples = NewSampleWindow(tw.window)
+	}
+	return tw
+}
+
+// add adds the sample, if it isn't in the warm-up after the start.
+func (tw *thresholdWindow) add(s Sample, start time.Time) {
+	if s.Time.Sub(start) < tw.ignoreFirst {
+		return
+	}
+	if tw.samples != nil {
+		tw.samples.Add(s)
+		return
+	}
+	if tw.sink == nil {
+		tw.sink = s.Metric.NewSink()
+	}
+	tw.sink.Add(s)
+}
+
+// run evaluates the threshold over its samples, a window that fails for the
+// consecutive windows fails the threshold for the rest of the test.
+func (tw *thresholdWindow) run(t *Threshold, timeSpentInTest time.Duration) (bool, error) {
+	sink, duration := tw.sink, timeSpentInTest-tw.ignoreFirst
+	if duration <= 0 {
+		duration = timeSpentInTest
+	}
+	if tw.window > 0 {
+		sink, duration = tw.samples.Sink(), tw.window
+	}
+	if sink == nil || sink.IsEmpty() {
+		t.LastFailed = tw.breached
+		return !tw.breached, nil
+	}
+	sinked, err := sinkValues(sink, duration, percentiles([]*Threshold{t}, ""))
+	if err != nil {
+		return false, err
+	}
+	passes, err := t.runNoTaint(sinked)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case tw.window == 0:
+		tw.breached = !passes
+	case passes:
+		tw.failingSince = time.Time{}
+	default:
+		latest := tw.samples.Latest()
+		if tw.failingSince.IsZero() {
+			tw.failingSince = latest
+		}
+		// the first failed window covers a whole window of samples
+		if latest.Sub(tw.failingSince) >= time.Duration(tw.consecutiveWindows-1)*tw.window {
+			tw.breached = true
+		}
+	}
+	t.LastFailed = tw.breached
+	return !tw.breached, nil
+}
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+)
+
+func newWindowedTestThresholds(t *testing.T, config string) (*Thresholds, *Metric) {
+	t.Helper()
+
+	registry := NewRegistry()
+	metric, err := registry.NewMetric("my_trend", Trend)
+	require.NoError(t, err)
+
+	var ts Thresholds
+	require.NoError(t, json.Unmarshal([]byte(config), &ts))
+	require.NoError(t, ts.Parse())
+	require.NoError(t, ts.Validate(metric.Name, registry))
+	return &ts, metric
+}
+
+func addWindowedTestSample(ts *Thresholds, metric *Metric, start time.Time, at time.Duration, value float64) {
+	ts.AddSample(Sample{
+		TimeSeries: TimeSeries{Metric: metric},
+		Time:       start.Add(at),
+		Value:      value,
+	}, start)
+}
+
+func TestThresholdsIgnoreFirst(t *testing.T) {
+	t.Parallel()
+
+	ts, metric := newWindowedTestThresholds(t, `[{"threshold":"max<100","ignoreFirst":"10s"}]`)
+	require.True(t, ts.HasWindows())
+
+	start := time.Unix(1000, 0)
+	addWindowedTestSample(ts, metric, start, 0, 500)
+	addWindowedTestSample(ts, metric, start, 9*time.Second, 500)
+
+	// the metric sink isn't used by the thresholds with a warm-up
+	passed, err := ts.Run(NewSink(Trend), 20*time.Second)
+	require.NoError(t, err)
+	assert.True(t, passed)
+
+	addWindowedTestSample(ts, metric, start, 10*time.Second, 50)
+	passed, err = ts.Run(NewSink(Trend), 20*time.Second)
+	require.NoError(t, err)
+	assert.True(t, passed)
+
+	addWindowedTestSample(ts, metric, start, 11*time.Second, 150)
+	passed, err = ts.Run(NewSink(Trend), 20*time.Second)
+	require.NoError(t, err)
+	assert.False(t, passed)
+	assert.True(t, ts.Thresholds[0].LastFailed)
+}
+
+func TestThresholdsWindow(t *testing.T) {
+	t.Parallel()
+
+	t.Run("a failed window fails the threshold", func(t *testing.T) {
+		t.Parallel()
+
+		ts, metric := newWindowedTestThresholds(t, `[{"threshold":"avg<100","window":"10s"}]`)
+		start := time.Unix(1000, 0)
+
+		addWindowedTestSample(ts, metric, start, 1*time.Second, 50)
+		passed, err := ts.Run(NewSink(Trend), 0)
+		require.NoError(t, err)
+		assert.True(t, passed)
+
+		addWindowedTestSample(ts, metric, start, 5*time.Second, 500)
+		passed, err = ts.Run(NewSink(Trend), 0)
+		require.NoError(t, err)
+		assert.False(t, passed)
+
+		// the failed window isn't forgotten when the next ones pass
+		addWindowedTestSample(ts, metric, start, 30*time.Second, 50)
+		passed, err = ts.Run(NewSink(Trend), 0)
+		require.NoError(t, err)
+		assert.False(t, passed)
+	})
+
+	t.Run("the old samples are out of the window", func(t *testing.T) {
+		t.Parallel()
+
+		ts, metric := newWindowedTestThresholds(t, `[{"threshold":"avg<100","window":"10s"}]`)
+		start := time.Unix(1000, 0)
+
+		addWindowedTestSample(ts, metric, start, 1*time.Second, 150)
+		addWindowedTestSample(ts, metric, start, 12*time.Second, 20)
+		passed, err := ts.Run(NewSink(Trend), 0)
+		require.NoError(t, err)
+		assert.True(t, passed)
+	})
+
+	t.Run("consecutive windows", func(t *testing.T) {
+		t.Parallel()
+
+		ts, metric := newWindowedTestThresholds(t,
+			`[{"threshold":"avg<100","window":"10s","consecutiveWindows":2}]`)
+		start := time.Unix(1000, 0)
+
+		// a short spike doesn't fail the threshold
+		addWindowedTestSample(ts, metric, start, 1*time.Second, 500)
+		passed, err := ts.Run(NewSink(Trend), 0)
+		require.NoError(t, err)
+		assert.True(t, passed)
+		assert.False(t, ts.Thresholds[0].LastFailed)
+
+		addWindowedTestSample(ts, metric, start, 12*time.Second, 50)
+		passed, err = ts.Run(NewSink(Trend), 0)
+		require.NoError(t, err)
+		assert.True(t, passed)
+
+		// a sustained violation does
+		for at := 20 * time.Second; at <= 40*time.Second; at += 5 * time.Second {
+			addWindowedTestSample(ts, metric, start, at, 500)
+			passed, err = ts.Run(NewSink(Trend), 0)
+			require.NoError(t, err)
+		}
+		assert.False(t, passed)
+		assert.True(t, ts.Thresholds[0].LastFailed)
+	})
+}
+
+func TestThresholdsValidateWindow(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"negative ignoreFirst":              `[{"threshold":"avg<100","ignoreFirst":"-1s"}]`,
+		"zero window":                       `[{"threshold":"avg<100","window":"0s"}]`,
+		"consecutiveWindows without window": `[{"threshold":"avg<100","consecutiveWindows":2}]`,
+		"consecutiveWindows lower than one": `[{"threshold":"avg<100","window":"10s","consecutiveWindows":0}]`,
+		"window with sub-metrics":           `[{"threshold":"avg of {group:::a}<100","window":"10s"}]`,
+	}
+
+	for name, config := range testCases {
+		config := config
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := NewRegistry()
+			_, err := registry.NewMetric("my_trend", Trend)
+			require.NoError(t, err)
+
+			var ts Thresholds
+			require.NoError(t, json.Unmarshal([]byte(config), &ts))
+			require.NoError(t, ts.Parse())
+
+			err = ts.Validate("my_trend", registry)
+			assert.ErrorIs(t, err, ErrInvalidThreshold)
+			var wantErr errext.HasExitCode
+			require.ErrorAs(t, err, &wantErr)
+			assert.Equal(t, exitcodes.InvalidConfig, wantErr.ExitCode())
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import "time"
+
+// SampleWindow has the samples of a metric in a sliding window of time, up to
+// the time of the latest one, so they can be aggregated without the older ones.
+type SampleWindow struct {
+	length  time.Duration
+	samples []Sample
+	latest  time.Time
+}
+
+// NewSampleWindow returns a SampleWindow of the length.
+func NewSampleWindow(length time.Duration) *SampleWindow {
+	return &SampleWindow{length: length}
+}
+
+// Add adds the sample to the window.
+func (w *SampleWindow) Add(s Sample) {
+	if s.Time.After(w.latest) {
+		w.latest = s.Time
+	}
+	// the old samples are dropped before growing, so they don't pile up
+	// between the reads of the sink
+	if len(w.samples) == cap(w.samples) {
+		w.prune()
+	}
+	w.samples = append(w.samples, s)
+}
+
+// Length returns the length of the window.
+func (w *SampleWindow) Length() time.Duration {
+	return w.length
+}
+
+// Latest returns the time of the latest sample, zero if there aren't any.
+func (w *SampleWindow) Latest() time.Time {
+	return w.latest
+}
+
+// Sink returns a new sink with the samples in the window, or nil if there
+// aren't any.
+func (w *SampleWindow) Sink() Sink {
+	w.prune()
+	if len(w.samples) == 0 {
+		return nil
+	}
+
+	sink := w.samples[0].Metric.NewSink()
+	for _, s := range w.samples {
+		sink.Add(s)
+	}
+	return sink
+}
+
+// prune drops the samples before the window.
+func (w *SampleWindow) prune() {
+	start := w.latest.Add(-w.length)
+	kept := w.samples[:0]
+	for _, s := range w.samples {
+		if s.Time.After(start) {
+			kept = append(kept, s)
+		}
+	}
+	w.samples = kept
+}
@@ -0,0 +1,223 @@
+package output
+
+import (
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// AggregationTag is the tag of the aggregated samples with the name of their
+// value, e.g. sum for the counters or p(95) for the trends.
+const AggregationTag = "aggregation"
+
+// WithAggregation returns an output that receives the aggregated values of the
+// samples of each metric and dimension once per period, instead of every
+// sample. The samples of the raw metrics are passed to the output as they are.
+//
+// The aggregated values of the counters are their sum, of the gauges their
+// last, min and max values, of the rates their rate and count, and of the
+// trends their count and the configured trend stats. Each of them is sent as a
+// sample of the metric with the aggregation tag, at the start of its period.
+// The values of a period are sent once it has ended for the wait period, so
+// they have all of its samples, and the values of the open periods are sent
+// when the output is stopped. The samples that arrive after the values of their
+// period were sent are aggregated again, in new values of the same period.
+func WithAggregation(out Output, aggregation lib.OutputAggregation) (Output, error) {
+	trendStats := aggregation.GetTrendStats()
+	resolvers, err := metrics.GetResolversForTrendColumns(trendStats)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := resolvers["count"]; !ok {
+		trendStats = append([]string{"count"}, trendStats...)
+		resolvers["count"] = func(s *metrics.TrendSink) float64 { return float64(s.Count()) }
+	}
+
+	return &aggregatedOutput{
+		outputWrapper:  outputWrapper{Output: out},
+		aggregation:    aggregation,
+		trendStats:     trendStats,
+		trendResolvers: resolvers,
+		sinks:          make(map[aggregationKey]metrics.Sink),
+		emptyTags:      metrics.NewRegistry().RootTagSet(),
+		now:            time.Now,
+	}, nil
+}
+
+type aggregatedOutput struct {
+	outputWrapper
+
+	aggregation     lib.OutputAggregation
+	trendStats      []string
+	trendResolvers  map[string]func(s *metrics.TrendSink) float64
+	buffer          SampleBuffer
+	periodicFlusher *PeriodicFlusher
+	now             func() time.Time
+	// emptyTags are the tags of the samples without tags, so their aggregated
+	// values can have the aggregation tag
+	emptyTags *metrics.TagSet
+
+	// sinks are the aggregated values of the periods that weren't sent yet,
+	// and keys their keys in the order of their first sample.
+	sinks map[aggregationKey]metrics.Sink
+	keys  []aggregationKey
+}
+
+// aggregationKey identifies the aggregated values of a time series in a
+// period.
+type aggregationKey struct {
+	period int64
+	series metrics.TimeSeries
+}
+
+// Start starts the output, then the periodic aggregation of the samples.
+func (ao *aggregatedOutput) Start() error {
+	if err := ao.Output.Start(); err != nil {
+		return err
+	}
+	pf, err := NewPeriodicFlusher(ao.aggregation.GetPeriod(), func() { ao.flush(false) })
+	if err != nil {
+		return err
+	}
+	ao.periodicFlusher = pf
+	return nil
+}
+
+// Stop sends the last aggregated values to the output, then it stops it.
+func (ao *aggregatedOutput) Stop() error {
+	ao.periodicFlusher.Stop()
+	ao.flush(true)
+	return ao.Output.Stop()
+}
+
+// StopWithTestError is like Stop, with the test error.
+func (ao *aggregatedOutput) StopWithTestError(testRunErr error) error {
+	ao.periodicFlusher.Stop()
+	ao.flush(true)
+	return ao.outputWrapper.StopWithTestError(testRunErr)
+}
+
+// AddMetricSamples buffers the samples to aggregate, and passes the samples of
+// the raw metrics to the output.
+func (ao *aggregatedOutput) AddMetricSamples(containers []metrics.SampleContainer) {
+	var raw, aggregated []metrics.SampleContainer
+	for _, sc := range containers {
+		samples := sc.GetSamples()
+		var rawSamples, aggregatedSamples metrics.Samples
+		for _, s := range samples {
+			if ao.aggregation.AggregatesMetric(s.Metric.Name) {
+				aggregatedSamples = append(aggregatedSamples, s)
+			} else {
+				rawSamples = append(rawSamples, s)
+			}
+		}
+		switch {
+		case len(rawSamples) == len(samples):
+			raw = append(raw, sc)
+		case len(aggregatedSamples) == len(samples):
+			aggregated = append(aggregated, sc)
+		default:
+			raw = append(raw, rawSamples)
+			aggregated = append(aggregated, aggregatedSamples)
+		}
+	}
+
+	ao.buffer.AddMetricSamples(aggregated)
+	if len(raw) > 0 {
+		ao.Output.AddMetricSamples(raw)
+	}
+}
+
+// flush aggregates the buffered samples and sends the aggregated values of the
+// closed periods to the output, or of all of them if all is true. The values of
+// the open periods are kept for the next flush.
+func (ao *aggregatedOutput) flush(all bool) {
+	period := ao.aggregation.GetPeriod()
+	dimensions := make(map[*metrics.TagSet]*metrics.TagSet)
+	for _, sc := range ao.buffer.GetBufferedSamples() {
+		for _, s := range sc.GetSamples() {
+			tags, ok := dimensions[s.Tags]
+			if !ok {
+				tags = ao.dimensionTags(s.Tags)
+				dimensions[s.Tags] = tags
+			}
+			key := aggregationKey{
+				period: s.Time.Truncate(period).UnixNano(),
+				series: metrics.TimeSeries{Metric: s.Metric, Tags: tags},
+			}
+			sink, ok := ao.sinks[key]
+			if !ok {
+				sink = s.Metric.NewSink()
+				ao.sinks[key] = sink
+				ao.keys = append(ao.keys, key)
+			}
+			sink.Add(s)
+		}
+	}
+
+	// the periods that ended before this are closed
+	closed := ao.now().Add(-ao.aggregation.GetWaitPeriod()).Add(-period).UnixNano()
+	var samples metrics.Samples
+	open := ao.keys[:0]
+	for _, key := range ao.keys {
+		if !all && key.period > closed {
+			open = append(open, key)
+			continue
+		}
+		samples = ao.appendAggregatedSamples(samples, key, ao.sinks[key])
+		delete(ao.sinks, key)
+	}
+	for i := len(open); i < len(ao.keys); i++ {
+		ao.keys[i] = aggregationKey{} // don't keep the metrics and tags of the sent periods
+	}
+	ao.keys = open
+	if len(samples) > 0 {
+		ao.Output.AddMetricSamples([]metrics.SampleContainer{samples})
+	}
+}
+
+// dimensionTags returns the tag set with only the dimensions of the
+// aggregation.
+func (ao *aggregatedOutput) dimensionTags(tags *metrics.TagSet) *metrics.TagSet {
+	if tags == nil {
+		return ao.emptyTags
+	}
+	for key := range tags.Map() {
+		if !ao.aggregation.KeepsTag(key) {
+			tags = tags.Without(key)
+		}
+	}
+	return tags
+}
+
+func (ao *aggregatedOutput) appendAggregatedSamples(
+	samples metrics.Samples, key aggregationKey, sink metrics.Sink,
+) metrics.Samples {
+	add := func(name string, value float64) {
+		series := key.series
+		series.Tags = series.Tags.With(AggregationTag, name)
+		samples = append(samples, metrics.Sample{
+			TimeSeries: series,
+			Time:       time.Unix(0, key.period),
+			Value:      value,
+		})
+	}
+
+	switch sink := sink.(type) {
+	case *metrics.CounterSink:
+		add("sum", sink.Value)
+	case *metrics.GaugeSink:
+		add("last", sink.Value)
+		add("min", sink.Min)
+		add("max", sink.Max)
+	case *metrics.RateSink:
+		add("rate", float64(sink.Trues)/float64(sink.Total))
+		add("count", float64(sink.Total))
+	case *metrics.TrendSink:
+		for _, stat := range ao.trendStats {
+			add(stat, ao.trendResolvers[stat](sink))
+		}
+	}
+	return samples
+}
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func TestWithAggregation(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("my_counter", metrics.Counter)
+	gauge := registry.MustNewMetric("my_gauge", metrics.Gauge)
+	rate := registry.MustNewMetric("my_rate", metrics.Rate)
+	trend := registry.MustNewMetric("my_trend", metrics.Trend)
+	raw := registry.MustNewMetric("my_raw", metrics.Counter)
+	root := registry.RootTagSet()
+	sample := func(m *metrics.Metric, url string, sec int64, value float64) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: root.With("status", "200").With("url", url)},
+			Time:       time.Unix(sec, 0),
+			Value:      value,
+		}
+	}
+
+	inner := &stoppableOutput{}
+	out, err := WithAggregation(inner, lib.OutputAggregation{
+		Period:     types.NullDurationFrom(10 * time.Second),
+		Tags:       []string{"status"},
+		RawMetrics: []string{"my_raw"},
+		TrendStats: []string{"min", "max"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, out.Start())
+
+	rawSample := sample(raw, "/1", 1, 1)
+	out.AddMetricSamples([]metrics.SampleContainer{
+		metrics.Samples{sample(counter, "/1", 1, 2), rawSample, sample(counter, "/2", 2, 3)},
+		sample(gauge, "/1", 1, 5),
+		sample(gauge, "/1", 2, 4),
+		sample(rate, "/1", 1, 1),
+		sample(rate, "/1", 2, 0),
+		sample(trend, "/1", 1, 10),
+		sample(trend, "/2", 2, 30),
+		sample(counter, "/1", 11, 4),
+	})
+	assert.Equal(t, []metrics.SampleContainer{metrics.Samples{rawSample}}, inner.GetBufferedSamples())
+
+	require.NoError(t, out.(WithStopWithTestError).StopWithTestError(errors.New("test error"))) //nolint:forcetypeassert
+	containers := inner.GetBufferedSamples()
+	require.Len(t, containers, 1)
+
+	type value struct {
+		metric, aggregation string
+		time                int64
+		value               float64
+	}
+	var values []value
+	for _, s := range containers[0].GetSamples() {
+		assert.Equal(t, "200", s.Tags.Map()["status"])
+		assert.Len(t, s.Tags.Map(), 2)
+		values = append(values, value{s.Metric.Name, s.Tags.Map()[AggregationTag], s.Time.Unix(), s.Value})
+	}
+	assert.Equal(t, []value{
+		{"my_counter", "sum", 0, 5},
+		{"my_gauge", "last", 0, 4},
+		{"my_gauge", "min", 0, 4},
+		{"my_gauge", "max", 0, 5},
+		{"my_rate", "rate", 0, 0.5},
+		{"my_rate", "count", 0, 2},
+		{"my_trend", "count", 0, 2},
+		{"my_trend", "min", 0, 10},
+		{"my_trend", "max", 0, 30},
+		{"my_counter", "sum", 10, 4},
+	}, values)
+	assert.EqualError(t, inner.testErr, "test error")
+}
+
+func TestWithAggregationClosedPeriods(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("my_counter", metrics.Counter)
+	sample := func(sec int64, value float64) metrics.Sample {
+		// the samples don't have tags
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: counter}, Time: time.Unix(sec, 0), Value: value}
+	}
+
+	inner := &stoppableOutput{}
+	out, err := WithAggregation(inner, lib.OutputAggregation{
+		Period:     types.NullDurationFrom(10 * time.Second),
+		WaitPeriod: types.NullDurationFrom(2 * time.Second),
+	})
+	require.NoError(t, err)
+	ao := out.(*aggregatedOutput) //nolint:forcetypeassert
+	now := time.Unix(11, 0)
+	ao.now = func() time.Time { return now }
+
+	type value struct {
+		aggregation string
+		time        int64
+		value       float64
+	}
+	flushed := func(all bool) []value {
+		ao.flush(all)
+		var values []value
+		for _, sc := range inner.GetBufferedSamples() {
+			for _, s := range sc.GetSamples() {
+				values = append(values, value{s.Tags.Map()[AggregationTag], s.Time.Unix(), s.Value})
+			}
+		}
+		return values
+	}
+
+	// the first period isn't sent until its samples were waited for
+	ao.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{sample(1, 1), sample(9, 2), sample(10, 4)}})
+	assert.Empty(t, flushed(false))
+
+	// the late samples are still aggregated with their period
+	ao.AddMetricSamples([]metrics.SampleContainer{sample(8, 8)})
+	now = time.Unix(12, 0)
+	assert.Equal(t, []value{{"sum", 0, 11}}, flushed(false))
+
+	// the open period is sent when the output is stopped
+	ao.AddMetricSamples([]metrics.SampleContainer{sample(15, 16)})
+	assert.Empty(t, flushed(false))
+	assert.Equal(t, []value{{"sum", 10, 20}}, flushed(true))
+	assert.Empty(t, ao.sinks)
+	assert.Empty(t, ao.keys)
+}
@@ -0,0 +1,144 @@
+package output
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+)
+
+// CollapsedTagValue is the value of the collapsed tags of the time series of
+// the metrics over the cardinality limit.
+const CollapsedTagValue = "[collapsed]"
+
+// CardinalityGuard tracks the number of distinct tag sets, i.e. time series,
+// of every metric. When a metric goes over the limit, it warns and, if there
+// are tags to collapse, the tags of its new time series are collapsed: the url
+// tag is replaced by the name tag, if it's different, and the other tags by
+// CollapsedTagValue. This prevents the unbounded growth of the time series,
+// e.g. of the requests to dynamic URLs, in the outputs and in their backends.
+//
+// It isn't thread-safe, the Manager uses it from a single goroutine.
+type CardinalityGuard struct {
+	limit        int
+	collapseTags []string
+	logger       logrus.FieldLogger
+
+	mx         sync.Mutex
+	timeSeries map[*metrics.Metric]map[*metrics.TagSet]struct{}
+	overLimit  map[*metrics.Metric]bool
+}
+
+// NewCardinalityGuard returns a new guard with the given limit of time series
+// per metric and tags to collapse.
+func NewCardinalityGuard(limit int, collapseTags []string, logger logrus.FieldLogger) *CardinalityGuard {
+	return &CardinalityGuard{
+		limit:        limit,
+		collapseTags: collapseTags,
+		logger:       logger.WithField("component", "cardinality-guard"),
+		timeSeries:   make(map[*metrics.Metric]map[*metrics.TagSet]struct{}),
+		overLimit:    make(map[*metrics.Metric]bool),
+	}
+}
+
+// Cardinality returns the number of distinct time series of the metric seen so
+// far, up to the limit, the time series over it aren't tracked.
+func (g *CardinalityGuard) Cardinality(m *metrics.Metric) int {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	return len(g.timeSeries[m])
+}
+
+// Guard tracks the time series of the samples, and returns them with the
+// collapsed tags of the new time series over the limit. The containers with
+// collapsed tags are replaced by new ones. It's safe for concurrent use.
+func (g *CardinalityGuard) Guard(containers []metrics.SampleContainer) []metrics.SampleContainer {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	for i, sc := range containers {
+		samples := sc.GetSamples()
+		var guarded []metrics.Sample
+		for j, s := range samples {
+			tags := g.guardSample(s)
+			if tags == s.Tags {
+				if guarded != nil {
+					guarded = append(guarded, s)
+				}
+				continue
+			}
+			if guarded == nil {
+				guarded = append(make([]metrics.Sample, 0, len(samples)), samples[:j]...)
+			}
+			s.Tags = tags
+			guarded = append(guarded, s)
+		}
+		if guarded == nil {
+			continue
+		}
+		if cs, ok := sc.(metrics.ConnectedSamples); ok {
+			cs.Samples = guarded
+			cs.Tags = g.collapse(cs.Tags)
+			containers[i] = cs
+		} else {
+			containers[i] = metrics.Samples(guarded)
+		}
+	}
+	return containers
+}
+
+// guardSample returns the tags of the sample, collapsed if they are of a new
+// time series of a metric over the limit.
+func (g *CardinalityGuard) guardSample(s metrics.Sample) *metrics.TagSet {
+	seen, ok := g.timeSeries[s.Metric]
+	if !ok {
+		seen = make(map[*metrics.TagSet]struct{})
+		g.timeSeries[s.Metric] = seen
+	}
+	if _, ok = seen[s.Tags]; ok {
+		return s.Tags
+	}
+
+	if len(seen) < g.limit {
+		seen[s.Tags] = struct{}{}
+		return s.Tags
+	}
+	if !g.overLimit[s.Metric] {
+		g.overLimit[s.Metric] = true
+		g.warn(s.Metric)
+	}
+	return g.collapse(s.Tags)
+}
+
+func (g *CardinalityGuard) warn(m *metrics.Metric) {
+	logger := g.logger.WithFields(logrus.Fields{"metric": m.Name, "limit": g.limit})
+	if len(g.collapseTags) == 0 {
+		logger.Warnf("The metric '%s' has more than %d time series (distinct tag sets), which could lead to a high "+
+			"memory usage and costs of the outputs; consider grouping the URLs by name or setting the tags "+
+			"to collapse with the cardinalityCollapseTags option", m.Name, g.limit)
+		return
+	}
+	logger.Warnf("The metric '%s' has more than %d time series (distinct tag sets), the %s tags of its "+
+		"new time series will be collapsed", m.Name, g.limit, strings.Join(g.collapseTags, ", "))
+}
+
+// collapse returns the tag set with the collapsed tags.
+func (g *CardinalityGuard) collapse(tags *metrics.TagSet) *metrics.TagSet {
+	if tags == nil {
+		return nil
+	}
+	collapsed := tags
+	for _, key := range g.collapseTags {
+		value, ok := tags.Get(key)
+		if !ok {
+			continue
+		}
+		newValue := CollapsedTagValue
+		if name, hasName := tags.Get(metrics.TagName.String()); key == metrics.TagURL.String() && hasName && name != value {
+			newValue = name
+		}
+		collapsed = collapsed.With(key, newValue)
+	}
+	return collapsed
+}
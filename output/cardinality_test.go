@@ -0,0 +1,95 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+func TestCardinalityGuard(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+	sample := func(m *metrics.Metric, url, name string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: m,
+				Tags:   registry.RootTagSet().WithTagsFromMap(map[string]string{"url": url, "name": name, "status": "200"}),
+			},
+			Time:  time.Now(),
+			Value: 1,
+		}
+	}
+	newGuard := func(collapseTags []string) (*CardinalityGuard, *testutils.SimpleLogrusHook) {
+		hook := testutils.NewLogHook(logrus.WarnLevel)
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		logger.AddHook(hook)
+		return NewCardinalityGuard(2, collapseTags, logger), hook
+	}
+
+	t.Run("WarnOnly", func(t *testing.T) {
+		t.Parallel()
+		guard, hook := newGuard(nil)
+		containers := []metrics.SampleContainer{
+			sample(reqs, "/1", "/1"), sample(reqs, "/2", "/2"), sample(reqs, "/3", "/3"), sample(reqs, "/1", "/1"),
+			sample(vus, "", ""),
+		}
+		expected := append([]metrics.SampleContainer{}, containers...)
+		assert.Equal(t, expected, guard.Guard(containers))
+		assert.Equal(t, 2, guard.Cardinality(reqs))
+		assert.Equal(t, 1, guard.Cardinality(vus))
+
+		guard.Guard([]metrics.SampleContainer{sample(reqs, "/4", "/4")})
+		entries := hook.Drain()
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Message, "The metric 'http_reqs' has more than 2 time series")
+	})
+
+	t.Run("Collapse", func(t *testing.T) {
+		t.Parallel()
+		guard, hook := newGuard([]string{"url", "name"})
+		var containers []metrics.SampleContainer
+		for i := 0; i < 4; i++ {
+			url := fmt.Sprintf("/posts/%d", i)
+			containers = append(containers, metrics.Samples{sample(reqs, url, url)})
+		}
+		connected := metrics.ConnectedSamples{
+			Samples: []metrics.Sample{sample(reqs, "/posts/5", "/posts/${id}")},
+			Tags:    sample(reqs, "/posts/5", "/posts/${id}").Tags,
+		}
+		containers = append(containers, connected)
+		guarded := guard.Guard(containers)
+
+		var tags []map[string]string
+		for _, sc := range guarded {
+			for _, s := range sc.GetSamples() {
+				tags = append(tags, s.Tags.Map())
+			}
+		}
+		assert.Equal(t, []map[string]string{
+			{"url": "/posts/0", "name": "/posts/0", "status": "200"},
+			{"url": "/posts/1", "name": "/posts/1", "status": "200"},
+			{"url": CollapsedTagValue, "name": CollapsedTagValue, "status": "200"},
+			{"url": CollapsedTagValue, "name": CollapsedTagValue, "status": "200"},
+			{"url": "/posts/${id}", "name": CollapsedTagValue, "status": "200"},
+		}, tags)
+		cs, ok := guarded[4].(metrics.ConnectedSamples)
+		require.True(t, ok)
+		assert.Equal(t, cs.Samples[0].Tags, cs.Tags)
+
+		entries := hook.Drain()
+		require.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Message, "the url, name tags of its new time series will be collapsed")
+	})
+}
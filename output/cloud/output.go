@@ -304,7 +304,7 @@ func (out *Output) getRunStatus(testErr error) cloudapi.RunStatus {
 			return cloudapi.RunStatusAbortedScriptError
 		case errext.AbortedByScriptAbort:
 			return cloudapi.RunStatusAbortedUser // TODO: have a better value than this?
-		case errext.AbortedByTimeout:
+		case errext.AbortedByTimeout, errext.AbortedByMemoryLimit:
 			return cloudapi.RunStatusAbortedLimit
 		case errext.AbortedByOutput:
 			return cloudapi.RunStatusAbortedSystem
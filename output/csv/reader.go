@@ -0,0 +1,118 @@
+package csv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// ReadResults reads the samples written by the CSV output and passes every one
+// of them to the callback. Since the CSV results don't contain the types of
+// the metrics, only the samples of the metrics already registered in the
+// registry, e.g. the built-in ones, can be read. The names of the other
+// metrics, whose samples were skipped, are returned.
+func ReadResults(r io.Reader, registry *metrics.Registry, callback func(metrics.Sample)) ([]string, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the header: %w", err)
+	}
+	if len(header) < 5 || header[0] != "metric_name" || header[1] != "timestamp" || header[2] != "metric_value" ||
+		header[len(header)-2] != "extra_tags" || header[len(header)-1] != "metadata" {
+		return nil, fmt.Errorf("invalid header %q", strings.Join(header, ","))
+	}
+	tagColumns := header[3 : len(header)-2]
+
+	unknown := make(map[string]struct{})
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid row on line %d: %w", line, err)
+		}
+
+		metric := registry.Get(row[0])
+		if metric == nil {
+			unknown[row[0]] = struct{}{}
+			continue
+		}
+		t, err := parseTimestamp(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp on line %d: %w", line, err)
+		}
+		value, err := strconv.ParseFloat(row[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value on line %d: %w", line, err)
+		}
+
+		tags := parseExtraTags(row[len(row)-2])
+		for i, tag := range tagColumns {
+			if row[i+3] != "" {
+				tags[tag] = row[i+3]
+			}
+		}
+		var metadata map[string]string
+		if row[len(row)-1] != "" {
+			metadata = parseExtraTags(row[len(row)-1])
+		}
+
+		callback(metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: metric,
+				Tags:   registry.RootTagSet().WithTagsFromMap(tags),
+			},
+			Time:     t,
+			Value:    value,
+			Metadata: metadata,
+		})
+	}
+
+	names := make([]string, 0, len(unknown))
+	for name := range unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// parseTimestamp parses the timestamps in any of the supported time formats.
+// The precision of the Unix timestamps is deduced from their length.
+func parseTimestamp(s string) (time.Time, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	switch {
+	case len(s) <= 10:
+		return time.Unix(n, 0), nil
+	case len(s) <= 13:
+		return time.UnixMilli(n), nil
+	case len(s) <= 16:
+		return time.UnixMicro(n), nil
+	default:
+		return time.Unix(0, n), nil
+	}
+}
+
+// parseExtraTags parses the tags in the `key=value&key=value` format of the
+// extra_tags and metadata columns.
+func parseExtraTags(s string) map[string]string {
+	tags := make(map[string]string)
+	if s == "" {
+		return tags
+	}
+	for _, pair := range strings.Split(s, "&") {
+		key, value, _ := strings.Cut(pair, "=")
+		tags[key] = value
+	}
+	return tags
+}
@@ -0,0 +1,68 @@
+package csv
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestReadResults(t *testing.T) {
+	t.Parallel()
+
+	input := "metric_name,timestamp,metric_value,check,error,extra_tags,metadata\n" +
+		"my_metric,1562324643,1.000000,val1,val3,url=val2,\n" +
+		"my_metric,1562324644123,2.500000,,,tag4=val4&vu=1,trace_id=abc\n" +
+		"unknown_metric,1562324645,1.000000,,,,\n" +
+		"my_metric,2019-07-05T11:04:05.5Z,3.000000,val1,,,\n"
+
+	registry := metrics.NewRegistry()
+	metric := registry.MustNewMetric("my_metric", metrics.Gauge)
+	var read []metrics.Sample
+	unknown, err := ReadResults(strings.NewReader(input), registry, func(s metrics.Sample) { read = append(read, s) })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unknown_metric"}, unknown)
+
+	require.Len(t, read, 3)
+	for _, s := range read {
+		assert.Equal(t, metric, s.Metric)
+	}
+	assert.Equal(t, map[string]string{"check": "val1", "error": "val3", "url": "val2"}, read[0].Tags.Map())
+	assert.True(t, time.Unix(1562324643, 0).Equal(read[0].Time))
+	assert.Nil(t, read[0].Metadata)
+	assert.Equal(t, float64(1), read[0].Value)
+
+	assert.Equal(t, map[string]string{"tag4": "val4", "vu": "1"}, read[1].Tags.Map())
+	assert.True(t, time.UnixMilli(1562324644123).Equal(read[1].Time))
+	assert.Equal(t, map[string]string{"trace_id": "abc"}, read[1].Metadata)
+	assert.Equal(t, 2.5, read[1].Value)
+
+	assert.True(t, time.Date(2019, time.July, 5, 11, 4, 5, 5e8, time.UTC).Equal(read[2].Time))
+}
+
+func TestReadResultsInvalid(t *testing.T) {
+	t.Parallel()
+
+	header := "metric_name,timestamp,metric_value,extra_tags,metadata\n"
+	testCases := map[string]string{
+		"no header":         "",
+		"invalid header":    "foo,bar\n",
+		"invalid timestamp": header + "my_metric,yesterday,1,,\n",
+		"invalid value":     header + "my_metric,1562324643,one,,\n",
+		"invalid row":       header + "my_metric,1562324643\n",
+	}
+	for name, input := range testCases {
+		input := input
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			registry := metrics.NewRegistry()
+			registry.MustNewMetric("my_metric", metrics.Gauge)
+			_, err := ReadResults(strings.NewReader(input), registry, func(metrics.Sample) {})
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// document is a document to index, with its already encoded source.
+type document struct {
+	index  string
+	source []byte
+}
+
+// bulkResponse is the part of the response of the bulk API the client checks.
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulkClient indexes documents with the bulk API, retrying them with an
+// exponential backoff when the cluster is overloaded.
+type bulkClient struct {
+	httpClient *http.Client
+	url        string
+	config     config
+	logger     logrus.FieldLogger
+}
+
+func newBulkClient(conf config, logger logrus.FieldLogger) *bulkClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	if conf.InsecureSkipTLSVerify.Bool {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+	return &bulkClient{
+		httpClient: &http.Client{Transport: transport, Timeout: conf.Timeout.TimeDuration()},
+		url:        strings.TrimSuffix(conf.URL.String, "/") + "/_bulk",
+		config:     conf,
+		logger:     logger,
+	}
+}
+
+// index indexes the documents. The documents rejected with 429 Too Many
+// Requests, or all of them if the whole request was, are retried after the
+// backoff, up to the configured number of times.
+func (c *bulkClient) index(ctx context.Context, docs []document) error {
+	backoff := c.config.RetryBackoff.TimeDuration()
+	for retries := int64(0); ; retries++ {
+		retry, wait, err := c.send(ctx, docs)
+		if err != nil {
+			return err
+		}
+		if len(retry) == 0 {
+			return nil
+		}
+		if retries >= c.config.MaxRetries.Int64 {
+			return fmt.Errorf("%d documents weren't indexed after %d retries, the cluster is overloaded",
+				len(retry), retries)
+		}
+
+		if wait <= 0 {
+			wait = backoff
+		}
+		backoff *= 2
+		c.logger.WithFields(logrus.Fields{
+			"documents": len(retry),
+			"wait":      wait,
+		}).Debug("The cluster is overloaded, retrying")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		docs = retry
+	}
+}
+
+// send sends a bulk request and returns the documents to retry, and how long
+// to wait before, if the cluster asked for it.
+func (c *bulkClient) send(ctx context.Context, docs []document) ([]document, time.Duration, error) {
+	var body bytes.Buffer
+	for _, d := range docs {
+		body.WriteString(`{"create":{"_index":`)
+		body.WriteString(strconv.Quote(d.index))
+		body.WriteString("}}\n")
+		body.Write(d.source)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, &body)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", "k6-elasticsearch-output")
+	switch {
+	case c.config.APIKey.String != "":
+		req.Header.Set("Authorization", "ApiKey "+c.config.APIKey.String)
+	case c.config.Username.String != "":
+		req.SetBasicAuth(c.config.Username.String, c.config.Password.String)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return docs, retryAfter(resp.Header.Get("Retry-After")), nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, 0, fmt.Errorf("the bulk request failed with status %d: %s", resp.StatusCode, msg)
+	}
+
+	var result bulkResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("couldn't decode the bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil, 0, nil
+	}
+	if len(result.Items) != len(docs) {
+		return nil, 0, fmt.Errorf("the bulk response has %d items instead of %d", len(result.Items), len(docs))
+	}
+
+	var (
+		retry  []document
+		failed int
+		reason string
+	)
+	for i, item := range result.Items {
+		for _, r := range item {
+			switch {
+			case r.Status == http.StatusTooManyRequests:
+				retry = append(retry, docs[i])
+			case r.Status < 200 || r.Status > 299:
+				failed++
+				reason = r.Error.Type + ": " + r.Error.Reason
+			}
+		}
+	}
+	if failed > 0 {
+		c.logger.WithField("reason", reason).Warnf("%d documents were rejected", failed)
+	}
+	return retry, 0, nil
+}
+
+// retryAfter returns the wait of the Retry-After header in seconds, or 0 if
+// it isn't set or isn't a number of seconds.
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
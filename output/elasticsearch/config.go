@@ -0,0 +1,194 @@
+// Package elasticsearch implements an output that bulk-indexes the metric
+// samples, and the results of the checks, into Elasticsearch or OpenSearch.
+package elasticsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+const defaultURL = "http://localhost:9200"
+
+// config is the configuration of the Elasticsearch output.
+type config struct {
+	// URL is the address of the cluster, the credentials of the basic
+	// authentication can be set in its user info.
+	URL      null.String `json:"url,omitempty" envconfig:"K6_ELASTICSEARCH_URL"`
+	Username null.String `json:"username,omitempty" envconfig:"K6_ELASTICSEARCH_USERNAME"`
+	Password null.String `json:"password,omitempty" envconfig:"K6_ELASTICSEARCH_PASSWORD"`
+	// APIKey is the base64-encoded API key, it's used instead of the basic
+	// authentication if it's set.
+	APIKey                null.String `json:"apiKey,omitempty" envconfig:"K6_ELASTICSEARCH_API_KEY"`
+	InsecureSkipTLSVerify null.Bool   `json:"insecureSkipTLSVerify,omitempty" envconfig:"K6_ELASTICSEARCH_INSECURE_SKIP_TLS_VERIFY"`
+
+	// IndexPrefix is the prefix of the indices, the samples are indexed in
+	// <prefix>-metrics and the checks in <prefix>-checks.
+	IndexPrefix null.String `json:"indexPrefix,omitempty" envconfig:"K6_ELASTICSEARCH_INDEX_PREFIX"`
+	// IndexDateFormat is the Go layout of the date appended to the names of
+	// the indices, e.g. k6-metrics-2006.01.02. If it's empty in the JSON config,
+	// the documents are indexed in k6-metrics and k6-checks, that can be the
+	// aliases or the data streams managed by an ILM policy.
+	IndexDateFormat null.String `json:"indexDateFormat,omitempty" envconfig:"K6_ELASTICSEARCH_INDEX_DATE_FORMAT"`
+
+	// MaxRetries is how many times a bulk request, or its documents, are
+	// retried when the cluster answers with 429 Too Many Requests.
+	MaxRetries null.Int `json:"maxRetries,omitempty" envconfig:"K6_ELASTICSEARCH_MAX_RETRIES"`
+	// RetryBackoff is the wait before the first retry, it's doubled on each
+	// following one, unless the cluster sets the Retry-After header.
+	RetryBackoff types.NullDuration `json:"retryBackoff,omitempty" envconfig:"K6_ELASTICSEARCH_RETRY_BACKOFF"`
+
+	PushInterval types.NullDuration `json:"pushInterval,omitempty" envconfig:"K6_ELASTICSEARCH_PUSH_INTERVAL"`
+	Timeout      types.NullDuration `json:"timeout,omitempty" envconfig:"K6_ELASTICSEARCH_TIMEOUT"`
+}
+
+// newConfig creates a new config instance with the default values.
+func newConfig() config {
+	return config{
+		URL:                   null.NewString(defaultURL, false),
+		InsecureSkipTLSVerify: null.NewBool(false, false),
+		IndexPrefix:           null.NewString("k6", false),
+		IndexDateFormat:       null.NewString("2006.01.02", false),
+		MaxRetries:            null.NewInt(5, false),
+		RetryBackoff:          types.NewNullDuration(500*time.Millisecond, false),
+		PushInterval:          types.NewNullDuration(time.Second, false),
+		Timeout:               types.NewNullDuration(10*time.Second, false),
+	}
+}
+
+// Apply saves the non-zero config values from the passed config in the receiver.
+func (c config) Apply(cfg config) config {
+	if cfg.URL.Valid {
+		c.URL = cfg.URL
+	}
+	if cfg.Username.Valid {
+		c.Username = cfg.Username
+	}
+	if cfg.Password.Valid {
+		c.Password = cfg.Password
+	}
+	if cfg.APIKey.Valid {
+		c.APIKey = cfg.APIKey
+	}
+	if cfg.InsecureSkipTLSVerify.Valid {
+		c.InsecureSkipTLSVerify = cfg.InsecureSkipTLSVerify
+	}
+	if cfg.IndexPrefix.Valid {
+		c.IndexPrefix = cfg.IndexPrefix
+	}
+	if cfg.IndexDateFormat.Valid {
+		c.IndexDateFormat = cfg.IndexDateFormat
+	}
+	if cfg.MaxRetries.Valid {
+		c.MaxRetries = cfg.MaxRetries
+	}
+	if cfg.RetryBackoff.Valid {
+		c.RetryBackoff = cfg.RetryBackoff
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+	if cfg.Timeout.Valid {
+		c.Timeout = cfg.Timeout
+	}
+
+	return c
+}
+
+// index returns the name of the index of the kind of documents at the time.
+func (c config) index(kind string, t time.Time) string {
+	name := c.IndexPrefix.String + "-" + kind
+	if c.IndexDateFormat.String == "" {
+		return name
+	}
+	return name + "-" + t.UTC().Format(c.IndexDateFormat.String)
+}
+
+func (c config) validate() error {
+	u, err := url.Parse(c.URL.String)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid URL scheme '%s', it can be http or https", u.Scheme)
+	}
+	if c.IndexPrefix.String == "" {
+		return errors.New("the index prefix can't be empty")
+	}
+	// the names of the indices have to be lowercase
+	if c.IndexPrefix.String != strings.ToLower(c.IndexPrefix.String) {
+		return fmt.Errorf("the index prefix '%s' has to be lowercase", c.IndexPrefix.String)
+	}
+	if c.MaxRetries.Int64 < 0 {
+		return fmt.Errorf("the max retries have to be a non-negative number, not %d", c.MaxRetries.Int64)
+	}
+	if c.RetryBackoff.TimeDuration() <= 0 {
+		return errors.New("the retry backoff has to be positive")
+	}
+	if c.PushInterval.TimeDuration() <= 0 {
+		return errors.New("the push interval has to be positive")
+	}
+	if c.Timeout.TimeDuration() <= 0 {
+		return errors.New("the timeout has to be positive")
+	}
+	return nil
+}
+
+// parseArg parses the argument of --out elasticsearch=<url>, the credentials
+// in the user info of the URL are used for the basic authentication.
+func parseArg(arg string) (config, error) {
+	c := config{}
+	u, err := url.Parse(arg)
+	if err != nil {
+		return c, err
+	}
+	if u.User != nil {
+		c.Username = null.StringFrom(u.User.Username())
+		if pass, ok := u.User.Password(); ok {
+			c.Password = null.StringFrom(pass)
+		}
+		u.User = nil
+	}
+	c.URL = null.StringFrom(u.String())
+	return c, nil
+}
+
+// getConsolidatedConfig combines {default config values + JSON config +
+// environment vars + argument}, and returns the final result.
+func getConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (config, error) {
+	result := newConfig()
+	if jsonRawConf != nil {
+		jsonConf := config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := parseArg(arg)
+		if err != nil {
+			return result, fmt.Errorf("invalid elasticsearch output argument '%s': %w", arg, err)
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.validate()
+}
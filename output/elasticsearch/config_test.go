@@ -0,0 +1,95 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	withDefaults := func(c config) config { return newConfig().Apply(c) }
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  config
+		errMsg  string
+	}{
+		"Defaults": {
+			config: newConfig(),
+		},
+		"JSONAndEnv": {
+			jsonRaw: json.RawMessage(`{"url":"https://es:9200","indexPrefix":"loadtests","indexDateFormat":"","maxRetries":2}`),
+			env: map[string]string{
+				"K6_ELASTICSEARCH_API_KEY":     "a2V5",
+				"K6_ELASTICSEARCH_MAX_RETRIES": "3",
+			},
+			config: withDefaults(config{
+				URL:             null.StringFrom("https://es:9200"),
+				APIKey:          null.StringFrom("a2V5"),
+				IndexPrefix:     null.StringFrom("loadtests"),
+				IndexDateFormat: null.StringFrom(""),
+				MaxRetries:      null.IntFrom(3),
+			}),
+		},
+		"ArgWithCredentials": {
+			env: map[string]string{"K6_ELASTICSEARCH_URL": "http://other:9200"},
+			arg: "https://k6:secret@es:9200/",
+			config: withDefaults(config{
+				URL:      null.StringFrom("https://es:9200/"),
+				Username: null.StringFrom("k6"),
+				Password: null.StringFrom("secret"),
+			}),
+		},
+		"InvalidScheme": {
+			arg:    "ftp://es:9200",
+			errMsg: "invalid URL scheme 'ftp', it can be http or https",
+		},
+		"UppercaseIndexPrefix": {
+			env:    map[string]string{"K6_ELASTICSEARCH_INDEX_PREFIX": "K6"},
+			errMsg: "the index prefix 'K6' has to be lowercase",
+		},
+		"NegativeMaxRetries": {
+			env:    map[string]string{"K6_ELASTICSEARCH_MAX_RETRIES": "-1"},
+			errMsg: "the max retries have to be a non-negative number, not -1",
+		},
+		"InvalidRetryBackoff": {
+			env:    map[string]string{"K6_ELASTICSEARCH_RETRY_BACKOFF": "0s"},
+			errMsg: "the retry backoff has to be positive",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, err := getConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.errMsg != "" {
+				require.EqualError(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, c)
+		})
+	}
+}
+
+func TestConfigIndex(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2023, 11, 5, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+	c := newConfig()
+	assert.Equal(t, "k6-metrics-2023.11.06", c.index(metricsIndexKind, ts))
+
+	c.IndexDateFormat = null.StringFrom("2006.01")
+	assert.Equal(t, "k6-checks-2023.11", c.index(checksIndexKind, ts))
+
+	c.IndexDateFormat = null.StringFrom("")
+	assert.Equal(t, "k6-metrics", c.index(metricsIndexKind, ts))
+}
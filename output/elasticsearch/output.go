@@ -0,0 +1,160 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+const (
+	metricsIndexKind = "metrics"
+	checksIndexKind  = "checks"
+
+	// maxBulkDocuments is the max number of documents of a bulk request, the
+	// flushes with more samples are split.
+	maxBulkDocuments = 5000
+)
+
+// New creates a new Elasticsearch output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := getConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := params.Logger.WithFields(logrus.Fields{"output": "elasticsearch"})
+	return &Output{
+		config: conf,
+		logger: logger,
+		client: newBulkClient(conf, logger),
+	}, nil
+}
+
+var _ output.Output = &Output{}
+
+// Output periodically bulk-indexes the buffered metric samples.
+type Output struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+
+	config config
+	logger logrus.FieldLogger
+	client *bulkClient
+}
+
+// metricDocument is the document of a metric sample.
+type metricDocument struct {
+	Timestamp time.Time         `json:"@timestamp"`
+	Metric    string            `json:"metric"`
+	Type      string            `json:"type"`
+	Contains  string            `json:"contains"`
+	Value     float64           `json:"value"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// checkDocument is the document of the result of a check.
+type checkDocument struct {
+	Timestamp time.Time         `json:"@timestamp"`
+	Check     string            `json:"check"`
+	Group     string            `json:"group"`
+	Scenario  string            `json:"scenario,omitempty"`
+	Passed    bool              `json:"passed"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("elasticsearch (%s, %s)", o.config.URL.String, o.config.index(metricsIndexKind, time.Now()))
+}
+
+// Start starts the goroutine for the periodic indexing of the samples.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop indexes the remaining samples.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return nil
+}
+
+func (o *Output) flushMetrics() {
+	var docs []document
+	for _, sc := range o.GetBufferedSamples() {
+		for _, s := range sc.GetSamples() {
+			doc, err := o.document(s)
+			if err != nil {
+				o.logger.WithError(err).Error("Couldn't encode the sample")
+				continue
+			}
+			docs = append(docs, doc)
+		}
+	}
+	if len(docs) == 0 {
+		return
+	}
+
+	start := time.Now()
+	for len(docs) > 0 {
+		n := len(docs)
+		if n > maxBulkDocuments {
+			n = maxBulkDocuments
+		}
+		if err := o.client.index(context.Background(), docs[:n]); err != nil {
+			o.logger.WithError(err).Error("Couldn't index the samples")
+		}
+		docs = docs[n:]
+	}
+	o.logger.WithField("t", time.Since(start)).Debug("Indexed the samples")
+}
+
+// document returns the document of the sample, the samples of the checks
+// metric are indexed as the results of the checks in their own index.
+func (o *Output) document(s metrics.Sample) (document, error) {
+	tags := s.Tags.Map()
+	if s.Metric.Name == metrics.ChecksName {
+		source, err := json.Marshal(checkDocument{
+			Timestamp: s.Time,
+			Check:     tags["check"],
+			Group:     tags["group"],
+			Scenario:  tags["scenario"],
+			Passed:    s.Value != 0,
+			Tags:      tags,
+		})
+		return document{index: o.config.index(checksIndexKind, s.Time), source: source}, err
+	}
+
+	source, err := json.Marshal(metricDocument{
+		Timestamp: s.Time,
+		Metric:    s.Metric.Name,
+		Type:      s.Metric.Type.String(),
+		Contains:  s.Metric.Contains.String(),
+		Value:     s.Value,
+		Tags:      tags,
+		Metadata:  s.Metadata,
+	})
+	return document{index: o.config.index(metricsIndexKind, s.Time), source: source}, err
+}
@@ -0,0 +1,236 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// bulkRequest is an action of a bulk request with its document.
+type bulkRequest struct {
+	Index  string
+	Source map[string]interface{}
+}
+
+// fakeCluster is a bulk API which answers with the statuses returned by
+// respond for each request.
+type fakeCluster struct {
+	mu       sync.Mutex
+	requests [][]bulkRequest
+	headers  []http.Header
+	respond  func(n int, reqs []bulkRequest) (status int, itemStatuses []int)
+}
+
+func (fc *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var reqs []bulkRequest
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var action map[string]struct {
+			Index string `json:"_index"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		req := bulkRequest{Index: action["create"].Index}
+		if err := json.Unmarshal(scanner.Bytes(), &req.Source); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reqs = append(reqs, req)
+	}
+
+	fc.mu.Lock()
+	n := len(fc.requests)
+	fc.requests = append(fc.requests, reqs)
+	fc.headers = append(fc.headers, r.Header.Clone())
+	fc.mu.Unlock()
+
+	status, itemStatuses := http.StatusOK, []int(nil)
+	if fc.respond != nil {
+		status, itemStatuses = fc.respond(n, reqs)
+	}
+	if status != http.StatusOK {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(status)
+		return
+	}
+
+	var items []string
+	hasErrors := false
+	for i := range reqs {
+		itemStatus := http.StatusCreated
+		if itemStatuses != nil {
+			itemStatus = itemStatuses[i]
+		}
+		if itemStatus != http.StatusCreated {
+			hasErrors = true
+		}
+		items = append(items, fmt.Sprintf(
+			`{"create":{"status":%d,"error":{"type":"some_exception","reason":"some reason"}}}`, itemStatus))
+	}
+	_, _ = fmt.Fprintf(w, `{"errors":%t,"items":[%s]}`, hasErrors, strings.Join(items, ","))
+}
+
+func (fc *fakeCluster) allRequests() [][]bulkRequest {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.requests
+}
+
+func newTestOutput(t *testing.T, fc *fakeCluster, env map[string]string) *Output {
+	srv := httptest.NewServer(fc)
+	t.Cleanup(srv.Close)
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: srv.URL,
+		Environment:    env,
+	})
+	require.NoError(t, err)
+	return o
+}
+
+func TestOutputFlushMetrics(t *testing.T) {
+	t.Parallel()
+
+	fc := &fakeCluster{}
+	o := newTestOutput(t, fc, map[string]string{"K6_ELASTICSEARCH_API_KEY": "a2V5"})
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	trend := registry.MustNewMetric("my_trend", metrics.Trend, metrics.Time)
+	ts := time.Date(2023, 11, 5, 10, 0, 0, 0, time.UTC)
+
+	tags := registry.RootTagSet().With("scenario", "default")
+	traced := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: trend, Tags: tags},
+		Time:       ts,
+		Value:      123.5,
+		Metadata:   map[string]string{lib.MetadataTraceID: "abc"},
+	}
+	checkTags := tags.With("check", "status is 200").With("group", "::login")
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		traced,
+		{TimeSeries: metrics.TimeSeries{Metric: builtin.Checks, Tags: checkTags}, Time: ts, Value: 1},
+		{TimeSeries: metrics.TimeSeries{Metric: builtin.Checks, Tags: checkTags}, Time: ts, Value: 0},
+	}})
+	require.NoError(t, o.Stop())
+
+	requests := fc.allRequests()
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0], 3)
+	assert.Equal(t, "ApiKey a2V5", fc.headers[0].Get("Authorization"))
+
+	assert.Equal(t, bulkRequest{
+		Index: "k6-metrics-2023.11.05",
+		Source: map[string]interface{}{
+			"@timestamp": "2023-11-05T10:00:00Z",
+			"metric":     "my_trend",
+			"type":       "trend",
+			"contains":   "time",
+			"value":      123.5,
+			"tags":       map[string]interface{}{"scenario": "default"},
+			"metadata":   map[string]interface{}{"trace_id": "abc"},
+		},
+	}, requests[0][0])
+
+	check := requests[0][1]
+	assert.Equal(t, "k6-checks-2023.11.05", check.Index)
+	assert.Equal(t, "status is 200", check.Source["check"])
+	assert.Equal(t, "::login", check.Source["group"])
+	assert.Equal(t, "default", check.Source["scenario"])
+	assert.Equal(t, true, check.Source["passed"])
+	assert.Equal(t, false, requests[0][2].Source["passed"])
+}
+
+func TestBulkClientRetries(t *testing.T) {
+	t.Parallel()
+
+	docs := []document{
+		{index: "k6-metrics", source: []byte(`{"value":1}`)},
+		{index: "k6-metrics", source: []byte(`{"value":2}`)},
+		{index: "k6-metrics", source: []byte(`{"value":3}`)},
+	}
+	env := map[string]string{"K6_ELASTICSEARCH_RETRY_BACKOFF": "1ms"}
+
+	t.Run("TooManyRequests", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeCluster{respond: func(n int, _ []bulkRequest) (int, []int) {
+			if n < 2 {
+				return http.StatusTooManyRequests, nil
+			}
+			return http.StatusOK, nil
+		}}
+		o := newTestOutput(t, fc, env)
+		require.NoError(t, o.client.index(context.Background(), docs))
+
+		requests := fc.allRequests()
+		require.Len(t, requests, 3)
+		assert.Equal(t, requests[0], requests[2])
+	})
+
+	t.Run("RejectedDocuments", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeCluster{respond: func(n int, _ []bulkRequest) (int, []int) {
+			if n == 0 {
+				return http.StatusOK, []int{http.StatusCreated, http.StatusTooManyRequests, http.StatusBadRequest}
+			}
+			return http.StatusOK, nil
+		}}
+		o := newTestOutput(t, fc, env)
+		require.NoError(t, o.client.index(context.Background(), docs))
+
+		// only the document rejected with 429 is retried
+		requests := fc.allRequests()
+		require.Len(t, requests, 2)
+		require.Len(t, requests[1], 1)
+		assert.Equal(t, float64(2), requests[1][0].Source["value"])
+	})
+
+	t.Run("MaxRetries", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeCluster{respond: func(int, []bulkRequest) (int, []int) {
+			return http.StatusTooManyRequests, nil
+		}}
+		o := newTestOutput(t, fc, map[string]string{
+			"K6_ELASTICSEARCH_RETRY_BACKOFF": "1ms",
+			"K6_ELASTICSEARCH_MAX_RETRIES":   "2",
+		})
+		err := o.client.index(context.Background(), docs)
+		require.EqualError(t, err, "3 documents weren't indexed after 2 retries, the cluster is overloaded")
+		assert.Len(t, fc.allRequests(), 3)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+		fc := &fakeCluster{respond: func(int, []bulkRequest) (int, []int) {
+			return http.StatusUnauthorized, nil
+		}}
+		o := newTestOutput(t, fc, env)
+		err := o.client.index(context.Background(), docs)
+		require.EqualError(t, err, "the bulk request failed with status 401: ")
+		assert.Len(t, fc.allRequests(), 1)
+	})
+}
@@ -0,0 +1,96 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// config is the configuration of the exec output.
+type config struct {
+	// Command is the command line of the exporter, the executable and its
+	// arguments separated by spaces.
+	Command null.String `json:"command,omitempty" envconfig:"K6_EXEC_COMMAND"`
+	// PushInterval is how often the buffered samples are sent to the exporter.
+	PushInterval types.NullDuration `json:"pushInterval,omitempty" envconfig:"K6_EXEC_PUSH_INTERVAL"`
+	// StopTimeout is how long the exporter has to exit after the end of the
+	// test, before it's killed.
+	StopTimeout types.NullDuration `json:"stopTimeout,omitempty" envconfig:"K6_EXEC_STOP_TIMEOUT"`
+}
+
+// newConfig creates a new config instance with the default values.
+func newConfig() config {
+	return config{
+		Command:      null.NewString("", false),
+		PushInterval: types.NewNullDuration(1*time.Second, false),
+		StopTimeout:  types.NewNullDuration(30*time.Second, false),
+	}
+}
+
+// Apply saves the non-zero config values from the passed config in the receiver.
+func (c config) Apply(cfg config) config {
+	if cfg.Command.Valid {
+		c.Command = cfg.Command
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+	if cfg.StopTimeout.Valid {
+		c.StopTimeout = cfg.StopTimeout
+	}
+
+	return c
+}
+
+// commandLine returns the executable and the arguments of the exporter.
+func (c config) commandLine() []string {
+	return strings.Fields(c.Command.String)
+}
+
+func (c config) validate() error {
+	if len(c.commandLine()) == 0 {
+		return errors.New("the command of the exporter is required, e.g. --out exec=./my-exporter")
+	}
+	if c.PushInterval.TimeDuration() <= 0 {
+		return errors.New("the push interval has to be positive")
+	}
+	if c.StopTimeout.TimeDuration() <= 0 {
+		return errors.New("the stop timeout has to be positive")
+	}
+	return nil
+}
+
+// getConsolidatedConfig combines {default config values + JSON config +
+// environment vars + argument}, and returns the final result. The argument of
+// --out exec=<command> is the command line of the exporter.
+func getConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (config, error) {
+	result := newConfig()
+	if jsonRawConf != nil {
+		jsonConf := config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		result = result.Apply(config{Command: null.StringFrom(arg)})
+	}
+
+	return result, result.validate()
+}
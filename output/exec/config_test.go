@@ -0,0 +1,72 @@
+package exec
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	withDefaults := func(c config) config { return newConfig().Apply(c) }
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  config
+		errMsg  string
+	}{
+		"Arg": {
+			arg:    "./my-exporter --verbose",
+			config: withDefaults(config{Command: null.StringFrom("./my-exporter --verbose")}),
+		},
+		"JSONEnvAndArg": {
+			jsonRaw: json.RawMessage(`{"command":"json-exporter","pushInterval":"5s"}`),
+			env: map[string]string{
+				"K6_EXEC_COMMAND":      "env-exporter",
+				"K6_EXEC_STOP_TIMEOUT": "1m",
+			},
+			arg: "arg-exporter",
+			config: withDefaults(config{
+				Command:      null.StringFrom("arg-exporter"),
+				PushInterval: types.NullDurationFrom(5 * time.Second),
+				StopTimeout:  types.NullDurationFrom(time.Minute),
+			}),
+		},
+		"MissingCommand": {
+			errMsg: "the command of the exporter is required, e.g. --out exec=./my-exporter",
+		},
+		"InvalidStopTimeout": {
+			env:    map[string]string{"K6_EXEC_COMMAND": "exporter", "K6_EXEC_STOP_TIMEOUT": "0s"},
+			errMsg: "the stop timeout has to be positive",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, err := getConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.errMsg != "" {
+				require.EqualError(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, c)
+		})
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	t.Parallel()
+
+	c := config{Command: null.StringFrom("  ./my-exporter  --endpoint http://localhost ")}
+	assert.Equal(t, []string{"./my-exporter", "--endpoint", "http://localhost"}, c.commandLine())
+}
@@ -0,0 +1,318 @@
+package exec
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	osexec "os/exec"
+	"sync"
+	"time"
+
+	"github.com/mailru/easyjson/jwriter"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+	jsonoutput "go.k6.io/k6/output/json"
+)
+
+// New creates a new exec output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := getConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	env := make([]string, 0, len(params.Environment))
+	for k, v := range params.Environment {
+		env = append(env, k+"="+v)
+	}
+
+	return &Output{
+		config:      conf,
+		logger:      params.Logger.WithFields(logrus.Fields{"output": "exec"}),
+		env:         env,
+		seenMetrics: make(map[string]struct{}),
+	}, nil
+}
+
+var (
+	_ output.WithThresholds        = &Output{}
+	_ output.WithTestRunStop       = &Output{}
+	_ output.WithStopWithTestError = &Output{}
+)
+
+// Output streams the metric samples to the standard input of the exporter
+// process, see the package documentation for the protocol.
+type Output struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+
+	config config
+	logger logrus.FieldLogger
+	env    []string
+
+	cmd   *osexec.Cmd
+	stdin io.WriteCloser
+	// writeErr is the error of the last write to the exporter, once the
+	// exporter can't receive the messages they are dropped
+	writeErr error
+	// exited is closed when the exporter exits, after which waitErr is the
+	// result of waiting for it
+	exited  chan struct{}
+	waitErr error
+
+	seenMetrics map[string]struct{}
+	thresholds  map[string]metrics.Thresholds
+	testRunStop func(error)
+	abortOnce   sync.Once
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("exec (%s)", o.config.Command.String)
+}
+
+// SetThresholds receives the thresholds before the output is Start()-ed, they
+// are sent to the exporter in the Metric messages.
+func (o *Output) SetThresholds(thresholds map[string]metrics.Thresholds) {
+	if len(thresholds) == 0 {
+		return
+	}
+	o.thresholds = make(map[string]metrics.Thresholds, len(thresholds))
+	for name, t := range thresholds {
+		o.thresholds[name] = t
+	}
+}
+
+// SetTestRunStopCallback receives the function that stops the test run, it's
+// called when the exporter sends an Abort message.
+func (o *Output) SetTestRunStopCallback(stopFunc func(error)) {
+	o.testRunStop = stopFunc
+}
+
+// Start starts the exporter and sends it the Start message, then it starts
+// the goroutine for the periodic sending of the samples.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	commandLine := o.config.commandLine()
+	cmd := osexec.Command(commandLine[0], commandLine[1:]...) //nolint:gosec
+	cmd.Env = o.env
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("couldn't start the exporter: %w", err)
+	}
+	o.cmd = cmd
+	o.stdin = stdin
+
+	// the pipes have to be completely read before waiting for the process
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		o.readMessages(stdout)
+	}()
+	go func() {
+		defer readers.Done()
+		o.logLines(stderr, "stderr")
+	}()
+	o.exited = make(chan struct{})
+	go func() {
+		readers.Wait()
+		o.waitErr = cmd.Wait()
+		close(o.exited)
+	}()
+
+	o.send(messageStart, startData{Protocol: protocolVersion, K6Version: consts.Version})
+	if o.writeErr != nil {
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("couldn't send the start message to the exporter: %w", o.writeErr)
+	}
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.WithField("pid", cmd.Process.Pid).Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop is like StopWithTestError without a test error.
+func (o *Output) Stop() error {
+	return o.StopWithTestError(nil)
+}
+
+// StopWithTestError sends the remaining samples and the Stop message to the
+// exporter, then it waits for the exporter to exit, or kills it after the
+// stop timeout.
+func (o *Output) StopWithTestError(testErr error) error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	var stop stopData
+	if testErr != nil {
+		stop.Error = testErr.Error()
+	}
+	o.send(messageStop, stop)
+	_ = o.stdin.Close()
+
+	select {
+	case <-o.exited:
+	case <-time.After(o.config.StopTimeout.TimeDuration()):
+		o.logger.Warnf("The exporter didn't exit in %s, killing it", o.config.StopTimeout.String())
+		_ = o.cmd.Process.Kill()
+		<-o.exited
+	}
+	if o.waitErr != nil {
+		return fmt.Errorf("the exporter failed: %w", o.waitErr)
+	}
+	return nil
+}
+
+func (o *Output) flushMetrics() {
+	samples := o.GetBufferedSamples()
+	if len(samples) == 0 {
+		return
+	}
+
+	start := time.Now()
+	var count int
+	jw := new(jwriter.Writer)
+	for _, sc := range samples {
+		for _, s := range sc.GetSamples() {
+			if _, ok := o.seenMetrics[s.Metric.Name]; !ok {
+				o.seenMetrics[s.Metric.Name] = struct{}{}
+				jsonoutput.WriteMetric(jw, s.Metric, o.thresholds[s.Metric.Name])
+			}
+			jsonoutput.WriteSample(jw, s)
+			count++
+		}
+	}
+
+	if o.writeErr != nil {
+		o.logger.WithField("samples", count).Debug("Dropped the samples, the exporter can't receive them")
+		return
+	}
+	if _, err := jw.DumpTo(o.stdin); err != nil {
+		o.writeFailed(err)
+		return
+	}
+	o.logger.WithFields(logrus.Fields{
+		"t":       time.Since(start),
+		"samples": count,
+	}).Debug("Sent the samples to the exporter")
+}
+
+// send writes a message, other than the samples, to the exporter.
+func (o *Output) send(messageType string, data interface{}) {
+	if o.writeErr != nil {
+		return
+	}
+	line, err := newMessage(messageType, data)
+	if err == nil {
+		_, err = o.stdin.Write(line)
+	}
+	if err != nil {
+		o.writeFailed(err)
+	}
+}
+
+func (o *Output) writeFailed(err error) {
+	o.writeErr = err
+	o.logger.WithError(err).Error("Couldn't send the messages to the exporter, the next samples will be dropped")
+}
+
+// readMessages handles the messages from the standard output of the exporter,
+// the lines that aren't messages are logged.
+func (o *Output) readMessages(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil || msg.Type == "" {
+			o.logger.WithField("stream", "stdout").Info(string(line))
+			continue
+		}
+		if err := o.handleMessage(msg); err != nil {
+			o.logger.WithError(err).Warnf("Invalid %s message from the exporter", msg.Type)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		o.logger.WithError(err).Warn("Couldn't read the standard output of the exporter")
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+}
+
+func (o *Output) handleMessage(msg message) error {
+	switch msg.Type {
+	case messageLog:
+		var data logData
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			return err
+		}
+		o.logger.WithField("source", "exporter").Log(logLevel(data.Level), data.Msg)
+	case messageAbort:
+		var data abortData
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			return err
+		}
+		o.abort(data.Reason)
+	default:
+		return fmt.Errorf("unknown message type '%s'", msg.Type)
+	}
+	return nil
+}
+
+// abort stops the test run, only the first Abort message is handled.
+func (o *Output) abort(reason string) {
+	o.abortOnce.Do(func() {
+		if reason == "" {
+			reason = "no reason given"
+		}
+		o.logger.Warnf("The exporter aborted the test run: %s", reason)
+		if o.testRunStop == nil {
+			return
+		}
+		err := fmt.Errorf("the exporter aborted the test run: %s", reason)
+		o.testRunStop(errext.WithAbortReasonIfNone(
+			errext.WithExitCodeIfNone(err, exitcodes.ExternalAbort),
+			errext.AbortedByOutput,
+		))
+	})
+}
+
+// logLines logs every line of the stream of the exporter.
+func (o *Output) logLines(r io.Reader, stream string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		o.logger.WithField("stream", stream).Info(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		o.logger.WithError(err).Warnf("Couldn't read the %s of the exporter", stream)
+		_, _ = io.Copy(io.Discard, r)
+	}
+}
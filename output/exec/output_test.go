@@ -0,0 +1,190 @@
+package exec
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// exporterArgs returns the arguments after --, if the test binary was started
+// as the exporter of a test.
+func exporterArgs() []string {
+	args := os.Args //nolint:forbidigo
+	for i, arg := range args {
+		if arg == "--" {
+			return args[i+1:]
+		}
+	}
+	return nil
+}
+
+// TestExporterProcess isn't a real test, it's the exporter started by the
+// other tests. It writes the messages it receives to the file of its first
+// argument and behaves as the second one says.
+func TestExporterProcess(t *testing.T) { //nolint:paralleltest
+	args := exporterArgs()
+	if len(args) != 2 {
+		t.Skip("it's only run as the exporter of the other tests")
+	}
+	fileName, behavior := args[0], args[1]
+	stdin, stdout, stderr := os.Stdin, os.Stdout, os.Stderr //nolint:forbidigo
+
+	f, err := os.Create(fileName) //nolint:forbidigo
+	if err != nil {
+		os.Exit(2) //nolint:forbidigo
+	}
+	defer os.Exit(0) //nolint:forbidigo
+	defer func() { _ = f.Close() }()
+
+	switch behavior {
+	case "log":
+		_, _ = fmt.Fprintln(stdout, `{"type":"Log","data":{"level":"warning","msg":"hello from the exporter"}}`)
+		_, _ = fmt.Fprintln(stdout, "not a message")
+		_, _ = fmt.Fprintln(stderr, "an error line")
+	case "abort":
+		_, _ = fmt.Fprintln(stdout, `{"type":"Abort","data":{"reason":"the backend is down"}}`)
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+	_, _ = io.Copy(f, stdin)
+	if behavior == "fail" {
+		os.Exit(3) //nolint:forbidigo
+	}
+}
+
+func newTestOutput(t *testing.T, behavior string, env map[string]string) (*Output, string, *testutils.SimpleLogrusHook) {
+	fileName := filepath.Join(t.TempDir(), "messages.ndjson")
+	hook := testutils.NewLogHook(logrus.InfoLevel, logrus.WarnLevel, logrus.ErrorLevel)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+
+	testBinary := os.Args[0] //nolint:forbidigo
+	o, err := newOutput(output.Params{
+		Logger:         logger,
+		Environment:    env,
+		ConfigArgument: strings.Join([]string{testBinary, "-test.run=^TestExporterProcess$", "--", fileName, behavior}, " "),
+	})
+	require.NoError(t, err)
+	return o, fileName, hook
+}
+
+// readMessages returns the types of the messages received by the exporter
+// and their data.
+func readMessages(t *testing.T, fileName string) ([]string, []string) {
+	t.Helper()
+	f, err := os.Open(fileName) //nolint:forbidigo
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	var types, data []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		types = append(types, msg.Type)
+		data = append(data, string(msg.Data))
+	}
+	require.NoError(t, scanner.Err())
+	return types, data
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	o, fileName, hook := newTestOutput(t, "log", nil)
+	thresholds := metrics.NewThresholds([]string{"p(95)<100"})
+	o.SetThresholds(map[string]metrics.Thresholds{"my_trend": thresholds})
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	trend := registry.MustNewMetric("my_trend", metrics.Trend, metrics.Time)
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: trend, Tags: registry.RootTagSet().With("status", "200")},
+		Time:       time.Unix(10, 0).UTC(),
+		Value:      12.5,
+	}
+	o.AddMetricSamples([]metrics.SampleContainer{sample})
+	o.flushMetrics()
+	o.AddMetricSamples([]metrics.SampleContainer{sample})
+	require.NoError(t, o.StopWithTestError(errors.New("something went wrong")))
+
+	types, data := readMessages(t, fileName)
+	assert.Equal(t, []string{"Start", "Metric", "Point", "Point", "Stop"}, types)
+	assert.Contains(t, data[0], `"protocol":1`)
+	assert.JSONEq(t, `{"name":"my_trend","type":"trend","contains":"time","thresholds":["p(95)<100"],"submetrics":null}`,
+		data[1])
+	assert.JSONEq(t, `{"time":"1970-01-01T00:00:10Z","value":12.5,"tags":{"status":"200"}}`, data[2])
+	assert.JSONEq(t, `{"error":"something went wrong"}`, data[4])
+
+	entries := hook.Drain()
+	assert.True(t, testutils.LogContains(entries, logrus.WarnLevel, "hello from the exporter"))
+	assert.True(t, testutils.LogContains(entries, logrus.InfoLevel, "not a message"))
+	assert.True(t, testutils.LogContains(entries, logrus.InfoLevel, "an error line"))
+}
+
+func TestOutputAbort(t *testing.T) {
+	t.Parallel()
+
+	o, _, _ := newTestOutput(t, "abort", nil)
+	stopped := make(chan error, 1)
+	o.SetTestRunStopCallback(func(err error) { stopped <- err })
+	require.NoError(t, o.Start())
+
+	select {
+	case err := <-stopped:
+		assert.ErrorContains(t, err, "the exporter aborted the test run: the backend is down")
+		var withExitCode errext.HasExitCode
+		require.ErrorAs(t, err, &withExitCode)
+		assert.Equal(t, exitcodes.ExternalAbort, withExitCode.ExitCode())
+	case <-time.After(10 * time.Second):
+		t.Fatal("the test run wasn't stopped")
+	}
+	require.NoError(t, o.Stop())
+}
+
+func TestOutputExporterErrors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ExitCode", func(t *testing.T) {
+		t.Parallel()
+		o, _, _ := newTestOutput(t, "fail", nil)
+		require.NoError(t, o.Start())
+		assert.ErrorContains(t, o.Stop(), "the exporter failed: exit status 3")
+	})
+
+	t.Run("StopTimeout", func(t *testing.T) {
+		t.Parallel()
+		o, _, hook := newTestOutput(t, "hang", map[string]string{"K6_EXEC_STOP_TIMEOUT": "100ms"})
+		require.NoError(t, o.Start())
+		assert.ErrorContains(t, o.Stop(), "the exporter failed")
+		assert.True(t, testutils.LogContains(hook.Drain(), logrus.WarnLevel, "The exporter didn't exit in 100ms"))
+	})
+
+	t.Run("MissingExecutable", func(t *testing.T) {
+		t.Parallel()
+		o, err := newOutput(output.Params{
+			Logger:         testutils.NewLogger(t),
+			ConfigArgument: filepath.Join(t.TempDir(), "missing-exporter"),
+		})
+		require.NoError(t, err)
+		assert.ErrorContains(t, o.Start(), "couldn't start the exporter")
+	})
+}
@@ -0,0 +1,91 @@
+// Package exec implements an output that streams the metric samples to an
+// external process, the exporter, so they can be sent to any backend by a
+// program written in any language.
+//
+// The protocol is newline-delimited JSON. On the standard input of the
+// exporter, k6 writes:
+//
+//   - a Start message, {"type":"Start","data":{"protocol":1,"k6_version":"..."}};
+//   - a Metric message before the first sample of each metric and a Point
+//     message for each sample, in the format of the json output;
+//   - a Stop message, {"type":"Stop","data":{"error":"..."}}, with the error of
+//     the test run, if any, then the standard input is closed and the exporter
+//     has to exit.
+//
+// The exporter can write these messages to its standard output:
+//
+//   - {"type":"Log","data":{"level":"warning","msg":"..."}}, to log a message
+//     with the k6 logger;
+//   - {"type":"Abort","data":{"reason":"..."}}, to stop the test run.
+//
+// The other lines of the standard output and the standard error of the
+// exporter are logged as they are.
+package exec
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+)
+
+// protocolVersion is the version of the protocol, it's increased with the
+// changes that aren't backwards-compatible.
+const protocolVersion = 1
+
+// the types of the messages of the protocol
+const (
+	messageStart = "Start"
+	messageStop  = "Stop"
+	messageLog   = "Log"
+	messageAbort = "Abort"
+)
+
+// message is a line of the protocol.
+type message struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type startData struct {
+	Protocol  int    `json:"protocol"`
+	K6Version string `json:"k6_version"`
+}
+
+type stopData struct {
+	Error string `json:"error,omitempty"`
+}
+
+type logData struct {
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+type abortData struct {
+	Reason string `json:"reason"`
+}
+
+// newMessage returns the line of the message with the data.
+func newMessage(messageType string, data interface{}) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(message{Type: messageType, Data: raw})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// logLevel returns the level of a Log message, it's info if it's unknown and
+// error at most, the exporter can't make k6 exit.
+func logLevel(level string) logrus.Level {
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		return logrus.InfoLevel
+	}
+	if l < logrus.ErrorLevel {
+		return logrus.ErrorLevel
+	}
+	return l
+}
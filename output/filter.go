@@ -0,0 +1,94 @@
+package output
+
+import (
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// WithFilter returns an output that only receives the samples of the metrics
+// selected by the filter, without the tags it removes. The optional
+// interfaces of the output are still implemented by the returned one.
+func WithFilter(out Output, filter lib.OutputFilter) Output {
+	return &filteredOutput{
+		outputWrapper: outputWrapper{Output: out},
+		filter:        filter,
+	}
+}
+
+var (
+	_ WithThresholds        = &filteredOutput{}
+	_ WithTestRunStop       = &filteredOutput{}
+	_ WithStopWithTestError = &filteredOutput{}
+	_ WithBuiltinMetrics    = &filteredOutput{}
+	_ WithDroppedSamples    = &filteredOutput{}
+)
+
+type filteredOutput struct {
+	outputWrapper
+	filter lib.OutputFilter
+}
+
+// AddMetricSamples passes the filtered samples to the output, the containers
+// without any samples left are dropped.
+func (fo *filteredOutput) AddMetricSamples(containers []metrics.SampleContainer) {
+	filtered := make([]metrics.SampleContainer, 0, len(containers))
+	for _, sc := range containers {
+		if sc = fo.filterContainer(sc); sc != nil {
+			filtered = append(filtered, sc)
+		}
+	}
+	fo.Output.AddMetricSamples(filtered)
+}
+
+// filterContainer returns the container as it is if none of its samples is
+// filtered, otherwise the remaining samples, or nil if there aren't any.
+func (fo *filteredOutput) filterContainer(sc metrics.SampleContainer) metrics.SampleContainer {
+	samples := sc.GetSamples()
+	var filtered []metrics.Sample
+	changed := false
+	for i, s := range samples {
+		keep := fo.filter.IncludesMetric(s.Metric.Name)
+		tags := s.Tags
+		if keep {
+			tags = fo.filterTags(s.Tags)
+		}
+		if keep && tags == s.Tags && !changed {
+			continue
+		}
+		if !changed {
+			changed = true
+			filtered = append(make([]metrics.Sample, 0, len(samples)), samples[:i]...)
+		}
+		if keep {
+			s.Tags = tags
+			filtered = append(filtered, s)
+		}
+	}
+
+	switch {
+	case !changed:
+		return sc
+	case len(filtered) == 0:
+		return nil
+	}
+	if cs, ok := sc.(metrics.ConnectedSamples); ok {
+		cs.Samples = filtered
+		cs.Tags = fo.filterTags(cs.Tags)
+		return cs
+	}
+	return metrics.Samples(filtered)
+}
+
+// filterTags returns the tag set without the tags removed by the filter, or
+// the same one if there aren't any.
+func (fo *filteredOutput) filterTags(tags *metrics.TagSet) *metrics.TagSet {
+	if tags == nil {
+		return nil
+	}
+	for key := range tags.Map() {
+		if !fo.filter.KeepsTag(key) {
+			tags = tags.Without(key)
+		}
+	}
+	return tags
+}
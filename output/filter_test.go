@@ -0,0 +1,76 @@
+package output
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+type stoppableOutput struct {
+	SampleBuffer
+	testErr error
+}
+
+func (o *stoppableOutput) Description() string { return "stoppable" }
+func (o *stoppableOutput) Start() error        { return nil }
+func (o *stoppableOutput) Stop() error         { return errors.New("Stop() shouldn't be called") }
+
+func (o *stoppableOutput) StopWithTestError(testErr error) error {
+	o.testErr = testErr
+	return nil
+}
+
+func TestWithFilter(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	iterations := registry.MustNewMetric("iterations", metrics.Counter)
+	tags := registry.RootTagSet().WithTagsFromMap(map[string]string{"url": "http://k6.io/1", "status": "200"})
+	sample := func(m *metrics.Metric) metrics.Sample {
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags}, Time: time.Unix(1, 0), Value: 1}
+	}
+
+	inner := &stoppableOutput{}
+	out := WithFilter(inner, lib.OutputFilter{
+		IncludeMetrics: []string{"http_*"},
+		ExcludeMetrics: []string{"http_reqs"},
+		DenyTags:       []string{"url"},
+	})
+	dropping, ok := out.(WithDroppedSamples)
+	require.True(t, ok)
+	assert.False(t, dropping.DropsSamples())
+	connected := metrics.ConnectedSamples{
+		Samples: []metrics.Sample{sample(duration), sample(reqs)},
+		Tags:    tags,
+		Time:    time.Unix(1, 0),
+	}
+	out.AddMetricSamples([]metrics.SampleContainer{connected, sample(iterations)})
+
+	buffered := inner.GetBufferedSamples()
+	require.Len(t, buffered, 1)
+	filtered, ok := buffered[0].(metrics.ConnectedSamples)
+	require.True(t, ok)
+	require.Len(t, filtered.Samples, 1)
+	assert.Equal(t, duration, filtered.Samples[0].Metric)
+	assert.Equal(t, map[string]string{"status": "200"}, filtered.Samples[0].Tags.Map())
+	assert.Equal(t, map[string]string{"status": "200"}, filtered.Tags.Map())
+
+	// the containers that aren't filtered are passed as they are
+	untouched := WithFilter(inner, lib.OutputFilter{AllowTags: []string{"url", "status"}})
+	untouched.AddMetricSamples([]metrics.SampleContainer{connected})
+	assert.Equal(t, []metrics.SampleContainer{connected}, inner.GetBufferedSamples())
+
+	testErr := errors.New("test error")
+	stopper, ok := out.(WithStopWithTestError)
+	require.True(t, ok)
+	require.NoError(t, stopper.StopWithTestError(testErr))
+	assert.Equal(t, testErr, inner.testErr)
+}
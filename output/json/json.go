@@ -132,8 +132,7 @@ func (o *Output) flushMetrics() {
 		for _, sample := range samples {
 			sample := sample
 			o.handleMetric(sample.Metric, jw)
-			wrapSample(sample).MarshalEasyJSON(jw)
-			jw.RawByte('\n')
+			WriteSample(jw, sample)
 		}
 	}
 
@@ -152,19 +151,5 @@ func (o *Output) handleMetric(m *metrics.Metric, jw *jwriter.Writer) {
 	}
 	o.seenMetrics[m.Name] = struct{}{}
 
-	wrapped := metricEnvelope{
-		Type:   "Metric",
-		Metric: m.Name,
-	}
-	wrapped.Data.Name = m.Name
-	wrapped.Data.Type = m.Type
-	wrapped.Data.Contains = m.Contains
-	wrapped.Data.Submetrics = m.Submetrics
-
-	if ts, ok := o.thresholds[m.Name]; ok {
-		wrapped.Data.Thresholds = ts
-	}
-
-	wrapped.MarshalEasyJSON(jw)
-	jw.RawByte('\n')
+	WriteMetric(jw, m, o.thresholds[m.Name])
 }
@@ -0,0 +1,86 @@
+package json
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+type resultEnvelope struct {
+	Type   string          `json:"type"`
+	Metric string          `json:"metric"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type resultMetric struct {
+	Name       string             `json:"name"`
+	Type       metrics.MetricType `json:"type"`
+	Contains   metrics.ValueType  `json:"contains"`
+	Thresholds metrics.Thresholds `json:"thresholds"`
+}
+
+type resultPoint struct {
+	Time     time.Time         `json:"time"`
+	Value    float64           `json:"value"`
+	Tags     map[string]string `json:"tags"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// ReadResults reads the metrics and samples written by the JSON output. The
+// metrics are registered in the registry, if needed, and every sample is
+// passed to the callback. It returns the thresholds of the metrics, if the
+// results contain any.
+func ReadResults(
+	r io.Reader, registry *metrics.Registry, callback func(metrics.Sample),
+) (map[string]metrics.Thresholds, error) {
+	thresholds := make(map[string]metrics.Thresholds)
+	decoder := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var envelope resultEnvelope
+		err := decoder.Decode(&envelope)
+		if errors.Is(err, io.EOF) {
+			return thresholds, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid result on line %d: %w", line, err)
+		}
+
+		switch envelope.Type {
+		case "Metric":
+			var m resultMetric
+			if err = json.Unmarshal(envelope.Data, &m); err != nil {
+				return nil, fmt.Errorf("invalid metric on line %d: %w", line, err)
+			}
+			if _, err = registry.NewMetric(m.Name, m.Type, m.Contains); err != nil {
+				return nil, fmt.Errorf("invalid metric on line %d: %w", line, err)
+			}
+			if len(m.Thresholds.Thresholds) > 0 {
+				thresholds[m.Name] = m.Thresholds
+			}
+		case "Point":
+			metric := registry.Get(envelope.Metric)
+			if metric == nil {
+				return nil, fmt.Errorf("the sample on line %d is for the unknown metric '%s'", line, envelope.Metric)
+			}
+			var p resultPoint
+			if err = json.Unmarshal(envelope.Data, &p); err != nil {
+				return nil, fmt.Errorf("invalid sample on line %d: %w", line, err)
+			}
+			callback(metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: metric,
+					Tags:   registry.RootTagSet().WithTagsFromMap(p.Tags),
+				},
+				Time:     p.Time,
+				Value:    p.Value,
+				Metadata: p.Metadata,
+			})
+		default:
+			return nil, fmt.Errorf("unknown result type '%s' on line %d", envelope.Type, line)
+		}
+	}
+}
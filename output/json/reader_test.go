@@ -0,0 +1,77 @@
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+func TestReadResults(t *testing.T) {
+	t.Parallel()
+
+	stdout := new(bytes.Buffer)
+	out, err := New(output.Params{
+		Logger: testutils.NewLogger(t),
+		StdOut: stdout,
+	})
+	require.NoError(t, err)
+	setThresholds(t, out)
+	require.NoError(t, out.Start())
+	samples, _ := generateTestMetricSamples(t)
+	out.AddMetricSamples(samples)
+	require.NoError(t, out.Stop())
+
+	registry := metrics.NewRegistry()
+	var read []metrics.Sample
+	thresholds, err := ReadResults(stdout, registry, func(s metrics.Sample) { read = append(read, s) })
+	require.NoError(t, err)
+
+	require.Len(t, thresholds, 1)
+	require.Len(t, thresholds["my_metric1"].Thresholds, 2)
+	assert.Equal(t, "p(99)<250", thresholds["my_metric1"].Thresholds[1].Source)
+
+	var i int
+	for _, sc := range samples {
+		for _, s := range sc.GetSamples() {
+			require.Less(t, i, len(read))
+			assert.Equal(t, s.Metric.Name, read[i].Metric.Name)
+			assert.Equal(t, s.Metric.Type, read[i].Metric.Type)
+			assert.Equal(t, s.Metric.Contains, read[i].Metric.Contains)
+			assert.Equal(t, s.Tags.Map(), read[i].Tags.Map())
+			assert.True(t, s.Time.Equal(read[i].Time))
+			assert.Equal(t, s.Value, read[i].Value)
+			if len(s.Metadata) > 0 {
+				assert.Equal(t, s.Metadata, read[i].Metadata)
+			}
+			i++
+		}
+	}
+	assert.Len(t, read, i)
+	assert.Equal(t, metrics.Gauge, registry.Get("my_metric1").Type)
+}
+
+func TestReadResultsInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"unknown metric": `{"type":"Point","data":{"time":"2021-02-24T13:37:10Z","value":1},"metric":"unknown"}`,
+		"unknown type":   `{"type":"Foo","data":{},"metric":"my_metric"}`,
+		"invalid json":   `{"type":`,
+		"invalid metric": `{"type":"Metric","data":{"name":"my metric!","type":"gauge"},"metric":"my metric!"}`,
+	}
+	for name, input := range testCases {
+		input := input
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ReadResults(strings.NewReader(input), metrics.NewRegistry(), func(metrics.Sample) {})
+			assert.Error(t, err)
+		})
+	}
+}
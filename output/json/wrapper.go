@@ -3,6 +3,8 @@ package json
 import (
 	"time"
 
+	"github.com/mailru/easyjson/jwriter"
+
 	"go.k6.io/k6/metrics"
 )
 
@@ -46,3 +48,27 @@ type metricEnvelope struct {
 	} `json:"data"`
 	Metric string `json:"metric"`
 }
+
+// WriteSample writes the sample as a line of the results, in the same format
+// as the output, e.g. for the outputs that stream the samples as JSON.
+func WriteSample(jw *jwriter.Writer, sample metrics.Sample) {
+	wrapSample(sample).MarshalEasyJSON(jw)
+	jw.RawByte('\n')
+}
+
+// WriteMetric writes the metric and its thresholds as a line of the results,
+// in the same format as the output.
+func WriteMetric(jw *jwriter.Writer, m *metrics.Metric, thresholds metrics.Thresholds) {
+	wrapped := metricEnvelope{
+		Type:   "Metric",
+		Metric: m.Name,
+	}
+	wrapped.Data.Name = m.Name
+	wrapped.Data.Type = m.Type
+	wrapped.Data.Contains = m.Contains
+	wrapped.Data.Submetrics = m.Submetrics
+	wrapped.Data.Thresholds = thresholds
+
+	wrapped.MarshalEasyJSON(jw)
+	jw.RawByte('\n')
+}
@@ -0,0 +1,224 @@
+// Package kafka implements an output that publishes the metric samples to a
+// Kafka topic, encoded as JSON, Avro or Protobuf.
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+const (
+	formatJSON     = "json"
+	formatAvro     = "avro"
+	formatProtobuf = "protobuf"
+
+	saslPlain       = "plain"
+	saslScramSHA256 = "scram-sha-256"
+	saslScramSHA512 = "scram-sha-512"
+)
+
+// config is the configuration of the Kafka output.
+type config struct {
+	Brokers []string    `json:"brokers,omitempty" envconfig:"K6_KAFKA_BROKERS"`
+	Topic   null.String `json:"topic,omitempty" envconfig:"K6_KAFKA_TOPIC"`
+	// Format is the encoding of the messages, json, avro or protobuf.
+	Format null.String `json:"format,omitempty" envconfig:"K6_KAFKA_FORMAT"`
+
+	TLS                   null.Bool `json:"tls,omitempty" envconfig:"K6_KAFKA_TLS"`
+	InsecureSkipTLSVerify null.Bool `json:"insecureSkipTLSVerify,omitempty" envconfig:"K6_KAFKA_INSECURE_SKIP_TLS_VERIFY"`
+	// SASLMechanism is plain, scram-sha-256 or scram-sha-512, the SASL
+	// authentication is disabled if it's empty.
+	SASLMechanism null.String `json:"saslMechanism,omitempty" envconfig:"K6_KAFKA_SASL_MECHANISM"`
+	SASLUsername  null.String `json:"saslUsername,omitempty" envconfig:"K6_KAFKA_SASL_USERNAME"`
+	SASLPassword  null.String `json:"saslPassword,omitempty" envconfig:"K6_KAFKA_SASL_PASSWORD"`
+
+	PushInterval types.NullDuration `json:"pushInterval,omitempty" envconfig:"K6_KAFKA_PUSH_INTERVAL"`
+	Timeout      types.NullDuration `json:"timeout,omitempty" envconfig:"K6_KAFKA_TIMEOUT"`
+}
+
+// newConfig creates a new config instance with the default values.
+func newConfig() config {
+	return config{
+		Format:                null.NewString(formatJSON, false),
+		TLS:                   null.NewBool(false, false),
+		InsecureSkipTLSVerify: null.NewBool(false, false),
+		PushInterval:          types.NewNullDuration(time.Second, false),
+		Timeout:               types.NewNullDuration(10*time.Second, false),
+	}
+}
+
+// Apply saves the non-zero config values from the passed config in the receiver.
+func (c config) Apply(cfg config) config {
+	if cfg.Brokers != nil {
+		c.Brokers = cfg.Brokers
+	}
+	if cfg.Topic.Valid {
+		c.Topic = cfg.Topic
+	}
+	if cfg.Format.Valid {
+		c.Format = cfg.Format
+	}
+	if cfg.TLS.Valid {
+		c.TLS = cfg.TLS
+	}
+	if cfg.InsecureSkipTLSVerify.Valid {
+		c.InsecureSkipTLSVerify = cfg.InsecureSkipTLSVerify
+	}
+	if cfg.SASLMechanism.Valid {
+		c.SASLMechanism = cfg.SASLMechanism
+	}
+	if cfg.SASLUsername.Valid {
+		c.SASLUsername = cfg.SASLUsername
+	}
+	if cfg.SASLPassword.Valid {
+		c.SASLPassword = cfg.SASLPassword
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+	if cfg.Timeout.Valid {
+		c.Timeout = cfg.Timeout
+	}
+
+	return c
+}
+
+func (c config) validate() error {
+	if len(c.Brokers) == 0 {
+		return errors.New("at least a broker is required")
+	}
+	if c.Topic.String == "" {
+		return errors.New("the topic is required")
+	}
+	switch c.Format.String {
+	case formatJSON, formatAvro, formatProtobuf:
+	default:
+		return fmt.Errorf("invalid format '%s', it can be %s, %s or %s",
+			c.Format.String, formatJSON, formatAvro, formatProtobuf)
+	}
+	switch c.SASLMechanism.String {
+	case "", saslPlain, saslScramSHA256, saslScramSHA512:
+	default:
+		return fmt.Errorf("invalid SASL mechanism '%s', it can be %s, %s or %s",
+			c.SASLMechanism.String, saslPlain, saslScramSHA256, saslScramSHA512)
+	}
+	if c.PushInterval.TimeDuration() <= 0 {
+		return errors.New("the push interval has to be positive")
+	}
+	if c.Timeout.TimeDuration() <= 0 {
+		return errors.New("the timeout has to be positive")
+	}
+	return nil
+}
+
+// parseArg parses the argument of --out kafka=brokers=...,topic=..., the
+// brokers can be a single one or a list in brackets or braces, e.g.
+// brokers={broker1:9092,broker2:9092}.
+func parseArg(arg string) (config, error) {
+	c := config{}
+	pairs, err := splitArg(arg)
+	if err != nil {
+		return c, err
+	}
+	for _, pair := range pairs {
+		r := strings.SplitN(pair, "=", 2)
+		if len(r) != 2 || r[1] == "" {
+			return c, fmt.Errorf("key '%s' with no value", r[0])
+		}
+		key, value := r[0], r[1]
+		switch key {
+		case "brokers":
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}")
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			c.Brokers = strings.Split(value, ",")
+		case "topic":
+			c.Topic = null.StringFrom(value)
+		case "format":
+			c.Format = null.StringFrom(value)
+		case "tls":
+			err = c.TLS.UnmarshalText([]byte(value))
+		case "insecureSkipTLSVerify":
+			err = c.InsecureSkipTLSVerify.UnmarshalText([]byte(value))
+		case "saslMechanism":
+			c.SASLMechanism = null.StringFrom(value)
+		case "saslUsername":
+			c.SASLUsername = null.StringFrom(value)
+		case "saslPassword":
+			c.SASLPassword = null.StringFrom(value)
+		case "pushInterval":
+			err = c.PushInterval.UnmarshalText([]byte(value))
+		case "timeout":
+			err = c.Timeout.UnmarshalText([]byte(value))
+		default:
+			return c, fmt.Errorf("unknown key '%s'", key)
+		}
+		if err != nil {
+			return c, fmt.Errorf("invalid value of '%s': %w", key, err)
+		}
+	}
+	return c, nil
+}
+
+// splitArg splits the argument on the commas which aren't in a list.
+func splitArg(arg string) ([]string, error) {
+	var (
+		pairs []string
+		start int
+		open  rune
+	)
+	for i, r := range arg {
+		switch {
+		case open == 0 && (r == '{' || r == '['):
+			open = r
+		case (open == '{' && r == '}') || (open == '[' && r == ']'):
+			open = 0
+		case open == 0 && r == ',':
+			pairs = append(pairs, arg[start:i])
+			start = i + 1
+		}
+	}
+	if open != 0 {
+		return nil, fmt.Errorf("the list starting with '%c' isn't closed", open)
+	}
+	return append(pairs, arg[start:]), nil
+}
+
+// getConsolidatedConfig combines {default config values + JSON config +
+// environment vars + argument}, and returns the final result.
+func getConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (config, error) {
+	result := newConfig()
+	if jsonRawConf != nil {
+		jsonConf := config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := parseArg(arg)
+		if err != nil {
+			return result, fmt.Errorf("invalid kafka output argument '%s': %w", arg, err)
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.validate()
+}
@@ -0,0 +1,114 @@
+package kafka
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	withDefaults := func(c config) config { return newConfig().Apply(c) }
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  config
+		errMsg  string
+	}{
+		"JSON": {
+			jsonRaw: json.RawMessage(`{"brokers":["b1:9092","b2:9092"],"topic":"k6","format":"avro"}`),
+			config: withDefaults(config{
+				Brokers: []string{"b1:9092", "b2:9092"},
+				Topic:   null.StringFrom("k6"),
+				Format:  null.StringFrom(formatAvro),
+			}),
+		},
+		"Env": {
+			env: map[string]string{
+				"K6_KAFKA_BROKERS":        "b1:9092,b2:9092",
+				"K6_KAFKA_TOPIC":          "k6",
+				"K6_KAFKA_TLS":            "true",
+				"K6_KAFKA_SASL_MECHANISM": "scram-sha-512",
+			},
+			config: withDefaults(config{
+				Brokers:       []string{"b1:9092", "b2:9092"},
+				Topic:         null.StringFrom("k6"),
+				TLS:           null.BoolFrom(true),
+				SASLMechanism: null.StringFrom(saslScramSHA512),
+			}),
+		},
+		"Arg": {
+			env: map[string]string{"K6_KAFKA_TOPIC": "other"},
+			arg: "brokers=b1:9092,topic=k6metrics,format=protobuf,pushInterval=2s",
+			config: withDefaults(config{
+				Brokers:      []string{"b1:9092"},
+				Topic:        null.StringFrom("k6metrics"),
+				Format:       null.StringFrom(formatProtobuf),
+				PushInterval: types.NullDurationFrom(2 * time.Second),
+			}),
+		},
+		"ArgBrokersList": {
+			arg: "brokers={b1:9092,b2:9092},topic=k6,saslMechanism=plain,saslUsername=k6,saslPassword=pass",
+			config: withDefaults(config{
+				Brokers:       []string{"b1:9092", "b2:9092"},
+				Topic:         null.StringFrom("k6"),
+				SASLMechanism: null.StringFrom(saslPlain),
+				SASLUsername:  null.StringFrom("k6"),
+				SASLPassword:  null.StringFrom("pass"),
+			}),
+		},
+		"ArgBrokersArray": {
+			arg: "topic=k6,brokers=[b1:9092,b2:9092]",
+			config: withDefaults(config{
+				Brokers: []string{"b1:9092", "b2:9092"},
+				Topic:   null.StringFrom("k6"),
+			}),
+		},
+		"ArgUnknownKey": {
+			arg:    "brokers=b1:9092,topic=k6,influxdb.tagsAsFields={url}",
+			errMsg: "invalid kafka output argument 'brokers=b1:9092,topic=k6,influxdb.tagsAsFields={url}': unknown key 'influxdb.tagsAsFields'",
+		},
+		"ArgUnclosedList": {
+			arg:    "brokers={b1:9092,topic=k6",
+			errMsg: "invalid kafka output argument 'brokers={b1:9092,topic=k6': the list starting with '{' isn't closed",
+		},
+		"NoBrokers": {
+			arg:    "topic=k6",
+			errMsg: "at least a broker is required",
+		},
+		"NoTopic": {
+			arg:    "brokers=b1:9092",
+			errMsg: "the topic is required",
+		},
+		"InvalidFormat": {
+			arg:    "brokers=b1:9092,topic=k6,format=influxdb",
+			errMsg: "invalid format 'influxdb', it can be json, avro or protobuf",
+		},
+		"InvalidSASLMechanism": {
+			arg:    "brokers=b1:9092,topic=k6,saslMechanism=gssapi",
+			errMsg: "invalid SASL mechanism 'gssapi', it can be plain, scram-sha-256 or scram-sha-512",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, err := getConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.errMsg != "" {
+				require.EqualError(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, c)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"go.k6.io/k6/metrics"
+)
+
+// avroSchema is the schema of the messages in the avro format.
+const avroSchema = `{
+  "type": "record",
+  "name": "Sample",
+  "namespace": "io.k6",
+  "fields": [
+    {"name": "metric", "type": "string"},
+    {"name": "type", "type": "string"},
+    {"name": "contains", "type": "string"},
+    {"name": "time", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+    {"name": "value", "type": "double"},
+    {"name": "tags", "type": {"type": "map", "values": "string"}},
+    {"name": "metadata", "type": {"type": "map", "values": "string"}}
+  ]
+}`
+
+// the field numbers of the Sample message of sample.proto
+const (
+	protoFieldMetric protowire.Number = iota + 1
+	protoFieldType
+	protoFieldContains
+	protoFieldTime
+	protoFieldValue
+	protoFieldTags
+	protoFieldMetadata
+)
+
+// encoder encodes a sample as the value of a message.
+type encoder func(metrics.Sample) ([]byte, error)
+
+// newEncoder returns the encoder of the format.
+func newEncoder(format string) encoder {
+	switch format {
+	case formatAvro:
+		return encodeAvro
+	case formatProtobuf:
+		return encodeProtobuf
+	default:
+		return encodeJSON
+	}
+}
+
+// contentType returns the content type of the messages of the format.
+func contentType(format string) string {
+	switch format {
+	case formatAvro:
+		return "avro/binary"
+	case formatProtobuf:
+		return "application/x-protobuf"
+	default:
+		return "application/json"
+	}
+}
+
+type jsonSample struct {
+	Metric   string            `json:"metric"`
+	Type     string            `json:"type"`
+	Contains string            `json:"contains"`
+	Time     time.Time         `json:"time"`
+	Value    float64           `json:"value"`
+	Tags     map[string]string `json:"tags"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func encodeJSON(s metrics.Sample) ([]byte, error) {
+	return json.Marshal(jsonSample{
+		Metric:   s.Metric.Name,
+		Type:     s.Metric.Type.String(),
+		Contains: s.Metric.Contains.String(),
+		Time:     s.Time,
+		Value:    s.Value,
+		Tags:     s.Tags.Map(),
+		Metadata: s.Metadata,
+	})
+}
+
+// encodeAvro encodes the sample with the Avro binary encoding of avroSchema.
+func encodeAvro(s metrics.Sample) ([]byte, error) {
+	b := make([]byte, 0, 128)
+	b = appendAvroString(b, s.Metric.Name)
+	b = appendAvroString(b, s.Metric.Type.String())
+	b = appendAvroString(b, s.Metric.Contains.String())
+	b = binary.AppendVarint(b, s.Time.UnixMicro())
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(s.Value))
+	b = appendAvroMap(b, s.Tags.Map())
+	b = appendAvroMap(b, s.Metadata)
+	return b, nil
+}
+
+func appendAvroString(b []byte, s string) []byte {
+	b = binary.AppendVarint(b, int64(len(s)))
+	return append(b, s...)
+}
+
+// appendAvroMap appends the map as a single block, sorted by key.
+func appendAvroMap(b []byte, m map[string]string) []byte {
+	if len(m) > 0 {
+		b = binary.AppendVarint(b, int64(len(m)))
+		for _, k := range sortedKeys(m) {
+			b = appendAvroString(b, k)
+			b = appendAvroString(b, m[k])
+		}
+	}
+	return binary.AppendVarint(b, 0)
+}
+
+// encodeProtobuf encodes the sample as the Sample message of sample.proto.
+func encodeProtobuf(s metrics.Sample) ([]byte, error) {
+	b := make([]byte, 0, 128)
+	b = protowire.AppendTag(b, protoFieldMetric, protowire.BytesType)
+	b = protowire.AppendString(b, s.Metric.Name)
+	b = protowire.AppendTag(b, protoFieldType, protowire.BytesType)
+	b = protowire.AppendString(b, s.Metric.Type.String())
+	b = protowire.AppendTag(b, protoFieldContains, protowire.BytesType)
+	b = protowire.AppendString(b, s.Metric.Contains.String())
+	b = protowire.AppendTag(b, protoFieldTime, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(s.Time.UnixNano()))
+	b = protowire.AppendTag(b, protoFieldValue, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(s.Value))
+	b = appendProtoMap(b, protoFieldTags, s.Tags.Map())
+	b = appendProtoMap(b, protoFieldMetadata, s.Metadata)
+	return b, nil
+}
+
+// appendProtoMap appends the entries of a map<string, string> field, sorted by key.
+func appendProtoMap(b []byte, field protowire.Number, m map[string]string) []byte {
+	for _, k := range sortedKeys(m) {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, m[k])
+
+		b = protowire.AppendTag(b, field, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,171 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/output"
+)
+
+// New creates a new Kafka output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := getConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{
+		config: conf,
+		logger: params.Logger.WithFields(logrus.Fields{"output": "kafka"}),
+		encode: newEncoder(conf.Format.String),
+	}, nil
+}
+
+var _ output.Output = &Output{}
+
+// messageWriter publishes the messages to the topic, it's implemented by
+// kafka.Writer.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Output periodically publishes the buffered metric samples to a Kafka topic,
+// a message for each sample keyed by the name of its metric.
+type Output struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+
+	config config
+	logger logrus.FieldLogger
+	writer messageWriter
+	encode encoder
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("kafka (%s, topic %s, %s)",
+		strings.Join(o.config.Brokers, ","), o.config.Topic.String, o.config.Format.String)
+}
+
+// Start creates the writer of the topic and starts the goroutine for the
+// periodic publishing of the samples.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	if o.writer == nil {
+		w, err := newWriter(o.config)
+		if err != nil {
+			return err
+		}
+		o.writer = w
+	}
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop publishes the remaining samples and closes the writer.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return o.writer.Close()
+}
+
+func (o *Output) flushMetrics() {
+	headers := []kafka.Header{{Key: "content-type", Value: []byte(contentType(o.config.Format.String))}}
+
+	var msgs []kafka.Message
+	for _, sc := range o.GetBufferedSamples() {
+		for _, s := range sc.GetSamples() {
+			value, err := o.encode(s)
+			if err != nil {
+				o.logger.WithError(err).Error("Couldn't encode the sample")
+				continue
+			}
+			msgs = append(msgs, kafka.Message{
+				Key:     []byte(s.Metric.Name),
+				Value:   value,
+				Headers: headers,
+				Time:    s.Time,
+			})
+		}
+	}
+	if len(msgs) == 0 {
+		return
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.Timeout.TimeDuration())
+	defer cancel()
+	if err := o.writer.WriteMessages(ctx, msgs...); err != nil {
+		o.logger.WithError(err).Error("Couldn't publish the samples")
+		return
+	}
+	o.logger.WithFields(logrus.Fields{
+		"t":        time.Since(start),
+		"messages": len(msgs),
+	}).Debug("Published the samples")
+}
+
+// newWriter creates the writer of the configured topic.
+func newWriter(conf config) (*kafka.Writer, error) {
+	transport := &kafka.Transport{}
+	if conf.TLS.Bool {
+		transport.TLS = &tls.Config{
+			InsecureSkipVerify: conf.InsecureSkipTLSVerify.Bool, //nolint:gosec
+			MinVersion:         tls.VersionTLS12,
+		}
+	}
+	mechanism, err := saslMechanism(conf)
+	if err != nil {
+		return nil, err
+	}
+	transport.SASL = mechanism
+
+	return &kafka.Writer{
+		Addr:         kafka.TCP(conf.Brokers...),
+		Topic:        conf.Topic.String,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: conf.Timeout.TimeDuration(),
+		RequiredAcks: kafka.RequireOne,
+		Transport:    transport,
+	}, nil
+}
+
+// saslMechanism returns the configured SASL mechanism, or nil if the SASL
+// authentication is disabled.
+func saslMechanism(conf config) (sasl.Mechanism, error) {
+	switch conf.SASLMechanism.String {
+	case saslPlain:
+		return plain.Mechanism{Username: conf.SASLUsername.String, Password: conf.SASLPassword.String}, nil
+	case saslScramSHA256:
+		return scram.Mechanism(scram.SHA256, conf.SASLUsername.String, conf.SASLPassword.String)
+	case saslScramSHA512:
+		return scram.Mechanism(scram.SHA512, conf.SASLUsername.String, conf.SASLPassword.String)
+	default:
+		return nil, nil //nolint:nilnil
+	}
+}
@@ -0,0 +1,201 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+type fakeWriter struct {
+	mu     sync.Mutex
+	msgs   []kafka.Message
+	closed bool
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func testSample(t *testing.T) metrics.Sample {
+	t.Helper()
+	registry := metrics.NewRegistry()
+	m := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	return metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: m,
+			Tags:   registry.RootTagSet().With("status", "200").With("method", "GET"),
+		},
+		Time:     time.Unix(10, 123456000),
+		Value:    145.25,
+		Metadata: map[string]string{lib.MetadataTraceID: "abc"},
+	}
+}
+
+func TestOutputFlushMetrics(t *testing.T) {
+	t.Parallel()
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "brokers=localhost:9092,topic=k6,format=protobuf",
+	})
+	require.NoError(t, err)
+	w := &fakeWriter{}
+	o.writer = w
+	require.NoError(t, o.Start())
+
+	s := testSample(t)
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{s, s}})
+	require.NoError(t, o.Stop())
+
+	assert.True(t, w.closed)
+	require.Len(t, w.msgs, 2)
+	msg := w.msgs[0]
+	assert.Equal(t, "http_req_duration", string(msg.Key))
+	assert.Equal(t, s.Time, msg.Time)
+	assert.Equal(t, []kafka.Header{{Key: "content-type", Value: []byte("application/x-protobuf")}}, msg.Headers)
+	encoded, err := encodeProtobuf(s)
+	require.NoError(t, err)
+	assert.Equal(t, encoded, msg.Value)
+}
+
+func TestEncodeJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := encodeJSON(testSample(t))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"metric": "http_req_duration",
+		"type": "trend",
+		"contains": "time",
+		"time": "1970-01-01T00:00:10.123456Z",
+		"value": 145.25,
+		"tags": {"method": "GET", "status": "200"},
+		"metadata": {"trace_id": "abc"}
+	}`, string(b))
+}
+
+func TestEncodeAvro(t *testing.T) {
+	t.Parallel()
+
+	b, err := encodeAvro(testSample(t))
+	require.NoError(t, err)
+
+	readLong := func() int64 {
+		v, n := binary.Varint(b)
+		require.Positive(t, n)
+		b = b[n:]
+		return v
+	}
+	readString := func() string {
+		l := readLong()
+		s := string(b[:l])
+		b = b[l:]
+		return s
+	}
+	readMap := func() map[string]string {
+		m := make(map[string]string)
+		for count := readLong(); count != 0; count = readLong() {
+			for i := int64(0); i < count; i++ {
+				k := readString()
+				m[k] = readString()
+			}
+		}
+		return m
+	}
+
+	assert.Equal(t, "http_req_duration", readString())
+	assert.Equal(t, "trend", readString())
+	assert.Equal(t, "time", readString())
+	assert.Equal(t, time.Unix(10, 123456000).UnixMicro(), readLong())
+	assert.Equal(t, 145.25, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	b = b[8:]
+	assert.Equal(t, map[string]string{"method": "GET", "status": "200"}, readMap())
+	assert.Equal(t, map[string]string{"trace_id": "abc"}, readMap())
+	assert.Empty(t, b)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(avroSchema), &schema))
+	assert.Len(t, schema["fields"], 7)
+}
+
+func TestEncodeProtobuf(t *testing.T) {
+	t.Parallel()
+
+	b, err := encodeProtobuf(testSample(t))
+	require.NoError(t, err)
+
+	fields := make(map[protowire.Number]string)
+	maps := make(map[protowire.Number]map[string]string)
+	var (
+		timeUnixNano uint64
+		value        float64
+	)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.Positive(t, n)
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			timeUnixNano, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			var v uint64
+			v, n = protowire.ConsumeFixed64(b)
+			value = math.Float64frombits(v)
+		case protowire.BytesType:
+			var v []byte
+			v, n = protowire.ConsumeBytes(b)
+			if num != protoFieldTags && num != protoFieldMetadata {
+				fields[num] = string(v)
+				break
+			}
+			if maps[num] == nil {
+				maps[num] = make(map[string]string)
+			}
+			_, _, kn := protowire.ConsumeTag(v)
+			k, kl := protowire.ConsumeString(v[kn:])
+			_, _, vn := protowire.ConsumeTag(v[kn+kl:])
+			mv, _ := protowire.ConsumeString(v[kn+kl+vn:])
+			maps[num][k] = mv
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		require.Positive(t, n)
+		b = b[n:]
+	}
+
+	assert.Equal(t, map[protowire.Number]string{
+		protoFieldMetric:   "http_req_duration",
+		protoFieldType:     "trend",
+		protoFieldContains: "time",
+	}, fields)
+	assert.Equal(t, uint64(time.Unix(10, 123456000).UnixNano()), timeUnixNano)
+	assert.Equal(t, 145.25, value)
+	assert.Equal(t, map[protowire.Number]map[string]string{
+		protoFieldTags:     {"method": "GET", "status": "200"},
+		protoFieldMetadata: {"trace_id": "abc"},
+	}, maps)
+}
@@ -2,6 +2,7 @@ package output
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -11,10 +12,33 @@ import (
 // TODO: completely get rid of this, see https://github.com/grafana/k6/issues/2430
 const sendBatchToOutputsRate = 50 * time.Millisecond
 
+// outputRingSize is the number of the batches of the samples that can wait for
+// an output, which is a few seconds' worth of them.
+const outputRingSize = 128
+
 // Manager can be used to manage multiple outputs at the same time.
 type Manager struct {
-	outputs []Output
-	logger  logrus.FieldLogger
+	outputs          []Output
+	logger           logrus.FieldLogger
+	cardinalityGuard *CardinalityGuard
+
+	// the metric of the durations of the flushes to the outputs, and their
+	// tags, if the self metrics are enabled
+	flushDuration *metrics.Metric
+	flushTags     []*metrics.TagSet
+
+	// the metric of the samples dropped for the outputs, and its tags
+	droppedSamples *metrics.Metric
+	droppedTags    *metrics.TagSet
+
+	// whether all of the outputs are waited for, even the ones
+	// WithDroppedSamples
+	keepAllSamples bool
+	ringSize       int
+
+	// the samples about the outputs themselves, sent with the next batch
+	selfSamplesMx sync.Mutex
+	selfSamples   metrics.Samples
 
 	testStopCallback func(error)
 }
@@ -25,53 +49,106 @@ func NewManager(outputs []Output, logger logrus.FieldLogger, testStopCallback fu
 		outputs:          outputs,
 		logger:           logger.WithField("component", "output-manager"),
 		testStopCallback: testStopCallback,
+		ringSize:         outputRingSize,
+	}
+}
+
+// SetCardinalityGuard sets the guard of the time series of the samples sent to
+// the outputs, it has to be called before Start().
+func (om *Manager) SetCardinalityGuard(guard *CardinalityGuard) {
+	om.cardinalityGuard = guard
+}
+
+// SetFlushDurationMetric enables the emission of the durations of the flushes
+// of the samples to each output, tagged with its description, it has to be
+// called before Start().
+func (om *Manager) SetFlushDurationMetric(metric *metrics.Metric, tags *metrics.TagSet) {
+	om.flushDuration = metric
+	om.flushTags = make([]*metrics.TagSet, len(om.outputs))
+	for i, out := range om.outputs {
+		om.flushTags[i] = tags.With("output", out.Description())
 	}
 }
 
-// Start spins up all configured outputs and then starts a new goroutine that
-// pipes metrics from the given samples channel to them.
+// SetDroppedSamplesMetric enables the emission of the number of the samples
+// dropped for each output, tagged with its description, it has to be called
+// before Start().
+func (om *Manager) SetDroppedSamplesMetric(metric *metrics.Metric, tags *metrics.TagSet) {
+	om.droppedSamples = metric
+	om.droppedTags = tags
+}
+
+// KeepAllSamples makes the Manager wait for all of the outputs, even the ones
+// WithDroppedSamples, like when the samples are replayed from files and there
+// are no VUs to slow down. It has to be called before Start().
+func (om *Manager) KeepAllSamples() {
+	om.keepAllSamples = true
+}
+
+// Start spins up all configured outputs and then starts a new goroutine for
+// every given samples channel, which pipes the metrics from it to them. The
+// producers of the samples can send them to different channels, e.g. the VUs
+// by their IDs, so they don't all contend for the same one.
+//
+// Every output gets the batches of the samples through a ring buffer of its
+// own, which it drains from a goroutine of its own, so a slow output doesn't
+// block the others until its ring is full. Then the Manager waits for it,
+// slowing down the whole test run, unless it's an output WithDroppedSamples,
+// whose batches are dropped and counted with the k6_dropped_samples metric.
 //
 // If some output fails to start, it stops the already started ones. This may
 // take some time, since some outputs make initial network requests to set up
 // whatever remote services are going to listen to them.
 //
 // If all outputs start successfully, this method will return 2 callbacks. The
-// first one, wait(), will block until the samples channel has been closed and
-// all of its buffered metrics have been sent to all outputs. The second
-// callback will call the Stop() or StopWithTestError() method of every output.
-func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(), finish func(error), err error) {
+// first one, wait(), will block until all of the samples channels have been
+// closed and all of their buffered metrics have been sent to all outputs. The
+// second callback will call the Stop() or StopWithTestError() method of every
+// output.
+func (om *Manager) Start(samplesChans ...chan metrics.SampleContainer) (wait func(), finish func(error), err error) {
 	if err := om.startOutputs(); err != nil {
 		return nil, nil, err
 	}
 
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-
-	sendToOutputs := func(sampleContainers []metrics.SampleContainer) {
-		for _, out := range om.outputs {
-			out.AddMetricSamples(sampleContainers)
+	queues := make([]*outputQueue, len(om.outputs))
+	for i, out := range om.outputs {
+		dropping, ok := out.(WithDroppedSamples)
+		queues[i] = &outputQueue{
+			out:      out,
+			ring:     newSampleRing(om.ringSize),
+			lossless: !ok || !dropping.DropsSamples() || om.keepAllSamples,
+			full:     make(chan struct{}, 1),
+			drained:  make(chan struct{}),
 		}
 	}
 
+	drained := make(chan struct{})
+	drainersWG := &sync.WaitGroup{}
+	for i, q := range queues {
+		drainersWG.Add(1)
+		go func(i int, q *outputQueue) {
+			defer drainersWG.Done()
+			om.drainQueue(i, q, drained)
+		}(i, q)
+	}
+
+	dispatchersWG := &sync.WaitGroup{}
+	for _, samplesChan := range samplesChans {
+		dispatchersWG.Add(1)
+		go func(samplesChan chan metrics.SampleContainer) {
+			defer dispatchersWG.Done()
+			om.dispatchFrom(samplesChan, queues)
+		}(samplesChan)
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		ticker := time.NewTicker(sendBatchToOutputsRate)
-		defer ticker.Stop()
-
-		buffer := make([]metrics.SampleContainer, 0, cap(samplesChan))
-		for {
-			select {
-			case sampleContainer, ok := <-samplesChan:
-				if !ok {
-					sendToOutputs(buffer)
-					return
-				}
-				buffer = append(buffer, sampleContainer)
-			case <-ticker.C:
-				sendToOutputs(buffer)
-				buffer = make([]metrics.SampleContainer, 0, cap(buffer))
-			}
-		}
+		dispatchersWG.Wait()
+		close(drained)
+		drainersWG.Wait()
+		om.logDroppedSamples(queues)
 	}()
 
 	wait = wg.Wait
@@ -82,6 +159,191 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 	return wait, finish, nil
 }
 
+// dispatchFrom batches the samples of the channel, and pushes the batches to
+// the queues of the outputs periodically and once the channel is closed.
+func (om *Manager) dispatchFrom(samplesChan chan metrics.SampleContainer, queues []*outputQueue) {
+	ticker := time.NewTicker(sendBatchToOutputsRate)
+	defer ticker.Stop()
+
+	buffer := make([]metrics.SampleContainer, 0, cap(samplesChan))
+	for {
+		select {
+		case sampleContainer, ok := <-samplesChan:
+			if !ok {
+				om.dispatch(queues, buffer)
+				return
+			}
+			buffer = append(buffer, sampleContainer)
+		case <-ticker.C:
+			om.dispatch(queues, buffer)
+			buffer = make([]metrics.SampleContainer, 0, cap(buffer))
+			buffer = append(buffer, om.takeSelfSamples(queues)...)
+		}
+	}
+}
+
+// outputQueue is the ring buffer of the batches of the samples for an output.
+type outputQueue struct {
+	out      Output
+	ring     *sampleRing
+	lossless bool
+
+	// full wakes up the drainer of the ring when it's full, and drained is
+	// closed, and replaced, once it has drained the ring, so the dispatchers
+	// of a lossless output can wait for it
+	full      chan struct{}
+	drainedMx sync.Mutex
+	drained   chan struct{}
+
+	// the number of the samples dropped since the last time they were
+	// emitted, and in total
+	dropped, totalDropped uint64
+}
+
+// dispatch pushes the batch of the samples to the ring of every output.
+func (om *Manager) dispatch(queues []*outputQueue, batch []metrics.SampleContainer) {
+	if om.cardinalityGuard != nil {
+		batch = om.cardinalityGuard.Guard(batch)
+	}
+	if len(batch) == 0 {
+		return
+	}
+	for _, q := range queues {
+		for {
+			// the signal is taken before the push, so a drain in between
+			// isn't missed
+			drained := q.drainedSignal()
+			if q.ring.push(batch) {
+				break
+			}
+			if q.lossless {
+				select {
+				case q.full <- struct{}{}:
+				default: // the drainer was already woken up
+				}
+				<-drained
+				continue
+			}
+			n := countSamples(batch)
+			if atomic.AddUint64(&q.totalDropped, n) == n {
+				om.logger.Warnf("The output %s can't keep up with the metric samples, so some of them are "+
+					"dropped for it", q.out.Description())
+			}
+			atomic.AddUint64(&q.dropped, n)
+			break
+		}
+	}
+}
+
+// drainedSignal returns a channel that is closed the next time the ring of the
+// output is drained.
+func (q *outputQueue) drainedSignal() <-chan struct{} {
+	q.drainedMx.Lock()
+	defer q.drainedMx.Unlock()
+	return q.drained
+}
+
+// signalDrained wakes up the dispatchers that wait for the ring to be drained.
+func (q *outputQueue) signalDrained() {
+	q.drainedMx.Lock()
+	defer q.drainedMx.Unlock()
+	close(q.drained)
+	q.drained = make(chan struct{})
+}
+
+// drainQueue sends the batches in the ring of the output to it, periodically,
+// when the ring is full, and one last time when drained is closed.
+func (om *Manager) drainQueue(i int, q *outputQueue, drained <-chan struct{}) {
+	ticker := time.NewTicker(sendBatchToOutputsRate)
+	defer ticker.Stop()
+
+	var buf []metrics.SampleContainer
+	flush := func() {
+		buf = q.ring.drain(buf[:0])
+		q.signalDrained()
+		if len(buf) == 0 {
+			return
+		}
+		// the batches are passed to the output in a new slice, since it may
+		// keep it
+		batch := make([]metrics.SampleContainer, len(buf))
+		copy(batch, buf)
+		start := time.Now()
+		q.out.AddMetricSamples(batch)
+		if om.flushDuration != nil {
+			om.addSelfSample(metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: om.flushDuration, Tags: om.flushTags[i]},
+				Time:       start,
+				Value:      metrics.D(time.Since(start)),
+			})
+		}
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			flush()
+		case <-q.full:
+			flush()
+		case <-drained:
+			flush()
+			return
+		}
+	}
+}
+
+// addSelfSample adds a sample about the outputs themselves, which is sent to
+// them with the next batch.
+func (om *Manager) addSelfSample(sample metrics.Sample) {
+	om.selfSamplesMx.Lock()
+	om.selfSamples = append(om.selfSamples, sample)
+	om.selfSamplesMx.Unlock()
+}
+
+// takeSelfSamples returns the samples about the outputs since the last call,
+// including the ones of the samples dropped for them.
+func (om *Manager) takeSelfSamples(queues []*outputQueue) []metrics.SampleContainer {
+	now := time.Now()
+	for _, q := range queues {
+		if dropped := atomic.SwapUint64(&q.dropped, 0); dropped > 0 && om.droppedSamples != nil {
+			om.addSelfSample(metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: om.droppedSamples,
+					Tags:   om.droppedTags.With("output", q.out.Description()),
+				},
+				Time:  now,
+				Value: float64(dropped),
+			})
+		}
+	}
+
+	om.selfSamplesMx.Lock()
+	defer om.selfSamplesMx.Unlock()
+	if len(om.selfSamples) == 0 {
+		return nil
+	}
+	samples := om.selfSamples
+	om.selfSamples = nil
+	return []metrics.SampleContainer{samples}
+}
+
+func (om *Manager) logDroppedSamples(queues []*outputQueue) {
+	for _, q := range queues {
+		if dropped := atomic.LoadUint64(&q.totalDropped); dropped > 0 {
+			om.logger.Warnf("The output %s dropped %d metric samples, since it couldn't keep up with them",
+				q.out.Description(), dropped)
+		}
+	}
+}
+
+func countSamples(batch []metrics.SampleContainer) uint64 {
+	var count uint64
+	for _, sc := range batch {
+		count += uint64(len(sc.GetSamples()))
+	}
+	return count
+}
+
 // startOutputs spins up all configured outputs. If some output fails to start,
 // it stops the already started ones. This may take some time, since some
 // outputs make initial network requests to set up whatever remote services are
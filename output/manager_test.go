@@ -0,0 +1,146 @@
+package output
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+type blockingOutput struct {
+	SampleBuffer
+	description string
+	unblock     chan struct{}
+}
+
+func (o *blockingOutput) Description() string { return o.description }
+func (o *blockingOutput) Start() error        { return nil }
+func (o *blockingOutput) Stop() error         { return nil }
+
+func (o *blockingOutput) AddMetricSamples(samples []metrics.SampleContainer) {
+	<-o.unblock
+	o.SampleBuffer.AddMetricSamples(samples)
+}
+
+type droppingOutput struct {
+	blockingOutput
+}
+
+func (o *droppingOutput) DropsSamples() bool { return true }
+
+func countBuffered(samples []metrics.SampleContainer, metric *metrics.Metric) (count int) {
+	for _, sc := range samples {
+		for _, s := range sc.GetSamples() {
+			if s.Metric == metric {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestManagerDropsSamplesForSlowOutputs(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metric, err := registry.NewMetric("my_metric", metrics.Counter)
+	require.NoError(t, err)
+	dropped, err := registry.NewMetric("dropped", metrics.Counter)
+	require.NoError(t, err)
+
+	slow := &droppingOutput{blockingOutput{description: "slow", unblock: make(chan struct{})}}
+	lossless := &blockingOutput{description: "lossless", unblock: make(chan struct{})}
+	logger, hook := test.NewNullLogger()
+	// the wrappers of the outputs still drop their samples
+	om := NewManager([]Output{WithFilter(slow, lib.OutputFilter{}), lossless}, logger, nil)
+	om.SetDroppedSamplesMetric(dropped, registry.RootTagSet())
+	om.ringSize = 8
+
+	samples := make(chan metrics.SampleContainer, 10)
+	wait, finish, err := om.Start(samples)
+	require.NoError(t, err)
+
+	// the lossless output is unblocked after a while, the slow one only at
+	// the end, so its ring fills up
+	const sent = 200
+	go func() {
+		time.Sleep(time.Second)
+		close(lossless.unblock)
+	}()
+	for i := 0; i < sent; i++ {
+		samples <- metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet()}}
+		time.Sleep(sendBatchToOutputsRate / 2)
+	}
+	close(slow.unblock)
+	close(samples)
+	wait()
+	finish(nil)
+
+	losslessSamples := lossless.GetBufferedSamples()
+	assert.Equal(t, sent, countBuffered(losslessSamples, metric))
+	assert.Positive(t, countBuffered(losslessSamples, dropped))
+	received := countBuffered(slow.GetBufferedSamples(), metric)
+	assert.Less(t, received, sent)
+	assert.Positive(t, received)
+
+	var warnings []string
+	for _, e := range hook.AllEntries() {
+		if e.Level == logrus.WarnLevel {
+			warnings = append(warnings, e.Message)
+		}
+	}
+	assert.Contains(t, warnings, "The output slow can't keep up with the metric samples, so some of them are "+
+		"dropped for it")
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[1], "The output slow dropped ")
+}
+
+func TestManagerSamplesChannels(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metric, err := registry.NewMetric("my_metric", metrics.Counter)
+	require.NoError(t, err)
+
+	// the output is slower than the samples, but none of them are dropped
+	unblock := make(chan struct{})
+	out := &blockingOutput{description: "slow", unblock: unblock}
+	om := NewManager([]Output{out}, testutils.NewLogger(t), nil)
+	om.ringSize = 2
+
+	channels := make([]chan metrics.SampleContainer, 4)
+	for i := range channels {
+		channels[i] = make(chan metrics.SampleContainer, 10)
+	}
+	wait, finish, err := om.Start(channels...)
+	require.NoError(t, err)
+
+	const sentPerChannel = 50
+	var wg sync.WaitGroup
+	for _, ch := range channels {
+		wg.Add(1)
+		go func(ch chan metrics.SampleContainer) {
+			defer wg.Done()
+			for i := 0; i < sentPerChannel; i++ {
+				ch <- metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet()}}
+				time.Sleep(sendBatchToOutputsRate / 5)
+			}
+			close(ch)
+		}(ch)
+	}
+	time.Sleep(time.Second)
+	close(unblock)
+	wg.Wait()
+	wait()
+	finish(nil)
+
+	assert.Equal(t, len(channels)*sentPerChannel, countBuffered(out.GetBufferedSamples(), metric))
+}
@@ -0,0 +1,50 @@
+package output
+
+import (
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// TestRunOptions are the options of a test run that the outputs can store with
+// its results. They describe the test, but they don't have the secrets that the
+// whole options can have, like the keys and passwords of the TLS certificates,
+// the tokens of the cloud and the extensions, or the env of the scenarios.
+type TestRunOptions struct {
+	VUs        null.Int           `json:"vus"`
+	Duration   types.NullDuration `json:"duration"`
+	Iterations null.Int           `json:"iterations"`
+	Stages     []lib.Stage        `json:"stages"`
+	// Scenarios are the executors of the scenarios, by name.
+	Scenarios  map[string]string             `json:"scenarios"`
+	Thresholds map[string]metrics.Thresholds `json:"thresholds"`
+	Tags       map[string]string             `json:"tags"`
+
+	TestName        null.String `json:"testName"`
+	RunID           null.String `json:"runId"`
+	TestVersion     null.String `json:"testVersion"`
+	TestEnvironment null.String `json:"testEnvironment"`
+}
+
+// NewTestRunOptions returns the options of the test run that can be stored.
+func NewTestRunOptions(opts lib.Options) TestRunOptions {
+	scenarios := make(map[string]string, len(opts.Scenarios))
+	for name, conf := range opts.Scenarios {
+		scenarios[name] = conf.GetType()
+	}
+	return TestRunOptions{
+		VUs:             opts.VUs,
+		Duration:        opts.Duration,
+		Iterations:      opts.Iterations,
+		Stages:          opts.Stages,
+		Scenarios:       scenarios,
+		Thresholds:      opts.Thresholds,
+		Tags:            opts.RunTags,
+		TestName:        opts.TestName,
+		RunID:           opts.RunID,
+		TestVersion:     opts.TestVersion,
+		TestEnvironment: opts.TestEnvironment,
+	}
+}
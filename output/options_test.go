@@ -0,0 +1,43 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+)
+
+func TestNewTestRunOptions(t *testing.T) {
+	t.Parallel()
+
+	scenario := executor.NewConstantVUsConfig("default")
+	scenario.Env = map[string]string{"API_TOKEN": "scenario-secret"}
+	opts := lib.Options{
+		VUs:       null.IntFrom(10),
+		Scenarios: lib.ScenarioConfigs{"default": scenario},
+		TLSAuth: []*lib.TLSAuth{{TLSAuthFields: lib.TLSAuthFields{
+			Cert: "cert-secret", Key: "key-secret", Password: null.StringFrom("password-secret"),
+		}}},
+		TLSClientCerts: &lib.TLSClientCerts{PKCS12: "certs.p12", Password: "pkcs12-secret"},
+		Cloud:          json.RawMessage(`{"token":"cloud-secret"}`),
+		External:       map[string]json.RawMessage{"ext": json.RawMessage(`{"token":"ext-secret"}`)},
+		RunTags:        map[string]string{"team": "qa"},
+		TestName:       null.StringFrom("checkout"),
+	}
+
+	b, err := json.Marshal(NewTestRunOptions(opts))
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "secret")
+
+	var got TestRunOptions
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, null.IntFrom(10), got.VUs)
+	assert.Equal(t, map[string]string{"default": "constant-vus"}, got.Scenarios)
+	assert.Equal(t, map[string]string{"team": "qa"}, got.Tags)
+	assert.Equal(t, null.StringFrom("checkout"), got.TestName)
+}
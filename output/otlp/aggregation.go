@@ -0,0 +1,288 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"sort"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// series is the aggregated data of a time series, since its start or, with the
+// delta temporality, since the last export.
+type series struct {
+	attributes []*commonpb.KeyValue
+	start      time.Time
+	// updated is whether samples were added since the last export
+	updated bool
+
+	// counters and trends
+	sum float64
+	// gauges
+	value     float64
+	valueTime time.Time
+	// rates
+	nonZero, total uint64
+	// trends
+	count        uint64
+	min, max     float64
+	bucketCounts []uint64
+
+	// exemplar links the data point to the trace of a sample of a request
+	// since the last export, the slowest one for the trends
+	exemplar *metricpb.Exemplar
+}
+
+// aggregator aggregates the samples of the metrics for their export.
+type aggregator struct {
+	delta   bool
+	buckets []float64
+
+	series map[*metrics.Metric]map[*metrics.TagSet]*series
+}
+
+func newAggregator(delta bool, buckets []float64) *aggregator {
+	return &aggregator{
+		delta:   delta,
+		buckets: buckets,
+		series:  make(map[*metrics.Metric]map[*metrics.TagSet]*series),
+	}
+}
+
+func (a *aggregator) add(sample metrics.Sample) {
+	byTags, ok := a.series[sample.Metric]
+	if !ok {
+		byTags = make(map[*metrics.TagSet]*series)
+		a.series[sample.Metric] = byTags
+	}
+	s, ok := byTags[sample.Tags]
+	if !ok {
+		s = &series{attributes: attributes(sample.Tags.Map()), start: sample.Time}
+		if sample.Metric.Type == metrics.Trend {
+			s.bucketCounts = make([]uint64, len(a.buckets)+1)
+		}
+		byTags[sample.Tags] = s
+	}
+	s.updated = true
+
+	switch sample.Metric.Type {
+	case metrics.Counter:
+		s.sum += sample.Value
+	case metrics.Gauge:
+		s.value, s.valueTime = sample.Value, sample.Time
+	case metrics.Rate:
+		s.total++
+		if sample.Value != 0 {
+			s.nonZero++
+		}
+	case metrics.Trend:
+		if s.count == 0 || sample.Value < s.min {
+			s.min = sample.Value
+		}
+		if s.count == 0 || sample.Value > s.max {
+			s.max = sample.Value
+		}
+		s.count++
+		s.sum += sample.Value
+		// the bucket i counts the values in (buckets[i-1], buckets[i]]
+		s.bucketCounts[sort.SearchFloat64s(a.buckets, sample.Value)]++
+	}
+
+	switch sample.Metric.Type { //nolint:exhaustive // the gauges and rates don't get exemplars
+	case metrics.Counter:
+		s.setExemplar(sample)
+	case metrics.Trend:
+		if s.exemplar == nil || sample.Value > s.exemplar.GetAsDouble() {
+			s.setExemplar(sample)
+		}
+	}
+}
+
+// setExemplar sets the exemplar of the series to the sample, if it has the IDs
+// of the trace and of the span of its request.
+func (s *series) setExemplar(sample metrics.Sample) {
+	traceID, err := hex.DecodeString(sample.Metadata[lib.MetadataTraceID])
+	if err != nil || len(traceID) != 16 {
+		return
+	}
+	spanID, err := hex.DecodeString(sample.Metadata[lib.MetadataSpanID])
+	if err != nil || len(spanID) != 8 {
+		return
+	}
+	s.exemplar = &metricpb.Exemplar{
+		TimeUnixNano: uint64(sample.Time.UnixNano()),
+		Value:        &metricpb.Exemplar_AsDouble{AsDouble: sample.Value},
+		TraceId:      traceID,
+		SpanId:       spanID,
+	}
+}
+
+// collect returns the metrics with the aggregated data of their time series at
+// the time of the export. With the delta temporality, only the series updated
+// since the last export are returned. The data isn't reset until exported is
+// called, so the deltas of a failed export are sent with the next one.
+func (a *aggregator) collect(now time.Time) []*metricpb.Metric {
+	temporality := metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	if a.delta {
+		temporality = metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	}
+
+	result := make([]*metricpb.Metric, 0, len(a.series))
+	for metric, byTags := range a.series {
+		m := &metricpb.Metric{Name: metric.Name, Unit: unit(metric), Description: metric.Description}
+		var (
+			numbers    []*metricpb.NumberDataPoint
+			histograms []*metricpb.HistogramDataPoint
+		)
+		for _, s := range byTags {
+			if a.delta && !s.updated {
+				continue
+			}
+			if metric.Type == metrics.Trend {
+				histograms = append(histograms, a.histogramDataPoint(s, now))
+			} else {
+				numbers = append(numbers, numberDataPoint(metric.Type, s, now))
+			}
+		}
+		if len(numbers) == 0 && len(histograms) == 0 {
+			continue
+		}
+
+		switch metric.Type {
+		case metrics.Counter:
+			m.Data = &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+				DataPoints:             numbers,
+				AggregationTemporality: temporality,
+				IsMonotonic:            true,
+			}}
+		case metrics.Gauge, metrics.Rate:
+			m.Data = &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: numbers}}
+		case metrics.Trend:
+			m.Data = &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+				DataPoints:             histograms,
+				AggregationTemporality: temporality,
+			}}
+		}
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// exported resets the series after a successful export: their exemplars and,
+// with the delta temporality, their aggregated data, for the next delta.
+func (a *aggregator) exported(now time.Time) {
+	for _, byTags := range a.series {
+		for _, s := range byTags {
+			if !s.updated {
+				continue
+			}
+			s.updated = false
+			s.exemplar = nil
+			if a.delta {
+				s.reset(now)
+			}
+		}
+	}
+}
+
+// reset the aggregated data, for the next delta. The value of a gauge isn't
+// reset, since it isn't aggregated.
+func (s *series) reset(now time.Time) {
+	s.start = now
+	s.sum = 0
+	s.nonZero, s.total = 0, 0
+	s.count, s.min, s.max = 0, 0, 0
+	for i := range s.bucketCounts {
+		s.bucketCounts[i] = 0
+	}
+}
+
+func numberDataPoint(mt metrics.MetricType, s *series, now time.Time) *metricpb.NumberDataPoint {
+	dp := &metricpb.NumberDataPoint{
+		Attributes:        s.attributes,
+		StartTimeUnixNano: uint64(s.start.UnixNano()),
+		TimeUnixNano:      uint64(now.UnixNano()),
+	}
+	var value float64
+	switch mt { //nolint:exhaustive // the trends are histograms
+	case metrics.Counter:
+		value = s.sum
+	case metrics.Gauge:
+		value = s.value
+		dp.StartTimeUnixNano = 0 // the gauges don't have a start time
+		dp.TimeUnixNano = uint64(s.valueTime.UnixNano())
+	case metrics.Rate:
+		// the ratio of the non-zero samples, like the k6 summary shows it
+		if s.total > 0 {
+			value = float64(s.nonZero) / float64(s.total)
+		}
+		dp.StartTimeUnixNano = 0
+	}
+	dp.Value = &metricpb.NumberDataPoint_AsDouble{AsDouble: value}
+	if s.exemplar != nil {
+		dp.Exemplars = []*metricpb.Exemplar{s.exemplar}
+	}
+	return dp
+}
+
+func (a *aggregator) histogramDataPoint(s *series, now time.Time) *metricpb.HistogramDataPoint {
+	dp := &metricpb.HistogramDataPoint{
+		Attributes:        s.attributes,
+		StartTimeUnixNano: uint64(s.start.UnixNano()),
+		TimeUnixNano:      uint64(now.UnixNano()),
+		Count:             s.count,
+		BucketCounts:      append([]uint64(nil), s.bucketCounts...),
+		ExplicitBounds:    a.buckets,
+	}
+	sum := s.sum
+	dp.Sum = &sum
+	if s.count > 0 {
+		minimum, maximum := s.min, s.max
+		dp.Min, dp.Max = &minimum, &maximum
+	}
+	if s.exemplar != nil {
+		dp.Exemplars = []*metricpb.Exemplar{s.exemplar}
+	}
+	return dp
+}
+
+// unit returns the UCUM unit of the metric, as the semantic conventions of
+// OpenTelemetry recommend, or the unit that was set for the custom metric.
+func unit(m *metrics.Metric) string {
+	switch {
+	case m.Contains == metrics.Time:
+		return "ms"
+	case m.Contains == metrics.Data:
+		return "By"
+	case m.Type == metrics.Rate:
+		return "1"
+	default:
+		return m.Unit
+	}
+}
+
+func attributes(tags map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, stringAttribute(k, tags[k]))
+	}
+	return attrs
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
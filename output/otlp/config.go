@@ -0,0 +1,201 @@
+// Package otlp implements an output that exports the k6 metrics to an
+// OpenTelemetry collector, or any other backend supporting the OpenTelemetry
+// protocol (OTLP), via gRPC or HTTP.
+package otlp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+const (
+	protocolGRPC = "grpc"
+	protocolHTTP = "http"
+
+	temporalityCumulative = "cumulative"
+	temporalityDelta      = "delta"
+
+	defaultGRPCEndpoint = "localhost:4317"
+	defaultHTTPEndpoint = "localhost:4318"
+)
+
+// config is the configuration of the OTLP output.
+type config struct {
+	// Protocol is grpc or http, the latter sends protobuf-encoded requests.
+	Protocol null.String `json:"protocol,omitempty" envconfig:"K6_OTLP_PROTOCOL"`
+	// Endpoint is the host:port of the receiver, it defaults to the standard
+	// port of the protocol on localhost.
+	Endpoint null.String `json:"endpoint,omitempty" envconfig:"K6_OTLP_ENDPOINT"`
+	// URLPath is the path of the receiver, for the http protocol.
+	URLPath  null.String       `json:"urlPath,omitempty" envconfig:"K6_OTLP_URL_PATH"`
+	Insecure null.Bool         `json:"insecure,omitempty" envconfig:"K6_OTLP_INSECURE"`
+	Headers  map[string]string `json:"headers,omitempty" envconfig:"K6_OTLP_HEADERS"`
+	// ResourceAttributes are added to the resource of the exported metrics,
+	// in addition to the service name and version.
+	ResourceAttributes map[string]string `json:"resourceAttributes,omitempty" envconfig:"K6_OTLP_RESOURCE_ATTRIBUTES"`
+	// Temporality is cumulative or delta, for the sums and the histograms.
+	Temporality null.String `json:"temporality,omitempty" envconfig:"K6_OTLP_TEMPORALITY"`
+	// HistogramBuckets are the explicit bounds of the histograms of the trend
+	// metrics, in the unit of the metric (e.g. milliseconds for the times).
+	HistogramBuckets []float64          `json:"histogramBuckets,omitempty" envconfig:"K6_OTLP_HISTOGRAM_BUCKETS"`
+	PushInterval     types.NullDuration `json:"pushInterval,omitempty" envconfig:"K6_OTLP_PUSH_INTERVAL"`
+	Timeout          types.NullDuration `json:"timeout,omitempty" envconfig:"K6_OTLP_TIMEOUT"`
+}
+
+// newConfig creates a new config instance with the default values.
+func newConfig() config {
+	return config{
+		Protocol:    null.NewString(protocolGRPC, false),
+		URLPath:     null.NewString("/v1/metrics", false),
+		Insecure:    null.NewBool(false, false),
+		Temporality: null.NewString(temporalityCumulative, false),
+		HistogramBuckets: []float64{
+			0, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000,
+		},
+		PushInterval: types.NewNullDuration(5*time.Second, false),
+		Timeout:      types.NewNullDuration(10*time.Second, false),
+	}
+}
+
+// Apply saves the non-zero config values from the passed config in the receiver.
+func (c config) Apply(cfg config) config {
+	if cfg.Protocol.Valid {
+		c.Protocol = cfg.Protocol
+	}
+	if cfg.Endpoint.Valid {
+		c.Endpoint = cfg.Endpoint
+	}
+	if cfg.URLPath.Valid {
+		c.URLPath = cfg.URLPath
+	}
+	if cfg.Insecure.Valid {
+		c.Insecure = cfg.Insecure
+	}
+	if cfg.Headers != nil {
+		c.Headers = cfg.Headers
+	}
+	if cfg.ResourceAttributes != nil {
+		c.ResourceAttributes = cfg.ResourceAttributes
+	}
+	if cfg.Temporality.Valid {
+		c.Temporality = cfg.Temporality
+	}
+	if cfg.HistogramBuckets != nil {
+		c.HistogramBuckets = cfg.HistogramBuckets
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+	if cfg.Timeout.Valid {
+		c.Timeout = cfg.Timeout
+	}
+
+	return c
+}
+
+// endpoint returns the configured endpoint, or the default one of the protocol.
+func (c config) endpoint() string {
+	if c.Endpoint.String != "" {
+		return c.Endpoint.String
+	}
+	if c.Protocol.String == protocolHTTP {
+		return defaultHTTPEndpoint
+	}
+	return defaultGRPCEndpoint
+}
+
+func (c config) validate() error {
+	switch c.Protocol.String {
+	case protocolGRPC, protocolHTTP:
+	default:
+		return fmt.Errorf("invalid protocol '%s', it can be %s or %s", c.Protocol.String, protocolGRPC, protocolHTTP)
+	}
+	switch c.Temporality.String {
+	case temporalityCumulative, temporalityDelta:
+	default:
+		return fmt.Errorf("invalid temporality '%s', it can be %s or %s",
+			c.Temporality.String, temporalityCumulative, temporalityDelta)
+	}
+	if !sort.Float64sAreSorted(c.HistogramBuckets) {
+		return errors.New("the histogram buckets have to be sorted in increasing order")
+	}
+	for i := 1; i < len(c.HistogramBuckets); i++ {
+		if c.HistogramBuckets[i] == c.HistogramBuckets[i-1] {
+			return fmt.Errorf("the histogram bucket %v is duplicated", c.HistogramBuckets[i])
+		}
+	}
+	if c.PushInterval.TimeDuration() <= 0 {
+		return errors.New("the push interval has to be positive")
+	}
+	return nil
+}
+
+// parseArg parses the argument of --out otlp=<endpoint>. An URL with the http
+// or https scheme selects the http protocol, otherwise the endpoint is the
+// host:port of a gRPC receiver.
+func parseArg(arg string) (config, error) {
+	c := config{}
+	if !strings.Contains(arg, "://") {
+		c.Endpoint = null.StringFrom(arg)
+		return c, nil
+	}
+
+	u, err := url.Parse(arg)
+	if err != nil {
+		return c, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		c.Protocol = null.StringFrom(protocolHTTP)
+		c.Insecure = null.BoolFrom(u.Scheme == "http")
+		if u.Path != "" {
+			c.URLPath = null.StringFrom(u.Path)
+		}
+	default:
+		return c, fmt.Errorf("invalid URL scheme '%s', it can be http or https", u.Scheme)
+	}
+	c.Endpoint = null.StringFrom(u.Host)
+	return c, nil
+}
+
+// getConsolidatedConfig combines {default config values + JSON config +
+// environment vars + argument}, and returns the final result.
+func getConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (config, error) {
+	result := newConfig()
+	if jsonRawConf != nil {
+		jsonConf := config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := parseArg(arg)
+		if err != nil {
+			return result, fmt.Errorf("invalid otlp output argument '%s': %w", arg, err)
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.validate()
+}
@@ -0,0 +1,100 @@
+package otlp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Defaults", func(t *testing.T) {
+		t.Parallel()
+		c, err := getConsolidatedConfig(nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, newConfig(), c)
+		assert.Equal(t, "localhost:4317", c.endpoint())
+	})
+
+	t.Run("JSONEnvAndArgument", func(t *testing.T) {
+		t.Parallel()
+		jsonConf := json.RawMessage(`{"temporality":"delta","pushInterval":"2s","histogramBuckets":[1,2,3]}`)
+		env := map[string]string{
+			"K6_OTLP_HEADERS":             "Authorization:Bearer token,X-Scope-OrgID:k6",
+			"K6_OTLP_RESOURCE_ATTRIBUTES": "deployment.environment:staging",
+			"K6_OTLP_PUSH_INTERVAL":       "3s",
+		}
+		c, err := getConsolidatedConfig(jsonConf, env, "http://collector:4318/otlp/v1/metrics")
+		require.NoError(t, err)
+
+		assert.Equal(t, null.StringFrom(protocolHTTP), c.Protocol)
+		assert.Equal(t, "collector:4318", c.endpoint())
+		assert.Equal(t, null.StringFrom("/otlp/v1/metrics"), c.URLPath)
+		assert.Equal(t, null.BoolFrom(true), c.Insecure)
+		assert.Equal(t, map[string]string{"Authorization": "Bearer token", "X-Scope-OrgID": "k6"}, c.Headers)
+		assert.Equal(t, map[string]string{"deployment.environment": "staging"}, c.ResourceAttributes)
+		assert.Equal(t, null.StringFrom(temporalityDelta), c.Temporality)
+		assert.Equal(t, []float64{1, 2, 3}, c.HistogramBuckets)
+		assert.Equal(t, types.NullDurationFrom(3*time.Second), c.PushInterval)
+	})
+
+	t.Run("HTTPDefaultEndpoint", func(t *testing.T) {
+		t.Parallel()
+		c, err := getConsolidatedConfig(nil, map[string]string{"K6_OTLP_PROTOCOL": "http"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "localhost:4318", c.endpoint())
+	})
+
+	t.Run("GRPCEndpointArgument", func(t *testing.T) {
+		t.Parallel()
+		c, err := getConsolidatedConfig(nil, nil, "collector:4317")
+		require.NoError(t, err)
+		assert.Equal(t, protocolGRPC, c.Protocol.String)
+		assert.Equal(t, "collector:4317", c.endpoint())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		testCases := map[string]struct {
+			env map[string]string
+			arg string
+			err string
+		}{
+			"protocol": {
+				env: map[string]string{"K6_OTLP_PROTOCOL": "udp"},
+				err: "invalid protocol 'udp', it can be grpc or http",
+			},
+			"temporality": {
+				env: map[string]string{"K6_OTLP_TEMPORALITY": "sometimes"},
+				err: "invalid temporality 'sometimes', it can be cumulative or delta",
+			},
+			"unsorted buckets": {
+				env: map[string]string{"K6_OTLP_HISTOGRAM_BUCKETS": "10,5"},
+				err: "the histogram buckets have to be sorted in increasing order",
+			},
+			"duplicated buckets": {
+				env: map[string]string{"K6_OTLP_HISTOGRAM_BUCKETS": "5,5"},
+				err: "the histogram bucket 5 is duplicated",
+			},
+			"scheme": {
+				arg: "ftp://collector",
+				err: "invalid otlp output argument 'ftp://collector': invalid URL scheme 'ftp', it can be http or https",
+			},
+		}
+		for name, tc := range testCases {
+			tc := tc
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				_, err := getConsolidatedConfig(nil, tc.env, tc.arg)
+				require.EqualError(t, err, tc.err)
+			})
+		}
+	})
+}
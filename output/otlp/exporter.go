@@ -0,0 +1,135 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// exporter sends the metrics to the receiver.
+type exporter interface {
+	export(ctx context.Context, req *collectorpb.ExportMetricsServiceRequest) error
+	close() error
+}
+
+func newExporter(conf config) (exporter, error) {
+	if conf.Protocol.String == protocolHTTP {
+		return newHTTPExporter(conf), nil
+	}
+	return newGRPCExporter(conf)
+}
+
+type grpcExporter struct {
+	conn    *grpc.ClientConn
+	client  collectorpb.MetricsServiceClient
+	headers metadata.MD
+}
+
+func newGRPCExporter(conf config) (*grpcExporter, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if conf.Insecure.Bool {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(conf.endpoint(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create the gRPC client: %w", err)
+	}
+	return &grpcExporter{
+		conn:    conn,
+		client:  collectorpb.NewMetricsServiceClient(conn),
+		headers: metadata.New(conf.Headers),
+	}, nil
+}
+
+func (e *grpcExporter) export(ctx context.Context, req *collectorpb.ExportMetricsServiceRequest) error {
+	if len(e.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, e.headers)
+	}
+	resp, err := e.client.Export(ctx, req)
+	if err != nil {
+		return err
+	}
+	return partialSuccessError(resp)
+}
+
+func (e *grpcExporter) close() error {
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func newHTTPExporter(conf config) *httpExporter {
+	scheme := "https"
+	if conf.Insecure.Bool {
+		scheme = "http"
+	}
+	return &httpExporter{
+		client:  &http.Client{},
+		url:     scheme + "://" + conf.endpoint() + conf.URLPath.String,
+		headers: conf.Headers,
+	}
+}
+
+func (e *httpExporter) export(ctx context.Context, req *collectorpb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpResp, err := e.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return fmt.Errorf("the receiver responded with %s: %s", httpResp.Status, bytes.TrimSpace(respBody))
+	}
+
+	resp := &collectorpb.ExportMetricsServiceResponse{}
+	if err := proto.Unmarshal(respBody, resp); err != nil {
+		// the response is only needed for the partial success
+		return nil //nolint:nilerr
+	}
+	return partialSuccessError(resp)
+}
+
+func (e *httpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// partialSuccessError returns an error if the receiver rejected some of the
+// data points.
+func partialSuccessError(resp *collectorpb.ExportMetricsServiceResponse) error {
+	ps := resp.GetPartialSuccess()
+	if ps == nil || (ps.GetRejectedDataPoints() == 0 && ps.GetErrorMessage() == "") {
+		return nil
+	}
+	return fmt.Errorf("the receiver rejected %d data points: %s", ps.GetRejectedDataPoints(), ps.GetErrorMessage())
+}
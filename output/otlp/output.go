@@ -0,0 +1,172 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/output"
+)
+
+const (
+	serviceName = "k6"
+	scopeName   = "go.k6.io/k6/output/otlp"
+)
+
+// New creates a new OTLP output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := getConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{
+		config:     conf,
+		logger:     params.Logger.WithFields(logrus.Fields{"output": "otlp"}),
+		aggregator: newAggregator(conf.Temporality.String == temporalityDelta, conf.HistogramBuckets),
+		resource:   newResource(params.ScriptOptions, conf.ResourceAttributes),
+		now:        time.Now,
+	}, nil
+}
+
+var _ output.Output = &Output{}
+
+// Output aggregates the metric samples and periodically exports them to an
+// OTLP receiver.
+type Output struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+
+	config     config
+	logger     logrus.FieldLogger
+	exporter   exporter
+	aggregator *aggregator
+	resource   *resourcepb.Resource
+	now        func() time.Time
+}
+
+// newResource returns the resource of the exported metrics, with the service
+// name and version of k6 and the metadata of the test run, that can be
+// overridden by the attributes.
+func newResource(opts lib.Options, attrs map[string]string) *resourcepb.Resource {
+	all := map[string]string{
+		"service.name":    serviceName,
+		"service.version": consts.Version,
+	}
+	for attr, value := range map[string]null.String{
+		"k6.test.name":           opts.TestName,
+		"k6.run.id":              opts.RunID,
+		"k6.test.version":        opts.TestVersion,
+		"deployment.environment": opts.TestEnvironment,
+	} {
+		if value.Valid && value.String != "" {
+			all[attr] = value.String
+		}
+	}
+	for k, v := range attrs {
+		all[k] = v
+	}
+	return &resourcepb.Resource{Attributes: attributes(all)}
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("otlp (%s %s, %s)", o.config.Protocol.String, o.target(), o.config.Temporality.String)
+}
+
+func (o *Output) target() string {
+	if o.config.Protocol.String == protocolHTTP {
+		return o.config.endpoint() + o.config.URLPath.String
+	}
+	return o.config.endpoint()
+}
+
+// Start creates the client of the receiver and starts the goroutine for the
+// periodic export of the metrics.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	var err error
+	o.exporter, err = newExporter(o.config)
+	if err != nil {
+		return err
+	}
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop exports the remaining metrics and closes the client of the receiver.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return o.exporter.close()
+}
+
+func (o *Output) flushMetrics() {
+	var count int
+	for _, sc := range o.GetBufferedSamples() {
+		samples := sc.GetSamples()
+		count += len(samples)
+		for _, sample := range samples {
+			o.aggregator.add(sample)
+		}
+	}
+
+	now := o.now()
+	req := o.request(o.aggregator.collect(now))
+	if req == nil {
+		return
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.Timeout.TimeDuration())
+	defer cancel()
+	if err := o.exporter.export(ctx, req); err != nil {
+		o.logger.WithError(err).Error("Couldn't export the metrics")
+		return
+	}
+	o.aggregator.exported(now)
+	o.logger.WithFields(logrus.Fields{
+		"t":       time.Since(start),
+		"samples": count,
+		"metrics": len(req.ResourceMetrics[0].ScopeMetrics[0].Metrics),
+	}).Debug("Exported the metrics")
+}
+
+// request returns the export request of the metrics, or nil if there aren't any.
+func (o *Output) request(ms []*metricpb.Metric) *collectorpb.ExportMetricsServiceRequest {
+	if len(ms) == 0 {
+		return nil
+	}
+	return &collectorpb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{{
+			Resource: o.resource,
+			ScopeMetrics: []*metricpb.ScopeMetrics{{
+				Scope:   &commonpb.InstrumentationScope{Name: scopeName, Version: consts.Version},
+				Metrics: ms,
+			}},
+		}},
+	}
+}
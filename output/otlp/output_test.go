@@ -0,0 +1,381 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+type testMetrics struct {
+	registry *metrics.Registry
+	counter  *metrics.Metric
+	gauge    *metrics.Metric
+	rate     *metrics.Metric
+	trend    *metrics.Metric
+}
+
+func newTestMetrics() testMetrics {
+	registry := metrics.NewRegistry()
+	return testMetrics{
+		registry: registry,
+		counter:  registry.MustNewMetric("my_counter", metrics.Counter),
+		gauge:    registry.MustNewMetric("my_gauge", metrics.Gauge, metrics.Data),
+		rate:     registry.MustNewMetric("my_rate", metrics.Rate),
+		trend:    registry.MustNewMetric("my_trend", metrics.Trend, metrics.Time),
+	}
+}
+
+func (tm testMetrics) sample(m *metrics.Metric, value float64, tags map[string]string) metrics.Sample {
+	return metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: m, Tags: tm.registry.RootTagSet().WithTagsFromMap(tags)},
+		Time:       time.Unix(10, 0),
+		Value:      value,
+	}
+}
+
+func (tm testMetrics) samples() metrics.Samples {
+	tags := map[string]string{"scenario": "default"}
+	return metrics.Samples{
+		tm.sample(tm.counter, 1, tags),
+		tm.sample(tm.counter, 2, tags),
+		tm.sample(tm.counter, 5, map[string]string{"scenario": "other"}),
+		tm.sample(tm.gauge, 7, tags),
+		tm.sample(tm.gauge, 3, tags),
+		tm.sample(tm.rate, 1, tags),
+		tm.sample(tm.rate, 0, tags),
+		tm.sample(tm.rate, 1, tags),
+		tm.sample(tm.rate, 1, tags),
+		tm.sample(tm.trend, 4, tags),
+		tm.sample(tm.trend, 10, tags),
+		tm.sample(tm.trend, 11, tags),
+		tm.sample(tm.trend, 1000, tags),
+	}
+}
+
+func byName(ms []*metricpb.Metric) map[string]*metricpb.Metric {
+	result := make(map[string]*metricpb.Metric, len(ms))
+	for _, m := range ms {
+		result[m.Name] = m
+	}
+	return result
+}
+
+func TestAggregatorCumulative(t *testing.T) {
+	t.Parallel()
+	tm := newTestMetrics()
+	require.NoError(t, tm.registry.DescribeMetric(tm.counter, "{request}", "The sent requests", nil))
+	a := newAggregator(false, []float64{5, 10, 100})
+	for _, s := range tm.samples() {
+		a.add(s)
+	}
+
+	ms := a.collect(time.Unix(20, 0))
+	require.Len(t, ms, 4)
+	assert.Equal(t, []string{"my_counter", "my_gauge", "my_rate", "my_trend"},
+		[]string{ms[0].Name, ms[1].Name, ms[2].Name, ms[3].Name})
+	got := byName(ms)
+
+	assert.Equal(t, "{request}", got["my_counter"].Unit)
+	assert.Equal(t, "The sent requests", got["my_counter"].Description)
+	sum := got["my_counter"].GetSum()
+	require.NotNil(t, sum)
+	assert.True(t, sum.IsMonotonic)
+	assert.Equal(t, metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, sum.AggregationTemporality)
+	require.Len(t, sum.DataPoints, 2)
+	values := map[string]float64{}
+	for _, dp := range sum.DataPoints {
+		require.Len(t, dp.Attributes, 1)
+		values[dp.Attributes[0].Value.GetStringValue()] = dp.GetAsDouble()
+		assert.Equal(t, uint64(time.Unix(10, 0).UnixNano()), dp.StartTimeUnixNano)
+		assert.Equal(t, uint64(time.Unix(20, 0).UnixNano()), dp.TimeUnixNano)
+	}
+	assert.Equal(t, map[string]float64{"default": 3, "other": 5}, values)
+
+	assert.Equal(t, "By", got["my_gauge"].Unit)
+	require.Len(t, got["my_gauge"].GetGauge().DataPoints, 1)
+	assert.Equal(t, 3.0, got["my_gauge"].GetGauge().DataPoints[0].GetAsDouble())
+
+	assert.Equal(t, "1", got["my_rate"].Unit)
+	require.Len(t, got["my_rate"].GetGauge().DataPoints, 1)
+	assert.Equal(t, 0.75, got["my_rate"].GetGauge().DataPoints[0].GetAsDouble())
+
+	assert.Equal(t, "ms", got["my_trend"].Unit)
+	hist := got["my_trend"].GetHistogram()
+	require.NotNil(t, hist)
+	require.Len(t, hist.DataPoints, 1)
+	dp := hist.DataPoints[0]
+	assert.Equal(t, uint64(4), dp.Count)
+	assert.Equal(t, 1025.0, dp.GetSum())
+	assert.Equal(t, 4.0, dp.GetMin())
+	assert.Equal(t, 1000.0, dp.GetMax())
+	assert.Equal(t, []float64{5, 10, 100}, dp.ExplicitBounds)
+	assert.Equal(t, []uint64{1, 1, 1, 1}, dp.BucketCounts)
+
+	// the cumulative series are exported again, even without new samples
+	a.add(tm.sample(tm.counter, 1, map[string]string{"scenario": "other"}))
+	got = byName(a.collect(time.Unix(30, 0)))
+	require.Len(t, got, 4)
+	assert.Len(t, got["my_counter"].GetSum().DataPoints, 2)
+	assert.Equal(t, uint64(4), got["my_trend"].GetHistogram().DataPoints[0].Count)
+}
+
+func TestAggregatorDelta(t *testing.T) {
+	t.Parallel()
+	tm := newTestMetrics()
+	a := newAggregator(true, []float64{5, 10, 100})
+	for _, s := range tm.samples() {
+		a.add(s)
+	}
+
+	got := byName(a.collect(time.Unix(20, 0)))
+	require.Len(t, got, 4)
+	assert.Equal(t, metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+		got["my_counter"].GetSum().AggregationTemporality)
+	assert.Equal(t, metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+		got["my_trend"].GetHistogram().AggregationTemporality)
+	a.exported(time.Unix(20, 0))
+
+	// only the series with new samples are exported, with the data since the
+	// last export
+	a.add(tm.sample(tm.counter, 4, map[string]string{"scenario": "other"}))
+	a.add(tm.sample(tm.trend, 7, map[string]string{"scenario": "default"}))
+	got = byName(a.collect(time.Unix(30, 0)))
+	require.Len(t, got, 2)
+
+	require.Len(t, got["my_counter"].GetSum().DataPoints, 1)
+	counter := got["my_counter"].GetSum().DataPoints[0]
+	assert.Equal(t, 4.0, counter.GetAsDouble())
+	assert.Equal(t, uint64(time.Unix(20, 0).UnixNano()), counter.StartTimeUnixNano)
+	assert.Equal(t, uint64(time.Unix(30, 0).UnixNano()), counter.TimeUnixNano)
+
+	trend := got["my_trend"].GetHistogram().DataPoints[0]
+	assert.Equal(t, uint64(1), trend.Count)
+	assert.Equal(t, 7.0, trend.GetMin())
+	assert.Equal(t, []uint64{0, 1, 0, 0}, trend.BucketCounts)
+
+	// the export failed, so the deltas are sent again with the new samples
+	a.add(tm.sample(tm.counter, 2, map[string]string{"scenario": "other"}))
+	got = byName(a.collect(time.Unix(40, 0)))
+	require.Len(t, got, 2)
+	counter = got["my_counter"].GetSum().DataPoints[0]
+	assert.Equal(t, 6.0, counter.GetAsDouble())
+	assert.Equal(t, uint64(time.Unix(20, 0).UnixNano()), counter.StartTimeUnixNano)
+	assert.Equal(t, uint64(1), got["my_trend"].GetHistogram().DataPoints[0].Count)
+	a.exported(time.Unix(40, 0))
+
+	assert.Empty(t, a.collect(time.Unix(50, 0)))
+}
+
+type metricsServer struct {
+	collectorpb.UnimplementedMetricsServiceServer
+
+	mu       sync.Mutex
+	requests []*collectorpb.ExportMetricsServiceRequest
+	headers  []metadata.MD
+}
+
+func (s *metricsServer) Export(
+	ctx context.Context, req *collectorpb.ExportMetricsServiceRequest,
+) (*collectorpb.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	s.headers = append(s.headers, md)
+	return &collectorpb.ExportMetricsServiceResponse{}, nil
+}
+
+func runOutput(t *testing.T, env map[string]string, arg string) {
+	t.Helper()
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		Environment:    env,
+		ConfigArgument: arg,
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+	o.AddMetricSamples([]metrics.SampleContainer{newTestMetrics().samples()})
+	require.NoError(t, o.Stop())
+}
+
+func checkRequest(t *testing.T, req *collectorpb.ExportMetricsServiceRequest) {
+	t.Helper()
+	require.Len(t, req.ResourceMetrics, 1)
+	rm := req.ResourceMetrics[0]
+	resource := map[string]string{}
+	for _, attr := range rm.Resource.Attributes {
+		resource[attr.Key] = attr.Value.GetStringValue()
+	}
+	assert.Equal(t, "k6", resource["service.name"])
+	assert.Equal(t, "staging", resource["deployment.environment"])
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, scopeName, rm.ScopeMetrics[0].Scope.Name)
+	assert.Len(t, rm.ScopeMetrics[0].Metrics, 4)
+}
+
+func TestResourceTestMetadata(t *testing.T) {
+	t.Parallel()
+
+	opts := lib.Options{
+		TestName:        null.StringFrom("checkout"),
+		RunID:           null.StringFrom("run-1"),
+		TestEnvironment: null.StringFrom("staging"),
+	}
+	resource := map[string]string{}
+	for _, attr := range newResource(opts, map[string]string{"deployment.environment": "prod"}).Attributes {
+		resource[attr.Key] = attr.Value.GetStringValue()
+	}
+	assert.Equal(t, "checkout", resource["k6.test.name"])
+	assert.Equal(t, "run-1", resource["k6.run.id"])
+	assert.NotContains(t, resource, "k6.test.version")
+	// the attributes of the output take precedence
+	assert.Equal(t, "prod", resource["deployment.environment"])
+}
+
+func TestOutputGRPC(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	ms := &metricsServer{}
+	collectorpb.RegisterMetricsServiceServer(server, ms)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	runOutput(t, map[string]string{
+		"K6_OTLP_INSECURE":            "true",
+		"K6_OTLP_HEADERS":             "authorization:Bearer token",
+		"K6_OTLP_RESOURCE_ATTRIBUTES": "deployment.environment:staging",
+	}, listener.Addr().String())
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	require.Len(t, ms.requests, 1)
+	checkRequest(t, ms.requests[0])
+	assert.Equal(t, []string{"Bearer token"}, ms.headers[0].Get("authorization"))
+}
+
+func TestOutputHTTP(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		requests []*collectorpb.ExportMetricsServiceRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		req := &collectorpb.ExportMetricsServiceRequest{}
+		assert.NoError(t, proto.Unmarshal(body, req))
+
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		resp, err := proto.Marshal(&collectorpb.ExportMetricsServiceResponse{})
+		assert.NoError(t, err)
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	runOutput(t, map[string]string{
+		"K6_OTLP_HEADERS":             "Authorization:Bearer token",
+		"K6_OTLP_RESOURCE_ATTRIBUTES": "deployment.environment:staging",
+	}, srv.URL+"/v1/metrics")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 1)
+	checkRequest(t, requests[0])
+}
+
+func TestHTTPExporterErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/partial") {
+			resp, _ := proto.Marshal(&collectorpb.ExportMetricsServiceResponse{
+				PartialSuccess: &collectorpb.ExportMetricsPartialSuccess{
+					RejectedDataPoints: 2,
+					ErrorMessage:       "too old",
+				},
+			})
+			_, _ = w.Write(resp)
+			return
+		}
+		http.Error(w, "no more quota", http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+
+	conf, err := getConsolidatedConfig(nil, nil, srv.URL+"/v1/metrics")
+	require.NoError(t, err)
+	e := newHTTPExporter(conf)
+	err = e.export(context.Background(), &collectorpb.ExportMetricsServiceRequest{})
+	require.EqualError(t, err, "the receiver responded with 429 Too Many Requests: no more quota")
+
+	conf.URLPath = null.StringFrom("/partial")
+	e = newHTTPExporter(conf)
+	err = e.export(context.Background(), &collectorpb.ExportMetricsServiceRequest{})
+	require.EqualError(t, err, "the receiver rejected 2 data points: too old")
+}
+
+func TestAggregatorExemplars(t *testing.T) {
+	t.Parallel()
+	tm := newTestMetrics()
+	a := newAggregator(false, []float64{100})
+	traced := func(m *metrics.Metric, value float64, spanID string) metrics.Sample {
+		s := tm.sample(m, value, nil)
+		s.Metadata = map[string]string{
+			"trace_id": "0af7651916cd43dd8448eb211c80319c",
+			"span_id":  spanID,
+		}
+		return s
+	}
+	a.add(traced(tm.trend, 50, "00000000000000a1"))
+	a.add(traced(tm.trend, 300, "00000000000000a2"))
+	a.add(traced(tm.trend, 20, "00000000000000a3"))
+	a.add(tm.sample(tm.trend, 500, nil)) // not traced
+	a.add(traced(tm.counter, 1, "00000000000000b1"))
+	a.add(traced(tm.counter, 1, "00000000000000b2"))
+
+	got := byName(a.collect(time.Unix(20, 0)))
+	exemplars := got["my_trend"].GetHistogram().DataPoints[0].Exemplars
+	require.Len(t, exemplars, 1)
+	// the slowest traced request
+	assert.Equal(t, 300.0, exemplars[0].GetAsDouble())
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0xa2}, exemplars[0].SpanId)
+	assert.Len(t, exemplars[0].TraceId, 16)
+
+	exemplars = got["my_counter"].GetSum().DataPoints[0].Exemplars
+	require.Len(t, exemplars, 1)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0xb2}, exemplars[0].SpanId)
+
+	// the exemplars are only of the samples since the last export
+	a.exported(time.Unix(20, 0))
+	got = byName(a.collect(time.Unix(30, 0)))
+	assert.Empty(t, got["my_trend"].GetHistogram().DataPoints[0].Exemplars)
+}
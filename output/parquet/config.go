@@ -0,0 +1,122 @@
+// Package parquet implements an output that writes the metric samples to
+// rotating Parquet files, with a column for each tag.
+package parquet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+const (
+	compressionNone   = "none"
+	compressionSnappy = "snappy"
+	compressionGzip   = "gzip"
+	compressionZstd   = "zstd"
+)
+
+// config is the configuration of the Parquet output.
+type config struct {
+	// FileName is the name of the files without their sequence number, e.g.
+	// with results.parquet the files are results-000001.parquet and so on.
+	FileName null.String `json:"fileName,omitempty" envconfig:"K6_PARQUET_FILENAME"`
+	// MaxFileSize is the size in bytes after which a new file is started.
+	MaxFileSize null.Int `json:"maxFileSize,omitempty" envconfig:"K6_PARQUET_MAX_FILE_SIZE"`
+	// RotationInterval is how long a file is written before a new one is
+	// started, the files are only rotated by size if it's 0.
+	RotationInterval types.NullDuration `json:"rotationInterval,omitempty" envconfig:"K6_PARQUET_ROTATION_INTERVAL"`
+	// Compression is the codec of the columns, none, snappy, gzip or zstd.
+	Compression null.String `json:"compression,omitempty" envconfig:"K6_PARQUET_COMPRESSION"`
+	// PushInterval is how often the samples are written, each write is a row
+	// group of the file.
+	PushInterval types.NullDuration `json:"pushInterval,omitempty" envconfig:"K6_PARQUET_PUSH_INTERVAL"`
+}
+
+// newConfig creates a new config instance with the default values.
+func newConfig() config {
+	return config{
+		FileName:         null.NewString("k6-results.parquet", false),
+		MaxFileSize:      null.NewInt(128<<20, false),
+		RotationInterval: types.NewNullDuration(0, false),
+		Compression:      null.NewString(compressionSnappy, false),
+		PushInterval:     types.NewNullDuration(10*time.Second, false),
+	}
+}
+
+// Apply saves the non-zero config values from the passed config in the receiver.
+func (c config) Apply(cfg config) config {
+	if cfg.FileName.Valid {
+		c.FileName = cfg.FileName
+	}
+	if cfg.MaxFileSize.Valid {
+		c.MaxFileSize = cfg.MaxFileSize
+	}
+	if cfg.RotationInterval.Valid {
+		c.RotationInterval = cfg.RotationInterval
+	}
+	if cfg.Compression.Valid {
+		c.Compression = cfg.Compression
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+
+	return c
+}
+
+func (c config) validate() error {
+	if c.FileName.String == "" {
+		return errors.New("the file name can't be empty")
+	}
+	if c.MaxFileSize.Int64 <= 0 {
+		return fmt.Errorf("the max file size has to be positive, not %d", c.MaxFileSize.Int64)
+	}
+	if c.RotationInterval.TimeDuration() < 0 {
+		return errors.New("the rotation interval can't be negative")
+	}
+	switch c.Compression.String {
+	case compressionNone, compressionSnappy, compressionGzip, compressionZstd:
+	default:
+		return fmt.Errorf("invalid compression '%s', it can be %s, %s, %s or %s", c.Compression.String,
+			compressionNone, compressionSnappy, compressionGzip, compressionZstd)
+	}
+	if c.PushInterval.TimeDuration() <= 0 {
+		return errors.New("the push interval has to be positive")
+	}
+	return nil
+}
+
+// getConsolidatedConfig combines {default config values + JSON config +
+// environment vars + argument}, and returns the final result. The argument of
+// --out parquet=<file> is the file name.
+func getConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (config, error) {
+	result := newConfig()
+	if jsonRawConf != nil {
+		jsonConf := config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		result = result.Apply(config{FileName: null.StringFrom(arg)})
+	}
+
+	return result, result.validate()
+}
@@ -0,0 +1,70 @@
+package parquet
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	withDefaults := func(c config) config { return newConfig().Apply(c) }
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  config
+		errMsg  string
+	}{
+		"Defaults": {
+			config: newConfig(),
+		},
+		"JSONEnvAndArg": {
+			jsonRaw: json.RawMessage(`{"fileName":"json.parquet","compression":"gzip","maxFileSize":1024}`),
+			env: map[string]string{
+				"K6_PARQUET_COMPRESSION":       "zstd",
+				"K6_PARQUET_ROTATION_INTERVAL": "15m",
+			},
+			arg: "arg.parquet",
+			config: withDefaults(config{
+				FileName:         null.StringFrom("arg.parquet"),
+				MaxFileSize:      null.IntFrom(1024),
+				RotationInterval: types.NullDurationFrom(15 * time.Minute),
+				Compression:      null.StringFrom(compressionZstd),
+			}),
+		},
+		"InvalidCompression": {
+			env:    map[string]string{"K6_PARQUET_COMPRESSION": "lzo"},
+			errMsg: "invalid compression 'lzo', it can be none, snappy, gzip or zstd",
+		},
+		"InvalidMaxFileSize": {
+			env:    map[string]string{"K6_PARQUET_MAX_FILE_SIZE": "0"},
+			errMsg: "the max file size has to be positive, not 0",
+		},
+		"NegativeRotationInterval": {
+			env:    map[string]string{"K6_PARQUET_ROTATION_INTERVAL": "-1s"},
+			errMsg: "the rotation interval can't be negative",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, err := getConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.errMsg != "" {
+				require.EqualError(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, c)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package parquet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+)
+
+// the columns of every file, the tags have a column each
+const (
+	columnTimestamp  = "timestamp"
+	columnMetric     = "metric"
+	columnMetricType = "metric_type"
+	columnValue      = "value"
+	columnMetadata   = "metadata"
+)
+
+// tagColumn returns the name of the column of the tag, the characters which
+// aren't letters, digits or underscores are replaced by underscores, and the
+// tags with the name of one of the fixed columns are prefixed by tag_.
+func tagColumn(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, key)
+	switch name {
+	case columnTimestamp, columnMetric, columnMetricType, columnValue, columnMetadata:
+		return "tag_" + name
+	default:
+		return name
+	}
+}
+
+// fileName returns the name of the file with the sequence number, e.g.
+// results-000001.parquet for results.parquet.
+func fileName(base string, seq int) string {
+	ext := filepath.Ext(base)
+	if ext == "" {
+		ext = ".parquet"
+	}
+	return fmt.Sprintf("%s-%06d%s", strings.TrimSuffix(base, filepath.Ext(base)), seq, ext)
+}
+
+func compressionCodec(name string) compress.Codec {
+	switch name {
+	case compressionNone:
+		return &parquet.Uncompressed
+	case compressionGzip:
+		return &parquet.Gzip
+	case compressionZstd:
+		return &parquet.Zstd
+	default:
+		return &parquet.Snappy
+	}
+}
+
+// countingWriter counts the bytes written to the file.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// file is a Parquet file being written, its schema has a column for each of
+// the tags it was created with.
+type file struct {
+	name    string
+	f       io.Closer
+	counter *countingWriter
+	writer  *parquet.Writer
+	opened  time.Time
+
+	// columns are the indexes of the columns, by name
+	columns map[string]int
+	// optional are whether the columns, by index, can be null
+	optional []bool
+	// tags are the keys of the tags with a column
+	tags map[string]struct{}
+}
+
+func createFile(
+	fs fsext.Fs, name string, tagKeys []string, codec compress.Codec, now time.Time,
+) (*file, error) {
+	dictionary := func(node parquet.Node) parquet.Node {
+		return parquet.Encoded(node, &parquet.RLEDictionary)
+	}
+	group := parquet.Group{
+		columnTimestamp:  parquet.Timestamp(parquet.Microsecond),
+		columnMetric:     dictionary(parquet.String()),
+		columnMetricType: dictionary(parquet.String()),
+		columnValue:      parquet.Leaf(parquet.DoubleType),
+		columnMetadata:   parquet.Optional(parquet.String()),
+	}
+	tags := make(map[string]struct{}, len(tagKeys))
+	for _, k := range tagKeys {
+		tags[k] = struct{}{}
+		column := tagColumn(k)
+		if _, ok := group[column]; ok {
+			continue
+		}
+		group[column] = parquet.Optional(dictionary(parquet.String()))
+	}
+	schema := parquet.NewSchema("k6", group)
+
+	// the columns of a group are sorted by name
+	fields := schema.Fields()
+	columns := make(map[string]int, len(fields))
+	optional := make([]bool, len(fields))
+	for i, field := range fields {
+		columns[field.Name()] = i
+		optional[i] = field.Optional()
+	}
+
+	f, err := fs.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	counter := &countingWriter{w: f}
+	// the row groups are written without buffering, so the counted size is
+	// the size of the file, for the rotation
+	w := parquet.NewWriter(counter, schema, parquet.Compression(codec), parquet.WriteBufferSize(0))
+
+	return &file{
+		name:     name,
+		f:        f,
+		counter:  counter,
+		writer:   w,
+		opened:   now,
+		columns:  columns,
+		optional: optional,
+		tags:     tags,
+	}, nil
+}
+
+// hasColumns returns true if the file has a column for each of the tags.
+func (f *file) hasColumns(tagKeys map[string]struct{}) bool {
+	for k := range tagKeys {
+		if _, ok := f.tags[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// write writes the samples as a row group.
+func (f *file) write(samples []metrics.Sample) error {
+	rows := make([]parquet.Row, 0, len(samples))
+	for _, s := range samples {
+		row, err := f.row(s)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+	if _, err := f.writer.WriteRows(rows); err != nil {
+		return err
+	}
+	return f.writer.Flush()
+}
+
+// row returns the values of the columns for the sample, the tags without a
+// value are null.
+func (f *file) row(s metrics.Sample) (parquet.Row, error) {
+	values := make([]parquet.Value, len(f.columns))
+	values[f.columns[columnTimestamp]] = parquet.Int64Value(s.Time.UnixMicro())
+	values[f.columns[columnMetric]] = parquet.ByteArrayValue([]byte(s.Metric.Name))
+	values[f.columns[columnMetricType]] = parquet.ByteArrayValue([]byte(s.Metric.Type.String()))
+	values[f.columns[columnValue]] = parquet.DoubleValue(s.Value)
+	if len(s.Metadata) > 0 {
+		metadata, err := json.Marshal(s.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		values[f.columns[columnMetadata]] = parquet.ByteArrayValue(metadata)
+	}
+	for k, v := range s.Tags.Map() {
+		values[f.columns[tagColumn(k)]] = parquet.ByteArrayValue([]byte(v))
+	}
+
+	// the definition level of the optional columns is 1 if they have a value
+	row := make(parquet.Row, len(values))
+	for i, v := range values {
+		definitionLevel := 0
+		if f.optional[i] && !v.IsNull() {
+			definitionLevel = 1
+		}
+		row[i] = v.Level(0, definitionLevel, i)
+	}
+	return row, nil
+}
+
+// size returns the number of bytes written to the file.
+func (f *file) size() int64 {
+	return f.counter.n
+}
+
+// close writes the footer of the file and closes it.
+func (f *file) close() error {
+	err := f.writer.Close()
+	if closeErr := f.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// sortedKeys returns the keys of the set in order.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
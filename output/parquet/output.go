@@ -0,0 +1,156 @@
+package parquet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// New creates a new Parquet output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := getConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{
+		config:  conf,
+		logger:  params.Logger.WithFields(logrus.Fields{"output": "parquet"}),
+		fs:      params.FS,
+		codec:   compressionCodec(conf.Compression.String),
+		tagKeys: make(map[string]struct{}),
+		now:     time.Now,
+	}, nil
+}
+
+var _ output.Output = &Output{}
+
+// Output periodically writes the buffered metric samples to the current
+// Parquet file. A new file is started when the current one is too big or too
+// old, or when there are samples with tags it doesn't have a column for.
+type Output struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+
+	config config
+	logger logrus.FieldLogger
+	fs     fsext.Fs
+	codec  compress.Codec
+
+	current *file
+	seq     int
+	// tagKeys are the keys of all the tags seen so far, every new file has a
+	// column for each of them
+	tagKeys map[string]struct{}
+	now     func() time.Time
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("parquet (%s)", fileName(o.config.FileName.String, 1))
+}
+
+// Start starts the goroutine for the periodic writes of the samples.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop writes the remaining samples and closes the current file.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return o.closeCurrent()
+}
+
+func (o *Output) flushMetrics() {
+	var samples []metrics.Sample
+	newTags := false
+	for _, sc := range o.GetBufferedSamples() {
+		for _, s := range sc.GetSamples() {
+			for k := range s.Tags.Map() {
+				if _, ok := o.tagKeys[k]; !ok {
+					o.tagKeys[k] = struct{}{}
+					newTags = true
+				}
+			}
+			samples = append(samples, s)
+		}
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	start := time.Now()
+	if err := o.write(samples, newTags); err != nil {
+		o.logger.WithError(err).Error("Couldn't write the samples")
+		return
+	}
+	o.logger.WithFields(logrus.Fields{
+		"t":       time.Since(start),
+		"samples": len(samples),
+		"file":    o.current.name,
+	}).Debug("Wrote the samples")
+}
+
+// write writes the samples to the current file, it starts a new one if it's
+// required for the new tags or by the rotation.
+func (o *Output) write(samples []metrics.Sample, newTags bool) error {
+	if o.current != nil && o.shouldRotate(newTags) {
+		if err := o.closeCurrent(); err != nil {
+			return err
+		}
+	}
+	if o.current == nil {
+		o.seq++
+		f, err := createFile(o.fs, fileName(o.config.FileName.String, o.seq), sortedKeys(o.tagKeys), o.codec, o.now())
+		if err != nil {
+			return err
+		}
+		o.current = f
+	}
+	return o.current.write(samples)
+}
+
+func (o *Output) shouldRotate(newTags bool) bool {
+	if newTags && !o.current.hasColumns(o.tagKeys) {
+		return true
+	}
+	if o.current.size() >= o.config.MaxFileSize.Int64 {
+		return true
+	}
+	interval := o.config.RotationInterval.TimeDuration()
+	return interval > 0 && o.now().Sub(o.current.opened) >= interval
+}
+
+func (o *Output) closeCurrent() error {
+	if o.current == nil {
+		return nil
+	}
+	f := o.current
+	o.current = nil
+	if err := f.close(); err != nil {
+		return fmt.Errorf("couldn't close %s: %w", f.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// readColumns returns the values of the columns of the file, by name.
+func readColumns(t *testing.T, fs fsext.Fs, name string, columns ...string) map[string][]interface{} {
+	t.Helper()
+	b, err := fsext.ReadFile(fs, name)
+	require.NoError(t, err)
+	f, err := parquet.OpenFile(bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+
+	indexes := make(map[string]int)
+	for i, field := range f.Schema().Fields() {
+		indexes[field.Name()] = i
+	}
+	reader := parquet.NewReader(f)
+	defer func() { assert.NoError(t, reader.Close()) }()
+	// the rows are read one row group at a time
+	rows := make([]parquet.Row, f.NumRows())
+	for read := 0; read < len(rows); {
+		n, err := reader.ReadRows(rows[read:])
+		// the values of the rows are only valid until the next read
+		for i := read; i < read+n; i++ {
+			rows[i] = rows[i].Clone()
+		}
+		read += n
+		if errors.Is(err, io.EOF) {
+			require.Equal(t, len(rows), read)
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	result := make(map[string][]interface{}, len(columns))
+	for _, c := range columns {
+		i, ok := indexes[c]
+		require.True(t, ok, c)
+		values := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			v := row[i]
+			switch {
+			case v.IsNull():
+				values = append(values, nil)
+			case v.Kind() == parquet.Int64:
+				values = append(values, v.Int64())
+			case v.Kind() == parquet.Double:
+				values = append(values, v.Double())
+			default:
+				values = append(values, v.String())
+			}
+		}
+		result[c] = values
+	}
+	return result
+}
+
+func newTestOutput(t *testing.T, env map[string]string) (*Output, fsext.Fs) {
+	fs := fsext.NewMemMapFs()
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		Environment:    env,
+		ConfigArgument: "results.parquet",
+	})
+	require.NoError(t, err)
+	return o, fs
+}
+
+func TestOutputFiles(t *testing.T) {
+	t.Parallel()
+
+	o, fs := newTestOutput(t, nil)
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	trend := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	counter := registry.MustNewMetric("iterations", metrics.Counter)
+	sample := func(m *metrics.Metric, value float64, tags map[string]string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: registry.RootTagSet().WithTagsFromMap(tags)},
+			Time:       time.Unix(10, 0),
+			Value:      value,
+		}
+	}
+
+	traced := sample(trend, 120.5, map[string]string{"status": "200", "method": "GET"})
+	traced.Metadata = map[string]string{lib.MetadataTraceID: "abc"}
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		traced,
+		sample(counter, 1, map[string]string{"scenario": "default"}),
+	}})
+	o.flushMetrics()
+
+	// the same tags are written to the same file
+	o.AddMetricSamples([]metrics.SampleContainer{sample(trend, 90, map[string]string{"status": "404"})})
+	o.flushMetrics()
+
+	// a new tag requires a new file
+	o.AddMetricSamples([]metrics.SampleContainer{sample(trend, 80, map[string]string{"name": "http://k6.io"})})
+	require.NoError(t, o.Stop())
+
+	columns := readColumns(t, fs, "results-000001.parquet",
+		"timestamp", "metric", "metric_type", "value", "metadata", "method", "scenario", "status")
+	assert.Equal(t, map[string][]interface{}{
+		"timestamp":   {int64(10_000_000), int64(10_000_000), int64(10_000_000)},
+		"metric":      {"http_req_duration", "iterations", "http_req_duration"},
+		"metric_type": {"trend", "counter", "trend"},
+		"value":       {120.5, float64(1), float64(90)},
+		"metadata":    {`{"trace_id":"abc"}`, nil, nil},
+		"method":      {"GET", nil, nil},
+		"scenario":    {nil, "default", nil},
+		"status":      {"200", nil, "404"},
+	}, columns)
+
+	columns = readColumns(t, fs, "results-000002.parquet", "value", "name", "status")
+	assert.Equal(t, map[string][]interface{}{
+		"value":  {float64(80)},
+		"name":   {"http://k6.io"},
+		"status": {nil},
+	}, columns)
+
+	_, err := fs.Stat("results-000003.parquet")
+	assert.Error(t, err)
+}
+
+func TestOutputRotation(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	m := registry.MustNewMetric("my_counter", metrics.Counter)
+	samples := []metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: m, Tags: registry.RootTagSet()},
+		Time:       time.Unix(10, 0),
+		Value:      1,
+	}}
+
+	t.Run("Size", func(t *testing.T) {
+		t.Parallel()
+		o, fs := newTestOutput(t, map[string]string{"K6_PARQUET_MAX_FILE_SIZE": "1"})
+		require.NoError(t, o.Start())
+		for i := 0; i < 3; i++ {
+			o.AddMetricSamples(samples)
+			o.flushMetrics()
+		}
+		require.NoError(t, o.Stop())
+
+		for _, name := range []string{"results-000001.parquet", "results-000002.parquet", "results-000003.parquet"} {
+			assert.Len(t, readColumns(t, fs, name, "value")["value"], 1, name)
+		}
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		t.Parallel()
+		o, fs := newTestOutput(t, map[string]string{"K6_PARQUET_ROTATION_INTERVAL": "1m"})
+		now := time.Unix(100, 0)
+		o.now = func() time.Time { return now }
+		require.NoError(t, o.Start())
+
+		o.AddMetricSamples(samples)
+		o.flushMetrics()
+		now = now.Add(30 * time.Second)
+		o.AddMetricSamples(samples)
+		o.flushMetrics()
+		now = now.Add(30 * time.Second)
+		o.AddMetricSamples(samples)
+		require.NoError(t, o.Stop())
+
+		assert.Len(t, readColumns(t, fs, "results-000001.parquet", "value")["value"], 2)
+		assert.Len(t, readColumns(t, fs, "results-000002.parquet", "value")["value"], 1)
+	})
+}
+
+func TestColumnAndFileNames(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "status", tagColumn("status"))
+	assert.Equal(t, "tag_value", tagColumn("value"))
+	assert.Equal(t, "my_tag_name", tagColumn("my.tag name"))
+
+	assert.Equal(t, "results-000001.parquet", fileName("results.parquet", 1))
+	assert.Equal(t, "out/k6-000012.parquet", fileName("out/k6", 12))
+}
@@ -0,0 +1,209 @@
+package report
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+const (
+	collectRate = 100 * time.Millisecond
+	// bucketPeriod is the resolution of the samples over time, the buckets
+	// are merged to draw the charts with at most maxChartPoints points.
+	bucketPeriod   = time.Second
+	maxChartPoints = 300
+)
+
+var _ output.Output = &Collector{}
+
+// Collector is an output that aggregates the samples of every metric in
+// buckets of one second, for the charts of the HTML report.
+type Collector struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+	logger          logrus.FieldLogger
+	reports         Reports
+
+	mu     sync.Mutex
+	start  time.Time
+	series map[*metrics.Metric][]bucket
+}
+
+// bucket are the aggregated values of the samples of a metric in a period.
+type bucket struct {
+	count, sum, last float64
+	// nonZero is the number of the non-zero values, for the rates
+	nonZero float64
+}
+
+func (b *bucket) add(value float64) {
+	b.count++
+	b.sum += value
+	b.last = value
+	if value != 0 {
+		b.nonZero++
+	}
+}
+
+func (b *bucket) merge(other bucket) {
+	if other.count == 0 {
+		return
+	}
+	b.count += other.count
+	b.sum += other.sum
+	b.last = other.last
+	b.nonZero += other.nonZero
+}
+
+// NewCollector returns a new collector for the reports.
+func NewCollector(reports Reports, logger logrus.FieldLogger) *Collector {
+	return &Collector{
+		logger:  logger.WithFields(logrus.Fields{"output": "report"}),
+		reports: reports,
+		series:  make(map[*metrics.Metric][]bucket),
+	}
+}
+
+// Description returns a human-readable description of the output.
+func (c *Collector) Description() string {
+	return "report (" + c.reports.String() + ")"
+}
+
+// Start starts the goroutine for the periodic aggregation of the samples.
+func (c *Collector) Start() error {
+	c.logger.Debug("Starting...")
+	pf, err := output.NewPeriodicFlusher(collectRate, c.flushMetrics)
+	if err != nil {
+		return err
+	}
+	c.logger.Debug("Started!")
+	c.periodicFlusher = pf
+	return nil
+}
+
+// Stop aggregates the remaining samples and stops the goroutine.
+func (c *Collector) Stop() error {
+	c.logger.Debug("Stopping...")
+	defer c.logger.Debug("Stopped!")
+	c.periodicFlusher.Stop()
+	return nil
+}
+
+func (c *Collector) flushMetrics() {
+	containers := c.GetBufferedSamples()
+	if len(containers) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sc := range containers {
+		for _, s := range sc.GetSamples() {
+			c.add(s)
+		}
+	}
+}
+
+func (c *Collector) add(s metrics.Sample) {
+	if c.start.IsZero() {
+		c.start = s.Time.Truncate(bucketPeriod)
+	}
+	i := int(s.Time.Sub(c.start) / bucketPeriod)
+	if i < 0 {
+		// the samples are almost in order, the few late ones of the first
+		// second are added to the first bucket
+		i = 0
+	}
+	buckets := c.series[s.Metric]
+	if i >= len(buckets) {
+		buckets = append(buckets, make([]bucket, i-len(buckets)+1)...)
+		c.series[s.Metric] = buckets
+	}
+	buckets[i].add(s.Value)
+}
+
+// chartPoint is a value of a metric over time, the offset is from the start
+// of the test run.
+type chartPoint struct {
+	offset time.Duration
+	value  float64
+}
+
+// chartSeries returns the values over time of the metric for its chart, with
+// the buckets merged so there are at most maxChartPoints points. For the
+// counters it's the rate per second, for the gauges the last value, for the
+// rates the ratio of the non-zero values and for the trends the average.
+// The periods without any samples are skipped, except for the counters.
+func (c *Collector) chartSeries(m *metrics.Metric) []chartPoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	buckets := c.series[m]
+	if len(buckets) == 0 {
+		return nil
+	}
+	size := (len(buckets) + maxChartPoints - 1) / maxChartPoints
+	period := bucketPeriod * time.Duration(size)
+
+	points := make([]chartPoint, 0, len(buckets)/size+1)
+	for start := 0; start < len(buckets); start += size {
+		end := start + size
+		if end > len(buckets) {
+			end = len(buckets)
+		}
+		var merged bucket
+		for _, b := range buckets[start:end] {
+			merged.merge(b)
+		}
+		offset := bucketPeriod * time.Duration(start)
+		switch {
+		case m.Type == metrics.Counter:
+			points = append(points, chartPoint{offset, merged.sum / period.Seconds()})
+		case merged.count == 0:
+			continue
+		case m.Type == metrics.Gauge:
+			points = append(points, chartPoint{offset, merged.last})
+		case m.Type == metrics.Rate:
+			points = append(points, chartPoint{offset, merged.nonZero / merged.count})
+		default:
+			points = append(points, chartPoint{offset, merged.sum / merged.count})
+		}
+	}
+	return points
+}
+
+// timeSeriesChartPoints returns the values over time of the aggregated
+// periods of a metric, like chartSeries, with the offsets from the start.
+func timeSeriesChartPoints(
+	periods []metrics.AggregatedPeriod, resolution time.Duration, start time.Time,
+) []chartPoint {
+	points := make([]chartPoint, 0, len(periods))
+	for _, p := range periods {
+		var value float64
+		switch sink := p.Sink.(type) {
+		case *metrics.CounterSink:
+			value = sink.Value / resolution.Seconds()
+		case *metrics.GaugeSink:
+			value = sink.Value
+		case *metrics.RateSink:
+			if sink.Total == 0 {
+				continue
+			}
+			value = float64(sink.Trues) / float64(sink.Total)
+		case *metrics.TrendSink:
+			if sink.IsEmpty() {
+				continue
+			}
+			value = sink.Avg()
+		default:
+			continue
+		}
+		points = append(points, chartPoint{p.Start.Sub(start), value})
+	}
+	return points
+}
@@ -0,0 +1,228 @@
+package report
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/metrics"
+)
+
+//go:embed report.html
+var htmlTemplateSource string
+
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateSource))
+
+const (
+	chartWidth  = 600
+	chartHeight = 150
+	// chartMargin is the space above the chart for the label of the maximum
+	chartMargin = 15
+)
+
+type htmlReport struct {
+	Version    string
+	Generated  string
+	Duration   string
+	Passed     bool
+	Thresholds []thresholdResult
+	Checks     []checkResult
+	Metrics    []htmlMetric
+}
+
+type thresholdResult struct {
+	Metric string
+	Source string
+	Passed bool
+}
+
+type checkResult struct {
+	Groups []string
+	Name   string
+	Passes int64
+	Fails  int64
+}
+
+// Group returns the names of the groups of the check.
+func (c checkResult) Group() string {
+	return strings.Join(c.Groups, " › ")
+}
+
+type htmlMetric struct {
+	Name   string
+	Type   string
+	Values string
+	Chart  *htmlChart
+}
+
+type htmlChart struct {
+	Width, Height int
+	Points        string
+	Max           string
+	Duration      string
+	Description   string
+}
+
+func writeHTML(w io.Writer, summary *lib.Summary, collector *Collector) error {
+	report := htmlReport{
+		Version:    consts.Version,
+		Generated:  time.Now().Format(time.RFC1123),
+		Duration:   summary.TestRunDuration.Round(time.Millisecond).String(),
+		Passed:     true,
+		Thresholds: thresholdResults(summary.Metrics),
+	}
+	for _, t := range report.Thresholds {
+		report.Passed = report.Passed && t.Passed
+	}
+	if summary.RootGroup != nil {
+		report.Checks = checkResults(summary.RootGroup, nil)
+	}
+
+	// the time series of the metrics engine, if they are recorded, are used
+	// instead of the buckets of the collector, for the configured resolution
+	var start time.Time
+	for _, periods := range summary.TimeSeries {
+		if len(periods) > 0 && (start.IsZero() || periods[0].Start.Before(start)) {
+			start = periods[0].Start
+		}
+	}
+
+	for _, name := range sortedMetricNames(summary.Metrics) {
+		m := summary.Metrics[name]
+		metric := htmlMetric{
+			Name:   name,
+			Type:   m.Type.String(),
+			Values: formatSinkValues(m, summary.TestRunDuration),
+		}
+		switch {
+		case m.Sub != nil:
+			// the sub-metrics don't have charts
+		case len(summary.TimeSeries[name]) > 0:
+			series := timeSeriesChartPoints(summary.TimeSeries[name], summary.TimeSeriesResolution, start)
+			metric.Chart = newHTMLChart(m, series, summary.TestRunDuration)
+		case collector != nil:
+			metric.Chart = newHTMLChart(m, collector.chartSeries(m), summary.TestRunDuration)
+		}
+		report.Metrics = append(report.Metrics, metric)
+	}
+
+	return htmlTemplate.Execute(w, report)
+}
+
+func sortedMetricNames(ms map[string]*metrics.Metric) []string {
+	names := make([]string, 0, len(ms))
+	for name := range ms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func thresholdResults(ms map[string]*metrics.Metric) []thresholdResult {
+	var thresholds []thresholdResult
+	for _, name := range sortedMetricNames(ms) {
+		for _, t := range ms[name].Thresholds.Thresholds {
+			thresholds = append(thresholds, thresholdResult{Metric: name, Source: t.Source, Passed: !t.LastFailed})
+		}
+	}
+	return thresholds
+}
+
+// checkResults returns the checks of the group and of its sub-groups, depth
+// first, in the order they were defined.
+func checkResults(g *lib.Group, path []string) []checkResult {
+	var checks []checkResult
+	if g.Name != "" {
+		path = append(path[:len(path):len(path)], g.Name)
+	}
+	for _, c := range g.OrderedChecks {
+		checks = append(checks, checkResult{
+			Groups: path,
+			Name:   c.Name,
+			Passes: c.Passes,
+			Fails:  c.Fails,
+		})
+	}
+	for _, sub := range g.OrderedGroups {
+		checks = append(checks, checkResults(sub, path)...)
+	}
+	return checks
+}
+
+func formatSinkValues(m *metrics.Metric, duration time.Duration) string {
+	values := m.Sink.Format(duration)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		keys[i] = key + "=" + formatValue(m, values[key], key)
+	}
+	return strings.Join(keys, " ")
+}
+
+func formatValue(m *metrics.Metric, value float64, stat string) string {
+	switch {
+	case m.Type == metrics.Rate && stat == "rate":
+		return strconv.FormatFloat(value*100, 'f', 2, 64) + "%"
+	case m.Contains == metrics.Time && stat != "rate" && stat != "count":
+		return (time.Duration(value * float64(time.Millisecond))).Round(time.Microsecond).String()
+	case m.Contains == metrics.Data && stat != "rate":
+		return strconv.FormatFloat(value, 'f', 0, 64) + " B"
+	default:
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	}
+}
+
+func newHTMLChart(m *metrics.Metric, series []chartPoint, duration time.Duration) *htmlChart {
+	if len(series) == 0 {
+		return nil
+	}
+	if last := series[len(series)-1].offset; duration <= last {
+		duration = last + bucketPeriod
+	}
+	maxValue := 0.0
+	for _, p := range series {
+		if p.value > maxValue {
+			maxValue = p.value
+		}
+	}
+
+	points := make([]string, 0, len(series))
+	for _, p := range series {
+		x := float64(chartWidth) * float64(p.offset) / float64(duration)
+		y := float64(chartHeight)
+		if maxValue > 0 {
+			y -= float64(chartHeight) * p.value / maxValue
+		}
+		points = append(points, strconv.FormatFloat(x, 'f', 1, 64)+","+strconv.FormatFloat(y, 'f', 1, 64))
+	}
+
+	chart := &htmlChart{
+		Width:    chartWidth,
+		Height:   chartHeight + chartMargin,
+		Points:   strings.Join(points, " "),
+		Max:      formatValue(m, maxValue, "avg"),
+		Duration: duration.Round(time.Second).String(),
+	}
+	switch m.Type {
+	case metrics.Counter:
+		chart.Description = "rate per second"
+		chart.Max = strconv.FormatFloat(maxValue, 'f', 2, 64) + "/s"
+	case metrics.Gauge:
+		chart.Description = "value"
+	case metrics.Rate:
+		chart.Description = "rate"
+		chart.Max = formatValue(m, maxValue, "rate")
+	default:
+		chart.Description = "average"
+	}
+	return chart
+}
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.k6.io/k6/lib"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+func (s *junitTestSuite) add(tc junitTestCase) {
+	s.Tests++
+	if tc.Failure != nil {
+		s.Failures++
+	}
+	s.Cases = append(s.Cases, tc)
+}
+
+// writeJUnit writes a test suite with a test case for every threshold, and
+// one with a test case for every check, the failed ones are the crossed
+// thresholds and the checks that have failed at least once.
+func writeJUnit(w io.Writer, summary *lib.Summary) error {
+	thresholds := junitTestSuite{Name: "thresholds"}
+	for _, t := range thresholdResults(summary.Metrics) {
+		tc := junitTestCase{Name: t.Metric + ": " + t.Source, ClassName: "k6.thresholds"}
+		if !t.Passed {
+			tc.Failure = &junitFailure{Message: fmt.Sprintf("the threshold '%s' on '%s' has been crossed", t.Source, t.Metric)}
+		}
+		thresholds.add(tc)
+	}
+
+	checks := junitTestSuite{Name: "checks"}
+	if summary.RootGroup != nil {
+		for _, c := range checkResults(summary.RootGroup, nil) {
+			tc := junitTestCase{Name: c.Name, ClassName: junitClassName(c.Groups)}
+			if c.Fails > 0 {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("%d out of %d failed", c.Fails, c.Passes+c.Fails),
+				}
+			}
+			checks.add(tc)
+		}
+	}
+
+	suites := junitTestSuites{
+		Name:   "k6",
+		Time:   fmt.Sprintf("%.3f", summary.TestRunDuration.Seconds()),
+		Suites: []junitTestSuite{thresholds, checks},
+	}
+	for _, s := range suites.Suites {
+		suites.Tests += s.Tests
+		suites.Failures += s.Failures
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitClassName returns the class name of the checks of a group, with the
+// names of the groups separated by dots.
+func junitClassName(groups []string) string {
+	return strings.Join(append([]string{"k6", "checks"}, groups...), ".")
+}
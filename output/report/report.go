@@ -0,0 +1,101 @@
+// Package report generates the end-of-test reports of the --report option,
+// i.e. a self-contained HTML page and a JUnit XML file for the CI systems.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const (
+	// FormatHTML is the self-contained HTML report, with the charts of the
+	// metrics over time, the checks and the thresholds.
+	FormatHTML = "html"
+	// FormatJUnit is the JUnit XML report of the thresholds and the checks.
+	FormatJUnit = "junit"
+)
+
+// Reports are the file paths of the reports to generate by their format.
+type Reports map[string]string
+
+// ParseArgument parses the value of the --report option, e.g.
+// html=report.html,junit=report.xml.
+func ParseArgument(arg string) (Reports, error) {
+	reports := make(Reports)
+	for _, part := range strings.Split(arg, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		format, path, ok := strings.Cut(part, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid report '%s', the format is <format>=<file>, e.g. html=report.html", part)
+		}
+		if format != FormatHTML && format != FormatJUnit {
+			return nil, fmt.Errorf("invalid report format '%s', available formats are: %s, %s",
+				format, FormatHTML, FormatJUnit)
+		}
+		if _, ok := reports[format]; ok {
+			return nil, fmt.Errorf("the %s report is defined more than once", format)
+		}
+		reports[format] = path
+	}
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("there aren't any reports in '%s'", arg)
+	}
+	return reports, nil
+}
+
+// String returns the reports in the same format of the --report option.
+func (r Reports) String() string {
+	formats := make([]string, 0, len(r))
+	for format := range r {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	for i, format := range formats {
+		formats[i] = format + "=" + r[format]
+	}
+	return strings.Join(formats, ",")
+}
+
+// Generate writes the reports with the end-of-test summary and the samples
+// over time gathered by the collector.
+func (r Reports) Generate(fs fsext.Fs, summary *lib.Summary, collector *Collector) error {
+	var errs []string
+	for _, format := range []string{FormatHTML, FormatJUnit} {
+		path, ok := r[format]
+		if !ok {
+			continue
+		}
+		if err := writeReport(fs, path, func(w io.Writer) error {
+			if format == FormatHTML {
+				return writeHTML(w, summary, collector)
+			}
+			return writeJUnit(w, summary)
+		}); err != nil {
+			errs = append(errs, fmt.Sprintf("the %s report to '%s': %s", format, path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("could not generate %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func writeReport(fs fsext.Fs, path string, write func(io.Writer) error) error {
+	f, err := fs.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
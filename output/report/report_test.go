@@ -0,0 +1,181 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+func TestParseArgument(t *testing.T) {
+	t.Parallel()
+
+	reports, err := ParseArgument("html=report.html, junit=out/report.xml")
+	require.NoError(t, err)
+	assert.Equal(t, Reports{FormatHTML: "report.html", FormatJUnit: "out/report.xml"}, reports)
+	assert.Equal(t, "html=report.html,junit=out/report.xml", reports.String())
+
+	for arg, expErr := range map[string]string{
+		"":                        "there aren't any reports",
+		"report.html":             "the format is <format>=<file>",
+		"html=":                   "the format is <format>=<file>",
+		"pdf=report.pdf":          "invalid report format 'pdf'",
+		"html=a.html,html=b.html": "the html report is defined more than once",
+	} {
+		_, err := ParseArgument(arg)
+		assert.ErrorContains(t, err, expErr, arg)
+	}
+}
+
+func TestCollectorChartSeries(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	counter, err := registry.NewMetric("my_counter", metrics.Counter)
+	require.NoError(t, err)
+	trend, err := registry.NewMetric("my_trend", metrics.Trend)
+	require.NoError(t, err)
+	rate, err := registry.NewMetric("my_rate", metrics.Rate)
+	require.NoError(t, err)
+
+	c := NewCollector(Reports{FormatHTML: "report.html"}, testutils.NewLogger(t))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	add := func(m *metrics.Metric, offset time.Duration, value float64) {
+		c.add(metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: registry.RootTagSet()},
+			Time:       start.Add(offset),
+			Value:      value,
+		})
+	}
+	add(counter, 0, 2)
+	add(counter, 500*time.Millisecond, 3)
+	add(counter, 2*time.Second, 1)
+	add(trend, 0, 10)
+	add(trend, 100*time.Millisecond, 20)
+	add(trend, 2*time.Second, 40)
+	add(rate, 0, 1)
+	add(rate, time.Second, 0)
+	add(rate, time.Second, 1)
+
+	assert.Equal(t, []chartPoint{{0, 5}, {time.Second, 0}, {2 * time.Second, 1}}, c.chartSeries(counter))
+	assert.Equal(t, []chartPoint{{0, 15}, {2 * time.Second, 40}}, c.chartSeries(trend))
+	assert.Equal(t, []chartPoint{{0, 1}, {time.Second, 0.5}}, c.chartSeries(rate))
+
+	// the buckets are merged when there are too many of them
+	for i := 0; i < 2*maxChartPoints; i++ {
+		add(trend, time.Duration(i)*time.Second, float64(i))
+	}
+	series := c.chartSeries(trend)
+	require.Len(t, series, maxChartPoints)
+	assert.Equal(t, 2*time.Second, series[1].offset)
+}
+
+func TestTimeSeriesChartPoints(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	counter, rate, empty := &metrics.CounterSink{}, &metrics.RateSink{}, &metrics.RateSink{}
+	counter.Add(metrics.Sample{Value: 10})
+	rate.Add(metrics.Sample{Value: 1})
+	rate.Add(metrics.Sample{Value: 0})
+
+	assert.Equal(t, []chartPoint{{5 * time.Second, 2}}, timeSeriesChartPoints(
+		[]metrics.AggregatedPeriod{{Start: start.Add(5 * time.Second), Sink: counter}}, 5*time.Second, start))
+	assert.Equal(t, []chartPoint{{0, 0.5}}, timeSeriesChartPoints([]metrics.AggregatedPeriod{
+		{Start: start, Sink: rate}, {Start: start.Add(time.Second), Sink: empty},
+	}, time.Second, start))
+}
+
+func newTestSummary(t *testing.T) *lib.Summary {
+	t.Helper()
+
+	registry := metrics.NewRegistry()
+	reqs, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	reqs.Sink.Add(metrics.Sample{Time: time.Now(), Value: 10})
+	reqs.Thresholds = metrics.NewThresholds([]string{"count<5", "count>1"})
+	reqs.Thresholds.Thresholds[0].LastFailed = true
+
+	root, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	check, err := root.Check("status is 200")
+	require.NoError(t, err)
+	check.Passes = 3
+	group, err := root.Group("login")
+	require.NoError(t, err)
+	check, err = group.Check("has <token>")
+	require.NoError(t, err)
+	check.Passes, check.Fails = 1, 2
+
+	return &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{"http_reqs": reqs},
+		RootGroup:       root,
+		TestRunDuration: 2 * time.Second,
+	}
+}
+
+func TestGenerateJUnit(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	require.NoError(t, Reports{FormatJUnit: "report.xml"}.Generate(fs, newTestSummary(t), nil))
+
+	data, err := fsext.ReadFile(fs, "report.xml")
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="k6" tests="4" failures="2" time="2.000">
+  <testsuite name="thresholds" tests="2" failures="1">
+    <testcase name="http_reqs: count&lt;5" classname="k6.thresholds">
+      <failure message="the threshold &#39;count&lt;5&#39; on &#39;http_reqs&#39; has been crossed"></failure>
+    </testcase>
+    <testcase name="http_reqs: count&gt;1" classname="k6.thresholds"></testcase>
+  </testsuite>
+  <testsuite name="checks" tests="2" failures="1">
+    <testcase name="status is 200" classname="k6.checks"></testcase>
+    <testcase name="has &lt;token&gt;" classname="k6.checks.login">
+      <failure message="2 out of 3 failed"></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, string(data))
+}
+
+func TestGenerateHTML(t *testing.T) {
+	t.Parallel()
+
+	summary := newTestSummary(t)
+	c := NewCollector(Reports{FormatHTML: "report.html"}, testutils.NewLogger(t))
+	c.add(metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: summary.Metrics["http_reqs"]},
+		Time:       time.Now(),
+		Value:      10,
+	})
+
+	fs := fsext.NewMemMapFs()
+	require.NoError(t, Reports{FormatHTML: "report.html"}.Generate(fs, summary, c))
+
+	data, err := fsext.ReadFile(fs, "report.html")
+	require.NoError(t, err)
+	html := string(data)
+	assert.Contains(t, html, "Thresholds failed")
+	assert.Contains(t, html, "<td>count&lt;5</td>")
+	assert.Contains(t, html, "<td>login</td><td>has &lt;token&gt;</td>")
+	assert.Contains(t, html, `<polyline points="0.0,0.0"/>`)
+	assert.Contains(t, html, "count=10.00 rate=5.00")
+	assert.False(t, strings.Contains(html, "<script"), "the report shouldn't need any scripts")
+}
+
+func TestGenerateError(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewReadOnlyFs(fsext.NewMemMapFs())
+	err := Reports{FormatHTML: "report.html"}.Generate(fs, newTestSummary(t), nil)
+	assert.ErrorContains(t, err, "could not generate the html report to 'report.html'")
+}
@@ -0,0 +1,99 @@
+package output
+
+import (
+	"sync/atomic"
+
+	"go.k6.io/k6/metrics"
+)
+
+// sampleRing is a bounded lock-free queue of batches of samples, based on the
+// https://www.1024cores.net/home/lock-free-algorithms/queues/bounded-mpmc-queue
+// algorithm. Every output has one, so the batches of the samples can be handed
+// to it without waiting for it, or for the other outputs, to flush them.
+type sampleRing struct {
+	slots []ringSlot
+	mask  uint64
+
+	// the positions of the next batch to push and to pop, on separate cache
+	// lines, since they are updated by different goroutines
+	_    [56]byte
+	tail uint64
+	_    [56]byte
+	head uint64
+	_    [56]byte
+}
+
+type ringSlot struct {
+	// seq is the position the slot is ready to be pushed to, or that plus one
+	// once a batch was pushed to it and it's ready to be popped
+	seq   uint64
+	batch []metrics.SampleContainer
+}
+
+// newSampleRing returns a sampleRing for size batches, rounded up to a power
+// of two.
+func newSampleRing(size int) *sampleRing {
+	capacity := uint64(1)
+	for capacity < uint64(size) {
+		capacity <<= 1
+	}
+	r := &sampleRing{slots: make([]ringSlot, capacity), mask: capacity - 1}
+	for i := range r.slots {
+		r.slots[i].seq = uint64(i)
+	}
+	return r
+}
+
+// push adds the batch to the ring, and returns false if it's full.
+func (r *sampleRing) push(batch []metrics.SampleContainer) bool {
+	pos := atomic.LoadUint64(&r.tail)
+	for {
+		slot := &r.slots[pos&r.mask]
+		switch seq := atomic.LoadUint64(&slot.seq); {
+		case seq == pos:
+			if atomic.CompareAndSwapUint64(&r.tail, pos, pos+1) {
+				slot.batch = batch
+				atomic.StoreUint64(&slot.seq, pos+1)
+				return true
+			}
+			pos = atomic.LoadUint64(&r.tail)
+		case seq < pos:
+			return false
+		default:
+			pos = atomic.LoadUint64(&r.tail)
+		}
+	}
+}
+
+// pop removes the oldest batch from the ring, and returns false if it's empty.
+func (r *sampleRing) pop() ([]metrics.SampleContainer, bool) {
+	pos := atomic.LoadUint64(&r.head)
+	for {
+		slot := &r.slots[pos&r.mask]
+		switch seq := atomic.LoadUint64(&slot.seq); {
+		case seq == pos+1:
+			if atomic.CompareAndSwapUint64(&r.head, pos, pos+1) {
+				batch := slot.batch
+				slot.batch = nil
+				atomic.StoreUint64(&slot.seq, pos+r.mask+1)
+				return batch, true
+			}
+			pos = atomic.LoadUint64(&r.head)
+		case seq < pos+1:
+			return nil, false
+		default:
+			pos = atomic.LoadUint64(&r.head)
+		}
+	}
+}
+
+// drain pops all of the batches in the ring, and appends their samples to buf.
+func (r *sampleRing) drain(buf []metrics.SampleContainer) []metrics.SampleContainer {
+	for {
+		batch, ok := r.pop()
+		if !ok {
+			return buf
+		}
+		buf = append(buf, batch...)
+	}
+}
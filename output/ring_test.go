@@ -0,0 +1,79 @@
+package output
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestSampleRing(t *testing.T) {
+	t.Parallel()
+
+	r := newSampleRing(3)
+	assert.Len(t, r.slots, 4)
+	_, ok := r.pop()
+	assert.False(t, ok)
+
+	batch := func(value float64) []metrics.SampleContainer {
+		return []metrics.SampleContainer{metrics.Sample{Value: value}}
+	}
+	for i := 0; i < 4; i++ {
+		require.True(t, r.push(batch(float64(i))))
+	}
+	assert.False(t, r.push(batch(4)))
+
+	popped, ok := r.pop()
+	require.True(t, ok)
+	assert.Equal(t, batch(0), popped)
+	require.True(t, r.push(batch(4)))
+
+	assert.Equal(t, []metrics.SampleContainer{
+		metrics.Sample{Value: 1}, metrics.Sample{Value: 2}, metrics.Sample{Value: 3}, metrics.Sample{Value: 4},
+	}, r.drain(nil))
+	assert.Empty(t, r.drain(nil))
+}
+
+func TestSampleRingConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const producers, batches = 8, 1000
+	r := newSampleRing(16)
+
+	wg := &sync.WaitGroup{}
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < batches; i++ {
+				for !r.push([]metrics.SampleContainer{metrics.Sample{Value: 1}}) {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var sum float64
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		for _, sc := range r.drain(nil) {
+			sum += sc.GetSamples()[0].Value
+		}
+		runtime.Gosched()
+	}
+	assert.Equal(t, float64(producers*batches), sum)
+}
@@ -0,0 +1,90 @@
+// Package sqlite implements an output that writes the metric samples, the
+// checks and the metadata of the test runs to a single SQLite file, which can
+// be analyzed with `k6 report` or any SQLite client.
+package sqlite
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// config is the configuration of the SQLite output.
+type config struct {
+	// FileName is the path of the database, the results of every test run
+	// are added to it.
+	FileName null.String `json:"fileName,omitempty" envconfig:"K6_SQLITE_FILENAME"`
+	// RunName is the name of the test run, the name of the script by default.
+	RunName null.String `json:"runName,omitempty" envconfig:"K6_SQLITE_RUN_NAME"`
+	// PushInterval is how often the samples are written, each write is a
+	// transaction.
+	PushInterval types.NullDuration `json:"pushInterval,omitempty" envconfig:"K6_SQLITE_PUSH_INTERVAL"`
+}
+
+// newConfig creates a new config instance with the default values.
+func newConfig() config {
+	return config{
+		FileName:     null.NewString("k6-results.db", false),
+		RunName:      null.NewString("", false),
+		PushInterval: types.NewNullDuration(1*time.Second, false),
+	}
+}
+
+// Apply saves the non-zero config values from the passed config in the receiver.
+func (c config) Apply(cfg config) config {
+	if cfg.FileName.Valid {
+		c.FileName = cfg.FileName
+	}
+	if cfg.RunName.Valid {
+		c.RunName = cfg.RunName
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+
+	return c
+}
+
+func (c config) validate() error {
+	if c.FileName.String == "" {
+		return errors.New("the file name can't be empty")
+	}
+	if c.PushInterval.TimeDuration() <= 0 {
+		return errors.New("the push interval has to be positive")
+	}
+	return nil
+}
+
+// getConsolidatedConfig combines {default config values + JSON config +
+// environment vars + argument}, and returns the final result. The argument of
+// --out sqlite=<file> is the file name.
+func getConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (config, error) {
+	result := newConfig()
+	if jsonRawConf != nil {
+		jsonConf := config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		result = result.Apply(config{FileName: null.StringFrom(arg)})
+	}
+
+	return result, result.validate()
+}
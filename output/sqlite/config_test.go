@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	withDefaults := func(c config) config { return newConfig().Apply(c) }
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  config
+		errMsg  string
+	}{
+		"Defaults": {
+			config: newConfig(),
+		},
+		"JSONEnvAndArg": {
+			jsonRaw: json.RawMessage(`{"fileName":"json.db","runName":"json run","pushInterval":"5s"}`),
+			env:     map[string]string{"K6_SQLITE_RUN_NAME": "env run"},
+			arg:     "arg.db",
+			config: withDefaults(config{
+				FileName:     null.StringFrom("arg.db"),
+				RunName:      null.StringFrom("env run"),
+				PushInterval: types.NullDurationFrom(5 * time.Second),
+			}),
+		},
+		"InvalidPushInterval": {
+			env:    map[string]string{"K6_SQLITE_PUSH_INTERVAL": "0s"},
+			errMsg: "the push interval has to be positive",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, err := getConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.errMsg != "" {
+				require.EqualError(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, c)
+		})
+	}
+}
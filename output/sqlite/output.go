@@ -0,0 +1,228 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// New creates a new SQLite output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := getConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	runName := conf.RunName.String
+	if runName == "" && params.ScriptPath != nil {
+		runName = path.Base(params.ScriptPath.Path)
+	}
+
+	return &Output{
+		config:  conf,
+		logger:  params.Logger.WithFields(logrus.Fields{"output": "sqlite"}),
+		runName: runName,
+		options: params.ScriptOptions,
+		metrics: make(map[*metrics.Metric]struct{}),
+		now:     time.Now,
+	}, nil
+}
+
+var _ output.Output = &Output{}
+
+// Output periodically writes the buffered metric samples to the SQLite
+// database, every test run is a new row of the test_runs table.
+type Output struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+
+	config  config
+	logger  logrus.FieldLogger
+	runName string
+	options lib.Options
+
+	db        *sql.DB
+	testRunID int64
+	// metrics are the metrics already saved for the test run
+	metrics map[*metrics.Metric]struct{}
+	now     func() time.Time
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("sqlite (%s)", o.config.FileName.String)
+}
+
+// Start creates the tables, if needed, and the test run, then it starts the
+// goroutine for the periodic writes of the samples.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	db, err := sql.Open("sqlite3", o.config.FileName.String)
+	if err != nil {
+		return err
+	}
+	// the writes are serialized anyway, a single connection avoids the
+	// busy errors of the concurrent transactions
+	db.SetMaxOpenConns(1)
+	if err = o.createTestRun(db); err != nil {
+		_ = db.Close()
+		return fmt.Errorf("couldn't create the test run in '%s': %w", o.config.FileName.String, err)
+	}
+	o.db = db
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.WithField("test_run_id", o.testRunID).Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop writes the remaining samples and the end of the test run, then it
+// closes the database.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	_, err := o.db.Exec("UPDATE test_runs SET ended_at = ? WHERE id = ?", o.now().UnixMicro(), o.testRunID)
+	if closeErr := o.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (o *Output) createTestRun(db *sql.DB) error {
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	// only the options that describe the test are stored, without the secrets
+	options, err := json.Marshal(output.NewTestRunOptions(o.options))
+	if err != nil {
+		return err
+	}
+	res, err := db.Exec("INSERT INTO test_runs (name, k6_version, options, started_at) VALUES (?, ?, ?, ?)",
+		o.runName, consts.Version, string(options), o.now().UnixMicro())
+	if err != nil {
+		return err
+	}
+	o.testRunID, err = res.LastInsertId()
+	return err
+}
+
+func (o *Output) flushMetrics() {
+	samples := o.GetBufferedSamples()
+	if len(samples) == 0 {
+		return
+	}
+
+	start := time.Now()
+	count, err := o.write(samples)
+	if err != nil {
+		o.logger.WithError(err).Error("Couldn't write the samples")
+		return
+	}
+	o.logger.WithFields(logrus.Fields{
+		"t":       time.Since(start),
+		"samples": count,
+	}).Debug("Wrote the samples")
+}
+
+// write writes the samples in a single transaction, and returns how many
+// were written.
+func (o *Output) write(containers []metrics.SampleContainer) (count int, err error) {
+	tx, err := o.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	insertMetric, err := tx.Prepare("INSERT OR IGNORE INTO metrics (test_run_id, name, type, contains) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	insertSample, err := tx.Prepare(
+		"INSERT INTO samples (test_run_id, time, metric, value, tags, metadata) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	insertCheck, err := tx.Prepare(`INSERT INTO checks (test_run_id, time, name, "group", passed) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+
+	newMetrics := make(map[*metrics.Metric]struct{})
+	for _, sc := range containers {
+		for _, s := range sc.GetSamples() {
+			if _, ok := o.metrics[s.Metric]; !ok {
+				if _, err = insertMetric.Exec(o.testRunID, s.Metric.Name, s.Metric.Type.String(),
+					s.Metric.Contains.String()); err != nil {
+					return 0, err
+				}
+				newMetrics[s.Metric] = struct{}{}
+			}
+			if err = o.writeSample(insertSample, insertCheck, s); err != nil {
+				return 0, err
+			}
+			count++
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	for m := range newMetrics {
+		o.metrics[m] = struct{}{}
+	}
+	return count, nil
+}
+
+func (o *Output) writeSample(insertSample, insertCheck *sql.Stmt, s metrics.Sample) error {
+	tags := s.Tags.Map()
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	var metadata interface{}
+	if len(s.Metadata) > 0 {
+		metadataJSON, err := json.Marshal(s.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(metadataJSON)
+	}
+	t := s.Time.UnixMicro()
+	if _, err = insertSample.Exec(o.testRunID, t, s.Metric.Name, s.Value, string(tagsJSON), metadata); err != nil {
+		return err
+	}
+
+	if s.Metric.Name != metrics.ChecksName {
+		return nil
+	}
+	name, ok := tags[metrics.TagCheck.String()]
+	if !ok {
+		return nil
+	}
+	_, err = insertCheck.Exec(o.testRunID, t, name, tags[metrics.TagGroup.String()], s.Value != 0)
+	return err
+}
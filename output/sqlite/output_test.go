@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+func newTestOutput(t *testing.T, fileName string, now time.Time) *Output {
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: fileName,
+		ScriptPath:     &url.URL{Scheme: "file", Path: "/tests/script.js"},
+	})
+	require.NoError(t, err)
+	o.now = func() time.Time { return now }
+	return o
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "results.db")
+	registry := metrics.NewRegistry()
+	trend := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	checks := registry.MustNewMetric(metrics.ChecksName, metrics.Rate)
+	sample := func(m *metrics.Metric, value float64, tags map[string]string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: registry.RootTagSet().WithTagsFromMap(tags)},
+			Time:       time.Unix(10, 0),
+			Value:      value,
+		}
+	}
+
+	// every test run is added to the same file
+	for i := 0; i < 2; i++ {
+		o := newTestOutput(t, fileName, time.Unix(int64(10*i), 0))
+		require.NoError(t, o.Start())
+
+		traced := sample(trend, 120.5, map[string]string{"status": "200"})
+		traced.Metadata = map[string]string{lib.MetadataTraceID: "abc"}
+		o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+			traced,
+			sample(checks, 1, map[string]string{"check": "is ok", "group": "::my group"}),
+		}})
+		o.flushMetrics()
+		o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+			sample(trend, 90, map[string]string{"status": "404"}),
+			sample(checks, 0, map[string]string{"check": "is ok", "group": "::my group"}),
+		}})
+		o.now = func() time.Time { return time.Unix(int64(10*i+5), 0) }
+		require.NoError(t, o.Stop())
+	}
+
+	db, err := OpenResults(fileName)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+
+	runs, err := ReadTestRuns(db)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	for i, r := range runs {
+		assert.Equal(t, int64(i+1), r.ID)
+		assert.Equal(t, "script.js", r.Name)
+		assert.Equal(t, time.Unix(int64(10*i), 0), r.StartedAt)
+		assert.Equal(t, 5*time.Second, r.Duration())
+	}
+
+	readRegistry := metrics.NewRegistry()
+	var samples []metrics.Sample
+	require.NoError(t, ReadSamples(db, 2, readRegistry, func(s metrics.Sample) { samples = append(samples, s) }))
+	require.Len(t, samples, 4)
+	assert.Equal(t, metrics.Trend, samples[0].Metric.Type)
+	assert.Equal(t, metrics.Time, samples[0].Metric.Contains)
+	assert.Equal(t, 120.5, samples[0].Value)
+	assert.Equal(t, time.Unix(10, 0), samples[0].Time)
+	assert.Equal(t, map[string]string{"status": "200"}, samples[0].Tags.Map())
+	assert.Equal(t, map[string]string{lib.MetadataTraceID: "abc"}, samples[0].Metadata)
+	assert.Equal(t, metrics.ChecksName, samples[1].Metric.Name)
+	assert.Equal(t, map[string]string{"status": "404"}, samples[2].Tags.Map())
+	assert.Nil(t, samples[2].Metadata)
+
+	checkResults, err := ReadChecks(db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []CheckResult{{Name: "is ok", Group: "::my group", Passes: 1, Fails: 1}}, checkResults)
+}
+
+func TestOpenResultsInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := OpenResults(filepath.Join(t.TempDir(), "missing.db"))
+	assert.ErrorContains(t, err, "doesn't contain the results of the sqlite output")
+}
@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	// the SQLite driver and its embedded build
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+
+	"go.k6.io/k6/metrics"
+)
+
+// schema creates the tables of the results, if they don't exist yet. The
+// times are in microseconds since the Unix epoch, the tags and the metadata
+// of the samples are JSON objects.
+const schema = `
+CREATE TABLE IF NOT EXISTS test_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	k6_version TEXT NOT NULL,
+	options TEXT NOT NULL,
+	started_at INTEGER NOT NULL,
+	ended_at INTEGER
+);
+CREATE TABLE IF NOT EXISTS metrics (
+	test_run_id INTEGER NOT NULL REFERENCES test_runs (id),
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	contains TEXT NOT NULL,
+	PRIMARY KEY (test_run_id, name)
+);
+CREATE TABLE IF NOT EXISTS samples (
+	test_run_id INTEGER NOT NULL REFERENCES test_runs (id),
+	time INTEGER NOT NULL,
+	metric TEXT NOT NULL,
+	value REAL NOT NULL,
+	tags TEXT NOT NULL,
+	metadata TEXT
+);
+CREATE INDEX IF NOT EXISTS samples_test_run_metric ON samples (test_run_id, metric);
+CREATE TABLE IF NOT EXISTS checks (
+	test_run_id INTEGER NOT NULL REFERENCES test_runs (id),
+	time INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	"group" TEXT NOT NULL,
+	passed INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS checks_test_run ON checks (test_run_id);
+`
+
+// TestRun is a test run in the results.
+type TestRun struct {
+	ID        int64
+	Name      string
+	K6Version string
+	StartedAt time.Time
+	// EndedAt is zero if the test run was interrupted before the results were
+	// completely written.
+	EndedAt time.Time
+}
+
+// Duration returns how long the test run lasted, or zero if it's unknown.
+func (r TestRun) Duration() time.Duration {
+	if r.EndedAt.IsZero() {
+		return 0
+	}
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+// CheckResult is the number of passes and fails of a check in a test run.
+type CheckResult struct {
+	Name   string
+	Group  string
+	Passes int64
+	Fails  int64
+}
+
+// OpenResults opens the results written by the SQLite output, read-only.
+func OpenResults(path string) (*sql.DB, error) {
+	uri := "file:" + (&url.URL{Path: filepath.ToSlash(path)}).EscapedPath() + "?mode=ro"
+	db, err := sql.Open("sqlite3", uri)
+	if err != nil {
+		return nil, err
+	}
+	// an empty or a missing file isn't an error until it's queried
+	if _, err = db.Exec("SELECT 1 FROM test_runs LIMIT 1"); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("'%s' doesn't contain the results of the sqlite output: %w", path, err)
+	}
+	return db, nil
+}
+
+// ReadTestRuns returns the test runs in the results, in the order they
+// were started.
+func ReadTestRuns(db *sql.DB) ([]TestRun, error) {
+	rows, err := db.Query("SELECT id, name, k6_version, started_at, ended_at FROM test_runs ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []TestRun
+	for rows.Next() {
+		var (
+			r       TestRun
+			started int64
+			ended   sql.NullInt64
+		)
+		if err = rows.Scan(&r.ID, &r.Name, &r.K6Version, &started, &ended); err != nil {
+			return nil, err
+		}
+		r.StartedAt = time.UnixMicro(started)
+		if ended.Valid {
+			r.EndedAt = time.UnixMicro(ended.Int64)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// ReadSamples reads the samples of the test run. The metrics are registered
+// in the registry, if needed, and every sample is passed to the callback in
+// the order they were written.
+func ReadSamples(db *sql.DB, testRunID int64, registry *metrics.Registry, callback func(metrics.Sample)) error {
+	if err := readMetrics(db, testRunID, registry); err != nil {
+		return err
+	}
+
+	rows, err := db.Query(
+		"SELECT time, metric, value, tags, metadata FROM samples WHERE test_run_id = ? ORDER BY rowid", testRunID)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			t              int64
+			name, tags     string
+			value          float64
+			metadataColumn sql.NullString
+		)
+		if err = rows.Scan(&t, &name, &value, &tags, &metadataColumn); err != nil {
+			return err
+		}
+		metric := registry.Get(name)
+		if metric == nil {
+			return fmt.Errorf("a sample is for the unknown metric '%s'", name)
+		}
+		var tagsMap, metadata map[string]string
+		if err = json.Unmarshal([]byte(tags), &tagsMap); err != nil {
+			return fmt.Errorf("invalid tags of a sample of '%s': %w", name, err)
+		}
+		if metadataColumn.Valid {
+			if err = json.Unmarshal([]byte(metadataColumn.String), &metadata); err != nil {
+				return fmt.Errorf("invalid metadata of a sample of '%s': %w", name, err)
+			}
+		}
+		callback(metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: metric,
+				Tags:   registry.RootTagSet().WithTagsFromMap(tagsMap),
+			},
+			Time:     time.UnixMicro(t),
+			Metadata: metadata,
+			Value:    value,
+		})
+	}
+	return rows.Err()
+}
+
+func readMetrics(db *sql.DB, testRunID int64, registry *metrics.Registry) error {
+	rows, err := db.Query("SELECT name, type, contains FROM metrics WHERE test_run_id = ?", testRunID)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			name         string
+			metricType   metrics.MetricType
+			valueType    metrics.ValueType
+			mType, vType string
+		)
+		if err = rows.Scan(&name, &mType, &vType); err != nil {
+			return err
+		}
+		if err = metricType.UnmarshalText([]byte(mType)); err != nil {
+			return fmt.Errorf("invalid metric '%s': %w", name, err)
+		}
+		if err = valueType.UnmarshalText([]byte(vType)); err != nil {
+			return fmt.Errorf("invalid metric '%s': %w", name, err)
+		}
+		if _, err = registry.NewMetric(name, metricType, valueType); err != nil {
+			return fmt.Errorf("invalid metric '%s': %w", name, err)
+		}
+	}
+	return rows.Err()
+}
+
+// ReadChecks returns the results of the checks of the test run, by group and
+// name.
+func ReadChecks(db *sql.DB, testRunID int64) ([]CheckResult, error) {
+	rows, err := db.Query(`SELECT name, "group", SUM(passed), COUNT(*) - SUM(passed) FROM checks
+		WHERE test_run_id = ? GROUP BY "group", name ORDER BY "group", MIN(rowid)`, testRunID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var checks []CheckResult
+	for rows.Next() {
+		var c CheckResult
+		if err = rows.Scan(&c.Name, &c.Group, &c.Passes, &c.Fails); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
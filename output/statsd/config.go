@@ -20,6 +20,7 @@ type config struct {
 	PushInterval types.NullDuration  `json:"pushInterval,omitempty" envconfig:"K6_STATSD_PUSH_INTERVAL"`
 	TagBlocklist metrics.EnabledTags `json:"tagBlocklist,omitempty" envconfig:"K6_STATSD_TAG_BLOCKLIST"`
 	EnableTags   null.Bool           `json:"enableTags,omitempty" envconfig:"K6_STATSD_ENABLE_TAGS"`
+	DropSamples  null.Bool           `json:"dropSamples,omitempty" envconfig:"K6_STATSD_DROP_SAMPLES"`
 }
 
 func processTags(t metrics.EnabledTags, tags map[string]string) []string {
@@ -52,6 +53,9 @@ func (c config) Apply(cfg config) config {
 	if cfg.EnableTags.Valid {
 		c.EnableTags = cfg.EnableTags
 	}
+	if cfg.DropSamples.Valid {
+		c.DropSamples = cfg.DropSamples
+	}
 
 	return c
 }
@@ -65,6 +69,7 @@ func newConfig() config {
 		PushInterval: types.NewNullDuration(1*time.Second, false),
 		TagBlocklist: metrics.SystemTagSet(metrics.TagVU | metrics.TagIter | metrics.TagURL).Map(),
 		EnableTags:   null.NewBool(false, false),
+		DropSamples:  null.NewBool(false, false),
 	}
 }
 
@@ -29,7 +29,7 @@ func newOutput(params output.Params) (*Output, error) {
 	}, nil
 }
 
-var _ output.Output = &Output{}
+var _ output.WithDroppedSamples = &Output{}
 
 // Output sends result data to statsd daemons with the ability to send to datadog as well
 type Output struct {
@@ -71,6 +71,13 @@ func (o *Output) dispatch(entry metrics.Sample) error {
 	}
 }
 
+// DropsSamples returns if the samples can be dropped when the output can't
+// keep up with them, rather than slow down the test run, since they're sent
+// over UDP anyway.
+func (o *Output) DropsSamples() bool {
+	return o.config.DropSamples.Bool
+}
+
 func checkToString(check string, value float64) string {
 	label := "pass"
 	if value == 0 {
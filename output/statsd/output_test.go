@@ -119,3 +119,18 @@ func TestLinkReturnAddress(t *testing.T) {
 	}
 	require.Equal(t, fmt.Sprintf("statsd (%s)", bogusValue), c.Description())
 }
+
+func TestStatsdDropsSamples(t *testing.T) {
+	t.Parallel()
+
+	out, err := newOutput(output.Params{Logger: testutils.NewLogger(t)})
+	require.NoError(t, err)
+	assert.False(t, out.DropsSamples())
+
+	out, err = newOutput(output.Params{
+		Logger:      testutils.NewLogger(t),
+		Environment: map[string]string{"K6_STATSD_DROP_SAMPLES": "true"},
+	})
+	require.NoError(t, err)
+	assert.True(t, out.DropsSamples())
+}
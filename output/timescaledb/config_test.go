@@ -0,0 +1,91 @@
+package timescaledb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		jsonRaw json.RawMessage
+		env     map[string]string
+		arg     string
+		config  config
+		errMsg  string
+	}{
+		"Defaults": {
+			config: newConfig(),
+		},
+		"JSON": {
+			jsonRaw: json.RawMessage(`{"url":"postgres://k6:pass@db:5432/results","pushInterval":"3s"}`),
+			config: config{
+				URL:          null.StringFrom("postgres://k6:pass@db:5432/results"),
+				PushInterval: types.NullDurationFrom(3 * time.Second),
+				Timeout:      types.NewNullDuration(10*time.Second, false),
+			},
+		},
+		"EnvOverridesJSON": {
+			jsonRaw: json.RawMessage(`{"url":"postgres://db:5432/results","timeout":"2s"}`),
+			env: map[string]string{
+				"K6_TIMESCALEDB_URL":     "postgresql://other:5432/k6",
+				"K6_TIMESCALEDB_TIMEOUT": "5s",
+			},
+			config: config{
+				URL:          null.StringFrom("postgresql://other:5432/k6"),
+				PushInterval: types.NewNullDuration(time.Second, false),
+				Timeout:      types.NullDurationFrom(5 * time.Second),
+			},
+		},
+		"ArgOverridesEnv": {
+			env: map[string]string{"K6_TIMESCALEDB_URL": "postgresql://other:5432/k6"},
+			arg: "postgresql://arg:5432/k6",
+			config: config{
+				URL:          null.StringFrom("postgresql://arg:5432/k6"),
+				PushInterval: types.NewNullDuration(time.Second, false),
+				Timeout:      types.NewNullDuration(10*time.Second, false),
+			},
+		},
+		"InvalidScheme": {
+			arg:    "mysql://localhost:3306/k6",
+			errMsg: "invalid URL scheme 'mysql', it can be postgres or postgresql",
+		},
+		"InvalidPushInterval": {
+			env:    map[string]string{"K6_TIMESCALEDB_PUSH_INTERVAL": "0s"},
+			errMsg: "the push interval has to be positive",
+		},
+		"InvalidTimeout": {
+			env:    map[string]string{"K6_TIMESCALEDB_TIMEOUT": "-1s"},
+			errMsg: "the timeout has to be positive",
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, err := getConsolidatedConfig(tc.jsonRaw, tc.env, tc.arg)
+			if tc.errMsg != "" {
+				require.EqualError(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, c)
+		})
+	}
+}
+
+func TestConfigRedactedURL(t *testing.T) {
+	t.Parallel()
+
+	c := newConfig().Apply(config{URL: null.StringFrom("postgresql://k6:secret@db:5432/k6?sslmode=require")})
+	assert.Equal(t, "postgresql://k6:xxxxx@db:5432/k6?sslmode=require", c.redactedURL())
+}
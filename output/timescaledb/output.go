@@ -0,0 +1,299 @@
+package timescaledb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// schema creates the tables of the test runs, of the metrics and of their
+// samples, if they don't exist yet.
+//
+//nolint:gochecknoglobals
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS k6_test_runs (
+		id BIGSERIAL PRIMARY KEY,
+		script TEXT NOT NULL,
+		k6_version TEXT NOT NULL,
+		options JSONB,
+		started_at TIMESTAMPTZ NOT NULL,
+		ended_at TIMESTAMPTZ,
+		error TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS k6_metrics (
+		name TEXT PRIMARY KEY,
+		type TEXT NOT NULL,
+		contains TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS k6_samples (
+		ts TIMESTAMPTZ NOT NULL,
+		test_run_id BIGINT NOT NULL REFERENCES k6_test_runs (id) ON DELETE CASCADE,
+		metric TEXT NOT NULL,
+		value DOUBLE PRECISION NOT NULL,
+		tags JSONB,
+		metadata JSONB
+	)`,
+	`CREATE INDEX IF NOT EXISTS k6_samples_test_run_id_metric_ts_idx ON k6_samples (test_run_id, metric, ts DESC)`,
+}
+
+// New creates a new TimescaleDB output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := getConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the options that describe the test are stored, without the secrets
+	options, err := json.Marshal(output.NewTestRunOptions(params.ScriptOptions))
+	if err != nil {
+		return nil, err
+	}
+	var script string
+	if params.ScriptPath != nil {
+		script = params.ScriptPath.String()
+	}
+
+	return &Output{
+		config:      conf,
+		logger:      params.Logger.WithFields(logrus.Fields{"output": "timescaledb"}),
+		driverName:  "postgres",
+		script:      script,
+		options:     string(options),
+		seenMetrics: make(map[*metrics.Metric]struct{}),
+		now:         time.Now,
+	}, nil
+}
+
+var _ output.WithStopWithTestError = &Output{}
+
+// Output periodically writes the buffered metric samples to the database.
+type Output struct {
+	output.SampleBuffer
+
+	periodicFlusher *output.PeriodicFlusher
+
+	config     config
+	logger     logrus.FieldLogger
+	driverName string
+	db         *sql.DB
+
+	script    string
+	options   string
+	testRunID int64
+
+	// seenMetrics are the metrics already saved in the k6_metrics table
+	seenMetrics map[*metrics.Metric]struct{}
+	now         func() time.Time
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("timescaledb (%s)", o.config.redactedURL())
+}
+
+// Start connects to the database, creates the schema if it doesn't exist, saves
+// the test run and starts the goroutine for the periodic writes of the samples.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	db, err := sql.Open(o.driverName, o.config.URL.String)
+	if err != nil {
+		return err
+	}
+	o.db = db
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.Timeout.TimeDuration())
+	defer cancel()
+	if err = db.PingContext(ctx); err != nil {
+		return fmt.Errorf("couldn't connect to the database: %w", err)
+	}
+	if err = o.setupSchema(ctx); err != nil {
+		return fmt.Errorf("couldn't create the schema: %w", err)
+	}
+	err = db.QueryRowContext(ctx,
+		`INSERT INTO k6_test_runs (script, k6_version, options, started_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		o.script, consts.Version, o.options, o.now(),
+	).Scan(&o.testRunID)
+	if err != nil {
+		return fmt.Errorf("couldn't save the test run: %w", err)
+	}
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.WithField("testRunID", o.testRunID).Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// setupSchema creates the tables, and makes the one of the samples a
+// hypertable if the TimescaleDB extension is available. Otherwise, it's a
+// regular PostgreSQL table.
+func (o *Output) setupSchema(ctx context.Context) error {
+	var available bool
+	err := o.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb')`,
+	).Scan(&available)
+	if err != nil {
+		return err
+	}
+	if available {
+		if _, err = o.db.ExecContext(ctx, `CREATE EXTENSION IF NOT EXISTS timescaledb`); err != nil {
+			o.logger.WithError(err).Warn("Couldn't create the timescaledb extension, the samples will be " +
+				"saved in a regular table")
+			available = false
+		}
+	}
+
+	for _, stmt := range schema {
+		if _, err = o.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	if available {
+		_, err = o.db.ExecContext(ctx,
+			`SELECT create_hypertable('k6_samples', 'ts', if_not_exists => TRUE, migrate_data => TRUE)`)
+	}
+	return err
+}
+
+// Stop writes the remaining samples and saves the end of the test run.
+func (o *Output) Stop() error {
+	return o.StopWithTestError(nil)
+}
+
+// StopWithTestError writes the remaining samples and saves the end of the test
+// run, with the error it finished with, if any.
+func (o *Output) StopWithTestError(testErr error) error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	var errText sql.NullString
+	if testErr != nil {
+		errText = sql.NullString{String: testErr.Error(), Valid: true}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.Timeout.TimeDuration())
+	defer cancel()
+	_, err := o.db.ExecContext(ctx, `UPDATE k6_test_runs SET ended_at = $1, error = $2 WHERE id = $3`,
+		o.now(), errText, o.testRunID)
+	if err != nil {
+		err = fmt.Errorf("couldn't save the end of the test run: %w", err)
+	}
+	if closeErr := o.db.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (o *Output) flushMetrics() {
+	var samples []metrics.Sample
+	for _, sc := range o.GetBufferedSamples() {
+		samples = append(samples, sc.GetSamples()...)
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.Timeout.TimeDuration())
+	defer cancel()
+	if err := o.write(ctx, samples); err != nil {
+		o.logger.WithError(err).Error("Couldn't write the samples")
+		return
+	}
+	o.logger.WithFields(logrus.Fields{
+		"t":       time.Since(start),
+		"samples": len(samples),
+	}).Debug("Wrote the samples")
+}
+
+// write saves the samples, and their metrics if it's the first time they are
+// seen, in a single transaction. The samples are copied in bulk.
+func (o *Output) write(ctx context.Context, samples []metrics.Sample) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	newMetrics := make(map[*metrics.Metric]struct{})
+	for _, s := range samples {
+		if _, ok := o.seenMetrics[s.Metric]; ok {
+			continue
+		}
+		if _, ok := newMetrics[s.Metric]; ok {
+			continue
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO k6_metrics (name, type, contains) VALUES ($1, $2, $3) ON CONFLICT (name) DO NOTHING`,
+			s.Metric.Name, s.Metric.Type.String(), s.Metric.Contains.String())
+		if err != nil {
+			return err
+		}
+		newMetrics[s.Metric] = struct{}{}
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		pq.CopyIn("k6_samples", "ts", "test_run_id", "metric", "value", "tags", "metadata"))
+	if err != nil {
+		return err
+	}
+	for _, s := range samples {
+		tags, metadata, err := jsonColumns(s)
+		if err != nil {
+			return err
+		}
+		if _, err = stmt.ExecContext(ctx, s.Time, o.testRunID, s.Metric.Name, s.Value, tags, metadata); err != nil {
+			return err
+		}
+	}
+	// the copy is completed by an Exec without arguments
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+	if err = stmt.Close(); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	for m := range newMetrics {
+		o.seenMetrics[m] = struct{}{}
+	}
+	return nil
+}
+
+// jsonColumns returns the values of the JSONB columns of the sample, the
+// metadata is NULL if the sample doesn't have any.
+func jsonColumns(s metrics.Sample) (tags string, metadata interface{}, err error) {
+	b, err := json.Marshal(s.Tags.Map())
+	if err != nil {
+		return "", nil, err
+	}
+	if len(s.Metadata) == 0 {
+		return string(b), nil, nil
+	}
+	m, err := json.Marshal(s.Metadata)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(b), string(m), nil
+}
@@ -0,0 +1,275 @@
+package timescaledb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+const fakeDriverName = "k6-timescaledb-fake"
+
+//nolint:gochecknoglobals
+var fakeDatabases sync.Map
+
+//nolint:gocheckinits
+func init() {
+	sql.Register(fakeDriverName, fakeDriver{})
+}
+
+// fakeDriver is a database/sql driver which records the statements executed
+// on the fakeDatabase registered with the test's name.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	db, ok := fakeDatabases.Load(u.Query().Get("test"))
+	if !ok {
+		return nil, errors.New("unknown test database")
+	}
+	return db.(*fakeDatabase), nil //nolint:forcetypeassert
+}
+
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeDatabase struct {
+	mu                 sync.Mutex
+	statements         []fakeStatement
+	extensionAvailable bool
+	failOn             string
+}
+
+func newFakeDatabase(t *testing.T, extensionAvailable bool) (*fakeDatabase, string) {
+	db := &fakeDatabase{extensionAvailable: extensionAvailable}
+	fakeDatabases.Store(t.Name(), db)
+	t.Cleanup(func() { fakeDatabases.Delete(t.Name()) })
+	return db, "postgresql://localhost:5432/k6?test=" + url.QueryEscape(t.Name())
+}
+
+func (db *fakeDatabase) record(query string, args []driver.Value) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.failOn != "" && strings.Contains(query, db.failOn) {
+		return errors.New("fake failure")
+	}
+	db.statements = append(db.statements, fakeStatement{query: query, args: args})
+	return nil
+}
+
+// queries returns the recorded statements which start with the prefix.
+func (db *fakeDatabase) queries(prefix string) []fakeStatement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var result []fakeStatement
+	for _, s := range db.statements {
+		if strings.HasPrefix(s.query, prefix) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (db *fakeDatabase) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{db: db, query: query}, nil
+}
+
+func (db *fakeDatabase) Close() error { return nil }
+
+func (db *fakeDatabase) Begin() (driver.Tx, error) {
+	return fakeTx{db: db}, db.record("BEGIN", nil)
+}
+
+type fakeTx struct{ db *fakeDatabase }
+
+func (tx fakeTx) Commit() error   { return tx.db.record("COMMIT", nil) }
+func (tx fakeTx) Rollback() error { return tx.db.record("ROLLBACK", nil) }
+
+type fakeStmt struct {
+	db    *fakeDatabase
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), s.db.record(s.query, args)
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.db.record(s.query, args); err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.Contains(s.query, "pg_available_extensions"):
+		return &fakeRows{values: []driver.Value{s.db.extensionAvailable}}, nil
+	case strings.Contains(s.query, "RETURNING id"):
+		return &fakeRows{values: []driver.Value{int64(42)}}, nil
+	default:
+		return nil, errors.New("unexpected query")
+	}
+}
+
+// fakeRows is a single row with a single column.
+type fakeRows struct {
+	values []driver.Value
+	read   bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"result"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.read {
+		return io.EOF
+	}
+	r.read = true
+	copy(dest, r.values)
+	return nil
+}
+
+func newTestOutput(t *testing.T, dsn string) *Output {
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: dsn,
+		ScriptPath:     &url.URL{Scheme: "file", Path: "/script.js"},
+		ScriptOptions:  lib.Options{},
+	})
+	require.NoError(t, err)
+	o.driverName = fakeDriverName
+	o.now = func() time.Time { return time.Unix(100, 0) }
+	return o
+}
+
+func TestOutputStart(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Hypertable", func(t *testing.T) {
+		t.Parallel()
+		db, dsn := newFakeDatabase(t, true)
+		o := newTestOutput(t, dsn)
+		require.NoError(t, o.Start())
+		require.NoError(t, o.Stop())
+
+		assert.Len(t, db.queries("CREATE EXTENSION IF NOT EXISTS timescaledb"), 1)
+		assert.Len(t, db.queries("CREATE TABLE IF NOT EXISTS"), 3)
+		assert.Len(t, db.queries("SELECT create_hypertable('k6_samples'"), 1)
+
+		inserts := db.queries("INSERT INTO k6_test_runs")
+		require.Len(t, inserts, 1)
+		assert.Equal(t, "file:///script.js", inserts[0].args[0])
+		assert.JSONEq(t, `{"vus":null,"duration":null,"iterations":null,"stages":null,"scenarios":{},`+
+			`"thresholds":null,"tags":null,"testName":null,"runId":null,"testVersion":null,"testEnvironment":null}`,
+			inserts[0].args[2].(string))
+		assert.Equal(t, time.Unix(100, 0), inserts[0].args[3])
+		assert.Equal(t, int64(42), o.testRunID)
+	})
+
+	t.Run("RegularTable", func(t *testing.T) {
+		t.Parallel()
+		db, dsn := newFakeDatabase(t, false)
+		o := newTestOutput(t, dsn)
+		require.NoError(t, o.Start())
+		require.NoError(t, o.Stop())
+
+		assert.Empty(t, db.queries("CREATE EXTENSION"))
+		assert.Len(t, db.queries("CREATE TABLE IF NOT EXISTS"), 3)
+		assert.Empty(t, db.queries("SELECT create_hypertable"))
+	})
+
+	t.Run("ExtensionNotCreated", func(t *testing.T) {
+		t.Parallel()
+		db, dsn := newFakeDatabase(t, true)
+		db.failOn = "CREATE EXTENSION"
+		o := newTestOutput(t, dsn)
+		require.NoError(t, o.Start())
+		require.NoError(t, o.Stop())
+
+		assert.Len(t, db.queries("CREATE TABLE IF NOT EXISTS"), 3)
+		assert.Empty(t, db.queries("SELECT create_hypertable"))
+	})
+
+	t.Run("SchemaError", func(t *testing.T) {
+		t.Parallel()
+		db, dsn := newFakeDatabase(t, false)
+		db.failOn = "CREATE TABLE IF NOT EXISTS k6_samples"
+		o := newTestOutput(t, dsn)
+		require.EqualError(t, o.Start(), "couldn't create the schema: fake failure")
+	})
+}
+
+func TestOutputFlushMetrics(t *testing.T) {
+	t.Parallel()
+
+	db, dsn := newFakeDatabase(t, false)
+	o := newTestOutput(t, dsn)
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("my_counter", metrics.Counter)
+	trend := registry.MustNewMetric("my_trend", metrics.Trend, metrics.Time)
+	tags := registry.RootTagSet().With("scenario", "default")
+	sample := func(m *metrics.Metric, value float64) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags},
+			Time:       time.Unix(10, 0),
+			Value:      value,
+		}
+	}
+	traced := sample(trend, 123)
+	traced.Metadata = map[string]string{lib.MetadataTraceID: "abc"}
+
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(counter, 1), sample(counter, 2), traced,
+	}})
+	o.flushMetrics()
+
+	metricInserts := db.queries("INSERT INTO k6_metrics")
+	require.Len(t, metricInserts, 2)
+	assert.Equal(t, []driver.Value{"my_counter", "counter", "default"}, metricInserts[0].args)
+	assert.Equal(t, []driver.Value{"my_trend", "trend", "time"}, metricInserts[1].args)
+
+	rows := db.queries(`COPY "k6_samples"`)
+	require.Len(t, rows, 4) // the last one completes the copy
+	assert.Equal(t, []driver.Value{
+		time.Unix(10, 0), int64(42), "my_counter", float64(1), `{"scenario":"default"}`, nil,
+	}, rows[0].args)
+	assert.Equal(t, "my_trend", rows[2].args[2])
+	var metadata map[string]string
+	require.NoError(t, json.Unmarshal([]byte(rows[2].args[5].(string)), &metadata)) //nolint:forcetypeassert
+	assert.Equal(t, map[string]string{"trace_id": "abc"}, metadata)
+	assert.Empty(t, rows[3].args)
+	assert.Len(t, db.queries("COMMIT"), 1)
+
+	// the metrics are only saved the first time
+	o.AddMetricSamples([]metrics.SampleContainer{sample(counter, 3)})
+	o.flushMetrics()
+	assert.Len(t, db.queries("INSERT INTO k6_metrics"), 2)
+	assert.Len(t, db.queries(`COPY "k6_samples"`), 6)
+	assert.Len(t, db.queries("COMMIT"), 2)
+
+	require.NoError(t, o.StopWithTestError(errors.New("test aborted")))
+	updates := db.queries("UPDATE k6_test_runs")
+	require.Len(t, updates, 1)
+	assert.Equal(t, []driver.Value{time.Unix(100, 0), "test aborted", int64(42)}, updates[0].args)
+}
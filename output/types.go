@@ -93,3 +93,13 @@ type WithBuiltinMetrics interface {
 	Output
 	SetBuiltinMetrics(builtinMetrics *metrics.BuiltinMetrics)
 }
+
+// WithDroppedSamples is an output that can lose some of the samples, rather
+// than slow down the whole test run when it can't keep up with them, like one
+// that sends them over UDP anyway, if DropsSamples returns true. Its samples
+// are dropped when its queue is full, and counted with the k6_dropped_samples
+// metric, while the Manager waits for all of the other outputs.
+type WithDroppedSamples interface {
+	Output
+	DropsSamples() bool
+}
@@ -0,0 +1,55 @@
+package output
+
+import "go.k6.io/k6/metrics"
+
+var (
+	_ WithThresholds        = outputWrapper{}
+	_ WithTestRunStop       = outputWrapper{}
+	_ WithStopWithTestError = outputWrapper{}
+	_ WithBuiltinMetrics    = outputWrapper{}
+	_ WithDroppedSamples    = outputWrapper{}
+)
+
+// outputWrapper is embedded by the outputs that wrap another one, it passes
+// the calls of the optional interfaces to the wrapped output, if it
+// implements them.
+type outputWrapper struct {
+	Output
+}
+
+// SetThresholds passes the thresholds to the output, if it needs them.
+func (w outputWrapper) SetThresholds(thresholds map[string]metrics.Thresholds) {
+	if out, ok := w.Output.(WithThresholds); ok {
+		out.SetThresholds(thresholds)
+	}
+}
+
+// SetTestRunStopCallback passes the callback to the output, if it can stop
+// the test run.
+func (w outputWrapper) SetTestRunStopCallback(stopFunc func(error)) {
+	if out, ok := w.Output.(WithTestRunStop); ok {
+		out.SetTestRunStopCallback(stopFunc)
+	}
+}
+
+// StopWithTestError stops the output with the test error, if it needs it.
+func (w outputWrapper) StopWithTestError(testRunErr error) error {
+	if out, ok := w.Output.(WithStopWithTestError); ok {
+		return out.StopWithTestError(testRunErr)
+	}
+	return w.Output.Stop()
+}
+
+// DropsSamples returns if the samples of the output can be dropped.
+func (w outputWrapper) DropsSamples() bool {
+	out, ok := w.Output.(WithDroppedSamples)
+	return ok && out.DropsSamples()
+}
+
+// SetBuiltinMetrics passes the builtin metrics to the output, if it needs
+// them.
+func (w outputWrapper) SetBuiltinMetrics(builtinMetrics *metrics.BuiltinMetrics) {
+	if out, ok := w.Output.(WithBuiltinMetrics); ok {
+		out.SetBuiltinMetrics(builtinMetrics)
+	}
+}
@@ -0,0 +1,168 @@
+// Package aws implements the aws secret source, which reads the secrets from
+// a secret of AWS Secrets Manager with its key/value pairs as a JSON object.
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"go.k6.io/k6/lib/awsconfig"
+	"go.k6.io/k6/lib/strvals"
+	"go.k6.io/k6/secretsource"
+)
+
+const (
+	signingName    = "secretsmanager"
+	requestTimeout = 10 * time.Second
+)
+
+type source struct {
+	secretID, region string
+	env              map[string]string
+	client           *http.Client
+
+	mx      sync.Mutex
+	secrets map[string]string
+}
+
+// New returns an aws secret source, configured with
+// `aws=secret=k6/creds[,region=eu-west-1]`, where the secret is the name or
+// the ARN of the secret. The credentials, and the region if it isn't set,
+// are found like the AWS CLI does, and the endpoint can be changed with the
+// AWS_ENDPOINT_URL_SECRETS_MANAGER or AWS_ENDPOINT_URL environment variables.
+func New(params secretsource.Params) (secretsource.Source, error) {
+	s := &source{
+		env:    params.Environment,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+
+	tokens, err := strvals.Parse(params.ConfigArgument)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing the aws secret source configuration: %w", err)
+	}
+	for _, token := range tokens {
+		switch token.Key {
+		case "secret":
+			s.secretID = token.Value
+		case "region":
+			s.region = token.Value
+		default:
+			return nil, fmt.Errorf("unknown aws secret source config key %s", token.Key)
+		}
+	}
+
+	if s.secretID == "" {
+		return nil, errors.New("the aws secret source should be in the form `aws=secret=name-of-the-secret`")
+	}
+	return s, nil
+}
+
+func (s *source) Description() string {
+	return fmt.Sprintf("aws (%s)", s.secretID)
+}
+
+// Get returns the secret with the key, all of the key/value pairs of the
+// secret are read with the first call.
+func (s *source) Get(key string) (string, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.secrets == nil {
+		secrets, err := s.read(context.Background())
+		if err != nil {
+			return "", err
+		}
+		s.secrets = secrets
+	}
+	value, ok := s.secrets[key]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return value, nil
+}
+
+func (s *source) read(ctx context.Context) (map[string]string, error) {
+	cfg, err := awsconfig.Load(ctx, s.env)
+	if err != nil {
+		return nil, err
+	}
+	if s.region != "" {
+		cfg.Region = s.region
+	}
+	credentials, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no AWS credentials were found: %w", err)
+	}
+
+	endpoint := awsconfig.Endpoint(s.env, "SECRETS_MANAGER")
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + cfg.Region + ".amazonaws.com"
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": s.secretID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/",
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	payloadHash := sha256.Sum256(body)
+	err = v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(payloadHash[:]),
+		signingName, cfg.Region, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("signing the request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type string `json:"__type"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Type != "" {
+			// the type can be prefixed with its namespace
+			return nil, fmt.Errorf("AWS Secrets Manager responded with status %d: %s",
+				resp.StatusCode, apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:])
+		}
+		return nil, fmt.Errorf("AWS Secrets Manager responded with status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(respBody, &payload); err != nil {
+		return nil, fmt.Errorf("parsing the AWS Secrets Manager response: %w", err)
+	}
+	if payload.SecretString == nil {
+		return nil, errors.New("the secret is binary, not key/value pairs")
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(*payload.SecretString), &values); err != nil {
+		return nil, errors.New("the secret isn't a JSON object of key/value pairs")
+	}
+	return secretsource.StringValues(values)
+}
@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/secretsource"
+)
+
+func TestAWSSource(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Contains(t, r.Header.Get("Authorization"), "Credential=AKID/")
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		switch req["SecretId"] {
+		case "k6/creds":
+			_, _ = w.Write([]byte(`{"Name": "k6/creds", "SecretString": "{\"password\": \"s3cret\", \"port\": 5432}"}`))
+		case "k6/plain":
+			_, _ = w.Write([]byte(`{"Name": "k6/plain", "SecretString": "s3cret"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "com.amazonaws.secretsmanager#ResourceNotFoundException"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	env := map[string]string{
+		"AWS_ACCESS_KEY_ID":                "AKID",
+		"AWS_SECRET_ACCESS_KEY":            "secret",
+		"AWS_REGION":                       "us-east-1",
+		"AWS_EC2_METADATA_DISABLED":        "true",
+		"AWS_CONFIG_FILE":                  "/nonexistent/config",
+		"AWS_SHARED_CREDENTIALS_FILE":      "/nonexistent/credentials",
+		"AWS_ENDPOINT_URL_SECRETS_MANAGER": srv.URL,
+	}
+	newSource := func(config string) secretsource.Source {
+		source, err := New(secretsource.Params{ConfigArgument: config, Environment: env})
+		require.NoError(t, err)
+		return source
+	}
+
+	source := newSource("secret=k6/creds,region=eu-west-1")
+	assert.Equal(t, "aws (k6/creds)", source.Description())
+	value, err := source.Get("password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+	value, err = source.Get("port")
+	require.NoError(t, err)
+	assert.Equal(t, "5432", value)
+	_, err = source.Get("missing")
+	assert.EqualError(t, err, "no such secret")
+	assert.Equal(t, 1, requests)
+
+	_, err = newSource("secret=k6/plain,region=eu-west-1").Get("password")
+	assert.EqualError(t, err, "the secret isn't a JSON object of key/value pairs")
+	_, err = newSource("secret=k6/missing,region=eu-west-1").Get("password")
+	assert.EqualError(t, err, "AWS Secrets Manager responded with status 400: ResourceNotFoundException")
+}
+
+func TestAWSSourceConfig(t *testing.T) {
+	t.Parallel()
+
+	for config, expErr := range map[string]string{
+		"":                        "the aws secret source should be in the form `aws=secret=name-of-the-secret`",
+		"region=eu-west-1":        "the aws secret source should be in the form `aws=secret=name-of-the-secret`",
+		"secret=k6,unknown=value": "unknown aws secret source config key unknown",
+	} {
+		_, err := New(secretsource.Params{ConfigArgument: config})
+		assert.EqualError(t, err, expErr)
+	}
+}
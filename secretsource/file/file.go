@@ -0,0 +1,68 @@
+// Package file implements the file secret source, which reads the secrets
+// from a file with a key=value pair on each line.
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/secretsource"
+)
+
+type source struct {
+	path    string
+	secrets map[string]string
+}
+
+// New reads the secrets from the file at the path in the config argument.
+// The empty lines, and the ones starting with #, are ignored.
+func New(params secretsource.Params) (secretsource.Source, error) {
+	path := params.ConfigArgument
+	if path == "" {
+		return nil, errors.New("the file secret source should be in the form `file=path-to-local-file`")
+	}
+	if !filepath.IsAbs(path) {
+		cwd, err := params.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is a relative path but could not determine CWD: %w", path, err)
+		}
+		path = filepath.Join(cwd, path)
+	}
+
+	data, err := fsext.ReadFile(params.FS, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the secrets file: %w", err)
+	}
+
+	s := &source{path: params.ConfigArgument, secrets: make(map[string]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d of the secrets file isn't in the form key=value", line)
+		}
+		s.secrets[key] = value
+	}
+	return s, scanner.Err()
+}
+
+func (s *source) Description() string {
+	return fmt.Sprintf("file (%s)", s.path)
+}
+
+func (s *source) Get(key string) (string, error) {
+	value, ok := s.secrets[key]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return value, nil
+}
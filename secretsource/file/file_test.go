@@ -0,0 +1,40 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/secretsource"
+)
+
+func TestFileSource(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	require.NoError(t, fsext.WriteFile(fs, "/test/secrets.env",
+		[]byte("# the credentials\nuser=admin\n\npassword=s3cr=t\n"), 0o600))
+	getwd := func() (string, error) { return "/test", nil }
+
+	source, err := New(secretsource.Params{ConfigArgument: "secrets.env", FS: fs, Getwd: getwd})
+	require.NoError(t, err)
+	assert.Equal(t, "file (secrets.env)", source.Description())
+
+	value, err := source.Get("user")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", value)
+	value, err = source.Get("password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr=t", value)
+	_, err = source.Get("missing")
+	assert.EqualError(t, err, "no such secret")
+
+	require.NoError(t, fsext.WriteFile(fs, "/test/invalid.env", []byte("user=admin\ninvalid\n"), 0o600))
+	_, err = New(secretsource.Params{ConfigArgument: "invalid.env", FS: fs, Getwd: getwd})
+	assert.EqualError(t, err, "line 2 of the secrets file isn't in the form key=value")
+
+	_, err = New(secretsource.Params{ConfigArgument: "", FS: fs, Getwd: getwd})
+	assert.Error(t, err)
+}
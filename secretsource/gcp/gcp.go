@@ -0,0 +1,172 @@
+// Package gcp implements the gcp secret source, which reads the secrets from
+// Google Cloud Secret Manager, with each key being the name of a secret.
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/strvals"
+	"go.k6.io/k6/secretsource"
+)
+
+const (
+	defaultEndpoint = "https://secretmanager.googleapis.com"
+	defaultVersion  = "latest"
+	scope           = "https://www.googleapis.com/auth/cloud-platform"
+	requestTimeout  = 10 * time.Second
+)
+
+type source struct {
+	project, version, endpoint string
+	env                        map[string]string
+	fs                         fsext.Fs
+
+	mx      sync.Mutex
+	client  *http.Client
+	secrets map[string]string
+}
+
+// New returns a gcp secret source, configured with
+// `gcp=project=my-project[,version=latest][,endpoint=https://...]`. The
+// credentials are found like the Google Cloud client libraries do: the access
+// token in the GOOGLE_OAUTH_ACCESS_TOKEN environment variable, or the
+// application default credentials, i.e. the file in
+// GOOGLE_APPLICATION_CREDENTIALS, the one written by gcloud or the service
+// account of the instance.
+func New(params secretsource.Params) (secretsource.Source, error) {
+	s := &source{
+		version:  defaultVersion,
+		endpoint: defaultEndpoint,
+		env:      params.Environment,
+		fs:       params.FS,
+		secrets:  make(map[string]string),
+	}
+
+	tokens, err := strvals.Parse(params.ConfigArgument)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing the gcp secret source configuration: %w", err)
+	}
+	for _, token := range tokens {
+		switch token.Key {
+		case "project":
+			s.project = token.Value
+		case "version":
+			s.version = token.Value
+		case "endpoint":
+			s.endpoint = strings.TrimSuffix(token.Value, "/")
+		default:
+			return nil, fmt.Errorf("unknown gcp secret source config key %s", token.Key)
+		}
+	}
+
+	if s.project == "" {
+		return nil, errors.New("the gcp secret source should be in the form `gcp=project=id-of-the-project`")
+	}
+	return s, nil
+}
+
+func (s *source) Description() string {
+	return fmt.Sprintf("gcp (%s)", s.project)
+}
+
+// Get returns the version of the secret with the key as its name, the secrets
+// are read once.
+func (s *source) Get(key string) (string, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if value, ok := s.secrets[key]; ok {
+		return value, nil
+	}
+	if s.client == nil {
+		client, err := s.newClient(context.Background())
+		if err != nil {
+			return "", err
+		}
+		s.client = client
+	}
+	value, err := s.access(key)
+	if err != nil {
+		return "", err
+	}
+	s.secrets[key] = value
+	return value, nil
+}
+
+func (s *source) newClient(ctx context.Context) (*http.Client, error) {
+	var tokenSource oauth2.TokenSource
+	switch {
+	case s.env["GOOGLE_OAUTH_ACCESS_TOKEN"] != "":
+		tokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: s.env["GOOGLE_OAUTH_ACCESS_TOKEN"]})
+	case s.env["GOOGLE_APPLICATION_CREDENTIALS"] != "":
+		data, err := fsext.ReadFile(s.fs, s.env["GOOGLE_APPLICATION_CREDENTIALS"])
+		if err != nil {
+			return nil, fmt.Errorf("reading the Google Cloud credentials: %w", err)
+		}
+		credentials, err := google.CredentialsFromJSON(ctx, data, scope)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Google Cloud credentials: %w", err)
+		}
+		tokenSource = credentials.TokenSource
+	default:
+		credentials, err := google.FindDefaultCredentials(ctx, scope)
+		if err != nil {
+			return nil, fmt.Errorf("no Google Cloud credentials were found: %w", err)
+		}
+		tokenSource = credentials.TokenSource
+	}
+	client := oauth2.NewClient(ctx, tokenSource)
+	client.Timeout = requestTimeout
+	return client, nil
+}
+
+func (s *source) access(name string) (string, error) {
+	u := fmt.Sprintf("%s/v1/projects/%s/secrets/%s/versions/%s:access",
+		s.endpoint, url.PathEscape(s.project), url.PathEscape(name), url.PathEscape(s.version))
+	resp, err := s.client.Get(u) //nolint:noctx
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", errors.New("no such secret")
+	default:
+		return "", fmt.Errorf("Google Cloud Secret Manager responded with status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("parsing the Google Cloud Secret Manager response: %w", err)
+	}
+	value, err := base64.StdEncoding.DecodeString(payload.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("parsing the Google Cloud Secret Manager response: %w", err)
+	}
+	return string(value), nil
+}
@@ -0,0 +1,74 @@
+package gcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/secretsource"
+)
+
+func TestGCPSource(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/v1/projects/k6/secrets/password/versions/latest:access":
+			_, _ = w.Write([]byte(`{"name": "password", "payload": {"data": "czNjcmV0"}}`))
+		case "/v1/projects/k6/secrets/password/versions/2:access":
+			_, _ = w.Write([]byte(`{"name": "password", "payload": {"data": "b2xk"}}`))
+		case "/v1/projects/k6/secrets/forbidden/versions/latest:access":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	newSource := func(config string) secretsource.Source {
+		source, err := New(secretsource.Params{
+			ConfigArgument: config + ",endpoint=" + srv.URL,
+			Environment:    map[string]string{"GOOGLE_OAUTH_ACCESS_TOKEN": "token"},
+		})
+		require.NoError(t, err)
+		return source
+	}
+
+	source := newSource("project=k6")
+	assert.Equal(t, "gcp (k6)", source.Description())
+	value, err := source.Get("password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+	value, err = source.Get("password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+	assert.Equal(t, 1, requests)
+
+	_, err = source.Get("missing")
+	assert.EqualError(t, err, "no such secret")
+	_, err = source.Get("forbidden")
+	assert.EqualError(t, err, "Google Cloud Secret Manager responded with status 403")
+
+	value, err = newSource("project=k6,version=2").Get("password")
+	require.NoError(t, err)
+	assert.Equal(t, "old", value)
+}
+
+func TestGCPSourceConfig(t *testing.T) {
+	t.Parallel()
+
+	for config, expErr := range map[string]string{
+		"":                         "the gcp secret source should be in the form `gcp=project=id-of-the-project`",
+		"version=2":                "the gcp secret source should be in the form `gcp=project=id-of-the-project`",
+		"project=k6,unknown=value": "unknown gcp secret source config key unknown",
+	} {
+		_, err := New(secretsource.Params{ConfigArgument: config})
+		assert.EqualError(t, err, expErr, config)
+	}
+}
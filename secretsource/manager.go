@@ -0,0 +1,113 @@
+package secretsource
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the values of the secrets in the logs.
+const Redacted = "***SECRET_REDACTED***"
+
+// Manager gives access to the configured secret sources. It's a log.Redactor
+// of the values of the secrets it returned.
+type Manager struct {
+	sources       map[string]Source
+	defaultSource string
+
+	mx       sync.RWMutex
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// NewManager returns a new Manager for the sources with the given names, with
+// the one named defaultSource being used when the name isn't specified.
+func NewManager(sources map[string]Source, defaultSource string) (*Manager, error) {
+	if _, ok := sources[defaultSource]; !ok && len(sources) > 0 {
+		return nil, fmt.Errorf("the default secret source %q isn't configured", defaultSource)
+	}
+	return &Manager{
+		sources:       sources,
+		defaultSource: defaultSource,
+		secrets:       make(map[string]struct{}),
+	}, nil
+}
+
+// Get returns the value of the secret with the given key from the named
+// source, or from the default one if the name is empty. From then on, the
+// value is redacted from the logs.
+func (m *Manager) Get(sourceName, key string) (string, error) {
+	if m == nil || len(m.sources) == 0 {
+		return "", errors.New("no secret sources are configured, they can be with --secret-source")
+	}
+	if sourceName == "" {
+		sourceName = m.defaultSource
+	}
+	source, ok := m.sources[sourceName]
+	if !ok {
+		return "", fmt.Errorf("no secret source %q is configured", sourceName)
+	}
+
+	value, err := source.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("getting the secret %q from %s: %w", key, source.Description(), err)
+	}
+	m.redact(value)
+	return value, nil
+}
+
+// Sources returns the descriptions of the secret sources, sorted by their
+// names.
+func (m *Manager) Sources() []string {
+	if m == nil {
+		return nil
+	}
+	names := make([]string, 0, len(m.sources))
+	for name := range m.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	descriptions := make([]string, len(names))
+	for i, name := range names {
+		descriptions[i] = m.sources[name].Description()
+	}
+	return descriptions
+}
+
+func (m *Manager) redact(value string) {
+	if value == "" {
+		return
+	}
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	if _, ok := m.secrets[value]; ok {
+		return
+	}
+	m.secrets[value] = struct{}{}
+
+	// the longer secrets are replaced first, in case they contain the
+	// shorter ones
+	values := make([]string, 0, len(m.secrets))
+	for s := range m.secrets {
+		values = append(values, s)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	oldnew := make([]string, 0, 2*len(values))
+	for _, s := range values {
+		oldnew = append(oldnew, s, Redacted)
+	}
+	m.replacer = strings.NewReplacer(oldnew...)
+}
+
+// Redact returns the string with the values of the secrets replaced.
+func (m *Manager) Redact(s string) string {
+	m.mx.RLock()
+	replacer := m.replacer
+	m.mx.RUnlock()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
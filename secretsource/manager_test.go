@@ -0,0 +1,62 @@
+package secretsource
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapSource map[string]string
+
+func (s mapSource) Description() string { return "map" }
+
+func (s mapSource) Get(key string) (string, error) {
+	value, ok := s[key]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return value, nil
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewManager(map[string]Source{
+		"first":  mapSource{"password": "s3cret", "token": "s3cret-token"},
+		"second": mapSource{"password": "other"},
+	}, "first")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"map", "map"}, m.Sources())
+
+	value, err := m.Get("", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+	value, err = m.Get("second", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "other", value)
+	value, err = m.Get("first", "token")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret-token", value)
+
+	_, err = m.Get("first", "missing")
+	assert.EqualError(t, err, `getting the secret "missing" from map: no such secret`)
+	_, err = m.Get("third", "password")
+	assert.EqualError(t, err, `no secret source "third" is configured`)
+
+	assert.Equal(t, "the token is "+Redacted+" and the password "+Redacted,
+		m.Redact("the token is s3cret-token and the password s3cret"))
+	assert.Equal(t, Redacted+" value", m.Redact("other value"))
+}
+
+func TestManagerWithoutSources(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewManager(map[string]Source{"first": mapSource{}}, "second")
+	assert.EqualError(t, err, `the default secret source "second" isn't configured`)
+
+	var m *Manager
+	_, err = m.Get("", "password")
+	assert.EqualError(t, err, "no secret sources are configured, they can be with --secret-source")
+}
@@ -0,0 +1,65 @@
+// Package secretsource contains the sources of the secrets that the scripts
+// can get with the k6/secrets module, instead of having them passed in
+// plaintext as environment variables, and the Manager that keeps them out of
+// the logs.
+package secretsource
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/ext"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// Params contains all possible constructor parameters a secret source may
+// need.
+type Params struct {
+	SourceType     string // --secret-source $SourceType=$ConfigArgument
+	ConfigArgument string
+
+	Logger      logrus.FieldLogger
+	Environment map[string]string
+	FS          fsext.Fs
+	Getwd       func() (string, error)
+}
+
+// Source is a source of secrets, like a file or a secrets manager.
+type Source interface {
+	// Description returns a human-readable description of the source, without
+	// any credentials.
+	Description() string
+
+	// Get returns the value of the secret with the given key, or an error if
+	// it doesn't exist or it can't be retrieved. It may be called
+	// concurrently.
+	Get(key string) (string, error)
+}
+
+// StringValues returns the values of the secrets decoded from JSON as
+// strings, the ones that aren't strings are returned as JSON.
+func StringValues(values map[string]any) (map[string]string, error) {
+	secrets := make(map[string]string, len(values))
+	for k, v := range values {
+		if str, ok := v.(string); ok {
+			secrets[k] = str
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		secrets[k] = string(data)
+	}
+	return secrets, nil
+}
+
+// Constructor returns an instance of a secret source extension module.
+type Constructor func(Params) (Source, error)
+
+// RegisterExtension registers the given secret source extension constructor.
+// This function panics if a module with the same name is already registered.
+func RegisterExtension(name string, c Constructor) {
+	ext.Register(name, ext.SecretSourceExtension, c)
+}
@@ -0,0 +1,137 @@
+// Package vault implements the vault secret source, which reads the secrets
+// from a HashiCorp Vault KV version 2 secrets engine.
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib/strvals"
+	"go.k6.io/k6/secretsource"
+)
+
+const (
+	defaultMount   = "secret"
+	requestTimeout = 10 * time.Second
+)
+
+type source struct {
+	address, mount, path string
+	token, namespace     string
+	client               *http.Client
+
+	mx      sync.Mutex
+	secrets map[string]string
+}
+
+// New returns a vault secret source, configured with
+// `vault=path=k6/creds[,mount=secret][,address=https://vault:8200]`. The
+// address defaults to the VAULT_ADDR environment variable, and the token is
+// always read from VAULT_TOKEN, so it isn't visible in the command line.
+func New(params secretsource.Params) (secretsource.Source, error) {
+	s := &source{
+		address:   params.Environment["VAULT_ADDR"],
+		mount:     defaultMount,
+		token:     params.Environment["VAULT_TOKEN"],
+		namespace: params.Environment["VAULT_NAMESPACE"],
+		client:    &http.Client{Timeout: requestTimeout},
+	}
+
+	tokens, err := strvals.Parse(params.ConfigArgument)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing the vault secret source configuration: %w", err)
+	}
+	for _, token := range tokens {
+		switch token.Key {
+		case "path":
+			s.path = strings.Trim(token.Value, "/")
+		case "mount":
+			s.mount = strings.Trim(token.Value, "/")
+		case "address":
+			s.address = token.Value
+		default:
+			return nil, fmt.Errorf("unknown vault secret source config key %s", token.Key)
+		}
+	}
+
+	if s.path == "" {
+		return nil, errors.New("the vault secret source should be in the form `vault=path=path-of-the-secrets`")
+	}
+	if s.address == "" {
+		return nil, errors.New("the vault address has to be set with the address key or VAULT_ADDR")
+	}
+	if s.token == "" {
+		return nil, errors.New("the vault token has to be set with VAULT_TOKEN")
+	}
+	s.address = strings.TrimSuffix(s.address, "/")
+	return s, nil
+}
+
+func (s *source) Description() string {
+	return fmt.Sprintf("vault (%s/%s/%s)", s.address, s.mount, s.path)
+}
+
+// Get returns the secret with the key, all of the secrets at the path are
+// read with the first call.
+func (s *source) Get(key string) (string, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.secrets == nil {
+		secrets, err := s.read()
+		if err != nil {
+			return "", err
+		}
+		s.secrets = secrets
+	}
+	value, ok := s.secrets[key]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return value, nil
+}
+
+func (s *source) read() (map[string]string, error) {
+	u := s.address + "/v1/" + url.PathEscape(s.mount) + "/data/" + s.path
+	req, err := http.NewRequest(http.MethodGet, u, nil) //nolint:noctx
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+	if s.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault responded with status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("parsing the vault response: %w", err)
+	}
+
+	return secretsource.StringValues(payload.Data.Data)
+}
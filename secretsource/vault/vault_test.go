@@ -0,0 +1,71 @@
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/secretsource"
+)
+
+func TestVaultSource(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "/v1/kv/data/k6/creds", r.URL.Path)
+		_, _ = w.Write([]byte(`{"data": {"data": {"password": "s3cret", "port": 5432}, "metadata": {"version": 1}}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	source, err := New(secretsource.Params{
+		ConfigArgument: "path=/k6/creds,mount=kv",
+		Environment:    map[string]string{"VAULT_ADDR": srv.URL + "/", "VAULT_TOKEN": "token"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "vault ("+srv.URL+"/kv/k6/creds)", source.Description())
+
+	value, err := source.Get("password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+	value, err = source.Get("port")
+	require.NoError(t, err)
+	assert.Equal(t, "5432", value)
+	_, err = source.Get("missing")
+	assert.EqualError(t, err, "no such secret")
+	assert.Equal(t, 1, requests)
+
+	forbidden, err := New(secretsource.Params{
+		ConfigArgument: "path=k6/creds,mount=kv,address=" + srv.URL,
+		Environment:    map[string]string{"VAULT_TOKEN": "wrong"},
+	})
+	require.NoError(t, err)
+	_, err = forbidden.Get("password")
+	assert.EqualError(t, err, "vault responded with status 403")
+}
+
+func TestVaultSourceConfig(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"VAULT_ADDR": "http://vault:8200", "VAULT_TOKEN": "token"}
+	for config, expErr := range map[string]string{
+		"":                      "the vault secret source should be in the form `vault=path=path-of-the-secrets`",
+		"path=k6,unknown=value": "unknown vault secret source config key unknown",
+	} {
+		_, err := New(secretsource.Params{ConfigArgument: config, Environment: env})
+		assert.EqualError(t, err, expErr)
+	}
+
+	_, err := New(secretsource.Params{ConfigArgument: "path=k6", Environment: map[string]string{"VAULT_TOKEN": "t"}})
+	assert.EqualError(t, err, "the vault address has to be set with the address key or VAULT_ADDR")
+	_, err = New(secretsource.Params{ConfigArgument: "path=k6", Environment: map[string]string{"VAULT_ADDR": "a"}})
+	assert.EqualError(t, err, "the vault token has to be set with VAULT_TOKEN")
+}
@@ -189,6 +189,18 @@ func (pbr ProgressBarRender) String() string {
 	return pbr.Left + " " + pbr.Status() + " " + pbr.Progress() + right
 }
 
+// Progress returns the current progress, between 0 and 1, and the right-side
+// text of the progress bar.
+func (pb *ProgressBar) Progress() (float64, []string) {
+	pb.mutex.RLock()
+	defer pb.mutex.RUnlock()
+	if pb.progress == nil {
+		return 0, nil
+	}
+	progress, right := pb.progress()
+	return Clampf(progress, 0, 1), right
+}
+
 // Render locks the progressbar struct for reading and calls all of
 // its methods to return the final output. A struct is returned over a
 // plain string to allow dynamic padding and positioning of elements
@@ -0,0 +1,16 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !zos
+
+package tui
+
+import "golang.org/x/term"
+
+// setInputMode sets the terminal to the raw mode, to read the keys as they
+// are pressed. Ctrl+C doesn't send the interrupt signal then, so it's handled
+// by the TUI like the key to stop the test.
+func setInputMode(fd int) (restore func(), err error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, err
+	}
+	return func() { _ = term.Restore(fd, state) }, nil
+}
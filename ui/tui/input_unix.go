@@ -0,0 +1,25 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+
+package tui
+
+import "golang.org/x/sys/unix"
+
+// setInputMode sets the terminal to read the keys as they are pressed,
+// without echoing them. Unlike the raw mode, Ctrl+C still sends the interrupt
+// signal, so it stops the test like it does without the TUI. The reads time
+// out after 100ms, so the reading can be stopped.
+func setInputMode(fd int) (restore func(), err error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+	previous := *termios
+
+	termios.Lflag &^= unix.ICANON | unix.ECHO
+	termios.Cc[unix.VMIN] = 0
+	termios.Cc[unix.VTIME] = 1
+	if err = unix.IoctlSetTermios(fd, ioctlWriteTermios, termios); err != nil {
+		return nil, err
+	}
+	return func() { _ = unix.IoctlSetTermios(fd, ioctlWriteTermios, &previous) }, nil
+}
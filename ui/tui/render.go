@@ -0,0 +1,285 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+
+	v2 "go.k6.io/k6/api/v2"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+	progressWidth = 20
+	maxLogRows    = 5
+
+	enterAltScreen = "\x1b[?1049h\x1b[?25l\x1b[?7l" // with the cursor hidden and no line wrap
+	exitAltScreen  = "\x1b[?7h\x1b[?25h\x1b[?1049l"
+	// every frame is drawn from the top left corner, clearing the rest of the
+	// lines, so it doesn't flicker like clearing the whole screen would
+	frameStart = "\x1b[H"
+	lineEnd    = "\x1b[K\r\n"
+	frameEnd   = "\x1b[J"
+)
+
+const helpText = "↑/↓ select  p pause/resume  +/- scale  s stop scenario  " +
+	"space pause test  o sort URLs  q stop test"
+
+type colors struct {
+	title, header, good, bad, warn, muted *color.Color
+}
+
+func newColors(noColor bool) colors {
+	get := func(attributes ...color.Attribute) *color.Color {
+		c := color.New(attributes...)
+		if noColor {
+			c.DisableColor()
+		} else {
+			c.EnableColor()
+		}
+		return c
+	}
+	return colors{
+		title:  get(color.FgBlack, color.BgCyan, color.Bold),
+		header: get(color.Bold),
+		good:   get(color.FgGreen),
+		bad:    get(color.FgRed),
+		warn:   get(color.FgYellow),
+		muted:  get(color.Faint),
+	}
+}
+
+// fit pads or truncates the text to the width, in runes.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	runes := []rune(s)
+	if width == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func formatDuration(ms float64) string {
+	d := time.Duration(ms * float64(time.Millisecond))
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
+
+func progressBar(progress float64) string {
+	if progress < 0 {
+		progress = 0
+	} else if progress > 1 {
+		progress = 1
+	}
+	filled := int(progress * progressWidth)
+	return "[" + strings.Repeat("=", filled) + strings.Repeat("-", progressWidth-filled) + "]" +
+		fmt.Sprintf(" %3.0f%%", progress*100)
+}
+
+// render returns the frame of the TUI, at most width runes wide and height
+// lines long. It has to be called with the lock held.
+func (t *TUI) render(width, height int) string {
+	var lines []string
+	add := func(line ...string) {
+		lines = append(lines, line...)
+	}
+
+	add(t.renderHeader(width), "")
+	add(t.renderScenarios(width)...)
+	add("")
+	if thresholds := t.renderThresholds(width); len(thresholds) > 0 {
+		add(thresholds...)
+		add("")
+	}
+
+	footer := []string{}
+	if logs := t.logs; len(logs) > 0 {
+		if len(logs) > maxLogRows {
+			logs = logs[len(logs)-maxLogRows:]
+		}
+		footer = append(footer, t.colors.header.Sprint(fit("Logs", width)))
+		for _, line := range logs {
+			footer = append(footer, t.colors.muted.Sprint(fit(line, width)))
+		}
+		footer = append(footer, "")
+	}
+	footer = append(footer, fit(t.message, width), t.colors.muted.Sprint(fit(helpText, width)))
+
+	// the URLs get the rest of the lines
+	if rows := height - len(lines) - len(footer) - 3; rows > 0 {
+		add(t.renderURLs(width, rows)...)
+		add("")
+	}
+	for len(lines)+len(footer) < height {
+		add("")
+	}
+	add(footer...)
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	return frameStart + strings.Join(lines, lineEnd) + frameEnd
+}
+
+// segment is a part of a line, with an optional color.
+type segment struct {
+	text string
+	c    *color.Color
+}
+
+func (t *TUI) renderHeader(width int) string {
+	s := t.status
+	state := s.Status
+	stateColor := t.colors.good
+	switch {
+	case s.Paused:
+		state, stateColor = "paused", t.colors.warn
+	case s.Stopped && !s.Running:
+		stateColor = t.colors.muted
+	case s.Stopped:
+		state, stateColor = "stopping", t.colors.warn
+	}
+	parts := []segment{
+		{" k6 ", t.colors.title},
+		{" " + state, stateColor},
+		{fmt.Sprintf("  %s", time.Duration(s.Duration*float64(time.Second)).Round(time.Second)), nil},
+		{fmt.Sprintf("  VUs %d/%d", s.VUs, s.VUsMax), nil},
+		{fmt.Sprintf("  iterations %d", s.Iterations.Completed), nil},
+	}
+	if s.Iterations.Interrupted > 0 {
+		parts = append(parts, segment{fmt.Sprintf(" (%d interrupted)", s.Iterations.Interrupted), t.colors.warn})
+	}
+	if s.Tainted {
+		parts = append(parts, segment{"  thresholds crossed", t.colors.bad})
+	}
+
+	var line strings.Builder
+	left := width
+	for _, p := range parts {
+		text := p.text
+		if n := utf8.RuneCountInString(text); n > left {
+			text = fit(text, left)
+		}
+		left -= utf8.RuneCountInString(text)
+		if p.c != nil {
+			text = p.c.Sprint(text)
+		}
+		line.WriteString(text)
+		if left <= 0 {
+			break
+		}
+	}
+	return line.String()
+}
+
+func (t *TUI) renderScenarios(width int) []string {
+	const (
+		nameWidth     = 20
+		executorWidth = 22
+		barWidth      = progressWidth + 8
+		stateWidth    = 9
+		vusWidth      = 7
+	)
+	detailsWidth := width - 2 - nameWidth - executorWidth - barWidth - stateWidth - vusWidth
+	lines := []string{
+		t.colors.header.Sprint(fit("Scenarios", width)),
+		t.colors.muted.Sprint("  " + fit("NAME", nameWidth) + fit("EXECUTOR", executorWidth) +
+			fit("PROGRESS", barWidth) + fit("STATE", stateWidth) + fit("VUS", vusWidth) +
+			fit("DETAILS", detailsWidth)),
+	}
+	if len(t.status.Scenarios) == 0 {
+		return append(lines, t.colors.muted.Sprint(fit("  no scenarios have been initialized yet", width)))
+	}
+	for i, sc := range t.status.Scenarios {
+		cursor := "  "
+		if i == t.selected {
+			cursor = "> "
+		}
+		state, stateColor := "running", t.colors.good
+		switch {
+		case sc.Paused:
+			state, stateColor = "paused", t.colors.warn
+		case sc.Progress >= 1:
+			state, stateColor = "done", t.colors.muted
+		}
+		vus := "-"
+		if sc.VUs != nil {
+			vus = fmt.Sprint(*sc.VUs)
+			if sc.MaxVUs != nil {
+				vus += "/" + fmt.Sprint(*sc.MaxVUs)
+			}
+		}
+		line := cursor + fit(sc.Name, nameWidth) + fit(sc.Executor, executorWidth) +
+			fit(progressBar(sc.Progress), barWidth) + stateColor.Sprint(fit(state, stateWidth)) +
+			fit(vus, vusWidth) + fit(strings.Join(sc.Details, " "), detailsWidth)
+		if i == t.selected {
+			line = t.colors.header.Sprint(line)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (t *TUI) renderThresholds(width int) []string {
+	if len(t.status.Thresholds) == 0 {
+		return nil
+	}
+	lines := []string{t.colors.header.Sprint(fit("Thresholds", width))}
+	for _, th := range t.status.Thresholds {
+		mark := t.colors.good.Sprint("✓")
+		if th.Breached {
+			mark = t.colors.bad.Sprint("✗")
+		}
+		lines = append(lines, "  "+mark+" "+fit(th.Metric+"  "+th.Source, width-4))
+	}
+	return lines
+}
+
+func (t *TUI) renderURLs(width, rows int) []string {
+	const numberWidth = 10
+	title := "Top URLs by p95 response time"
+	if t.byErrors {
+		title = "Top URLs by error rate"
+	}
+	nameWidth := width - 2 - 5*numberWidth
+	lines := []string{
+		t.colors.header.Sprint(fit(title, width)),
+		t.colors.muted.Sprint("  " + fit("NAME", nameWidth) + fit("REQUESTS", numberWidth) +
+			fit("ERRORS", numberWidth) + fit("AVG", numberWidth) + fit("P95", numberWidth) +
+			fit("P99", numberWidth)),
+	}
+	top := topURLs(t.groups, rows, t.byErrors)
+	if len(top) == 0 {
+		return append(lines, t.colors.muted.Sprint(fit("  no HTTP requests yet", width)))
+	}
+	for _, g := range top {
+		errors := fit(fmt.Sprintf("%.2f%%", g.FailedRate*100), numberWidth)
+		if g.FailedRate > 0 {
+			errors = t.colors.bad.Sprint(errors)
+		}
+		var d v2.DurationStats
+		if g.Duration != nil {
+			d = *g.Duration
+		}
+		lines = append(lines, "  "+fit(g.Value, nameWidth)+fit(fmt.Sprint(g.Requests), numberWidth)+errors+
+			fit(formatDuration(d.Avg), numberWidth)+fit(formatDuration(d.P95), numberWidth)+
+			fit(formatDuration(d.P99), numberWidth))
+	}
+	return lines
+}
@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"sort"
+
+	v2 "go.k6.io/k6/api/v2"
+)
+
+// topURLs returns the n URLs, or rather names, since the requests are grouped
+// by their name tag, with the highest p95 of the durations or, if byErrors is
+// true, with the highest error rate.
+func topURLs(groups []v2.GroupStats, n int, byErrors bool) []v2.GroupStats {
+	key := func(g v2.GroupStats) float64 {
+		if byErrors {
+			return g.FailedRate
+		}
+		if g.Duration == nil {
+			return 0
+		}
+		return g.Duration.P95
+	}
+	top := make([]v2.GroupStats, len(groups))
+	copy(top, groups)
+	sort.Slice(top, func(i, j int) bool {
+		if ki, kj := key(top[i]), key(top[j]); ki != kj {
+			return ki > kj
+		}
+		return top[i].Value < top[j].Value
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,336 @@
+// Package tui implements the interactive terminal UI of k6 run, that shows
+// the scenarios, the slowest URLs and the thresholds of the running test, and
+// allows to pause, scale and stop its scenarios with the keyboard.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	v2 "go.k6.io/k6/api/v2"
+)
+
+const (
+	refreshInterval = 500 * time.Millisecond
+	maxLogLines     = 100
+	controlAPIName  = "the terminal UI"
+)
+
+// Controller is how the TUI controls the test run. It's implemented by the
+// control surface of the REST API v2.
+type Controller interface {
+	Status() v2.Status
+	PatchStatus(patch v2.StatusPatch) (v2.Status, error)
+	Stop(api string) v2.Status
+	PatchScenario(ctx context.Context, name string, patch v2.ScenarioPatch) (v2.Scenario, error)
+	StopScenario(name string) (v2.Scenario, error)
+}
+
+// Options are the terminal of the TUI. The keybindings are disabled if the
+// input isn't a terminal.
+type Options struct {
+	In      io.Reader
+	InFd    int
+	InIsTTY bool
+	Out     io.Writer
+	// Size returns the width and the height of the terminal.
+	Size    func() (int, int, error)
+	NoColor bool
+	// Logger is the logger whose entries are shown by the TUI, instead of
+	// being written to its output while the TUI is running.
+	Logger *logrus.Logger
+}
+
+// TUI is the interactive terminal UI of a running test.
+type TUI struct {
+	ctrl   Controller
+	stream *v2.SampleStream
+	opts   Options
+	colors colors
+
+	mu       sync.Mutex
+	urls     *v2.Aggregation
+	groups   []v2.GroupStats
+	status   v2.Status
+	selected int
+	byErrors bool
+	message  string
+	logs     []string
+}
+
+// New returns a new TUI, that controls the test with the controller. The
+// stats of the URLs are aggregated from the sample stream, if it isn't nil.
+func New(ctrl Controller, stream *v2.SampleStream, opts Options) *TUI {
+	return &TUI{
+		ctrl:   ctrl,
+		stream: stream,
+		opts:   opts,
+		colors: newColors(opts.NoColor),
+	}
+}
+
+// Run shows the TUI on the alternate screen of the terminal, until the ctx is
+// done. The terminal is restored, and the entries that were logged while the
+// TUI was running are written to the output of the logger, before it returns.
+func (t *TUI) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if t.opts.InIsTTY {
+		restore, err := setInputMode(t.opts.InFd)
+		if err != nil {
+			return fmt.Errorf("couldn't set up the terminal for the TUI: %w", err)
+		}
+		defer restore()
+	}
+	if t.opts.Logger != nil {
+		defer t.captureLogs()()
+	}
+	_, _ = io.WriteString(t.opts.Out, enterAltScreen)
+	defer func() { _, _ = io.WriteString(t.opts.Out, exitAltScreen) }()
+
+	if t.stream != nil {
+		// the requests are grouped by their name tag, that defaults to the URL
+		urls, stop := t.stream.Aggregate(v2.SnapshotFilter{GroupBy: "name"})
+		defer stop()
+		t.mu.Lock()
+		t.urls = urls
+		t.mu.Unlock()
+	}
+	keys := make(chan []byte)
+	if t.opts.InIsTTY {
+		go readKeys(ctx, t.opts.In, keys)
+	}
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	t.refresh()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case key := <-keys:
+			t.handleKey(ctx, string(key))
+		case <-ticker.C:
+		}
+		t.refresh()
+	}
+}
+
+// readKeys sends the keys, or the escape sequences of the special keys, read
+// from the input to the channel.
+func readKeys(ctx context.Context, in io.Reader, keys chan<- []byte) {
+	buf := make([]byte, 64)
+	for ctx.Err() == nil {
+		n, err := in.Read(buf)
+		if err != nil && n == 0 {
+			if err == io.EOF { //nolint:errorlint // the reader returns it unwrapped
+				// a timeout of the read, with the unix input mode
+				continue
+			}
+			return
+		}
+		for _, key := range splitKeys(buf[:n]) {
+			select {
+			case keys <- key:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// splitKeys splits the input into the keys, keeping the escape sequences of
+// the arrow keys together.
+func splitKeys(input []byte) [][]byte {
+	var keys [][]byte
+	for len(input) > 0 {
+		n := 1
+		if input[0] == '\x1b' && len(input) >= 3 && input[1] == '[' {
+			n = 3
+		}
+		keys = append(keys, input[:n])
+		input = input[n:]
+	}
+	return keys
+}
+
+func (t *TUI) handleKey(ctx context.Context, key string) {
+	t.mu.Lock()
+	scenarios := t.status.Scenarios
+	t.mu.Unlock()
+
+	var scenario *v2.Scenario
+	if t.selected < len(scenarios) {
+		scenario = &scenarios[t.selected]
+	}
+	switch key {
+	case "\x1b[A", "k":
+		if t.selected > 0 {
+			t.selected--
+		}
+	case "\x1b[B", "j":
+		if t.selected < len(scenarios)-1 {
+			t.selected++
+		}
+	case "o":
+		t.byErrors = !t.byErrors
+	case " ":
+		paused := !t.status.Paused
+		_, err := t.ctrl.PatchStatus(v2.StatusPatch{Paused: &paused})
+		t.setMessage(err, pausedMessage(paused)+" the test")
+	case "q", "\x03":
+		t.ctrl.Stop(controlAPIName)
+		t.setMessage(nil, "Stopping the test...")
+	case "p", "s", "+", "-":
+		if scenario == nil {
+			return
+		}
+		t.handleScenarioKey(ctx, key, *scenario)
+	}
+}
+
+func (t *TUI) handleScenarioKey(ctx context.Context, key string, scenario v2.Scenario) {
+	var (
+		err     error
+		message string
+	)
+	switch key {
+	case "p":
+		paused := !scenario.Paused
+		_, err = t.ctrl.PatchScenario(ctx, scenario.Name, v2.ScenarioPatch{Paused: &paused})
+		message = pausedMessage(paused) + " scenario " + scenario.Name
+	case "s":
+		_, err = t.ctrl.StopScenario(scenario.Name)
+		message = "Stopped scenario " + scenario.Name
+	case "+", "-":
+		var vus int64
+		if scenario.VUs != nil {
+			vus = *scenario.VUs
+		}
+		if key == "+" {
+			vus++
+		} else if vus > 0 {
+			vus--
+		}
+		var updated v2.Scenario
+		updated, err = t.ctrl.PatchScenario(ctx, scenario.Name, v2.ScenarioPatch{VUs: &vus})
+		if err == nil && updated.VUs != nil {
+			vus = *updated.VUs
+		}
+		message = fmt.Sprintf("Scaled scenario %s to %d VUs", scenario.Name, vus)
+	}
+	t.setMessage(err, message)
+}
+
+func pausedMessage(paused bool) string {
+	if paused {
+		return "Paused"
+	}
+	return "Resumed"
+}
+
+func (t *TUI) setMessage(err error, message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		message = t.colors.bad.Sprint(err.Error())
+	}
+	t.message = message
+}
+
+// refresh gets the current status of the test and renders the TUI.
+func (t *TUI) refresh() {
+	status := t.ctrl.Status()
+	width, height := defaultWidth, defaultHeight
+	if t.opts.Size != nil {
+		if w, h, err := t.opts.Size(); err == nil && w > 0 && h > 0 {
+			width, height = w, h
+		}
+	}
+
+	t.mu.Lock()
+	t.status = status
+	if t.urls != nil {
+		t.groups = t.urls.Snapshot(time.Now()).Groups
+	}
+	if n := len(status.Scenarios); t.selected >= n {
+		// a scenario can't disappear, but there could be none yet
+		t.selected = 0
+		if n > 0 {
+			t.selected = n - 1
+		}
+	}
+	frame := t.render(width, height)
+	t.mu.Unlock()
+	_, _ = io.WriteString(t.opts.Out, frame)
+}
+
+// captureLogs shows the entries of the logger in the TUI, instead of writing
+// them to its output. The returned function restores the output of the logger,
+// and writes the captured entries to it.
+func (t *TUI) captureLogs() func() {
+	logger := t.opts.Logger
+	hook := &logHook{tui: t, formatter: &logrus.TextFormatter{DisableColors: true, DisableTimestamp: true}}
+	logger.AddHook(hook)
+	out := logger.Out
+	logger.SetOutput(io.Discard)
+
+	return func() {
+		hook.disable()
+		logger.SetOutput(out)
+		t.mu.Lock()
+		logs := t.logs
+		t.mu.Unlock()
+		for _, line := range logs {
+			_, _ = fmt.Fprintln(out, line)
+		}
+	}
+}
+
+// logHook keeps the last entries of the logger, for the TUI.
+type logHook struct {
+	tui       *TUI
+	formatter logrus.Formatter
+
+	mu       sync.Mutex
+	disabled bool
+}
+
+func (h *logHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *logHook) Fire(entry *logrus.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.disabled || !entry.Logger.IsLevelEnabled(entry.Level) {
+		return nil
+	}
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	t := h.tui
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logs = append(t.logs, strings.TrimRight(string(line), "\n"))
+	if len(t.logs) > maxLogLines {
+		t.logs = t.logs[len(t.logs)-maxLogLines:]
+	}
+	return nil
+}
+
+// disable the hook, since logrus doesn't allow to remove it.
+func (h *logHook) disable() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.disabled = true
+}
@@ -0,0 +1,191 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v2 "go.k6.io/k6/api/v2"
+	"go.k6.io/k6/metrics"
+)
+
+type fakeController struct {
+	status  v2.Status
+	patches map[string][]v2.ScenarioPatch
+	stopped []string
+}
+
+func (c *fakeController) Status() v2.Status {
+	return c.status
+}
+
+func (c *fakeController) PatchStatus(patch v2.StatusPatch) (v2.Status, error) {
+	c.status.Paused = *patch.Paused
+	return c.status, nil
+}
+
+func (c *fakeController) Stop(string) v2.Status {
+	c.status.Stopped = true
+	return c.status
+}
+
+func (c *fakeController) PatchScenario(_ context.Context, name string, patch v2.ScenarioPatch) (v2.Scenario, error) {
+	for i, sc := range c.status.Scenarios {
+		if sc.Name != name {
+			continue
+		}
+		if patch.VUs != nil && sc.VUs == nil {
+			return v2.Scenario{}, errors.New("no live VU updates")
+		}
+		if patch.VUs != nil {
+			c.status.Scenarios[i].VUs = patch.VUs
+		}
+		if patch.Paused != nil {
+			c.status.Scenarios[i].Paused = *patch.Paused
+		}
+		c.patches[name] = append(c.patches[name], patch)
+		return c.status.Scenarios[i], nil
+	}
+	return v2.Scenario{}, v2.ErrScenarioNotFound
+}
+
+func (c *fakeController) StopScenario(name string) (v2.Scenario, error) {
+	c.stopped = append(c.stopped, name)
+	return v2.Scenario{Name: name}, nil
+}
+
+func newTestTUI() (*TUI, *fakeController) {
+	vus, maxVUs := int64(2), int64(10)
+	ctrl := &fakeController{
+		status: v2.Status{
+			Status:  "Running",
+			Running: true,
+			VUs:     3,
+			VUsMax:  11,
+			Scenarios: []v2.Scenario{
+				{Name: "external", Executor: "externally-controlled", Progress: 0.5, VUs: &vus, MaxVUs: &maxVUs},
+				{Name: "constant", Executor: "constant-vus", Progress: 1},
+			},
+			Thresholds: []v2.ThresholdStatus{
+				{Metric: "http_req_duration", Source: "p(95)<100", Breached: true},
+			},
+		},
+		patches: map[string][]v2.ScenarioPatch{},
+	}
+	t := New(ctrl, nil, Options{Out: &strings.Builder{}, NoColor: true})
+	t.refresh()
+	return t, ctrl
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+	tui, _ := newTestTUI()
+	tui.groups = []v2.GroupStats{
+		{Value: "/fast", Stats: v2.Stats{Requests: 1, Duration: &v2.DurationStats{Avg: 10, P95: 10, P99: 10}}},
+		{Value: "/slow", Stats: v2.Stats{Requests: 1, FailedRate: 1, Duration: &v2.DurationStats{Avg: 100, P95: 100}}},
+	}
+
+	frame := tui.render(120, 30)
+	require.True(t, strings.HasPrefix(frame, frameStart))
+	lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(frame, frameStart), frameEnd), lineEnd)
+	assert.Len(t, lines, 30)
+	for _, line := range lines {
+		assert.LessOrEqual(t, len([]rune(line)), 120, line)
+	}
+	assert.Contains(t, lines[0], "Running")
+	assert.Contains(t, lines[0], "VUs 3/11")
+
+	text := strings.Join(lines, "\n")
+	assert.Contains(t, text, "> external            externally-controlled [==========----------]  50% running  2/10")
+	assert.Contains(t, text, "  constant            constant-vus          [====================] 100% done")
+	assert.Contains(t, text, "✗ http_req_duration  p(95)<100")
+	slow := strings.Index(text, "/slow")
+	fast := strings.Index(text, "/fast")
+	require.Positive(t, slow)
+	assert.Less(t, slow, fast, "the slowest URL is the first")
+	assert.Contains(t, text, "100.00%")
+	assert.Contains(t, lines[len(lines)-1], "q stop test")
+}
+
+func TestHandleKeys(t *testing.T) {
+	t.Parallel()
+	tui, ctrl := newTestTUI()
+	ctx := context.Background()
+	press := func(keys string) {
+		for _, key := range splitKeys([]byte(keys)) {
+			tui.handleKey(ctx, string(key))
+			tui.refresh()
+		}
+	}
+
+	press("++-")
+	assert.Equal(t, int64(3), *ctrl.status.Scenarios[0].VUs)
+	assert.Equal(t, "Scaled scenario external to 3 VUs", tui.message)
+
+	press("p")
+	assert.True(t, ctrl.status.Scenarios[0].Paused)
+	press("p")
+	assert.False(t, ctrl.status.Scenarios[0].Paused)
+	assert.Equal(t, "Resumed scenario external", tui.message)
+
+	press("\x1b[B\x1b[B+")
+	assert.Equal(t, 1, tui.selected)
+	assert.Equal(t, "no live VU updates", tui.message)
+	press("s")
+	assert.Equal(t, []string{"constant"}, ctrl.stopped)
+	press("k")
+	assert.Equal(t, 0, tui.selected)
+
+	press(" ")
+	assert.True(t, ctrl.status.Paused)
+	press("o")
+	assert.True(t, tui.byErrors)
+	press("q")
+	assert.True(t, ctrl.status.Stopped)
+}
+
+func TestTopURLs(t *testing.T) {
+	t.Parallel()
+	groups := []v2.GroupStats{
+		{Value: "/none"},
+		{Value: "/fast", Stats: v2.Stats{Duration: &v2.DurationStats{P95: 10}}},
+		{Value: "/failing", Stats: v2.Stats{FailedRate: 0.5, Duration: &v2.DurationStats{P95: 5}}},
+		{Value: "/slow", Stats: v2.Stats{FailedRate: 0.1, Duration: &v2.DurationStats{P95: 100}}},
+	}
+	names := func(groups []v2.GroupStats) []string {
+		var names []string
+		for _, g := range groups {
+			names = append(names, g.Value)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"/slow", "/fast"}, names(topURLs(groups, 2, false)))
+	assert.Equal(t, []string{"/failing", "/slow", "/fast", "/none"}, names(topURLs(groups, 10, true)))
+	assert.Equal(t, "/none", groups[0].Value, "the groups aren't sorted in place")
+}
+
+func TestURLsFromStream(t *testing.T) {
+	t.Parallel()
+	stream := v2.NewSampleStream()
+	tui, _ := newTestTUI()
+	urls, stop := stream.Aggregate(v2.SnapshotFilter{GroupBy: "name"})
+	defer stop()
+	tui.urls = urls
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	stream.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqs, Tags: registry.RootTagSet().With("name", "/a")},
+		Time:       time.Now(),
+		Value:      1,
+	}})
+	tui.refresh()
+	require.Len(t, tui.groups, 1)
+	assert.Equal(t, "/a", tui.groups[0].Value)
+	assert.Equal(t, 1.0, tui.groups[0].Requests)
+}